@@ -0,0 +1,255 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/GT-610/tairitsu/internal/app/config"
+	"github.com/GT-610/tairitsu/internal/app/database"
+	"github.com/GT-610/tairitsu/internal/app/logger"
+	"github.com/GT-610/tairitsu/internal/app/paths"
+)
+
+// errUsage marks a command line that could not be parsed; the usage was already printed
+var errUsage = errors.New("invalid usage")
+
+// command is a subcommand, or a group of them when run is nil
+type command struct {
+	name        string
+	usage       string
+	summary     string
+	run         func(env *commandEnv, args []string) error
+	subcommands []*command
+}
+
+// commands are the subcommands of tairitsu; without one, or with only flags, it serves
+func commands() []*command {
+	return []*command{
+		{name: "serve", usage: "serve [--demo] [--regenerate-secrets] [--reset-admin-password]", summary: "run the HTTP server (default)"},
+		{name: "user", summary: "manage user accounts", subcommands: []*command{
+			{name: "create", usage: "user create --username NAME [--email ADDRESS] [--password PASSWORD] [--role user|admin] [--json]", summary: "create a user with a temporary password", run: runUserCreate},
+			{name: "list", usage: "user list [--json]", summary: "list users", run: runUserList},
+			{name: "reset-password", usage: "user reset-password --username NAME [--json]", summary: "replace a password with a temporary one and sign the user out", run: runUserResetPassword},
+		}},
+		{name: "network", summary: "inspect networks", subcommands: []*command{
+			{name: "list", usage: "network list [--json]", summary: "list networks with their owners", run: runNetworkList},
+		}},
+		{name: "planet", summary: "build custom planet files", subcommands: []*command{
+			{name: "generate", usage: "planet generate --roots FILE [--out FILE] [--signing-key-dir DIR] [--planet-id ID] [--birth-time MS] [--recommend-values] [--skip-validation] [--resolve-family ipv4|ipv6|both] [--json]", summary: "generate a planet from root node definitions", run: runPlanetGenerate},
+		}},
+		{name: "migrate", usage: "migrate [--dry-run] [--json]", summary: "apply pending database migrations", run: runMigrate},
+		{name: "config", summary: "inspect the configuration", subcommands: []*command{
+			{name: "show", usage: "config show [--redact-secrets] [--json]", summary: "print config.json", run: runConfigShow},
+		}},
+	}
+}
+
+// run dispatches a command line and returns the process exit code
+func run(args []string, stdout, stderr io.Writer) int {
+	dataDir, args, err := extractDataDir(args)
+	if err != nil {
+		fmt.Fprintf(stderr, "tairitsu: %v\n", err)
+		printUsage(stderr, commands())
+		return 2
+	}
+	paths.Init(dataDir)
+
+	if len(args) > 0 && (args[0] == "help" || args[0] == "-h" || args[0] == "--help") {
+		printUsage(stdout, commands())
+		return 0
+	}
+	if len(args) == 0 || strings.HasPrefix(args[0], "-") {
+		return runServe(args, stdout, stderr)
+	}
+	if args[0] == "serve" {
+		return runServe(args[1:], stdout, stderr)
+	}
+
+	cmd, rest := findCommand(commands(), args)
+	if cmd == nil || cmd.run == nil {
+		printUsage(stderr, commands())
+		return 2
+	}
+
+	env := &commandEnv{stdout: stdout, stderr: stderr}
+	defer env.close()
+	if err := cmd.run(env, rest); err != nil {
+		if errors.Is(err, errUsage) {
+			return 2
+		}
+		fmt.Fprintf(stderr, "tairitsu %s: %v\n", strings.Join(args[:len(args)-len(rest)], " "), err)
+		return 1
+	}
+	return 0
+}
+
+// extractDataDir removes --data-dir from args, wherever it appears, and returns its value
+func extractDataDir(args []string) (string, []string, error) {
+	var dataDir string
+	rest := make([]string, 0, len(args))
+	for i := 0; i < len(args); i++ {
+		name, value, hasValue := strings.Cut(args[i], "=")
+		if name != "--data-dir" && name != "-data-dir" {
+			rest = append(rest, args[i])
+			continue
+		}
+		if !hasValue {
+			if i+1 == len(args) {
+				return "", nil, fmt.Errorf("--data-dir needs a directory")
+			}
+			i++
+			value = args[i]
+		}
+		dataDir = value
+	}
+	return dataDir, rest, nil
+}
+
+// findCommand walks args down the command tree and returns the command with its own arguments
+func findCommand(available []*command, args []string) (*command, []string) {
+	for _, cmd := range available {
+		if len(args) == 0 || cmd.name != args[0] {
+			continue
+		}
+		if len(cmd.subcommands) == 0 {
+			return cmd, args[1:]
+		}
+		return findCommand(cmd.subcommands, args[1:])
+	}
+	return nil, nil
+}
+
+func printUsage(w io.Writer, available []*command) {
+	fmt.Fprintln(w, "Usage: tairitsu [--data-dir DIR] [command]")
+	fmt.Fprintln(w, "\nCommands:")
+	table := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	for _, cmd := range available {
+		if len(cmd.subcommands) == 0 {
+			fmt.Fprintf(table, "  %s\t%s\n", cmd.usage, cmd.summary)
+			continue
+		}
+		for _, sub := range cmd.subcommands {
+			fmt.Fprintf(table, "  %s\t%s\n", sub.usage, sub.summary)
+		}
+	}
+	_ = table.Flush()
+	fmt.Fprintln(w, "\nThe data directory holding config.json is ./data unless --data-dir or "+paths.DataDirEnv+" names another.")
+	fmt.Fprintln(w, "Administration commands work on its configuration and database without starting the server.")
+}
+
+// newFlagSet creates a command's flag set, which prints the usage to stderr on errors
+func (e *commandEnv) newFlagSet(usage string) *flag.FlagSet {
+	flags := flag.NewFlagSet(usage, flag.ContinueOnError)
+	flags.SetOutput(e.stderr)
+	flags.Usage = func() {
+		fmt.Fprintf(e.stderr, "Usage: tairitsu %s\n", usage)
+		flags.PrintDefaults()
+	}
+	return flags
+}
+
+// parseFlags parses args, which must not leave positional arguments behind
+func parseFlags(flags *flag.FlagSet, args []string) error {
+	if err := flags.Parse(args); err != nil {
+		return errUsage
+	}
+	if flags.NArg() > 0 {
+		fmt.Fprintf(flags.Output(), "unexpected argument %q\n", flags.Arg(0))
+		flags.Usage()
+		return errUsage
+	}
+	return nil
+}
+
+// commandEnv is what administration commands work on: the configuration in the data
+// directory and, once a command asks for it, the configured database
+type commandEnv struct {
+	stdout io.Writer
+	stderr io.Writer
+
+	cfg *config.Config
+	db  database.DBInterface
+}
+
+// config loads config.json from the data directory the way the server does
+func (e *commandEnv) config() (*config.Config, error) {
+	if e.cfg != nil {
+		return e.cfg, nil
+	}
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load configuration: %w", err)
+	}
+	// Log to the configured file only, so stdout carries nothing but the command's output
+	options := config.LoggerOptionsFrom(cfg)
+	options.Console = false
+	logger.Init(options)
+	e.cfg = cfg
+	return cfg, nil
+}
+
+// database opens the configured database, migrating it like the server does
+func (e *commandEnv) database() (database.DBInterface, error) {
+	if e.db != nil {
+		return e.db, nil
+	}
+	db, err := e.openDatabase()
+	if err != nil {
+		return nil, err
+	}
+	if err := db.Init(); err != nil {
+		return nil, fmt.Errorf("failed to initialize database: %w", err)
+	}
+	return db, nil
+}
+
+// openDatabase opens the configured database without migrating it
+func (e *commandEnv) openDatabase() (database.DBInterface, error) {
+	if e.db != nil {
+		return e.db, nil
+	}
+	cfg, err := e.config()
+	if err != nil {
+		return nil, err
+	}
+	dbConfig := database.LoadConfigFromApp(cfg)
+	if dbConfig.Type == "" {
+		return nil, fmt.Errorf("no database is configured; finish the setup wizard first")
+	}
+	dbConfig.Quiet = true
+
+	db, err := database.NewDatabase(dbConfig)
+	if err != nil {
+		return nil, err
+	}
+	e.db = db
+	return db, nil
+}
+
+func (e *commandEnv) close() {
+	if e.db != nil {
+		_ = e.db.Close()
+	}
+}
+
+// printJSON writes value as indented JSON
+func (e *commandEnv) printJSON(value any) error {
+	encoder := json.NewEncoder(e.stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(value)
+}
+
+// printTable writes rows under header as aligned columns
+func (e *commandEnv) printTable(header []string, rows [][]string) error {
+	table := tabwriter.NewWriter(e.stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(table, strings.Join(header, "\t"))
+	for _, row := range rows {
+		fmt.Fprintln(table, strings.Join(row, "\t"))
+	}
+	return table.Flush()
+}
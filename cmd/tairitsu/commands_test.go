@@ -0,0 +1,246 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/GT-610/tairitsu/internal/app/config"
+	"github.com/GT-610/tairitsu/internal/app/database"
+	"github.com/GT-610/tairitsu/internal/app/models"
+	"github.com/GT-610/tairitsu/internal/app/paths"
+	"github.com/GT-610/tairitsu/internal/mkworld"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testJWTSecret      = "cli-test-secret-0123456789abcdef0123456789abcdef"
+	testIdentityPublic = "f76fd3000b:0:542c89e34a369c2281ed940d05beeffdbaa66930f17b875e9172e43d0ba30b6a39708507f4d64e66cde4a1040d2a995d01209d685ca6c4adb4a5c880af1e9715"
+)
+
+// newDataDir points TAIRITSU_DATA_DIR at a temporary directory holding an initialized config.json
+func newDataDir(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	t.Cleanup(func() { paths.Init("") })
+	t.Setenv(paths.DataDirEnv, dir)
+	writeConfig(t, dir)
+	return dir
+}
+
+func writeConfig(t *testing.T, dir string) {
+	t.Helper()
+
+	cfg := map[string]any{
+		"initialized": true,
+		"server":      map[string]any{"port": 8080},
+		"database":    map[string]any{"type": "sqlite", "path": "data/tairitsu.db"},
+		"zerotier":    map[string]any{"url": "http://localhost:9993", "token": "controller-token"},
+		"security":    map[string]any{"jwt_secret": testJWTSecret},
+		"oidc":        map[string]any{"client_secret": "oidc-secret"},
+		"logging":     map[string]any{"file_path": "logs/tairitsu.log"},
+	}
+	data, err := json.Marshal(cfg)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "config.json"), data, 0600))
+}
+
+// runCommand runs a command line and returns its exit code, stdout and stderr
+func runCommand(t *testing.T, args ...string) (int, string, string) {
+	t.Helper()
+	var stdout, stderr bytes.Buffer
+	code := run(args, &stdout, &stderr)
+	return code, stdout.String(), stderr.String()
+}
+
+func TestUserCommands(t *testing.T) {
+	newDataDir(t)
+
+	code, stdout, stderr := runCommand(t, "user", "create", "--username", "alice", "--email", "alice@example.com", "--role", "admin", "--json")
+	require.Equal(t, 0, code, stderr)
+	var created userWithPassword
+	require.NoError(t, json.Unmarshal([]byte(stdout), &created))
+	assert.Equal(t, "alice", created.User.Username)
+	assert.Equal(t, "admin", created.User.Role)
+	assert.NotEmpty(t, created.TemporaryPassword)
+	assert.Nil(t, created.RevokedSessions)
+
+	code, stdout, stderr = runCommand(t, "user", "create", "--username", "bob")
+	require.Equal(t, 0, code, stderr)
+	assert.Contains(t, stdout, `Created user "bob"`)
+	assert.Contains(t, stdout, "Temporary password: ")
+
+	code, _, stderr = runCommand(t, "user", "create", "--username", "carol", "--role", "owner")
+	assert.Equal(t, 1, code)
+	assert.Contains(t, stderr, "role must be user or admin")
+
+	code, stdout, stderr = runCommand(t, "user", "list")
+	require.Equal(t, 0, code, stderr)
+	lines := strings.Split(strings.TrimSpace(stdout), "\n")
+	require.Len(t, lines, 3)
+	assert.Regexp(t, `^ID\s+USERNAME\s+ROLE\s+EMAIL\s+CREATED$`, lines[0])
+	assert.Contains(t, stdout, "alice@example.com")
+
+	code, stdout, stderr = runCommand(t, "user", "reset-password", "--username", "alice", "--json")
+	require.Equal(t, 0, code, stderr)
+	var reset userWithPassword
+	require.NoError(t, json.Unmarshal([]byte(stdout), &reset))
+	assert.Equal(t, created.User.ID, reset.User.ID)
+	assert.NotEqual(t, created.TemporaryPassword, reset.TemporaryPassword)
+	require.NotNil(t, reset.RevokedSessions)
+
+	code, _, stderr = runCommand(t, "user", "reset-password", "--username", "nobody")
+	assert.Equal(t, 1, code)
+	assert.Contains(t, stderr, `user "nobody" does not exist`)
+}
+
+func TestNetworkList(t *testing.T) {
+	dir := newDataDir(t)
+	code, _, stderr := runCommand(t, "user", "create", "--username", "owner")
+	require.Equal(t, 0, code, stderr)
+	assert.FileExists(t, filepath.Join(dir, "tairitsu.db"), "data/tairitsu.db names the data directory")
+
+	cfg, err := config.LoadConfig()
+	require.NoError(t, err)
+	db, err := database.NewDatabase(database.LoadConfigFromApp(cfg))
+	require.NoError(t, err)
+	require.NoError(t, db.Init())
+	owner, err := db.GetUserByUsername("owner")
+	require.NoError(t, err)
+	require.NoError(t, db.CreateNetwork(&models.Network{ID: "8056c2e21c000001", Name: "office", OwnerID: owner.ID}))
+	require.NoError(t, db.Close())
+
+	code, stdout, stderr := runCommand(t, "network", "list")
+	require.Equal(t, 0, code, stderr)
+	assert.Regexp(t, `8056c2e21c000001\s+office\s+owner\s+default`, stdout)
+
+	code, stdout, stderr = runCommand(t, "network", "list", "--json")
+	require.Equal(t, 0, code, stderr)
+	var listings []networkListing
+	require.NoError(t, json.Unmarshal([]byte(stdout), &listings))
+	require.Len(t, listings, 1)
+	assert.Equal(t, owner.ID, listings[0].OwnerID)
+	assert.Equal(t, "owner", listings[0].Owner)
+}
+
+func TestMigrate(t *testing.T) {
+	newDataDir(t)
+
+	code, stdout, stderr := runCommand(t, "migrate", "--dry-run", "--json")
+	require.Equal(t, 0, code, stderr)
+	var planned migrateResult
+	require.NoError(t, json.Unmarshal([]byte(stdout), &planned))
+	require.NotEmpty(t, planned.Pending)
+	assert.Equal(t, 1, planned.Pending[0].Version)
+	assert.Empty(t, planned.Applied)
+
+	// The dry run left the database alone
+	code, stdout, stderr = runCommand(t, "migrate", "--dry-run")
+	require.Equal(t, 0, code, stderr)
+	assert.Contains(t, stdout, "Would apply migration 1: create tables")
+
+	code, stdout, stderr = runCommand(t, "migrate")
+	require.Equal(t, 0, code, stderr)
+	assert.Contains(t, stdout, "Applied migration 1: create tables")
+	assert.Equal(t, len(planned.Pending), strings.Count(stdout, "Applied migration"))
+
+	code, stdout, stderr = runCommand(t, "migrate", "--dry-run")
+	require.Equal(t, 0, code, stderr)
+	assert.Equal(t, "The database schema is up to date.\n", stdout)
+}
+
+func TestConfigShowRedactsSecrets(t *testing.T) {
+	newDataDir(t)
+
+	code, stdout, stderr := runCommand(t, "config", "show", "--redact-secrets")
+	require.Equal(t, 0, code, stderr)
+	assert.Contains(t, stdout, `security.jwt_secret = "[redacted]"`)
+	assert.Contains(t, stdout, `oidc.client_secret = "[redacted]"`)
+	assert.Contains(t, stdout, `database.type = "sqlite"`)
+	assert.NotContains(t, stdout, testJWTSecret)
+	assert.NotContains(t, stdout, "oidc-secret")
+
+	code, stdout, stderr = runCommand(t, "config", "show", "--redact-secrets", "--json")
+	require.Equal(t, 0, code, stderr)
+	var shown config.Config
+	require.NoError(t, json.Unmarshal([]byte(stdout), &shown))
+	assert.Equal(t, redacted, shown.Security.JWTSecret)
+	assert.Equal(t, redacted, shown.ZeroTier.Token)
+	assert.Empty(t, shown.Database.Pass, "unset secrets stay empty")
+
+	code, stdout, stderr = runCommand(t, "config", "show")
+	require.Equal(t, 0, code, stderr)
+	assert.Contains(t, stdout, testJWTSecret)
+}
+
+func TestPlanetGenerate(t *testing.T) {
+	dir := newDataDir(t)
+	roots, err := json.Marshal([]mkworld.RootNodeConfig{{IdentityPublic: testIdentityPublic, Endpoints: []string{"203.0.113.1/9993"}}})
+	require.NoError(t, err)
+	rootsPath := filepath.Join(dir, "roots.json")
+	require.NoError(t, os.WriteFile(rootsPath, roots, 0644))
+	outPath := filepath.Join(dir, "planet")
+
+	code, stdout, stderr := runCommand(t, "planet", "generate", "--roots", rootsPath, "--out", outPath, "--planet-id", "4242424242", "--birth-time", "1700000000000", "--json")
+	require.Equal(t, 0, code, stderr)
+	var generated generatedPlanetOutput
+	require.NoError(t, json.Unmarshal([]byte(stdout), &generated))
+	assert.Equal(t, uint64(4242424242), generated.PlanetID)
+	assert.Equal(t, 1, generated.RootNodeCount)
+	assert.NotZero(t, generated.HistoryID, "the planet is kept in the history")
+
+	data, err := os.ReadFile(outPath)
+	require.NoError(t, err)
+	world, err := mkworld.InspectWorld(data)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(4242424242), world.ID)
+	assert.Equal(t, int64(1700000000000), world.Timestamp)
+
+	// Without --out the planet goes to the data directory
+	code, stdout, stderr = runCommand(t, "planet", "generate", "--roots", rootsPath, "--recommend-values", "--json")
+	require.Equal(t, 0, code, stderr)
+	require.NoError(t, json.Unmarshal([]byte(stdout), &generated))
+	assert.Equal(t, filepath.Join(dir, "planets", fmt.Sprintf("planet-%d", generated.PlanetID)), generated.Path)
+	assert.FileExists(t, generated.Path)
+
+	code, _, _ = runCommand(t, "planet", "generate", "--out", outPath)
+	assert.Equal(t, 2, code, "--roots is required")
+}
+
+func TestDataDirFlag(t *testing.T) {
+	dir := t.TempDir()
+	writeConfig(t, dir)
+	t.Cleanup(func() { paths.Init("") })
+	t.Setenv(paths.DataDirEnv, filepath.Join(t.TempDir(), "ignored"))
+
+	code, stdout, stderr := runCommand(t, "config", "show", "--data-dir", dir, "--json")
+	require.Equal(t, 0, code, stderr)
+	assert.Equal(t, dir, paths.DataDir(), "the flag wins over the environment")
+	var shown config.Config
+	require.NoError(t, json.Unmarshal([]byte(stdout), &shown))
+	assert.Equal(t, testJWTSecret, shown.Security.JWTSecret)
+
+	code, _, stderr = runCommand(t, "--data-dir")
+	assert.Equal(t, 2, code)
+	assert.Contains(t, stderr, "--data-dir needs a directory")
+}
+
+func TestUnknownCommandPrintsUsage(t *testing.T) {
+	code, stdout, stderr := runCommand(t, "frobnicate")
+	assert.Equal(t, 2, code)
+	assert.Empty(t, stdout)
+	assert.Contains(t, stderr, "Usage: tairitsu [--data-dir DIR] [command]")
+
+	code, stdout, _ = runCommand(t, "help")
+	assert.Equal(t, 0, code)
+	assert.Contains(t, stdout, "user reset-password")
+
+	code, _, stderr = runCommand(t, "user", "list", "extra")
+	assert.Equal(t, 2, code)
+	assert.Contains(t, stderr, `unexpected argument "extra"`)
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+
+	"github.com/GT-610/tairitsu/internal/app/config"
+)
+
+// redacted replaces secrets in the output of config show --redact-secrets
+const redacted = "[redacted]"
+
+func runConfigShow(env *commandEnv, args []string) error {
+	flags := env.newFlagSet("config show [--redact-secrets] [--json]")
+	redactSecrets := flags.Bool("redact-secrets", false, "hide the JWT secrets, controller token, database password and OIDC client secret")
+	asJSON := flags.Bool("json", false, "print JSON instead of key = value lines")
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+
+	cfg, err := env.config()
+	if err != nil {
+		return err
+	}
+	shown := *cfg
+	if *redactSecrets {
+		redactConfig(&shown)
+	}
+
+	if *asJSON {
+		return env.printJSON(&shown)
+	}
+	// Round-trip through JSON so the keys are the ones written in config.json
+	data, err := json.Marshal(&shown)
+	if err != nil {
+		return err
+	}
+	var tree any
+	if err := json.Unmarshal(data, &tree); err != nil {
+		return err
+	}
+	lines := map[string]string{}
+	flattenConfig("", tree, lines)
+	keys := make([]string, 0, len(lines))
+	for key := range lines {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		fmt.Fprintf(env.stdout, "%s = %s\n", key, lines[key])
+	}
+	return nil
+}
+
+// redactConfig hides the secrets of cfg, which must be a copy: the slices it replaces are
+// shared with the original
+func redactConfig(cfg *config.Config) {
+	redact := func(value *string) {
+		if *value != "" {
+			*value = redacted
+		}
+	}
+	redact(&cfg.Security.JWTSecret)
+	redact(&cfg.ZeroTier.Token)
+	redact(&cfg.Database.Pass)
+	redact(&cfg.OIDC.ClientSecret)
+
+	previous := make([]config.RetiredJWTSecret, len(cfg.Security.PreviousJWTSecrets))
+	copy(previous, cfg.Security.PreviousJWTSecrets)
+	for i := range previous {
+		redact(&previous[i].Secret)
+	}
+	cfg.Security.PreviousJWTSecrets = previous
+}
+
+// flattenConfig writes the leaves of a decoded JSON value as dotted keys, indexing arrays
+func flattenConfig(prefix string, value any, lines map[string]string) {
+	switch value := value.(type) {
+	case map[string]any:
+		for key, child := range value {
+			if prefix != "" {
+				key = prefix + "." + key
+			}
+			flattenConfig(key, child, lines)
+		}
+	case []any:
+		if len(value) == 0 {
+			lines[prefix] = "[]"
+		}
+		for i, child := range value {
+			flattenConfig(prefix+"."+strconv.Itoa(i), child, lines)
+		}
+	case string:
+		lines[prefix] = strconv.Quote(value)
+	default:
+		encoded, _ := json.Marshal(value)
+		lines[prefix] = string(encoded)
+	}
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/GT-610/tairitsu/internal/app/database"
+)
+
+// migrateResult is the output of migrate; Pending is only set with --dry-run
+type migrateResult struct {
+	DryRun  bool                     `json:"dryRun"`
+	Applied []database.MigrationInfo `json:"applied"`
+	Pending []database.MigrationInfo `json:"pending"`
+}
+
+func runMigrate(env *commandEnv, args []string) error {
+	flags := env.newFlagSet("migrate [--dry-run] [--json]")
+	dryRun := flags.Bool("dry-run", false, "list the pending migrations without applying them")
+	asJSON := flags.Bool("json", false, "print JSON instead of text")
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+
+	db, err := env.openDatabase()
+	if err != nil {
+		return err
+	}
+	migrator, ok := database.AsMigrator(db)
+	if !ok {
+		return fmt.Errorf("the configured database does not support migrations")
+	}
+
+	result := migrateResult{DryRun: *dryRun, Applied: []database.MigrationInfo{}, Pending: []database.MigrationInfo{}}
+	if *dryRun {
+		all, err := migrator.Migrations()
+		if err != nil {
+			return err
+		}
+		for _, info := range all {
+			if info.AppliedAt == nil {
+				result.Pending = append(result.Pending, info)
+			}
+		}
+	} else {
+		applied, err := migrator.MigrateUp()
+		result.Applied = append(result.Applied, applied...)
+		if err != nil {
+			// Report the steps that were applied before the failure
+			for _, info := range result.Applied {
+				fmt.Fprintf(env.stderr, "Applied migration %d: %s\n", info.Version, info.Name)
+			}
+			return err
+		}
+	}
+	if *asJSON {
+		return env.printJSON(result)
+	}
+
+	switch {
+	case *dryRun && len(result.Pending) == 0, !*dryRun && len(result.Applied) == 0:
+		fmt.Fprintln(env.stdout, "The database schema is up to date.")
+	case *dryRun:
+		for _, info := range result.Pending {
+			fmt.Fprintf(env.stdout, "Would apply migration %d: %s\n", info.Version, info.Name)
+		}
+	default:
+		for _, info := range result.Applied {
+			fmt.Fprintf(env.stdout, "Applied migration %d: %s\n", info.Version, info.Name)
+		}
+	}
+	return nil
+}
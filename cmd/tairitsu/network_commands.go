@@ -0,0 +1,71 @@
+package main
+
+import (
+	"fmt"
+	"time"
+)
+
+// networkListing is one network in the output of network list
+type networkListing struct {
+	ID             string    `json:"id"`
+	Name           string    `json:"name"`
+	OwnerID        string    `json:"ownerId"`
+	Owner          string    `json:"owner"`
+	OrganizationID string    `json:"organizationId"`
+	CreatedAt      time.Time `json:"createdAt"`
+}
+
+func runNetworkList(env *commandEnv, args []string) error {
+	flags := env.newFlagSet("network list [--json]")
+	asJSON := flags.Bool("json", false, "print JSON instead of a table")
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+
+	db, err := env.database()
+	if err != nil {
+		return err
+	}
+	networks, err := db.GetAllNetworks()
+	if err != nil {
+		return fmt.Errorf("failed to read networks: %w", err)
+	}
+	ownerIDs := make([]string, 0, len(networks))
+	for _, network := range networks {
+		ownerIDs = append(ownerIDs, network.OwnerID)
+	}
+	owners, err := db.GetUsersByIDs(ownerIDs)
+	if err != nil {
+		return fmt.Errorf("failed to read network owners: %w", err)
+	}
+	usernames := make(map[string]string, len(owners))
+	for _, owner := range owners {
+		usernames[owner.ID] = owner.Username
+	}
+
+	listings := make([]networkListing, 0, len(networks))
+	for _, network := range networks {
+		listings = append(listings, networkListing{
+			ID:             network.ID,
+			Name:           network.Name,
+			OwnerID:        network.OwnerID,
+			Owner:          usernames[network.OwnerID],
+			OrganizationID: network.OrganizationID,
+			CreatedAt:      network.CreatedAt,
+		})
+	}
+	if *asJSON {
+		return env.printJSON(listings)
+	}
+
+	rows := make([][]string, 0, len(listings))
+	for _, listing := range listings {
+		owner := listing.Owner
+		if owner == "" {
+			// The owner account was deleted
+			owner = listing.OwnerID
+		}
+		rows = append(rows, []string{listing.ID, listing.Name, owner, listing.OrganizationID, listing.CreatedAt.Format(time.RFC3339)})
+	}
+	return env.printTable([]string{"ID", "NAME", "OWNER", "ORGANIZATION", "CREATED"}, rows)
+}
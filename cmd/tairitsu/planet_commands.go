@@ -0,0 +1,138 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/GT-610/tairitsu/internal/app/config"
+	"github.com/GT-610/tairitsu/internal/app/database"
+	"github.com/GT-610/tairitsu/internal/app/paths"
+	"github.com/GT-610/tairitsu/internal/app/services"
+	"github.com/GT-610/tairitsu/internal/mkworld"
+)
+
+// generatedPlanetOutput is the JSON output of planet generate
+type generatedPlanetOutput struct {
+	Path                  string `json:"path"`
+	HistoryID             uint64 `json:"historyId,omitempty"`
+	PlanetID              uint64 `json:"planetId"`
+	BirthTime             int64  `json:"birthTime"`
+	RootNodeCount         int    `json:"rootNodeCount"`
+	EndpointCount         int    `json:"endpointCount"`
+	UsedRecommendedValues bool   `json:"usedRecommendedValues"`
+	SigningKeys           string `json:"signingKeys"`
+}
+
+func runPlanetGenerate(env *commandEnv, args []string) error {
+	flags := env.newFlagSet("planet generate --roots FILE [--out FILE] [--signing-key-dir DIR] [--planet-id ID] [--birth-time MS] [--recommend-values] [--skip-validation] [--resolve-family ipv4|ipv6|both] [--json]")
+	rootsPath := flags.String("roots", "", "JSON file with an array of {identityPublic, comments, endpoints} root nodes")
+	outPath := flags.String("out", "", "where the planet file is written; defaults to planet-<id> in the planets directory of the data directory")
+	signingKeyDir := flags.String("signing-key-dir", "", "directory with previous.c25519 and current.c25519, created when missing; throwaway keys when empty")
+	planetID := flags.Uint64("planet-id", 0, "planet ID; required without --recommend-values")
+	birthTime := flags.Int64("birth-time", 0, "birth time in Unix milliseconds; required without --recommend-values")
+	recommendValues := flags.Bool("recommend-values", false, "use a random planet ID and the current time as birth time")
+	skipValidation := flags.Bool("skip-validation", false, "accept identities whose public key does not derive their address")
+	resolveFamily := flags.String("resolve-family", "", "addresses of hostname endpoints to use: ipv4, ipv6 or both")
+	asJSON := flags.Bool("json", false, "print JSON instead of text")
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+	if strings.TrimSpace(*rootsPath) == "" {
+		flags.Usage()
+		return errUsage
+	}
+
+	rootNodes, err := readRootNodes(*rootsPath)
+	if err != nil {
+		return err
+	}
+	family, err := mkworld.ParseResolveFamily(*resolveFamily)
+	if err != nil {
+		return err
+	}
+	planet, err := mkworld.GeneratePlanet(&mkworld.GenerateOptions{
+		RootNodes:       rootNodes,
+		SigningKeyPath:  strings.TrimSpace(*signingKeyDir),
+		PlanetID:        *planetID,
+		BirthTime:       *birthTime,
+		RecommendValues: *recommendValues,
+		Hostnames:       &mkworld.HostnameResolution{Family: family},
+		SkipValidation:  *skipValidation,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to generate planet: %w", err)
+	}
+	if *outPath == "" {
+		*outPath = filepath.Join(paths.PlanetDir(), fmt.Sprintf("planet-%d", planet.PlanetID))
+		if err := os.MkdirAll(paths.PlanetDir(), 0755); err != nil {
+			return fmt.Errorf("failed to create planet directory: %w", err)
+		}
+	}
+	if err := os.WriteFile(*outPath, planet.PlanetData, 0644); err != nil {
+		return fmt.Errorf("failed to write planet: %w", err)
+	}
+
+	historyID, err := recordPlanet(env, planet, rootNodes)
+	if err != nil {
+		// The planet file is written either way, so only the history entry is lost
+		fmt.Fprintf(env.stderr, "warning: failed to record the planet in the history: %v\n", err)
+	}
+
+	if *asJSON {
+		return env.printJSON(generatedPlanetOutput{
+			Path:                  *outPath,
+			HistoryID:             historyID,
+			PlanetID:              planet.PlanetID,
+			BirthTime:             planet.BirthTime,
+			RootNodeCount:         planet.RootNodeCount,
+			EndpointCount:         planet.EndpointCount,
+			UsedRecommendedValues: planet.UsedRecommendedValues,
+			SigningKeys:           string(planet.SigningKeys),
+		})
+	}
+	fmt.Fprintf(env.stdout, "Wrote planet %d to %s\n", planet.PlanetID, *outPath)
+	fmt.Fprintf(env.stdout, "Birth time %d, %d root(s), %d endpoint(s), %s signing keys\n",
+		planet.BirthTime, planet.RootNodeCount, planet.EndpointCount, planet.SigningKeys)
+	return nil
+}
+
+// readRootNodes reads the root node definitions of planet generate
+func readRootNodes(path string) ([]mkworld.RootNodeConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read root nodes: %w", err)
+	}
+	var rootNodes []mkworld.RootNodeConfig
+	if err := json.Unmarshal(data, &rootNodes); err != nil {
+		return nil, fmt.Errorf("failed to parse root nodes: %w", err)
+	}
+	for i := range rootNodes {
+		rootNodes[i].IdentityPublic = strings.TrimSpace(rootNodes[i].IdentityPublic)
+		rootNodes[i].Comments = strings.TrimSpace(rootNodes[i].Comments)
+	}
+	return rootNodes, nil
+}
+
+// recordPlanet keeps the planet in the history shown by the web UI; before the setup wizard
+// configured a database there is no history to keep it in
+func recordPlanet(env *commandEnv, planet *mkworld.GeneratedPlanet, rootNodes []mkworld.RootNodeConfig) (uint64, error) {
+	cfg, err := env.config()
+	if err != nil {
+		return 0, err
+	}
+	if database.LoadConfigFromApp(cfg).Type == "" {
+		return 0, nil
+	}
+	db, err := env.database()
+	if err != nil {
+		return 0, err
+	}
+	generation, err := services.NewPlanetHistoryService(db, config.PlanetHistoryLimitFrom(cfg)).Record(planet, rootNodes, "")
+	if err != nil {
+		return 0, err
+	}
+	return generation.ID, nil
+}
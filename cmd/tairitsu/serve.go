@@ -0,0 +1,114 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/GT-610/tairitsu/internal/app/bootstrap"
+	"github.com/GT-610/tairitsu/internal/app/logger"
+	"go.uber.org/zap"
+)
+
+// runServe runs the HTTP server until it is signalled to stop
+func runServe(args []string, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("serve", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	demo := flags.Bool("demo", os.Getenv("TAIRITSU_DEMO") == "1", "run with seeded demo data; nothing is persisted")
+	regenerateSecrets := flags.Bool("regenerate-secrets", false, "replace a missing or weak JWT secret, signing out all sessions")
+	resetAdminPassword := flags.Bool("reset-admin-password", false, "print a one-time password reset token for the administrator and exit")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	fmt.Fprintln(stdout, "Tairitsu - ZeroTier Controller Interface")
+
+	build := func() (*bootstrap.App, error) {
+		return bootstrap.BuildWithOptions(bootstrap.Options{RegenerateSecrets: *regenerateSecrets})
+	}
+	if *demo {
+		build = bootstrap.BuildDemo
+	}
+
+	app, err := build()
+	if err != nil {
+		logger.Fatal("application initialization failed", zap.Error(err))
+	}
+
+	if *resetAdminPassword {
+		return printAdminResetToken(app, stdout, stderr)
+	}
+
+	if app.DemoCredentials != nil {
+		fmt.Fprintln(stdout, "Demo mode: all data is discarded on shutdown")
+		fmt.Fprintf(stdout, "Demo administrator: %s / %s\n", app.DemoCredentials.Username, app.DemoCredentials.Password)
+	}
+
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGTERM, syscall.SIGINT)
+
+	// SIGHUP reloads the TLS certificate files, so a renewed certificate is served without a restart
+	reload := make(chan os.Signal, 1)
+	signal.Notify(reload, syscall.SIGHUP)
+	go func() {
+		for range reload {
+			if err := app.ReloadTLSCertificate(); err != nil {
+				logger.Warn("TLS certificate reload on SIGHUP failed", zap.Error(err))
+			}
+		}
+	}()
+
+	listenErr := make(chan error, 1)
+	go func() {
+		listenErr <- app.Listen()
+	}()
+
+	exitCode := 0
+	select {
+	case sig := <-quit:
+		logger.Info("received shutdown signal", zap.String("signal", sig.String()))
+	case err := <-listenErr:
+		if err != nil {
+			logger.Error("server listen failed", zap.Error(err))
+			exitCode = 1
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), app.ShutdownGracePeriod())
+	defer cancel()
+	if err := app.Shutdown(ctx); err != nil {
+		if errors.Is(err, bootstrap.ErrShutdownTimeout) {
+			fmt.Fprintln(stderr, "shutdown grace period exceeded; in-flight requests were dropped")
+		}
+		exitCode = 1
+	}
+	return exitCode
+}
+
+// printAdminResetToken writes an administrator password reset token to stdout for delivery
+// outside the application, then releases the application's resources
+func printAdminResetToken(app *bootstrap.App, stdout, stderr io.Writer) int {
+	exitCode := 0
+	ticket, err := app.Dependencies.Services.User.IssueAdminPasswordResetToken()
+	if err != nil {
+		fmt.Fprintf(stderr, "failed to issue administrator password reset token: %v\n", err)
+		exitCode = 1
+	} else {
+		fmt.Fprintf(stdout, "Password reset token for administrator %q (valid until %s):\n", ticket.User.Username, ticket.ExpiresAt.Format(time.RFC3339))
+		fmt.Fprintln(stdout, ticket.Token)
+		fmt.Fprintln(stdout, "Set a new password by sending it to POST /api/auth/reset-confirm; it can be used once.")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), app.ShutdownGracePeriod())
+	defer cancel()
+	if err := app.Shutdown(ctx); err != nil {
+		exitCode = 1
+	}
+	return exitCode
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/GT-610/tairitsu/internal/app/config"
+	"github.com/GT-610/tairitsu/internal/app/models"
+	"github.com/GT-610/tairitsu/internal/app/services"
+)
+
+// userService returns the user service bound to the configured database
+func (e *commandEnv) userService() (*services.UserService, error) {
+	cfg, err := e.config()
+	if err != nil {
+		return nil, err
+	}
+	db, err := e.database()
+	if err != nil {
+		return nil, err
+	}
+	userService := services.NewUserService(db)
+	userService.SetPasswordPolicy(services.PasswordPolicyFromConfig(cfg))
+	userService.SetPasswordHashCost(config.PasswordHashCostFrom(cfg))
+	return userService, nil
+}
+
+// findUser looks a user up by username
+func (e *commandEnv) findUser(username string) (*models.User, error) {
+	db, err := e.database()
+	if err != nil {
+		return nil, err
+	}
+	user, err := db.GetUserByUsername(strings.TrimSpace(username))
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up user: %w", err)
+	}
+	if user == nil {
+		return nil, fmt.Errorf("user %q does not exist", username)
+	}
+	return user, nil
+}
+
+// userWithPassword is the JSON output of commands that issue a temporary password
+type userWithPassword struct {
+	User              models.UserResponse `json:"user"`
+	TemporaryPassword string              `json:"temporaryPassword"`
+	RevokedSessions   *int                `json:"revokedSessions,omitempty"`
+}
+
+func runUserCreate(env *commandEnv, args []string) error {
+	flags := env.newFlagSet("user create --username NAME [--email ADDRESS] [--password PASSWORD] [--role user|admin] [--json]")
+	username := flags.String("username", "", "username of the new account")
+	email := flags.String("email", "", "optional email address")
+	password := flags.String("password", "", "initial password; generated when empty. The user must change it after signing in")
+	role := flags.String("role", "user", "user or admin")
+	asJSON := flags.Bool("json", false, "print JSON instead of text")
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+	if *role != "user" && *role != "admin" {
+		return fmt.Errorf("role must be user or admin")
+	}
+
+	userService, err := env.userService()
+	if err != nil {
+		return err
+	}
+	user, temporaryPassword, err := userService.CreateUserWithTemporaryPassword(&models.CreateUserRequest{
+		Username: *username,
+		Password: *password,
+		Email:    *email,
+	}, *role)
+	if err != nil {
+		return err
+	}
+
+	if *asJSON {
+		return env.printJSON(userWithPassword{User: user.ToResponse(), TemporaryPassword: temporaryPassword})
+	}
+	fmt.Fprintf(env.stdout, "Created %s %q (%s)\n", user.Role, user.Username, user.ID)
+	fmt.Fprintf(env.stdout, "Temporary password: %s\n", temporaryPassword)
+	return nil
+}
+
+func runUserList(env *commandEnv, args []string) error {
+	flags := env.newFlagSet("user list [--json]")
+	asJSON := flags.Bool("json", false, "print JSON instead of a table")
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+
+	userService, err := env.userService()
+	if err != nil {
+		return err
+	}
+	users, err := userService.GetAllUsers()
+	if err != nil {
+		return err
+	}
+
+	if *asJSON {
+		responses := make([]models.UserResponse, 0, len(users))
+		for _, user := range users {
+			responses = append(responses, user.ToResponse())
+		}
+		return env.printJSON(responses)
+	}
+	rows := make([][]string, 0, len(users))
+	for _, user := range users {
+		email := user.EmailAddress()
+		if email == "" {
+			email = "-"
+		}
+		rows = append(rows, []string{user.ID, user.Username, user.Role, email, user.CreatedAt.Format(time.RFC3339)})
+	}
+	return env.printTable([]string{"ID", "USERNAME", "ROLE", "EMAIL", "CREATED"}, rows)
+}
+
+func runUserResetPassword(env *commandEnv, args []string) error {
+	flags := env.newFlagSet("user reset-password --username NAME [--json]")
+	username := flags.String("username", "", "account whose password is replaced")
+	asJSON := flags.Bool("json", false, "print JSON instead of text")
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+
+	userService, err := env.userService()
+	if err != nil {
+		return err
+	}
+	target, err := env.findUser(*username)
+	if err != nil {
+		return err
+	}
+	user, temporaryPassword, revoked, err := userService.ResetPasswordToTemporary(target.ID)
+	if err != nil {
+		return err
+	}
+
+	if *asJSON {
+		return env.printJSON(userWithPassword{User: user.ToResponse(), TemporaryPassword: temporaryPassword, RevokedSessions: &revoked})
+	}
+	fmt.Fprintf(env.stdout, "Reset the password of %q and signed out %d session(s)\n", user.Username, revoked)
+	fmt.Fprintf(env.stdout, "Temporary password: %s\n", temporaryPassword)
+	return nil
+}
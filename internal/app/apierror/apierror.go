@@ -0,0 +1,251 @@
+// Package apierror defines the body of every API error response and the codes that
+// identify them.
+//
+// Every error is answered with the same envelope:
+//
+//	{"message": "Network not found", "errorCode": "network.not_found", "code": 404, "requestId": "..."}
+//
+// errorCode is the machine-readable code clients translate and branch on; message is a
+// fallback, in the request's language for translated errors and in English otherwise.
+// Request body and validation problems add "fields", one entry per field.
+package apierror
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/GT-610/tairitsu/internal/app/i18n"
+	"github.com/GT-610/tairitsu/internal/app/logger"
+	"github.com/gofiber/fiber/v3"
+)
+
+// maxDetailLength bounds the optional detail so it stays a short explanation
+const maxDetailLength = 256
+
+// Response is the JSON body of every API error
+type Response struct {
+	Message   string `json:"message"`
+	ErrorCode string `json:"errorCode"`
+	// Code repeats the HTTP status for clients that only see the body
+	Code      int          `json:"code"`
+	RequestID string       `json:"requestId,omitempty"`
+	Detail    string       `json:"detail,omitempty"`
+	Fields    []FieldError `json:"fields,omitempty"`
+}
+
+// FieldError is a problem with one request field. Field is the JSON path, such as
+// "config.routes" or "name".
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// Error is reported as its message, so validation helpers can return it as a plain error
+func (e FieldError) Error() string {
+	return e.Message
+}
+
+// Invalid creates a validation error for one field
+func Invalid(field, message string) error {
+	return FieldError{Field: field, Message: message}
+}
+
+// UserFacingError marks an error whose text is safe to show to clients. Handlers answer
+// unexpected failures with a generic message; a UserFacingError's text is sent instead.
+type UserFacingError struct {
+	Err error
+}
+
+func (e *UserFacingError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *UserFacingError) Unwrap() error {
+	return e.Err
+}
+
+// UserFacing marks err as safe to show to clients. Only wrap errors whose text holds no
+// SQL, file paths, URLs or upstream responses.
+func UserFacing(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &UserFacingError{Err: err}
+}
+
+// UserMessage returns the text of the first UserFacingError in err's chain
+func UserMessage(err error) (string, bool) {
+	var userFacing *UserFacingError
+	if !errors.As(err, &userFacing) {
+		return "", false
+	}
+	return userFacing.Error(), true
+}
+
+// Error is an API error. Handlers write it with Write, or return it and leave it to the
+// error handler middleware.
+type Error struct {
+	Status  int
+	Code    string
+	Message string
+	// Detail is a client-safe explanation; never pass raw error text that may hold paths or internals
+	Detail string
+	Fields []FieldError
+	// messageKey translates the message for requests in languages other than the default
+	messageKey string
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// New creates an error with a registered code
+func New(status int, code, message string) *Error {
+	return &Error{Status: status, Code: code, Message: message}
+}
+
+// Localized creates an error whose message is the translation of its code into the
+// request's language
+func Localized(status int, code string) *Error {
+	return New(status, code, "").Translate(code)
+}
+
+// Translate makes the message follow the request's language. Message, or the
+// i18n.DefaultLocale text of key when Message is empty, answers requests in the default
+// language; other languages get the text of key. A Message saying more than that text, such
+// as which rule a value broke, is kept as the detail so the specifics are not lost.
+func (e *Error) Translate(key string) *Error {
+	e.messageKey = key
+	if e.Message == "" {
+		e.Message = i18n.Text(i18n.DefaultLocale, key)
+	}
+	return e
+}
+
+// FromStatus creates an error whose code is derived from the HTTP status
+func FromStatus(status int, message string) *Error {
+	return New(status, DefaultCode(status), message)
+}
+
+// WithDetail adds a client-safe detail, trimmed to a short length
+func (e *Error) WithDetail(detail string) *Error {
+	e.Detail = trimDetail(detail)
+	return e
+}
+
+func trimDetail(detail string) string {
+	detail = strings.TrimSpace(detail)
+	if len(detail) > maxDetailLength {
+		detail = detail[:maxDetailLength]
+	}
+	return detail
+}
+
+// Body builds the response body for the request, including its request ID
+func (e *Error) Body(c fiber.Ctx) Response {
+	message, detail := e.Message, e.Detail
+	if locale := i18n.LocaleFrom(c); e.messageKey != "" && locale != i18n.DefaultLocale {
+		message = i18n.Text(locale, e.messageKey)
+		if detail == "" && !strings.EqualFold(e.Message, i18n.Text(i18n.DefaultLocale, e.messageKey)) {
+			detail = trimDetail(e.Message)
+		}
+	}
+	return Response{
+		Message:   message,
+		ErrorCode: e.Code,
+		Code:      e.Status,
+		RequestID: logger.RequestID(c),
+		Detail:    detail,
+		Fields:    e.Fields,
+	}
+}
+
+// Write sends the error as the response
+func Write(c fiber.Ctx, e *Error) error {
+	return c.Status(e.Status).JSON(e.Body(c))
+}
+
+// DefaultCode derives a code from an HTTP status, such as http.not_found, for errors without
+// a more specific code
+func DefaultCode(status int) string {
+	text := strings.ToLower(http.StatusText(status))
+	if text == "" {
+		return CodeUnknown
+	}
+	return "http." + strings.ReplaceAll(text, " ", "_")
+}
+
+// Bind translates a request body that could not be decoded into field-level details,
+// without exposing decoder internals to the client
+func Bind(code string, err error) *Error {
+	apiErr := New(fiber.StatusBadRequest, code, "Invalid request body")
+
+	var typeErr *json.UnmarshalTypeError
+	var syntaxErr *json.SyntaxError
+	var fiberErr *fiber.Error
+	switch {
+	case errors.As(err, &typeErr) && typeErr.Field != "":
+		apiErr.Fields = []FieldError{{Field: typeErr.Field, Message: "must be " + jsonTypeName(typeErr.Type)}}
+	case errors.As(err, &typeErr):
+		apiErr.Detail = "request body must be " + jsonTypeName(typeErr.Type)
+	case errors.As(err, &syntaxErr):
+		apiErr.Detail = "request body is not valid JSON"
+	case errors.As(err, &fiberErr) && fiberErr.Code == fiber.StatusUnprocessableEntity:
+		apiErr.Detail = "request body must be JSON"
+	}
+	return apiErr
+}
+
+// Validation reports a rejected request. FieldErrors in err, also several joined with
+// errors.Join, are listed as fields; any other error is a plain bad request.
+func Validation(err error) *Error {
+	fields := fieldErrors(err)
+	if len(fields) == 0 {
+		return FromStatus(fiber.StatusBadRequest, err.Error())
+	}
+	apiErr := New(fiber.StatusBadRequest, CodeValidationFailed, fields[0].Message)
+	apiErr.Fields = fields
+	return apiErr
+}
+
+func fieldErrors(err error) []FieldError {
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		var fields []FieldError
+		for _, inner := range joined.Unwrap() {
+			fields = append(fields, fieldErrors(inner)...)
+		}
+		return fields
+	}
+	var fieldErr FieldError
+	if errors.As(err, &fieldErr) {
+		return []FieldError{fieldErr}
+	}
+	return nil
+}
+
+func jsonTypeName(t reflect.Type) string {
+	if t == nil {
+		return "a different type"
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		return "a boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "an integer"
+	case reflect.Float32, reflect.Float64:
+		return "a number"
+	case reflect.String:
+		return "a string"
+	case reflect.Slice, reflect.Array:
+		return "an array"
+	case reflect.Map, reflect.Struct:
+		return "an object"
+	case reflect.Pointer:
+		return jsonTypeName(t.Elem())
+	}
+	return "a different type"
+}
@@ -0,0 +1,189 @@
+package apierror
+
+// Error codes. A code names one failure for clients to translate and branch on; it never
+// changes once released, even if the message does. Codes are "area.reason" in lowercase.
+const (
+	// CodeUnknown is used for a status without a standard name
+	CodeUnknown = "error.unknown"
+
+	// CodeInvalidBody is a request body that could not be decoded
+	CodeInvalidBody = "request.invalid_body"
+	// CodeValidationFailed is a decoded request with invalid fields
+	CodeValidationFailed = "request.validation_failed"
+
+	CodeApiTokenDelegationDenied = "api_token.delegation_denied"
+	CodeApiTokenInvalidRequest   = "api_token.invalid_request"
+	CodeApiTokenNotFound         = "api_token.not_found"
+	CodeApiTokenSessionRequired  = "api_token.session_required"
+
+	CodeApprovalAlreadyDecided = "approval.already_decided"
+	CodeApprovalNotFound       = "approval.not_found"
+
+	CodeAppStateConflicts         = "appstate.conflicts"
+	CodeAppStateInvalidArchive    = "appstate.invalid_archive"
+	CodeAppStatePasswordRequired  = "appstate.password_required"
+	CodeAppStateSchemaUnsupported = "appstate.schema_unsupported"
+	CodeAppStateWrongPassword     = "appstate.wrong_password"
+
+	CodeAuthAdminRequired                = "auth.admin_required"
+	CodeAuthCaptchaInvalid               = "auth.captcha_invalid"
+	CodeAuthCaptchaRequired              = "auth.captcha_required"
+	CodeAuthCaptchaUnavailable           = "auth.captcha_unavailable"
+	CodeAuthInsufficientScope            = "auth.insufficient_scope"
+	CodeAuthInvalidFormat                = "auth.invalid_format"
+	CodeAuthInvalidToken                 = "auth.invalid_token"
+	CodeAuthJWTSecretRotationFailed      = "auth.jwt_secret_rotation_failed"
+	CodeAuthMissingToken                 = "auth.missing_token"
+	CodeAuthOIDCDisabled                 = "auth.oidc_disabled"
+	CodeAuthOIDCEmailNotVerified         = "auth.oidc_email_not_verified"
+	CodeAuthOIDCInvalidState             = "auth.oidc_invalid_state"
+	CodeAuthOIDCInvalidToken             = "auth.oidc_invalid_token"
+	CodeAuthOIDCProviderError            = "auth.oidc_provider_error"
+	CodeAuthPasswordChangeRequired       = "auth.password_change_required"
+	CodeAuthPasswordConfirmationMismatch = "auth.password_confirmation_mismatch"
+	CodeAuthPasswordLoginDisabled        = "auth.password_login_disabled"
+	CodeAuthRequired                     = "auth.required"
+	CodeAuthTokenGenerationFailed        = "auth.token_generation_failed"
+	CodeAuthUnauthorized                 = "auth.unauthorized"
+
+	CodeBackupConfirmationRequired  = "backup.confirmation_required"
+	CodeBackupControllerUnavailable = "backup.controller_unavailable"
+	CodeBackupInvalid               = "backup.invalid"
+	CodeBackupKeyMismatch           = "backup.key_mismatch"
+	CodeBackupKeyMissing            = "backup.key_missing"
+	CodeBackupNotFound              = "backup.not_found"
+	CodeBackupRunning               = "backup.running"
+
+	CodeCapabilityNotFound = "capability.not_found"
+
+	CodeChecklistItemNotFound = "checklist.item_not_found"
+
+	CodeControllerInvalidPeerRole  = "controller.invalid_peer_role"
+	CodeControllerNotFound         = "controller.not_found"
+	CodeControllerPeersUnsupported = "controller.peers_unsupported"
+	CodeControllerUnavailable      = "controller.unavailable"
+
+	CodeDeviceAlreadyAuthorized = "device.already_authorized"
+	CodeDeviceClaimNotFound     = "device.claim_not_found"
+	CodeDeviceClaimNotVerified  = "device.claim_not_verified"
+	CodeDeviceClaimedByOther    = "device.claimed_by_other"
+
+	CodeEventsLagging = "events.lagging"
+	CodeEventsStopped = "events.stopped"
+
+	CodeLockdownActive   = "lockdown.active"
+	CodeLockdownNotFound = "lockdown.not_found"
+
+	CodeMaintenanceCompactionRunning     = "maintenance.compaction_running"
+	CodeMaintenanceCompactionUnsupported = "maintenance.compaction_unsupported"
+
+	CodeMemberMetadataInvalid = "member.metadata_invalid"
+	CodeMemberNotFound        = "member.not_found"
+	CodeMemberTagUndefined    = "member.tag_undefined"
+	CodeMemberTagValueInvalid = "member.tag_value_invalid"
+
+	CodeNetworkAccessDenied         = "network.access_denied"
+	CodeNetworkBackupInvalid        = "network.backup_invalid"
+	CodeNetworkFeatureUnsupported   = "network.feature_unsupported"
+	CodeNetworkImportAccessDenied   = "network.import_access_denied"
+	CodeNetworkImportEmpty          = "network.import_empty"
+	CodeNetworkImportOwnerNotFound  = "network.import_owner_not_found"
+	CodeNetworkImportOwnerRequired  = "network.import_owner_required"
+	CodeNetworkInvalidPolicy        = "network.invalid_policy"
+	CodeNetworkInvalidPrivacyPolicy = "network.invalid_privacy_policy"
+	CodeNetworkNotFound             = "network.not_found"
+	CodeNetworkRestoreFailed        = "network.restore_failed"
+	CodeNetworkRulesInvalid         = "network.rules_invalid"
+	CodeNetworkViewerTargetInvalid  = "network.viewer_target_invalid"
+
+	CodeOperationIdempotencyKeyReused = "operation.idempotency_key_reused"
+	CodeOperationNotFound             = "operation.not_found"
+	CodeOperationNotRunning           = "operation.not_running"
+
+	CodeOrganizationAccessDenied     = "organization.access_denied"
+	CodeOrganizationDefaultProtected = "organization.default_protected"
+	CodeOrganizationInvalid          = "organization.invalid"
+	CodeOrganizationInvalidRole      = "organization.invalid_role"
+	CodeOrganizationMemberNotFound   = "organization.member_not_found"
+	CodeOrganizationNameTaken        = "organization.name_taken"
+	CodeOrganizationNotFound         = "organization.not_found"
+
+	CodePaginationCursorQueryChanged = "pagination.cursor_query_changed"
+	CodePaginationInvalidCursor      = "pagination.invalid_cursor"
+	CodePaginationInvalidRequest     = "pagination.invalid_request"
+
+	CodePlanetInvalidPath = "planet.invalid_path"
+	CodePlanetNotFound    = "planet.not_found"
+
+	CodeSessionAccessDenied = "session.access_denied"
+	CodeSessionExpired      = "session.expired"
+	CodeSessionNotFound     = "session.not_found"
+	CodeSessionRevoked      = "session.revoked"
+
+	CodeSetupAdminCreationInitFailed      = "setup.admin_creation_init_failed"
+	CodeSetupAdminRequired                = "setup.admin_required"
+	CodeSetupAdminStateCheckFailed        = "setup.admin_state_check_failed"
+	CodeSetupAlreadyInitialized           = "setup.already_initialized"
+	CodeSetupDatabaseConfigSaveFailed     = "setup.database_config_save_failed"
+	CodeSetupDatabaseConnectionFailed     = "setup.database_connection_failed"
+	CodeSetupDatabaseInitializationFailed = "setup.database_initialization_failed"
+	CodeSetupDatabaseReopenFailed         = "setup.database_reopen_failed"
+	CodeSetupInitializationStateFailed    = "setup.initialization_state_failed"
+	CodeSetupInvalidConfig                = "setup.invalid_config"
+	CodeSetupResetConfirmationRequired    = "setup.reset_confirmation_required"
+	CodeSetupStepOutOfOrder               = "setup.step_out_of_order"
+	CodeSetupUnsupportedDatabase          = "setup.unsupported_database"
+	CodeSetupZerotierClientCreateFailed   = "setup.zerotier_client_create_failed"
+	CodeSetupZerotierConfigSaveFailed     = "setup.zerotier_config_save_failed"
+	CodeSetupZerotierUnavailable          = "setup.zerotier_unavailable"
+	CodeSetupZerotierValidationFailed     = "setup.zerotier_validation_failed"
+
+	CodeStatusPageDisabled = "status_page.disabled"
+
+	CodeSystemAlreadyInitialized          = "system.already_initialized"
+	CodeSystemDatabaseUnavailable         = "system.database_unavailable"
+	CodeSystemDemoModeDisabled            = "system.demo_mode_disabled"
+	CodeSystemEncryptionKeyExternal       = "system.encryption_key_external"
+	CodeSystemEncryptionKeyRotationFailed = "system.encryption_key_rotation_failed"
+	CodeSystemInternalError               = "system.internal_error"
+	CodeSystemInvalidLogLevel             = "system.invalid_log_level"
+	CodeSystemInvalidPasswordPolicy       = "system.invalid_password_policy"
+	CodeSystemInvalidRequest              = "system.invalid_request"
+	CodeSystemMaintenance                 = "system.maintenance"
+	CodeSystemRateLimited                 = "system.rate_limited"
+	CodeSystemSetupRequired               = "system.setup_required"
+	CodeSystemStatsHistoryInvalid         = "system.stats_history_invalid"
+	CodeSystemStatsUnavailable            = "system.stats_unavailable"
+	CodeSystemUserServiceUnavailable      = "system.user_service_unavailable"
+
+	CodeTagConflict = "tag.conflict"
+	CodeTagInvalid  = "tag.invalid"
+	CodeTagNotFound = "tag.not_found"
+
+	CodeTLSNotConfigured = "tls.not_configured"
+	CodeTLSReloadFailed  = "tls.reload_failed"
+
+	CodeTraceBatchTooLarge = "trace.batch_too_large"
+
+	CodeUserAdminAccessDenied          = "user.admin_access_denied"
+	CodeUserDBUnavailable              = "user.db_unavailable"
+	CodeUserEmailExists                = "user.email_exists"
+	CodeUserInvalidAdminOperation      = "user.invalid_admin_operation"
+	CodeUserInvalidCredentials         = "user.invalid_credentials"
+	CodeUserInvalidEmail               = "user.invalid_email"
+	CodeUserInvalidPassword            = "user.invalid_password"
+	CodeUserInvalidUsername            = "user.invalid_username"
+	CodeUserNotFound                   = "user.not_found"
+	CodeUserOldPasswordIncorrect       = "user.old_password_incorrect"
+	CodeUserPasswordPolicy             = "user.password_policy"
+	CodeUserPublicRegistrationDisabled = "user.public_registration_disabled"
+	CodeUserRequired                   = "user.required"
+	CodeUserResetTokenExpired          = "user.reset_token_expired"
+	CodeUserResetTokenInvalid          = "user.reset_token_invalid"
+	CodeUserUsernameExists             = "user.username_exists"
+	CodeUserUsernameTooLong            = "user.username_too_long"
+
+	CodeWebhookInvalidRequest = "webhook.invalid_request"
+	CodeWebhookNotFound       = "webhook.not_found"
+	CodeWebhookQueueFull      = "webhook.queue_full"
+)
@@ -0,0 +1,17 @@
+// Package apptest holds helpers shared by the tests of the application packages.
+package apptest
+
+import (
+	"time"
+
+	"github.com/gofiber/fiber/v3"
+)
+
+// slowRequestTimeout replaces fiber's one-second test limit, which is too tight for bcrypt
+// on a busy machine or under the race detector
+const slowRequestTimeout = 10 * time.Second
+
+// SlowTestConfig is the fiber.App.Test config for requests that hash or verify a password
+func SlowTestConfig() fiber.TestConfig {
+	return fiber.TestConfig{Timeout: slowRequestTimeout, FailOnTimeout: true}
+}
@@ -1,32 +1,83 @@
 package assembly
 
 import (
+	"crypto/rand"
+	"encoding/base64"
+	"io/fs"
+	"os"
+	"path/filepath"
+
 	"github.com/GT-610/tairitsu/internal/app/config"
 	"github.com/GT-610/tairitsu/internal/app/database"
 	"github.com/GT-610/tairitsu/internal/app/handlers"
+	"github.com/GT-610/tairitsu/internal/app/logger"
 	"github.com/GT-610/tairitsu/internal/app/middleware"
 	"github.com/GT-610/tairitsu/internal/app/services"
 	"github.com/GT-610/tairitsu/internal/zerotier"
+	"github.com/GT-610/tairitsu/web"
 	"github.com/gofiber/fiber/v3"
+	"go.uber.org/zap"
 )
 
 type Services struct {
-	Network *services.NetworkService
-	User    *services.UserService
-	Session *services.SessionService
-	JWT     *services.JWTService
-	State   *services.StateService
-	Runtime *services.RuntimeService
-	Setup   *services.SetupService
-	System  *services.SystemService
+	Network       *services.NetworkService
+	User          *services.UserService
+	Session       *services.SessionService
+	JWT           *services.JWTService
+	State         *services.StateService
+	Runtime       *services.RuntimeService
+	Setup         *services.SetupService
+	System        *services.SystemService
+	Checklist     *services.ChecklistService
+	Audit         *services.AuditService
+	Health        *services.HealthService
+	StatusPage    *services.StatusPageService
+	ApiToken      *services.ApiTokenService
+	MemberStatus  *services.MemberStatusCollector
+	MemberEvents  *services.MemberEventHub
+	Trace         *services.ControllerTraceService
+	AppState      *services.AppStateService
+	Maintenance   *services.MaintenanceMode
+	DBMaintenance *services.DatabaseMaintenanceService
+	Inactivity    *services.MemberInactivityService
+	MemberExpiry  *services.MemberExpiryService
+	SystemBackup  *services.SystemBackupService
+	PlanetHistory *services.PlanetHistoryService
+	Webhooks      *services.WebhookDispatcher
+	Dashboard     *services.DashboardService
+	TLS           *services.TLSCertificateService
+	OIDC          *services.OIDCService
+	LogShipper    *services.LogShipper
+	Operations    *services.OperationManager
 }
 
 type Handlers struct {
-	Network *handlers.NetworkHandler
-	Member  *handlers.MemberHandler
-	Auth    *handlers.AuthHandler
-	User    *handlers.UserHandler
-	System  *handlers.SystemHandler
+	Network     *handlers.NetworkHandler
+	Member      *handlers.MemberHandler
+	MemberEvent *handlers.MemberEventHandler
+	Trace       *handlers.ControllerTraceHandler
+	Auth        *handlers.AuthHandler
+	User        *handlers.UserHandler
+	System      *handlers.SystemHandler
+	Checklist   *handlers.ChecklistHandler
+	Audit       *handlers.AuditHandler
+	Health      *handlers.HealthHandler
+	StatusPage  *handlers.StatusPageHandler
+	ApiToken    *handlers.ApiTokenHandler
+	AppState    *handlers.AppStateHandler
+	Jobs        *handlers.JobsHandler
+	Backup      *handlers.SystemBackupHandler
+	Planet      *handlers.PlanetHandler
+	Approval    *handlers.ApprovalHandler
+	Device      *handlers.DeviceHandler
+	Org         *handlers.OrganizationHandler
+	Webhook     *handlers.WebhookHandler
+	Dashboard   *handlers.DashboardHandler
+	TLS         *handlers.TLSHandler
+	LogShipping *handlers.LogShippingHandler
+	Operation   *handlers.OperationHandler
+	// Frontend is nil when there is no frontend build to serve
+	Frontend *handlers.FrontendHandler
 }
 
 type Middleware struct {
@@ -34,6 +85,8 @@ type Middleware struct {
 	SetupOnly   fiber.Handler
 	RuntimeOnly fiber.Handler
 	AdminOnly   fiber.Handler
+	Audit       fiber.Handler
+	DemoBlocked fiber.Handler
 }
 
 type Dependencies struct {
@@ -47,48 +100,194 @@ type Dependencies struct {
 
 func NewDependencies(cfg *config.Config, db database.DBInterface, ztClient *zerotier.Client) *Dependencies {
 	networkService := services.NewNetworkService(ztClient, db)
+	networkService.SetStatusRefreshInterval(config.ZTStatusRefreshIntervalFrom(cfg))
+	networkService.Controllers().LoadControllers(cfg)
 
 	userService := services.NewUserService(db)
 	sessionService := services.NewSessionService(db)
+	apiTokenService := services.NewApiTokenService(db)
 
 	stateService := services.NewStateServiceWithConfig(cfg)
 	runtimeService := services.NewRuntimeService(userService, sessionService, networkService, stateService)
 	setupService := services.NewSetupService(runtimeService, stateService, userService, networkService)
 	systemService := services.NewSystemService()
-	jwtSecret := ""
-	if cfg != nil && cfg.Security.JWTSecret != "" {
-		jwtSecret = cfg.Security.JWTSecret
+	systemService.SetSampleInterval(config.SystemStatsSampleIntervalFrom(cfg))
+	checklistService := services.NewChecklistService(stateService, userService, networkService)
+	auditService := services.NewAuditService(db)
+	traceService := services.NewControllerTraceService(db)
+	healthService := services.NewHealthService(networkService)
+	healthService.SetDatabaseState(runtimeService)
+	statusPageService := services.NewStatusPageService(stateService, healthService, networkService)
+	appStateService := services.NewAppStateService(db, stateService, userService)
+	if cfg != nil {
+		auditService.SetRetentionDays(cfg.Audit.RetentionDays)
+		traceService.SetRetentionDays(cfg.ControllerTrace.RetentionDays)
+		userService.SetPasswordPolicy(services.PasswordPolicyFromConfig(cfg))
+		userService.SetPasswordHashCost(config.PasswordHashCostFrom(cfg))
+	}
+	memberStatusCollector := services.NewMemberStatusCollector(networkService, config.MemberStatusPollIntervalFrom(cfg))
+	if webhookURL := config.ApprovalWebhookURLFrom(cfg); webhookURL != "" {
+		memberStatusCollector.SetApprovalNotifier(services.NewWebhookApprovalNotifier(webhookURL))
+	}
+	memberEventHub := services.NewMemberEventHub(networkService, config.MemberEventPollIntervalFrom(cfg))
+	apiTokenService.SetNetworkAuthorizer(networkService)
+	maintenanceMode := services.NewMaintenanceMode()
+	dbMaintenanceService := services.NewDatabaseMaintenanceService(db, maintenanceMode, auditService, config.CompactIntervalFrom(cfg), config.CompactFreeRatioFrom(cfg))
+	systemBackupService := services.NewSystemBackupService(networkService, appStateService, stateService, auditService,
+		config.BackupDirectoryFrom(cfg), config.BackupIntervalFrom(cfg), config.BackupRetentionFrom(cfg))
+	inactivityService := services.NewMemberInactivityService(networkService, auditService, config.InactivityCheckIntervalFrom(cfg))
+	memberExpiryService := services.NewMemberExpiryService(networkService, auditService, services.MemberExpiryInterval)
+	planetHistoryService := services.NewPlanetHistoryService(db, config.PlanetHistoryLimitFrom(cfg))
+	webhookDispatcher := services.NewWebhookDispatcher(db)
+	networkService.SetWebhookDispatcher(webhookDispatcher)
+	operationManager := services.NewOperationManager(db, config.OperationConcurrencyFrom(cfg))
+	networkService.SetOperationManager(operationManager)
+	userService.SetWebhookDispatcher(webhookDispatcher)
+	dashboardService := services.NewDashboardService(networkService, userService, auditService, checklistService)
+	tlsCertificateService := services.NewTLSCertificateService()
+	runtimeService.RegisterDBBinders(auditService, apiTokenService, traceService, appStateService, dbMaintenanceService, planetHistoryService, webhookDispatcher, operationManager)
+	jwtService := newJWTService(cfg)
+
+	oidcSettings, err := config.OIDCFrom(cfg)
+	if err != nil {
+		logger.Error("single sign-on is misconfigured and stays disabled", zap.Error(err))
+	}
+	oidcService := services.NewOIDCService(oidcSettings, userService)
+
+	captchaSettings, err := config.CaptchaFrom(cfg)
+	if err != nil {
+		logger.Error("captcha is misconfigured and stays disabled", zap.Error(err))
+	}
+	captchaGuard := services.NewCaptchaGuard(captchaSettings, services.NewCaptchaVerifier(captchaSettings))
+
+	logShippingSettings, err := config.LogShippingFrom(cfg)
+	if err != nil {
+		logger.Error("log shipping is misconfigured and stays disabled", zap.Error(err))
 	}
-	jwtService := services.NewJWTService(jwtSecret)
+	logShipper := services.NewLogShipper(logShippingSettings)
+	auditService.SetLogShipper(logShipper)
+	memberStatusCollector.SetLogShipper(logShipper)
 
 	authHandler := handlers.NewAuthHandler(userService, sessionService, jwtService, runtimeService, stateService)
+	authHandler.SetOIDCService(oidcService)
+	authHandler.SetLogShipper(logShipper)
+	authHandler.SetCaptchaGuard(captchaGuard)
+	var frontendHandler *handlers.FrontendHandler
+	if files := frontendFiles(cfg); files != nil {
+		frontendHandler = handlers.NewFrontendHandler(files)
+	}
 
 	return &Dependencies{
 		Config:   cfg,
 		Database: db,
 		ZTClient: ztClient,
 		Services: Services{
-			Network: networkService,
-			User:    userService,
-			Session: sessionService,
-			JWT:     jwtService,
-			State:   stateService,
-			Runtime: runtimeService,
-			Setup:   setupService,
-			System:  systemService,
+			Network:       networkService,
+			User:          userService,
+			Session:       sessionService,
+			JWT:           jwtService,
+			State:         stateService,
+			Runtime:       runtimeService,
+			Setup:         setupService,
+			System:        systemService,
+			Checklist:     checklistService,
+			Audit:         auditService,
+			Health:        healthService,
+			StatusPage:    statusPageService,
+			ApiToken:      apiTokenService,
+			MemberStatus:  memberStatusCollector,
+			MemberEvents:  memberEventHub,
+			Trace:         traceService,
+			AppState:      appStateService,
+			Maintenance:   maintenanceMode,
+			DBMaintenance: dbMaintenanceService,
+			Inactivity:    inactivityService,
+			MemberExpiry:  memberExpiryService,
+			SystemBackup:  systemBackupService,
+			PlanetHistory: planetHistoryService,
+			Webhooks:      webhookDispatcher,
+			Dashboard:     dashboardService,
+			TLS:           tlsCertificateService,
+			OIDC:          oidcService,
+			LogShipper:    logShipper,
+			Operations:    operationManager,
 		},
 		Handlers: Handlers{
-			Network: handlers.NewNetworkHandler(networkService),
-			Member:  handlers.NewMemberHandler(networkService),
-			Auth:    authHandler,
-			User:    handlers.NewUserHandler(userService),
-			System:  handlers.NewSystemHandler(setupService, systemService),
+			Network:     handlers.NewNetworkHandler(networkService),
+			Member:      handlers.NewMemberHandler(networkService),
+			MemberEvent: handlers.NewMemberEventHandler(memberEventHub),
+			Trace:       handlers.NewControllerTraceHandler(traceService),
+			Auth:        authHandler,
+			User:        handlers.NewUserHandler(userService, sessionService),
+			System:      handlers.NewSystemHandler(setupService, systemService),
+			Checklist:   handlers.NewChecklistHandler(checklistService),
+			Health:      handlers.NewHealthHandler(healthService),
+			StatusPage:  handlers.NewStatusPageHandler(statusPageService),
+			ApiToken:    handlers.NewApiTokenHandler(apiTokenService),
+			Audit:       handlers.NewAuditHandler(auditService),
+			AppState:    handlers.NewAppStateHandler(appStateService),
+			Jobs:        handlers.NewJobsHandler(memberEventHub, dbMaintenanceService, systemBackupService, inactivityService, memberExpiryService),
+			Backup:      handlers.NewSystemBackupHandler(systemBackupService),
+			Planet:      handlers.NewPlanetHandler(planetHistoryService),
+			Approval:    handlers.NewApprovalHandler(networkService),
+			Device:      handlers.NewDeviceHandler(networkService),
+			Org:         handlers.NewOrganizationHandler(networkService),
+			Webhook:     handlers.NewWebhookHandler(webhookDispatcher),
+			Dashboard:   handlers.NewDashboardHandler(dashboardService),
+			TLS:         handlers.NewTLSHandler(tlsCertificateService),
+			LogShipping: handlers.NewLogShippingHandler(logShipper),
+			Operation:   handlers.NewOperationHandler(operationManager),
+			Frontend:    frontendHandler,
 		},
 		Middleware: Middleware{
-			Auth:        middleware.AuthMiddleware(jwtService, sessionService),
+			Auth:        middleware.AuthMiddlewareWithTokens(jwtService, sessionService, apiTokenService, userService),
 			SetupOnly:   middleware.SetupOnlyWithState(stateService),
-			RuntimeOnly: middleware.InitializedOnlyWithState(stateService),
+			RuntimeOnly: middleware.RuntimeReadyWithState(stateService, runtimeService),
 			AdminOnly:   middleware.AdminRequiredWithUserService(userService),
+			Audit:       middleware.Audit(auditService),
+			DemoBlocked: middleware.DisabledInDemoWithState(stateService),
 		},
 	}
 }
+
+// newJWTService signs with the configured secret. LoadConfig always provides one, so
+// the ephemeral fallback only applies to assemblies built without a loaded configuration.
+func newJWTService(cfg *config.Config) *services.JWTService {
+	secret := ""
+	if cfg != nil {
+		secret = cfg.Security.JWTSecret
+	}
+	if secret == "" {
+		ephemeral := make([]byte, 32)
+		if _, err := rand.Read(ephemeral); err != nil {
+			panic("failed to generate ephemeral JWT secret: " + err.Error())
+		}
+		secret = base64.URLEncoding.EncodeToString(ephemeral)
+		logger.Warn("no JWT secret configured; signing tokens with an ephemeral secret", zap.Bool("config_loaded", cfg != nil))
+	}
+
+	jwtService, err := services.NewJWTService(secret)
+	if err != nil {
+		panic(err)
+	}
+	if cfg != nil && len(cfg.Security.PreviousJWTSecrets) > 0 {
+		// Keep accepting tokens signed before the last rotation
+		if err := jwtService.SetSecrets(secret, cfg.Security.PreviousJWTSecrets); err != nil {
+			panic(err)
+		}
+	}
+	return jwtService
+}
+
+// frontendFiles returns the configured frontend directory, or else the build bundled into
+// the binary. A directory without index.html is ignored so a wrong path does not serve 404s.
+func frontendFiles(cfg *config.Config) fs.FS {
+	if dir := config.FrontendDirectoryFrom(cfg); dir != "" {
+		if _, err := os.Stat(filepath.Join(dir, "index.html")); err != nil {
+			logger.Error("frontend directory has no index.html; serving the placeholder page", zap.String("directory", dir), zap.Error(err))
+			return nil
+		}
+		return os.DirFS(dir)
+	}
+	return web.Dist()
+}
@@ -2,6 +2,8 @@ package bootstrap
 
 import (
 	"context"
+	"crypto/tls"
+	"errors"
 	"fmt"
 	"time"
 
@@ -9,38 +11,96 @@ import (
 	"github.com/GT-610/tairitsu/internal/app/config"
 	"github.com/GT-610/tairitsu/internal/app/database"
 	"github.com/GT-610/tairitsu/internal/app/logger"
+	"github.com/GT-610/tairitsu/internal/app/middleware"
+	"github.com/GT-610/tairitsu/internal/app/paths"
 	"github.com/GT-610/tairitsu/internal/app/routes"
 	"github.com/GT-610/tairitsu/internal/zerotier"
 	"github.com/gofiber/fiber/v3"
 	"go.uber.org/zap"
 )
 
+// ErrShutdownTimeout is returned when in-flight requests outlast the shutdown grace period
+var ErrShutdownTimeout = errors.New("graceful shutdown grace period exceeded")
+
 type App struct {
-	Config       *config.Config
-	Database     database.DBInterface
-	ZTClient     *zerotier.Client
-	Dependencies *assembly.Dependencies
-	Router       *fiber.App
-	cancel       context.CancelFunc
-	cleanupDone  <-chan struct{}
+	Config        *config.Config
+	Database      database.DBInterface
+	ZTClient      *zerotier.Client
+	Dependencies  *assembly.Dependencies
+	Router        *fiber.App
+	cancel        context.CancelFunc
+	cleanupDone   <-chan struct{}
+	auditDone     <-chan struct{}
+	statusDone    <-chan struct{}
+	eventsDone    <-chan struct{}
+	traceDone     <-chan struct{}
+	compactDone   <-chan struct{}
+	backupDone    <-chan struct{}
+	inactiveDone  <-chan struct{}
+	expiryDone    <-chan struct{}
+	ztStatusDone  <-chan struct{}
+	webhooksDone  <-chan struct{}
+	statsDone     <-chan struct{}
+	reconnectDone <-chan struct{}
+	shippingDone  <-chan struct{}
+	operationDone <-chan struct{}
+
+	// tlsConfig is set when the server listens with HTTPS
+	tlsConfig *tls.Config
+	// redirectRouter redirects plain HTTP on redirectAddr to HTTPS
+	redirectRouter *fiber.App
+	redirectAddr   string
+
+	// databaseErr is why the configured database could not be opened at startup
+	databaseErr error
+
+	// configWatcher reloads config.json; nil in demo mode and when the data directory cannot be watched
+	configWatcher     *config.Watcher
+	unsubscribeConfig func()
+
+	// DemoCredentials is set when the application was built in demo mode
+	DemoCredentials *DemoCredentials
+	demo            *demoEnvironment
+}
+
+// Options adjusts application assembly
+type Options struct {
+	// RegenerateSecrets replaces a missing or weak JWT secret instead of refusing to start
+	RegenerateSecrets bool
 }
 
 func Build() (*App, error) {
-	logger.InitLogger("info")
-	logger.Info("starting application assembly")
+	return BuildWithOptions(Options{})
+}
+
+func BuildWithOptions(opts Options) (*App, error) {
+	// Log to stdout until the configuration says where logs go
+	bootLogging := logger.DefaultOptions()
+	bootLogging.Console = true
+	bootLogging.FileDisabled = true
+	logger.Init(bootLogging)
+	logger.Info("starting application assembly", zap.String("data_dir", paths.DataDir()))
 
-	cfg, err := config.LoadConfig()
+	cfg, err := config.LoadConfigWithOptions(config.LoadOptions{RegenerateSecrets: opts.RegenerateSecrets})
 	if err != nil {
 		return nil, fmt.Errorf("failed to load configuration: %w", err)
 	}
+	logger.Init(config.LoggerOptionsFrom(cfg))
+	if _, _, err := config.ProxySettingsFrom(cfg); err != nil {
+		return nil, fmt.Errorf("invalid proxy configuration: %w", err)
+	}
+	middleware.ApplyRateLimitSettings(cfg.RateLimits)
 
 	app := &App{Config: cfg}
 
 	if err := app.initializeDatabase(); err != nil {
-		if cfg.Initialized {
-			return nil, fmt.Errorf("system is initialized, but database initialization failed: %w", err)
+		if !cfg.Initialized {
+			logger.Warn("database initialization failed; continuing in uninitialized mode", zap.Error(err))
+		} else {
+			// Serve 503s for database-backed routes and connect once the database is back
+			logger.Error("database is configured but unreachable; retrying in the background", zap.Error(err))
+			app.databaseErr = err
 		}
-		logger.Warn("database initialization failed; continuing in uninitialized mode", zap.Error(err))
 	}
 
 	if err := app.initializeZeroTierClient(); err != nil {
@@ -50,28 +110,71 @@ func Build() (*App, error) {
 		logger.Warn("ZeroTier client initialization failed; continuing in uninitialized mode", zap.Error(err))
 	}
 
-	app.Dependencies = assembly.NewDependencies(app.Config, app.Database, app.ZTClient)
-	app.Router = newHTTPApp()
-	routes.SetupRoutes(app.Router, app.Dependencies)
+	app.assemble()
+	if err := app.configureTLS(); err != nil {
+		_ = app.Shutdown(context.Background())
+		return nil, fmt.Errorf("failed to configure TLS: %w", err)
+	}
 
-	ctx, cancel := context.WithCancel(context.Background())
-	app.cancel = cancel
-	app.cleanupDone = app.Dependencies.Services.Session.StartCleanup(ctx)
+	if cfg.SecretsRegenerated {
+		revoked, err := app.Dependencies.Services.Session.RevokeAllSessions()
+		if err != nil {
+			logger.Warn("failed to revoke sessions after regenerating secrets", zap.Error(err))
+		} else {
+			logger.Info("revoked sessions signed with the previous secret", zap.Int64("count", revoked))
+		}
+	}
+
+	app.watchConfig()
 
 	logger.Info("application assembly completed")
 	return app, nil
 }
 
-func newHTTPApp() *fiber.App {
-	return fiber.New(httpAppConfig())
+// assemble wires services, routes and background tasks around the initialized dependencies
+func (a *App) assemble() {
+	a.Dependencies = assembly.NewDependencies(a.Config, a.Database, a.ZTClient)
+	a.Router = newHTTPApp(a.Config)
+	routes.SetupRoutes(a.Router, a.Dependencies)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	a.cancel = cancel
+	a.cleanupDone = a.Dependencies.Services.Session.StartCleanup(ctx)
+	a.auditDone = a.Dependencies.Services.Audit.StartMaintenance(ctx)
+	a.statusDone = a.Dependencies.Services.MemberStatus.Start(ctx)
+	a.eventsDone = a.Dependencies.Services.MemberEvents.Start(ctx)
+	a.traceDone = a.Dependencies.Services.Trace.StartMaintenance(ctx)
+	a.compactDone = a.Dependencies.Services.DBMaintenance.Start(ctx)
+	a.backupDone = a.Dependencies.Services.SystemBackup.Start(ctx)
+	a.inactiveDone = a.Dependencies.Services.Inactivity.Start(ctx)
+	a.expiryDone = a.Dependencies.Services.MemberExpiry.Start(ctx)
+	a.ztStatusDone = a.Dependencies.Services.Network.StartStatusRefresh(ctx)
+	a.webhooksDone = a.Dependencies.Services.Webhooks.Start(ctx)
+	a.statsDone = a.Dependencies.Services.System.Start(ctx)
+	a.shippingDone = a.Dependencies.Services.LogShipper.Start(ctx)
+	a.operationDone = a.Dependencies.Services.Operations.Start(ctx)
+	if a.databaseErr != nil {
+		a.Dependencies.Services.Runtime.MarkDatabaseUnavailable(a.databaseErr)
+		a.reconnectDone = a.Dependencies.Services.Runtime.StartDatabaseReconnect(ctx)
+	}
+}
+
+func newHTTPApp(cfg *config.Config) *fiber.App {
+	return fiber.New(httpAppConfig(cfg))
 }
 
-func httpAppConfig() fiber.Config {
+// httpAppConfig honors proxy headers only from loopback and the configured trusted proxies, so
+// c.IP() is the real client address for rate limiting, audit entries and sessions. With
+// X-Forwarded-For, fiber reads the header from the right and skips trusted proxies, so entries a
+// client prepends are ignored.
+func httpAppConfig(cfg *config.Config) fiber.Config {
+	proxies, header, _ := config.ProxySettingsFrom(cfg)
 	return fiber.Config{
 		TrustProxy:  true,
-		ProxyHeader: "X-Real-IP",
+		ProxyHeader: header,
 		TrustProxyConfig: fiber.TrustProxyConfig{
 			Loopback: true,
+			Proxies:  proxies,
 		},
 		EnableIPValidation: true,
 	}
@@ -83,36 +186,117 @@ func (a *App) Listen() error {
 	}
 
 	serverAddr := config.ServerAddressFrom(a.Config)
-	logger.Info("starting HTTP server", zap.String("address", serverAddr))
+	logger.Info("starting HTTP server", zap.String("address", serverAddr), zap.Bool("tls", a.tlsConfig != nil))
 
-	errCh := make(chan error, 1)
+	errCh := make(chan error, 2)
 	go func() {
-		errCh <- a.Router.Listen(serverAddr)
+		errCh <- a.Router.Listen(serverAddr, fiber.ListenConfig{TLSConfig: a.tlsConfig})
 	}()
+	if a.redirectRouter != nil {
+		a.listenRedirect(errCh)
+	}
 
 	return <-errCh
 }
 
-func (a *App) Shutdown() {
+// ShutdownGracePeriod returns how long Shutdown should wait for in-flight requests
+func (a *App) ShutdownGracePeriod() time.Duration {
+	return config.ShutdownGracePeriodFrom(a.Config)
+}
+
+// Shutdown ends live event streams, stops accepting connections, waits for in-flight requests until ctx is done,
+// then stops background tasks, closes the database and flushes the logger. Resources are
+// released even when the grace period is exceeded, in which case ErrShutdownTimeout is returned.
+func (a *App) Shutdown(ctx context.Context) error {
 	logger.Info("shutting down application")
-	if a.cancel != nil {
-		a.cancel()
+	a.stopWatchingConfig()
+	var shutdownErr error
+	if a.Dependencies != nil && a.Dependencies.Services.MemberEvents != nil {
+		a.Dependencies.Services.MemberEvents.Close()
 	}
 	if a.Router != nil {
-		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-		defer cancel()
 		if err := a.Router.ShutdownWithContext(ctx); err != nil {
-			logger.Error("failed to shutdown HTTP server", zap.Error(err))
+			if errors.Is(err, context.DeadlineExceeded) || ctx.Err() != nil {
+				shutdownErr = fmt.Errorf("%w: %w", ErrShutdownTimeout, err)
+			} else {
+				shutdownErr = fmt.Errorf("failed to shutdown HTTP server: %w", err)
+			}
+			logger.Error("HTTP server did not shut down cleanly", zap.Error(err))
+		}
+	}
+	if a.redirectRouter != nil {
+		if err := a.redirectRouter.ShutdownWithContext(ctx); err != nil {
+			logger.Warn("redirect server did not shut down cleanly", zap.Error(err))
 		}
 	}
+	if a.cancel != nil {
+		a.cancel()
+	}
 	if a.cleanupDone != nil {
 		<-a.cleanupDone
 	}
-	if a.Database != nil {
-		if err := a.Database.Close(); err != nil {
+	if a.auditDone != nil {
+		<-a.auditDone
+	}
+	if a.statusDone != nil {
+		<-a.statusDone
+	}
+	if a.eventsDone != nil {
+		<-a.eventsDone
+	}
+	if a.traceDone != nil {
+		<-a.traceDone
+	}
+	if a.compactDone != nil {
+		<-a.compactDone
+	}
+	if a.backupDone != nil {
+		<-a.backupDone
+	}
+	if a.inactiveDone != nil {
+		<-a.inactiveDone
+	}
+	if a.expiryDone != nil {
+		<-a.expiryDone
+	}
+	if a.ztStatusDone != nil {
+		<-a.ztStatusDone
+	}
+	if a.webhooksDone != nil {
+		<-a.webhooksDone
+	}
+	if a.statsDone != nil {
+		<-a.statsDone
+	}
+	if a.reconnectDone != nil {
+		<-a.reconnectDone
+	}
+	if a.shippingDone != nil {
+		<-a.shippingDone
+	}
+	if a.operationDone != nil {
+		<-a.operationDone
+	}
+	if db := a.currentDatabase(); db != nil {
+		if err := db.Close(); err != nil {
 			logger.Error("failed to close database", zap.Error(err))
 		}
 	}
+	if a.demo != nil {
+		a.demo.discard()
+	}
+	logger.Sync()
+	return shutdownErr
+}
+
+// currentDatabase returns the database bound at runtime, which setup may have replaced since Build
+func (a *App) currentDatabase() database.DBInterface {
+	if a.Dependencies != nil && a.Dependencies.Services.Runtime != nil {
+		if db := a.Dependencies.Services.Runtime.CurrentDatabase(); db != nil {
+			return db
+		}
+	}
+	return a.Database
 }
 
 func (a *App) initializeDatabase() error {
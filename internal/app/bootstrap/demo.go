@@ -0,0 +1,154 @@
+package bootstrap
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/GT-610/tairitsu/internal/app/config"
+	"github.com/GT-610/tairitsu/internal/app/logger"
+	"github.com/GT-610/tairitsu/internal/app/models"
+	"github.com/GT-610/tairitsu/internal/app/paths"
+	"github.com/GT-610/tairitsu/internal/app/services"
+	"github.com/GT-610/tairitsu/internal/zerotier"
+	"github.com/GT-610/tairitsu/internal/zerotier/ztmock"
+	"go.uber.org/zap"
+)
+
+const demoAdminUsername = "demo"
+
+// DemoCredentials holds the generated login of the demo administrator
+type DemoCredentials struct {
+	Username string
+	Password string
+}
+
+// demoEnvironment owns the throwaway resources backing demo mode
+type demoEnvironment struct {
+	dataDir    string
+	controller *ztmock.Controller
+	// restoreDataDir points paths back at the data directory in use before demo mode
+	restoreDataDir func()
+}
+
+func (d *demoEnvironment) discard() {
+	if d.controller != nil {
+		if err := d.controller.Close(); err != nil {
+			logger.Warn("failed to stop demo controller", zap.Error(err))
+		}
+	}
+	if d.dataDir != "" {
+		if err := os.RemoveAll(d.dataDir); err != nil {
+			logger.Warn("failed to remove demo data directory", zap.String("path", d.dataDir), zap.Error(err))
+		}
+	}
+	if d.restoreDataDir != nil {
+		d.restoreDataDir()
+	}
+}
+
+// BuildDemo assembles the application against a seeded in-memory controller and a
+// temporary database. Nothing is written outside the temporary data directory and
+// everything is discarded on shutdown.
+func BuildDemo() (*App, error) {
+	dataDir, err := os.MkdirTemp("", "tairitsu-demo-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create demo data directory: %w", err)
+	}
+	// Disk usage, planets and anything else kept in the data directory stay in the temporary one
+	demo := &demoEnvironment{dataDir: dataDir, restoreDataDir: paths.SetDataDir(dataDir)}
+
+	app, err := buildDemo(demo)
+	if err != nil {
+		demo.discard()
+		return nil, err
+	}
+	return app, nil
+}
+
+func buildDemo(demo *demoEnvironment) (*App, error) {
+	logger.InitLoggerWithFile("info", filepath.Join(demo.dataDir, "tairitsu.log"))
+	logger.Info("starting application assembly in demo mode", zap.String("data_dir", demo.dataDir))
+
+	demo.controller = ztmock.NewController(ztmock.DemoAddress)
+	networkIDs := ztmock.SeedDemoData(demo.controller)
+	controllerURL, err := demo.controller.Start()
+	if err != nil {
+		return nil, err
+	}
+
+	cfg, err := config.NewDemoConfig(demo.dataDir, controllerURL, demo.controller.Token)
+	if err != nil {
+		return nil, err
+	}
+
+	app := &App{Config: cfg, demo: demo}
+	if err := app.initializeDatabase(); err != nil {
+		return nil, fmt.Errorf("failed to initialize demo database: %w", err)
+	}
+
+	app.ZTClient = &zerotier.Client{
+		BaseURL:    controllerURL,
+		Token:      demo.controller.Token,
+		HTTPClient: &http.Client{Timeout: 10 * time.Second},
+	}
+
+	app.DemoCredentials, err = app.seedDemoDatabase(networkIDs)
+	if err != nil {
+		app.Database.Close()
+		return nil, err
+	}
+
+	app.assemble()
+
+	logger.Info("demo application assembly completed", zap.Int("network_count", len(networkIDs)))
+	return app, nil
+}
+
+// seedDemoDatabase creates the demo administrator and registers the seeded networks to it
+func (a *App) seedDemoDatabase(networkIDs []string) (*DemoCredentials, error) {
+	password, err := generateDemoPassword()
+	if err != nil {
+		return nil, err
+	}
+
+	// Hashing goes through the normal registration path so demo logins behave like real ones.
+	admin, err := services.NewUserService(a.Database).Register(&models.RegisterRequest{
+		Username: demoAdminUsername,
+		Password: password,
+	}, "admin")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create demo administrator: %w", err)
+	}
+
+	for _, networkID := range networkIDs {
+		network, err := a.ZTClient.GetNetwork(networkID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read seeded network %s: %w", networkID, err)
+		}
+		now := time.Now()
+		if err := a.Database.CreateNetwork(&models.Network{
+			ID:        network.ID,
+			Name:      network.Name,
+			OwnerID:   admin.ID,
+			CreatedAt: now,
+			UpdatedAt: now,
+		}); err != nil {
+			return nil, fmt.Errorf("failed to register seeded network %s: %w", networkID, err)
+		}
+	}
+
+	return &DemoCredentials{Username: demoAdminUsername, Password: password}, nil
+}
+
+func generateDemoPassword() (string, error) {
+	b := make([]byte, 12)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate demo password: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
@@ -0,0 +1,136 @@
+package bootstrap
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/GT-610/tairitsu/internal/app/apptest"
+	"github.com/GT-610/tairitsu/internal/app/config"
+	"github.com/GT-610/tairitsu/internal/app/paths"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func demoRequest(t *testing.T, app *App, method, path, token string, body any) (*http.Response, []byte) {
+	t.Helper()
+
+	var payload bytes.Buffer
+	if body != nil {
+		require.NoError(t, json.NewEncoder(&payload).Encode(body))
+	}
+	req := httptest.NewRequest(method, path, &payload)
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := app.Router.Test(req, apptest.SlowTestConfig())
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	var raw bytes.Buffer
+	_, err = raw.ReadFrom(resp.Body)
+	require.NoError(t, err)
+	return resp, raw.Bytes()
+}
+
+func TestBuildDemoServesSeededDataWithoutTouchingWorkingDirectory(t *testing.T) {
+	workDir := t.TempDir()
+	t.Chdir(workDir)
+
+	app, err := BuildDemo()
+	require.NoError(t, err)
+	dataDir := app.demo.dataDir
+	require.NotNil(t, app.DemoCredentials)
+	assert.Equal(t, dataDir, paths.DataDir())
+	assert.Equal(t, filepath.Join(dataDir, "backups"), config.BackupDirectoryFrom(app.Config))
+
+	resp, body := demoRequest(t, app, http.MethodGet, "/api/system/status", "", nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var status struct {
+		Initialized bool `json:"initialized"`
+		DemoMode    bool `json:"demoMode"`
+	}
+	require.NoError(t, json.Unmarshal(body, &status))
+	assert.True(t, status.Initialized)
+	assert.True(t, status.DemoMode)
+
+	resp, body = demoRequest(t, app, http.MethodPost, "/api/auth/login", "", map[string]string{
+		"username": app.DemoCredentials.Username,
+		"password": app.DemoCredentials.Password,
+	})
+	require.Equal(t, http.StatusOK, resp.StatusCode, string(body))
+	var login struct {
+		Token string `json:"token"`
+	}
+	require.NoError(t, json.Unmarshal(body, &login))
+
+	resp, body = demoRequest(t, app, http.MethodGet, "/api/networks", login.Token, nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode, string(body))
+	var networks []struct {
+		ID          string `json:"id"`
+		Name        string `json:"name"`
+		MemberCount int    `json:"memberCount"`
+	}
+	require.NoError(t, json.Unmarshal(body, &networks))
+	require.Len(t, networks, 3)
+	assert.Positive(t, networks[0].MemberCount)
+
+	networkID := networks[0].ID
+	resp, body = demoRequest(t, app, http.MethodGet, "/api/networks/"+networkID+"/members", login.Token, nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode, string(body))
+	var members []struct {
+		ID         string `json:"id"`
+		Name       string `json:"name"`
+		Authorized bool   `json:"authorized"`
+	}
+	require.NoError(t, json.Unmarshal(body, &members))
+	require.NotEmpty(t, members)
+
+	resp, body = demoRequest(t, app, http.MethodPut, "/api/networks/"+networkID+"/members/"+members[0].ID, login.Token, map[string]any{
+		"name":       "renamed-in-demo",
+		"authorized": !members[0].Authorized,
+	})
+	require.Equal(t, http.StatusOK, resp.StatusCode, string(body))
+
+	resp, body = demoRequest(t, app, http.MethodGet, "/api/networks/"+networkID+"/members/"+members[0].ID, login.Token, nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode, string(body))
+	var member struct {
+		Name       string `json:"name"`
+		Authorized bool   `json:"authorized"`
+	}
+	require.NoError(t, json.Unmarshal(body, &member))
+	assert.Equal(t, "renamed-in-demo", member.Name)
+	assert.Equal(t, !members[0].Authorized, member.Authorized)
+
+	for _, blocked := range []struct{ method, path string }{
+		{http.MethodPost, "/api/admin/planet/keys"},
+		{http.MethodPost, "/api/admin/planet/generate"},
+		{http.MethodPost, "/api/admin/planet/moon"},
+		{http.MethodPost, "/api/users"},
+		{http.MethodDelete, "/api/users/some-user"},
+		{http.MethodDelete, "/api/webhooks/some-webhook"},
+		{http.MethodPost, "/api/admin/maintenance/compact"},
+	} {
+		resp, _ = demoRequest(t, app, blocked.method, blocked.path, login.Token, nil)
+		assert.Equal(t, http.StatusForbidden, resp.StatusCode, "%s %s", blocked.method, blocked.path)
+	}
+
+	resp, body = demoRequest(t, app, http.MethodPut, "/api/admin/checklist/multiple_admins", login.Token, map[string]bool{"dismissed": true})
+	require.Equal(t, http.StatusOK, resp.StatusCode, string(body))
+
+	require.NoError(t, app.Shutdown(context.Background()))
+
+	entries, err := os.ReadDir(workDir)
+	require.NoError(t, err)
+	assert.Empty(t, entries, "demo mode must not write to the working directory")
+	_, err = os.Stat(dataDir)
+	assert.True(t, os.IsNotExist(err), "demo data directory should be discarded on shutdown")
+	assert.Equal(t, paths.DefaultDataDir, paths.DataDir())
+}
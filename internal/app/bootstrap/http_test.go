@@ -6,6 +6,7 @@ import (
 	"net/http/httptest"
 	"testing"
 
+	appconfig "github.com/GT-610/tairitsu/internal/app/config"
 	"github.com/GT-610/tairitsu/internal/app/middleware"
 	"github.com/gofiber/fiber/v3"
 	"github.com/stretchr/testify/assert"
@@ -24,13 +25,13 @@ func addIdentityRoute(app *fiber.App) {
 }
 
 func newTrustedTestProxyApp() *fiber.App {
-	config := httpAppConfig()
+	config := httpAppConfig(nil)
 	config.TrustProxyConfig = fiber.TrustProxyConfig{Proxies: []string{"0.0.0.0/0", "::/0"}}
 	return fiber.New(config)
 }
 
 func TestHTTPAppConfigTrustsOnlyLoopbackProxies(t *testing.T) {
-	config := httpAppConfig()
+	config := httpAppConfig(nil)
 
 	assert.True(t, config.TrustProxy)
 	assert.Equal(t, "X-Real-IP", config.ProxyHeader)
@@ -58,7 +59,7 @@ func TestHTTPAppTrustsConfiguredProxyHeaders(t *testing.T) {
 }
 
 func TestHTTPAppIgnoresProxyHeadersFromUntrustedClients(t *testing.T) {
-	app := newHTTPApp()
+	app := newHTTPApp(nil)
 	addIdentityRoute(app)
 
 	req := httptest.NewRequest(http.MethodGet, "/identity", nil)
@@ -125,3 +126,74 @@ func TestHTTPAppSetsHSTSForTrustedHTTPSProxy(t *testing.T) {
 	defer resp.Body.Close()
 	assert.Equal(t, "max-age=31536000; includeSubDomains", resp.Header.Get("Strict-Transport-Security"))
 }
+
+// newConfiguredProxyApp trusts the test client address, 0.0.0.0, plus the given ranges
+func newConfiguredProxyApp(header string, proxies ...string) *fiber.App {
+	app := newHTTPApp(&appconfig.Config{Server: appconfig.ServerConfig{ProxyHeader: header, TrustedProxies: proxies}})
+	addIdentityRoute(app)
+	return app
+}
+
+func requestIdentityWithHeaders(t *testing.T, app *fiber.App, headers map[string]string) requestIdentity {
+	t.Helper()
+
+	req := httptest.NewRequest(http.MethodGet, "/identity", nil)
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	var identity requestIdentity
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&identity))
+	return identity
+}
+
+func TestHTTPAppIgnoresForwardedForFromUntrustedProxies(t *testing.T) {
+	app := newConfiguredProxyApp("X-Forwarded-For", "10.0.0.0/8")
+
+	identity := requestIdentityWithHeaders(t, app, map[string]string{"X-Forwarded-For": "203.0.113.5", "X-Real-IP": "203.0.113.6"})
+	assert.Equal(t, "0.0.0.0", identity.IP)
+}
+
+func TestHTTPAppSkipsSpoofedForwardedForEntriesFromTrustedProxies(t *testing.T) {
+	app := newConfiguredProxyApp("x-forwarded-for", "0.0.0.0/32", "10.0.0.0/8")
+
+	// The client prepended 198.51.100.7; its real address was appended by the outer proxy,
+	// and 10.1.2.3 is an inner trusted proxy
+	identity := requestIdentityWithHeaders(t, app, map[string]string{"X-Forwarded-For": "198.51.100.7, 203.0.113.5, 10.1.2.3"})
+	assert.Equal(t, "203.0.113.5", identity.IP)
+
+	// X-Real-IP is not the configured header
+	identity = requestIdentityWithHeaders(t, app, map[string]string{"X-Real-IP": "198.51.100.7"})
+	assert.Equal(t, "0.0.0.0", identity.IP)
+}
+
+func TestHTTPAppReadsRealIPFromConfiguredTrustedProxies(t *testing.T) {
+	app := newConfiguredProxyApp("", "0.0.0.0")
+
+	identity := requestIdentityWithHeaders(t, app, map[string]string{"X-Real-IP": "203.0.113.10", "X-Forwarded-For": "198.51.100.7"})
+	assert.Equal(t, "203.0.113.10", identity.IP)
+}
+
+func TestHTTPAppRateLimitsByResolvedClientDespiteSpoofedForwardedFor(t *testing.T) {
+	app := newHTTPApp(&appconfig.Config{Server: appconfig.ServerConfig{ProxyHeader: "X-Forwarded-For", TrustedProxies: []string{"0.0.0.0/32"}}})
+	app.Use(middleware.RateLimitWithLimiter(middleware.NewRateLimiter(1, 0)))
+	app.Get("/limited", func(c fiber.Ctx) error {
+		return c.SendStatus(fiber.StatusNoContent)
+	})
+
+	request := func(forwardedFor string) int {
+		req := httptest.NewRequest(http.MethodGet, "/limited", nil)
+		req.Header.Set("X-Forwarded-For", forwardedFor)
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	assert.Equal(t, fiber.StatusNoContent, request("198.51.100.1, 203.0.113.10"))
+	assert.Equal(t, fiber.StatusTooManyRequests, request("198.51.100.2, 203.0.113.10"))
+	assert.Equal(t, fiber.StatusNoContent, request("198.51.100.3, 203.0.113.11"))
+}
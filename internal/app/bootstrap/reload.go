@@ -0,0 +1,84 @@
+package bootstrap
+
+import (
+	"reflect"
+
+	"github.com/GT-610/tairitsu/internal/app/config"
+	"github.com/GT-610/tairitsu/internal/app/logger"
+	"github.com/GT-610/tairitsu/internal/app/middleware"
+	"go.uber.org/zap"
+)
+
+// watchConfig applies edits of config.json while the application runs. Settings that are
+// only read at startup are reported by config.Reload instead.
+func (a *App) watchConfig() {
+	watcher, err := config.Watch(a.Config)
+	if err != nil {
+		logger.Warn("configuration changes take effect after a restart", zap.Error(err))
+		return
+	}
+	a.configWatcher = watcher
+	a.unsubscribeConfig = config.Subscribe(a.applyConfigChange)
+}
+
+// applyConfigChange updates the components that read their settings once
+func (a *App) applyConfigChange(change config.Change) {
+	previous, current := change.Previous, change.Current
+
+	if !reflect.DeepEqual(previous.Logging, current.Logging) {
+		levelOnly := previous.Logging
+		levelOnly.Level = current.Logging.Level
+		if reflect.DeepEqual(levelOnly, current.Logging) {
+			if err := logger.SetLevel(config.LoggerOptionsFrom(current).Level); err != nil {
+				logger.Warn("failed to change the log level", zap.Error(err))
+			}
+		} else {
+			logger.Init(config.LoggerOptionsFrom(current))
+		}
+		logger.Info("applied logging configuration", zap.String("level", logger.Level()))
+	}
+
+	if !reflect.DeepEqual(previous.RateLimits, current.RateLimits) {
+		middleware.ApplyRateLimitSettings(current.RateLimits)
+	}
+
+	if a.Dependencies == nil {
+		return
+	}
+	if previous.Security.JWTSecret != current.Security.JWTSecret ||
+		!reflect.DeepEqual(previous.Security.PreviousJWTSecrets, current.Security.PreviousJWTSecrets) {
+		if err := a.Dependencies.Services.JWT.SetSecrets(current.Security.JWTSecret, current.Security.PreviousJWTSecrets); err != nil {
+			logger.Warn("failed to apply the JWT secrets", zap.Error(err))
+		}
+	}
+	if current.Initialized && zeroTierConnectionChanged(previous, current) {
+		if _, err := a.Dependencies.Services.Runtime.InitZTClientFromConfig(); err != nil {
+			logger.Error("failed to reconnect to the ZeroTier controller", zap.Error(err))
+		} else {
+			logger.Info("reconnected to the ZeroTier controller", zap.String("url", current.ZeroTier.URL))
+		}
+		a.Dependencies.Services.Network.Controllers().ReloadControllers(current)
+	}
+}
+
+// zeroTierConnectionChanged reports whether the ZeroTier clients must be recreated
+func zeroTierConnectionChanged(previous, current *config.Config) bool {
+	return previous.ZeroTier.URL != current.ZeroTier.URL ||
+		previous.ZeroTier.Token != current.ZeroTier.Token ||
+		previous.ZeroTier.TokenPath != current.ZeroTier.TokenPath ||
+		!reflect.DeepEqual(previous.ZeroTier.Controllers, current.ZeroTier.Controllers)
+}
+
+// stopWatchingConfig ends config.json reloads
+func (a *App) stopWatchingConfig() {
+	if a.unsubscribeConfig != nil {
+		a.unsubscribeConfig()
+		a.unsubscribeConfig = nil
+	}
+	if a.configWatcher != nil {
+		if err := a.configWatcher.Close(); err != nil {
+			logger.Warn("failed to stop watching the configuration", zap.Error(err))
+		}
+		a.configWatcher = nil
+	}
+}
@@ -0,0 +1,103 @@
+package bootstrap
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/GT-610/tairitsu/internal/app/database"
+	"github.com/gofiber/fiber/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// startSlowApp serves /slow, which blocks until release is closed, and returns the base URL.
+func startSlowApp(t *testing.T, entered chan<- struct{}, release <-chan struct{}) (*App, string) {
+	t.Helper()
+
+	db, err := database.NewDatabase(database.Config{Type: database.SQLite, Path: filepath.Join(t.TempDir(), "tairitsu.db")})
+	require.NoError(t, err)
+	require.NoError(t, db.Init())
+
+	router := newHTTPApp(nil)
+	router.Get("/slow", func(c fiber.Ctx) error {
+		entered <- struct{}{}
+		<-release
+		return c.SendString("done")
+	})
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	go func() {
+		_ = router.Listener(ln, fiber.ListenConfig{DisableStartupMessage: true})
+	}()
+
+	return &App{Database: db, Router: router}, "http://" + ln.Addr().String()
+}
+
+func TestShutdownWaitsForInFlightRequests(t *testing.T) {
+	entered := make(chan struct{}, 1)
+	release := make(chan struct{})
+	app, baseURL := startSlowApp(t, entered, release)
+
+	type result struct {
+		body string
+		err  error
+	}
+	responses := make(chan result, 1)
+	go func() {
+		resp, err := http.Get(baseURL + "/slow")
+		if err != nil {
+			responses <- result{err: err}
+			return
+		}
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		responses <- result{body: string(body), err: err}
+	}()
+	<-entered
+
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		close(release)
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	require.NoError(t, app.Shutdown(ctx))
+
+	res := <-responses
+	require.NoError(t, res.err)
+	assert.Equal(t, "done", res.body)
+	assert.Error(t, app.Database.Ping(), "database should be closed after shutdown")
+}
+
+func TestShutdownReportsExceededGracePeriod(t *testing.T) {
+	entered := make(chan struct{}, 1)
+	release := make(chan struct{})
+	defer close(release)
+	app, baseURL := startSlowApp(t, entered, release)
+
+	go func() {
+		resp, err := http.Get(baseURL + "/slow")
+		if err == nil {
+			resp.Body.Close()
+		}
+	}()
+	<-entered
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	err := app.Shutdown(ctx)
+	require.ErrorIs(t, err, ErrShutdownTimeout)
+	assert.Error(t, app.Database.Ping(), "database should be closed even when the grace period is exceeded")
+}
+
+func TestShutdownGracePeriodDefaultsToFifteenSeconds(t *testing.T) {
+	app := &App{}
+	assert.Equal(t, 15*time.Second, app.ShutdownGracePeriod())
+}
@@ -0,0 +1,102 @@
+package bootstrap
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/GT-610/tairitsu/internal/app/config"
+	"github.com/GT-610/tairitsu/internal/app/logger"
+	"github.com/gofiber/fiber/v3"
+	"github.com/gofiber/fiber/v3/middleware/adaptor"
+	"go.uber.org/zap"
+	"golang.org/x/crypto/acme/autocert"
+)
+
+// acmeChallengePrefix is where Let's Encrypt looks for HTTP-01 challenge responses
+const acmeChallengePrefix = "/.well-known/acme-challenge/"
+
+// configureTLS prepares HTTPS when it is configured. A certificate that cannot be loaded
+// fails startup instead of falling back to plain HTTP.
+func (a *App) configureTLS() error {
+	settings, err := config.TLSFrom(a.Config)
+	if err != nil || settings == nil {
+		return err
+	}
+
+	var autocertManager *autocert.Manager
+	if len(settings.AutocertHosts) > 0 {
+		autocertManager = &autocert.Manager{
+			Prompt:     autocert.AcceptTOS,
+			HostPolicy: autocert.HostWhitelist(settings.AutocertHosts...),
+			Cache:      autocert.DirCache(settings.AutocertCacheDir),
+			Email:      settings.AutocertEmail,
+		}
+		a.tlsConfig = autocertManager.TLSConfig()
+		a.tlsConfig.MinVersion = tls.VersionTLS12
+	} else {
+		certificates := a.Dependencies.Services.TLS
+		if err := certificates.LoadFiles(settings.CertFile, settings.KeyFile); err != nil {
+			return err
+		}
+		a.tlsConfig = &tls.Config{
+			MinVersion:     tls.VersionTLS12,
+			GetCertificate: certificates.GetCertificate,
+		}
+	}
+
+	if settings.RedirectPort != 0 {
+		a.redirectAddr = fmt.Sprintf(":%d", settings.RedirectPort)
+		a.redirectRouter = newRedirectApp(a.Config.Server.Port, autocertManager)
+	}
+	return nil
+}
+
+// newRedirectApp answers plain HTTP with a permanent redirect to the same URL over HTTPS,
+// except for Let's Encrypt challenges, which must be answered over plain HTTP
+func newRedirectApp(httpsPort int, autocertManager *autocert.Manager) *fiber.App {
+	router := fiber.New()
+	if autocertManager != nil {
+		challenge := adaptor.HTTPHandler(autocertManager.HTTPHandler(nil))
+		router.Get(acmeChallengePrefix+"*", challenge)
+	}
+	router.Use(func(c fiber.Ctx) error {
+		return c.Redirect().Status(fiber.StatusMovedPermanently).To(httpsURL(c.Hostname(), httpsPort, c.OriginalURL()))
+	})
+	return router
+}
+
+// httpsURL moves a request URI to HTTPS on the given port, leaving the port out when it is 443
+func httpsURL(host string, port int, requestURI string) string {
+	if hostname, _, err := net.SplitHostPort(host); err == nil {
+		host = hostname
+	}
+	host = strings.Trim(host, "[]")
+	if port != 443 {
+		host = net.JoinHostPort(host, strconv.Itoa(port))
+	} else if strings.Contains(host, ":") {
+		host = "[" + host + "]"
+	}
+	return "https://" + host + requestURI
+}
+
+// ReloadTLSCertificate reads the configured certificate files again, keeping the current
+// certificate if they cannot be loaded
+func (a *App) ReloadTLSCertificate() error {
+	if a.Dependencies == nil || a.Dependencies.Services.TLS == nil {
+		return fmt.Errorf("application is not assembled")
+	}
+	_, err := a.Dependencies.Services.TLS.Reload()
+	return err
+}
+
+func (a *App) listenRedirect(errCh chan<- error) {
+	logger.Info("starting HTTP to HTTPS redirect server", zap.String("address", a.redirectAddr))
+	go func() {
+		if err := a.redirectRouter.Listen(a.redirectAddr, fiber.ListenConfig{DisableStartupMessage: true}); err != nil {
+			errCh <- fmt.Errorf("redirect server: %w", err)
+		}
+	}()
+}
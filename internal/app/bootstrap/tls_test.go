@@ -0,0 +1,95 @@
+package bootstrap
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/GT-610/tairitsu/internal/app/assembly"
+	"github.com/GT-610/tairitsu/internal/app/config"
+	"github.com/gofiber/fiber/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHTTPSURLKeepsHostPathAndNonDefaultPort(t *testing.T) {
+	for _, tc := range []struct {
+		host string
+		port int
+		uri  string
+		want string
+	}{
+		{"zt.example.com", 443, "/networks?x=1", "https://zt.example.com/networks?x=1"},
+		{"zt.example.com:80", 8443, "/", "https://zt.example.com:8443/"},
+		{"[2001:db8::1]:80", 443, "/api/health", "https://[2001:db8::1]/api/health"},
+		{"[2001:db8::1]", 8443, "/", "https://[2001:db8::1]:8443/"},
+	} {
+		assert.Equal(t, tc.want, httpsURL(tc.host, tc.port, tc.uri), tc.host)
+	}
+}
+
+func TestRedirectAppRedirectsPermanentlyToHTTPS(t *testing.T) {
+	router := newRedirectApp(8443, nil)
+
+	req := httptest.NewRequest(http.MethodPost, "http://zt.example.com/api/auth/login?next=%2F", nil)
+	resp, err := router.Test(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, fiber.StatusMovedPermanently, resp.StatusCode)
+	assert.Equal(t, "https://zt.example.com:8443/api/auth/login?next=%2F", resp.Header.Get("Location"))
+}
+
+func TestConfigureTLSFailsOnUnloadableCertificate(t *testing.T) {
+	dir := t.TempDir()
+	cfg := &config.Config{
+		Security: config.SecurityConfig{JWTSecret: "tls-test-secret"},
+		Server: config.ServerConfig{Port: 8443, TLS: config.TLSConfig{
+			CertFile: filepath.Join(dir, "cert.pem"),
+			KeyFile:  filepath.Join(dir, "key.pem"),
+		}},
+	}
+	app := &App{Config: cfg, Dependencies: assembly.NewDependencies(cfg, nil, nil)}
+
+	err := app.configureTLS()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "cert.pem")
+	assert.Nil(t, app.tlsConfig)
+}
+
+func TestConfigureTLSWithoutSettingsServesPlainHTTP(t *testing.T) {
+	cfg := &config.Config{Security: config.SecurityConfig{JWTSecret: "tls-test-secret"}}
+	app := &App{Config: cfg, Dependencies: assembly.NewDependencies(cfg, nil, nil)}
+
+	require.NoError(t, app.configureTLS())
+	assert.Nil(t, app.tlsConfig)
+	assert.Nil(t, app.redirectRouter)
+}
+
+func TestConfigureTLSWithAutocertAnswersChallengesOverHTTP(t *testing.T) {
+	cfg := &config.Config{
+		Security: config.SecurityConfig{JWTSecret: "tls-test-secret"},
+		Server: config.ServerConfig{Port: 443, TLS: config.TLSConfig{
+			AutocertHosts:    []string{"zt.example.com"},
+			AutocertCacheDir: t.TempDir(),
+			RedirectPort:     80,
+		}},
+	}
+	app := &App{Config: cfg, Dependencies: assembly.NewDependencies(cfg, nil, nil)}
+	require.NoError(t, app.configureTLS())
+	require.NotNil(t, app.tlsConfig)
+	assert.Contains(t, app.tlsConfig.NextProtos, "acme-tls/1")
+	require.NotNil(t, app.redirectRouter)
+
+	// Unknown challenge tokens are answered by autocert itself, not redirected
+	resp, err := app.redirectRouter.Test(httptest.NewRequest(http.MethodGet, "http://zt.example.com/.well-known/acme-challenge/unknown", nil))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, fiber.StatusNotFound, resp.StatusCode)
+
+	resp, err = app.redirectRouter.Test(httptest.NewRequest(http.MethodGet, "http://zt.example.com/networks", nil))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, fiber.StatusMovedPermanently, resp.StatusCode)
+	assert.Equal(t, "https://zt.example.com/networks", resp.Header.Get("Location"))
+}
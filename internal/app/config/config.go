@@ -1,29 +1,68 @@
 package config
 
 import (
-	"crypto/rand"
-	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"net/netip"
+	"net/url"
 	"os"
+	"path/filepath"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/GT-610/tairitsu/internal/app/crypto"
 	"github.com/GT-610/tairitsu/internal/app/logger"
+	"github.com/GT-610/tairitsu/internal/app/paths"
 	"github.com/spf13/viper"
 	"go.uber.org/zap"
+	"golang.org/x/crypto/bcrypt"
 )
 
+// DatabaseType Database backend. The database package aliases this type, so there is a
+// single definition for both the config file and the connection factory.
+type DatabaseType string
+
+const (
+	DatabaseSQLite     DatabaseType = "sqlite"
+	DatabasePostgreSQL DatabaseType = "postgresql"
+	DatabaseMySQL      DatabaseType = "mysql"
+)
+
+// DefaultSQLitePath SQLite database file used when none is configured
+const DefaultSQLitePath = "data/tairitsu.db"
+
+// legacyDatabaseTypes Spellings accepted by older releases, mapped to the current names
+var legacyDatabaseTypes = map[string]DatabaseType{
+	"sqlite3":  DatabaseSQLite,
+	"postgres": DatabasePostgreSQL,
+	"pgsql":    DatabasePostgreSQL,
+	"mariadb":  DatabaseMySQL,
+}
+
 // DatabaseConfig Database configuration
 type DatabaseConfig struct {
-	Type string `json:"type"`
-	Path string `json:"path"`
-	Host string `json:"host"`
-	Port int    `json:"port"`
-	User string `json:"user"`
-	Pass string `json:"pass"` // Encrypted password
-	Name string `json:"name"`
+	Type DatabaseType `json:"type"`
+	Path string       `json:"path"`
+	Host string       `json:"host"`
+	Port int          `json:"port"`
+	User string       `json:"user"`
+	Pass string       `json:"pass"` // Encrypted password
+	Name string       `json:"name"`
+
+	MaxIdleConns           int `json:"max_idle_conns,omitempty"`            // Zero uses 10
+	MaxOpenConns           int `json:"max_open_conns,omitempty"`            // Zero uses 100; SQLite writes always use one connection
+	ConnMaxLifetimeMinutes int `json:"conn_max_lifetime_minutes,omitempty"` // Zero uses one hour
+
+	SQLiteBusyTimeoutMillis int    `json:"sqlite_busy_timeout_ms,omitempty"` // Zero uses 5000
+	SQLiteJournalMode       string `json:"sqlite_journal_mode,omitempty"`    // Empty uses WAL
+	SQLiteSynchronous       string `json:"sqlite_synchronous,omitempty"`     // Empty keeps SQLite's default, FULL
+}
+
+// SetupConfig Setup wizard progress, kept until the system is initialized
+type SetupConfig struct {
+	CompletedStep string `json:"completed_step,omitempty"` // Last completed wizard step that is not derived from the database
 }
 
 // ZeroTierConfig ZeroTier configuration
@@ -31,30 +70,242 @@ type ZeroTierConfig struct {
 	URL       string `json:"url"`
 	Token     string `json:"token"`     // Encrypted token
 	TokenPath string `json:"tokenPath"` // Token file path
+	// StatusRefreshSeconds is how often the cached controller status is refreshed; zero uses 15 seconds
+	StatusRefreshSeconds int `json:"status_refresh_seconds,omitempty"`
+	// Controllers lists further controllers managed next to the one above, which is named "default"
+	Controllers []ZeroTierControllerConfig `json:"controllers,omitempty"`
+}
+
+// DefaultControllerName names the controller configured by the setup wizard
+const DefaultControllerName = "default"
+
+// ZeroTierControllerConfig An additional ZeroTier controller
+type ZeroTierControllerConfig struct {
+	Name      string `json:"name"`
+	URL       string `json:"url"`
+	TokenPath string `json:"tokenPath"` // Token file path, read when the controller is connected
 }
 
 // ServerConfig Server configuration
 type ServerConfig struct {
 	Port int `json:"port"`
+	// ShutdownTimeoutSeconds bounds how long in-flight requests may finish on shutdown
+	ShutdownTimeoutSeconds int `json:"shutdown_timeout_seconds,omitempty"`
+	// LegacyJSONFields also emits and accepts the pre-camelCase API field names; removed in the next release
+	LegacyJSONFields bool `json:"legacy_json_fields,omitempty"`
+	// Frontend serves the web interface from the backend instead of a separate web server
+	Frontend FrontendConfig `json:"frontend,omitempty"`
+	// TLS serves HTTPS on Port instead of plain HTTP
+	TLS TLSConfig `json:"tls,omitempty"`
+	// TrustedProxies are addresses or CIDR ranges whose proxy headers are honored; loopback is always trusted
+	TrustedProxies []string `json:"trusted_proxies,omitempty"`
+	// ProxyHeader carries the client address from trusted proxies: X-Real-IP (default) or X-Forwarded-For
+	ProxyHeader string `json:"proxy_header,omitempty"`
+}
+
+// TLSConfig HTTPS with a certificate from files or from Let's Encrypt; empty serves plain HTTP
+type TLSConfig struct {
+	CertFile string `json:"cert_file,omitempty"`
+	KeyFile  string `json:"key_file,omitempty"`
+	// AutocertHosts obtains certificates from Let's Encrypt for these host names instead of files
+	AutocertHosts    []string `json:"autocert_hosts,omitempty"`
+	AutocertCacheDir string   `json:"autocert_cache_dir,omitempty"` // Defaults to ./data/autocert
+	AutocertEmail    string   `json:"autocert_email,omitempty"`
+	// RedirectPort runs a plain HTTP listener that redirects to HTTPS; zero disables it.
+	// Let's Encrypt HTTP-01 challenges are answered there too.
+	RedirectPort int `json:"redirect_port,omitempty"`
+}
+
+// FrontendConfig Web interface served for every path outside /api
+type FrontendConfig struct {
+	// Directory holds a frontend build (web/dist); empty serves the build bundled into the binary, if any
+	Directory string `json:"directory,omitempty"`
 }
 
 // SecurityConfig Security configuration
 type SecurityConfig struct {
-	JWTSecret string `json:"jwt_secret"`
+	JWTSecret      string               `json:"jwt_secret"`
+	PasswordPolicy PasswordPolicyConfig `json:"password_policy"`
+	// PreviousJWTSecrets still verify tokens signed before a rotation, each until its expiry
+	PreviousJWTSecrets     []RetiredJWTSecret `json:"previous_jwt_secrets,omitempty"`
+	PreviousJWTSecretHours int                `json:"previous_jwt_secret_hours,omitempty"` // How long a replaced secret is accepted; zero uses 24 hours
+	PasswordHashCost       int                `json:"password_hash_cost,omitempty"`        // bcrypt cost of new password hashes; zero uses bcrypt's default of 10
+}
+
+// RetiredJWTSecret A replaced JWT secret that verifies tokens until ExpiresAt
+type RetiredJWTSecret struct {
+	Secret    string    `json:"secret"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// PasswordPolicyConfig Password strength rules for local accounts; zero values use the defaults
+type PasswordPolicyConfig struct {
+	MinLength      int   `json:"min_length,omitempty"`
+	RequireUpper   bool  `json:"require_upper,omitempty"`
+	RequireLower   bool  `json:"require_lower,omitempty"`
+	RequireDigit   bool  `json:"require_digit,omitempty"`
+	RequireSymbol  bool  `json:"require_symbol,omitempty"`
+	RejectUsername *bool `json:"reject_username,omitempty"` // Defaults to true
 }
 
 type RegistrationConfig struct {
 	AllowPublicRegistration *bool `json:"allow_public_registration,omitempty"`
 }
 
+// MemberHistoryConfig Member online/offline history configuration
+type MemberHistoryConfig struct {
+	PollIntervalSeconds int `json:"poll_interval_seconds,omitempty"` // Zero uses the default of 60 seconds
+}
+
+// MemberEventsConfig Live member event stream configuration
+type MemberEventsConfig struct {
+	PollIntervalSeconds int `json:"poll_interval_seconds,omitempty"` // Base of the adaptive interval; zero uses the default of 5 seconds
+}
+
+// ApprovalsConfig Pending member approval configuration
+type ApprovalsConfig struct {
+	WebhookURL string `json:"webhook_url,omitempty"` // Receives a JSON payload for each newly pending member; empty sends nothing
+}
+
+// StatusPageConfig Public status page configuration
+type StatusPageConfig struct {
+	Enabled  bool   `json:"enabled,omitempty"`
+	Title    string `json:"title,omitempty"`
+	LogoText string `json:"logo_text,omitempty"`
+}
+
+// ControllerTraceConfig Forwarded controller trace configuration
+type ControllerTraceConfig struct {
+	RetentionDays int `json:"retention_days,omitempty"` // Zero uses the default of 7 days
+}
+
+// AuditConfig Audit log configuration
+type AuditConfig struct {
+	RetentionDays int `json:"retention_days,omitempty"` // Zero keeps audit entries forever
+}
+
+// MaintenanceConfig Database maintenance configuration
+type MaintenanceConfig struct {
+	CompactIntervalHours         int `json:"compact_interval_hours,omitempty"`          // Zero uses the default of one week
+	CompactFreePercent           int `json:"compact_free_percent,omitempty"`            // Share of free pages that triggers compaction; zero uses 20
+	InactivityCheckIntervalHours int `json:"inactivity_check_interval_hours,omitempty"` // How often inactivity policies run; zero uses 6 hours
+}
+
+// BackupConfig Controller-wide backup configuration
+type BackupConfig struct {
+	Directory     string `json:"directory,omitempty"`      // Defaults to backups in the data directory
+	IntervalHours int    `json:"interval_hours,omitempty"` // Zero disables scheduled backups
+	Retention     int    `json:"retention,omitempty"`      // Number of backups kept; zero keeps 7
+}
+
+// LogShippingConfig Forwarding of audit and authentication events to a syslog server or file
+type LogShippingConfig struct {
+	Target    string `json:"target,omitempty"`     // udp://host:port, tcp://host:port or file:PATH; empty disables shipping
+	Format    string `json:"format,omitempty"`     // rfc5424 or json; empty uses rfc5424 for syslog targets and json for files
+	QueueSize int    `json:"queue_size,omitempty"` // Events buffered while the target is slow or down; zero uses 1024
+}
+
+// SystemStatsConfig Resource usage history configuration
+type SystemStatsConfig struct {
+	SampleIntervalSeconds int `json:"sample_interval_seconds,omitempty"` // Zero uses the default of 30 seconds
+}
+
+// OperationsConfig Long-running operation configuration
+type OperationsConfig struct {
+	Concurrency int `json:"concurrency,omitempty"` // Operations running at once; zero uses the default of 4
+}
+
+// PlanetConfig Planet generator configuration
+type PlanetConfig struct {
+	HistoryLimit int `json:"history_limit,omitempty"` // Number of generated planets kept; zero keeps 20
+}
+
+// LoggingConfig Log output configuration; zero values use the defaults
+type LoggingConfig struct {
+	Level      string `json:"level,omitempty"`     // debug, info, warn or error; defaults to info
+	FilePath   string `json:"file_path,omitempty"` // Defaults to tairitsu.log in paths.LogDir
+	MaxSizeMB  int    `json:"max_size_mb,omitempty"`
+	MaxBackups int    `json:"max_backups,omitempty"`
+	MaxAgeDays int    `json:"max_age_days,omitempty"`
+	Console    *bool  `json:"console,omitempty"`  // Defaults to on outside production
+	Encoding   string `json:"encoding,omitempty"` // json or console; empty writes JSON files and colored stdout
+}
+
+// OIDCConfig Sign-in through an external OpenID Connect provider; local password login stays
+// available unless DisablePasswordLogin is set
+type OIDCConfig struct {
+	Enabled      bool     `json:"enabled,omitempty"`
+	IssuerURL    string   `json:"issuer_url,omitempty"` // Discovery is read from <issuer_url>/.well-known/openid-configuration
+	ClientID     string   `json:"client_id,omitempty"`
+	ClientSecret string   `json:"client_secret,omitempty"` // May be stored encrypted like the database password
+	RedirectURL  string   `json:"redirect_url,omitempty"`  // Public URL of /api/auth/oidc/callback
+	Scopes       []string `json:"scopes,omitempty"`        // Defaults to openid, email and profile
+	// RoleClaim names the ID token claim holding the user's roles or groups; dots reach nested
+	// claims such as realm_access.roles. Empty leaves roles to Tairitsu administrators.
+	RoleClaim  string   `json:"role_claim,omitempty"`
+	AdminRoles []string `json:"admin_roles,omitempty"` // Claim values that grant the admin role; defaults to admin
+	// PostLoginRedirect is where the browser lands after the callback, with the token or error
+	// code in the URL fragment; defaults to /login
+	PostLoginRedirect    string `json:"post_login_redirect,omitempty"`
+	DisablePasswordLogin bool   `json:"disable_password_login,omitempty"`
+}
+
+// CaptchaConfig Challenge required on registration, and on password sign-in after repeated
+// failures, verified with the provider before the password is checked
+type CaptchaConfig struct {
+	Provider      string `json:"provider,omitempty"`       // hcaptcha or turnstile; empty disables the challenge
+	SiteKey       string `json:"site_key,omitempty"`       // Public key the login page renders the widget with
+	Secret        string `json:"secret,omitempty"`         // May be stored encrypted like the database password
+	VerifyURL     string `json:"verify_url,omitempty"`     // Overrides the provider's siteverify endpoint
+	LoginFailures int    `json:"login_failures,omitempty"` // Failed sign-ins of a client or username before the challenge is required; zero uses 3
+}
+
+// RateLimitConfig Limits of one rate limit policy; zero values keep the built-in limits
+type RateLimitConfig struct {
+	Capacity   int `json:"capacity,omitempty"`    // Requests a client may burst
+	RefillRate int `json:"refill_rate,omitempty"` // Requests per second a client regains; per minute for login and login_username
+}
+
+// ChecklistConfig Onboarding checklist state
+type ChecklistConfig struct {
+	Dismissed []string `json:"dismissed,omitempty"`
+}
+
 // Config Application configuration structure
 type Config struct {
-	Initialized  bool               `json:"initialized"` // Initialization status flag
-	Database     DatabaseConfig     `json:"database"`    // Database configuration
-	ZeroTier     ZeroTierConfig     `json:"zerotier"`    // ZeroTier configuration
-	Server       ServerConfig       `json:"server"`      // Server configuration
-	Security     SecurityConfig     `json:"security"`    // Security configuration
-	Registration RegistrationConfig `json:"registration"`
+	Initialized     bool                  `json:"initialized"` // Initialization status flag
+	Setup           SetupConfig           `json:"setup"`       // Setup wizard progress
+	Database        DatabaseConfig        `json:"database"`    // Database configuration
+	ZeroTier        ZeroTierConfig        `json:"zerotier"`    // ZeroTier configuration
+	Server          ServerConfig          `json:"server"`      // Server configuration
+	Security        SecurityConfig        `json:"security"`    // Security configuration
+	Registration    RegistrationConfig    `json:"registration"`
+	Checklist       ChecklistConfig       `json:"checklist"`
+	Audit           AuditConfig           `json:"audit"`
+	StatusPage      StatusPageConfig      `json:"status_page"`
+	MemberHistory   MemberHistoryConfig   `json:"member_history"`
+	MemberEvents    MemberEventsConfig    `json:"member_events"`
+	Approvals       ApprovalsConfig       `json:"approvals"`
+	ControllerTrace ControllerTraceConfig `json:"controller_trace"`
+	Logging         LoggingConfig         `json:"logging"`
+	Maintenance     MaintenanceConfig     `json:"maintenance"`
+	Backup          BackupConfig          `json:"backup"`
+	Planet          PlanetConfig          `json:"planet"`
+	SystemStats     SystemStatsConfig     `json:"system_stats"`
+	Operations      OperationsConfig      `json:"operations"`
+	OIDC            OIDCConfig            `json:"oidc"`
+	Captcha         CaptchaConfig         `json:"captcha,omitempty"`
+	LogShipping     LogShippingConfig     `json:"log_shipping,omitempty"`
+	// RateLimits overrides rate limit policies by name: default, read, auth, login, login_username,
+	// status_page or trace_ingest
+	RateLimits map[string]RateLimitConfig `json:"rate_limits,omitempty"`
+	DemoMode   bool                       `json:"-"` // Runtime-only flag; demo configurations are never persisted
+	// SecretsRegenerated is set when LoadConfigWithOptions replaced a weak JWT secret at startup
+	SecretsRegenerated bool `json:"-"`
+
+	encryptionKey         string // Encrypts stored credentials; read from the key file, never from config.json
+	encryptionKeyPath     string
+	encryptionKeyExternal bool // The key file was named by EncryptionKeyFileEnv
 }
 
 // AppConfig Global configuration instance
@@ -64,12 +315,40 @@ var AppConfig *Config
 var tempSettings = make(map[string]string)
 var tempSettingsMutex sync.RWMutex
 
-const configFilePath = "./data/config.json"
+const (
+	defaultShutdownGracePeriod      = 15 * time.Second
+	defaultMemberStatusPollInterval = 60 * time.Second
+	defaultMemberEventPollInterval  = 5 * time.Second
+	defaultZTStatusRefreshInterval  = 15 * time.Second
+	defaultCompactInterval          = 7 * 24 * time.Hour
+	defaultInactivityCheckInterval  = 6 * time.Hour
+	defaultSystemStatsInterval      = 30 * time.Second
+	defaultCompactFreePercent       = 20
+	defaultBackupDirectory          = "./data/backups"
+	defaultAutocertCacheDir         = "./data/autocert"
+	defaultBackupRetention          = 7
+	defaultPlanetHistoryLimit       = 20
+	defaultOperationConcurrency     = 4
+)
+
+// Bounds of security.password_hash_cost. bcrypt takes twice as long for each step, so 16
+// already needs seconds per login on small hardware.
+const (
+	MinPasswordHashCost = bcrypt.MinCost
+	MaxPasswordHashCost = 16
+)
 
 // LoadConfig Load configuration (from config.json)
 func LoadConfig() (*Config, error) {
+	return LoadConfigWithOptions(LoadOptions{})
+}
+
+// LoadConfigWithOptions Load configuration (from config.json), refusing initialized
+// configurations with a missing or weak JWT secret unless opts allow regenerating it, and
+// initialized configurations that fail Validate
+func LoadConfigWithOptions(opts LoadOptions) (*Config, error) {
 	// Ensure data directory exists
-	dataDir := "./data"
+	dataDir := paths.DataDir()
 	if _, err := os.Stat(dataDir); os.IsNotExist(err) {
 		if err := os.MkdirAll(dataDir, 0755); err != nil {
 			return nil, fmt.Errorf("failed to create data directory: %w", err)
@@ -79,13 +358,24 @@ func LoadConfig() (*Config, error) {
 	// First try to load from config.json
 	cfg, err := loadConfigFromJSON()
 	if err == nil {
+		if err := loadEncryptionKey(cfg); err != nil {
+			return nil, err
+		}
+		if err := enforceSecretStrength(cfg, opts); err != nil {
+			return nil, err
+		}
 		generated, err := ensureJWTSecret(cfg)
 		if err != nil {
 			return nil, fmt.Errorf("failed to generate JWT secret: %w", err)
 		}
-		if generated {
+		if err := checkConfig(cfg); err != nil {
+			return nil, err
+		}
+		// Credentials saved before the key file existed are encrypted with the JWT secret
+		migrated := migrateCredentialsToEncryptionKey(cfg)
+		if generated || migrated {
 			if err := SaveConfig(cfg); err != nil {
-				return nil, fmt.Errorf("failed to save generated JWT secret: %w", err)
+				return nil, fmt.Errorf("failed to save configuration: %w", err)
 			}
 		}
 		AppConfig = cfg
@@ -94,20 +384,15 @@ func LoadConfig() (*Config, error) {
 
 	// If config.json doesn't exist or reading fails, create default configuration
 	cfg = createDefaultConfig()
+	if err := loadEncryptionKey(cfg); err != nil {
+		return nil, err
+	}
 
 	// Try to load partial configuration from .env file or environment variables
 	loadEnvConfig(cfg)
-	generated, err := ensureJWTSecret(cfg)
-	if err != nil {
+	if _, err := ensureJWTSecret(cfg); err != nil {
 		return nil, fmt.Errorf("failed to generate JWT secret: %w", err)
 	}
-	if generated && cfg.ZeroTier.TokenPath != "" && cfg.ZeroTier.Token == "" {
-		// Loading an environment-provided token path may have failed before a
-		// generated encryption key was available. Retry after creating the key.
-		if err := LoadTokenFromPathInto(cfg, cfg.ZeroTier.TokenPath); err != nil {
-			logger.Warn("failed to load environment-provided ZeroTier token after generating JWT secret; continuing without token", zap.Error(err))
-		}
-	}
 
 	// Save default configuration to config.json
 	if err := SaveConfig(cfg); err != nil {
@@ -121,27 +406,60 @@ func LoadConfig() (*Config, error) {
 // loadConfigFromJSON Load configuration from JSON file
 func loadConfigFromJSON() (*Config, error) {
 	// Check if configuration file exists
-	if _, err := os.Stat(configFilePath); os.IsNotExist(err) {
+	configFile := paths.ConfigFile()
+	if _, err := os.Stat(configFile); os.IsNotExist(err) {
 		return nil, fmt.Errorf("configuration file not found")
 	}
 
 	// Read configuration file
-	data, err := os.ReadFile(configFilePath)
+	data, err := os.ReadFile(configFile)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read configuration file: %w", err)
 	}
 
-	// Parse JSON
+	cfg, err := parseConfig(data)
+	if err != nil {
+		return nil, err
+	}
+	rememberConfigFile(data)
+	return cfg, nil
+}
+
+// parseConfig parses the content of config.json
+func parseConfig(data []byte) (*Config, error) {
 	cfg := &Config{}
 	if err := json.Unmarshal(data, cfg); err != nil {
 		return nil, fmt.Errorf("failed to parse configuration file: %w", err)
 	}
-
+	migrateDatabaseConfig(&cfg.Database)
 	return cfg, nil
 }
 
+// migrateDatabaseConfig Rewrite database settings saved by older releases: legacy or
+// differently cased type names, and SQLite configurations saved without a path
+func migrateDatabaseConfig(db *DatabaseConfig) {
+	name := strings.ToLower(strings.TrimSpace(string(db.Type)))
+	if current, ok := legacyDatabaseTypes[name]; ok {
+		name = string(current)
+	}
+	if name != string(db.Type) {
+		logger.Info("migrated database type from an older configuration", zap.String("from", string(db.Type)), zap.String("to", name))
+		db.Type = DatabaseType(name)
+	}
+	if db.Type == DatabaseSQLite && db.Path == "" {
+		db.Path = DefaultSQLitePath
+	}
+}
+
 // SaveConfig Save configuration to JSON file
 func SaveConfig(cfg *Config) error {
+	if cfg != nil && cfg.DemoMode {
+		return nil
+	}
+	if err := checkConfig(cfg); err != nil {
+		return err
+	}
+
 	// Serialize configuration
 	data, err := json.MarshalIndent(cfg, "", "  ")
 	if err != nil {
@@ -149,15 +467,26 @@ func SaveConfig(cfg *Config) error {
 	}
 
 	// Ensure data directory exists
-	dataDir := "./data"
+	dataDir := paths.DataDir()
 	if _, err := os.Stat(dataDir); os.IsNotExist(err) {
 		if err := os.MkdirAll(dataDir, 0755); err != nil {
 			return fmt.Errorf("failed to create data directory: %w", err)
 		}
 	}
 
-	// Write to file with appropriate permissions (owner read/write only)
-	return os.WriteFile(configFilePath, data, 0600)
+	// Replace the file in one step, so a reader never sees it half-written. The watcher skips
+	// its own writes by their content.
+	rememberConfigFile(data)
+	configFile := paths.ConfigFile()
+	tmp := configFile + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("failed to write configuration: %w", err)
+	}
+	if err := os.Rename(tmp, configFile); err != nil {
+		_ = os.Remove(tmp)
+		return fmt.Errorf("failed to write configuration: %w", err)
+	}
+	return nil
 }
 
 // createDefaultConfig Create default configuration
@@ -178,6 +507,36 @@ func createDefaultConfig() *Config {
 	}
 }
 
+// NewDemoConfig Create an in-memory initialized configuration for demo mode
+func NewDemoConfig(dataDir string, ztURL string, ztToken string) (*Config, error) {
+	cfg := createDefaultConfig()
+	cfg.Initialized = true
+	cfg.DemoMode = true
+	cfg.Database = DatabaseConfig{
+		Type: DatabaseSQLite,
+		Path: filepath.Join(dataDir, "tairitsu.db"),
+	}
+	cfg.Backup.Directory = filepath.Join(dataDir, "backups")
+	cfg.ZeroTier.URL = ztURL
+	cfg.Registration.AllowPublicRegistration = boolPtr(false)
+
+	if _, err := ensureJWTSecret(cfg); err != nil {
+		return nil, fmt.Errorf("failed to generate JWT secret: %w", err)
+	}
+	// Demo configurations are never persisted, so their key only lives in memory
+	key, err := crypto.GenerateSecret(crypto.SecretLength)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate encryption key: %w", err)
+	}
+	cfg.encryptionKey = key
+	if err := SetZTTokenOn(cfg, ztToken); err != nil {
+		return nil, fmt.Errorf("failed to store ZeroTier token: %w", err)
+	}
+
+	AppConfig = cfg
+	return cfg, nil
+}
+
 func ensureJWTSecret(cfg *Config) (bool, error) {
 	if cfg.Security.JWTSecret != "" {
 		return false, nil
@@ -192,11 +551,11 @@ func ensureJWTSecret(cfg *Config) (bool, error) {
 		return false, fmt.Errorf("failed to recover database password: %w", err)
 	}
 
-	secretBytes := make([]byte, 32)
-	if _, err := rand.Read(secretBytes); err != nil {
+	secret, err := crypto.GenerateSecret(crypto.SecretLength)
+	if err != nil {
 		return false, err
 	}
-	cfg.Security.JWTSecret = base64.URLEncoding.EncodeToString(secretBytes)
+	cfg.Security.JWTSecret = secret
 	if token != "" {
 		if err := SetZTTokenOn(cfg, token); err != nil {
 			return false, err
@@ -307,23 +666,57 @@ func SetZTConfigOn(cfg *Config, url, tokenPath string) error {
 	return SaveConfig(cfg)
 }
 
+// SetZTInlineConfigOn stores a controller URL with a token given directly, for controllers
+// whose token file Tairitsu cannot read; the token path is cleared
+func SetZTInlineConfigOn(cfg *Config, url, token string) error {
+	if cfg == nil {
+		return fmt.Errorf("configuration not loaded")
+	}
+	token = strings.TrimSpace(token)
+	if token == "" {
+		return fmt.Errorf("token is empty")
+	}
+
+	cfg.ZeroTier.URL = url
+	cfg.ZeroTier.TokenPath = ""
+	if err := SetZTTokenOn(cfg, token); err != nil {
+		return err
+	}
+
+	return SaveConfig(cfg)
+}
+
+// ZeroTierConfigured reports whether cfg names a controller and a way to authenticate to it
+func ZeroTierConfigured(cfg *Config) bool {
+	return cfg != nil && cfg.ZeroTier.URL != "" && (cfg.ZeroTier.TokenPath != "" || cfg.ZeroTier.Token != "")
+}
+
 func LoadTokenFromPathInto(cfg *Config, path string) error {
 	if cfg == nil {
 		return fmt.Errorf("configuration not loaded")
 	}
 
-	tokenBytes, err := os.ReadFile(path)
+	token, err := ReadTokenFile(path)
+	if err != nil {
+		return err
+	}
+	return SetZTTokenOn(cfg, token)
+}
+
+// ReadTokenFile reads a ZeroTier authtoken.secret file; a relative path is interpreted in
+// the data directory
+func ReadTokenFile(path string) (string, error) {
+	tokenBytes, err := os.ReadFile(paths.Resolve(path))
 	if err != nil {
-		return fmt.Errorf("failed to read token file: %w", err)
+		return "", fmt.Errorf("failed to read token file: %w", err)
 	}
 
-	// Successfully read file, remove newline and set token
+	// Successfully read file, remove newline
 	token := strings.TrimSpace(string(tokenBytes))
 	if token == "" {
-		return fmt.Errorf("token file is empty")
+		return "", fmt.Errorf("token file is empty")
 	}
-
-	return SetZTTokenOn(cfg, token)
+	return token, nil
 }
 
 func GetDatabasePasswordFrom(cfg *Config) (string, error) {
@@ -367,8 +760,7 @@ func encryptSensitiveDataWithConfig(cfg *Config, data string) (string, error) {
 		return "", fmt.Errorf("configuration not loaded")
 	}
 
-	key := cfg.Security.JWTSecret
-	encrypted, err := crypto.Encrypt(data, key)
+	encrypted, err := crypto.Encrypt(data, encryptionKeyOf(cfg))
 	if err != nil {
 		return "", err
 	}
@@ -376,8 +768,9 @@ func encryptSensitiveDataWithConfig(cfg *Config, data string) (string, error) {
 	return "encrypted:" + encrypted, nil
 }
 
-// decryptSensitiveDataWithConfig decrypts a value, transparently migrating
-// legacy zero-padding ciphertext to argon2id on first access.
+// decryptSensitiveDataWithConfig decrypts a value, transparently migrating legacy
+// zero-padding ciphertext to argon2id and ciphertext encrypted with the JWT secret to
+// the encryption key on first access.
 // Returns the plaintext and a re-encrypted value if migration was needed.
 func decryptSensitiveDataWithConfig(cfg *Config, data string) (plaintext string, reEncrypted string, err error) {
 	if cfg == nil {
@@ -389,8 +782,13 @@ func decryptSensitiveDataWithConfig(cfg *Config, data string) (plaintext string,
 	}
 
 	encryptedData := strings.TrimPrefix(data, "encrypted:")
-	key := cfg.Security.JWTSecret
+	key := encryptionKeyOf(cfg)
 	plaintext, needsReEncrypt, err := crypto.DecryptWithLegacy(encryptedData, key)
+	if err != nil && key != cfg.Security.JWTSecret {
+		if legacyPlaintext, _, jwtErr := crypto.DecryptWithLegacy(encryptedData, cfg.Security.JWTSecret); jwtErr == nil {
+			plaintext, needsReEncrypt, err = legacyPlaintext, true, nil
+		}
+	}
 	if err != nil {
 		return "", "", err
 	}
@@ -423,6 +821,102 @@ func SetAllowPublicRegistrationOn(cfg *Config, enabled bool) error {
 	return SaveConfig(cfg)
 }
 
+// SetPasswordPolicyOn replaces the password policy and persists the configuration
+func SetPasswordPolicyOn(cfg *Config, policy PasswordPolicyConfig) error {
+	if cfg == nil {
+		return fmt.Errorf("configuration not loaded")
+	}
+
+	cfg.Security.PasswordPolicy = policy
+	return SaveConfig(cfg)
+}
+
+// ChecklistItemDismissed reports whether an onboarding checklist item has been dismissed
+func ChecklistItemDismissed(cfg *Config, itemID string) bool {
+	if cfg == nil {
+		return false
+	}
+	for _, id := range cfg.Checklist.Dismissed {
+		if id == itemID {
+			return true
+		}
+	}
+	return false
+}
+
+// SetChecklistItemDismissedOn persists the dismissal state of an onboarding checklist item
+func SetChecklistItemDismissedOn(cfg *Config, itemID string, dismissed bool) error {
+	if cfg == nil {
+		return fmt.Errorf("configuration not loaded")
+	}
+
+	remaining := make([]string, 0, len(cfg.Checklist.Dismissed)+1)
+	for _, id := range cfg.Checklist.Dismissed {
+		if id != itemID {
+			remaining = append(remaining, id)
+		}
+	}
+	if dismissed {
+		remaining = append(remaining, itemID)
+	}
+	cfg.Checklist.Dismissed = remaining
+	return SaveConfig(cfg)
+}
+
+// AppStateSettings are the Tairitsu preferences carried by an app state archive. Connection
+// settings (database, controller, listen port) belong to the host, and their secrets are
+// encrypted with a key that stays on the host, so none of them are exported.
+type AppStateSettings struct {
+	LegacyJSONFields       bool                  `json:"legacy_json_fields,omitempty"`
+	ShutdownTimeoutSeconds int                   `json:"shutdown_timeout_seconds,omitempty"`
+	PasswordPolicy         PasswordPolicyConfig  `json:"password_policy"`
+	Registration           RegistrationConfig    `json:"registration"`
+	Checklist              ChecklistConfig       `json:"checklist"`
+	Audit                  AuditConfig           `json:"audit"`
+	StatusPage             StatusPageConfig      `json:"status_page"`
+	MemberHistory          MemberHistoryConfig   `json:"member_history"`
+	MemberEvents           MemberEventsConfig    `json:"member_events"`
+	ControllerTrace        ControllerTraceConfig `json:"controller_trace"`
+}
+
+// AppStateSettingsFrom extracts the exportable preferences of a configuration
+func AppStateSettingsFrom(cfg *Config) AppStateSettings {
+	if cfg == nil {
+		return AppStateSettings{}
+	}
+	return AppStateSettings{
+		LegacyJSONFields:       cfg.Server.LegacyJSONFields,
+		ShutdownTimeoutSeconds: cfg.Server.ShutdownTimeoutSeconds,
+		PasswordPolicy:         cfg.Security.PasswordPolicy,
+		Registration:           cfg.Registration,
+		Checklist:              cfg.Checklist,
+		Audit:                  cfg.Audit,
+		StatusPage:             cfg.StatusPage,
+		MemberHistory:          cfg.MemberHistory,
+		MemberEvents:           cfg.MemberEvents,
+		ControllerTrace:        cfg.ControllerTrace,
+	}
+}
+
+// SetAppStateSettingsOn replaces the exportable preferences and persists the configuration
+func SetAppStateSettingsOn(cfg *Config, settings AppStateSettings) error {
+	if cfg == nil {
+		return fmt.Errorf("configuration not loaded")
+	}
+
+	cfg.Server.LegacyJSONFields = settings.LegacyJSONFields
+	cfg.Server.ShutdownTimeoutSeconds = settings.ShutdownTimeoutSeconds
+	cfg.Security.PasswordPolicy = settings.PasswordPolicy
+	cfg.Registration = settings.Registration
+	cfg.Checklist = settings.Checklist
+	cfg.Audit = settings.Audit
+	cfg.StatusPage = settings.StatusPage
+	cfg.MemberHistory = settings.MemberHistory
+	cfg.MemberEvents = settings.MemberEvents
+	cfg.ControllerTrace = settings.ControllerTrace
+	return SaveConfig(cfg)
+}
+
 func boolPtr(value bool) *bool {
 	return &value
 }
@@ -434,6 +928,379 @@ func ServerAddressFrom(cfg *Config) string {
 	return fmt.Sprintf(":%d", cfg.Server.Port)
 }
 
+// Proxy headers that may carry the client address
+const (
+	ProxyHeaderRealIP       = "X-Real-IP"
+	ProxyHeaderForwardedFor = "X-Forwarded-For"
+)
+
+// ProxySettingsFrom returns the trusted proxy ranges and the header carrying the client address.
+// Entries that are neither an address nor a CIDR range are left out and reported in the error.
+func ProxySettingsFrom(cfg *Config) ([]string, string, error) {
+	if cfg == nil {
+		return nil, ProxyHeaderRealIP, nil
+	}
+
+	var errs []error
+	header := ProxyHeaderRealIP
+	switch {
+	case cfg.Server.ProxyHeader == "", strings.EqualFold(cfg.Server.ProxyHeader, ProxyHeaderRealIP):
+	case strings.EqualFold(cfg.Server.ProxyHeader, ProxyHeaderForwardedFor):
+		header = ProxyHeaderForwardedFor
+	default:
+		errs = append(errs, fmt.Errorf("server.proxy_header must be %s or %s, not %q", ProxyHeaderRealIP, ProxyHeaderForwardedFor, cfg.Server.ProxyHeader))
+	}
+
+	proxies := make([]string, 0, len(cfg.Server.TrustedProxies))
+	for _, entry := range cfg.Server.TrustedProxies {
+		entry = strings.TrimSpace(entry)
+		if _, err := netip.ParsePrefix(entry); err == nil {
+			proxies = append(proxies, entry)
+		} else if _, err := netip.ParseAddr(entry); err == nil {
+			proxies = append(proxies, entry)
+		} else {
+			errs = append(errs, fmt.Errorf("server.trusted_proxies: %q is neither an IP address nor a CIDR range", entry))
+		}
+	}
+	return proxies, header, errors.Join(errs...)
+}
+
+// TLSFrom returns the HTTPS settings, rejecting incomplete or conflicting ones; nil means plain HTTP
+func TLSFrom(cfg *Config) (*TLSConfig, error) {
+	if cfg == nil {
+		return nil, nil
+	}
+	settings := cfg.Server.TLS
+	hasFiles := settings.CertFile != "" || settings.KeyFile != ""
+	switch {
+	case hasFiles && len(settings.AutocertHosts) > 0:
+		return nil, fmt.Errorf("server.tls: configure either cert_file and key_file or autocert_hosts, not both")
+	case hasFiles && (settings.CertFile == "" || settings.KeyFile == ""):
+		return nil, fmt.Errorf("server.tls: cert_file and key_file must be set together")
+	case !hasFiles && len(settings.AutocertHosts) == 0:
+		if settings.RedirectPort != 0 {
+			return nil, fmt.Errorf("server.tls: redirect_port requires a certificate")
+		}
+		return nil, nil
+	}
+	if settings.RedirectPort < 0 || settings.RedirectPort > 65535 || (settings.RedirectPort != 0 && settings.RedirectPort == cfg.Server.Port) {
+		return nil, fmt.Errorf("server.tls: redirect_port %d is invalid or equals the server port", settings.RedirectPort)
+	}
+	if settings.AutocertCacheDir == "" {
+		settings.AutocertCacheDir = defaultAutocertCacheDir
+	}
+	settings.CertFile = paths.Resolve(settings.CertFile)
+	settings.KeyFile = paths.Resolve(settings.KeyFile)
+	settings.AutocertCacheDir = paths.Resolve(settings.AutocertCacheDir)
+	return &settings, nil
+}
+
+// Log shipping formats
+const (
+	LogShippingFormatRFC5424 = "rfc5424"
+	LogShippingFormatJSON    = "json"
+
+	defaultLogShippingQueueSize = 1024
+)
+
+// LogShippingFrom returns the log shipping settings with defaults applied and a file target
+// resolved to an absolute path; nil means shipping is disabled
+func LogShippingFrom(cfg *Config) (*LogShippingConfig, error) {
+	if cfg == nil || strings.TrimSpace(cfg.LogShipping.Target) == "" {
+		return nil, nil
+	}
+	settings := cfg.LogShipping
+	target, err := url.Parse(strings.TrimSpace(settings.Target))
+	if err != nil {
+		return nil, fmt.Errorf("log_shipping.target is not a valid URL: %v", err)
+	}
+	switch target.Scheme {
+	case "udp", "tcp":
+		if target.Hostname() == "" || target.Port() == "" {
+			return nil, fmt.Errorf("log_shipping.target must name a host and port, as in %s://siem.example.com:514", target.Scheme)
+		}
+		settings.Target = target.Scheme + "://" + target.Host
+		if settings.Format == "" {
+			settings.Format = LogShippingFormatRFC5424
+		}
+	case "file":
+		path := target.Opaque
+		if path == "" {
+			path = target.Path
+		}
+		if path == "" {
+			return nil, fmt.Errorf("log_shipping.target must name a file, as in file:logs/events.jsonl")
+		}
+		settings.Target = "file:" + paths.Resolve(path)
+		if settings.Format == "" {
+			settings.Format = LogShippingFormatJSON
+		}
+	default:
+		return nil, fmt.Errorf("log_shipping.target must start with udp://, tcp:// or file:, got %q", settings.Target)
+	}
+	settings.Format = strings.ToLower(settings.Format)
+	if settings.Format != LogShippingFormatRFC5424 && settings.Format != LogShippingFormatJSON {
+		return nil, fmt.Errorf("log_shipping.format must be rfc5424 or json, got %q", settings.Format)
+	}
+	if settings.QueueSize < 0 {
+		return nil, fmt.Errorf("log_shipping.queue_size must not be negative, got %d", settings.QueueSize)
+	}
+	if settings.QueueSize == 0 {
+		settings.QueueSize = defaultLogShippingQueueSize
+	}
+	return &settings, nil
+}
+
+// ShutdownGracePeriodFrom returns the configured shutdown grace period, defaulting to 15 seconds
+func ShutdownGracePeriodFrom(cfg *Config) time.Duration {
+	if cfg == nil || cfg.Server.ShutdownTimeoutSeconds <= 0 {
+		return defaultShutdownGracePeriod
+	}
+	return time.Duration(cfg.Server.ShutdownTimeoutSeconds) * time.Second
+}
+
+// LoggerOptionsFrom Logger options for the configured logging section
+func LoggerOptionsFrom(cfg *Config) logger.Options {
+	opts := logger.DefaultOptions()
+	if cfg == nil {
+		return opts
+	}
+	logging := cfg.Logging
+	if logging.Level != "" {
+		opts.Level = logging.Level
+	}
+	if logging.FilePath != "" {
+		opts.FilePath = paths.Resolve(logging.FilePath)
+	}
+	opts.MaxSizeMB = logging.MaxSizeMB
+	opts.MaxBackups = logging.MaxBackups
+	opts.MaxAgeDays = logging.MaxAgeDays
+	if logging.Console != nil {
+		opts.Console = *logging.Console
+	}
+	opts.Encoding = logging.Encoding
+	return opts
+}
+
+// LegacyJSONFieldsFrom reports whether API responses should also carry the former snake_case field names
+func LegacyJSONFieldsFrom(cfg *Config) bool {
+	return cfg != nil && cfg.Server.LegacyJSONFields
+}
+
+// FrontendDirectoryFrom returns the directory the web interface is served from, empty when not configured
+func FrontendDirectoryFrom(cfg *Config) string {
+	if cfg == nil {
+		return ""
+	}
+	return paths.Resolve(cfg.Server.Frontend.Directory)
+}
+
+// MemberStatusPollIntervalFrom returns how often member online status is sampled, defaulting to 60 seconds
+func MemberStatusPollIntervalFrom(cfg *Config) time.Duration {
+	if cfg == nil || cfg.MemberHistory.PollIntervalSeconds <= 0 {
+		return defaultMemberStatusPollInterval
+	}
+	return time.Duration(cfg.MemberHistory.PollIntervalSeconds) * time.Second
+}
+
+// MemberEventPollIntervalFrom returns how often subscribed networks are polled for live member events, defaulting to 5 seconds
+func MemberEventPollIntervalFrom(cfg *Config) time.Duration {
+	if cfg == nil || cfg.MemberEvents.PollIntervalSeconds <= 0 {
+		return defaultMemberEventPollInterval
+	}
+	return time.Duration(cfg.MemberEvents.PollIntervalSeconds) * time.Second
+}
+
+// ZTStatusRefreshIntervalFrom returns how often the controller status cache is refreshed, defaulting to 15 seconds
+func ZTStatusRefreshIntervalFrom(cfg *Config) time.Duration {
+	if cfg == nil || cfg.ZeroTier.StatusRefreshSeconds <= 0 {
+		return defaultZTStatusRefreshInterval
+	}
+	return time.Duration(cfg.ZeroTier.StatusRefreshSeconds) * time.Second
+}
+
+// SystemStatsSampleIntervalFrom returns how often resource usage is sampled for the stats history, defaulting to 30 seconds
+func SystemStatsSampleIntervalFrom(cfg *Config) time.Duration {
+	if cfg == nil || cfg.SystemStats.SampleIntervalSeconds <= 0 {
+		return defaultSystemStatsInterval
+	}
+	return time.Duration(cfg.SystemStats.SampleIntervalSeconds) * time.Second
+}
+
+// CompactIntervalFrom Interval between scheduled database compaction checks
+func CompactIntervalFrom(cfg *Config) time.Duration {
+	if cfg == nil || cfg.Maintenance.CompactIntervalHours <= 0 {
+		return defaultCompactInterval
+	}
+	return time.Duration(cfg.Maintenance.CompactIntervalHours) * time.Hour
+}
+
+// InactivityCheckIntervalFrom Interval between scheduled checks of network inactivity policies
+func InactivityCheckIntervalFrom(cfg *Config) time.Duration {
+	if cfg == nil || cfg.Maintenance.InactivityCheckIntervalHours <= 0 {
+		return defaultInactivityCheckInterval
+	}
+	return time.Duration(cfg.Maintenance.InactivityCheckIntervalHours) * time.Hour
+}
+
+// CompactFreeRatioFrom Share of free database pages above which scheduled compaction runs
+func CompactFreeRatioFrom(cfg *Config) float64 {
+	if cfg == nil || cfg.Maintenance.CompactFreePercent <= 0 || cfg.Maintenance.CompactFreePercent > 100 {
+		return defaultCompactFreePercent / 100.0
+	}
+	return float64(cfg.Maintenance.CompactFreePercent) / 100
+}
+
+// BackupDirectoryFrom Directory system backups are written to
+func BackupDirectoryFrom(cfg *Config) string {
+	if cfg == nil || cfg.Backup.Directory == "" {
+		return paths.Resolve(defaultBackupDirectory)
+	}
+	return paths.Resolve(cfg.Backup.Directory)
+}
+
+// BackupIntervalFrom Interval between scheduled system backups; zero when they are disabled
+func BackupIntervalFrom(cfg *Config) time.Duration {
+	if cfg == nil || cfg.Backup.IntervalHours <= 0 {
+		return 0
+	}
+	return time.Duration(cfg.Backup.IntervalHours) * time.Hour
+}
+
+// BackupRetentionFrom Number of system backups kept, defaulting to 7
+func BackupRetentionFrom(cfg *Config) int {
+	if cfg == nil || cfg.Backup.Retention <= 0 {
+		return defaultBackupRetention
+	}
+	return cfg.Backup.Retention
+}
+
+// PlanetHistoryLimitFrom Number of generated planets kept, defaulting to 20
+func PlanetHistoryLimitFrom(cfg *Config) int {
+	if cfg == nil || cfg.Planet.HistoryLimit <= 0 {
+		return defaultPlanetHistoryLimit
+	}
+	return cfg.Planet.HistoryLimit
+}
+
+// OperationConcurrencyFrom Number of long-running operations run at once, defaulting to 4
+func OperationConcurrencyFrom(cfg *Config) int {
+	if cfg == nil || cfg.Operations.Concurrency <= 0 {
+		return defaultOperationConcurrency
+	}
+	return cfg.Operations.Concurrency
+}
+
+// PasswordHashCostFrom bcrypt cost of new password hashes, defaulting to bcrypt.DefaultCost
+func PasswordHashCostFrom(cfg *Config) int {
+	if cfg == nil || cfg.Security.PasswordHashCost < MinPasswordHashCost || cfg.Security.PasswordHashCost > MaxPasswordHashCost {
+		return bcrypt.DefaultCost
+	}
+	return cfg.Security.PasswordHashCost
+}
+
+// ApprovalWebhookURLFrom Webhook notified of newly pending members; empty when none is configured
+func ApprovalWebhookURLFrom(cfg *Config) string {
+	if cfg == nil {
+		return ""
+	}
+	return strings.TrimSpace(cfg.Approvals.WebhookURL)
+}
+
+// OIDCFrom OpenID Connect settings with the client secret decrypted and defaults applied;
+// the zero value when OIDC is not enabled
+func OIDCFrom(cfg *Config) (OIDCConfig, error) {
+	if cfg == nil || !cfg.OIDC.Enabled {
+		return OIDCConfig{}, nil
+	}
+
+	settings := cfg.OIDC
+	settings.IssuerURL = strings.TrimRight(strings.TrimSpace(settings.IssuerURL), "/")
+	settings.ClientID = strings.TrimSpace(settings.ClientID)
+	settings.RedirectURL = strings.TrimSpace(settings.RedirectURL)
+	if settings.IssuerURL == "" || settings.ClientID == "" || settings.RedirectURL == "" {
+		return OIDCConfig{}, fmt.Errorf("oidc requires issuer_url, client_id and redirect_url")
+	}
+	secret, _, err := decryptSensitiveDataWithConfig(cfg, settings.ClientSecret)
+	if err != nil {
+		return OIDCConfig{}, fmt.Errorf("failed to decrypt oidc client secret: %w", err)
+	}
+	settings.ClientSecret = secret
+	if len(settings.Scopes) == 0 {
+		settings.Scopes = []string{"openid", "email", "profile"}
+	}
+	if len(settings.AdminRoles) == 0 {
+		settings.AdminRoles = []string{"admin"}
+	}
+	if strings.TrimSpace(settings.PostLoginRedirect) == "" {
+		settings.PostLoginRedirect = "/login"
+	}
+	return settings, nil
+}
+
+// Captcha providers
+const (
+	CaptchaProviderHCaptcha  = "hcaptcha"
+	CaptchaProviderTurnstile = "turnstile"
+
+	defaultCaptchaLoginFailures = 3
+)
+
+// captchaVerifyURLs are the siteverify endpoints of the providers
+var captchaVerifyURLs = map[string]string{
+	CaptchaProviderHCaptcha:  "https://api.hcaptcha.com/siteverify",
+	CaptchaProviderTurnstile: "https://challenges.cloudflare.com/turnstile/v0/siteverify",
+}
+
+// CaptchaFrom returns the captcha settings with the secret decrypted and defaults applied; an
+// empty provider means the challenge is disabled
+func CaptchaFrom(cfg *Config) (CaptchaConfig, error) {
+	if cfg == nil || strings.TrimSpace(cfg.Captcha.Provider) == "" {
+		return CaptchaConfig{}, nil
+	}
+	if err := checkCaptcha(cfg.Captcha); err != nil {
+		return CaptchaConfig{}, err
+	}
+
+	settings := cfg.Captcha
+	settings.Provider = strings.ToLower(strings.TrimSpace(settings.Provider))
+	secret, _, err := decryptSensitiveDataWithConfig(cfg, settings.Secret)
+	if err != nil {
+		return CaptchaConfig{}, fmt.Errorf("failed to decrypt captcha secret: %w", err)
+	}
+	settings.Secret = secret
+	if strings.TrimSpace(settings.VerifyURL) == "" {
+		settings.VerifyURL = captchaVerifyURLs[settings.Provider]
+	}
+	if settings.LoginFailures == 0 {
+		settings.LoginFailures = defaultCaptchaLoginFailures
+	}
+	return settings, nil
+}
+
+// checkCaptcha checks the captcha section without decrypting the secret
+func checkCaptcha(captcha CaptchaConfig) error {
+	provider := strings.ToLower(strings.TrimSpace(captcha.Provider))
+	if provider == "" {
+		return nil
+	}
+	if _, ok := captchaVerifyURLs[provider]; !ok {
+		return fmt.Errorf("captcha.provider must be hcaptcha or turnstile, got %q", captcha.Provider)
+	}
+	if strings.TrimSpace(captcha.SiteKey) == "" || strings.TrimSpace(captcha.Secret) == "" {
+		return fmt.Errorf("captcha requires site_key and secret")
+	}
+	if captcha.VerifyURL != "" {
+		if err := validateHTTPURL(captcha.VerifyURL); err != nil {
+			return fmt.Errorf("captcha.verify_url %v", err)
+		}
+	}
+	if captcha.LoginFailures < 0 {
+		return fmt.Errorf("captcha.login_failures must not be negative, got %d", captcha.LoginFailures)
+	}
+	return nil
+}
+
 // GetTempSetting Get temporary setting
 // Temporary settings are stored in memory and not persisted to configuration file
 func GetTempSetting(key string) string {
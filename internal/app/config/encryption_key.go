@@ -0,0 +1,170 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/GT-610/tairitsu/internal/app/crypto"
+	"github.com/GT-610/tairitsu/internal/app/logger"
+	"github.com/GT-610/tairitsu/internal/app/paths"
+	"go.uber.org/zap"
+)
+
+// EncryptionKeyFileEnv names a file holding the credential encryption key, such as a
+// Docker secret. A key provided this way must exist and is never rewritten by Tairitsu.
+const EncryptionKeyFileEnv = "TAIRITSU_MASTER_KEY_FILE"
+
+const defaultEncryptionKeyPath = "./data/master.key"
+
+// ErrEncryptionKeyExternal is returned when rotating a key Tairitsu does not manage
+var ErrEncryptionKeyExternal = errors.New("encryption key is provided externally and cannot be rotated here")
+
+// encryptionKeyFile returns the key file path and whether it came from the environment
+func encryptionKeyFile() (string, bool) {
+	if path := strings.TrimSpace(os.Getenv(EncryptionKeyFileEnv)); path != "" {
+		return path, true
+	}
+	return paths.Resolve(defaultEncryptionKeyPath), false
+}
+
+// loadEncryptionKey reads the credential encryption key, creating the default key file
+// on first run
+func loadEncryptionKey(cfg *Config) error {
+	path, fromEnv := encryptionKeyFile()
+	data, err := os.ReadFile(path)
+	switch {
+	case err == nil:
+	case errors.Is(err, os.ErrNotExist) && !fromEnv:
+		key, genErr := crypto.GenerateSecret(crypto.SecretLength)
+		if genErr != nil {
+			return fmt.Errorf("failed to generate encryption key: %w", genErr)
+		}
+		if err := writeEncryptionKeyFile(path, key); err != nil {
+			return err
+		}
+		logger.Info("created credential encryption key", zap.String("path", path))
+		data = []byte(key)
+	default:
+		return fmt.Errorf("failed to read encryption key %s: %w", path, err)
+	}
+
+	key := strings.TrimSpace(string(data))
+	if key == "" {
+		return fmt.Errorf("encryption key file %s is empty", path)
+	}
+	cfg.encryptionKey = key
+	cfg.encryptionKeyPath = path
+	cfg.encryptionKeyExternal = fromEnv
+	return nil
+}
+
+// writeEncryptionKeyFile replaces the key file atomically, readable by the owner only
+func writeEncryptionKeyFile(path, key string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create encryption key directory: %w", err)
+	}
+	tmp := path + ".tmp"
+	_ = os.Remove(tmp)
+	if err := os.WriteFile(tmp, []byte(key+"\n"), 0600); err != nil {
+		return fmt.Errorf("failed to write encryption key: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		_ = os.Remove(tmp)
+		return fmt.Errorf("failed to write encryption key: %w", err)
+	}
+	return nil
+}
+
+// encryptionKeyOf returns the key stored credentials are encrypted with. Configurations
+// built without LoadConfig, and releases before the key file, use the JWT secret.
+func encryptionKeyOf(cfg *Config) string {
+	if cfg.encryptionKey != "" {
+		return cfg.encryptionKey
+	}
+	return cfg.Security.JWTSecret
+}
+
+// migrateCredentialsToEncryptionKey re-encrypts credentials still encrypted with the JWT
+// secret. Values that cannot be decrypted are left for the caller that needs them to report.
+func migrateCredentialsToEncryptionKey(cfg *Config) bool {
+	migrated := false
+	credentials := []struct {
+		name  string
+		value *string
+	}{
+		{"ZeroTier token", &cfg.ZeroTier.Token},
+		{"database password", &cfg.Database.Pass},
+	}
+	for _, credential := range credentials {
+		_, reEncrypted, err := decryptSensitiveDataWithConfig(cfg, *credential.value)
+		if err != nil {
+			logger.Warn("stored credential could not be decrypted with the encryption key or the JWT secret", zap.String("credential", credential.name), zap.Error(err))
+			continue
+		}
+		if reEncrypted != "" {
+			*credential.value = reEncrypted
+			migrated = true
+		}
+	}
+	return migrated
+}
+
+// RotateEncryptionKey replaces the credential encryption key, re-encrypts the stored
+// credentials with it and persists both. On failure the previous key stays in use.
+func RotateEncryptionKey(cfg *Config) error {
+	if cfg == nil {
+		return fmt.Errorf("configuration not loaded")
+	}
+	if cfg.encryptionKeyPath == "" || cfg.encryptionKeyExternal {
+		return ErrEncryptionKeyExternal
+	}
+
+	token, _, err := decryptSensitiveDataWithConfig(cfg, cfg.ZeroTier.Token)
+	if err != nil {
+		return fmt.Errorf("failed to decrypt ZeroTier token: %w", err)
+	}
+	databasePassword, _, err := decryptSensitiveDataWithConfig(cfg, cfg.Database.Pass)
+	if err != nil {
+		return fmt.Errorf("failed to decrypt database password: %w", err)
+	}
+	key, err := crypto.GenerateSecret(crypto.SecretLength)
+	if err != nil {
+		return fmt.Errorf("failed to generate encryption key: %w", err)
+	}
+
+	previousKey, previousToken, previousPassword := cfg.encryptionKey, cfg.ZeroTier.Token, cfg.Database.Pass
+	restore := func() {
+		cfg.encryptionKey, cfg.ZeroTier.Token, cfg.Database.Pass = previousKey, previousToken, previousPassword
+	}
+
+	cfg.encryptionKey = key
+	if token != "" {
+		if err := SetZTTokenOn(cfg, token); err != nil {
+			restore()
+			return err
+		}
+	}
+	if databasePassword != "" {
+		if err := SetDatabasePasswordOn(cfg, databasePassword); err != nil {
+			restore()
+			return err
+		}
+	}
+	if err := writeEncryptionKeyFile(cfg.encryptionKeyPath, key); err != nil {
+		restore()
+		return err
+	}
+	if err := SaveConfig(cfg); err != nil {
+		restore()
+		if restoreErr := writeEncryptionKeyFile(cfg.encryptionKeyPath, previousKey); restoreErr != nil {
+			logger.Error("failed to restore the previous encryption key; stored credentials must be entered again", zap.Error(restoreErr))
+		}
+		return fmt.Errorf("failed to save re-encrypted configuration: %w", err)
+	}
+
+	logger.Info("credential encryption key rotated")
+	return nil
+}
@@ -0,0 +1,180 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/GT-610/tairitsu/internal/app/crypto"
+	"github.com/GT-610/tairitsu/internal/app/logger"
+	"github.com/GT-610/tairitsu/internal/app/paths"
+	"go.uber.org/zap"
+)
+
+// MinSecretLength is the shortest JWT secret accepted for an initialized installation.
+// Releases before the encryption key file also derived the credential key from it.
+const MinSecretLength = 32
+
+// defaultPreviousJWTSecretWindow matches the access token lifetime, so no session
+// outlives the secret it was signed with
+const defaultPreviousJWTSecretWindow = 24 * time.Hour
+
+// ErrWeakSecret is returned when an initialized configuration has a missing or too short JWT secret
+var ErrWeakSecret = errors.New("JWT secret is missing or too short")
+
+// LoadOptions adjusts how LoadConfigWithOptions treats the stored configuration
+type LoadOptions struct {
+	// RegenerateSecrets replaces a missing or weak JWT secret instead of refusing to start
+	RegenerateSecrets bool
+}
+
+// enforceSecretStrength refuses, or with RegenerateSecrets repairs, an initialized
+// configuration whose JWT secret cannot be trusted.
+func enforceSecretStrength(cfg *Config, opts LoadOptions) error {
+	secret := cfg.Security.JWTSecret
+	if !cfg.Initialized || len(secret) >= MinSecretLength {
+		return nil
+	}
+	if secret == "" && hasLegacyEmptyKeyCredentials(cfg) {
+		// v0.x encrypted credentials with an empty key; ensureJWTSecret migrates them.
+		return nil
+	}
+
+	if !opts.RegenerateSecrets {
+		state := "missing"
+		if secret != "" {
+			state = fmt.Sprintf("only %d characters long", len(secret))
+		}
+		return fmt.Errorf("%w: %s is marked initialized but security.jwt_secret is %s (minimum %d). "+
+			"Restore the original jwt_secret from a backup of that file, or restart with --regenerate-secrets "+
+			"to generate a new one; this signs out every session and any stored credential that cannot be "+
+			"decrypted must be entered again", ErrWeakSecret, paths.ConfigFile(), state, MinSecretLength)
+	}
+
+	return regenerateSecrets(cfg)
+}
+
+// hasLegacyEmptyKeyCredentials reports whether the configuration holds credentials that
+// were all encrypted with the legacy empty key.
+func hasLegacyEmptyKeyCredentials(cfg *Config) bool {
+	found := false
+	for _, value := range []string{cfg.ZeroTier.Token, cfg.Database.Pass} {
+		if !strings.HasPrefix(value, "encrypted:") {
+			continue
+		}
+		if _, err := decryptWithEmptyLegacyKey(value); err != nil {
+			return false
+		}
+		found = true
+	}
+	return found
+}
+
+// regenerateSecrets replaces the JWT secret and re-encrypts every credential the old
+// secret can still decrypt. Credentials that cannot be recovered are cleared.
+func regenerateSecrets(cfg *Config) error {
+	token, _, tokenErr := decryptSensitiveDataWithConfig(cfg, cfg.ZeroTier.Token)
+	databasePassword, _, passwordErr := decryptSensitiveDataWithConfig(cfg, cfg.Database.Pass)
+
+	secret, err := crypto.GenerateSecret(crypto.SecretLength)
+	if err != nil {
+		return fmt.Errorf("failed to generate JWT secret: %w", err)
+	}
+	cfg.Security.JWTSecret = secret
+	cfg.SecretsRegenerated = true
+	cfg.ZeroTier.Token = ""
+	cfg.Database.Pass = ""
+
+	switch {
+	case tokenErr == nil && token != "":
+		if err := SetZTTokenOn(cfg, token); err != nil {
+			return err
+		}
+	case cfg.ZeroTier.TokenPath != "":
+		if err := LoadTokenFromPathInto(cfg, cfg.ZeroTier.TokenPath); err != nil {
+			logger.Warn("failed to reload ZeroTier token after regenerating secrets; enter it again", zap.Error(err))
+		}
+	case tokenErr != nil:
+		logger.Warn("stored ZeroTier token could not be decrypted and was cleared; enter it again", zap.Error(tokenErr))
+	}
+
+	if passwordErr != nil {
+		logger.Warn("stored database password could not be decrypted and was cleared; enter it again", zap.Error(passwordErr))
+	} else if databasePassword != "" {
+		if err := SetDatabasePasswordOn(cfg, databasePassword); err != nil {
+			return err
+		}
+	}
+
+	logger.Warn("JWT secret regenerated; all existing sessions are invalidated")
+	return SaveConfig(cfg)
+}
+
+// PreviousJWTSecretWindow returns how long a replaced JWT secret keeps verifying tokens
+func PreviousJWTSecretWindow(cfg *Config) time.Duration {
+	if cfg == nil || cfg.Security.PreviousJWTSecretHours <= 0 {
+		return defaultPreviousJWTSecretWindow
+	}
+	return time.Duration(cfg.Security.PreviousJWTSecretHours) * time.Hour
+}
+
+// RotateJWTSecret replaces the JWT secret and keeps the old one as a previous secret for
+// PreviousJWTSecretWindow; previous secrets that have expired are dropped
+func RotateJWTSecret(cfg *Config) error {
+	if cfg == nil {
+		return fmt.Errorf("configuration not loaded")
+	}
+
+	// Without a loaded encryption key the credentials are encrypted with the JWT secret
+	token, _, err := decryptSensitiveDataWithConfig(cfg, cfg.ZeroTier.Token)
+	if err != nil {
+		return fmt.Errorf("failed to decrypt ZeroTier token: %w", err)
+	}
+	databasePassword, _, err := decryptSensitiveDataWithConfig(cfg, cfg.Database.Pass)
+	if err != nil {
+		return fmt.Errorf("failed to decrypt database password: %w", err)
+	}
+	secret, err := crypto.GenerateSecret(crypto.SecretLength)
+	if err != nil {
+		return fmt.Errorf("failed to generate JWT secret: %w", err)
+	}
+
+	previousSecurity, previousToken, previousPassword := cfg.Security, cfg.ZeroTier.Token, cfg.Database.Pass
+	restore := func() {
+		cfg.Security, cfg.ZeroTier.Token, cfg.Database.Pass = previousSecurity, previousToken, previousPassword
+	}
+
+	now := time.Now()
+	retired := make([]RetiredJWTSecret, 0, len(cfg.Security.PreviousJWTSecrets)+1)
+	for _, previous := range cfg.Security.PreviousJWTSecrets {
+		if previous.ExpiresAt.After(now) {
+			retired = append(retired, previous)
+		}
+	}
+	if cfg.Security.JWTSecret != "" {
+		retired = append(retired, RetiredJWTSecret{Secret: cfg.Security.JWTSecret, ExpiresAt: now.Add(PreviousJWTSecretWindow(cfg))})
+	}
+	cfg.Security.JWTSecret = secret
+	cfg.Security.PreviousJWTSecrets = retired
+
+	if token != "" {
+		if err := SetZTTokenOn(cfg, token); err != nil {
+			restore()
+			return err
+		}
+	}
+	if databasePassword != "" {
+		if err := SetDatabasePasswordOn(cfg, databasePassword); err != nil {
+			restore()
+			return err
+		}
+	}
+	if err := SaveConfig(cfg); err != nil {
+		restore()
+		return err
+	}
+
+	logger.Info("JWT secret rotated", zap.Int("previous_secrets", len(retired)))
+	return nil
+}
@@ -0,0 +1,163 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/GT-610/tairitsu/internal/app/logger"
+	"github.com/GT-610/tairitsu/internal/app/paths"
+	"go.uber.org/zap"
+)
+
+// ErrInvalidConfig is wrapped by the ValidationError of a configuration that fails Validate
+var ErrInvalidConfig = errors.New("invalid configuration")
+
+// ValidationError lists every problem Validate found, each naming the setting to fix
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("%s in %s:\n  - %s", ErrInvalidConfig, paths.ConfigFile(), strings.Join(e.Problems, "\n  - "))
+}
+
+func (e *ValidationError) Unwrap() error {
+	return ErrInvalidConfig
+}
+
+// Validate checks the settings that would otherwise only fail when they are used and
+// returns a *ValidationError listing every problem, or nil. Settings the setup wizard
+// fills in, such as the JWT secret, are only required once the system is initialized.
+func (c *Config) Validate() error {
+	var problems []string
+	add := func(format string, args ...any) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+
+	if c.Server.Port < 1 || c.Server.Port > 65535 {
+		add("server.port must be between 1 and 65535, got %d", c.Server.Port)
+	}
+	if c.Initialized && c.Security.JWTSecret == "" {
+		add("security.jwt_secret must be set once the system is initialized")
+	}
+	if cost := c.Security.PasswordHashCost; cost != 0 && (cost < MinPasswordHashCost || cost > MaxPasswordHashCost) {
+		add("security.password_hash_cost must be between %d and %d, got %d", MinPasswordHashCost, MaxPasswordHashCost, cost)
+	}
+	if c.ZeroTier.URL != "" {
+		if err := validateHTTPURL(c.ZeroTier.URL); err != nil {
+			add("zerotier.url %v", err)
+		}
+	}
+	for i, controller := range c.ZeroTier.Controllers {
+		if err := validateHTTPURL(controller.URL); err != nil {
+			add("zerotier.controllers[%d].url %v", i, err)
+		}
+	}
+	problems = append(problems, databaseProblems(c.Database)...)
+	if c.Logging.Level != "" && !logger.ValidLevel(c.Logging.Level) {
+		add("logging.level must be debug, info, warn or error, got %q", c.Logging.Level)
+	}
+	if _, _, err := ProxySettingsFrom(c); err != nil {
+		add("%v", err)
+	}
+	if _, err := TLSFrom(c); err != nil {
+		add("%v", err)
+	}
+	if _, err := LogShippingFrom(c); err != nil {
+		add("%v", err)
+	}
+	if err := checkCaptcha(c.Captcha); err != nil {
+		add("%v", err)
+	}
+	for name, limits := range c.RateLimits {
+		if limits.Capacity < 0 || limits.RefillRate < 0 {
+			add("rate_limits.%s: capacity and refill_rate must not be negative", name)
+		}
+	}
+
+	if len(problems) > 0 {
+		return &ValidationError{Problems: problems}
+	}
+	return nil
+}
+
+// databaseProblems checks that the selected database type has the fields it connects with
+func databaseProblems(db DatabaseConfig) []string {
+	switch db.Type {
+	case "":
+		// The setup wizard has not chosen a database yet
+		return nil
+	case DatabaseSQLite:
+		// The path falls back to DefaultSQLitePath
+		return sqliteProblems(db)
+	case DatabasePostgreSQL, DatabaseMySQL:
+	default:
+		return []string{fmt.Sprintf("database.type must be %s, %s or %s, got %q", DatabaseSQLite, DatabasePostgreSQL, DatabaseMySQL, db.Type)}
+	}
+
+	var problems []string
+	for _, field := range []struct{ name, value string }{{"host", db.Host}, {"user", db.User}, {"name", db.Name}} {
+		if strings.TrimSpace(field.value) == "" {
+			problems = append(problems, fmt.Sprintf("database.%s is required for %s", field.name, db.Type))
+		}
+	}
+	if db.Port < 1 || db.Port > 65535 {
+		problems = append(problems, fmt.Sprintf("database.port must be between 1 and 65535 for %s, got %d", db.Type, db.Port))
+	}
+	return problems
+}
+
+// sqliteProblems checks the SQLite tuning settings, which are passed to SQLite as pragmas
+func sqliteProblems(db DatabaseConfig) []string {
+	var problems []string
+	if db.SQLiteBusyTimeoutMillis < 0 {
+		problems = append(problems, fmt.Sprintf("database.sqlite_busy_timeout_ms must not be negative, got %d", db.SQLiteBusyTimeoutMillis))
+	}
+	if mode := db.SQLiteJournalMode; mode != "" && !oneOfFold(mode, "WAL", "DELETE", "TRUNCATE", "PERSIST", "MEMORY", "OFF") {
+		problems = append(problems, fmt.Sprintf("database.sqlite_journal_mode must be WAL, DELETE, TRUNCATE, PERSIST, MEMORY or OFF, got %q", mode))
+	}
+	if level := db.SQLiteSynchronous; level != "" && !oneOfFold(level, "OFF", "NORMAL", "FULL", "EXTRA") {
+		problems = append(problems, fmt.Sprintf("database.sqlite_synchronous must be OFF, NORMAL, FULL or EXTRA, got %q", level))
+	}
+	return problems
+}
+
+func oneOfFold(value string, options ...string) bool {
+	for _, option := range options {
+		if strings.EqualFold(value, option) {
+			return true
+		}
+	}
+	return false
+}
+
+// validateHTTPURL checks that raw is an absolute http or https URL
+func validateHTTPURL(raw string) error {
+	parsed, err := url.Parse(raw)
+	if err != nil {
+		return fmt.Errorf("is not a valid URL: %v", err)
+	}
+	if parsed.Scheme != "http" && parsed.Scheme != "https" {
+		return fmt.Errorf("must start with http:// or https://, got %q", raw)
+	}
+	if parsed.Host == "" {
+		return fmt.Errorf("must name a host, got %q", raw)
+	}
+	return nil
+}
+
+// checkConfig refuses an invalid configuration of an initialized system. An uninitialized
+// one only gets a warning, so the setup wizard can still start and correct it.
+func checkConfig(cfg *Config) error {
+	err := cfg.Validate()
+	if err == nil {
+		return nil
+	}
+	if cfg.Initialized {
+		return err
+	}
+	logger.Warn("configuration has problems; continuing because the system is not initialized yet", zap.Error(err))
+	return nil
+}
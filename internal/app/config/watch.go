@@ -0,0 +1,230 @@
+package config
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/GT-610/tairitsu/internal/app/logger"
+	"github.com/GT-610/tairitsu/internal/app/paths"
+	"github.com/fsnotify/fsnotify"
+	"go.uber.org/zap"
+)
+
+// Change is a reload of config.json. Previous is a copy of the configuration before the
+// reload; Current is the live configuration, which was updated in place.
+type Change struct {
+	Previous *Config
+	Current  *Config
+}
+
+// ChangeHandler reacts to a reload of config.json
+type ChangeHandler func(change Change)
+
+var (
+	subscribersMutex sync.Mutex
+	subscribers      = make(map[int]ChangeHandler)
+	nextSubscriber   int
+
+	// reloadMutex serializes reloads, so handlers see the changes in the order they were made
+	reloadMutex sync.Mutex
+
+	// fileDigestMutex guards fileDigest, the digest of config.json as last read or written
+	fileDigestMutex sync.Mutex
+	fileDigest      [sha256.Size]byte
+)
+
+// ErrConfigUnchanged is returned by Reload when config.json matches what was last read or written
+var ErrConfigUnchanged = errors.New("configuration file is unchanged")
+
+// reloadDebounce collects the several events editors cause when saving into one reload
+const reloadDebounce = 200 * time.Millisecond
+
+// restartOnlySettings are read once when the application starts; changing them in
+// config.json takes effect after a restart
+var restartOnlySettings = []struct {
+	name  string
+	value func(cfg *Config) any
+}{
+	{"server.port", func(cfg *Config) any { return cfg.Server.Port }},
+	{"server.tls", func(cfg *Config) any { return cfg.Server.TLS }},
+	{"server.frontend", func(cfg *Config) any { return cfg.Server.Frontend }},
+	{"server.trusted_proxies", func(cfg *Config) any { return cfg.Server.TrustedProxies }},
+	{"server.proxy_header", func(cfg *Config) any { return cfg.Server.ProxyHeader }},
+	{"server.legacy_json_fields", func(cfg *Config) any { return cfg.Server.LegacyJSONFields }},
+	{"database", func(cfg *Config) any { return cfg.Database }},
+	{"zerotier.status_refresh_seconds", func(cfg *Config) any { return cfg.ZeroTier.StatusRefreshSeconds }},
+	{"security.password_policy", func(cfg *Config) any { return cfg.Security.PasswordPolicy }},
+	{"security.password_hash_cost", func(cfg *Config) any { return cfg.Security.PasswordHashCost }},
+	{"audit", func(cfg *Config) any { return cfg.Audit }},
+	{"member_history", func(cfg *Config) any { return cfg.MemberHistory }},
+	{"member_events", func(cfg *Config) any { return cfg.MemberEvents }},
+	{"approvals", func(cfg *Config) any { return cfg.Approvals }},
+	{"controller_trace", func(cfg *Config) any { return cfg.ControllerTrace }},
+	{"maintenance", func(cfg *Config) any { return cfg.Maintenance }},
+	{"backup", func(cfg *Config) any { return cfg.Backup }},
+	{"planet", func(cfg *Config) any { return cfg.Planet }},
+	{"system_stats", func(cfg *Config) any { return cfg.SystemStats }},
+	{"operations", func(cfg *Config) any { return cfg.Operations }},
+	{"oidc", func(cfg *Config) any { return cfg.OIDC }},
+	{"captcha", func(cfg *Config) any { return cfg.Captcha }},
+	{"log_shipping", func(cfg *Config) any { return cfg.LogShipping }},
+}
+
+// Subscribe calls handler after every reload of config.json and returns a function that
+// removes it. Handlers run on the watcher's goroutine, one reload at a time.
+func Subscribe(handler ChangeHandler) (unsubscribe func()) {
+	subscribersMutex.Lock()
+	defer subscribersMutex.Unlock()
+	id := nextSubscriber
+	nextSubscriber++
+	subscribers[id] = handler
+	return func() {
+		subscribersMutex.Lock()
+		defer subscribersMutex.Unlock()
+		delete(subscribers, id)
+	}
+}
+
+func notify(change Change) {
+	subscribersMutex.Lock()
+	handlers := make([]ChangeHandler, 0, len(subscribers))
+	for _, handler := range subscribers {
+		handlers = append(handlers, handler)
+	}
+	subscribersMutex.Unlock()
+	for _, handler := range handlers {
+		handler(change)
+	}
+}
+
+// rememberConfigFile records the content of config.json as read or written by Tairitsu
+func rememberConfigFile(data []byte) {
+	fileDigestMutex.Lock()
+	defer fileDigestMutex.Unlock()
+	fileDigest = sha256.Sum256(data)
+}
+
+func isRememberedConfigFile(data []byte) bool {
+	fileDigestMutex.Lock()
+	defer fileDigestMutex.Unlock()
+	return fileDigest == sha256.Sum256(data)
+}
+
+// Reload reads config.json into cfg and notifies the subscribers. A file that cannot be
+// read, is half-written or fails validation leaves cfg untouched and returns the error;
+// a file matching what Tairitsu last read or wrote returns ErrConfigUnchanged.
+func Reload(cfg *Config) error {
+	reloadMutex.Lock()
+	defer reloadMutex.Unlock()
+
+	data, err := os.ReadFile(paths.ConfigFile())
+	if err != nil {
+		return fmt.Errorf("failed to read configuration file: %w", err)
+	}
+	if isRememberedConfigFile(data) {
+		return ErrConfigUnchanged
+	}
+	loaded, err := parseConfig(data)
+	if err != nil {
+		return err
+	}
+	if err := loadEncryptionKey(loaded); err != nil {
+		return err
+	}
+	if err := enforceSecretStrength(loaded, LoadOptions{}); err != nil {
+		return err
+	}
+	if err := loaded.Validate(); err != nil {
+		return err
+	}
+
+	previous := *cfg
+	// Runtime-only state is not part of the file
+	loaded.DemoMode = cfg.DemoMode
+	loaded.SecretsRegenerated = cfg.SecretsRegenerated
+	*cfg = *loaded
+	rememberConfigFile(data)
+
+	if pending := RestartRequired(&previous, cfg); len(pending) > 0 {
+		logger.Warn("configuration changes take effect after a restart", zap.Strings("settings", pending))
+	}
+	logger.Info("reloaded configuration", zap.String("path", paths.ConfigFile()))
+	notify(Change{Previous: &previous, Current: cfg})
+	return nil
+}
+
+// RestartRequired lists the changed settings that are only read at startup
+func RestartRequired(previous, current *Config) []string {
+	var changed []string
+	for _, setting := range restartOnlySettings {
+		if !reflect.DeepEqual(setting.value(previous), setting.value(current)) {
+			changed = append(changed, setting.name)
+		}
+	}
+	return changed
+}
+
+// Watcher reloads config.json into a configuration whenever the file changes
+type Watcher struct {
+	watcher *fsnotify.Watcher
+	done    chan struct{}
+}
+
+// Watch reloads config.json into cfg when it changes. The data directory is watched rather
+// than the file, because editors and SaveConfig replace the file instead of writing to it.
+func Watch(cfg *Config) (*Watcher, error) {
+	fsWatcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, fmt.Errorf("failed to watch configuration: %w", err)
+	}
+	if err := fsWatcher.Add(paths.DataDir()); err != nil {
+		_ = fsWatcher.Close()
+		return nil, fmt.Errorf("failed to watch configuration: %w", err)
+	}
+
+	w := &Watcher{watcher: fsWatcher, done: make(chan struct{})}
+	go w.run(cfg)
+	return w, nil
+}
+
+func (w *Watcher) run(cfg *Config) {
+	defer close(w.done)
+	configFile := filepath.Clean(paths.ConfigFile())
+	debounce := time.NewTimer(reloadDebounce)
+	debounce.Stop()
+	defer debounce.Stop()
+
+	for {
+		select {
+		case event, ok := <-w.watcher.Events:
+			if !ok {
+				return
+			}
+			if filepath.Clean(event.Name) == configFile && event.Has(fsnotify.Write|fsnotify.Create|fsnotify.Rename) {
+				debounce.Reset(reloadDebounce)
+			}
+		case err, ok := <-w.watcher.Errors:
+			if !ok {
+				return
+			}
+			logger.Warn("configuration watcher failed", zap.Error(err))
+		case <-debounce.C:
+			if err := Reload(cfg); err != nil && !errors.Is(err, ErrConfigUnchanged) {
+				logger.Error("ignored invalid configuration change; the previous configuration stays in effect", zap.Error(err))
+			}
+		}
+	}
+}
+
+// Close stops watching
+func (w *Watcher) Close() error {
+	err := w.watcher.Close()
+	<-w.done
+	return err
+}
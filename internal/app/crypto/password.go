@@ -0,0 +1,97 @@
+package crypto
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+
+	"golang.org/x/crypto/argon2"
+)
+
+// KDFArgon2id names the only password key derivation understood by OpenWithPassword
+const KDFArgon2id = "argon2id"
+
+// Argon2id parameters for new sealed data, and the bounds accepted when opening it
+const (
+	passwordTime      = 3
+	passwordMemoryKiB = 64 * 1024
+	passwordThreads   = 4
+	passwordSaltSize  = 16
+
+	maxPasswordTime      = 10
+	minPasswordMemoryKiB = 8 * 1024
+	maxPasswordMemoryKiB = 256 * 1024
+	maxPasswordThreads   = 16
+)
+
+var (
+	ErrEmptyPassword      = errors.New("crypto.empty_password")
+	ErrUnsupportedKDF     = errors.New("crypto.unsupported_kdf")
+	ErrInvalidKDFSettings = errors.New("crypto.invalid_kdf_settings")
+)
+
+// PasswordSealed is data encrypted with AES-256-GCM under a key derived from a password with
+// argon2id. The salt and cost parameters travel with the data so they can be raised later.
+type PasswordSealed struct {
+	KDF        string `json:"kdf"`
+	Salt       string `json:"salt"`
+	Time       uint32 `json:"time"`
+	MemoryKiB  uint32 `json:"memoryKiB"`
+	Threads    uint8  `json:"threads"`
+	Ciphertext string `json:"ciphertext"` // Base64 of nonce followed by the sealed data
+}
+
+// SealWithPassword encrypts plaintext under a key derived from password and a random salt
+func SealWithPassword(plaintext []byte, password string) (*PasswordSealed, error) {
+	if password == "" {
+		return nil, fmt.Errorf("%w: %v", ErrEncryptFailed, ErrEmptyPassword)
+	}
+	salt := make([]byte, passwordSaltSize)
+	if _, err := io.ReadFull(rand.Reader, salt); err != nil {
+		return nil, fmt.Errorf("%w: generate salt: %v", ErrEncryptFailed, err)
+	}
+
+	sealed := &PasswordSealed{
+		KDF:       KDFArgon2id,
+		Salt:      base64.StdEncoding.EncodeToString(salt),
+		Time:      passwordTime,
+		MemoryKiB: passwordMemoryKiB,
+		Threads:   passwordThreads,
+	}
+	key := argon2.IDKey([]byte(password), salt, sealed.Time, sealed.MemoryKiB, sealed.Threads, 32)
+	ciphertext, err := encryptWithKey(string(plaintext), key)
+	if err != nil {
+		return nil, err
+	}
+	sealed.Ciphertext = ciphertext
+	return sealed, nil
+}
+
+// OpenWithPassword decrypts data sealed by SealWithPassword. A wrong password fails with
+// ErrDecryptFailed; cost parameters outside sane bounds are rejected before any derivation.
+func OpenWithPassword(sealed *PasswordSealed, password string) ([]byte, error) {
+	if password == "" {
+		return nil, fmt.Errorf("%w: %v", ErrDecryptFailed, ErrEmptyPassword)
+	}
+	if sealed == nil || sealed.KDF != KDFArgon2id {
+		return nil, ErrUnsupportedKDF
+	}
+	if sealed.Time < 1 || sealed.Time > maxPasswordTime ||
+		sealed.MemoryKiB < minPasswordMemoryKiB || sealed.MemoryKiB > maxPasswordMemoryKiB ||
+		sealed.Threads < 1 || sealed.Threads > maxPasswordThreads {
+		return nil, ErrInvalidKDFSettings
+	}
+	salt, err := base64.StdEncoding.DecodeString(sealed.Salt)
+	if err != nil || len(salt) < 8 {
+		return nil, ErrInvalidKDFSettings
+	}
+
+	key := argon2.IDKey([]byte(password), salt, sealed.Time, sealed.MemoryKiB, sealed.Threads, 32)
+	plaintext, err := decryptWithKey(sealed.Ciphertext, key)
+	if err != nil {
+		return nil, err
+	}
+	return []byte(plaintext), nil
+}
@@ -0,0 +1,27 @@
+package crypto
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+)
+
+// SecretLength is the number of random bytes in the JWT secrets and encryption keys
+// Tairitsu generates
+const SecretLength = 32
+
+var ErrInvalidSecretLength = errors.New("crypto.invalid_secret_length")
+
+// GenerateSecret returns length bytes from crypto/rand, encoded as URL-safe base64. It fails
+// when the system random source does; there is deliberately no weaker fallback.
+func GenerateSecret(length int) (string, error) {
+	if length <= 0 {
+		return "", fmt.Errorf("%w: %d", ErrInvalidSecretLength, length)
+	}
+	secret := make([]byte, length)
+	if _, err := rand.Read(secret); err != nil {
+		return "", fmt.Errorf("failed to read random bytes: %w", err)
+	}
+	return base64.URLEncoding.EncodeToString(secret), nil
+}
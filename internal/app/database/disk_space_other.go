@@ -0,0 +1,10 @@
+//go:build !unix
+
+package database
+
+import "errors"
+
+// FreeDiskSpace is not implemented on this platform; callers skip their free space checks
+func FreeDiskSpace(dir string) (int64, error) {
+	return 0, errors.ErrUnsupported
+}
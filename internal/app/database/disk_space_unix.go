@@ -0,0 +1,14 @@
+//go:build unix
+
+package database
+
+import "syscall"
+
+// FreeDiskSpace returns the bytes available to unprivileged writers on the filesystem holding dir
+func FreeDiskSpace(dir string) (int64, error) {
+	var stat syscall.Statfs_t
+	if err := syscall.Statfs(dir, &stat); err != nil {
+		return 0, err
+	}
+	return int64(stat.Bavail) * int64(stat.Bsize), nil
+}
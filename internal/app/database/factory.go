@@ -1,28 +1,47 @@
 package database
 
 import (
+	"database/sql"
+	"errors"
 	"fmt"
+	"net/url"
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
 	"time"
 
 	"gorm.io/driver/mysql"
 	"gorm.io/driver/postgres"
 	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
 
 	"github.com/GT-610/tairitsu/internal/app/config"
 	"github.com/GT-610/tairitsu/internal/app/logger"
+	"github.com/GT-610/tairitsu/internal/app/models"
+	"github.com/GT-610/tairitsu/internal/app/paths"
 	"go.uber.org/zap"
 )
 
-// DatabaseType represents the type of database
-type DatabaseType string
+// DatabaseType represents the type of database; it is defined next to the config file format
+type DatabaseType = config.DatabaseType
 
 const (
-	SQLite     DatabaseType = "sqlite"
-	PostgreSQL DatabaseType = "postgresql"
-	MySQL      DatabaseType = "mysql"
+	SQLite     = config.DatabaseSQLite
+	PostgreSQL = config.DatabasePostgreSQL
+	MySQL      = config.DatabaseMySQL
+)
+
+// Connection pool defaults, used when Config leaves a setting at zero
+const (
+	defaultMaxIdleConns    = 10
+	defaultMaxOpenConns    = 100
+	defaultConnMaxLifetime = time.Hour
+	defaultConnMaxIdleTime = 30 * time.Minute
+
+	defaultSQLiteBusyTimeout = 5 * time.Second
+	defaultSQLiteJournalMode = "WAL"
 )
 
 // Config holds the database configuration
@@ -34,92 +53,173 @@ type Config struct {
 	User string // PostgreSQL/MySQL user
 	Pass string // PostgreSQL/MySQL password
 	Name string // PostgreSQL/MySQL database name
+
+	MaxIdleConns    int           // Zero uses 10
+	MaxOpenConns    int           // Zero uses 100; SQLite writes always use one connection
+	ConnMaxLifetime time.Duration // Zero uses one hour
+
+	SQLiteBusyTimeout time.Duration // How long a write waits for the lock; zero uses five seconds
+	SQLiteJournalMode string        // Empty uses WAL
+	SQLiteSynchronous string        // Empty keeps SQLite's default
+
+	// Quiet silences GORM's own query log, which writes to stdout, for command-line output
+	Quiet bool
 }
 
-// NewDatabase creates a database instance based on the given configuration
+// NewDatabase creates a database instance based on the given configuration. Queries run
+// through a pool that dials the database again when the server connection is lost.
 func NewDatabase(config Config) (DBInterface, error) {
+	config = config.withDefaults()
 	switch config.Type {
 	case SQLite:
-		// Use default path if none is specified
-		if config.Path == "" {
-			config.Path = "data/tairitsu.db"
-		}
-
-		if err := ensureSQLiteDir(config.Path); err != nil {
+		if err := ensureSQLiteDir(config.sqlitePath()); err != nil {
 			return nil, err
 		}
-
-		db, err := gorm.Open(sqlite.Open(config.Path+"?_journal_mode=WAL&_busy_timeout=5000"), &gorm.Config{})
-		if err != nil {
-			return nil, fmt.Errorf("failed to connect to SQLite database: %w", err)
-		}
-
-		sqlDB, err := db.DB()
-		if err != nil {
-			return nil, fmt.Errorf("failed to get SQLite database instance: %w", err)
+	case MySQL, PostgreSQL:
+	default:
+		// Return error if database type is not specified
+		if config.Type == "" {
+			return nil, fmt.Errorf("database type is required")
 		}
+		return nil, fmt.Errorf("unsupported database type: %s", config.Type)
+	}
 
-		// Configure connection pool
-		sqlDB.SetMaxIdleConns(10)
-		sqlDB.SetMaxOpenConns(100)
-		sqlDB.SetConnMaxLifetime(time.Hour)
-		sqlDB.SetConnMaxIdleTime(30 * time.Minute)
-
-		return &GormDB{db: db}, nil
+	pool, err := openPool(config)
+	if err != nil {
+		return nil, err
+	}
 
-	case MySQL:
-		// Build DSN
-		dsn := fmt.Sprintf("%s:%s@tcp(%s:%d)/%s?charset=utf8mb4&parseTime=True&loc=Local",
-			config.User, config.Pass, config.Host, config.Port, config.Name)
+	db, err := gorm.Open(newDialector(config, pool), gormConfig(config))
+	if err != nil {
+		_ = pool.Close()
+		return nil, fmt.Errorf("failed to connect to %s database: %w", displayName(config.Type), err)
+	}
+	return &GormDB{db: db, pool: pool}, nil
+}
 
-		db, err := gorm.Open(mysql.Open(dsn), &gorm.Config{})
+// openPool opens the pool queries run through: a writer and a reader pool for a SQLite file,
+// and a pool that reconnects after a lost connection for MySQL and PostgreSQL
+func openPool(config Config) (connPool, error) {
+	sqlDB, err := openSQLDB(config)
+	if err != nil {
+		return nil, err
+	}
+	if config.Type == SQLite {
+		readers, err := openSQLDB(config)
 		if err != nil {
-			return nil, fmt.Errorf("failed to connect to MySQL database: %w", err)
+			_ = sqlDB.Close()
+			return nil, err
 		}
+		return newSQLitePool(sqlDB, readers), nil
+	}
+	return newReconnectingPool(sqlDB, func() (*sql.DB, error) {
+		return openSQLDB(config)
+	}), nil
+}
 
-		sqlDB, err := db.DB()
-		if err != nil {
-			return nil, fmt.Errorf("failed to get MySQL database instance: %w", err)
-		}
+func gormConfig(config Config) *gorm.Config {
+	if config.Quiet {
+		return &gorm.Config{Logger: gormlogger.Default.LogMode(gormlogger.Silent)}
+	}
+	return &gorm.Config{}
+}
 
-		// Configure connection pool
-		sqlDB.SetMaxIdleConns(10)
-		sqlDB.SetMaxOpenConns(100)
-		sqlDB.SetConnMaxLifetime(time.Hour)
-		sqlDB.SetConnMaxIdleTime(30 * time.Minute)
+// openSQLDB dials the configured database and applies the connection pool settings
+func openSQLDB(config Config) (*sql.DB, error) {
+	db, err := gorm.Open(newDialector(config, nil), gormConfig(config))
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to %s database: %w", displayName(config.Type), err)
+	}
+
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get %s database instance: %w", displayName(config.Type), err)
+	}
 
-		return &GormDB{db: db}, nil
+	// Configure connection pool
+	sqlDB.SetMaxIdleConns(positiveOr(config.MaxIdleConns, defaultMaxIdleConns))
+	sqlDB.SetMaxOpenConns(positiveOr(config.MaxOpenConns, defaultMaxOpenConns))
+	sqlDB.SetConnMaxLifetime(positiveOr(config.ConnMaxLifetime, defaultConnMaxLifetime))
+	sqlDB.SetConnMaxIdleTime(defaultConnMaxIdleTime)
 
+	return sqlDB, nil
+}
+
+// newDialector builds the GORM dialector for config; a nil conn makes GORM dial itself
+func newDialector(config Config, conn gorm.ConnPool) gorm.Dialector {
+	switch config.Type {
+	case MySQL:
+		dsn := fmt.Sprintf("%s:%s@tcp(%s:%d)/%s?charset=utf8mb4&parseTime=True&loc=Local",
+			config.User, config.Pass, config.Host, config.Port, config.Name)
+		return mysql.New(mysql.Config{DSN: dsn, Conn: conn})
 	case PostgreSQL:
 		sslMode := "require"
 		dsn := fmt.Sprintf("host=%s user=%s password=%s dbname=%s port=%d sslmode=%s TimeZone=Asia/Shanghai",
 			config.Host, config.User, config.Pass, config.Name, config.Port, sslMode)
+		return postgres.New(postgres.Config{DSN: dsn, Conn: conn})
+	default:
+		return sqlite.New(sqlite.Config{DSN: sqliteDSN(config), Conn: conn})
+	}
+}
 
-		db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
-		if err != nil {
-			return nil, fmt.Errorf("failed to connect to PostgreSQL database: %w", err)
-		}
-
-		sqlDB, err := db.DB()
-		if err != nil {
-			return nil, fmt.Errorf("failed to get PostgreSQL database instance: %w", err)
-		}
+// sqliteDSN applies the SQLite pragmas. Transactions begin IMMEDIATE, so they wait for the
+// write lock up to the busy timeout instead of failing when a read turns into a write.
+func sqliteDSN(config Config) string {
+	params := url.Values{}
+	params.Set("_journal_mode", defaultSQLiteJournalMode)
+	if config.SQLiteJournalMode != "" {
+		params.Set("_journal_mode", strings.ToUpper(config.SQLiteJournalMode))
+	}
+	params.Set("_busy_timeout", strconv.FormatInt(positiveOr(config.SQLiteBusyTimeout, defaultSQLiteBusyTimeout).Milliseconds(), 10))
+	if config.SQLiteSynchronous != "" {
+		params.Set("_synchronous", strings.ToUpper(config.SQLiteSynchronous))
+	}
+	params.Set("_txlock", "immediate")
+	return config.sqlitePath() + "?" + params.Encode()
+}
 
-		// Configure connection pool
-		sqlDB.SetMaxIdleConns(10)
-		sqlDB.SetMaxOpenConns(100)
-		sqlDB.SetConnMaxLifetime(time.Hour)
-		sqlDB.SetConnMaxIdleTime(30 * time.Minute)
+func displayName(dbType DatabaseType) string {
+	switch dbType {
+	case MySQL:
+		return "MySQL"
+	case PostgreSQL:
+		return "PostgreSQL"
+	default:
+		return "SQLite"
+	}
+}
 
-		return &GormDB{db: db}, nil
+func positiveOr[T int | time.Duration](value T, fallback T) T {
+	if value > 0 {
+		return value
+	}
+	return fallback
+}
 
-	default:
-		// Return error if database type is not specified
-		if config.Type == "" {
-			return nil, fmt.Errorf("database type is required")
-		}
-		return nil, fmt.Errorf("unsupported database type: %s", config.Type)
+// withDefaults fills in the SQLite path, so the setup wizard saves the same file the factory opens
+func (c Config) withDefaults() Config {
+	if c.Type == SQLite && c.Path == "" {
+		c.Path = config.DefaultSQLitePath
 	}
+	return c
+}
+
+// sqlitePath returns the SQLite file, with a relative path interpreted in the data directory
+func (c Config) sqlitePath() string {
+	return paths.Resolve(c.Path)
+}
+
+// ConfigFromRequest converts the setup wizard's database settings
+func ConfigFromRequest(req models.DatabaseConfig) Config {
+	return Config{
+		Type: DatabaseType(req.Type),
+		Path: req.Path,
+		Host: req.Host,
+		Port: req.Port,
+		User: req.User,
+		Pass: req.Pass,
+		Name: req.Name,
+	}.withDefaults()
 }
 
 func LoadConfigFromApp(cfg *config.Config) Config {
@@ -130,32 +230,43 @@ func LoadConfigFromApp(cfg *config.Config) Config {
 	password, _ := config.GetDatabasePasswordFrom(cfg)
 
 	return Config{
-		Type: DatabaseType(cfg.Database.Type),
-		Path: cfg.Database.Path,
-		Host: cfg.Database.Host,
-		Port: cfg.Database.Port,
-		User: cfg.Database.User,
-		Pass: password,
-		Name: cfg.Database.Name,
-	}
+		Type:            cfg.Database.Type,
+		Path:            cfg.Database.Path,
+		Host:            cfg.Database.Host,
+		Port:            cfg.Database.Port,
+		User:            cfg.Database.User,
+		Pass:            password,
+		Name:            cfg.Database.Name,
+		MaxIdleConns:    cfg.Database.MaxIdleConns,
+		MaxOpenConns:    cfg.Database.MaxOpenConns,
+		ConnMaxLifetime: time.Duration(cfg.Database.ConnMaxLifetimeMinutes) * time.Minute,
+
+		SQLiteBusyTimeout: time.Duration(cfg.Database.SQLiteBusyTimeoutMillis) * time.Millisecond,
+		SQLiteJournalMode: cfg.Database.SQLiteJournalMode,
+		SQLiteSynchronous: cfg.Database.SQLiteSynchronous,
+	}.withDefaults()
 }
 
+// ErrResetNotConfirmed is returned when a reset is requested without confirmation
+var ErrResetNotConfirmed = errors.New("database reset was not confirmed")
+
 // ResetDatabase is the generic handler for resetting a database.
 // WARNING: This operation will delete all data in the database. Use with caution.
-func ResetDatabase(config Config) error {
+// confirmed must be set by the caller that asked the user, otherwise nothing is touched.
+func ResetDatabase(config Config, confirmed bool) error {
+	if !confirmed {
+		logger.Warn("refusing unconfirmed database reset", zap.String("type", string(config.Type)))
+		return ErrResetNotConfirmed
+	}
 	logger.Info("starting database reset", zap.String("type", string(config.Type)))
 
+	config = config.withDefaults()
 	switch config.Type {
 	case SQLite:
-		// Use default path if none is specified
-		if config.Path == "" {
-			config.Path = "data/tairitsu.db"
-		}
-
-		logger.Info("resetting SQLite database", zap.String("path", config.Path))
+		logger.Info("resetting SQLite database", zap.String("path", config.sqlitePath()))
 
 		// Delete the SQLite database file to reset it
-		err := os.Remove(config.Path)
+		err := os.Remove(config.sqlitePath())
 		if err != nil && !os.IsNotExist(err) {
 			logger.Error("failed to delete SQLite database file", zap.Error(err))
 			return fmt.Errorf("failed to reset SQLite database: %w", err)
@@ -169,13 +280,8 @@ func ResetDatabase(config Config) error {
 		logger.Info("SQLite database reset successfully")
 		return nil
 
-	case MySQL:
-		logger.Warn("MySQL database reset is not currently supported")
-		return fmt.Errorf("only SQLite is currently supported; MySQL reset is not supported")
-
-	case PostgreSQL:
-		logger.Warn("PostgreSQL database reset is not currently supported")
-		return fmt.Errorf("only SQLite is currently supported; PostgreSQL reset is not supported")
+	case MySQL, PostgreSQL:
+		return resetServerDatabase(config)
 
 	default:
 		logger.Error("unsupported database type", zap.String("type", string(config.Type)))
@@ -183,6 +289,36 @@ func ResetDatabase(config Config) error {
 	}
 }
 
+// resetServerDatabase drops and recreates Tairitsu's tables in the configured MySQL or
+// PostgreSQL database. The database itself is kept, since the configured user may not be
+// allowed to create it again.
+func resetServerDatabase(config Config) error {
+	logger.Info("resetting database tables", zap.String("type", string(config.Type)), zap.String("host", config.Host), zap.String("name", config.Name))
+
+	db, err := NewDatabase(config)
+	if err != nil {
+		return fmt.Errorf("failed to reset %s database: %w", config.Type, err)
+	}
+	defer func() {
+		if closeErr := db.Close(); closeErr != nil {
+			logger.Warn("failed to close database after reset", zap.Error(closeErr))
+		}
+	}()
+
+	gormDB, ok := db.(*GormDB)
+	if !ok {
+		return fmt.Errorf("failed to reset %s database: unexpected implementation %T", config.Type, db)
+	}
+	// PostgreSQL runs DDL inside transactions, so a failed reset leaves the old tables in place
+	if err := gormDB.resetTables(config.Type == PostgreSQL); err != nil {
+		logger.Error("failed to reset database tables", zap.String("type", string(config.Type)), zap.Error(err))
+		return fmt.Errorf("failed to reset %s database: %w", config.Type, err)
+	}
+
+	logger.Info("database tables reset successfully", zap.String("type", string(config.Type)))
+	return nil
+}
+
 func ensureSQLiteDir(path string) error {
 	dir := filepath.Dir(path)
 	if dir == "." || dir == "" {
@@ -202,7 +338,7 @@ func SaveConfigToApp(cfg *config.Config, dbConfig Config) error {
 	}
 
 	// Update database configuration
-	cfg.Database.Type = string(dbConfig.Type)
+	cfg.Database.Type = dbConfig.Type
 	cfg.Database.Path = dbConfig.Path
 	cfg.Database.Host = dbConfig.Host
 	cfg.Database.Port = dbConfig.Port
@@ -1,6 +1,7 @@
 package database
 
 import (
+	"context"
 	"fmt"
 	"time"
 
@@ -11,19 +12,64 @@ import (
 
 // GormDB is the GORM-based database implementation
 type GormDB struct {
-	db *gorm.DB
+	db   *gorm.DB
+	pool connPool // Nil for handles bound to a transaction
 }
 
-// Init initializes the database
+// connPool is the pool a GormDB runs queries through, which it pings and closes itself
+type connPool interface {
+	gorm.ConnPool
+	Ping(ctx context.Context) error
+	Close() error
+}
+
+// appModels lists every table Tairitsu owns
+func appModels() []any {
+	return []any{&models.User{}, &models.Network{}, &models.Session{}, &models.NetworkViewer{}, &models.AuditLog{}, &models.AuditAnchor{}, &models.ApiToken{}, &models.MemberStatusEvent{}, &models.ControllerTraceEvent{}, &models.PasswordResetToken{}, &models.PlanetGeneration{}, &models.MemberMetadata{}, &models.PendingApproval{}, &models.DeviceClaim{}, &models.Organization{}, &models.OrganizationMember{}, &models.Webhook{}, &models.WebhookDelivery{}, &models.NetworkRuleSource{}, &models.NetworkTag{}, &models.NetworkCapability{}, &models.NetworkLockdown{}, &models.Operation{}, &models.OperationItem{}}
+}
+
+// Init initializes the database by applying the pending migrations
 func (g *GormDB) Init() error {
-	// Auto-migrate user models
-	if err := g.db.AutoMigrate(&models.User{}, &models.Network{}, &models.Session{}, &models.NetworkViewer{}); err != nil {
-		return fmt.Errorf("failed to auto-migrate models: %w", err)
+	_, err := g.MigrateUp()
+	return err
+}
+
+// seedDefaultOrganization creates the default organization and places networks without an
+// organization, such as those created before organizations existed, in it
+func seedDefaultOrganization(db *gorm.DB) error {
+	now := time.Now()
+	organization := models.Organization{ID: models.DefaultOrganizationID, Name: "Default", CreatedAt: now, UpdatedAt: now}
+	if err := db.Clauses(clause.OnConflict{DoNothing: true}).Create(&organization).Error; err != nil {
+		return fmt.Errorf("failed to create the default organization: %w", err)
+	}
+	if err := db.Model(&models.Network{}).Where("organization_id = '' OR organization_id IS NULL").
+		Update("organization_id", models.DefaultOrganizationID).Error; err != nil {
+		return fmt.Errorf("failed to assign networks to the default organization: %w", err)
 	}
 	return nil
 }
 
-// WithTransaction executes database operations within a transaction
+// resetTables drops Tairitsu's tables and migrates them again, leaving other tables in the
+// schema alone. With transactional set the reset is all-or-nothing; MySQL commits every DDL
+// statement implicitly, so it cannot be.
+func (g *GormDB) resetTables(transactional bool) error {
+	reset := func(tx *gorm.DB) error {
+		if err := tx.Migrator().DropTable(append(appModels(), &schemaMigration{})...); err != nil {
+			return fmt.Errorf("failed to drop tables: %w", err)
+		}
+		_, err := migrateUp(tx, false)
+		return err
+	}
+	if transactional {
+		return g.db.Transaction(reset)
+	}
+	return reset(g.db)
+}
+
+// WithTransaction executes database operations within a transaction. The transaction
+// is rolled back when fn returns an error or panics. Calls made on the tx handle join
+// the outer transaction through a savepoint, so an inner failure only undoes the inner
+// writes while an outer rollback undoes everything.
 func (g *GormDB) WithTransaction(fn func(DBInterface) error) error {
 	return g.db.Transaction(func(tx *gorm.DB) error {
 		return fn(&GormDB{db: tx})
@@ -62,6 +108,32 @@ func (g *GormDB) GetUserByUsername(username string) (*models.User, error) {
 	return &user, nil
 }
 
+// GetUserByEmail retrieves a user by their normalized email address
+func (g *GormDB) GetUserByEmail(email string) (*models.User, error) {
+	var user models.User
+	result := g.db.First(&user, "email = ?", email)
+	if result.Error != nil {
+		if result.Error == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, result.Error
+	}
+	return &user, nil
+}
+
+// GetUserByExternalSubject retrieves the user linked to an OpenID Connect subject
+func (g *GormDB) GetUserByExternalSubject(subject string) (*models.User, error) {
+	var user models.User
+	result := g.db.First(&user, "external_subject = ?", subject)
+	if result.Error != nil {
+		if result.Error == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, result.Error
+	}
+	return &user, nil
+}
+
 // GetAllUsers retrieves all users
 func (g *GormDB) GetAllUsers() ([]*models.User, error) {
 	var users []*models.User
@@ -91,6 +163,13 @@ func (g *GormDB) UpdateUser(user *models.User) error {
 	return result.Error
 }
 
+// ReplaceUserPasswordHash stores hash as the user's password hash if it is still
+// previousHash, and reports whether it did
+func (g *GormDB) ReplaceUserPasswordHash(userID, previousHash, hash string) (bool, error) {
+	result := g.db.Model(&models.User{}).Where("id = ? AND password = ?", userID, previousHash).Update("password", hash)
+	return result.RowsAffected == 1, result.Error
+}
+
 // DeleteUser deletes a user
 func (g *GormDB) DeleteUser(id string) error {
 	result := g.db.Delete(&models.User{}, "id = ?", id)
@@ -137,6 +216,326 @@ func (g *GormDB) DeleteExpiredSessions(before time.Time) error {
 	return result.Error
 }
 
+// RevokeAllSessions marks every active session as revoked
+func (g *GormDB) RevokeAllSessions(at time.Time) (int64, error) {
+	result := g.db.Model(&models.Session{}).Where("revoked_at IS NULL").Updates(map[string]any{
+		"revoked_at": at,
+		"updated_at": at,
+	})
+	return result.RowsAffected, result.Error
+}
+
+// CreateMemberStatusEvents stores a batch of member status transitions
+func (g *GormDB) CreateMemberStatusEvents(events []*models.MemberStatusEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+	result := g.db.Create(events)
+	return result.Error
+}
+
+// ListLatestMemberStatusEvents returns the most recent transition of every member in a network
+func (g *GormDB) ListLatestMemberStatusEvents(networkID string) ([]*models.MemberStatusEvent, error) {
+	var events []*models.MemberStatusEvent
+	latest := g.db.Model(&models.MemberStatusEvent{}).Select("MAX(id)").Where("network_id = ?", networkID).Group("member_id")
+	result := g.db.Where("id IN (?)", latest).Find(&events)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return events, nil
+}
+
+// ListLatestOnlineMemberStatusEvents returns the most recent transition to online of every
+// member in a network that was ever recorded online
+func (g *GormDB) ListLatestOnlineMemberStatusEvents(networkID string) ([]*models.MemberStatusEvent, error) {
+	var events []*models.MemberStatusEvent
+	latest := g.db.Model(&models.MemberStatusEvent{}).Select("MAX(id)").Where("network_id = ? AND online = ?", networkID, true).Group("member_id")
+	result := g.db.Where("id IN (?)", latest).Find(&events)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return events, nil
+}
+
+// ListMemberStatusEvents returns the transitions of a member within [from, to], oldest first
+func (g *GormDB) ListMemberStatusEvents(networkID, memberID string, from, to time.Time) ([]*models.MemberStatusEvent, error) {
+	var events []*models.MemberStatusEvent
+	result := g.db.Where("network_id = ? AND member_id = ? AND changed_at >= ? AND changed_at <= ?", networkID, memberID, from, to).
+		Order("changed_at asc, id asc").Find(&events)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return events, nil
+}
+
+// ListMemberStatusEventsAfter pages through the whole member status history in ID order
+func (g *GormDB) ListMemberStatusEventsAfter(afterID uint64, limit int) ([]*models.MemberStatusEvent, error) {
+	var events []*models.MemberStatusEvent
+	query := g.db.Where("id > ?", afterID).Order("id ASC")
+	if limit > 0 {
+		query = query.Limit(limit)
+	}
+	if err := query.Find(&events).Error; err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+// GetMemberStatusEventBefore returns the last transition of a member before the given time
+func (g *GormDB) GetMemberStatusEventBefore(networkID, memberID string, before time.Time) (*models.MemberStatusEvent, error) {
+	var event models.MemberStatusEvent
+	result := g.db.Where("network_id = ? AND member_id = ? AND changed_at < ?", networkID, memberID, before).
+		Order("changed_at desc, id desc").First(&event)
+	if result.Error != nil {
+		if result.Error == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, result.Error
+	}
+	return &event, nil
+}
+
+// CreateControllerTraceEvents stores a batch of forwarded controller trace lines
+func (g *GormDB) CreateControllerTraceEvents(events []*models.ControllerTraceEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+	result := g.db.Create(events)
+	return result.Error
+}
+
+// QueryControllerTraceEvents lists stored controller trace lines, newest first
+func (g *GormDB) QueryControllerTraceEvents(q models.ControllerTraceQuery) ([]*models.ControllerTraceEvent, error) {
+	var events []*models.ControllerTraceEvent
+	query := g.db.Model(&models.ControllerTraceEvent{})
+	if q.NetworkID != "" {
+		query = query.Where("network_id = ?", q.NetworkID)
+	}
+	if q.MemberID != "" {
+		query = query.Where("member_id = ?", q.MemberID)
+	}
+	if q.Parsed != nil {
+		query = query.Where("parsed = ?", *q.Parsed)
+	}
+	if q.BeforeID > 0 {
+		query = query.Where("id < ?", q.BeforeID)
+	}
+	query = query.Order("id DESC")
+	if q.Limit > 0 {
+		query = query.Limit(q.Limit)
+	}
+	if err := query.Find(&events).Error; err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+// DeleteControllerTraceEventsBefore removes trace lines received before the given time
+func (g *GormDB) DeleteControllerTraceEventsBefore(before time.Time) (int64, error) {
+	result := g.db.Where("received_at < ?", before).Delete(&models.ControllerTraceEvent{})
+	return result.RowsAffected, result.Error
+}
+
+// CreatePlanetGeneration stores a generated planet
+func (g *GormDB) CreatePlanetGeneration(generation *models.PlanetGeneration) error {
+	result := g.db.Create(generation)
+	return result.Error
+}
+
+// ListPlanetGenerations lists generated planets newest first, without their file data
+func (g *GormDB) ListPlanetGenerations(limit int) ([]*models.PlanetGeneration, error) {
+	var generations []*models.PlanetGeneration
+	query := g.db.Omit("data").Order("id DESC")
+	if limit > 0 {
+		query = query.Limit(limit)
+	}
+	if err := query.Find(&generations).Error; err != nil {
+		return nil, err
+	}
+	return generations, nil
+}
+
+// GetPlanetGenerationByID retrieves a generated planet with its file data
+func (g *GormDB) GetPlanetGenerationByID(id uint64) (*models.PlanetGeneration, error) {
+	var generation models.PlanetGeneration
+	result := g.db.First(&generation, "id = ?", id)
+	if result.Error != nil {
+		if result.Error == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, result.Error
+	}
+	return &generation, nil
+}
+
+// DeletePlanetGenerationsBeyond removes all but the newest keep generated planets
+func (g *GormDB) DeletePlanetGenerationsBeyond(keep int) (int64, error) {
+	if keep <= 0 {
+		result := g.db.Where("1 = 1").Delete(&models.PlanetGeneration{})
+		return result.RowsAffected, result.Error
+	}
+	var oldestKept models.PlanetGeneration
+	result := g.db.Select("id").Order("id DESC").Offset(keep - 1).Limit(1).Find(&oldestKept)
+	if result.Error != nil || result.RowsAffected == 0 {
+		return 0, result.Error
+	}
+	result = g.db.Where("id < ?", oldestKept.ID).Delete(&models.PlanetGeneration{})
+	return result.RowsAffected, result.Error
+}
+
+// CreateWebhook creates a new webhook
+func (g *GormDB) CreateWebhook(webhook *models.Webhook) error {
+	return g.db.Create(webhook).Error
+}
+
+// GetWebhookByID retrieves a webhook by ID, or nil when it does not exist
+func (g *GormDB) GetWebhookByID(id string) (*models.Webhook, error) {
+	var webhook models.Webhook
+	result := g.db.First(&webhook, "id = ?", id)
+	if result.Error != nil {
+		if result.Error == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, result.Error
+	}
+	return &webhook, nil
+}
+
+// ListWebhooks returns every webhook, oldest first
+func (g *GormDB) ListWebhooks() ([]*models.Webhook, error) {
+	var webhooks []*models.Webhook
+	if err := g.db.Order("created_at ASC").Find(&webhooks).Error; err != nil {
+		return nil, err
+	}
+	return webhooks, nil
+}
+
+func (g *GormDB) UpdateWebhook(webhook *models.Webhook) error {
+	return g.db.Save(webhook).Error
+}
+
+// DeleteWebhook deletes a webhook together with its delivery log
+func (g *GormDB) DeleteWebhook(id string) error {
+	return g.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&models.WebhookDelivery{}, "webhook_id = ?", id).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&models.Webhook{}, "id = ?", id).Error
+	})
+}
+
+func (g *GormDB) CreateWebhookDelivery(delivery *models.WebhookDelivery) error {
+	return g.db.Create(delivery).Error
+}
+
+func (g *GormDB) UpdateWebhookDelivery(delivery *models.WebhookDelivery) error {
+	return g.db.Save(delivery).Error
+}
+
+// ListWebhookDeliveries returns the latest deliveries of a webhook, newest first
+func (g *GormDB) ListWebhookDeliveries(webhookID string, limit int) ([]*models.WebhookDelivery, error) {
+	var deliveries []*models.WebhookDelivery
+	if err := g.db.Where("webhook_id = ?", webhookID).Order("id DESC").Limit(limit).Find(&deliveries).Error; err != nil {
+		return nil, err
+	}
+	return deliveries, nil
+}
+
+// DeleteWebhookDeliveriesBeyond keeps the newest deliveries of a webhook and deletes the rest
+func (g *GormDB) DeleteWebhookDeliveriesBeyond(webhookID string, keep int) (int64, error) {
+	var oldestKept models.WebhookDelivery
+	result := g.db.Select("id").Where("webhook_id = ?", webhookID).Order("id DESC").Offset(keep - 1).Limit(1).Find(&oldestKept)
+	if result.Error != nil || result.RowsAffected == 0 {
+		return 0, result.Error
+	}
+	result = g.db.Where("webhook_id = ? AND id < ?", webhookID, oldestKept.ID).Delete(&models.WebhookDelivery{})
+	return result.RowsAffected, result.Error
+}
+
+// CreateApiToken creates a new API token
+func (g *GormDB) CreateApiToken(token *models.ApiToken) error {
+	result := g.db.Create(token)
+	return result.Error
+}
+
+// GetApiTokenByID retrieves an API token by ID
+func (g *GormDB) GetApiTokenByID(id string) (*models.ApiToken, error) {
+	var token models.ApiToken
+	result := g.db.First(&token, "id = ?", id)
+	if result.Error != nil {
+		if result.Error == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, result.Error
+	}
+	return &token, nil
+}
+
+// GetApiTokenByHash retrieves an API token by the hash of its plaintext
+func (g *GormDB) GetApiTokenByHash(hash string) (*models.ApiToken, error) {
+	var token models.ApiToken
+	result := g.db.First(&token, "token_hash = ?", hash)
+	if result.Error != nil {
+		if result.Error == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, result.Error
+	}
+	return &token, nil
+}
+
+// GetApiTokensByUserID retrieves all API tokens for a user
+func (g *GormDB) GetApiTokensByUserID(userID string) ([]*models.ApiToken, error) {
+	var tokens []*models.ApiToken
+	result := g.db.Where("user_id = ?", userID).Order("created_at desc").Find(&tokens)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return tokens, nil
+}
+
+// UpdateApiToken updates an API token
+func (g *GormDB) UpdateApiToken(token *models.ApiToken) error {
+	result := g.db.Save(token)
+	return result.Error
+}
+
+// CreatePasswordResetToken stores a password reset token
+func (g *GormDB) CreatePasswordResetToken(token *models.PasswordResetToken) error {
+	result := g.db.Create(token)
+	return result.Error
+}
+
+// GetPasswordResetTokenByHash retrieves a password reset token by the hash of its plaintext
+func (g *GormDB) GetPasswordResetTokenByHash(hash string) (*models.PasswordResetToken, error) {
+	var token models.PasswordResetToken
+	result := g.db.First(&token, "token_hash = ?", hash)
+	if result.Error != nil {
+		if result.Error == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, result.Error
+	}
+	return &token, nil
+}
+
+// ConsumePasswordResetToken marks the reset token with the given hash as used unless it
+// already is, returning how many rows changed
+func (g *GormDB) ConsumePasswordResetToken(hash string, at time.Time) (int64, error) {
+	result := g.db.Model(&models.PasswordResetToken{}).
+		Where("token_hash = ? AND used_at IS NULL", hash).
+		Update("used_at", at)
+	return result.RowsAffected, result.Error
+}
+
+// InvalidatePasswordResetTokens marks every unused reset token of a user as used
+func (g *GormDB) InvalidatePasswordResetTokens(userID string, at time.Time) (int64, error) {
+	result := g.db.Model(&models.PasswordResetToken{}).
+		Where("user_id = ? AND used_at IS NULL", userID).
+		Update("used_at", at)
+	return result.RowsAffected, result.Error
+}
+
 // HasAdminUser checks whether an admin user already exists
 func (g *GormDB) HasAdminUser() (bool, error) {
 	var count int64
@@ -147,6 +546,13 @@ func (g *GormDB) HasAdminUser() (bool, error) {
 	return count > 0, nil
 }
 
+// CountUsersByRole counts the users holding a role
+func (g *GormDB) CountUsersByRole(role string) (int64, error) {
+	var count int64
+	result := g.db.Model(&models.User{}).Where("role = ?", role).Count(&count)
+	return count, result.Error
+}
+
 // CreateNetwork creates a new network
 func (g *GormDB) CreateNetwork(network *models.Network) error {
 	result := g.db.Create(network)
@@ -251,8 +657,579 @@ func (g *GormDB) DeleteAllNetworkViewers(networkID string) error {
 	return g.db.Delete(&models.NetworkViewer{}, "network_id = ?", networkID).Error
 }
 
-// Ping checks if the database connection is alive.
+// GetMemberMetadata returns the metadata of a member, or nil when none was saved
+func (g *GormDB) GetMemberMetadata(networkID, memberID string) (*models.MemberMetadata, error) {
+	var metadata models.MemberMetadata
+	result := g.db.First(&metadata, "network_id = ? AND member_id = ?", networkID, memberID)
+	if result.Error != nil {
+		if result.Error == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, result.Error
+	}
+	return &metadata, nil
+}
+
+// ListMemberMetadata returns the metadata of every member in a network
+func (g *GormDB) ListMemberMetadata(networkID string) ([]*models.MemberMetadata, error) {
+	var metadata []*models.MemberMetadata
+	if err := g.db.Where("network_id = ?", networkID).Find(&metadata).Error; err != nil {
+		return nil, err
+	}
+	return metadata, nil
+}
+
+// ListMemberMetadataExpiringBy returns the metadata of members whose authorization deadline
+// is at or before the given time, in every network
+func (g *GormDB) ListMemberMetadataExpiringBy(deadline time.Time) ([]*models.MemberMetadata, error) {
+	var metadata []*models.MemberMetadata
+	if err := g.db.Where("authorized_until IS NOT NULL AND authorized_until <= ?", deadline).Order("authorized_until ASC").Find(&metadata).Error; err != nil {
+		return nil, err
+	}
+	return metadata, nil
+}
+
+// SaveMemberMetadata creates or replaces the metadata of a member
+func (g *GormDB) SaveMemberMetadata(metadata *models.MemberMetadata) error {
+	return g.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "network_id"}, {Name: "member_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"display_name", "notes", "tags", "authorized_until", "updated_by", "updated_at"}),
+	}).Create(metadata).Error
+}
+
+func (g *GormDB) DeleteMemberMetadata(networkID, memberID string) error {
+	return g.db.Delete(&models.MemberMetadata{}, "network_id = ? AND member_id = ?", networkID, memberID).Error
+}
+
+func (g *GormDB) DeleteAllMemberMetadata(networkID string) error {
+	return g.db.Delete(&models.MemberMetadata{}, "network_id = ?", networkID).Error
+}
+
+// GetNetworkRuleSource returns the rules source of a network, or nil when none was saved
+func (g *GormDB) GetNetworkRuleSource(networkID string) (*models.NetworkRuleSource, error) {
+	var source models.NetworkRuleSource
+	result := g.db.First(&source, "network_id = ?", networkID)
+	if result.Error != nil {
+		if result.Error == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, result.Error
+	}
+	return &source, nil
+}
+
+// SaveNetworkRuleSource creates or replaces the rules source of a network
+func (g *GormDB) SaveNetworkRuleSource(source *models.NetworkRuleSource) error {
+	return g.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "network_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"source", "updated_by", "updated_at"}),
+	}).Create(source).Error
+}
+
+func (g *GormDB) DeleteNetworkRuleSource(networkID string) error {
+	return g.db.Delete(&models.NetworkRuleSource{}, "network_id = ?", networkID).Error
+}
+
+// ListNetworkTags returns the tag definitions of a network ordered by tag ID
+func (g *GormDB) ListNetworkTags(networkID string) ([]*models.NetworkTag, error) {
+	tags := []*models.NetworkTag{}
+	if err := g.db.Where("network_id = ?", networkID).Order("tag_id").Find(&tags).Error; err != nil {
+		return nil, err
+	}
+	return tags, nil
+}
+
+func (g *GormDB) CreateNetworkTag(tag *models.NetworkTag) error {
+	return g.db.Create(tag).Error
+}
+
+func (g *GormDB) UpdateNetworkTag(tag *models.NetworkTag) error {
+	return g.db.Save(tag).Error
+}
+
+func (g *GormDB) DeleteNetworkTag(networkID string, tagID uint32) error {
+	return g.db.Delete(&models.NetworkTag{}, "network_id = ? AND tag_id = ?", networkID, tagID).Error
+}
+
+func (g *GormDB) DeleteAllNetworkTags(networkID string) error {
+	return g.db.Delete(&models.NetworkTag{}, "network_id = ?", networkID).Error
+}
+
+// ListNetworkCapabilities returns the capability definitions of a network ordered by capability ID
+func (g *GormDB) ListNetworkCapabilities(networkID string) ([]*models.NetworkCapability, error) {
+	capabilities := []*models.NetworkCapability{}
+	if err := g.db.Where("network_id = ?", networkID).Order("capability_id").Find(&capabilities).Error; err != nil {
+		return nil, err
+	}
+	return capabilities, nil
+}
+
+func (g *GormDB) CreateNetworkCapability(capability *models.NetworkCapability) error {
+	return g.db.Create(capability).Error
+}
+
+func (g *GormDB) DeleteNetworkCapability(networkID string, capabilityID uint32) error {
+	return g.db.Delete(&models.NetworkCapability{}, "network_id = ? AND capability_id = ?", networkID, capabilityID).Error
+}
+
+func (g *GormDB) DeleteAllNetworkCapabilities(networkID string) error {
+	return g.db.Delete(&models.NetworkCapability{}, "network_id = ?", networkID).Error
+}
+
+// CreatePendingApproval queues a member unless it already has an entry, and reports whether
+// a new entry was created
+func (g *GormDB) CreatePendingApproval(approval *models.PendingApproval) (bool, error) {
+	result := g.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "network_id"}, {Name: "member_id"}},
+		DoNothing: true,
+	}).Create(approval)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// GetPendingApproval retrieves a queue entry by ID, or nil when it does not exist
+func (g *GormDB) GetPendingApproval(id uint64) (*models.PendingApproval, error) {
+	var approval models.PendingApproval
+	result := g.db.First(&approval, "id = ?", id)
+	if result.Error != nil {
+		if result.Error == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, result.Error
+	}
+	return &approval, nil
+}
+
+// ListPendingApprovals returns the undecided entries of the given networks, oldest first
+func (g *GormDB) ListPendingApprovals(networkIDs []string) ([]*models.PendingApproval, error) {
+	approvals := []*models.PendingApproval{}
+	if len(networkIDs) == 0 {
+		return approvals, nil
+	}
+	err := g.db.Where("network_id IN ? AND status = ?", networkIDs, models.ApprovalPending).
+		Order("detected_at ASC, id ASC").
+		Find(&approvals).Error
+	if err != nil {
+		return nil, err
+	}
+	return approvals, nil
+}
+
+func (g *GormDB) SavePendingApproval(approval *models.PendingApproval) error {
+	return g.db.Save(approval).Error
+}
+
+func (g *GormDB) DeletePendingApproval(networkID, memberID string) error {
+	return g.db.Delete(&models.PendingApproval{}, "network_id = ? AND member_id = ?", networkID, memberID).Error
+}
+
+func (g *GormDB) DeleteAllPendingApprovals(networkID string) error {
+	return g.db.Delete(&models.PendingApproval{}, "network_id = ?", networkID).Error
+}
+
+// CreateDeviceClaim stores a new device claim
+func (g *GormDB) CreateDeviceClaim(claim *models.DeviceClaim) error {
+	return g.db.Create(claim).Error
+}
+
+// GetDeviceClaim retrieves a device claim by ID, or nil when it does not exist
+func (g *GormDB) GetDeviceClaim(id uint64) (*models.DeviceClaim, error) {
+	var claim models.DeviceClaim
+	result := g.db.First(&claim, "id = ?", id)
+	if result.Error != nil {
+		if result.Error == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, result.Error
+	}
+	return &claim, nil
+}
+
+// ListDeviceClaimsByUser returns the claims of one user, oldest first
+func (g *GormDB) ListDeviceClaimsByUser(userID string) ([]*models.DeviceClaim, error) {
+	claims := []*models.DeviceClaim{}
+	if err := g.db.Where("user_id = ?", userID).Order("id ASC").Find(&claims).Error; err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
+
+// ListDeviceClaimsByNode returns every claim on a node address, oldest first
+func (g *GormDB) ListDeviceClaimsByNode(nodeAddress string) ([]*models.DeviceClaim, error) {
+	claims := []*models.DeviceClaim{}
+	if err := g.db.Where("node_address = ?", nodeAddress).Order("id ASC").Find(&claims).Error; err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
+
+// ListUnverifiedDeviceClaims returns the claims still waiting for verification, oldest first
+func (g *GormDB) ListUnverifiedDeviceClaims() ([]*models.DeviceClaim, error) {
+	claims := []*models.DeviceClaim{}
+	if err := g.db.Where("verified = ?", false).Order("id ASC").Find(&claims).Error; err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
+
+func (g *GormDB) SaveDeviceClaim(claim *models.DeviceClaim) error {
+	return g.db.Save(claim).Error
+}
+
+func (g *GormDB) DeleteDeviceClaim(id uint64) error {
+	return g.db.Delete(&models.DeviceClaim{}, "id = ?", id).Error
+}
+
+func (g *GormDB) DeleteDeviceClaimsByUser(userID string) error {
+	return g.db.Delete(&models.DeviceClaim{}, "user_id = ?", userID).Error
+}
+
+// GetNetworksByOrganizationIDs retrieves the networks of the given organizations
+func (g *GormDB) GetNetworksByOrganizationIDs(organizationIDs []string) ([]*models.Network, error) {
+	if len(organizationIDs) == 0 {
+		return []*models.Network{}, nil
+	}
+	var networks []*models.Network
+	result := g.db.Where("organization_id IN ?", organizationIDs).Find(&networks)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return networks, nil
+}
+
+// MoveNetworksToOrganization assigns every network of one organization to another
+func (g *GormDB) MoveNetworksToOrganization(fromID, toID string) error {
+	return g.db.Model(&models.Network{}).Where("organization_id = ?", fromID).Update("organization_id", toID).Error
+}
+
+// CreateOrganization creates an organization
+func (g *GormDB) CreateOrganization(organization *models.Organization) error {
+	return g.db.Create(organization).Error
+}
+
+// GetOrganization returns an organization, or nil when it does not exist
+func (g *GormDB) GetOrganization(id string) (*models.Organization, error) {
+	var organization models.Organization
+	result := g.db.First(&organization, "id = ?", id)
+	if result.Error != nil {
+		if result.Error == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, result.Error
+	}
+	return &organization, nil
+}
+
+// GetOrganizationByName returns the organization with a name, or nil when there is none
+func (g *GormDB) GetOrganizationByName(name string) (*models.Organization, error) {
+	var organization models.Organization
+	result := g.db.First(&organization, "name = ?", name)
+	if result.Error != nil {
+		if result.Error == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, result.Error
+	}
+	return &organization, nil
+}
+
+// ListOrganizations returns all organizations by name
+func (g *GormDB) ListOrganizations() ([]*models.Organization, error) {
+	var organizations []*models.Organization
+	result := g.db.Order("name ASC").Find(&organizations)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return organizations, nil
+}
+
+// UpdateOrganization saves an organization
+func (g *GormDB) UpdateOrganization(organization *models.Organization) error {
+	return g.db.Save(organization).Error
+}
+
+// DeleteOrganization deletes an organization
+func (g *GormDB) DeleteOrganization(id string) error {
+	return g.db.Delete(&models.Organization{}, "id = ?", id).Error
+}
+
+// UpsertOrganizationMember adds a user to an organization or changes their role
+func (g *GormDB) UpsertOrganizationMember(member *models.OrganizationMember) error {
+	return g.db.Clauses(clause.OnConflict{
+		Columns: []clause.Column{
+			{Name: "organization_id"},
+			{Name: "user_id"},
+		},
+		DoUpdates: clause.AssignmentColumns([]string{"role", "added_by", "updated_at"}),
+	}).Create(member).Error
+}
+
+// GetOrganizationMember returns a user's membership in an organization, or nil when there is none
+func (g *GormDB) GetOrganizationMember(organizationID, userID string) (*models.OrganizationMember, error) {
+	var member models.OrganizationMember
+	result := g.db.First(&member, "organization_id = ? AND user_id = ?", organizationID, userID)
+	if result.Error != nil {
+		if result.Error == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, result.Error
+	}
+	return &member, nil
+}
+
+// ListOrganizationMembers returns the members of an organization
+func (g *GormDB) ListOrganizationMembers(organizationID string) ([]*models.OrganizationMember, error) {
+	var members []*models.OrganizationMember
+	result := g.db.Where("organization_id = ?", organizationID).Order("created_at ASC").Find(&members)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return members, nil
+}
+
+// ListOrganizationMembershipsByUser returns every organization membership of a user
+func (g *GormDB) ListOrganizationMembershipsByUser(userID string) ([]*models.OrganizationMember, error) {
+	var members []*models.OrganizationMember
+	result := g.db.Where("user_id = ?", userID).Find(&members)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return members, nil
+}
+
+// DeleteOrganizationMember removes a user from an organization
+func (g *GormDB) DeleteOrganizationMember(organizationID, userID string) error {
+	return g.db.Delete(&models.OrganizationMember{}, "organization_id = ? AND user_id = ?", organizationID, userID).Error
+}
+
+// DeleteAllOrganizationMembers removes every member of an organization
+func (g *GormDB) DeleteAllOrganizationMembers(organizationID string) error {
+	return g.db.Delete(&models.OrganizationMember{}, "organization_id = ?", organizationID).Error
+}
+
+// DeleteOrganizationMembershipsByUser removes a user from every organization
+func (g *GormDB) DeleteOrganizationMembershipsByUser(userID string) error {
+	return g.db.Delete(&models.OrganizationMember{}, "user_id = ?", userID).Error
+}
+
+// GetActiveNetworkLockdown returns the active lockdown of a network, or nil when there is none
+func (g *GormDB) GetActiveNetworkLockdown(networkID string) (*models.NetworkLockdown, error) {
+	var lockdown models.NetworkLockdown
+	result := g.db.Where("network_id = ? AND active = ?", networkID, true).Order("id DESC").First(&lockdown)
+	if result.Error != nil {
+		if result.Error == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, result.Error
+	}
+	return &lockdown, nil
+}
+
+func (g *GormDB) CreateNetworkLockdown(lockdown *models.NetworkLockdown) error {
+	return g.db.Create(lockdown).Error
+}
+
+func (g *GormDB) SaveNetworkLockdown(lockdown *models.NetworkLockdown) error {
+	return g.db.Save(lockdown).Error
+}
+
+func (g *GormDB) DeleteAllNetworkLockdowns(networkID string) error {
+	return g.db.Delete(&models.NetworkLockdown{}, "network_id = ?", networkID).Error
+}
+
+// CreateOperation stores an operation together with its items
+func (g *GormDB) CreateOperation(operation *models.Operation, items []*models.OperationItem) error {
+	return g.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(operation).Error; err != nil {
+			return err
+		}
+		if len(items) == 0 {
+			return nil
+		}
+		return tx.Create(items).Error
+	})
+}
+
+// GetOperation returns an operation, or nil when there is none
+func (g *GormDB) GetOperation(id string) (*models.Operation, error) {
+	var operation models.Operation
+	result := g.db.Where("id = ?", id).Limit(1).Find(&operation)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, nil
+	}
+	return &operation, nil
+}
+
+// GetOperationByIdempotencyKey returns the operation a user submitted with key, or nil
+func (g *GormDB) GetOperationByIdempotencyKey(userID, key string) (*models.Operation, error) {
+	var operation models.Operation
+	result := g.db.Where("user_id = ? AND idempotency_key = ?", userID, key).Limit(1).Find(&operation)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, nil
+	}
+	return &operation, nil
+}
+
+func (g *GormDB) SaveOperation(operation *models.Operation) error {
+	return g.db.Save(operation).Error
+}
+
+// ListOperations returns the newest operations of a user, or of every user when userID is empty
+func (g *GormDB) ListOperations(userID string, limit int) ([]*models.Operation, error) {
+	var operations []*models.Operation
+	query := g.db.Order("created_at DESC").Order("id")
+	if userID != "" {
+		query = query.Where("user_id = ?", userID)
+	}
+	err := query.Limit(limit).Find(&operations).Error
+	return operations, err
+}
+
+// ListUnfinishedOperations returns the operations that are pending or running
+func (g *GormDB) ListUnfinishedOperations() ([]*models.Operation, error) {
+	var operations []*models.Operation
+	err := g.db.Where("status IN ?", []string{models.OperationStatusPending, models.OperationStatusRunning}).Find(&operations).Error
+	return operations, err
+}
+
+// ListOperationItems returns the items of an operation in index order
+func (g *GormDB) ListOperationItems(operationID string) ([]*models.OperationItem, error) {
+	var items []*models.OperationItem
+	err := g.db.Where("operation_id = ?", operationID).Order("item_index").Find(&items).Error
+	return items, err
+}
+
+func (g *GormDB) SaveOperationItem(item *models.OperationItem) error {
+	return g.db.Save(item).Error
+}
+
+// CreateAuditLog appends an entry to the audit log
+func (g *GormDB) CreateAuditLog(entry *models.AuditLog) error {
+	return g.db.Create(entry).Error
+}
+
+// GetLatestAuditLog retrieves the most recent audit entry
+func (g *GormDB) GetLatestAuditLog() (*models.AuditLog, error) {
+	var entry models.AuditLog
+	result := g.db.Order("id DESC").First(&entry)
+	if result.Error != nil {
+		if result.Error == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, result.Error
+	}
+	return &entry, nil
+}
+
+// GetLatestAuditLogBefore retrieves the most recent audit entry created before the given time
+func (g *GormDB) GetLatestAuditLogBefore(before time.Time) (*models.AuditLog, error) {
+	var entry models.AuditLog
+	result := g.db.Where("created_at < ?", before).Order("id DESC").First(&entry)
+	if result.Error != nil {
+		if result.Error == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, result.Error
+	}
+	return &entry, nil
+}
+
+// ListAuditLogs retrieves audit entries with IDs greater than afterID in chain order
+func (g *GormDB) ListAuditLogs(afterID uint64, limit int) ([]*models.AuditLog, error) {
+	var entries []*models.AuditLog
+	query := g.db.Where("id > ?", afterID).Order("id ASC")
+	if limit > 0 {
+		query = query.Limit(limit)
+	}
+	if err := query.Find(&entries).Error; err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// QueryAuditLogs retrieves audit entries matching the query
+func (g *GormDB) QueryAuditLogs(q models.AuditLogQuery) ([]*models.AuditLog, error) {
+	var entries []*models.AuditLog
+	query := g.db.Model(&models.AuditLog{})
+	if q.Action != "" {
+		query = query.Where("action = ?", q.Action)
+	}
+	if q.ActorID != "" {
+		query = query.Where("actor_id = ?", q.ActorID)
+	}
+	if q.BeforeID > 0 {
+		query = query.Where("id < ?", q.BeforeID)
+	}
+	if q.AfterID > 0 {
+		query = query.Where("id > ?", q.AfterID)
+	}
+	if q.Ascending {
+		query = query.Order("id ASC")
+	} else {
+		query = query.Order("id DESC")
+	}
+	if q.Offset > 0 {
+		query = query.Offset(q.Offset)
+	}
+	if q.Limit > 0 {
+		query = query.Limit(q.Limit)
+	}
+	if err := query.Find(&entries).Error; err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// ListRecentAuditLogs retrieves the newest audit entries in chain order
+func (g *GormDB) ListRecentAuditLogs(limit int) ([]*models.AuditLog, error) {
+	var entries []*models.AuditLog
+	if err := g.db.Order("id DESC").Limit(limit).Find(&entries).Error; err != nil {
+		return nil, err
+	}
+	for i, j := 0, len(entries)-1; i < j; i, j = i+1, j-1 {
+		entries[i], entries[j] = entries[j], entries[i]
+	}
+	return entries, nil
+}
+
+// DeleteAuditLogsUpTo deletes audit entries with IDs less than or equal to id
+func (g *GormDB) DeleteAuditLogsUpTo(id uint64) (int64, error) {
+	result := g.db.Where("id <= ?", id).Delete(&models.AuditLog{})
+	return result.RowsAffected, result.Error
+}
+
+// GetAuditAnchor retrieves the audit chain anchor left by pruning
+func (g *GormDB) GetAuditAnchor() (*models.AuditAnchor, error) {
+	var anchor models.AuditAnchor
+	result := g.db.First(&anchor, "id = ?", 1)
+	if result.Error != nil {
+		if result.Error == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, result.Error
+	}
+	return &anchor, nil
+}
+
+// SaveAuditAnchor stores the audit chain anchor
+func (g *GormDB) SaveAuditAnchor(anchor *models.AuditAnchor) error {
+	anchor.ID = 1
+	return g.db.Save(anchor).Error
+}
+
+// Ping checks if the database connection is alive, reconnecting when it was lost.
 func (g *GormDB) Ping() error {
+	if g.pool != nil {
+		return g.pool.Ping(context.Background())
+	}
 	sqlDB, err := g.db.DB()
 	if err != nil {
 		return err
@@ -262,6 +1239,9 @@ func (g *GormDB) Ping() error {
 
 // Close closes the database connection
 func (g *GormDB) Close() error {
+	if g.pool != nil {
+		return g.pool.Close()
+	}
 	sqlDB, err := g.db.DB()
 	if err != nil {
 		return err
@@ -11,22 +11,35 @@ type DBInterface interface {
 	// Initialize the database
 	Init() error
 
-	// Execute database operations within a transaction
+	// Execute database operations within a transaction; nested calls on tx join the outer transaction
 	WithTransaction(fn func(DBInterface) error) error
 
 	// User operations
 	CreateUser(user *models.User) error
 	GetUserByID(id string) (*models.User, error)
 	GetUserByUsername(username string) (*models.User, error)
+	GetUserByEmail(email string) (*models.User, error)
+	GetUserByExternalSubject(subject string) (*models.User, error)
 	GetAllUsers() ([]*models.User, error)
 	GetUsersByIDs(ids []string) ([]*models.User, error)
 	UpdateUser(user *models.User) error
+	ReplaceUserPasswordHash(userID, previousHash, hash string) (bool, error)
 	DeleteUser(id string) error
 	CreateSession(session *models.Session) error
 	GetSessionByID(id string) (*models.Session, error)
 	GetSessionsByUserID(userID string) ([]*models.Session, error)
 	UpdateSession(session *models.Session) error
 	DeleteExpiredSessions(before time.Time) error
+	RevokeAllSessions(at time.Time) (int64, error)
+	CreateApiToken(token *models.ApiToken) error
+	GetApiTokenByID(id string) (*models.ApiToken, error)
+	GetApiTokenByHash(hash string) (*models.ApiToken, error)
+	GetApiTokensByUserID(userID string) ([]*models.ApiToken, error)
+	UpdateApiToken(token *models.ApiToken) error
+	CreatePasswordResetToken(token *models.PasswordResetToken) error
+	GetPasswordResetTokenByHash(hash string) (*models.PasswordResetToken, error)
+	ConsumePasswordResetToken(hash string, at time.Time) (int64, error)
+	InvalidatePasswordResetTokens(userID string, at time.Time) (int64, error)
 
 	// Network operations
 	CreateNetwork(network *models.Network) error
@@ -41,9 +54,126 @@ type DBInterface interface {
 	GetSharedNetworksByUserID(userID string) ([]*models.Network, error)
 	DeleteNetworkViewer(networkID, userID string) error
 	DeleteAllNetworkViewers(networkID string) error
+	GetNetworksByOrganizationIDs(organizationIDs []string) ([]*models.Network, error)
+	MoveNetworksToOrganization(fromID, toID string) error
+
+	// Organization operations
+	CreateOrganization(organization *models.Organization) error
+	GetOrganization(id string) (*models.Organization, error)
+	GetOrganizationByName(name string) (*models.Organization, error)
+	ListOrganizations() ([]*models.Organization, error)
+	UpdateOrganization(organization *models.Organization) error
+	DeleteOrganization(id string) error
+	UpsertOrganizationMember(member *models.OrganizationMember) error
+	GetOrganizationMember(organizationID, userID string) (*models.OrganizationMember, error)
+	ListOrganizationMembers(organizationID string) ([]*models.OrganizationMember, error)
+	ListOrganizationMembershipsByUser(userID string) ([]*models.OrganizationMember, error)
+	DeleteOrganizationMember(organizationID, userID string) error
+	DeleteAllOrganizationMembers(organizationID string) error
+	DeleteOrganizationMembershipsByUser(userID string) error
+
+	// Member metadata operations
+	GetMemberMetadata(networkID, memberID string) (*models.MemberMetadata, error)
+	ListMemberMetadata(networkID string) ([]*models.MemberMetadata, error)
+	ListMemberMetadataExpiringBy(deadline time.Time) ([]*models.MemberMetadata, error)
+	SaveMemberMetadata(metadata *models.MemberMetadata) error
+	DeleteMemberMetadata(networkID, memberID string) error
+	DeleteAllMemberMetadata(networkID string) error
+
+	// Network rules source operations
+	GetNetworkRuleSource(networkID string) (*models.NetworkRuleSource, error)
+	SaveNetworkRuleSource(source *models.NetworkRuleSource) error
+	DeleteNetworkRuleSource(networkID string) error
+
+	// Network tag and capability definition operations
+	ListNetworkTags(networkID string) ([]*models.NetworkTag, error)
+	CreateNetworkTag(tag *models.NetworkTag) error
+	UpdateNetworkTag(tag *models.NetworkTag) error
+	DeleteNetworkTag(networkID string, tagID uint32) error
+	DeleteAllNetworkTags(networkID string) error
+	ListNetworkCapabilities(networkID string) ([]*models.NetworkCapability, error)
+	CreateNetworkCapability(capability *models.NetworkCapability) error
+	DeleteNetworkCapability(networkID string, capabilityID uint32) error
+	DeleteAllNetworkCapabilities(networkID string) error
+
+	// Pending approval operations
+	CreatePendingApproval(approval *models.PendingApproval) (bool, error)
+	GetPendingApproval(id uint64) (*models.PendingApproval, error)
+	ListPendingApprovals(networkIDs []string) ([]*models.PendingApproval, error)
+	SavePendingApproval(approval *models.PendingApproval) error
+	DeletePendingApproval(networkID, memberID string) error
+	DeleteAllPendingApprovals(networkID string) error
+
+	// Device claim operations
+	CreateDeviceClaim(claim *models.DeviceClaim) error
+	GetDeviceClaim(id uint64) (*models.DeviceClaim, error)
+	ListDeviceClaimsByUser(userID string) ([]*models.DeviceClaim, error)
+	ListDeviceClaimsByNode(nodeAddress string) ([]*models.DeviceClaim, error)
+	ListUnverifiedDeviceClaims() ([]*models.DeviceClaim, error)
+	SaveDeviceClaim(claim *models.DeviceClaim) error
+	DeleteDeviceClaim(id uint64) error
+	DeleteDeviceClaimsByUser(userID string) error
+
+	// Network lockdown operations
+	GetActiveNetworkLockdown(networkID string) (*models.NetworkLockdown, error)
+	CreateNetworkLockdown(lockdown *models.NetworkLockdown) error
+	SaveNetworkLockdown(lockdown *models.NetworkLockdown) error
+	DeleteAllNetworkLockdowns(networkID string) error
+
+	// Operation operations
+	CreateOperation(operation *models.Operation, items []*models.OperationItem) error
+	GetOperation(id string) (*models.Operation, error)
+	GetOperationByIdempotencyKey(userID, key string) (*models.Operation, error)
+	SaveOperation(operation *models.Operation) error
+	ListOperations(userID string, limit int) ([]*models.Operation, error)
+	ListUnfinishedOperations() ([]*models.Operation, error)
+	ListOperationItems(operationID string) ([]*models.OperationItem, error)
+	SaveOperationItem(item *models.OperationItem) error
+
+	// Member status history operations
+	CreateMemberStatusEvents(events []*models.MemberStatusEvent) error
+	ListLatestMemberStatusEvents(networkID string) ([]*models.MemberStatusEvent, error)
+	ListLatestOnlineMemberStatusEvents(networkID string) ([]*models.MemberStatusEvent, error)
+	ListMemberStatusEvents(networkID, memberID string, from, to time.Time) ([]*models.MemberStatusEvent, error)
+	GetMemberStatusEventBefore(networkID, memberID string, before time.Time) (*models.MemberStatusEvent, error)
+	ListMemberStatusEventsAfter(afterID uint64, limit int) ([]*models.MemberStatusEvent, error)
+
+	// Controller trace operations
+	CreateControllerTraceEvents(events []*models.ControllerTraceEvent) error
+	QueryControllerTraceEvents(query models.ControllerTraceQuery) ([]*models.ControllerTraceEvent, error)
+	DeleteControllerTraceEventsBefore(before time.Time) (int64, error)
+
+	// Planet generation history operations
+	CreatePlanetGeneration(generation *models.PlanetGeneration) error
+	ListPlanetGenerations(limit int) ([]*models.PlanetGeneration, error)
+	GetPlanetGenerationByID(id uint64) (*models.PlanetGeneration, error)
+	DeletePlanetGenerationsBeyond(keep int) (int64, error)
+
+	// Webhook operations
+	CreateWebhook(webhook *models.Webhook) error
+	GetWebhookByID(id string) (*models.Webhook, error)
+	ListWebhooks() ([]*models.Webhook, error)
+	UpdateWebhook(webhook *models.Webhook) error
+	DeleteWebhook(id string) error
+	CreateWebhookDelivery(delivery *models.WebhookDelivery) error
+	UpdateWebhookDelivery(delivery *models.WebhookDelivery) error
+	ListWebhookDeliveries(webhookID string, limit int) ([]*models.WebhookDelivery, error)
+	DeleteWebhookDeliveriesBeyond(webhookID string, keep int) (int64, error)
+
+	// Audit log operations
+	CreateAuditLog(entry *models.AuditLog) error
+	GetLatestAuditLog() (*models.AuditLog, error)
+	GetLatestAuditLogBefore(before time.Time) (*models.AuditLog, error)
+	ListAuditLogs(afterID uint64, limit int) ([]*models.AuditLog, error)
+	ListRecentAuditLogs(limit int) ([]*models.AuditLog, error)
+	QueryAuditLogs(query models.AuditLogQuery) ([]*models.AuditLog, error)
+	DeleteAuditLogsUpTo(id uint64) (int64, error)
+	GetAuditAnchor() (*models.AuditAnchor, error)
+	SaveAuditAnchor(anchor *models.AuditAnchor) error
 
 	// Check whether an admin user already exists
 	HasAdminUser() (bool, error)
+	CountUsersByRole(role string) (int64, error)
 
 	// Check whether the database connection is alive
 	Ping() error
@@ -0,0 +1,185 @@
+package database
+
+import (
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/GT-610/tairitsu/internal/app/logger"
+	"github.com/GT-610/tairitsu/internal/app/models"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// migration is one step of the schema history. Steps run once each, in version order, and
+// are recorded in schema_migrations; a step and its record commit together wherever the
+// backend runs DDL in transactions.
+type migration struct {
+	version int
+	name    string
+	// backends limits the step to some database types; empty runs it on every backend
+	backends []DatabaseType
+	up       func(tx *gorm.DB) error
+}
+
+// migrations is the schema history. Append new steps with the next version and never edit
+// or reorder applied ones. The first step creates the tables from the current models, so a
+// later step that adds a column the models already declare must check for it first.
+var migrations = []migration{
+	{version: 1, name: "create tables", up: func(tx *gorm.DB) error {
+		// Installs from before versioned migrations already have tables; AutoMigrate brings them up to date
+		return tx.AutoMigrate(appModels()...)
+	}},
+	{version: 2, name: "default organization", up: seedDefaultOrganization},
+	{version: 3, name: "operations", up: func(tx *gorm.DB) error {
+		return tx.AutoMigrate(&models.Operation{}, &models.OperationItem{})
+	}},
+	{version: 4, name: "operation progress", up: func(tx *gorm.DB) error {
+		return tx.AutoMigrate(&models.Operation{})
+	}},
+	{version: 5, name: "member metadata unique index", up: func(tx *gorm.DB) error {
+		return tx.AutoMigrate(&models.MemberMetadata{})
+	}},
+}
+
+// schemaMigration records an applied migration
+type schemaMigration struct {
+	Version   int `gorm:"primaryKey;autoIncrement:false"`
+	Name      string
+	AppliedAt time.Time
+}
+
+func (schemaMigration) TableName() string {
+	return "schema_migrations"
+}
+
+// MigrationInfo describes a migration, applied or pending
+type MigrationInfo struct {
+	Version   int        `json:"version"`
+	Name      string     `json:"name"`
+	AppliedAt *time.Time `json:"appliedAt,omitempty"` // Nil while pending
+}
+
+// Migrator is implemented by backends with a versioned schema
+type Migrator interface {
+	// Migrations lists every migration known to this release with when it was applied
+	Migrations() ([]MigrationInfo, error)
+	// MigrateUp applies the pending migrations and returns them
+	MigrateUp() ([]MigrationInfo, error)
+}
+
+// AsMigrator returns db as a Migrator when its schema is versioned
+func AsMigrator(db DBInterface) (Migrator, bool) {
+	g, ok := db.(*GormDB)
+	if !ok || g.pool == nil {
+		return nil, false
+	}
+	return g, true
+}
+
+// Migrations lists every migration for this backend with when it was applied, without
+// changing the database
+func (g *GormDB) Migrations() ([]MigrationInfo, error) {
+	applied, err := appliedMigrations(g.db)
+	if err != nil {
+		return nil, err
+	}
+	var infos []MigrationInfo
+	for _, m := range migrationsFor(backendOf(g.db)) {
+		info := MigrationInfo{Version: m.version, Name: m.name}
+		if record, ok := applied[m.version]; ok {
+			info.AppliedAt = &record.AppliedAt
+		}
+		infos = append(infos, info)
+	}
+	return infos, nil
+}
+
+// MigrateUp applies the pending migrations in order and returns them. MySQL commits DDL
+// implicitly, so a step that fails there may leave part of its changes behind.
+func (g *GormDB) MigrateUp() ([]MigrationInfo, error) {
+	return migrateUp(g.db, backendOf(g.db) != MySQL)
+}
+
+func migrateUp(db *gorm.DB, transactional bool) ([]MigrationInfo, error) {
+	if err := db.AutoMigrate(&schemaMigration{}); err != nil {
+		return nil, fmt.Errorf("failed to create schema_migrations table: %w", err)
+	}
+	applied, err := appliedMigrations(db)
+	if err != nil {
+		return nil, err
+	}
+	known := migrationsFor(backendOf(db))
+	for version := range applied {
+		if !slices.ContainsFunc(known, func(m migration) bool { return m.version == version }) {
+			logger.Warn("database has a migration this release does not know; it was migrated by a newer release", zap.Int("version", version))
+		}
+	}
+
+	var ran []MigrationInfo
+	for _, m := range known {
+		if _, ok := applied[m.version]; ok {
+			continue
+		}
+		record := schemaMigration{Version: m.version, Name: m.name, AppliedAt: time.Now()}
+		apply := func(tx *gorm.DB) error {
+			if err := m.up(tx); err != nil {
+				return err
+			}
+			// Another process may have applied the step at the same time
+			return tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&record).Error
+		}
+		if transactional {
+			err = db.Transaction(apply)
+		} else {
+			err = apply(db)
+		}
+		if err != nil {
+			return ran, fmt.Errorf("migration %d (%s) failed: %w", m.version, m.name, err)
+		}
+		logger.Info("applied database migration", zap.Int("version", m.version), zap.String("name", m.name))
+		ran = append(ran, MigrationInfo{Version: m.version, Name: m.name, AppliedAt: &record.AppliedAt})
+	}
+	return ran, nil
+}
+
+// appliedMigrations reads schema_migrations, which does not exist before the first migration
+func appliedMigrations(db *gorm.DB) (map[int]schemaMigration, error) {
+	applied := map[int]schemaMigration{}
+	if !db.Migrator().HasTable(&schemaMigration{}) {
+		return applied, nil
+	}
+	var records []schemaMigration
+	if err := db.Order("version").Find(&records).Error; err != nil {
+		return nil, fmt.Errorf("failed to read schema_migrations: %w", err)
+	}
+	for _, record := range records {
+		applied[record.Version] = record
+	}
+	return applied, nil
+}
+
+// backendOf names the database type behind db; GORM calls PostgreSQL "postgres"
+func backendOf(db *gorm.DB) DatabaseType {
+	switch db.Dialector.Name() {
+	case "postgres":
+		return PostgreSQL
+	case "mysql":
+		return MySQL
+	default:
+		return SQLite
+	}
+}
+
+// migrationsFor returns the migrations that apply to dbType, in version order
+func migrationsFor(dbType DatabaseType) []migration {
+	var selected []migration
+	for _, m := range migrations {
+		if len(m.backends) == 0 || slices.Contains(m.backends, dbType) {
+			selected = append(selected, m)
+		}
+	}
+	slices.SortFunc(selected, func(a, b migration) int { return a.version - b.version })
+	return selected
+}
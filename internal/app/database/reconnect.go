@@ -0,0 +1,141 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/GT-610/tairitsu/internal/app/logger"
+	"go.uber.org/zap"
+)
+
+// redialInterval limits how often a lost connection is dialed again, so an unreachable
+// server is not hammered by every incoming request
+const redialInterval = 5 * time.Second
+
+// reconnectingPool is the connection pool GORM runs queries through. When a query fails
+// because the server went away, it dials the database again from the saved Config and
+// swaps the handle, so a restarted MySQL or PostgreSQL server does not need a Tairitsu
+// restart. The failed query itself is not retried, since it may have been applied.
+type reconnectingPool struct {
+	mu         sync.RWMutex
+	sqlDB      *sql.DB
+	dial       func() (*sql.DB, error)
+	lastDialAt time.Time
+	closed     bool
+}
+
+func newReconnectingPool(sqlDB *sql.DB, dial func() (*sql.DB, error)) *reconnectingPool {
+	return &reconnectingPool{sqlDB: sqlDB, dial: dial}
+}
+
+func (p *reconnectingPool) current() *sql.DB {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.sqlDB
+}
+
+// check redials when err shows that failed lost its connection, and returns err unchanged
+func (p *reconnectingPool) check(failed *sql.DB, err error) error {
+	if isConnectionError(err) {
+		p.redial(failed, err)
+	}
+	return err
+}
+
+func (p *reconnectingPool) redial(failed *sql.DB, cause error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	// Another request already replaced the handle, or one dial is enough for now
+	if p.closed || p.sqlDB != failed || time.Since(p.lastDialAt) < redialInterval {
+		return
+	}
+	p.lastDialAt = time.Now()
+
+	logger.Warn("database connection lost, reconnecting", zap.Error(cause))
+	sqlDB, err := p.dial()
+	if err != nil {
+		logger.Error("failed to reconnect to database", zap.Error(err))
+		return
+	}
+	p.sqlDB = sqlDB
+	if closeErr := failed.Close(); closeErr != nil {
+		logger.Warn("failed to close lost database handle", zap.Error(closeErr))
+	}
+	logger.Info("database connection re-established")
+}
+
+// Ping checks the connection, reconnecting when it was lost
+func (p *reconnectingPool) Ping(ctx context.Context) error {
+	sqlDB := p.current()
+	return p.check(sqlDB, sqlDB.PingContext(ctx))
+}
+
+// Close closes the current handle; the pool never reconnects afterwards
+func (p *reconnectingPool) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.closed = true
+	return p.sqlDB.Close()
+}
+
+// GetDBConn exposes the current handle to gorm.DB.DB()
+func (p *reconnectingPool) GetDBConn() (*sql.DB, error) {
+	return p.current(), nil
+}
+
+func (p *reconnectingPool) PrepareContext(ctx context.Context, query string) (*sql.Stmt, error) {
+	sqlDB := p.current()
+	stmt, err := sqlDB.PrepareContext(ctx, query)
+	return stmt, p.check(sqlDB, err)
+}
+
+func (p *reconnectingPool) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	sqlDB := p.current()
+	result, err := sqlDB.ExecContext(ctx, query, args...)
+	return result, p.check(sqlDB, err)
+}
+
+func (p *reconnectingPool) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	sqlDB := p.current()
+	rows, err := sqlDB.QueryContext(ctx, query, args...)
+	return rows, p.check(sqlDB, err)
+}
+
+func (p *reconnectingPool) QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row {
+	sqlDB := p.current()
+	row := sqlDB.QueryRowContext(ctx, query, args...)
+	_ = p.check(sqlDB, row.Err())
+	return row
+}
+
+func (p *reconnectingPool) BeginTx(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error) {
+	sqlDB := p.current()
+	tx, err := sqlDB.BeginTx(ctx, opts)
+	return tx, p.check(sqlDB, err)
+}
+
+// isConnectionError reports whether err means the server connection is gone, as opposed
+// to a failing statement
+func isConnectionError(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, driver.ErrBadConn) || errors.Is(err, syscall.ECONNREFUSED) || errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.EPIPE) {
+		return true
+	}
+	// Drivers do not always wrap the underlying network error
+	message := strings.ToLower(err.Error())
+	for _, fragment := range []string{"connection refused", "bad connection", "invalid connection", "broken pipe", "connection reset"} {
+		if strings.Contains(message, fragment) {
+			return true
+		}
+	}
+	return false
+}
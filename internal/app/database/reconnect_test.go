@@ -0,0 +1,162 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// flakyServer stands in for a database server that can go down and restart. Handles
+// dialed before a restart are refused afterwards, like a server that moved.
+type flakyServer struct {
+	down       atomic.Bool
+	generation atomic.Int32
+}
+
+type flakyConnector struct {
+	server     *flakyServer
+	generation int32
+}
+
+func (c flakyConnector) Connect(context.Context) (driver.Conn, error) {
+	if c.server.down.Load() || c.server.generation.Load() != c.generation {
+		return nil, errors.New("dial tcp 127.0.0.1:3306: connect: connection refused")
+	}
+	return &flakyConn{server: c.server, generation: c.generation}, nil
+}
+
+func (c flakyConnector) Driver() driver.Driver {
+	return nil
+}
+
+type flakyConn struct {
+	server     *flakyServer
+	generation int32
+}
+
+func (c *flakyConn) lost() bool {
+	return c.server.down.Load() || c.server.generation.Load() != c.generation
+}
+
+func (c *flakyConn) Prepare(string) (driver.Stmt, error) {
+	return nil, errors.New("not supported")
+}
+
+func (c *flakyConn) Close() error {
+	return nil
+}
+
+func (c *flakyConn) Begin() (driver.Tx, error) {
+	return nil, errors.New("not supported")
+}
+
+func (c *flakyConn) QueryContext(context.Context, string, []driver.NamedValue) (driver.Rows, error) {
+	if c.lost() {
+		return nil, driver.ErrBadConn
+	}
+	return &oneRow{}, nil
+}
+
+func (c *flakyConn) Ping(context.Context) error {
+	if c.lost() {
+		return driver.ErrBadConn
+	}
+	return nil
+}
+
+type oneRow struct {
+	done bool
+}
+
+func (r *oneRow) Columns() []string {
+	return []string{"value"}
+}
+
+func (r *oneRow) Close() error {
+	return nil
+}
+
+func (r *oneRow) Next(dest []driver.Value) error {
+	if r.done {
+		return io.EOF
+	}
+	r.done = true
+	dest[0] = int64(1)
+	return nil
+}
+
+func newFlakyPool(server *flakyServer, dials *atomic.Int32) *reconnectingPool {
+	return newReconnectingPool(sql.OpenDB(flakyConnector{server: server}), func() (*sql.DB, error) {
+		dials.Add(1)
+		sqlDB := sql.OpenDB(flakyConnector{server: server, generation: server.generation.Load()})
+		if err := sqlDB.Ping(); err != nil {
+			_ = sqlDB.Close()
+			return nil, fmt.Errorf("failed to connect: %w", err)
+		}
+		return sqlDB, nil
+	})
+}
+
+func TestReconnectingPoolRedialsAfterServerRestart(t *testing.T) {
+	server := &flakyServer{}
+	var dials atomic.Int32
+	pool := newFlakyPool(server, &dials)
+	t.Cleanup(func() {
+		_ = pool.Close()
+	})
+	ctx := context.Background()
+	require.NoError(t, pool.Ping(ctx))
+	original := pool.current()
+
+	// A dial that fails keeps the old handle
+	server.down.Store(true)
+	require.Error(t, pool.Ping(ctx))
+	assert.Equal(t, int32(1), dials.Load())
+	assert.Same(t, original, pool.current())
+
+	// Redials are throttled
+	server.down.Store(false)
+	server.generation.Store(1)
+	_, err := pool.QueryContext(ctx, "SELECT 1")
+	require.Error(t, err)
+	assert.Equal(t, int32(1), dials.Load())
+
+	pool.lastDialAt = time.Time{}
+	_, err = pool.QueryContext(ctx, "SELECT 1")
+	require.Error(t, err, "the query that noticed the lost connection is not retried")
+	assert.Equal(t, int32(2), dials.Load())
+	assert.NotSame(t, original, pool.current(), "the redialed handle replaces the lost one")
+
+	rows, err := pool.QueryContext(ctx, "SELECT 1")
+	require.NoError(t, err)
+	require.NoError(t, rows.Close())
+}
+
+func TestReconnectingPoolStaysClosed(t *testing.T) {
+	server := &flakyServer{}
+	var dials atomic.Int32
+	pool := newFlakyPool(server, &dials)
+
+	require.NoError(t, pool.Close())
+	assert.Error(t, pool.Ping(context.Background()))
+	assert.Zero(t, dials.Load())
+}
+
+func TestIsConnectionError(t *testing.T) {
+	assert.True(t, isConnectionError(driver.ErrBadConn))
+	assert.True(t, isConnectionError(fmt.Errorf("query: %w", driver.ErrBadConn)))
+	assert.True(t, isConnectionError(errors.New("[mysql] invalid connection")))
+	assert.True(t, isConnectionError(errors.New("dial tcp 10.0.0.5:5432: connect: connection refused")))
+	assert.False(t, isConnectionError(errors.New("UNIQUE constraint failed: users.username")))
+	assert.False(t, isConnectionError(sql.ErrConnDone))
+	assert.False(t, isConnectionError(nil))
+}
@@ -0,0 +1,121 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"os"
+)
+
+// ErrCompactionUnsupported is returned when the backend is not a SQLite file
+var ErrCompactionUnsupported = errors.New("database compaction is only supported for SQLite files")
+
+// SQLiteFileStats describes how much of a SQLite database file holds data
+type SQLiteFileStats struct {
+	Path      string `json:"path"`
+	SizeBytes int64  `json:"sizeBytes"` // Main file plus write-ahead log
+	PageSize  int64  `json:"pageSize"`
+	PageCount int64  `json:"pageCount"`
+	FreePages int64  `json:"freePages"`
+}
+
+// FreeRatio is the share of pages left unused by deleted rows
+func (s *SQLiteFileStats) FreeRatio() float64 {
+	if s == nil || s.PageCount == 0 {
+		return 0
+	}
+	return float64(s.FreePages) / float64(s.PageCount)
+}
+
+// Compactor is implemented by backends whose storage file can be compacted
+type Compactor interface {
+	// SQLiteFileStats reports the size and free page count of the database file
+	SQLiteFileStats() (*SQLiteFileStats, error)
+	// Compact returns free pages to the filesystem
+	Compact() error
+}
+
+// AsCompactor returns db as a Compactor when it is backed by a SQLite file
+func AsCompactor(db DBInterface) (Compactor, bool) {
+	g, ok := db.(*GormDB)
+	if !ok || g.db.Dialector.Name() != string(SQLite) {
+		return nil, false
+	}
+	return g, true
+}
+
+// SQLiteFileStats reports the size and free page count of the SQLite database file
+func (g *GormDB) SQLiteFileStats() (*SQLiteFileStats, error) {
+	if g.db.Dialector.Name() != string(SQLite) {
+		return nil, ErrCompactionUnsupported
+	}
+
+	stats := &SQLiteFileStats{}
+	var files []struct {
+		Name string
+		File string
+	}
+	if err := g.db.Raw("PRAGMA database_list").Scan(&files).Error; err != nil {
+		return nil, fmt.Errorf("failed to locate database file: %w", err)
+	}
+	for _, file := range files {
+		if file.Name == "main" {
+			stats.Path = file.File
+		}
+	}
+	if stats.Path == "" {
+		return nil, ErrCompactionUnsupported
+	}
+
+	for pragma, target := range map[string]*int64{
+		"PRAGMA page_size":      &stats.PageSize,
+		"PRAGMA page_count":     &stats.PageCount,
+		"PRAGMA freelist_count": &stats.FreePages,
+	} {
+		if err := g.db.Raw(pragma).Scan(target).Error; err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", pragma, err)
+		}
+	}
+
+	for _, path := range []string{stats.Path, stats.Path + "-wal"} {
+		info, err := os.Stat(path)
+		if err == nil {
+			stats.SizeBytes += info.Size()
+		} else if !os.IsNotExist(err) {
+			return nil, fmt.Errorf("failed to stat database file: %w", err)
+		}
+	}
+	return stats, nil
+}
+
+// Compact returns free pages to the filesystem. Databases created with incremental
+// auto-vacuum release them in place; otherwise VACUUM rebuilds the file through a temporary
+// copy that SQLite swaps in atomically. The write-ahead log is checkpointed and truncated
+// afterwards so the main file shrinks on disk.
+func (g *GormDB) Compact() error {
+	if g.db.Dialector.Name() != string(SQLite) {
+		return ErrCompactionUnsupported
+	}
+
+	var autoVacuum int
+	if err := g.db.Raw("PRAGMA auto_vacuum").Scan(&autoVacuum).Error; err != nil {
+		return fmt.Errorf("failed to read auto_vacuum mode: %w", err)
+	}
+	if autoVacuum == 2 {
+		// incremental_vacuum frees one page per step, so the statement must be read to the end
+		rows, err := g.db.Raw("PRAGMA incremental_vacuum").Rows()
+		if err != nil {
+			return fmt.Errorf("failed to compact database: %w", err)
+		}
+		for rows.Next() {
+		}
+		if err := rows.Close(); err != nil {
+			return fmt.Errorf("failed to compact database: %w", err)
+		}
+	} else if err := g.db.Exec("VACUUM").Error; err != nil {
+		return fmt.Errorf("failed to compact database: %w", err)
+	}
+	if err := g.db.Exec("PRAGMA wal_checkpoint(TRUNCATE)").Error; err != nil {
+		return fmt.Errorf("failed to checkpoint write-ahead log: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,111 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/GT-610/tairitsu/internal/app/logger"
+	"go.uber.org/zap"
+)
+
+// SQLite writes are retried this many times when another process holds the write lock for
+// longer than the busy timeout, such as a command-line subcommand next to the server
+const (
+	sqliteBusyRetries = 3
+	sqliteBusyBackoff = 100 * time.Millisecond
+)
+
+// sqlitePool is the connection pool GORM runs SQLite queries through. SQLite allows one
+// writer at a time, so writes and transactions share a single connection and wait for it
+// in line instead of failing with "database is locked". Plain reads use a separate pool
+// and, in WAL mode, run alongside the writer.
+type sqlitePool struct {
+	writer  *sql.DB
+	readers *sql.DB
+}
+
+func newSQLitePool(writer, readers *sql.DB) *sqlitePool {
+	writer.SetMaxOpenConns(1)
+	writer.SetMaxIdleConns(1)
+	return &sqlitePool{writer: writer, readers: readers}
+}
+
+// Ping checks both pools
+func (p *sqlitePool) Ping(ctx context.Context) error {
+	if err := p.writer.PingContext(ctx); err != nil {
+		return err
+	}
+	return p.readers.PingContext(ctx)
+}
+
+// Close closes both pools
+func (p *sqlitePool) Close() error {
+	return errors.Join(p.writer.Close(), p.readers.Close())
+}
+
+// GetDBConn exposes the writer to gorm.DB.DB()
+func (p *sqlitePool) GetDBConn() (*sql.DB, error) {
+	return p.writer, nil
+}
+
+func (p *sqlitePool) PrepareContext(ctx context.Context, query string) (*sql.Stmt, error) {
+	return p.writer.PrepareContext(ctx, query)
+}
+
+func (p *sqlitePool) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	var result sql.Result
+	err := retryBusy(ctx, func() error {
+		var err error
+		result, err = p.writer.ExecContext(ctx, query, args...)
+		return err
+	})
+	return result, err
+}
+
+func (p *sqlitePool) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	return p.readers.QueryContext(ctx, query, args...)
+}
+
+func (p *sqlitePool) QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row {
+	return p.readers.QueryRowContext(ctx, query, args...)
+}
+
+// BeginTx starts a transaction on the writer. The DSN makes it BEGIN IMMEDIATE, so the write
+// lock is taken up front and a transaction never fails halfway when it first writes.
+func (p *sqlitePool) BeginTx(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error) {
+	var tx *sql.Tx
+	err := retryBusy(ctx, func() error {
+		var err error
+		tx, err = p.writer.BeginTx(ctx, opts)
+		return err
+	})
+	return tx, err
+}
+
+// retryBusy runs write until it does not fail with SQLITE_BUSY. A statement that failed
+// that way was not applied, so running it again is safe.
+func retryBusy(ctx context.Context, write func() error) error {
+	err := write()
+	for attempt := 1; attempt <= sqliteBusyRetries && isBusyError(err); attempt++ {
+		logger.Debug("SQLite database is busy, retrying write", zap.Int("attempt", attempt), zap.Error(err))
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(time.Duration(attempt) * sqliteBusyBackoff):
+		}
+		err = write()
+	}
+	return err
+}
+
+// isBusyError reports whether err means another connection holds the SQLite write lock
+func isBusyError(err error) bool {
+	if err == nil {
+		return false
+	}
+	message := strings.ToLower(err.Error())
+	return strings.Contains(message, "database is locked") || strings.Contains(message, "database table is locked") || strings.Contains(message, "sqlite_busy")
+}
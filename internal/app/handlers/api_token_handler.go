@@ -0,0 +1,115 @@
+package handlers
+
+import (
+	"errors"
+	"time"
+
+	"github.com/GT-610/tairitsu/internal/app/apierror"
+	"github.com/GT-610/tairitsu/internal/app/logger"
+	"github.com/GT-610/tairitsu/internal/app/models"
+	"github.com/GT-610/tairitsu/internal/app/services"
+	"github.com/gofiber/fiber/v3"
+	"go.uber.org/zap"
+)
+
+// ApiTokenHandler manages personal access tokens of the current user
+type ApiTokenHandler struct {
+	tokenService *services.ApiTokenService
+}
+
+// NewApiTokenHandler creates a new API token handler instance
+func NewApiTokenHandler(tokenService *services.ApiTokenService) *ApiTokenHandler {
+	return &ApiTokenHandler{
+		tokenService: tokenService,
+	}
+}
+
+func writeApiTokenError(c fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, services.ErrApiTokenInvalidName), errors.Is(err, services.ErrApiTokenInvalidScope), errors.Is(err, services.ErrApiTokenInvalidExpiry), errors.Is(err, services.ErrApiTokenInvalidNetwork):
+		return writeLocalizedErrorMessage(c, fiber.StatusBadRequest, apierror.CodeApiTokenInvalidRequest, err.Error())
+	case errors.Is(err, services.ErrApiTokenDelegation):
+		return writeLocalizedErrorMessage(c, fiber.StatusForbidden, apierror.CodeApiTokenDelegationDenied, err.Error())
+	case errors.Is(err, services.ErrApiTokenNotFound):
+		return writeLocalizedErrorMessage(c, fiber.StatusNotFound, apierror.CodeApiTokenNotFound, err.Error())
+	default:
+		return writeUserServiceError(c, err)
+	}
+}
+
+// CreateToken issues a token; the plaintext is only returned in this response
+func (h *ApiTokenHandler) CreateToken(c fiber.Ctx) error {
+	userID, authErr := requiredUserID(c)
+	if authErr != nil {
+		logger.Error("Failed to create API token: unauthenticated")
+		return authErr
+	}
+	if tokenID, _ := c.Locals("api_token_id").(string); tokenID != "" {
+		return writeLocalizedError(c, fiber.StatusForbidden, apierror.CodeApiTokenSessionRequired)
+	}
+
+	var req struct {
+		Name       string     `json:"name"`
+		Scopes     []string   `json:"scopes"`
+		NetworkIDs []string   `json:"networkIds"`
+		ExpiresAt  *time.Time `json:"expiresAt"`
+	}
+	if err := c.Bind().Body(&req); err != nil {
+		return writeBindErrorWithCode(c, apierror.CodeApiTokenInvalidRequest, err)
+	}
+
+	token, plaintext, err := h.tokenService.CreateToken(services.ApiTokenCreateInput{
+		UserID:     userID,
+		Name:       req.Name,
+		Scopes:     req.Scopes,
+		NetworkIDs: req.NetworkIDs,
+		ExpiresAt:  req.ExpiresAt,
+	})
+	if err != nil {
+		logger.Error("Failed to create API token", zap.String("user_id", userID), zap.Error(err))
+		return writeApiTokenError(c, err)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"token":    plaintext,
+		"apiToken": token.ToResponse(),
+	})
+}
+
+// ListTokens lists the tokens of the current user without their plaintext
+func (h *ApiTokenHandler) ListTokens(c fiber.Ctx) error {
+	userID, authErr := requiredUserID(c)
+	if authErr != nil {
+		logger.Error("Failed to list API tokens: unauthenticated")
+		return authErr
+	}
+
+	tokens, err := h.tokenService.ListTokens(userID)
+	if err != nil {
+		logger.Error("Failed to list API tokens", zap.String("user_id", userID), zap.Error(err))
+		return writeApiTokenError(c, err)
+	}
+
+	responses := make([]models.ApiTokenResponse, 0, len(tokens))
+	for _, token := range tokens {
+		responses = append(responses, token.ToResponse())
+	}
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{"tokens": responses})
+}
+
+// RevokeToken revokes one token owned by the current user
+func (h *ApiTokenHandler) RevokeToken(c fiber.Ctx) error {
+	userID, authErr := requiredUserID(c)
+	if authErr != nil {
+		logger.Error("Failed to revoke API token: unauthenticated")
+		return authErr
+	}
+	tokenID := c.Params("tokenId")
+
+	if err := h.tokenService.RevokeToken(userID, tokenID); err != nil {
+		logger.Error("Failed to revoke API token", zap.String("user_id", userID), zap.String("token_id", tokenID), zap.Error(err))
+		return writeApiTokenError(c, err)
+	}
+
+	return writeLocalizedMessage(c, fiber.StatusOK, "api_token.revoked", nil)
+}
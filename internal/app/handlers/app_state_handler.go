@@ -0,0 +1,81 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/GT-610/tairitsu/internal/app/apierror"
+	"github.com/GT-610/tairitsu/internal/app/logger"
+	"github.com/GT-610/tairitsu/internal/app/services"
+	"github.com/gofiber/fiber/v3"
+	"go.uber.org/zap"
+)
+
+// appStatePasswordHeader carries the archive password, keeping it out of URLs and access logs
+const appStatePasswordHeader = "X-Archive-Password"
+
+// AppStateHandler handles export and import of Tairitsu-side state
+type AppStateHandler struct {
+	appStateService *services.AppStateService
+}
+
+// NewAppStateHandler creates a new app state handler instance
+func NewAppStateHandler(appStateService *services.AppStateService) *AppStateHandler {
+	return &AppStateHandler{appStateService: appStateService}
+}
+
+// ExportAppState downloads an archive of users, ownership, metadata, history and preferences,
+// encrypted with the password sent in the X-Archive-Password header
+func (h *AppStateHandler) ExportAppState(c fiber.Ctx) error {
+	password := c.Get(appStatePasswordHeader)
+	if password == "" {
+		return writeLocalizedError(c, fiber.StatusBadRequest, apierror.CodeAppStatePasswordRequired)
+	}
+
+	archive, err := h.appStateService.Export(password)
+	if err != nil {
+		return writeInternalError(c, "Failed to export app state", err)
+	}
+
+	c.Set(fiber.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="tairitsu-app-state-%s.json"`, archive.CreatedAt.Format("20060102-150405")))
+	c.Set(fiber.HeaderCacheControl, "no-store")
+	return c.Status(fiber.StatusOK).JSON(archive)
+}
+
+// ImportAppState restores an archive produced by ExportAppState. onConflict=abort (the default)
+// writes nothing when any row conflicts; onConflict=skip keeps existing rows and imports the rest.
+func (h *AppStateHandler) ImportAppState(c fiber.Ctx) error {
+	password := c.Get(appStatePasswordHeader)
+	if password == "" {
+		return writeLocalizedError(c, fiber.StatusBadRequest, apierror.CodeAppStatePasswordRequired)
+	}
+	onConflict := c.Query("onConflict", services.AppStateOnConflictAbort)
+	if onConflict != services.AppStateOnConflictAbort && onConflict != services.AppStateOnConflictSkip {
+		return writeLocalizedStatusError(c, fiber.StatusBadRequest, "request.invalid_parameter", "onConflict must be abort or skip")
+	}
+
+	var archive services.AppStateArchive
+	if err := json.Unmarshal(c.Body(), &archive); err != nil {
+		return writeLocalizedError(c, fiber.StatusBadRequest, apierror.CodeAppStateInvalidArchive)
+	}
+
+	report, err := h.appStateService.Import(&archive, password, onConflict)
+	switch {
+	case err == nil:
+		logger.Info("App state imported", zap.Any("imported", report.Imported), zap.Int("conflicts", len(report.Conflicts)))
+		return writeLocalizedMessage(c, fiber.StatusOK, "appstate.imported", fiber.Map{"report": report})
+	case errors.Is(err, services.ErrAppStateConflicts):
+		return writeErrorResponseWithExtra(c, apierror.New(fiber.StatusConflict, apierror.CodeAppStateConflicts, err.Error()).Translate(apierror.CodeAppStateConflicts), fiber.Map{
+			"report": report,
+		})
+	case errors.Is(err, services.ErrAppStateWrongPassword):
+		return writeLocalizedError(c, fiber.StatusUnprocessableEntity, apierror.CodeAppStateWrongPassword)
+	case errors.Is(err, services.ErrAppStateSchemaUnsupported):
+		return writeLocalizedErrorMessage(c, fiber.StatusUnprocessableEntity, apierror.CodeAppStateSchemaUnsupported, err.Error())
+	case errors.Is(err, services.ErrAppStateArchiveInvalid):
+		return writeLocalizedError(c, fiber.StatusBadRequest, apierror.CodeAppStateInvalidArchive)
+	default:
+		return writeInternalError(c, "Failed to import app state", err)
+	}
+}
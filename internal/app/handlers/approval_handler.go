@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"strconv"
+
+	"github.com/GT-610/tairitsu/internal/app/logger"
+	"github.com/GT-610/tairitsu/internal/app/services"
+	"github.com/gofiber/fiber/v3"
+	"go.uber.org/zap"
+)
+
+// ApprovalHandler serves the queue of members waiting for approval
+type ApprovalHandler struct {
+	networkService *services.NetworkService
+}
+
+// NewApprovalHandler creates a new approval handler instance
+func NewApprovalHandler(networkService *services.NetworkService) *ApprovalHandler {
+	return &ApprovalHandler{networkService: networkService}
+}
+
+type decideApprovalRequest struct {
+	Action string `json:"action"`
+}
+
+// ListApprovals returns the members waiting for approval in the caller's networks
+func (h *ApprovalHandler) ListApprovals(c fiber.Ctx) error {
+	userID, authErr := requiredUserID(c)
+	if authErr != nil {
+		logger.WithRequestID(c).Error("Failed to get user ID")
+		return authErr
+	}
+
+	approvals, err := h.networkService.ListPendingApprovals(userID)
+	if err != nil {
+		logger.WithRequestID(c).Error("Failed to list pending approvals", zap.Error(err))
+		return writeNetworkServiceError(c, err, "Network not found", "Network access denied")
+	}
+	return c.Status(fiber.StatusOK).JSON(approvals)
+}
+
+// DecideApproval approves or denies a queued member
+func (h *ApprovalHandler) DecideApproval(c fiber.Ctx) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 64)
+	if err != nil || id == 0 {
+		return writeLocalizedStatusError(c, fiber.StatusBadRequest, "request.invalid_integer", "id must be a positive integer")
+	}
+
+	var req decideApprovalRequest
+	if err := c.Bind().JSON(&req); err != nil {
+		return writeBindError(c, err)
+	}
+	if req.Action != "approve" && req.Action != "deny" {
+		return writeLocalizedStatusError(c, fiber.StatusBadRequest, "request.invalid_parameter", "action must be approve or deny")
+	}
+
+	userID, authErr := requiredUserID(c)
+	if authErr != nil {
+		logger.WithRequestID(c).Error("Failed to get user ID")
+		return authErr
+	}
+
+	approval, err := h.networkService.DecidePendingApproval(id, req.Action == "approve", userID)
+	if err != nil {
+		logger.WithRequestID(c).Error("Failed to decide pending approval", zap.Uint64("approval_id", id), zap.Error(err))
+		return writeNetworkServiceError(c, err, "Network not found", "Network member update access denied")
+	}
+	return c.Status(fiber.StatusOK).JSON(approval)
+}
@@ -0,0 +1,53 @@
+package handlers
+
+import (
+	"github.com/GT-610/tairitsu/internal/app/services"
+	"github.com/gofiber/fiber/v3"
+)
+
+// AuditHandler handles audit log endpoints
+type AuditHandler struct {
+	auditService *services.AuditService
+}
+
+// NewAuditHandler creates a new audit handler instance
+func NewAuditHandler(auditService *services.AuditService) *AuditHandler {
+	return &AuditHandler{
+		auditService: auditService,
+	}
+}
+
+// VerifyChain walks the audit hash chain and reports the first broken link
+func (h *AuditHandler) VerifyChain(c fiber.Ctx) error {
+	result, err := h.auditService.Verify()
+	if err != nil {
+		return writeInternalError(c, "Failed to verify audit chain", err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"verification": result,
+		"scheduled":    h.auditService.Metrics(),
+	})
+}
+
+// ListEntries returns audit entries newest first, paged by cursor or offset
+func (h *AuditHandler) ListEntries(c fiber.Ctx) error {
+	pageReq, _, err := parsePageRequest(c)
+	if err != nil {
+		_, resp := writePaginationError(c, err)
+		return resp
+	}
+
+	page, err := h.auditService.ListEntries(services.AuditListQuery{
+		Action:  c.Query("action"),
+		ActorID: c.Query("actor_id"),
+	}, pageReq)
+	if err != nil {
+		if handled, resp := writePaginationError(c, err); handled {
+			return resp
+		}
+		return writeInternalError(c, "Failed to list audit entries", err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(page)
+}
@@ -4,6 +4,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/GT-610/tairitsu/internal/app/apierror"
 	"github.com/GT-610/tairitsu/internal/app/logger"
 	"github.com/GT-610/tairitsu/internal/app/models"
 	"github.com/GT-610/tairitsu/internal/app/services"
@@ -18,6 +19,9 @@ type AuthHandler struct {
 	jwtService     *services.JWTService
 	runtimeService *services.RuntimeService
 	stateService   *services.StateService
+	oidc           *services.OIDCService
+	shipper        *services.LogShipper
+	captcha        *services.CaptchaGuard
 }
 
 // NewAuthHandler creates a new instance of AuthHandler
@@ -31,12 +35,34 @@ func NewAuthHandler(userService *services.UserService, sessionService *services.
 	}
 }
 
+// SetLogShipper forwards sign-ins, failed sign-ins and sign-outs to shipper
+func (h *AuthHandler) SetLogShipper(shipper *services.LogShipper) {
+	h.shipper = shipper
+}
+
+// SetCaptchaGuard requires a solved captcha on registration and on repeatedly failing sign-ins
+func (h *AuthHandler) SetCaptchaGuard(guard *services.CaptchaGuard) {
+	h.captcha = guard
+}
+
+// shipAuthEvent forwards an authentication event; target is the username the client gave
+func (h *AuthHandler) shipAuthEvent(c fiber.Ctx, action, actorID, target, details string) {
+	h.shipper.Ship(services.ShippedEvent{
+		Kind:    services.ShippedEventAuth,
+		Action:  action,
+		ActorID: actorID,
+		Target:  target,
+		Details: details,
+		IP:      strings.Clone(c.IP()),
+	})
+}
+
 // Register handles user registration requests
 func (h *AuthHandler) Register(c fiber.Ctx) error {
 	var req models.RegisterRequest
 	if err := c.Bind().Body(&req); err != nil {
 		logger.Error("Failed to bind registration request", zap.Error(err))
-		return writeErrorResponse(c, fiber.StatusBadRequest, err.Error())
+		return writeBindError(c, err)
 	}
 
 	logger.Info("Starting user registration", zap.String("username", req.Username))
@@ -46,6 +72,12 @@ func (h *AuthHandler) Register(c fiber.Ctx) error {
 		logger.Warn("Public registration is disabled; rejecting runtime registration", zap.String("username", req.Username))
 		return writeUserServiceError(c, services.ErrPublicRegistrationDisabled)
 	}
+	// The setup wizard registers the first administrator without a captcha widget
+	if h.stateService != nil && h.stateService.IsInitialized() {
+		if err := h.captcha.Check(c.Context(), req.CaptchaToken, strings.Clone(c.IP())); err != nil {
+			return writeCaptchaError(c, err)
+		}
+	}
 	if h.stateService != nil && !h.stateService.IsInitialized() {
 		hasAdmin, err := h.userService.HasAdminUser()
 		if err != nil {
@@ -74,10 +106,8 @@ func (h *AuthHandler) Register(c fiber.Ctx) error {
 		}
 	}
 
-	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
-		"user":         user.ToResponse(),
-		"message":      "Registration succeeded",
-		"message_code": "auth.registration_success",
+	return writeLocalizedMessage(c, fiber.StatusCreated, "auth.registration_success", fiber.Map{
+		"user": user.ToResponse(),
 	})
 }
 
@@ -86,45 +116,79 @@ func (h *AuthHandler) Login(c fiber.Ctx) error {
 	var req models.LoginRequest
 	if err := c.Bind().Body(&req); err != nil {
 		logger.Error("Failed to bind login request", zap.Error(err))
-		return writeErrorResponse(c, fiber.StatusBadRequest, err.Error())
+		return writeBindError(c, err)
 	}
 
 	logger.Info("User login attempt", zap.String("username", req.Username))
 
+	if h.oidc.PasswordLoginDisabled() {
+		logger.Warn("Password login is disabled; rejecting login attempt", zap.String("username", req.Username))
+		h.shipAuthEvent(c, services.AuthEventLoginFailed, "", req.Username, "method=password reason="+services.ErrPasswordLoginDisabled.Error())
+		return writeUserServiceError(c, services.ErrPasswordLoginDisabled)
+	}
+
+	clientIP := strings.Clone(c.IP())
+	if h.captcha.LoginNeedsCaptcha(req.Username, clientIP) {
+		if err := h.captcha.Check(c.Context(), req.CaptchaToken, clientIP); err != nil {
+			h.shipAuthEvent(c, services.AuthEventLoginFailed, "", req.Username, "method=password reason="+err.Error())
+			return writeCaptchaError(c, err)
+		}
+	}
+
 	user, err := h.userService.Login(&req)
 	if err != nil {
 		logger.Error("User login failed", zap.String("username", req.Username), zap.Error(err))
+		h.shipAuthEvent(c, services.AuthEventLoginFailed, "", req.Username, "method=password reason="+err.Error())
+		if services.IsInvalidCredentials(err) && h.captcha.RecordLoginFailure(req.Username, clientIP) {
+			return writeErrorResponseWithExtra(c, apierror.New(fiber.StatusUnauthorized, apierror.CodeUserInvalidCredentials, err.Error()).Translate(apierror.CodeUserInvalidCredentials), fiber.Map{
+				"captchaRequired": true,
+			})
+		}
 		return writeUserServiceError(c, err)
 	}
+	h.captcha.ClearLoginFailures(req.Username)
 
 	logger.Info("User logged in successfully", zap.String("user_id", user.ID), zap.String("username", user.Username))
 
+	session, token, err := h.startSession(c, user, req.RememberMe)
+	if err != nil {
+		if session == nil {
+			logger.Error("Failed to create login session", zap.String("user_id", user.ID), zap.Error(err))
+			return writeUserServiceError(c, err)
+		}
+		logger.Error("Failed to generate JWT token", zap.String("user_id", user.ID), zap.Error(err))
+		return writeLocalizedError(c, fiber.StatusInternalServerError, apierror.CodeAuthTokenGenerationFailed)
+	}
+
+	logger.Info("JWT token generated successfully", zap.String("user_id", user.ID))
+	h.shipAuthEvent(c, services.AuthEventLogin, user.ID, user.Username, "method=password session="+session.ID)
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"token":   token,
+		"user":    user.ToResponse(),
+		"session": session.ToResponse(true),
+	})
+}
+
+// startSession records a login session for user and signs its access token. The session is
+// returned even when only signing the token failed.
+func (h *AuthHandler) startSession(c fiber.Ctx, user *models.User, rememberMe bool) (*models.Session, string, error) {
 	session, err := h.sessionService.CreateSession(services.SessionCreateInput{
 		UserID:     user.ID,
 		UserAgent:  c.Get("User-Agent"),
 		IPAddress:  strings.Clone(c.IP()),
-		RememberMe: req.RememberMe,
+		RememberMe: rememberMe,
 		ExpiresAt:  time.Now().Add(h.jwtService.AccessExpiry()),
 	})
 	if err != nil {
-		logger.Error("Failed to create login session", zap.String("user_id", user.ID), zap.Error(err))
-		return writeUserServiceError(c, err)
+		return nil, "", err
 	}
 
-	// Generate JWT token for authenticated user
 	token, err := h.jwtService.GenerateToken(user, session.ID)
 	if err != nil {
-		logger.Error("Failed to generate JWT token", zap.String("user_id", user.ID), zap.Error(err))
-		return writeErrorResponseWithCode(c, fiber.StatusInternalServerError, "auth.token_generation_failed", "Failed to generate token")
+		return session, "", err
 	}
-
-	logger.Info("JWT token generated successfully", zap.String("user_id", user.ID))
-
-	return c.Status(fiber.StatusOK).JSON(fiber.Map{
-		"token":   token,
-		"user":    user.ToResponse(),
-		"session": session.ToResponse(true),
-	})
+	return session, token, nil
 }
 
 // GetProfile retrieves the authenticated user's profile information
@@ -148,6 +212,30 @@ func (h *AuthHandler) GetProfile(c fiber.Ctx) error {
 	return c.Status(fiber.StatusOK).JSON(user.ToResponse())
 }
 
+// UpdateProfile changes the authenticated user's email address
+func (h *AuthHandler) UpdateProfile(c fiber.Ctx) error {
+	userID, authErr := requiredUserID(c)
+	if authErr != nil {
+		logger.Error("Failed to update profile: unauthenticated")
+		return authErr
+	}
+
+	var req models.UpdateProfileRequest
+	if err := c.Bind().Body(&req); err != nil {
+		logger.Error("Failed to bind profile update request", zap.Error(err))
+		return writeBindError(c, err)
+	}
+
+	user, err := h.userService.UpdateProfile(userID, &req)
+	if err != nil {
+		logger.Error("Profile update failed", zap.String("user_id", userID), zap.Error(err))
+		return writeUserServiceError(c, err)
+	}
+
+	logger.Info("Profile updated successfully", zap.String("user_id", userID))
+	return c.Status(fiber.StatusOK).JSON(user.ToResponse())
+}
+
 // ChangePassword handles user password change requests
 func (h *AuthHandler) ChangePassword(c fiber.Ctx) error {
 	// Get user ID from context (set by auth middleware)
@@ -161,7 +249,7 @@ func (h *AuthHandler) ChangePassword(c fiber.Ctx) error {
 	var req models.ChangePasswordRequest
 	if err := c.Bind().Body(&req); err != nil {
 		logger.Error("Failed to bind change password request", zap.Error(err))
-		return writeErrorResponse(c, fiber.StatusBadRequest, err.Error())
+		return writeBindError(c, err)
 	}
 
 	logger.Info("Processing password change request", zap.String("user_id", userID))
@@ -169,7 +257,7 @@ func (h *AuthHandler) ChangePassword(c fiber.Ctx) error {
 
 	if req.NewPassword != req.ConfirmPassword {
 		logger.Error("Password change failed: confirmation mismatch", zap.String("user_id", userID))
-		return writeErrorResponseWithCode(c, fiber.StatusBadRequest, "auth.password_confirmation_mismatch", "The new password and confirmation do not match")
+		return writeLocalizedError(c, fiber.StatusBadRequest, apierror.CodeAuthPasswordConfirmationMismatch)
 	}
 
 	revokedCount := 0
@@ -188,13 +276,46 @@ func (h *AuthHandler) ChangePassword(c fiber.Ctx) error {
 	logger.Info("Password changed successfully", zap.String("user_id", userID))
 
 	// Return success response
-	return c.Status(fiber.StatusOK).JSON(fiber.Map{
-		"message":                "Password updated successfully",
-		"message_code":           "auth.password_updated",
-		"revoked_other_sessions": revokedCount,
+	return writeLocalizedMessage(c, fiber.StatusOK, "auth.password_updated", fiber.Map{
+		"revokedOtherSessions": revokedCount,
 	})
 }
 
+// RequestPasswordReset issues a reset token for the named account. The response is the same
+// whether or not the account exists.
+func (h *AuthHandler) RequestPasswordReset(c fiber.Ctx) error {
+	var req models.PasswordResetRequest
+	if err := c.Bind().Body(&req); err != nil {
+		logger.Error("Failed to bind password reset request", zap.Error(err))
+		return writeBindError(c, err)
+	}
+
+	if err := h.userService.RequestPasswordReset(&req); err != nil {
+		logger.Error("Password reset request failed", zap.Error(err))
+		return writeUserServiceError(c, err)
+	}
+
+	return writeLocalizedMessage(c, fiber.StatusAccepted, "auth.password_reset_requested", nil)
+}
+
+// ConfirmPasswordReset sets a new password with a reset token
+func (h *AuthHandler) ConfirmPasswordReset(c fiber.Ctx) error {
+	var req models.PasswordResetConfirmRequest
+	if err := c.Bind().Body(&req); err != nil {
+		logger.Error("Failed to bind password reset confirmation", zap.Error(err))
+		return writeBindError(c, err)
+	}
+
+	user, err := h.userService.ConfirmPasswordReset(&req)
+	if err != nil {
+		logger.Error("Password reset confirmation failed", zap.Error(err))
+		return writeUserServiceError(c, err)
+	}
+
+	logger.Info("Password reset with token", zap.String("user_id", user.ID))
+	return writeLocalizedMessage(c, fiber.StatusOK, "auth.password_reset_completed", nil)
+}
+
 // ListSessions returns the current user's active and historical sessions.
 func (h *AuthHandler) ListSessions(c fiber.Ctx) error {
 	userID, authErr := requiredUserID(c)
@@ -235,8 +356,9 @@ func (h *AuthHandler) Logout(c fiber.Ctx) error {
 		logger.Error("Logout failed", zap.String("user_id", userID), zap.String("session_id", sessionID), zap.Error(err))
 		return writeUserServiceError(c, err)
 	}
+	h.shipAuthEvent(c, services.AuthEventLogout, userID, "", "session="+sessionID)
 
-	return writeMessageResponse(c, fiber.StatusOK, "auth.logout_success", "Current session signed out", nil)
+	return writeLocalizedMessage(c, fiber.StatusOK, "auth.logout_success", nil)
 }
 
 // RevokeSession revokes one session owned by the current user.
@@ -253,7 +375,7 @@ func (h *AuthHandler) RevokeSession(c fiber.Ctx) error {
 		return writeUserServiceError(c, err)
 	}
 
-	return writeMessageResponse(c, fiber.StatusOK, "auth.session_removed", "Session removed", nil)
+	return writeLocalizedMessage(c, fiber.StatusOK, "auth.session_removed", nil)
 }
 
 // RevokeOtherSessions revokes all other sessions of the current user.
@@ -271,9 +393,25 @@ func (h *AuthHandler) RevokeOtherSessions(c fiber.Ctx) error {
 		return writeUserServiceError(c, err)
 	}
 
-	return c.Status(fiber.StatusOK).JSON(fiber.Map{
-		"message":      "Other sessions removed",
-		"message_code": "auth.other_sessions_removed",
-		"count":        count,
+	return writeLocalizedMessage(c, fiber.StatusOK, "auth.other_sessions_removed", fiber.Map{
+		"count": count,
+	})
+}
+
+// RotateJWTSecret replaces the token signing secret. Tokens signed with the previous secret
+// stay valid until previousSecretValidUntil, so nobody is signed out.
+func (h *AuthHandler) RotateJWTSecret(c fiber.Ctx) error {
+	if h.stateService == nil {
+		return writeLocalizedError(c, fiber.StatusInternalServerError, apierror.CodeSystemInternalError)
+	}
+
+	previousValidUntil, err := h.stateService.RotateJWTSecret(h.jwtService)
+	if err != nil {
+		logger.Error("Failed to rotate JWT secret", zap.Error(err))
+		return writeLocalizedError(c, fiber.StatusInternalServerError, apierror.CodeAuthJWTSecretRotationFailed)
+	}
+
+	return writeLocalizedMessage(c, fiber.StatusOK, "auth.jwt_secret_rotated", fiber.Map{
+		"previousSecretValidUntil": previousValidUntil,
 	})
 }
@@ -0,0 +1,139 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/GT-610/tairitsu/internal/app/apierror"
+	"github.com/GT-610/tairitsu/internal/app/logger"
+	"github.com/GT-610/tairitsu/internal/app/services"
+	"github.com/gofiber/fiber/v3"
+	"go.uber.org/zap"
+)
+
+// oidcStateCookie binds a provider round trip to the browser that started it
+const oidcStateCookie = "tairitsu_oidc"
+
+// oidcStateTTL is how long a started single sign-on may take
+const oidcStateTTL = 10 * time.Minute
+
+// SetOIDCService enables the OpenID Connect endpoints
+func (h *AuthHandler) SetOIDCService(oidc *services.OIDCService) {
+	h.oidc = oidc
+}
+
+// AuthMethods reports which sign-in methods the login page should offer
+func (h *AuthHandler) AuthMethods(c fiber.Ctx) error {
+	methods := fiber.Map{
+		"password": !h.oidc.PasswordLoginDisabled(),
+		"oidc":     h.oidc.Enabled(),
+	}
+	if challenge := h.captcha.Challenge(); challenge != nil {
+		methods["captcha"] = challenge
+	}
+	return c.Status(fiber.StatusOK).JSON(methods)
+}
+
+// OIDCLogin redirects the browser to the OpenID Connect provider
+func (h *AuthHandler) OIDCLogin(c fiber.Ctx) error {
+	if !h.oidc.Enabled() {
+		return writeUserServiceError(c, services.ErrOIDCDisabled)
+	}
+
+	state, err := randomURLToken()
+	if err != nil {
+		return writeLocalizedError(c, fiber.StatusInternalServerError, apierror.CodeSystemInternalError)
+	}
+	nonce, err := randomURLToken()
+	if err != nil {
+		return writeLocalizedError(c, fiber.StatusInternalServerError, apierror.CodeSystemInternalError)
+	}
+	target, err := h.oidc.AuthorizationURL(c.Context(), state, nonce)
+	if err != nil {
+		logger.Error("Failed to start single sign-on", zap.Error(err))
+		return h.redirectAfterOIDC(c, url.Values{"error": {oidcErrorCode(err)}})
+	}
+
+	c.Cookie(&fiber.Cookie{
+		Name:     oidcStateCookie,
+		Value:    state + "." + nonce,
+		Path:     "/api/auth/oidc",
+		MaxAge:   int(oidcStateTTL / time.Second),
+		Secure:   c.Protocol() == "https",
+		HTTPOnly: true,
+		SameSite: fiber.CookieSameSiteLaxMode,
+	})
+	return c.Redirect().Status(fiber.StatusFound).To(target)
+}
+
+// OIDCCallback finishes a provider sign-in and hands the browser a normal Tairitsu token in
+// the URL fragment of the post-login page, or an error code when sign-in failed
+func (h *AuthHandler) OIDCCallback(c fiber.Ctx) error {
+	if !h.oidc.Enabled() {
+		return writeUserServiceError(c, services.ErrOIDCDisabled)
+	}
+
+	stored := c.Cookies(oidcStateCookie)
+	c.ClearCookie(oidcStateCookie)
+	state, nonce, _ := strings.Cut(stored, ".")
+	if state == "" || subtle.ConstantTimeCompare([]byte(state), []byte(c.Query("state"))) != 1 {
+		logger.Warn("Single sign-on callback with an unknown state")
+		return h.redirectAfterOIDC(c, url.Values{"error": {apierror.CodeAuthOIDCInvalidState}})
+	}
+	if providerError := c.Query("error"); providerError != "" {
+		logger.Warn("Single sign-on provider refused the sign-in", zap.String("error", providerError), zap.String("description", c.Query("error_description")))
+		return h.redirectAfterOIDC(c, url.Values{"error": {apierror.CodeAuthOIDCProviderError}})
+	}
+
+	user, err := h.oidc.SignIn(c.Context(), c.Query("code"), nonce)
+	if err != nil {
+		logger.Error("Single sign-on failed", zap.Error(err))
+		h.shipAuthEvent(c, services.AuthEventLoginFailed, "", "", "method=oidc reason="+err.Error())
+		return h.redirectAfterOIDC(c, url.Values{"error": {oidcErrorCode(err)}})
+	}
+
+	session, token, err := h.startSession(c, user, false)
+	if err != nil {
+		logger.Error("Failed to start single sign-on session", zap.String("user_id", user.ID), zap.Error(err))
+		return h.redirectAfterOIDC(c, url.Values{"error": {apierror.CodeAuthTokenGenerationFailed}})
+	}
+
+	logger.Info("User logged in through single sign-on", zap.String("user_id", user.ID), zap.String("session_id", session.ID))
+	h.shipAuthEvent(c, services.AuthEventLogin, user.ID, user.Username, "method=oidc session="+session.ID)
+	return h.redirectAfterOIDC(c, url.Values{"token": {token}})
+}
+
+// redirectAfterOIDC sends the browser to the post-login page with values in the fragment,
+// which browsers never send to servers or put in Referer headers
+func (h *AuthHandler) redirectAfterOIDC(c fiber.Ctx, values url.Values) error {
+	return c.Redirect().Status(fiber.StatusFound).To(h.oidc.PostLoginRedirect() + "#" + values.Encode())
+}
+
+// oidcErrorCode maps a single sign-on failure to the error code shown by the login page
+func oidcErrorCode(err error) string {
+	switch {
+	case services.IsOIDCProviderError(err):
+		return apierror.CodeAuthOIDCProviderError
+	case services.IsOIDCInvalidToken(err):
+		return apierror.CodeAuthOIDCInvalidToken
+	case services.IsOIDCEmailNotVerified(err):
+		return apierror.CodeAuthOIDCEmailNotVerified
+	case services.IsEmailExists(err):
+		return apierror.CodeUserEmailExists
+	case services.IsUserDBUnavailable(err):
+		return apierror.CodeUserDBUnavailable
+	}
+	return apierror.CodeSystemInternalError
+}
+
+func randomURLToken() (string, error) {
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(raw), nil
+}
@@ -0,0 +1,61 @@
+package handlers
+
+import (
+	"errors"
+
+	"github.com/GT-610/tairitsu/internal/app/apierror"
+	"github.com/GT-610/tairitsu/internal/app/logger"
+	"github.com/GT-610/tairitsu/internal/app/services"
+	"github.com/gofiber/fiber/v3"
+	"go.uber.org/zap"
+)
+
+// ChecklistHandler handles the post-setup onboarding checklist
+type ChecklistHandler struct {
+	checklistService *services.ChecklistService
+}
+
+// NewChecklistHandler creates a new checklist handler instance
+func NewChecklistHandler(checklistService *services.ChecklistService) *ChecklistHandler {
+	return &ChecklistHandler{
+		checklistService: checklistService,
+	}
+}
+
+// GetChecklist returns the onboarding checklist with completion state
+func (h *ChecklistHandler) GetChecklist(c fiber.Ctx) error {
+	// Secure only believes X-Forwarded-Proto from the trusted proxies the app is configured with
+	checklist, err := h.checklistService.GetChecklist(services.ChecklistContext{
+		SecureTransport: c.Secure(),
+	})
+	if err != nil {
+		return writeInternalError(c, "Failed to compute onboarding checklist", err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(checklist)
+}
+
+// UpdateChecklistItem dismisses or restores a checklist item
+func (h *ChecklistHandler) UpdateChecklistItem(c fiber.Ctx) error {
+	itemID := c.Params("itemId")
+
+	var req struct {
+		Dismissed bool `json:"dismissed"`
+	}
+	if err := c.Bind().Body(&req); err != nil {
+		logger.Error("Failed to bind checklist item request", zap.Error(err))
+		return writeBindErrorWithCode(c, apierror.CodeSystemInvalidRequest, err)
+	}
+
+	if err := h.checklistService.SetItemDismissed(itemID, req.Dismissed); err != nil {
+		if errors.Is(err, services.ErrChecklistItemNotFound) {
+			return writeLocalizedError(c, fiber.StatusNotFound, apierror.CodeChecklistItemNotFound)
+		}
+		return writeInternalError(c, "Failed to update checklist item", err, zap.String("item_id", itemID))
+	}
+
+	return writeLocalizedMessage(c, fiber.StatusOK, "checklist.item_updated", fiber.Map{
+		"id":        itemID,
+		"dismissed": req.Dismissed,
+	})
+}
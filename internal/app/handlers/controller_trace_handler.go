@@ -0,0 +1,99 @@
+package handlers
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/GT-610/tairitsu/internal/app/apierror"
+	"github.com/GT-610/tairitsu/internal/app/services"
+	"github.com/gofiber/fiber/v3"
+)
+
+// ControllerTraceHandler handles forwarded controller trace endpoints
+type ControllerTraceHandler struct {
+	traceService *services.ControllerTraceService
+}
+
+// NewControllerTraceHandler creates a new controller trace handler instance
+func NewControllerTraceHandler(traceService *services.ControllerTraceService) *ControllerTraceHandler {
+	return &ControllerTraceHandler{
+		traceService: traceService,
+	}
+}
+
+// IngestTrace stores forwarded trace lines, sent either as a JSON body {"lines": [...]}
+// or as plain text with one line per trace line
+func (h *ControllerTraceHandler) IngestTrace(c fiber.Ctx) error {
+	var lines []string
+	if strings.HasPrefix(strings.ToLower(c.Get(fiber.HeaderContentType)), fiber.MIMEApplicationJSON) {
+		var req struct {
+			Lines []string `json:"lines"`
+		}
+		if err := c.Bind().Body(&req); err != nil {
+			return writeBindError(c, err)
+		}
+		lines = req.Lines
+	} else {
+		lines = strings.Split(string(c.Body()), "\n")
+	}
+
+	result, err := h.traceService.Ingest(lines)
+	if err != nil {
+		if errors.Is(err, services.ErrTraceBatchTooLarge) {
+			return writeLocalizedErrorMessage(c, fiber.StatusRequestEntityTooLarge, apierror.CodeTraceBatchTooLarge, err.Error())
+		}
+		return writeInternalError(c, "Failed to ingest controller trace", err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(result)
+}
+
+// ListTrace returns stored trace lines newest first, filtered by network_id, member_id and parsed
+func (h *ControllerTraceHandler) ListTrace(c fiber.Ctx) error {
+	query := services.ControllerTraceListQuery{
+		NetworkID: c.Query("network_id"),
+		MemberID:  c.Query("member_id"),
+	}
+	if raw := c.Query("parsed"); raw != "" {
+		parsed, err := strconv.ParseBool(raw)
+		if err != nil {
+			return writeLocalizedStatusError(c, fiber.StatusBadRequest, "request.invalid_boolean", "parsed must be true or false")
+		}
+		query.Parsed = &parsed
+	}
+	if raw := c.Query("before_id"); raw != "" {
+		beforeID, err := strconv.ParseUint(raw, 10, 64)
+		if err != nil {
+			return writeLocalizedStatusError(c, fiber.StatusBadRequest, "request.invalid_integer", "before_id must be a positive integer")
+		}
+		query.BeforeID = beforeID
+	}
+	limit, err := parseTraceLimit(c)
+	if err != nil {
+		return writeLocalizedStatusError(c, fiber.StatusBadRequest, "request.invalid_integer", err.Error())
+	}
+	query.Limit = limit
+
+	events, err := h.traceService.List(query)
+	if err != nil {
+		return writeInternalError(c, "Failed to list controller trace", err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"events": events,
+		"stats":  h.traceService.Stats(),
+	})
+}
+
+func parseTraceLimit(c fiber.Ctx) (int, error) {
+	raw := c.Query("limit")
+	if raw == "" {
+		return 0, nil
+	}
+	limit, err := strconv.Atoi(raw)
+	if err != nil || limit <= 0 {
+		return 0, errors.New("limit must be a positive integer")
+	}
+	return limit, nil
+}
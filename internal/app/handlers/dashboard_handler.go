@@ -0,0 +1,31 @@
+package handlers
+
+import (
+	"github.com/GT-610/tairitsu/internal/app/services"
+	"github.com/gofiber/fiber/v3"
+)
+
+// DashboardHandler serves the overview shown after login
+type DashboardHandler struct {
+	dashboardService *services.DashboardService
+}
+
+// NewDashboardHandler creates a new dashboard handler instance
+func NewDashboardHandler(dashboardService *services.DashboardService) *DashboardHandler {
+	return &DashboardHandler{
+		dashboardService: dashboardService,
+	}
+}
+
+// GetDashboard returns the dashboard summary; sections that fail are null rather than
+// failing the request
+func (h *DashboardHandler) GetDashboard(c fiber.Ctx) error {
+	userID, err := requiredUserID(c)
+	if userID == "" {
+		return err
+	}
+	role, _ := c.Locals("role").(string)
+
+	summary := h.dashboardService.Summary(c.Context(), userID, role == "admin", services.ChecklistContext{SecureTransport: c.Secure()})
+	return c.Status(fiber.StatusOK).JSON(summary)
+}
@@ -0,0 +1,182 @@
+package handlers
+
+import (
+	"strconv"
+
+	"github.com/GT-610/tairitsu/internal/app/logger"
+	"github.com/GT-610/tairitsu/internal/app/services"
+	"github.com/gofiber/fiber/v3"
+	"go.uber.org/zap"
+)
+
+// DeviceHandler lets users claim their own ZeroTier nodes and manage them across networks
+type DeviceHandler struct {
+	networkService *services.NetworkService
+}
+
+// NewDeviceHandler creates a new device handler instance
+func NewDeviceHandler(networkService *services.NetworkService) *DeviceHandler {
+	return &DeviceHandler{networkService: networkService}
+}
+
+type claimDeviceRequest struct {
+	NodeAddress string `json:"nodeAddress"`
+}
+
+type renameDeviceRequest struct {
+	DisplayName string `json:"displayName"`
+}
+
+type decideDeviceClaimRequest struct {
+	Action string `json:"action"`
+}
+
+// ListDevices returns the caller's claimed devices with their network memberships
+func (h *DeviceHandler) ListDevices(c fiber.Ctx) error {
+	userID, authErr := requiredUserID(c)
+	if authErr != nil {
+		logger.WithRequestID(c).Error("Failed to get user ID")
+		return authErr
+	}
+
+	devices, err := h.networkService.ListDevices(userID)
+	if err != nil {
+		logger.WithRequestID(c).Error("Failed to list devices", zap.Error(err))
+		return writeNetworkServiceError(c, err, "Network not found", "Network access denied")
+	}
+	return c.Status(fiber.StatusOK).JSON(devices)
+}
+
+// ClaimDevice claims a node for the caller
+func (h *DeviceHandler) ClaimDevice(c fiber.Ctx) error {
+	var req claimDeviceRequest
+	if err := c.Bind().JSON(&req); err != nil {
+		return writeBindError(c, err)
+	}
+	if err := validateNodeAddress(req.NodeAddress); err != nil {
+		return writeValidationError(c, err)
+	}
+
+	userID, authErr := requiredUserID(c)
+	if authErr != nil {
+		logger.WithRequestID(c).Error("Failed to get user ID")
+		return authErr
+	}
+
+	device, err := h.networkService.ClaimDevice(userID, req.NodeAddress)
+	if err != nil {
+		logger.WithRequestID(c).Error("Failed to claim device", zap.String("node_address", req.NodeAddress), zap.Error(err))
+		return writeNetworkServiceError(c, err, "Network not found", "Network access denied")
+	}
+	return c.Status(fiber.StatusOK).JSON(device)
+}
+
+// DeleteDevice drops the caller's claim on a node
+func (h *DeviceHandler) DeleteDevice(c fiber.Ctx) error {
+	address := c.Params("address")
+	if err := validateNodeAddress(address); err != nil {
+		return writeValidationError(c, err)
+	}
+
+	userID, authErr := requiredUserID(c)
+	if authErr != nil {
+		logger.WithRequestID(c).Error("Failed to get user ID")
+		return authErr
+	}
+
+	if err := h.networkService.DeleteDeviceClaim(userID, address); err != nil {
+		logger.WithRequestID(c).Error("Failed to delete device claim", zap.String("node_address", address), zap.Error(err))
+		return writeNetworkServiceError(c, err, "Network not found", "Network access denied")
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// RenameDevice sets the display name of a verified device on all of its networks
+func (h *DeviceHandler) RenameDevice(c fiber.Ctx) error {
+	address := c.Params("address")
+	if err := validateNodeAddress(address); err != nil {
+		return writeValidationError(c, err)
+	}
+
+	var req renameDeviceRequest
+	if err := c.Bind().JSON(&req); err != nil {
+		return writeBindError(c, err)
+	}
+
+	userID, authErr := requiredUserID(c)
+	if authErr != nil {
+		logger.WithRequestID(c).Error("Failed to get user ID")
+		return authErr
+	}
+
+	device, err := h.networkService.RenameDevice(userID, address, req.DisplayName)
+	if err != nil {
+		logger.WithRequestID(c).Error("Failed to rename device", zap.String("node_address", address), zap.Error(err))
+		return writeNetworkServiceError(c, err, "Network not found", "Network access denied")
+	}
+	return c.Status(fiber.StatusOK).JSON(device)
+}
+
+// RequestAuthorization asks the network owner to authorize a verified device
+func (h *DeviceHandler) RequestAuthorization(c fiber.Ctx) error {
+	address := c.Params("address")
+	networkID := c.Params("id")
+	if err := validateNodeAddress(address); err != nil {
+		return writeValidationError(c, err)
+	}
+	if err := validateNetworkID(networkID); err != nil {
+		return writeValidationError(c, err)
+	}
+
+	userID, authErr := requiredUserID(c)
+	if authErr != nil {
+		logger.WithRequestID(c).Error("Failed to get user ID")
+		return authErr
+	}
+
+	approval, err := h.networkService.RequestDeviceAuthorization(userID, address, networkID)
+	if err != nil {
+		logger.WithRequestID(c).Error("Failed to request device authorization", zap.String("network_id", networkID), zap.String("node_address", address), zap.Error(err))
+		return writeNetworkServiceError(c, err, "Network not found", "Network access denied")
+	}
+	return c.Status(fiber.StatusOK).JSON(approval)
+}
+
+// ListClaims returns the device claims waiting for an administrator
+func (h *DeviceHandler) ListClaims(c fiber.Ctx) error {
+	claims, err := h.networkService.ListUnverifiedDeviceClaims()
+	if err != nil {
+		logger.WithRequestID(c).Error("Failed to list device claims", zap.Error(err))
+		return writeNetworkServiceError(c, err, "Network not found", "Network access denied")
+	}
+	return c.Status(fiber.StatusOK).JSON(claims)
+}
+
+// DecideClaim verifies or rejects a device claim
+func (h *DeviceHandler) DecideClaim(c fiber.Ctx) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 64)
+	if err != nil || id == 0 {
+		return writeLocalizedStatusError(c, fiber.StatusBadRequest, "request.invalid_integer", "id must be a positive integer")
+	}
+
+	var req decideDeviceClaimRequest
+	if err := c.Bind().JSON(&req); err != nil {
+		return writeBindError(c, err)
+	}
+	if req.Action != "approve" && req.Action != "deny" {
+		return writeLocalizedStatusError(c, fiber.StatusBadRequest, "request.invalid_parameter", "action must be approve or deny")
+	}
+
+	userID, authErr := requiredUserID(c)
+	if authErr != nil {
+		logger.WithRequestID(c).Error("Failed to get user ID")
+		return authErr
+	}
+
+	claim, err := h.networkService.DecideDeviceClaim(id, req.Action == "approve", userID)
+	if err != nil {
+		logger.WithRequestID(c).Error("Failed to decide device claim", zap.Uint64("claim_id", id), zap.Error(err))
+		return writeNetworkServiceError(c, err, "Network not found", "Network access denied")
+	}
+	return c.Status(fiber.StatusOK).JSON(claim)
+}
@@ -0,0 +1,122 @@
+package handlers
+
+import (
+	"errors"
+	"io/fs"
+	"mime"
+	"path"
+	"strings"
+
+	"github.com/GT-610/tairitsu/internal/app/logger"
+	"github.com/gofiber/fiber/v3"
+	"go.uber.org/zap"
+)
+
+const (
+	frontendIndex = "index.html"
+	// Vite writes content-hashed file names under assets/, so they never change in place
+	frontendHashedAssets    = "assets/"
+	frontendImmutableCache  = "public, max-age=31536000, immutable"
+	frontendRevalidateCache = "no-cache"
+)
+
+// frontendEncodings are the precompressed variants looked for next to a file, preferred first
+var frontendEncodings = []struct {
+	name      string
+	extension string
+}{
+	{name: "br", extension: ".br"},
+	{name: "gzip", extension: ".gz"},
+}
+
+// FrontendHandler serves the built web interface for every path outside /api
+type FrontendHandler struct {
+	files fs.FS
+}
+
+// NewFrontendHandler creates a frontend handler serving files from a frontend build
+func NewFrontendHandler(files fs.FS) *FrontendHandler {
+	return &FrontendHandler{
+		files: files,
+	}
+}
+
+// Serve sends the requested file. Paths without a file extension that match no file are
+// client-side routes and get index.html; /api paths are left to the API routes.
+func (h *FrontendHandler) Serve(c fiber.Ctx) error {
+	requestPath := c.Path()
+	if requestPath == "/api" || strings.HasPrefix(requestPath, "/api/") {
+		return c.Next()
+	}
+
+	name := strings.TrimPrefix(path.Clean("/"+requestPath), "/")
+	if name == "" {
+		name = frontendIndex
+	}
+	if !h.isFile(name) {
+		if path.Ext(name) != "" {
+			return writeLocalizedStatusError(c, fiber.StatusNotFound, "request.file_not_found", "File not found")
+		}
+		name = frontendIndex
+	}
+	return h.sendFile(c, name)
+}
+
+func (h *FrontendHandler) isFile(name string) bool {
+	info, err := fs.Stat(h.files, name)
+	return err == nil && !info.IsDir()
+}
+
+func (h *FrontendHandler) sendFile(c fiber.Ctx, name string) error {
+	// Variants are picked by Accept-Encoding, so caches must keep them apart
+	c.Vary(fiber.HeaderAcceptEncoding)
+	file, encoding := name, ""
+	for _, candidate := range frontendEncodings {
+		if acceptsEncoding(c.Get(fiber.HeaderAcceptEncoding), candidate.name) && h.isFile(name+candidate.extension) {
+			file, encoding = name+candidate.extension, candidate.name
+			break
+		}
+	}
+
+	content, err := fs.ReadFile(h.files, file)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			logger.Error("Frontend build has no index.html", zap.String("file", file))
+			return writeLocalizedStatusError(c, fiber.StatusNotFound, "request.file_not_found", "File not found")
+		}
+		return writeInternalError(c, "Failed to read frontend file", err, zap.String("file", file))
+	}
+
+	contentType := mime.TypeByExtension(path.Ext(name))
+	if contentType == "" {
+		contentType = fiber.MIMEOctetStream
+	}
+	c.Set(fiber.HeaderContentType, contentType)
+	if encoding != "" {
+		c.Set(fiber.HeaderContentEncoding, encoding)
+	}
+	if strings.HasPrefix(name, frontendHashedAssets) {
+		c.Set(fiber.HeaderCacheControl, frontendImmutableCache)
+	} else {
+		c.Set(fiber.HeaderCacheControl, frontendRevalidateCache)
+	}
+	return c.Status(fiber.StatusOK).Send(content)
+}
+
+// acceptsEncoding reports whether an Accept-Encoding header allows encoding; q=0 refuses it
+func acceptsEncoding(header, encoding string) bool {
+	for _, part := range strings.Split(header, ",") {
+		token, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if !strings.EqualFold(strings.TrimSpace(token), encoding) {
+			continue
+		}
+		for _, param := range strings.Split(params, ";") {
+			key, value, _ := strings.Cut(strings.TrimSpace(param), "=")
+			if strings.EqualFold(key, "q") && strings.Trim(strings.TrimSpace(value), "0.") == "" {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
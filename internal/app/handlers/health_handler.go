@@ -0,0 +1,32 @@
+package handlers
+
+import (
+	"github.com/GT-610/tairitsu/internal/app/services"
+	"github.com/gofiber/fiber/v3"
+)
+
+// HealthHandler reports dependency health for load balancers and uptime monitors
+type HealthHandler struct {
+	healthService *services.HealthService
+}
+
+// NewHealthHandler creates a new health handler instance
+func NewHealthHandler(healthService *services.HealthService) *HealthHandler {
+	return &HealthHandler{
+		healthService: healthService,
+	}
+}
+
+// GetHealth probes the database and controller; ?verbose=false skips the probes for cheap liveness checks
+func (h *HealthHandler) GetHealth(c fiber.Ctx) error {
+	if c.Query("verbose") == "false" {
+		return c.Status(fiber.StatusOK).JSON(fiber.Map{"status": services.HealthStatusOK})
+	}
+
+	report := h.healthService.Check(c.Context())
+	status := fiber.StatusOK
+	if report.Status == services.HealthStatusDown {
+		status = fiber.StatusServiceUnavailable
+	}
+	return c.Status(status).JSON(report)
+}
@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/GT-610/tairitsu/internal/app/apierror"
+	"github.com/GT-610/tairitsu/internal/app/database"
+	"github.com/GT-610/tairitsu/internal/app/logger"
+	"github.com/GT-610/tairitsu/internal/app/services"
+	"github.com/gofiber/fiber/v3"
+	"go.uber.org/zap"
+)
+
+// JobsHandler exposes background jobs to administrators
+type JobsHandler struct {
+	hub           *services.MemberEventHub
+	dbMaintenance *services.DatabaseMaintenanceService
+	systemBackup  *services.SystemBackupService
+	inactivity    *services.MemberInactivityService
+	memberExpiry  *services.MemberExpiryService
+}
+
+// NewJobsHandler creates a new jobs handler instance
+func NewJobsHandler(hub *services.MemberEventHub, dbMaintenance *services.DatabaseMaintenanceService, systemBackup *services.SystemBackupService, inactivity *services.MemberInactivityService, memberExpiry *services.MemberExpiryService) *JobsHandler {
+	return &JobsHandler{hub: hub, dbMaintenance: dbMaintenance, systemBackup: systemBackup, inactivity: inactivity, memberExpiry: memberExpiry}
+}
+
+// ListJobs returns the background jobs view: the effective member poll interval of every
+// watched network, the database compaction job, the system backup job and the member
+// inactivity and authorization expiry jobs
+func (h *JobsHandler) ListJobs(c fiber.Ctx) error {
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"memberPolling":      h.hub.PollSchedules(),
+		"databaseCompaction": h.dbMaintenance.Status(),
+		"systemBackup":       h.systemBackup.Status(),
+		"memberInactivity":   h.inactivity.Status(),
+		"memberExpiry":       h.memberExpiry.Status(),
+	})
+}
+
+// CompactDatabase starts a database compaction in the background. Without force=true it only
+// compacts when the share of free pages reaches the configured threshold.
+func (h *JobsHandler) CompactDatabase(c fiber.Ctx) error {
+	userID, authErr := requiredUserID(c)
+	if authErr != nil {
+		return authErr
+	}
+	force := false
+	if raw := c.Query("force"); raw != "" {
+		parsed, err := strconv.ParseBool(raw)
+		if err != nil {
+			return writeLocalizedStatusError(c, fiber.StatusBadRequest, "request.invalid_boolean", "force must be true or false")
+		}
+		force = parsed
+	}
+
+	switch err := h.dbMaintenance.TriggerCompaction(userID, force); {
+	case err == nil:
+		logger.WithRequestID(c).Info("Database compaction started", zap.String("user_id", userID), zap.Bool("force", force))
+		return writeLocalizedMessage(c, fiber.StatusAccepted, "maintenance.compaction_started", nil)
+	case errors.Is(err, services.ErrCompactionRunning):
+		return writeLocalizedError(c, fiber.StatusConflict, apierror.CodeMaintenanceCompactionRunning)
+	case errors.Is(err, database.ErrCompactionUnsupported):
+		return writeLocalizedError(c, fiber.StatusConflict, apierror.CodeMaintenanceCompactionUnsupported)
+	default:
+		return writeInternalError(c, "Failed to start database compaction", err)
+	}
+}
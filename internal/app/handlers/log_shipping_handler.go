@@ -0,0 +1,21 @@
+package handlers
+
+import (
+	"github.com/GT-610/tairitsu/internal/app/services"
+	"github.com/gofiber/fiber/v3"
+)
+
+// LogShippingHandler reports on the forwarding of events to a syslog server or file
+type LogShippingHandler struct {
+	shipper *services.LogShipper
+}
+
+// NewLogShippingHandler creates a new log shipping handler instance
+func NewLogShippingHandler(shipper *services.LogShipper) *LogShippingHandler {
+	return &LogShippingHandler{shipper: shipper}
+}
+
+// GetStatus reports the queue depth, the events shipped and dropped, and the last error
+func (h *LogShippingHandler) GetStatus(c fiber.Ctx) error {
+	return c.Status(fiber.StatusOK).JSON(h.shipper.Status())
+}
@@ -0,0 +1,131 @@
+package handlers
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/GT-610/tairitsu/internal/app/apierror"
+	"github.com/GT-610/tairitsu/internal/app/logger"
+	"github.com/GT-610/tairitsu/internal/app/services"
+	"github.com/gofiber/fiber/v3"
+	"go.uber.org/zap"
+)
+
+// memberEventHeartbeat keeps idle event streams open through proxies
+const memberEventHeartbeat = 25 * time.Second
+
+// MemberEventHandler streams live member events
+type MemberEventHandler struct {
+	hub *services.MemberEventHub
+}
+
+// NewMemberEventHandler creates a new member event handler instance
+func NewMemberEventHandler(hub *services.MemberEventHub) *MemberEventHandler {
+	return &MemberEventHandler{hub: hub}
+}
+
+// StreamMemberEvents streams the member events of a network as server-sent events. Clients
+// load the member list once, then apply events; the first poll after connecting only records
+// a baseline. When the server ends the stream it sends a final "closed" event whose
+// errorCode tells whether access was revoked, the client fell behind, or the server stopped.
+func (h *MemberEventHandler) StreamMemberEvents(c fiber.Ctx) error {
+	networkID := c.Params("id")
+	if err := validateNetworkID(networkID); err != nil {
+		return writeValidationError(c, err)
+	}
+
+	// Get user ID from context
+	userID, authErr := requiredUserID(c)
+	if authErr != nil {
+		logger.Error("Failed to get user ID")
+		return authErr
+	}
+
+	subscription, err := h.hub.Subscribe(networkID, userID)
+	if err != nil {
+		if errors.Is(err, services.ErrMemberEventsStopped) {
+			return writeLocalizedErrorMessage(c, fiber.StatusServiceUnavailable, apierror.CodeEventsStopped, err.Error())
+		}
+		logger.Warn("Failed to subscribe to member events", zap.String("network_id", networkID), zap.Error(err))
+		return writeNetworkServiceError(c, err, "Network not found", "Network member access denied")
+	}
+
+	c.Set(fiber.HeaderContentType, "text/event-stream")
+	c.Set(fiber.HeaderCacheControl, "no-cache")
+	c.Set(fiber.HeaderConnection, "keep-alive")
+	c.Set("X-Accel-Buffering", "no")
+
+	return c.SendStreamWriter(func(w *bufio.Writer) {
+		defer subscription.Close()
+		heartbeat := time.NewTicker(memberEventHeartbeat)
+		defer heartbeat.Stop()
+
+		fmt.Fprint(w, ": connected\n\n")
+		if err := w.Flush(); err != nil {
+			return
+		}
+		for {
+			select {
+			case event, ok := <-subscription.Events():
+				if !ok {
+					writeStreamClosedEvent(w, subscription.Err())
+					return
+				}
+				payload, err := json.Marshal(event)
+				if err != nil {
+					logger.Error("Failed to encode member event", zap.String("network_id", networkID), zap.Error(err))
+					continue
+				}
+				fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event.Type, payload)
+			case <-heartbeat.C:
+				fmt.Fprint(w, ": keepalive\n\n")
+			}
+			if err := w.Flush(); err != nil {
+				return
+			}
+		}
+	})
+}
+
+// RefreshMemberEvents asks the hub to poll a watched network now instead of waiting for its
+// next scheduled poll
+func (h *MemberEventHandler) RefreshMemberEvents(c fiber.Ctx) error {
+	networkID := c.Params("id")
+	if err := validateNetworkID(networkID); err != nil {
+		return writeValidationError(c, err)
+	}
+
+	// Get user ID from context
+	userID, authErr := requiredUserID(c)
+	if authErr != nil {
+		logger.Error("Failed to get user ID")
+		return authErr
+	}
+
+	if err := h.hub.Refresh(networkID, userID); err != nil {
+		if errors.Is(err, services.ErrMemberEventsStopped) {
+			return writeLocalizedErrorMessage(c, fiber.StatusServiceUnavailable, apierror.CodeEventsStopped, err.Error())
+		}
+		return writeNetworkServiceError(c, err, "Network not found", "Network member access denied")
+	}
+
+	return c.SendStatus(fiber.StatusAccepted)
+}
+
+func writeStreamClosedEvent(w *bufio.Writer, reason error) {
+	code := apierror.CodeEventsStopped
+	switch {
+	case services.IsNetworkNotFound(reason):
+		code = apierror.CodeNetworkNotFound
+	case services.IsNetworkAccessDenied(reason):
+		code = apierror.CodeNetworkAccessDenied
+	case errors.Is(reason, services.ErrMemberEventsLagging):
+		code = apierror.CodeEventsLagging
+	}
+	payload, _ := json.Marshal(fiber.Map{"errorCode": code})
+	fmt.Fprintf(w, "event: closed\ndata: %s\n\n", payload)
+	_ = w.Flush()
+}
@@ -1,6 +1,13 @@
 package handlers
 
 import (
+	"bufio"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/GT-610/tairitsu/internal/app/apierror"
 	"github.com/GT-610/tairitsu/internal/app/logger"
 	"github.com/GT-610/tairitsu/internal/app/services"
 	"github.com/GT-610/tairitsu/internal/zerotier"
@@ -10,131 +17,413 @@ import (
 
 // MemberHandler handles member-related HTTP requests
 type MemberHandler struct {
-	networkService *services.NetworkService
+	networkService services.NetworkServiceInterface
 }
 
 // NewMemberHandler creates a new member handler instance
-func NewMemberHandler(networkService *services.NetworkService) *MemberHandler {
+func NewMemberHandler(networkService services.NetworkServiceInterface) *MemberHandler {
 	return &MemberHandler{
 		networkService: networkService,
 	}
 }
 
-// GetMembers retrieves all members in a network
+// updateMemberRequest is the controller update plus the authorization deadline Tairitsu keeps.
+// AuthorizedUntil is an RFC 3339 time; an empty string removes the deadline.
+type updateMemberRequest struct {
+	zerotier.MemberUpdateRequest
+	AuthorizedUntil *string `json:"authorizedUntil"`
+}
+
+// GetMembers retrieves the members in a network. Without paging or filter
+// parameters the full list is returned as before; otherwise a page envelope
+// with nextCursor/prevCursor is returned.
 func (h *MemberHandler) GetMembers(c fiber.Ctx) error {
 	networkID := c.Params("id")
 	if err := validateNetworkID(networkID); err != nil {
-		return writeErrorResponse(c, fiber.StatusBadRequest, err.Error())
+		return writeValidationError(c, err)
 	}
 
 	// Get user ID from context
 	userID, authErr := requiredUserID(c)
 	if authErr != nil {
-		logger.Error("Failed to get user ID")
+		logger.WithRequestID(c).Error("Failed to get user ID")
 		return authErr
 	}
 
+	pageReq, paged, err := parsePageRequest(c)
+	if err != nil {
+		_, resp := writePaginationError(c, err)
+		return resp
+	}
+	query, err := parseMemberListQuery(c)
+	if err != nil {
+		return writeLocalizedStatusError(c, fiber.StatusBadRequest, "request.invalid_boolean", err.Error())
+	}
+	if paged || query != (services.MemberListQuery{}) {
+		page, err := h.networkService.GetNetworkMembersPage(networkID, userID, query, pageReq)
+		if err != nil {
+			if handled, resp := writePaginationError(c, err); handled {
+				return resp
+			}
+			logger.WithRequestID(c).Error("Failed to get network member page", zap.String("network_id", networkID), zap.Error(err))
+			return writeNetworkServiceError(c, err, "Network not found", "Network member access denied")
+		}
+		return c.Status(fiber.StatusOK).JSON(page)
+	}
+
 	members, err := h.networkService.GetNetworkMembers(networkID, userID)
 	if err != nil {
-		logger.Error("Failed to get network members", zap.String("network_id", networkID), zap.Error(err))
+		logger.WithRequestID(c).Error("Failed to get network members", zap.String("network_id", networkID), zap.Error(err))
 		return writeNetworkServiceError(c, err, "Network not found", "Network member access denied")
 	}
 
 	return c.Status(fiber.StatusOK).JSON(members)
 }
 
+// parseMemberListQuery reads the sort, q, authorized and online member list filters
+func parseMemberListQuery(c fiber.Ctx) (services.MemberListQuery, error) {
+	query := services.MemberListQuery{Sort: c.Query("sort"), Search: c.Query("q")}
+	for name, target := range map[string]**bool{"authorized": &query.Authorized, "online": &query.Online} {
+		raw := c.Query(name)
+		if raw == "" {
+			continue
+		}
+		value, err := strconv.ParseBool(raw)
+		if err != nil {
+			return query, fmt.Errorf("%s must be true or false", name)
+		}
+		*target = &value
+	}
+	return query, nil
+}
+
+// ExportMembers downloads the member list as CSV or JSON, honoring the list filters.
+// format is csv (the default) or json; bom=true prefixes CSV with a UTF-8 byte order mark.
+func (h *MemberHandler) ExportMembers(c fiber.Ctx) error {
+	networkID := c.Params("id")
+	if err := validateNetworkID(networkID); err != nil {
+		return writeValidationError(c, err)
+	}
+
+	userID, authErr := requiredUserID(c)
+	if authErr != nil {
+		logger.WithRequestID(c).Error("Failed to get user ID")
+		return authErr
+	}
+
+	opts := services.MemberExportOptions{Format: c.Query("format", services.MemberExportCSV)}
+	if opts.Format != services.MemberExportCSV && opts.Format != services.MemberExportJSON {
+		return writeLocalizedStatusError(c, fiber.StatusBadRequest, "request.invalid_parameter", services.ErrInvalidExportFormat.Error())
+	}
+	if raw := c.Query("bom"); raw != "" {
+		bom, err := strconv.ParseBool(raw)
+		if err != nil {
+			return writeLocalizedStatusError(c, fiber.StatusBadRequest, "request.invalid_boolean", "bom must be true or false")
+		}
+		opts.BOM = bom
+	}
+	query, err := parseMemberListQuery(c)
+	if err != nil {
+		return writeLocalizedStatusError(c, fiber.StatusBadRequest, "request.invalid_boolean", err.Error())
+	}
+
+	members, err := h.networkService.ExportNetworkMembers(networkID, userID, query)
+	if err != nil {
+		if handled, resp := writePaginationError(c, err); handled {
+			return resp
+		}
+		logger.WithRequestID(c).Error("Failed to export network members", zap.String("network_id", networkID), zap.Error(err))
+		return writeNetworkServiceError(c, err, "Network not found", "Network member access denied")
+	}
+
+	contentType := "text/csv; charset=utf-8"
+	if opts.Format == services.MemberExportJSON {
+		contentType = fiber.MIMEApplicationJSONCharsetUTF8
+	}
+	c.Set(fiber.HeaderContentType, contentType)
+	c.Set(fiber.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="members-%s-%s.%s"`, networkID, time.Now().UTC().Format("20060102"), opts.Format))
+	c.Set(fiber.HeaderCacheControl, "no-store")
+
+	log := logger.WithRequestID(c)
+	return c.Status(fiber.StatusOK).SendStreamWriter(func(w *bufio.Writer) {
+		if err := services.WriteMemberExport(w, members, opts); err != nil {
+			log.Warn("Member export stream ended early", zap.String("network_id", networkID), zap.Error(err))
+		}
+	})
+}
+
 // GetMember retrieves a specific member in a network
 func (h *MemberHandler) GetMember(c fiber.Ctx) error {
 	networkID := c.Params("id")
 	memberID := c.Params("memberId")
 	if err := validateNetworkID(networkID); err != nil {
-		return writeErrorResponse(c, fiber.StatusBadRequest, err.Error())
+		return writeValidationError(c, err)
 	}
 	if err := validateMemberID(memberID); err != nil {
-		return writeErrorResponse(c, fiber.StatusBadRequest, err.Error())
+		return writeValidationError(c, err)
 	}
 
 	// Get user ID from context
 	userID, authErr := requiredUserID(c)
 	if authErr != nil {
-		logger.Error("Failed to get user ID")
+		logger.WithRequestID(c).Error("Failed to get user ID")
 		return authErr
 	}
 
 	member, err := h.networkService.GetNetworkMember(networkID, memberID, userID)
 	if err != nil {
-		logger.Error("Failed to get network member", zap.String("network_id", networkID), zap.String("member_id", memberID), zap.Error(err))
+		logger.WithRequestID(c).Error("Failed to get network member", zap.String("network_id", networkID), zap.String("member_id", memberID), zap.Error(err))
 		return writeNetworkServiceError(c, err, "Network not found", "Network member access denied")
 	}
 
 	if member == nil {
-		logger.Warn("Network member not found", zap.String("network_id", networkID), zap.String("member_id", memberID))
-		return writeErrorResponseWithCode(c, fiber.StatusNotFound, "member.not_found", "Member not found")
+		logger.WithRequestID(c).Warn("Network member not found", zap.String("network_id", networkID), zap.String("member_id", memberID))
+		return writeLocalizedError(c, fiber.StatusNotFound, apierror.CodeMemberNotFound)
 	}
 
 	return c.Status(fiber.StatusOK).JSON(member)
 }
 
+// GetMemberHistory returns the recorded online/offline transitions of a member.
+// from and to are RFC 3339 timestamps; max_points bounds the number of returned points.
+func (h *MemberHandler) GetMemberHistory(c fiber.Ctx) error {
+	networkID := c.Params("id")
+	memberID := c.Params("memberId")
+	if err := validateNetworkID(networkID); err != nil {
+		return writeValidationError(c, err)
+	}
+	if err := validateMemberID(memberID); err != nil {
+		return writeValidationError(c, err)
+	}
+
+	var query services.MemberStatusHistoryQuery
+	var err error
+	if raw := c.Query("from"); raw != "" {
+		if query.From, err = time.Parse(time.RFC3339, raw); err != nil {
+			return writeLocalizedStatusError(c, fiber.StatusBadRequest, "request.invalid_timestamp", "from must be an RFC 3339 timestamp")
+		}
+	}
+	if raw := c.Query("to"); raw != "" {
+		if query.To, err = time.Parse(time.RFC3339, raw); err != nil {
+			return writeLocalizedStatusError(c, fiber.StatusBadRequest, "request.invalid_timestamp", "to must be an RFC 3339 timestamp")
+		}
+	}
+	if raw := c.Query("max_points"); raw != "" {
+		if query.MaxPoints, err = strconv.Atoi(raw); err != nil || query.MaxPoints <= 0 {
+			return writeLocalizedStatusError(c, fiber.StatusBadRequest, "request.invalid_integer", "max_points must be a positive integer")
+		}
+	}
+
+	// Get user ID from context
+	userID, authErr := requiredUserID(c)
+	if authErr != nil {
+		logger.WithRequestID(c).Error("Failed to get user ID")
+		return authErr
+	}
+
+	history, err := h.networkService.GetMemberStatusHistory(networkID, memberID, userID, query)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidHistoryRange) {
+			return writeLocalizedStatusError(c, fiber.StatusBadRequest, "member.invalid_history_range", err.Error())
+		}
+		logger.WithRequestID(c).Error("Failed to get member status history", zap.String("network_id", networkID), zap.String("member_id", memberID), zap.Error(err))
+		return writeNetworkServiceError(c, err, "Network not found", "Network member access denied")
+	}
+
+	return c.Status(fiber.StatusOK).JSON(history)
+}
+
+// GetMemberTrace returns recent controller trace decisions about a member, newest first
+func (h *MemberHandler) GetMemberTrace(c fiber.Ctx) error {
+	networkID := c.Params("id")
+	memberID := c.Params("memberId")
+	if err := validateNetworkID(networkID); err != nil {
+		return writeValidationError(c, err)
+	}
+	if err := validateMemberID(memberID); err != nil {
+		return writeValidationError(c, err)
+	}
+	limit, err := parseTraceLimit(c)
+	if err != nil {
+		return writeLocalizedStatusError(c, fiber.StatusBadRequest, "request.invalid_integer", err.Error())
+	}
+
+	// Get user ID from context
+	userID, authErr := requiredUserID(c)
+	if authErr != nil {
+		logger.WithRequestID(c).Error("Failed to get user ID")
+		return authErr
+	}
+
+	events, err := h.networkService.GetMemberTraceEvents(networkID, memberID, userID, limit)
+	if err != nil {
+		logger.WithRequestID(c).Error("Failed to get member trace", zap.String("network_id", networkID), zap.String("member_id", memberID), zap.Error(err))
+		return writeNetworkServiceError(c, err, "Network not found", "Network member access denied")
+	}
+
+	return c.Status(fiber.StatusOK).JSON(events)
+}
+
 // UpdateMember updates a network member
 func (h *MemberHandler) UpdateMember(c fiber.Ctx) error {
 	networkID := c.Params("id")
 	memberID := c.Params("memberId")
 	if err := validateNetworkID(networkID); err != nil {
-		return writeErrorResponse(c, fiber.StatusBadRequest, err.Error())
+		return writeValidationError(c, err)
 	}
 	if err := validateMemberID(memberID); err != nil {
-		return writeErrorResponse(c, fiber.StatusBadRequest, err.Error())
+		return writeValidationError(c, err)
 	}
 
-	var req zerotier.MemberUpdateRequest
+	var req updateMemberRequest
 	if err := c.Bind().Body(&req); err != nil {
-		logger.Error("Failed to bind request", zap.String("network_id", networkID), zap.String("member_id", memberID), zap.Error(err))
-		return writeErrorResponse(c, fiber.StatusBadRequest, err.Error())
+		logger.WithRequestID(c).Error("Failed to bind request", zap.String("network_id", networkID), zap.String("member_id", memberID), zap.Error(err))
+		return writeBindError(c, err)
 	}
 
 	if err := validateMemberName(req.Name); err != nil {
-		return writeErrorResponse(c, fiber.StatusBadRequest, err.Error())
+		return writeValidationError(c, err)
+	}
+	authorizedUntil, err := parseAuthorizedUntil(req.AuthorizedUntil)
+	if err != nil {
+		return writeValidationError(c, err)
 	}
 
 	// Get user ID from context
 	userID, authErr := requiredUserID(c)
 	if authErr != nil {
-		logger.Error("Failed to get user ID")
+		logger.WithRequestID(c).Error("Failed to get user ID")
 		return authErr
 	}
 
-	member, err := h.networkService.UpdateNetworkMember(networkID, memberID, &req, userID)
+	member, err := h.networkService.UpdateNetworkMember(networkID, memberID, &req.MemberUpdateRequest, userID)
 	if err != nil {
-		logger.Error("Failed to update network member", zap.String("network_id", networkID), zap.String("member_id", memberID), zap.Error(err))
+		logger.WithRequestID(c).Error("Failed to update network member", zap.String("network_id", networkID), zap.String("member_id", memberID), zap.Error(err))
 		return writeNetworkServiceError(c, err, "Network not found", "Network member update access denied")
 	}
 
+	if req.AuthorizedUntil != nil {
+		metadata, err := h.networkService.SetMemberAuthorizedUntil(networkID, memberID, authorizedUntil, userID)
+		if err != nil {
+			logger.WithRequestID(c).Error("Failed to set member authorization deadline", zap.String("network_id", networkID), zap.String("member_id", memberID), zap.Error(err))
+			return writeNetworkServiceError(c, err, "Network not found", "Network member update access denied")
+		}
+		member.Metadata = metadata
+	}
+
 	return c.Status(fiber.StatusOK).JSON(member)
 }
 
+// UpdateMemberMetadata changes the display name, notes and tags Tairitsu keeps for a member
+func (h *MemberHandler) UpdateMemberMetadata(c fiber.Ctx) error {
+	networkID := c.Params("id")
+	memberID := c.Params("memberId")
+	if err := validateNetworkID(networkID); err != nil {
+		return writeValidationError(c, err)
+	}
+	if err := validateMemberID(memberID); err != nil {
+		return writeValidationError(c, err)
+	}
+
+	var req services.MemberMetadataUpdate
+	if err := c.Bind().JSON(&req); err != nil {
+		return writeBindError(c, err)
+	}
+
+	// Get user ID from context
+	userID, authErr := requiredUserID(c)
+	if authErr != nil {
+		logger.WithRequestID(c).Error("Failed to get user ID")
+		return authErr
+	}
+
+	metadata, err := h.networkService.UpdateMemberMetadata(networkID, memberID, req, userID)
+	if err != nil {
+		logger.WithRequestID(c).Error("Failed to update member metadata", zap.String("network_id", networkID), zap.String("member_id", memberID), zap.Error(err))
+		return writeNetworkServiceError(c, err, "Network not found", "Network member update access denied")
+	}
+
+	return c.Status(fiber.StatusOK).JSON(metadata)
+}
+
 // DeleteMember deletes a network member
 func (h *MemberHandler) DeleteMember(c fiber.Ctx) error {
 	networkID := c.Params("id")
 	memberID := c.Params("memberId")
 	if err := validateNetworkID(networkID); err != nil {
-		return writeErrorResponse(c, fiber.StatusBadRequest, err.Error())
+		return writeValidationError(c, err)
 	}
 	if err := validateMemberID(memberID); err != nil {
-		return writeErrorResponse(c, fiber.StatusBadRequest, err.Error())
+		return writeValidationError(c, err)
 	}
 
 	// Get user ID from context
 	userID, authErr := requiredUserID(c)
 	if authErr != nil {
-		logger.Error("Failed to get user ID")
+		logger.WithRequestID(c).Error("Failed to get user ID")
 		return authErr
 	}
 
 	err := h.networkService.RemoveNetworkMember(networkID, memberID, userID)
 	if err != nil {
-		logger.Error("Failed to delete network member", zap.String("network_id", networkID), zap.String("member_id", memberID), zap.Error(err))
+		logger.WithRequestID(c).Error("Failed to delete network member", zap.String("network_id", networkID), zap.String("member_id", memberID), zap.Error(err))
 		return writeNetworkServiceError(c, err, "Network not found", "Network member delete access denied")
 	}
 
-	return writeMessageResponse(c, fiber.StatusOK, "member.delete_success", "Member deleted successfully", nil)
+	return writeLocalizedMessage(c, fiber.StatusOK, "member.delete_success", nil)
+}
+
+type lockdownNetworkRequest struct {
+	Except []string `json:"except"`
+}
+
+// LockdownNetwork deauthorizes every authorized member of a network except the listed ones
+func (h *MemberHandler) LockdownNetwork(c fiber.Ctx) error {
+	networkID := c.Params("id")
+	if err := validateNetworkID(networkID); err != nil {
+		return writeValidationError(c, err)
+	}
+	var req lockdownNetworkRequest
+	if len(c.Body()) > 0 {
+		if err := c.Bind().JSON(&req); err != nil {
+			return writeBindError(c, err)
+		}
+	}
+	for _, memberID := range req.Except {
+		if err := validateMemberID(memberID); err != nil {
+			return writeValidationError(c, err)
+		}
+	}
+	userID, authErr := requiredUserID(c)
+	if authErr != nil {
+		logger.WithRequestID(c).Error("Failed to get user ID")
+		return authErr
+	}
+
+	result, err := h.networkService.LockdownNetwork(networkID, req.Except, userID)
+	if err != nil {
+		logger.WithRequestID(c).Error("Failed to lock down network", zap.String("network_id", networkID), zap.Error(err))
+		return writeNetworkServiceError(c, err, "Network not found", "Network member update access denied")
+	}
+	return c.Status(fiber.StatusCreated).JSON(result)
+}
+
+// RollbackNetworkLockdown authorizes again the members the active lockdown deauthorized
+func (h *MemberHandler) RollbackNetworkLockdown(c fiber.Ctx) error {
+	networkID := c.Params("id")
+	if err := validateNetworkID(networkID); err != nil {
+		return writeValidationError(c, err)
+	}
+	userID, authErr := requiredUserID(c)
+	if authErr != nil {
+		logger.WithRequestID(c).Error("Failed to get user ID")
+		return authErr
+	}
+
+	result, err := h.networkService.RollbackNetworkLockdown(networkID, userID)
+	if err != nil {
+		logger.WithRequestID(c).Error("Failed to roll back network lockdown", zap.String("network_id", networkID), zap.Error(err))
+		return writeNetworkServiceError(c, err, "Network not found", "Network member update access denied")
+	}
+	return c.Status(fiber.StatusOK).JSON(result)
 }
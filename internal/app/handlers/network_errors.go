@@ -3,30 +3,99 @@ package handlers
 import (
 	"errors"
 
-	"github.com/GT-610/tairitsu/internal/app/logger"
+	"github.com/GT-610/tairitsu/internal/app/apierror"
 	"github.com/GT-610/tairitsu/internal/app/services"
+	"github.com/GT-610/tairitsu/internal/zerotier"
 	"github.com/gofiber/fiber/v3"
-	"go.uber.org/zap"
 )
 
 func writeNetworkServiceError(c fiber.Ctx, err error, notFoundMessage string, forbiddenMessage string) error {
+	var unsupported *zerotier.UnsupportedFeatureError
 	switch {
 	case services.IsNetworkNotFound(err):
-		return writeErrorResponseWithCode(c, fiber.StatusNotFound, "network.not_found", notFoundMessage)
+		return writeLocalizedErrorMessage(c, fiber.StatusNotFound, apierror.CodeNetworkNotFound, notFoundMessage)
 	case services.IsNetworkAccessDenied(err):
-		return writeErrorResponseWithCode(c, fiber.StatusForbidden, "network.access_denied", forbiddenMessage)
+		return writeLocalizedErrorMessage(c, fiber.StatusForbidden, apierror.CodeNetworkAccessDenied, forbiddenMessage)
 	case errors.Is(err, services.ErrImportAccessDenied):
-		return writeErrorResponseWithCode(c, fiber.StatusForbidden, "network.import_access_denied", err.Error())
+		return writeLocalizedErrorMessage(c, fiber.StatusForbidden, apierror.CodeNetworkImportAccessDenied, err.Error())
 	case errors.Is(err, services.ErrImportOwnerRequired):
-		return writeErrorResponseWithCode(c, fiber.StatusBadRequest, "network.import_owner_required", err.Error())
+		return writeLocalizedErrorMessage(c, fiber.StatusBadRequest, apierror.CodeNetworkImportOwnerRequired, err.Error())
 	case errors.Is(err, services.ErrImportOwnerNotFound):
-		return writeErrorResponseWithCode(c, fiber.StatusBadRequest, "network.import_owner_not_found", err.Error())
+		return writeLocalizedErrorMessage(c, fiber.StatusBadRequest, apierror.CodeNetworkImportOwnerNotFound, err.Error())
 	case errors.Is(err, services.ErrUserNotFound):
-		return writeErrorResponseWithCode(c, fiber.StatusBadRequest, "user.not_found", err.Error())
+		return writeLocalizedErrorMessage(c, fiber.StatusBadRequest, apierror.CodeUserNotFound, err.Error())
 	case errors.Is(err, services.ErrViewerTargetInvalid):
-		return writeErrorResponseWithCode(c, fiber.StatusBadRequest, "network.viewer_target_invalid", err.Error())
+		return writeLocalizedErrorMessage(c, fiber.StatusBadRequest, apierror.CodeNetworkViewerTargetInvalid, err.Error())
+	case errors.Is(err, services.ErrInvalidNetworkBackup), errors.Is(err, services.ErrUnsupportedBackupVersion):
+		return writeLocalizedErrorMessage(c, fiber.StatusBadRequest, apierror.CodeNetworkBackupInvalid, err.Error())
+	case errors.Is(err, services.ErrMemberNotFound):
+		return writeLocalizedError(c, fiber.StatusNotFound, apierror.CodeMemberNotFound)
+	case errors.Is(err, services.ErrInvalidMemberMetadata):
+		return writeLocalizedErrorMessage(c, fiber.StatusBadRequest, apierror.CodeMemberMetadataInvalid, err.Error())
+	case errors.Is(err, services.ErrAuthorizedUntilPast):
+		return writeValidationError(c, apierror.Invalid("authorizedUntil", err.Error()))
+	case errors.Is(err, services.ErrInvalidDNSConfig):
+		return writeValidationError(c, apierror.Invalid("dns", err.Error()))
+	case errors.Is(err, services.ErrInvalidMulticastLimit):
+		return writeValidationError(c, apierror.Invalid("multicastLimit", err.Error()))
+	case errors.Is(err, services.ErrTagNotFound):
+		return writeLocalizedError(c, fiber.StatusNotFound, apierror.CodeTagNotFound)
+	case errors.Is(err, services.ErrCapabilityNotFound):
+		return writeLocalizedError(c, fiber.StatusNotFound, apierror.CodeCapabilityNotFound)
+	case errors.Is(err, services.ErrTagConflict), errors.Is(err, services.ErrCapabilityConflict):
+		return writeLocalizedErrorMessage(c, fiber.StatusConflict, apierror.CodeTagConflict, err.Error())
+	case errors.Is(err, services.ErrInvalidTagDefinition):
+		return writeLocalizedErrorMessage(c, fiber.StatusBadRequest, apierror.CodeTagInvalid, err.Error())
+	case errors.Is(err, services.ErrUndefinedMemberTag):
+		return writeLocalizedErrorMessage(c, fiber.StatusBadRequest, apierror.CodeMemberTagUndefined, err.Error())
+	case errors.Is(err, services.ErrInvalidMemberTagValue):
+		return writeLocalizedErrorMessage(c, fiber.StatusBadRequest, apierror.CodeMemberTagValueInvalid, err.Error())
+	case errors.Is(err, services.ErrApprovalNotFound):
+		return writeLocalizedError(c, fiber.StatusNotFound, apierror.CodeApprovalNotFound)
+	case errors.Is(err, services.ErrApprovalDecided):
+		return writeLocalizedErrorMessage(c, fiber.StatusConflict, apierror.CodeApprovalAlreadyDecided, err.Error())
+	case errors.Is(err, services.ErrDeviceClaimNotFound):
+		return writeLocalizedError(c, fiber.StatusNotFound, apierror.CodeDeviceClaimNotFound)
+	case errors.Is(err, services.ErrDeviceClaimNotVerified):
+		return writeLocalizedErrorMessage(c, fiber.StatusForbidden, apierror.CodeDeviceClaimNotVerified, err.Error())
+	case errors.Is(err, services.ErrDeviceClaimedByOther):
+		return writeLocalizedErrorMessage(c, fiber.StatusConflict, apierror.CodeDeviceClaimedByOther, err.Error())
+	case errors.Is(err, services.ErrDeviceAlreadyAuthorized):
+		return writeLocalizedErrorMessage(c, fiber.StatusConflict, apierror.CodeDeviceAlreadyAuthorized, err.Error())
+	case errors.Is(err, services.ErrOrganizationNotFound):
+		return writeLocalizedError(c, fiber.StatusNotFound, apierror.CodeOrganizationNotFound)
+	case errors.Is(err, services.ErrOrganizationAccessDenied):
+		return writeLocalizedError(c, fiber.StatusForbidden, apierror.CodeOrganizationAccessDenied)
+	case errors.Is(err, services.ErrOrganizationMemberNotFound):
+		return writeLocalizedErrorMessage(c, fiber.StatusNotFound, apierror.CodeOrganizationMemberNotFound, err.Error())
+	case errors.Is(err, services.ErrOrganizationNameTaken):
+		return writeLocalizedErrorMessage(c, fiber.StatusConflict, apierror.CodeOrganizationNameTaken, err.Error())
+	case errors.Is(err, services.ErrInvalidOrganization):
+		return writeLocalizedErrorMessage(c, fiber.StatusBadRequest, apierror.CodeOrganizationInvalid, err.Error())
+	case errors.Is(err, services.ErrInvalidOrganizationRole):
+		return writeLocalizedErrorMessage(c, fiber.StatusBadRequest, apierror.CodeOrganizationInvalidRole, err.Error())
+	case errors.Is(err, services.ErrDefaultOrganizationProtected):
+		return writeLocalizedErrorMessage(c, fiber.StatusConflict, apierror.CodeOrganizationDefaultProtected, err.Error())
+	case errors.Is(err, services.ErrInvalidInactivityPolicy):
+		return writeLocalizedErrorMessage(c, fiber.StatusBadRequest, apierror.CodeNetworkInvalidPolicy, err.Error())
+	case errors.Is(err, services.ErrLockdownActive):
+		return writeLocalizedErrorMessage(c, fiber.StatusConflict, apierror.CodeLockdownActive, err.Error())
+	case errors.Is(err, services.ErrLockdownNotActive):
+		return writeLocalizedErrorMessage(c, fiber.StatusNotFound, apierror.CodeLockdownNotFound, err.Error())
+	case errors.Is(err, services.ErrControllerNotFound):
+		return writeLocalizedErrorMessage(c, fiber.StatusNotFound, apierror.CodeControllerNotFound, err.Error())
+	case errors.Is(err, services.ErrControllerUnavailable):
+		// The cause is the controller's own error, which may quote its address or response
+		apiErr := apierror.Localized(fiber.StatusServiceUnavailable, apierror.CodeControllerUnavailable)
+		return writeSanitizedError(c, apiErr, "ZeroTier controller unavailable", err)
+	case errors.Is(err, services.ErrNetworkRestoreConfigFailed):
+		apiErr := apierror.Localized(fiber.StatusBadGateway, apierror.CodeNetworkRestoreFailed)
+		return writeSanitizedError(c, apiErr, "Failed to restore network configuration", err)
+	case errors.As(err, &unsupported):
+		return writeLocalizedErrorMessage(c, fiber.StatusUnprocessableEntity, apierror.CodeNetworkFeatureUnsupported, err.Error())
+	case errors.Is(err, services.ErrIdempotencyKeyReused):
+		return writeLocalizedErrorMessage(c, fiber.StatusConflict, apierror.CodeOperationIdempotencyKeyReused, err.Error())
 	default:
-		logger.Error("unhandled network service error", zap.Error(err))
-		return writeErrorResponseWithCode(c, fiber.StatusInternalServerError, "system.internal_error", "Internal Server Error")
+		return writeInternalError(c, "unhandled network service error", err)
 	}
 }
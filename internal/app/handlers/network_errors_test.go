@@ -33,6 +33,16 @@ func TestWriteNetworkServiceError_MapsWrappedImportErrors(t *testing.T) {
 			err:          fmt.Errorf("wrapped: %w", services.ErrImportOwnerNotFound),
 			expectedCode: fiber.StatusBadRequest,
 		},
+		{
+			name:         "wrapped unsupported backup version",
+			err:          fmt.Errorf("%w: %d", services.ErrUnsupportedBackupVersion, 9),
+			expectedCode: fiber.StatusBadRequest,
+		},
+		{
+			name:         "wrapped restore configuration failure",
+			err:          fmt.Errorf("%w: controller unavailable", services.ErrNetworkRestoreConfigFailed),
+			expectedCode: fiber.StatusBadGateway,
+		},
 	}
 
 	for _, tc := range testCases {
@@ -59,9 +69,9 @@ func TestWriteNetworkServiceError_MapsWrappedImportErrors(t *testing.T) {
 			if !ok || strings.TrimSpace(messageText) == "" {
 				t.Fatalf("expected non-empty message body")
 			}
-			errorCode, ok := body["error_code"].(string)
+			errorCode, ok := body["errorCode"].(string)
 			if !ok || strings.TrimSpace(errorCode) == "" {
-				t.Fatalf("expected non-empty error_code body")
+				t.Fatalf("expected non-empty errorCode body")
 			}
 		})
 	}
@@ -1,54 +1,111 @@
 package handlers
 
 import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/GT-610/tairitsu/internal/app/apierror"
 	"github.com/GT-610/tairitsu/internal/app/logger"
 	"github.com/GT-610/tairitsu/internal/app/services"
 	"github.com/GT-610/tairitsu/internal/zerotier"
+	"github.com/GT-610/tairitsu/internal/ztrules"
 	"github.com/gofiber/fiber/v3"
 	"go.uber.org/zap"
 )
 
 // NetworkHandler handles network-related HTTP requests
 type NetworkHandler struct {
-	networkService *services.NetworkService
+	networkService services.NetworkServiceInterface
 }
 
 // NewNetworkHandler creates a new network handler instance
-func NewNetworkHandler(networkService *services.NetworkService) *NetworkHandler {
+func NewNetworkHandler(networkService services.NetworkServiceInterface) *NetworkHandler {
 	return &NetworkHandler{
 		networkService: networkService,
 	}
 }
 
-// GetStatus retrieves the ZeroTier network status
+// GetStatus retrieves the ZeroTier network status from the status cache; ?fresh=true asks the controller directly
 func (h *NetworkHandler) GetStatus(c fiber.Ctx) error {
-	logger.Info("Getting ZeroTier network status")
+	logger.WithRequestID(c).Info("Getting ZeroTier network status")
 
-	status := h.networkService.GetRuntimeStatus()
+	fresh := false
+	if raw := c.Query("fresh"); raw != "" {
+		parsed, err := strconv.ParseBool(raw)
+		if err != nil {
+			return writeLocalizedStatusError(c, fiber.StatusBadRequest, "request.invalid_boolean", "fresh must be true or false")
+		}
+		fresh = parsed
+	}
+	status := h.networkService.GetRuntimeStatus(fresh)
 
-	logger.Info("ZeroTier network status retrieved")
+	logger.WithRequestID(c).Info("ZeroTier network status retrieved")
 
 	return c.Status(fiber.StatusOK).JSON(status)
 }
 
+// GetControllers lists the configured ZeroTier controllers with their live status
+func (h *NetworkHandler) GetControllers(c fiber.Ctx) error {
+	controllers := h.networkService.Controllers().List()
+	// Controller addresses are infrastructure details only administrators see elsewhere
+	if role, _ := c.Locals("role").(string); role != "admin" {
+		for i := range controllers {
+			controllers[i].URL = ""
+		}
+	}
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{"controllers": controllers})
+}
+
+// GetControllerPeers lists the peers a controller sees, so administrators can confirm it
+// reaches its roots. ?controller= picks the controller and ?role= filters by peer role.
+func (h *NetworkHandler) GetControllerPeers(c fiber.Ctx) error {
+	peers, err := h.networkService.GetControllerPeers(c.Query("controller"), c.Query("role"))
+	switch {
+	case err == nil:
+		return c.Status(fiber.StatusOK).JSON(peers)
+	case errors.Is(err, services.ErrInvalidPeerRole):
+		return writeLocalizedErrorMessage(c, fiber.StatusBadRequest, apierror.CodeControllerInvalidPeerRole, err.Error())
+	case errors.Is(err, services.ErrPeersUnsupported):
+		return writeLocalizedError(c, fiber.StatusNotImplemented, apierror.CodeControllerPeersUnsupported)
+	default:
+		logger.WithRequestID(c).Error("Failed to get controller peers", zap.Error(err))
+		return writeNetworkServiceError(c, err, "Controller not found", "Controller access denied")
+	}
+}
+
 // GetNetworks retrieves all networks owned by the current user
 func (h *NetworkHandler) GetNetworks(c fiber.Ctx) error {
-	logger.Info("Getting networks for current user")
+	logger.WithRequestID(c).Info("Getting networks for current user")
 
 	// Get user ID from context
 	userID, authErr := requiredUserID(c)
 	if authErr != nil {
-		logger.Error("Failed to get user ID")
+		logger.WithRequestID(c).Error("Failed to get user ID")
 		return authErr
 	}
 
-	networks, err := h.networkService.GetAllNetworks(userID)
+	// ?summary=false skips the member lists behind the member counts
+	summary := true
+	if raw := c.Query("summary"); raw != "" {
+		parsed, err := strconv.ParseBool(raw)
+		if err != nil {
+			return writeLocalizedStatusError(c, fiber.StatusBadRequest, "request.invalid_boolean", "summary must be true or false")
+		}
+		summary = parsed
+	}
+
+	// ?org= keeps only the networks of one organization
+	networks, err := h.networkService.ListNetworks(userID, c.Query("org"), summary)
 	if err != nil {
-		logger.Error("Failed to get network list", zap.Error(err))
-		return writeErrorResponse(c, fiber.StatusInternalServerError, err.Error())
+		if errors.Is(err, services.ErrOrganizationNotFound) {
+			return writeNetworkServiceError(c, err, "Network not found", "Network access denied")
+		}
+		return writeInternalError(c, "Failed to get network list", err)
 	}
 
-	logger.Info("Network list retrieved", zap.Int("network_count", len(networks)))
+	logger.WithRequestID(c).Info("Network list retrieved", zap.Int("network_count", len(networks)))
 
 	return c.Status(fiber.StatusOK).JSON(networks)
 }
@@ -56,14 +113,13 @@ func (h *NetworkHandler) GetNetworks(c fiber.Ctx) error {
 func (h *NetworkHandler) GetSharedNetworks(c fiber.Ctx) error {
 	userID, authErr := requiredUserID(c)
 	if authErr != nil {
-		logger.Error("Failed to get user ID")
+		logger.WithRequestID(c).Error("Failed to get user ID")
 		return authErr
 	}
 
 	networks, err := h.networkService.GetSharedNetworks(userID)
 	if err != nil {
-		logger.Error("Failed to get shared network list", zap.String("user_id", userID), zap.Error(err))
-		return writeErrorResponse(c, fiber.StatusInternalServerError, err.Error())
+		return writeInternalError(c, "Failed to get shared network list", err, zap.String("user_id", userID))
 	}
 
 	return c.Status(fiber.StatusOK).JSON(networks)
@@ -73,59 +129,318 @@ func (h *NetworkHandler) GetSharedNetworks(c fiber.Ctx) error {
 func (h *NetworkHandler) GetNetwork(c fiber.Ctx) error {
 	id := c.Params("id")
 	if err := validateNetworkID(id); err != nil {
-		return writeErrorResponse(c, fiber.StatusBadRequest, err.Error())
+		return writeValidationError(c, err)
 	}
-	logger.Info("Getting network", zap.String("network_id", id))
+	logger.WithRequestID(c).Info("Getting network", zap.String("network_id", id))
 
 	// Get user ID from context
 	userID, authErr := requiredUserID(c)
 	if authErr != nil {
-		logger.Error("Failed to get user ID")
+		logger.WithRequestID(c).Error("Failed to get user ID")
 		return authErr
 	}
 
 	network, err := h.networkService.GetNetworkByID(id, userID)
 	if err != nil {
-		logger.Error("Failed to get network", zap.String("network_id", id), zap.Error(err))
+		logger.WithRequestID(c).Error("Failed to get network", zap.String("network_id", id), zap.Error(err))
 		return writeNetworkServiceError(c, err, "Network not found", "Network access denied")
 	}
 
-	logger.Info("Network retrieved", zap.String("network_id", id))
+	logger.WithRequestID(c).Info("Network retrieved", zap.String("network_id", id))
 
 	return c.Status(fiber.StatusOK).JSON(network)
 }
 
+// GetNetworkIPv6Prefixes retrieves the rfc4193 and 6plane prefixes of a network
+func (h *NetworkHandler) GetNetworkIPv6Prefixes(c fiber.Ctx) error {
+	id := c.Params("id")
+	if err := validateNetworkID(id); err != nil {
+		return writeValidationError(c, err)
+	}
+
+	userID, authErr := requiredUserID(c)
+	if authErr != nil {
+		logger.WithRequestID(c).Error("Failed to get user ID")
+		return authErr
+	}
+
+	prefixes, err := h.networkService.GetNetworkIPv6Prefixes(id, userID)
+	if err != nil {
+		logger.WithRequestID(c).Error("Failed to get network IPv6 prefixes", zap.String("network_id", id), zap.Error(err))
+		return writeNetworkServiceError(c, err, "Network not found", "Network access denied")
+	}
+
+	return c.Status(fiber.StatusOK).JSON(prefixes)
+}
+
+// GetNetworkIPUsage reports how much of each IP assignment pool of a network is in use
+func (h *NetworkHandler) GetNetworkIPUsage(c fiber.Ctx) error {
+	id := c.Params("id")
+	if err := validateNetworkID(id); err != nil {
+		return writeValidationError(c, err)
+	}
+	nextFree := services.DefaultIPUsageNextFree
+	if raw := c.Query("next"); raw != "" {
+		value, err := strconv.Atoi(raw)
+		if err != nil || value < 0 || value > services.MaxIPUsageNextFree {
+			return writeLocalizedStatusError(c, fiber.StatusBadRequest, "request.invalid_integer", "next must be a number between 0 and "+strconv.Itoa(services.MaxIPUsageNextFree))
+		}
+		nextFree = value
+	}
+
+	userID, authErr := requiredUserID(c)
+	if authErr != nil {
+		logger.WithRequestID(c).Error("Failed to get user ID")
+		return authErr
+	}
+
+	usage, err := h.networkService.GetNetworkIPUsage(id, nextFree, userID)
+	if err != nil {
+		logger.WithRequestID(c).Error("Failed to get network IP usage", zap.String("network_id", id), zap.Error(err))
+		return writeNetworkServiceError(c, err, "Network not found", "Network access denied")
+	}
+
+	return c.Status(fiber.StatusOK).JSON(usage)
+}
+
+// GetNetworkPrivacy retrieves the member physical address policy of a network
+func (h *NetworkHandler) GetNetworkPrivacy(c fiber.Ctx) error {
+	id := c.Params("id")
+	if err := validateNetworkID(id); err != nil {
+		return writeValidationError(c, err)
+	}
+
+	userID, authErr := requiredUserID(c)
+	if authErr != nil {
+		logger.WithRequestID(c).Error("Failed to get user ID")
+		return authErr
+	}
+
+	privacy, err := h.networkService.GetNetworkPrivacy(id, userID)
+	if err != nil {
+		logger.WithRequestID(c).Error("Failed to get network privacy", zap.String("network_id", id), zap.Error(err))
+		return writeNetworkServiceError(c, err, "Network not found", "Network access denied")
+	}
+
+	return c.Status(fiber.StatusOK).JSON(privacy)
+}
+
+// UpdateNetworkPrivacy changes the member physical address policy of an owned network
+func (h *NetworkHandler) UpdateNetworkPrivacy(c fiber.Ctx) error {
+	id := c.Params("id")
+	if err := validateNetworkID(id); err != nil {
+		return writeValidationError(c, err)
+	}
+
+	userID, authErr := requiredUserID(c)
+	if authErr != nil {
+		logger.WithRequestID(c).Error("Failed to get user ID")
+		return authErr
+	}
+
+	var req struct {
+		PhysicalAddressPolicy string `json:"physicalAddressPolicy"`
+	}
+	if err := c.Bind().Body(&req); err != nil {
+		return writeBindError(c, err)
+	}
+
+	privacy, err := h.networkService.UpdateNetworkPrivacy(id, req.PhysicalAddressPolicy, userID)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidPhysicalAddressPolicy) {
+			return writeLocalizedErrorMessage(c, fiber.StatusBadRequest, apierror.CodeNetworkInvalidPrivacyPolicy, err.Error())
+		}
+		logger.WithRequestID(c).Error("Failed to update network privacy", zap.String("network_id", id), zap.Error(err))
+		return writeNetworkServiceError(c, err, "Network not found", "Network privacy access denied")
+	}
+
+	return c.Status(fiber.StatusOK).JSON(privacy)
+}
+
+// GetNetworkPolicies retrieves the automated policies of a network
+func (h *NetworkHandler) GetNetworkPolicies(c fiber.Ctx) error {
+	id := c.Params("id")
+	if err := validateNetworkID(id); err != nil {
+		return writeValidationError(c, err)
+	}
+
+	userID, authErr := requiredUserID(c)
+	if authErr != nil {
+		logger.WithRequestID(c).Error("Failed to get user ID")
+		return authErr
+	}
+
+	policies, err := h.networkService.GetNetworkPolicies(id, userID)
+	if err != nil {
+		logger.WithRequestID(c).Error("Failed to get network policies", zap.String("network_id", id), zap.Error(err))
+		return writeNetworkServiceError(c, err, "Network not found", "Network access denied")
+	}
+
+	return c.Status(fiber.StatusOK).JSON(policies)
+}
+
+// UpdateNetworkPolicies replaces the automated policies of an owned network
+func (h *NetworkHandler) UpdateNetworkPolicies(c fiber.Ctx) error {
+	id := c.Params("id")
+	if err := validateNetworkID(id); err != nil {
+		return writeValidationError(c, err)
+	}
+
+	userID, authErr := requiredUserID(c)
+	if authErr != nil {
+		logger.WithRequestID(c).Error("Failed to get user ID")
+		return authErr
+	}
+
+	var req services.NetworkPolicies
+	if err := c.Bind().Body(&req); err != nil {
+		return writeBindError(c, err)
+	}
+
+	policies, err := h.networkService.UpdateNetworkPolicies(id, req, userID)
+	if err != nil {
+		logger.WithRequestID(c).Error("Failed to update network policies", zap.String("network_id", id), zap.Error(err))
+		return writeNetworkServiceError(c, err, "Network not found", "Network policy access denied")
+	}
+
+	return c.Status(fiber.StatusOK).JSON(policies)
+}
+
+// PreviewInactivityPolicy reports the members the inactivity policy would deauthorize now.
+// The days query parameter previews a different threshold than the saved one.
+func (h *NetworkHandler) PreviewInactivityPolicy(c fiber.Ctx) error {
+	id := c.Params("id")
+	if err := validateNetworkID(id); err != nil {
+		return writeValidationError(c, err)
+	}
+
+	days := 0
+	if raw := c.Query("days"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed <= 0 {
+			return writeLocalizedStatusError(c, fiber.StatusBadRequest, "request.invalid_integer", "days must be a positive integer")
+		}
+		days = parsed
+	}
+
+	userID, authErr := requiredUserID(c)
+	if authErr != nil {
+		logger.WithRequestID(c).Error("Failed to get user ID")
+		return authErr
+	}
+
+	report, err := h.networkService.PreviewInactivity(id, days, userID)
+	if err != nil {
+		logger.WithRequestID(c).Error("Failed to preview inactivity policy", zap.String("network_id", id), zap.Error(err))
+		return writeNetworkServiceError(c, err, "Network not found", "Network member update access denied")
+	}
+
+	return c.Status(fiber.StatusOK).JSON(report)
+}
+
+// GetNetworkRules returns the saved rules source and the controller's compiled rules of an owned network
+func (h *NetworkHandler) GetNetworkRules(c fiber.Ctx) error {
+	id := c.Params("id")
+	if err := validateNetworkID(id); err != nil {
+		return writeValidationError(c, err)
+	}
+
+	userID, authErr := requiredUserID(c)
+	if authErr != nil {
+		logger.WithRequestID(c).Error("Failed to get user ID")
+		return authErr
+	}
+
+	rules, err := h.networkService.GetNetworkRules(id, userID)
+	if err != nil {
+		logger.WithRequestID(c).Error("Failed to get network rules", zap.String("network_id", id), zap.Error(err))
+		return writeNetworkServiceError(c, err, "Network not found", "Network rules access denied")
+	}
+
+	return c.Status(fiber.StatusOK).JSON(rules)
+}
+
+// UpdateNetworkRules compiles rules source and applies it to an owned network
+func (h *NetworkHandler) UpdateNetworkRules(c fiber.Ctx) error {
+	id := c.Params("id")
+	if err := validateNetworkID(id); err != nil {
+		return writeValidationError(c, err)
+	}
+
+	userID, authErr := requiredUserID(c)
+	if authErr != nil {
+		logger.WithRequestID(c).Error("Failed to get user ID")
+		return authErr
+	}
+
+	var req struct {
+		Source string `json:"source"`
+	}
+	if err := c.Bind().Body(&req); err != nil {
+		return writeBindError(c, err)
+	}
+
+	rules, err := h.networkService.UpdateNetworkRules(id, req.Source, userID)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidNetworkRules) {
+			return writeNetworkRulesError(c, err)
+		}
+		logger.WithRequestID(c).Error("Failed to update network rules", zap.String("network_id", id), zap.Error(err))
+		return writeNetworkServiceError(c, err, "Network not found", "Network rules access denied")
+	}
+
+	return c.Status(fiber.StatusOK).JSON(rules)
+}
+
+// writeNetworkRulesError reports a rules compile error together with its position so
+// editors can point at the offending word
+func writeNetworkRulesError(c fiber.Ctx, err error) error {
+	var compileErr *ztrules.Error
+	if !errors.As(err, &compileErr) {
+		return writeLocalizedErrorMessage(c, fiber.StatusBadRequest, apierror.CodeNetworkRulesInvalid, err.Error())
+	}
+	return writeErrorResponseWithExtra(c, apierror.New(fiber.StatusBadRequest, apierror.CodeNetworkRulesInvalid, compileErr.Error()).Translate(apierror.CodeNetworkRulesInvalid), fiber.Map{
+		"line":   compileErr.Line,
+		"column": compileErr.Column,
+	})
+}
+
 // CreateNetwork creates a new network
 func (h *NetworkHandler) CreateNetwork(c fiber.Ctx) error {
-	var req zerotier.Network
+	var req zerotier.NetworkUpdateRequest
 	if err := c.Bind().Body(&req); err != nil {
-		logger.Error("Failed to bind create network request", zap.Error(err))
-		return writeErrorResponse(c, fiber.StatusBadRequest, err.Error())
+		logger.WithRequestID(c).Error("Failed to bind create network request", zap.Error(err))
+		return writeBindError(c, err)
 	}
 
 	if err := validateNetworkName(req.Name); err != nil {
-		return writeErrorResponse(c, fiber.StatusBadRequest, err.Error())
+		return writeValidationError(c, err)
 	}
 	if err := validateNetworkDescription(req.Description); err != nil {
-		return writeErrorResponse(c, fiber.StatusBadRequest, err.Error())
+		return writeValidationError(c, err)
 	}
 
-	logger.Info("Creating network", zap.String("network_name", req.Name))
+	logger.WithRequestID(c).Info("Creating network", zap.String("network_name", req.Name))
 
 	// Get user ID from context
 	userID, authErr := requiredUserID(c)
 	if authErr != nil {
-		logger.Error("Failed to get user ID")
+		logger.WithRequestID(c).Error("Failed to get user ID")
 		return authErr
 	}
 
-	network, err := h.networkService.CreateNetwork(&req, userID)
+	network, err := h.networkService.CreateNetworkWithSettings(c.Query("controller"), &zerotier.Network{Name: req.Name, Description: req.Description}, &req, userID)
 	if err != nil {
-		logger.Error("Failed to create network", zap.String("network_name", req.Name), zap.Error(err))
-		return writeErrorResponse(c, fiber.StatusInternalServerError, err.Error())
+		var unsupported *zerotier.UnsupportedFeatureError
+		if errors.Is(err, services.ErrControllerNotFound) || errors.Is(err, services.ErrControllerUnavailable) ||
+			errors.Is(err, services.ErrInvalidDNSConfig) || errors.Is(err, services.ErrInvalidMulticastLimit) || errors.As(err, &unsupported) {
+			logger.WithRequestID(c).Error("Failed to create network", zap.String("network_name", req.Name), zap.Error(err))
+			return writeNetworkServiceError(c, err, "Network not found", "Network creation access denied")
+		}
+		return writeInternalError(c, "Failed to create network", err, zap.String("network_name", req.Name))
 	}
 
-	logger.Info("Network created", zap.String("network_id", network.ID), zap.String("network_name", network.Name))
+	logger.WithRequestID(c).Info("Network created", zap.String("network_id", network.ID), zap.String("network_name", network.Name))
 
 	return c.Status(fiber.StatusCreated).JSON(network)
 }
@@ -134,31 +449,31 @@ func (h *NetworkHandler) CreateNetwork(c fiber.Ctx) error {
 func (h *NetworkHandler) UpdateNetwork(c fiber.Ctx) error {
 	id := c.Params("id")
 	if err := validateNetworkID(id); err != nil {
-		return writeErrorResponse(c, fiber.StatusBadRequest, err.Error())
+		return writeValidationError(c, err)
 	}
 
 	var req zerotier.NetworkUpdateRequest
 	if err := c.Bind().Body(&req); err != nil {
-		logger.Error("Failed to bind update network request", zap.Error(err))
-		return writeErrorResponse(c, fiber.StatusBadRequest, err.Error())
+		logger.WithRequestID(c).Error("Failed to bind update network request", zap.Error(err))
+		return writeBindError(c, err)
 	}
 
-	logger.Info("Updating network", zap.String("network_id", id))
+	logger.WithRequestID(c).Info("Updating network", zap.String("network_id", id))
 
 	// Get user ID from context
 	userID, authErr := requiredUserID(c)
 	if authErr != nil {
-		logger.Error("Failed to get user ID")
+		logger.WithRequestID(c).Error("Failed to get user ID")
 		return authErr
 	}
 
 	network, err := h.networkService.UpdateNetwork(id, &req, userID)
 	if err != nil {
-		logger.Error("Failed to update network", zap.String("network_id", id), zap.Error(err))
+		logger.WithRequestID(c).Error("Failed to update network", zap.String("network_id", id), zap.Error(err))
 		return writeNetworkServiceError(c, err, "Network not found", "Network update access denied")
 	}
 
-	logger.Info("Network updated", zap.String("network_id", network.ID))
+	logger.WithRequestID(c).Info("Network updated", zap.String("network_id", network.ID))
 
 	return c.Status(fiber.StatusOK).JSON(network)
 }
@@ -168,7 +483,7 @@ func (h *NetworkHandler) UpdateNetwork(c fiber.Ctx) error {
 func (h *NetworkHandler) UpdateNetworkMetadata(c fiber.Ctx) error {
 	id := c.Params("id")
 	if err := validateNetworkID(id); err != nil {
-		return writeErrorResponse(c, fiber.StatusBadRequest, err.Error())
+		return writeValidationError(c, err)
 	}
 
 	var req struct {
@@ -176,33 +491,33 @@ func (h *NetworkHandler) UpdateNetworkMetadata(c fiber.Ctx) error {
 		Description string `json:"description"`
 	}
 	if err := c.Bind().Body(&req); err != nil {
-		logger.Error("Failed to bind network metadata update request", zap.Error(err))
-		return writeErrorResponse(c, fiber.StatusBadRequest, err.Error())
+		logger.WithRequestID(c).Error("Failed to bind network metadata update request", zap.Error(err))
+		return writeBindError(c, err)
 	}
 
 	if err := validateNetworkName(req.Name); err != nil {
-		return writeErrorResponse(c, fiber.StatusBadRequest, err.Error())
+		return writeValidationError(c, err)
 	}
 	if err := validateNetworkDescription(req.Description); err != nil {
-		return writeErrorResponse(c, fiber.StatusBadRequest, err.Error())
+		return writeValidationError(c, err)
 	}
 
-	logger.Info("Updating network metadata", zap.String("network_id", id), zap.String("name", req.Name))
+	logger.WithRequestID(c).Info("Updating network metadata", zap.String("network_id", id), zap.String("name", req.Name))
 
 	// Get user ID from context
 	userID, authErr := requiredUserID(c)
 	if authErr != nil {
-		logger.Error("Failed to get user ID")
+		logger.WithRequestID(c).Error("Failed to get user ID")
 		return authErr
 	}
 
 	network, err := h.networkService.UpdateNetworkMetadata(id, req.Name, req.Description, userID)
 	if err != nil {
-		logger.Error("Failed to update network metadata", zap.String("network_id", id), zap.Error(err))
+		logger.WithRequestID(c).Error("Failed to update network metadata", zap.String("network_id", id), zap.Error(err))
 		return writeNetworkServiceError(c, err, "Network not found", "Network update access denied")
 	}
 
-	logger.Info("Network metadata updated", zap.String("network_id", network.ID))
+	logger.WithRequestID(c).Info("Network metadata updated", zap.String("network_id", network.ID))
 
 	return c.Status(fiber.StatusOK).JSON(network)
 }
@@ -211,86 +526,185 @@ func (h *NetworkHandler) UpdateNetworkMetadata(c fiber.Ctx) error {
 func (h *NetworkHandler) DeleteNetwork(c fiber.Ctx) error {
 	id := c.Params("id")
 	if err := validateNetworkID(id); err != nil {
-		return writeErrorResponse(c, fiber.StatusBadRequest, err.Error())
+		return writeValidationError(c, err)
 	}
-	logger.Info("Deleting network", zap.String("network_id", id))
+	logger.WithRequestID(c).Info("Deleting network", zap.String("network_id", id))
 
 	// Get user ID from context
 	userID, authErr := requiredUserID(c)
 	if authErr != nil {
-		logger.Error("Failed to get user ID")
+		logger.WithRequestID(c).Error("Failed to get user ID")
 		return authErr
 	}
 
 	err := h.networkService.DeleteNetwork(id, userID)
 	if err != nil {
-		logger.Error("Failed to delete network", zap.String("network_id", id), zap.Error(err))
+		logger.WithRequestID(c).Error("Failed to delete network", zap.String("network_id", id), zap.Error(err))
 		return writeNetworkServiceError(c, err, "Network not found", "Network delete access denied")
 	}
 
-	logger.Info("Network deleted", zap.String("network_id", id))
+	logger.WithRequestID(c).Info("Network deleted", zap.String("network_id", id))
+
+	return writeLocalizedMessage(c, fiber.StatusOK, "network.delete_success", nil)
+}
+
+// BackupNetwork downloads a network's configuration and members as a backup document
+func (h *NetworkHandler) BackupNetwork(c fiber.Ctx) error {
+	id := c.Params("id")
+	if err := validateNetworkID(id); err != nil {
+		return writeValidationError(c, err)
+	}
+
+	userID, authErr := requiredUserID(c)
+	if authErr != nil {
+		logger.WithRequestID(c).Error("Failed to get user ID")
+		return authErr
+	}
+
+	backup, err := h.networkService.ExportNetwork(id, userID)
+	if err != nil {
+		logger.WithRequestID(c).Error("Failed to back up network", zap.String("network_id", id), zap.Error(err))
+		return writeNetworkServiceError(c, err, "Network not found", "Network backup access denied")
+	}
+
+	c.Set(fiber.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="network-%s-%s.json"`, id, backup.ExportedAt.Format("20060102")))
+	c.Set(fiber.HeaderCacheControl, "no-store")
+	return c.Status(fiber.StatusOK).JSON(backup)
+}
+
+// RestoreNetwork creates a new network from a backup document
+func (h *NetworkHandler) RestoreNetwork(c fiber.Ctx) error {
+	var backup services.NetworkBackup
+	if err := c.Bind().Body(&backup); err != nil {
+		logger.WithRequestID(c).Error("Failed to bind restore network request", zap.Error(err))
+		return writeBindErrorWithCode(c, apierror.CodeNetworkBackupInvalid, err)
+	}
+
+	if err := validateNetworkName(backup.Network.Name); err != nil {
+		return writeValidationError(c, err)
+	}
+	if err := validateNetworkDescription(backup.Network.Description); err != nil {
+		return writeValidationError(c, err)
+	}
+	for _, member := range backup.Members {
+		if err := validateMemberName(member.Name); err != nil {
+			return writeValidationError(c, err)
+		}
+	}
+
+	userID, authErr := requiredUserID(c)
+	if authErr != nil {
+		logger.WithRequestID(c).Error("Failed to get user ID")
+		return authErr
+	}
+
+	logger.WithRequestID(c).Info("Restoring network from backup", zap.String("source_id", backup.SourceID), zap.Int("member_count", len(backup.Members)))
+
+	result, err := h.networkService.RestoreNetwork(c.Query("controller"), &backup, userID)
+	if err != nil {
+		logger.WithRequestID(c).Error("Failed to restore network", zap.String("source_id", backup.SourceID), zap.Error(err))
+		return writeNetworkServiceError(c, err, "Network not found", "Network restore access denied")
+	}
+
+	logger.WithRequestID(c).Info("Network restored",
+		zap.String("network_id", result.Network.ID),
+		zap.Int("restored_members", result.RestoredMembers),
+		zap.Int("failed_members", len(result.FailedMembers)))
+
+	return c.Status(fiber.StatusCreated).JSON(result)
+}
+
+// CreateNetworkBatch starts creating several networks of the same shape. The idempotency
+// key may also be sent in the Idempotency-Key header.
+func (h *NetworkHandler) CreateNetworkBatch(c fiber.Ctx) error {
+	var req services.NetworkBatchRequest
+	if err := c.Bind().Body(&req); err != nil {
+		logger.WithRequestID(c).Error("Failed to bind network batch request", zap.Error(err))
+		return writeBindError(c, err)
+	}
+	if req.IdempotencyKey == "" {
+		req.IdempotencyKey = strings.TrimSpace(c.Get("Idempotency-Key"))
+	}
+	if err := validateNetworkBatch(&req); err != nil {
+		return writeValidationError(c, err)
+	}
+
+	userID, authErr := requiredUserID(c)
+	if authErr != nil {
+		logger.WithRequestID(c).Error("Failed to get user ID")
+		return authErr
+	}
+
+	logger.WithRequestID(c).Info("Starting network batch", zap.Int("count", req.Count), zap.String("name_pattern", req.NamePattern))
+
+	operation, err := h.networkService.StartNetworkBatch(req, userID)
+	if err != nil {
+		logger.WithRequestID(c).Error("Failed to start network batch", zap.Error(err))
+		return writeNetworkServiceError(c, err, "Network not found", "Network creation access denied")
+	}
 
-	return writeMessageResponse(c, fiber.StatusOK, "network.delete_success", "Network deleted successfully", nil)
+	c.Set(fiber.HeaderLocation, "/api/operations/"+operation.ID)
+	return c.Status(fiber.StatusAccepted).JSON(operation)
 }
 
 // GetImportableNetworks retrieves the list of importable networks
 func (h *NetworkHandler) GetImportableNetworks(c fiber.Ctx) error {
-	logger.Info("Getting importable networks")
+	logger.WithRequestID(c).Info("Getting importable networks")
 
 	// Get user ID from context
 	_, authErr := requiredUserID(c)
 	if authErr != nil {
-		logger.Error("Failed to get user ID")
+		logger.WithRequestID(c).Error("Failed to get user ID")
 		return authErr
 	}
 
-	importableNetworks, err := h.networkService.GetImportableNetworks()
+	importableNetworks, err := h.networkService.GetImportableNetworks(c.Query("controller"))
 	if err != nil {
-		logger.Error("Failed to get importable networks", zap.Error(err))
+		logger.WithRequestID(c).Error("Failed to get importable networks", zap.Error(err))
 		return writeNetworkServiceError(c, err, "Network not found", "Importable network access denied")
 	}
 
-	logger.Info("Importable networks retrieved", zap.Int("count", len(importableNetworks.Candidates)))
+	logger.WithRequestID(c).Info("Importable networks retrieved", zap.Int("count", len(importableNetworks.Candidates)))
 	return c.Status(fiber.StatusOK).JSON(importableNetworks)
 }
 
 // ImportNetworks imports the specified networks
 func (h *NetworkHandler) ImportNetworks(c fiber.Ctx) error {
-	logger.Info("Importing networks")
+	logger.WithRequestID(c).Info("Importing networks")
 
 	// Get user ID from context
 	_, authErr := requiredUserID(c)
 	if authErr != nil {
-		logger.Error("Failed to get user ID")
+		logger.WithRequestID(c).Error("Failed to get user ID")
 		return authErr
 	}
 
 	// Parse request body
 	var request struct {
-		NetworkIDs []string `json:"network_ids"`
-		OwnerID    string   `json:"owner_id"`
+		NetworkIDs []string `json:"networkIds"`
+		OwnerID    string   `json:"ownerId"`
 	}
 
 	if err := c.Bind().Body(&request); err != nil {
-		logger.Error("Failed to bind import networks request", zap.Error(err))
-		return writeErrorResponse(c, fiber.StatusBadRequest, err.Error())
+		logger.WithRequestID(c).Error("Failed to bind import networks request", zap.Error(err))
+		return writeBindError(c, err)
 	}
 
 	if len(request.NetworkIDs) == 0 {
-		return writeErrorResponseWithCode(c, fiber.StatusBadRequest, "network.import_empty", "Network ID list is empty")
+		return writeLocalizedError(c, fiber.StatusBadRequest, apierror.CodeNetworkImportEmpty)
 	}
 
 	role, _ := c.Locals("role").(string)
 
-	logger.Info("Processing network import", zap.Strings("network_ids", request.NetworkIDs), zap.String("owner_id", request.OwnerID))
+	logger.WithRequestID(c).Info("Processing network import", zap.Strings("network_ids", request.NetworkIDs), zap.String("owner_id", request.OwnerID))
 
-	result, err := h.networkService.ImportNetworks(request.NetworkIDs, request.OwnerID, role)
+	result, err := h.networkService.ImportNetworks(c.Query("controller"), request.NetworkIDs, request.OwnerID, role)
 	if err != nil {
-		logger.Error("Network import failed", zap.Error(err))
+		logger.WithRequestID(c).Error("Network import failed", zap.Error(err))
 		return writeNetworkServiceError(c, err, "Network not found", "Network import access denied")
 	}
 
-	logger.Info("Network import completed",
+	logger.WithRequestID(c).Info("Network import completed",
 		zap.Int("imported_count", len(result.Imported)),
 		zap.Int("skipped_count", len(result.Skipped)),
 		zap.Int("failed_count", len(result.Failed)))
@@ -301,7 +715,7 @@ func (h *NetworkHandler) ImportNetworks(c fiber.Ctx) error {
 func (h *NetworkHandler) GetNetworkViewers(c fiber.Ctx) error {
 	networkID := c.Params("id")
 	if err := validateNetworkID(networkID); err != nil {
-		return writeErrorResponse(c, fiber.StatusBadRequest, err.Error())
+		return writeValidationError(c, err)
 	}
 	userID, authErr := requiredUserID(c)
 	if authErr != nil {
@@ -319,7 +733,7 @@ func (h *NetworkHandler) GetNetworkViewers(c fiber.Ctx) error {
 func (h *NetworkHandler) GetNetworkViewerCandidates(c fiber.Ctx) error {
 	networkID := c.Params("id")
 	if err := validateNetworkID(networkID); err != nil {
-		return writeErrorResponse(c, fiber.StatusBadRequest, err.Error())
+		return writeValidationError(c, err)
 	}
 	userID, authErr := requiredUserID(c)
 	if authErr != nil {
@@ -337,7 +751,7 @@ func (h *NetworkHandler) GetNetworkViewerCandidates(c fiber.Ctx) error {
 func (h *NetworkHandler) AddNetworkViewer(c fiber.Ctx) error {
 	networkID := c.Params("id")
 	if err := validateNetworkID(networkID); err != nil {
-		return writeErrorResponse(c, fiber.StatusBadRequest, err.Error())
+		return writeValidationError(c, err)
 	}
 	userID, authErr := requiredUserID(c)
 	if authErr != nil {
@@ -345,26 +759,26 @@ func (h *NetworkHandler) AddNetworkViewer(c fiber.Ctx) error {
 	}
 
 	var request struct {
-		UserID string `json:"user_id"`
+		UserID string `json:"userId"`
 	}
 	if err := c.Bind().Body(&request); err != nil {
-		return writeErrorResponse(c, fiber.StatusBadRequest, err.Error())
+		return writeBindError(c, err)
 	}
 	if request.UserID == "" {
-		return writeErrorResponseWithCode(c, fiber.StatusBadRequest, "user.required", "User is required")
+		return writeLocalizedError(c, fiber.StatusBadRequest, apierror.CodeUserRequired)
 	}
 
 	if err := h.networkService.GrantNetworkViewer(networkID, request.UserID, userID); err != nil {
 		return writeNetworkServiceError(c, err, "Network not found", "Network viewer access denied")
 	}
 
-	return writeMessageResponse(c, fiber.StatusOK, "network.viewer_added", "Read-only viewer access granted", nil)
+	return writeLocalizedMessage(c, fiber.StatusOK, "network.viewer_added", nil)
 }
 
 func (h *NetworkHandler) DeleteNetworkViewer(c fiber.Ctx) error {
 	networkID := c.Params("id")
 	if err := validateNetworkID(networkID); err != nil {
-		return writeErrorResponse(c, fiber.StatusBadRequest, err.Error())
+		return writeValidationError(c, err)
 	}
 	targetUserID := c.Params("userId")
 	userID, authErr := requiredUserID(c)
@@ -372,12 +786,12 @@ func (h *NetworkHandler) DeleteNetworkViewer(c fiber.Ctx) error {
 		return authErr
 	}
 	if targetUserID == "" {
-		return writeErrorResponseWithCode(c, fiber.StatusBadRequest, "user.required", "User is required")
+		return writeLocalizedError(c, fiber.StatusBadRequest, apierror.CodeUserRequired)
 	}
 
 	if err := h.networkService.RevokeNetworkViewer(networkID, targetUserID, userID); err != nil {
 		return writeNetworkServiceError(c, err, "Network not found", "Network viewer access denied")
 	}
 
-	return writeMessageResponse(c, fiber.StatusOK, "network.viewer_removed", "Read-only viewer access removed", nil)
+	return writeLocalizedMessage(c, fiber.StatusOK, "network.viewer_removed", nil)
 }
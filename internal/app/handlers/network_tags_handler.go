@@ -0,0 +1,244 @@
+package handlers
+
+import (
+	"strconv"
+
+	"github.com/GT-610/tairitsu/internal/app/logger"
+	"github.com/GT-610/tairitsu/internal/app/services"
+	"github.com/gofiber/fiber/v3"
+	"go.uber.org/zap"
+)
+
+type setMemberTagsRequest struct {
+	Tags map[string]services.TagValue `json:"tags"`
+}
+
+type setMemberCapabilitiesRequest struct {
+	Capabilities []string `json:"capabilities"`
+}
+
+func parseTagID(c fiber.Ctx, param string) (uint32, error) {
+	id, err := strconv.ParseUint(c.Params(param), 10, 32)
+	if err != nil {
+		return 0, writeLocalizedStatusError(c, fiber.StatusBadRequest, "request.invalid_integer", param+" must be a number between 0 and 4294967295")
+	}
+	return uint32(id), nil
+}
+
+// GetNetworkTags lists the named tags of a network
+func (h *NetworkHandler) GetNetworkTags(c fiber.Ctx) error {
+	id := c.Params("id")
+	if err := validateNetworkID(id); err != nil {
+		return writeValidationError(c, err)
+	}
+	userID, authErr := requiredUserID(c)
+	if authErr != nil {
+		logger.WithRequestID(c).Error("Failed to get user ID")
+		return authErr
+	}
+
+	tags, err := h.networkService.ListNetworkTags(id, userID)
+	if err != nil {
+		logger.WithRequestID(c).Error("Failed to list network tags", zap.String("network_id", id), zap.Error(err))
+		return writeNetworkServiceError(c, err, "Network not found", "Network access denied")
+	}
+	return c.Status(fiber.StatusOK).JSON(tags)
+}
+
+// CreateNetworkTag names a tag ID of an owned network
+func (h *NetworkHandler) CreateNetworkTag(c fiber.Ctx) error {
+	id := c.Params("id")
+	if err := validateNetworkID(id); err != nil {
+		return writeValidationError(c, err)
+	}
+	var req services.NetworkTagInput
+	if err := c.Bind().JSON(&req); err != nil {
+		return writeBindError(c, err)
+	}
+	userID, authErr := requiredUserID(c)
+	if authErr != nil {
+		logger.WithRequestID(c).Error("Failed to get user ID")
+		return authErr
+	}
+
+	tag, err := h.networkService.CreateNetworkTag(id, req, userID)
+	if err != nil {
+		logger.WithRequestID(c).Error("Failed to create network tag", zap.String("network_id", id), zap.Error(err))
+		return writeNetworkServiceError(c, err, "Network not found", "Network access denied")
+	}
+	return c.Status(fiber.StatusCreated).JSON(tag)
+}
+
+// UpdateNetworkTag renames a tag of an owned network and replaces its named values
+func (h *NetworkHandler) UpdateNetworkTag(c fiber.Ctx) error {
+	id := c.Params("id")
+	if err := validateNetworkID(id); err != nil {
+		return writeValidationError(c, err)
+	}
+	tagID, err := parseTagID(c, "tagId")
+	if err != nil {
+		return err
+	}
+	var req services.NetworkTagInput
+	if err := c.Bind().JSON(&req); err != nil {
+		return writeBindError(c, err)
+	}
+	userID, authErr := requiredUserID(c)
+	if authErr != nil {
+		logger.WithRequestID(c).Error("Failed to get user ID")
+		return authErr
+	}
+
+	tag, err := h.networkService.UpdateNetworkTag(id, tagID, req, userID)
+	if err != nil {
+		logger.WithRequestID(c).Error("Failed to update network tag", zap.String("network_id", id), zap.Uint32("tag_id", tagID), zap.Error(err))
+		return writeNetworkServiceError(c, err, "Network not found", "Network access denied")
+	}
+	return c.Status(fiber.StatusOK).JSON(tag)
+}
+
+// DeleteNetworkTag removes a tag definition of an owned network
+func (h *NetworkHandler) DeleteNetworkTag(c fiber.Ctx) error {
+	id := c.Params("id")
+	if err := validateNetworkID(id); err != nil {
+		return writeValidationError(c, err)
+	}
+	tagID, err := parseTagID(c, "tagId")
+	if err != nil {
+		return err
+	}
+	userID, authErr := requiredUserID(c)
+	if authErr != nil {
+		logger.WithRequestID(c).Error("Failed to get user ID")
+		return authErr
+	}
+
+	if err := h.networkService.DeleteNetworkTag(id, tagID, userID); err != nil {
+		logger.WithRequestID(c).Error("Failed to delete network tag", zap.String("network_id", id), zap.Uint32("tag_id", tagID), zap.Error(err))
+		return writeNetworkServiceError(c, err, "Network not found", "Network access denied")
+	}
+	return writeLocalizedMessage(c, fiber.StatusOK, "tag.deleted", nil)
+}
+
+// GetNetworkCapabilities lists the named capabilities of a network
+func (h *NetworkHandler) GetNetworkCapabilities(c fiber.Ctx) error {
+	id := c.Params("id")
+	if err := validateNetworkID(id); err != nil {
+		return writeValidationError(c, err)
+	}
+	userID, authErr := requiredUserID(c)
+	if authErr != nil {
+		logger.WithRequestID(c).Error("Failed to get user ID")
+		return authErr
+	}
+
+	capabilities, err := h.networkService.ListNetworkCapabilities(id, userID)
+	if err != nil {
+		logger.WithRequestID(c).Error("Failed to list network capabilities", zap.String("network_id", id), zap.Error(err))
+		return writeNetworkServiceError(c, err, "Network not found", "Network access denied")
+	}
+	return c.Status(fiber.StatusOK).JSON(capabilities)
+}
+
+// CreateNetworkCapability names a capability ID of an owned network
+func (h *NetworkHandler) CreateNetworkCapability(c fiber.Ctx) error {
+	id := c.Params("id")
+	if err := validateNetworkID(id); err != nil {
+		return writeValidationError(c, err)
+	}
+	var req services.NetworkCapabilityInput
+	if err := c.Bind().JSON(&req); err != nil {
+		return writeBindError(c, err)
+	}
+	userID, authErr := requiredUserID(c)
+	if authErr != nil {
+		logger.WithRequestID(c).Error("Failed to get user ID")
+		return authErr
+	}
+
+	capability, err := h.networkService.CreateNetworkCapability(id, req, userID)
+	if err != nil {
+		logger.WithRequestID(c).Error("Failed to create network capability", zap.String("network_id", id), zap.Error(err))
+		return writeNetworkServiceError(c, err, "Network not found", "Network access denied")
+	}
+	return c.Status(fiber.StatusCreated).JSON(capability)
+}
+
+// DeleteNetworkCapability removes a capability definition of an owned network
+func (h *NetworkHandler) DeleteNetworkCapability(c fiber.Ctx) error {
+	id := c.Params("id")
+	if err := validateNetworkID(id); err != nil {
+		return writeValidationError(c, err)
+	}
+	capabilityID, err := parseTagID(c, "capabilityId")
+	if err != nil {
+		return err
+	}
+	userID, authErr := requiredUserID(c)
+	if authErr != nil {
+		logger.WithRequestID(c).Error("Failed to get user ID")
+		return authErr
+	}
+
+	if err := h.networkService.DeleteNetworkCapability(id, capabilityID, userID); err != nil {
+		logger.WithRequestID(c).Error("Failed to delete network capability", zap.String("network_id", id), zap.Uint32("capability_id", capabilityID), zap.Error(err))
+		return writeNetworkServiceError(c, err, "Network not found", "Network access denied")
+	}
+	return writeLocalizedMessage(c, fiber.StatusOK, "capability.deleted", nil)
+}
+
+// SetMemberTags replaces the tags of a member, given by tag name
+func (h *MemberHandler) SetMemberTags(c fiber.Ctx) error {
+	networkID := c.Params("id")
+	memberID := c.Params("memberId")
+	if err := validateNetworkID(networkID); err != nil {
+		return writeValidationError(c, err)
+	}
+	if err := validateMemberID(memberID); err != nil {
+		return writeValidationError(c, err)
+	}
+	var req setMemberTagsRequest
+	if err := c.Bind().JSON(&req); err != nil {
+		return writeBindError(c, err)
+	}
+	userID, authErr := requiredUserID(c)
+	if authErr != nil {
+		logger.WithRequestID(c).Error("Failed to get user ID")
+		return authErr
+	}
+
+	member, err := h.networkService.SetMemberTags(networkID, memberID, req.Tags, userID)
+	if err != nil {
+		logger.WithRequestID(c).Error("Failed to set member tags", zap.String("network_id", networkID), zap.String("member_id", memberID), zap.Error(err))
+		return writeNetworkServiceError(c, err, "Network not found", "Network member update access denied")
+	}
+	return c.Status(fiber.StatusOK).JSON(member)
+}
+
+// SetMemberCapabilities replaces the capabilities of a member, given by capability name
+func (h *MemberHandler) SetMemberCapabilities(c fiber.Ctx) error {
+	networkID := c.Params("id")
+	memberID := c.Params("memberId")
+	if err := validateNetworkID(networkID); err != nil {
+		return writeValidationError(c, err)
+	}
+	if err := validateMemberID(memberID); err != nil {
+		return writeValidationError(c, err)
+	}
+	var req setMemberCapabilitiesRequest
+	if err := c.Bind().JSON(&req); err != nil {
+		return writeBindError(c, err)
+	}
+	userID, authErr := requiredUserID(c)
+	if authErr != nil {
+		logger.WithRequestID(c).Error("Failed to get user ID")
+		return authErr
+	}
+
+	member, err := h.networkService.SetMemberCapabilities(networkID, memberID, req.Capabilities, userID)
+	if err != nil {
+		logger.WithRequestID(c).Error("Failed to set member capabilities", zap.String("network_id", networkID), zap.String("member_id", memberID), zap.Error(err))
+		return writeNetworkServiceError(c, err, "Network not found", "Network member update access denied")
+	}
+	return c.Status(fiber.StatusOK).JSON(member)
+}
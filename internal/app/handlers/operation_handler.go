@@ -0,0 +1,89 @@
+package handlers
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/GT-610/tairitsu/internal/app/apierror"
+	"github.com/GT-610/tairitsu/internal/app/logger"
+	"github.com/GT-610/tairitsu/internal/app/services"
+	"github.com/gofiber/fiber/v3"
+	"go.uber.org/zap"
+)
+
+// OperationHandler lets users follow and cancel their long-running operations;
+// administrators see every user's
+type OperationHandler struct {
+	operations *services.OperationManager
+}
+
+// NewOperationHandler creates a new operation handler instance
+func NewOperationHandler(operations *services.OperationManager) *OperationHandler {
+	return &OperationHandler{operations: operations}
+}
+
+func writeOperationError(c fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, services.ErrOperationNotFound):
+		return writeLocalizedError(c, fiber.StatusNotFound, apierror.CodeOperationNotFound)
+	case errors.Is(err, services.ErrOperationNotRunning):
+		return writeLocalizedErrorMessage(c, fiber.StatusConflict, apierror.CodeOperationNotRunning, err.Error())
+	default:
+		return writeInternalError(c, "unhandled operation error", err)
+	}
+}
+
+// ListOperations returns the newest operations, without the items of network batches
+func (h *OperationHandler) ListOperations(c fiber.Ctx) error {
+	userID, authErr := requiredUserID(c)
+	if authErr != nil {
+		return authErr
+	}
+	limit := services.DefaultOperationListLimit
+	if raw := c.Query("limit"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed <= 0 || parsed > services.MaxOperationListLimit {
+			return writeLocalizedStatusError(c, fiber.StatusBadRequest, "request.invalid_integer", "limit must be a number between 1 and "+strconv.Itoa(services.MaxOperationListLimit))
+		}
+		limit = parsed
+	}
+
+	role, _ := c.Locals("role").(string)
+	operations, err := h.operations.List(userID, role == "admin", limit)
+	if err != nil {
+		logger.WithRequestID(c).Error("Failed to list operations", zap.Error(err))
+		return writeOperationError(c, err)
+	}
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{"operations": operations})
+}
+
+// GetOperation reports the status, progress and result of an operation
+func (h *OperationHandler) GetOperation(c fiber.Ctx) error {
+	userID, authErr := requiredUserID(c)
+	if authErr != nil {
+		return authErr
+	}
+
+	role, _ := c.Locals("role").(string)
+	operation, err := h.operations.Get(c.Params("id"), userID, role == "admin")
+	if err != nil {
+		return writeOperationError(c, err)
+	}
+	return c.Status(fiber.StatusOK).JSON(operation)
+}
+
+// CancelOperation cancels a pending or running operation. The response is sent before the
+// operation has stopped; it is cancelled once GET /api/operations/:id says so.
+func (h *OperationHandler) CancelOperation(c fiber.Ctx) error {
+	userID, authErr := requiredUserID(c)
+	if authErr != nil {
+		return authErr
+	}
+
+	role, _ := c.Locals("role").(string)
+	operation, err := h.operations.Cancel(c.Params("id"), userID, role == "admin")
+	if err != nil {
+		return writeOperationError(c, err)
+	}
+	return c.Status(fiber.StatusAccepted).JSON(operation)
+}
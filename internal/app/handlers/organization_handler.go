@@ -0,0 +1,152 @@
+package handlers
+
+import (
+	"github.com/GT-610/tairitsu/internal/app/logger"
+	"github.com/GT-610/tairitsu/internal/app/services"
+	"github.com/gofiber/fiber/v3"
+	"go.uber.org/zap"
+)
+
+// OrganizationHandler manages organizations, their members and the networks assigned to them
+type OrganizationHandler struct {
+	networkService *services.NetworkService
+}
+
+// NewOrganizationHandler creates a new organization handler instance
+func NewOrganizationHandler(networkService *services.NetworkService) *OrganizationHandler {
+	return &OrganizationHandler{networkService: networkService}
+}
+
+type organizationMemberRequest struct {
+	Role string `json:"role"`
+}
+
+// ListOrganizations returns the organizations visible to the caller
+func (h *OrganizationHandler) ListOrganizations(c fiber.Ctx) error {
+	userID, authErr := requiredUserID(c)
+	if authErr != nil {
+		logger.WithRequestID(c).Error("Failed to get user ID")
+		return authErr
+	}
+
+	organizations, err := h.networkService.ListOrganizations(userID)
+	if err != nil {
+		logger.WithRequestID(c).Error("Failed to list organizations", zap.Error(err))
+		return writeNetworkServiceError(c, err, "Network not found", "Network access denied")
+	}
+	return c.Status(fiber.StatusOK).JSON(organizations)
+}
+
+// CreateOrganization creates an organization
+func (h *OrganizationHandler) CreateOrganization(c fiber.Ctx) error {
+	var req services.OrganizationInput
+	if err := c.Bind().JSON(&req); err != nil {
+		return writeBindError(c, err)
+	}
+
+	organization, err := h.networkService.CreateOrganization(req)
+	if err != nil {
+		logger.WithRequestID(c).Error("Failed to create organization", zap.Error(err))
+		return writeNetworkServiceError(c, err, "Network not found", "Network access denied")
+	}
+	return c.Status(fiber.StatusCreated).JSON(organization)
+}
+
+// UpdateOrganization renames or describes an organization
+func (h *OrganizationHandler) UpdateOrganization(c fiber.Ctx) error {
+	id := c.Params("orgId")
+	var req services.OrganizationInput
+	if err := c.Bind().JSON(&req); err != nil {
+		return writeBindError(c, err)
+	}
+
+	organization, err := h.networkService.UpdateOrganization(id, req)
+	if err != nil {
+		logger.WithRequestID(c).Error("Failed to update organization", zap.String("organization_id", id), zap.Error(err))
+		return writeNetworkServiceError(c, err, "Network not found", "Network access denied")
+	}
+	return c.Status(fiber.StatusOK).JSON(organization)
+}
+
+// DeleteOrganization deletes an organization, moving its networks to the default organization
+func (h *OrganizationHandler) DeleteOrganization(c fiber.Ctx) error {
+	id := c.Params("orgId")
+	if err := h.networkService.DeleteOrganization(id); err != nil {
+		logger.WithRequestID(c).Error("Failed to delete organization", zap.String("organization_id", id), zap.Error(err))
+		return writeNetworkServiceError(c, err, "Network not found", "Network access denied")
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// AssignNetwork moves a network to an organization
+func (h *OrganizationHandler) AssignNetwork(c fiber.Ctx) error {
+	id := c.Params("orgId")
+	networkID := c.Params("id")
+	if err := validateNetworkID(networkID); err != nil {
+		return writeValidationError(c, err)
+	}
+
+	network, err := h.networkService.AssignNetworkOrganization(networkID, id)
+	if err != nil {
+		logger.WithRequestID(c).Error("Failed to assign network to organization", zap.String("organization_id", id), zap.String("network_id", networkID), zap.Error(err))
+		return writeNetworkServiceError(c, err, "Network not found", "Network access denied")
+	}
+	return c.Status(fiber.StatusOK).JSON(network)
+}
+
+// ListMembers returns the members of an organization
+func (h *OrganizationHandler) ListMembers(c fiber.Ctx) error {
+	id := c.Params("orgId")
+	userID, authErr := requiredUserID(c)
+	if authErr != nil {
+		logger.WithRequestID(c).Error("Failed to get user ID")
+		return authErr
+	}
+
+	members, err := h.networkService.ListOrganizationMembers(id, userID)
+	if err != nil {
+		logger.WithRequestID(c).Error("Failed to list organization members", zap.String("organization_id", id), zap.Error(err))
+		return writeNetworkServiceError(c, err, "Network not found", "Network access denied")
+	}
+	return c.Status(fiber.StatusOK).JSON(members)
+}
+
+// SetMember adds a user to an organization or changes their role
+func (h *OrganizationHandler) SetMember(c fiber.Ctx) error {
+	id := c.Params("orgId")
+	targetUserID := c.Params("userId")
+	var req organizationMemberRequest
+	if err := c.Bind().JSON(&req); err != nil {
+		return writeBindError(c, err)
+	}
+
+	userID, authErr := requiredUserID(c)
+	if authErr != nil {
+		logger.WithRequestID(c).Error("Failed to get user ID")
+		return authErr
+	}
+
+	member, err := h.networkService.SetOrganizationMember(id, targetUserID, req.Role, userID)
+	if err != nil {
+		logger.WithRequestID(c).Error("Failed to set organization member", zap.String("organization_id", id), zap.String("target_user_id", targetUserID), zap.Error(err))
+		return writeNetworkServiceError(c, err, "Network not found", "Network access denied")
+	}
+	return c.Status(fiber.StatusOK).JSON(member)
+}
+
+// RemoveMember removes a user from an organization
+func (h *OrganizationHandler) RemoveMember(c fiber.Ctx) error {
+	id := c.Params("orgId")
+	targetUserID := c.Params("userId")
+	userID, authErr := requiredUserID(c)
+	if authErr != nil {
+		logger.WithRequestID(c).Error("Failed to get user ID")
+		return authErr
+	}
+
+	if err := h.networkService.RemoveOrganizationMember(id, targetUserID, userID); err != nil {
+		logger.WithRequestID(c).Error("Failed to remove organization member", zap.String("organization_id", id), zap.String("target_user_id", targetUserID), zap.Error(err))
+		return writeNetworkServiceError(c, err, "Network not found", "Network access denied")
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
@@ -0,0 +1,44 @@
+package handlers
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/GT-610/tairitsu/internal/app/apierror"
+	"github.com/GT-610/tairitsu/internal/app/services"
+	"github.com/gofiber/fiber/v3"
+)
+
+// parsePageRequest reads cursor or offset paging parameters; paged is false when none were given
+func parsePageRequest(c fiber.Ctx) (req services.PageRequest, paged bool, err error) {
+	req.Cursor = c.Query("cursor")
+	paged = req.Cursor != ""
+
+	if raw := c.Query("limit"); raw != "" {
+		if req.Limit, err = strconv.Atoi(raw); err != nil {
+			return req, true, services.ErrInvalidPageRequest
+		}
+		paged = true
+	}
+	if raw := c.Query("offset"); raw != "" {
+		if req.Offset, err = strconv.Atoi(raw); err != nil {
+			return req, true, services.ErrInvalidPageRequest
+		}
+		req.UseOffset = true
+		paged = true
+	}
+	return req, paged, nil
+}
+
+// writePaginationError maps pagination errors to 400 responses; it returns false for other errors
+func writePaginationError(c fiber.Ctx, err error) (bool, error) {
+	switch {
+	case errors.Is(err, services.ErrCursorQueryChanged):
+		return true, writeLocalizedError(c, fiber.StatusBadRequest, apierror.CodePaginationCursorQueryChanged)
+	case errors.Is(err, services.ErrInvalidCursor):
+		return true, writeLocalizedError(c, fiber.StatusBadRequest, apierror.CodePaginationInvalidCursor)
+	case errors.Is(err, services.ErrInvalidPageRequest):
+		return true, writeLocalizedError(c, fiber.StatusBadRequest, apierror.CodePaginationInvalidRequest)
+	}
+	return false, nil
+}
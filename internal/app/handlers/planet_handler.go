@@ -7,13 +7,20 @@
 package handlers
 
 import (
+	"encoding/hex"
 	"errors"
 	"fmt"
+	"io"
+	"net"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 
+	"github.com/GT-610/tairitsu/internal/app/apierror"
+	"github.com/GT-610/tairitsu/internal/app/i18n"
 	"github.com/GT-610/tairitsu/internal/app/logger"
+	"github.com/GT-610/tairitsu/internal/app/services"
 	"github.com/GT-610/tairitsu/internal/mkworld"
 	"github.com/gofiber/fiber/v3"
 	"go.uber.org/zap"
@@ -23,6 +30,9 @@ const defaultZTPath = "/var/lib/zerotier-one"
 
 var allowedBasePath = defaultZTPath
 
+// lookupHostname resolves hostname endpoints of generated planets and moons
+var lookupHostname = net.LookupIP
+
 func sanitizeZTPath(userPath string) (string, error) {
 	cleaned := filepath.Clean(userPath)
 	baseClean := filepath.Clean(allowedBasePath)
@@ -33,105 +43,177 @@ func sanitizeZTPath(userPath string) (string, error) {
 	return cleaned, nil
 }
 
+// writeInvalidZTPath rejects a path outside the allowed ZeroTier directory without echoing
+// it back; the rejected path is only logged
+func writeInvalidZTPath(c fiber.Ctx, err error) error {
+	logger.WithRequestID(c).Warn("rejected ZeroTier directory path", zap.Error(err))
+	return writeLocalizedError(c, fiber.StatusBadRequest, apierror.CodePlanetInvalidPath)
+}
+
 type GeneratePlanetRequest struct {
-	RootNodes       []PlanetRootNodeRequest `json:"root_nodes"`
-	SigningKeyPath  string                  `json:"signing_key_path"`
-	PlanetID        uint64                  `json:"planet_id"`
-	BirthTime       int64                   `json:"birth_time"`
-	RecommendValues bool                    `json:"recommend_values"`
-	DownloadName    string                  `json:"download_name"`
+	RootNodes []PlanetRootNodeRequest `json:"rootNodes"`
+	// SigningKeyDir holds the signing keys; missing keys are created there
+	SigningKeyDir   string `json:"signingKeyDir"`
+	PlanetID        uint64 `json:"planetId"`
+	BirthTime       int64  `json:"birthTime"`
+	RecommendValues bool   `json:"recommendValues"`
+	DownloadName    string `json:"downloadName"`
+	// ResolveHostnames allows endpoints like "root1.example.com/9993"; nil means true
+	ResolveHostnames *bool `json:"resolveHostnames"`
+	// ResolveFamily is "ipv4", "ipv6" or "both" (the default)
+	ResolveFamily string `json:"resolveFamily"`
+	// SkipValidation accepts roots whose public key does not derive their address
+	SkipValidation bool `json:"skipValidation"`
 }
 
 type PlanetRootNodeRequest struct {
-	IdentityPublic string   `json:"identity_public"`
+	IdentityPublic string   `json:"identityPublic"`
 	Comments       string   `json:"comments"`
 	Endpoints      []string `json:"endpoints"`
 }
 
 type GeneratePlanetResponse struct {
 	Message               string `json:"message"`
-	PlanetData            []byte `json:"planet_data"`
-	PlanetID              uint64 `json:"planet_id"`
-	BirthTime             int64  `json:"birth_time"`
-	DownloadName          string `json:"download_name"`
-	RootNodeCount         int    `json:"root_node_count"`
-	EndpointCount         int    `json:"endpoint_count"`
-	UsedRecommendedValues bool   `json:"used_recommended_values"`
+	PlanetData            []byte `json:"planetData"`
+	PlanetID              uint64 `json:"planetId"`
+	BirthTime             int64  `json:"birthTime"`
+	DownloadName          string `json:"downloadName"`
+	RootNodeCount         int    `json:"rootNodeCount"`
+	EndpointCount         int    `json:"endpointCount"`
+	UsedRecommendedValues bool   `json:"usedRecommendedValues"`
+	// SigningKeys is "reused", "created" or "ephemeral"
+	SigningKeys   string `json:"signingKeys"`
+	SigningKeyDir string `json:"signingKeyDir,omitempty"`
+	// HistoryID identifies the planet in the generation history; zero when it was not kept
+	HistoryID uint64 `json:"historyId,omitempty"`
+}
+
+type GenerateMoonRequest struct {
+	RootNodes     []PlanetRootNodeRequest `json:"rootNodes"`
+	SigningKeyDir string                  `json:"signingKeyDir"`
+	// Timestamp in milliseconds; zero uses the current time
+	Timestamp        int64  `json:"timestamp"`
+	ResolveHostnames *bool  `json:"resolveHostnames"`
+	ResolveFamily    string `json:"resolveFamily"`
+	SkipValidation   bool   `json:"skipValidation"`
+}
+
+// GenerateMoonResponse carries the moon file base64-encoded in MoonData
+type GenerateMoonResponse struct {
+	Message       string `json:"message"`
+	MoonData      []byte `json:"moonData"`
+	MoonID        string `json:"moonId"`
+	Timestamp     int64  `json:"timestamp"`
+	FileName      string `json:"fileName"`
+	RootNodeCount int    `json:"rootNodeCount"`
+	EndpointCount int    `json:"endpointCount"`
+	SigningKeys   string `json:"signingKeys"`
+	SigningKeyDir string `json:"signingKeyDir,omitempty"`
+}
+
+// InspectWorldRequest carries a planet or moon file base64-encoded, for clients that do not upload it
+type InspectWorldRequest struct {
+	PlanetData []byte `json:"planetData"`
+}
+
+type InspectWorldResponse struct {
+	Message               string                  `json:"message"`
+	Type                  string                  `json:"type"`
+	ID                    uint64                  `json:"id"`
+	Timestamp             int64                   `json:"timestamp"`
+	UpdatesMustBeSignedBy string                  `json:"updatesMustBeSignedBy"`
+	Signature             string                  `json:"signature"`
+	RootNodes             []PlanetRootNodeRequest `json:"rootNodes"`
+	RootNodeCount         int                     `json:"rootNodeCount"`
+	EndpointCount         int                     `json:"endpointCount"`
 }
 
 type IdentityInfoResponse struct {
 	Message        string `json:"message"`
-	IdentityPublic string `json:"identity_public"`
-	IdentityPath   string `json:"identity_path"`
+	IdentityPublic string `json:"identityPublic"`
+	IdentityPath   string `json:"identityPath"`
 }
 
 type SigningKeysInfoResponse struct {
 	Message         string `json:"message"`
-	SigningKeyPath  string `json:"signing_key_path"`
-	PreviousKeyPath string `json:"previous_key_path"`
-	CurrentKeyPath  string `json:"current_key_path"`
-	PreviousExists  bool   `json:"previous_exists"`
-	CurrentExists   bool   `json:"current_exists"`
+	SigningKeyPath  string `json:"signingKeyPath"`
+	PreviousKeyPath string `json:"previousKeyPath"`
+	CurrentKeyPath  string `json:"currentKeyPath"`
+	PreviousExists  bool   `json:"previousExists"`
+	CurrentExists   bool   `json:"currentExists"`
 	Ready           bool   `json:"ready"`
 }
 
 type GenerateSigningKeysResponse struct {
 	Message         string `json:"message"`
-	SigningKeyPath  string `json:"signing_key_path"`
-	PreviousKeyPath string `json:"previous_key_path"`
-	CurrentKeyPath  string `json:"current_key_path"`
+	SigningKeyPath  string `json:"signingKeyPath"`
+	PreviousKeyPath string `json:"previousKeyPath"`
+	CurrentKeyPath  string `json:"currentKeyPath"`
+}
+
+// PlanetHandler handles planet generation and the history of generated planets
+type PlanetHandler struct {
+	history *services.PlanetHistoryService
 }
 
-func GeneratePlanetHandler(c fiber.Ctx) error {
+// NewPlanetHandler creates a new planet handler instance; a nil history keeps no planets
+func NewPlanetHandler(history *services.PlanetHistoryService) *PlanetHandler {
+	return &PlanetHandler{history: history}
+}
+
+// GeneratePlanet builds a planet and keeps it in the history for later downloads
+func (h *PlanetHandler) GeneratePlanet(c fiber.Ctx) error {
 	var req GeneratePlanetRequest
 	if err := c.Bind().JSON(&req); err != nil {
-		return writeErrorResponse(c, fiber.StatusBadRequest, "Invalid request body: "+err.Error())
+		return writeBindError(c, err)
 	}
 
 	if len(req.RootNodes) == 0 {
-		return writeErrorResponse(c, fiber.StatusBadRequest, "root_nodes is required")
+		return writeLocalizedStatusError(c, fiber.StatusBadRequest, "request.field_required", "root_nodes is required")
+	}
+	signingKeyDir, err := resolveSigningKeyDir(req.SigningKeyDir)
+	if err != nil {
+		return writeInvalidZTPath(c, err)
 	}
 
-	rootNodes := make([]mkworld.RootNodeConfig, 0, len(req.RootNodes))
-	for _, rootNode := range req.RootNodes {
-		rootNodes = append(rootNodes, mkworld.RootNodeConfig{
-			IdentityPublic: strings.TrimSpace(rootNode.IdentityPublic),
-			Comments:       strings.TrimSpace(rootNode.Comments),
-			Endpoints:      rootNode.Endpoints,
-		})
+	hostnames, err := hostnameResolution(req.ResolveHostnames, req.ResolveFamily)
+	if err != nil {
+		return writeLocalizedStatusError(c, fiber.StatusBadRequest, "planet.invalid_input", err.Error())
 	}
 
+	rootNodes := toRootNodeConfigs(req.RootNodes)
 	generatedPlanet, err := mkworld.GeneratePlanet(&mkworld.GenerateOptions{
+		Hostnames:       hostnames,
+		SkipValidation:  req.SkipValidation,
 		RootNodes:       rootNodes,
-		SigningKeyPath:  strings.TrimSpace(req.SigningKeyPath),
+		SigningKeyPath:  signingKeyDir,
 		PlanetID:        req.PlanetID,
 		BirthTime:       req.BirthTime,
 		RecommendValues: req.RecommendValues,
 		DownloadName:    strings.TrimSpace(req.DownloadName),
 	})
 	if err != nil {
-		switch {
-		case errors.Is(err, mkworld.ErrIdentityPublicRequired),
-			errors.Is(err, mkworld.ErrNoRootNodes),
-			errors.Is(err, mkworld.ErrNoEndpoints),
-			errors.Is(err, mkworld.ErrInvalidIdentity),
-			errors.Is(err, mkworld.ErrInvalidEndpoint),
-			errors.Is(err, mkworld.ErrDuplicateEndpoint),
-			errors.Is(err, mkworld.ErrDuplicateIdentity),
-			errors.Is(err, mkworld.ErrMaxEndpointsExceeded),
-			errors.Is(err, mkworld.ErrMaxRootNodesExceeded),
-			errors.Is(err, mkworld.ErrReservedPlanetID),
-			errors.Is(err, mkworld.ErrInvalidBirthTime),
-			errors.Is(err, mkworld.ErrInvalidSigningKeys):
-			return writeErrorResponse(c, fiber.StatusBadRequest, err.Error())
-		default:
-			logger.Error("failed to generate planet", zap.Error(err))
-			return writeErrorResponse(c, fiber.StatusInternalServerError, "Failed to generate planet")
+		if isWorldInputError(err) {
+			return writeLocalizedStatusError(c, fiber.StatusBadRequest, "planet.invalid_input", err.Error())
+		}
+		logger.Error("failed to generate planet", zap.Error(err))
+		return writeLocalizedStatusError(c, fiber.StatusInternalServerError, "planet.generation_failed", "Failed to generate planet")
+	}
+
+	var historyID uint64
+	if h.history != nil {
+		userID, _ := c.Locals("user_id").(string)
+		// The planet was generated either way, so a failure to keep it only costs the history entry
+		if generation, err := h.history.Record(generatedPlanet, rootNodes, userID); err != nil {
+			logger.WithRequestID(c).Error("failed to record generated planet", zap.Error(err))
+		} else {
+			historyID = generation.ID
 		}
 	}
 
 	return c.JSON(GeneratePlanetResponse{
-		Message:               "Planet generated successfully",
+		Message:               i18n.Text(i18n.LocaleFrom(c), "planet.generated"),
+		HistoryID:             historyID,
 		PlanetData:            generatedPlanet.PlanetData,
 		PlanetID:              generatedPlanet.PlanetID,
 		BirthTime:             generatedPlanet.BirthTime,
@@ -139,31 +221,324 @@ func GeneratePlanetHandler(c fiber.Ctx) error {
 		RootNodeCount:         generatedPlanet.RootNodeCount,
 		EndpointCount:         generatedPlanet.EndpointCount,
 		UsedRecommendedValues: generatedPlanet.UsedRecommendedValues,
+		SigningKeys:           string(generatedPlanet.SigningKeys),
+		SigningKeyDir:         signingKeyDir,
+	})
+}
+
+// ListPlanetHistory lists the kept planets newest first
+func (h *PlanetHandler) ListPlanetHistory(c fiber.Ctx) error {
+	generations, err := h.history.List()
+	if err != nil {
+		return writePlanetHistoryError(c, err)
+	}
+	return c.JSON(fiber.Map{"planets": generations})
+}
+
+// DownloadPlanet sends a kept planet file, or with ?format=cheader the C header that
+// zerotier-one is built with
+func (h *PlanetHandler) DownloadPlanet(c fiber.Ctx) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 64)
+	if err != nil || id == 0 {
+		return writeLocalizedStatusError(c, fiber.StatusBadRequest, "request.invalid_integer", "id must be a positive integer")
+	}
+	format := c.Query("format", "binary")
+	if format != "binary" && format != "cheader" {
+		return writeLocalizedStatusError(c, fiber.StatusBadRequest, "request.invalid_parameter", "format must be binary or cheader")
+	}
+
+	generation, err := h.history.Get(id)
+	if err != nil {
+		return writePlanetHistoryError(c, err)
+	}
+
+	filename := attachmentName(generation.DownloadName, "planet")
+	if format == "cheader" {
+		c.Set(fiber.HeaderContentType, "text/x-c; charset=utf-8")
+		c.Set(fiber.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="%s.h"`, filename))
+		return c.SendString(mkworld.CHeader(generation.Data))
+	}
+	c.Set(fiber.HeaderContentType, fiber.MIMEOctetStream)
+	c.Set(fiber.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="%s"`, filename))
+	return c.Send(generation.Data)
+}
+
+func writePlanetHistoryError(c fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, services.ErrPlanetGenerationNotFound):
+		logger.WithRequestID(c).Warn("planet generation not found", zap.Error(err))
+		return writeLocalizedError(c, fiber.StatusNotFound, apierror.CodePlanetNotFound)
+	case services.IsUserDBUnavailable(err):
+		return writeLocalizedError(c, fiber.StatusServiceUnavailable, apierror.CodeUserDBUnavailable)
+	default:
+		return writeInternalError(c, "failed to read planet history", err)
+	}
+}
+
+// attachmentName makes a user-chosen name safe for a Content-Disposition header
+func attachmentName(name string, fallback string) string {
+	name = strings.Map(func(r rune) rune {
+		if r < 0x20 || r == 0x7f || r == '"' || r == '\\' || r == '/' {
+			return -1
+		}
+		return r
+	}, strings.TrimSpace(name))
+	if name == "" || name == "." || name == ".." {
+		return fallback
+	}
+	return name
+}
+
+// GenerateMoonHandler builds a moon from the given roots. Unlike a planet, a moon's ID is the
+// address of its first root, and the file is named after it for the moons.d directory.
+func GenerateMoonHandler(c fiber.Ctx) error {
+	var req GenerateMoonRequest
+	if err := c.Bind().JSON(&req); err != nil {
+		return writeBindError(c, err)
+	}
+
+	if len(req.RootNodes) == 0 {
+		return writeLocalizedStatusError(c, fiber.StatusBadRequest, "request.field_required", "root_nodes is required")
+	}
+	signingKeyDir, err := resolveSigningKeyDir(req.SigningKeyDir)
+	if err != nil {
+		return writeInvalidZTPath(c, err)
+	}
+
+	hostnames, err := hostnameResolution(req.ResolveHostnames, req.ResolveFamily)
+	if err != nil {
+		return writeLocalizedStatusError(c, fiber.StatusBadRequest, "planet.invalid_input", err.Error())
+	}
+
+	generatedMoon, err := mkworld.GenerateMoon(&mkworld.MoonOptions{
+		Hostnames:      hostnames,
+		SkipValidation: req.SkipValidation,
+		RootNodes:      toRootNodeConfigs(req.RootNodes),
+		SigningKeyPath: signingKeyDir,
+		Timestamp:      req.Timestamp,
+	})
+	if err != nil {
+		if isWorldInputError(err) {
+			return writeLocalizedStatusError(c, fiber.StatusBadRequest, "planet.invalid_input", err.Error())
+		}
+		logger.Error("failed to generate moon", zap.Error(err))
+		return writeLocalizedStatusError(c, fiber.StatusInternalServerError, "planet.moon_generation_failed", "Failed to generate moon")
+	}
+
+	return c.JSON(GenerateMoonResponse{
+		Message:       i18n.Text(i18n.LocaleFrom(c), "planet.moon_generated"),
+		MoonData:      generatedMoon.MoonData,
+		MoonID:        fmt.Sprintf("%016x", generatedMoon.MoonID),
+		Timestamp:     generatedMoon.Timestamp,
+		FileName:      generatedMoon.FileName,
+		RootNodeCount: generatedMoon.RootNodeCount,
+		EndpointCount: generatedMoon.EndpointCount,
+		SigningKeys:   string(generatedMoon.SigningKeys),
+		SigningKeyDir: signingKeyDir,
 	})
 }
 
+// InspectPlanetHandler decodes the planet file in the ZeroTier directory given by ?path
+func InspectPlanetHandler(c fiber.Ctx) error {
+	ztPath := c.Query("path", defaultZTPath)
+	safePath, err := sanitizeZTPath(ztPath)
+	if err != nil {
+		return writeInvalidZTPath(c, err)
+	}
+	planetPath := filepath.Join(safePath, "planet")
+
+	data, err := os.ReadFile(planetPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return writeLocalizedStatusError(c, fiber.StatusNotFound, "planet.file_not_found", fmt.Sprintf("planet not found at %s", planetPath))
+		}
+		logger.Error("failed to read planet", zap.String("path", planetPath), zap.Error(err))
+		return writeLocalizedStatusError(c, fiber.StatusInternalServerError, "planet.read_failed", "Failed to read planet")
+	}
+	return writeInspectedWorld(c, data)
+}
+
+// InspectUploadedWorldHandler decodes a planet or moon file uploaded as the multipart field
+// "file" or sent base64-encoded in a JSON body
+func InspectUploadedWorldHandler(c fiber.Ctx) error {
+	var data []byte
+	if strings.HasPrefix(c.Get(fiber.HeaderContentType), fiber.MIMEMultipartForm) {
+		fileHeader, err := c.FormFile("file")
+		if err != nil {
+			return writeLocalizedStatusError(c, fiber.StatusBadRequest, "request.field_required", "file is required")
+		}
+		if fileHeader.Size > mkworld.ZT_WORLD_MAX_SERIALIZED_LENGTH {
+			return writeLocalizedStatusError(c, fiber.StatusBadRequest, "planet.invalid_world", mkworld.ErrSerializedDataTooLarge.Error())
+		}
+		file, err := fileHeader.Open()
+		if err != nil {
+			return writeLocalizedStatusError(c, fiber.StatusBadRequest, "planet.upload_read_failed", "Failed to read uploaded file")
+		}
+		defer file.Close()
+		if data, err = io.ReadAll(file); err != nil {
+			return writeLocalizedStatusError(c, fiber.StatusBadRequest, "planet.upload_read_failed", "Failed to read uploaded file")
+		}
+	} else {
+		var req InspectWorldRequest
+		if err := c.Bind().JSON(&req); err != nil {
+			return writeBindError(c, err)
+		}
+		data = req.PlanetData
+	}
+
+	if len(data) == 0 {
+		return writeLocalizedStatusError(c, fiber.StatusBadRequest, "request.field_required", "planet_data is required")
+	}
+	return writeInspectedWorld(c, data)
+}
+
+func writeInspectedWorld(c fiber.Ctx, data []byte) error {
+	inspected, err := mkworld.InspectWorld(data)
+	if err != nil {
+		var formatErr *mkworld.WorldFormatError
+		if errors.As(err, &formatErr) {
+			return writeErrorResponseWithExtra(c, apierror.FromStatus(fiber.StatusBadRequest, err.Error()).Translate("planet.invalid_world"), fiber.Map{
+				"offset": formatErr.Offset,
+			})
+		}
+		return writeLocalizedStatusError(c, fiber.StatusBadRequest, "planet.invalid_world", err.Error())
+	}
+
+	rootNodes := make([]PlanetRootNodeRequest, 0, len(inspected.RootNodes))
+	for _, rootNode := range inspected.RootNodes {
+		rootNodes = append(rootNodes, PlanetRootNodeRequest{
+			IdentityPublic: rootNode.IdentityPublic,
+			Endpoints:      rootNode.Endpoints,
+		})
+	}
+
+	return c.JSON(InspectWorldResponse{
+		Message:               i18n.Text(i18n.LocaleFrom(c), "planet.world_decoded"),
+		Type:                  inspected.Type.String(),
+		ID:                    inspected.ID,
+		Timestamp:             inspected.Timestamp,
+		UpdatesMustBeSignedBy: hex.EncodeToString(inspected.UpdatesMustBeSignedBy[:]),
+		Signature:             hex.EncodeToString(inspected.Signature[:]),
+		RootNodes:             rootNodes,
+		RootNodeCount:         len(rootNodes),
+		EndpointCount:         inspected.EndpointCount,
+	})
+}
+
+// resolveSigningKeyDir picks the signing key directory of a generate request. Keys may be
+// written there, so it has to be inside the ZeroTier directory; empty means throwaway keys.
+func resolveSigningKeyDir(dir string) (string, error) {
+	dir = strings.TrimSpace(dir)
+	if dir == "" {
+		return "", nil
+	}
+	return sanitizeZTPath(dir)
+}
+
+// hostnameResolution returns how hostname endpoints are resolved; nil when they are not allowed
+func hostnameResolution(resolve *bool, family string) (*mkworld.HostnameResolution, error) {
+	if resolve != nil && !*resolve {
+		return nil, nil
+	}
+	resolveFamily, err := mkworld.ParseResolveFamily(family)
+	if err != nil {
+		return nil, err
+	}
+	return &mkworld.HostnameResolution{Family: resolveFamily, Lookup: lookupHostname}, nil
+}
+
+func toRootNodeConfigs(requests []PlanetRootNodeRequest) []mkworld.RootNodeConfig {
+	rootNodes := make([]mkworld.RootNodeConfig, 0, len(requests))
+	for _, rootNode := range requests {
+		rootNodes = append(rootNodes, mkworld.RootNodeConfig{
+			IdentityPublic: strings.TrimSpace(rootNode.IdentityPublic),
+			Comments:       strings.TrimSpace(rootNode.Comments),
+			Endpoints:      rootNode.Endpoints,
+		})
+	}
+	return rootNodes
+}
+
+// isWorldInputError reports whether err was caused by the planet or moon request itself
+func isWorldInputError(err error) bool {
+	for _, target := range []error{
+		mkworld.ErrIdentityPublicRequired,
+		mkworld.ErrNoRootNodes,
+		mkworld.ErrNoEndpoints,
+		mkworld.ErrInvalidIdentity,
+		mkworld.ErrInvalidEndpoint,
+		mkworld.ErrDuplicateEndpoint,
+		mkworld.ErrDuplicateIdentity,
+		mkworld.ErrMaxEndpointsExceeded,
+		mkworld.ErrMaxRootNodesExceeded,
+		mkworld.ErrReservedPlanetID,
+		mkworld.ErrInvalidBirthTime,
+		mkworld.ErrInvalidSigningKeys,
+		mkworld.ErrUnresolvableHostname,
+		mkworld.ErrIdentityMismatch,
+	} {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+type ValidateIdentityRequest struct {
+	IdentityPublic string `json:"identityPublic"`
+}
+
+// ValidateIdentityResponse tells whether an identity can serve as a root; Error explains why not
+type ValidateIdentityResponse struct {
+	Valid   bool   `json:"valid"`
+	Address string `json:"address,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+// ValidateIdentityHandler checks an identity.public the way nodes check the roots of a world.
+// An invalid identity is still a successful check, so the page can validate as the user types.
+func ValidateIdentityHandler(c fiber.Ctx) error {
+	var req ValidateIdentityRequest
+	if err := c.Bind().JSON(&req); err != nil {
+		return writeBindError(c, err)
+	}
+	if strings.TrimSpace(req.IdentityPublic) == "" {
+		return writeLocalizedStatusError(c, fiber.StatusBadRequest, "request.field_required", mkworld.ErrIdentityPublicRequired.Error())
+	}
+
+	identity, err := mkworld.ParseIdentityPublic(strings.TrimSpace(req.IdentityPublic))
+	if err != nil {
+		return c.JSON(ValidateIdentityResponse{Error: err.Error()})
+	}
+	response := ValidateIdentityResponse{Valid: true, Address: identity.ZtNodeAddressString()}
+	if err := mkworld.ValidateIdentity(identity); err != nil {
+		response.Valid = false
+		response.Error = err.Error()
+	}
+	return c.JSON(response)
+}
+
 func GetIdentityHandler(c fiber.Ctx) error {
 	ztPath := c.Query("path", defaultZTPath)
 	safePath, err := sanitizeZTPath(ztPath)
 	if err != nil {
-		return writeErrorResponse(c, fiber.StatusBadRequest, err.Error())
+		return writeInvalidZTPath(c, err)
 	}
 	identityPath := filepath.Join(safePath, "identity.public")
 
 	identityPublic, err := os.ReadFile(identityPath)
 	if err != nil {
 		if os.IsNotExist(err) {
-			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
-				"error":         fmt.Sprintf("identity.public not found at %s", identityPath),
-				"identity_path": identityPath,
+			return writeErrorResponseWithExtra(c, apierror.FromStatus(fiber.StatusNotFound, fmt.Sprintf("identity.public not found at %s", identityPath)).Translate("planet.file_not_found"), fiber.Map{
+				"identityPath": identityPath,
 			})
 		}
 		logger.Error("failed to read identity.public", zap.String("path", identityPath), zap.Error(err))
-		return writeErrorResponse(c, fiber.StatusInternalServerError, "Failed to read identity.public")
+		return writeLocalizedStatusError(c, fiber.StatusInternalServerError, "planet.identity_read_failed", "Failed to read identity.public")
 	}
 
 	return c.JSON(IdentityInfoResponse{
-		Message:        "Identity read successfully",
+		Message:        i18n.Text(i18n.LocaleFrom(c), "planet.identity_read"),
 		IdentityPublic: strings.TrimSpace(string(identityPublic)),
 		IdentityPath:   identityPath,
 	})
@@ -173,7 +548,7 @@ func GetSigningKeysInfoHandler(c fiber.Ctx) error {
 	ztPath := c.Query("path", defaultZTPath)
 	safePath, err := sanitizeZTPath(ztPath)
 	if err != nil {
-		return writeErrorResponse(c, fiber.StatusBadRequest, err.Error())
+		return writeInvalidZTPath(c, err)
 	}
 	prevPath := filepath.Join(safePath, "previous.c25519")
 	curPath := filepath.Join(safePath, "current.c25519")
@@ -182,17 +557,17 @@ func GetSigningKeysInfoHandler(c fiber.Ctx) error {
 	_, curErr := os.Stat(curPath)
 	if prevErr != nil && !os.IsNotExist(prevErr) {
 		logger.Error("failed to inspect previous signing key", zap.String("path", prevPath), zap.Error(prevErr))
-		return writeErrorResponse(c, fiber.StatusInternalServerError, "Failed to inspect previous signing key")
+		return writeLocalizedStatusError(c, fiber.StatusInternalServerError, "planet.signing_key_inspect_failed", "Failed to inspect previous signing key")
 	}
 	if curErr != nil && !os.IsNotExist(curErr) {
 		logger.Error("failed to inspect current signing key", zap.String("path", curPath), zap.Error(curErr))
-		return writeErrorResponse(c, fiber.StatusInternalServerError, "Failed to inspect current signing key")
+		return writeLocalizedStatusError(c, fiber.StatusInternalServerError, "planet.signing_key_inspect_failed", "Failed to inspect current signing key")
 	}
 	prevExists := prevErr == nil
 	curExists := curErr == nil
 
 	return c.JSON(SigningKeysInfoResponse{
-		Message:         "Signing key status loaded successfully",
+		Message:         i18n.Text(i18n.LocaleFrom(c), "planet.signing_keys_loaded"),
 		SigningKeyPath:  ztPath,
 		PreviousKeyPath: prevPath,
 		CurrentKeyPath:  curPath,
@@ -206,7 +581,7 @@ func GenerateSigningKeysHandler(c fiber.Ctx) error {
 	ztPath := c.Query("path", defaultZTPath)
 	safePath, err := sanitizeZTPath(ztPath)
 	if err != nil {
-		return writeErrorResponse(c, fiber.StatusBadRequest, err.Error())
+		return writeInvalidZTPath(c, err)
 	}
 	prevPath := filepath.Join(safePath, "previous.c25519")
 	curPath := filepath.Join(safePath, "current.c25519")
@@ -214,11 +589,11 @@ func GenerateSigningKeysHandler(c fiber.Ctx) error {
 	err = mkworld.CreateSigningKeys(prevPath, curPath)
 	if err != nil {
 		logger.Error("failed to generate signing keys", zap.String("path", ztPath), zap.Error(err))
-		return writeErrorResponse(c, fiber.StatusInternalServerError, "Failed to generate signing keys")
+		return writeLocalizedStatusError(c, fiber.StatusInternalServerError, "planet.signing_keys_generation_failed", "Failed to generate signing keys")
 	}
 
 	return c.JSON(GenerateSigningKeysResponse{
-		Message:         "Signing keys generated successfully",
+		Message:         i18n.Text(i18n.LocaleFrom(c), "planet.signing_keys_generated"),
 		SigningKeyPath:  ztPath,
 		PreviousKeyPath: prevPath,
 		CurrentKeyPath:  curPath,
@@ -2,6 +2,9 @@ package handlers
 
 import (
 	"encoding/json"
+	"fmt"
+	"io"
+	"net"
 	"net/http"
 	"net/http/httptest"
 	"os"
@@ -9,6 +12,7 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/GT-610/tairitsu/internal/app/apierror"
 	"github.com/gofiber/fiber/v3"
 )
 
@@ -40,10 +44,10 @@ func TestGetIdentityHandler_ReadsIdentityPublic(t *testing.T) {
 		t.Fatalf("decode response: %v", err)
 	}
 	if body.IdentityPublic == "" {
-		t.Fatalf("expected identity_public in response")
+		t.Fatalf("expected identityPublic in response")
 	}
 	if body.IdentityPath != identityPath {
-		t.Fatalf("identity_path = %q, want %q", body.IdentityPath, identityPath)
+		t.Fatalf("identityPath = %q, want %q", body.IdentityPath, identityPath)
 	}
 }
 
@@ -65,23 +69,23 @@ func TestGetIdentityHandler_ReturnsNotFoundForMissingIdentity(t *testing.T) {
 		t.Fatalf("status = %d, want %d", resp.StatusCode, fiber.StatusNotFound)
 	}
 
-	var body map[string]string
+	var body map[string]any
 	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
 		t.Fatalf("decode response: %v", err)
 	}
-	if body["error"] == "" {
-		t.Fatalf("expected error body")
+	if body["message"] == "" || body["errorCode"] != "http.not_found" {
+		t.Fatalf("expected error envelope, got %v", body)
 	}
-	if !strings.HasSuffix(body["identity_path"], "/identity.public") {
-		t.Fatalf("identity_path = %q, want suffix /identity.public", body["identity_path"])
+	if identityPath, _ := body["identityPath"].(string); !strings.HasSuffix(identityPath, "/identity.public") {
+		t.Fatalf("identityPath = %q, want suffix /identity.public", body["identityPath"])
 	}
 }
 
 func TestGeneratePlanetHandler_ReturnsPlanetDataAndMetadata(t *testing.T) {
 	app := fiber.New()
-	app.Post("/planet", GeneratePlanetHandler)
+	app.Post("/planet", NewPlanetHandler(nil).GeneratePlanet)
 
-	body := `{"root_nodes":[{"identity_public":"f76fd3000b:0:542c89e34a369c2281ed940d05beeffdbaa66930f17b875e9172e43d0ba30b6a39708507f4d64e66cde4a1040d2a995d01209d685ca6c4adb4a5c880af1e9715","endpoints":["203.0.113.1/9993"],"comments":"test"}],"recommend_values":true,"download_name":"planet.custom"}`
+	body := `{"rootNodes":[{"identityPublic":"f76fd3000b:0:542c89e34a369c2281ed940d05beeffdbaa66930f17b875e9172e43d0ba30b6a39708507f4d64e66cde4a1040d2a995d01209d685ca6c4adb4a5c880af1e9715","endpoints":["203.0.113.1/9993"],"comments":"test"}],"recommendValues":true,"downloadName":"planet.custom"}`
 	req := httptest.NewRequest(http.MethodPost, "/planet", strings.NewReader(body))
 	req.Header.Set("Content-Type", "application/json")
 
@@ -98,33 +102,33 @@ func TestGeneratePlanetHandler_ReturnsPlanetDataAndMetadata(t *testing.T) {
 		t.Fatalf("decode response: %v", err)
 	}
 	if result.PlanetID == 0 {
-		t.Fatalf("planet_id = 0, want non-zero")
+		t.Fatalf("planetId = 0, want non-zero")
 	}
 	if result.BirthTime <= 0 {
-		t.Fatalf("birth_time = %d, want positive value", result.BirthTime)
+		t.Fatalf("birthTime = %d, want positive value", result.BirthTime)
 	}
 	if len(result.PlanetData) == 0 {
-		t.Fatalf("planet_data is empty")
+		t.Fatalf("planetData is empty")
 	}
 	if result.DownloadName != "planet.custom" {
-		t.Fatalf("download_name = %q, want planet.custom", result.DownloadName)
+		t.Fatalf("downloadName = %q, want planet.custom", result.DownloadName)
 	}
 	if result.RootNodeCount != 1 {
-		t.Fatalf("root_node_count = %d, want 1", result.RootNodeCount)
+		t.Fatalf("rootNodeCount = %d, want 1", result.RootNodeCount)
 	}
 	if result.EndpointCount != 1 {
-		t.Fatalf("endpoint_count = %d, want 1", result.EndpointCount)
+		t.Fatalf("endpointCount = %d, want 1", result.EndpointCount)
 	}
 	if !result.UsedRecommendedValues {
-		t.Fatal("used_recommended_values = false, want true")
+		t.Fatal("usedRecommendedValues = false, want true")
 	}
 }
 
 func TestGeneratePlanetHandler_RejectsDuplicateRootIdentity(t *testing.T) {
 	app := fiber.New()
-	app.Post("/planet", GeneratePlanetHandler)
+	app.Post("/planet", NewPlanetHandler(nil).GeneratePlanet)
 
-	body := `{"root_nodes":[{"identity_public":"f76fd3000b:0:542c89e34a369c2281ed940d05beeffdbaa66930f17b875e9172e43d0ba30b6a39708507f4d64e66cde4a1040d2a995d01209d685ca6c4adb4a5c880af1e9715","endpoints":["203.0.113.1/9993"]},{"identity_public":"f76fd3000b:0:542c89e34a369c2281ed940d05beeffdbaa66930f17b875e9172e43d0ba30b6a39708507f4d64e66cde4a1040d2a995d01209d685ca6c4adb4a5c880af1e9715","endpoints":["203.0.113.2/9993"]}],"recommend_values":true}`
+	body := `{"rootNodes":[{"identityPublic":"f76fd3000b:0:542c89e34a369c2281ed940d05beeffdbaa66930f17b875e9172e43d0ba30b6a39708507f4d64e66cde4a1040d2a995d01209d685ca6c4adb4a5c880af1e9715","endpoints":["203.0.113.1/9993"]},{"identityPublic":"f76fd3000b:0:542c89e34a369c2281ed940d05beeffdbaa66930f17b875e9172e43d0ba30b6a39708507f4d64e66cde4a1040d2a995d01209d685ca6c4adb4a5c880af1e9715","endpoints":["203.0.113.2/9993"]}],"recommendValues":true}`
 	req := httptest.NewRequest(http.MethodPost, "/planet", strings.NewReader(body))
 	req.Header.Set("Content-Type", "application/json")
 
@@ -137,6 +141,76 @@ func TestGeneratePlanetHandler_RejectsDuplicateRootIdentity(t *testing.T) {
 	}
 }
 
+func TestGeneratePlanetHandler_ReportsCreatedThenReusedSigningKeys(t *testing.T) {
+	tempDir := t.TempDir()
+	origBase := allowedBasePath
+	allowedBasePath = tempDir
+	defer func() { allowedBasePath = origBase }()
+
+	app := fiber.New()
+	app.Post("/planet", NewPlanetHandler(nil).GeneratePlanet)
+
+	body := `{"rootNodes":[{"identityPublic":"f76fd3000b:0:542c89e34a369c2281ed940d05beeffdbaa66930f17b875e9172e43d0ba30b6a39708507f4d64e66cde4a1040d2a995d01209d685ca6c4adb4a5c880af1e9715","endpoints":["203.0.113.1/9993"]}],"recommendValues":true,"signingKeyDir":"` + tempDir + `"}`
+	for _, want := range []string{"created", "reused"} {
+		req := httptest.NewRequest(http.MethodPost, "/planet", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatalf("app.Test() error = %v", err)
+		}
+		if resp.StatusCode != fiber.StatusOK {
+			t.Fatalf("status = %d, want %d", resp.StatusCode, fiber.StatusOK)
+		}
+
+		var result GeneratePlanetResponse
+		if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+			t.Fatalf("decode response: %v", err)
+		}
+		if result.SigningKeys != want {
+			t.Fatalf("signingKeys = %q, want %q", result.SigningKeys, want)
+		}
+		if result.SigningKeyDir != tempDir {
+			t.Fatalf("signingKeyDir = %q, want %q", result.SigningKeyDir, tempDir)
+		}
+	}
+}
+
+func TestGeneratePlanetHandler_RejectsSigningKeyDirOutsideZeroTier(t *testing.T) {
+	origBase := allowedBasePath
+	allowedBasePath = t.TempDir()
+	defer func() { allowedBasePath = origBase }()
+
+	app := fiber.New()
+	app.Post("/planet", NewPlanetHandler(nil).GeneratePlanet)
+
+	outside := t.TempDir()
+	body := `{"rootNodes":[{"identityPublic":"f76fd3000b:0:542c89e34a369c2281ed940d05beeffdbaa66930f17b875e9172e43d0ba30b6a39708507f4d64e66cde4a1040d2a995d01209d685ca6c4adb4a5c880af1e9715","endpoints":["203.0.113.1/9993"]}],"recommendValues":true,"signingKeyDir":"` + outside + `"}`
+	req := httptest.NewRequest(http.MethodPost, "/planet", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := app.Test(req)
+	if err != nil {
+		t.Fatalf("app.Test() error = %v", err)
+	}
+	if resp.StatusCode != fiber.StatusBadRequest {
+		t.Fatalf("status = %d, want %d", resp.StatusCode, fiber.StatusBadRequest)
+	}
+
+	raw, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("read response: %v", err)
+	}
+	var response apierror.Response
+	if err := json.Unmarshal(raw, &response); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if response.ErrorCode != apierror.CodePlanetInvalidPath {
+		t.Fatalf("errorCode = %q, want %q", response.ErrorCode, apierror.CodePlanetInvalidPath)
+	}
+	if strings.Contains(string(raw), outside) {
+		t.Fatalf("response %s echoes the rejected path", raw)
+	}
+}
+
 func TestGetSigningKeysInfoHandler_ReturnsStatus(t *testing.T) {
 	tempDir := t.TempDir()
 	origBase := allowedBasePath
@@ -172,6 +246,59 @@ func TestGetSigningKeysInfoHandler_ReturnsStatus(t *testing.T) {
 		t.Fatal("Ready = false, want true")
 	}
 	if body.SigningKeyPath != tempDir {
-		t.Fatalf("signing_key_path = %q, want %q", body.SigningKeyPath, tempDir)
+		t.Fatalf("signingKeyPath = %q, want %q", body.SigningKeyPath, tempDir)
+	}
+}
+
+func TestGeneratePlanetHandler_ResolvesHostnameEndpoints(t *testing.T) {
+	origLookup := lookupHostname
+	lookupHostname = func(host string) ([]net.IP, error) {
+		if host != "root1.example.com" {
+			return nil, fmt.Errorf("lookup %s: no such host", host)
+		}
+		return []net.IP{net.ParseIP("203.0.113.10"), net.ParseIP("2001:db8::10")}, nil
+	}
+	defer func() { lookupHostname = origLookup }()
+
+	app := fiber.New()
+	app.Post("/planet", NewPlanetHandler(nil).GeneratePlanet)
+
+	root := `{"identityPublic":"f76fd3000b:0:542c89e34a369c2281ed940d05beeffdbaa66930f17b875e9172e43d0ba30b6a39708507f4d64e66cde4a1040d2a995d01209d685ca6c4adb4a5c880af1e9715","endpoints":["%s/9993"]}`
+	testCases := []struct {
+		name          string
+		host          string
+		options       string
+		status        int
+		endpointCount int
+	}{
+		{name: "both families by default", host: "root1.example.com", status: fiber.StatusOK, endpointCount: 2},
+		{name: "ipv4 only", host: "root1.example.com", options: `,"resolveFamily":"ipv4"`, status: fiber.StatusOK, endpointCount: 1},
+		{name: "unresolved hostname", host: "gone.example.com", status: fiber.StatusBadRequest},
+		{name: "resolving disabled", host: "root1.example.com", options: `,"resolveHostnames":false`, status: fiber.StatusBadRequest},
+		{name: "unknown family", host: "root1.example.com", options: `,"resolveFamily":"ipv5"`, status: fiber.StatusBadRequest},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			body := `{"rootNodes":[` + fmt.Sprintf(root, tc.host) + `],"recommendValues":true` + tc.options + `}`
+			req := httptest.NewRequest(http.MethodPost, "/planet", strings.NewReader(body))
+			req.Header.Set("Content-Type", "application/json")
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Fatalf("app.Test() error = %v", err)
+			}
+			if resp.StatusCode != tc.status {
+				t.Fatalf("status = %d, want %d", resp.StatusCode, tc.status)
+			}
+			if tc.status != fiber.StatusOK {
+				return
+			}
+			var result GeneratePlanetResponse
+			if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+				t.Fatalf("decode response: %v", err)
+			}
+			if result.EndpointCount != tc.endpointCount {
+				t.Fatalf("endpointCount = %d, want %d", result.EndpointCount, tc.endpointCount)
+			}
+		})
 	}
 }
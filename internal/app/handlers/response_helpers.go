@@ -1,49 +1,95 @@
 package handlers
 
 import (
-	"strings"
-
-	"github.com/GT-610/tairitsu/internal/app/httpcode"
+	"github.com/GT-610/tairitsu/internal/app/apierror"
+	"github.com/GT-610/tairitsu/internal/app/i18n"
+	"github.com/GT-610/tairitsu/internal/app/logger"
 	"github.com/gofiber/fiber/v3"
+	"go.uber.org/zap"
 )
 
-func writeErrorResponse(c fiber.Ctx, status int, message string) error {
-	return writeErrorResponseWithCode(c, status, httpcode.DefaultErrorCode(status), message)
+// writeLocalizedError answers with the message of code in the request's language
+func writeLocalizedError(c fiber.Ctx, status int, code string) error {
+	return apierror.Write(c, apierror.Localized(status, code))
+}
+
+// writeLocalizedErrorMessage answers with message in the default language and the message of
+// code in others, keeping a more specific message as the detail
+func writeLocalizedErrorMessage(c fiber.Ctx, status int, code string, message string) error {
+	return apierror.Write(c, apierror.New(status, code, message).Translate(code))
 }
 
-func writeErrorResponseWithCode(c fiber.Ctx, status int, code string, message string) error {
-	return c.Status(status).JSON(fiber.Map{
-		"message":    message,
-		"error_code": code,
-		"code":       status,
-	})
+// writeLocalizedStatusError is writeLocalizedErrorMessage for errors whose code is derived
+// from the status; key names the translated message
+func writeLocalizedStatusError(c fiber.Ctx, status int, key string, message string) error {
+	return apierror.Write(c, apierror.FromStatus(status, message).Translate(key))
+}
+
+// writeErrorResponseWithExtra adds endpoint-specific fields, such as a parse position, to
+// the error envelope. Extra fields never replace envelope fields.
+func writeErrorResponseWithExtra(c fiber.Ctx, apiErr *apierror.Error, extra fiber.Map) error {
+	envelope := apiErr.Body(c)
+	body := fiber.Map{
+		"message":   envelope.Message,
+		"errorCode": envelope.ErrorCode,
+		"code":      envelope.Code,
+	}
+	if envelope.RequestID != "" {
+		body["requestId"] = envelope.RequestID
+	}
+	if envelope.Detail != "" {
+		body["detail"] = envelope.Detail
+	}
+	for key, value := range extra {
+		if _, reserved := body[key]; reserved || key == "fields" {
+			continue
+		}
+		body[key] = value
+	}
+	return c.Status(apiErr.Status).JSON(body)
 }
 
-// writeErrorResponseWithDetail returns an error response that includes a
-// client-safe detail string. Use this instead of writeErrorResponseWithCode
-// when callers need to surface a sanitized explanation (e.g. a human-readable
-// setup error code). Never pass raw err.Error()—callers must strip internal
-// paths, stack traces, and wrapped causes before calling this function.
-func writeErrorResponseWithDetail(c fiber.Ctx, status int, code string, message string, detail string) error {
-	detail = strings.TrimSpace(detail)
-	if len(detail) > 256 {
-		detail = detail[:256]
+// writeSanitizedError answers with apiErr and logs err with the request ID instead of
+// sending it, since err may hold SQL, file paths or controller responses. The text of an
+// error marked with apierror.UserFacing replaces the message.
+func writeSanitizedError(c fiber.Ctx, apiErr *apierror.Error, logMessage string, err error, fields ...zap.Field) error {
+	logger.WithRequestID(c).Error(logMessage, append(fields, zap.Error(err))...)
+	if message, ok := apierror.UserMessage(err); ok {
+		apiErr = apierror.New(apiErr.Status, apiErr.Code, message).WithDetail(apiErr.Detail)
 	}
-	return c.Status(status).JSON(fiber.Map{
-		"message":    message,
-		"error_code": code,
-		"code":       status,
-		"detail":     detail,
-	})
+	return apierror.Write(c, apiErr)
+}
+
+// writeInternalError answers an unexpected failure with a generic 500
+func writeInternalError(c fiber.Ctx, logMessage string, err error, fields ...zap.Field) error {
+	return writeSanitizedError(c, apierror.Localized(fiber.StatusInternalServerError, apierror.CodeSystemInternalError), logMessage, err, fields...)
+}
+
+// writeBindError answers a request body that could not be decoded, naming the offending
+// field instead of echoing the decoder error
+func writeBindError(c fiber.Ctx, err error) error {
+	return writeBindErrorWithCode(c, apierror.CodeInvalidBody, err)
+}
+
+// writeBindErrorWithCode is writeBindError for endpoints that already answer bad bodies with
+// their own code
+func writeBindErrorWithCode(c fiber.Ctx, code string, err error) error {
+	return apierror.Write(c, apierror.Bind(code, err))
+}
+
+// writeValidationError answers a rejected request; apierror.FieldError values in err are
+// listed per field
+func writeValidationError(c fiber.Ctx, err error) error {
+	return apierror.Write(c, apierror.Validation(err))
 }
 
 func writeMessageResponse(c fiber.Ctx, status int, code string, message string, extra fiber.Map) error {
 	body := fiber.Map{
-		"message":      message,
-		"message_code": code,
+		"message":     message,
+		"messageCode": code,
 	}
 	for key, value := range extra {
-		if key == "message" || key == "message_code" {
+		if key == "message" || key == "messageCode" {
 			continue
 		}
 		body[key] = value
@@ -51,10 +97,18 @@ func writeMessageResponse(c fiber.Ctx, status int, code string, message string,
 	return c.Status(status).JSON(body)
 }
 
+// writeLocalizedMessage is writeMessageResponse with the message of code in the request's
+// language
+func writeLocalizedMessage(c fiber.Ctx, status int, code string, extra fiber.Map) error {
+	return writeMessageResponse(c, status, code, i18n.Text(i18n.LocaleFrom(c), code), extra)
+}
+
+// requiredUserID returns the authenticated user, or an error for the handler to return; writing
+// the response here would leave the handler nothing to stop on
 func requiredUserID(c fiber.Ctx) (string, error) {
 	userID, _ := c.Locals("user_id").(string)
 	if userID == "" {
-		return "", writeErrorResponseWithCode(c, fiber.StatusUnauthorized, "auth.unauthorized", "Unauthorized access")
+		return "", apierror.Localized(fiber.StatusUnauthorized, apierror.CodeAuthUnauthorized)
 	}
 	return userID, nil
 }
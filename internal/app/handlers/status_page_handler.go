@@ -0,0 +1,108 @@
+package handlers
+
+import (
+	"bytes"
+	"errors"
+	"html/template"
+	"strings"
+
+	"github.com/GT-610/tairitsu/internal/app/apierror"
+	"github.com/GT-610/tairitsu/internal/app/logger"
+	"github.com/GT-610/tairitsu/internal/app/services"
+	"github.com/gofiber/fiber/v3"
+	"go.uber.org/zap"
+)
+
+var statusPageTemplate = template.Must(template.New("status-page").Parse(`<!DOCTYPE html>
+<html>
+<head>
+	<meta charset="utf-8">
+	<title>{{.Title}}</title>
+	<style>
+		body { margin: 20px; color: #333; font-family: sans-serif; }
+		h1 { color: #4a6fa5; }
+		.ok { color: #2e7d32; }
+		.degraded { color: #ed6c02; }
+		.down { color: #c62828; }
+		td, th { padding: 4px 12px; text-align: left; }
+	</style>
+</head>
+<body>
+	{{if .LogoText}}<p><strong>{{.LogoText}}</strong></p>{{end}}
+	<h1>{{.Title}}</h1>
+	<p>Overall status: <span class="{{.Status}}">{{.Status}}</span></p>
+	<table>
+		<tr><th>Component</th><th>Status</th></tr>
+		{{range $name, $status := .Components}}<tr><td>{{$name}}</td><td class="{{$status}}">{{$status}}</td></tr>
+		{{end}}
+	</table>
+	{{if .Networks}}
+	<table>
+		<tr><th>Network</th><th>Online nodes</th></tr>
+		{{range .Networks}}<tr><td>{{.Name}}</td><td>{{.OnlineNodes}} / {{.TotalNodes}}</td></tr>
+		{{end}}
+	</table>
+	{{end}}
+	<p><small>Updated {{.GeneratedAt.UTC.Format "2006-01-02 15:04:05 MST"}}</small></p>
+</body>
+</html>`))
+
+// StatusPageHandler serves the unauthenticated status page and its admin controls
+type StatusPageHandler struct {
+	statusPageService *services.StatusPageService
+}
+
+// NewStatusPageHandler creates a new status page handler instance
+func NewStatusPageHandler(statusPageService *services.StatusPageService) *StatusPageHandler {
+	return &StatusPageHandler{
+		statusPageService: statusPageService,
+	}
+}
+
+// GetStatusPage renders the status page as HTML, or as JSON for ?format=json and JSON Accept headers
+func (h *StatusPageHandler) GetStatusPage(c fiber.Ctx) error {
+	page, err := h.statusPageService.Page(c.Context())
+	if err != nil {
+		if errors.Is(err, services.ErrStatusPageDisabled) {
+			return writeLocalizedError(c, fiber.StatusNotFound, apierror.CodeStatusPageDisabled)
+		}
+		return writeInternalError(c, "Failed to build status page", err)
+	}
+
+	c.Set(fiber.HeaderCacheControl, "public, max-age=30")
+	if c.Query("format") == "json" || strings.Contains(c.Get(fiber.HeaderAccept), fiber.MIMEApplicationJSON) {
+		return c.Status(fiber.StatusOK).JSON(page)
+	}
+
+	var body bytes.Buffer
+	if err := statusPageTemplate.Execute(&body, page); err != nil {
+		return writeInternalError(c, "Failed to render status page", err)
+	}
+	c.Set(fiber.HeaderContentType, fiber.MIMETextHTMLCharsetUTF8)
+	return c.Status(fiber.StatusOK).Send(body.Bytes())
+}
+
+// SetNetworkPublished lists or unlists a network on the status page
+func (h *StatusPageHandler) SetNetworkPublished(c fiber.Ctx) error {
+	id := c.Params("id")
+	if err := validateNetworkID(id); err != nil {
+		return writeValidationError(c, err)
+	}
+
+	var req struct {
+		Published bool `json:"published"`
+	}
+	if err := c.Bind().Body(&req); err != nil {
+		return writeBindError(c, err)
+	}
+
+	if err := h.statusPageService.SetNetworkPublished(id, req.Published); err != nil {
+		logger.Error("Failed to update status page publication", zap.String("network_id", id), zap.Error(err))
+		return writeNetworkServiceError(c, err, "Network not found", "Network access denied")
+	}
+
+	return writeLocalizedMessage(c, fiber.StatusOK, "status_page.network_updated", fiber.Map{
+		"networkId": id,
+		"published": req.Published,
+	})
+}
@@ -0,0 +1,93 @@
+package handlers
+
+import (
+	"errors"
+
+	"github.com/GT-610/tairitsu/internal/app/apierror"
+	"github.com/GT-610/tairitsu/internal/app/logger"
+	"github.com/GT-610/tairitsu/internal/app/services"
+	"github.com/gofiber/fiber/v3"
+	"go.uber.org/zap"
+)
+
+// SystemBackupHandler handles controller-wide backups
+type SystemBackupHandler struct {
+	backupService *services.SystemBackupService
+}
+
+// NewSystemBackupHandler creates a new system backup handler instance
+func NewSystemBackupHandler(backupService *services.SystemBackupService) *SystemBackupHandler {
+	return &SystemBackupHandler{backupService: backupService}
+}
+
+// CreateBackup writes a backup of every network, its members and the Tairitsu-side state
+func (h *SystemBackupHandler) CreateBackup(c fiber.Ctx) error {
+	userID, authErr := requiredUserID(c)
+	if authErr != nil {
+		return authErr
+	}
+
+	backup, err := h.backupService.Backup(services.BackupTriggerManual, userID)
+	if err != nil {
+		return writeSystemBackupError(c, err)
+	}
+	return writeLocalizedMessage(c, fiber.StatusCreated, "backup.created", fiber.Map{"backup": backup})
+}
+
+// ListBackups returns the available backups, newest first
+func (h *SystemBackupHandler) ListBackups(c fiber.Ctx) error {
+	backups, err := h.backupService.List()
+	if err != nil {
+		return writeSystemBackupError(c, err)
+	}
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"backups": backups,
+		"job":     h.backupService.Status(),
+	})
+}
+
+// RestoreBackup restores a backup. The body must repeat the backup name as {"confirm": "<name>"},
+// so a restore is never started by an accidental request.
+func (h *SystemBackupHandler) RestoreBackup(c fiber.Ctx) error {
+	userID, authErr := requiredUserID(c)
+	if authErr != nil {
+		return authErr
+	}
+	name := c.Params("name")
+
+	var req struct {
+		Confirm string `json:"confirm"`
+	}
+	if err := c.Bind().Body(&req); err != nil || req.Confirm != name {
+		return writeLocalizedError(c, fiber.StatusBadRequest, apierror.CodeBackupConfirmationRequired)
+	}
+
+	report, err := h.backupService.Restore(name, userID)
+	if err != nil {
+		return writeSystemBackupError(c, err)
+	}
+	logger.WithRequestID(c).Info("System backup restored", zap.String("name", name), zap.String("user_id", userID))
+	return writeLocalizedMessage(c, fiber.StatusOK, "backup.restored", fiber.Map{"report": report})
+}
+
+func writeSystemBackupError(c fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, services.ErrBackupRunning):
+		return writeLocalizedErrorMessage(c, fiber.StatusConflict, apierror.CodeBackupRunning, err.Error())
+	case errors.Is(err, services.ErrBackupNotFound):
+		return writeLocalizedErrorMessage(c, fiber.StatusNotFound, apierror.CodeBackupNotFound, err.Error())
+	case errors.Is(err, services.ErrBackupInvalid):
+		return writeLocalizedErrorMessage(c, fiber.StatusUnprocessableEntity, apierror.CodeBackupInvalid, err.Error())
+	case errors.Is(err, services.ErrBackupKeyMismatch):
+		return writeLocalizedErrorMessage(c, fiber.StatusUnprocessableEntity, apierror.CodeBackupKeyMismatch, err.Error())
+	case errors.Is(err, services.ErrBackupKeyMissing):
+		return writeLocalizedErrorMessage(c, fiber.StatusServiceUnavailable, apierror.CodeBackupKeyMissing, err.Error())
+	case errors.Is(err, services.ErrBackupNetworkFailed):
+		apiErr := apierror.Localized(fiber.StatusBadGateway, apierror.CodeBackupControllerUnavailable)
+		return writeSanitizedError(c, apiErr, "System backup could not read the controller", err)
+	case services.IsUserDBUnavailable(err):
+		return writeLocalizedError(c, fiber.StatusServiceUnavailable, apierror.CodeUserDBUnavailable)
+	default:
+		return writeInternalError(c, "System backup failed", err)
+	}
+}
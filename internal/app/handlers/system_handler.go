@@ -3,9 +3,13 @@ package handlers
 import (
 	"errors"
 	"net/url"
+	"strconv"
 	"strings"
+	"time"
 
+	"github.com/GT-610/tairitsu/internal/app/apierror"
 	"github.com/GT-610/tairitsu/internal/app/logger"
+	"github.com/GT-610/tairitsu/internal/app/middleware"
 	"github.com/GT-610/tairitsu/internal/app/models"
 	"github.com/GT-610/tairitsu/internal/app/services"
 	"github.com/gofiber/fiber/v3"
@@ -23,14 +27,15 @@ func sanitizeErrorDetail(err error) string {
 }
 
 func setupErrorResponse(c fiber.Ctx, err error) error {
-	code := "system.internal_error"
+	code := apierror.CodeSystemInternalError
 	message := "Internal server error"
 	status := fiber.StatusInternalServerError
 
 	// Determine HTTP status based on error type
 	switch {
 	case errors.Is(err, services.ErrSetupUnsupportedDatabase),
-		errors.Is(err, services.ErrSetupInvalidConfig):
+		errors.Is(err, services.ErrSetupInvalidConfig),
+		errors.Is(err, services.ErrSetupResetNotConfirmed):
 		status = fiber.StatusBadRequest
 	case errors.Is(err, services.ErrSetupDatabaseConnectionFailed),
 		errors.Is(err, services.ErrSetupDatabaseInitialization),
@@ -42,7 +47,8 @@ func setupErrorResponse(c fiber.Ctx, err error) error {
 		errors.Is(err, services.ErrSetupInitializationStateFailed):
 		status = fiber.StatusInternalServerError
 	case errors.Is(err, services.ErrSetupAdminRequired),
-		errors.Is(err, services.ErrSetupAlreadyInitialized):
+		errors.Is(err, services.ErrSetupAlreadyInitialized),
+		errors.Is(err, services.ErrSetupStepOutOfOrder):
 		status = fiber.StatusConflict
 	case errors.Is(err, services.ErrSetupZeroTierUnavailable),
 		errors.Is(err, services.ErrSetupZeroTierValidationFailed),
@@ -53,52 +59,58 @@ func setupErrorResponse(c fiber.Ctx, err error) error {
 	// Map error to code and message
 	switch {
 	case errors.Is(err, services.ErrSetupUnsupportedDatabase):
-		code = "setup.unsupported_database"
+		code = apierror.CodeSetupUnsupportedDatabase
 		message = "Only SQLite is currently supported"
 	case errors.Is(err, services.ErrSetupInvalidConfig):
-		code = "setup.invalid_config"
+		code = apierror.CodeSetupInvalidConfig
 		message = "Setup configuration is incomplete"
 	case errors.Is(err, services.ErrSetupDatabaseConnectionFailed):
-		code = "setup.database_connection_failed"
+		code = apierror.CodeSetupDatabaseConnectionFailed
 		message = "Database connection failed"
 	case errors.Is(err, services.ErrSetupDatabaseInitialization):
-		code = "setup.database_initialization_failed"
+		code = apierror.CodeSetupDatabaseInitializationFailed
 		message = "Database initialization failed"
 	case errors.Is(err, services.ErrSetupDatabaseConfigSaveFailed):
-		code = "setup.database_config_save_failed"
+		code = apierror.CodeSetupDatabaseConfigSaveFailed
 		message = "Failed to save database configuration"
 	case errors.Is(err, services.ErrSetupZeroTierConfigSaveFailed):
-		code = "setup.zerotier_config_save_failed"
+		code = apierror.CodeSetupZerotierConfigSaveFailed
 		message = "Failed to save ZeroTier configuration"
 	case errors.Is(err, services.ErrSetupZeroTierClientCreateFailed):
-		code = "setup.zerotier_client_create_failed"
+		code = apierror.CodeSetupZerotierClientCreateFailed
 		message = "Failed to create ZeroTier client"
 	case errors.Is(err, services.ErrSetupZeroTierValidationFailed):
-		code = "setup.zerotier_validation_failed"
+		code = apierror.CodeSetupZerotierValidationFailed
 		message = "ZeroTier controller validation failed"
 	case errors.Is(err, services.ErrSetupAlreadyInitialized):
-		code = "setup.already_initialized"
+		code = apierror.CodeSetupAlreadyInitialized
 		message = "System is already initialized"
 	case errors.Is(err, services.ErrSetupAdminStateCheckFailed):
-		code = "setup.admin_state_check_failed"
+		code = apierror.CodeSetupAdminStateCheckFailed
 		message = "Failed to confirm administrator state"
 	case errors.Is(err, services.ErrSetupAdminCreationInitFailed):
-		code = "setup.admin_creation_init_failed"
+		code = apierror.CodeSetupAdminCreationInitFailed
 		message = "Failed to initialize administrator account creation"
 	case errors.Is(err, services.ErrSetupDatabaseReopenFailed):
-		code = "setup.database_reopen_failed"
+		code = apierror.CodeSetupDatabaseReopenFailed
 		message = "Failed to reopen configured database"
 	case errors.Is(err, services.ErrSetupInitializationStateFailed):
-		code = "setup.initialization_state_failed"
+		code = apierror.CodeSetupInitializationStateFailed
 		message = "Failed to update initialization state"
 	case errors.Is(err, services.ErrSetupAdminRequired):
-		code = "setup.admin_required"
+		code = apierror.CodeSetupAdminRequired
 		message = "Create the first administrator account first"
 	case errors.Is(err, services.ErrSetupZeroTierUnavailable):
-		code = "setup.zerotier_unavailable"
+		code = apierror.CodeSetupZerotierUnavailable
 		message = "ZeroTier controller is currently unavailable"
+	case errors.Is(err, services.ErrSetupResetNotConfirmed):
+		code = apierror.CodeSetupResetConfirmationRequired
+		message = "Confirm the database reset to continue"
+	case errors.Is(err, services.ErrSetupStepOutOfOrder):
+		code = apierror.CodeSetupStepOutOfOrder
+		message = "Complete the previous setup steps first"
 	}
-	return writeErrorResponseWithDetail(c, status, code, message, sanitizeErrorDetail(err))
+	return apierror.Write(c, apierror.New(status, code, message).Translate(code).WithDetail(sanitizeErrorDetail(err)))
 }
 
 // SystemHandler handles system-related API endpoints and operations
@@ -119,15 +131,28 @@ func NewSystemHandler(
 	}
 }
 
-// GetSystemStatus retrieves the current system status
+// GetSystemStatus retrieves the current system status; ?fresh=true reads the controller status live
 func (h *SystemHandler) GetSystemStatus(c fiber.Ctx) error {
-	status := h.setupService.GetSetupStatus()
+	fresh := false
+	if raw := c.Query("fresh"); raw != "" {
+		parsed, err := strconv.ParseBool(raw)
+		if err != nil {
+			return writeLocalizedStatusError(c, fiber.StatusBadRequest, "request.invalid_boolean", "fresh must be true or false")
+		}
+		fresh = parsed
+	}
+	status := h.setupService.GetSetupStatus(fresh)
 	if status.Initialized && status.ZTStatus != nil && !status.ZTStatus.Online {
 		logger.Debug("[system status] ZeroTier status check failed or is offline")
 	}
 	return c.Status(fiber.StatusOK).JSON(status)
 }
 
+// GetSetupState returns the setup wizard progress and the next step to complete
+func (h *SystemHandler) GetSetupState(c fiber.Ctx) error {
+	return c.Status(fiber.StatusOK).JSON(h.setupService.GetSetupState())
+}
+
 func (h *SystemHandler) GetRuntimeSettings(c fiber.Ctx) error {
 	settings := h.setupService.GetRuntimeSettings()
 	return c.Status(fiber.StatusOK).JSON(settings)
@@ -137,7 +162,7 @@ func (h *SystemHandler) UpdateRuntimeSettings(c fiber.Ctx) error {
 	var req services.RuntimeSettings
 	if err := c.Bind().Body(&req); err != nil {
 		logger.Error("Failed to bind instance settings request", zap.Error(err))
-		return writeErrorResponseWithCode(c, fiber.StatusBadRequest, "system.invalid_request", "Invalid request body")
+		return writeBindErrorWithCode(c, apierror.CodeSystemInvalidRequest, err)
 	}
 
 	if err := h.setupService.UpdateRuntimeSettings(req); err != nil {
@@ -145,7 +170,43 @@ func (h *SystemHandler) UpdateRuntimeSettings(c fiber.Ctx) error {
 		return setupErrorResponse(c, err)
 	}
 
-	return writeMessageResponse(c, fiber.StatusOK, "system.settings_updated", "Instance settings updated successfully", fiber.Map{"settings": req})
+	return writeLocalizedMessage(c, fiber.StatusOK, "system.settings_updated", fiber.Map{"settings": req})
+}
+
+// GetPasswordPolicy returns the password policy; it is public because the setup wizard needs it
+func (h *SystemHandler) GetPasswordPolicy(c fiber.Ctx) error {
+	return c.Status(fiber.StatusOK).JSON(h.setupService.GetPasswordPolicy())
+}
+
+func (h *SystemHandler) UpdatePasswordPolicy(c fiber.Ctx) error {
+	var req services.PasswordPolicy
+	if err := c.Bind().Body(&req); err != nil {
+		logger.Error("Failed to bind password policy request", zap.Error(err))
+		return writeBindErrorWithCode(c, apierror.CodeSystemInvalidRequest, err)
+	}
+
+	policy, err := h.setupService.UpdatePasswordPolicy(req)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidPasswordPolicy) {
+			return writeLocalizedErrorMessage(c, fiber.StatusBadRequest, apierror.CodeSystemInvalidPasswordPolicy, err.Error())
+		}
+		logger.Error("Failed to update password policy", zap.Error(err))
+		return setupErrorResponse(c, err)
+	}
+
+	return writeLocalizedMessage(c, fiber.StatusOK, "system.password_policy_updated", fiber.Map{"policy": policy})
+}
+
+// RotateEncryptionKey replaces the key that encrypts stored credentials
+func (h *SystemHandler) RotateEncryptionKey(c fiber.Ctx) error {
+	if err := h.setupService.RotateEncryptionKey(); err != nil {
+		if errors.Is(err, services.ErrEncryptionKeyExternal) {
+			return writeLocalizedErrorMessage(c, fiber.StatusConflict, apierror.CodeSystemEncryptionKeyExternal, err.Error())
+		}
+		return writeLocalizedError(c, fiber.StatusInternalServerError, apierror.CodeSystemEncryptionKeyRotationFailed)
+	}
+
+	return writeLocalizedMessage(c, fiber.StatusOK, "system.encryption_key_rotated", nil)
 }
 
 // ConfigureDatabase configures the database connection settings
@@ -153,7 +214,7 @@ func (h *SystemHandler) ConfigureDatabase(c fiber.Ctx) error {
 	var dbConfig models.DatabaseConfig
 	if err := c.Bind().Body(&dbConfig); err != nil {
 		logger.Error("Failed to bind database configuration request", zap.Error(err))
-		return writeErrorResponseWithCode(c, fiber.StatusBadRequest, "system.invalid_request", "Invalid request body")
+		return writeBindErrorWithCode(c, apierror.CodeSystemInvalidRequest, err)
 	}
 
 	logger.Info("Configuring database", zap.String("type", string(dbConfig.Type)))
@@ -166,9 +227,7 @@ func (h *SystemHandler) ConfigureDatabase(c fiber.Ctx) error {
 
 	logger.Info("Database configured successfully", zap.String("type", string(dbCfg.Type)))
 
-	return c.Status(fiber.StatusOK).JSON(fiber.Map{
-		"message":      "Database configured successfully",
-		"message_code": "system.database_configured",
+	return writeLocalizedMessage(c, fiber.StatusOK, "system.database_configured", fiber.Map{
 		"config": fiber.Map{
 			"type": dbCfg.Type,
 		},
@@ -180,31 +239,31 @@ func (h *SystemHandler) SaveZeroTierConfig(c fiber.Ctx) error {
 	var req struct {
 		ControllerURL string `json:"controllerUrl"`
 		TokenPath     string `json:"tokenPath"`
+		// Token is given instead of TokenPath when Tairitsu cannot read the controller's files
+		Token string `json:"token"`
 	}
 
 	if err := c.Bind().Body(&req); err != nil {
 		logger.Error("Failed to bind ZeroTier configuration request", zap.Error(err))
-		return writeErrorResponseWithCode(c, fiber.StatusBadRequest, "system.invalid_request", "Invalid request body")
+		return writeBindErrorWithCode(c, apierror.CodeSystemInvalidRequest, err)
 	}
 
-	// Sanitize: log only hostname from URL and whether token path is present
+	// Sanitize: log only hostname from URL and which kind of token was given, never the token
 	controllerHost := "invalid"
 	if u, err := url.Parse(req.ControllerURL); err == nil && u.Host != "" {
 		controllerHost = u.Host
 	}
-	tokenPresent := req.TokenPath != ""
-	logger.Info("Saving ZeroTier configuration", zap.String("controllerHost", controllerHost), zap.Bool("tokenPathPresent", tokenPresent))
+	logger.Info("Saving ZeroTier configuration", zap.String("controllerHost", controllerHost),
+		zap.Bool("tokenPathPresent", req.TokenPath != ""), zap.Bool("inlineTokenPresent", req.Token != ""))
 
-	status, err := h.setupService.SaveZeroTierConfig(req.ControllerURL, req.TokenPath)
+	status, err := h.setupService.SaveZeroTierConfig(req.ControllerURL, req.TokenPath, req.Token)
 	if err != nil {
 		logger.Error("Failed to save ZeroTier configuration", zap.Error(err))
 		return setupErrorResponse(c, err)
 	}
 
 	logger.Info("ZeroTier configuration saved and validated")
-	return c.Status(fiber.StatusOK).JSON(fiber.Map{
-		"message":      "ZeroTier configuration saved successfully",
-		"message_code": "system.zerotier_configured",
+	return writeLocalizedMessage(c, fiber.StatusOK, "system.zerotier_configured", fiber.Map{
 		"config": fiber.Map{
 			"controllerUrl": req.ControllerURL,
 		},
@@ -212,12 +271,46 @@ func (h *SystemHandler) SaveZeroTierConfig(c fiber.Ctx) error {
 	})
 }
 
+// TestZeroTierConnection checks a controller URL and token without saving them. A
+// controller that cannot be reached or refuses the token is reported in the result.
+func (h *SystemHandler) TestZeroTierConnection(c fiber.Ctx) error {
+	var req struct {
+		ControllerURL string `json:"controllerUrl"`
+		TokenPath     string `json:"tokenPath"`
+		Token         string `json:"token"`
+	}
+	if err := c.Bind().Body(&req); err != nil {
+		logger.Error("Failed to bind ZeroTier connection test request", zap.Error(err))
+		return writeBindErrorWithCode(c, apierror.CodeSystemInvalidRequest, err)
+	}
+
+	check, err := h.setupService.TestZeroTierConnection(req.ControllerURL, req.TokenPath, req.Token)
+	if err != nil {
+		logger.Error("Failed to test ZeroTier connection", zap.Error(err))
+		return setupErrorResponse(c, err)
+	}
+	logger.Info("Tested ZeroTier connection", zap.Bool("reachable", check.Reachable),
+		zap.Bool("authenticated", check.Authenticated), zap.Bool("controllerAccess", check.ControllerAccess))
+	return c.JSON(check)
+}
+
 // InitializeAdminCreation prepares the system for admin account creation
 // This function is called when user enters the admin creation step to ensure correct database state
 func (h *SystemHandler) InitializeAdminCreation(c fiber.Ctx) error {
 	logger.Info("Initializing administrator account creation step")
 
-	databaseType, err := h.setupService.InitializeAdminCreation()
+	// The reset wipes the configured database, so the wizard has to ask for it explicitly
+	var req struct {
+		ConfirmReset bool `json:"confirmReset"`
+	}
+	if len(c.Body()) > 0 {
+		if err := c.Bind().Body(&req); err != nil {
+			logger.Error("Failed to bind administrator creation request", zap.Error(err))
+			return writeBindErrorWithCode(c, apierror.CodeSystemInvalidRequest, err)
+		}
+	}
+
+	databaseType, err := h.setupService.InitializeAdminCreation(req.ConfirmReset)
 	if err != nil {
 		logger.Error("Failed to initialize administrator account creation step", zap.Error(err))
 		return setupErrorResponse(c, err)
@@ -225,9 +318,7 @@ func (h *SystemHandler) InitializeAdminCreation(c fiber.Ctx) error {
 
 	logger.Info("Database reset successfully", zap.String("type", databaseType))
 
-	return c.Status(fiber.StatusOK).JSON(fiber.Map{
-		"message":      "Administrator account creation step initialized successfully",
-		"message_code": "system.admin_creation_initialized",
+	return writeLocalizedMessage(c, fiber.StatusOK, "system.admin_creation_initialized", fiber.Map{
 		"resetDone":    true,
 		"databaseType": databaseType,
 	})
@@ -241,7 +332,7 @@ func (h *SystemHandler) SetInitialized(c fiber.Ctx) error {
 
 	if err := c.Bind().Body(&req); err != nil {
 		logger.Error("Failed to bind initialization state request", zap.Error(err))
-		return writeErrorResponseWithCode(c, fiber.StatusBadRequest, "system.invalid_request", "Invalid request body")
+		return writeBindErrorWithCode(c, apierror.CodeSystemInvalidRequest, err)
 	}
 
 	logger.Info("Setting system initialization state", zap.Bool("initialized", req.Initialized))
@@ -251,7 +342,7 @@ func (h *SystemHandler) SetInitialized(c fiber.Ctx) error {
 		return setupErrorResponse(c, err)
 	}
 
-	return writeMessageResponse(c, fiber.StatusOK, "system.initialized_updated", "Initialization state updated successfully", nil)
+	return writeLocalizedMessage(c, fiber.StatusOK, "system.initialized_updated", nil)
 }
 
 // GetSystemStats retrieves system resource usage statistics
@@ -261,8 +352,76 @@ func (h *SystemHandler) GetSystemStats(c fiber.Ctx) error {
 	stats, err := h.systemService.GetSystemStats()
 	if err != nil {
 		logger.Error("Failed to get system stats", zap.Error(err))
-		return writeErrorResponseWithCode(c, fiber.StatusInternalServerError, "system.stats_unavailable", "Unable to retrieve system resource statistics")
+		return writeLocalizedError(c, fiber.StatusInternalServerError, apierror.CodeSystemStatsUnavailable)
 	}
 
 	return c.Status(fiber.StatusOK).JSON(stats)
 }
+
+// GetSystemStatsHistory returns sampled CPU and memory usage of a recent window, for sparklines
+// This endpoint is only accessible to admin users
+func (h *SystemHandler) GetSystemStatsHistory(c fiber.Ctx) error {
+	window := services.DefaultStatsHistoryWindow
+	if raw := c.Query("window"); raw != "" {
+		parsed, err := time.ParseDuration(raw)
+		if err != nil {
+			return writeLocalizedErrorMessage(c, fiber.StatusBadRequest, apierror.CodeSystemStatsHistoryInvalid, "window must be a duration such as 1h or 30m")
+		}
+		window = parsed
+	}
+	points := services.DefaultStatsHistoryPoints
+	if raw := c.Query("points"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil {
+			return writeLocalizedErrorMessage(c, fiber.StatusBadRequest, apierror.CodeSystemStatsHistoryInvalid, "points must be a number")
+		}
+		points = parsed
+	}
+
+	history, err := h.systemService.GetSystemStatsHistory(window, points)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidStatsHistoryQuery) {
+			return writeLocalizedErrorMessage(c, fiber.StatusBadRequest, apierror.CodeSystemStatsHistoryInvalid, "window must be positive and within the kept history, and points between 1 and "+strconv.Itoa(services.MaxStatsHistoryPoints))
+		}
+		logger.Error("Failed to get system stats history", zap.Error(err))
+		return writeLocalizedError(c, fiber.StatusInternalServerError, apierror.CodeSystemStatsUnavailable)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(history)
+}
+
+// GetRateLimits lists the rate limit policies and how many client IPs each currently tracks
+// This endpoint is only accessible to admin users
+func (h *SystemHandler) GetRateLimits(c fiber.Ctx) error {
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"policies": middleware.RateLimitPolicies(),
+	})
+}
+
+// LogLevelRequest Runtime log level change request
+type LogLevelRequest struct {
+	Level string `json:"level"`
+}
+
+// GetLogLevel returns the current minimum log level
+// This endpoint is only accessible to admin users
+func (h *SystemHandler) GetLogLevel(c fiber.Ctx) error {
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{"level": logger.Level()})
+}
+
+// UpdateLogLevel changes the minimum log level until the next restart; the configured
+// logging.level applies again at startup
+// This endpoint is only accessible to admin users
+func (h *SystemHandler) UpdateLogLevel(c fiber.Ctx) error {
+	var req LogLevelRequest
+	if err := c.Bind().Body(&req); err != nil {
+		return writeBindErrorWithCode(c, apierror.CodeSystemInvalidRequest, err)
+	}
+	previous := logger.Level()
+	if err := logger.SetLevel(req.Level); err != nil {
+		return writeLocalizedError(c, fiber.StatusBadRequest, apierror.CodeSystemInvalidLogLevel)
+	}
+
+	logger.WithRequestID(c).Info("Log level changed", zap.String("from", previous), zap.String("to", req.Level))
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{"level": logger.Level()})
+}
@@ -0,0 +1,32 @@
+package handlers
+
+import (
+	"errors"
+
+	"github.com/GT-610/tairitsu/internal/app/apierror"
+	"github.com/GT-610/tairitsu/internal/app/services"
+	"github.com/gofiber/fiber/v3"
+)
+
+// TLSHandler manages the HTTPS certificate
+type TLSHandler struct {
+	certificateService *services.TLSCertificateService
+}
+
+// NewTLSHandler creates a new TLS handler instance
+func NewTLSHandler(certificateService *services.TLSCertificateService) *TLSHandler {
+	return &TLSHandler{certificateService: certificateService}
+}
+
+// ReloadCertificate reads the configured certificate files again, so a renewed certificate
+// is served without a restart
+func (h *TLSHandler) ReloadCertificate(c fiber.Ctx) error {
+	certificate, err := h.certificateService.Reload()
+	if err != nil {
+		if errors.Is(err, services.ErrTLSCertificateNotConfigured) {
+			return writeLocalizedError(c, fiber.StatusConflict, apierror.CodeTLSNotConfigured)
+		}
+		return writeLocalizedError(c, fiber.StatusInternalServerError, apierror.CodeTLSReloadFailed)
+	}
+	return writeLocalizedMessage(c, fiber.StatusOK, "tls.reloaded", fiber.Map{"certificate": certificate})
+}
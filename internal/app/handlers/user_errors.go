@@ -1,6 +1,9 @@
 package handlers
 
 import (
+	"errors"
+
+	"github.com/GT-610/tairitsu/internal/app/apierror"
 	"github.com/GT-610/tairitsu/internal/app/logger"
 	"github.com/GT-610/tairitsu/internal/app/services"
 	"github.com/gofiber/fiber/v3"
@@ -8,40 +11,75 @@ import (
 )
 
 func writeUserServiceError(c fiber.Ctx, err error) error {
+	var policyErr *services.PasswordPolicyError
 	switch {
+	case errors.As(err, &policyErr):
+		return writePasswordPolicyError(c, policyErr)
 	case services.IsUserDBUnavailable(err):
 		logger.Error("User service database is unavailable", zap.Error(err))
-		return writeErrorResponseWithCode(c, fiber.StatusServiceUnavailable, "user.db_unavailable", "User service is unavailable")
+		return writeLocalizedError(c, fiber.StatusServiceUnavailable, apierror.CodeUserDBUnavailable)
 	case services.IsUsernameExists(err):
-		return writeErrorResponseWithCode(c, fiber.StatusBadRequest, "user.username_exists", err.Error())
+		return writeLocalizedErrorMessage(c, fiber.StatusBadRequest, apierror.CodeUserUsernameExists, err.Error())
+	case services.IsEmailExists(err):
+		return writeLocalizedErrorMessage(c, fiber.StatusConflict, apierror.CodeUserEmailExists, err.Error())
+	case services.IsInvalidEmail(err):
+		return writeLocalizedErrorMessage(c, fiber.StatusBadRequest, apierror.CodeUserInvalidEmail, err.Error())
 	case services.IsInvalidUsername(err):
-		return writeErrorResponseWithCode(c, fiber.StatusBadRequest, "user.invalid_username", err.Error())
+		return writeLocalizedErrorMessage(c, fiber.StatusBadRequest, apierror.CodeUserInvalidUsername, err.Error())
 	case services.IsUsernameTooLong(err):
-		return writeErrorResponseWithCode(c, fiber.StatusBadRequest, "user.username_too_long", err.Error())
+		return writeLocalizedErrorMessage(c, fiber.StatusBadRequest, apierror.CodeUserUsernameTooLong, err.Error())
 	case services.IsPasswordTooShort(err), services.IsPasswordTooLong(err):
-		return writeErrorResponseWithCode(c, fiber.StatusBadRequest, "user.invalid_password", err.Error())
+		return writeLocalizedErrorMessage(c, fiber.StatusBadRequest, apierror.CodeUserInvalidPassword, err.Error())
 	case services.IsInvalidCredentials(err):
-		return writeErrorResponseWithCode(c, fiber.StatusUnauthorized, "user.invalid_credentials", err.Error())
+		return writeLocalizedErrorMessage(c, fiber.StatusUnauthorized, apierror.CodeUserInvalidCredentials, err.Error())
 	case services.IsPublicRegistrationDisabled(err):
-		return writeErrorResponseWithCode(c, fiber.StatusForbidden, "user.public_registration_disabled", err.Error())
+		return writeLocalizedErrorMessage(c, fiber.StatusForbidden, apierror.CodeUserPublicRegistrationDisabled, err.Error())
 	case services.IsSessionRevoked(err):
-		return writeErrorResponseWithCode(c, fiber.StatusUnauthorized, "session.revoked", err.Error())
+		return writeLocalizedErrorMessage(c, fiber.StatusUnauthorized, apierror.CodeSessionRevoked, err.Error())
 	case services.IsSessionExpired(err):
-		return writeErrorResponseWithCode(c, fiber.StatusUnauthorized, "session.expired", err.Error())
+		return writeLocalizedErrorMessage(c, fiber.StatusUnauthorized, apierror.CodeSessionExpired, err.Error())
 	case services.IsUserNotFound(err):
-		return writeErrorResponseWithCode(c, fiber.StatusNotFound, "user.not_found", err.Error())
+		return writeLocalizedErrorMessage(c, fiber.StatusNotFound, apierror.CodeUserNotFound, err.Error())
+	case services.IsPasswordResetTokenInvalid(err):
+		return writeLocalizedErrorMessage(c, fiber.StatusBadRequest, apierror.CodeUserResetTokenInvalid, err.Error())
+	case services.IsPasswordResetTokenExpired(err):
+		return writeLocalizedErrorMessage(c, fiber.StatusBadRequest, apierror.CodeUserResetTokenExpired, err.Error())
 	case services.IsSessionNotFound(err):
-		return writeErrorResponseWithCode(c, fiber.StatusNotFound, "session.not_found", err.Error())
+		return writeLocalizedErrorMessage(c, fiber.StatusNotFound, apierror.CodeSessionNotFound, err.Error())
 	case services.IsOldPasswordIncorrect(err):
-		return writeErrorResponseWithCode(c, fiber.StatusBadRequest, "user.old_password_incorrect", err.Error())
+		return writeLocalizedErrorMessage(c, fiber.StatusBadRequest, apierror.CodeUserOldPasswordIncorrect, err.Error())
 	case services.IsAdminTransferSelf(err), services.IsAdminResetSelf(err), services.IsAdminDeleteSelf(err), services.IsAdminDeleteBlocked(err), services.IsTransferTargetAdmin(err):
-		return writeErrorResponseWithCode(c, fiber.StatusBadRequest, "user.invalid_admin_operation", err.Error())
+		return writeLocalizedErrorMessage(c, fiber.StatusBadRequest, apierror.CodeUserInvalidAdminOperation, err.Error())
 	case services.IsAdminAccessDenied(err):
-		return writeErrorResponseWithCode(c, fiber.StatusForbidden, "user.admin_access_denied", err.Error())
+		return writeLocalizedErrorMessage(c, fiber.StatusForbidden, apierror.CodeUserAdminAccessDenied, err.Error())
 	case services.IsSessionAccessDenied(err):
-		return writeErrorResponseWithCode(c, fiber.StatusForbidden, "session.access_denied", err.Error())
+		return writeLocalizedErrorMessage(c, fiber.StatusForbidden, apierror.CodeSessionAccessDenied, err.Error())
+	case services.IsPasswordLoginDisabled(err):
+		return writeLocalizedErrorMessage(c, fiber.StatusForbidden, apierror.CodeAuthPasswordLoginDisabled, err.Error())
+	case services.IsOIDCDisabled(err):
+		return writeLocalizedErrorMessage(c, fiber.StatusNotFound, apierror.CodeAuthOIDCDisabled, err.Error())
 	default:
-		logger.Error("Unhandled user service error", zap.Error(err))
-		return writeErrorResponseWithCode(c, fiber.StatusInternalServerError, "system.internal_error", "Internal Server Error")
+		return writeInternalError(c, "Unhandled user service error", err)
 	}
 }
+
+// writeCaptchaError reports a missing, rejected or unverifiable captcha; captchaRequired tells
+// the client to show the widget
+func writeCaptchaError(c fiber.Ctx, err error) error {
+	apiErr := apierror.New(fiber.StatusBadRequest, apierror.CodeAuthCaptchaRequired, services.ErrCaptchaRequired.Error())
+	switch {
+	case services.IsCaptchaInvalid(err):
+		apiErr = apierror.New(fiber.StatusBadRequest, apierror.CodeAuthCaptchaInvalid, services.ErrCaptchaInvalid.Error())
+	case services.IsCaptchaUnavailable(err):
+		apiErr = apierror.New(fiber.StatusServiceUnavailable, apierror.CodeAuthCaptchaUnavailable, services.ErrCaptchaUnavailable.Error())
+	}
+	return writeErrorResponseWithExtra(c, apiErr.Translate(apiErr.Code), fiber.Map{"captchaRequired": true})
+}
+
+// writePasswordPolicyError lists the failed rules alongside the policy so clients can show specific hints
+func writePasswordPolicyError(c fiber.Ctx, err *services.PasswordPolicyError) error {
+	return writeErrorResponseWithExtra(c, apierror.New(fiber.StatusBadRequest, apierror.CodeUserPasswordPolicy, err.Error()).Translate(apierror.CodeUserPasswordPolicy), fiber.Map{
+		"failedRules": err.Failed,
+		"policy":      err.Policy,
+	})
+}
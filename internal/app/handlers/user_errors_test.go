@@ -28,6 +28,8 @@ func TestWriteUserServiceError_MapsKnownErrors(t *testing.T) {
 		{name: "delete current admin blocked", err: services.ErrAdminDeleteBlocked, expectedCode: fiber.StatusBadRequest},
 		{name: "target already admin", err: services.ErrTransferTargetAdmin, expectedCode: fiber.StatusBadRequest},
 		{name: "admin access denied", err: services.ErrAdminAccessDenied, expectedCode: fiber.StatusForbidden},
+		{name: "reset token invalid", err: services.ErrPasswordResetTokenInvalid, expectedCode: fiber.StatusBadRequest},
+		{name: "reset token expired", err: services.ErrPasswordResetTokenExpired, expectedCode: fiber.StatusBadRequest},
 		{name: "wrapped user not found", err: fmt.Errorf("wrapped: %w", services.ErrUserNotFound), expectedCode: fiber.StatusNotFound},
 	}
 
@@ -55,9 +57,9 @@ func TestWriteUserServiceError_MapsKnownErrors(t *testing.T) {
 			if !ok || strings.TrimSpace(messageText) == "" {
 				t.Fatalf("expected non-empty message body")
 			}
-			errorCode, ok := body["error_code"].(string)
+			errorCode, ok := body["errorCode"].(string)
 			if !ok || strings.TrimSpace(errorCode) == "" {
-				t.Fatalf("expected non-empty error_code body")
+				t.Fatalf("expected non-empty errorCode body")
 			}
 		})
 	}
@@ -1,6 +1,9 @@
 package handlers
 
 import (
+	"time"
+
+	"github.com/GT-610/tairitsu/internal/app/i18n"
 	"github.com/GT-610/tairitsu/internal/app/logger"
 	"github.com/GT-610/tairitsu/internal/app/models"
 	"github.com/GT-610/tairitsu/internal/app/services"
@@ -10,13 +13,15 @@ import (
 
 // UserHandler handles user-related requests
 type UserHandler struct {
-	userService *services.UserService
+	userService    *services.UserService
+	sessionService *services.SessionService
 }
 
 // NewUserHandler creates a new instance of UserHandler
-func NewUserHandler(userService *services.UserService) *UserHandler {
+func NewUserHandler(userService *services.UserService, sessionService *services.SessionService) *UserHandler {
 	return &UserHandler{
-		userService: userService,
+		userService:    userService,
+		sessionService: sessionService,
 	}
 }
 
@@ -28,7 +33,7 @@ func (h *UserHandler) GetAllUsers(c fiber.Ctx) error {
 	users, err := h.userService.GetAllUsers()
 	if err != nil {
 		logger.Error("failed to get all users", zap.Error(err))
-		return writeErrorResponse(c, fiber.StatusInternalServerError, "Failed to retrieve users")
+		return writeLocalizedStatusError(c, fiber.StatusInternalServerError, "user.list_failed", "Failed to retrieve users")
 	}
 
 	// Convert users to response format
@@ -42,27 +47,23 @@ func (h *UserHandler) GetAllUsers(c fiber.Ctx) error {
 }
 
 type TransferAdminRequest struct {
-	UserID string `json:"user_id"`
-}
-
-type CreateUserRequest struct {
-	Username string `json:"username"`
+	UserID string `json:"userId"`
 }
 
 type ResetPasswordResponse struct {
 	Message           string              `json:"message"`
-	MessageCode       string              `json:"message_code"`
+	MessageCode       string              `json:"messageCode"`
 	User              models.UserResponse `json:"user"`
-	TemporaryPassword string              `json:"temporary_password"`
-	RevokedSessions   int                 `json:"revoked_sessions"`
+	TemporaryPassword string              `json:"temporaryPassword"`
+	RevokedSessions   int                 `json:"revokedSessions"`
 }
 
 type DeleteUserResponse struct {
 	Message             string              `json:"message"`
-	MessageCode         string              `json:"message_code"`
+	MessageCode         string              `json:"messageCode"`
 	User                models.UserResponse `json:"user"`
-	TransferredNetworks int                 `json:"transferred_networks"`
-	RevokedSessions     int                 `json:"revoked_sessions"`
+	TransferredNetworks int                 `json:"transferredNetworks"`
+	RevokedSessions     int                 `json:"revokedSessions"`
 }
 
 func (h *UserHandler) CreateUser(c fiber.Ctx) error {
@@ -72,23 +73,21 @@ func (h *UserHandler) CreateUser(c fiber.Ctx) error {
 		return authErr
 	}
 
-	var req CreateUserRequest
+	var req models.CreateUserRequest
 	if err := c.Bind().Body(&req); err != nil {
 		logger.Error("Failed to create user: request binding failed", zap.String("current_user_id", currentUserID), zap.Error(err))
-		return writeErrorResponse(c, fiber.StatusBadRequest, err.Error())
+		return writeBindError(c, err)
 	}
 
-	user, temporaryPassword, err := h.userService.CreateUserByAdmin(currentUserID, req.Username)
+	user, temporaryPassword, err := h.userService.CreateUserByAdmin(currentUserID, &req)
 	if err != nil {
 		logger.Error("Failed to create user", zap.String("current_user_id", currentUserID), zap.String("username", req.Username), zap.Error(err))
 		return writeUserServiceError(c, err)
 	}
 
-	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
-		"message":            "User created successfully. Share the temporary password securely outside this system.",
-		"message_code":       "user.created",
-		"user":               user.ToResponse(),
-		"temporary_password": temporaryPassword,
+	return writeLocalizedMessage(c, fiber.StatusCreated, "user.created", fiber.Map{
+		"user":              user.ToResponse(),
+		"temporaryPassword": temporaryPassword,
 	})
 }
 
@@ -103,7 +102,7 @@ func (h *UserHandler) TransferAdmin(c fiber.Ctx) error {
 	var req TransferAdminRequest
 	if err := c.Bind().Body(&req); err != nil {
 		logger.Error("Failed to transfer administrator role: request binding failed", zap.String("current_user_id", currentUserID), zap.Error(err))
-		return writeErrorResponse(c, fiber.StatusBadRequest, err.Error())
+		return writeBindError(c, err)
 	}
 
 	user, err := h.userService.TransferAdmin(currentUserID, req.UserID)
@@ -113,10 +112,8 @@ func (h *UserHandler) TransferAdmin(c fiber.Ctx) error {
 	}
 
 	logger.Info("Administrator role transferred", zap.String("current_user_id", currentUserID), zap.String("target_user_id", req.UserID))
-	return c.Status(fiber.StatusOK).JSON(fiber.Map{
-		"message":      "Administrator role transferred successfully",
-		"message_code": "user.admin_transferred",
-		"user":         user.ToResponse(),
+	return writeLocalizedMessage(c, fiber.StatusOK, "user.admin_transferred", fiber.Map{
+		"user": user.ToResponse(),
 	})
 }
 
@@ -147,7 +144,7 @@ func (h *UserHandler) ResetPassword(c fiber.Ctx) error {
 		zap.Int("revoked_sessions", revokedSessions))
 
 	return c.Status(fiber.StatusOK).JSON(ResetPasswordResponse{
-		Message:           "Password reset successfully. Share it securely outside this system and ask the user to change it soon.",
+		Message:           i18n.Text(i18n.LocaleFrom(c), "user.password_reset"),
 		MessageCode:       "user.password_reset",
 		User:              user.ToResponse(),
 		TemporaryPassword: temporaryPassword,
@@ -155,6 +152,35 @@ func (h *UserHandler) ResetPassword(c fiber.Ctx) error {
 	})
 }
 
+// IssueResetToken issues a password reset token for a user, for delivery outside this system
+func (h *UserHandler) IssueResetToken(c fiber.Ctx) error {
+	currentUserID, authErr := requiredUserID(c)
+	if authErr != nil {
+		logger.Error("Failed to issue password reset token: unauthenticated")
+		return authErr
+	}
+	targetUserID := c.Params("userId")
+
+	ticket, err := h.userService.IssuePasswordResetToken(targetUserID)
+	if err != nil {
+		logger.Error("Failed to issue password reset token",
+			zap.String("current_user_id", currentUserID),
+			zap.String("target_user_id", targetUserID),
+			zap.Error(err))
+		return writeUserServiceError(c, err)
+	}
+
+	logger.Info("Password reset token issued",
+		zap.String("current_user_id", currentUserID),
+		zap.String("target_user_id", targetUserID))
+
+	return writeLocalizedMessage(c, fiber.StatusCreated, "user.reset_token_issued", fiber.Map{
+		"user":      ticket.User.ToResponse(),
+		"token":     ticket.Token,
+		"expiresAt": ticket.ExpiresAt,
+	})
+}
+
 func (h *UserHandler) DeleteUser(c fiber.Ctx) error {
 	currentUserID, authErr := requiredUserID(c)
 	if authErr != nil {
@@ -183,10 +209,77 @@ func (h *UserHandler) DeleteUser(c fiber.Ctx) error {
 		zap.Int("revoked_sessions", revokedSessions))
 
 	return c.Status(fiber.StatusOK).JSON(DeleteUserResponse{
-		Message:             "User deleted. Their networks were transferred to the current administrator.",
+		Message:             i18n.Text(i18n.LocaleFrom(c), "user.deleted"),
 		MessageCode:         "user.deleted",
 		User:                user.ToResponse(),
 		TransferredNetworks: transferredNetworks,
 		RevokedSessions:     revokedSessions,
 	})
 }
+
+// ListUserSessions returns the unexpired and revoked sessions of any user, for administrators
+func (h *UserHandler) ListUserSessions(c fiber.Ctx) error {
+	currentUserID, authErr := requiredUserID(c)
+	if authErr != nil {
+		logger.Error("Failed to list user sessions: unauthenticated")
+		return authErr
+	}
+	targetUserID := c.Params("userId")
+
+	if _, err := h.userService.GetUserByID(targetUserID); err != nil {
+		logger.Error("Failed to list user sessions", zap.String("current_user_id", currentUserID), zap.String("target_user_id", targetUserID), zap.Error(err))
+		return writeUserServiceError(c, err)
+	}
+	sessions, err := h.sessionService.GetUserSessions(targetUserID)
+	if err != nil {
+		logger.Error("Failed to list user sessions", zap.String("current_user_id", currentUserID), zap.String("target_user_id", targetUserID), zap.Error(err))
+		return writeUserServiceError(c, err)
+	}
+
+	currentSessionID, _ := c.Locals("session_id").(string)
+	now := time.Now()
+	responses := make([]models.SessionResponse, 0, len(sessions))
+	for _, session := range sessions {
+		if session.RevokedAt == nil && now.After(session.ExpiresAt) {
+			continue
+		}
+		responses = append(responses, session.ToResponse(session.ID == currentSessionID))
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{"sessions": responses})
+}
+
+// RevokeUserSession signs out one session of any user, for administrators
+func (h *UserHandler) RevokeUserSession(c fiber.Ctx) error {
+	currentUserID, authErr := requiredUserID(c)
+	if authErr != nil {
+		logger.Error("Failed to revoke user session: unauthenticated")
+		return authErr
+	}
+	targetUserID := c.Params("userId")
+	sessionID := c.Params("sessionId")
+
+	if _, err := h.userService.GetUserByID(targetUserID); err != nil {
+		logger.Error("Failed to revoke user session", zap.String("current_user_id", currentUserID), zap.String("target_user_id", targetUserID), zap.Error(err))
+		return writeUserServiceError(c, err)
+	}
+	err := h.sessionService.RevokeSession(targetUserID, sessionID)
+	if services.IsSessionAccessDenied(err) {
+		// The session belongs to someone else, so it does not exist for this user
+		err = services.ErrSessionNotFound
+	}
+	if err != nil {
+		logger.Error("Failed to revoke user session",
+			zap.String("current_user_id", currentUserID),
+			zap.String("target_user_id", targetUserID),
+			zap.String("session_id", sessionID),
+			zap.Error(err))
+		return writeUserServiceError(c, err)
+	}
+
+	logger.Info("User session revoked",
+		zap.String("current_user_id", currentUserID),
+		zap.String("target_user_id", targetUserID),
+		zap.String("session_id", sessionID))
+	return writeLocalizedMessage(c, fiber.StatusOK, "user.session_revoked", nil)
+}
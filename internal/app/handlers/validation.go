@@ -1,57 +1,114 @@
 package handlers
 
 import (
-	"encoding/hex"
 	"fmt"
 	"strings"
+	"time"
 	"unicode/utf8"
+
+	"github.com/GT-610/tairitsu/internal/app/apierror"
+	"github.com/GT-610/tairitsu/internal/app/services"
 )
 
 const (
+	networkIDLen             = 16
+	memberIDLen              = 10
 	maxNetworkNameLen        = 128
 	maxNetworkDescriptionLen = 1024
 	maxMemberNameLen         = 128
+	maxIdempotencyKeyLen     = 128
 )
 
+// validateNetworkID and the other validators return an apierror.FieldError naming the field.
+// IDs end up in controller request paths, so anything but the exact lowercase hex form is refused.
 func validateNetworkID(id string) error {
-	id = strings.TrimSpace(id)
-	if len(id) != 16 {
-		return fmt.Errorf("network ID must be 16 hexadecimal characters")
-	}
-	if _, err := hex.DecodeString(id); err != nil {
-		return fmt.Errorf("network ID must be a valid hexadecimal string")
+	if !isLowerHex(id, networkIDLen) {
+		return apierror.Invalid("networkId", "network ID must be 16 lowercase hexadecimal characters")
 	}
 	return nil
 }
 
 func validateMemberID(id string) error {
-	id = strings.TrimSpace(id)
-	if len(id) != 10 {
-		return fmt.Errorf("member ID must be 10 hexadecimal characters")
-	}
-	if _, err := hex.DecodeString(id); err != nil {
-		return fmt.Errorf("member ID must be a valid hexadecimal string")
+	if !isLowerHex(id, memberIDLen) {
+		return apierror.Invalid("memberId", "member ID must be 10 lowercase hexadecimal characters")
 	}
 	return nil
 }
 
+func isLowerHex(value string, length int) bool {
+	if len(value) != length {
+		return false
+	}
+	for _, r := range value {
+		if (r < '0' || r > '9') && (r < 'a' || r > 'f') {
+			return false
+		}
+	}
+	return true
+}
+
 func validateNetworkName(name string) error {
 	if utf8.RuneCountInString(name) > maxNetworkNameLen {
-		return fmt.Errorf("network name must be %d characters or fewer", maxNetworkNameLen)
+		return apierror.Invalid("name", fmt.Sprintf("network name must be %d characters or fewer", maxNetworkNameLen))
 	}
 	return nil
 }
 
 func validateNetworkDescription(desc string) error {
 	if utf8.RuneCountInString(desc) > maxNetworkDescriptionLen {
-		return fmt.Errorf("network description must be %d characters or fewer", maxNetworkDescriptionLen)
+		return apierror.Invalid("description", fmt.Sprintf("network description must be %d characters or fewer", maxNetworkDescriptionLen))
+	}
+	return nil
+}
+
+// validateNetworkBatch checks the shape of a batch. Indexes are zero-padded, so every name is
+// as long as the last one.
+func validateNetworkBatch(req *services.NetworkBatchRequest) error {
+	if req.Count < 1 || req.Count > services.MaxNetworkBatchSize {
+		return apierror.Invalid("count", fmt.Sprintf("count must be between 1 and %d", services.MaxNetworkBatchSize))
+	}
+	if !strings.Contains(req.NamePattern, services.NetworkBatchIndexPlaceholder) {
+		return apierror.Invalid("namePattern", fmt.Sprintf("name pattern must contain %s", services.NetworkBatchIndexPlaceholder))
+	}
+	names := services.NetworkBatchNames(req.NamePattern, req.Count)
+	if utf8.RuneCountInString(names[len(names)-1]) > maxNetworkNameLen {
+		return apierror.Invalid("namePattern", fmt.Sprintf("network names must be %d characters or fewer", maxNetworkNameLen))
+	}
+	if err := validateNetworkDescription(req.Description); err != nil {
+		return err
+	}
+	if len(req.IdempotencyKey) > maxIdempotencyKeyLen {
+		return apierror.Invalid("idempotencyKey", fmt.Sprintf("idempotency key must be %d bytes or fewer", maxIdempotencyKeyLen))
 	}
 	return nil
 }
 
 func validateMemberName(name string) error {
 	if utf8.RuneCountInString(name) > maxMemberNameLen {
-		return fmt.Errorf("member name must be %d characters or fewer", maxMemberNameLen)
+		return apierror.Invalid("name", fmt.Sprintf("member name must be %d characters or fewer", maxMemberNameLen))
+	}
+	return nil
+}
+
+func validateNodeAddress(address string) error {
+	if !isLowerHex(address, memberIDLen) {
+		return apierror.Invalid("nodeAddress", "node address must be 10 lowercase hexadecimal characters")
 	}
 	return nil
 }
+
+// parseAuthorizedUntil reads an optional authorization deadline. Nil and empty values yield no
+// deadline; any other value must be an RFC 3339 time in the future.
+func parseAuthorizedUntil(value *string) (*time.Time, error) {
+	if value == nil || *value == "" {
+		return nil, nil
+	}
+	until, err := time.Parse(time.RFC3339, *value)
+	if err != nil {
+		return nil, apierror.Invalid("authorizedUntil", "authorizedUntil must be an RFC 3339 time")
+	}
+	if !until.After(time.Now()) {
+		return nil, apierror.Invalid("authorizedUntil", "authorizedUntil must be in the future")
+	}
+	return &until, nil
+}
@@ -0,0 +1,150 @@
+package handlers
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/GT-610/tairitsu/internal/app/apierror"
+	"github.com/GT-610/tairitsu/internal/app/logger"
+	"github.com/GT-610/tairitsu/internal/app/models"
+	"github.com/GT-610/tairitsu/internal/app/services"
+	"github.com/gofiber/fiber/v3"
+	"go.uber.org/zap"
+)
+
+// WebhookHandler lets administrators manage outbound webhooks
+type WebhookHandler struct {
+	dispatcher *services.WebhookDispatcher
+}
+
+// NewWebhookHandler creates a new webhook handler instance
+func NewWebhookHandler(dispatcher *services.WebhookDispatcher) *WebhookHandler {
+	return &WebhookHandler{dispatcher: dispatcher}
+}
+
+func writeWebhookError(c fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, services.ErrWebhookInvalidName), errors.Is(err, services.ErrWebhookInvalidURL), errors.Is(err, services.ErrWebhookInvalidEvent):
+		return writeLocalizedErrorMessage(c, fiber.StatusBadRequest, apierror.CodeWebhookInvalidRequest, err.Error())
+	case errors.Is(err, services.ErrWebhookNotFound):
+		return writeLocalizedErrorMessage(c, fiber.StatusNotFound, apierror.CodeWebhookNotFound, err.Error())
+	case errors.Is(err, services.ErrWebhookQueueFull):
+		return writeLocalizedErrorMessage(c, fiber.StatusServiceUnavailable, apierror.CodeWebhookQueueFull, err.Error())
+	default:
+		return writeUserServiceError(c, err)
+	}
+}
+
+// CreateWebhook adds a webhook; its secret is only returned in this response
+func (h *WebhookHandler) CreateWebhook(c fiber.Ctx) error {
+	userID, authErr := requiredUserID(c)
+	if authErr != nil {
+		return authErr
+	}
+
+	var req services.WebhookInput
+	if err := c.Bind().Body(&req); err != nil {
+		return writeBindErrorWithCode(c, apierror.CodeWebhookInvalidRequest, err)
+	}
+
+	webhook, secret, err := h.dispatcher.CreateWebhook(req, userID)
+	if err != nil {
+		logger.WithRequestID(c).Error("Failed to create webhook", zap.Error(err))
+		return writeWebhookError(c, err)
+	}
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"secret":  secret,
+		"webhook": webhook.ToResponse(),
+	})
+}
+
+// ListWebhooks lists every webhook without its secret
+func (h *WebhookHandler) ListWebhooks(c fiber.Ctx) error {
+	webhooks, err := h.dispatcher.ListWebhooks()
+	if err != nil {
+		logger.WithRequestID(c).Error("Failed to list webhooks", zap.Error(err))
+		return writeWebhookError(c, err)
+	}
+
+	responses := make([]models.WebhookResponse, 0, len(webhooks))
+	for _, webhook := range webhooks {
+		responses = append(responses, webhook.ToResponse())
+	}
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{"webhooks": responses, "events": services.WebhookEvents})
+}
+
+// UpdateWebhook replaces the settings of a webhook
+func (h *WebhookHandler) UpdateWebhook(c fiber.Ctx) error {
+	var req services.WebhookInput
+	if err := c.Bind().Body(&req); err != nil {
+		return writeBindErrorWithCode(c, apierror.CodeWebhookInvalidRequest, err)
+	}
+
+	webhook, err := h.dispatcher.UpdateWebhook(c.Params("id"), req)
+	if err != nil {
+		logger.WithRequestID(c).Error("Failed to update webhook", zap.String("webhook_id", c.Params("id")), zap.Error(err))
+		return writeWebhookError(c, err)
+	}
+	return c.Status(fiber.StatusOK).JSON(webhook.ToResponse())
+}
+
+// DeleteWebhook removes a webhook and its delivery log
+func (h *WebhookHandler) DeleteWebhook(c fiber.Ctx) error {
+	if err := h.dispatcher.DeleteWebhook(c.Params("id")); err != nil {
+		logger.WithRequestID(c).Error("Failed to delete webhook", zap.String("webhook_id", c.Params("id")), zap.Error(err))
+		return writeWebhookError(c, err)
+	}
+	return writeLocalizedMessage(c, fiber.StatusOK, "webhook.deleted", nil)
+}
+
+// ListDeliveries returns the latest deliveries of a webhook, newest first
+func (h *WebhookHandler) ListDeliveries(c fiber.Ctx) error {
+	limit := 50
+	if raw := c.Query("limit"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed <= 0 {
+			return writeLocalizedStatusError(c, fiber.StatusBadRequest, "request.invalid_integer", "limit must be a positive integer")
+		}
+		limit = parsed
+	}
+
+	deliveries, err := h.dispatcher.ListDeliveries(c.Params("id"), limit)
+	if err != nil {
+		logger.WithRequestID(c).Error("Failed to list webhook deliveries", zap.String("webhook_id", c.Params("id")), zap.Error(err))
+		return writeWebhookError(c, err)
+	}
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{"deliveries": deliveries})
+}
+
+// PingWebhook sends a ping event right away and returns how the receiver answered
+func (h *WebhookHandler) PingWebhook(c fiber.Ctx) error {
+	delivery, err := h.dispatcher.Ping(c.Context(), c.Params("id"))
+	if err != nil {
+		logger.WithRequestID(c).Error("Failed to ping webhook", zap.String("webhook_id", c.Params("id")), zap.Error(err))
+		return writeWebhookError(c, err)
+	}
+	return c.Status(fiber.StatusOK).JSON(delivery)
+}
+
+// SendTestEvent queues a sample event of the requested type, marked as a test, and returns
+// its delivery
+func (h *WebhookHandler) SendTestEvent(c fiber.Ctx) error {
+	var req struct {
+		Event string `json:"event"`
+	}
+	if err := c.Bind().Body(&req); err != nil {
+		return writeBindErrorWithCode(c, apierror.CodeWebhookInvalidRequest, err)
+	}
+
+	delivery, err := h.dispatcher.SendTestEvent(c.Params("id"), req.Event)
+	if err != nil {
+		logger.WithRequestID(c).Error("Failed to send webhook test event", zap.String("webhook_id", c.Params("id")), zap.String("event", req.Event), zap.Error(err))
+		return writeWebhookError(c, err)
+	}
+	return c.Status(fiber.StatusAccepted).JSON(delivery)
+}
+
+// GetPayloadSchemas returns the JSON Schema of the payload of every event
+func (h *WebhookHandler) GetPayloadSchemas(c fiber.Ctx) error {
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{"schemas": services.WebhookSchemas()})
+}
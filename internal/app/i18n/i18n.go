@@ -0,0 +1,149 @@
+// Package i18n translates API messages. Messages are keyed by their errorCode or
+// messageCode, or for errors whose code is derived from the status by a key of their own,
+// and each supported locale has a bundle in locales/<locale>.json.
+//
+// Clients should still branch on the code; the translated message is a convenience for
+// operators reading raw responses.
+package i18n
+
+import (
+	"context"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	// DefaultLocale is used for requests without a supported Accept-Language
+	DefaultLocale = "en-US"
+	// FallbackLocale supplies messages missing from the requested locale
+	FallbackLocale = "zh-CN"
+)
+
+// LocaleKey is the key under which the locale middleware stores the locale in fiber Locals
+const LocaleKey = "locale"
+
+//go:embed locales/*.json
+var localeFiles embed.FS
+
+var bundles = mustLoadBundles()
+
+func mustLoadBundles() map[string]map[string]string {
+	entries, err := localeFiles.ReadDir("locales")
+	if err != nil {
+		panic(fmt.Sprintf("i18n: read locales: %v", err))
+	}
+	loaded := make(map[string]map[string]string, len(entries))
+	for _, entry := range entries {
+		raw, err := localeFiles.ReadFile("locales/" + entry.Name())
+		if err != nil {
+			panic(fmt.Sprintf("i18n: read %s: %v", entry.Name(), err))
+		}
+		messages := map[string]string{}
+		if err := json.Unmarshal(raw, &messages); err != nil {
+			panic(fmt.Sprintf("i18n: parse %s: %v", entry.Name(), err))
+		}
+		loaded[strings.TrimSuffix(entry.Name(), ".json")] = messages
+	}
+	return loaded
+}
+
+// Locales returns the supported locales, sorted
+func Locales() []string {
+	locales := make([]string, 0, len(bundles))
+	for locale := range bundles {
+		locales = append(locales, locale)
+	}
+	sort.Strings(locales)
+	return locales
+}
+
+// Keys returns the message keys of a locale, sorted
+func Keys(locale string) []string {
+	keys := make([]string, 0, len(bundles[locale]))
+	for key := range bundles[locale] {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Text returns the message for key in locale. A key missing from locale is looked up in
+// FallbackLocale, and a key missing there too is returned as is.
+func Text(locale, key string) string {
+	if message, ok := bundles[locale][key]; ok {
+		return message
+	}
+	if message, ok := bundles[FallbackLocale][key]; ok {
+		return message
+	}
+	return key
+}
+
+// Negotiate picks the supported locale best matching an Accept-Language header. A tag
+// matches a locale exactly or by its language, so "zh" and "zh-TW" both pick zh-CN.
+func Negotiate(acceptLanguage string) string {
+	type candidate struct {
+		tag     string
+		quality float64
+	}
+	var candidates []candidate
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		quality := 1.0
+		if value, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				continue
+			}
+			quality = parsed
+		}
+		if tag = strings.TrimSpace(tag); tag != "" && quality > 0 {
+			candidates = append(candidates, candidate{tag: tag, quality: quality})
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].quality > candidates[j].quality
+	})
+
+	for _, candidate := range candidates {
+		if candidate.tag == "*" {
+			return DefaultLocale
+		}
+		if locale, ok := matchLocale(candidate.tag); ok {
+			return locale
+		}
+	}
+	return DefaultLocale
+}
+
+func matchLocale(tag string) (string, bool) {
+	language, _, _ := strings.Cut(tag, "-")
+	var byLanguage string
+	for _, locale := range Locales() {
+		if strings.EqualFold(locale, tag) {
+			return locale, true
+		}
+		localeLanguage, _, _ := strings.Cut(locale, "-")
+		if byLanguage == "" && strings.EqualFold(localeLanguage, language) {
+			byLanguage = locale
+		}
+	}
+	return byLanguage, byLanguage != ""
+}
+
+// LocaleFrom returns the locale the locale middleware chose for the request, or
+// DefaultLocale outside a request
+func LocaleFrom(ctx context.Context) string {
+	if ctx == nil {
+		return DefaultLocale
+	}
+	locale, _ := ctx.Value(LocaleKey).(string)
+	if locale == "" {
+		return DefaultLocale
+	}
+	return locale
+}
@@ -1,20 +1,37 @@
 package logger
 
 import (
+	"context"
+	"errors"
 	"os"
+	"path/filepath"
+	"sync/atomic"
 
+	"github.com/GT-610/tairitsu/internal/app/paths"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 	"gopkg.in/natefinch/lumberjack.v2"
 )
 
-var logger *zap.Logger
+// current is the logger behind the package functions. Until Init runs it writes warnings and
+// errors to stderr, so failures before the configuration is loaded are not lost.
+var current atomic.Pointer[zap.Logger]
 
-func ensureLogger() *zap.Logger {
-	if logger == nil {
-		logger = zap.NewNop()
-	}
-	return logger
+func init() {
+	core := zapcore.NewCore(zapcore.NewConsoleEncoder(encoderConfig(zapcore.LowercaseLevelEncoder)), zapcore.Lock(os.Stderr), zap.WarnLevel)
+	current.Store(zap.New(core, zap.AddCaller(), zap.WithFatalHook(syncThenExit{})))
+}
+
+func currentLogger() *zap.Logger {
+	return current.Load()
+}
+
+// syncThenExit flushes every sink before a Fatal entry ends the process
+type syncThenExit struct{}
+
+func (syncThenExit) OnWrite(*zapcore.CheckedEntry, []zapcore.Field) {
+	Sync()
+	os.Exit(1)
 }
 
 // isProduction checks both APP_ENV and NODE_ENV for backward compatibility.
@@ -22,34 +39,134 @@ func isProduction() bool {
 	return os.Getenv("APP_ENV") == "production" || os.Getenv("NODE_ENV") == "production"
 }
 
+// defaultLogFile is tairitsu.log in the log directory of the data directory
+func defaultLogFile() string {
+	return filepath.Join(paths.LogDir(), "tairitsu.log")
+}
+
+// Log file rotation defaults
+const (
+	defaultMaxSizeMB  = 10
+	defaultMaxBackups = 5
+	defaultMaxAgeDays = 30
+)
+
+// Encodings accepted by Options.Encoding
+const (
+	EncodingJSON    = "json"
+	EncodingConsole = "console"
+)
+
+// ErrInvalidLevel is returned for level names other than debug, info, warn and error
+var ErrInvalidLevel = errors.New("logger.invalid_level")
+
+// level is shared by every logger built by Init, so SetLevel takes effect without rebuilding them
+var level = zap.NewAtomicLevelAt(zap.InfoLevel)
+
+// Options configures the logger; zero values use the defaults
+type Options struct {
+	Level      string
+	FilePath   string // Defaults to tairitsu.log in paths.LogDir
+	MaxSizeMB  int
+	MaxBackups int
+	MaxAgeDays int
+	// Console also writes to stdout. Without it, an unwritable log file falls back to stdout.
+	Console bool
+	// Encoding applies to every sink; empty writes JSON to the file and colored text to stdout
+	Encoding string
+	// FileDisabled writes to stdout only, for example before the configuration is loaded
+	FileDisabled bool
+}
+
+// DefaultOptions returns the options used before the configuration customizes them. Console output
+// is enabled outside production.
+func DefaultOptions() Options {
+	return Options{
+		Level:      "info",
+		FilePath:   defaultLogFile(),
+		MaxSizeMB:  defaultMaxSizeMB,
+		MaxBackups: defaultMaxBackups,
+		MaxAgeDays: defaultMaxAgeDays,
+		Console:    !isProduction(),
+	}
+}
+
 // InitLogger initializes the logger
 func InitLogger(level string) {
-	// Set the log level
-	var zapLevel zapcore.Level
-	switch level {
-	case "debug":
-		zapLevel = zap.DebugLevel
-	case "info":
-		zapLevel = zap.InfoLevel
-	case "warn":
-		zapLevel = zap.WarnLevel
-	case "error":
-		zapLevel = zap.ErrorLevel
-	default:
+	opts := DefaultOptions()
+	opts.Level = level
+	Init(opts)
+}
+
+// InitLoggerWithFile initializes the logger writing rotated logs to the given file
+func InitLoggerWithFile(level string, filename string) {
+	opts := DefaultOptions()
+	opts.Level = level
+	opts.FilePath = filename
+	Init(opts)
+}
+
+// Init (re)builds the logger from opts. When the log file cannot be written, for example on a
+// read-only filesystem, logging continues on stdout and a warning says why.
+func Init(opts Options) {
+	zapLevel, err := parseLevel(opts.Level)
+	if err != nil {
 		zapLevel = zap.InfoLevel
 	}
+	level.SetLevel(zapLevel)
 
-	// Configure log rotation
-	logWriter := zapcore.AddSync(&lumberjack.Logger{
-		Filename:   "./logs/tairitsu.log",
-		MaxSize:    10,   // Max 10MB per log file
-		MaxBackups: 5,    // Keep at most 5 backup files
-		MaxAge:     30,   // Retain logs for up to 30 days
-		Compress:   true, // Compress old log files
-	})
+	filePath := opts.FilePath
+	if filePath == "" {
+		filePath = defaultLogFile()
+	}
 
-	// Configure the encoder
-	encoderConfig := zapcore.EncoderConfig{
+	var cores []zapcore.Core
+	var fileErr error
+	if !opts.FileDisabled {
+		if fileErr = ensureWritable(filePath); fileErr == nil {
+			// Configure log rotation
+			logWriter := zapcore.AddSync(&lumberjack.Logger{
+				Filename:   filePath,
+				MaxSize:    positiveOr(opts.MaxSizeMB, defaultMaxSizeMB),
+				MaxBackups: positiveOr(opts.MaxBackups, defaultMaxBackups),
+				MaxAge:     positiveOr(opts.MaxAgeDays, defaultMaxAgeDays),
+				Compress:   true, // Compress old log files
+			})
+			fileEncoder := zapcore.NewJSONEncoder(encoderConfig(zapcore.LowercaseLevelEncoder))
+			if opts.Encoding == EncodingConsole {
+				fileEncoder = zapcore.NewConsoleEncoder(encoderConfig(zapcore.LowercaseLevelEncoder))
+			}
+			cores = append(cores, zapcore.NewCore(fileEncoder, logWriter, level))
+		}
+	}
+
+	if opts.Console || len(cores) == 0 {
+		consoleEncoder := zapcore.NewConsoleEncoder(encoderConfig(zapcore.LowercaseColorLevelEncoder))
+		if opts.Encoding == EncodingJSON {
+			consoleEncoder = zapcore.NewJSONEncoder(encoderConfig(zapcore.LowercaseLevelEncoder))
+		}
+		cores = append(cores, zapcore.NewCore(consoleEncoder, zapcore.AddSync(os.Stdout), level))
+	}
+
+	// Create the combined core
+	zapOpts := []zap.Option{zap.AddCaller(), zap.WithFatalHook(syncThenExit{})}
+	if !isProduction() {
+		zapOpts = append(zapOpts, zap.Development())
+	}
+	logger := zap.New(zapcore.NewTee(cores...), zapOpts...)
+	// Entries already written by the replaced logger still reach its sinks
+	_ = current.Swap(logger).Sync()
+
+	if err != nil {
+		logger.Warn("unknown log level; using info", zap.String("level", opts.Level))
+	}
+	if fileErr != nil {
+		logger.Warn("log file is not writable; logging to stdout only", zap.String("path", filePath), zap.Error(fileErr))
+	}
+}
+
+func encoderConfig(encodeLevel zapcore.LevelEncoder) zapcore.EncoderConfig {
+	return zapcore.EncoderConfig{
 		TimeKey:        "ts",
 		LevelKey:       "level",
 		NameKey:        "logger",
@@ -58,78 +175,117 @@ func InitLogger(level string) {
 		MessageKey:     "msg",
 		StacktraceKey:  "stacktrace",
 		LineEnding:     zapcore.DefaultLineEnding,
-		EncodeLevel:    zapcore.LowercaseLevelEncoder,
+		EncodeLevel:    encodeLevel,
 		EncodeTime:     zapcore.ISO8601TimeEncoder,
 		EncodeDuration: zapcore.SecondsDurationEncoder,
 		EncodeCaller:   zapcore.ShortCallerEncoder,
 	}
+}
 
-	// Create the core
-	core := zapcore.NewCore(
-		zapcore.NewJSONEncoder(encoderConfig),
-		logWriter,
-		zapLevel,
-	)
+// ensureWritable creates the log directory and checks the file can be opened for appending
+func ensureWritable(filePath string) error {
+	if err := os.MkdirAll(filepath.Dir(filePath), 0755); err != nil {
+		return err
+	}
+	file, err := os.OpenFile(filePath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	return file.Close()
+}
 
-	// Add console output based on the environment
-	var cores []zapcore.Core
-	cores = append(cores, core)
+func positiveOr(value, fallback int) int {
+	if value > 0 {
+		return value
+	}
+	return fallback
+}
 
-	if !isProduction() {
-		consoleEncoder := zapcore.NewConsoleEncoder(zapcore.EncoderConfig{
-			TimeKey:        "ts",
-			LevelKey:       "level",
-			NameKey:        "logger",
-			CallerKey:      "caller",
-			FunctionKey:    zapcore.OmitKey,
-			MessageKey:     "msg",
-			StacktraceKey:  "stacktrace",
-			LineEnding:     zapcore.DefaultLineEnding,
-			EncodeLevel:    zapcore.LowercaseColorLevelEncoder,
-			EncodeTime:     zapcore.ISO8601TimeEncoder,
-			EncodeDuration: zapcore.SecondsDurationEncoder,
-			EncodeCaller:   zapcore.ShortCallerEncoder,
-		})
-
-		cores = append(cores, zapcore.NewCore(consoleEncoder, zapcore.AddSync(os.Stdout), zapLevel))
+func parseLevel(name string) (zapcore.Level, error) {
+	switch name {
+	case "debug":
+		return zap.DebugLevel, nil
+	case "info":
+		return zap.InfoLevel, nil
+	case "warn":
+		return zap.WarnLevel, nil
+	case "error":
+		return zap.ErrorLevel, nil
+	default:
+		return zap.InfoLevel, ErrInvalidLevel
 	}
+}
 
-	// Create the combined core
-	opts := []zap.Option{zap.AddCaller()}
-	if !isProduction() {
-		opts = append(opts, zap.Development())
+// ValidLevel reports whether name is a level accepted by SetLevel
+func ValidLevel(name string) bool {
+	_, err := parseLevel(name)
+	return err == nil
+}
+
+// Level returns the name of the current minimum level
+func Level() string {
+	return level.Level().String()
+}
+
+// SetLevel changes the minimum level of every sink at runtime
+func SetLevel(name string) error {
+	zapLevel, err := parseLevel(name)
+	if err != nil {
+		return err
 	}
-	logger = zap.New(zapcore.NewTee(cores...), opts...)
+	level.SetLevel(zapLevel)
+	return nil
 }
 
 // Sync flushes any buffered log entries. Call this at application shutdown.
 func Sync() {
-	if logger != nil {
-		_ = logger.Sync()
-	}
+	_ = currentLogger().Sync()
 }
 
 // Debug logs a message at Debug level
 func Debug(msg string, fields ...zap.Field) {
-	ensureLogger().WithOptions(zap.AddCallerSkip(1)).Debug(msg, fields...)
+	currentLogger().WithOptions(zap.AddCallerSkip(1)).Debug(msg, fields...)
 }
 
 // Info logs a message at Info level
 func Info(msg string, fields ...zap.Field) {
-	ensureLogger().WithOptions(zap.AddCallerSkip(1)).Info(msg, fields...)
+	currentLogger().WithOptions(zap.AddCallerSkip(1)).Info(msg, fields...)
 }
 
 // Warn logs a message at Warn level
 func Warn(msg string, fields ...zap.Field) {
-	ensureLogger().WithOptions(zap.AddCallerSkip(1)).Warn(msg, fields...)
+	currentLogger().WithOptions(zap.AddCallerSkip(1)).Warn(msg, fields...)
 }
 
 // Error logs a message at Error level
 func Error(msg string, fields ...zap.Field) {
-	ensureLogger().WithOptions(zap.AddCallerSkip(1)).Error(msg, fields...)
+	currentLogger().WithOptions(zap.AddCallerSkip(1)).Error(msg, fields...)
 }
 
 // Fatal logs a message at Fatal level
 func Fatal(msg string, fields ...zap.Field) {
-	ensureLogger().WithOptions(zap.AddCallerSkip(1)).Fatal(msg, fields...)
+	currentLogger().WithOptions(zap.AddCallerSkip(1)).Fatal(msg, fields...)
+}
+
+// RequestIDKey is the key under which the request ID middleware stores the ID in fiber Locals.
+// A fiber.Ctx is a context.Context whose values are its Locals, so the key also works with Value.
+const RequestIDKey = "request_id"
+
+// RequestID returns the request ID carried by ctx, or an empty string
+func RequestID(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	requestID, _ := ctx.Value(RequestIDKey).(string)
+	return requestID
+}
+
+// WithRequestID returns a logger that tags every entry with the request ID carried by ctx.
+// Handlers pass their fiber.Ctx; work without a request ID logs exactly like the package functions.
+func WithRequestID(ctx context.Context) *zap.Logger {
+	requestID := RequestID(ctx)
+	if requestID == "" {
+		return currentLogger()
+	}
+	return currentLogger().With(zap.String(RequestIDKey, requestID))
 }
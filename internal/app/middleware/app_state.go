@@ -1,6 +1,9 @@
 package middleware
 
-import "github.com/gofiber/fiber/v3"
+import (
+	"github.com/GT-610/tairitsu/internal/app/apierror"
+	"github.com/gofiber/fiber/v3"
+)
 
 type initializationState interface {
 	IsInitialized() bool
@@ -10,12 +13,7 @@ type initializationState interface {
 func SetupOnlyWithState(state initializationState) fiber.Handler {
 	return func(c fiber.Ctx) error {
 		if state.IsInitialized() {
-			return c.Status(fiber.StatusConflict).JSON(ErrorResponse{
-				Error:     "Already Initialized",
-				Message:   "The system is already initialized. This endpoint is only available during first-time setup.",
-				ErrorCode: "system.already_initialized",
-				Code:      fiber.StatusConflict,
-			})
+			return apierror.Write(c, apierror.New(fiber.StatusForbidden, apierror.CodeSystemAlreadyInitialized, "The system is already initialized. This endpoint is only available during first-time setup.").Translate(apierror.CodeSystemAlreadyInitialized))
 		}
 
 		return c.Next()
@@ -26,12 +24,42 @@ func SetupOnlyWithState(state initializationState) fiber.Handler {
 func InitializedOnlyWithState(state initializationState) fiber.Handler {
 	return func(c fiber.Ctx) error {
 		if !state.IsInitialized() {
-			return c.Status(fiber.StatusServiceUnavailable).JSON(ErrorResponse{
-				Error:     "Setup Required",
-				Message:   "System setup is required. Complete the setup wizard first.",
-				ErrorCode: "system.setup_required",
-				Code:      fiber.StatusServiceUnavailable,
-			})
+			return apierror.Write(c, apierror.New(fiber.StatusServiceUnavailable, apierror.CodeSystemSetupRequired, "System setup is required. Complete the setup wizard first.").Translate(apierror.CodeSystemSetupRequired))
+		}
+
+		return c.Next()
+	}
+}
+
+type databaseState interface {
+	DatabaseError() error
+}
+
+// RuntimeReadyWithState blocks runtime routes until setup is complete, and while the
+// configured database cannot be reached.
+func RuntimeReadyWithState(state initializationState, database databaseState) fiber.Handler {
+	initializedOnly := InitializedOnlyWithState(state)
+	return func(c fiber.Ctx) error {
+		if !state.IsInitialized() {
+			return initializedOnly(c)
+		}
+		if database.DatabaseError() != nil {
+			return apierror.Write(c, apierror.New(fiber.StatusServiceUnavailable, apierror.CodeSystemDatabaseUnavailable, "The database is temporarily unavailable. Try again shortly.").Translate(apierror.CodeSystemDatabaseUnavailable))
+		}
+
+		return c.Next()
+	}
+}
+
+type demoModeState interface {
+	IsDemoMode() bool
+}
+
+// DisabledInDemoWithState blocks routes that write outside the demo data directory.
+func DisabledInDemoWithState(state demoModeState) fiber.Handler {
+	return func(c fiber.Ctx) error {
+		if state.IsDemoMode() {
+			return apierror.Write(c, apierror.New(fiber.StatusForbidden, apierror.CodeSystemDemoModeDisabled, "This operation is disabled in demo mode.").Translate(apierror.CodeSystemDemoModeDisabled))
 		}
 
 		return c.Next()
@@ -0,0 +1,39 @@
+package middleware
+
+import (
+	"fmt"
+
+	"github.com/GT-610/tairitsu/internal/app/logger"
+	"github.com/GT-610/tairitsu/internal/app/services"
+	"github.com/gofiber/fiber/v3"
+	"go.uber.org/zap"
+)
+
+// Audit records every state-changing API request made by an authenticated user, except
+// controller trace ingestion, which forwarders post continuously
+func Audit(auditService *services.AuditService) fiber.Handler {
+	return func(c fiber.Ctx) error {
+		err := c.Next()
+
+		switch c.Method() {
+		case fiber.MethodGet, fiber.MethodHead, fiber.MethodOptions:
+			return err
+		}
+
+		actorID, _ := c.Locals("user_id").(string)
+		if actorID == "" || auditService == nil || isControllerTraceIngest(c) {
+			return err
+		}
+
+		if _, recordErr := auditService.Record(services.AuditEntryInput{
+			ActorID: actorID,
+			Action:  c.Method(),
+			Target:  c.Path(),
+			Details: fmt.Sprintf("status=%d ip=%s", c.Response().StatusCode(), c.IP()),
+		}); recordErr != nil {
+			logger.Warn("failed to record audit entry", zap.String("path", c.Path()), zap.Error(recordErr))
+		}
+
+		return err
+	}
+}
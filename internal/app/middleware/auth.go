@@ -3,6 +3,7 @@ package middleware
 import (
 	"strings"
 
+	"github.com/GT-610/tairitsu/internal/app/apierror"
 	"github.com/GT-610/tairitsu/internal/app/logger"
 	"github.com/GT-610/tairitsu/internal/app/services"
 	"github.com/gofiber/fiber/v3"
@@ -11,50 +12,39 @@ import (
 
 // AuthMiddleware is the authentication middleware
 func AuthMiddleware(jwtService *services.JWTService, sessionService *services.SessionService) fiber.Handler {
+	return AuthMiddlewareWithTokens(jwtService, sessionService, nil, nil)
+}
+
+// AuthMiddlewareWithTokens also accepts personal access tokens ("Bearer tairitsu_...") when a
+// bearer value is not a valid JWT. Token scopes limit the permitted methods and networks.
+func AuthMiddlewareWithTokens(jwtService *services.JWTService, sessionService *services.SessionService, tokenService *services.ApiTokenService, userService *services.UserService) fiber.Handler {
 	return func(c fiber.Ctx) error {
 		// Extract the token from the request header
 		authHeader := c.Get("Authorization")
 		if authHeader == "" {
-			return c.Status(fiber.StatusUnauthorized).JSON(ErrorResponse{
-				Error:     "Unauthorized",
-				Message:   "Missing authentication token",
-				ErrorCode: "auth.missing_token",
-				Code:      fiber.StatusUnauthorized,
-			})
+			return apierror.Write(c, apierror.New(fiber.StatusUnauthorized, apierror.CodeAuthMissingToken, "Missing authentication token").Translate(apierror.CodeAuthMissingToken))
 		}
 
 		// Check for Bearer prefix
 		parts := strings.SplitN(authHeader, " ", 2)
 		if !(len(parts) == 2 && parts[0] == "Bearer") {
-			return c.Status(fiber.StatusUnauthorized).JSON(ErrorResponse{
-				Error:     "Unauthorized",
-				Message:   "Invalid authentication format",
-				ErrorCode: "auth.invalid_format",
-				Code:      fiber.StatusUnauthorized,
-			})
+			return apierror.Write(c, apierror.New(fiber.StatusUnauthorized, apierror.CodeAuthInvalidFormat, "Invalid authentication format").Translate(apierror.CodeAuthInvalidFormat))
 		}
 
 		// Validate the token
 		claims, err := jwtService.ValidateToken(parts[1])
+		if err != nil && tokenService != nil && userService != nil && strings.HasPrefix(parts[1], services.ApiTokenPrefix) {
+			return authenticateApiToken(c, parts[1], tokenService, userService)
+		}
 		if err != nil {
-			return c.Status(fiber.StatusUnauthorized).JSON(ErrorResponse{
-				Error:     "Unauthorized",
-				Message:   "Invalid authentication token",
-				ErrorCode: "auth.invalid_token",
-				Code:      fiber.StatusUnauthorized,
-			})
+			return apierror.Write(c, apierror.New(fiber.StatusUnauthorized, apierror.CodeAuthInvalidToken, "Invalid authentication token").Translate(apierror.CodeAuthInvalidToken))
 		}
 
 		// Store user info in the context
 		if sessionService != nil {
 			session, err := sessionService.ValidateSession(claims.UserID, claims.SessionID)
 			if err != nil {
-				return c.Status(fiber.StatusUnauthorized).JSON(ErrorResponse{
-					Error:     "Unauthorized",
-					Message:   "Invalid authentication token",
-					ErrorCode: "auth.invalid_token",
-					Code:      fiber.StatusUnauthorized,
-				})
+				return apierror.Write(c, apierror.New(fiber.StatusUnauthorized, apierror.CodeAuthInvalidToken, "Invalid authentication token").Translate(apierror.CodeAuthInvalidToken))
 			}
 			_ = sessionService.TouchSession(session)
 			c.Locals("session_id", claims.SessionID)
@@ -64,59 +54,134 @@ func AuthMiddleware(jwtService *services.JWTService, sessionService *services.Se
 		c.Locals("username", claims.Username)
 		c.Locals("role", claims.Role)
 
+		if userService != nil {
+			if user, err := userService.GetUserByID(claims.UserID); err == nil && user.MustChangePassword && !passwordChangeExempt(c) {
+				return writePasswordChangeRequired(c)
+			}
+		}
+
+		return c.Next()
+	}
+}
+
+// PasswordChangePath is where users holding an administrator-issued password must go first
+const PasswordChangePath = "/api/profile/password"
+
+// passwordChangeExemptRoutes stay reachable while a password change is pending, so the user
+// can read their profile, change the password or sign out
+var passwordChangeExemptRoutes = map[string]string{
+	"/api/profile":     fiber.MethodGet,
+	PasswordChangePath: fiber.MethodPut,
+	"/api/auth/logout": fiber.MethodPost,
+}
+
+func passwordChangeExempt(c fiber.Ctx) bool {
+	method, ok := passwordChangeExemptRoutes[c.Route().Path]
+	return ok && method == c.Method()
+}
+
+// writePasswordChangeRequired refuses the request and points the client at the password change endpoint
+func writePasswordChangeRequired(c fiber.Ctx) error {
+	c.Set(fiber.HeaderLocation, PasswordChangePath)
+	return apierror.Write(c, apierror.New(fiber.StatusForbidden, apierror.CodeAuthPasswordChangeRequired, "Change your temporary password before continuing").Translate(apierror.CodeAuthPasswordChangeRequired))
+}
+
+// QueryTokenAuth lets a route take its bearer token from the access_token query parameter,
+// because browser EventSource connections cannot send headers. A header takes precedence.
+func QueryTokenAuth() fiber.Handler {
+	return func(c fiber.Ctx) error {
+		if token := c.Query("access_token"); token != "" && c.Get("Authorization") == "" {
+			c.Request().Header.Set("Authorization", "Bearer "+token)
+		}
 		return c.Next()
 	}
 }
 
+func authenticateApiToken(c fiber.Ctx, plaintext string, tokenService *services.ApiTokenService, userService *services.UserService) error {
+	token, err := tokenService.Authenticate(plaintext)
+	if err != nil {
+		if services.IsUserDBUnavailable(err) {
+			return apierror.Write(c, apierror.New(fiber.StatusServiceUnavailable, apierror.CodeUserDBUnavailable, "User service is unavailable").Translate(apierror.CodeUserDBUnavailable))
+		}
+		logger.Warn("API token authentication failed", zap.Error(err))
+		return apierror.Write(c, apierror.New(fiber.StatusUnauthorized, apierror.CodeAuthInvalidToken, "Invalid authentication token").Translate(apierror.CodeAuthInvalidToken))
+	}
+
+	user, err := userService.GetUserByID(token.UserID)
+	if err != nil {
+		return apierror.Write(c, apierror.New(fiber.StatusUnauthorized, apierror.CodeAuthInvalidToken, "Invalid authentication token").Translate(apierror.CodeAuthInvalidToken))
+	}
+
+	if user.MustChangePassword {
+		return writePasswordChangeRequired(c)
+	}
+
+	// Scopes are enforced here, once for every route, so handlers and services need no token state
+	networkID, permission := requiredTokenPermission(c)
+	if err := services.NewApiTokenScope(token).Authorize(networkID, permission); err != nil {
+		return apierror.Write(c, apierror.New(fiber.StatusForbidden, apierror.CodeAuthInsufficientScope, err.Error()).Translate(apierror.CodeAuthInsufficientScope))
+	}
+
+	c.Locals("user_id", user.ID)
+	c.Locals("username", user.Username)
+	c.Locals("role", user.Role)
+	c.Locals("api_token_id", token.ID)
+
+	return c.Next()
+}
+
+// tokenPermissionKey holds the API token permission a route declared with TokenPermission
+const tokenPermissionKey = "token_permission"
+
+// TokenPermission declares the API token permission a route needs. It goes before the auth
+// middleware; routes without it need read for safe methods and write otherwise.
+func TokenPermission(permission string) fiber.Handler {
+	return func(c fiber.Ctx) error {
+		c.Locals(tokenPermissionKey, permission)
+		return c.Next()
+	}
+}
+
+// requiredTokenPermission returns the network a route acts on and the permission it declared
+func requiredTokenPermission(c fiber.Ctx) (networkID string, permission string) {
+	if strings.Contains(c.Route().Path, "/networks/:id") {
+		networkID = c.Params("id")
+	}
+
+	if declared, ok := c.Locals(tokenPermissionKey).(string); ok && declared != "" {
+		return networkID, declared
+	}
+	switch c.Method() {
+	case fiber.MethodGet, fiber.MethodHead, fiber.MethodOptions:
+		return networkID, services.ApiTokenScopeRead
+	}
+	return networkID, services.ApiTokenScopeWrite
+}
+
 // AdminRequiredWithUserService is the admin authorization middleware.
 // It checks the database on every request to detect stale tokens after admin transfers.
 func AdminRequiredWithUserService(userService *services.UserService) fiber.Handler {
 	return func(c fiber.Ctx) error {
 		userID, exists := c.Locals("user_id").(string)
 		if !exists || userID == "" {
-			return c.Status(fiber.StatusForbidden).JSON(ErrorResponse{
-				Error:     "Forbidden",
-				Message:   "Authentication required",
-				ErrorCode: "auth.required",
-				Code:      fiber.StatusForbidden,
-			})
+			return apierror.Write(c, apierror.New(fiber.StatusForbidden, apierror.CodeAuthRequired, "Authentication required").Translate(apierror.CodeAuthRequired))
 		}
 
 		if userService == nil {
-			return c.Status(fiber.StatusServiceUnavailable).JSON(ErrorResponse{
-				Error:     "Service Unavailable",
-				Message:   "User service is unavailable",
-				ErrorCode: "system.user_service_unavailable",
-				Code:      fiber.StatusServiceUnavailable,
-			})
+			return apierror.Write(c, apierror.New(fiber.StatusServiceUnavailable, apierror.CodeSystemUserServiceUnavailable, "User service is unavailable").Translate(apierror.CodeSystemUserServiceUnavailable))
 		}
 
 		user, err := userService.GetUserByID(userID)
 		if err != nil {
 			if services.IsUserDBUnavailable(err) {
 				logger.Error("Administrator authorization failed because user database is unavailable", zap.Error(err))
-				return c.Status(fiber.StatusServiceUnavailable).JSON(ErrorResponse{
-					Error:     "Service Unavailable",
-					Message:   "User service is unavailable",
-					ErrorCode: "user.db_unavailable",
-					Code:      fiber.StatusServiceUnavailable,
-				})
+				return apierror.Write(c, apierror.New(fiber.StatusServiceUnavailable, apierror.CodeUserDBUnavailable, "User service is unavailable").Translate(apierror.CodeUserDBUnavailable))
 			}
-			return c.Status(fiber.StatusForbidden).JSON(ErrorResponse{
-				Error:     "Forbidden",
-				Message:   "Administrator permission required",
-				ErrorCode: "auth.admin_required",
-				Code:      fiber.StatusForbidden,
-			})
+			return apierror.Write(c, apierror.New(fiber.StatusForbidden, apierror.CodeAuthAdminRequired, "Administrator permission required").Translate(apierror.CodeAuthAdminRequired))
 		}
 
 		if user.Role != "admin" {
-			return c.Status(fiber.StatusForbidden).JSON(ErrorResponse{
-				Error:     "Forbidden",
-				Message:   "Administrator permission required",
-				ErrorCode: "auth.admin_required",
-				Code:      fiber.StatusForbidden,
-			})
+			return apierror.Write(c, apierror.New(fiber.StatusForbidden, apierror.CodeAuthAdminRequired, "Administrator permission required").Translate(apierror.CodeAuthAdminRequired))
 		}
 
 		c.Locals("role", user.Role)
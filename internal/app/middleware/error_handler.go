@@ -2,46 +2,33 @@ package middleware
 
 import (
 	"errors"
-	"net/http"
 
-	"github.com/GT-610/tairitsu/internal/app/httpcode"
+	"github.com/GT-610/tairitsu/internal/app/apierror"
 	"github.com/GT-610/tairitsu/internal/app/logger"
 	"github.com/gofiber/fiber/v3"
 	"go.uber.org/zap"
 )
 
-// ErrorResponse represents an error response structure
-type ErrorResponse struct {
-	Error     string `json:"error"`
-	Message   string `json:"message,omitempty"`
-	ErrorCode string `json:"error_code,omitempty"`
-	Code      int    `json:"code,omitempty"`
-}
-
-// ErrorHandler is the global error handling middleware
+// ErrorHandler is the global error handling middleware. Errors returned by handlers are
+// answered with the apierror envelope; anything unexpected becomes a 500 without internals.
 func ErrorHandler() fiber.Handler {
 	return func(c fiber.Ctx) error {
 		err := c.Next()
 		if err != nil {
-			logger.Error("API error", zap.Error(err))
+			logger.WithRequestID(c).Error("API error", zap.Error(err))
+
+			var apiErr *apierror.Error
+			if errors.As(err, &apiErr) {
+				return apierror.Write(c, apiErr)
+			}
 
 			var fiberErr *fiber.Error
 			if errors.As(err, &fiberErr) {
-				return c.Status(fiberErr.Code).JSON(ErrorResponse{
-					Error:     http.StatusText(fiberErr.Code),
-					Message:   fiberErr.Message,
-					ErrorCode: httpcode.DefaultErrorCode(fiberErr.Code),
-					Code:      fiberErr.Code,
-				})
+				return apierror.Write(c, apierror.FromStatus(fiberErr.Code, fiberErr.Message))
 			}
 
 			// Return error response
-			return c.Status(fiber.StatusInternalServerError).JSON(ErrorResponse{
-				Error:     "Internal Server Error",
-				Message:   "Internal Server Error",
-				ErrorCode: "system.internal_error",
-				Code:      fiber.StatusInternalServerError,
-			})
+			return apierror.Write(c, apierror.Localized(fiber.StatusInternalServerError, apierror.CodeSystemInternalError))
 		}
 		return nil
 	}
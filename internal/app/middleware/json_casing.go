@@ -0,0 +1,199 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+
+	"github.com/gofiber/fiber/v3"
+)
+
+// LegacyJSONFieldNames maps every camelCase API field renamed from snake_case to its former name.
+// docs/api/JSON_Field_Names.md documents the same mapping for integrators.
+var LegacyJSONFieldNames = map[string]string{
+	"actorId":                 "actor_id",
+	"allowPublicRegistration": "allow_public_registration",
+	"apiToken":                "api_token",
+	"authorizedMemberCount":   "authorized_member_count",
+	"birthTime":               "birth_time",
+	"brokenEntryId":           "broken_entry_id",
+	"canImport":               "can_import",
+	"checkedAt":               "checked_at",
+	"checkedCount":            "checked_count",
+	"confirmPassword":         "confirm_password",
+	"controllerStatus":        "controller_status",
+	"createdAt":               "created_at",
+	"currentExists":           "current_exists",
+	"currentKeyPath":          "current_key_path",
+	"currentPassword":         "current_password",
+	"dbDescription":           "db_description",
+	"downloadName":            "download_name",
+	"endpointCount":           "endpoint_count",
+	"errorCode":               "error_code",
+	"expiresAt":               "expires_at",
+	"failedRules":             "failed_rules",
+	"generatedAt":             "generated_at",
+	"grantedBy":               "granted_by",
+	"identityPath":            "identity_path",
+	"identityPublic":          "identity_public",
+	"lastResult":              "last_result",
+	"latencyMs":               "latency_ms",
+	"logoText":                "logo_text",
+	"logoutOtherSessions":     "logout_other_sessions",
+	"maxLength":               "max_length",
+	"memberCount":             "member_count",
+	"messageCode":             "message_code",
+	"minLength":               "min_length",
+	"networkId":               "network_id",
+	"networkIds":              "network_ids",
+	"newPassword":             "new_password",
+	"onlineNodes":             "online_nodes",
+	"ownerId":                 "owner_id",
+	"ownerUsername":           "owner_username",
+	"pendingMemberCount":      "pending_member_count",
+	"physicalAddressPolicy":   "physical_address_policy",
+	"planetData":              "planet_data",
+	"planetId":                "planet_id",
+	"prevHash":                "prev_hash",
+	"previousExists":          "previous_exists",
+	"previousKeyPath":         "previous_key_path",
+	"reasonCode":              "reason_code",
+	"reasonMessage":           "reason_message",
+	"recommendValues":         "recommend_values",
+	"rejectUsername":          "reject_username",
+	"rememberMe":              "remember_me",
+	"requireDigit":            "require_digit",
+	"requireLower":            "require_lower",
+	"requireSymbol":           "require_symbol",
+	"requireUpper":            "require_upper",
+	"revokedOtherSessions":    "revoked_other_sessions",
+	"revokedSessions":         "revoked_sessions",
+	"rfc4193Enabled":          "rfc4193_enabled",
+	"rfc4193Prefix":           "rfc4193_prefix",
+	"rootNodeCount":           "root_node_count",
+	"rootNodes":               "root_nodes",
+	"signingKeyPath":          "signing_key_path",
+	"sixPlaneEnabled":         "6plane_enabled",
+	"sixPlanePrefix":          "6plane_prefix",
+	"statusPagePublished":     "status_page_published",
+	"targetOwner":             "target_owner",
+	"temporaryPassword":       "temporary_password",
+	"totalNodes":              "total_nodes",
+	"transferredNetworks":     "transferred_networks",
+	"updatedAt":               "updated_at",
+	"usedRecommendedValues":   "used_recommended_values",
+	"userId":                  "user_id",
+	"verifiedAt":              "verified_at",
+}
+
+// LegacyJSONFields keeps clients written against the snake_case API working for one release:
+// JSON request bodies may use the former names, and JSON responses carry both names of every
+// renamed field. When disabled it only passes requests through.
+func LegacyJSONFields(enabled bool) fiber.Handler {
+	if !enabled {
+		return func(c fiber.Ctx) error {
+			return c.Next()
+		}
+	}
+
+	camelNames := make(map[string]string, len(LegacyJSONFieldNames))
+	for camel, snake := range LegacyJSONFieldNames {
+		camelNames[snake] = camel
+	}
+
+	return func(c fiber.Ctx) error {
+		if isJSONContent(c.Get(fiber.HeaderContentType)) {
+			if body, ok := rewriteJSONFields(c.Body(), func(fields map[string]any) bool {
+				return renameLegacyFields(fields, camelNames)
+			}); ok {
+				c.Request().SetBody(body)
+			}
+		}
+
+		if err := c.Next(); err != nil {
+			return err
+		}
+
+		// Streamed downloads are left alone; reading them here would buffer the whole body
+		if isJSONContent(string(c.Response().Header.ContentType())) && !c.Response().IsBodyStream() {
+			if body, ok := rewriteJSONFields(c.Response().Body(), addLegacyFields); ok {
+				c.Response().SetBodyRaw(body)
+			}
+		}
+		return nil
+	}
+}
+
+func isJSONContent(contentType string) bool {
+	return strings.HasPrefix(strings.ToLower(contentType), fiber.MIMEApplicationJSON)
+}
+
+// rewriteJSONFields applies rewrite to every object in a JSON document and reports whether anything changed
+func rewriteJSONFields(body []byte, rewrite func(map[string]any) bool) ([]byte, bool) {
+	if len(body) == 0 {
+		return nil, false
+	}
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	var document any
+	if err := decoder.Decode(&document); err != nil {
+		return nil, false
+	}
+	if !walkJSONObjects(document, rewrite) {
+		return nil, false
+	}
+	rewritten, err := json.Marshal(document)
+	if err != nil {
+		return nil, false
+	}
+	return rewritten, true
+}
+
+func walkJSONObjects(value any, rewrite func(map[string]any) bool) bool {
+	changed := false
+	switch typed := value.(type) {
+	case map[string]any:
+		for _, child := range typed {
+			changed = walkJSONObjects(child, rewrite) || changed
+		}
+		changed = rewrite(typed) || changed
+	case []any:
+		for _, child := range typed {
+			changed = walkJSONObjects(child, rewrite) || changed
+		}
+	}
+	return changed
+}
+
+// renameLegacyFields moves snake_case request fields to their camelCase names unless both are present
+func renameLegacyFields(fields map[string]any, camelNames map[string]string) bool {
+	changed := false
+	for key, value := range fields {
+		camel, renamed := camelNames[key]
+		if !renamed {
+			continue
+		}
+		if _, exists := fields[camel]; !exists {
+			fields[camel] = value
+		}
+		delete(fields, key)
+		changed = true
+	}
+	return changed
+}
+
+// addLegacyFields duplicates renamed response fields under their snake_case names
+func addLegacyFields(fields map[string]any) bool {
+	changed := false
+	for key, value := range fields {
+		snake, renamed := LegacyJSONFieldNames[key]
+		if !renamed {
+			continue
+		}
+		if _, exists := fields[snake]; !exists {
+			fields[snake] = value
+			changed = true
+		}
+	}
+	return changed
+}
@@ -0,0 +1,16 @@
+package middleware
+
+import (
+	"github.com/GT-610/tairitsu/internal/app/i18n"
+	"github.com/gofiber/fiber/v3"
+)
+
+// Locale picks the language of API messages from Accept-Language and stores it in Locals
+// under i18n.LocaleKey. Requests without a supported language get i18n.DefaultLocale.
+func Locale() fiber.Handler {
+	return func(c fiber.Ctx) error {
+		c.Locals(i18n.LocaleKey, i18n.Negotiate(c.Get(fiber.HeaderAcceptLanguage)))
+		c.Vary(fiber.HeaderAcceptLanguage)
+		return c.Next()
+	}
+}
@@ -32,7 +32,7 @@ func Logger() fiber.Handler {
 		clientIP := c.IP()
 
 		// Log format
-		logger.Info("FIBER Request",
+		logger.WithRequestID(c).Info("FIBER Request",
 			zap.String("method", method),
 			zap.Int("status", statusCode),
 			zap.Duration("latency", latency),
@@ -0,0 +1,39 @@
+package middleware
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/GT-610/tairitsu/internal/app/apierror"
+	"github.com/GT-610/tairitsu/internal/app/services"
+	"github.com/gofiber/fiber/v3"
+)
+
+const (
+	// maintenanceWriteWait is how long a write waits for maintenance to finish before giving up
+	maintenanceWriteWait = 10 * time.Second
+	// maintenanceRetryAfter is suggested to clients whose write was turned away
+	maintenanceRetryAfter = 30 * time.Second
+)
+
+// PauseWritesDuringMaintenance holds state-changing requests while a maintenance job runs and
+// answers 503 with system.maintenance when it does not finish in time. Reads are never held.
+func PauseWritesDuringMaintenance(mode *services.MaintenanceMode) fiber.Handler {
+	return func(c fiber.Ctx) error {
+		if mode == nil {
+			return c.Next()
+		}
+		switch c.Method() {
+		case fiber.MethodGet, fiber.MethodHead, fiber.MethodOptions:
+			return c.Next()
+		}
+
+		release, ok := mode.AcquireWrite(maintenanceWriteWait)
+		if !ok {
+			c.Set(fiber.HeaderRetryAfter, strconv.Itoa(int(maintenanceRetryAfter.Seconds())))
+			return apierror.Write(c, apierror.New(fiber.StatusServiceUnavailable, apierror.CodeSystemMaintenance, "Maintenance is in progress. Try again shortly.").Translate(apierror.CodeSystemMaintenance))
+		}
+		defer release()
+		return c.Next()
+	}
+}
@@ -1,10 +1,14 @@
 package middleware
 
 import (
+	"encoding/json"
+	"sort"
 	"strings"
 	"sync"
 	"time"
 
+	"github.com/GT-610/tairitsu/internal/app/apierror"
+	"github.com/GT-610/tairitsu/internal/app/config"
 	"github.com/GT-610/tairitsu/internal/app/logger"
 	"github.com/gofiber/fiber/v3"
 	"go.uber.org/zap"
@@ -15,20 +19,29 @@ import (
 
 // TokenBucket represents a token bucket structure
 type TokenBucket struct {
-	capacity    int        // Bucket capacity
-	tokens      int        // Current number of tokens
-	refillRate  int        // Tokens added per second
-	lastRefill  time.Time  // Last time tokens were refilled
-	refillMutex sync.Mutex // Mutex for refilling tokens
+	capacity     int           // Bucket capacity
+	tokens       int           // Current number of tokens
+	refillRate   int           // Tokens added per refill period
+	refillPeriod time.Duration // A second unless the policy refills per minute
+	lastRefill   time.Time     // Last time tokens were refilled
+	lastSeen     time.Time     // Last time a token was requested
+	refillMutex  sync.Mutex    // Mutex for refilling tokens
 }
 
-// NewTokenBucket creates a new token bucket
+// NewTokenBucket creates a new token bucket that refills every second
 func NewTokenBucket(capacity, refillRate int) *TokenBucket {
+	return newTokenBucket(capacity, refillRate, time.Second)
+}
+
+func newTokenBucket(capacity, refillRate int, refillPeriod time.Duration) *TokenBucket {
+	now := time.Now()
 	return &TokenBucket{
-		capacity:   capacity,
-		tokens:     capacity, // Initially full
-		refillRate: refillRate,
-		lastRefill: time.Now(),
+		capacity:     capacity,
+		tokens:       capacity, // Initially full
+		refillRate:   refillRate,
+		refillPeriod: refillPeriod,
+		lastRefill:   now,
+		lastSeen:     now,
 	}
 }
 
@@ -40,8 +53,9 @@ func (tb *TokenBucket) GetToken() bool {
 
 	// Refill tokens
 	now := time.Now()
+	tb.lastSeen = now
 	duration := now.Sub(tb.lastRefill)
-	tokensToAdd := int(duration.Seconds()) * tb.refillRate
+	tokensToAdd := int(duration/tb.refillPeriod) * tb.refillRate
 
 	if tokensToAdd > 0 {
 		// Update token count, capped at capacity
@@ -62,58 +76,136 @@ func (tb *TokenBucket) GetToken() bool {
 	return false
 }
 
+func (tb *TokenBucket) idleSince(now time.Time) time.Duration {
+	tb.refillMutex.Lock()
+	defer tb.refillMutex.Unlock()
+	return now.Sub(tb.lastSeen)
+}
+
 // RateLimiter is the rate limiter
 type RateLimiter struct {
-	buckets     map[string]*TokenBucket // IP address to token bucket mapping
-	bucketMutex sync.RWMutex            // Mutex for accessing buckets
-	capacity    int                     // Default bucket capacity
-	refillRate  int                     // Default tokens added per second
-	maxBuckets  int                     // Maximum number of tracked IPs
-	denyBucket  *TokenBucket            // Reusable zero-token bucket for over-capacity IPs
+	buckets      map[string]*TokenBucket // IP address to token bucket mapping
+	bucketMutex  sync.RWMutex            // Mutex for accessing buckets and the limits
+	capacity     int                     // Capacity of new buckets
+	refillRate   int                     // Tokens added per refill period to new buckets
+	refillPeriod time.Duration           // Fixed when the limiter is created
+	maxBuckets   int                     // Maximum number of tracked IPs
+	denyBucket   *TokenBucket            // Reusable zero-token bucket for over-capacity IPs
+	idleTTL      time.Duration           // Buckets unused for longer are evicted
+	stop         chan struct{}           // Closed by Stop to end the janitor
+	stopOnce     sync.Once
 }
 
-// NewRateLimiter creates a new rate limiter
+// defaultBucketIdleTTL is how long an IP's bucket is kept after its last request
+const defaultBucketIdleTTL = 10 * time.Minute
+
+// maxJanitorInterval bounds how often the janitor scans for idle buckets
+const maxJanitorInterval = 5 * time.Minute
+
+// NewRateLimiter creates a new rate limiter whose buckets are evicted after ten idle minutes
 func NewRateLimiter(capacity, refillRate int) *RateLimiter {
+	return NewRateLimiterWithTTL(capacity, refillRate, defaultBucketIdleTTL)
+}
+
+// NewRateLimiterWithTTL creates a new rate limiter whose background janitor evicts buckets
+// idle for longer than idleTTL. Call Stop to end the janitor.
+func NewRateLimiterWithTTL(capacity, refillRate int, idleTTL time.Duration) *RateLimiter {
+	if idleTTL <= 0 {
+		idleTTL = defaultBucketIdleTTL
+	}
 	rl := &RateLimiter{
-		buckets:    make(map[string]*TokenBucket),
-		capacity:   capacity,
-		refillRate: refillRate,
-		maxBuckets: 10000,
-		denyBucket: NewTokenBucket(0, 0),
+		buckets:      make(map[string]*TokenBucket),
+		capacity:     capacity,
+		refillRate:   refillRate,
+		refillPeriod: time.Second,
+		maxBuckets:   10000,
+		denyBucket:   NewTokenBucket(0, 0),
+		idleTTL:      idleTTL,
+		stop:         make(chan struct{}),
 	}
-	go rl.cleanupLoop()
+	go rl.janitor(min(idleTTL/2, maxJanitorInterval))
 	return rl
 }
 
-const cleanupInterval = 5 * time.Minute
-const bucketStaleThreshold = 10 * time.Minute
-
-func (rl *RateLimiter) cleanupLoop() {
-	ticker := time.NewTicker(cleanupInterval)
+func (rl *RateLimiter) janitor(interval time.Duration) {
+	ticker := time.NewTicker(max(interval, time.Millisecond))
 	defer ticker.Stop()
-	for range ticker.C {
-		rl.cleanupStale()
+	for {
+		select {
+		case <-rl.stop:
+			return
+		case <-ticker.C:
+			rl.evictIdle(time.Now())
+		}
 	}
 }
 
-func (rl *RateLimiter) cleanupStale() {
+// evictIdle removes the buckets of IPs that sent no request within the idle TTL
+func (rl *RateLimiter) evictIdle(now time.Time) int {
 	rl.bucketMutex.Lock()
 	defer rl.bucketMutex.Unlock()
-	now := time.Now()
+	evicted := 0
 	for ip, bucket := range rl.buckets {
-		bucket.refillMutex.Lock()
-		stale := now.Sub(bucket.lastRefill) > bucketStaleThreshold
-		bucket.refillMutex.Unlock()
-		if stale {
+		if bucket.idleSince(now) > rl.idleTTL {
 			delete(rl.buckets, ip)
+			evicted++
 		}
 	}
+	return evicted
+}
+
+// Stop ends the background janitor; the limiter keeps limiting but no longer evicts buckets
+func (rl *RateLimiter) Stop() {
+	rl.stopOnce.Do(func() {
+		close(rl.stop)
+	})
+}
+
+// Reset forgets every client's bucket, so all clients start over with a full bucket
+func (rl *RateLimiter) Reset() {
+	rl.bucketMutex.Lock()
+	defer rl.bucketMutex.Unlock()
+	clear(rl.buckets)
+}
+
+// BucketCount returns the number of client IPs currently tracked
+func (rl *RateLimiter) BucketCount() int {
+	rl.bucketMutex.RLock()
+	defer rl.bucketMutex.RUnlock()
+	return len(rl.buckets)
+}
+
+// RefillPeriod returns how often the limiter's buckets regain their refill rate
+func (rl *RateLimiter) RefillPeriod() time.Duration {
+	return rl.refillPeriod
+}
+
+// Limits returns the capacity and refill rate of the limiter
+func (rl *RateLimiter) Limits() (capacity, refillRate int) {
+	rl.bucketMutex.RLock()
+	defer rl.bucketMutex.RUnlock()
+	return rl.capacity, rl.refillRate
+}
+
+// SetLimits changes the capacity and refill rate of the limiter, including the buckets of
+// clients it already tracks. Buckets holding more tokens than the new capacity are trimmed.
+func (rl *RateLimiter) SetLimits(capacity, refillRate int) {
+	rl.bucketMutex.Lock()
+	defer rl.bucketMutex.Unlock()
+	rl.capacity, rl.refillRate = capacity, refillRate
+	for _, bucket := range rl.buckets {
+		bucket.refillMutex.Lock()
+		bucket.capacity, bucket.refillRate = capacity, refillRate
+		bucket.tokens = min(bucket.tokens, capacity)
+		bucket.refillMutex.Unlock()
+	}
 }
 
 // GetBucket retrieves or creates the token bucket for the given IP
 func (rl *RateLimiter) GetBucket(ip string) *TokenBucket {
 	rl.bucketMutex.RLock()
 	bucket, exists := rl.buckets[ip]
+	capacity, refillRate := rl.capacity, rl.refillRate
 	rl.bucketMutex.RUnlock()
 
 	if exists {
@@ -121,7 +213,7 @@ func (rl *RateLimiter) GetBucket(ip string) *TokenBucket {
 	}
 
 	// Create a new token bucket
-	newBucket := NewTokenBucket(rl.capacity, rl.refillRate)
+	newBucket := newTokenBucket(capacity, refillRate, rl.refillPeriod)
 
 	rl.bucketMutex.Lock()
 	// Double-check: another goroutine may have inserted this IP
@@ -142,15 +234,146 @@ func (rl *RateLimiter) GetBucket(ip string) *TokenBucket {
 	return newBucket
 }
 
-// DefaultRateLimiter is the default rate limiter instance
-var DefaultRateLimiter = NewRateLimiter(100, 10) // 100 tokens, refills 10 per second
+// Names of the built-in rate limit policies
+const (
+	RateLimitPolicyDefault     = "default"
+	RateLimitPolicyRead        = "read"
+	RateLimitPolicyAuth        = "auth"
+	RateLimitPolicyStatusPage  = "status_page"
+	RateLimitPolicyTraceIngest = "trace_ingest"
+	// The credential policies refill per minute
+	RateLimitPolicyLogin         = "login"
+	RateLimitPolicyLoginUsername = "login_username"
+)
+
+// RateLimitPolicy describes a named rate limiter and how many client IPs it tracks
+type RateLimitPolicy struct {
+	Name                string `json:"name"`
+	Capacity            int    `json:"capacity"`
+	RefillRate          int    `json:"refillRate"`
+	RefillPeriodSeconds int    `json:"refillPeriodSeconds"`
+	Buckets             int    `json:"buckets"`
+}
+
+var (
+	policyMutex sync.Mutex
+	policies    = make(map[string]*RateLimiter)
+	// builtInLimits are the limits each policy was registered with, restored when
+	// config.json stops overriding them
+	builtInLimits = make(map[string]config.RateLimitConfig)
+)
+
+// RateLimiterForPolicy returns the limiter of a named policy, creating it on first use. The
+// first registration of a name fixes its built-in capacity and refill rate.
+func RateLimiterForPolicy(name string, capacity, refillRate int) *RateLimiter {
+	return RateLimiterForPolicyPer(name, capacity, refillRate, time.Second)
+}
+
+// RateLimiterForPolicyPer is RateLimiterForPolicy for a policy that regains refillRate tokens
+// every refillPeriod instead of every second
+func RateLimiterForPolicyPer(name string, capacity, refillRate int, refillPeriod time.Duration) *RateLimiter {
+	policyMutex.Lock()
+	defer policyMutex.Unlock()
+	if limiter, exists := policies[name]; exists {
+		return limiter
+	}
+	limiter := NewRateLimiter(capacity, refillRate)
+	limiter.refillPeriod = refillPeriod
+	policies[name] = limiter
+	builtInLimits[name] = config.RateLimitConfig{Capacity: capacity, RefillRate: refillRate}
+	return limiter
+}
+
+// ApplyRateLimitSettings applies the rate_limits section of config.json to the registered
+// policies. Policies it does not mention, and zero values, get their built-in limits back.
+func ApplyRateLimitSettings(settings map[string]config.RateLimitConfig) {
+	policyMutex.Lock()
+	defer policyMutex.Unlock()
+	for name := range settings {
+		if _, exists := policies[name]; !exists {
+			logger.Warn("ignored rate limit for an unknown policy", zap.String("policy", name))
+		}
+	}
+	for name, limiter := range policies {
+		limits := builtInLimits[name]
+		if override := settings[name]; override.Capacity > 0 {
+			limits.Capacity = override.Capacity
+		}
+		if override := settings[name]; override.RefillRate > 0 {
+			limits.RefillRate = override.RefillRate
+		}
+		if capacity, refillRate := limiter.Limits(); capacity != limits.Capacity || refillRate != limits.RefillRate {
+			limiter.SetLimits(limits.Capacity, limits.RefillRate)
+			logger.Info("updated rate limit policy", zap.String("policy", name),
+				zap.Int("capacity", limits.Capacity), zap.Int("refill_rate", limits.RefillRate))
+		}
+	}
+}
+
+// RateLimitPolicies lists the registered policies by name
+func RateLimitPolicies() []RateLimitPolicy {
+	policyMutex.Lock()
+	defer policyMutex.Unlock()
+	list := make([]RateLimitPolicy, 0, len(policies))
+	for name, limiter := range policies {
+		capacity, refillRate := limiter.Limits()
+		list = append(list, RateLimitPolicy{
+			Name:                name,
+			Capacity:            capacity,
+			RefillRate:          refillRate,
+			RefillPeriodSeconds: int(limiter.RefillPeriod() / time.Second),
+			Buckets:             limiter.BucketCount(),
+		})
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Name < list[j].Name
+	})
+	return list
+}
+
+// DefaultRateLimiter limits requests that no other policy covers
+var DefaultRateLimiter = RateLimiterForPolicy(RateLimitPolicyDefault, 100, 10) // 100 tokens, refills 10 per second
+
+// ReadRateLimiter is the generous limiter for authenticated reads such as member listing
+var ReadRateLimiter = RateLimiterForPolicy(RateLimitPolicyRead, 600, 60) // 600 tokens, refills 60 per second
 
 // AuthRateLimiter is a stricter rate limiter for authentication endpoints
-var AuthRateLimiter = NewRateLimiter(20, 2) // 20 tokens, refills 2 per second
+var AuthRateLimiter = RateLimiterForPolicy(RateLimitPolicyAuth, 10, 1) // 10 tokens, refills 1 per second
+
+// StatusPageRateLimiter throttles the unauthenticated status page
+var StatusPageRateLimiter = RateLimiterForPolicy(RateLimitPolicyStatusPage, 30, 1) // 30 tokens, refills 1 per second
+
+// TraceIngestRateLimiter allows trace forwarders to post continuously
+var TraceIngestRateLimiter = RateLimiterForPolicy(RateLimitPolicyTraceIngest, 600, 100) // 600 tokens, refills 100 per second
+
+// LoginRateLimiter limits password sign-ins, registrations and password resets per client IP
+var LoginRateLimiter = RateLimiterForPolicyPer(RateLimitPolicyLogin, 5, 5, time.Minute) // 5 tokens, refills 5 per minute
+
+// LoginUsernameRateLimiter limits the same requests per username, from whatever addresses they come
+var LoginUsernameRateLimiter = RateLimiterForPolicyPer(RateLimitPolicyLoginUsername, 5, 5, time.Minute) // 5 tokens, refills 5 per minute
+
+// controllerTraceIngestPath is limited by TraceIngestRateLimiter instead of the default limiter
+const controllerTraceIngestPath = "/api/admin/controller/trace"
 
-// RateLimit is the API rate limiting middleware
+// RateLimit is the API rate limiting middleware. Reads carrying credentials use the
+// generous read policy; the token itself is checked later by the auth middleware.
 func RateLimit() fiber.Handler {
-	return rateLimitHandler(DefaultRateLimiter)
+	limit := rateLimitHandler(DefaultRateLimiter)
+	limitRead := rateLimitHandler(ReadRateLimiter)
+	return func(c fiber.Ctx) error {
+		if isControllerTraceIngest(c) {
+			return c.Next()
+		}
+		if isAuthenticatedRead(c) {
+			return limitRead(c)
+		}
+		return limit(c)
+	}
+}
+
+// RateLimitWithPolicy limits a route with a named policy shared by every route using the name
+func RateLimitWithPolicy(name string, capacity, refillRate int) fiber.Handler {
+	return rateLimitHandler(RateLimiterForPolicy(name, capacity, refillRate))
 }
 
 // AuthRateLimit is the stricter rate limiting middleware for auth endpoints
@@ -158,6 +381,57 @@ func AuthRateLimit() fiber.Handler {
 	return rateLimitHandler(AuthRateLimiter)
 }
 
+// CredentialRateLimit is the strictest rate limiting middleware, for requests that check or set
+// a password. It limits each client IP, and each username or email in the JSON body so that
+// guessing one account's password from many addresses is limited too.
+func CredentialRateLimit() fiber.Handler {
+	return func(c fiber.Ctx) error {
+		clientIP := strings.Clone(c.IP())
+		if !LoginRateLimiter.GetBucket(clientIP).GetToken() {
+			return rejectRateLimited(c, clientIP)
+		}
+		if account := credentialAccount(c); account != "" && !LoginUsernameRateLimiter.GetBucket(account).GetToken() {
+			return rejectRateLimited(c, clientIP)
+		}
+		return c.Next()
+	}
+}
+
+// credentialAccount returns the lowercased username, or else email, of a JSON request body
+func credentialAccount(c fiber.Ctx) string {
+	var body struct {
+		Username string `json:"username"`
+		Email    string `json:"email"`
+	}
+	// A body that does not parse is rejected by the handler
+	_ = json.Unmarshal(c.Body(), &body)
+	if account := strings.TrimSpace(body.Username); account != "" {
+		return strings.ToLower(account)
+	}
+	return strings.ToLower(strings.TrimSpace(body.Email))
+}
+
+// StatusPageRateLimit is the rate limiting middleware for the public status page
+func StatusPageRateLimit() fiber.Handler {
+	return rateLimitHandler(StatusPageRateLimiter)
+}
+
+// TraceIngestRateLimit is the high-volume rate limiting middleware for controller trace ingestion
+func TraceIngestRateLimit() fiber.Handler {
+	return rateLimitHandler(TraceIngestRateLimiter)
+}
+
+func isAuthenticatedRead(c fiber.Ctx) bool {
+	if c.Method() != fiber.MethodGet && c.Method() != fiber.MethodHead {
+		return false
+	}
+	return c.Get(fiber.HeaderAuthorization) != "" || c.Query("access_token") != ""
+}
+
+func isControllerTraceIngest(c fiber.Ctx) bool {
+	return c.Method() == fiber.MethodPost && c.Path() == controllerTraceIngestPath
+}
+
 func RateLimitWithLimiter(limiter *RateLimiter) fiber.Handler {
 	return rateLimitHandler(limiter)
 }
@@ -168,16 +442,15 @@ func rateLimitHandler(limiter *RateLimiter) fiber.Handler {
 		bucket := limiter.GetBucket(clientIP)
 
 		if !bucket.GetToken() {
-			logger.Warn("API rate limit triggered", zap.String("client_ip", clientIP), zap.String("path", c.Path()))
-
-			return c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{
-				"error":      "Too many requests. Please try again later.",
-				"message":    "Too many requests. Please try again later.",
-				"error_code": "system.rate_limited",
-				"code":       fiber.StatusTooManyRequests,
-			})
+			return rejectRateLimited(c, clientIP)
 		}
 
 		return c.Next()
 	}
 }
+
+func rejectRateLimited(c fiber.Ctx, clientIP string) error {
+	logger.Warn("API rate limit triggered", zap.String("client_ip", clientIP), zap.String("path", c.Path()))
+
+	return apierror.Write(c, apierror.New(fiber.StatusTooManyRequests, apierror.CodeSystemRateLimited, "Too many requests. Please try again later.").Translate(apierror.CodeSystemRateLimited))
+}
@@ -0,0 +1,48 @@
+package middleware
+
+import (
+	"github.com/GT-610/tairitsu/internal/app/logger"
+	"github.com/gofiber/fiber/v3"
+	"github.com/google/uuid"
+)
+
+// RequestIDHeader carries the request ID in both directions
+const RequestIDHeader = "X-Request-ID"
+
+// maxRequestIDLength bounds IDs taken from clients or proxies so they stay log friendly
+const maxRequestIDLength = 128
+
+// RequestID tags every request with an ID: a well-formed incoming X-Request-ID (for example from
+// a reverse proxy) is kept, otherwise a UUID is generated. The ID is stored in Locals under
+// logger.RequestIDKey and echoed in the response header.
+func RequestID() fiber.Handler {
+	return func(c fiber.Ctx) error {
+		requestID := c.Get(RequestIDHeader)
+		if !validRequestID(requestID) {
+			requestID = uuid.NewString()
+		}
+		c.Locals(logger.RequestIDKey, requestID)
+		c.Set(RequestIDHeader, requestID)
+		return c.Next()
+	}
+}
+
+// RequestIDFrom returns the ID assigned to the request by RequestID
+func RequestIDFrom(c fiber.Ctx) string {
+	return logger.RequestID(c)
+}
+
+func validRequestID(requestID string) bool {
+	if requestID == "" || len(requestID) > maxRequestIDLength {
+		return false
+	}
+	for _, r := range requestID {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+		case r == '-', r == '_', r == '.', r == ':', r == '/', r == '+', r == '=':
+		default:
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,68 @@
+package models
+
+import (
+	"strings"
+	"time"
+)
+
+// ApiToken is a personal access token that authenticates automation as its user.
+type ApiToken struct {
+	ID         string     `json:"id" gorm:"primaryKey"`
+	UserID     string     `json:"userId" gorm:"index;not null"`
+	Name       string     `json:"name"`
+	TokenHash  string     `json:"-" gorm:"uniqueIndex;not null"` // SHA-256 of the plaintext token
+	Scopes     string     `json:"scopes"`                        // Comma-separated scope list
+	NetworkIDs string     `json:"networkIds"`                    // Comma-separated networks the token is limited to; empty allows all
+	ExpiresAt  *time.Time `json:"expiresAt"`                     // Nil never expires
+	LastUsedAt *time.Time `json:"lastUsedAt"`
+	RevokedAt  *time.Time `json:"revokedAt"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	UpdatedAt  time.Time  `json:"updatedAt"`
+}
+
+// TableName returns the database table name for ApiToken.
+func (ApiToken) TableName() string {
+	return "api_tokens"
+}
+
+// ScopeList splits the stored scopes.
+func (t *ApiToken) ScopeList() []string {
+	if t.Scopes == "" {
+		return []string{}
+	}
+	return strings.Split(t.Scopes, ",")
+}
+
+// NetworkIDList splits the stored network restrictions; an empty list means unrestricted.
+func (t *ApiToken) NetworkIDList() []string {
+	if t.NetworkIDs == "" {
+		return []string{}
+	}
+	return strings.Split(t.NetworkIDs, ",")
+}
+
+// ApiTokenResponse is the API response shape for an API token; the plaintext is never included.
+type ApiTokenResponse struct {
+	ID         string     `json:"id"`
+	Name       string     `json:"name"`
+	Scopes     []string   `json:"scopes"`
+	NetworkIDs []string   `json:"networkIds"`
+	ExpiresAt  *time.Time `json:"expiresAt,omitempty"`
+	LastUsedAt *time.Time `json:"lastUsedAt,omitempty"`
+	RevokedAt  *time.Time `json:"revokedAt,omitempty"`
+	CreatedAt  time.Time  `json:"createdAt"`
+}
+
+// ToResponse converts an ApiToken to an ApiTokenResponse.
+func (t *ApiToken) ToResponse() ApiTokenResponse {
+	return ApiTokenResponse{
+		ID:         t.ID,
+		Name:       t.Name,
+		Scopes:     t.ScopeList(),
+		NetworkIDs: t.NetworkIDList(),
+		ExpiresAt:  t.ExpiresAt,
+		LastUsedAt: t.LastUsedAt,
+		RevokedAt:  t.RevokedAt,
+		CreatedAt:  t.CreatedAt,
+	}
+}
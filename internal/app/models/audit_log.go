@@ -0,0 +1,45 @@
+package models
+
+import "time"
+
+// AuditLog is a single entry in the hash-chained audit log.
+type AuditLog struct {
+	ID        uint64    `json:"id" gorm:"primaryKey;autoIncrement"`
+	ActorID   string    `json:"actorId" gorm:"index"`
+	Action    string    `json:"action"`
+	Target    string    `json:"target"`
+	Details   string    `json:"details"`
+	CreatedAt time.Time `json:"createdAt" gorm:"index"`
+	PrevHash  string    `json:"prevHash"`
+	Hash      string    `json:"hash"`
+}
+
+// TableName returns the database table name for AuditLog.
+func (AuditLog) TableName() string {
+	return "audit_logs"
+}
+
+// AuditAnchor records the last pruned audit entry so the remaining chain stays verifiable.
+type AuditAnchor struct {
+	ID             uint      `json:"id" gorm:"primaryKey"`
+	LastPrunedID   uint64    `json:"lastPrunedId"`
+	LastPrunedHash string    `json:"lastPrunedHash"`
+	UpdatedAt      time.Time `json:"updatedAt"`
+}
+
+// TableName returns the database table name for AuditAnchor.
+func (AuditAnchor) TableName() string {
+	return "audit_anchors"
+}
+
+// AuditLogQuery filters and bounds an audit log listing.
+type AuditLogQuery struct {
+	Action   string
+	ActorID  string
+	BeforeID uint64
+	AfterID  uint64
+	// Ascending lists oldest entries first; the default is newest first.
+	Ascending bool
+	Offset    int
+	Limit     int
+}
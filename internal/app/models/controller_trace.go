@@ -0,0 +1,35 @@
+package models
+
+import "time"
+
+// ControllerTraceEvent is one forwarded controller trace line. Parsed lines carry the network,
+// member and rule outcome they describe; unparseable lines are kept raw with Parsed unset.
+type ControllerTraceEvent struct {
+	ID         uint64    `json:"id" gorm:"primaryKey;autoIncrement"`
+	ReceivedAt time.Time `json:"receivedAt" gorm:"not null;index"`
+	// OccurredAt is the timestamp reported by the trace line, when it has one
+	OccurredAt *time.Time `json:"occurredAt,omitempty"`
+	Parsed     bool       `json:"parsed" gorm:"index"`
+	Format     string     `json:"format,omitempty"`
+	EventType  string     `json:"eventType,omitempty"`
+	NetworkID  string     `json:"networkId,omitempty" gorm:"index:idx_controller_trace_member,priority:1"`
+	MemberID   string     `json:"memberId,omitempty" gorm:"index:idx_controller_trace_member,priority:2"`
+	Outcome    string     `json:"outcome,omitempty"`
+	Rule       string     `json:"rule,omitempty"`
+	Raw        string     `json:"raw"`
+}
+
+// TableName returns the database table name for ControllerTraceEvent.
+func (ControllerTraceEvent) TableName() string {
+	return "controller_trace_events"
+}
+
+// ControllerTraceQuery filters a controller trace listing, newest first.
+type ControllerTraceQuery struct {
+	NetworkID string
+	MemberID  string
+	// Parsed selects parsed (true) or raw-only (false) lines; nil lists both
+	Parsed   *bool
+	BeforeID uint64
+	Limit    int
+}
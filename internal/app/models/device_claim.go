@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+// DeviceClaim records that a user says a ZeroTier node is theirs. A claim only counts once it
+// is verified, either by an administrator or by the node's member name matching ClaimCode.
+type DeviceClaim struct {
+	ID          uint64     `json:"id" gorm:"primaryKey;autoIncrement"`
+	UserID      string     `json:"userId" gorm:"not null;uniqueIndex:idx_device_claim_user_node,priority:1"`
+	NodeAddress string     `json:"nodeAddress" gorm:"not null;index;uniqueIndex:idx_device_claim_user_node,priority:2"`
+	ClaimCode   string     `json:"claimCode" gorm:"not null"`
+	Verified    bool       `json:"verified" gorm:"not null;default:false"`
+	VerifiedBy  string     `json:"verifiedBy,omitempty"`
+	VerifiedAt  *time.Time `json:"verifiedAt,omitempty"`
+	CreatedAt   time.Time  `json:"createdAt"`
+}
+
+// TableName returns the database table name for DeviceClaim.
+func (DeviceClaim) TableName() string {
+	return "device_claims"
+}
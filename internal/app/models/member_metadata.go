@@ -0,0 +1,23 @@
+package models
+
+import "time"
+
+// MemberMetadata holds Tairitsu's own notes about a network member. Controllers do not keep
+// member names reliably across versions, so the display name lives here as well.
+type MemberMetadata struct {
+	NetworkID   string `json:"networkId" gorm:"primaryKey;uniqueIndex:idx_member_metadata_member,priority:1"`
+	MemberID    string `json:"memberId" gorm:"primaryKey;uniqueIndex:idx_member_metadata_member,priority:2"`
+	DisplayName string `json:"displayName"`
+	Notes       string `json:"notes" gorm:"type:text"`
+	// Tags are free-form labels such as owner, location or asset tag
+	Tags map[string]string `json:"tags" gorm:"serializer:json"`
+	// AuthorizedUntil is when the expiry job deauthorizes the member; nil keeps it authorized
+	AuthorizedUntil *time.Time `json:"authorizedUntil,omitempty" gorm:"index"`
+	UpdatedBy       string     `json:"updatedBy"`
+	UpdatedAt       time.Time  `json:"updatedAt"`
+}
+
+// TableName returns the database table name for MemberMetadata.
+func (MemberMetadata) TableName() string {
+	return "member_metadata"
+}
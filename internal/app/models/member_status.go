@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+// MemberStatusEvent records a member going online or offline, as observed by the status collector.
+type MemberStatusEvent struct {
+	ID        uint64    `json:"id" gorm:"primaryKey;autoIncrement"`
+	NetworkID string    `json:"networkId" gorm:"not null;index:idx_member_status_lookup,priority:1"`
+	MemberID  string    `json:"memberId" gorm:"not null;index:idx_member_status_lookup,priority:2"`
+	Online    bool      `json:"online"`
+	ChangedAt time.Time `json:"changedAt" gorm:"not null;index:idx_member_status_lookup,priority:3"`
+}
+
+// TableName returns the database table name for MemberStatusEvent.
+func (MemberStatusEvent) TableName() string {
+	return "member_status_history"
+}
@@ -6,14 +6,33 @@ import (
 
 // Network represents a managed network record in the database.
 type Network struct {
-	ID          string    `json:"id" gorm:"primaryKey"`
-	Name        string    `json:"name"`
-	Description string    `json:"description"`
-	OwnerID     string    `json:"owner_id" gorm:"index"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
+	ID                    string    `json:"id" gorm:"primaryKey"`
+	Name                  string    `json:"name"`
+	Description           string    `json:"description"`
+	OwnerID               string    `json:"ownerId" gorm:"index"`
+	Controller            string    `json:"controller" gorm:"index;not null;default:''"`          // Controller hosting the network; empty for the default one
+	OrganizationID        string    `json:"organizationId" gorm:"index;not null;default:default"` // Organization the network belongs to
+	CreatedAt             time.Time `json:"createdAt"`
+	UpdatedAt             time.Time `json:"updatedAt"`
+	PhysicalAddressPolicy string    `json:"physicalAddressPolicy" gorm:"not null;default:truncated"` // How member physical IPs are shown to viewers
+	StatusPagePublished   bool      `json:"statusPagePublished" gorm:"not null;default:false"`       // Listed on the public status page
+	InactivityDays        int       `json:"inactivityDays" gorm:"not null;default:0"`                // Days offline before a member counts as inactive; zero disables the policy
+	InactivityAction      string    `json:"inactivityAction" gorm:"not null;default:flag"`           // What the inactivity job does with inactive members
 }
 
+// Physical address policies for members shown to network viewers.
+const (
+	PhysicalAddressPolicyFull      = "full"
+	PhysicalAddressPolicyTruncated = "truncated"
+	PhysicalAddressPolicyHidden    = "hidden"
+)
+
+// Inactivity policy actions. Flagging only reports inactive members; enforcing deauthorizes them.
+const (
+	InactivityActionFlag    = "flag"
+	InactivityActionEnforce = "enforce"
+)
+
 // TableName returns the database table name for Network.
 func (Network) TableName() string {
 	return "networks"
@@ -0,0 +1,23 @@
+package models
+
+import "time"
+
+// NetworkLockdown records an emergency lockdown of a network: the members it deauthorized,
+// so a rollback can authorize them again. A network has at most one active lockdown.
+type NetworkLockdown struct {
+	ID        uint64   `json:"id" gorm:"primaryKey;autoIncrement"`
+	NetworkID string   `json:"networkId" gorm:"not null;index:idx_network_lockdown_active,priority:1"`
+	Active    bool     `json:"active" gorm:"not null;index:idx_network_lockdown_active,priority:2"`
+	Except    []string `json:"except" gorm:"serializer:json"`
+	// Deauthorized lists the members that were authorized before the lockdown and have not been restored yet
+	Deauthorized []string   `json:"deauthorized" gorm:"serializer:json"`
+	CreatedBy    string     `json:"createdBy"`
+	CreatedAt    time.Time  `json:"createdAt"`
+	RolledBackBy string     `json:"rolledBackBy,omitempty"`
+	RolledBackAt *time.Time `json:"rolledBackAt,omitempty"`
+}
+
+// TableName returns the database table name for NetworkLockdown.
+func (NetworkLockdown) TableName() string {
+	return "network_lockdowns"
+}
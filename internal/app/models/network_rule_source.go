@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+// NetworkRuleSource is the rules text a network's rules were last compiled from. The
+// controller only keeps the compiled rules, so the source lives in Tairitsu's database.
+type NetworkRuleSource struct {
+	NetworkID string    `json:"networkId" gorm:"primaryKey"`
+	Source    string    `json:"source" gorm:"type:text"`
+	UpdatedBy string    `json:"updatedBy"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// TableName returns the database table name for NetworkRuleSource.
+func (NetworkRuleSource) TableName() string {
+	return "network_rule_sources"
+}
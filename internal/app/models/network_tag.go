@@ -0,0 +1,35 @@
+package models
+
+import "time"
+
+// NetworkTag names a numeric tag ID of a network. Controllers only know tag IDs and values,
+// so the names, and the names of well-known values, live in Tairitsu's database.
+type NetworkTag struct {
+	NetworkID string `json:"networkId" gorm:"primaryKey;uniqueIndex:idx_network_tag_name,priority:1"`
+	TagID     uint32 `json:"id" gorm:"primaryKey;autoIncrement:false"`
+	Name      string `json:"name" gorm:"not null;uniqueIndex:idx_network_tag_name,priority:2"`
+	// Values names tag values, for example sales → 100
+	Values    map[string]uint32 `json:"values" gorm:"serializer:json"`
+	CreatedBy string            `json:"createdBy"`
+	CreatedAt time.Time         `json:"createdAt"`
+	UpdatedAt time.Time         `json:"updatedAt"`
+}
+
+// TableName returns the database table name for NetworkTag.
+func (NetworkTag) TableName() string {
+	return "network_tags"
+}
+
+// NetworkCapability names a numeric capability ID of a network.
+type NetworkCapability struct {
+	NetworkID    string    `json:"networkId" gorm:"primaryKey;uniqueIndex:idx_network_capability_name,priority:1"`
+	CapabilityID uint32    `json:"id" gorm:"primaryKey;autoIncrement:false"`
+	Name         string    `json:"name" gorm:"not null;uniqueIndex:idx_network_capability_name,priority:2"`
+	CreatedBy    string    `json:"createdBy"`
+	CreatedAt    time.Time `json:"createdAt"`
+}
+
+// TableName returns the database table name for NetworkCapability.
+func (NetworkCapability) TableName() string {
+	return "network_capabilities"
+}
@@ -4,11 +4,11 @@ import "time"
 
 // NetworkViewer grants read-only member visibility on a network to a user.
 type NetworkViewer struct {
-	NetworkID  string    `json:"network_id" gorm:"primaryKey;index"`
-	UserID     string    `json:"user_id" gorm:"primaryKey;index"`
-	GrantedBy  string    `json:"granted_by" gorm:"index"`
-	CreatedAt  time.Time `json:"created_at"`
-	UpdatedAt  time.Time `json:"updated_at"`
+	NetworkID string    `json:"networkId" gorm:"primaryKey;index"`
+	UserID    string    `json:"userId" gorm:"primaryKey;index"`
+	GrantedBy string    `json:"grantedBy" gorm:"index"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
 }
 
 func (NetworkViewer) TableName() string {
@@ -0,0 +1,75 @@
+package models
+
+import "time"
+
+// Operation kinds
+const (
+	OperationKindNetworkBatch = "network_batch"
+)
+
+// Operation states. Pending operations wait for a free worker; succeeded, failed and
+// cancelled ones are finished. A failed network batch may be retried with its idempotency key.
+const (
+	OperationStatusPending   = "pending"
+	OperationStatusRunning   = "running"
+	OperationStatusSucceeded = "succeeded"
+	OperationStatusFailed    = "failed"
+	OperationStatusCancelled = "cancelled"
+)
+
+// Operation states of items
+const (
+	OperationItemPending = "pending"
+	OperationItemCreated = "created"
+	OperationItemFailed  = "failed"
+)
+
+// Operation records a long-running job, its progress and its result. The idempotency key
+// a request was submitted with lets a client retrying it resume the operation instead of
+// starting it again; operations submitted without one use their ID.
+type Operation struct {
+	ID             string `json:"id" gorm:"primaryKey"`
+	Kind           string `json:"kind" gorm:"not null"`
+	UserID         string `json:"userId" gorm:"not null;uniqueIndex:idx_operation_idempotency,priority:1"`
+	IdempotencyKey string `json:"idempotencyKey" gorm:"not null;uniqueIndex:idx_operation_idempotency,priority:2"`
+	Status         string `json:"status" gorm:"not null;index"`
+	// Total, Done and Failed count the units of work, such as the networks of a batch
+	Total  int    `json:"total" gorm:"not null;default:0"`
+	Done   int    `json:"done" gorm:"not null;default:0"`
+	Failed int    `json:"failed" gorm:"not null;default:0"`
+	Error  string `json:"error,omitempty"`
+	// Request is the submitted request as JSON, compared on retries and used to resume;
+	// Result is the JSON the operation produced
+	Request    string     `json:"-" gorm:"type:text"`
+	Result     string     `json:"-" gorm:"type:text"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	UpdatedAt  time.Time  `json:"updatedAt"`
+	StartedAt  *time.Time `json:"startedAt,omitempty"`
+	FinishedAt *time.Time `json:"finishedAt,omitempty"`
+}
+
+// TableName returns the database table name for Operation.
+func (Operation) TableName() string {
+	return "operations"
+}
+
+// Finished reports whether the operation has stopped running
+func (o *Operation) Finished() bool {
+	return o.Status != OperationStatusPending && o.Status != OperationStatusRunning
+}
+
+// OperationItem is one unit of work of an operation, such as one network of a batch
+type OperationItem struct {
+	OperationID string    `json:"-" gorm:"primaryKey"`
+	Index       int       `json:"index" gorm:"column:item_index;primaryKey;autoIncrement:false"`
+	Name        string    `json:"name"`
+	Status      string    `json:"status" gorm:"not null"`
+	NetworkID   string    `json:"networkId,omitempty"`
+	Error       string    `json:"error,omitempty"`
+	UpdatedAt   time.Time `json:"updatedAt"`
+}
+
+// TableName returns the database table name for OperationItem.
+func (OperationItem) TableName() string {
+	return "operation_items"
+}
@@ -0,0 +1,42 @@
+package models
+
+import "time"
+
+// DefaultOrganizationID is the organization networks belong to until they are assigned to another
+const DefaultOrganizationID = "default"
+
+// Organization-scoped roles. Organization administrators manage the organization's members and
+// networks; organization members can see its networks.
+const (
+	OrganizationRoleAdmin  = "org_admin"
+	OrganizationRoleMember = "org_member"
+)
+
+// Organization groups users and networks, for example one per customer.
+type Organization struct {
+	ID          string    `json:"id" gorm:"primaryKey"`
+	Name        string    `json:"name" gorm:"not null;uniqueIndex;size:128"`
+	Description string    `json:"description"`
+	CreatedAt   time.Time `json:"createdAt"`
+	UpdatedAt   time.Time `json:"updatedAt"`
+}
+
+// TableName returns the database table name for Organization.
+func (Organization) TableName() string {
+	return "organizations"
+}
+
+// OrganizationMember gives a user a role in an organization.
+type OrganizationMember struct {
+	OrganizationID string    `json:"organizationId" gorm:"primaryKey;index"`
+	UserID         string    `json:"userId" gorm:"primaryKey;index"`
+	Role           string    `json:"role" gorm:"not null"`
+	AddedBy        string    `json:"addedBy"`
+	CreatedAt      time.Time `json:"createdAt"`
+	UpdatedAt      time.Time `json:"updatedAt"`
+}
+
+// TableName returns the database table name for OrganizationMember.
+func (OrganizationMember) TableName() string {
+	return "organization_members"
+}
@@ -0,0 +1,31 @@
+package models
+
+import "time"
+
+// PasswordResetToken is a single-use credential that lets a user set a new password without
+// knowing the current one. Only a hash of the token is stored.
+type PasswordResetToken struct {
+	ID        string     `json:"id" gorm:"primaryKey"`
+	UserID    string     `json:"userId" gorm:"index;not null"`
+	TokenHash string     `json:"-" gorm:"uniqueIndex;not null"` // SHA-256 of the plaintext token
+	ExpiresAt time.Time  `json:"expiresAt"`
+	UsedAt    *time.Time `json:"usedAt"` // Set when the token is used or invalidated by another reset
+	CreatedAt time.Time  `json:"createdAt"`
+}
+
+// TableName returns the database table name for PasswordResetToken.
+func (PasswordResetToken) TableName() string {
+	return "password_reset_tokens"
+}
+
+// PasswordResetRequest asks for a reset token by username or email address.
+type PasswordResetRequest struct {
+	Username string `json:"username"`
+	Email    string `json:"email"`
+}
+
+// PasswordResetConfirmRequest sets a new password with a reset token.
+type PasswordResetConfirmRequest struct {
+	Token       string `json:"token"`
+	NewPassword string `json:"newPassword"`
+}
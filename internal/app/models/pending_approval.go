@@ -0,0 +1,28 @@
+package models
+
+import "time"
+
+// Pending approval states
+const (
+	ApprovalPending  = "pending"
+	ApprovalApproved = "approved"
+	ApprovalDenied   = "denied"
+)
+
+// PendingApproval records an unauthorized member found by the status collector. There is one
+// entry per member, so a member is only queued and announced once.
+type PendingApproval struct {
+	ID         uint64     `json:"id" gorm:"primaryKey;autoIncrement"`
+	NetworkID  string     `json:"networkId" gorm:"not null;uniqueIndex:idx_pending_approval_member,priority:1"`
+	MemberID   string     `json:"memberId" gorm:"not null;uniqueIndex:idx_pending_approval_member,priority:2"`
+	Name       string     `json:"name"`
+	Status     string     `json:"status" gorm:"not null;index"`
+	DetectedAt time.Time  `json:"detectedAt"`
+	DecidedBy  string     `json:"decidedBy,omitempty"`
+	DecidedAt  *time.Time `json:"decidedAt,omitempty"`
+}
+
+// TableName returns the database table name for PendingApproval.
+func (PendingApproval) TableName() string {
+	return "pending_approvals"
+}
@@ -0,0 +1,32 @@
+package models
+
+import "time"
+
+// PlanetGeneration is a planet file produced by the planet generator, kept so it can be
+// downloaded again later.
+type PlanetGeneration struct {
+	ID            uint64           `json:"id" gorm:"primaryKey;autoIncrement"`
+	PlanetID      uint64           `json:"planetId" gorm:"not null"`
+	BirthTime     int64            `json:"birthTime" gorm:"not null"`
+	DownloadName  string           `json:"downloadName"`
+	RootNodes     []PlanetRootNode `json:"rootNodes" gorm:"serializer:json"`
+	RootNodeCount int              `json:"rootNodeCount"`
+	EndpointCount int              `json:"endpointCount"`
+	SigningKeys   string           `json:"signingKeys"`
+	CreatedBy     string           `json:"createdBy" gorm:"index"`
+	CreatedAt     time.Time        `json:"createdAt" gorm:"not null;index"`
+	// Data is the planet file itself; listings leave it out
+	Data []byte `json:"-" gorm:"not null"`
+}
+
+// PlanetRootNode is a root of a generated planet as it was entered
+type PlanetRootNode struct {
+	IdentityPublic string   `json:"identityPublic"`
+	Comments       string   `json:"comments"`
+	Endpoints      []string `json:"endpoints"`
+}
+
+// TableName returns the database table name for PlanetGeneration.
+func (PlanetGeneration) TableName() string {
+	return "planet_generations"
+}
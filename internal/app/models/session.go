@@ -5,15 +5,15 @@ import "time"
 // Session represents an authenticated user session.
 type Session struct {
 	ID         string     `json:"id" gorm:"primaryKey"`
-	UserID     string     `json:"user_id" gorm:"index;not null"`
-	UserAgent  string     `json:"user_agent"`
-	IPAddress  string     `json:"ip_address"`
-	RememberMe bool       `json:"remember_me"`
-	LastSeenAt time.Time  `json:"last_seen_at"`
-	ExpiresAt  time.Time  `json:"expires_at"`
-	RevokedAt  *time.Time `json:"revoked_at"`
-	CreatedAt  time.Time  `json:"created_at"`
-	UpdatedAt  time.Time  `json:"updated_at"`
+	UserID     string     `json:"userId" gorm:"index;not null"`
+	UserAgent  string     `json:"userAgent"`
+	IPAddress  string     `json:"ipAddress"`
+	RememberMe bool       `json:"rememberMe"`
+	LastSeenAt time.Time  `json:"lastSeenAt"`
+	ExpiresAt  time.Time  `json:"expiresAt"`
+	RevokedAt  *time.Time `json:"revokedAt"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	UpdatedAt  time.Time  `json:"updatedAt"`
 }
 
 // TableName returns the database table name for Session.
@@ -6,51 +6,84 @@ import (
 
 // User represents a user account.
 type User struct {
-	ID        string    `json:"id"`
-	Username  string    `json:"username"`
-	Password  string    `json:"-"` // Password is never returned to the client
-	Role      string    `json:"role"` // admin, user
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID       string  `json:"id"`
+	Username string  `json:"username"`
+	Email    *string `json:"email,omitempty" gorm:"uniqueIndex;size:254"` // Optional; stored lowercase, nil when unset
+	Password string  `json:"-"`                                           // Password is never returned to the client
+	Role     string  `json:"role"`                                        // admin, user
+	// ExternalSubject is the OpenID Connect subject linked to this account, nil for local-only accounts
+	ExternalSubject *string `json:"-" gorm:"uniqueIndex;size:255"`
+
+	MustChangePassword bool      `json:"mustChangePassword" gorm:"not null;default:false"` // Set by administrator-issued passwords until the user picks their own
+	CreatedAt          time.Time `json:"createdAt"`
+	UpdatedAt          time.Time `json:"updatedAt"`
 }
 
 // LoginRequest represents a login request payload.
 type LoginRequest struct {
-	Username   string `json:"username"`
-	Password   string `json:"password"`
-	RememberMe bool   `json:"remember_me"`
+	Username     string `json:"username"`
+	Password     string `json:"password"`
+	RememberMe   bool   `json:"rememberMe"`
+	CaptchaToken string `json:"captchaToken,omitempty"` // Required after repeated failures when a captcha is configured
 }
 
 // RegisterRequest represents a registration request payload.
 type RegisterRequest struct {
+	Username     string `json:"username"`
+	Password     string `json:"password"`
+	Email        string `json:"email,omitempty"`        // Optional
+	CaptchaToken string `json:"captchaToken,omitempty"` // Required when a captcha is configured
+}
+
+// CreateUserRequest represents an administrator's user creation payload.
+// An empty password makes the service generate a temporary one.
+type CreateUserRequest struct {
 	Username string `json:"username"`
-	Password string `json:"password"`
+	Password string `json:"password,omitempty"`
+	Email    string `json:"email,omitempty"`
+}
+
+// UpdateProfileRequest represents a profile update payload. An empty email removes it.
+type UpdateProfileRequest struct {
+	Email string `json:"email"`
 }
 
 // ChangePasswordRequest represents a password change request payload.
 type ChangePasswordRequest struct {
-	CurrentPassword     string `json:"current_password"`
-	NewPassword         string `json:"new_password"`
-	ConfirmPassword     string `json:"confirm_password"`
-	LogoutOtherSessions bool   `json:"logout_other_sessions"`
+	CurrentPassword     string `json:"currentPassword"`
+	NewPassword         string `json:"newPassword"`
+	ConfirmPassword     string `json:"confirmPassword"`
+	LogoutOtherSessions bool   `json:"logoutOtherSessions"`
 }
 
 // UserResponse is the API response shape for a user.
 type UserResponse struct {
-	ID        string    `json:"id"`
-	Username  string    `json:"username"`
-	Role      string    `json:"role"`
-	CreatedAt time.Time `json:"createdAt"`
-	UpdatedAt time.Time `json:"updatedAt"`
+	ID                 string    `json:"id"`
+	Username           string    `json:"username"`
+	Email              string    `json:"email,omitempty"`
+	Role               string    `json:"role"`
+	MustChangePassword bool      `json:"mustChangePassword"`
+	CreatedAt          time.Time `json:"createdAt"`
+	UpdatedAt          time.Time `json:"updatedAt"`
 }
 
 // ToResponse converts a User to a UserResponse.
 func (u *User) ToResponse() UserResponse {
 	return UserResponse{
-		ID:        u.ID,
-		Username:  u.Username,
-		Role:      u.Role,
-		CreatedAt: u.CreatedAt,
-		UpdatedAt: u.UpdatedAt,
+		ID:                 u.ID,
+		Username:           u.Username,
+		Email:              u.EmailAddress(),
+		Role:               u.Role,
+		MustChangePassword: u.MustChangePassword,
+		CreatedAt:          u.CreatedAt,
+		UpdatedAt:          u.UpdatedAt,
+	}
+}
+
+// EmailAddress returns the user's email address, or an empty string when none is set.
+func (u *User) EmailAddress() string {
+	if u.Email == nil {
+		return ""
 	}
+	return *u.Email
 }
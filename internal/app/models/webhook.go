@@ -0,0 +1,77 @@
+package models
+
+import (
+	"strings"
+	"time"
+)
+
+// Webhook is an outbound endpoint that receives signed event notifications.
+type Webhook struct {
+	ID        string    `json:"id" gorm:"primaryKey"`
+	Name      string    `json:"name"`
+	URL       string    `json:"url" gorm:"not null"`
+	Secret    string    `json:"-" gorm:"not null"` // Signs deliveries; only returned when the webhook is created
+	Events    string    `json:"events"`            // Comma-separated event filter; empty receives every event
+	Enabled   bool      `json:"enabled"`
+	CreatedBy string    `json:"createdBy"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// TableName returns the database table name for Webhook.
+func (Webhook) TableName() string {
+	return "webhooks"
+}
+
+// EventList splits the stored event filter; an empty list receives every event.
+func (w *Webhook) EventList() []string {
+	if w.Events == "" {
+		return []string{}
+	}
+	return strings.Split(w.Events, ",")
+}
+
+// WebhookResponse is the API response shape for a webhook; the secret is never included.
+type WebhookResponse struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	URL       string    `json:"url"`
+	Events    []string  `json:"events"`
+	Enabled   bool      `json:"enabled"`
+	CreatedBy string    `json:"createdBy"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// ToResponse converts a Webhook to a WebhookResponse.
+func (w *Webhook) ToResponse() WebhookResponse {
+	return WebhookResponse{
+		ID:        w.ID,
+		Name:      w.Name,
+		URL:       w.URL,
+		Events:    w.EventList(),
+		Enabled:   w.Enabled,
+		CreatedBy: w.CreatedBy,
+		CreatedAt: w.CreatedAt,
+		UpdatedAt: w.UpdatedAt,
+	}
+}
+
+// WebhookDelivery logs one event sent to a webhook, including its retries.
+type WebhookDelivery struct {
+	ID          uint64     `json:"id" gorm:"primaryKey;autoIncrement"`
+	WebhookID   string     `json:"webhookId" gorm:"not null;index"`
+	Event       string     `json:"event" gorm:"not null"`
+	Payload     string     `json:"payload" gorm:"type:text"`
+	Attempts    int        `json:"attempts"`
+	StatusCode  int        `json:"statusCode,omitempty"` // Response status of the last attempt; zero when no response arrived
+	Success     bool       `json:"success"`
+	Error       string     `json:"error,omitempty"`
+	CreatedAt   time.Time  `json:"createdAt"`
+	CompletedAt *time.Time `json:"completedAt,omitempty"` // Nil while attempts remain
+}
+
+// TableName returns the database table name for WebhookDelivery.
+func (WebhookDelivery) TableName() string {
+	return "webhook_deliveries"
+}
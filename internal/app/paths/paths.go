@@ -0,0 +1,112 @@
+// Package paths locates the files Tairitsu keeps on disk. Everything lives in the data
+// directory, which is ./data unless --data-dir or TAIRITSU_DATA_DIR names another one.
+package paths
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// DataDirEnv names the data directory when --data-dir is not given
+const DataDirEnv = "TAIRITSU_DATA_DIR"
+
+// DefaultDataDir is used when neither --data-dir nor TAIRITSU_DATA_DIR is set
+const DefaultDataDir = "./data"
+
+// defaultLogDir is where logs go with the default data directory, as before it was configurable
+const defaultLogDir = "./logs"
+
+var (
+	mutex   sync.RWMutex
+	dataDir = DefaultDataDir
+	// explicit is set when the data directory was chosen rather than defaulted
+	explicit bool
+)
+
+// Init resolves the data directory once at startup: flagValue when set, otherwise
+// TAIRITSU_DATA_DIR, otherwise ./data
+func Init(flagValue string) {
+	dir := strings.TrimSpace(flagValue)
+	if dir == "" {
+		dir = strings.TrimSpace(os.Getenv(DataDirEnv))
+	}
+	if dir == "" {
+		set(DefaultDataDir, false)
+		return
+	}
+	set(dir, true)
+}
+
+// SetDataDir makes dir the data directory and returns a function restoring the previous one
+func SetDataDir(dir string) (restore func()) {
+	mutex.RLock()
+	previousDir, previousExplicit := dataDir, explicit
+	mutex.RUnlock()
+	set(dir, true)
+	return func() { set(previousDir, previousExplicit) }
+}
+
+func set(dir string, isExplicit bool) {
+	if isExplicit {
+		// An absolute path keeps working after the working directory changes
+		if abs, err := filepath.Abs(dir); err == nil {
+			dir = abs
+		}
+	}
+	mutex.Lock()
+	defer mutex.Unlock()
+	dataDir, explicit = dir, isExplicit
+}
+
+// DataDir returns the data directory
+func DataDir() string {
+	mutex.RLock()
+	defer mutex.RUnlock()
+	return dataDir
+}
+
+// ConfigFile returns the path of config.json
+func ConfigFile() string {
+	return filepath.Join(DataDir(), "config.json")
+}
+
+// LogDir returns the default log directory: ./logs next to the default data directory, or
+// logs inside a chosen one
+func LogDir() string {
+	mutex.RLock()
+	defer mutex.RUnlock()
+	if !explicit {
+		return defaultLogDir
+	}
+	return filepath.Join(dataDir, "logs")
+}
+
+// PlanetDir returns where generated planet files are written by default
+func PlanetDir() string {
+	return filepath.Join(DataDir(), "planets")
+}
+
+// Resolve interprets a path from config.json. Relative paths were written relative to the
+// working directory holding ./data, so with the default data directory they are kept as
+// they are. With a chosen data directory they are relative to it, and a leading data/
+// element, as in the default data/tairitsu.db, names the data directory itself.
+func Resolve(path string) string {
+	if path == "" || filepath.IsAbs(path) {
+		return path
+	}
+	mutex.RLock()
+	dir, isExplicit := dataDir, explicit
+	mutex.RUnlock()
+	if !isExplicit {
+		return path
+	}
+
+	cleaned := filepath.ToSlash(filepath.Clean(path))
+	if cleaned == "data" {
+		return dir
+	}
+	cleaned = strings.TrimPrefix(cleaned, "data/")
+	return filepath.Join(dir, filepath.FromSlash(cleaned))
+}
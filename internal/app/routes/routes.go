@@ -4,8 +4,10 @@ import (
 	"os"
 
 	"github.com/GT-610/tairitsu/internal/app/assembly"
+	"github.com/GT-610/tairitsu/internal/app/config"
 	"github.com/GT-610/tairitsu/internal/app/handlers"
 	"github.com/GT-610/tairitsu/internal/app/middleware"
+	"github.com/GT-610/tairitsu/internal/app/services"
 	"github.com/gofiber/fiber/v3"
 	"github.com/gofiber/fiber/v3/middleware/cors"
 )
@@ -17,18 +19,24 @@ func SetupRoutes(router *fiber.App, dependencies *assembly.Dependencies) {
 	if isProd {
 		corsConfig.AllowOrigins = []string{}
 	}
+	corsConfig.ExposeHeaders = append(corsConfig.ExposeHeaders, middleware.RequestIDHeader)
 
 	// Apply middleware
+	router.Use(middleware.RequestID())
+	router.Use(middleware.Locale())
 	router.Use(middleware.Logger())
 	router.Use(middleware.SecurityHeaders())
 	router.Use(cors.New(corsConfig))
+	router.Use(middleware.LegacyJSONFields(config.LegacyJSONFieldsFrom(dependencies.Config)))
 	router.Use(middleware.RateLimit())
 	router.Use(middleware.ErrorHandler())
+	router.Use(middleware.PauseWritesDuringMaintenance(dependencies.Services.Maintenance))
 
-	// Root path handler for HTML browsers
-	router.Get("/", func(c fiber.Ctx) error {
-		c.Set("Content-Type", "text/html; charset=utf-8")
-		return c.SendString(`<html>
+	// Without a frontend build the root path shows a placeholder page for HTML browsers
+	if dependencies.Handlers.Frontend == nil {
+		router.Get("/", func(c fiber.Ctx) error {
+			c.Set("Content-Type", "text/html; charset=utf-8")
+			return c.SendString(`<html>
 <head>
 	<title>Tairitsu Backend</title>
 	<style>
@@ -47,94 +55,223 @@ func SetupRoutes(router *fiber.App, dependencies *assembly.Dependencies) {
 	<p>If you can see this page, it means the backend is running.</p>
 </body>
 </html>`)
-	})
+		})
+	}
+
+	// Public status page (no authentication; disabled unless status_page.enabled is set)
+	router.Get("/status-page", middleware.StatusPageRateLimit(), dependencies.Handlers.StatusPage.GetStatusPage)
 
 	networkHandler := dependencies.Handlers.Network
 	memberHandler := dependencies.Handlers.Member
 	authHandler := dependencies.Handlers.Auth
 	userHandler := dependencies.Handlers.User
 	systemHandler := dependencies.Handlers.System
+	checklistHandler := dependencies.Handlers.Checklist
+	auditHandler := dependencies.Handlers.Audit
 
 	authMiddleware := dependencies.Middleware.Auth
 	setupOnly := dependencies.Middleware.SetupOnly
 	runtimeOnly := dependencies.Middleware.RuntimeOnly
 	adminOnly := dependencies.Middleware.AdminOnly
+	demoBlocked := dependencies.Middleware.DemoBlocked
+	// API tokens with the members:write scope may change members but nothing else
+	membersWrite := middleware.TokenPermission(services.ApiTokenScopeMembersWrite)
 
 	// API routes group
 	api := router.Group("/api")
 	{
-		// Liveness probe (no dependency checks)
-		api.Get("/health", func(c fiber.Ctx) error {
-			return c.Status(fiber.StatusOK).JSON(fiber.Map{"status": "ok"})
-		})
+		// Record state-changing requests from authenticated users in the audit log
+		if dependencies.Middleware.Audit != nil {
+			api.Use(dependencies.Middleware.Audit)
+		}
+
+		// Dependency health; ?verbose=false is a liveness probe without dependency checks
+		api.Get("/health", dependencies.Handlers.Health.GetHealth)
 
-		// Readiness probe (checks database connectivity)
+		// Readiness probe: ready once the database is bound and answers a ping
 		api.Get("/ready", func(c fiber.Ctx) error {
-			if dependencies.Database != nil {
-				if err := dependencies.Database.Ping(); err != nil {
-					return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{
-						"status": "unavailable",
-						"error":  "database connection failed",
-					})
-				}
+			runtime := dependencies.Services.Runtime
+			db := runtime.CurrentDatabase()
+			if runtime.DatabaseError() != nil || db == nil || db.Ping() != nil {
+				return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{
+					"status": "unavailable",
+					"error":  "database connection failed",
+				})
 			}
 			return c.Status(fiber.StatusOK).JSON(fiber.Map{"status": "ok"})
 		})
 
 		// System status check (no authentication required)
 		api.Get("/system/status", systemHandler.GetSystemStatus)
+		api.Get("/system/setup/state", systemHandler.GetSetupState)
+		api.Get("/system/password-policy", systemHandler.GetPasswordPolicy)
 
 		auth := api.Group("/auth")
 		{
-			auth.Post("/register", middleware.AuthRateLimit(), authHandler.Register)
-			auth.Post("/login", middleware.AuthRateLimit(), runtimeOnly, authHandler.Login)
+			auth.Post("/register", middleware.CredentialRateLimit(), authHandler.Register)
+			auth.Post("/login", middleware.CredentialRateLimit(), runtimeOnly, authHandler.Login)
+			auth.Get("/methods", authHandler.AuthMethods)
+			auth.Get("/oidc/login", middleware.AuthRateLimit(), runtimeOnly, authHandler.OIDCLogin)
+			auth.Get("/oidc/callback", middleware.AuthRateLimit(), runtimeOnly, authHandler.OIDCCallback)
 			auth.Post("/logout", runtimeOnly, authMiddleware, authHandler.Logout)
+			auth.Post("/reset-request", middleware.CredentialRateLimit(), runtimeOnly, authHandler.RequestPasswordReset)
+			auth.Post("/reset-confirm", middleware.CredentialRateLimit(), runtimeOnly, authHandler.ConfirmPasswordReset)
 		}
 
 		api.Post("/system/database", setupOnly, systemHandler.ConfigureDatabase)
 		api.Post("/system/zerotier/config", setupOnly, systemHandler.SaveZeroTierConfig)
+		api.Post("/system/zerotier/test", setupOnly, systemHandler.TestZeroTierConnection)
 		api.Post("/system/initialized", setupOnly, systemHandler.SetInitialized)
 		api.Post("/system/admin/init", setupOnly, systemHandler.InitializeAdminCreation)
 
 		api.Get("/profile", runtimeOnly, authMiddleware, authHandler.GetProfile)
+		api.Put("/profile", runtimeOnly, authMiddleware, authHandler.UpdateProfile)
 		api.Put("/profile/password", runtimeOnly, authMiddleware, authHandler.ChangePassword)
 		api.Get("/profile/sessions", runtimeOnly, authMiddleware, authHandler.ListSessions)
 		api.Delete("/profile/sessions/others", runtimeOnly, authMiddleware, authHandler.RevokeOtherSessions)
 		api.Delete("/profile/sessions/:sessionId", runtimeOnly, authMiddleware, authHandler.RevokeSession)
+		api.Get("/tokens", runtimeOnly, authMiddleware, dependencies.Handlers.ApiToken.ListTokens)
+		api.Post("/tokens", runtimeOnly, authMiddleware, dependencies.Handlers.ApiToken.CreateToken)
+		api.Delete("/tokens/:tokenId", runtimeOnly, authMiddleware, dependencies.Handlers.ApiToken.RevokeToken)
 		api.Get("/system/settings", runtimeOnly, authMiddleware, adminOnly, systemHandler.GetRuntimeSettings)
 		api.Put("/system/settings", runtimeOnly, authMiddleware, adminOnly, systemHandler.UpdateRuntimeSettings)
+		api.Put("/system/password-policy", runtimeOnly, authMiddleware, adminOnly, systemHandler.UpdatePasswordPolicy)
+		api.Post("/admin/security/encryption-key/rotate", runtimeOnly, authMiddleware, adminOnly, demoBlocked, systemHandler.RotateEncryptionKey)
+		api.Post("/system/rotate-jwt-secret", runtimeOnly, authMiddleware, adminOnly, demoBlocked, authHandler.RotateJWTSecret)
 
 		api.Get("/status", runtimeOnly, authMiddleware, networkHandler.GetStatus)
+		api.Get("/dashboard", runtimeOnly, authMiddleware, dependencies.Handlers.Dashboard.GetDashboard)
 
+		api.Get("/controllers", runtimeOnly, authMiddleware, networkHandler.GetControllers)
+		api.Get("/controller/peers", runtimeOnly, authMiddleware, adminOnly, networkHandler.GetControllerPeers)
 		api.Get("/networks", runtimeOnly, authMiddleware, networkHandler.GetNetworks)
 		api.Get("/networks/shared", runtimeOnly, authMiddleware, networkHandler.GetSharedNetworks)
 		api.Post("/networks", runtimeOnly, authMiddleware, networkHandler.CreateNetwork)
+		api.Post("/networks/restore", runtimeOnly, authMiddleware, networkHandler.RestoreNetwork)
+		api.Post("/networks/batch", runtimeOnly, authMiddleware, networkHandler.CreateNetworkBatch)
+		api.Get("/operations", runtimeOnly, authMiddleware, dependencies.Handlers.Operation.ListOperations)
+		api.Get("/operations/:id", runtimeOnly, authMiddleware, dependencies.Handlers.Operation.GetOperation)
+		api.Delete("/operations/:id", runtimeOnly, authMiddleware, dependencies.Handlers.Operation.CancelOperation)
 		api.Get("/networks/:id", runtimeOnly, authMiddleware, networkHandler.GetNetwork)
 		api.Put("/networks/:id", runtimeOnly, authMiddleware, networkHandler.UpdateNetwork)
+		api.Get("/networks/:id/backup", runtimeOnly, authMiddleware, networkHandler.BackupNetwork)
+		api.Get("/networks/:id/ipv6-prefixes", runtimeOnly, authMiddleware, networkHandler.GetNetworkIPv6Prefixes)
+		api.Get("/networks/:id/ip-usage", runtimeOnly, authMiddleware, networkHandler.GetNetworkIPUsage)
 		api.Put("/networks/:id/metadata", runtimeOnly, authMiddleware, networkHandler.UpdateNetworkMetadata)
+		api.Get("/networks/:id/privacy", runtimeOnly, authMiddleware, networkHandler.GetNetworkPrivacy)
+		api.Put("/networks/:id/privacy", runtimeOnly, authMiddleware, networkHandler.UpdateNetworkPrivacy)
+		api.Get("/networks/:id/policies", runtimeOnly, authMiddleware, networkHandler.GetNetworkPolicies)
+		api.Put("/networks/:id/policies", runtimeOnly, authMiddleware, networkHandler.UpdateNetworkPolicies)
+		api.Get("/networks/:id/policies/inactivity/dry-run", runtimeOnly, authMiddleware, networkHandler.PreviewInactivityPolicy)
+		api.Get("/networks/:id/rules", runtimeOnly, authMiddleware, networkHandler.GetNetworkRules)
+		api.Put("/networks/:id/rules", runtimeOnly, authMiddleware, networkHandler.UpdateNetworkRules)
+		api.Get("/networks/:id/tags", runtimeOnly, authMiddleware, networkHandler.GetNetworkTags)
+		api.Post("/networks/:id/tags", runtimeOnly, authMiddleware, networkHandler.CreateNetworkTag)
+		api.Put("/networks/:id/tags/:tagId", runtimeOnly, authMiddleware, networkHandler.UpdateNetworkTag)
+		api.Delete("/networks/:id/tags/:tagId", runtimeOnly, authMiddleware, networkHandler.DeleteNetworkTag)
+		api.Get("/networks/:id/capabilities", runtimeOnly, authMiddleware, networkHandler.GetNetworkCapabilities)
+		api.Post("/networks/:id/capabilities", runtimeOnly, authMiddleware, networkHandler.CreateNetworkCapability)
+		api.Delete("/networks/:id/capabilities/:capabilityId", runtimeOnly, authMiddleware, networkHandler.DeleteNetworkCapability)
 		api.Delete("/networks/:id", runtimeOnly, authMiddleware, networkHandler.DeleteNetwork)
 		api.Get("/networks/:id/viewers", runtimeOnly, authMiddleware, networkHandler.GetNetworkViewers)
 		api.Get("/networks/:id/viewers/available", runtimeOnly, authMiddleware, networkHandler.GetNetworkViewerCandidates)
 		api.Post("/networks/:id/viewers", runtimeOnly, authMiddleware, networkHandler.AddNetworkViewer)
 		api.Delete("/networks/:id/viewers/:userId", runtimeOnly, authMiddleware, networkHandler.DeleteNetworkViewer)
 
+		// Live member events as server-sent events; EventSource clients pass ?access_token=
+		api.Get("/networks/:id/events", runtimeOnly, middleware.QueryTokenAuth(), authMiddleware, dependencies.Handlers.MemberEvent.StreamMemberEvents)
+		api.Post("/networks/:id/events/refresh", runtimeOnly, authMiddleware, dependencies.Handlers.MemberEvent.RefreshMemberEvents)
+
 		api.Get("/networks/:id/members", runtimeOnly, authMiddleware, memberHandler.GetMembers)
+		api.Get("/networks/:id/members/export", runtimeOnly, authMiddleware, memberHandler.ExportMembers)
 		api.Get("/networks/:id/members/:memberId", runtimeOnly, authMiddleware, memberHandler.GetMember)
-		api.Put("/networks/:id/members/:memberId", runtimeOnly, authMiddleware, memberHandler.UpdateMember)
-		api.Delete("/networks/:id/members/:memberId", runtimeOnly, authMiddleware, memberHandler.DeleteMember)
+		api.Get("/networks/:id/members/:memberId/history", runtimeOnly, authMiddleware, memberHandler.GetMemberHistory)
+		api.Get("/networks/:id/members/:memberId/trace", runtimeOnly, authMiddleware, memberHandler.GetMemberTrace)
+		api.Put("/networks/:id/members/:memberId", runtimeOnly, membersWrite, authMiddleware, memberHandler.UpdateMember)
+		api.Patch("/networks/:id/members/:memberId/metadata", runtimeOnly, membersWrite, authMiddleware, memberHandler.UpdateMemberMetadata)
+		api.Put("/networks/:id/members/:memberId/tags", runtimeOnly, membersWrite, authMiddleware, memberHandler.SetMemberTags)
+		api.Put("/networks/:id/members/:memberId/capabilities", runtimeOnly, membersWrite, authMiddleware, memberHandler.SetMemberCapabilities)
+		api.Delete("/networks/:id/members/:memberId", runtimeOnly, membersWrite, authMiddleware, memberHandler.DeleteMember)
+		api.Post("/networks/:id/lockdown", runtimeOnly, authMiddleware, memberHandler.LockdownNetwork)
+		api.Post("/networks/:id/lockdown/rollback", runtimeOnly, authMiddleware, memberHandler.RollbackNetworkLockdown)
+
+		api.Get("/approvals", runtimeOnly, authMiddleware, dependencies.Handlers.Approval.ListApprovals)
+		api.Post("/approvals/:id", runtimeOnly, authMiddleware, dependencies.Handlers.Approval.DecideApproval)
+
+		// Devices are nodes a user claimed as their own, across every network
+		api.Get("/devices", runtimeOnly, authMiddleware, dependencies.Handlers.Device.ListDevices)
+		api.Post("/devices/claim", runtimeOnly, authMiddleware, dependencies.Handlers.Device.ClaimDevice)
+		api.Delete("/devices/:address", runtimeOnly, authMiddleware, dependencies.Handlers.Device.DeleteDevice)
+		api.Put("/devices/:address/name", runtimeOnly, authMiddleware, dependencies.Handlers.Device.RenameDevice)
+		api.Post("/devices/:address/networks/:id/authorization-request", runtimeOnly, authMiddleware, dependencies.Handlers.Device.RequestAuthorization)
+
+		// Organizations group users and networks; organization administrators manage members
+		api.Get("/organizations", runtimeOnly, authMiddleware, dependencies.Handlers.Org.ListOrganizations)
+		api.Get("/organizations/:orgId/members", runtimeOnly, authMiddleware, dependencies.Handlers.Org.ListMembers)
+		api.Put("/organizations/:orgId/members/:userId", runtimeOnly, authMiddleware, dependencies.Handlers.Org.SetMember)
+		api.Delete("/organizations/:orgId/members/:userId", runtimeOnly, authMiddleware, dependencies.Handlers.Org.RemoveMember)
 
 		// Admin-only routes
 		api.Get("/system/stats", runtimeOnly, authMiddleware, adminOnly, systemHandler.GetSystemStats)
+		api.Get("/system/stats/history", runtimeOnly, authMiddleware, adminOnly, systemHandler.GetSystemStatsHistory)
+		api.Get("/system/rate-limits", runtimeOnly, authMiddleware, adminOnly, systemHandler.GetRateLimits)
+		api.Get("/system/log-level", runtimeOnly, authMiddleware, adminOnly, systemHandler.GetLogLevel)
+		api.Post("/system/tls/reload", runtimeOnly, authMiddleware, adminOnly, dependencies.Handlers.TLS.ReloadCertificate)
+		api.Get("/system/log-shipping/status", runtimeOnly, authMiddleware, adminOnly, dependencies.Handlers.LogShipping.GetStatus)
+		api.Put("/system/log-level", runtimeOnly, authMiddleware, adminOnly, systemHandler.UpdateLogLevel)
 		api.Get("/users", runtimeOnly, authMiddleware, adminOnly, userHandler.GetAllUsers)
-		api.Post("/users", runtimeOnly, authMiddleware, adminOnly, userHandler.CreateUser)
-		api.Delete("/users/:userId", runtimeOnly, authMiddleware, adminOnly, userHandler.DeleteUser)
+		api.Post("/users", runtimeOnly, authMiddleware, adminOnly, demoBlocked, userHandler.CreateUser)
+		api.Delete("/users/:userId", runtimeOnly, authMiddleware, adminOnly, demoBlocked, userHandler.DeleteUser)
 		api.Post("/users/transfer-admin", runtimeOnly, authMiddleware, adminOnly, userHandler.TransferAdmin)
 		api.Post("/users/:userId/reset-password", runtimeOnly, authMiddleware, adminOnly, userHandler.ResetPassword)
+		api.Post("/users/:userId/reset-token", runtimeOnly, authMiddleware, adminOnly, userHandler.IssueResetToken)
+		api.Get("/users/:userId/sessions", runtimeOnly, authMiddleware, adminOnly, userHandler.ListUserSessions)
+		api.Delete("/users/:userId/sessions/:sessionId", runtimeOnly, authMiddleware, adminOnly, userHandler.RevokeUserSession)
+		api.Get("/admin/checklist", runtimeOnly, authMiddleware, adminOnly, checklistHandler.GetChecklist)
+		api.Put("/admin/checklist/:itemId", runtimeOnly, authMiddleware, adminOnly, checklistHandler.UpdateChecklistItem)
+		api.Get("/admin/devices/claims", runtimeOnly, authMiddleware, adminOnly, dependencies.Handlers.Device.ListClaims)
+		api.Post("/admin/devices/claims/:id", runtimeOnly, authMiddleware, adminOnly, dependencies.Handlers.Device.DecideClaim)
+		api.Get("/admin/audit", runtimeOnly, authMiddleware, adminOnly, auditHandler.ListEntries)
+		api.Get("/admin/audit/verify", runtimeOnly, authMiddleware, adminOnly, auditHandler.VerifyChain)
+		api.Get("/admin/export/app-state", runtimeOnly, authMiddleware, adminOnly, dependencies.Handlers.AppState.ExportAppState)
+		api.Post("/admin/import/app-state", runtimeOnly, authMiddleware, adminOnly, demoBlocked, dependencies.Handlers.AppState.ImportAppState)
+		api.Get("/admin/jobs", runtimeOnly, authMiddleware, adminOnly, dependencies.Handlers.Jobs.ListJobs)
+		api.Post("/admin/maintenance/compact", runtimeOnly, authMiddleware, adminOnly, demoBlocked, dependencies.Handlers.Jobs.CompactDatabase)
+		api.Get("/webhooks", runtimeOnly, authMiddleware, adminOnly, dependencies.Handlers.Webhook.ListWebhooks)
+		api.Post("/webhooks", runtimeOnly, authMiddleware, adminOnly, demoBlocked, dependencies.Handlers.Webhook.CreateWebhook)
+		api.Put("/webhooks/:id", runtimeOnly, authMiddleware, adminOnly, demoBlocked, dependencies.Handlers.Webhook.UpdateWebhook)
+		api.Delete("/webhooks/:id", runtimeOnly, authMiddleware, adminOnly, demoBlocked, dependencies.Handlers.Webhook.DeleteWebhook)
+		api.Get("/webhooks/:id/deliveries", runtimeOnly, authMiddleware, adminOnly, dependencies.Handlers.Webhook.ListDeliveries)
+		api.Post("/webhooks/:id/ping", runtimeOnly, authMiddleware, adminOnly, demoBlocked, dependencies.Handlers.Webhook.PingWebhook)
+		api.Get("/admin/webhooks/schema", runtimeOnly, authMiddleware, adminOnly, dependencies.Handlers.Webhook.GetPayloadSchemas)
+		api.Post("/admin/webhooks/:id/test", runtimeOnly, authMiddleware, adminOnly, demoBlocked, dependencies.Handlers.Webhook.SendTestEvent)
+		api.Post("/system/backup", runtimeOnly, authMiddleware, adminOnly, demoBlocked, dependencies.Handlers.Backup.CreateBackup)
+		api.Get("/system/backups", runtimeOnly, authMiddleware, adminOnly, dependencies.Handlers.Backup.ListBackups)
+		api.Post("/system/backups/:name/restore", runtimeOnly, authMiddleware, adminOnly, demoBlocked, dependencies.Handlers.Backup.RestoreBackup)
+		// Forwarded controller trace lines; exempt from the default limiter and the audit log
+		api.Post("/admin/controller/trace", runtimeOnly, middleware.TraceIngestRateLimit(), authMiddleware, adminOnly, dependencies.Handlers.Trace.IngestTrace)
+		api.Get("/admin/controller/trace", runtimeOnly, authMiddleware, adminOnly, dependencies.Handlers.Trace.ListTrace)
+		api.Put("/admin/status-page/networks/:id", runtimeOnly, authMiddleware, adminOnly, dependencies.Handlers.StatusPage.SetNetworkPublished)
+		api.Post("/organizations", runtimeOnly, authMiddleware, adminOnly, dependencies.Handlers.Org.CreateOrganization)
+		api.Put("/organizations/:orgId", runtimeOnly, authMiddleware, adminOnly, dependencies.Handlers.Org.UpdateOrganization)
+		api.Delete("/organizations/:orgId", runtimeOnly, authMiddleware, adminOnly, dependencies.Handlers.Org.DeleteOrganization)
+		api.Put("/organizations/:orgId/networks/:id", runtimeOnly, authMiddleware, adminOnly, dependencies.Handlers.Org.AssignNetwork)
 		api.Get("/admin/networks/importable", runtimeOnly, authMiddleware, adminOnly, networkHandler.GetImportableNetworks)
 		api.Post("/admin/networks/import", runtimeOnly, authMiddleware, adminOnly, networkHandler.ImportNetworks)
 		api.Get("/admin/planet/identity", runtimeOnly, authMiddleware, adminOnly, handlers.GetIdentityHandler)
-		api.Post("/admin/planet/generate", runtimeOnly, authMiddleware, adminOnly, handlers.GeneratePlanetHandler)
+		api.Post("/admin/planet/validate-identity", runtimeOnly, authMiddleware, adminOnly, handlers.ValidateIdentityHandler)
+		api.Post("/admin/planet/generate", runtimeOnly, authMiddleware, adminOnly, demoBlocked, dependencies.Handlers.Planet.GeneratePlanet)
+		api.Get("/admin/planet/history", runtimeOnly, authMiddleware, adminOnly, dependencies.Handlers.Planet.ListPlanetHistory)
+		api.Get("/admin/planet/:id/download", runtimeOnly, authMiddleware, adminOnly, dependencies.Handlers.Planet.DownloadPlanet)
+		api.Post("/admin/planet/moon", runtimeOnly, authMiddleware, adminOnly, demoBlocked, handlers.GenerateMoonHandler)
+		api.Get("/admin/planet/inspect", runtimeOnly, authMiddleware, adminOnly, handlers.InspectPlanetHandler)
+		api.Post("/admin/planet/inspect", runtimeOnly, authMiddleware, adminOnly, handlers.InspectUploadedWorldHandler)
 		api.Get("/admin/planet/signing-keys", runtimeOnly, authMiddleware, adminOnly, handlers.GetSigningKeysInfoHandler)
-		api.Post("/admin/planet/keys", runtimeOnly, authMiddleware, adminOnly, handlers.GenerateSigningKeysHandler)
+		api.Post("/admin/planet/keys", runtimeOnly, authMiddleware, adminOnly, demoBlocked, handlers.GenerateSigningKeysHandler)
+	}
+
+	// The frontend build answers every other path, so it is registered after all other routes
+	if dependencies.Handlers.Frontend != nil {
+		router.Get("/*", dependencies.Handlers.Frontend.Serve)
 	}
 }
@@ -0,0 +1,335 @@
+package services
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/GT-610/tairitsu/internal/app/database"
+	"github.com/GT-610/tairitsu/internal/app/logger"
+	"github.com/GT-610/tairitsu/internal/app/models"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+const (
+	// ApiTokenPrefix marks personal access tokens so they are never mistaken for JWTs
+	ApiTokenPrefix = "tairitsu_"
+
+	ApiTokenScopeRead         = "read"
+	ApiTokenScopeMembersWrite = "members:write"
+	ApiTokenScopeWrite        = "write"
+
+	apiTokenTouchInterval = time.Minute
+	maxApiTokenNameLength = 64
+)
+
+var (
+	ErrApiTokenNotFound       = errors.New("API token not found")
+	ErrApiTokenInvalid        = errors.New("API token is invalid")
+	ErrApiTokenRevoked        = errors.New("API token has been revoked")
+	ErrApiTokenExpired        = errors.New("API token has expired")
+	ErrApiTokenInvalidName    = fmt.Errorf("token name is required and must be at most %d characters", maxApiTokenNameLength)
+	ErrApiTokenInvalidScope   = errors.New("token scopes must be read, members:write or write")
+	ErrApiTokenInvalidExpiry  = errors.New("token expiry must be in the future")
+	ErrApiTokenInvalidNetwork = errors.New("token networks must be 16-character hexadecimal network IDs")
+	ErrApiTokenDelegation     = errors.New("cannot delegate permissions you do not hold on the network")
+	ErrApiTokenScopeDenied    = errors.New("API token scope does not allow this operation")
+)
+
+// ApiTokenNetworkAuthorizer verifies that a user holds the network permissions a token delegates
+type ApiTokenNetworkAuthorizer interface {
+	AuthorizeTokenDelegation(networkID, userID string, write bool) error
+}
+
+// ApiTokenCreateInput describes a new personal access token
+type ApiTokenCreateInput struct {
+	UserID string
+	Name   string
+	Scopes []string
+	// NetworkIDs limits the token to these networks; empty leaves it unrestricted
+	NetworkIDs []string
+	ExpiresAt  *time.Time
+}
+
+// ApiTokenService issues, lists, revokes and authenticates personal access tokens
+type ApiTokenService struct {
+	db         database.DBInterface
+	authorizer ApiTokenNetworkAuthorizer
+	mutex      sync.RWMutex
+}
+
+// NewApiTokenService creates a new API token service instance
+func NewApiTokenService(db database.DBInterface) *ApiTokenService {
+	return &ApiTokenService{db: db}
+}
+
+func (s *ApiTokenService) SetDB(db database.DBInterface) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.db = db
+}
+
+func (s *ApiTokenService) getDB() database.DBInterface {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return s.db
+}
+
+// SetNetworkAuthorizer sets the check used to validate network-scoped tokens at creation
+func (s *ApiTokenService) SetNetworkAuthorizer(authorizer ApiTokenNetworkAuthorizer) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.authorizer = authorizer
+}
+
+func (s *ApiTokenService) getNetworkAuthorizer() ApiTokenNetworkAuthorizer {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return s.authorizer
+}
+
+func hashApiToken(plaintext string) string {
+	sum := sha256.Sum256([]byte(plaintext))
+	return hex.EncodeToString(sum[:])
+}
+
+// normalizeApiTokenScopes accepts read, members:write and write; every token can read,
+// and write implies members:write
+func normalizeApiTokenScopes(scopes []string) (string, error) {
+	for _, scope := range scopes {
+		if scope != ApiTokenScopeRead && scope != ApiTokenScopeMembersWrite && scope != ApiTokenScopeWrite {
+			return "", ErrApiTokenInvalidScope
+		}
+	}
+	switch {
+	case slices.Contains(scopes, ApiTokenScopeWrite):
+		return ApiTokenScopeRead + "," + ApiTokenScopeWrite, nil
+	case slices.Contains(scopes, ApiTokenScopeMembersWrite):
+		return ApiTokenScopeRead + "," + ApiTokenScopeMembersWrite, nil
+	default:
+		return ApiTokenScopeRead, nil
+	}
+}
+
+// normalizeApiTokenNetworks validates and de-duplicates network restrictions
+func normalizeApiTokenNetworks(networkIDs []string) ([]string, error) {
+	normalized := make([]string, 0, len(networkIDs))
+	for _, networkID := range networkIDs {
+		networkID = strings.ToLower(strings.TrimSpace(networkID))
+		if _, err := hex.DecodeString(networkID); err != nil || len(networkID) != 16 {
+			return nil, ErrApiTokenInvalidNetwork
+		}
+		if !slices.Contains(normalized, networkID) {
+			normalized = append(normalized, networkID)
+		}
+	}
+	return normalized, nil
+}
+
+// authorizeDelegation rejects network restrictions on networks where the creator lacks the delegated permission
+func (s *ApiTokenService) authorizeDelegation(userID string, networkIDs []string, scopes string) error {
+	if len(networkIDs) == 0 {
+		return nil
+	}
+	authorizer := s.getNetworkAuthorizer()
+	if authorizer == nil {
+		return fmt.Errorf("network-scoped tokens are unavailable: %w", ErrApiTokenDelegation)
+	}
+	write := scopes != ApiTokenScopeRead
+	for _, networkID := range networkIDs {
+		if err := authorizer.AuthorizeTokenDelegation(networkID, userID, write); err != nil {
+			if IsNetworkNotFound(err) || IsNetworkAccessDenied(err) {
+				return fmt.Errorf("%w: %s", ErrApiTokenDelegation, networkID)
+			}
+			return fmt.Errorf("failed to verify network access: %w", err)
+		}
+	}
+	return nil
+}
+
+// CreateToken stores a new token and returns it with its plaintext, which is never retrievable again
+func (s *ApiTokenService) CreateToken(input ApiTokenCreateInput) (*models.ApiToken, string, error) {
+	db := s.getDB()
+	if db == nil {
+		return nil, "", ErrUserDBUnavailable
+	}
+
+	name := strings.TrimSpace(input.Name)
+	if name == "" || len(name) > maxApiTokenNameLength {
+		return nil, "", ErrApiTokenInvalidName
+	}
+	scopes, err := normalizeApiTokenScopes(input.Scopes)
+	if err != nil {
+		return nil, "", err
+	}
+	networkIDs, err := normalizeApiTokenNetworks(input.NetworkIDs)
+	if err != nil {
+		return nil, "", err
+	}
+	now := time.Now()
+	if input.ExpiresAt != nil && !input.ExpiresAt.After(now) {
+		return nil, "", ErrApiTokenInvalidExpiry
+	}
+	if err := s.authorizeDelegation(input.UserID, networkIDs, scopes); err != nil {
+		return nil, "", err
+	}
+
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, "", fmt.Errorf("failed to generate API token: %w", err)
+	}
+	plaintext := ApiTokenPrefix + base64.RawURLEncoding.EncodeToString(secret)
+
+	token := &models.ApiToken{
+		ID:         uuid.New().String(),
+		UserID:     input.UserID,
+		Name:       name,
+		TokenHash:  hashApiToken(plaintext),
+		Scopes:     scopes,
+		NetworkIDs: strings.Join(networkIDs, ","),
+		ExpiresAt:  input.ExpiresAt,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}
+	if err := db.CreateApiToken(token); err != nil {
+		return nil, "", fmt.Errorf("failed to create API token: %w", err)
+	}
+
+	logger.Info("service: API token created", zap.String("user_id", token.UserID), zap.String("token_id", token.ID), zap.String("scopes", scopes), zap.Strings("network_ids", networkIDs))
+	return token, plaintext, nil
+}
+
+// ListTokens returns every token owned by a user, including revoked ones
+func (s *ApiTokenService) ListTokens(userID string) ([]*models.ApiToken, error) {
+	db := s.getDB()
+	if db == nil {
+		return nil, ErrUserDBUnavailable
+	}
+
+	tokens, err := db.GetApiTokensByUserID(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read API tokens: %w", err)
+	}
+	return tokens, nil
+}
+
+// RevokeToken revokes a token owned by userID
+func (s *ApiTokenService) RevokeToken(userID, tokenID string) error {
+	db := s.getDB()
+	if db == nil {
+		return ErrUserDBUnavailable
+	}
+
+	token, err := db.GetApiTokenByID(tokenID)
+	if err != nil {
+		return fmt.Errorf("failed to read API token: %w", err)
+	}
+	if token == nil || token.UserID != userID {
+		return ErrApiTokenNotFound
+	}
+	if token.RevokedAt != nil {
+		return nil
+	}
+
+	now := time.Now()
+	token.RevokedAt = &now
+	token.UpdatedAt = now
+	if err := db.UpdateApiToken(token); err != nil {
+		return fmt.Errorf("failed to revoke API token: %w", err)
+	}
+	return nil
+}
+
+// Authenticate resolves a plaintext token to its record, rejecting revoked and expired tokens
+func (s *ApiTokenService) Authenticate(plaintext string) (*models.ApiToken, error) {
+	db := s.getDB()
+	if db == nil {
+		return nil, ErrUserDBUnavailable
+	}
+	if !strings.HasPrefix(plaintext, ApiTokenPrefix) {
+		return nil, ErrApiTokenInvalid
+	}
+
+	token, err := db.GetApiTokenByHash(hashApiToken(plaintext))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read API token: %w", err)
+	}
+	if token == nil {
+		return nil, ErrApiTokenInvalid
+	}
+	if token.RevokedAt != nil {
+		return nil, ErrApiTokenRevoked
+	}
+	now := time.Now()
+	if token.ExpiresAt != nil && now.After(*token.ExpiresAt) {
+		return nil, ErrApiTokenExpired
+	}
+
+	if token.LastUsedAt == nil || now.Sub(*token.LastUsedAt) >= apiTokenTouchInterval {
+		token.LastUsedAt = &now
+		token.UpdatedAt = now
+		if err := db.UpdateApiToken(token); err != nil {
+			logger.Warn("service: failed to record API token use", zap.String("token_id", token.ID), zap.Error(err))
+		}
+	}
+	return token, nil
+}
+
+// ApiTokenScope is the set of operations an authenticated API token may perform
+type ApiTokenScope struct {
+	Scopes     []string
+	NetworkIDs []string // Empty allows every network the user can access
+}
+
+// ApiTokenScopeError names the scope a token lacks for an operation
+type ApiTokenScopeError struct {
+	Missing string
+}
+
+func (e *ApiTokenScopeError) Error() string {
+	return fmt.Sprintf("API token is missing the %s scope", e.Missing)
+}
+
+func (e *ApiTokenScopeError) Unwrap() error {
+	return ErrApiTokenScopeDenied
+}
+
+// NewApiTokenScope returns the scope granted by token
+func NewApiTokenScope(token *models.ApiToken) *ApiTokenScope {
+	return &ApiTokenScope{Scopes: token.ScopeList(), NetworkIDs: token.NetworkIDList()}
+}
+
+// Authorize checks that the scope grants permission on networkID. An empty networkID denotes an
+// operation outside any single network, which network-restricted tokens may not perform.
+func (s *ApiTokenScope) Authorize(networkID, permission string) error {
+	switch permission {
+	case ApiTokenScopeRead:
+	case ApiTokenScopeMembersWrite:
+		if !slices.Contains(s.Scopes, ApiTokenScopeMembersWrite) && !slices.Contains(s.Scopes, ApiTokenScopeWrite) {
+			return &ApiTokenScopeError{Missing: ApiTokenScopeMembersWrite}
+		}
+	default:
+		if !slices.Contains(s.Scopes, ApiTokenScopeWrite) {
+			return &ApiTokenScopeError{Missing: ApiTokenScopeWrite}
+		}
+	}
+
+	if len(s.NetworkIDs) == 0 {
+		return nil
+	}
+	if networkID == "" {
+		return &ApiTokenScopeError{Missing: "network:*"}
+	}
+	if !slices.Contains(s.NetworkIDs, strings.ToLower(networkID)) {
+		return &ApiTokenScopeError{Missing: "network:" + networkID}
+	}
+	return nil
+}
@@ -0,0 +1,585 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/GT-610/tairitsu/internal/app/config"
+	"github.com/GT-610/tairitsu/internal/app/crypto"
+	"github.com/GT-610/tairitsu/internal/app/database"
+	"github.com/GT-610/tairitsu/internal/app/logger"
+	"github.com/GT-610/tairitsu/internal/app/models"
+	"go.uber.org/zap"
+)
+
+const (
+	// AppStateArchiveFormat identifies app state archives
+	AppStateArchiveFormat = "tairitsu-app-state"
+	// AppStateArchiveVersion is the version of the encrypted envelope
+	AppStateArchiveVersion = 1
+	// AppStateSchemaVersion is the version of the decrypted snapshot; newer snapshots are refused
+	AppStateSchemaVersion = 1
+
+	appStatePageSize = 500
+)
+
+// Conflict handling of an app state import
+const (
+	// AppStateOnConflictAbort writes nothing when any row conflicts
+	AppStateOnConflictAbort = "abort"
+	// AppStateOnConflictSkip keeps the existing rows and imports everything else
+	AppStateOnConflictSkip = "skip"
+)
+
+// Table names used in import reports
+const (
+	AppStateTableUsers              = "users"
+	AppStateTableNetworks           = "networks"
+	AppStateTableNetworkViewers     = "networkViewers"
+	AppStateTableApiTokens          = "apiTokens"
+	AppStateTableAuditLogs          = "auditLogs"
+	AppStateTableMemberStatusEvents = "memberStatusEvents"
+)
+
+var (
+	ErrAppStateArchiveInvalid    = errors.New("app state archive is not valid")
+	ErrAppStateSchemaUnsupported = errors.New("app state archive was written by a newer version of Tairitsu")
+	ErrAppStateWrongPassword     = errors.New("app state archive password is wrong")
+	ErrAppStateConflicts         = errors.New("app state import conflicts with existing data")
+)
+
+// AppStateArchive is the versioned, password-encrypted envelope of an AppStateSnapshot
+type AppStateArchive struct {
+	Format    string    `json:"format"`
+	Version   int       `json:"version"`
+	CreatedAt time.Time `json:"createdAt"`
+	crypto.PasswordSealed
+}
+
+// AppStateSnapshot holds every Tairitsu-side table and preference. Networks are referenced by
+// their controller ID only; controller objects are never included. Sessions and forwarded
+// controller trace lines are host-local and not exported.
+type AppStateSnapshot struct {
+	SchemaVersion      int                         `json:"schemaVersion"`
+	ExportedAt         time.Time                   `json:"exportedAt"`
+	Settings           config.AppStateSettings     `json:"settings"`
+	Users              []AppStateUser              `json:"users"`
+	Networks           []*models.Network           `json:"networks"`
+	NetworkViewers     []*models.NetworkViewer     `json:"networkViewers"`
+	ApiTokens          []AppStateApiToken          `json:"apiTokens"`
+	AuditLogs          []*models.AuditLog          `json:"auditLogs"`
+	AuditAnchor        *models.AuditAnchor         `json:"auditAnchor,omitempty"`
+	MemberStatusEvents []*models.MemberStatusEvent `json:"memberStatusEvents"`
+}
+
+// AppStateUser is a user with the password hash the API never returns
+type AppStateUser struct {
+	models.User
+	PasswordHash string `json:"passwordHash"`
+}
+
+// AppStateApiToken is an API token with the token hash the API never returns
+type AppStateApiToken struct {
+	models.ApiToken
+	TokenHash string `json:"tokenHash"`
+}
+
+// AppStateConflict is a row of the archive that disagrees with existing data
+type AppStateConflict struct {
+	Table  string `json:"table"`
+	ID     string `json:"id"`
+	Reason string `json:"reason"`
+}
+
+// AppStateMergedUser is an archived user matched to an existing user with the same username.
+// References to the archived ID are rewritten to the existing one, whose password is kept.
+type AppStateMergedUser struct {
+	Username string `json:"username"`
+	SourceID string `json:"sourceId"`
+	TargetID string `json:"targetId"`
+}
+
+// AppStateImportReport summarizes an import; nothing is written when Applied is false
+type AppStateImportReport struct {
+	Applied         bool                 `json:"applied"`
+	SettingsApplied bool                 `json:"settingsApplied"`
+	Imported        map[string]int       `json:"imported"`
+	Unchanged       map[string]int       `json:"unchanged"`
+	MergedUsers     []AppStateMergedUser `json:"mergedUsers"`
+	Conflicts       []AppStateConflict   `json:"conflicts"`
+}
+
+func (r *AppStateImportReport) conflict(table, id, reason string) {
+	r.Conflicts = append(r.Conflicts, AppStateConflict{Table: table, ID: id, Reason: reason})
+}
+
+// AppStateService exports and imports the Tairitsu-side state of an instance, so it can move
+// to a new host that manages the same controller
+type AppStateService struct {
+	db           database.DBInterface
+	mutex        sync.RWMutex
+	stateService *StateService
+	userService  *UserService
+}
+
+// NewAppStateService creates a new app state service instance
+func NewAppStateService(db database.DBInterface, stateService *StateService, userService *UserService) *AppStateService {
+	return &AppStateService{db: db, stateService: stateService, userService: userService}
+}
+
+func (s *AppStateService) SetDB(db database.DBInterface) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.db = db
+}
+
+func (s *AppStateService) getDB() database.DBInterface {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return s.db
+}
+
+// Export encrypts a snapshot of the current state with the given password
+func (s *AppStateService) Export(password string) (*AppStateArchive, error) {
+	snapshot, err := s.Snapshot()
+	if err != nil {
+		return nil, err
+	}
+	payload, err := json.Marshal(snapshot)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode app state: %w", err)
+	}
+	sealed, err := crypto.SealWithPassword(payload, password)
+	if err != nil {
+		return nil, err
+	}
+	return &AppStateArchive{
+		Format:         AppStateArchiveFormat,
+		Version:        AppStateArchiveVersion,
+		CreatedAt:      snapshot.ExportedAt,
+		PasswordSealed: *sealed,
+	}, nil
+}
+
+// Import decrypts an archive and restores it; see Restore
+func (s *AppStateService) Import(archive *AppStateArchive, password, onConflict string) (*AppStateImportReport, error) {
+	if archive == nil || archive.Format != AppStateArchiveFormat || archive.Version < 1 {
+		return nil, ErrAppStateArchiveInvalid
+	}
+	if archive.Version > AppStateArchiveVersion {
+		return nil, ErrAppStateSchemaUnsupported
+	}
+	payload, err := crypto.OpenWithPassword(&archive.PasswordSealed, password)
+	if err != nil {
+		if errors.Is(err, crypto.ErrDecryptFailed) {
+			return nil, ErrAppStateWrongPassword
+		}
+		return nil, fmt.Errorf("%w: %v", ErrAppStateArchiveInvalid, err)
+	}
+	var snapshot AppStateSnapshot
+	if err := json.Unmarshal(payload, &snapshot); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrAppStateArchiveInvalid, err)
+	}
+	return s.Restore(&snapshot, onConflict)
+}
+
+// Snapshot reads every exported table and the exportable preferences
+func (s *AppStateService) Snapshot() (*AppStateSnapshot, error) {
+	db := s.getDB()
+	if db == nil {
+		return nil, ErrUserDBUnavailable
+	}
+
+	snapshot := &AppStateSnapshot{
+		SchemaVersion: AppStateSchemaVersion,
+		ExportedAt:    time.Now().UTC(),
+		Settings:      config.AppStateSettingsFrom(s.stateService.Config()),
+	}
+
+	users, err := db.GetAllUsers()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read users: %w", err)
+	}
+	for _, user := range users {
+		snapshot.Users = append(snapshot.Users, AppStateUser{User: *user, PasswordHash: user.Password})
+		tokens, err := db.GetApiTokensByUserID(user.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read API tokens: %w", err)
+		}
+		for _, token := range tokens {
+			snapshot.ApiTokens = append(snapshot.ApiTokens, AppStateApiToken{ApiToken: *token, TokenHash: token.TokenHash})
+		}
+	}
+
+	networks, err := db.GetAllNetworks()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read networks: %w", err)
+	}
+	snapshot.Networks = networks
+	for _, network := range networks {
+		viewers, err := db.GetNetworkViewers(network.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read network viewers: %w", err)
+		}
+		snapshot.NetworkViewers = append(snapshot.NetworkViewers, viewers...)
+	}
+
+	for afterID := uint64(0); ; {
+		entries, err := db.ListAuditLogs(afterID, appStatePageSize)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read audit log: %w", err)
+		}
+		snapshot.AuditLogs = append(snapshot.AuditLogs, entries...)
+		if len(entries) < appStatePageSize {
+			break
+		}
+		afterID = entries[len(entries)-1].ID
+	}
+	if snapshot.AuditAnchor, err = db.GetAuditAnchor(); err != nil {
+		return nil, fmt.Errorf("failed to read audit anchor: %w", err)
+	}
+
+	for afterID := uint64(0); ; {
+		events, err := db.ListMemberStatusEventsAfter(afterID, appStatePageSize)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read member status history: %w", err)
+		}
+		snapshot.MemberStatusEvents = append(snapshot.MemberStatusEvents, events...)
+		if len(events) < appStatePageSize {
+			break
+		}
+		afterID = events[len(events)-1].ID
+	}
+
+	return snapshot, nil
+}
+
+// appStatePlan lists the rows a restore will write
+type appStatePlan struct {
+	users       []*models.User
+	networks    []*models.Network
+	viewers     []*models.NetworkViewer
+	tokens      []*models.ApiToken
+	auditLogs   []*models.AuditLog
+	auditAnchor *models.AuditAnchor
+	history     []*models.MemberStatusEvent
+}
+
+// Restore writes a snapshot into the current database in one transaction. Rows missing here
+// are inserted and identical rows left alone. An archived user whose username already exists
+// under another ID is merged into that user. Any other disagreement is a conflict: with
+// AppStateOnConflictAbort nothing is written and ErrAppStateConflicts is returned alongside the
+// report; with AppStateOnConflictSkip the existing rows win. The audit chain is only imported
+// into an empty audit log, and member status history is appended without duplicates.
+func (s *AppStateService) Restore(snapshot *AppStateSnapshot, onConflict string) (*AppStateImportReport, error) {
+	if snapshot == nil {
+		return nil, ErrAppStateArchiveInvalid
+	}
+	if snapshot.SchemaVersion > AppStateSchemaVersion {
+		return nil, ErrAppStateSchemaUnsupported
+	}
+	if onConflict == "" {
+		onConflict = AppStateOnConflictAbort
+	}
+	if onConflict != AppStateOnConflictAbort && onConflict != AppStateOnConflictSkip {
+		return nil, fmt.Errorf("unknown conflict mode %q", onConflict)
+	}
+	db := s.getDB()
+	if db == nil {
+		return nil, ErrUserDBUnavailable
+	}
+
+	report := &AppStateImportReport{
+		Imported:    map[string]int{},
+		Unchanged:   map[string]int{},
+		MergedUsers: []AppStateMergedUser{},
+		Conflicts:   []AppStateConflict{},
+	}
+	plan, err := planAppStateRestore(db, snapshot, report)
+	if err != nil {
+		return nil, err
+	}
+	if len(report.Conflicts) > 0 && onConflict == AppStateOnConflictAbort {
+		return report, ErrAppStateConflicts
+	}
+
+	err = db.WithTransaction(func(tx database.DBInterface) error {
+		for _, user := range plan.users {
+			if err := tx.CreateUser(user); err != nil {
+				return fmt.Errorf("failed to import user %s: %w", user.Username, err)
+			}
+		}
+		for _, network := range plan.networks {
+			if err := tx.CreateNetwork(network); err != nil {
+				return fmt.Errorf("failed to import network %s: %w", network.ID, err)
+			}
+		}
+		for _, viewer := range plan.viewers {
+			if err := tx.UpsertNetworkViewer(viewer); err != nil {
+				return fmt.Errorf("failed to import viewer of network %s: %w", viewer.NetworkID, err)
+			}
+		}
+		for _, token := range plan.tokens {
+			if err := tx.CreateApiToken(token); err != nil {
+				return fmt.Errorf("failed to import API token %s: %w", token.ID, err)
+			}
+		}
+		for _, entry := range plan.auditLogs {
+			if err := tx.CreateAuditLog(entry); err != nil {
+				return fmt.Errorf("failed to import audit log: %w", err)
+			}
+		}
+		if plan.auditAnchor != nil {
+			if err := tx.SaveAuditAnchor(plan.auditAnchor); err != nil {
+				return fmt.Errorf("failed to import audit anchor: %w", err)
+			}
+		}
+		for start := 0; start < len(plan.history); start += appStatePageSize {
+			if err := tx.CreateMemberStatusEvents(plan.history[start:min(start+appStatePageSize, len(plan.history))]); err != nil {
+				return fmt.Errorf("failed to import member status history: %w", err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	report.Applied = true
+	report.Imported[AppStateTableUsers] = len(plan.users)
+	report.Imported[AppStateTableNetworks] = len(plan.networks)
+	report.Imported[AppStateTableNetworkViewers] = len(plan.viewers)
+	report.Imported[AppStateTableApiTokens] = len(plan.tokens)
+	report.Imported[AppStateTableAuditLogs] = len(plan.auditLogs)
+	report.Imported[AppStateTableMemberStatusEvents] = len(plan.history)
+
+	if cfg := s.stateService.Config(); cfg != nil {
+		if err := config.SetAppStateSettingsOn(cfg, snapshot.Settings); err != nil {
+			logger.Error("service: failed to apply imported settings", zap.Error(err))
+			return report, fmt.Errorf("data was imported but settings could not be saved: %w", err)
+		}
+		if s.userService != nil {
+			s.userService.SetPasswordPolicy(PasswordPolicyFromConfig(cfg))
+		}
+		report.SettingsApplied = true
+	}
+	return report, nil
+}
+
+// planAppStateRestore compares the snapshot with the database and records conflicts in report
+func planAppStateRestore(db database.DBInterface, snapshot *AppStateSnapshot, report *AppStateImportReport) (*appStatePlan, error) {
+	plan := &appStatePlan{}
+
+	existingUsers, err := db.GetAllUsers()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read users: %w", err)
+	}
+	usersByID := make(map[string]*models.User, len(existingUsers))
+	usersByName := make(map[string]*models.User, len(existingUsers))
+	usersByEmail := make(map[string]*models.User, len(existingUsers))
+	for _, user := range existingUsers {
+		usersByID[user.ID] = user
+		usersByName[user.Username] = user
+		if email := user.EmailAddress(); email != "" {
+			usersByEmail[email] = user
+		}
+	}
+	// userIDs maps archived user IDs to the IDs they have here; conflicting users are absent
+	userIDs := make(map[string]string, len(snapshot.Users))
+	for _, archived := range snapshot.Users {
+		if existing := usersByID[archived.ID]; existing != nil {
+			if existing.Username != archived.Username {
+				report.conflict(AppStateTableUsers, archived.ID, fmt.Sprintf("user ID belongs to %q here", existing.Username))
+				continue
+			}
+			userIDs[archived.ID] = existing.ID
+			report.Unchanged[AppStateTableUsers]++
+			continue
+		}
+		if existing := usersByName[archived.Username]; existing != nil {
+			userIDs[archived.ID] = existing.ID
+			report.MergedUsers = append(report.MergedUsers, AppStateMergedUser{Username: archived.Username, SourceID: archived.ID, TargetID: existing.ID})
+			continue
+		}
+		if existing := usersByEmail[archived.EmailAddress()]; existing != nil {
+			report.conflict(AppStateTableUsers, archived.ID, fmt.Sprintf("email address belongs to %q here", existing.Username))
+			continue
+		}
+		user := archived.User
+		user.Password = archived.PasswordHash
+		plan.users = append(plan.users, &user)
+		userIDs[archived.ID] = archived.ID
+	}
+	mapUser := func(id string) (string, bool) {
+		if id == "" {
+			return "", true
+		}
+		mapped, ok := userIDs[id]
+		return mapped, ok
+	}
+
+	importedNetworks := make(map[string]bool, len(snapshot.Networks))
+	for _, archived := range snapshot.Networks {
+		ownerID, ok := mapUser(archived.OwnerID)
+		if !ok {
+			report.conflict(AppStateTableNetworks, archived.ID, "owner was not imported")
+			continue
+		}
+		existing, err := db.GetNetworkByID(archived.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read network %s: %w", archived.ID, err)
+		}
+		if existing != nil {
+			if existing.OwnerID != ownerID || existing.Name != archived.Name || existing.Description != archived.Description ||
+				existing.PhysicalAddressPolicy != archived.PhysicalAddressPolicy || existing.StatusPagePublished != archived.StatusPagePublished {
+				report.conflict(AppStateTableNetworks, archived.ID, "network is already registered with different ownership or metadata")
+				continue
+			}
+			importedNetworks[archived.ID] = true
+			report.Unchanged[AppStateTableNetworks]++
+			continue
+		}
+		network := *archived
+		network.OwnerID = ownerID
+		plan.networks = append(plan.networks, &network)
+		importedNetworks[archived.ID] = true
+	}
+
+	for _, archived := range snapshot.NetworkViewers {
+		viewerID := archived.NetworkID + "/" + archived.UserID
+		if !importedNetworks[archived.NetworkID] {
+			report.conflict(AppStateTableNetworkViewers, viewerID, "network was not imported")
+			continue
+		}
+		userID, userOK := mapUser(archived.UserID)
+		grantedBy, grantorOK := mapUser(archived.GrantedBy)
+		if !userOK || !grantorOK {
+			report.conflict(AppStateTableNetworkViewers, viewerID, "user was not imported")
+			continue
+		}
+		existing, err := db.GetNetworkViewer(archived.NetworkID, userID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read network viewer %s: %w", viewerID, err)
+		}
+		if existing != nil {
+			report.Unchanged[AppStateTableNetworkViewers]++
+			continue
+		}
+		viewer := *archived
+		viewer.UserID = userID
+		viewer.GrantedBy = grantedBy
+		plan.viewers = append(plan.viewers, &viewer)
+	}
+
+	for _, archived := range snapshot.ApiTokens {
+		userID, ok := mapUser(archived.UserID)
+		if !ok {
+			report.conflict(AppStateTableApiTokens, archived.ID, "owner was not imported")
+			continue
+		}
+		existing, err := db.GetApiTokenByID(archived.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read API token %s: %w", archived.ID, err)
+		}
+		if existing != nil {
+			if existing.TokenHash != archived.TokenHash {
+				report.conflict(AppStateTableApiTokens, archived.ID, "token ID is used by another token")
+				continue
+			}
+			report.Unchanged[AppStateTableApiTokens]++
+			continue
+		}
+		if sameHash, err := db.GetApiTokenByHash(archived.TokenHash); err != nil {
+			return nil, fmt.Errorf("failed to read API token %s: %w", archived.ID, err)
+		} else if sameHash != nil {
+			report.conflict(AppStateTableApiTokens, archived.ID, "token secret is used by another token")
+			continue
+		}
+		token := archived.ApiToken
+		token.UserID = userID
+		token.TokenHash = archived.TokenHash
+		plan.tokens = append(plan.tokens, &token)
+	}
+
+	if err := planAuditRestore(db, snapshot, plan, report); err != nil {
+		return nil, err
+	}
+	if err := planHistoryRestore(db, snapshot, plan, report); err != nil {
+		return nil, err
+	}
+	return plan, nil
+}
+
+// planAuditRestore imports the audit chain only into an empty audit log. Entries get new IDs,
+// so the anchor is rebased to ID zero while keeping the hash the first entry links to.
+func planAuditRestore(db database.DBInterface, snapshot *AppStateSnapshot, plan *appStatePlan, report *AppStateImportReport) error {
+	if len(snapshot.AuditLogs) == 0 && snapshot.AuditAnchor == nil {
+		return nil
+	}
+	latest, err := db.GetLatestAuditLog()
+	if err != nil {
+		return fmt.Errorf("failed to read audit log: %w", err)
+	}
+	anchor, err := db.GetAuditAnchor()
+	if err != nil {
+		return fmt.Errorf("failed to read audit anchor: %w", err)
+	}
+	if latest != nil || anchor != nil {
+		report.conflict(AppStateTableAuditLogs, "", "audit log already has entries; the archived chain cannot be joined to it")
+		return nil
+	}
+
+	for _, archived := range snapshot.AuditLogs {
+		entry := *archived
+		entry.ID = 0
+		plan.auditLogs = append(plan.auditLogs, &entry)
+	}
+	if snapshot.AuditAnchor != nil {
+		plan.auditAnchor = &models.AuditAnchor{LastPrunedHash: snapshot.AuditAnchor.LastPrunedHash, UpdatedAt: snapshot.AuditAnchor.UpdatedAt}
+	}
+	return nil
+}
+
+// planHistoryRestore appends archived member status transitions not already recorded here
+func planHistoryRestore(db database.DBInterface, snapshot *AppStateSnapshot, plan *appStatePlan, report *AppStateImportReport) error {
+	if len(snapshot.MemberStatusEvents) == 0 {
+		return nil
+	}
+	type transition struct {
+		networkID, memberID string
+		changedAt           int64
+		online              bool
+	}
+	key := func(event *models.MemberStatusEvent) transition {
+		return transition{event.NetworkID, event.MemberID, event.ChangedAt.UnixMilli(), event.Online}
+	}
+
+	recorded := map[transition]bool{}
+	for afterID := uint64(0); ; {
+		events, err := db.ListMemberStatusEventsAfter(afterID, appStatePageSize)
+		if err != nil {
+			return fmt.Errorf("failed to read member status history: %w", err)
+		}
+		for _, event := range events {
+			recorded[key(event)] = true
+		}
+		if len(events) < appStatePageSize {
+			break
+		}
+		afterID = events[len(events)-1].ID
+	}
+
+	for _, archived := range snapshot.MemberStatusEvents {
+		if recorded[key(archived)] {
+			report.Unchanged[AppStateTableMemberStatusEvents]++
+			continue
+		}
+		recorded[key(archived)] = true
+		event := *archived
+		event.ID = 0
+		plan.history = append(plan.history, &event)
+	}
+	return nil
+}
@@ -0,0 +1,460 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/GT-610/tairitsu/internal/app/database"
+	"github.com/GT-610/tairitsu/internal/app/logger"
+	"github.com/GT-610/tairitsu/internal/app/models"
+	"go.uber.org/zap"
+)
+
+const (
+	auditVerifyInterval    = time.Hour
+	auditVerifySegmentSize = 1000
+	auditVerifyBatchSize   = 500
+)
+
+// AuditEntryInput describes an event to append to the audit log
+type AuditEntryInput struct {
+	ActorID string
+	Action  string
+	Target  string
+	Details string
+}
+
+// AuditVerification is the result of walking the audit hash chain
+type AuditVerification struct {
+	Valid         bool      `json:"valid"`
+	CheckedCount  int       `json:"checkedCount"`
+	BrokenEntryID uint64    `json:"brokenEntryId,omitempty"`
+	Reason        string    `json:"reason,omitempty"`
+	VerifiedAt    time.Time `json:"verifiedAt"`
+}
+
+// AuditVerificationMetrics summarizes scheduled chain verifications
+type AuditVerificationMetrics struct {
+	Runs       int64              `json:"runs"`
+	Failures   int64              `json:"failures"`
+	LastResult *AuditVerification `json:"lastResult,omitempty"`
+}
+
+// AuditListQuery filters an audit log listing
+type AuditListQuery struct {
+	Action  string
+	ActorID string
+}
+
+// AuditPage is one page of audit entries, newest first
+type AuditPage struct {
+	Items []*models.AuditLog `json:"items"`
+	PageInfo
+}
+
+// auditCanonicalEntry fixes the field order hashed for each audit entry.
+type auditCanonicalEntry struct {
+	ActorID   string `json:"actor_id"`
+	Action    string `json:"action"`
+	Target    string `json:"target"`
+	Details   string `json:"details"`
+	CreatedAt int64  `json:"created_at"`
+	PrevHash  string `json:"prev_hash"`
+}
+
+// AuditService maintains the tamper-evident audit log
+type AuditService struct {
+	db            database.DBInterface
+	mutex         sync.RWMutex
+	writeMutex    sync.Mutex
+	retentionDays int
+	metrics       AuditVerificationMetrics
+	metricsMutex  sync.RWMutex
+	shipper       *LogShipper
+}
+
+// NewAuditService creates a new audit service instance
+func NewAuditService(db database.DBInterface) *AuditService {
+	return &AuditService{db: db}
+}
+
+func (s *AuditService) SetDB(db database.DBInterface) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.db = db
+}
+
+func (s *AuditService) getDB() database.DBInterface {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return s.db
+}
+
+// SetRetentionDays configures how long audit entries are kept; zero keeps them forever
+func (s *AuditService) SetRetentionDays(days int) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.retentionDays = days
+}
+
+// SetLogShipper forwards every recorded entry to shipper
+func (s *AuditService) SetLogShipper(shipper *LogShipper) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.shipper = shipper
+}
+
+func (s *AuditService) getLogShipper() *LogShipper {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return s.shipper
+}
+
+func (s *AuditService) getRetentionDays() int {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return s.retentionDays
+}
+
+// HashAuditEntry computes the chained SHA-256 hash of an audit entry
+func HashAuditEntry(entry *models.AuditLog) string {
+	canonical, _ := json.Marshal(auditCanonicalEntry{
+		ActorID:   entry.ActorID,
+		Action:    entry.Action,
+		Target:    entry.Target,
+		Details:   entry.Details,
+		CreatedAt: entry.CreatedAt.UTC().UnixMilli(),
+		PrevHash:  entry.PrevHash,
+	})
+	sum := sha256.Sum256(canonical)
+	return hex.EncodeToString(sum[:])
+}
+
+// Record appends a new entry to the audit log, linking it to the previous entry
+func (s *AuditService) Record(input AuditEntryInput) (*models.AuditLog, error) {
+	db := s.getDB()
+	if db == nil {
+		return nil, ErrUserDBUnavailable
+	}
+
+	s.writeMutex.Lock()
+	defer s.writeMutex.Unlock()
+
+	var entry *models.AuditLog
+	err := db.WithTransaction(func(tx database.DBInterface) error {
+		prevHash, err := s.chainHead(tx)
+		if err != nil {
+			return err
+		}
+
+		entry = &models.AuditLog{
+			ActorID: input.ActorID,
+			Action:  input.Action,
+			Target:  input.Target,
+			Details: input.Details,
+			// Millisecond precision survives every supported database backend unchanged.
+			CreatedAt: time.Now().UTC().Truncate(time.Millisecond),
+			PrevHash:  prevHash,
+		}
+		entry.Hash = HashAuditEntry(entry)
+		return tx.CreateAuditLog(entry)
+	})
+	if err != nil {
+		logger.Error("service: failed to record audit entry", zap.String("action", input.Action), zap.Error(err))
+		return nil, err
+	}
+
+	s.getLogShipper().Ship(ShippedEvent{
+		Time:    entry.CreatedAt,
+		Kind:    ShippedEventAudit,
+		Action:  entry.Action,
+		ActorID: entry.ActorID,
+		Target:  entry.Target,
+		Details: entry.Details,
+	})
+	return entry, nil
+}
+
+// chainHead returns the hash new entries must link to
+func (s *AuditService) chainHead(db database.DBInterface) (string, error) {
+	latest, err := db.GetLatestAuditLog()
+	if err != nil {
+		return "", err
+	}
+	if latest != nil {
+		return latest.Hash, nil
+	}
+
+	anchor, err := db.GetAuditAnchor()
+	if err != nil {
+		return "", err
+	}
+	if anchor != nil {
+		return anchor.LastPrunedHash, nil
+	}
+	return "", nil
+}
+
+// Verify walks the whole audit chain from its anchor and reports the first broken link
+func (s *AuditService) Verify() (*AuditVerification, error) {
+	db := s.getDB()
+	if db == nil {
+		return nil, ErrUserDBUnavailable
+	}
+
+	anchor, err := db.GetAuditAnchor()
+	if err != nil {
+		return nil, err
+	}
+
+	result := &AuditVerification{Valid: true, VerifiedAt: time.Now()}
+	expectedPrev := ""
+	var afterID uint64
+	if anchor != nil {
+		expectedPrev = anchor.LastPrunedHash
+		afterID = anchor.LastPrunedID
+	}
+
+	for {
+		entries, err := db.ListAuditLogs(afterID, auditVerifyBatchSize)
+		if err != nil {
+			return nil, err
+		}
+		if len(entries) == 0 {
+			return result, nil
+		}
+
+		for _, entry := range entries {
+			if !verifyAuditEntry(entry, expectedPrev, result) {
+				return result, nil
+			}
+			expectedPrev = entry.Hash
+			afterID = entry.ID
+		}
+	}
+}
+
+// VerifyRecent checks the newest segment of the chain; the oldest entry of the segment seeds the walk
+func (s *AuditService) VerifyRecent(segmentSize int) (*AuditVerification, error) {
+	db := s.getDB()
+	if db == nil {
+		return nil, ErrUserDBUnavailable
+	}
+
+	entries, err := db.ListRecentAuditLogs(segmentSize)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &AuditVerification{Valid: true, VerifiedAt: time.Now()}
+	for i, entry := range entries {
+		expectedPrev := entry.PrevHash
+		if i > 0 {
+			expectedPrev = entries[i-1].Hash
+		}
+		if !verifyAuditEntry(entry, expectedPrev, result) {
+			break
+		}
+	}
+
+	return result, nil
+}
+
+// ListEntries returns one page of audit entries, newest first.
+// Cursors are keyed on the entry ID, which only ever grows, so entries
+// recorded while paging never shift the older pages.
+func (s *AuditService) ListEntries(query AuditListQuery, req PageRequest) (*AuditPage, error) {
+	limit, err := req.normalizedLimit()
+	if err != nil {
+		return nil, err
+	}
+	db := s.getDB()
+	if db == nil {
+		return nil, ErrUserDBUnavailable
+	}
+
+	fingerprint := pageQuery("audit", query.Action, query.ActorID)
+	dbQuery := models.AuditLogQuery{Action: query.Action, ActorID: query.ActorID}
+	info := PageInfo{Limit: limit}
+
+	if req.UseOffset {
+		dbQuery.Offset = req.Offset
+		dbQuery.Limit = limit
+		entries, err := db.QueryAuditLogs(dbQuery)
+		if err != nil {
+			return nil, err
+		}
+		offset := req.Offset
+		info.Offset = &offset
+		return &AuditPage{Items: entries, PageInfo: info}, nil
+	}
+
+	direction := ""
+	if req.Cursor != "" {
+		cursor, err := pageCursors.Decode(req.Cursor, fingerprint)
+		if err != nil {
+			return nil, err
+		}
+		boundary, err := strconv.ParseUint(cursor.LastID, 10, 64)
+		if err != nil {
+			return nil, ErrInvalidCursor
+		}
+		direction = cursor.Direction
+		if direction == PageDirectionNext {
+			dbQuery.BeforeID = boundary
+		} else {
+			dbQuery.AfterID = boundary
+			dbQuery.Ascending = true
+		}
+	}
+
+	// One extra row tells whether another page exists in the fetch direction.
+	dbQuery.Limit = limit + 1
+	entries, err := db.QueryAuditLogs(dbQuery)
+	if err != nil {
+		return nil, err
+	}
+	more := len(entries) > limit
+	if more {
+		entries = entries[:limit]
+	}
+	if dbQuery.Ascending {
+		for i, j := 0, len(entries)-1; i < j; i, j = i+1, j-1 {
+			entries[i], entries[j] = entries[j], entries[i]
+		}
+	}
+
+	hasNext := more
+	hasPrev := direction == PageDirectionNext
+	if direction == PageDirectionPrev {
+		hasNext, hasPrev = true, more
+	}
+	if len(entries) > 0 {
+		if hasNext {
+			info.NextCursor = pageCursors.Encode(PageCursor{Query: fingerprint, Direction: PageDirectionNext, LastID: strconv.FormatUint(entries[len(entries)-1].ID, 10)})
+		}
+		if hasPrev {
+			info.PrevCursor = pageCursors.Encode(PageCursor{Query: fingerprint, Direction: PageDirectionPrev, LastID: strconv.FormatUint(entries[0].ID, 10)})
+		}
+	}
+
+	return &AuditPage{Items: entries, PageInfo: info}, nil
+}
+
+func verifyAuditEntry(entry *models.AuditLog, expectedPrev string, result *AuditVerification) bool {
+	result.CheckedCount++
+	if entry.PrevHash != expectedPrev {
+		result.Valid = false
+		result.BrokenEntryID = entry.ID
+		result.Reason = "previous hash does not match the preceding entry"
+		return false
+	}
+	if HashAuditEntry(entry) != entry.Hash {
+		result.Valid = false
+		result.BrokenEntryID = entry.ID
+		result.Reason = "entry hash does not match its contents"
+		return false
+	}
+	return true
+}
+
+// Prune deletes entries created before the cutoff and anchors the chain at the last pruned hash
+func (s *AuditService) Prune(before time.Time) (int64, error) {
+	db := s.getDB()
+	if db == nil {
+		return 0, ErrUserDBUnavailable
+	}
+
+	s.writeMutex.Lock()
+	defer s.writeMutex.Unlock()
+
+	var deleted int64
+	err := db.WithTransaction(func(tx database.DBInterface) error {
+		last, err := tx.GetLatestAuditLogBefore(before)
+		if err != nil || last == nil {
+			return err
+		}
+
+		if err := tx.SaveAuditAnchor(&models.AuditAnchor{
+			LastPrunedID:   last.ID,
+			LastPrunedHash: last.Hash,
+			UpdatedAt:      time.Now(),
+		}); err != nil {
+			return err
+		}
+
+		deleted, err = tx.DeleteAuditLogsUpTo(last.ID)
+		return err
+	})
+	if err != nil {
+		logger.Error("service: failed to prune audit log", zap.Error(err))
+		return 0, err
+	}
+
+	return deleted, nil
+}
+
+// Metrics returns the results of scheduled chain verification
+func (s *AuditService) Metrics() AuditVerificationMetrics {
+	s.metricsMutex.RLock()
+	defer s.metricsMutex.RUnlock()
+	return s.metrics
+}
+
+func (s *AuditService) recordVerification(result *AuditVerification) {
+	s.metricsMutex.Lock()
+	defer s.metricsMutex.Unlock()
+	s.metrics.Runs++
+	if !result.Valid {
+		s.metrics.Failures++
+	}
+	s.metrics.LastResult = result
+}
+
+// StartMaintenance periodically prunes expired entries and verifies the recent chain segment
+func (s *AuditService) StartMaintenance(ctx context.Context) <-chan struct{} {
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(auditVerifyInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				s.runMaintenance()
+			}
+		}
+	}()
+	return done
+}
+
+func (s *AuditService) runMaintenance() {
+	if s.getDB() == nil {
+		return
+	}
+
+	if days := s.getRetentionDays(); days > 0 {
+		if _, err := s.Prune(time.Now().AddDate(0, 0, -days)); err != nil {
+			logger.Warn("audit log pruning failed", zap.Error(err))
+		}
+	}
+
+	result, err := s.VerifyRecent(auditVerifySegmentSize)
+	if err != nil {
+		logger.Warn("audit chain verification failed to run", zap.Error(err))
+		return
+	}
+	s.recordVerification(result)
+	if !result.Valid {
+		logger.Error("audit chain verification detected tampering",
+			zap.Uint64("broken_entry_id", result.BrokenEntryID),
+			zap.String("reason", result.Reason))
+	}
+}
@@ -0,0 +1,227 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/GT-610/tairitsu/internal/app/config"
+	"github.com/GT-610/tairitsu/internal/app/logger"
+	"go.uber.org/zap"
+)
+
+const (
+	// loginFailureWindow is how long a failed sign-in counts towards the captcha threshold
+	loginFailureWindow = 15 * time.Minute
+	// maxTrackedLoginFailures bounds the clients and usernames whose failures are remembered
+	maxTrackedLoginFailures = 10000
+)
+
+// CaptchaVerifier checks the token a captcha widget gave the client. Verify returns
+// ErrCaptchaInvalid when the provider rejects the token, and ErrCaptchaUnavailable when the
+// provider cannot be asked.
+type CaptchaVerifier interface {
+	Verify(ctx context.Context, token, remoteIP string) error
+}
+
+// NewCaptchaVerifier returns the verifier for settings from config.CaptchaFrom, or nil when
+// no provider is configured
+func NewCaptchaVerifier(settings config.CaptchaConfig) CaptchaVerifier {
+	if settings.Provider == "" {
+		return nil
+	}
+	return &siteVerifyCaptcha{
+		verifyURL:  settings.VerifyURL,
+		secret:     settings.Secret,
+		siteKey:    settings.SiteKey,
+		httpClient: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// siteVerifyCaptcha asks a siteverify endpoint, which hCaptcha and Turnstile both implement
+type siteVerifyCaptcha struct {
+	verifyURL  string
+	secret     string
+	siteKey    string
+	httpClient *http.Client
+}
+
+func (v *siteVerifyCaptcha) Verify(ctx context.Context, token, remoteIP string) error {
+	form := url.Values{"secret": {v.secret}, "response": {token}, "sitekey": {v.siteKey}}
+	if remoteIP != "" {
+		form.Set("remoteip", remoteIP)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, v.verifyURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrCaptchaUnavailable, err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp, err := v.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrCaptchaUnavailable, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%w: siteverify answered %s", ErrCaptchaUnavailable, resp.Status)
+	}
+
+	var result struct {
+		Success    bool     `json:"success"`
+		ErrorCodes []string `json:"error-codes"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return fmt.Errorf("%w: invalid siteverify response: %v", ErrCaptchaUnavailable, err)
+	}
+	if !result.Success {
+		return fmt.Errorf("%w (%s)", ErrCaptchaInvalid, strings.Join(result.ErrorCodes, ", "))
+	}
+	return nil
+}
+
+// CaptchaChallenge is what a client needs to render the captcha widget
+type CaptchaChallenge struct {
+	Provider string `json:"provider"`
+	SiteKey  string `json:"siteKey"`
+}
+
+// CaptchaGuard decides when registration and password sign-in need a solved captcha, and
+// checks it. Registration always needs one; a sign-in needs one once its client IP or
+// username has failed LoginFailures times within 15 minutes. Without a verifier the guard
+// never asks for a captcha.
+type CaptchaGuard struct {
+	verifier  CaptchaVerifier
+	challenge CaptchaChallenge
+	threshold int
+
+	mutex    sync.Mutex
+	failures map[string][]time.Time // "ip:" or "user:" key to the times of recent failures
+}
+
+// NewCaptchaGuard creates the guard for settings from config.CaptchaFrom. Tests pass a fake
+// verifier; the application passes NewCaptchaVerifier(settings).
+func NewCaptchaGuard(settings config.CaptchaConfig, verifier CaptchaVerifier) *CaptchaGuard {
+	return &CaptchaGuard{
+		verifier:  verifier,
+		challenge: CaptchaChallenge{Provider: settings.Provider, SiteKey: settings.SiteKey},
+		threshold: max(settings.LoginFailures, 1),
+		failures:  make(map[string][]time.Time),
+	}
+}
+
+// Enabled reports whether the guard asks for captchas
+func (g *CaptchaGuard) Enabled() bool {
+	return g != nil && g.verifier != nil
+}
+
+// Challenge returns the widget settings, or nil when the guard is disabled
+func (g *CaptchaGuard) Challenge() *CaptchaChallenge {
+	if !g.Enabled() {
+		return nil
+	}
+	challenge := g.challenge
+	return &challenge
+}
+
+// Check verifies token, returning ErrCaptchaRequired when it is empty
+func (g *CaptchaGuard) Check(ctx context.Context, token, remoteIP string) error {
+	if !g.Enabled() {
+		return nil
+	}
+	if strings.TrimSpace(token) == "" {
+		return ErrCaptchaRequired
+	}
+	if err := g.verifier.Verify(ctx, token, remoteIP); err != nil {
+		logger.Warn("captcha verification failed", zap.String("client_ip", remoteIP), zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+// LoginNeedsCaptcha reports whether a sign-in from remoteIP as username must carry a captcha
+func (g *CaptchaGuard) LoginNeedsCaptcha(username, remoteIP string) bool {
+	if !g.Enabled() {
+		return false
+	}
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	now := time.Now()
+	for _, key := range loginFailureKeys(username, remoteIP) {
+		if len(g.recentFailures(key, now)) >= g.threshold {
+			return true
+		}
+	}
+	return false
+}
+
+// RecordLoginFailure counts a failed sign-in and reports whether the next attempt needs a captcha
+func (g *CaptchaGuard) RecordLoginFailure(username, remoteIP string) bool {
+	if !g.Enabled() {
+		return false
+	}
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	now := time.Now()
+	if len(g.failures) >= maxTrackedLoginFailures {
+		g.pruneFailures(now)
+	}
+	needsCaptcha := false
+	for _, key := range loginFailureKeys(username, remoteIP) {
+		recent := g.recentFailures(key, now)
+		if len(recent) == 0 && len(g.failures) >= maxTrackedLoginFailures {
+			// Still full after pruning; the rate limits bound what is not tracked
+			continue
+		}
+		recent = append(recent, now)
+		g.failures[key] = recent
+		needsCaptcha = needsCaptcha || len(recent) >= g.threshold
+	}
+	return needsCaptcha
+}
+
+// ClearLoginFailures forgets the failures of username after it signed in. Failures from the
+// client IP are kept, so signing in to one account does not reset guessing at others.
+func (g *CaptchaGuard) ClearLoginFailures(username string) {
+	if !g.Enabled() {
+		return
+	}
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	delete(g.failures, "user:"+normalizeLoginUsername(username))
+}
+
+// recentFailures returns the failures of key within the window, dropping older ones
+func (g *CaptchaGuard) recentFailures(key string, now time.Time) []time.Time {
+	times := g.failures[key]
+	first := 0
+	for first < len(times) && now.Sub(times[first]) > loginFailureWindow {
+		first++
+	}
+	if first == len(times) {
+		delete(g.failures, key)
+		return nil
+	}
+	return times[first:]
+}
+
+func (g *CaptchaGuard) pruneFailures(now time.Time) {
+	for key := range g.failures {
+		g.recentFailures(key, now)
+	}
+}
+
+func loginFailureKeys(username, remoteIP string) []string {
+	keys := []string{"ip:" + remoteIP}
+	if username := normalizeLoginUsername(username); username != "" {
+		keys = append(keys, "user:"+username)
+	}
+	return keys
+}
+
+func normalizeLoginUsername(username string) string {
+	return strings.ToLower(strings.TrimSpace(username))
+}
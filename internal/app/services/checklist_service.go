@@ -0,0 +1,170 @@
+package services
+
+import (
+	"errors"
+
+	"github.com/GT-610/tairitsu/internal/app/config"
+	"github.com/GT-610/tairitsu/internal/app/logger"
+	"go.uber.org/zap"
+)
+
+const (
+	ChecklistItemNetworkCreated    = "network_created"
+	ChecklistItemSecureTransport   = "secure_transport"
+	ChecklistItemBackupsConfigured = "backups_configured"
+	ChecklistItemMultipleAdmins    = "multiple_admins"
+)
+
+var ErrChecklistItemNotFound = errors.New("checklist item not found")
+
+// ChecklistItem is a single onboarding recommendation and its completion state
+type ChecklistItem struct {
+	ID        string `json:"id"`
+	Done      bool   `json:"done"`
+	Dismissed bool   `json:"dismissed"`
+	Pointer   string `json:"pointer"`
+}
+
+// Checklist is the onboarding checklist shown to administrators after setup
+type Checklist struct {
+	Items       []ChecklistItem `json:"items"`
+	Outstanding int             `json:"outstanding"`
+}
+
+// ChecklistContext carries request-time signals the service cannot observe itself
+type ChecklistContext struct {
+	// SecureTransport is whether the request arrived over TLS, directly or as reported by a
+	// trusted proxy
+	SecureTransport bool
+}
+
+type checklistDefinition struct {
+	id      string
+	pointer string
+	detect  func(s *ChecklistService, ctx ChecklistContext) (bool, error)
+}
+
+var checklistDefinitions = []checklistDefinition{
+	{
+		id:      ChecklistItemNetworkCreated,
+		pointer: "POST /api/networks",
+		detect:  (*ChecklistService).hasNetworks,
+	},
+	{
+		id:      ChecklistItemSecureTransport,
+		pointer: "Configure server.tls, or serve Tairitsu behind a reverse proxy listed in server.trusted_proxies that sets X-Forwarded-Proto",
+		detect:  (*ChecklistService).usesSecureTransport,
+	},
+	{
+		id:      ChecklistItemBackupsConfigured,
+		pointer: "Set backup.interval_hours to run scheduled system backups",
+		detect:  (*ChecklistService).hasScheduledBackups,
+	},
+	{
+		id:      ChecklistItemMultipleAdmins,
+		pointer: "POST /api/users",
+		detect:  (*ChecklistService).hasMultipleAdmins,
+	},
+}
+
+// ChecklistService computes the onboarding checklist from the current deployment state
+type ChecklistService struct {
+	stateService   *StateService
+	userService    *UserService
+	networkService *NetworkService
+}
+
+// NewChecklistService creates a new checklist service instance
+func NewChecklistService(stateService *StateService, userService *UserService, networkService *NetworkService) *ChecklistService {
+	return &ChecklistService{
+		stateService:   stateService,
+		userService:    userService,
+		networkService: networkService,
+	}
+}
+
+// GetChecklist evaluates every checklist item against the current state
+func (s *ChecklistService) GetChecklist(ctx ChecklistContext) (*Checklist, error) {
+	cfg := s.stateService.Config()
+	checklist := &Checklist{Items: make([]ChecklistItem, 0, len(checklistDefinitions))}
+
+	for _, def := range checklistDefinitions {
+		item := ChecklistItem{
+			ID:        def.id,
+			Dismissed: config.ChecklistItemDismissed(cfg, def.id),
+			Pointer:   def.pointer,
+		}
+		if def.detect != nil {
+			done, err := def.detect(s, ctx)
+			if err != nil {
+				logger.Error("service: failed to evaluate checklist item", zap.String("item_id", def.id), zap.Error(err))
+				return nil, err
+			}
+			item.Done = done
+		}
+		if !item.Done && !item.Dismissed {
+			checklist.Outstanding++
+		}
+		checklist.Items = append(checklist.Items, item)
+	}
+
+	return checklist, nil
+}
+
+// SetItemDismissed persists whether a checklist item is dismissed
+func (s *ChecklistService) SetItemDismissed(itemID string, dismissed bool) error {
+	known := false
+	for _, def := range checklistDefinitions {
+		if def.id == itemID {
+			known = true
+			break
+		}
+	}
+	if !known {
+		return ErrChecklistItemNotFound
+	}
+
+	return config.SetChecklistItemDismissedOn(s.stateService.ensureConfig(), itemID, dismissed)
+}
+
+func (s *ChecklistService) hasNetworks(_ ChecklistContext) (bool, error) {
+	db := s.networkService.getDB()
+	if db == nil {
+		return false, errors.New("database is not initialized")
+	}
+
+	networks, err := db.GetAllNetworks()
+	if err != nil {
+		return false, err
+	}
+	return len(networks) > 0, nil
+}
+
+// usesSecureTransport reports HTTPS configured on the server, or a request that arrived over it
+func (s *ChecklistService) usesSecureTransport(ctx ChecklistContext) (bool, error) {
+	if ctx.SecureTransport {
+		return true, nil
+	}
+	settings, err := config.TLSFrom(s.stateService.Config())
+	return err == nil && settings != nil, nil
+}
+
+// hasScheduledBackups reports whether system backups run on a schedule
+func (s *ChecklistService) hasScheduledBackups(_ ChecklistContext) (bool, error) {
+	return config.BackupIntervalFrom(s.stateService.Config()) > 0, nil
+}
+
+func (s *ChecklistService) hasMultipleAdmins(_ ChecklistContext) (bool, error) {
+	users, err := s.userService.GetAllUsers()
+	if err != nil {
+		return false, err
+	}
+
+	admins := 0
+	for _, user := range users {
+		if user.Role == "admin" {
+			admins++
+		}
+	}
+	return admins > 1, nil
+}
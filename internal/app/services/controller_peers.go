@@ -0,0 +1,62 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/GT-610/tairitsu/internal/zerotier"
+)
+
+var (
+	// ErrPeersUnsupported is returned when the controller does not serve the node peer list
+	ErrPeersUnsupported = errors.New("the controller does not expose its peer list")
+	ErrInvalidPeerRole  = errors.New("role must be PLANET, MOON or LEAF")
+)
+
+// ControllerPeers lists the peers one controller currently sees
+type ControllerPeers struct {
+	Controller string          `json:"controller"`
+	Peers      []zerotier.Peer `json:"peers"`
+}
+
+// GetControllerPeers reads the peer list of the named controller, keeping only peers with the
+// given role when one is set. An empty controller name means the default controller.
+func (s *NetworkService) GetControllerPeers(controller string, role string) (*ControllerPeers, error) {
+	role = strings.ToUpper(strings.TrimSpace(role))
+	switch role {
+	case "", zerotier.PeerRolePlanet, zerotier.PeerRoleMoon, zerotier.PeerRoleLeaf:
+	default:
+		return nil, ErrInvalidPeerRole
+	}
+
+	name, err := s.controllers.Resolve(controller)
+	if err != nil {
+		return nil, err
+	}
+	client, err := s.controllers.Client(name)
+	if err != nil {
+		return nil, err
+	}
+
+	peers, err := client.GetPeersAt(zerotier.DefaultPeerPath)
+	if err != nil {
+		var statusErr *zerotier.StatusError
+		if errors.As(err, &statusErr) {
+			switch statusErr.StatusCode {
+			case http.StatusNotFound, http.StatusMethodNotAllowed, http.StatusNotImplemented:
+				return nil, fmt.Errorf("%w: %s", ErrPeersUnsupported, name)
+			}
+		}
+		return nil, fmt.Errorf("failed to get peers of controller %s: %w", name, err)
+	}
+
+	result := &ControllerPeers{Controller: name, Peers: make([]zerotier.Peer, 0, len(peers))}
+	for _, peer := range peers {
+		if role == "" || peer.Role == role {
+			result.Peers = append(result.Peers, peer)
+		}
+	}
+	return result, nil
+}
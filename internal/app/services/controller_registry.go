@@ -0,0 +1,161 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/GT-610/tairitsu/internal/app/config"
+	"github.com/GT-610/tairitsu/internal/app/logger"
+	"github.com/GT-610/tairitsu/internal/zerotier"
+	"go.uber.org/zap"
+)
+
+var (
+	ErrControllerNotFound    = errors.New("ZeroTier controller not found")
+	ErrControllerUnavailable = errors.New("ZeroTier controller is not connected")
+)
+
+// ControllerInfo describes a configured controller and its live status
+type ControllerInfo struct {
+	Name    string           `json:"name"`
+	URL     string           `json:"url,omitempty"`
+	Default bool             `json:"default"`
+	Status  *zerotier.Status `json:"status,omitempty"`
+	Error   string           `json:"error,omitempty"`
+}
+
+type registeredController struct {
+	client *zerotier.Client
+	// err explains why client is nil
+	err error
+}
+
+// ControllerRegistry holds a ZeroTier client per configured controller. The controller set
+// up by the wizard is registered as config.DefaultControllerName and always comes first.
+type ControllerRegistry struct {
+	mutex       sync.RWMutex
+	names       []string
+	controllers map[string]registeredController
+}
+
+// NewControllerRegistry creates a registry holding only the default controller
+func NewControllerRegistry(defaultClient *zerotier.Client) *ControllerRegistry {
+	registry := &ControllerRegistry{controllers: make(map[string]registeredController)}
+	registry.set(config.DefaultControllerName, defaultClient, nil)
+	return registry
+}
+
+func (r *ControllerRegistry) set(name string, client *zerotier.Client, err error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if _, exists := r.controllers[name]; !exists {
+		r.names = append(r.names, name)
+	}
+	if client == nil && err == nil {
+		err = ErrControllerUnavailable
+	}
+	r.controllers[name] = registeredController{client: client, err: err}
+}
+
+// LoadControllers connects the additional controllers of cfg. A controller that cannot be
+// connected stays listed with its error, so its networks report why they are unreachable.
+func (r *ControllerRegistry) LoadControllers(cfg *config.Config) {
+	if cfg == nil {
+		return
+	}
+	for _, controller := range cfg.ZeroTier.Controllers {
+		if controller.Name == "" || controller.Name == config.DefaultControllerName {
+			logger.Warn("ignoring ZeroTier controller without a usable name", zap.String("name", controller.Name), zap.String("url", controller.URL))
+			continue
+		}
+		if _, exists := r.lookup(controller.Name); exists {
+			logger.Warn("ignoring duplicate ZeroTier controller", zap.String("name", controller.Name))
+			continue
+		}
+		client, err := zerotier.NewClientForController(controller)
+		if err != nil {
+			logger.Warn("failed to connect ZeroTier controller", zap.String("name", controller.Name), zap.Error(err))
+		}
+		r.set(controller.Name, client, err)
+	}
+}
+
+// ReloadControllers replaces the additional controllers with those of cfg, keeping the default one
+func (r *ControllerRegistry) ReloadControllers(cfg *config.Config) {
+	r.mutex.Lock()
+	for _, name := range r.names {
+		if name != config.DefaultControllerName {
+			delete(r.controllers, name)
+		}
+	}
+	r.names = []string{config.DefaultControllerName}
+	r.mutex.Unlock()
+	r.LoadControllers(cfg)
+}
+
+func (r *ControllerRegistry) lookup(name string) (registeredController, bool) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	controller, exists := r.controllers[name]
+	return controller, exists
+}
+
+// Names lists the controllers, the default one first
+func (r *ControllerRegistry) Names() []string {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	return append([]string(nil), r.names...)
+}
+
+// Resolve returns the canonical controller name; an empty name means the default controller
+func (r *ControllerRegistry) Resolve(name string) (string, error) {
+	if name == "" {
+		return config.DefaultControllerName, nil
+	}
+	if _, exists := r.lookup(name); !exists {
+		return "", fmt.Errorf("%w: %s", ErrControllerNotFound, name)
+	}
+	return name, nil
+}
+
+// Client returns the client of the named controller; an empty name means the default controller
+func (r *ControllerRegistry) Client(name string) (*zerotier.Client, error) {
+	name, err := r.Resolve(name)
+	if err != nil {
+		return nil, err
+	}
+	controller, _ := r.lookup(name)
+	if controller.client == nil {
+		return nil, fmt.Errorf("%w: %s: %v", ErrControllerUnavailable, name, controller.err)
+	}
+	return controller.client, nil
+}
+
+// List reads the live status of every controller
+func (r *ControllerRegistry) List() []ControllerInfo {
+	names := r.Names()
+	infos := make([]ControllerInfo, len(names))
+	var wg sync.WaitGroup
+	for i, name := range names {
+		controller, _ := r.lookup(name)
+		infos[i] = ControllerInfo{Name: name, Default: name == config.DefaultControllerName}
+		if controller.client == nil {
+			infos[i].Error = controller.err.Error()
+			continue
+		}
+		infos[i].URL = controller.client.BaseURL
+		wg.Add(1)
+		go func(info *ControllerInfo, client *zerotier.Client) {
+			defer wg.Done()
+			status, err := client.GetStatus()
+			if err != nil {
+				info.Error = err.Error()
+				return
+			}
+			info.Status = status
+		}(&infos[i], controller.client)
+	}
+	wg.Wait()
+	return infos
+}
@@ -0,0 +1,162 @@
+package services
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/GT-610/tairitsu/internal/app/logger"
+	"github.com/GT-610/tairitsu/internal/zerotier"
+	"go.uber.org/zap"
+)
+
+// DefaultControllerStatusRefreshInterval is used until SetStatusRefreshInterval is called
+const DefaultControllerStatusRefreshInterval = 15 * time.Second
+
+// ControllerStatusSnapshot is the cached controller status. Status is nil until the
+// controller answered once; a snapshot is stale when the latest refresh failed or the
+// background refresh fell behind.
+type ControllerStatusSnapshot struct {
+	Status *zerotier.Status
+	// FetchedAt is when Status was read; zero when it never was
+	FetchedAt time.Time
+	Stale     bool
+	// Err is the error of the latest refresh, if it failed
+	Err error
+}
+
+// Fetched reports whether the controller answered at least once since the client was bound
+func (s ControllerStatusSnapshot) Fetched() bool {
+	return s.Status != nil
+}
+
+// controllerStatusCache keeps the latest controller status, so status pages do not wait for
+// a slow controller. It belongs to NetworkService and outlives route reloads with it.
+type controllerStatusCache struct {
+	mutex     sync.RWMutex
+	interval  time.Duration
+	status    *zerotier.Status
+	fetchedAt time.Time
+	checked   bool
+	lastErr   error
+	// generation changes whenever the client is replaced, so a refresh against the old
+	// client cannot overwrite the reset cache
+	generation int
+
+	// fetchMutex lets a single controller request run at a time
+	fetchMutex sync.Mutex
+}
+
+// reset forgets the cached status, e.g. after the ZeroTier client was replaced
+func (c *controllerStatusCache) reset() {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.status = nil
+	c.fetchedAt = time.Time{}
+	c.checked = false
+	c.lastErr = nil
+	c.generation++
+}
+
+func (c *controllerStatusCache) snapshot() (ControllerStatusSnapshot, bool) {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	snapshot := ControllerStatusSnapshot{
+		Status:    c.status,
+		FetchedAt: c.fetchedAt,
+		Err:       c.lastErr,
+	}
+	// Allow one missed refresh before calling the status stale
+	snapshot.Stale = c.lastErr != nil || (c.status != nil && time.Since(c.fetchedAt) > 2*c.interval)
+	return snapshot, c.checked
+}
+
+// SetStatusRefreshInterval sets how often the controller status is refreshed in the background
+func (s *NetworkService) SetStatusRefreshInterval(interval time.Duration) {
+	if interval <= 0 {
+		interval = DefaultControllerStatusRefreshInterval
+	}
+	s.statusCache.mutex.Lock()
+	defer s.statusCache.mutex.Unlock()
+	s.statusCache.interval = interval
+}
+
+// ControllerStatus returns the cached controller status. fresh forces a live request;
+// the first call after the client was bound also waits for one.
+func (s *NetworkService) ControllerStatus(fresh bool) ControllerStatusSnapshot {
+	if snapshot, checked := s.statusCache.snapshot(); checked && !fresh {
+		return snapshot
+	}
+	_ = s.RefreshControllerStatus()
+	snapshot, _ := s.statusCache.snapshot()
+	return snapshot
+}
+
+// RefreshControllerStatus reads the controller status and updates the cache. A failed
+// refresh keeps the previous status, marked stale.
+func (s *NetworkService) RefreshControllerStatus() error {
+	cache := s.statusCache
+	cache.fetchMutex.Lock()
+	defer cache.fetchMutex.Unlock()
+
+	cache.mutex.RLock()
+	generation := cache.generation
+	cache.mutex.RUnlock()
+
+	status, err := s.GetStatus()
+
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+	if cache.generation != generation {
+		return err
+	}
+	cache.checked = true
+	cache.lastErr = err
+	if err == nil {
+		cache.status = status
+		cache.fetchedAt = time.Now()
+	}
+	return err
+}
+
+// StartStatusRefresh refreshes the controller status until ctx is cancelled. Failed
+// refreshes back off exponentially up to ten minutes, like the member status collector.
+func (s *NetworkService) StartStatusRefresh(ctx context.Context) <-chan struct{} {
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		// Fill the cache right away, so the first status page does not wait for the controller
+		timer := time.NewTimer(0)
+		defer timer.Stop()
+		var delay time.Duration
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-timer.C:
+				s.statusCache.mutex.RLock()
+				interval := s.statusCache.interval
+				s.statusCache.mutex.RUnlock()
+
+				if s.getZTClient() == nil {
+					delay = interval
+				} else if err := s.RefreshControllerStatus(); err != nil {
+					delay = min(max(delay, interval)*2, max(maxMemberStatusBackoff, interval))
+					logger.Warn("controller status refresh failed; backing off", zap.Duration("retry_in", delay), zap.Error(err))
+				} else {
+					delay = interval
+				}
+				timer.Reset(delay)
+			}
+		}
+	}()
+	return done
+}
+
+// controllerStatusError describes a snapshot without a status for API responses
+func controllerStatusError(snapshot ControllerStatusSnapshot) string {
+	if snapshot.Err != nil {
+		return snapshot.Err.Error()
+	}
+	return "controller status has not been fetched yet"
+}
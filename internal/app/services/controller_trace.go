@@ -0,0 +1,337 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/GT-610/tairitsu/internal/app/database"
+	"github.com/GT-610/tairitsu/internal/app/logger"
+	"github.com/GT-610/tairitsu/internal/app/models"
+	"go.uber.org/zap"
+)
+
+const (
+	// ControllerTraceMaxLines bounds the lines accepted in one ingestion request
+	ControllerTraceMaxLines = 1000
+	// controllerTraceMaxLineLength truncates stored lines; longer lines are never parsed
+	controllerTraceMaxLineLength = 4096
+
+	defaultControllerTraceRetentionDays = 7
+	defaultControllerTraceLimit         = 100
+	maxControllerTraceLimit             = 500
+	controllerTracePruneInterval        = time.Hour
+)
+
+// Normalized rule outcomes of parsed trace lines; other controller actions keep their own name
+const (
+	TraceOutcomeAccept = "accept"
+	TraceOutcomeDrop   = "drop"
+)
+
+// Trace line formats understood by ParseControllerTraceLine
+const (
+	TraceFormatJSON     = "json"
+	TraceFormatKeyValue = "kv"
+)
+
+// ErrTraceBatchTooLarge is returned when an ingestion request carries too many lines
+var ErrTraceBatchTooLarge = fmt.Errorf("at most %d trace lines can be ingested per request", ControllerTraceMaxLines)
+
+var (
+	traceKeyValuePattern  = regexp.MustCompile(`([A-Za-z_][A-Za-z0-9_]*)=("(?:[^"\\]|\\.)*"|\S+)`)
+	traceNetworkIDPattern = regexp.MustCompile(`^[0-9a-f]{16}$`)
+	traceMemberIDPattern  = regexp.MustCompile(`^[0-9a-f]{10}$`)
+)
+
+// Accepted field names per trace attribute, compared case-insensitively
+var (
+	traceNetworkKeys = []string{"networkid", "nwid", "network_id", "network"}
+	traceMemberKeys  = []string{"memberid", "member_id", "member", "address", "remoteztaddr", "ztsource"}
+	traceOutcomeKeys = []string{"outcome", "result", "ruleresult", "action", "accept", "accepted"}
+	traceRuleKeys    = []string{"rule", "ruleid", "matchingrule"}
+	traceEventKeys   = []string{"event", "type"}
+	traceTimeKeys    = []string{"time", "timestamp", "ts"}
+)
+
+// ControllerTraceIngestResult summarizes one ingestion request
+type ControllerTraceIngestResult struct {
+	Accepted      int `json:"accepted"`
+	Parsed        int `json:"parsed"`
+	ParseFailures int `json:"parseFailures"`
+}
+
+// ControllerTraceStats counts the trace lines ingested since startup
+type ControllerTraceStats struct {
+	Received      int64 `json:"received"`
+	Parsed        int64 `json:"parsed"`
+	ParseFailures int64 `json:"parseFailures"`
+}
+
+// ControllerTraceListQuery filters a listing of stored trace lines
+type ControllerTraceListQuery struct {
+	NetworkID string
+	MemberID  string
+	Parsed    *bool
+	BeforeID  uint64
+	Limit     int // Zero defaults to 100; capped at 500
+}
+
+// ControllerTraceService stores controller trace lines forwarded by a sidecar or syslog
+// forwarder, parsed into member, network and rule outcome where the format is known.
+type ControllerTraceService struct {
+	db            database.DBInterface
+	mutex         sync.RWMutex
+	retentionDays int
+
+	received      atomic.Int64
+	parsed        atomic.Int64
+	parseFailures atomic.Int64
+}
+
+// NewControllerTraceService creates a new controller trace service instance
+func NewControllerTraceService(db database.DBInterface) *ControllerTraceService {
+	return &ControllerTraceService{db: db, retentionDays: defaultControllerTraceRetentionDays}
+}
+
+func (s *ControllerTraceService) SetDB(db database.DBInterface) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.db = db
+}
+
+func (s *ControllerTraceService) getDB() database.DBInterface {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return s.db
+}
+
+// SetRetentionDays configures how long trace lines are kept; zero keeps the default of seven days
+func (s *ControllerTraceService) SetRetentionDays(days int) {
+	if days <= 0 {
+		days = defaultControllerTraceRetentionDays
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.retentionDays = days
+}
+
+func (s *ControllerTraceService) getRetentionDays() int {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return s.retentionDays
+}
+
+// Ingest parses and stores a batch of trace lines. Blank lines are ignored; lines in an
+// unknown format are stored raw and counted as parse failures.
+func (s *ControllerTraceService) Ingest(lines []string) (*ControllerTraceIngestResult, error) {
+	if len(lines) > ControllerTraceMaxLines {
+		return nil, ErrTraceBatchTooLarge
+	}
+	db := s.getDB()
+	if db == nil {
+		return nil, ErrUserDBUnavailable
+	}
+
+	now := time.Now()
+	result := &ControllerTraceIngestResult{}
+	events := make([]*models.ControllerTraceEvent, 0, len(lines))
+	for _, line := range lines {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		event := ParseControllerTraceLine(line)
+		event.ReceivedAt = now
+		events = append(events, event)
+		if event.Parsed {
+			result.Parsed++
+		} else {
+			result.ParseFailures++
+		}
+	}
+	if err := db.CreateControllerTraceEvents(events); err != nil {
+		return nil, fmt.Errorf("failed to store controller trace lines: %w", err)
+	}
+
+	result.Accepted = len(events)
+	s.received.Add(int64(result.Accepted))
+	s.parsed.Add(int64(result.Parsed))
+	s.parseFailures.Add(int64(result.ParseFailures))
+	return result, nil
+}
+
+// Stats returns the ingestion counters since startup
+func (s *ControllerTraceService) Stats() ControllerTraceStats {
+	return ControllerTraceStats{
+		Received:      s.received.Load(),
+		Parsed:        s.parsed.Load(),
+		ParseFailures: s.parseFailures.Load(),
+	}
+}
+
+// List returns stored trace lines, newest first
+func (s *ControllerTraceService) List(query ControllerTraceListQuery) ([]*models.ControllerTraceEvent, error) {
+	db := s.getDB()
+	if db == nil {
+		return nil, ErrUserDBUnavailable
+	}
+	limit := query.Limit
+	if limit <= 0 {
+		limit = defaultControllerTraceLimit
+	}
+	return db.QueryControllerTraceEvents(models.ControllerTraceQuery{
+		NetworkID: query.NetworkID,
+		MemberID:  query.MemberID,
+		Parsed:    query.Parsed,
+		BeforeID:  query.BeforeID,
+		Limit:     min(limit, maxControllerTraceLimit),
+	})
+}
+
+// Prune removes trace lines received before the given time
+func (s *ControllerTraceService) Prune(before time.Time) (int64, error) {
+	db := s.getDB()
+	if db == nil {
+		return 0, nil
+	}
+	return db.DeleteControllerTraceEventsBefore(before)
+}
+
+// StartMaintenance periodically prunes trace lines older than the retention period
+func (s *ControllerTraceService) StartMaintenance(ctx context.Context) <-chan struct{} {
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(controllerTracePruneInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if _, err := s.Prune(time.Now().AddDate(0, 0, -s.getRetentionDays())); err != nil {
+					logger.Warn("controller trace pruning failed", zap.Error(err))
+				}
+			}
+		}
+	}()
+	return done
+}
+
+// ParseControllerTraceLine parses one trace line. JSON objects and key=value lines (optionally
+// after a syslog prefix) are understood; a line is parsed when it names a valid network ID.
+// The returned event always carries the raw line, truncated to 4096 bytes.
+func ParseControllerTraceLine(line string) *models.ControllerTraceEvent {
+	event := &models.ControllerTraceEvent{Raw: line}
+	if len(line) > controllerTraceMaxLineLength {
+		event.Raw = line[:controllerTraceMaxLineLength]
+		return event
+	}
+
+	fields, format := traceFields(line)
+	if fields == nil {
+		return event
+	}
+	networkID := strings.ToLower(traceField(fields, traceNetworkKeys))
+	if !traceNetworkIDPattern.MatchString(networkID) {
+		return event
+	}
+
+	event.Parsed = true
+	event.Format = format
+	event.NetworkID = networkID
+	if memberID := strings.ToLower(traceField(fields, traceMemberKeys)); traceMemberIDPattern.MatchString(memberID) {
+		event.MemberID = memberID
+	}
+	event.Outcome = normalizeTraceOutcome(traceField(fields, traceOutcomeKeys))
+	event.Rule = traceField(fields, traceRuleKeys)
+	event.EventType = traceField(fields, traceEventKeys)
+	event.OccurredAt = parseTraceTime(traceField(fields, traceTimeKeys))
+	return event
+}
+
+// traceFields extracts the lower-cased field map of a JSON or key=value line
+func traceFields(line string) (map[string]string, string) {
+	if strings.HasPrefix(line, "{") {
+		var object map[string]any
+		if err := json.Unmarshal([]byte(line), &object); err != nil {
+			return nil, ""
+		}
+		fields := make(map[string]string, len(object))
+		for key, value := range object {
+			switch typed := value.(type) {
+			case string:
+				fields[strings.ToLower(key)] = typed
+			case bool:
+				fields[strings.ToLower(key)] = strconv.FormatBool(typed)
+			case float64:
+				fields[strings.ToLower(key)] = strconv.FormatFloat(typed, 'f', -1, 64)
+			}
+		}
+		return fields, TraceFormatJSON
+	}
+
+	matches := traceKeyValuePattern.FindAllStringSubmatch(line, -1)
+	if len(matches) == 0 {
+		return nil, ""
+	}
+	fields := make(map[string]string, len(matches))
+	for _, match := range matches {
+		value := match[2]
+		if unquoted, err := strconv.Unquote(value); err == nil {
+			value = unquoted
+		}
+		fields[strings.ToLower(match[1])] = value
+	}
+	return fields, TraceFormatKeyValue
+}
+
+func traceField(fields map[string]string, keys []string) string {
+	for _, key := range keys {
+		if value := strings.TrimSpace(fields[key]); value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+func normalizeTraceOutcome(value string) string {
+	switch strings.ToLower(value) {
+	case "":
+		return ""
+	case "accept", "accepted", "allow", "allowed", "true", "1":
+		return TraceOutcomeAccept
+	case "drop", "dropped", "deny", "denied", "reject", "rejected", "false", "0":
+		return TraceOutcomeDrop
+	default:
+		return strings.ToLower(value)
+	}
+}
+
+// parseTraceTime reads RFC 3339 timestamps and Unix times in seconds or milliseconds
+func parseTraceTime(value string) *time.Time {
+	if value == "" {
+		return nil
+	}
+	if parsed, err := time.Parse(time.RFC3339Nano, value); err == nil {
+		return &parsed
+	}
+	number, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || number <= 0 {
+		return nil
+	}
+	var parsed time.Time
+	if number > 1e12 {
+		parsed = time.UnixMilli(number)
+	} else {
+		parsed = time.Unix(number, 0)
+	}
+	return &parsed
+}
@@ -0,0 +1,287 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/GT-610/tairitsu/internal/app/logger"
+	"github.com/GT-610/tairitsu/internal/app/models"
+	"github.com/GT-610/tairitsu/internal/zerotier"
+	"go.uber.org/zap"
+)
+
+const (
+	// dashboardTimeout bounds the whole summary; sections still loading are left out
+	dashboardTimeout         = 5 * time.Second
+	dashboardRecentAuditSize = 10
+)
+
+// DashboardNetworks counts the networks a user can access and their members. A member is
+// online when its controller currently has it as a peer.
+type DashboardNetworks struct {
+	Count               int `json:"count"`
+	Members             int `json:"members"`
+	OnlineMembers       int `json:"onlineMembers"`
+	UnauthorizedMembers int `json:"unauthorizedMembers"`
+	// UnreachableNetworks were left out of the member counts because their controller failed
+	UnreachableNetworks int `json:"unreachableNetworks"`
+}
+
+// DashboardUsers counts local accounts; only administrators see it
+type DashboardUsers struct {
+	Count int `json:"count"`
+}
+
+// DashboardController is the cached status of the default controller
+type DashboardController struct {
+	Online    bool       `json:"online"`
+	Version   string     `json:"version,omitempty"`
+	Address   string     `json:"address,omitempty"`
+	Stale     bool       `json:"stale"`
+	FetchedAt *time.Time `json:"fetchedAt,omitempty"`
+	Error     string     `json:"error,omitempty"`
+}
+
+// DashboardChecklist counts the onboarding checklist items still to do; only administrators
+// see it
+type DashboardChecklist struct {
+	Outstanding int `json:"outstanding"`
+}
+
+// DashboardSummary aggregates the overview page. A section is null when it failed or did
+// not finish in time, and its name is listed in Unavailable.
+type DashboardSummary struct {
+	Networks    *DashboardNetworks   `json:"networks"`
+	Users       *DashboardUsers      `json:"users"`
+	Controller  *DashboardController `json:"controller"`
+	Checklist   *DashboardChecklist  `json:"checklist"`
+	RecentAudit []*models.AuditLog   `json:"recentAudit"`
+	Unavailable []string             `json:"unavailable"`
+}
+
+// DashboardService builds the overview page from the network, user, audit and checklist
+// services
+type DashboardService struct {
+	networkService   *NetworkService
+	userService      *UserService
+	auditService     *AuditService
+	checklistService *ChecklistService
+	timeout          time.Duration
+}
+
+// NewDashboardService creates a new dashboard service instance
+func NewDashboardService(networkService *NetworkService, userService *UserService, auditService *AuditService, checklistService *ChecklistService) *DashboardService {
+	return &DashboardService{
+		networkService:   networkService,
+		userService:      userService,
+		auditService:     auditService,
+		checklistService: checklistService,
+		timeout:          dashboardTimeout,
+	}
+}
+
+type dashboardSection struct {
+	name  string
+	value any
+	err   error
+}
+
+// Summary loads the dashboard sections concurrently. Administrators see every network, the
+// user count, the outstanding checklist items and the latest audit entries; other users see
+// the networks they own or view and their own audit entries. checklist carries the request
+// details the checklist is evaluated against.
+func (s *DashboardService) Summary(ctx context.Context, userID string, isAdmin bool, checklist ChecklistContext) *DashboardSummary {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	// Buffered so sections finishing after the deadline do not block
+	results := make(chan dashboardSection, 5)
+	pending := map[string]bool{}
+	run := func(name string, load func() (any, error)) {
+		pending[name] = true
+		go func() {
+			value, err := load()
+			results <- dashboardSection{name: name, value: value, err: err}
+		}()
+	}
+
+	run("networks", func() (any, error) { return s.networkCounts(userID, isAdmin) })
+	run("controller", func() (any, error) { return s.controllerStatus(), nil })
+	run("recentAudit", func() (any, error) { return s.recentAudit(userID, isAdmin) })
+	if isAdmin {
+		run("users", func() (any, error) {
+			users, err := s.userService.GetAllUsers()
+			if err != nil {
+				return nil, err
+			}
+			return &DashboardUsers{Count: len(users)}, nil
+		})
+		run("checklist", func() (any, error) {
+			items, err := s.checklistService.GetChecklist(checklist)
+			if err != nil {
+				return nil, err
+			}
+			return &DashboardChecklist{Outstanding: items.Outstanding}, nil
+		})
+	}
+
+	summary := &DashboardSummary{}
+	for len(pending) > 0 {
+		select {
+		case <-ctx.Done():
+			logger.Warn("service: dashboard sections did not finish in time", zap.Int("pending", len(pending)))
+			for name := range pending {
+				summary.Unavailable = append(summary.Unavailable, name)
+			}
+			pending = nil
+		case result := <-results:
+			delete(pending, result.name)
+			if result.err != nil {
+				logger.Warn("service: dashboard section failed", zap.String("section", result.name), zap.Error(result.err))
+				summary.Unavailable = append(summary.Unavailable, result.name)
+				continue
+			}
+			switch value := result.value.(type) {
+			case *DashboardNetworks:
+				summary.Networks = value
+			case *DashboardUsers:
+				summary.Users = value
+			case *DashboardController:
+				summary.Controller = value
+			case *DashboardChecklist:
+				summary.Checklist = value
+			case []*models.AuditLog:
+				summary.RecentAudit = value
+			}
+		}
+	}
+	if summary.Unavailable == nil {
+		summary.Unavailable = []string{}
+	}
+	sort.Strings(summary.Unavailable)
+	return summary
+}
+
+func (s *DashboardService) accessibleNetworks(userID string, isAdmin bool) ([]*models.Network, error) {
+	db := s.networkService.getDB()
+	if db == nil {
+		return nil, errors.New("database is not initialized")
+	}
+	if isAdmin {
+		return db.GetAllNetworks()
+	}
+	owned, err := db.GetNetworksByOwnerID(userID)
+	if err != nil {
+		return nil, err
+	}
+	shared, err := db.GetSharedNetworksByUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+	return append(owned, shared...), nil
+}
+
+// networkCounts reads the members of every accessible network, and the peers of each
+// controller once, with the same bounded concurrency as the network list
+func (s *DashboardService) networkCounts(userID string, isAdmin bool) (*DashboardNetworks, error) {
+	networks, err := s.accessibleNetworks(userID, isAdmin)
+	if err != nil {
+		return nil, err
+	}
+	counts := &DashboardNetworks{Count: len(networks)}
+	if len(networks) == 0 {
+		return counts, nil
+	}
+
+	var mutex sync.Mutex
+	peersByClient := map[*zerotier.Client]map[string]bool{}
+	onlinePeers := func(client *zerotier.Client) map[string]bool {
+		mutex.Lock()
+		defer mutex.Unlock()
+		if online, ok := peersByClient[client]; ok {
+			return online
+		}
+		online := map[string]bool{}
+		peers, err := client.GetPeers()
+		if err != nil {
+			logger.Warn("service: failed to get peers for the dashboard", zap.Error(err))
+		}
+		for _, peer := range peers {
+			online[peer.Address] = true
+		}
+		peersByClient[client] = online
+		return online
+	}
+
+	var wg sync.WaitGroup
+	limiter := make(chan struct{}, min(networkMemberStatsConcurrency, len(networks)))
+	for _, network := range networks {
+		client, err := s.networkService.clientFor(network)
+		if err != nil {
+			counts.UnreachableNetworks++
+			continue
+		}
+
+		wg.Add(1)
+		go func(networkID string, client *zerotier.Client) {
+			defer wg.Done()
+			limiter <- struct{}{}
+			defer func() { <-limiter }()
+
+			members, err := client.GetMembers(networkID)
+			if err != nil {
+				logger.Warn("service: failed to get members for the dashboard", zap.String("network_id", networkID), zap.Error(err))
+				mutex.Lock()
+				counts.UnreachableNetworks++
+				mutex.Unlock()
+				return
+			}
+			online := onlinePeers(client)
+
+			mutex.Lock()
+			defer mutex.Unlock()
+			for _, member := range members {
+				counts.Members++
+				if !member.Config.Authorized {
+					counts.UnauthorizedMembers++
+				}
+				if online[member.Address] {
+					counts.OnlineMembers++
+				}
+			}
+		}(network.ID, client)
+	}
+	wg.Wait()
+	return counts, nil
+}
+
+func (s *DashboardService) controllerStatus() *DashboardController {
+	snapshot := s.networkService.ControllerStatus(false)
+	status := &DashboardController{Stale: snapshot.Stale}
+	if snapshot.Status != nil {
+		status.Online = snapshot.Status.Online
+		status.Version = snapshot.Status.Version
+		status.Address = snapshot.Status.Address
+		fetchedAt := snapshot.FetchedAt
+		status.FetchedAt = &fetchedAt
+	}
+	if snapshot.Err != nil {
+		status.Error = snapshot.Err.Error()
+	}
+	return status
+}
+
+func (s *DashboardService) recentAudit(userID string, isAdmin bool) ([]*models.AuditLog, error) {
+	query := AuditListQuery{}
+	if !isAdmin {
+		query.ActorID = userID
+	}
+	page, err := s.auditService.ListEntries(query, PageRequest{Limit: dashboardRecentAuditSize})
+	if err != nil {
+		return nil, err
+	}
+	return page.Items, nil
+}
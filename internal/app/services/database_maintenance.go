@@ -0,0 +1,291 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/GT-610/tairitsu/internal/app/database"
+	"github.com/GT-610/tairitsu/internal/app/logger"
+	"go.uber.org/zap"
+)
+
+// Triggers recorded on compaction runs
+const (
+	CompactionTriggerSchedule = "schedule"
+	CompactionTriggerManual   = "manual"
+)
+
+const (
+	// AuditActionDatabaseCompact is the audit action recorded for every compaction that ran
+	AuditActionDatabaseCompact = "database.compact"
+	// auditSystemActor attributes entries to background jobs rather than a user
+	auditSystemActor = "system"
+	// compactionSpaceFactor leaves room for the temporary copy VACUUM writes and its journal
+	compactionSpaceFactor = 2
+)
+
+var (
+	ErrCompactionRunning     = errors.New("database compaction is already running")
+	ErrInsufficientDiskSpace = errors.New("not enough free disk space to compact the database")
+)
+
+// CompactionRun describes one compaction attempt
+type CompactionRun struct {
+	Trigger         string    `json:"trigger"`
+	StartedAt       time.Time `json:"startedAt"`
+	FinishedAt      time.Time `json:"finishedAt"`
+	Compacted       bool      `json:"compacted"`
+	SkippedReason   string    `json:"skippedReason,omitempty"`
+	Error           string    `json:"error,omitempty"`
+	SizeBefore      int64     `json:"sizeBefore"`
+	SizeAfter       int64     `json:"sizeAfter"`
+	FreeRatioBefore float64   `json:"freeRatioBefore"`
+}
+
+// CompactionStatus is the database compaction job view
+type CompactionStatus struct {
+	Supported            bool           `json:"supported"`
+	Running              bool           `json:"running"`
+	IntervalHours        int            `json:"intervalHours"`
+	FreePercentThreshold int            `json:"freePercentThreshold"`
+	NextRunAt            *time.Time     `json:"nextRunAt,omitempty"`
+	LastRun              *CompactionRun `json:"lastRun,omitempty"`
+}
+
+// DatabaseMaintenanceService compacts the SQLite database file once pruned audit, history and
+// trace rows leave enough free pages behind. Writes are paused through the maintenance mode
+// while the file is rebuilt.
+type DatabaseMaintenanceService struct {
+	db          database.DBInterface
+	maintenance *MaintenanceMode
+	audit       *AuditService
+	interval    time.Duration
+	threshold   float64
+	freeSpace   func(dir string) (int64, error)
+
+	mutex     sync.RWMutex
+	running   bool
+	nextRunAt time.Time
+	lastRun   *CompactionRun
+}
+
+// NewDatabaseMaintenanceService creates the compaction job. Scheduled runs happen every
+// interval and only compact when the share of free pages reaches threshold.
+func NewDatabaseMaintenanceService(db database.DBInterface, maintenance *MaintenanceMode, audit *AuditService, interval time.Duration, threshold float64) *DatabaseMaintenanceService {
+	return &DatabaseMaintenanceService{
+		db:          db,
+		maintenance: maintenance,
+		audit:       audit,
+		interval:    interval,
+		threshold:   threshold,
+		freeSpace:   database.FreeDiskSpace,
+	}
+}
+
+func (s *DatabaseMaintenanceService) SetDB(db database.DBInterface) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.db = db
+}
+
+func (s *DatabaseMaintenanceService) getDB() database.DBInterface {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return s.db
+}
+
+// SetFreeDiskSpaceFunc replaces the free disk space probe, for tests
+func (s *DatabaseMaintenanceService) SetFreeDiskSpaceFunc(fn func(dir string) (int64, error)) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.freeSpace = fn
+}
+
+// Status returns the compaction job view
+func (s *DatabaseMaintenanceService) Status() CompactionStatus {
+	_, supported := database.AsCompactor(s.getDB())
+
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	status := CompactionStatus{
+		Supported:            supported,
+		Running:              s.running,
+		IntervalHours:        int(s.interval / time.Hour),
+		FreePercentThreshold: int(math.Round(s.threshold * 100)),
+	}
+	if !s.nextRunAt.IsZero() {
+		next := s.nextRunAt
+		status.NextRunAt = &next
+	}
+	if s.lastRun != nil {
+		last := *s.lastRun
+		status.LastRun = &last
+	}
+	return status
+}
+
+// Compact runs a compaction now and returns its outcome. Unless force is set, databases whose
+// free pages stay below the threshold are left alone.
+func (s *DatabaseMaintenanceService) Compact(trigger string, actorID string, force bool) (*CompactionRun, error) {
+	compactor, err := s.reserve()
+	if err != nil {
+		return nil, err
+	}
+	return s.run(compactor, trigger, actorID, force)
+}
+
+// TriggerCompaction starts a compaction in the background; its outcome appears in Status
+func (s *DatabaseMaintenanceService) TriggerCompaction(actorID string, force bool) error {
+	compactor, err := s.reserve()
+	if err != nil {
+		return err
+	}
+	go func() {
+		if _, err := s.run(compactor, CompactionTriggerManual, actorID, force); err != nil {
+			logger.Warn("database compaction failed", zap.Error(err))
+		}
+	}()
+	return nil
+}
+
+// reserve marks a compaction as running, so only one can run at a time
+func (s *DatabaseMaintenanceService) reserve() (database.Compactor, error) {
+	db := s.getDB()
+	if db == nil {
+		return nil, ErrUserDBUnavailable
+	}
+	compactor, ok := database.AsCompactor(db)
+	if !ok {
+		return nil, database.ErrCompactionUnsupported
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.running {
+		return nil, ErrCompactionRunning
+	}
+	s.running = true
+	return compactor, nil
+}
+
+func (s *DatabaseMaintenanceService) run(compactor database.Compactor, trigger string, actorID string, force bool) (*CompactionRun, error) {
+	run := &CompactionRun{Trigger: trigger, StartedAt: time.Now()}
+	defer func() {
+		run.FinishedAt = time.Now()
+		s.mutex.Lock()
+		s.running = false
+		s.lastRun = run
+		s.mutex.Unlock()
+	}()
+
+	fail := func(err error) (*CompactionRun, error) {
+		run.Error = err.Error()
+		return run, err
+	}
+
+	before, err := compactor.SQLiteFileStats()
+	if err != nil {
+		return fail(err)
+	}
+	run.SizeBefore = before.SizeBytes
+	run.SizeAfter = before.SizeBytes
+	run.FreeRatioBefore = before.FreeRatio()
+	if !force && run.FreeRatioBefore < s.threshold {
+		run.SkippedReason = fmt.Sprintf("free pages below %d%%", int(math.Round(s.threshold*100)))
+		return run, nil
+	}
+
+	if err := s.checkDiskSpace(before); err != nil {
+		return fail(err)
+	}
+
+	resume := s.maintenance.Enter("database compaction")
+	err = compactor.Compact()
+	resume()
+	if err != nil {
+		return fail(err)
+	}
+
+	after, err := compactor.SQLiteFileStats()
+	if err != nil {
+		return fail(err)
+	}
+	run.Compacted = true
+	run.SizeAfter = after.SizeBytes
+	logger.Info("database compacted",
+		zap.String("trigger", trigger),
+		zap.Int64("size_before", run.SizeBefore),
+		zap.Int64("size_after", run.SizeAfter))
+
+	if s.audit != nil {
+		if actorID == "" {
+			actorID = auditSystemActor
+		}
+		if _, err := s.audit.Record(AuditEntryInput{
+			ActorID: actorID,
+			Action:  AuditActionDatabaseCompact,
+			Target:  "database",
+			Details: fmt.Sprintf("trigger=%s size_before=%d size_after=%d", trigger, run.SizeBefore, run.SizeAfter),
+		}); err != nil {
+			logger.Warn("failed to record compaction audit entry", zap.Error(err))
+		}
+	}
+	return run, nil
+}
+
+// checkDiskSpace refuses to compact when the filesystem cannot hold a copy of the live data
+func (s *DatabaseMaintenanceService) checkDiskSpace(stats *database.SQLiteFileStats) error {
+	s.mutex.RLock()
+	freeSpace := s.freeSpace
+	s.mutex.RUnlock()
+
+	available, err := freeSpace(filepath.Dir(stats.Path))
+	if err != nil {
+		if !errors.Is(err, errors.ErrUnsupported) {
+			logger.Warn("could not check free disk space before compaction", zap.Error(err))
+		}
+		return nil
+	}
+	needed := (stats.PageCount - stats.FreePages) * stats.PageSize * compactionSpaceFactor
+	if available < needed {
+		return fmt.Errorf("%w: %d bytes needed, %d available", ErrInsufficientDiskSpace, needed, available)
+	}
+	return nil
+}
+
+// Start runs scheduled compaction checks until ctx is cancelled
+func (s *DatabaseMaintenanceService) Start(ctx context.Context) <-chan struct{} {
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(s.interval)
+		defer ticker.Stop()
+		s.setNextRun(time.Now().Add(s.interval))
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				s.setNextRun(time.Now().Add(s.interval))
+				if _, ok := database.AsCompactor(s.getDB()); !ok {
+					continue
+				}
+				if _, err := s.Compact(CompactionTriggerSchedule, "", false); err != nil {
+					logger.Warn("scheduled database compaction failed", zap.Error(err))
+				}
+			}
+		}
+	}()
+	return done
+}
+
+func (s *DatabaseMaintenanceService) setNextRun(at time.Time) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.nextRunAt = at
+}
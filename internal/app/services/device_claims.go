@@ -0,0 +1,430 @@
+package services
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/GT-610/tairitsu/internal/app/database"
+	"github.com/GT-610/tairitsu/internal/app/logger"
+	"github.com/GT-610/tairitsu/internal/app/models"
+	"github.com/GT-610/tairitsu/internal/zerotier"
+	"go.uber.org/zap"
+)
+
+// DeviceClaimCodePrefix starts every claim code. Setting a member's name to the code on a
+// network the claimant does not control proves the claim without an administrator.
+const DeviceClaimCodePrefix = "tairitsu-claim-"
+
+// deviceClaimVerifiedByCode is recorded as the verifier of claims proven by their claim code
+const deviceClaimVerifiedByCode = "claim-code"
+
+var (
+	ErrDeviceClaimNotFound     = errors.New("device claim not found")
+	ErrDeviceClaimedByOther    = errors.New("the device is already claimed by another user")
+	ErrDeviceClaimNotVerified  = errors.New("the device claim is not verified yet")
+	ErrDeviceAlreadyAuthorized = errors.New("the device is already authorized on this network")
+)
+
+// Device is a claimed node with the networks it is a member of. Networks stay empty until
+// the claim is verified.
+type Device struct {
+	models.DeviceClaim
+	Networks []DeviceMembership `json:"networks"`
+}
+
+// DeviceMembership is the state of a claimed node on one network
+type DeviceMembership struct {
+	NetworkID     string   `json:"networkId"`
+	NetworkName   string   `json:"networkName"`
+	Authorized    bool     `json:"authorized"`
+	Name          string   `json:"name"`
+	DisplayName   string   `json:"displayName,omitempty"`
+	IPAssignments []string `json:"ipAssignments"`
+}
+
+// ClaimDevice records that userID claims the node and tries to verify the claim right away.
+// Claiming a node twice returns the existing claim.
+func (s *NetworkService) ClaimDevice(userID, nodeAddress string) (*Device, error) {
+	db := s.getDB()
+	if db == nil {
+		return nil, fmt.Errorf("database is not initialized")
+	}
+
+	claims, err := db.ListDeviceClaimsByNode(nodeAddress)
+	if err != nil {
+		return nil, err
+	}
+	var claim *models.DeviceClaim
+	for _, existing := range claims {
+		if existing.UserID == userID {
+			claim = existing
+		} else if existing.Verified {
+			return nil, ErrDeviceClaimedByOther
+		}
+	}
+
+	if claim == nil {
+		code, err := newDeviceClaimCode()
+		if err != nil {
+			return nil, err
+		}
+		claim = &models.DeviceClaim{UserID: userID, NodeAddress: nodeAddress, ClaimCode: code, CreatedAt: time.Now()}
+		if err := db.CreateDeviceClaim(claim); err != nil {
+			return nil, fmt.Errorf("failed to save device claim: %w", err)
+		}
+		logger.Info("service: device claimed", zap.String("user_id", userID), zap.String("node_address", nodeAddress))
+	}
+
+	devices, err := s.assembleDevices(db, []*models.DeviceClaim{claim})
+	if err != nil {
+		return nil, err
+	}
+	return devices[0], nil
+}
+
+// ListDevices returns the devices userID claimed. Claims whose code now appears as a member
+// name are verified on the way.
+func (s *NetworkService) ListDevices(userID string) ([]*Device, error) {
+	db := s.getDB()
+	if db == nil {
+		return nil, fmt.Errorf("database is not initialized")
+	}
+
+	claims, err := db.ListDeviceClaimsByUser(userID)
+	if err != nil {
+		return nil, err
+	}
+	return s.assembleDevices(db, claims)
+}
+
+// DeleteDeviceClaim drops a claim userID made
+func (s *NetworkService) DeleteDeviceClaim(userID, nodeAddress string) error {
+	db := s.getDB()
+	if db == nil {
+		return fmt.Errorf("database is not initialized")
+	}
+
+	claim, err := userDeviceClaim(db, userID, nodeAddress)
+	if err != nil {
+		return err
+	}
+	return db.DeleteDeviceClaim(claim.ID)
+}
+
+// RenameDevice sets the display name of a verified device on every network it is a member of
+func (s *NetworkService) RenameDevice(userID, nodeAddress, displayName string) (*Device, error) {
+	if err := (MemberMetadataUpdate{DisplayName: &displayName}).validate(); err != nil {
+		return nil, err
+	}
+	db := s.getDB()
+	if db == nil {
+		return nil, fmt.Errorf("database is not initialized")
+	}
+
+	claim, err := userDeviceClaim(db, userID, nodeAddress)
+	if err != nil {
+		return nil, err
+	}
+	if !claim.Verified {
+		return nil, ErrDeviceClaimNotVerified
+	}
+
+	memberships, err := s.deviceMemberships(db, []string{nodeAddress})
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	for _, membership := range memberships[nodeAddress] {
+		metadata, err := db.GetMemberMetadata(membership.NetworkID, nodeAddress)
+		if err != nil {
+			return nil, err
+		}
+		if metadata == nil {
+			metadata = &models.MemberMetadata{NetworkID: membership.NetworkID, MemberID: nodeAddress}
+		}
+		metadata.DisplayName = strings.TrimSpace(displayName)
+		metadata.UpdatedBy = userID
+		metadata.UpdatedAt = now
+		if err := db.SaveMemberMetadata(metadata); err != nil {
+			logger.Error("service: failed to rename device", zap.String("network_id", membership.NetworkID), zap.String("node_address", nodeAddress), zap.Error(err))
+			return nil, err
+		}
+		s.notifyMemberChange(membership.NetworkID)
+	}
+
+	devices, err := s.assembleDevices(db, []*models.DeviceClaim{claim})
+	if err != nil {
+		return nil, err
+	}
+	return devices[0], nil
+}
+
+// RequestDeviceAuthorization queues a verified device for approval by the network owner.
+// Asking again while the request is pending returns the same entry.
+func (s *NetworkService) RequestDeviceAuthorization(userID, nodeAddress, networkID string) (*models.PendingApproval, error) {
+	db := s.getDB()
+	if db == nil {
+		return nil, fmt.Errorf("database is not initialized")
+	}
+
+	claim, err := userDeviceClaim(db, userID, nodeAddress)
+	if err != nil {
+		return nil, err
+	}
+	if !claim.Verified {
+		return nil, ErrDeviceClaimNotVerified
+	}
+
+	network, err := s.getNetwork(networkID)
+	if err != nil {
+		return nil, err
+	}
+	client, err := s.clientFor(network)
+	if err != nil {
+		return nil, err
+	}
+	member, err := client.GetMember(networkID, nodeAddress)
+	if zerotier.IsNotFound(err) || (err == nil && member == nil) {
+		return nil, ErrMemberNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if member.Config.Authorized {
+		return nil, ErrDeviceAlreadyAuthorized
+	}
+
+	approval := &models.PendingApproval{
+		NetworkID:  networkID,
+		MemberID:   nodeAddress,
+		Name:       member.Name,
+		Status:     models.ApprovalPending,
+		DetectedAt: time.Now(),
+	}
+	created, err := db.CreatePendingApproval(approval)
+	if err != nil {
+		return nil, fmt.Errorf("failed to queue device for approval: %w", err)
+	}
+	if created {
+		logger.Info("service: device authorization requested", zap.String("user_id", userID), zap.String("network_id", networkID), zap.String("node_address", nodeAddress))
+		return approval, nil
+	}
+
+	pending, err := db.ListPendingApprovals([]string{networkID})
+	if err != nil {
+		return nil, err
+	}
+	for _, existing := range pending {
+		if existing.MemberID == nodeAddress {
+			return existing, nil
+		}
+	}
+	return nil, ErrApprovalDecided
+}
+
+// ListUnverifiedDeviceClaims returns the claims waiting for an administrator
+func (s *NetworkService) ListUnverifiedDeviceClaims() ([]*models.DeviceClaim, error) {
+	db := s.getDB()
+	if db == nil {
+		return nil, fmt.Errorf("database is not initialized")
+	}
+	return db.ListUnverifiedDeviceClaims()
+}
+
+// DecideDeviceClaim verifies a claim or, when denied, removes it
+func (s *NetworkService) DecideDeviceClaim(id uint64, approve bool, adminID string) (*models.DeviceClaim, error) {
+	db := s.getDB()
+	if db == nil {
+		return nil, fmt.Errorf("database is not initialized")
+	}
+
+	claim, err := db.GetDeviceClaim(id)
+	if err != nil {
+		return nil, err
+	}
+	if claim == nil {
+		return nil, ErrDeviceClaimNotFound
+	}
+	if !approve {
+		if err := db.DeleteDeviceClaim(id); err != nil {
+			return nil, err
+		}
+		return claim, nil
+	}
+	if claim.Verified {
+		return claim, nil
+	}
+	if err := verifyDeviceClaim(db, claim, adminID); err != nil {
+		return nil, err
+	}
+	return claim, nil
+}
+
+// assembleDevices attaches memberships to claims, verifying the claims whose code is the
+// member name on some network the claimant cannot write to. Naming a member on a network
+// the claimant controls proves nothing, since they could add anyone's node there.
+func (s *NetworkService) assembleDevices(db database.DBInterface, claims []*models.DeviceClaim) ([]*Device, error) {
+	devices := make([]*Device, 0, len(claims))
+	if len(claims) == 0 {
+		return devices, nil
+	}
+	addresses := make([]string, 0, len(claims))
+	for _, claim := range claims {
+		addresses = append(addresses, claim.NodeAddress)
+	}
+	memberships, err := s.deviceMemberships(db, addresses)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, claim := range claims {
+		networks := memberships[claim.NodeAddress]
+		if !claim.Verified {
+			for _, membership := range networks {
+				if membership.Name != claim.ClaimCode {
+					continue
+				}
+				writable, err := s.canWriteNetwork(membership.NetworkID, claim.UserID)
+				if err != nil {
+					return nil, err
+				}
+				if writable {
+					continue
+				}
+				if err := verifyDeviceClaim(db, claim, deviceClaimVerifiedByCode); err != nil && !errors.Is(err, ErrDeviceClaimedByOther) {
+					return nil, err
+				}
+				break
+			}
+		}
+
+		device := &Device{DeviceClaim: *claim, Networks: []DeviceMembership{}}
+		if claim.Verified {
+			device.Networks = append(device.Networks, networks...)
+		}
+		devices = append(devices, device)
+	}
+	return devices, nil
+}
+
+// deviceMemberships finds the given node addresses on every network Tairitsu manages.
+// Networks whose controller cannot be reached are skipped.
+func (s *NetworkService) deviceMemberships(db database.DBInterface, addresses []string) (map[string][]DeviceMembership, error) {
+	networks, err := db.GetAllNetworks()
+	if err != nil {
+		return nil, err
+	}
+	wanted := make(map[string]bool, len(addresses))
+	for _, address := range addresses {
+		wanted[address] = true
+	}
+
+	result := map[string][]DeviceMembership{}
+	if len(networks) == 0 {
+		return result, nil
+	}
+	var mutex sync.Mutex
+	var wg sync.WaitGroup
+	limiter := make(chan struct{}, min(networkMemberStatsConcurrency, len(networks)))
+	for _, network := range networks {
+		client, err := s.clientFor(network)
+		if err != nil {
+			continue
+		}
+
+		wg.Add(1)
+		go func(network *models.Network, client *zerotier.Client) {
+			defer wg.Done()
+			limiter <- struct{}{}
+			defer func() { <-limiter }()
+
+			members, err := client.GetMembers(network.ID)
+			if err != nil {
+				logger.Warn("service: failed to get members for claimed devices", zap.String("network_id", network.ID), zap.Error(err))
+				return
+			}
+			found := []zerotier.Member{}
+			for _, member := range members {
+				if wanted[member.ID] {
+					found = append(found, member)
+				}
+			}
+			s.attachMemberMetadata(network.ID, found)
+
+			mutex.Lock()
+			defer mutex.Unlock()
+			for _, member := range found {
+				membership := DeviceMembership{
+					NetworkID:     network.ID,
+					NetworkName:   network.Name,
+					Authorized:    member.Config.Authorized,
+					Name:          member.Name,
+					IPAssignments: member.Config.IPAssignments,
+				}
+				if membership.IPAssignments == nil {
+					membership.IPAssignments = []string{}
+				}
+				if member.Metadata != nil {
+					membership.DisplayName = member.Metadata.DisplayName
+				}
+				result[member.ID] = append(result[member.ID], membership)
+			}
+		}(network, client)
+	}
+	wg.Wait()
+
+	for _, memberships := range result {
+		sort.Slice(memberships, func(i, j int) bool { return memberships[i].NetworkID < memberships[j].NetworkID })
+	}
+	return result, nil
+}
+
+// verifyDeviceClaim marks a claim verified unless another user already holds the node
+func verifyDeviceClaim(db database.DBInterface, claim *models.DeviceClaim, verifiedBy string) error {
+	claims, err := db.ListDeviceClaimsByNode(claim.NodeAddress)
+	if err != nil {
+		return err
+	}
+	for _, existing := range claims {
+		if existing.ID != claim.ID && existing.Verified {
+			return ErrDeviceClaimedByOther
+		}
+	}
+
+	now := time.Now()
+	claim.Verified = true
+	claim.VerifiedBy = verifiedBy
+	claim.VerifiedAt = &now
+	if err := db.SaveDeviceClaim(claim); err != nil {
+		return fmt.Errorf("failed to verify device claim: %w", err)
+	}
+	logger.Info("service: device claim verified", zap.String("user_id", claim.UserID), zap.String("node_address", claim.NodeAddress), zap.String("verified_by", verifiedBy))
+	return nil
+}
+
+func userDeviceClaim(db database.DBInterface, userID, nodeAddress string) (*models.DeviceClaim, error) {
+	claims, err := db.ListDeviceClaimsByUser(userID)
+	if err != nil {
+		return nil, err
+	}
+	for _, claim := range claims {
+		if claim.NodeAddress == nodeAddress {
+			return claim, nil
+		}
+	}
+	return nil, ErrDeviceClaimNotFound
+}
+
+func newDeviceClaimCode() (string, error) {
+	random := make([]byte, 6)
+	if _, err := rand.Read(random); err != nil {
+		return "", fmt.Errorf("failed to generate claim code: %w", err)
+	}
+	return DeviceClaimCodePrefix + hex.EncodeToString(random), nil
+}
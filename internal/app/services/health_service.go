@@ -0,0 +1,156 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/GT-610/tairitsu/internal/app/logger"
+	"go.uber.org/zap"
+)
+
+const (
+	HealthStatusOK            = "ok"
+	HealthStatusDegraded      = "degraded"
+	HealthStatusDown          = "down"
+	HealthStatusNotConfigured = "not_configured"
+
+	HealthComponentDatabase = "database"
+	HealthComponentZeroTier = "zerotier"
+
+	defaultHealthCheckTimeout = 2 * time.Second
+)
+
+// ComponentHealth is the result of probing a single dependency
+type ComponentHealth struct {
+	Status    string `json:"status"`
+	LatencyMS int64  `json:"latencyMs"`
+	Error     string `json:"error,omitempty"`
+}
+
+// HealthReport summarizes dependency health for load balancers and uptime monitors
+type HealthReport struct {
+	Status     string                     `json:"status"`
+	Components map[string]ComponentHealth `json:"components"`
+	CheckedAt  time.Time                  `json:"checkedAt"`
+}
+
+// HealthService probes the database and the ZeroTier controller
+type HealthService struct {
+	networkService *NetworkService
+	runtimeService *RuntimeService
+	timeout        time.Duration
+}
+
+// NewHealthService creates a new health service instance
+func NewHealthService(networkService *NetworkService) *HealthService {
+	return &HealthService{
+		networkService: networkService,
+		timeout:        defaultHealthCheckTimeout,
+	}
+}
+
+// SetTimeout bounds how long each dependency probe may take
+func (s *HealthService) SetTimeout(timeout time.Duration) {
+	s.timeout = timeout
+}
+
+// SetDatabaseState reports a configured database that could not be reached as down
+// instead of not configured
+func (s *HealthService) SetDatabaseState(runtimeService *RuntimeService) {
+	s.runtimeService = runtimeService
+}
+
+// Check probes every dependency concurrently. The database is required for any
+// authenticated request, so losing it reports "down"; losing only the controller
+// reports "degraded". Dependencies not configured yet do not affect the result.
+func (s *HealthService) Check(ctx context.Context) *HealthReport {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	var database, zerotier ComponentHealth
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		database = s.checkDatabase(ctx)
+	}()
+	go func() {
+		defer wg.Done()
+		zerotier = s.checkZeroTier(ctx)
+	}()
+	wg.Wait()
+
+	report := &HealthReport{
+		Status: HealthStatusOK,
+		Components: map[string]ComponentHealth{
+			HealthComponentDatabase: database,
+			HealthComponentZeroTier: zerotier,
+		},
+		CheckedAt: time.Now(),
+	}
+	switch {
+	case database.Status == HealthStatusDown:
+		report.Status = HealthStatusDown
+	case zerotier.Status == HealthStatusDown:
+		report.Status = HealthStatusDegraded
+	}
+	return report
+}
+
+func (s *HealthService) checkDatabase(ctx context.Context) ComponentHealth {
+	db := s.networkService.getDB()
+	if db == nil {
+		if s.runtimeService != nil && s.runtimeService.DatabaseError() != nil {
+			return ComponentHealth{Status: HealthStatusDown, Error: "database unreachable"}
+		}
+		return ComponentHealth{Status: HealthStatusNotConfigured}
+	}
+
+	return probeComponent(ctx, HealthComponentDatabase, "database ping failed", func(context.Context) error {
+		// Ping has no context; a hung ping is abandoned once ctx expires.
+		return db.Ping()
+	})
+}
+
+func (s *HealthService) checkZeroTier(ctx context.Context) ComponentHealth {
+	client := s.networkService.getZTClient()
+	if client == nil {
+		return ComponentHealth{Status: HealthStatusNotConfigured}
+	}
+
+	return probeComponent(ctx, HealthComponentZeroTier, "controller unreachable", func(ctx context.Context) error {
+		_, err := client.GetStatusContext(ctx)
+		return err
+	})
+}
+
+// probeComponent runs probe until it returns or ctx expires. Raw errors are only
+// logged; the report carries a generic reason because the endpoint is public.
+func probeComponent(ctx context.Context, component string, failure string, probe func(context.Context) error) ComponentHealth {
+	start := time.Now()
+	result := make(chan error, 1)
+	go func() {
+		result <- probe(ctx)
+	}()
+
+	var err error
+	select {
+	case err = <-result:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+	health := ComponentHealth{Status: HealthStatusOK, LatencyMS: time.Since(start).Milliseconds()}
+	if err == nil {
+		return health
+	}
+
+	health.Status = HealthStatusDown
+	health.Error = failure
+	if errors.Is(err, context.DeadlineExceeded) {
+		health.Error = "health check timed out"
+	}
+	logger.Warn("service: health check failed", zap.String("component", component), zap.Error(err))
+	return health
+}
@@ -2,8 +2,10 @@ package services
 
 import (
 	"errors"
+	"sync"
 	"time"
 
+	"github.com/GT-610/tairitsu/internal/app/config"
 	"github.com/GT-610/tairitsu/internal/app/models"
 	"github.com/golang-jwt/jwt/v4"
 )
@@ -19,26 +21,65 @@ type JWTClaims struct {
 
 // JWTService handles JWT token generation, validation, and parsing
 type JWTService struct {
-	secretKey    []byte        // Secret key used for signing tokens
-	accessExpiry time.Duration // Default expiration time for access tokens
+	secretMutex  sync.RWMutex
+	secretKey    []byte                    // Secret key used for signing tokens
+	previousKeys []config.RetiredJWTSecret // Replaced keys that still verify tokens until they expire
+	accessExpiry time.Duration             // Default expiration time for access tokens
 }
 
+// ErrEmptyJWTSecret is returned when a JWT service is constructed without a signing key
+var ErrEmptyJWTSecret = errors.New("JWT signing key must not be empty")
+
 // NewJWTService creates a new JWT service instance with the provided secret key
-func NewJWTService(secretKey string) *JWTService {
+func NewJWTService(secretKey string) (*JWTService, error) {
+	if secretKey == "" {
+		return nil, ErrEmptyJWTSecret
+	}
 	return &JWTService{
 		secretKey:    []byte(secretKey),
 		accessExpiry: time.Hour * 24, // 24 hours expiration time
+	}, nil
+}
+
+// SetSecrets replaces the signing key. Tokens signed with one of the previous secrets
+// stay valid until that secret expires, so a rotation does not sign anyone out.
+func (s *JWTService) SetSecrets(secretKey string, previous []config.RetiredJWTSecret) error {
+	if secretKey == "" {
+		return ErrEmptyJWTSecret
 	}
+
+	s.secretMutex.Lock()
+	defer s.secretMutex.Unlock()
+	s.secretKey = []byte(secretKey)
+	s.previousKeys = append([]config.RetiredJWTSecret(nil), previous...)
+	return nil
 }
 
-// GenerateToken creates a new JWT token for the given user
+// verificationKeys returns the signing key followed by the previous keys that have not expired
+func (s *JWTService) verificationKeys(now time.Time) [][]byte {
+	s.secretMutex.RLock()
+	defer s.secretMutex.RUnlock()
+
+	keys := [][]byte{s.secretKey}
+	for _, previous := range s.previousKeys {
+		if previous.ExpiresAt.After(now) {
+			keys = append(keys, []byte(previous.Secret))
+		}
+	}
+	return keys
+}
+
+// AccessExpiry returns the lifetime of newly issued access tokens
 func (s *JWTService) AccessExpiry() time.Duration {
 	return s.accessExpiry
 }
 
 // GenerateToken creates a new JWT token for the given user
 func (s *JWTService) GenerateToken(user *models.User, sessionID string) (string, error) {
-	if len(s.secretKey) == 0 {
+	s.secretMutex.RLock()
+	secretKey := s.secretKey
+	s.secretMutex.RUnlock()
+	if len(secretKey) == 0 {
 		return "", errors.New("JWT signing key is not configured; complete initial setup first")
 	}
 
@@ -56,7 +97,7 @@ func (s *JWTService) GenerateToken(user *models.User, sessionID string) (string,
 	}
 
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	tokenString, err := token.SignedString(s.secretKey)
+	tokenString, err := token.SignedString(secretKey)
 	if err != nil {
 		return "", err
 	}
@@ -64,24 +105,30 @@ func (s *JWTService) GenerateToken(user *models.User, sessionID string) (string,
 	return tokenString, nil
 }
 
-// ValidateToken validates a JWT token
+// ValidateToken validates a JWT token against the signing key and any previous key that
+// has not expired
 func (s *JWTService) ValidateToken(tokenString string) (*JWTClaims, error) {
-	// Parse the token
-	token, err := jwt.ParseWithClaims(tokenString, &JWTClaims{}, func(token *jwt.Token) (interface{}, error) {
-		if token.Method != jwt.SigningMethodHS256 {
-			return nil, errors.New("invalid signing method")
+	var err error
+	for _, key := range s.verificationKeys(time.Now()) {
+		var token *jwt.Token
+		token, err = jwt.ParseWithClaims(tokenString, &JWTClaims{}, func(token *jwt.Token) (interface{}, error) {
+			if token.Method != jwt.SigningMethodHS256 {
+				return nil, errors.New("invalid signing method")
+			}
+			return key, nil
+		})
+		if err == nil {
+			// Validate claims
+			if claims, ok := token.Claims.(*JWTClaims); ok && token.Valid {
+				return claims, nil
+			}
+			return nil, errors.New("invalid token")
+		}
+		// Only a signature mismatch means another key may have signed the token
+		if !errors.Is(err, jwt.ErrSignatureInvalid) {
+			return nil, err
 		}
-		return s.secretKey, nil
-	})
-
-	if err != nil {
-		return nil, err
-	}
-
-	// Validate claims
-	if claims, ok := token.Claims.(*JWTClaims); ok && token.Valid {
-		return claims, nil
 	}
 
-	return nil, errors.New("invalid token")
+	return nil, err
 }
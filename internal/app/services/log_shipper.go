@@ -0,0 +1,364 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/GT-610/tairitsu/internal/app/config"
+	"github.com/GT-610/tairitsu/internal/app/logger"
+	"go.uber.org/zap"
+)
+
+// Kinds of shipped events
+const (
+	ShippedEventAudit  = "audit"
+	ShippedEventAuth   = "auth"
+	ShippedEventMember = "member"
+)
+
+// Actions of shipped authentication events. Audit events keep their audit action, and member
+// events use MemberEventOnline and MemberEventOffline.
+const (
+	AuthEventLogin       = "auth.login"
+	AuthEventLoginFailed = "auth.login_failed"
+	AuthEventLogout      = "auth.logout"
+)
+
+const (
+	logShippingWriteTimeout = 5 * time.Second
+	logShippingMinRetry     = time.Second
+	logShippingMaxRetry     = 30 * time.Second
+
+	// syslogEnterpriseID is the IANA number reserved for documentation (RFC 5612), used in
+	// the structured data ID since Tairitsu has none of its own
+	syslogEnterpriseID = "32473"
+	syslogAppName      = "tairitsu"
+	// syslog facilities: authpriv for sign-ins, log audit for everything else
+	syslogFacilityAuthPriv = 10
+	syslogFacilityAudit    = 13
+	syslogSeverityWarning  = 4
+	syslogSeverityNotice   = 5
+)
+
+// ShippedEvent is an audit, authentication or member event as sent to the log shipping target
+type ShippedEvent struct {
+	Time    time.Time `json:"time"`
+	Kind    string    `json:"kind"`
+	Action  string    `json:"action"`
+	ActorID string    `json:"actorId,omitempty"`
+	Target  string    `json:"target,omitempty"`
+	Details string    `json:"details,omitempty"`
+	IP      string    `json:"ip,omitempty"`
+}
+
+// LogShippingStatus reports how far the log shipper is behind and what it lost
+type LogShippingStatus struct {
+	Enabled       bool       `json:"enabled"`
+	Target        string     `json:"target,omitempty"`
+	Format        string     `json:"format,omitempty"`
+	Connected     bool       `json:"connected"`
+	QueueDepth    int        `json:"queueDepth"`
+	QueueCapacity int        `json:"queueCapacity"`
+	Shipped       int64      `json:"shipped"`
+	Dropped       int64      `json:"dropped"`
+	LastError     string     `json:"lastError,omitempty"`
+	LastErrorAt   *time.Time `json:"lastErrorAt,omitempty"`
+}
+
+// LogShipper forwards events to a syslog server over UDP or TCP, or appends them to a file,
+// in the background. Events wait in a bounded queue; when the target falls behind, new
+// events are dropped and counted so request handlers never block on it. A target that
+// fails is reconnected with exponential backoff, and the event that failed is sent again.
+type LogShipper struct {
+	settings *config.LogShippingConfig
+	queue    chan ShippedEvent
+	hostname string
+	maxRetry time.Duration
+
+	shipped  atomic.Int64
+	dropped  atomic.Int64
+	dropping atomic.Bool
+
+	mutex       sync.Mutex
+	connected   bool
+	lastError   string
+	lastErrorAt time.Time
+}
+
+// NewLogShipper creates a shipper for settings from config.LogShippingFrom; nil settings
+// create a disabled shipper that ignores events
+func NewLogShipper(settings *config.LogShippingConfig) *LogShipper {
+	shipper := &LogShipper{settings: settings, maxRetry: logShippingMaxRetry}
+	if settings != nil {
+		shipper.queue = make(chan ShippedEvent, settings.QueueSize)
+	}
+	shipper.hostname, _ = os.Hostname()
+	return shipper
+}
+
+// SetMaxRetryDelay caps the backoff between reconnection attempts
+func (s *LogShipper) SetMaxRetryDelay(delay time.Duration) {
+	s.maxRetry = delay
+}
+
+func (s *LogShipper) enabled() bool {
+	return s != nil && s.settings != nil
+}
+
+// Ship queues event without waiting. A nil or disabled shipper ignores it, and a full
+// queue drops it.
+func (s *LogShipper) Ship(event ShippedEvent) {
+	if !s.enabled() {
+		return
+	}
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+	select {
+	case s.queue <- event:
+	default:
+		s.dropped.Add(1)
+		if !s.dropping.Swap(true) {
+			logger.Warn("log shipping queue is full; dropping events until the target catches up", zap.String("target", s.settings.Target))
+		}
+	}
+}
+
+// Status reports the queue and connection state
+func (s *LogShipper) Status() LogShippingStatus {
+	if !s.enabled() {
+		return LogShippingStatus{}
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	status := LogShippingStatus{
+		Enabled:       true,
+		Target:        s.settings.Target,
+		Format:        s.settings.Format,
+		Connected:     s.connected,
+		QueueDepth:    len(s.queue),
+		QueueCapacity: cap(s.queue),
+		Shipped:       s.shipped.Load(),
+		Dropped:       s.dropped.Load(),
+		LastError:     s.lastError,
+	}
+	if !s.lastErrorAt.IsZero() {
+		lastErrorAt := s.lastErrorAt
+		status.LastErrorAt = &lastErrorAt
+	}
+	return status
+}
+
+// Start ships queued events until ctx is cancelled. Events still queued then are written
+// while the target is reachable, without reconnecting.
+func (s *LogShipper) Start(ctx context.Context) <-chan struct{} {
+	done := make(chan struct{})
+	if !s.enabled() {
+		close(done)
+		return done
+	}
+	go func() {
+		defer close(done)
+		var conn io.WriteCloser
+		defer func() {
+			if conn != nil {
+				_ = conn.Close()
+			}
+			s.setConnected(false)
+		}()
+
+		delay := logShippingMinRetry
+		var pending *ShippedEvent
+		for {
+			if pending == nil {
+				select {
+				case <-ctx.Done():
+					s.flush(conn)
+					return
+				case event := <-s.queue:
+					pending = &event
+				}
+			}
+
+			if conn == nil {
+				var err error
+				if conn, err = s.connect(); err != nil {
+					s.recordError("failed to connect to log shipping target", err)
+					if !sleepContext(ctx, delay) {
+						return
+					}
+					delay = min(delay*2, s.maxRetry)
+					continue
+				}
+				s.setConnected(true)
+			}
+
+			if err := s.write(conn, *pending); err != nil {
+				s.recordError("failed to ship event", err)
+				_ = conn.Close()
+				conn = nil
+				s.setConnected(false)
+				if !sleepContext(ctx, delay) {
+					return
+				}
+				delay = min(delay*2, s.maxRetry)
+				continue
+			}
+			delay = logShippingMinRetry
+			pending = nil
+		}
+	}()
+	return done
+}
+
+// flush writes the events still queued at shutdown, stopping at the first failure
+func (s *LogShipper) flush(conn io.WriteCloser) {
+	if conn == nil {
+		return
+	}
+	for {
+		select {
+		case event := <-s.queue:
+			if err := s.write(conn, event); err != nil {
+				s.recordError("failed to ship event", err)
+				return
+			}
+		default:
+			return
+		}
+	}
+}
+
+// connect opens the target: a socket for syslog, or the file in append mode
+func (s *LogShipper) connect() (io.WriteCloser, error) {
+	network, address, _ := strings.Cut(s.settings.Target, ":")
+	if network == "file" {
+		if err := os.MkdirAll(filepath.Dir(address), 0755); err != nil {
+			return nil, err
+		}
+		return os.OpenFile(address, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0640)
+	}
+	return net.DialTimeout(network, strings.TrimPrefix(address, "//"), logShippingWriteTimeout)
+}
+
+// write sends one event. TCP syslog frames messages by octet counting (RFC 6587); JSON
+// over TCP and files get one event per line, and UDP one event per datagram.
+func (s *LogShipper) write(conn io.Writer, event ShippedEvent) error {
+	message, err := s.encode(event)
+	if err != nil {
+		return err
+	}
+	switch {
+	case strings.HasPrefix(s.settings.Target, "tcp:") && s.settings.Format == config.LogShippingFormatRFC5424:
+		message = append([]byte(strconv.Itoa(len(message))+" "), message...)
+	case !strings.HasPrefix(s.settings.Target, "udp:"):
+		message = append(message, '\n')
+	}
+
+	if deadline, ok := conn.(interface{ SetWriteDeadline(time.Time) error }); ok {
+		_ = deadline.SetWriteDeadline(time.Now().Add(logShippingWriteTimeout))
+	}
+	if _, err := conn.Write(message); err != nil {
+		return err
+	}
+	s.shipped.Add(1)
+	s.dropping.Store(false)
+	return nil
+}
+
+func (s *LogShipper) encode(event ShippedEvent) ([]byte, error) {
+	if s.settings.Format == config.LogShippingFormatJSON {
+		return json.Marshal(event)
+	}
+	return []byte(formatRFC5424(event, s.hostname)), nil
+}
+
+// formatRFC5424 renders event as a syslog message with its fields as structured data
+func formatRFC5424(event ShippedEvent, hostname string) string {
+	facility, severity := syslogFacilityAudit, syslogSeverityNotice
+	if event.Kind == ShippedEventAuth {
+		facility = syslogFacilityAuthPriv
+	}
+	if strings.HasSuffix(event.Action, "_failed") {
+		severity = syslogSeverityWarning
+	}
+
+	var data strings.Builder
+	data.WriteString("[" + syslogAppName + "@" + syslogEnterpriseID)
+	for _, param := range []struct{ name, value string }{
+		{"kind", event.Kind}, {"actor", event.ActorID}, {"target", event.Target}, {"ip", event.IP},
+	} {
+		if param.value != "" {
+			fmt.Fprintf(&data, " %s=\"%s\"", param.name, escapeSDValue(param.value))
+		}
+	}
+	data.WriteString("]")
+
+	message := fmt.Sprintf("<%d>1 %s %s %s %d %s %s",
+		facility*8+severity,
+		event.Time.UTC().Format("2006-01-02T15:04:05.000Z07:00"),
+		syslogHeaderField(hostname, 255),
+		syslogAppName,
+		os.Getpid(),
+		syslogHeaderField(event.Action, 32),
+		data.String())
+	if event.Details != "" {
+		message += " " + event.Details
+	}
+	return message
+}
+
+// syslogHeaderField limits a header field to printable ASCII without spaces, or "-" when empty
+func syslogHeaderField(value string, maxLength int) string {
+	field := strings.Map(func(r rune) rune {
+		if r <= ' ' || r > '~' {
+			return '_'
+		}
+		return r
+	}, value)
+	if field == "" {
+		return "-"
+	}
+	return field[:min(len(field), maxLength)]
+}
+
+// escapeSDValue escapes the characters RFC 5424 reserves in structured data values
+func escapeSDValue(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, `]`, `\]`).Replace(value)
+}
+
+func (s *LogShipper) setConnected(connected bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.connected = connected
+}
+
+func (s *LogShipper) recordError(message string, err error) {
+	logger.Warn(message, zap.String("target", s.settings.Target), zap.Error(err))
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.lastError = err.Error()
+	s.lastErrorAt = time.Now()
+}
+
+// sleepContext waits for delay and reports false when ctx was cancelled first
+func sleepContext(ctx context.Context, delay time.Duration) bool {
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
@@ -0,0 +1,63 @@
+package services
+
+import (
+	"sync"
+	"time"
+)
+
+// maintenanceWritePollInterval is how often a paused write checks whether maintenance ended
+const maintenanceWritePollInterval = 20 * time.Millisecond
+
+// MaintenanceMode pauses state-changing requests while a maintenance job needs the database to
+// itself. Entering waits for in-flight writes to finish; writes arriving meanwhile wait briefly
+// for maintenance to end.
+type MaintenanceMode struct {
+	gate   sync.RWMutex // Held shared by in-flight writes and exclusively during maintenance
+	mutex  sync.RWMutex
+	reason string
+}
+
+// NewMaintenanceMode creates a maintenance mode switch with writes allowed
+func NewMaintenanceMode() *MaintenanceMode {
+	return &MaintenanceMode{}
+}
+
+// Enter pauses new writes, waits for in-flight ones and returns the function that resumes them
+func (m *MaintenanceMode) Enter(reason string) func() {
+	m.gate.Lock()
+	m.mutex.Lock()
+	m.reason = reason
+	m.mutex.Unlock()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			m.mutex.Lock()
+			m.reason = ""
+			m.mutex.Unlock()
+			m.gate.Unlock()
+		})
+	}
+}
+
+// Active reports whether maintenance is running and why
+func (m *MaintenanceMode) Active() (string, bool) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+	return m.reason, m.reason != ""
+}
+
+// AcquireWrite admits a write, waiting up to wait while maintenance runs or is about to start.
+// The returned function must be called when the write is done; ok is false when the wait ran out.
+func (m *MaintenanceMode) AcquireWrite(wait time.Duration) (release func(), ok bool) {
+	deadline := time.Now().Add(wait)
+	for {
+		if m.gate.TryRLock() {
+			return m.gate.RUnlock, true
+		}
+		if !time.Now().Before(deadline) {
+			return nil, false
+		}
+		time.Sleep(maintenanceWritePollInterval)
+	}
+}
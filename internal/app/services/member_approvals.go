@@ -0,0 +1,167 @@
+package services
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/GT-610/tairitsu/internal/app/database"
+	"github.com/GT-610/tairitsu/internal/app/logger"
+	"github.com/GT-610/tairitsu/internal/app/models"
+	"github.com/GT-610/tairitsu/internal/zerotier"
+	"go.uber.org/zap"
+)
+
+var (
+	ErrApprovalNotFound = errors.New("pending approval not found")
+	ErrApprovalDecided  = errors.New("pending approval was already decided")
+)
+
+// PendingApprovalEvent is the event name sent to approval notifiers
+const PendingApprovalEvent = "member.pending_approval"
+
+// PendingApprovalNotification describes a member that newly waits for approval
+type PendingApprovalNotification struct {
+	Event       string                  `json:"event"`
+	NetworkName string                  `json:"networkName"`
+	Approval    *models.PendingApproval `json:"approval"`
+}
+
+// ApprovalNotifier is told about every member the status collector queues for approval
+type ApprovalNotifier interface {
+	NotifyPendingApproval(notification PendingApprovalNotification) error
+}
+
+// WebhookApprovalNotifier posts each notification as JSON to a configured URL
+type WebhookApprovalNotifier struct {
+	url    string
+	client *http.Client
+}
+
+// NewWebhookApprovalNotifier creates a notifier posting to url
+func NewWebhookApprovalNotifier(url string) *WebhookApprovalNotifier {
+	return &WebhookApprovalNotifier{url: url, client: &http.Client{Timeout: 10 * time.Second}}
+}
+
+func (n *WebhookApprovalNotifier) NotifyPendingApproval(notification PendingApprovalNotification) error {
+	body, err := json.Marshal(notification)
+	if err != nil {
+		return err
+	}
+	resp, err := n.client.Post(n.url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to call approval webhook: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("approval webhook returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// queuePendingMembers adds the unauthorized members of a network to the approval queue and
+// drops pending entries whose member was authorized or removed elsewhere. Members that already
+// have an entry, decided or not, are neither queued nor announced again.
+func (c *MemberStatusCollector) queuePendingMembers(db database.DBInterface, network *models.Network, members []zerotier.Member, now time.Time) error {
+	pending, err := db.ListPendingApprovals([]string{network.ID})
+	if err != nil {
+		return fmt.Errorf("failed to read pending approvals: %w", err)
+	}
+
+	waiting := make(map[string]bool, len(members))
+	for _, member := range members {
+		if member.Config.Authorized {
+			continue
+		}
+		waiting[member.ID] = true
+
+		approval := &models.PendingApproval{
+			NetworkID:  network.ID,
+			MemberID:   member.ID,
+			Name:       member.Name,
+			Status:     models.ApprovalPending,
+			DetectedAt: now,
+		}
+		created, err := db.CreatePendingApproval(approval)
+		if err != nil {
+			return fmt.Errorf("failed to queue member %s for approval: %w", member.ID, err)
+		}
+		if created && c.notifier != nil {
+			notification := PendingApprovalNotification{Event: PendingApprovalEvent, NetworkName: network.Name, Approval: approval}
+			if err := c.notifier.NotifyPendingApproval(notification); err != nil {
+				logger.Warn("failed to notify about pending member", zap.String("network_id", network.ID), zap.String("member_id", member.ID), zap.Error(err))
+			}
+		}
+	}
+
+	for _, approval := range pending {
+		if waiting[approval.MemberID] {
+			continue
+		}
+		if err := db.DeletePendingApproval(approval.NetworkID, approval.MemberID); err != nil {
+			return fmt.Errorf("failed to drop resolved approval: %w", err)
+		}
+	}
+	return nil
+}
+
+// ListPendingApprovals returns the members waiting for approval in every network userID owns
+func (s *NetworkService) ListPendingApprovals(userID string) ([]*models.PendingApproval, error) {
+	db := s.getDB()
+	if db == nil {
+		return nil, fmt.Errorf("database is not initialized")
+	}
+
+	networks, err := db.GetNetworksByOwnerID(userID)
+	if err != nil {
+		return nil, err
+	}
+	networkIDs := make([]string, 0, len(networks))
+	for _, network := range networks {
+		networkIDs = append(networkIDs, network.ID)
+	}
+	return db.ListPendingApprovals(networkIDs)
+}
+
+// DecidePendingApproval authorizes or denies a queued member on the controller and records
+// who decided
+func (s *NetworkService) DecidePendingApproval(id uint64, approve bool, userID string) (*models.PendingApproval, error) {
+	db := s.getDB()
+	if db == nil {
+		return nil, fmt.Errorf("database is not initialized")
+	}
+
+	approval, err := db.GetPendingApproval(id)
+	if err != nil {
+		return nil, err
+	}
+	if approval == nil {
+		return nil, ErrApprovalNotFound
+	}
+	if approval.Status != models.ApprovalPending {
+		return nil, ErrApprovalDecided
+	}
+
+	if _, err := s.UpdateNetworkMember(approval.NetworkID, approval.MemberID, &zerotier.MemberUpdateRequest{Authorized: &approve}, userID); err != nil {
+		if zerotier.IsNotFound(err) {
+			return nil, ErrMemberNotFound
+		}
+		return nil, err
+	}
+
+	now := time.Now()
+	approval.Status = models.ApprovalDenied
+	if approve {
+		approval.Status = models.ApprovalApproved
+	}
+	approval.DecidedBy = userID
+	approval.DecidedAt = &now
+	if err := db.SavePendingApproval(approval); err != nil {
+		logger.Error("service: failed to record approval decision", zap.Uint64("approval_id", id), zap.Error(err))
+		return nil, fmt.Errorf("member updated but the decision was not recorded: %w", err)
+	}
+	return approval, nil
+}
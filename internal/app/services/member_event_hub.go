@@ -0,0 +1,561 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/GT-610/tairitsu/internal/app/logger"
+	"github.com/GT-610/tairitsu/internal/zerotier"
+	"go.uber.org/zap"
+)
+
+// Member event types published by MemberEventHub
+const (
+	MemberEventJoined       = "member.joined"
+	MemberEventLeft         = "member.left"
+	MemberEventAuthorized   = "member.authorized"
+	MemberEventDeauthorized = "member.deauthorized"
+	MemberEventOnline       = "member.online"
+	MemberEventOffline      = "member.offline"
+)
+
+// memberEventBuffer bounds the events queued for one subscriber before it counts as lagging
+const memberEventBuffer = 64
+
+// Adaptive polling bounds, relative to the configured base interval
+const (
+	// memberPollFastDivisor shortens the interval after a mutation or while members await authorization
+	memberPollFastDivisor = 5
+	// memberPollBoostDuration is how long a mutation made through Tairitsu keeps the fast interval
+	memberPollBoostDuration = time.Minute
+	// memberPollIdleThreshold is the number of no-change polls before the interval starts doubling
+	memberPollIdleThreshold = 3
+	// memberPollMaxBackoff caps the idle interval as a multiple of the base interval
+	memberPollMaxBackoff = 8
+)
+
+// Reasons for the effective poll interval of a network, as shown in the admin jobs view
+const (
+	MemberPollReasonBase      = "base"
+	MemberPollReasonImmediate = "immediate"
+	MemberPollReasonMutation  = "mutation"
+	MemberPollReasonPending   = "pending"
+	MemberPollReasonIdle      = "idle"
+)
+
+var (
+	// ErrMemberEventsLagging closes a subscription that did not keep up with its events
+	ErrMemberEventsLagging = errors.New("member event subscriber fell behind")
+	// ErrMemberEventsStopped is returned when subscribing to, or closes subscriptions of, a stopped hub
+	ErrMemberEventsStopped = errors.New("member event hub is stopped")
+)
+
+// MemberEvent is a member change observed between two controller polls
+type MemberEvent struct {
+	Type      string    `json:"type"`
+	NetworkID string    `json:"networkId"`
+	MemberID  string    `json:"memberId"`
+	Time      time.Time `json:"time"`
+	// Member is the member after the change; nil for member.left
+	Member *zerotier.Member `json:"member,omitempty"`
+}
+
+// MemberEventSubscription receives the member events of one network until it is closed
+type MemberEventSubscription struct {
+	hub       *MemberEventHub
+	networkID string
+	userID    string
+	policy    string
+	events    chan MemberEvent
+
+	// closeErr is the reason the hub closed the subscription; guarded by hub.mutex
+	closeErr error
+	closed   bool
+}
+
+// Events delivers member events; it is closed when the subscription ends
+func (s *MemberEventSubscription) Events() <-chan MemberEvent {
+	return s.events
+}
+
+// Err reports why the hub closed the subscription: network access was revoked, the
+// subscriber fell behind, or the hub stopped. It is nil while open or after Close.
+func (s *MemberEventSubscription) Err() error {
+	s.hub.mutex.Lock()
+	defer s.hub.mutex.Unlock()
+	return s.closeErr
+}
+
+// Close unsubscribes and closes the events channel
+func (s *MemberEventSubscription) Close() {
+	s.hub.mutex.Lock()
+	defer s.hub.mutex.Unlock()
+	s.hub.removeLocked(s, nil)
+}
+
+// MemberPollSchedule describes when the hub next polls a subscribed network and why
+type MemberPollSchedule struct {
+	NetworkID       string     `json:"networkId"`
+	IntervalSeconds float64    `json:"intervalSeconds"`
+	Reason          string     `json:"reason"`
+	NextPollAt      time.Time  `json:"nextPollAt"`
+	LastPollAt      *time.Time `json:"lastPollAt,omitempty"`
+	IdlePolls       int        `json:"idlePolls"`
+	Subscribers     int        `json:"subscribers"`
+}
+
+// memberPollSchedule is the adaptive polling state of one subscribed network
+type memberPollSchedule struct {
+	interval   time.Duration
+	reason     string
+	nextPoll   time.Time
+	lastPoll   time.Time
+	idlePolls  int
+	pending    bool
+	boostUntil time.Time
+}
+
+// MemberEventHub polls the controller for networks with subscribers and broadcasts member
+// changes. Each network is polled once per effective interval however many connections
+// watch it. The interval starts at the configured base, is shortened after a member
+// mutation or while members await authorization, and doubles after consecutive polls
+// without changes, up to eight times the base.
+type MemberEventHub struct {
+	networkService *NetworkService
+	interval       time.Duration
+	now            func() time.Time
+	// wake interrupts the polling loop when a network needs an earlier poll
+	wake chan struct{}
+
+	// pollMutex serializes polls so snapshots are diffed in order
+	pollMutex sync.Mutex
+
+	mutex       sync.Mutex
+	stopped     bool
+	subscribers map[string]map[*MemberEventSubscription]struct{}
+	// snapshots maps network ID to member ID to the member seen by the last poll
+	snapshots map[string]map[string]zerotier.Member
+	schedules map[string]*memberPollSchedule
+}
+
+// NewMemberEventHub creates a hub polling subscribed networks around the given base interval
+func NewMemberEventHub(networkService *NetworkService, interval time.Duration) *MemberEventHub {
+	hub := &MemberEventHub{
+		networkService: networkService,
+		interval:       interval,
+		now:            time.Now,
+		wake:           make(chan struct{}, 1),
+		subscribers:    make(map[string]map[*MemberEventSubscription]struct{}),
+		snapshots:      make(map[string]map[string]zerotier.Member),
+		schedules:      make(map[string]*memberPollSchedule),
+	}
+	if networkService != nil {
+		networkService.SetMemberChangeListener(hub.NotifyMemberChange)
+	}
+	return hub
+}
+
+// SetClock replaces the time source used for poll scheduling, for tests
+func (h *MemberEventHub) SetClock(now func() time.Time) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	h.now = now
+}
+
+// Start polls due networks until ctx is cancelled, then closes every subscription with
+// ErrMemberEventsStopped
+func (h *MemberEventHub) Start(ctx context.Context) <-chan struct{} {
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		defer h.Close()
+		timer := time.NewTimer(h.untilNextPoll())
+		defer timer.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-h.wake:
+			case <-timer.C:
+			}
+			if err := h.PollDue(); err != nil {
+				logger.Warn("member event poll failed", zap.Error(err))
+			}
+			timer.Reset(h.untilNextPoll())
+		}
+	}()
+	return done
+}
+
+// untilNextPoll returns the wait before the earliest scheduled poll, or the base interval
+// when no network is subscribed
+func (h *MemberEventHub) untilNextPoll() time.Duration {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	if len(h.schedules) == 0 {
+		return h.interval
+	}
+	now := h.now()
+	wait := h.interval * memberPollMaxBackoff
+	for _, schedule := range h.schedules {
+		wait = min(wait, schedule.nextPoll.Sub(now))
+	}
+	return max(wait, 0)
+}
+
+// Subscribe opens a subscription to the member events of a network the user can read and
+// schedules an immediate poll of the network
+func (h *MemberEventHub) Subscribe(networkID, userID string) (*MemberEventSubscription, error) {
+	network, err := h.networkService.authorizeMemberReadAccess(networkID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	subscription := &MemberEventSubscription{
+		hub:       h,
+		networkID: networkID,
+		userID:    userID,
+		policy:    h.networkService.physicalAddressPolicyFor(network, userID),
+		events:    make(chan MemberEvent, memberEventBuffer),
+	}
+
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	if h.stopped {
+		return nil, ErrMemberEventsStopped
+	}
+	if h.subscribers[networkID] == nil {
+		h.subscribers[networkID] = make(map[*MemberEventSubscription]struct{})
+	}
+	h.subscribers[networkID][subscription] = struct{}{}
+	h.pollNowLocked(networkID)
+	return subscription, nil
+}
+
+// Subscribers returns the number of open subscriptions to a network
+func (h *MemberEventHub) Subscribers(networkID string) int {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	return len(h.subscribers[networkID])
+}
+
+// Refresh schedules an immediate poll of a network the user can read. It does nothing when
+// the network has no subscribers.
+func (h *MemberEventHub) Refresh(networkID, userID string) error {
+	if _, err := h.networkService.authorizeMemberReadAccess(networkID, userID); err != nil {
+		return err
+	}
+
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	if h.stopped {
+		return ErrMemberEventsStopped
+	}
+	if h.schedules[networkID] != nil {
+		h.pollNowLocked(networkID)
+	}
+	return nil
+}
+
+// NotifyMemberChange shortens the poll interval of a network for a while after one of its
+// members was changed through Tairitsu
+func (h *MemberEventHub) NotifyMemberChange(networkID string) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	schedule := h.schedules[networkID]
+	if schedule == nil {
+		return
+	}
+	now := h.now()
+	schedule.boostUntil = now.Add(memberPollBoostDuration)
+	schedule.idlePolls = 0
+	h.applyIntervalLocked(schedule, now)
+	if next := now.Add(schedule.interval); next.Before(schedule.nextPoll) {
+		schedule.nextPoll = next
+		h.signalWake()
+	}
+}
+
+// PollSchedules lists the effective poll interval of every subscribed network
+func (h *MemberEventHub) PollSchedules() []MemberPollSchedule {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	schedules := make([]MemberPollSchedule, 0, len(h.schedules))
+	for networkID, schedule := range h.schedules {
+		entry := MemberPollSchedule{
+			NetworkID:       networkID,
+			IntervalSeconds: schedule.interval.Seconds(),
+			Reason:          schedule.reason,
+			NextPollAt:      schedule.nextPoll,
+			IdlePolls:       schedule.idlePolls,
+			Subscribers:     len(h.subscribers[networkID]),
+		}
+		if !schedule.lastPoll.IsZero() {
+			lastPoll := schedule.lastPoll
+			entry.LastPollAt = &lastPoll
+		}
+		schedules = append(schedules, entry)
+	}
+	sort.Slice(schedules, func(i, j int) bool {
+		return schedules[i].NetworkID < schedules[j].NetworkID
+	})
+	return schedules
+}
+
+// pollNowLocked makes a network due immediately. Callers hold h.mutex.
+func (h *MemberEventHub) pollNowLocked(networkID string) {
+	schedule := h.schedules[networkID]
+	if schedule == nil {
+		schedule = &memberPollSchedule{interval: h.interval}
+		h.schedules[networkID] = schedule
+	}
+	schedule.nextPoll = h.now()
+	schedule.reason = MemberPollReasonImmediate
+	h.signalWake()
+}
+
+func (h *MemberEventHub) signalWake() {
+	select {
+	case h.wake <- struct{}{}:
+	default:
+	}
+}
+
+// applyIntervalLocked derives the effective interval of a network from its recent activity.
+// Callers hold h.mutex.
+func (h *MemberEventHub) applyIntervalLocked(schedule *memberPollSchedule, now time.Time) {
+	fast := max(h.interval/memberPollFastDivisor, min(h.interval, time.Second))
+	switch {
+	case now.Before(schedule.boostUntil):
+		schedule.interval, schedule.reason = fast, MemberPollReasonMutation
+	case schedule.pending:
+		schedule.interval, schedule.reason = fast, MemberPollReasonPending
+	case schedule.idlePolls > memberPollIdleThreshold:
+		interval := h.interval
+		for i := memberPollIdleThreshold; i < schedule.idlePolls && interval < h.interval*memberPollMaxBackoff; i++ {
+			interval *= 2
+		}
+		schedule.interval, schedule.reason = min(interval, h.interval*memberPollMaxBackoff), MemberPollReasonIdle
+	default:
+		schedule.interval, schedule.reason = h.interval, MemberPollReasonBase
+	}
+}
+
+// Poll fetches the members of every subscribed network once and publishes the changes since
+// the previous poll. The first poll of a network only records its baseline.
+func (h *MemberEventHub) Poll() error {
+	return h.poll(false)
+}
+
+// PollDue polls the subscribed networks whose next scheduled poll has arrived
+func (h *MemberEventHub) PollDue() error {
+	return h.poll(true)
+}
+
+func (h *MemberEventHub) poll(dueOnly bool) error {
+	h.pollMutex.Lock()
+	defer h.pollMutex.Unlock()
+
+	client := h.networkService.getZTClient()
+	networkIDs := h.subscribedNetworks(dueOnly)
+	if client == nil || len(networkIDs) == 0 {
+		return nil
+	}
+
+	peerByAddress := map[string]zerotier.Peer{}
+	peers, err := client.GetPeers()
+	if err != nil {
+		logger.Warn("service: failed to get peer list; member events will use controller online state", zap.Error(err))
+	}
+	for _, peer := range peers {
+		if peer.Address != "" {
+			peerByAddress[peer.Address] = peer
+		}
+	}
+
+	var pollErr error
+	for _, networkID := range networkIDs {
+		h.closeUnauthorized(networkID)
+
+		members, err := client.GetMembers(networkID)
+		if err != nil {
+			pollErr = errors.Join(pollErr, fmt.Errorf("failed to get members of network %s: %w", networkID, err))
+			h.reschedule(networkID, false, false)
+			continue
+		}
+		current := make(map[string]zerotier.Member, len(members))
+		pending := false
+		for _, member := range members {
+			peer, isPeer := peerByAddress[member.Address]
+			enrichMemberPeerFields(&member, peer)
+			member.Online = member.Online || isPeer
+			current[member.ID] = member
+			pending = pending || !member.Authorized
+		}
+		changed := h.publish(networkID, current)
+		h.reschedule(networkID, changed, pending)
+	}
+	return pollErr
+}
+
+func (h *MemberEventHub) subscribedNetworks(dueOnly bool) []string {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	now := h.now()
+	networkIDs := make([]string, 0, len(h.subscribers))
+	for networkID := range h.subscribers {
+		if schedule := h.schedules[networkID]; dueOnly && schedule != nil && schedule.nextPoll.After(now) {
+			continue
+		}
+		networkIDs = append(networkIDs, networkID)
+	}
+	sort.Strings(networkIDs)
+	return networkIDs
+}
+
+// reschedule records a finished poll of a network and schedules the next one
+func (h *MemberEventHub) reschedule(networkID string, changed, pending bool) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	schedule := h.schedules[networkID]
+	if schedule == nil {
+		return
+	}
+	now := h.now()
+	schedule.lastPoll = now
+	schedule.pending = pending
+	if changed {
+		schedule.idlePolls = 0
+	} else {
+		schedule.idlePolls++
+	}
+	h.applyIntervalLocked(schedule, now)
+	schedule.nextPoll = now.Add(schedule.interval)
+}
+
+// closeUnauthorized ends subscriptions whose user lost read access since subscribing
+func (h *MemberEventHub) closeUnauthorized(networkID string) {
+	h.mutex.Lock()
+	subscriptions := make([]*MemberEventSubscription, 0, len(h.subscribers[networkID]))
+	for subscription := range h.subscribers[networkID] {
+		subscriptions = append(subscriptions, subscription)
+	}
+	h.mutex.Unlock()
+
+	for _, subscription := range subscriptions {
+		_, err := h.networkService.authorizeMemberReadAccess(networkID, subscription.userID)
+		if err == nil || !(IsNetworkAccessDenied(err) || IsNetworkNotFound(err)) {
+			continue
+		}
+		h.mutex.Lock()
+		h.removeLocked(subscription, err)
+		h.mutex.Unlock()
+	}
+}
+
+// publish diffs current against the stored snapshot and delivers the changes to subscribers.
+// It reports whether any member changed since the previous poll.
+func (h *MemberEventHub) publish(networkID string, current map[string]zerotier.Member) bool {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	if len(h.subscribers[networkID]) == 0 {
+		return false
+	}
+	previous, known := h.snapshots[networkID]
+	h.snapshots[networkID] = current
+	if !known {
+		return false
+	}
+
+	events := diffMembers(networkID, previous, current, h.now())
+	for subscription := range h.subscribers[networkID] {
+		for _, event := range events {
+			if event.Member != nil {
+				member := *event.Member
+				member.PreferredPath = maskPhysicalAddress(member.PreferredPath, subscription.policy)
+				event.Member = &member
+			}
+			select {
+			case subscription.events <- event:
+			default:
+				h.removeLocked(subscription, ErrMemberEventsLagging)
+			}
+			if subscription.closed {
+				break
+			}
+		}
+	}
+	return len(events) > 0
+}
+
+// diffMembers lists the events that turn previous into current
+func diffMembers(networkID string, previous, current map[string]zerotier.Member, now time.Time) []MemberEvent {
+	var events []MemberEvent
+	for memberID, member := range current {
+		event := MemberEvent{NetworkID: networkID, MemberID: memberID, Time: now, Member: &member}
+		before, existed := previous[memberID]
+		if !existed {
+			event.Type = MemberEventJoined
+			events = append(events, event)
+			continue
+		}
+		if before.Authorized != member.Authorized {
+			event.Type = MemberEventDeauthorized
+			if member.Authorized {
+				event.Type = MemberEventAuthorized
+			}
+			events = append(events, event)
+		}
+		if before.Online != member.Online {
+			event.Type = MemberEventOffline
+			if member.Online {
+				event.Type = MemberEventOnline
+			}
+			events = append(events, event)
+		}
+	}
+	for memberID := range previous {
+		if _, exists := current[memberID]; !exists {
+			events = append(events, MemberEvent{Type: MemberEventLeft, NetworkID: networkID, MemberID: memberID, Time: now})
+		}
+	}
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].MemberID < events[j].MemberID
+	})
+	return events
+}
+
+// removeLocked closes a subscription once, recording why. Callers hold h.mutex.
+func (h *MemberEventHub) removeLocked(subscription *MemberEventSubscription, reason error) {
+	if subscription.closed {
+		return
+	}
+	subscription.closed = true
+	subscription.closeErr = reason
+	close(subscription.events)
+
+	delete(h.subscribers[subscription.networkID], subscription)
+	if len(h.subscribers[subscription.networkID]) == 0 {
+		delete(h.subscribers, subscription.networkID)
+		delete(h.snapshots, subscription.networkID)
+		delete(h.schedules, subscription.networkID)
+	}
+}
+
+// Close ends every subscription with ErrMemberEventsStopped and refuses new ones, so open
+// event streams finish before the HTTP server waits for in-flight requests
+func (h *MemberEventHub) Close() {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	h.stopped = true
+	for _, subscriptions := range h.subscribers {
+		for subscription := range subscriptions {
+			h.removeLocked(subscription, ErrMemberEventsStopped)
+		}
+	}
+}
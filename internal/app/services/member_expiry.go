@@ -0,0 +1,176 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/GT-610/tairitsu/internal/app/logger"
+	"github.com/GT-610/tairitsu/internal/zerotier"
+	"go.uber.org/zap"
+)
+
+// AuditActionMemberAuthorizationExpired is recorded for every member deauthorized at its deadline
+const AuditActionMemberAuthorizationExpired = "member.authorization_expired"
+
+// MemberExpiryInterval is how often authorization deadlines are checked
+const MemberExpiryInterval = time.Minute
+
+// MemberExpiryRun describes one run of the authorization expiry job
+type MemberExpiryRun struct {
+	StartedAt  time.Time `json:"startedAt"`
+	FinishedAt time.Time `json:"finishedAt"`
+	Expired    int       `json:"expired"`
+	Failures   int       `json:"failures"`
+}
+
+// MemberExpiryStatus is the authorization expiry job view
+type MemberExpiryStatus struct {
+	IntervalSeconds int              `json:"intervalSeconds"`
+	NextRunAt       *time.Time       `json:"nextRunAt,omitempty"`
+	LastRun         *MemberExpiryRun `json:"lastRun,omitempty"`
+}
+
+// MemberExpiryService deauthorizes members whose authorization deadline has passed. A deadline
+// is only cleared once the member is deauthorized, so deadlines that passed while Tairitsu
+// was down are handled on the next run and failed ones are retried.
+type MemberExpiryService struct {
+	networkService *NetworkService
+	audit          *AuditService
+	interval       time.Duration
+
+	runMutex  sync.Mutex
+	mutex     sync.RWMutex
+	nextRunAt time.Time
+	lastRun   *MemberExpiryRun
+}
+
+// NewMemberExpiryService creates the authorization expiry job, running every interval
+func NewMemberExpiryService(networkService *NetworkService, audit *AuditService, interval time.Duration) *MemberExpiryService {
+	return &MemberExpiryService{networkService: networkService, audit: audit, interval: interval}
+}
+
+// Status returns the authorization expiry job view
+func (s *MemberExpiryService) Status() MemberExpiryStatus {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	status := MemberExpiryStatus{IntervalSeconds: int(s.interval / time.Second)}
+	if !s.nextRunAt.IsZero() {
+		next := s.nextRunAt
+		status.NextRunAt = &next
+	}
+	if s.lastRun != nil {
+		last := *s.lastRun
+		status.LastRun = &last
+	}
+	return status
+}
+
+// Run deauthorizes every member whose deadline is at or before now and returns the run
+func (s *MemberExpiryService) Run(now time.Time) (*MemberExpiryRun, error) {
+	db := s.networkService.getDB()
+	if db == nil {
+		return nil, fmt.Errorf("database is not initialized")
+	}
+
+	s.runMutex.Lock()
+	defer s.runMutex.Unlock()
+
+	expired, err := db.ListMemberMetadataExpiringBy(now)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read authorization deadlines: %w", err)
+	}
+
+	run := &MemberExpiryRun{StartedAt: time.Now()}
+	deauthorize := false
+	for _, metadata := range expired {
+		network, err := db.GetNetworkByID(metadata.NetworkID)
+		if err != nil {
+			logger.Warn("service: failed to read network of expired member", zap.String("network_id", metadata.NetworkID), zap.Error(err))
+			run.Failures++
+			continue
+		}
+		// Without the network or the member there is nobody left to deauthorize
+		if network != nil {
+			client, err := s.networkService.clientFor(network)
+			if err != nil {
+				logger.Warn("service: no controller for expired member", zap.String("network_id", network.ID), zap.Error(err))
+				run.Failures++
+				continue
+			}
+			_, err = client.UpdateMember(network.ID, metadata.MemberID, &zerotier.MemberUpdateRequest{Authorized: &deauthorize})
+			if err != nil && !zerotier.IsNotFound(err) {
+				logger.Warn("service: failed to deauthorize expired member", zap.String("network_id", network.ID), zap.String("member_id", metadata.MemberID), zap.Error(err))
+				run.Failures++
+				continue
+			}
+			if err == nil {
+				s.networkService.afterLockdownChange(network.ID, []string{metadata.MemberID}, false, auditSystemActor)
+			}
+		}
+
+		deadline := *metadata.AuthorizedUntil
+		metadata.AuthorizedUntil = nil
+		metadata.UpdatedBy = auditSystemActor
+		metadata.UpdatedAt = time.Now()
+		if err := db.SaveMemberMetadata(metadata); err != nil {
+			// The member is deauthorized; the next run repeats that harmlessly
+			logger.Warn("service: failed to clear authorization deadline", zap.String("network_id", metadata.NetworkID), zap.String("member_id", metadata.MemberID), zap.Error(err))
+			run.Failures++
+			continue
+		}
+		run.Expired++
+		s.recordAudit(metadata.NetworkID, metadata.MemberID, deadline)
+		logger.Info("service: member authorization expired", zap.String("network_id", metadata.NetworkID), zap.String("member_id", metadata.MemberID), zap.Time("deadline", deadline))
+	}
+	run.FinishedAt = time.Now()
+
+	s.mutex.Lock()
+	s.lastRun = run
+	s.mutex.Unlock()
+	return run, nil
+}
+
+func (s *MemberExpiryService) recordAudit(networkID, memberID string, deadline time.Time) {
+	if s.audit == nil {
+		return
+	}
+	if _, err := s.audit.Record(AuditEntryInput{
+		ActorID: auditSystemActor,
+		Action:  AuditActionMemberAuthorizationExpired,
+		Target:  networkID + "/" + memberID,
+		Details: "deadline=" + deadline.UTC().Format(time.RFC3339),
+	}); err != nil {
+		logger.Warn("failed to record authorization expiry audit entry", zap.String("network_id", networkID), zap.String("member_id", memberID), zap.Error(err))
+	}
+}
+
+// Start runs the expiry job right away, to catch deadlines that passed while Tairitsu was
+// down, and then every interval until ctx is cancelled
+func (s *MemberExpiryService) Start(ctx context.Context) <-chan struct{} {
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(s.interval)
+		defer ticker.Stop()
+		for {
+			s.setNextRun(time.Now().Add(s.interval))
+			if _, err := s.Run(time.Now()); err != nil {
+				logger.Warn("scheduled authorization expiry check failed", zap.Error(err))
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+	return done
+}
+
+func (s *MemberExpiryService) setNextRun(at time.Time) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.nextRunAt = at
+}
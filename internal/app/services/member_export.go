@@ -0,0 +1,217 @@
+package services
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/GT-610/tairitsu/internal/zerotier"
+)
+
+// Member export formats
+const (
+	MemberExportCSV  = "csv"
+	MemberExportJSON = "json"
+)
+
+// memberExportFlushEvery bounds how many rows are buffered before they are written out
+const memberExportFlushEvery = 500
+
+var ErrInvalidExportFormat = errors.New("format must be csv or json")
+
+// utf8BOM lets spreadsheet applications detect UTF-8 encoded CSV files
+var utf8BOM = []byte{0xEF, 0xBB, 0xBF}
+
+// memberExportColumns is the CSV header; JSON rows use the same names
+var memberExportColumns = []string{"address", "name", "authorized", "online", "ipAssignments", "lastSeen"}
+
+// MemberExportRow is one exported member
+type MemberExportRow struct {
+	Address       string   `json:"address"`
+	Name          string   `json:"name"`
+	Authorized    bool     `json:"authorized"`
+	Online        bool     `json:"online"`
+	IPAssignments []string `json:"ipAssignments"`
+	LastSeen      string   `json:"lastSeen,omitempty"` // RFC 3339; empty when the member was never recorded online
+}
+
+// MemberExportOptions adjusts the exported file
+type MemberExportOptions struct {
+	Format string
+	BOM    bool // Prefix CSV output with a UTF-8 byte order mark for Excel
+}
+
+// NewMemberExportRow converts a controller member to its exported form
+func NewMemberExportRow(member zerotier.Member) MemberExportRow {
+	address := member.Address
+	if address == "" {
+		address = member.ID
+	}
+	row := MemberExportRow{
+		Address:       address,
+		Name:          member.Name,
+		Authorized:    member.Authorized,
+		Online:        member.Online,
+		IPAssignments: member.IPAssignments,
+	}
+	if row.IPAssignments == nil {
+		row.IPAssignments = []string{}
+	}
+	if member.LastSeen > 0 {
+		row.LastSeen = time.UnixMilli(member.LastSeen).UTC().Format(time.RFC3339)
+	}
+	return row
+}
+
+// ExportNetworkMembers returns the members matching query in list order, for WriteMemberExport.
+// Online comes from the peer list and LastSeen from the member status history.
+func (s *NetworkService) ExportNetworkMembers(networkID string, userID string, query MemberListQuery) ([]zerotier.Member, error) {
+	query, err := query.normalized()
+	if err != nil {
+		return nil, err
+	}
+	members, err := s.listNetworkMembers(networkID, userID, query)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.attachLastSeen(networkID, members, time.Now()); err != nil {
+		return nil, err
+	}
+	return members, nil
+}
+
+// attachLastSeen sets LastSeen to now for online members and, for the others, to when they
+// were recorded going offline. Members never recorded online are left without one.
+func (s *NetworkService) attachLastSeen(networkID string, members []zerotier.Member, now time.Time) error {
+	db := s.getDB()
+	if db == nil {
+		return fmt.Errorf("database is not initialized")
+	}
+	latest, err := db.ListLatestMemberStatusEvents(networkID)
+	if err != nil {
+		return fmt.Errorf("failed to read member status history: %w", err)
+	}
+	wentOnline, err := db.ListLatestOnlineMemberStatusEvents(networkID)
+	if err != nil {
+		return fmt.Errorf("failed to read member status history: %w", err)
+	}
+	seen := make(map[string]bool, len(wentOnline))
+	for _, event := range wentOnline {
+		seen[event.MemberID] = true
+	}
+	// Only transitions are recorded, so a latest offline event of a member that was ever
+	// online is when it was last seen
+	wentOffline := make(map[string]time.Time, len(latest))
+	for _, event := range latest {
+		if !event.Online && seen[event.MemberID] {
+			wentOffline[event.MemberID] = event.ChangedAt
+		}
+	}
+
+	for i := range members {
+		members[i].LastSeen = 0
+		if members[i].Online {
+			members[i].LastSeen = now.UnixMilli()
+		} else if at, ok := wentOffline[members[i].ID]; ok {
+			members[i].LastSeen = at.UnixMilli()
+		}
+	}
+	return nil
+}
+
+// WriteMemberExport writes members in the requested format one row at a time, flushing w
+// periodically when it supports it so large exports are never held in memory as a whole
+func WriteMemberExport(w io.Writer, members []zerotier.Member, opts MemberExportOptions) error {
+	switch opts.Format {
+	case MemberExportCSV:
+		return writeMembersCSV(w, members, opts.BOM)
+	case MemberExportJSON:
+		return writeMembersJSON(w, members)
+	default:
+		return ErrInvalidExportFormat
+	}
+}
+
+func writeMembersCSV(w io.Writer, members []zerotier.Member, bom bool) error {
+	if bom {
+		if _, err := w.Write(utf8BOM); err != nil {
+			return err
+		}
+	}
+	writer := csv.NewWriter(w)
+	if err := writer.Write(memberExportColumns); err != nil {
+		return err
+	}
+	for i, member := range members {
+		row := NewMemberExportRow(member)
+		if err := writer.Write([]string{
+			row.Address,
+			csvSafeCell(row.Name),
+			strconv.FormatBool(row.Authorized),
+			strconv.FormatBool(row.Online),
+			strings.Join(row.IPAssignments, ";"),
+			row.LastSeen,
+		}); err != nil {
+			return err
+		}
+		if (i+1)%memberExportFlushEvery == 0 {
+			if err := flushExport(writer, w); err != nil {
+				return err
+			}
+		}
+	}
+	return flushExport(writer, w)
+}
+
+func writeMembersJSON(w io.Writer, members []zerotier.Member) error {
+	if _, err := io.WriteString(w, "["); err != nil {
+		return err
+	}
+	encoder := json.NewEncoder(w)
+	for i, member := range members {
+		if i > 0 {
+			if _, err := io.WriteString(w, ","); err != nil {
+				return err
+			}
+		}
+		if err := encoder.Encode(NewMemberExportRow(member)); err != nil {
+			return err
+		}
+		if (i+1)%memberExportFlushEvery == 0 {
+			if err := flushExport(nil, w); err != nil {
+				return err
+			}
+		}
+	}
+	if _, err := io.WriteString(w, "]\n"); err != nil {
+		return err
+	}
+	return flushExport(nil, w)
+}
+
+// flushExport pushes buffered rows through the CSV writer and then the underlying writer
+func flushExport(writer *csv.Writer, w io.Writer) error {
+	if writer != nil {
+		writer.Flush()
+		if err := writer.Error(); err != nil {
+			return err
+		}
+	}
+	if flusher, ok := w.(interface{ Flush() error }); ok {
+		return flusher.Flush()
+	}
+	return nil
+}
+
+// csvSafeCell keeps spreadsheet applications from evaluating user-controlled text as a formula
+func csvSafeCell(value string) string {
+	if value != "" && strings.ContainsRune("=+-@\t\r", rune(value[0])) {
+		return "'" + value
+	}
+	return value
+}
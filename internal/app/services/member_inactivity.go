@@ -0,0 +1,444 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/GT-610/tairitsu/internal/app/logger"
+	"github.com/GT-610/tairitsu/internal/app/models"
+	"github.com/GT-610/tairitsu/internal/zerotier"
+	"go.uber.org/zap"
+)
+
+const (
+	// AuditActionMemberInactiveFlagged is recorded when the inactivity job finds inactive members
+	AuditActionMemberInactiveFlagged = "member.inactive_flagged"
+	// AuditActionMemberInactiveDeauthorized is recorded when the inactivity job deauthorizes members
+	AuditActionMemberInactiveDeauthorized = "member.inactive_deauthorized"
+
+	// PinnedMemberTag is the metadata tag that exempts a member from inactivity policies when
+	// set to true
+	PinnedMemberTag = "pinned"
+
+	maxInactivityDays = 3650
+)
+
+var ErrInvalidInactivityPolicy = errors.New("invalid inactivity policy")
+
+// InactivityPolicy deauthorizes or flags members that have been offline for Days days.
+// Zero days disables the policy.
+type InactivityPolicy struct {
+	Days   int    `json:"days"`
+	Action string `json:"action"`
+}
+
+// NetworkPolicies are the automated policies Tairitsu applies to a network
+type NetworkPolicies struct {
+	NetworkID  string           `json:"networkId"`
+	Inactivity InactivityPolicy `json:"inactivity"`
+}
+
+// InactiveMember is an authorized member that was last online before the policy threshold.
+// LastOnline is when it was recorded going offline, or first recorded as offline.
+type InactiveMember struct {
+	MemberID     string    `json:"memberId"`
+	Name         string    `json:"name"`
+	LastOnline   time.Time `json:"lastOnline"`
+	InactiveDays int       `json:"inactiveDays"`
+}
+
+// InactivityReport lists the inactive members of a network and, unless it is a dry run of
+// an enforced policy, what was done with them
+type InactivityReport struct {
+	NetworkID    string                  `json:"networkId"`
+	Policy       InactivityPolicy        `json:"policy"`
+	DryRun       bool                    `json:"dryRun"`
+	CheckedAt    time.Time               `json:"checkedAt"`
+	Inactive     []InactiveMember        `json:"inactive"`
+	Deauthorized []string                `json:"deauthorized"`
+	Failures     []LockdownMemberFailure `json:"failures"`
+}
+
+func (p InactivityPolicy) validate() (InactivityPolicy, error) {
+	if p.Days < 0 || p.Days > maxInactivityDays {
+		return p, fmt.Errorf("%w: days must be between 0 and %d", ErrInvalidInactivityPolicy, maxInactivityDays)
+	}
+	switch p.Action {
+	case "":
+		p.Action = models.InactivityActionFlag
+	case models.InactivityActionFlag, models.InactivityActionEnforce:
+	default:
+		return p, fmt.Errorf("%w: action must be flag or enforce", ErrInvalidInactivityPolicy)
+	}
+	return p, nil
+}
+
+func networkInactivityPolicy(network *models.Network) InactivityPolicy {
+	action := network.InactivityAction
+	if action != models.InactivityActionEnforce {
+		action = models.InactivityActionFlag
+	}
+	return InactivityPolicy{Days: network.InactivityDays, Action: action}
+}
+
+// GetNetworkPolicies returns the policies of a network to anyone who can read its members
+func (s *NetworkService) GetNetworkPolicies(networkID string, userID string) (*NetworkPolicies, error) {
+	network, err := s.authorizeMemberReadAccess(networkID, userID)
+	if err != nil {
+		logger.Warn("service: no permission to read network policies", zap.String("network_id", networkID), zap.String("user_id", userID), zap.Error(err))
+		return nil, err
+	}
+	return &NetworkPolicies{NetworkID: network.ID, Inactivity: networkInactivityPolicy(network)}, nil
+}
+
+// UpdateNetworkPolicies replaces the policies of an owned network
+func (s *NetworkService) UpdateNetworkPolicies(networkID string, policies NetworkPolicies, userID string) (*NetworkPolicies, error) {
+	inactivity, err := policies.Inactivity.validate()
+	if err != nil {
+		return nil, err
+	}
+
+	network, err := s.authorizeOwnedNetwork(networkID, userID)
+	if err != nil {
+		logger.Warn("service: no permission to update network policies", zap.String("network_id", networkID), zap.String("user_id", userID), zap.Error(err))
+		return nil, err
+	}
+
+	network.InactivityDays = inactivity.Days
+	network.InactivityAction = inactivity.Action
+	network.UpdatedAt = time.Now()
+	if err := s.getDB().UpdateNetwork(network); err != nil {
+		logger.Error("service: failed to update network policies", zap.String("network_id", networkID), zap.Error(err))
+		return nil, err
+	}
+
+	return &NetworkPolicies{NetworkID: network.ID, Inactivity: inactivity}, nil
+}
+
+// PreviewInactivity reports which members of an owned network the inactivity policy would
+// deauthorize now, without changing them. A positive days overrides the saved threshold.
+func (s *NetworkService) PreviewInactivity(networkID string, days int, userID string) (*InactivityReport, error) {
+	network, err := s.authorizeMemberWriteAccess(networkID, userID)
+	if err != nil {
+		logger.Warn("service: no permission to preview inactivity policy", zap.String("network_id", networkID), zap.String("user_id", userID), zap.Error(err))
+		return nil, err
+	}
+
+	policy := networkInactivityPolicy(network)
+	if days != 0 {
+		policy.Days = days
+	}
+	if policy, err = policy.validate(); err != nil {
+		return nil, err
+	}
+
+	report := newInactivityReport(network.ID, policy, true, time.Now())
+	if policy.Days == 0 {
+		return report, nil
+	}
+	if report.Inactive, err = s.inactiveMembers(network, policy.Days, report.CheckedAt); err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+func newInactivityReport(networkID string, policy InactivityPolicy, dryRun bool, now time.Time) *InactivityReport {
+	return &InactivityReport{
+		NetworkID:    networkID,
+		Policy:       policy,
+		DryRun:       dryRun,
+		CheckedAt:    now,
+		Inactive:     []InactiveMember{},
+		Deauthorized: []string{},
+		Failures:     []LockdownMemberFailure{},
+	}
+}
+
+// inactiveMembers lists the authorized members that have been offline for days or more
+// before now. The controller does not report when a member was last online, so this is
+// judged from the member status history: a member counts from its latest recorded offline
+// transition. Current peers, pinned members and members without recorded history are left
+// alone.
+func (s *NetworkService) inactiveMembers(network *models.Network, days int, now time.Time) ([]InactiveMember, error) {
+	client, err := s.clientFor(network)
+	if err != nil {
+		return nil, err
+	}
+	members, err := client.GetMembers(network.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get members of network %s: %w", network.ID, err)
+	}
+	// Without the peer list an online member could be taken for an inactive one
+	peers, err := client.GetPeers()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get peers: %w", err)
+	}
+	online := make(map[string]bool, len(peers))
+	for _, peer := range peers {
+		online[peer.Address] = true
+	}
+	db := s.getDB()
+	if db == nil {
+		return nil, fmt.Errorf("database is not initialized")
+	}
+	latest, err := db.ListLatestMemberStatusEvents(network.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read member status history: %w", err)
+	}
+	lastEvents := make(map[string]*models.MemberStatusEvent, len(latest))
+	for _, event := range latest {
+		lastEvents[event.MemberID] = event
+	}
+	s.attachMemberMetadata(network.ID, members)
+
+	threshold := time.Duration(days) * 24 * time.Hour
+	inactive := []InactiveMember{}
+	for _, member := range members {
+		if !member.Config.Authorized || isPinnedMember(member) || online[member.Address] {
+			continue
+		}
+		event := lastEvents[member.ID]
+		if event == nil || event.Online {
+			continue
+		}
+		lastOnline := event.ChangedAt.UTC()
+		if offline := now.Sub(lastOnline); offline >= threshold {
+			inactive = append(inactive, InactiveMember{
+				MemberID:     member.ID,
+				Name:         member.Name,
+				LastOnline:   lastOnline,
+				InactiveDays: int(offline / (24 * time.Hour)),
+			})
+		}
+	}
+	sort.Slice(inactive, func(i, j int) bool { return inactive[i].MemberID < inactive[j].MemberID })
+	return inactive, nil
+}
+
+func isPinnedMember(member zerotier.Member) bool {
+	if member.Metadata == nil {
+		return false
+	}
+	pinned, _ := strconv.ParseBool(strings.TrimSpace(member.Metadata.Tags[PinnedMemberTag]))
+	return pinned
+}
+
+// InactivityCheckRun describes one run of the inactivity job
+type InactivityCheckRun struct {
+	StartedAt    time.Time `json:"startedAt"`
+	FinishedAt   time.Time `json:"finishedAt"`
+	Networks     int       `json:"networks"`
+	Flagged      int       `json:"flagged"`
+	Deauthorized int       `json:"deauthorized"`
+	Failures     int       `json:"failures"`
+}
+
+// InactivityCheckStatus is the inactivity job view
+type InactivityCheckStatus struct {
+	IntervalHours int                 `json:"intervalHours"`
+	NextRunAt     *time.Time          `json:"nextRunAt,omitempty"`
+	LastRun       *InactivityCheckRun `json:"lastRun,omitempty"`
+}
+
+// MemberInactivityService applies the inactivity policy of every network on a schedule
+type MemberInactivityService struct {
+	networkService *NetworkService
+	audit          *AuditService
+	interval       time.Duration
+
+	runMutex sync.Mutex
+	// flagged maps network ID to the members the last flagging run found inactive, so each
+	// member is audited once when it becomes inactive. Callers hold runMutex.
+	flagged   map[string]map[string]bool
+	mutex     sync.RWMutex
+	nextRunAt time.Time
+	lastRun   *InactivityCheckRun
+}
+
+// NewMemberInactivityService creates the inactivity job, running every interval
+func NewMemberInactivityService(networkService *NetworkService, audit *AuditService, interval time.Duration) *MemberInactivityService {
+	return &MemberInactivityService{
+		networkService: networkService,
+		audit:          audit,
+		interval:       interval,
+		flagged:        make(map[string]map[string]bool),
+	}
+}
+
+// Status returns the inactivity job view
+func (s *MemberInactivityService) Status() InactivityCheckStatus {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	status := InactivityCheckStatus{IntervalHours: int(s.interval / time.Hour)}
+	if !s.nextRunAt.IsZero() {
+		next := s.nextRunAt
+		status.NextRunAt = &next
+	}
+	if s.lastRun != nil {
+		last := *s.lastRun
+		status.LastRun = &last
+	}
+	return status
+}
+
+// Run applies every enabled inactivity policy once. A network that fails is logged and the
+// others still run.
+func (s *MemberInactivityService) Run(now time.Time) ([]*InactivityReport, error) {
+	db := s.networkService.getDB()
+	if db == nil {
+		return nil, fmt.Errorf("database is not initialized")
+	}
+	networks, err := db.GetAllNetworks()
+	if err != nil {
+		return nil, err
+	}
+
+	s.runMutex.Lock()
+	defer s.runMutex.Unlock()
+
+	run := &InactivityCheckRun{StartedAt: time.Now()}
+	reports := []*InactivityReport{}
+	for _, network := range networks {
+		if network.InactivityDays <= 0 {
+			continue
+		}
+		report, err := s.applyPolicy(network, now)
+		if err != nil {
+			logger.Warn("service: failed to apply inactivity policy", zap.String("network_id", network.ID), zap.Error(err))
+			continue
+		}
+		run.Networks++
+		run.Deauthorized += len(report.Deauthorized)
+		run.Failures += len(report.Failures)
+		if report.Policy.Action == models.InactivityActionFlag {
+			run.Flagged += len(report.Inactive)
+		}
+		reports = append(reports, report)
+	}
+	run.FinishedAt = time.Now()
+
+	s.mutex.Lock()
+	s.lastRun = run
+	s.mutex.Unlock()
+	return reports, nil
+}
+
+// applyPolicy flags or deauthorizes the inactive members of one network. Deauthorized members
+// are queued for approval again, so the owner can restore them from the approval queue.
+func (s *MemberInactivityService) applyPolicy(network *models.Network, now time.Time) (*InactivityReport, error) {
+	policy := networkInactivityPolicy(network)
+	report := newInactivityReport(network.ID, policy, false, now)
+	inactive, err := s.networkService.inactiveMembers(network, policy.Days, now)
+	if err != nil {
+		return nil, err
+	}
+	report.Inactive = inactive
+	if policy.Action != models.InactivityActionEnforce {
+		s.flagMembers(network.ID, policy, inactive)
+		return report, nil
+	}
+	delete(s.flagged, network.ID)
+	if len(inactive) == 0 {
+		return report, nil
+	}
+
+	memberIDs := make([]string, 0, len(inactive))
+	names := make(map[string]string, len(inactive))
+	for _, member := range inactive {
+		memberIDs = append(memberIDs, member.MemberID)
+		names[member.MemberID] = member.Name
+	}
+
+	client, err := s.networkService.clientFor(network)
+	if err != nil {
+		return nil, err
+	}
+	report.Deauthorized, report.Failures = setMembersAuthorized(client, network.ID, memberIDs, false)
+	s.networkService.afterLockdownChange(network.ID, report.Deauthorized, false, auditSystemActor)
+
+	if db := s.networkService.getDB(); db != nil {
+		for _, memberID := range report.Deauthorized {
+			// An earlier decision would keep the member out of the queue, so it is replaced
+			if err := db.DeletePendingApproval(network.ID, memberID); err != nil {
+				logger.Warn("service: failed to reset approval of inactive member", zap.String("network_id", network.ID), zap.String("member_id", memberID), zap.Error(err))
+				continue
+			}
+			approval := &models.PendingApproval{NetworkID: network.ID, MemberID: memberID, Name: names[memberID], Status: models.ApprovalPending, DetectedAt: now}
+			if _, err := db.CreatePendingApproval(approval); err != nil {
+				logger.Warn("service: failed to queue inactive member for approval", zap.String("network_id", network.ID), zap.String("member_id", memberID), zap.Error(err))
+			}
+		}
+	}
+	if len(report.Deauthorized) > 0 {
+		s.recordAudit(AuditActionMemberInactiveDeauthorized, network.ID, policy, report.Deauthorized)
+	}
+	logger.Info("service: deauthorized inactive members", zap.String("network_id", network.ID), zap.Int("deauthorized", len(report.Deauthorized)), zap.Int("failed", len(report.Failures)))
+	return report, nil
+}
+
+// flagMembers audits the inactive members of a network that were not inactive on the last
+// flagging run
+func (s *MemberInactivityService) flagMembers(networkID string, policy InactivityPolicy, inactive []InactiveMember) {
+	previous := s.flagged[networkID]
+	current := make(map[string]bool, len(inactive))
+	newlyInactive := []string{}
+	for _, member := range inactive {
+		current[member.MemberID] = true
+		if !previous[member.MemberID] {
+			newlyInactive = append(newlyInactive, member.MemberID)
+		}
+	}
+	s.flagged[networkID] = current
+	if len(newlyInactive) > 0 {
+		s.recordAudit(AuditActionMemberInactiveFlagged, networkID, policy, newlyInactive)
+	}
+}
+
+func (s *MemberInactivityService) recordAudit(action string, networkID string, policy InactivityPolicy, memberIDs []string) {
+	if s.audit == nil {
+		return
+	}
+	if _, err := s.audit.Record(AuditEntryInput{
+		ActorID: auditSystemActor,
+		Action:  action,
+		Target:  networkID,
+		Details: fmt.Sprintf("days=%d members=%s", policy.Days, strings.Join(memberIDs, ",")),
+	}); err != nil {
+		logger.Warn("failed to record inactivity audit entry", zap.String("network_id", networkID), zap.Error(err))
+	}
+}
+
+// Start runs the inactivity job every interval until ctx is cancelled
+func (s *MemberInactivityService) Start(ctx context.Context) <-chan struct{} {
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(s.interval)
+		defer ticker.Stop()
+		s.setNextRun(time.Now().Add(s.interval))
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				s.setNextRun(time.Now().Add(s.interval))
+				if _, err := s.Run(time.Now()); err != nil {
+					logger.Warn("scheduled inactivity check failed", zap.Error(err))
+				}
+			}
+		}
+	}()
+	return done
+}
+
+func (s *MemberInactivityService) setNextRun(at time.Time) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.nextRunAt = at
+}
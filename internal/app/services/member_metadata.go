@@ -0,0 +1,216 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/GT-610/tairitsu/internal/app/logger"
+	"github.com/GT-610/tairitsu/internal/app/models"
+	"github.com/GT-610/tairitsu/internal/zerotier"
+	"go.uber.org/zap"
+)
+
+const (
+	maxMemberDisplayNameLen = 128
+	maxMemberNotesLen       = 4096
+	maxMemberTags           = 32
+	maxMemberTagKeyLen      = 64
+	maxMemberTagValueLen    = 256
+)
+
+var (
+	ErrMemberNotFound        = errors.New("network member not found")
+	ErrInvalidMemberMetadata = errors.New("invalid member metadata")
+	ErrAuthorizedUntilPast   = errors.New("authorization deadline must be in the future")
+)
+
+// MemberMetadataUpdate changes the metadata of a member. Nil fields keep their value, and an
+// empty Tags object removes all tags.
+type MemberMetadataUpdate struct {
+	DisplayName *string           `json:"displayName"`
+	Notes       *string           `json:"notes"`
+	Tags        map[string]string `json:"tags"`
+}
+
+func (u MemberMetadataUpdate) validate() error {
+	if u.DisplayName != nil && utf8.RuneCountInString(*u.DisplayName) > maxMemberDisplayNameLen {
+		return fmt.Errorf("%w: display name must be %d characters or fewer", ErrInvalidMemberMetadata, maxMemberDisplayNameLen)
+	}
+	if u.Notes != nil && utf8.RuneCountInString(*u.Notes) > maxMemberNotesLen {
+		return fmt.Errorf("%w: notes must be %d characters or fewer", ErrInvalidMemberMetadata, maxMemberNotesLen)
+	}
+	if len(u.Tags) > maxMemberTags {
+		return fmt.Errorf("%w: at most %d tags are allowed", ErrInvalidMemberMetadata, maxMemberTags)
+	}
+	for key, value := range u.Tags {
+		if strings.TrimSpace(key) == "" || utf8.RuneCountInString(key) > maxMemberTagKeyLen {
+			return fmt.Errorf("%w: tag names must be 1 to %d characters", ErrInvalidMemberMetadata, maxMemberTagKeyLen)
+		}
+		if utf8.RuneCountInString(value) > maxMemberTagValueLen {
+			return fmt.Errorf("%w: tag %q must be %d characters or fewer", ErrInvalidMemberMetadata, key, maxMemberTagValueLen)
+		}
+	}
+	return nil
+}
+
+func toMemberMetadata(metadata *models.MemberMetadata) *zerotier.MemberMetadata {
+	result := &zerotier.MemberMetadata{
+		DisplayName: metadata.DisplayName,
+		Notes:       metadata.Notes,
+		Tags:        metadata.Tags,
+		UpdatedBy:   metadata.UpdatedBy,
+		UpdatedAt:   metadata.UpdatedAt,
+	}
+	if metadata.AuthorizedUntil != nil {
+		until := *metadata.AuthorizedUntil
+		remaining := max(int64(time.Until(until)/time.Second), 0)
+		result.AuthorizedUntil = &until
+		result.AuthorizationRemainingSeconds = &remaining
+	}
+	return result
+}
+
+// attachMemberMetadata merges the saved metadata into members. The members are still useful
+// without it, so a database failure is only logged.
+func (s *NetworkService) attachMemberMetadata(networkID string, members []zerotier.Member) {
+	db := s.getDB()
+	if db == nil || len(members) == 0 {
+		return
+	}
+	saved, err := db.ListMemberMetadata(networkID)
+	if err != nil {
+		logger.Warn("service: failed to load member metadata", zap.String("network_id", networkID), zap.Error(err))
+		return
+	}
+	byMember := make(map[string]*models.MemberMetadata, len(saved))
+	for _, metadata := range saved {
+		byMember[metadata.MemberID] = metadata
+	}
+	for index := range members {
+		if metadata, ok := byMember[members[index].ID]; ok {
+			members[index].Metadata = toMemberMetadata(metadata)
+		}
+	}
+}
+
+func (s *NetworkService) attachSingleMemberMetadata(networkID string, member *zerotier.Member) {
+	db := s.getDB()
+	if db == nil || member == nil {
+		return
+	}
+	metadata, err := db.GetMemberMetadata(networkID, member.ID)
+	if err != nil {
+		logger.Warn("service: failed to load member metadata", zap.String("network_id", networkID), zap.String("member_id", member.ID), zap.Error(err))
+		return
+	}
+	if metadata != nil {
+		member.Metadata = toMemberMetadata(metadata)
+	}
+}
+
+// UpdateMemberMetadata changes the metadata Tairitsu keeps about a member of the network
+func (s *NetworkService) UpdateMemberMetadata(networkID, memberID string, update MemberMetadataUpdate, userID string) (*zerotier.MemberMetadata, error) {
+	if err := update.validate(); err != nil {
+		return nil, err
+	}
+
+	network, err := s.authorizeMemberWriteAccess(networkID, userID)
+	if err != nil {
+		logger.Warn("service: no permission to update member metadata", zap.String("network_id", networkID), zap.String("user_id", userID), zap.Error(err))
+		return nil, err
+	}
+	db := s.getDB()
+	if db == nil {
+		return nil, fmt.Errorf("database is not initialized")
+	}
+	client, err := s.clientFor(network)
+	if err != nil {
+		return nil, err
+	}
+
+	// Only members the controller knows get metadata, so none is left behind for a typo
+	member, err := client.GetMember(networkID, memberID)
+	if zerotier.IsNotFound(err) {
+		return nil, ErrMemberNotFound
+	}
+	if err != nil {
+		logger.Error("service: failed to get network member", zap.String("network_id", networkID), zap.String("member_id", memberID), zap.Error(err))
+		return nil, err
+	}
+	if member == nil {
+		return nil, ErrMemberNotFound
+	}
+
+	metadata, err := db.GetMemberMetadata(networkID, memberID)
+	if err != nil {
+		return nil, err
+	}
+	if metadata == nil {
+		metadata = &models.MemberMetadata{NetworkID: networkID, MemberID: memberID}
+	}
+	if update.DisplayName != nil {
+		metadata.DisplayName = strings.TrimSpace(*update.DisplayName)
+	}
+	if update.Notes != nil {
+		metadata.Notes = *update.Notes
+	}
+	if update.Tags != nil {
+		metadata.Tags = make(map[string]string, len(update.Tags))
+		for key, value := range update.Tags {
+			metadata.Tags[strings.TrimSpace(key)] = strings.TrimSpace(value)
+		}
+	}
+	metadata.UpdatedBy = userID
+	metadata.UpdatedAt = time.Now()
+
+	if err := db.SaveMemberMetadata(metadata); err != nil {
+		logger.Error("service: failed to save member metadata", zap.String("network_id", networkID), zap.String("member_id", memberID), zap.Error(err))
+		return nil, err
+	}
+	s.notifyMemberChange(networkID)
+
+	return toMemberMetadata(metadata), nil
+}
+
+// SetMemberAuthorizedUntil sets when the expiry job deauthorizes a member. A nil deadline
+// removes it, and a later one extends the member's access.
+func (s *NetworkService) SetMemberAuthorizedUntil(networkID, memberID string, until *time.Time, userID string) (*zerotier.MemberMetadata, error) {
+	if until != nil && !until.After(time.Now()) {
+		return nil, ErrAuthorizedUntilPast
+	}
+	if _, err := s.authorizeMemberWriteAccess(networkID, userID); err != nil {
+		logger.Warn("service: no permission to set member authorization deadline", zap.String("network_id", networkID), zap.String("user_id", userID), zap.Error(err))
+		return nil, err
+	}
+	db := s.getDB()
+	if db == nil {
+		return nil, fmt.Errorf("database is not initialized")
+	}
+
+	metadata, err := db.GetMemberMetadata(networkID, memberID)
+	if err != nil {
+		return nil, err
+	}
+	if metadata == nil {
+		metadata = &models.MemberMetadata{NetworkID: networkID, MemberID: memberID}
+	}
+	if until != nil {
+		deadline := until.UTC()
+		metadata.AuthorizedUntil = &deadline
+	} else {
+		metadata.AuthorizedUntil = nil
+	}
+	metadata.UpdatedBy = userID
+	metadata.UpdatedAt = time.Now()
+
+	if err := db.SaveMemberMetadata(metadata); err != nil {
+		logger.Error("service: failed to save member authorization deadline", zap.String("network_id", networkID), zap.String("member_id", memberID), zap.Error(err))
+		return nil, err
+	}
+	s.notifyMemberChange(networkID)
+
+	return toMemberMetadata(metadata), nil
+}
@@ -0,0 +1,162 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/GT-610/tairitsu/internal/app/logger"
+	"github.com/GT-610/tairitsu/internal/app/models"
+	"go.uber.org/zap"
+)
+
+const maxMemberStatusBackoff = 10 * time.Minute
+
+// MemberStatusCollector samples member online status for every managed network and
+// records transitions in the member status history. Unauthorized members it sees are
+// queued for approval.
+type MemberStatusCollector struct {
+	networkService *NetworkService
+	interval       time.Duration
+	notifier       ApprovalNotifier
+	shipper        *LogShipper
+
+	mutex sync.Mutex
+	// lastKnown maps network ID to member ID to the last recorded online state
+	lastKnown map[string]map[string]bool
+}
+
+// NewMemberStatusCollector creates a collector polling at the given interval
+func NewMemberStatusCollector(networkService *NetworkService, interval time.Duration) *MemberStatusCollector {
+	return &MemberStatusCollector{
+		networkService: networkService,
+		interval:       interval,
+		lastKnown:      make(map[string]map[string]bool),
+	}
+}
+
+// SetApprovalNotifier sets who is told about newly pending members. Call it before Start.
+func (c *MemberStatusCollector) SetApprovalNotifier(notifier ApprovalNotifier) {
+	c.notifier = notifier
+}
+
+// SetLogShipper forwards every recorded status transition to shipper. Call it before Start.
+func (c *MemberStatusCollector) SetLogShipper(shipper *LogShipper) {
+	c.shipper = shipper
+}
+
+// Start polls until ctx is cancelled. Failed polls back off exponentially up to ten minutes.
+func (c *MemberStatusCollector) Start(ctx context.Context) <-chan struct{} {
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		delay := c.interval
+		timer := time.NewTimer(delay)
+		defer timer.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-timer.C:
+				if err := c.Poll(); err != nil {
+					delay = min(delay*2, max(maxMemberStatusBackoff, c.interval))
+					logger.Warn("member status poll failed; backing off", zap.Duration("retry_in", delay), zap.Error(err))
+				} else {
+					delay = c.interval
+				}
+				timer.Reset(delay)
+			}
+		}
+	}()
+	return done
+}
+
+// Poll samples every managed network once and stores any status transitions
+func (c *MemberStatusCollector) Poll() error {
+	db := c.networkService.getDB()
+	client := c.networkService.getZTClient()
+	if db == nil || client == nil {
+		return nil
+	}
+
+	networks, err := db.GetAllNetworks()
+	if err != nil {
+		return fmt.Errorf("failed to list networks: %w", err)
+	}
+	if len(networks) == 0 {
+		return nil
+	}
+
+	online := map[string]bool{}
+	peers, err := client.GetPeers()
+	if err != nil {
+		return fmt.Errorf("failed to get peers: %w", err)
+	}
+	for _, peer := range peers {
+		online[peer.Address] = true
+	}
+
+	now := time.Now()
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	for _, network := range networks {
+		members, err := client.GetMembers(network.ID)
+		if err != nil {
+			return fmt.Errorf("failed to get members of network %s: %w", network.ID, err)
+		}
+
+		known, err := c.knownStatuses(network.ID)
+		if err != nil {
+			return err
+		}
+		var events []*models.MemberStatusEvent
+		for _, member := range members {
+			isOnline := member.Online || online[member.Address]
+			if previous, seen := known[member.ID]; seen && previous == isOnline {
+				continue
+			}
+			events = append(events, &models.MemberStatusEvent{
+				NetworkID: network.ID,
+				MemberID:  member.ID,
+				Online:    isOnline,
+				ChangedAt: now,
+			})
+		}
+		if err := db.CreateMemberStatusEvents(events); err != nil {
+			return fmt.Errorf("failed to record member status history: %w", err)
+		}
+		for _, event := range events {
+			known[event.MemberID] = event.Online
+			action := MemberEventOffline
+			if event.Online {
+				action = MemberEventOnline
+			}
+			c.shipper.Ship(ShippedEvent{Time: event.ChangedAt, Kind: ShippedEventMember, Action: action, Target: event.NetworkID + "/" + event.MemberID})
+		}
+
+		if err := c.queuePendingMembers(db, network, members, now); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// knownStatuses returns the last recorded states of a network, loading them on first use
+// so a restart does not record a transition for every member. Callers hold c.mutex.
+func (c *MemberStatusCollector) knownStatuses(networkID string) (map[string]bool, error) {
+	if known, ok := c.lastKnown[networkID]; ok {
+		return known, nil
+	}
+
+	latest, err := c.networkService.getDB().ListLatestMemberStatusEvents(networkID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read member status history: %w", err)
+	}
+	known := make(map[string]bool, len(latest))
+	for _, event := range latest {
+		known[event.MemberID] = event.Online
+	}
+	c.lastKnown[networkID] = known
+	return known, nil
+}
@@ -0,0 +1,119 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/GT-610/tairitsu/internal/app/models"
+)
+
+const (
+	defaultMemberHistoryRange     = 7 * 24 * time.Hour
+	defaultMemberHistoryMaxPoints = 500
+	maxMemberHistoryPoints        = 5000
+)
+
+// ErrInvalidHistoryRange is returned when a history query ends before it starts
+var ErrInvalidHistoryRange = errors.New("history range end must not be before its start")
+
+// MemberStatusHistoryQuery selects a time range of member status history
+type MemberStatusHistoryQuery struct {
+	From      time.Time // Zero defaults to seven days before To
+	To        time.Time // Zero defaults to now
+	MaxPoints int       // Zero defaults to 500; capped at 5000
+}
+
+// MemberStatusPoint is an observed online/offline transition
+type MemberStatusPoint struct {
+	Time   time.Time `json:"time"`
+	Online bool      `json:"online"`
+}
+
+// MemberStatusHistory is the status timeline of a member within a time range
+type MemberStatusHistory struct {
+	NetworkID string    `json:"networkId"`
+	MemberID  string    `json:"memberId"`
+	From      time.Time `json:"from"`
+	To        time.Time `json:"to"`
+	// InitialOnline is the recorded state at From; nil when nothing was recorded before the range
+	InitialOnline *bool               `json:"initialOnline"`
+	Points        []MemberStatusPoint `json:"points"`
+	Downsampled   bool                `json:"downsampled"`
+}
+
+// GetMemberStatusHistory returns recorded status transitions of a member, downsampled to at most query.MaxPoints
+func (s *NetworkService) GetMemberStatusHistory(networkID, memberID, userID string, query MemberStatusHistoryQuery) (*MemberStatusHistory, error) {
+	if _, err := s.authorizeMemberReadAccess(networkID, userID); err != nil {
+		return nil, err
+	}
+
+	to := query.To
+	if to.IsZero() {
+		to = time.Now()
+	}
+	from := query.From
+	if from.IsZero() {
+		from = to.Add(-defaultMemberHistoryRange)
+	}
+	if to.Before(from) {
+		return nil, ErrInvalidHistoryRange
+	}
+	maxPoints := query.MaxPoints
+	if maxPoints <= 0 {
+		maxPoints = defaultMemberHistoryMaxPoints
+	}
+	maxPoints = min(maxPoints, maxMemberHistoryPoints)
+
+	db := s.getDB()
+	events, err := db.ListMemberStatusEvents(networkID, memberID, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read member status history: %w", err)
+	}
+	previous, err := db.GetMemberStatusEventBefore(networkID, memberID, from)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read member status history: %w", err)
+	}
+
+	history := &MemberStatusHistory{
+		NetworkID: networkID,
+		MemberID:  memberID,
+		From:      from,
+		To:        to,
+		Points:    downsampleMemberStatus(events, from, to, maxPoints),
+	}
+	history.Downsampled = len(history.Points) < len(events)
+	if previous != nil {
+		history.InitialOnline = &previous.Online
+	}
+	return history, nil
+}
+
+// downsampleMemberStatus splits [from, to] into maxPoints equal buckets and keeps the last
+// transition of each bucket, so the state at every bucket boundary stays accurate
+func downsampleMemberStatus(events []*models.MemberStatusEvent, from, to time.Time, maxPoints int) []MemberStatusPoint {
+	points := make([]MemberStatusPoint, 0, min(len(events), maxPoints))
+	if len(events) <= maxPoints {
+		for _, event := range events {
+			points = append(points, MemberStatusPoint{Time: event.ChangedAt, Online: event.Online})
+		}
+		return points
+	}
+
+	bucketWidth := to.Sub(from) / time.Duration(maxPoints)
+	if bucketWidth <= 0 {
+		last := events[len(events)-1]
+		return append(points, MemberStatusPoint{Time: last.ChangedAt, Online: last.Online})
+	}
+	for i, event := range events {
+		bucket := min(int(event.ChangedAt.Sub(from)/bucketWidth), maxPoints-1)
+		if i+1 < len(events) {
+			next := min(int(events[i+1].ChangedAt.Sub(from)/bucketWidth), maxPoints-1)
+			if next == bucket {
+				continue
+			}
+		}
+		points = append(points, MemberStatusPoint{Time: event.ChangedAt, Online: event.Online})
+	}
+	return points
+}
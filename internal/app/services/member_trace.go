@@ -0,0 +1,27 @@
+package services
+
+import (
+	"github.com/GT-610/tairitsu/internal/app/models"
+)
+
+// GetMemberTraceEvents returns the most recent parsed controller trace decisions about a member, newest first
+func (s *NetworkService) GetMemberTraceEvents(networkID, memberID, userID string, limit int) ([]*models.ControllerTraceEvent, error) {
+	if _, err := s.authorizeMemberReadAccess(networkID, userID); err != nil {
+		return nil, err
+	}
+	db := s.getDB()
+	if db == nil {
+		return nil, ErrUserDBUnavailable
+	}
+
+	if limit <= 0 {
+		limit = defaultControllerTraceLimit
+	}
+	parsed := true
+	return db.QueryControllerTraceEvents(models.ControllerTraceQuery{
+		NetworkID: networkID,
+		MemberID:  memberID,
+		Parsed:    &parsed,
+		Limit:     min(limit, maxControllerTraceLimit),
+	})
+}
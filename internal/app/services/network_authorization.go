@@ -34,18 +34,99 @@ func (s *NetworkService) getNetwork(networkID string) (*models.Network, error) {
 	return network, nil
 }
 
+// getOwnedNetwork returns a network userID controls: as its owner or as an administrator of
+// its organization
 func (s *NetworkService) getOwnedNetwork(networkID, userID string) (*models.Network, error) {
 	network, err := s.getNetwork(networkID)
 	if err != nil {
 		return nil, err
 	}
-	if network.OwnerID != userID {
+	if network.OwnerID == userID {
+		return network, nil
+	}
+
+	role, err := s.organizationRole(network, userID)
+	if err != nil {
+		return nil, err
+	}
+	if role != models.OrganizationRoleAdmin {
 		return nil, ErrNetworkAccessDenied
 	}
+	return network, nil
+}
+
+// canWriteNetwork reports whether userID controls a network, as its owner or as an
+// administrator of its organization
+func (s *NetworkService) canWriteNetwork(networkID, userID string) (bool, error) {
+	_, err := s.getOwnedNetwork(networkID, userID)
+	switch {
+	case err == nil:
+		return true, nil
+	case IsNetworkAccessDenied(err):
+		return false, nil
+	}
+	return false, err
+}
 
+// authorizeNetworkReadAccess allows the owner, members of the network's organization and
+// administrators to read a network
+func (s *NetworkService) authorizeNetworkReadAccess(networkID, userID string) (*models.Network, error) {
+	network, err := s.getNetwork(networkID)
+	if err != nil {
+		return nil, err
+	}
+	allowed, err := s.canReadNetwork(network, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !allowed {
+		return nil, ErrNetworkAccessDenied
+	}
 	return network, nil
 }
 
+// canReadNetwork reports whether userID owns network, belongs to its organization or is an
+// administrator
+func (s *NetworkService) canReadNetwork(network *models.Network, userID string) (bool, error) {
+	if network.OwnerID == userID {
+		return true, nil
+	}
+	role, err := s.organizationRole(network, userID)
+	if err != nil || role != "" {
+		return role != "", err
+	}
+	return s.isAdministrator(userID)
+}
+
+// organizationRole returns userID's role in the organization of network, or "" without one
+func (s *NetworkService) organizationRole(network *models.Network, userID string) (string, error) {
+	db := s.getDB()
+	if db == nil {
+		return "", errors.New("database is not initialized")
+	}
+	member, err := db.GetOrganizationMember(organizationIDOf(network), userID)
+	if err != nil {
+		return "", err
+	}
+	if member == nil {
+		return "", nil
+	}
+	return member.Role, nil
+}
+
+// isAdministrator reports whether userID is a global administrator
+func (s *NetworkService) isAdministrator(userID string) (bool, error) {
+	db := s.getDB()
+	if db == nil {
+		return false, errors.New("database is not initialized")
+	}
+	user, err := db.GetUserByID(userID)
+	if err != nil {
+		return false, err
+	}
+	return user != nil && user.Role == "admin", nil
+}
+
 func (s *NetworkService) authorizeOwnedNetwork(networkID, userID string) (*models.Network, error) {
 	return s.getOwnedNetwork(networkID, userID)
 }
@@ -55,7 +136,11 @@ func (s *NetworkService) authorizeMemberReadAccess(networkID, userID string) (*m
 	if err != nil {
 		return nil, err
 	}
-	if network.OwnerID == userID {
+	allowed, err := s.canReadNetwork(network, userID)
+	if err != nil {
+		return nil, err
+	}
+	if allowed {
 		return network, nil
 	}
 
@@ -97,6 +182,17 @@ func (s *NetworkService) authorizeViewerManagement(networkID, userID string) (*m
 	return network, nil
 }
 
+// AuthorizeTokenDelegation checks that userID may read, or with write manage members of, a network
+func (s *NetworkService) AuthorizeTokenDelegation(networkID, userID string, write bool) error {
+	var err error
+	if write {
+		_, err = s.authorizeMemberWriteAccess(networkID, userID)
+	} else {
+		_, err = s.authorizeMemberReadAccess(networkID, userID)
+	}
+	return err
+}
+
 func authorizeImport(actorRole, ownerID string) error {
 	if actorRole != "admin" {
 		return ErrImportAccessDenied
@@ -0,0 +1,320 @@
+package services
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/GT-610/tairitsu/internal/app/logger"
+	"github.com/GT-610/tairitsu/internal/zerotier"
+	"go.uber.org/zap"
+)
+
+// NetworkBackupVersion is the document version written by ExportNetwork
+const NetworkBackupVersion = 1
+
+// Member restore failure reasons
+const (
+	RestoreReasonInvalidAddress  = "invalid_address"
+	RestoreReasonDuplicate       = "duplicate_member"
+	RestoreReasonControllerError = "controller_error"
+)
+
+var (
+	ErrInvalidNetworkBackup       = errors.New("network backup document is invalid")
+	ErrUnsupportedBackupVersion   = errors.New("network backup version is not supported")
+	ErrNetworkRestoreConfigFailed = errors.New("failed to apply the backed up network configuration")
+)
+
+// NetworkBackup is a versioned, self-contained copy of a network's configuration and members
+type NetworkBackup struct {
+	Version    int                   `json:"version"`
+	ExportedAt time.Time             `json:"exportedAt"`
+	SourceID   string                `json:"sourceId,omitempty"`
+	Network    NetworkBackupNetwork  `json:"network"`
+	Members    []NetworkBackupMember `json:"members"`
+}
+
+// NetworkBackupNetwork holds the restorable network settings
+type NetworkBackupNetwork struct {
+	Name        string                 `json:"name"`
+	Description string                 `json:"description"`
+	Config      zerotier.NetworkConfig `json:"config"`
+}
+
+// NetworkBackupMember holds the restorable settings of one member. Member identities live on
+// the nodes themselves, so a restore re-authorizes the same node IDs instead of re-creating them.
+type NetworkBackupMember struct {
+	Address         string   `json:"address"`
+	Name            string   `json:"name"`
+	Authorized      bool     `json:"authorized"`
+	ActiveBridge    bool     `json:"activeBridge"`
+	IPAssignments   []string `json:"ipAssignments"`
+	NoAutoAssignIPs bool     `json:"noAutoAssignIps"`
+}
+
+// NetworkRestoreMemberFailure reports a member that could not be restored
+type NetworkRestoreMemberFailure struct {
+	Address       string `json:"address"`
+	ReasonCode    string `json:"reasonCode"`
+	ReasonMessage string `json:"reasonMessage"`
+}
+
+// NetworkRestoreResult describes the network created by RestoreNetwork
+type NetworkRestoreResult struct {
+	Network         *zerotier.Network             `json:"network"`
+	RestoredMembers int                           `json:"restoredMembers"`
+	FailedMembers   []NetworkRestoreMemberFailure `json:"failedMembers"`
+}
+
+// ExportNetwork assembles a backup document of a network owned by userID
+func (s *NetworkService) ExportNetwork(networkID string, userID string) (*NetworkBackup, error) {
+	ownedNetwork, err := s.authorizeOwnedNetwork(networkID, userID)
+	if err != nil {
+		logger.Warn("service: no permission to back up network", zap.String("network_id", networkID), zap.String("user_id", userID), zap.Error(err))
+		return nil, err
+	}
+	client, err := s.clientFor(ownedNetwork)
+	if err != nil {
+		return nil, err
+	}
+
+	backup, err := buildNetworkBackup(client, networkID, ownedNetwork.Description)
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Info("service: network backup exported", zap.String("network_id", networkID), zap.Int("member_count", len(backup.Members)))
+	return backup, nil
+}
+
+// buildNetworkBackup reads a network and its members from the controller
+func buildNetworkBackup(client *zerotier.Client, networkID string, description string) (*NetworkBackup, error) {
+	network, err := client.GetNetwork(networkID)
+	if err != nil {
+		logger.Error("service: failed to read network for backup", zap.String("network_id", networkID), zap.Error(err))
+		return nil, err
+	}
+	members, err := client.GetMembers(networkID)
+	if err != nil {
+		logger.Error("service: failed to read network members for backup", zap.String("network_id", networkID), zap.Error(err))
+		return nil, err
+	}
+
+	backup := &NetworkBackup{
+		Version:    NetworkBackupVersion,
+		ExportedAt: time.Now().UTC(),
+		SourceID:   networkID,
+		Network: NetworkBackupNetwork{
+			Name:        network.Name,
+			Description: description,
+			Config:      network.Config,
+		},
+		Members: make([]NetworkBackupMember, 0, len(members)),
+	}
+	for _, member := range members {
+		address := member.Address
+		if address == "" {
+			address = member.ID
+		}
+		ipAssignments := member.IPAssignments
+		if ipAssignments == nil {
+			ipAssignments = []string{}
+		}
+		backup.Members = append(backup.Members, NetworkBackupMember{
+			Address:         address,
+			Name:            member.Name,
+			Authorized:      member.Authorized,
+			ActiveBridge:    member.ActiveBridge,
+			IPAssignments:   ipAssignments,
+			NoAutoAssignIPs: member.NoAutoAssignIPs,
+		})
+	}
+	return backup, nil
+}
+
+// RestoreNetwork creates a new network owned by ownerID on the named controller from a backup
+// document. The network configuration must apply for the restore to succeed; members are
+// restored one by one and the ones the controller rejects are reported in the result instead
+// of failing the restore.
+func (s *NetworkService) RestoreNetwork(controller string, backup *NetworkBackup, ownerID string) (*NetworkRestoreResult, error) {
+	if backup == nil {
+		return nil, ErrInvalidNetworkBackup
+	}
+	if backup.Version != NetworkBackupVersion {
+		return nil, fmt.Errorf("%w: %d", ErrUnsupportedBackupVersion, backup.Version)
+	}
+
+	created, err := s.CreateNetwork(controller, &zerotier.Network{
+		Name:        backup.Network.Name,
+		Description: backup.Network.Description,
+		Config:      backup.Network.Config,
+	}, ownerID)
+	if err != nil {
+		return nil, err
+	}
+	// CreateNetwork already resolved the controller
+	client, _ := s.controllers.Client(controller)
+
+	// The controller API takes flat settings, so the configuration is applied through the
+	// same partial update the network settings page uses
+	restored, err := client.PartialUpdateNetwork(created.ID, networkUpdateFromBackup(backup))
+	if err != nil {
+		logger.Error("service: failed to apply restored network configuration", zap.String("network_id", created.ID), zap.Error(err))
+		if delErr := s.DeleteNetwork(created.ID, ownerID); delErr != nil {
+			logger.Error("service: failed to roll back restored network", zap.String("network_id", created.ID), zap.Error(delErr))
+		}
+		return nil, fmt.Errorf("%w: %v", ErrNetworkRestoreConfigFailed, err)
+	}
+
+	result := &NetworkRestoreResult{Network: restored}
+	result.RestoredMembers, result.FailedMembers = s.restoreNetworkMembers(client, created.ID, backup.Members)
+
+	logger.Info("service: network restored from backup",
+		zap.String("network_id", created.ID),
+		zap.String("source_id", backup.SourceID),
+		zap.Int("restored_members", result.RestoredMembers),
+		zap.Int("failed_members", len(result.FailedMembers)))
+	return result, nil
+}
+
+// ExportControllerNetworks backs up every network on the default controller, for system backups
+func (s *NetworkService) ExportControllerNetworks() ([]NetworkBackup, error) {
+	client := s.getZTClient()
+	if client == nil {
+		logger.Warn("service: ZeroTier client is not initialized")
+		return nil, fmt.Errorf("ZeroTier client is not initialized")
+	}
+
+	descriptions := map[string]string{}
+	if db := s.getDB(); db != nil {
+		networks, err := db.GetAllNetworks()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read networks: %w", err)
+		}
+		for _, network := range networks {
+			descriptions[network.ID] = network.Description
+		}
+	}
+
+	networkIDs, err := client.GetNetworkIDs()
+	if err != nil {
+		logger.Error("service: failed to list controller networks for backup", zap.Error(err))
+		return nil, err
+	}
+	backups := make([]NetworkBackup, 0, len(networkIDs))
+	for _, networkID := range networkIDs {
+		backup, err := buildNetworkBackup(client, networkID, descriptions[networkID])
+		if err != nil {
+			return nil, err
+		}
+		backups = append(backups, *backup)
+	}
+	return backups, nil
+}
+
+// RestoreControllerNetwork writes a backed up network back under its original ID, creating it
+// when the controller no longer has it, then re-adds its members
+func (s *NetworkService) RestoreControllerNetwork(backup *NetworkBackup) (int, []NetworkRestoreMemberFailure, error) {
+	client := s.getZTClient()
+	if client == nil {
+		logger.Warn("service: ZeroTier client is not initialized")
+		return 0, nil, fmt.Errorf("ZeroTier client is not initialized")
+	}
+	if backup == nil || len(backup.SourceID) != 16 {
+		return 0, nil, ErrInvalidNetworkBackup
+	}
+	if _, err := hex.DecodeString(backup.SourceID); err != nil {
+		return 0, nil, ErrInvalidNetworkBackup
+	}
+
+	if _, err := client.PartialUpdateNetwork(backup.SourceID, networkUpdateFromBackup(backup)); err != nil {
+		logger.Error("service: failed to restore controller network", zap.String("network_id", backup.SourceID), zap.Error(err))
+		return 0, nil, fmt.Errorf("%w: %v", ErrNetworkRestoreConfigFailed, err)
+	}
+	restored, failures := s.restoreNetworkMembers(client, backup.SourceID, backup.Members)
+	return restored, failures, nil
+}
+
+// networkUpdateFromBackup converts backed up settings to a partial network update
+func networkUpdateFromBackup(backup *NetworkBackup) *zerotier.NetworkUpdateRequest {
+	config := backup.Network.Config
+	updateReq := NormalizeNetworkUpdateRequest(&zerotier.NetworkUpdateRequest{
+		Name:              backup.Network.Name,
+		EnableBroadcast:   &config.EnableBroadcast,
+		IpAssignmentPools: config.IpAssignmentPools,
+		Routes:            config.Routes,
+		DNS:               &config.DNS,
+		V4AssignMode:      &config.V4AssignMode,
+		V6AssignMode:      &config.V6AssignMode,
+	})
+	if config.Mtu > 0 {
+		updateReq.Mtu = &config.Mtu
+	}
+	if config.MulticastLimit > 0 {
+		updateReq.MulticastLimit = &config.MulticastLimit
+	}
+	return updateReq
+}
+
+// restoreNetworkMembers re-adds backed up members by node ID, reporting the ones that fail
+func (s *NetworkService) restoreNetworkMembers(client *zerotier.Client, networkID string, members []NetworkBackupMember) (int, []NetworkRestoreMemberFailure) {
+	restored := 0
+	failures := []NetworkRestoreMemberFailure{}
+	seen := make(map[string]struct{}, len(members))
+	for _, member := range members {
+		address := strings.ToLower(strings.TrimSpace(member.Address))
+		if !looksLikeMemberAddress(address) {
+			failures = append(failures, NetworkRestoreMemberFailure{
+				Address:       member.Address,
+				ReasonCode:    RestoreReasonInvalidAddress,
+				ReasonMessage: "member address must be 10 hexadecimal characters",
+			})
+			continue
+		}
+		if _, duplicate := seen[address]; duplicate {
+			failures = append(failures, NetworkRestoreMemberFailure{
+				Address:       address,
+				ReasonCode:    RestoreReasonDuplicate,
+				ReasonMessage: "member appears more than once in the backup",
+			})
+			continue
+		}
+		seen[address] = struct{}{}
+
+		authorized := member.Authorized
+		activeBridge := member.ActiveBridge
+		noAutoAssignIPs := member.NoAutoAssignIPs
+		if _, err := client.UpdateMember(networkID, address, &zerotier.MemberUpdateRequest{
+			Name:            member.Name,
+			Authorized:      &authorized,
+			ActiveBridge:    &activeBridge,
+			IPAssignments:   member.IPAssignments,
+			NoAutoAssignIPs: &noAutoAssignIPs,
+		}); err != nil {
+			logger.Warn("service: failed to restore network member", zap.String("network_id", networkID), zap.String("member_id", address), zap.Error(err))
+			failures = append(failures, NetworkRestoreMemberFailure{
+				Address:       address,
+				ReasonCode:    RestoreReasonControllerError,
+				ReasonMessage: err.Error(),
+			})
+			continue
+		}
+		restored++
+	}
+	if restored > 0 {
+		s.invalidateMemberStats(networkID)
+		s.notifyMemberChange(networkID)
+	}
+	return restored, failures
+}
+
+func looksLikeMemberAddress(address string) bool {
+	if len(address) != 10 {
+		return false
+	}
+	_, err := hex.DecodeString(address)
+	return err == nil
+}
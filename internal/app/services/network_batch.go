@@ -0,0 +1,272 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/GT-610/tairitsu/internal/app/logger"
+	"github.com/GT-610/tairitsu/internal/app/models"
+	"github.com/GT-610/tairitsu/internal/zerotier"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+const (
+	// MaxNetworkBatchSize bounds how many networks one batch creates
+	MaxNetworkBatchSize = 100
+	// NetworkBatchIndexPlaceholder is replaced by the 1-based index of each network in a
+	// batch name pattern
+	NetworkBatchIndexPlaceholder = "{index}"
+
+	networkBatchConcurrency = 4
+)
+
+// ErrIdempotencyKeyReused is returned when a key is submitted again with a different request
+var ErrIdempotencyKeyReused = errors.New("idempotency key was already used for a different request")
+
+// NetworkBatchRequest describes networks to create with the same shape
+type NetworkBatchRequest struct {
+	Count       int    `json:"count"`
+	NamePattern string `json:"namePattern"`
+	Description string `json:"description,omitempty"`
+	Controller  string `json:"controller,omitempty"`
+	// Config is applied to every network once it is created, as by PUT /api/networks/:id;
+	// its name and description are ignored
+	Config *zerotier.NetworkUpdateRequest `json:"config,omitempty"`
+	// IdempotencyKey identifies the batch for retries; one is generated when empty
+	IdempotencyKey string `json:"idempotencyKey,omitempty"`
+}
+
+// NetworkBatchResult is the result of a network batch
+type NetworkBatchResult struct {
+	// NetworkIDs lists the networks created so far in index order
+	NetworkIDs []string `json:"networkIds"`
+}
+
+// NetworkBatchNames returns the network names of a batch: pattern with the placeholder
+// replaced by each index, zero-padded to the width of count so the names sort in order
+func NetworkBatchNames(pattern string, count int) []string {
+	width := len(strconv.Itoa(count))
+	names := make([]string, 0, max(count, 0))
+	for index := 1; index <= count; index++ {
+		names = append(names, strings.ReplaceAll(pattern, NetworkBatchIndexPlaceholder, fmt.Sprintf("%0*d", width, index)))
+	}
+	return names
+}
+
+// StartNetworkBatch submits an operation that creates the networks of req, a few at a
+// time. Submitting the same idempotency key again returns that operation and, unless it is
+// pending, running or succeeded, retries its items that were not created; a network that
+// was created is never created again.
+func (s *NetworkService) StartNetworkBatch(req NetworkBatchRequest, userID string) (*OperationDetail, error) {
+	db := s.getDB()
+	if db == nil {
+		logger.Warn("service: database is not initialized")
+		return nil, fmt.Errorf("database is not initialized")
+	}
+	operations := s.getOperationManager()
+	if operations == nil {
+		return nil, fmt.Errorf("operations are not available")
+	}
+	controller, err := s.controllers.Resolve(req.Controller)
+	if err != nil {
+		return nil, err
+	}
+	req.Controller = controller
+	if req.IdempotencyKey == "" {
+		req.IdempotencyKey = uuid.New().String()
+	}
+	request, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+	run := func(ctx context.Context, progress *OperationProgress) (any, error) {
+		return s.runNetworkBatch(ctx, progress, req)
+	}
+
+	s.batchMutex.Lock()
+	defer s.batchMutex.Unlock()
+
+	operation, err := db.GetOperationByIdempotencyKey(userID, req.IdempotencyKey)
+	if err != nil {
+		logger.Error("service: failed to look up operation", zap.String("user_id", userID), zap.Error(err))
+		return nil, err
+	}
+	if operation == nil {
+		operation = &models.Operation{
+			Kind:           models.OperationKindNetworkBatch,
+			UserID:         userID,
+			IdempotencyKey: req.IdempotencyKey,
+			Total:          req.Count,
+			Request:        string(request),
+		}
+		names := NetworkBatchNames(req.NamePattern, req.Count)
+		items := make([]*models.OperationItem, 0, len(names))
+		for i, name := range names {
+			items = append(items, &models.OperationItem{Index: i + 1, Name: name, Status: models.OperationItemPending})
+		}
+		if err := operations.Submit(operation, items, run); err != nil {
+			return nil, err
+		}
+		return operations.Get(operation.ID, userID, true)
+	}
+
+	if operation.Kind != models.OperationKindNetworkBatch || operation.Request != string(request) {
+		return nil, ErrIdempotencyKeyReused
+	}
+	if operations.Active(operation.ID) || operation.Status == models.OperationStatusSucceeded {
+		return operations.Get(operation.ID, userID, true)
+	}
+
+	items, err := db.ListOperationItems(operation.ID)
+	if err != nil {
+		return nil, err
+	}
+	// Items left pending were running when the process stopped; their network may exist
+	s.adoptBatchNetworks(operation, items)
+	for _, item := range items {
+		if item.Status == models.OperationItemFailed {
+			item.Status = models.OperationItemPending
+			item.Error = ""
+			if err := db.SaveOperationItem(item); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if err := operations.Resubmit(operation, run); err != nil {
+		return nil, err
+	}
+	return operations.Get(operation.ID, userID, true)
+}
+
+// runNetworkBatch creates the pending networks of a batch and returns the IDs of every
+// network it has created. Items still pending when ctx is cancelled stay pending.
+func (s *NetworkService) runNetworkBatch(ctx context.Context, progress *OperationProgress, req NetworkBatchRequest) (any, error) {
+	db := s.getDB()
+	if db == nil {
+		return nil, fmt.Errorf("database is not initialized")
+	}
+	operationID := progress.OperationID()
+	items, err := db.ListOperationItems(operationID)
+	if err != nil {
+		return nil, err
+	}
+	progress.SetTotal(len(items))
+
+	var wg sync.WaitGroup
+	limiter := make(chan struct{}, networkBatchConcurrency)
+	for _, item := range items {
+		if item.Status == models.OperationItemCreated {
+			progress.Add(1, 0)
+			continue
+		}
+		wg.Add(1)
+		go func(item *models.OperationItem) {
+			defer wg.Done()
+			select {
+			case limiter <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+			defer func() { <-limiter }()
+			if ctx.Err() != nil {
+				return
+			}
+
+			network, err := s.createBatchNetwork(req, item.Name, progress.UserID())
+			item.Status = models.OperationItemFailed
+			if network != nil {
+				item.Status = models.OperationItemCreated
+				item.NetworkID = network.ID
+			}
+			if err != nil {
+				logger.Warn("service: network batch item failed", zap.String("operation_id", operationID), zap.Int("index", item.Index), zap.Error(err))
+				item.Error = err.Error()
+			}
+			if err := db.SaveOperationItem(item); err != nil {
+				logger.Error("service: failed to record network batch item", zap.String("operation_id", operationID), zap.Int("index", item.Index), zap.Error(err))
+			}
+			if network != nil {
+				progress.Add(1, 0)
+			} else {
+				progress.Add(0, 1)
+			}
+		}(item)
+	}
+	wg.Wait()
+
+	result := NetworkBatchResult{NetworkIDs: make([]string, 0, len(items))}
+	failed := 0
+	for _, item := range items {
+		switch item.Status {
+		case models.OperationItemCreated:
+			result.NetworkIDs = append(result.NetworkIDs, item.NetworkID)
+		case models.OperationItemFailed:
+			failed++
+		}
+	}
+	if failed > 0 {
+		return result, fmt.Errorf("%d of %d networks were not created", failed, len(items))
+	}
+	return result, nil
+}
+
+// createBatchNetwork creates one network of a batch and applies the batch configuration.
+// When only the configuration fails the network is returned with the error, since it
+// exists and must not be created again.
+func (s *NetworkService) createBatchNetwork(req NetworkBatchRequest, name, userID string) (*zerotier.Network, error) {
+	network, err := s.CreateNetwork(req.Controller, &zerotier.Network{Name: name, Description: req.Description}, userID)
+	if err != nil {
+		return nil, err
+	}
+	if req.Config != nil {
+		update := *req.Config
+		update.Name, update.Description = "", ""
+		if _, err := s.UpdateNetwork(network.ID, &update, userID); err != nil {
+			return network, fmt.Errorf("network was created but its configuration was not applied: %w", err)
+		}
+	}
+	return network, nil
+}
+
+// adoptBatchNetworks marks pending items created when the user owns a network with their
+// name that was created after the operation started and is not claimed by another item
+func (s *NetworkService) adoptBatchNetworks(operation *models.Operation, items []*models.OperationItem) {
+	db := s.getDB()
+	claimed := make(map[string]bool)
+	pending := false
+	for _, item := range items {
+		claimed[item.NetworkID] = true
+		pending = pending || item.Status == models.OperationItemPending
+	}
+	if !pending {
+		return
+	}
+	networks, err := db.GetNetworksByOwnerID(operation.UserID)
+	if err != nil {
+		logger.Warn("service: failed to list networks to resume network batch", zap.String("operation_id", operation.ID), zap.Error(err))
+		return
+	}
+	for _, item := range items {
+		if item.Status != models.OperationItemPending {
+			continue
+		}
+		for _, network := range networks {
+			if network.Name != item.Name || claimed[network.ID] || network.CreatedAt.Before(operation.CreatedAt) {
+				continue
+			}
+			claimed[network.ID] = true
+			item.Status = models.OperationItemCreated
+			item.NetworkID = network.ID
+			if err := db.SaveOperationItem(item); err != nil {
+				logger.Warn("service: failed to record network batch item", zap.String("operation_id", operation.ID), zap.Int("index", item.Index), zap.Error(err))
+			}
+			break
+		}
+	}
+}
@@ -1,11 +1,30 @@
 package services
 
 import (
+	"fmt"
 	"strings"
 
 	"github.com/GT-610/tairitsu/internal/zerotier"
 )
 
+// MaxMulticastLimit bounds how many members a multicast is sent to; networks needing more,
+// such as large mDNS networks, are better served by broadcast
+const MaxMulticastLimit = 4096
+
+// ErrInvalidMulticastLimit is returned for a multicast limit outside 0 to MaxMulticastLimit
+var ErrInvalidMulticastLimit = fmt.Errorf("multicast limit must be between 0 and %d", MaxMulticastLimit)
+
+// ValidateNetworkUpdateRequest checks the settings of a normalized network update
+func ValidateNetworkUpdateRequest(req *zerotier.NetworkUpdateRequest) error {
+	if req == nil {
+		return nil
+	}
+	if req.MulticastLimit != nil && (*req.MulticastLimit < 0 || *req.MulticastLimit > MaxMulticastLimit) {
+		return ErrInvalidMulticastLimit
+	}
+	return ValidateDNSConfig(req.DNS)
+}
+
 func NormalizeNetworkUpdateRequest(req *zerotier.NetworkUpdateRequest) *zerotier.NetworkUpdateRequest {
 	if req == nil {
 		return nil
@@ -17,22 +36,28 @@ func NormalizeNetworkUpdateRequest(req *zerotier.NetworkUpdateRequest) *zerotier
 	normalized.IpAssignmentPools = cloneAssignmentPools(req.IpAssignmentPools)
 
 	if req.DNS != nil {
-		dns := zerotier.DNSConfig{
-			Domain:  strings.TrimSpace(req.DNS.Domain),
-			Servers: make([]string, 0, len(req.DNS.Servers)),
-		}
-		for _, server := range req.DNS.Servers {
-			trimmed := strings.TrimSpace(server)
-			if trimmed != "" {
-				dns.Servers = append(dns.Servers, trimmed)
-			}
-		}
+		dns := normalizeDNSConfig(*req.DNS)
 		normalized.DNS = &dns
 	}
 
 	return &normalized
 }
 
+// normalizeDNSConfig trims the search domain and servers and drops empty servers
+func normalizeDNSConfig(config zerotier.DNSConfig) zerotier.DNSConfig {
+	dns := zerotier.DNSConfig{
+		Domain:  strings.TrimSpace(config.Domain),
+		Servers: make([]string, 0, len(config.Servers)),
+	}
+	for _, server := range config.Servers {
+		trimmed := strings.TrimSpace(server)
+		if trimmed != "" {
+			dns.Servers = append(dns.Servers, trimmed)
+		}
+	}
+	return dns
+}
+
 func cloneRoutes(routes []zerotier.Route) []zerotier.Route {
 	if len(routes) == 0 {
 		return nil
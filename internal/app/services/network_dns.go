@@ -0,0 +1,63 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"net/netip"
+	"strings"
+
+	"github.com/GT-610/tairitsu/internal/zerotier"
+)
+
+// MaxDNSServers is how many DNS servers a controller pushes to its members
+const MaxDNSServers = 4
+
+// ErrInvalidDNSConfig is returned for a DNS search domain or server list the controller
+// would push to members as is
+var ErrInvalidDNSConfig = errors.New("invalid DNS configuration")
+
+// ValidateDNSConfig checks a normalized DNS configuration: the search domain must be a
+// valid domain name and every server an IP address. An empty configuration clears DNS.
+func ValidateDNSConfig(dns *zerotier.DNSConfig) error {
+	if dns == nil {
+		return nil
+	}
+	if dns.Domain != "" && !validDNSDomain(dns.Domain) {
+		return fmt.Errorf("%w: %q is not a valid domain name", ErrInvalidDNSConfig, dns.Domain)
+	}
+	if len(dns.Servers) > MaxDNSServers {
+		return fmt.Errorf("%w: at most %d DNS servers are supported", ErrInvalidDNSConfig, MaxDNSServers)
+	}
+	seen := make(map[netip.Addr]bool, len(dns.Servers))
+	for _, server := range dns.Servers {
+		addr, err := netip.ParseAddr(server)
+		if err != nil || addr.Zone() != "" {
+			return fmt.Errorf("%w: DNS server %q is not an IP address", ErrInvalidDNSConfig, server)
+		}
+		if seen[addr] {
+			return fmt.Errorf("%w: DNS server %s is listed twice", ErrInvalidDNSConfig, server)
+		}
+		seen[addr] = true
+	}
+	return nil
+}
+
+// validDNSDomain reports whether domain is a domain name of letters, digits and hyphens,
+// with an optional trailing dot
+func validDNSDomain(domain string) bool {
+	domain = strings.TrimSuffix(domain, ".")
+	if domain == "" || len(domain) > 253 {
+		return false
+	}
+	for _, label := range strings.Split(domain, ".") {
+		if label == "" || len(label) > 63 || label[0] == '-' || label[len(label)-1] == '-' {
+			return false
+		}
+		for _, r := range label {
+			if (r < 'a' || r > 'z') && (r < 'A' || r > 'Z') && (r < '0' || r > '9') && r != '-' {
+				return false
+			}
+		}
+	}
+	return true
+}
@@ -0,0 +1,108 @@
+package services
+
+import (
+	"net/netip"
+
+	"github.com/GT-610/tairitsu/internal/app/logger"
+	"github.com/GT-610/tairitsu/internal/ipcalc"
+	"go.uber.org/zap"
+)
+
+const (
+	DefaultIPUsageNextFree = 10
+	MaxIPUsageNextFree     = 256
+)
+
+// IPPoolUsage is the utilization of one assignment pool. Capacity and Free are decimal
+// strings because IPv6 pools easily hold more addresses than a JSON number keeps exactly.
+type IPPoolUsage struct {
+	IPRangeStart string   `json:"ipRangeStart"`
+	IPRangeEnd   string   `json:"ipRangeEnd"`
+	Capacity     string   `json:"capacity"`
+	Assigned     int      `json:"assigned"`
+	Free         string   `json:"free"`
+	NextFree     []string `json:"nextFree"`
+}
+
+// NetworkIPUsage reports how much of each assignment pool of a network is in use
+type NetworkIPUsage struct {
+	NetworkID string        `json:"networkId"`
+	Pools     []IPPoolUsage `json:"pools"`
+	// OutsidePools lists assigned addresses that lie in no pool, such as manual assignments
+	OutsidePools []string `json:"outsidePools"`
+}
+
+// GetNetworkIPUsage matches the addresses assigned to members against the network's
+// assignment pools, listing up to nextFree unassigned addresses per pool
+func (s *NetworkService) GetNetworkIPUsage(networkID string, nextFree int, userID string) (*NetworkIPUsage, error) {
+	record, err := s.authorizeMemberReadAccess(networkID, userID)
+	if err != nil {
+		logger.Warn("service: no permission to access network IP usage", zap.String("network_id", networkID), zap.String("user_id", userID), zap.Error(err))
+		return nil, err
+	}
+	client, err := s.clientFor(record)
+	if err != nil {
+		return nil, err
+	}
+
+	network, err := client.GetNetwork(networkID)
+	if err != nil {
+		logger.Error("service: failed to get network by ID", zap.String("network_id", networkID), zap.Error(err))
+		return nil, err
+	}
+	if network == nil {
+		return nil, ErrNetworkNotFound
+	}
+	members, err := client.GetMembers(networkID)
+	if err != nil {
+		logger.Error("service: failed to get network members", zap.String("network_id", networkID), zap.Error(err))
+		return nil, err
+	}
+
+	pools := make([]ipcalc.Range, 0, len(network.Config.IpAssignmentPools))
+	for _, pool := range network.Config.IpAssignmentPools {
+		r, err := ipcalc.ParseRange(pool.IpRangeStart, pool.IpRangeEnd)
+		if err != nil {
+			logger.Warn("service: skipping invalid IP assignment pool", zap.String("network_id", networkID), zap.Error(err))
+			continue
+		}
+		pools = append(pools, r)
+	}
+	assigned := make([]netip.Addr, 0, len(members))
+	for _, member := range members {
+		for _, value := range member.IPAssignments {
+			addr, err := netip.ParseAddr(value)
+			if err != nil {
+				logger.Warn("service: skipping invalid member IP assignment", zap.String("network_id", networkID), zap.String("member_id", member.ID), zap.String("ip", value))
+				continue
+			}
+			assigned = append(assigned, addr)
+		}
+	}
+
+	usages, outside := ipcalc.Utilization(pools, assigned, nextFree)
+	report := &NetworkIPUsage{
+		NetworkID:    networkID,
+		Pools:        make([]IPPoolUsage, 0, len(usages)),
+		OutsidePools: addrStrings(outside),
+	}
+	for _, usage := range usages {
+		report.Pools = append(report.Pools, IPPoolUsage{
+			IPRangeStart: usage.Range.Start.String(),
+			IPRangeEnd:   usage.Range.End.String(),
+			Capacity:     usage.Capacity.String(),
+			Assigned:     len(usage.Assigned),
+			Free:         usage.Free.String(),
+			NextFree:     addrStrings(usage.NextFree),
+		})
+	}
+	return report, nil
+}
+
+func addrStrings(addrs []netip.Addr) []string {
+	values := make([]string, len(addrs))
+	for i, addr := range addrs {
+		values[i] = addr.String()
+	}
+	return values
+}
@@ -0,0 +1,55 @@
+package services
+
+import (
+	"github.com/GT-610/tairitsu/internal/app/logger"
+	"github.com/GT-610/tairitsu/internal/zerotier"
+	"go.uber.org/zap"
+)
+
+// NetworkIPv6Prefixes describes the IPv6 address spaces ZeroTier derives from a network ID
+type NetworkIPv6Prefixes struct {
+	NetworkID       string `json:"networkId"`
+	RFC4193Enabled  bool   `json:"rfc4193Enabled"`
+	RFC4193Prefix   string `json:"rfc4193Prefix"`
+	SixPlaneEnabled bool   `json:"sixPlaneEnabled"`
+	SixPlanePrefix  string `json:"sixPlanePrefix"`
+}
+
+// GetNetworkIPv6Prefixes computes the rfc4193 and 6plane prefixes of a network for display
+func (s *NetworkService) GetNetworkIPv6Prefixes(networkID, userID string) (*NetworkIPv6Prefixes, error) {
+	record, err := s.authorizeMemberReadAccess(networkID, userID)
+	if err != nil {
+		logger.Warn("service: no permission to access network IPv6 prefixes", zap.String("network_id", networkID), zap.String("user_id", userID), zap.Error(err))
+		return nil, err
+	}
+	client, err := s.clientFor(record)
+	if err != nil {
+		return nil, err
+	}
+
+	network, err := client.GetNetwork(networkID)
+	if err != nil {
+		logger.Error("service: failed to get network by ID", zap.String("network_id", networkID), zap.Error(err))
+		return nil, err
+	}
+	if network == nil {
+		return nil, ErrNetworkNotFound
+	}
+
+	rfc4193Prefix, err := zerotier.RFC4193Prefix(networkID)
+	if err != nil {
+		return nil, err
+	}
+	sixPlanePrefix, err := zerotier.SixPlanePrefix(networkID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &NetworkIPv6Prefixes{
+		NetworkID:       networkID,
+		RFC4193Enabled:  network.Config.V6AssignMode.Rfc4193,
+		RFC4193Prefix:   rfc4193Prefix,
+		SixPlaneEnabled: network.Config.V6AssignMode.Plane6,
+		SixPlanePrefix:  sixPlanePrefix,
+	}, nil
+}
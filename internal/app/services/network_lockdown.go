@@ -0,0 +1,219 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/GT-610/tairitsu/internal/app/logger"
+	"github.com/GT-610/tairitsu/internal/app/models"
+	"github.com/GT-610/tairitsu/internal/zerotier"
+	"go.uber.org/zap"
+)
+
+const networkLockdownConcurrency = 8
+
+var (
+	ErrLockdownActive    = errors.New("network is already locked down")
+	ErrLockdownNotActive = errors.New("network is not locked down")
+)
+
+// LockdownMemberFailure reports a member whose authorization could not be changed
+type LockdownMemberFailure struct {
+	MemberID string `json:"memberId"`
+	Error    string `json:"error"`
+}
+
+// NetworkLockdownResult describes the outcome of a lockdown or its rollback
+type NetworkLockdownResult struct {
+	Lockdown *models.NetworkLockdown `json:"lockdown"`
+	// Changed lists the members whose authorization was changed by this request
+	Changed  []string                `json:"changed"`
+	Failures []LockdownMemberFailure `json:"failures"`
+}
+
+// setMembersAuthorized changes the authorization of members concurrently and returns
+// the members that were changed and those that failed
+func setMembersAuthorized(client *zerotier.Client, networkID string, memberIDs []string, authorized bool) ([]string, []LockdownMemberFailure) {
+	changed := make([]string, 0, len(memberIDs))
+	failures := make([]LockdownMemberFailure, 0)
+	if len(memberIDs) == 0 {
+		return changed, failures
+	}
+
+	var wg sync.WaitGroup
+	var resultMutex sync.Mutex
+	limiter := make(chan struct{}, min(networkLockdownConcurrency, len(memberIDs)))
+	for _, memberID := range memberIDs {
+		wg.Add(1)
+		go func(memberID string) {
+			defer wg.Done()
+			limiter <- struct{}{}
+			defer func() { <-limiter }()
+
+			_, err := client.UpdateMember(networkID, memberID, &zerotier.MemberUpdateRequest{Authorized: &authorized})
+			resultMutex.Lock()
+			defer resultMutex.Unlock()
+			if err != nil {
+				logger.Warn("service: failed to change member authorization", zap.String("network_id", networkID), zap.String("member_id", memberID), zap.Bool("authorized", authorized), zap.Error(err))
+				failures = append(failures, LockdownMemberFailure{MemberID: memberID, Error: err.Error()})
+				return
+			}
+			changed = append(changed, memberID)
+		}(memberID)
+	}
+	wg.Wait()
+
+	slices.Sort(changed)
+	slices.SortFunc(failures, func(a, b LockdownMemberFailure) int {
+		return strings.Compare(a.MemberID, b.MemberID)
+	})
+	return changed, failures
+}
+
+// LockdownNetwork deauthorizes every authorized member of an owned network except the
+// given ones and records them so RollbackNetworkLockdown can authorize them again
+func (s *NetworkService) LockdownNetwork(networkID string, except []string, userID string) (*NetworkLockdownResult, error) {
+	network, err := s.authorizeMemberWriteAccess(networkID, userID)
+	if err != nil {
+		logger.Warn("service: no permission to lock down network", zap.String("network_id", networkID), zap.String("user_id", userID), zap.Error(err))
+		return nil, err
+	}
+	db := s.getDB()
+	if db == nil {
+		return nil, errors.New("database is not initialized")
+	}
+	client, err := s.clientFor(network)
+	if err != nil {
+		return nil, err
+	}
+
+	s.lockdownMutex.Lock()
+	defer s.lockdownMutex.Unlock()
+
+	active, err := db.GetActiveNetworkLockdown(networkID)
+	if err != nil {
+		return nil, err
+	}
+	if active != nil {
+		return nil, ErrLockdownActive
+	}
+
+	members, err := client.GetMembers(networkID)
+	if err != nil {
+		logger.Error("service: failed to list members for lockdown", zap.String("network_id", networkID), zap.Error(err))
+		return nil, err
+	}
+	targets := make([]string, 0, len(members))
+	for _, member := range members {
+		if member.Config.Authorized && !slices.Contains(except, member.ID) {
+			targets = append(targets, member.ID)
+		}
+	}
+
+	changed, failures := setMembersAuthorized(client, networkID, targets, false)
+	if except == nil {
+		except = []string{}
+	}
+	lockdown := &models.NetworkLockdown{
+		NetworkID:    networkID,
+		Active:       true,
+		Except:       except,
+		Deauthorized: changed,
+		CreatedBy:    userID,
+		CreatedAt:    time.Now(),
+	}
+	if err := db.CreateNetworkLockdown(lockdown); err != nil {
+		// The members stay deauthorized; log them so they can be restored by hand
+		logger.Error("service: failed to record network lockdown", zap.String("network_id", networkID), zap.Strings("deauthorized", changed), zap.Error(err))
+		return nil, fmt.Errorf("failed to record lockdown: %w", err)
+	}
+
+	s.afterLockdownChange(networkID, changed, false, userID)
+	logger.Info("service: network locked down", zap.String("network_id", networkID), zap.String("user_id", userID), zap.Int("deauthorized", len(changed)), zap.Int("failed", len(failures)))
+	return &NetworkLockdownResult{Lockdown: lockdown, Changed: changed, Failures: failures}, nil
+}
+
+// RollbackNetworkLockdown authorizes again the members the active lockdown deauthorized.
+// Members that could not be restored stay recorded, and the lockdown stays active until
+// a later rollback restores them.
+func (s *NetworkService) RollbackNetworkLockdown(networkID string, userID string) (*NetworkLockdownResult, error) {
+	network, err := s.authorizeMemberWriteAccess(networkID, userID)
+	if err != nil {
+		logger.Warn("service: no permission to roll back network lockdown", zap.String("network_id", networkID), zap.String("user_id", userID), zap.Error(err))
+		return nil, err
+	}
+	db := s.getDB()
+	if db == nil {
+		return nil, errors.New("database is not initialized")
+	}
+	client, err := s.clientFor(network)
+	if err != nil {
+		return nil, err
+	}
+
+	s.lockdownMutex.Lock()
+	defer s.lockdownMutex.Unlock()
+
+	lockdown, err := db.GetActiveNetworkLockdown(networkID)
+	if err != nil {
+		return nil, err
+	}
+	if lockdown == nil {
+		return nil, ErrLockdownNotActive
+	}
+
+	members, err := client.GetMembers(networkID)
+	if err != nil {
+		logger.Error("service: failed to list members for lockdown rollback", zap.String("network_id", networkID), zap.Error(err))
+		return nil, err
+	}
+	// The controller creates members it is asked to update, so members deleted since the
+	// lockdown are dropped rather than authorized again
+	targets := make([]string, 0, len(lockdown.Deauthorized))
+	for _, member := range members {
+		if slices.Contains(lockdown.Deauthorized, member.ID) {
+			targets = append(targets, member.ID)
+		}
+	}
+
+	changed, failures := setMembersAuthorized(client, networkID, targets, true)
+	remaining := make([]string, 0, len(failures))
+	for _, failure := range failures {
+		remaining = append(remaining, failure.MemberID)
+	}
+
+	lockdown.Deauthorized = remaining
+	if len(remaining) == 0 {
+		now := time.Now()
+		lockdown.Active = false
+		lockdown.RolledBackBy = userID
+		lockdown.RolledBackAt = &now
+	}
+	if err := db.SaveNetworkLockdown(lockdown); err != nil {
+		logger.Error("service: failed to record network lockdown rollback", zap.String("network_id", networkID), zap.Error(err))
+		return nil, fmt.Errorf("failed to record lockdown rollback: %w", err)
+	}
+
+	s.afterLockdownChange(networkID, changed, true, userID)
+	logger.Info("service: network lockdown rolled back", zap.String("network_id", networkID), zap.String("user_id", userID), zap.Int("authorized", len(changed)), zap.Int("failed", len(failures)))
+	return &NetworkLockdownResult{Lockdown: lockdown, Changed: changed, Failures: failures}, nil
+}
+
+func (s *NetworkService) afterLockdownChange(networkID string, memberIDs []string, authorized bool, userID string) {
+	if len(memberIDs) == 0 {
+		return
+	}
+	s.invalidateMemberStats(networkID)
+	s.notifyMemberChange(networkID)
+	event := WebhookEventMemberDeauthorized
+	if authorized {
+		event = WebhookEventMemberAuthorized
+	}
+	for _, memberID := range memberIDs {
+		s.dispatchEvent(event, memberWebhookData(networkID, memberID, userID))
+	}
+}
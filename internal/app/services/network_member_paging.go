@@ -0,0 +1,122 @@
+package services
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/GT-610/tairitsu/internal/zerotier"
+)
+
+const (
+	MemberSortID   = "id"
+	MemberSortName = "name"
+)
+
+// MemberListQuery filters and orders a paged member list
+type MemberListQuery struct {
+	Sort       string
+	Authorized *bool
+	Online     *bool
+	Search     string
+}
+
+// MemberPage is one page of a network member list
+type MemberPage struct {
+	Items []zerotier.Member `json:"items"`
+	PageInfo
+}
+
+func (q MemberListQuery) normalized() (MemberListQuery, error) {
+	q.Search = strings.ToLower(strings.TrimSpace(q.Search))
+	switch q.Sort {
+	case "":
+		q.Sort = MemberSortID
+	case MemberSortID, MemberSortName:
+	default:
+		return q, ErrInvalidPageRequest
+	}
+	return q, nil
+}
+
+func (q MemberListQuery) fingerprint(networkID string) string {
+	authorized := ""
+	if q.Authorized != nil {
+		authorized = strconv.FormatBool(*q.Authorized)
+	}
+	online := ""
+	if q.Online != nil {
+		online = strconv.FormatBool(*q.Online)
+	}
+	return pageQuery("members", networkID, q.Sort, authorized, online, q.Search)
+}
+
+func (q MemberListQuery) matches(member zerotier.Member) bool {
+	if q.Authorized != nil && member.Authorized != *q.Authorized {
+		return false
+	}
+	if q.Online != nil && member.Online != *q.Online {
+		return false
+	}
+	if q.Search == "" {
+		return true
+	}
+	if strings.Contains(strings.ToLower(member.ID), q.Search) || strings.Contains(strings.ToLower(member.Name), q.Search) {
+		return true
+	}
+	return member.Metadata != nil && (strings.Contains(strings.ToLower(member.Metadata.DisplayName), q.Search) ||
+		strings.Contains(strings.ToLower(member.Metadata.Notes), q.Search))
+}
+
+func (q MemberListQuery) sortKey(member zerotier.Member) (string, string) {
+	if q.Sort == MemberSortName {
+		return strings.ToLower(member.Name), member.ID
+	}
+	return member.ID, member.ID
+}
+
+// GetNetworkMembersPage retrieves one page of network members, filtered and sorted by query
+func (s *NetworkService) GetNetworkMembersPage(networkID string, userID string, query MemberListQuery, req PageRequest) (*MemberPage, error) {
+	query, err := query.normalized()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := req.normalizedLimit(); err != nil {
+		return nil, err
+	}
+
+	filtered, err := s.listNetworkMembers(networkID, userID, query)
+	if err != nil {
+		return nil, err
+	}
+
+	items, info, err := sortedPage(filtered, query.sortKey, req, query.fingerprint(networkID))
+	if err != nil {
+		return nil, err
+	}
+	return &MemberPage{Items: items, PageInfo: info}, nil
+}
+
+// listNetworkMembers returns the members matching a normalized query in its sort order
+func (s *NetworkService) listNetworkMembers(networkID string, userID string, query MemberListQuery) ([]zerotier.Member, error) {
+	members, err := s.GetNetworkMembers(networkID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	filtered := make([]zerotier.Member, 0, len(members))
+	for _, member := range members {
+		if query.matches(member) {
+			filtered = append(filtered, member)
+		}
+	}
+	sort.Slice(filtered, func(i, j int) bool {
+		ki, ii := query.sortKey(filtered[i])
+		kj, ij := query.sortKey(filtered[j])
+		if ki != kj {
+			return ki < kj
+		}
+		return ii < ij
+	})
+	return filtered, nil
+}
@@ -0,0 +1,129 @@
+package services
+
+import (
+	"errors"
+	"net/netip"
+	"strings"
+	"time"
+
+	"github.com/GT-610/tairitsu/internal/app/logger"
+	"github.com/GT-610/tairitsu/internal/app/models"
+	"github.com/GT-610/tairitsu/internal/zerotier"
+	"go.uber.org/zap"
+)
+
+var ErrInvalidPhysicalAddressPolicy = errors.New("physical address policy must be full, truncated or hidden")
+
+// NetworkPrivacy describes how member physical addresses are shown to network viewers
+type NetworkPrivacy struct {
+	NetworkID             string `json:"networkId"`
+	PhysicalAddressPolicy string `json:"physicalAddressPolicy"`
+}
+
+// NormalizePhysicalAddressPolicy maps unset policies to the truncated default
+func NormalizePhysicalAddressPolicy(policy string) string {
+	switch policy {
+	case models.PhysicalAddressPolicyFull, models.PhysicalAddressPolicyHidden:
+		return policy
+	default:
+		return models.PhysicalAddressPolicyTruncated
+	}
+}
+
+// GetNetworkPrivacy returns the physical address policy of a network to anyone who can read its members
+func (s *NetworkService) GetNetworkPrivacy(networkID string, userID string) (*NetworkPrivacy, error) {
+	network, err := s.authorizeMemberReadAccess(networkID, userID)
+	if err != nil {
+		logger.Warn("service: no permission to read network privacy", zap.String("network_id", networkID), zap.String("user_id", userID), zap.Error(err))
+		return nil, err
+	}
+
+	return &NetworkPrivacy{
+		NetworkID:             network.ID,
+		PhysicalAddressPolicy: NormalizePhysicalAddressPolicy(network.PhysicalAddressPolicy),
+	}, nil
+}
+
+// UpdateNetworkPrivacy changes the physical address policy of an owned network
+func (s *NetworkService) UpdateNetworkPrivacy(networkID string, policy string, userID string) (*NetworkPrivacy, error) {
+	if policy != models.PhysicalAddressPolicyFull && policy != models.PhysicalAddressPolicyTruncated && policy != models.PhysicalAddressPolicyHidden {
+		return nil, ErrInvalidPhysicalAddressPolicy
+	}
+
+	network, err := s.authorizeOwnedNetwork(networkID, userID)
+	if err != nil {
+		logger.Warn("service: no permission to update network privacy", zap.String("network_id", networkID), zap.String("user_id", userID), zap.Error(err))
+		return nil, err
+	}
+
+	network.PhysicalAddressPolicy = policy
+	network.UpdatedAt = time.Now()
+	if err := s.getDB().UpdateNetwork(network); err != nil {
+		logger.Error("service: failed to update network privacy", zap.String("network_id", networkID), zap.Error(err))
+		return nil, err
+	}
+
+	return &NetworkPrivacy{NetworkID: network.ID, PhysicalAddressPolicy: policy}, nil
+}
+
+// physicalAddressPolicyFor resolves the policy applied for a reader; owners, organization
+// administrators and administrators see full addresses
+func (s *NetworkService) physicalAddressPolicyFor(network *models.Network, userID string) string {
+	if network.OwnerID == userID {
+		return models.PhysicalAddressPolicyFull
+	}
+	if role, err := s.organizationRole(network, userID); err == nil && role == models.OrganizationRoleAdmin {
+		return models.PhysicalAddressPolicyFull
+	}
+
+	policy := NormalizePhysicalAddressPolicy(network.PhysicalAddressPolicy)
+	if policy == models.PhysicalAddressPolicyFull {
+		return policy
+	}
+	if db := s.getDB(); db != nil {
+		user, err := db.GetUserByID(userID)
+		if err != nil {
+			logger.Warn("service: failed to resolve reader role; applying network privacy policy", zap.String("user_id", userID), zap.Error(err))
+		} else if user != nil && user.Role == "admin" {
+			return models.PhysicalAddressPolicyFull
+		}
+	}
+	return policy
+}
+
+// applyPhysicalAddressPolicy redacts every member field that can carry a physical IP
+func applyPhysicalAddressPolicy(members []zerotier.Member, policy string) {
+	for index := range members {
+		members[index].PreferredPath = maskPhysicalAddress(members[index].PreferredPath, policy)
+	}
+}
+
+// maskPhysicalAddress applies policy to a ZeroTier "ip/port" path address. Truncation keeps
+// the /24 of IPv4 addresses and the /48 of IPv6 addresses and drops the port.
+func maskPhysicalAddress(address string, policy string) string {
+	if address == "" || policy == models.PhysicalAddressPolicyFull {
+		return address
+	}
+	if policy == models.PhysicalAddressPolicyHidden {
+		return ""
+	}
+
+	host := address
+	if index := strings.LastIndex(address, "/"); index >= 0 {
+		host = address[:index]
+	}
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return ""
+	}
+
+	bits := 24
+	if addr.Is6() && !addr.Is4In6() {
+		bits = 48
+	}
+	prefix, err := addr.Unmap().Prefix(bits)
+	if err != nil {
+		return ""
+	}
+	return prefix.String()
+}
@@ -0,0 +1,98 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/GT-610/tairitsu/internal/app/logger"
+	"github.com/GT-610/tairitsu/internal/app/models"
+	"github.com/GT-610/tairitsu/internal/zerotier"
+	"github.com/GT-610/tairitsu/internal/ztrules"
+	"go.uber.org/zap"
+)
+
+const maxNetworkRulesSourceLen = 64 * 1024
+
+var ErrInvalidNetworkRules = errors.New("invalid network rules")
+
+// NetworkRulesDetail pairs the rules source saved in Tairitsu with the compiled rules the
+// controller currently enforces. The two differ when the rules were changed elsewhere.
+type NetworkRulesDetail struct {
+	Source    string     `json:"source"`
+	UpdatedBy string     `json:"updatedBy,omitempty"`
+	UpdatedAt *time.Time `json:"updatedAt,omitempty"`
+	zerotier.NetworkRules
+}
+
+// GetNetworkRules returns the rules source and the controller's compiled rules of an owned network
+func (s *NetworkService) GetNetworkRules(networkID string, userID string) (*NetworkRulesDetail, error) {
+	network, err := s.authorizeOwnedNetwork(networkID, userID)
+	if err != nil {
+		logger.Warn("service: no permission to read network rules", zap.String("network_id", networkID), zap.String("user_id", userID), zap.Error(err))
+		return nil, err
+	}
+	client, err := s.clientFor(network)
+	if err != nil {
+		return nil, err
+	}
+
+	rules, err := client.GetNetworkRules(networkID)
+	if err != nil {
+		logger.Error("service: failed to get network rules", zap.String("network_id", networkID), zap.Error(err))
+		return nil, err
+	}
+	source, err := s.getDB().GetNetworkRuleSource(networkID)
+	if err != nil {
+		return nil, err
+	}
+
+	return newNetworkRulesDetail(source, rules), nil
+}
+
+// UpdateNetworkRules compiles source, replaces the rules, capabilities and tags of an owned
+// network on its controller and saves the source. Compile errors wrap ErrInvalidNetworkRules
+// and a *ztrules.Error with the position of the problem.
+func (s *NetworkService) UpdateNetworkRules(networkID string, source string, userID string) (*NetworkRulesDetail, error) {
+	network, err := s.authorizeOwnedNetwork(networkID, userID)
+	if err != nil {
+		logger.Warn("service: no permission to update network rules", zap.String("network_id", networkID), zap.String("user_id", userID), zap.Error(err))
+		return nil, err
+	}
+
+	if len(source) > maxNetworkRulesSourceLen {
+		return nil, fmt.Errorf("%w: rules must be %d bytes or fewer", ErrInvalidNetworkRules, maxNetworkRulesSourceLen)
+	}
+	compiled, err := ztrules.Compile(source)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidNetworkRules, err)
+	}
+
+	client, err := s.clientFor(network)
+	if err != nil {
+		return nil, err
+	}
+	rules, err := client.UpdateNetworkRules(networkID, compiled)
+	if err != nil {
+		logger.Error("service: failed to update network rules", zap.String("network_id", networkID), zap.Error(err))
+		return nil, err
+	}
+
+	saved := &models.NetworkRuleSource{NetworkID: networkID, Source: source, UpdatedBy: userID, UpdatedAt: time.Now()}
+	if err := s.getDB().SaveNetworkRuleSource(saved); err != nil {
+		logger.Error("service: failed to save network rules source", zap.String("network_id", networkID), zap.Error(err))
+		return nil, fmt.Errorf("rules applied on the controller but the source was not saved: %w", err)
+	}
+
+	return newNetworkRulesDetail(saved, rules), nil
+}
+
+func newNetworkRulesDetail(source *models.NetworkRuleSource, rules *zerotier.NetworkRules) *NetworkRulesDetail {
+	detail := &NetworkRulesDetail{NetworkRules: *rules}
+	if source != nil {
+		detail.Source = source.Source
+		detail.UpdatedBy = source.UpdatedBy
+		detail.UpdatedAt = &source.UpdatedAt
+	}
+	return detail
+}
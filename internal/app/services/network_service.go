@@ -1,10 +1,13 @@
 package services
 
 import (
+	"errors"
 	"fmt"
+	"sort"
 	"sync"
 	"time"
 
+	"github.com/GT-610/tairitsu/internal/app/config"
 	"github.com/GT-610/tairitsu/internal/app/database"
 	"github.com/GT-610/tairitsu/internal/app/logger"
 	"github.com/GT-610/tairitsu/internal/app/models"
@@ -16,31 +19,47 @@ const networkMemberStatsConcurrency = 4
 const networkMemberStatsCacheTTL = 15 * time.Second
 
 type networkMemberStats struct {
-	memberCount     int
-	authorizedCount int
-	pendingCount    int
-	expiresAt       time.Time
+	counts    MemberCounts
+	expiresAt time.Time
 }
 
-type memberStatsSetter interface {
-	SetMemberStats(memberCount, authorizedCount, pendingCount int)
+// MemberCounts summarizes the members of a network in network lists
+type MemberCounts struct {
+	Total      int `json:"total"`
+	Authorized int `json:"authorized"`
+	// Online counts members the controller reports online or has as a peer
+	Online int `json:"online"`
 }
 
-func (s *NetworkService) fillMemberStats(nIDs []string, targets []memberStatsSetter) {
-	s.mutex.RLock()
-	zt := s.ztClient
-	s.mutex.RUnlock()
+type memberStatsSetter interface {
+	SetMemberCounts(counts MemberCounts)
+}
 
-	if zt == nil || len(nIDs) == 0 {
+// fillMemberStats fetches the member lists of networks, at most networkMemberStatsConcurrency
+// at a time, and sets the counts of each target. Counts are cached per network for
+// networkMemberStatsCacheTTL; a target whose network cannot be read is left without counts.
+func (s *NetworkService) fillMemberStats(networks []*models.Network, targets []memberStatsSetter) {
+	if len(networks) == 0 {
 		return
 	}
 	var wg sync.WaitGroup
-	limiter := make(chan struct{}, min(networkMemberStatsConcurrency, len(nIDs)))
-	for i, nID := range nIDs {
-		if stats, ok := s.getCachedMemberStats(nID); ok {
-			targets[i].SetMemberStats(stats.memberCount, stats.authorizedCount, stats.pendingCount)
+	limiter := make(chan struct{}, min(networkMemberStatsConcurrency, len(networks)))
+	// Each controller's peers are fetched once, by the first network that needs them
+	peersOf := make(map[*zerotier.Client]func() map[string]bool)
+	for i, network := range networks {
+		if stats, ok := s.getCachedMemberStats(network.ID); ok {
+			targets[i].SetMemberCounts(stats.counts)
+			continue
+		}
+		zt, err := s.clientFor(network)
+		if err != nil {
 			continue
 		}
+		peers, ok := peersOf[zt]
+		if !ok {
+			peers = sync.OnceValue(func() map[string]bool { return peerAddresses(zt) })
+			peersOf[zt] = peers
+		}
 
 		wg.Add(1)
 		go func(index int, networkID string) {
@@ -53,31 +72,56 @@ func (s *NetworkService) fillMemberStats(nIDs []string, targets []memberStatsSet
 				logger.Warn("service: failed to get network member counts", zap.String("network_id", networkID), zap.Error(err))
 				return
 			}
-			authorized, pending := 0, 0
+			online := peers()
+			counts := MemberCounts{Total: len(members)}
 			for _, m := range members {
 				if m.Config.Authorized {
-					authorized++
-				} else {
-					pending++
+					counts.Authorized++
+				}
+				if m.Online || online[m.Address] {
+					counts.Online++
 				}
 			}
 			s.setCachedMemberStats(networkID, networkMemberStats{
-				memberCount:     len(members),
-				authorizedCount: authorized,
-				pendingCount:    pending,
-				expiresAt:       time.Now().Add(networkMemberStatsCacheTTL),
+				counts:    counts,
+				expiresAt: time.Now().Add(networkMemberStatsCacheTTL),
 			})
-			targets[index].SetMemberStats(len(members), authorized, pending)
-		}(i, nID)
+			targets[index].SetMemberCounts(counts)
+		}(i, network.ID)
 	}
 	wg.Wait()
 }
 
+// peerAddresses returns the addresses of the controller's peers. Without them only members
+// the controller itself reports online are counted.
+func peerAddresses(zt *zerotier.Client) map[string]bool {
+	peers, err := zt.GetPeers()
+	if err != nil {
+		logger.Warn("service: failed to get peer list; online member counts will use controller online state", zap.Error(err))
+		return nil
+	}
+	addresses := make(map[string]bool, len(peers))
+	for _, peer := range peers {
+		addresses[peer.Address] = true
+	}
+	return addresses
+}
+
 type NetworkService struct {
 	ztClient         *zerotier.Client
 	db               database.DBInterface
 	mutex            sync.RWMutex
 	memberStatsCache map[string]networkMemberStats
+	statusCache      *controllerStatusCache
+	controllers      *ControllerRegistry
+	// memberChanged is told about member mutations made through Tairitsu
+	memberChanged func(networkID string)
+	webhooks      *WebhookDispatcher
+	// lockdownMutex keeps two lockdowns or rollbacks from running at the same time
+	lockdownMutex sync.Mutex
+	operations    *OperationManager
+	// batchMutex keeps two requests with one idempotency key from both starting a batch
+	batchMutex sync.Mutex
 }
 
 type RuntimeStatus struct {
@@ -90,6 +134,9 @@ type RuntimeStatus struct {
 	DatabaseStatus       string `json:"databaseStatus"`
 	ZeroTierError        string `json:"zeroTierError,omitempty"`
 	DatabaseError        string `json:"databaseError,omitempty"`
+	// StatusFetchedAt is when the controller fields were read; absent when they never were
+	StatusFetchedAt *time.Time `json:"statusFetchedAt,omitempty"`
+	StatusStale     bool       `json:"statusStale"`
 }
 
 func NewNetworkService(ztClient *zerotier.Client, db database.DBInterface) *NetworkService {
@@ -97,9 +144,51 @@ func NewNetworkService(ztClient *zerotier.Client, db database.DBInterface) *Netw
 		ztClient:         ztClient,
 		db:               db,
 		memberStatsCache: make(map[string]networkMemberStats),
+		statusCache:      &controllerStatusCache{interval: DefaultControllerStatusRefreshInterval},
+		controllers:      NewControllerRegistry(ztClient),
 	}
 }
 
+// Controllers returns the registry of ZeroTier controllers networks can live on
+func (s *NetworkService) Controllers() *ControllerRegistry {
+	return s.controllers
+}
+
+// clientFor returns the client of the controller hosting network
+func (s *NetworkService) clientFor(network *models.Network) (*zerotier.Client, error) {
+	if network == nil || network.Controller == "" {
+		if client := s.getZTClient(); client != nil {
+			return client, nil
+		}
+		return nil, fmt.Errorf("ZeroTier client is not initialized")
+	}
+	return s.controllers.Client(network.Controller)
+}
+
+// storedControllerName is the controller column value for a network on the named controller
+func storedControllerName(name string) string {
+	if name == config.DefaultControllerName {
+		return ""
+	}
+	return name
+}
+
+// withStoredMetadata fills in the name and description Tairitsu stored for a network where
+// the controller returned none; self-hosted controllers often do not keep them. Values the
+// controller does return take precedence.
+func withStoredMetadata(network *zerotier.Network, stored *models.Network) *zerotier.Network {
+	if network == nil || stored == nil {
+		return network
+	}
+	if network.Name == "" {
+		network.Name = stored.Name
+	}
+	if network.Description == "" {
+		network.Description = stored.Description
+	}
+	return network
+}
+
 func (s *NetworkService) getCachedMemberStats(networkID string) (networkMemberStats, bool) {
 	s.mutex.RLock()
 	stats, ok := s.memberStatsCache[networkID]
@@ -130,6 +219,49 @@ func (s *NetworkService) invalidateMemberStats(networkID string) {
 	delete(s.memberStatsCache, networkID)
 }
 
+// SetMemberChangeListener registers a callback invoked after a member is updated or removed
+func (s *NetworkService) SetMemberChangeListener(listener func(networkID string)) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.memberChanged = listener
+}
+
+// SetWebhookDispatcher sets where network and member events are sent
+func (s *NetworkService) SetWebhookDispatcher(dispatcher *WebhookDispatcher) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.webhooks = dispatcher
+}
+
+// SetOperationManager sets the manager network batches run on
+func (s *NetworkService) SetOperationManager(operations *OperationManager) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.operations = operations
+}
+
+func (s *NetworkService) getOperationManager() *OperationManager {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return s.operations
+}
+
+func (s *NetworkService) dispatchEvent(event string, data any) {
+	s.mutex.RLock()
+	dispatcher := s.webhooks
+	s.mutex.RUnlock()
+	dispatcher.Dispatch(event, data)
+}
+
+func (s *NetworkService) notifyMemberChange(networkID string) {
+	s.mutex.RLock()
+	listener := s.memberChanged
+	s.mutex.RUnlock()
+	if listener != nil {
+		listener(networkID)
+	}
+}
+
 func (s *NetworkService) SetDB(db database.DBInterface) {
 	s.mutex.Lock()
 	defer s.mutex.Unlock()
@@ -150,15 +282,22 @@ func (s *NetworkService) getDB() database.DBInterface {
 	return db
 }
 
+func (s *NetworkService) getZTClient() *zerotier.Client {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return s.ztClient
+}
+
 // GetStatus retrieves the current ZeroTier network status
 func (s *NetworkService) GetStatus() (*zerotier.Status, error) {
 	// Check if ZeroTier client is initialized
-	if s.ztClient == nil {
+	client := s.getZTClient()
+	if client == nil {
 		logger.Warn("service: ZeroTier client is not initialized")
 		return nil, fmt.Errorf("ZeroTier client is not initialized")
 	}
 
-	status, err := s.ztClient.GetStatus()
+	status, err := client.GetStatus()
 	if err != nil {
 		logger.Error("service: failed to get ZeroTier network status", zap.Error(err))
 		return nil, err
@@ -167,7 +306,9 @@ func (s *NetworkService) GetStatus() (*zerotier.Status, error) {
 	return status, nil
 }
 
-func (s *NetworkService) GetRuntimeStatus() *RuntimeStatus {
+// GetRuntimeStatus reports database and controller health. Controller fields come from the
+// status cache unless fresh is set.
+func (s *NetworkService) GetRuntimeStatus(fresh bool) *RuntimeStatus {
 	runtimeStatus := &RuntimeStatus{
 		ZeroTierStatus: "offline",
 		DatabaseStatus: "disconnected",
@@ -182,18 +323,25 @@ func (s *NetworkService) GetRuntimeStatus() *RuntimeStatus {
 		}
 	}
 
-	if s.ztClient == nil {
+	if s.getZTClient() == nil {
 		runtimeStatus.ZeroTierStatus = "error"
 		runtimeStatus.ZeroTierError = "ZeroTier client is not initialized"
 		return runtimeStatus
 	}
 
-	status, err := s.ztClient.GetStatus()
-	if err != nil {
+	snapshot := s.ControllerStatus(fresh)
+	if !snapshot.Fetched() {
 		runtimeStatus.ZeroTierStatus = "error"
-		runtimeStatus.ZeroTierError = err.Error()
+		runtimeStatus.ZeroTierError = controllerStatusError(snapshot)
 		return runtimeStatus
 	}
+	status := snapshot.Status
+	fetchedAt := snapshot.FetchedAt
+	runtimeStatus.StatusFetchedAt = &fetchedAt
+	runtimeStatus.StatusStale = snapshot.Stale
+	if snapshot.Err != nil {
+		runtimeStatus.ZeroTierError = snapshot.Err.Error()
+	}
 
 	runtimeStatus.Version = status.Version
 	runtimeStatus.Address = status.Address
@@ -209,57 +357,67 @@ func (s *NetworkService) GetRuntimeStatus() *RuntimeStatus {
 	return runtimeStatus
 }
 
-// NetworkSummary contains summary information for a network (retrieved from database)
+// NetworkSummary contains summary information for a network (retrieved from database).
+// MemberCounts is nil when the members could not be read or were not asked for.
 type NetworkSummary struct {
-	ID                    string    `json:"id"`
-	Name                  string    `json:"name"`
-	Description           string    `json:"description"`
-	OwnerID               string    `json:"owner_id"`
-	MemberCount           int       `json:"member_count"`
-	AuthorizedMemberCount int       `json:"authorized_member_count"`
-	PendingMemberCount    int       `json:"pending_member_count"`
-	CreatedAt             time.Time `json:"created_at"`
-	UpdatedAt             time.Time `json:"updated_at"`
+	ID                    string        `json:"id"`
+	Name                  string        `json:"name"`
+	Description           string        `json:"description"`
+	OwnerID               string        `json:"ownerId"`
+	Controller            string        `json:"controller"`
+	OrganizationID        string        `json:"organizationId"`
+	MemberCount           int           `json:"memberCount"`
+	AuthorizedMemberCount int           `json:"authorizedMemberCount"`
+	PendingMemberCount    int           `json:"pendingMemberCount"`
+	MemberCounts          *MemberCounts `json:"memberCounts"`
+	CreatedAt             time.Time     `json:"createdAt"`
+	UpdatedAt             time.Time     `json:"updatedAt"`
 }
 
-func (n *NetworkSummary) SetMemberStats(memberCount, authorizedCount, pendingCount int) {
-	n.MemberCount = memberCount
-	n.AuthorizedMemberCount = authorizedCount
-	n.PendingMemberCount = pendingCount
+func (n *NetworkSummary) SetMemberCounts(counts MemberCounts) {
+	n.MemberCount = counts.Total
+	n.AuthorizedMemberCount = counts.Authorized
+	n.PendingMemberCount = counts.Total - counts.Authorized
+	n.MemberCounts = &counts
 }
 
+// SharedNetworkSummary is a network shared with the user; MemberCounts is nil when the
+// members could not be read
 type SharedNetworkSummary struct {
-	ID                    string    `json:"id"`
-	Name                  string    `json:"name"`
-	Description           string    `json:"description"`
-	OwnerID               string    `json:"owner_id"`
-	OwnerUsername         string    `json:"owner_username"`
-	MemberCount           int       `json:"member_count"`
-	AuthorizedMemberCount int       `json:"authorized_member_count"`
-	PendingMemberCount    int       `json:"pending_member_count"`
-	CreatedAt             time.Time `json:"created_at"`
-	UpdatedAt             time.Time `json:"updated_at"`
+	ID                    string        `json:"id"`
+	Name                  string        `json:"name"`
+	Description           string        `json:"description"`
+	OwnerID               string        `json:"ownerId"`
+	OwnerUsername         string        `json:"ownerUsername"`
+	Controller            string        `json:"controller"`
+	MemberCount           int           `json:"memberCount"`
+	AuthorizedMemberCount int           `json:"authorizedMemberCount"`
+	PendingMemberCount    int           `json:"pendingMemberCount"`
+	MemberCounts          *MemberCounts `json:"memberCounts"`
+	CreatedAt             time.Time     `json:"createdAt"`
+	UpdatedAt             time.Time     `json:"updatedAt"`
 }
 
-func (n *SharedNetworkSummary) SetMemberStats(memberCount, authorizedCount, pendingCount int) {
-	n.MemberCount = memberCount
-	n.AuthorizedMemberCount = authorizedCount
-	n.PendingMemberCount = pendingCount
+func (n *SharedNetworkSummary) SetMemberCounts(counts MemberCounts) {
+	n.MemberCount = counts.Total
+	n.AuthorizedMemberCount = counts.Authorized
+	n.PendingMemberCount = counts.Total - counts.Authorized
+	n.MemberCounts = &counts
 }
 
 type NetworkViewerSummary struct {
 	ID        string    `json:"id"`
 	Username  string    `json:"username"`
 	Role      string    `json:"role"`
-	GrantedBy string    `json:"granted_by"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	GrantedBy string    `json:"grantedBy"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
 }
 
 // NetworkDetail contains detailed network information (includes controller data and database description)
 type NetworkDetail struct {
 	*zerotier.Network
-	DBDescription string            `json:"db_description"`
+	DBDescription string            `json:"dbDescription"`
 	Members       []zerotier.Member `json:"members"`
 }
 
@@ -277,35 +435,42 @@ func (s *NetworkService) GetAllNetworks(ownerID string) ([]NetworkSummary, error
 		return nil, err
 	}
 
-	// If no networks owned, return empty slice
-	if len(ownedNetworks) == 0 {
-		return []NetworkSummary{}, nil
+	return s.summarizeNetworks(ownedNetworks, true), nil
+}
+
+// summarizeNetworks converts networks to summaries, with their member counts when
+// memberCounts is set
+func (s *NetworkService) summarizeNetworks(networks []*models.Network, memberCounts bool) []NetworkSummary {
+	// If there are no networks, return empty slice
+	if len(networks) == 0 {
+		return []NetworkSummary{}
 	}
 
 	// Convert to NetworkSummary
-	networkSummaries := make([]NetworkSummary, len(ownedNetworks))
-	for i, net := range ownedNetworks {
+	networkSummaries := make([]NetworkSummary, len(networks))
+	for i, net := range networks {
 		networkSummaries[i] = NetworkSummary{
-			ID:          net.ID,
-			Name:        net.Name,
-			Description: net.Description,
-			OwnerID:     net.OwnerID,
-			CreatedAt:   net.CreatedAt,
-			UpdatedAt:   net.UpdatedAt,
+			ID:             net.ID,
+			Name:           net.Name,
+			Description:    net.Description,
+			OwnerID:        net.OwnerID,
+			Controller:     controllerNameOf(net),
+			OrganizationID: organizationIDOf(net),
+			CreatedAt:      net.CreatedAt,
+			UpdatedAt:      net.UpdatedAt,
 		}
 	}
-
-	networkIDs := make([]string, len(ownedNetworks))
-	for i, net := range ownedNetworks {
-		networkIDs[i] = net.ID
+	if !memberCounts {
+		return networkSummaries
 	}
+
 	targets := make([]memberStatsSetter, len(networkSummaries))
 	for i := range networkSummaries {
 		targets[i] = &networkSummaries[i]
 	}
-	s.fillMemberStats(networkIDs, targets)
+	s.fillMemberStats(networks, targets)
 
-	return networkSummaries, nil
+	return networkSummaries
 }
 
 func (s *NetworkService) GetSharedNetworks(userID string) ([]SharedNetworkSummary, error) {
@@ -349,38 +514,34 @@ func (s *NetworkService) GetSharedNetworks(userID string) ([]SharedNetworkSummar
 			Description:   net.Description,
 			OwnerID:       net.OwnerID,
 			OwnerUsername: ownersByID[net.OwnerID],
+			Controller:    controllerNameOf(net),
 			CreatedAt:     net.CreatedAt,
 			UpdatedAt:     net.UpdatedAt,
 		}
 	}
 
-	sharedIDs := make([]string, len(sharedNetworks))
-	for i, net := range sharedNetworks {
-		sharedIDs[i] = net.ID
-	}
 	targets := make([]memberStatsSetter, len(summaries))
 	for i := range summaries {
 		targets[i] = &summaries[i]
 	}
-	s.fillMemberStats(sharedIDs, targets)
+	s.fillMemberStats(sharedNetworks, targets)
 
 	return summaries, nil
 }
 
 func (s *NetworkService) GetNetworkByID(id string, userID string) (*NetworkDetail, error) {
-	if s.ztClient == nil {
-		logger.Warn("service: ZeroTier client is not initialized")
-		return nil, fmt.Errorf("ZeroTier client is not initialized")
-	}
-
-	ownedNetwork, err := s.authorizeOwnedNetwork(id, userID)
+	ownedNetwork, err := s.authorizeNetworkReadAccess(id, userID)
 	if err != nil {
 		logger.Warn("service: failed to get network", zap.String("network_id", id), zap.String("user_id", userID), zap.Error(err))
 		return nil, err
 	}
+	client, err := s.clientFor(ownedNetwork)
+	if err != nil {
+		return nil, err
+	}
 
 	// Get network details from ZeroTier
-	network, err := s.ztClient.GetNetwork(id)
+	network, err := client.GetNetwork(id)
 	if err != nil {
 		logger.Error("service: failed to get network by ID", zap.String("network_id", id), zap.Error(err))
 		return nil, err
@@ -391,13 +552,13 @@ func (s *NetworkService) GetNetworkByID(id string, userID string) (*NetworkDetai
 		return nil, ErrNetworkNotFound
 	}
 
-	members, err := s.ztClient.GetMembers(id)
+	members, err := client.GetMembers(id)
 	if err != nil {
 		logger.Error("service: failed to get network members", zap.String("network_id", id), zap.Error(err))
 		return nil, err
 	}
 
-	s.enrichMembersWithPeerMetadata(members)
+	enrichMembersWithPeerMetadata(client, members)
 
 	logger.Info("service: network details retrieved successfully",
 		zap.String("network_id", id),
@@ -407,17 +568,30 @@ func (s *NetworkService) GetNetworkByID(id string, userID string) (*NetworkDetai
 
 	// Return combined network detail with database description
 	return &NetworkDetail{
-		Network:       network,
+		Network:       withStoredMetadata(network, ownedNetwork),
 		DBDescription: ownedNetwork.Description,
 		Members:       members,
 	}, nil
 }
 
-// CreateNetwork creates a new ZeroTier network with ownership
-func (s *NetworkService) CreateNetwork(network *zerotier.Network, ownerID string) (*zerotier.Network, error) {
-	if s.ztClient == nil {
-		logger.Warn("service: ZeroTier client is not initialized")
-		return nil, fmt.Errorf("ZeroTier client is not initialized")
+// CreateNetwork creates a new ZeroTier network with ownership on the named controller; an
+// empty name means the default controller
+func (s *NetworkService) CreateNetwork(controller string, network *zerotier.Network, ownerID string) (*zerotier.Network, error) {
+	return s.CreateNetworkWithSettings(controller, network, nil, ownerID)
+}
+
+// CreateNetworkWithSettings creates a network like CreateNetwork that starts with the
+// fields set in settings, checked like a network update. The DNS configuration of network
+// is used when settings has none.
+func (s *NetworkService) CreateNetworkWithSettings(controller string, network *zerotier.Network, settings *zerotier.NetworkUpdateRequest, ownerID string) (*zerotier.Network, error) {
+	controller, err := s.controllers.Resolve(controller)
+	if err != nil {
+		return nil, err
+	}
+	client, err := s.controllers.Client(controller)
+	if err != nil {
+		logger.Warn("service: ZeroTier client is not initialized", zap.String("controller", controller), zap.Error(err))
+		return nil, err
 	}
 
 	db := s.getDB()
@@ -427,40 +601,63 @@ func (s *NetworkService) CreateNetwork(network *zerotier.Network, ownerID string
 	}
 
 	network.Config.Private = true
+	if (settings == nil || settings.DNS == nil) && !network.Config.DNS.IsEmpty() {
+		withDNS := zerotier.NetworkUpdateRequest{}
+		if settings != nil {
+			withDNS = *settings
+		}
+		dns := network.Config.DNS
+		withDNS.DNS = &dns
+		settings = &withDNS
+	}
+	if settings != nil {
+		settings = NormalizeNetworkUpdateRequest(settings)
+		if err := ValidateNetworkUpdateRequest(settings); err != nil {
+			return nil, err
+		}
+		if err := checkControllerFeatures(client, settings); err != nil {
+			return nil, err
+		}
+	}
 
-	createdNetwork, err := s.ztClient.CreateNetwork(network)
+	createdNetwork, err := client.CreateNetworkWithSettings(network, settings)
 	if err != nil {
 		logger.Error("service: failed to create network", zap.String("network_name", network.Name), zap.Error(err))
 		return nil, err
 	}
 
-	// Save network to database with owner information
+	// Save network to database with owner information. The requested name and description
+	// are kept when the controller does not echo them back.
 	dbNetwork := &models.Network{
 		ID:          createdNetwork.ID,
 		Name:        createdNetwork.Name,
 		Description: createdNetwork.Description,
 		OwnerID:     ownerID,
+		Controller:  storedControllerName(controller),
+	}
+	if dbNetwork.Name == "" {
+		dbNetwork.Name = network.Name
+	}
+	if dbNetwork.Description == "" {
+		dbNetwork.Description = network.Description
 	}
 
 	if err := db.CreateNetwork(dbNetwork); err != nil {
 		logger.Error("service: failed to save network ownership", zap.String("network_id", createdNetwork.ID), zap.Error(err))
 		// Try to delete network from ZeroTier if database save fails
-		if delErr := s.ztClient.DeleteNetwork(createdNetwork.ID); delErr != nil {
+		if delErr := client.DeleteNetwork(createdNetwork.ID); delErr != nil {
 			logger.Error("service: failed to roll back network deletion", zap.String("network_id", createdNetwork.ID), zap.Error(delErr))
 		}
 		return nil, err
 	}
+	createdNetwork = withStoredMetadata(createdNetwork, dbNetwork)
+	s.dispatchEvent(WebhookEventNetworkCreated, networkCreatedWebhookData(createdNetwork, ownerID, controller))
 
 	return createdNetwork, nil
 }
 
 // UpdateNetwork updates a network with ownership check and private network enforcement
 func (s *NetworkService) UpdateNetwork(id string, updateReq *zerotier.NetworkUpdateRequest, userID string) (*zerotier.Network, error) {
-	if s.ztClient == nil {
-		logger.Warn("service: ZeroTier client is not initialized")
-		return nil, fmt.Errorf("ZeroTier client is not initialized")
-	}
-
 	db := s.getDB()
 	if db == nil {
 		logger.Warn("service: database is not initialized")
@@ -472,11 +669,22 @@ func (s *NetworkService) UpdateNetwork(id string, updateReq *zerotier.NetworkUpd
 		logger.Warn("service: no permission to update network", zap.String("network_id", id), zap.String("user_id", userID), zap.Error(err))
 		return nil, err
 	}
+	client, err := s.clientFor(ownedNetwork)
+	if err != nil {
+		return nil, err
+	}
 
 	updateReq = NormalizeNetworkUpdateRequest(updateReq)
+	if err := ValidateNetworkUpdateRequest(updateReq); err != nil {
+		return nil, err
+	}
+	if err := checkControllerFeatures(client, updateReq); err != nil {
+		logger.Warn("service: network update uses a feature the controller does not support", zap.String("network_id", id), zap.Error(err))
+		return nil, err
+	}
 
 	// Update network in ZeroTier using partial update
-	updatedNetwork, err := s.ztClient.PartialUpdateNetwork(id, updateReq)
+	updatedNetwork, err := client.PartialUpdateNetwork(id, updateReq)
 	if err != nil {
 		logger.Error("service: failed to update network", zap.String("network_id", id), zap.Error(err))
 		return nil, err
@@ -496,15 +704,28 @@ func (s *NetworkService) UpdateNetwork(id string, updateReq *zerotier.NetworkUpd
 		}
 	}
 
-	return updatedNetwork, nil
+	return withStoredMetadata(updatedNetwork, ownedNetwork), nil
 }
 
-func (s *NetworkService) UpdateNetworkMetadata(id string, name string, description string, userID string) (*zerotier.Network, error) {
-	if s.ztClient == nil {
-		logger.Warn("service: ZeroTier client is not initialized")
-		return nil, fmt.Errorf("ZeroTier client is not initialized")
+// checkControllerFeatures rejects settings the controller's version does not accept. A
+// disabled single sign-on flag is dropped instead, since leaving it out means the same.
+func checkControllerFeatures(client *zerotier.Client, updateReq *zerotier.NetworkUpdateRequest) error {
+	features, err := client.ControllerFeatures()
+	if err != nil {
+		// The update itself reports a controller that cannot be reached
+		logger.Warn("service: failed to detect the controller version; sending the update unchecked", zap.Error(err))
+		return nil
+	}
+	if err := features.CheckNetworkUpdate(updateReq); err != nil {
+		return err
+	}
+	if !features.Supports(zerotier.FeatureSSO) {
+		updateReq.SsoEnabled = nil
 	}
+	return nil
+}
 
+func (s *NetworkService) UpdateNetworkMetadata(id string, name string, description string, userID string) (*zerotier.Network, error) {
 	db := s.getDB()
 	if db == nil {
 		logger.Warn("service: database is not initialized")
@@ -516,12 +737,16 @@ func (s *NetworkService) UpdateNetworkMetadata(id string, name string, descripti
 		logger.Warn("service: no permission to update network metadata", zap.String("network_id", id), zap.String("user_id", userID), zap.Error(err))
 		return nil, err
 	}
+	client, err := s.clientFor(ownedNetwork)
+	if err != nil {
+		return nil, err
+	}
 
 	// Update network name in the controller (name is synced to the controller)
 	updateReq := NormalizeNetworkUpdateRequest(&zerotier.NetworkUpdateRequest{
 		Name: name,
 	})
-	updatedNetwork, err := s.ztClient.PartialUpdateNetwork(id, updateReq)
+	updatedNetwork, err := client.PartialUpdateNetwork(id, updateReq)
 	if err != nil {
 		logger.Error("service: failed to update controller network name", zap.String("network_id", id), zap.Error(err))
 		return nil, err
@@ -537,30 +762,29 @@ func (s *NetworkService) UpdateNetworkMetadata(id string, name string, descripti
 
 	logger.Info("network metadata updated successfully", zap.String("network_id", id), zap.String("name", name))
 
-	return updatedNetwork, nil
+	return withStoredMetadata(updatedNetwork, ownedNetwork), nil
 }
 
 // DeleteNetwork deletes a network with ownership check
 func (s *NetworkService) DeleteNetwork(networkID string, userID string) error {
-	if s.ztClient == nil {
-		logger.Warn("service: ZeroTier client is not initialized")
-		return fmt.Errorf("ZeroTier client is not initialized")
-	}
-
 	db := s.getDB()
 	if db == nil {
 		logger.Warn("service: database is not initialized")
 		return fmt.Errorf("database is not initialized")
 	}
 
-	_, err := s.authorizeOwnedNetwork(networkID, userID)
+	ownedNetwork, err := s.authorizeOwnedNetwork(networkID, userID)
 	if err != nil {
 		logger.Warn("service: no permission to delete network", zap.String("network_id", networkID), zap.String("user_id", userID), zap.Error(err))
 		return err
 	}
+	client, err := s.clientFor(ownedNetwork)
+	if err != nil {
+		return err
+	}
 
 	// Delete network from ZeroTier
-	err = s.ztClient.DeleteNetwork(networkID)
+	err = client.DeleteNetwork(networkID)
 	if err != nil {
 		logger.Error("service: failed to delete network", zap.String("network_id", networkID), zap.Error(err))
 		return err
@@ -570,53 +794,76 @@ func (s *NetworkService) DeleteNetwork(networkID string, userID string) error {
 		if deleteErr := tx.DeleteAllNetworkViewers(networkID); deleteErr != nil {
 			return deleteErr
 		}
+		if deleteErr := tx.DeleteAllMemberMetadata(networkID); deleteErr != nil {
+			return deleteErr
+		}
+		if deleteErr := tx.DeleteAllPendingApprovals(networkID); deleteErr != nil {
+			return deleteErr
+		}
+		if deleteErr := tx.DeleteNetworkRuleSource(networkID); deleteErr != nil {
+			return deleteErr
+		}
+		if deleteErr := tx.DeleteAllNetworkTags(networkID); deleteErr != nil {
+			return deleteErr
+		}
+		if deleteErr := tx.DeleteAllNetworkCapabilities(networkID); deleteErr != nil {
+			return deleteErr
+		}
+		if deleteErr := tx.DeleteAllNetworkLockdowns(networkID); deleteErr != nil {
+			return deleteErr
+		}
 		return tx.DeleteNetwork(networkID)
 	}); err != nil {
 		logger.Error("service: failed to delete network and viewer grants from database", zap.String("network_id", networkID), zap.Error(err))
 		return fmt.Errorf("ZeroTier network deleted but database cleanup failed: %w", err)
 	}
+	s.dispatchEvent(WebhookEventNetworkDeleted, networkDeletedWebhookData(ownedNetwork, userID))
 
 	return nil
 }
 
 // GetNetworkMembers retrieves all members in a network with ownership check
 func (s *NetworkService) GetNetworkMembers(networkID string, userID string) ([]zerotier.Member, error) {
-	if s.ztClient == nil {
-		logger.Warn("service: ZeroTier client is not initialized")
-		return nil, fmt.Errorf("ZeroTier client is not initialized")
-	}
-
-	_, err := s.authorizeMemberReadAccess(networkID, userID)
+	network, err := s.authorizeMemberReadAccess(networkID, userID)
 	if err != nil {
 		logger.Warn("service: no permission to access network members", zap.String("network_id", networkID), zap.String("user_id", userID), zap.Error(err))
 		return nil, err
 	}
 
-	members, err := s.ztClient.GetMembers(networkID)
+	client, err := s.clientFor(network)
+	if err != nil {
+		return nil, err
+	}
+	members, err := client.GetMembers(networkID)
 	if err != nil {
 		logger.Error("service: failed to get network member list", zap.String("network_id", networkID), zap.Error(err))
 		return nil, err
 	}
 
-	s.enrichMembersWithPeerMetadata(members)
+	enrichMembersWithPeerMetadata(client, members)
+	s.attachMemberMetadata(networkID, members)
+	s.attachMemberTagNames(networkID, members)
+	applyPhysicalAddressPolicy(members, s.physicalAddressPolicyFor(network, userID))
 
 	return members, nil
 }
 
 // GetNetworkMember retrieves a specific member in a network with ownership check
 func (s *NetworkService) GetNetworkMember(networkID, memberID string, userID string) (*zerotier.Member, error) {
-	if s.ztClient == nil {
-		logger.Warn("service: ZeroTier client is not initialized")
-		return nil, fmt.Errorf("ZeroTier client is not initialized")
-	}
-
-	_, err := s.authorizeMemberReadAccess(networkID, userID)
+	network, err := s.authorizeMemberReadAccess(networkID, userID)
 	if err != nil {
 		logger.Warn("service: no permission to access network members", zap.String("network_id", networkID), zap.String("user_id", userID), zap.Error(err))
 		return nil, err
 	}
 
-	member, err := s.ztClient.GetMember(networkID, memberID)
+	client, err := s.clientFor(network)
+	if err != nil {
+		return nil, err
+	}
+	member, err := client.GetMember(networkID, memberID)
+	if zerotier.IsNotFound(err) {
+		return nil, ErrMemberNotFound
+	}
 	if err != nil {
 		logger.Error("service: failed to get network members", zap.String("network_id", networkID), zap.String("member_id", memberID), zap.Error(err))
 		return nil, err
@@ -627,42 +874,57 @@ func (s *NetworkService) GetNetworkMember(networkID, memberID string, userID str
 		return nil, nil
 	}
 
-	s.enrichMemberWithPeerMetadata(member)
+	enrichMemberWithPeerMetadata(client, member)
+	s.attachSingleMemberMetadata(networkID, member)
+	s.attachSingleMemberTagNames(networkID, member)
+	member.PreferredPath = maskPhysicalAddress(member.PreferredPath, s.physicalAddressPolicyFor(network, userID))
 
 	return member, nil
 }
 
 // UpdateNetworkMember updates a network member with ownership check
 func (s *NetworkService) UpdateNetworkMember(networkID, memberID string, member *zerotier.MemberUpdateRequest, userID string) (*zerotier.Member, error) {
-	if s.ztClient == nil {
-		logger.Warn("service: ZeroTier client is not initialized")
-		return nil, fmt.Errorf("ZeroTier client is not initialized")
-	}
-
-	_, err := s.authorizeMemberWriteAccess(networkID, userID)
+	network, err := s.authorizeMemberWriteAccess(networkID, userID)
 	if err != nil {
 		logger.Warn("service: no permission to update network member", zap.String("network_id", networkID), zap.String("user_id", userID), zap.Error(err))
 		return nil, err
 	}
+	if err := s.validateMemberTagIDs(networkID, member); err != nil {
+		return nil, err
+	}
+	client, err := s.clientFor(network)
+	if err != nil {
+		return nil, err
+	}
 
-	updatedMember, err := s.ztClient.UpdateMember(networkID, memberID, member)
+	updatedMember, err := client.UpdateMember(networkID, memberID, member)
 	if err != nil {
 		logger.Error("service: failed to update network member", zap.String("network_id", networkID), zap.String("member_id", memberID), zap.Error(err))
 		return nil, err
 	}
 	s.invalidateMemberStats(networkID)
+	s.notifyMemberChange(networkID)
+	if member.Authorized != nil {
+		event := WebhookEventMemberDeauthorized
+		if *member.Authorized {
+			event = WebhookEventMemberAuthorized
+		}
+		s.dispatchEvent(event, memberWebhookData(networkID, memberID, userID))
+	}
 
-	s.enrichMemberWithPeerMetadata(updatedMember)
+	enrichMemberWithPeerMetadata(client, updatedMember)
+	s.attachSingleMemberMetadata(networkID, updatedMember)
+	s.attachSingleMemberTagNames(networkID, updatedMember)
 
 	return updatedMember, nil
 }
 
-func (s *NetworkService) enrichMembersWithPeerMetadata(members []zerotier.Member) {
-	if s.ztClient == nil || len(members) == 0 {
+func enrichMembersWithPeerMetadata(client *zerotier.Client, members []zerotier.Member) {
+	if client == nil || len(members) == 0 {
 		return
 	}
 
-	peers, err := s.ztClient.GetPeers()
+	peers, err := client.GetPeers()
 	if err != nil {
 		logger.Warn("service: failed to get peer list; member metadata will not include peer-derived fields", zap.Error(err))
 		return
@@ -677,16 +939,19 @@ func (s *NetworkService) enrichMembersWithPeerMetadata(members []zerotier.Member
 	}
 
 	for index := range members {
-		enrichMemberPeerFields(&members[index], peerByAddress[members[index].Address])
+		peer, isPeer := peerByAddress[members[index].Address]
+		enrichMemberPeerFields(&members[index], peer)
+		// Controllers do not report online state themselves; a member is online while it is a peer
+		members[index].Online = members[index].Online || isPeer
 	}
 }
 
-func (s *NetworkService) enrichMemberWithPeerMetadata(member *zerotier.Member) {
-	if s.ztClient == nil || member == nil || member.Address == "" {
+func enrichMemberWithPeerMetadata(client *zerotier.Client, member *zerotier.Member) {
+	if client == nil || member == nil || member.Address == "" {
 		return
 	}
 
-	peers, err := s.ztClient.GetPeers()
+	peers, err := client.GetPeers()
 	if err != nil {
 		logger.Warn("service: failed to get peer list; single member metadata will not include peer-derived fields", zap.Error(err))
 		return
@@ -695,6 +960,7 @@ func (s *NetworkService) enrichMemberWithPeerMetadata(member *zerotier.Member) {
 	for _, peer := range peers {
 		if peer.Address == member.Address {
 			enrichMemberPeerFields(member, peer)
+			member.Online = true
 			return
 		}
 	}
@@ -728,23 +994,34 @@ func enrichMemberPeerFields(member *zerotier.Member, peer zerotier.Peer) {
 
 // RemoveNetworkMember removes a member from a network with ownership check
 func (s *NetworkService) RemoveNetworkMember(networkID, memberID string, userID string) error {
-	if s.ztClient == nil {
-		logger.Warn("service: ZeroTier client is not initialized")
-		return fmt.Errorf("ZeroTier client is not initialized")
-	}
-
-	_, err := s.authorizeMemberWriteAccess(networkID, userID)
+	network, err := s.authorizeMemberWriteAccess(networkID, userID)
 	if err != nil {
 		logger.Warn("service: no permission to remove network member", zap.String("network_id", networkID), zap.String("user_id", userID), zap.Error(err))
 		return err
 	}
+	client, err := s.clientFor(network)
+	if err != nil {
+		return err
+	}
 
-	err = s.ztClient.DeleteMember(networkID, memberID)
+	err = client.DeleteMember(networkID, memberID)
 	if err != nil {
 		logger.Error("service: failed to remove member from network", zap.String("network_id", networkID), zap.String("member_id", memberID), zap.Error(err))
 		return err
 	}
 	s.invalidateMemberStats(networkID)
+	s.notifyMemberChange(networkID)
+
+	if db := s.getDB(); db != nil {
+		if err := db.DeleteMemberMetadata(networkID, memberID); err != nil {
+			logger.Error("service: failed to delete member metadata", zap.String("network_id", networkID), zap.String("member_id", memberID), zap.Error(err))
+			return fmt.Errorf("ZeroTier member deleted but database cleanup failed: %w", err)
+		}
+		if err := db.DeletePendingApproval(networkID, memberID); err != nil {
+			logger.Error("service: failed to delete pending approval", zap.String("network_id", networkID), zap.String("member_id", memberID), zap.Error(err))
+			return fmt.Errorf("ZeroTier member deleted but database cleanup failed: %w", err)
+		}
+	}
 
 	return nil
 }
@@ -896,6 +1173,8 @@ func (s *NetworkService) SetZTClient(client *zerotier.Client) {
 	defer s.mutex.Unlock()
 	s.ztClient = client
 	s.memberStatsCache = make(map[string]networkMemberStats)
+	s.statusCache.reset()
+	s.controllers.set(config.DefaultControllerName, client, nil)
 }
 
 const importCandidateConcurrency = 4
@@ -916,17 +1195,17 @@ const (
 )
 
 type ImportableNetworkCandidate struct {
-	NetworkID        string `json:"network_id"`
+	NetworkID        string `json:"networkId"`
 	Name             string `json:"name,omitempty"`
 	Description      string `json:"description,omitempty"`
-	ControllerStatus string `json:"controller_status,omitempty"`
-	MemberCount      *int   `json:"member_count,omitempty"`
+	ControllerStatus string `json:"controllerStatus,omitempty"`
+	MemberCount      *int   `json:"memberCount,omitempty"`
 	Status           string `json:"status"`
-	CanImport        bool   `json:"can_import"`
-	ReasonCode       string `json:"reason_code"`
-	ReasonMessage    string `json:"reason_message"`
-	OwnerID          string `json:"owner_id,omitempty"`
-	OwnerUsername    string `json:"owner_username,omitempty"`
+	CanImport        bool   `json:"canImport"`
+	ReasonCode       string `json:"reasonCode"`
+	ReasonMessage    string `json:"reasonMessage"`
+	OwnerID          string `json:"ownerId,omitempty"`
+	OwnerUsername    string `json:"ownerUsername,omitempty"`
 }
 
 type ImportableNetworksSummary struct {
@@ -939,6 +1218,8 @@ type ImportableNetworksSummary struct {
 type ImportableNetworksResult struct {
 	Candidates []ImportableNetworkCandidate `json:"candidates"`
 	Summary    ImportableNetworksSummary    `json:"summary"`
+	// Warnings name the controller networks that could not be read; they are listed as blocked
+	Warnings []string `json:"warnings,omitempty"`
 }
 
 type ImportTargetOwner struct {
@@ -947,12 +1228,12 @@ type ImportTargetOwner struct {
 }
 
 type ImportNetworkResultItem struct {
-	NetworkID     string `json:"network_id"`
+	NetworkID     string `json:"networkId"`
 	Name          string `json:"name,omitempty"`
-	OwnerID       string `json:"owner_id,omitempty"`
-	OwnerUsername string `json:"owner_username,omitempty"`
-	ReasonCode    string `json:"reason_code,omitempty"`
-	ReasonMessage string `json:"reason_message,omitempty"`
+	OwnerID       string `json:"ownerId,omitempty"`
+	OwnerUsername string `json:"ownerUsername,omitempty"`
+	ReasonCode    string `json:"reasonCode,omitempty"`
+	ReasonMessage string `json:"reasonMessage,omitempty"`
 }
 
 type ImportNetworksSummary struct {
@@ -963,31 +1244,54 @@ type ImportNetworksSummary struct {
 }
 
 type ImportNetworksResult struct {
-	TargetOwner ImportTargetOwner         `json:"target_owner"`
+	TargetOwner ImportTargetOwner         `json:"targetOwner"`
 	Summary     ImportNetworksSummary     `json:"summary"`
 	Imported    []ImportNetworkResultItem `json:"imported"`
 	Failed      []ImportNetworkResultItem `json:"failed"`
 	Skipped     []ImportNetworkResultItem `json:"skipped"`
 }
 
-// GetImportableNetworks retrieves the list of controller takeover candidates
-func (s *NetworkService) GetImportableNetworks() (*ImportableNetworksResult, error) {
+// GetImportableNetworks retrieves the takeover candidates of the named controller; an empty
+// name means the default controller
+func (s *NetworkService) GetImportableNetworks(controller string) (*ImportableNetworksResult, error) {
 	db := s.getDB()
 	if db == nil {
 		logger.Warn("service: database is not initialized")
 		return nil, fmt.Errorf("database is not initialized")
 	}
 
-	if s.ztClient == nil {
-		logger.Warn("service: ZeroTier client is not initialized")
-		return nil, fmt.Errorf("ZeroTier client is not initialized")
+	client, err := s.controllers.Client(controller)
+	if err != nil {
+		logger.Warn("service: ZeroTier client is not initialized", zap.String("controller", controller), zap.Error(err))
+		return nil, err
 	}
 
-	ztNetworkIDs, err := s.ztClient.GetNetworkIDs()
-	if err != nil {
-		logger.Error("service: failed to get ZeroTier network ID list", zap.Error(err))
+	// A network that cannot be read is still listed, as blocked, next to the others
+	ztNetworks, err := client.GetNetworks()
+	var listErr *zerotier.NetworkListError
+	if err != nil && !errors.As(err, &listErr) {
+		logger.Error("service: failed to get ZeroTier network list", zap.Error(err))
 		return nil, err
 	}
+	ztNetworkByID := make(map[string]*zerotier.Network, len(ztNetworks))
+	ztNetworkIDs := make([]string, 0, len(ztNetworks))
+	for i := range ztNetworks {
+		ztNetworkByID[ztNetworks[i].ID] = &ztNetworks[i]
+		ztNetworkIDs = append(ztNetworkIDs, ztNetworks[i].ID)
+	}
+	var warnings []string
+	if listErr != nil {
+		for networkID, readErr := range listErr.Failures {
+			logger.Warn("service: failed to read controller network", zap.String("network_id", networkID), zap.Error(readErr))
+			ztNetworkIDs = append(ztNetworkIDs, networkID)
+		}
+		sort.Strings(ztNetworkIDs)
+		for _, networkID := range ztNetworkIDs {
+			if readErr, failed := listErr.Failures[networkID]; failed {
+				warnings = append(warnings, fmt.Sprintf("Network %s could not be read from the controller: %v", networkID, readErr))
+			}
+		}
+	}
 
 	dbNetworks, err := db.GetAllNetworks()
 	if err != nil {
@@ -1024,8 +1328,7 @@ func (s *NetworkService) GetImportableNetworks() (*ImportableNetworksResult, err
 				<-limiter
 			}()
 
-			dbNet := dbNetworkMap[id]
-			candidates[i] = s.buildImportCandidate(id, dbNet, usernameByID)
+			candidates[i] = buildImportCandidate(client, id, ztNetworkByID[id], dbNetworkMap[id], usernameByID)
 		}(index, networkID)
 	}
 
@@ -1036,6 +1339,7 @@ func (s *NetworkService) GetImportableNetworks() (*ImportableNetworksResult, err
 		Summary: ImportableNetworksSummary{
 			Total: len(candidates),
 		},
+		Warnings: warnings,
 	}
 
 	for _, candidate := range candidates {
@@ -1052,11 +1356,17 @@ func (s *NetworkService) GetImportableNetworks() (*ImportableNetworksResult, err
 	return result, nil
 }
 
-// ImportNetworks imports the specified networks
-func (s *NetworkService) ImportNetworks(networkIDs []string, ownerID string, actorRole string) (*ImportNetworksResult, error) {
-	if s.ztClient == nil {
-		logger.Warn("service: ZeroTier client is not initialized")
-		return nil, fmt.Errorf("ZeroTier client is not initialized")
+// ImportNetworks imports the specified networks of the named controller; an empty name means
+// the default controller
+func (s *NetworkService) ImportNetworks(controller string, networkIDs []string, ownerID string, actorRole string) (*ImportNetworksResult, error) {
+	controller, err := s.controllers.Resolve(controller)
+	if err != nil {
+		return nil, err
+	}
+	client, err := s.controllers.Client(controller)
+	if err != nil {
+		logger.Warn("service: ZeroTier client is not initialized", zap.String("controller", controller), zap.Error(err))
+		return nil, err
 	}
 
 	db := s.getDB()
@@ -1101,7 +1411,7 @@ func (s *NetworkService) ImportNetworks(networkIDs []string, ownerID string, act
 	}
 
 	// Retrieve all ZeroTier network IDs from the controller
-	ztNetworkIDs, err := s.ztClient.GetNetworkIDs()
+	ztNetworkIDs, err := client.GetNetworkIDs()
 	if err != nil {
 		logger.Error("service: failed to get ZeroTier network ID list", zap.Error(err))
 		return nil, err
@@ -1157,7 +1467,7 @@ func (s *NetworkService) ImportNetworks(networkIDs []string, ownerID string, act
 
 		if !dbExists {
 			// Fetch full network details from ZeroTier
-			ztNet, err := s.ztClient.GetNetwork(networkID)
+			ztNet, err := client.GetNetwork(networkID)
 			if err != nil {
 				logger.Error("Failed to read network details", zap.String("network_id", networkID), zap.Error(err))
 				result.Failed = append(result.Failed, ImportNetworkResultItem{
@@ -1176,6 +1486,7 @@ func (s *NetworkService) ImportNetworks(networkIDs []string, ownerID string, act
 				Name:        ztNet.Name,
 				Description: ztNet.Description,
 				OwnerID:     ownerID,
+				Controller:  storedControllerName(controller),
 				CreatedAt:   now,
 				UpdatedAt:   now,
 			}
@@ -1201,10 +1512,37 @@ func (s *NetworkService) ImportNetworks(networkIDs []string, ownerID string, act
 				OwnerUsername: owner.Username,
 			})
 		} else if dbNet.OwnerID == "" {
-			dbNet.OwnerID = ownerID
-			dbNet.UpdatedAt = now
-
-			if err := db.UpdateNetwork(dbNet); err != nil {
+			// Re-read the record inside the transaction so a network claimed by a concurrent import is not overwritten
+			claimedBy := ""
+			err := db.WithTransaction(func(tx database.DBInterface) error {
+				current, err := tx.GetNetworkByID(networkID)
+				if err != nil {
+					return err
+				}
+				if current == nil {
+					return fmt.Errorf("network record disappeared during import")
+				}
+				if current.OwnerID != "" {
+					claimedBy = current.OwnerID
+					return nil
+				}
+				current.OwnerID = ownerID
+				current.UpdatedAt = now
+				return tx.UpdateNetwork(current)
+			})
+			if err == nil && claimedBy != "" {
+				logger.Warn("Network was claimed by another import and was skipped", zap.String("network_id", networkID), zap.String("owner_id", claimedBy))
+				result.Skipped = append(result.Skipped, ImportNetworkResultItem{
+					NetworkID:     networkID,
+					Name:          dbNet.Name,
+					OwnerID:       claimedBy,
+					OwnerUsername: usernamesByID[claimedBy],
+					ReasonCode:    ImportReasonAlreadyManaged,
+					ReasonMessage: "Network is already managed by another owner and was skipped",
+				})
+				continue
+			}
+			if err != nil {
 				logger.Error("Failed to update network owner", zap.String("network_id", networkID), zap.Error(err))
 				result.Failed = append(result.Failed, ImportNetworkResultItem{
 					NetworkID:     networkID,
@@ -1255,7 +1593,8 @@ func (s *NetworkService) ImportNetworks(networkIDs []string, ownerID string, act
 	return result, nil
 }
 
-func (s *NetworkService) buildImportCandidate(networkID string, dbNet *models.Network, usernameByUserID map[string]string) ImportableNetworkCandidate {
+// buildImportCandidate describes a controller network; ztNet is nil when it could not be read
+func buildImportCandidate(client *zerotier.Client, networkID string, ztNet *zerotier.Network, dbNet *models.Network, usernameByUserID map[string]string) ImportableNetworkCandidate {
 	candidate := ImportableNetworkCandidate{
 		NetworkID: networkID,
 	}
@@ -1267,8 +1606,7 @@ func (s *NetworkService) buildImportCandidate(networkID string, dbNet *models.Ne
 		candidate.OwnerUsername = usernameByUserID[dbNet.OwnerID]
 	}
 
-	ztNet, err := s.ztClient.GetNetwork(networkID)
-	if err == nil && ztNet != nil {
+	if ztNet != nil {
 		if ztNet.Name != "" {
 			candidate.Name = ztNet.Name
 		}
@@ -1277,7 +1615,7 @@ func (s *NetworkService) buildImportCandidate(networkID string, dbNet *models.Ne
 		}
 		candidate.ControllerStatus = ztNet.Status
 
-		members, memberErr := s.ztClient.GetMembers(networkID)
+		members, memberErr := client.GetMembers(networkID)
 		if memberErr != nil {
 			logger.Warn("Failed to get member count for import candidate", zap.String("network_id", networkID), zap.Error(memberErr))
 		} else {
@@ -1287,7 +1625,7 @@ func (s *NetworkService) buildImportCandidate(networkID string, dbNet *models.Ne
 	}
 
 	switch {
-	case dbNet == nil && err == nil:
+	case dbNet == nil && ztNet != nil:
 		candidate.Status = ImportCandidateAvailable
 		candidate.CanImport = true
 		candidate.ReasonCode = ImportReasonUnregistered
@@ -1315,3 +1653,18 @@ func (s *NetworkService) buildImportCandidate(networkID string, dbNet *models.Ne
 
 	return candidate
 }
+
+// controllerNameOf returns the name of the controller hosting network
+func controllerNameOf(network *models.Network) string {
+	if network.Controller == "" {
+		return config.DefaultControllerName
+	}
+	return network.Controller
+}
+
+func organizationIDOf(network *models.Network) string {
+	if network.OrganizationID == "" {
+		return models.DefaultOrganizationID
+	}
+	return network.OrganizationID
+}
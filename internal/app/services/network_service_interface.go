@@ -0,0 +1,73 @@
+package services
+
+import (
+	"time"
+
+	"github.com/GT-610/tairitsu/internal/app/models"
+	"github.com/GT-610/tairitsu/internal/zerotier"
+)
+
+// NetworkServiceInterface is what the network and member handlers need from NetworkService,
+// so they can be tested against a fake
+type NetworkServiceInterface interface {
+	// Controller operations
+	Controllers() *ControllerRegistry
+	GetRuntimeStatus(fresh bool) *RuntimeStatus
+	GetControllerPeers(controller string, role string) (*ControllerPeers, error)
+	GetImportableNetworks(controller string) (*ImportableNetworksResult, error)
+	ImportNetworks(controller string, networkIDs []string, ownerID string, actorRole string) (*ImportNetworksResult, error)
+
+	// Network operations
+	ListNetworks(userID, organizationID string, memberCounts bool) ([]NetworkSummary, error)
+	GetSharedNetworks(userID string) ([]SharedNetworkSummary, error)
+	GetNetworkByID(id string, userID string) (*NetworkDetail, error)
+	CreateNetworkWithSettings(controller string, network *zerotier.Network, settings *zerotier.NetworkUpdateRequest, ownerID string) (*zerotier.Network, error)
+	UpdateNetwork(id string, updateReq *zerotier.NetworkUpdateRequest, userID string) (*zerotier.Network, error)
+	UpdateNetworkMetadata(id string, name string, description string, userID string) (*zerotier.Network, error)
+	DeleteNetwork(networkID string, userID string) error
+	ExportNetwork(networkID string, userID string) (*NetworkBackup, error)
+	RestoreNetwork(controller string, backup *NetworkBackup, ownerID string) (*NetworkRestoreResult, error)
+	StartNetworkBatch(req NetworkBatchRequest, userID string) (*OperationDetail, error)
+	GetNetworkIPv6Prefixes(networkID, userID string) (*NetworkIPv6Prefixes, error)
+	GetNetworkIPUsage(networkID string, nextFree int, userID string) (*NetworkIPUsage, error)
+	GetNetworkPrivacy(networkID string, userID string) (*NetworkPrivacy, error)
+	UpdateNetworkPrivacy(networkID string, policy string, userID string) (*NetworkPrivacy, error)
+	GetNetworkPolicies(networkID string, userID string) (*NetworkPolicies, error)
+	UpdateNetworkPolicies(networkID string, policies NetworkPolicies, userID string) (*NetworkPolicies, error)
+	PreviewInactivity(networkID string, days int, userID string) (*InactivityReport, error)
+	GetNetworkRules(networkID string, userID string) (*NetworkRulesDetail, error)
+	UpdateNetworkRules(networkID string, source string, userID string) (*NetworkRulesDetail, error)
+	LockdownNetwork(networkID string, except []string, userID string) (*NetworkLockdownResult, error)
+	RollbackNetworkLockdown(networkID string, userID string) (*NetworkLockdownResult, error)
+
+	// Viewer operations
+	GetNetworkViewers(networkID, ownerID string) ([]NetworkViewerSummary, error)
+	GetNetworkViewerCandidates(networkID, ownerID string) ([]NetworkViewerSummary, error)
+	GrantNetworkViewer(networkID, targetUserID, ownerID string) error
+	RevokeNetworkViewer(networkID, targetUserID, ownerID string) error
+
+	// Tag and capability operations
+	ListNetworkTags(networkID, userID string) ([]*models.NetworkTag, error)
+	CreateNetworkTag(networkID string, input NetworkTagInput, userID string) (*models.NetworkTag, error)
+	UpdateNetworkTag(networkID string, tagID uint32, input NetworkTagInput, userID string) (*models.NetworkTag, error)
+	DeleteNetworkTag(networkID string, tagID uint32, userID string) error
+	ListNetworkCapabilities(networkID, userID string) ([]*models.NetworkCapability, error)
+	CreateNetworkCapability(networkID string, input NetworkCapabilityInput, userID string) (*models.NetworkCapability, error)
+	DeleteNetworkCapability(networkID string, capabilityID uint32, userID string) error
+
+	// Member operations
+	GetNetworkMembers(networkID string, userID string) ([]zerotier.Member, error)
+	GetNetworkMembersPage(networkID string, userID string, query MemberListQuery, req PageRequest) (*MemberPage, error)
+	ExportNetworkMembers(networkID string, userID string, query MemberListQuery) ([]zerotier.Member, error)
+	GetNetworkMember(networkID, memberID string, userID string) (*zerotier.Member, error)
+	GetMemberStatusHistory(networkID, memberID, userID string, query MemberStatusHistoryQuery) (*MemberStatusHistory, error)
+	GetMemberTraceEvents(networkID, memberID, userID string, limit int) ([]*models.ControllerTraceEvent, error)
+	UpdateNetworkMember(networkID, memberID string, member *zerotier.MemberUpdateRequest, userID string) (*zerotier.Member, error)
+	UpdateMemberMetadata(networkID, memberID string, update MemberMetadataUpdate, userID string) (*zerotier.MemberMetadata, error)
+	SetMemberAuthorizedUntil(networkID, memberID string, until *time.Time, userID string) (*zerotier.MemberMetadata, error)
+	SetMemberTags(networkID, memberID string, values map[string]TagValue, userID string) (*zerotier.Member, error)
+	SetMemberCapabilities(networkID, memberID string, names []string, userID string) (*zerotier.Member, error)
+	RemoveNetworkMember(networkID, memberID string, userID string) error
+}
+
+var _ NetworkServiceInterface = (*NetworkService)(nil)
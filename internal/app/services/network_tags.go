@@ -0,0 +1,456 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/GT-610/tairitsu/internal/app/logger"
+	"github.com/GT-610/tairitsu/internal/app/models"
+	"github.com/GT-610/tairitsu/internal/zerotier"
+	"go.uber.org/zap"
+)
+
+const (
+	maxNetworkTagNameLen = 64
+	maxNetworkTagValues  = 64
+)
+
+var (
+	ErrTagNotFound           = errors.New("tag not found")
+	ErrCapabilityNotFound    = errors.New("capability not found")
+	ErrTagConflict           = errors.New("a tag with this name or ID is already defined")
+	ErrCapabilityConflict    = errors.New("a capability with this name or ID is already defined")
+	ErrInvalidTagDefinition  = errors.New("invalid tag or capability definition")
+	ErrUndefinedMemberTag    = errors.New("tag or capability is not defined on the network")
+	ErrInvalidMemberTagValue = errors.New("invalid member tag value")
+)
+
+// NetworkTagInput defines or changes a named tag. Values names well-known tag values.
+type NetworkTagInput struct {
+	ID     uint32            `json:"id"`
+	Name   string            `json:"name"`
+	Values map[string]uint32 `json:"values"`
+}
+
+// NetworkCapabilityInput defines a named capability
+type NetworkCapabilityInput struct {
+	ID   uint32 `json:"id"`
+	Name string `json:"name"`
+}
+
+// TagValue is a tag value given either as a number or as the name of one of the tag's values
+type TagValue struct {
+	Number *uint32
+	Name   string
+}
+
+func (v *TagValue) UnmarshalJSON(data []byte) error {
+	var number uint32
+	if err := json.Unmarshal(data, &number); err == nil {
+		v.Number = &number
+		return nil
+	}
+	if err := json.Unmarshal(data, &v.Name); err != nil {
+		return fmt.Errorf("tag value must be a number or a value name")
+	}
+	return nil
+}
+
+// validateTagName accepts names that cannot be confused with numeric IDs
+func validateTagName(name string) error {
+	if name == "" || utf8.RuneCountInString(name) > maxNetworkTagNameLen {
+		return fmt.Errorf("%w: names must be 1 to %d characters", ErrInvalidTagDefinition, maxNetworkTagNameLen)
+	}
+	if _, err := strconv.ParseUint(name, 10, 32); err == nil {
+		return fmt.Errorf("%w: name %q must not be a number", ErrInvalidTagDefinition, name)
+	}
+	return nil
+}
+
+func (input NetworkTagInput) normalize() (NetworkTagInput, error) {
+	input.Name = strings.TrimSpace(input.Name)
+	if err := validateTagName(input.Name); err != nil {
+		return input, err
+	}
+	if len(input.Values) > maxNetworkTagValues {
+		return input, fmt.Errorf("%w: a tag can name at most %d values", ErrInvalidTagDefinition, maxNetworkTagValues)
+	}
+	values := make(map[string]uint32, len(input.Values))
+	for name, value := range input.Values {
+		name = strings.TrimSpace(name)
+		if err := validateTagName(name); err != nil {
+			return input, err
+		}
+		values[name] = value
+	}
+	input.Values = values
+	return input, nil
+}
+
+// ListNetworkTags returns the tag definitions of a network to anyone who can read its members
+func (s *NetworkService) ListNetworkTags(networkID, userID string) ([]*models.NetworkTag, error) {
+	if _, err := s.authorizeMemberReadAccess(networkID, userID); err != nil {
+		return nil, err
+	}
+	return s.getDB().ListNetworkTags(networkID)
+}
+
+// CreateNetworkTag defines a named tag on an owned network
+func (s *NetworkService) CreateNetworkTag(networkID string, input NetworkTagInput, userID string) (*models.NetworkTag, error) {
+	input, err := input.normalize()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.authorizeOwnedNetwork(networkID, userID); err != nil {
+		logger.Warn("service: no permission to define network tags", zap.String("network_id", networkID), zap.String("user_id", userID), zap.Error(err))
+		return nil, err
+	}
+
+	db := s.getDB()
+	tags, err := db.ListNetworkTags(networkID)
+	if err != nil {
+		return nil, err
+	}
+	for _, existing := range tags {
+		if existing.TagID == input.ID || existing.Name == input.Name {
+			return nil, ErrTagConflict
+		}
+	}
+
+	now := time.Now()
+	tag := &models.NetworkTag{
+		NetworkID: networkID,
+		TagID:     input.ID,
+		Name:      input.Name,
+		Values:    input.Values,
+		CreatedBy: userID,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if err := db.CreateNetworkTag(tag); err != nil {
+		logger.Error("service: failed to create network tag", zap.String("network_id", networkID), zap.Error(err))
+		return nil, err
+	}
+	return tag, nil
+}
+
+// UpdateNetworkTag renames a tag of an owned network and replaces its named values; the ID
+// stays, so members keep their assignments
+func (s *NetworkService) UpdateNetworkTag(networkID string, tagID uint32, input NetworkTagInput, userID string) (*models.NetworkTag, error) {
+	input, err := input.normalize()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.authorizeOwnedNetwork(networkID, userID); err != nil {
+		logger.Warn("service: no permission to update network tags", zap.String("network_id", networkID), zap.String("user_id", userID), zap.Error(err))
+		return nil, err
+	}
+
+	db := s.getDB()
+	tags, err := db.ListNetworkTags(networkID)
+	if err != nil {
+		return nil, err
+	}
+	var tag *models.NetworkTag
+	for _, existing := range tags {
+		switch {
+		case existing.TagID == tagID:
+			tag = existing
+		case existing.Name == input.Name:
+			return nil, ErrTagConflict
+		}
+	}
+	if tag == nil {
+		return nil, ErrTagNotFound
+	}
+
+	tag.Name = input.Name
+	tag.Values = input.Values
+	tag.UpdatedAt = time.Now()
+	if err := db.UpdateNetworkTag(tag); err != nil {
+		logger.Error("service: failed to update network tag", zap.String("network_id", networkID), zap.Uint32("tag_id", tagID), zap.Error(err))
+		return nil, err
+	}
+	return tag, nil
+}
+
+// DeleteNetworkTag removes a tag definition of an owned network. Members keep the numeric tag
+// on the controller; it is only no longer named.
+func (s *NetworkService) DeleteNetworkTag(networkID string, tagID uint32, userID string) error {
+	if _, err := s.authorizeOwnedNetwork(networkID, userID); err != nil {
+		logger.Warn("service: no permission to delete network tags", zap.String("network_id", networkID), zap.String("user_id", userID), zap.Error(err))
+		return err
+	}
+	tag, err := s.findNetworkTag(networkID, tagID)
+	if err != nil {
+		return err
+	}
+	return s.getDB().DeleteNetworkTag(networkID, tag.TagID)
+}
+
+func (s *NetworkService) findNetworkTag(networkID string, tagID uint32) (*models.NetworkTag, error) {
+	tags, err := s.getDB().ListNetworkTags(networkID)
+	if err != nil {
+		return nil, err
+	}
+	for _, tag := range tags {
+		if tag.TagID == tagID {
+			return tag, nil
+		}
+	}
+	return nil, ErrTagNotFound
+}
+
+// ListNetworkCapabilities returns the capability definitions of a network to anyone who can
+// read its members
+func (s *NetworkService) ListNetworkCapabilities(networkID, userID string) ([]*models.NetworkCapability, error) {
+	if _, err := s.authorizeMemberReadAccess(networkID, userID); err != nil {
+		return nil, err
+	}
+	return s.getDB().ListNetworkCapabilities(networkID)
+}
+
+// CreateNetworkCapability defines a named capability on an owned network
+func (s *NetworkService) CreateNetworkCapability(networkID string, input NetworkCapabilityInput, userID string) (*models.NetworkCapability, error) {
+	input.Name = strings.TrimSpace(input.Name)
+	if err := validateTagName(input.Name); err != nil {
+		return nil, err
+	}
+	if _, err := s.authorizeOwnedNetwork(networkID, userID); err != nil {
+		logger.Warn("service: no permission to define network capabilities", zap.String("network_id", networkID), zap.String("user_id", userID), zap.Error(err))
+		return nil, err
+	}
+
+	db := s.getDB()
+	capabilities, err := db.ListNetworkCapabilities(networkID)
+	if err != nil {
+		return nil, err
+	}
+	for _, existing := range capabilities {
+		if existing.CapabilityID == input.ID || existing.Name == input.Name {
+			return nil, ErrCapabilityConflict
+		}
+	}
+
+	capability := &models.NetworkCapability{
+		NetworkID:    networkID,
+		CapabilityID: input.ID,
+		Name:         input.Name,
+		CreatedBy:    userID,
+		CreatedAt:    time.Now(),
+	}
+	if err := db.CreateNetworkCapability(capability); err != nil {
+		logger.Error("service: failed to create network capability", zap.String("network_id", networkID), zap.Error(err))
+		return nil, err
+	}
+	return capability, nil
+}
+
+// DeleteNetworkCapability removes a capability definition of an owned network
+func (s *NetworkService) DeleteNetworkCapability(networkID string, capabilityID uint32, userID string) error {
+	if _, err := s.authorizeOwnedNetwork(networkID, userID); err != nil {
+		logger.Warn("service: no permission to delete network capabilities", zap.String("network_id", networkID), zap.String("user_id", userID), zap.Error(err))
+		return err
+	}
+	db := s.getDB()
+	capabilities, err := db.ListNetworkCapabilities(networkID)
+	if err != nil {
+		return err
+	}
+	for _, capability := range capabilities {
+		if capability.CapabilityID == capabilityID {
+			return db.DeleteNetworkCapability(networkID, capabilityID)
+		}
+	}
+	return ErrCapabilityNotFound
+}
+
+// SetMemberTags replaces the tags of a member. Keys are tag names and values are numbers or
+// names of the tag's values; every tag must be defined on the network.
+func (s *NetworkService) SetMemberTags(networkID, memberID string, values map[string]TagValue, userID string) (*zerotier.Member, error) {
+	if _, err := s.authorizeMemberWriteAccess(networkID, userID); err != nil {
+		return nil, err
+	}
+	tags, err := s.getDB().ListNetworkTags(networkID)
+	if err != nil {
+		return nil, err
+	}
+	byName := make(map[string]*models.NetworkTag, len(tags))
+	for _, tag := range tags {
+		byName[tag.Name] = tag
+	}
+
+	pairs := make(zerotier.TagPairs, 0, len(values))
+	for name, value := range values {
+		tag, ok := byName[name]
+		if !ok {
+			return nil, fmt.Errorf("%w: tag %q", ErrUndefinedMemberTag, name)
+		}
+		number, err := resolveTagValue(tag, value)
+		if err != nil {
+			return nil, err
+		}
+		pairs = append(pairs, zerotier.Tag{ID: int(tag.TagID), Value: int(number)})
+	}
+	sort.Slice(pairs, func(i, j int) bool { return pairs[i].ID < pairs[j].ID })
+
+	return s.UpdateNetworkMember(networkID, memberID, &zerotier.MemberUpdateRequest{Tags: &pairs}, userID)
+}
+
+// SetMemberCapabilities replaces the capabilities of a member with the named ones; every
+// capability must be defined on the network
+func (s *NetworkService) SetMemberCapabilities(networkID, memberID string, names []string, userID string) (*zerotier.Member, error) {
+	if _, err := s.authorizeMemberWriteAccess(networkID, userID); err != nil {
+		return nil, err
+	}
+	capabilities, err := s.getDB().ListNetworkCapabilities(networkID)
+	if err != nil {
+		return nil, err
+	}
+	byName := make(map[string]uint32, len(capabilities))
+	for _, capability := range capabilities {
+		byName[capability.Name] = capability.CapabilityID
+	}
+
+	seen := make(map[uint32]bool, len(names))
+	ids := make([]int, 0, len(names))
+	for _, name := range names {
+		id, ok := byName[name]
+		if !ok {
+			return nil, fmt.Errorf("%w: capability %q", ErrUndefinedMemberTag, name)
+		}
+		if !seen[id] {
+			seen[id] = true
+			ids = append(ids, int(id))
+		}
+	}
+	sort.Ints(ids)
+
+	return s.UpdateNetworkMember(networkID, memberID, &zerotier.MemberUpdateRequest{Capabilities: &ids}, userID)
+}
+
+func resolveTagValue(tag *models.NetworkTag, value TagValue) (uint32, error) {
+	if value.Number != nil {
+		return *value.Number, nil
+	}
+	if number, ok := tag.Values[value.Name]; ok {
+		return number, nil
+	}
+	if number, err := strconv.ParseUint(value.Name, 10, 32); err == nil {
+		return uint32(number), nil
+	}
+	return 0, fmt.Errorf("%w: tag %q has no value named %q", ErrInvalidMemberTagValue, tag.Name, value.Name)
+}
+
+// validateMemberTagIDs rejects member updates that assign tag or capability IDs the network
+// has not defined, however the update reached the service
+func (s *NetworkService) validateMemberTagIDs(networkID string, update *zerotier.MemberUpdateRequest) error {
+	if update.Tags != nil {
+		tags, err := s.getDB().ListNetworkTags(networkID)
+		if err != nil {
+			return err
+		}
+		defined := make(map[int]bool, len(tags))
+		for _, tag := range tags {
+			defined[int(tag.TagID)] = true
+		}
+		for _, tag := range *update.Tags {
+			if !defined[tag.ID] {
+				return fmt.Errorf("%w: tag ID %d", ErrUndefinedMemberTag, tag.ID)
+			}
+			if tag.Value < 0 || int64(tag.Value) > math.MaxUint32 {
+				return fmt.Errorf("%w: tag %d value %d is out of range", ErrInvalidMemberTagValue, tag.ID, tag.Value)
+			}
+		}
+	}
+
+	if update.Capabilities != nil {
+		capabilities, err := s.getDB().ListNetworkCapabilities(networkID)
+		if err != nil {
+			return err
+		}
+		defined := make(map[int]bool, len(capabilities))
+		for _, capability := range capabilities {
+			defined[int(capability.CapabilityID)] = true
+		}
+		for _, id := range *update.Capabilities {
+			if !defined[id] {
+				return fmt.Errorf("%w: capability ID %d", ErrUndefinedMemberTag, id)
+			}
+		}
+	}
+	return nil
+}
+
+// attachMemberTagNames resolves the numeric tags and capabilities of members against the
+// network's definitions. The numbers stay usable without names, so failures are only logged.
+func (s *NetworkService) attachMemberTagNames(networkID string, members []zerotier.Member) {
+	db := s.getDB()
+	if db == nil || len(members) == 0 {
+		return
+	}
+	tags, err := db.ListNetworkTags(networkID)
+	if err != nil {
+		logger.Warn("service: failed to load network tags", zap.String("network_id", networkID), zap.Error(err))
+		return
+	}
+	capabilities, err := db.ListNetworkCapabilities(networkID)
+	if err != nil {
+		logger.Warn("service: failed to load network capabilities", zap.String("network_id", networkID), zap.Error(err))
+		return
+	}
+
+	tagsByID := make(map[int]*models.NetworkTag, len(tags))
+	for _, tag := range tags {
+		tagsByID[int(tag.TagID)] = tag
+	}
+	capabilityNames := make(map[int]string, len(capabilities))
+	for _, capability := range capabilities {
+		capabilityNames[int(capability.CapabilityID)] = capability.Name
+	}
+
+	for index := range members {
+		member := &members[index]
+		member.ResolvedTags = nil
+		for _, assigned := range member.Config.Tags {
+			resolved := zerotier.ResolvedTag{ID: assigned.ID, Value: assigned.Value}
+			if tag, ok := tagsByID[assigned.ID]; ok {
+				resolved.Name = tag.Name
+				resolved.ValueName = tagValueName(tag, assigned.Value)
+			}
+			member.ResolvedTags = append(member.ResolvedTags, resolved)
+		}
+		member.ResolvedCapabilities = nil
+		for _, id := range member.Config.Capabilities {
+			member.ResolvedCapabilities = append(member.ResolvedCapabilities, zerotier.ResolvedCapability{ID: id, Name: capabilityNames[id]})
+		}
+	}
+}
+
+func (s *NetworkService) attachSingleMemberTagNames(networkID string, member *zerotier.Member) {
+	if member == nil {
+		return
+	}
+	members := []zerotier.Member{*member}
+	s.attachMemberTagNames(networkID, members)
+	*member = members[0]
+}
+
+// tagValueName picks the name of a value; with several names for one value the first in
+// alphabetical order wins so responses are stable
+func tagValueName(tag *models.NetworkTag, value int) string {
+	name := ""
+	for candidate, number := range tag.Values {
+		if int(number) == value && (name == "" || candidate < name) {
+			name = candidate
+		}
+	}
+	return name
+}
@@ -0,0 +1,425 @@
+package services
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/GT-610/tairitsu/internal/app/config"
+	"github.com/GT-610/tairitsu/internal/app/logger"
+	"github.com/GT-610/tairitsu/internal/app/models"
+	"github.com/golang-jwt/jwt/v4"
+	"go.uber.org/zap"
+)
+
+// oidcSigningMethods are the ID token algorithms accepted from the provider
+var oidcSigningMethods = []string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512"}
+
+// OIDCIdentity is the verified identity carried by a provider's ID token
+type OIDCIdentity struct {
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Username      string
+	Roles         []string
+}
+
+// oidcProviderMetadata is the part of the discovery document Tairitsu uses
+type oidcProviderMetadata struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// OIDCService signs users in through an external OpenID Connect provider using the
+// authorization code flow. Provider metadata and signing keys are fetched on first use, so
+// Tairitsu starts even while the provider is unreachable.
+type OIDCService struct {
+	settings    config.OIDCConfig
+	userService *UserService
+	httpClient  *http.Client
+
+	mutex    sync.Mutex
+	metadata *oidcProviderMetadata
+	keys     map[string]crypto.PublicKey
+}
+
+// NewOIDCService creates the OpenID Connect sign-in service; zero settings leave it disabled
+func NewOIDCService(settings config.OIDCConfig, userService *UserService) *OIDCService {
+	return &OIDCService{
+		settings:    settings,
+		userService: userService,
+		httpClient:  &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Enabled reports whether OpenID Connect sign-in is configured
+func (s *OIDCService) Enabled() bool {
+	return s != nil && s.settings.Enabled
+}
+
+// PasswordLoginDisabled reports whether local password sign-in is turned off in favour of the provider
+func (s *OIDCService) PasswordLoginDisabled() bool {
+	return s.Enabled() && s.settings.DisablePasswordLogin
+}
+
+// PostLoginRedirect is where the browser is sent once the callback has finished
+func (s *OIDCService) PostLoginRedirect() string {
+	return s.settings.PostLoginRedirect
+}
+
+// AuthorizationURL returns the provider URL that starts a sign-in carrying state and nonce
+func (s *OIDCService) AuthorizationURL(ctx context.Context, state, nonce string) (string, error) {
+	if !s.Enabled() {
+		return "", ErrOIDCDisabled
+	}
+	metadata, err := s.providerMetadata(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	query := url.Values{}
+	query.Set("response_type", "code")
+	query.Set("client_id", s.settings.ClientID)
+	query.Set("redirect_uri", s.settings.RedirectURL)
+	query.Set("scope", strings.Join(s.settings.Scopes, " "))
+	query.Set("state", state)
+	query.Set("nonce", nonce)
+	separator := "?"
+	if strings.Contains(metadata.AuthorizationEndpoint, "?") {
+		separator = "&"
+	}
+	return metadata.AuthorizationEndpoint + separator + query.Encode(), nil
+}
+
+// SignIn exchanges an authorization code, verifies the returned ID token against nonce and
+// returns the local user it belongs to, linking or provisioning one as needed
+func (s *OIDCService) SignIn(ctx context.Context, code, nonce string) (*models.User, error) {
+	identity, err := s.Authenticate(ctx, code, nonce)
+	if err != nil {
+		return nil, err
+	}
+	return s.userService.SignInWithOIDC(identity, s.MappedRole(identity))
+}
+
+// Authenticate exchanges an authorization code and returns the verified identity
+func (s *OIDCService) Authenticate(ctx context.Context, code, nonce string) (*OIDCIdentity, error) {
+	if !s.Enabled() {
+		return nil, ErrOIDCDisabled
+	}
+	rawIDToken, err := s.exchangeCode(ctx, code)
+	if err != nil {
+		return nil, err
+	}
+	return s.verifyIDToken(ctx, rawIDToken, nonce)
+}
+
+// MappedRole returns the Tairitsu role granted by the identity's role claim, or an empty
+// string when no role claim is configured and roles are managed in Tairitsu
+func (s *OIDCService) MappedRole(identity *OIDCIdentity) string {
+	if s.settings.RoleClaim == "" {
+		return ""
+	}
+	for _, role := range identity.Roles {
+		for _, adminRole := range s.settings.AdminRoles {
+			if role == adminRole {
+				return "admin"
+			}
+		}
+	}
+	return "user"
+}
+
+func (s *OIDCService) providerMetadata(ctx context.Context) (*oidcProviderMetadata, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.metadata != nil {
+		return s.metadata, nil
+	}
+
+	var metadata oidcProviderMetadata
+	if err := s.getJSON(ctx, s.settings.IssuerURL+"/.well-known/openid-configuration", &metadata); err != nil {
+		return nil, err
+	}
+	if strings.TrimRight(metadata.Issuer, "/") != s.settings.IssuerURL {
+		logger.Error("service: oidc discovery issuer mismatch", zap.String("configured", s.settings.IssuerURL), zap.String("advertised", metadata.Issuer))
+		return nil, fmt.Errorf("%w: discovery document is for issuer %q", ErrOIDCProviderError, metadata.Issuer)
+	}
+	if metadata.AuthorizationEndpoint == "" || metadata.TokenEndpoint == "" || metadata.JWKSURI == "" {
+		return nil, fmt.Errorf("%w: discovery document is missing endpoints", ErrOIDCProviderError)
+	}
+	s.metadata = &metadata
+	return s.metadata, nil
+}
+
+// exchangeCode trades an authorization code for the provider's ID token
+func (s *OIDCService) exchangeCode(ctx context.Context, code string) (string, error) {
+	metadata, err := s.providerMetadata(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("redirect_uri", s.settings.RedirectURL)
+	form.Set("client_id", s.settings.ClientID)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, metadata.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrOIDCProviderError, err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	req.SetBasicAuth(url.QueryEscape(s.settings.ClientID), url.QueryEscape(s.settings.ClientSecret))
+
+	var response struct {
+		IDToken string `json:"id_token"`
+	}
+	if err := s.doJSON(req, &response); err != nil {
+		return "", err
+	}
+	if response.IDToken == "" {
+		return "", fmt.Errorf("%w: token response has no id_token", ErrOIDCProviderError)
+	}
+	return response.IDToken, nil
+}
+
+// verifyIDToken checks the signature, issuer, audience, expiry and nonce of an ID token
+func (s *OIDCService) verifyIDToken(ctx context.Context, rawIDToken, nonce string) (*OIDCIdentity, error) {
+	metadata, err := s.providerMetadata(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	claims := jwt.MapClaims{}
+	parser := jwt.NewParser(jwt.WithValidMethods(oidcSigningMethods))
+	_, err = parser.ParseWithClaims(rawIDToken, claims, func(token *jwt.Token) (any, error) {
+		kid, _ := token.Header["kid"].(string)
+		return s.signingKey(ctx, metadata, kid)
+	})
+	if err != nil {
+		logger.Warn("service: oidc id token rejected", zap.Error(err))
+		return nil, fmt.Errorf("%w: %v", ErrOIDCInvalidToken, err)
+	}
+	// Parsing only checks exp when it is present; an ID token must carry one
+	if !claims.VerifyExpiresAt(time.Now().Unix(), true) {
+		return nil, fmt.Errorf("%w: token has no expiry", ErrOIDCInvalidToken)
+	}
+	if !claims.VerifyIssuer(metadata.Issuer, true) {
+		return nil, fmt.Errorf("%w: unexpected issuer", ErrOIDCInvalidToken)
+	}
+	if !claims.VerifyAudience(s.settings.ClientID, true) {
+		return nil, fmt.Errorf("%w: token was issued for another client", ErrOIDCInvalidToken)
+	}
+	if tokenNonce, _ := claims["nonce"].(string); nonce == "" || tokenNonce != nonce {
+		return nil, fmt.Errorf("%w: nonce mismatch", ErrOIDCInvalidToken)
+	}
+
+	identity := &OIDCIdentity{}
+	identity.Subject, _ = claims["sub"].(string)
+	if identity.Subject == "" {
+		return nil, fmt.Errorf("%w: token has no subject", ErrOIDCInvalidToken)
+	}
+	identity.Email, _ = claims["email"].(string)
+	switch verified := claims["email_verified"].(type) {
+	case bool:
+		identity.EmailVerified = verified
+	case string:
+		// Some providers send the flag as a string
+		identity.EmailVerified = verified == "true"
+	}
+	identity.Username, _ = claims["preferred_username"].(string)
+	if s.settings.RoleClaim != "" {
+		identity.Roles = claimStrings(claims, s.settings.RoleClaim)
+	}
+	return identity, nil
+}
+
+// claimStrings reads a string or string list claim; dots in path reach nested objects
+func claimStrings(claims jwt.MapClaims, path string) []string {
+	var value any = map[string]any(claims)
+	for _, part := range strings.Split(path, ".") {
+		object, ok := value.(map[string]any)
+		if !ok {
+			return nil
+		}
+		value = object[part]
+	}
+
+	switch typed := value.(type) {
+	case string:
+		return []string{typed}
+	case []any:
+		values := make([]string, 0, len(typed))
+		for _, item := range typed {
+			if text, ok := item.(string); ok {
+				values = append(values, text)
+			}
+		}
+		return values
+	}
+	return nil
+}
+
+// signingKey returns the provider key with the given id, refreshing the key set once when
+// it is unknown so rotated keys are picked up
+func (s *OIDCService) signingKey(ctx context.Context, metadata *oidcProviderMetadata, kid string) (crypto.PublicKey, error) {
+	s.mutex.Lock()
+	keys := s.keys
+	s.mutex.Unlock()
+	if key := pickSigningKey(keys, kid); key != nil {
+		return key, nil
+	}
+
+	keys, err := s.fetchKeys(ctx, metadata.JWKSURI)
+	if err != nil {
+		return nil, err
+	}
+	s.mutex.Lock()
+	s.keys = keys
+	s.mutex.Unlock()
+	if key := pickSigningKey(keys, kid); key != nil {
+		return key, nil
+	}
+	return nil, fmt.Errorf("no provider signing key matches %q", kid)
+}
+
+// pickSigningKey finds a key by id; tokens without an id may only use a single-key set
+func pickSigningKey(keys map[string]crypto.PublicKey, kid string) crypto.PublicKey {
+	if kid != "" {
+		return keys[kid]
+	}
+	if len(keys) == 1 {
+		for _, key := range keys {
+			return key
+		}
+	}
+	return nil
+}
+
+// jsonWebKey is the part of a JWK needed to verify RSA and EC signatures
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+func (s *OIDCService) fetchKeys(ctx context.Context, jwksURI string) (map[string]crypto.PublicKey, error) {
+	var keySet struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := s.getJSON(ctx, jwksURI, &keySet); err != nil {
+		return nil, err
+	}
+
+	keys := make(map[string]crypto.PublicKey, len(keySet.Keys))
+	for _, jwk := range keySet.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		key, err := jwk.publicKey()
+		if err != nil {
+			logger.Warn("service: skipping unusable oidc signing key", zap.String("kid", jwk.Kid), zap.Error(err))
+			continue
+		}
+		keys[jwk.Kid] = key
+	}
+	return keys, nil
+}
+
+func (k jsonWebKey) publicKey() (crypto.PublicKey, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeKeyComponent(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeKeyComponent(k.E)
+		if err != nil {
+			return nil, err
+		}
+		if !e.IsInt64() {
+			return nil, fmt.Errorf("rsa exponent is too large")
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := decodeKeyComponent(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeKeyComponent(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	}
+	return nil, fmt.Errorf("unsupported key type %q", k.Kty)
+}
+
+func decodeKeyComponent(value string) (*big.Int, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(value, "="))
+	if err != nil || len(raw) == 0 {
+		return nil, fmt.Errorf("invalid key component")
+	}
+	return new(big.Int).SetBytes(raw), nil
+}
+
+func (s *OIDCService) getJSON(ctx context.Context, target string, out any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrOIDCProviderError, err)
+	}
+	req.Header.Set("Accept", "application/json")
+	return s.doJSON(req, out)
+}
+
+func (s *OIDCService) doJSON(req *http.Request, out any) error {
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		logger.Warn("service: oidc provider request failed", zap.String("url", req.URL.Redacted()), zap.Error(err))
+		return fmt.Errorf("%w: %v", ErrOIDCProviderError, err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrOIDCProviderError, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		logger.Warn("service: oidc provider returned an error", zap.String("url", req.URL.Redacted()), zap.Int("status", resp.StatusCode), zap.ByteString("body", body))
+		return fmt.Errorf("%w: %s returned status %d", ErrOIDCProviderError, req.URL.Path, resp.StatusCode)
+	}
+	if err := json.Unmarshal(body, out); err != nil {
+		return fmt.Errorf("%w: %v", ErrOIDCProviderError, err)
+	}
+	return nil
+}
@@ -0,0 +1,176 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/GT-610/tairitsu/internal/app/database"
+	"github.com/GT-610/tairitsu/internal/app/logger"
+	"github.com/GT-610/tairitsu/internal/app/models"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// maxUsernameLength matches the limit enforced by normalizeUsername
+const maxUsernameLength = 16
+
+// SignInWithOIDC returns the local account for a verified provider identity. An account already
+// linked to the subject is used first; otherwise an account with the same verified email is
+// linked, keeping its password, and failing that a new account without a password is created.
+// role is applied when the provider maps roles; an empty role leaves them to Tairitsu.
+func (s *UserService) SignInWithOIDC(identity *OIDCIdentity, role string) (*models.User, error) {
+	db := s.getDB()
+	if db == nil {
+		return nil, ErrUserDBUnavailable
+	}
+
+	user, err := db.GetUserByExternalSubject(identity.Subject)
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up linked user: %w", err)
+	}
+	if user != nil {
+		if err := s.applyExternalRole(db, user, role); err != nil {
+			return nil, err
+		}
+		logger.Info("service: user signed in through oidc", zap.String("user_id", user.ID))
+		return user, nil
+	}
+
+	email, err := normalizeEmail(identity.Email)
+	if err != nil {
+		// A malformed address cannot match an account, so it is ignored
+		email = ""
+	}
+	if email != "" {
+		user, err = db.GetUserByEmail(email)
+		if err != nil {
+			return nil, fmt.Errorf("failed to look up user by email: %w", err)
+		}
+	}
+	if user != nil {
+		if !identity.EmailVerified {
+			logger.Warn("service: refusing to link oidc identity with an unverified email", zap.String("user_id", user.ID))
+			return nil, ErrOIDCEmailNotVerified
+		}
+		if user.ExternalSubject != nil {
+			logger.Warn("service: email already belongs to an account linked to another oidc subject", zap.String("user_id", user.ID))
+			return nil, ErrEmailExists
+		}
+		subject := identity.Subject
+		user.ExternalSubject = &subject
+		user.UpdatedAt = time.Now()
+		if err := s.applyExternalRole(db, user, role); err != nil {
+			return nil, err
+		}
+		if err := db.UpdateUser(user); err != nil {
+			return nil, fmt.Errorf("failed to link user: %w", err)
+		}
+		logger.Info("service: linked existing user to oidc subject", zap.String("user_id", user.ID))
+		return user, nil
+	}
+
+	return s.provisionOIDCUser(db, identity, email, role)
+}
+
+// provisionOIDCUser creates an account for a first-time provider identity. It has no local
+// password until the user sets one through a password reset.
+func (s *UserService) provisionOIDCUser(db database.DBInterface, identity *OIDCIdentity, email, role string) (*models.User, error) {
+	username, err := availableUsername(db, oidcUsernameBase(identity, email))
+	if err != nil {
+		return nil, err
+	}
+	if role == "" {
+		role = "user"
+	}
+
+	subject := identity.Subject
+	now := time.Now()
+	user := &models.User{
+		ID:              uuid.New().String(),
+		Username:        username,
+		Role:            role,
+		ExternalSubject: &subject,
+		CreatedAt:       now,
+		UpdatedAt:       now,
+	}
+	if email != "" && identity.EmailVerified {
+		user.Email = &email
+	}
+	if err := db.CreateUser(user); err != nil {
+		return nil, fmt.Errorf("failed to save user: %w", err)
+	}
+
+	logger.Info("service: provisioned user from oidc", zap.String("user_id", user.ID), zap.String("username", user.Username), zap.String("role", user.Role))
+	s.mutex.RLock()
+	dispatcher := s.webhooks
+	s.mutex.RUnlock()
+	dispatcher.Dispatch(WebhookEventUserCreated, userCreatedWebhookData(user))
+	return user, nil
+}
+
+// applyExternalRole updates user's role to the one mapped by the provider, except that the
+// last administrator is never demoted
+func (s *UserService) applyExternalRole(db database.DBInterface, user *models.User, role string) error {
+	if role == "" || role == user.Role {
+		return nil
+	}
+	if user.Role == "admin" {
+		admins, err := db.CountUsersByRole("admin")
+		if err != nil {
+			return fmt.Errorf("failed to count administrators: %w", err)
+		}
+		if admins <= 1 {
+			logger.Warn("service: keeping the last administrator despite the oidc role mapping", zap.String("user_id", user.ID))
+			return nil
+		}
+	}
+
+	user.Role = role
+	user.UpdatedAt = time.Now()
+	if err := db.UpdateUser(user); err != nil {
+		return fmt.Errorf("failed to update role: %w", err)
+	}
+	logger.Info("service: applied oidc role mapping", zap.String("user_id", user.ID), zap.String("role", role))
+	return nil
+}
+
+// oidcUsernameBase picks a username from the preferred username, the email's local part or the subject
+func oidcUsernameBase(identity *OIDCIdentity, email string) string {
+	for _, candidate := range []string{identity.Username, strings.SplitN(email, "@", 2)[0], identity.Subject} {
+		if candidate = strings.TrimSpace(candidate); candidate != "" {
+			return truncateUsername(candidate, maxUsernameLength)
+		}
+	}
+	return "user"
+}
+
+// availableUsername returns base, or base with a numeric suffix when it is taken
+func availableUsername(db database.DBInterface, base string) (string, error) {
+	candidate := base
+	for attempt := 2; attempt <= 100; attempt++ {
+		existing, err := db.GetUserByUsername(candidate)
+		if err != nil {
+			return "", fmt.Errorf("failed to check username: %w", err)
+		}
+		if existing == nil {
+			return candidate, nil
+		}
+		suffix := fmt.Sprintf("-%d", attempt)
+		candidate = truncateUsername(base, maxUsernameLength-len(suffix)) + suffix
+	}
+	return "", ErrUsernameExists
+}
+
+// truncateUsername cuts name to at most limit bytes without splitting a character
+func truncateUsername(name string, limit int) string {
+	if len(name) <= limit {
+		return name
+	}
+	cut := limit
+	for cut > 0 && !utf8.RuneStart(name[cut]) {
+		cut--
+	}
+	return name[:cut]
+}
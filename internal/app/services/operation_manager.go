@@ -0,0 +1,391 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/GT-610/tairitsu/internal/app/database"
+	"github.com/GT-610/tairitsu/internal/app/logger"
+	"github.com/GT-610/tairitsu/internal/app/models"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+const (
+	// DefaultOperationListLimit and MaxOperationListLimit bound GET /api/operations
+	DefaultOperationListLimit = 50
+	MaxOperationListLimit     = 200
+)
+
+var (
+	ErrOperationNotFound   = errors.New("operation not found")
+	ErrOperationNotRunning = errors.New("operation is not pending or running")
+	ErrOperationActive     = errors.New("operation is already pending or running")
+	ErrOperationsStopped   = errors.New("operations are not accepted while shutting down")
+
+	// errOperationCancelled and errOperationInterrupted tell a cancelled operation from one
+	// stopped by shutdown
+	errOperationCancelled   = errors.New("operation was cancelled")
+	errOperationInterrupted = errors.New("operation was interrupted by shutdown")
+)
+
+// OperationFunc does the work of an operation. It reports progress through progress and
+// should return soon after ctx is cancelled; a non-nil result is stored as JSON.
+type OperationFunc func(ctx context.Context, progress *OperationProgress) (any, error)
+
+// OperationDetail is an operation with its result and, for network batches, its items
+type OperationDetail struct {
+	*models.Operation
+	Result json.RawMessage         `json:"result,omitempty"`
+	Items  []*models.OperationItem `json:"items,omitempty"`
+}
+
+// OperationManager runs long-running operations in the background, at most a configured
+// number at a time, and records their status, progress and result in the database so
+// they can be followed from another request and survive restarts. Operations that were
+// pending or running when the process stopped are marked failed once it starts again.
+type OperationManager struct {
+	limiter chan struct{}
+
+	mutex   sync.Mutex
+	db      database.DBInterface
+	active  map[string]context.CancelCauseFunc
+	stopped bool
+	wg      sync.WaitGroup
+}
+
+// NewOperationManager creates a manager that runs up to concurrency operations at once
+func NewOperationManager(db database.DBInterface, concurrency int) *OperationManager {
+	return &OperationManager{
+		limiter: make(chan struct{}, max(concurrency, 1)),
+		db:      db,
+		active:  make(map[string]context.CancelCauseFunc),
+	}
+}
+
+func (m *OperationManager) SetDB(db database.DBInterface) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.db = db
+	if db != nil {
+		m.failInterrupted(db)
+	}
+}
+
+func (m *OperationManager) getDB() database.DBInterface {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	return m.db
+}
+
+// Start marks the operations an earlier process left unfinished as failed. When ctx is
+// cancelled it stops the running operations, which are recorded as failed, and closes the
+// returned channel once they have returned.
+func (m *OperationManager) Start(ctx context.Context) <-chan struct{} {
+	m.mutex.Lock()
+	if m.db != nil {
+		m.failInterrupted(m.db)
+	}
+	m.mutex.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		<-ctx.Done()
+		m.mutex.Lock()
+		m.stopped = true
+		for _, cancel := range m.active {
+			cancel(errOperationInterrupted)
+		}
+		m.mutex.Unlock()
+		m.wg.Wait()
+	}()
+	return done
+}
+
+// Submit records operation as pending, together with its items, and runs it once a worker
+// is free. The ID is generated, and the idempotency key defaults to the ID.
+func (m *OperationManager) Submit(operation *models.Operation, items []*models.OperationItem, run OperationFunc) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	if m.stopped {
+		return ErrOperationsStopped
+	}
+	if m.db == nil {
+		return fmt.Errorf("database is not initialized")
+	}
+
+	operation.ID = uuid.New().String()
+	if operation.IdempotencyKey == "" {
+		operation.IdempotencyKey = operation.ID
+	}
+	operation.Status = models.OperationStatusPending
+	for _, item := range items {
+		item.OperationID = operation.ID
+	}
+	if err := m.db.CreateOperation(operation, items); err != nil {
+		logger.Error("service: failed to record operation", zap.String("kind", operation.Kind), zap.String("user_id", operation.UserID), zap.Error(err))
+		return err
+	}
+	m.launch(operation, run)
+	return nil
+}
+
+// Resubmit runs a finished operation again, resetting its progress, error and result
+func (m *OperationManager) Resubmit(operation *models.Operation, run OperationFunc) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	if m.stopped {
+		return ErrOperationsStopped
+	}
+	if m.db == nil {
+		return fmt.Errorf("database is not initialized")
+	}
+	if _, ok := m.active[operation.ID]; ok {
+		return ErrOperationActive
+	}
+
+	operation.Status = models.OperationStatusPending
+	operation.Done, operation.Failed = 0, 0
+	operation.Error, operation.Result = "", ""
+	operation.StartedAt, operation.FinishedAt = nil, nil
+	if err := m.db.SaveOperation(operation); err != nil {
+		return err
+	}
+	logger.Info("service: resubmitting operation", zap.String("operation_id", operation.ID), zap.String("kind", operation.Kind))
+	m.launch(operation, run)
+	return nil
+}
+
+// Active reports whether an operation is pending or running in this process
+func (m *OperationManager) Active(id string) bool {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	_, ok := m.active[id]
+	return ok
+}
+
+// Get returns an operation to the user who submitted it, or to an administrator
+func (m *OperationManager) Get(id, userID string, admin bool) (*OperationDetail, error) {
+	db := m.getDB()
+	if db == nil {
+		return nil, fmt.Errorf("database is not initialized")
+	}
+	operation, err := m.visibleOperation(db, id, userID, admin)
+	if err != nil {
+		return nil, err
+	}
+	detail := operationDetail(operation, nil)
+	if operation.Kind == models.OperationKindNetworkBatch {
+		if detail.Items, err = db.ListOperationItems(id); err != nil {
+			return nil, err
+		}
+	}
+	return detail, nil
+}
+
+// List returns the newest operations of a user, or of every user for an administrator
+func (m *OperationManager) List(userID string, admin bool, limit int) ([]*OperationDetail, error) {
+	db := m.getDB()
+	if db == nil {
+		return nil, fmt.Errorf("database is not initialized")
+	}
+	if admin {
+		userID = ""
+	}
+	operations, err := db.ListOperations(userID, limit)
+	if err != nil {
+		return nil, err
+	}
+	details := make([]*OperationDetail, 0, len(operations))
+	for _, operation := range operations {
+		details = append(details, operationDetail(operation, nil))
+	}
+	return details, nil
+}
+
+// Cancel cancels a pending or running operation. It returns at once; the operation is
+// recorded as cancelled when its work has stopped.
+func (m *OperationManager) Cancel(id, userID string, admin bool) (*OperationDetail, error) {
+	db := m.getDB()
+	if db == nil {
+		return nil, fmt.Errorf("database is not initialized")
+	}
+	operation, err := m.visibleOperation(db, id, userID, admin)
+	if err != nil {
+		return nil, err
+	}
+
+	m.mutex.Lock()
+	cancel, ok := m.active[id]
+	m.mutex.Unlock()
+	if !ok {
+		return nil, ErrOperationNotRunning
+	}
+	cancel(errOperationCancelled)
+	logger.Info("service: operation cancelled", zap.String("operation_id", id), zap.String("user_id", userID))
+	return operationDetail(operation, nil), nil
+}
+
+func (m *OperationManager) visibleOperation(db database.DBInterface, id, userID string, admin bool) (*models.Operation, error) {
+	operation, err := db.GetOperation(id)
+	if err != nil {
+		return nil, err
+	}
+	if operation == nil || (operation.UserID != userID && !admin) {
+		return nil, ErrOperationNotFound
+	}
+	return operation, nil
+}
+
+// launch starts the goroutine running operation. The caller holds the mutex.
+func (m *OperationManager) launch(operation *models.Operation, run OperationFunc) {
+	ctx, cancel := context.WithCancelCause(context.Background())
+	m.active[operation.ID] = cancel
+	m.wg.Add(1)
+	progress := &OperationProgress{manager: m, id: operation.ID, userID: operation.UserID, operation: *operation}
+	go m.run(ctx, progress, run)
+}
+
+func (m *OperationManager) run(ctx context.Context, progress *OperationProgress, run OperationFunc) {
+	defer m.wg.Done()
+
+	select {
+	case m.limiter <- struct{}{}:
+	case <-ctx.Done():
+		m.finish(ctx, progress, nil, context.Cause(ctx))
+		return
+	}
+	defer func() { <-m.limiter }()
+
+	progress.update(func(operation *models.Operation) {
+		now := time.Now()
+		operation.Status = models.OperationStatusRunning
+		operation.StartedAt = &now
+	})
+	result, err := run(ctx, progress)
+	m.finish(ctx, progress, result, err)
+}
+
+// finish records how an operation ended and forgets it, under the mutex so a retry never
+// sees an operation that is neither active nor finished
+func (m *OperationManager) finish(ctx context.Context, progress *OperationProgress, result any, err error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	progress.updateWith(m.db, func(operation *models.Operation) {
+		now := time.Now()
+		operation.FinishedAt = &now
+		operation.Error = ""
+		switch cause := context.Cause(ctx); {
+		case errors.Is(cause, errOperationCancelled):
+			operation.Status = models.OperationStatusCancelled
+		case cause != nil:
+			operation.Status = models.OperationStatusFailed
+			operation.Error = cause.Error()
+		case err != nil:
+			operation.Status = models.OperationStatusFailed
+			operation.Error = err.Error()
+		default:
+			operation.Status = models.OperationStatusSucceeded
+		}
+		if result != nil {
+			if encoded, err := json.Marshal(result); err == nil {
+				operation.Result = string(encoded)
+			} else {
+				logger.Error("service: failed to encode operation result", zap.String("operation_id", operation.ID), zap.Error(err))
+			}
+		}
+		logger.Info("service: operation finished", zap.String("operation_id", operation.ID), zap.String("kind", operation.Kind), zap.String("status", operation.Status))
+	})
+	if cancel, ok := m.active[progress.id]; ok {
+		cancel(nil)
+		delete(m.active, progress.id)
+	}
+}
+
+// failInterrupted marks pending and running operations that this process is not running
+// as failed. The caller holds the mutex.
+func (m *OperationManager) failInterrupted(db database.DBInterface) {
+	operations, err := db.ListUnfinishedOperations()
+	if err != nil {
+		logger.Warn("service: failed to list unfinished operations", zap.Error(err))
+		return
+	}
+	for _, operation := range operations {
+		if _, ok := m.active[operation.ID]; ok {
+			continue
+		}
+		now := time.Now()
+		operation.Status = models.OperationStatusFailed
+		operation.Error = "operation was interrupted by a restart"
+		operation.FinishedAt = &now
+		if err := db.SaveOperation(operation); err != nil {
+			logger.Warn("service: failed to record interrupted operation", zap.String("operation_id", operation.ID), zap.Error(err))
+		}
+	}
+}
+
+// OperationProgress is how an OperationFunc reports progress. Every change is saved, so
+// it is visible to GET /api/operations/:id at once.
+type OperationProgress struct {
+	manager *OperationManager
+	id      string
+	userID  string
+
+	mutex     sync.Mutex
+	operation models.Operation
+}
+
+// OperationID returns the ID of the operation
+func (p *OperationProgress) OperationID() string {
+	return p.id
+}
+
+// UserID returns the user who submitted the operation
+func (p *OperationProgress) UserID() string {
+	return p.userID
+}
+
+// SetTotal sets the number of units of work
+func (p *OperationProgress) SetTotal(total int) {
+	p.update(func(operation *models.Operation) {
+		operation.Total = total
+	})
+}
+
+// Add counts units of work that are done or failed
+func (p *OperationProgress) Add(done, failed int) {
+	p.update(func(operation *models.Operation) {
+		operation.Done += done
+		operation.Failed += failed
+	})
+}
+
+func (p *OperationProgress) update(change func(operation *models.Operation)) {
+	p.updateWith(p.manager.getDB(), change)
+}
+
+func (p *OperationProgress) updateWith(db database.DBInterface, change func(operation *models.Operation)) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	change(&p.operation)
+	if db == nil {
+		return
+	}
+	if err := db.SaveOperation(&p.operation); err != nil {
+		logger.Warn("service: failed to record operation progress", zap.String("operation_id", p.id), zap.Error(err))
+	}
+}
+
+func operationDetail(operation *models.Operation, items []*models.OperationItem) *OperationDetail {
+	detail := &OperationDetail{Operation: operation, Items: items}
+	if operation.Result != "" {
+		detail.Result = json.RawMessage(operation.Result)
+	}
+	return detail
+}
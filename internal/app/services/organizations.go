@@ -0,0 +1,438 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/GT-610/tairitsu/internal/app/database"
+	"github.com/GT-610/tairitsu/internal/app/logger"
+	"github.com/GT-610/tairitsu/internal/app/models"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+const (
+	maxOrganizationNameLength        = 128
+	maxOrganizationDescriptionLength = 1024
+)
+
+var (
+	ErrOrganizationNotFound         = errors.New("organization not found")
+	ErrOrganizationAccessDenied     = errors.New("organization access denied")
+	ErrOrganizationMemberNotFound   = errors.New("the user is not a member of the organization")
+	ErrOrganizationNameTaken        = errors.New("an organization with this name already exists")
+	ErrInvalidOrganization          = fmt.Errorf("organization name is required and must be at most %d characters, and the description at most %d", maxOrganizationNameLength, maxOrganizationDescriptionLength)
+	ErrInvalidOrganizationRole      = errors.New("organization role must be org_admin or org_member")
+	ErrDefaultOrganizationProtected = errors.New("the default organization cannot be deleted")
+)
+
+// OrganizationInput describes a new or changed organization
+type OrganizationInput struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+// OrganizationSummary is an organization with the caller's role in it. Administrators see
+// every organization; the role is empty for those they are not a member of.
+type OrganizationSummary struct {
+	models.Organization
+	Role string `json:"role,omitempty"`
+}
+
+// OrganizationMemberSummary is a member of an organization
+type OrganizationMemberSummary struct {
+	UserID    string    `json:"userId"`
+	Username  string    `json:"username"`
+	Role      string    `json:"role"`
+	AddedBy   string    `json:"addedBy"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// ListNetworks returns the networks userID can see: those they own and those of their
+// organizations, or every network for administrators. A non-empty organizationID keeps only
+// the networks of that organization. Without memberCounts the controller is not asked for
+// member lists and the summaries carry no counts.
+func (s *NetworkService) ListNetworks(userID, organizationID string, memberCounts bool) ([]NetworkSummary, error) {
+	db := s.getDB()
+	if db == nil {
+		return nil, fmt.Errorf("database is not initialized")
+	}
+	if organizationID != "" {
+		organization, err := db.GetOrganization(organizationID)
+		if err != nil {
+			return nil, err
+		}
+		if organization == nil {
+			return nil, ErrOrganizationNotFound
+		}
+	}
+
+	networks, err := s.visibleNetworks(db, userID)
+	if err != nil {
+		logger.Error("service: failed to get user network list", zap.String("user_id", userID), zap.Error(err))
+		return nil, err
+	}
+	if organizationID != "" {
+		filtered := networks[:0]
+		for _, network := range networks {
+			if organizationIDOf(network) == organizationID {
+				filtered = append(filtered, network)
+			}
+		}
+		networks = filtered
+	}
+	sort.SliceStable(networks, func(i, j int) bool {
+		return networks[i].CreatedAt.Before(networks[j].CreatedAt)
+	})
+
+	return s.summarizeNetworks(networks, memberCounts), nil
+}
+
+func (s *NetworkService) visibleNetworks(db database.DBInterface, userID string) ([]*models.Network, error) {
+	admin, err := s.isAdministrator(userID)
+	if err != nil {
+		return nil, err
+	}
+	if admin {
+		return db.GetAllNetworks()
+	}
+
+	networks, err := db.GetNetworksByOwnerID(userID)
+	if err != nil {
+		return nil, err
+	}
+	memberships, err := db.ListOrganizationMembershipsByUser(userID)
+	if err != nil {
+		return nil, err
+	}
+	organizationIDs := make([]string, 0, len(memberships))
+	for _, membership := range memberships {
+		organizationIDs = append(organizationIDs, membership.OrganizationID)
+	}
+	organizationNetworks, err := db.GetNetworksByOrganizationIDs(organizationIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]struct{}, len(networks))
+	for _, network := range networks {
+		seen[network.ID] = struct{}{}
+	}
+	for _, network := range organizationNetworks {
+		if _, ok := seen[network.ID]; !ok {
+			seen[network.ID] = struct{}{}
+			networks = append(networks, network)
+		}
+	}
+	return networks, nil
+}
+
+// ListOrganizations returns every organization to administrators and the caller's own
+// organizations to everybody else
+func (s *NetworkService) ListOrganizations(userID string) ([]OrganizationSummary, error) {
+	db := s.getDB()
+	if db == nil {
+		return nil, fmt.Errorf("database is not initialized")
+	}
+
+	memberships, err := db.ListOrganizationMembershipsByUser(userID)
+	if err != nil {
+		return nil, err
+	}
+	roles := make(map[string]string, len(memberships))
+	for _, membership := range memberships {
+		roles[membership.OrganizationID] = membership.Role
+	}
+	admin, err := s.isAdministrator(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	organizations, err := db.ListOrganizations()
+	if err != nil {
+		return nil, err
+	}
+	summaries := make([]OrganizationSummary, 0, len(organizations))
+	for _, organization := range organizations {
+		role, member := roles[organization.ID]
+		if !member && !admin {
+			continue
+		}
+		summaries = append(summaries, OrganizationSummary{Organization: *organization, Role: role})
+	}
+	return summaries, nil
+}
+
+// CreateOrganization creates an organization; callers check that the actor is an administrator
+func (s *NetworkService) CreateOrganization(input OrganizationInput) (*models.Organization, error) {
+	db := s.getDB()
+	if db == nil {
+		return nil, fmt.Errorf("database is not initialized")
+	}
+	input, err := normalizeOrganizationInput(db, input, "")
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	organization := &models.Organization{
+		ID:          uuid.New().String(),
+		Name:        input.Name,
+		Description: input.Description,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	if err := db.CreateOrganization(organization); err != nil {
+		return nil, err
+	}
+	logger.Info("service: organization created", zap.String("organization_id", organization.ID), zap.String("name", organization.Name))
+	return organization, nil
+}
+
+// UpdateOrganization renames or describes an organization; callers check that the actor is an
+// administrator
+func (s *NetworkService) UpdateOrganization(id string, input OrganizationInput) (*models.Organization, error) {
+	db := s.getDB()
+	if db == nil {
+		return nil, fmt.Errorf("database is not initialized")
+	}
+	organization, err := getOrganization(db, id)
+	if err != nil {
+		return nil, err
+	}
+	input, err = normalizeOrganizationInput(db, input, id)
+	if err != nil {
+		return nil, err
+	}
+
+	organization.Name = input.Name
+	organization.Description = input.Description
+	organization.UpdatedAt = time.Now()
+	if err := db.UpdateOrganization(organization); err != nil {
+		return nil, err
+	}
+	return organization, nil
+}
+
+// DeleteOrganization deletes an organization and moves its networks back to the default
+// organization; callers check that the actor is an administrator
+func (s *NetworkService) DeleteOrganization(id string) error {
+	db := s.getDB()
+	if db == nil {
+		return fmt.Errorf("database is not initialized")
+	}
+	if id == models.DefaultOrganizationID {
+		return ErrDefaultOrganizationProtected
+	}
+	if _, err := getOrganization(db, id); err != nil {
+		return err
+	}
+
+	err := db.WithTransaction(func(tx database.DBInterface) error {
+		if err := tx.MoveNetworksToOrganization(id, models.DefaultOrganizationID); err != nil {
+			return fmt.Errorf("failed to move networks to the default organization: %w", err)
+		}
+		if err := tx.DeleteAllOrganizationMembers(id); err != nil {
+			return fmt.Errorf("failed to delete organization members: %w", err)
+		}
+		return tx.DeleteOrganization(id)
+	})
+	if err != nil {
+		return err
+	}
+	logger.Info("service: organization deleted", zap.String("organization_id", id))
+	return nil
+}
+
+// AssignNetworkOrganization moves a network to an organization; callers check that the actor
+// is an administrator
+func (s *NetworkService) AssignNetworkOrganization(networkID, organizationID string) (*NetworkSummary, error) {
+	db := s.getDB()
+	if db == nil {
+		return nil, fmt.Errorf("database is not initialized")
+	}
+	if _, err := getOrganization(db, organizationID); err != nil {
+		return nil, err
+	}
+	network, err := s.getNetwork(networkID)
+	if err != nil {
+		return nil, err
+	}
+
+	network.OrganizationID = organizationID
+	network.UpdatedAt = time.Now()
+	if err := db.UpdateNetwork(network); err != nil {
+		return nil, err
+	}
+	logger.Info("service: network assigned to organization", zap.String("network_id", networkID), zap.String("organization_id", organizationID))
+	return &s.summarizeNetworks([]*models.Network{network}, true)[0], nil
+}
+
+// ListOrganizationMembers returns the members of an organization to its members and to
+// administrators
+func (s *NetworkService) ListOrganizationMembers(organizationID, userID string) ([]OrganizationMemberSummary, error) {
+	db := s.getDB()
+	if db == nil {
+		return nil, fmt.Errorf("database is not initialized")
+	}
+	if _, err := s.authorizeOrganization(db, organizationID, userID, false); err != nil {
+		return nil, err
+	}
+
+	members, err := db.ListOrganizationMembers(organizationID)
+	if err != nil {
+		return nil, err
+	}
+	userIDs := make([]string, 0, len(members))
+	for _, member := range members {
+		userIDs = append(userIDs, member.UserID)
+	}
+	users, err := db.GetUsersByIDs(userIDs)
+	if err != nil {
+		return nil, err
+	}
+	usernames := make(map[string]string, len(users))
+	for _, user := range users {
+		usernames[user.ID] = user.Username
+	}
+
+	summaries := make([]OrganizationMemberSummary, 0, len(members))
+	for _, member := range members {
+		summaries = append(summaries, OrganizationMemberSummary{
+			UserID:    member.UserID,
+			Username:  usernames[member.UserID],
+			Role:      member.Role,
+			AddedBy:   member.AddedBy,
+			CreatedAt: member.CreatedAt,
+			UpdatedAt: member.UpdatedAt,
+		})
+	}
+	return summaries, nil
+}
+
+// SetOrganizationMember adds a user to an organization or changes their role. Organization
+// administrators and administrators may do so.
+func (s *NetworkService) SetOrganizationMember(organizationID, targetUserID, role, actorID string) (*OrganizationMemberSummary, error) {
+	db := s.getDB()
+	if db == nil {
+		return nil, fmt.Errorf("database is not initialized")
+	}
+	if role != models.OrganizationRoleAdmin && role != models.OrganizationRoleMember {
+		return nil, ErrInvalidOrganizationRole
+	}
+	if _, err := s.authorizeOrganization(db, organizationID, actorID, true); err != nil {
+		return nil, err
+	}
+	user, err := db.GetUserByID(targetUserID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, ErrUserNotFound
+	}
+
+	now := time.Now()
+	member := &models.OrganizationMember{
+		OrganizationID: organizationID,
+		UserID:         targetUserID,
+		Role:           role,
+		AddedBy:        actorID,
+		CreatedAt:      now,
+		UpdatedAt:      now,
+	}
+	if err := db.UpsertOrganizationMember(member); err != nil {
+		return nil, err
+	}
+	saved, err := db.GetOrganizationMember(organizationID, targetUserID)
+	if err != nil {
+		return nil, err
+	}
+	if saved != nil {
+		member = saved
+	}
+	return &OrganizationMemberSummary{
+		UserID:    member.UserID,
+		Username:  user.Username,
+		Role:      member.Role,
+		AddedBy:   member.AddedBy,
+		CreatedAt: member.CreatedAt,
+		UpdatedAt: member.UpdatedAt,
+	}, nil
+}
+
+// RemoveOrganizationMember removes a user from an organization. Organization administrators
+// and administrators may do so.
+func (s *NetworkService) RemoveOrganizationMember(organizationID, targetUserID, actorID string) error {
+	db := s.getDB()
+	if db == nil {
+		return fmt.Errorf("database is not initialized")
+	}
+	if _, err := s.authorizeOrganization(db, organizationID, actorID, true); err != nil {
+		return err
+	}
+	member, err := db.GetOrganizationMember(organizationID, targetUserID)
+	if err != nil {
+		return err
+	}
+	if member == nil {
+		return ErrOrganizationMemberNotFound
+	}
+	return db.DeleteOrganizationMember(organizationID, targetUserID)
+}
+
+// authorizeOrganization returns the organization when userID is an administrator or a member
+// of it; with manage only organization administrators qualify among the members
+func (s *NetworkService) authorizeOrganization(db database.DBInterface, organizationID, userID string, manage bool) (*models.Organization, error) {
+	organization, err := getOrganization(db, organizationID)
+	if err != nil {
+		return nil, err
+	}
+	member, err := db.GetOrganizationMember(organizationID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if member != nil && (!manage || member.Role == models.OrganizationRoleAdmin) {
+		return organization, nil
+	}
+	admin, err := s.isAdministrator(userID)
+	if err != nil {
+		return nil, err
+	}
+	if !admin {
+		return nil, ErrOrganizationAccessDenied
+	}
+	return organization, nil
+}
+
+func getOrganization(db database.DBInterface, id string) (*models.Organization, error) {
+	organization, err := db.GetOrganization(id)
+	if err != nil {
+		return nil, err
+	}
+	if organization == nil {
+		return nil, ErrOrganizationNotFound
+	}
+	return organization, nil
+}
+
+// normalizeOrganizationInput trims and checks input; exceptID is the organization being
+// renamed, which may keep its own name
+func normalizeOrganizationInput(db database.DBInterface, input OrganizationInput, exceptID string) (OrganizationInput, error) {
+	input.Name = strings.TrimSpace(input.Name)
+	input.Description = strings.TrimSpace(input.Description)
+	if input.Name == "" || len(input.Name) > maxOrganizationNameLength || len(input.Description) > maxOrganizationDescriptionLength {
+		return input, ErrInvalidOrganization
+	}
+	existing, err := db.GetOrganizationByName(input.Name)
+	if err != nil {
+		return input, err
+	}
+	if existing != nil && existing.ID != exceptID {
+		return input, ErrOrganizationNameTaken
+	}
+	return input, nil
+}
@@ -0,0 +1,200 @@
+package services
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+)
+
+const (
+	DefaultPageLimit = 50
+	MaxPageLimit     = 500
+
+	PageDirectionNext = "next"
+	PageDirectionPrev = "prev"
+)
+
+var (
+	ErrInvalidCursor      = errors.New("invalid pagination cursor")
+	ErrCursorQueryChanged = errors.New("pagination cursor does not match the current filter or sort")
+	ErrInvalidPageRequest = errors.New("invalid pagination request")
+)
+
+// PageCursor is the decoded position of a cursor page boundary
+type PageCursor struct {
+	// Query fingerprints the filter and sort the cursor was issued for.
+	Query     string `json:"q"`
+	Direction string `json:"d"`
+	SortKey   string `json:"k"`
+	LastID    string `json:"i"`
+}
+
+// CursorCodec encodes page cursors as opaque, HMAC-signed tokens
+type CursorCodec struct {
+	key []byte
+}
+
+// NewCursorCodec creates a cursor codec signing with the given key
+func NewCursorCodec(key []byte) *CursorCodec {
+	return &CursorCodec{key: key}
+}
+
+// pageCursors signs cursors with a per-process key; cursors from before a restart are rejected
+// and clients start over from the first page.
+var pageCursors = NewCursorCodec(newCursorKey())
+
+func newCursorKey() []byte {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		panic("failed to generate pagination cursor key: " + err.Error())
+	}
+	return key
+}
+
+// Encode serializes and signs a cursor
+func (c *CursorCodec) Encode(cursor PageCursor) string {
+	payload, _ := json.Marshal(cursor)
+	encoded := base64.RawURLEncoding.EncodeToString(payload)
+	return encoded + "." + base64.RawURLEncoding.EncodeToString(c.sign(encoded))
+}
+
+// Decode verifies a cursor signature and checks it was issued for the given query
+func (c *CursorCodec) Decode(token string, query string) (*PageCursor, error) {
+	encoded, signature, ok := strings.Cut(token, ".")
+	if !ok {
+		return nil, ErrInvalidCursor
+	}
+	mac, err := base64.RawURLEncoding.DecodeString(signature)
+	if err != nil || !hmac.Equal(mac, c.sign(encoded)) {
+		return nil, ErrInvalidCursor
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	var cursor PageCursor
+	if err := json.Unmarshal(payload, &cursor); err != nil {
+		return nil, ErrInvalidCursor
+	}
+	if cursor.Direction != PageDirectionNext && cursor.Direction != PageDirectionPrev {
+		return nil, ErrInvalidCursor
+	}
+	if cursor.Query != query {
+		return nil, ErrCursorQueryChanged
+	}
+	return &cursor, nil
+}
+
+func (c *CursorCodec) sign(encoded string) []byte {
+	mac := hmac.New(sha256.New, c.key)
+	mac.Write([]byte(encoded))
+	return mac.Sum(nil)
+}
+
+// PageRequest selects a page either by cursor or, for older clients, by offset.
+// Offset pages are positional: members added or removed between requests shift
+// later pages, so the same item may appear twice or be skipped. Cursor pages
+// resume after the last item seen and are unaffected by such churn.
+type PageRequest struct {
+	Cursor string
+	Offset int
+	Limit  int
+	// UseOffset selects offset mode; it is set when the caller passed offset parameters.
+	UseOffset bool
+}
+
+// PageInfo describes how to fetch the pages around the returned one
+type PageInfo struct {
+	Limit      int    `json:"limit"`
+	NextCursor string `json:"nextCursor,omitempty"`
+	PrevCursor string `json:"prevCursor,omitempty"`
+	Offset     *int   `json:"offset,omitempty"`
+	Total      *int   `json:"total,omitempty"`
+}
+
+func (r PageRequest) normalizedLimit() (int, error) {
+	if r.Limit < 0 || r.Offset < 0 {
+		return 0, ErrInvalidPageRequest
+	}
+	if r.UseOffset && r.Cursor != "" {
+		return 0, ErrInvalidPageRequest
+	}
+	if r.Limit == 0 {
+		return DefaultPageLimit, nil
+	}
+	return min(r.Limit, MaxPageLimit), nil
+}
+
+// pageQuery fingerprints list parameters so cursors cannot be replayed against a different view
+func pageQuery(parts ...string) string {
+	sum := sha256.Sum256([]byte(strings.Join(parts, "\x00")))
+	return base64.RawURLEncoding.EncodeToString(sum[:12])
+}
+
+// sortedPage cuts a page from items already sorted ascending by (sort key, ID)
+func sortedPage[T any](items []T, keyOf func(T) (string, string), req PageRequest, query string) ([]T, PageInfo, error) {
+	limit, err := req.normalizedLimit()
+	if err != nil {
+		return nil, PageInfo{}, err
+	}
+	info := PageInfo{Limit: limit}
+
+	if req.UseOffset {
+		total := len(items)
+		start := min(req.Offset, total)
+		end := min(start+limit, total)
+		offset := req.Offset
+		info.Offset = &offset
+		info.Total = &total
+		return items[start:end], info, nil
+	}
+
+	start := 0
+	end := min(limit, len(items))
+	if req.Cursor != "" {
+		cursor, err := pageCursors.Decode(req.Cursor, query)
+		if err != nil {
+			return nil, PageInfo{}, err
+		}
+		// The boundary is located by value rather than position, so items inserted
+		// or removed elsewhere in the list do not shift the page.
+		boundary := 0
+		for boundary < len(items) {
+			key, id := keyOf(items[boundary])
+			if key > cursor.SortKey || (key == cursor.SortKey && id >= cursor.LastID) {
+				break
+			}
+			boundary++
+		}
+		if cursor.Direction == PageDirectionNext {
+			if boundary < len(items) {
+				if key, id := keyOf(items[boundary]); key == cursor.SortKey && id == cursor.LastID {
+					boundary++
+				}
+			}
+			start = boundary
+			end = min(start+limit, len(items))
+		} else {
+			end = boundary
+			start = max(0, end-limit)
+		}
+	}
+
+	page := items[start:end]
+	if len(page) > 0 {
+		if end < len(items) {
+			key, id := keyOf(page[len(page)-1])
+			info.NextCursor = pageCursors.Encode(PageCursor{Query: query, Direction: PageDirectionNext, SortKey: key, LastID: id})
+		}
+		if start > 0 {
+			key, id := keyOf(page[0])
+			info.PrevCursor = pageCursors.Encode(PageCursor{Query: query, Direction: PageDirectionPrev, SortKey: key, LastID: id})
+		}
+	}
+	return page, info, nil
+}
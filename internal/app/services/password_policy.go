@@ -0,0 +1,205 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/GT-610/tairitsu/internal/app/config"
+)
+
+const (
+	DefaultPasswordMinLength = 8
+	MinPasswordMinLength     = 6
+	MaxPasswordLength        = 32
+
+	PasswordRuleMinLength      = "min_length"
+	PasswordRuleMaxLength      = "max_length"
+	PasswordRuleRequireUpper   = "require_upper"
+	PasswordRuleRequireLower   = "require_lower"
+	PasswordRuleRequireDigit   = "require_digit"
+	PasswordRuleRequireSymbol  = "require_symbol"
+	PasswordRuleRejectUsername = "reject_username"
+
+	temporaryPasswordSymbols = "!@#$%^&*-_=+?"
+)
+
+var (
+	ErrPasswordPolicy        = errors.New("password does not satisfy the password policy")
+	ErrInvalidPasswordPolicy = fmt.Errorf("password minimum length must be between %d and %d", MinPasswordMinLength, MaxPasswordLength)
+)
+
+// PasswordPolicy is the effective set of password rules
+type PasswordPolicy struct {
+	MinLength      int  `json:"minLength"`
+	MaxLength      int  `json:"maxLength"`
+	RequireUpper   bool `json:"requireUpper"`
+	RequireLower   bool `json:"requireLower"`
+	RequireDigit   bool `json:"requireDigit"`
+	RequireSymbol  bool `json:"requireSymbol"`
+	RejectUsername bool `json:"rejectUsername"`
+}
+
+// PasswordPolicyError lists every rule a password failed so clients can show specific hints
+type PasswordPolicyError struct {
+	Failed []string
+	Policy PasswordPolicy
+}
+
+func (e *PasswordPolicyError) Error() string {
+	return fmt.Sprintf("%s: %s", ErrPasswordPolicy, strings.Join(e.Failed, ", "))
+}
+
+// Is keeps errors.Is working for the length sentinels used before the policy existed
+func (e *PasswordPolicyError) Is(target error) bool {
+	switch target {
+	case ErrPasswordPolicy:
+		return true
+	case ErrPasswordTooShort:
+		return slices.Contains(e.Failed, PasswordRuleMinLength)
+	case ErrPasswordTooLong:
+		return slices.Contains(e.Failed, PasswordRuleMaxLength)
+	}
+	return false
+}
+
+// DefaultPasswordPolicy returns the policy used when none is configured
+func DefaultPasswordPolicy() PasswordPolicy {
+	return PasswordPolicy{
+		MinLength:      DefaultPasswordMinLength,
+		MaxLength:      MaxPasswordLength,
+		RejectUsername: true,
+	}
+}
+
+// PasswordPolicyFromConfig resolves the configured policy, filling unset fields with defaults
+func PasswordPolicyFromConfig(cfg *config.Config) PasswordPolicy {
+	policy := DefaultPasswordPolicy()
+	if cfg == nil {
+		return policy
+	}
+
+	stored := cfg.Security.PasswordPolicy
+	if stored.MinLength > 0 {
+		policy.MinLength = stored.MinLength
+	}
+	policy.RequireUpper = stored.RequireUpper
+	policy.RequireLower = stored.RequireLower
+	policy.RequireDigit = stored.RequireDigit
+	policy.RequireSymbol = stored.RequireSymbol
+	if stored.RejectUsername != nil {
+		policy.RejectUsername = *stored.RejectUsername
+	}
+	return policy
+}
+
+// ConfigValue converts the policy to its persisted form
+func (p PasswordPolicy) ConfigValue() config.PasswordPolicyConfig {
+	rejectUsername := p.RejectUsername
+	return config.PasswordPolicyConfig{
+		MinLength:      p.MinLength,
+		RequireUpper:   p.RequireUpper,
+		RequireLower:   p.RequireLower,
+		RequireDigit:   p.RequireDigit,
+		RequireSymbol:  p.RequireSymbol,
+		RejectUsername: &rejectUsername,
+	}
+}
+
+// Validate checks that the policy itself is usable
+func (p PasswordPolicy) Validate() error {
+	if p.MinLength < MinPasswordMinLength || p.MinLength > MaxPasswordLength {
+		return ErrInvalidPasswordPolicy
+	}
+	return nil
+}
+
+// ValidatePassword checks password against policy and returns a *PasswordPolicyError naming every failed rule
+func ValidatePassword(policy PasswordPolicy, username, password string) error {
+	if policy.MaxLength == 0 {
+		policy.MaxLength = MaxPasswordLength
+	}
+
+	var failed []string
+	length := len([]rune(password))
+	if length < policy.MinLength {
+		failed = append(failed, PasswordRuleMinLength)
+	}
+	if length > policy.MaxLength {
+		failed = append(failed, PasswordRuleMaxLength)
+	}
+
+	var hasUpper, hasLower, hasDigit, hasSymbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			hasUpper = true
+		case unicode.IsLower(r):
+			hasLower = true
+		case unicode.IsDigit(r):
+			hasDigit = true
+		case unicode.IsPunct(r) || unicode.IsSymbol(r) || unicode.IsSpace(r):
+			hasSymbol = true
+		}
+	}
+	if policy.RequireUpper && !hasUpper {
+		failed = append(failed, PasswordRuleRequireUpper)
+	}
+	if policy.RequireLower && !hasLower {
+		failed = append(failed, PasswordRuleRequireLower)
+	}
+	if policy.RequireDigit && !hasDigit {
+		failed = append(failed, PasswordRuleRequireDigit)
+	}
+	if policy.RequireSymbol && !hasSymbol {
+		failed = append(failed, PasswordRuleRequireSymbol)
+	}
+	if policy.RejectUsername && username != "" && strings.Contains(strings.ToLower(password), strings.ToLower(username)) {
+		failed = append(failed, PasswordRuleRejectUsername)
+	}
+
+	if len(failed) == 0 {
+		return nil
+	}
+	return &PasswordPolicyError{Failed: failed, Policy: policy}
+}
+
+// SetPasswordPolicy replaces the policy enforced on registration and password changes
+func (s *UserService) SetPasswordPolicy(policy PasswordPolicy) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.policy = &policy
+}
+
+// PasswordPolicy returns the policy currently enforced
+func (s *UserService) PasswordPolicy() PasswordPolicy {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	if s.policy == nil {
+		return DefaultPasswordPolicy()
+	}
+	return *s.policy
+}
+
+// newTemporaryPassword generates an administrator-issued password that satisfies the current policy
+func (s *UserService) newTemporaryPassword(username string) (string, error) {
+	policy := s.PasswordPolicy()
+	length := max(16, policy.MinLength)
+	alphabet := temporaryPasswordAlphabet
+	if policy.RequireSymbol {
+		alphabet += temporaryPasswordSymbols
+	}
+
+	for attempt := 0; attempt < 100; attempt++ {
+		password, err := generateTemporaryPassword(length, alphabet)
+		if err != nil {
+			return "", err
+		}
+		if ValidatePassword(policy, username, password) == nil {
+			return password, nil
+		}
+	}
+	return "", fmt.Errorf("failed to generate a temporary password that satisfies the password policy")
+}
@@ -0,0 +1,202 @@
+package services
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"time"
+
+	"github.com/GT-610/tairitsu/internal/app/database"
+	"github.com/GT-610/tairitsu/internal/app/logger"
+	"github.com/GT-610/tairitsu/internal/app/models"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// PasswordResetTokenTTL is how long a reset token can be used after it is issued
+const PasswordResetTokenTTL = time.Hour
+
+// PasswordResetTicket carries a freshly issued reset token; the plaintext is never stored
+type PasswordResetTicket struct {
+	Token     string       `json:"token"`
+	ExpiresAt time.Time    `json:"expiresAt"`
+	User      *models.User `json:"-"`
+}
+
+// RequestPasswordReset issues a reset token for the account matching the username or email
+// address. Unknown accounts are not reported, so the caller cannot probe which accounts exist.
+// There is no mailer yet, so the token is not delivered; administrators issue deliverable
+// tokens with IssuePasswordResetToken or the --reset-admin-password flag.
+func (s *UserService) RequestPasswordReset(req *models.PasswordResetRequest) error {
+	db := s.getDB()
+	if db == nil {
+		return ErrUserDBUnavailable
+	}
+
+	var user *models.User
+	var err error
+	if username, usernameErr := normalizeUsername(req.Username); usernameErr == nil {
+		user, err = db.GetUserByUsername(username)
+	} else if email, emailErr := normalizeEmail(req.Email); emailErr == nil && email != "" {
+		user, err = db.GetUserByEmail(email)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to look up user: %w", err)
+	}
+	if user == nil {
+		logger.Info("service: password reset requested for an unknown account")
+		return nil
+	}
+
+	if _, err := s.issuePasswordResetToken(db, user); err != nil {
+		return err
+	}
+	logger.Warn("service: password reset token issued but no delivery channel is configured; an administrator must issue one out of band",
+		zap.String("user_id", user.ID))
+	return nil
+}
+
+// IssuePasswordResetToken issues a reset token for a user and returns it for out-of-band delivery
+func (s *UserService) IssuePasswordResetToken(userID string) (*PasswordResetTicket, error) {
+	db := s.getDB()
+	if db == nil {
+		return nil, ErrUserDBUnavailable
+	}
+
+	user, err := s.GetUserByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	return s.issuePasswordResetToken(db, user)
+}
+
+// IssueAdminPasswordResetToken issues a reset token for the administrator, for operators who
+// lost the only administrator password
+func (s *UserService) IssueAdminPasswordResetToken() (*PasswordResetTicket, error) {
+	db := s.getDB()
+	if db == nil {
+		return nil, ErrUserDBUnavailable
+	}
+
+	users, err := db.GetAllUsers()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read users: %w", err)
+	}
+	var admin *models.User
+	for _, user := range users {
+		if user.Role == "admin" && (admin == nil || user.CreatedAt.Before(admin.CreatedAt)) {
+			admin = user
+		}
+	}
+	if admin == nil {
+		return nil, ErrUserNotFound
+	}
+	return s.issuePasswordResetToken(db, admin)
+}
+
+func (s *UserService) issuePasswordResetToken(db database.DBInterface, user *models.User) (*PasswordResetTicket, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, fmt.Errorf("failed to generate password reset token: %w", err)
+	}
+	plaintext := base64.RawURLEncoding.EncodeToString(secret)
+
+	now := time.Now()
+	token := &models.PasswordResetToken{
+		ID:        uuid.New().String(),
+		UserID:    user.ID,
+		TokenHash: hashApiToken(plaintext),
+		ExpiresAt: now.Add(PasswordResetTokenTTL),
+		CreatedAt: now,
+	}
+	if err := db.CreatePasswordResetToken(token); err != nil {
+		return nil, fmt.Errorf("failed to save password reset token: %w", err)
+	}
+
+	logger.Info("service: password reset token issued", zap.String("user_id", user.ID), zap.Time("expires_at", token.ExpiresAt))
+	return &PasswordResetTicket{Token: plaintext, ExpiresAt: token.ExpiresAt, User: user}, nil
+}
+
+// ConfirmPasswordReset sets a new password with a reset token. Using a token invalidates every
+// other outstanding token of the user and signs out all of their sessions.
+func (s *UserService) ConfirmPasswordReset(req *models.PasswordResetConfirmRequest) (*models.User, error) {
+	db := s.getDB()
+	if db == nil {
+		return nil, ErrUserDBUnavailable
+	}
+	if req.Token == "" {
+		return nil, ErrPasswordResetTokenInvalid
+	}
+
+	var user *models.User
+	now := time.Now()
+	tokenHash := hashApiToken(req.Token)
+	if err := db.WithTransaction(func(tx database.DBInterface) error {
+		token, err := tx.GetPasswordResetTokenByHash(tokenHash)
+		if err != nil {
+			return fmt.Errorf("failed to read password reset token: %w", err)
+		}
+		if token == nil || token.UsedAt != nil {
+			return ErrPasswordResetTokenInvalid
+		}
+		if !now.Before(token.ExpiresAt) {
+			return ErrPasswordResetTokenExpired
+		}
+
+		user, err = tx.GetUserByID(token.UserID)
+		if err != nil {
+			return fmt.Errorf("failed to read user: %w", err)
+		}
+		if user == nil {
+			return ErrPasswordResetTokenInvalid
+		}
+		if err := ValidatePassword(s.PasswordPolicy(), user.Username, req.NewPassword); err != nil {
+			return err
+		}
+		hashedPassword, err := s.hashPassword(req.NewPassword)
+		if err != nil {
+			return err
+		}
+
+		// Consuming only an unused token keeps a concurrent confirmation from using it again
+		consumed, err := tx.ConsumePasswordResetToken(tokenHash, now)
+		if err != nil {
+			return fmt.Errorf("failed to consume password reset token: %w", err)
+		}
+		if consumed != 1 {
+			return ErrPasswordResetTokenInvalid
+		}
+		if _, err := tx.InvalidatePasswordResetTokens(user.ID, now); err != nil {
+			return fmt.Errorf("failed to invalidate password reset tokens: %w", err)
+		}
+
+		user.Password = string(hashedPassword)
+		user.MustChangePassword = false
+		user.UpdatedAt = now
+		if err := tx.UpdateUser(user); err != nil {
+			return fmt.Errorf("failed to update password: %w", err)
+		}
+
+		sessions, err := tx.GetSessionsByUserID(user.ID)
+		if err != nil {
+			return fmt.Errorf("failed to read session list: %w", err)
+		}
+		for _, session := range sessions {
+			if session.RevokedAt != nil {
+				continue
+			}
+			session.RevokedAt = &now
+			session.UpdatedAt = now
+			if err := tx.UpdateSession(session); err != nil {
+				return fmt.Errorf("failed to revoke user sessions: %w", err)
+			}
+		}
+		return nil
+	}); err != nil {
+		logger.Warn("service: password reset failed", zap.Error(err))
+		return nil, err
+	}
+
+	logger.Info("service: password reset with token completed", zap.String("user_id", user.ID))
+	return user, nil
+}
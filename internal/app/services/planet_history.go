@@ -0,0 +1,106 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/GT-610/tairitsu/internal/app/database"
+	"github.com/GT-610/tairitsu/internal/app/logger"
+	"github.com/GT-610/tairitsu/internal/app/models"
+	"github.com/GT-610/tairitsu/internal/mkworld"
+	"go.uber.org/zap"
+)
+
+// ErrPlanetGenerationNotFound is returned when a generated planet is not in the history
+var ErrPlanetGenerationNotFound = errors.New("generated planet not found")
+
+// PlanetHistoryService keeps the planets generated through the API, so they can be downloaded
+// again. Only the newest generations up to the configured limit are kept.
+type PlanetHistoryService struct {
+	db    database.DBInterface
+	mutex sync.RWMutex
+	limit int
+}
+
+// NewPlanetHistoryService creates a new planet history service instance
+func NewPlanetHistoryService(db database.DBInterface, limit int) *PlanetHistoryService {
+	return &PlanetHistoryService{db: db, limit: limit}
+}
+
+func (s *PlanetHistoryService) SetDB(db database.DBInterface) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.db = db
+}
+
+func (s *PlanetHistoryService) getDB() database.DBInterface {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return s.db
+}
+
+// Record stores a generated planet and drops the generations beyond the limit
+func (s *PlanetHistoryService) Record(planet *mkworld.GeneratedPlanet, rootNodes []mkworld.RootNodeConfig, createdBy string) (*models.PlanetGeneration, error) {
+	db := s.getDB()
+	if db == nil {
+		return nil, ErrUserDBUnavailable
+	}
+
+	roots := make([]models.PlanetRootNode, 0, len(rootNodes))
+	for _, root := range rootNodes {
+		roots = append(roots, models.PlanetRootNode{
+			IdentityPublic: root.IdentityPublic,
+			Comments:       root.Comments,
+			Endpoints:      root.Endpoints,
+		})
+	}
+	generation := &models.PlanetGeneration{
+		PlanetID:      planet.PlanetID,
+		BirthTime:     planet.BirthTime,
+		DownloadName:  planet.DownloadName,
+		RootNodes:     roots,
+		RootNodeCount: planet.RootNodeCount,
+		EndpointCount: planet.EndpointCount,
+		SigningKeys:   string(planet.SigningKeys),
+		CreatedBy:     createdBy,
+		CreatedAt:     time.Now(),
+		Data:          planet.PlanetData,
+	}
+	if err := db.CreatePlanetGeneration(generation); err != nil {
+		return nil, fmt.Errorf("failed to store generated planet: %w", err)
+	}
+
+	if deleted, err := db.DeletePlanetGenerationsBeyond(s.limit); err != nil {
+		logger.Warn("failed to prune planet history", zap.Error(err))
+	} else if deleted > 0 {
+		logger.Info("pruned planet history", zap.Int64("deleted", deleted), zap.Int("kept", s.limit))
+	}
+	return generation, nil
+}
+
+// List returns the kept generations newest first, without their file data
+func (s *PlanetHistoryService) List() ([]*models.PlanetGeneration, error) {
+	db := s.getDB()
+	if db == nil {
+		return nil, ErrUserDBUnavailable
+	}
+	return db.ListPlanetGenerations(s.limit)
+}
+
+// Get returns a kept generation with its file data
+func (s *PlanetHistoryService) Get(id uint64) (*models.PlanetGeneration, error) {
+	db := s.getDB()
+	if db == nil {
+		return nil, ErrUserDBUnavailable
+	}
+	generation, err := db.GetPlanetGenerationByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if generation == nil {
+		return nil, ErrPlanetGenerationNotFound
+	}
+	return generation, nil
+}
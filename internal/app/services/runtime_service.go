@@ -1,7 +1,10 @@
 package services
 
 import (
+	"context"
 	"fmt"
+	"sync"
+	"time"
 
 	"github.com/GT-610/tairitsu/internal/app/database"
 	"github.com/GT-610/tairitsu/internal/app/logger"
@@ -9,23 +12,94 @@ import (
 	"go.uber.org/zap"
 )
 
+// DBBinder is implemented by services that follow the active database connection.
+type DBBinder interface {
+	SetDB(db database.DBInterface)
+}
+
+const (
+	defaultDatabaseReconnectInterval = 5 * time.Second
+	maxDatabaseReconnectInterval     = time.Minute
+)
+
 // RuntimeService coordinates mutable runtime dependencies such as the active DB and ZeroTier client.
 type RuntimeService struct {
 	userService    *UserService
 	sessionService *SessionService
 	networkService *NetworkService
 	stateService   *StateService
+	dbBinders      []DBBinder
+
+	databaseMutex     sync.RWMutex
+	databaseErr       error // Why the configured database could not be reached; nil once it is bound
+	reconnectInterval time.Duration
 }
 
 func NewRuntimeService(userService *UserService, sessionService *SessionService, networkService *NetworkService, stateService *StateService) *RuntimeService {
 	return &RuntimeService{
-		userService:    userService,
-		sessionService: sessionService,
-		networkService: networkService,
-		stateService:   stateService,
+		userService:       userService,
+		sessionService:    sessionService,
+		networkService:    networkService,
+		stateService:      stateService,
+		reconnectInterval: defaultDatabaseReconnectInterval,
 	}
 }
 
+// SetDatabaseReconnectInterval sets the first delay between reconnect attempts; it doubles up to one minute
+func (s *RuntimeService) SetDatabaseReconnectInterval(interval time.Duration) {
+	if interval > 0 {
+		s.reconnectInterval = interval
+	}
+}
+
+// DatabaseError returns why the configured database is unreachable, or nil when it is
+// bound or not configured at all
+func (s *RuntimeService) DatabaseError() error {
+	s.databaseMutex.RLock()
+	defer s.databaseMutex.RUnlock()
+	return s.databaseErr
+}
+
+// MarkDatabaseUnavailable records that the configured database could not be reached
+func (s *RuntimeService) MarkDatabaseUnavailable(err error) {
+	s.databaseMutex.Lock()
+	defer s.databaseMutex.Unlock()
+	s.databaseErr = err
+}
+
+// StartDatabaseReconnect reopens the configured database in the background until it
+// succeeds or ctx ends; it does nothing while no connection failure is recorded
+func (s *RuntimeService) StartDatabaseReconnect(ctx context.Context) <-chan struct{} {
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		delay := s.reconnectInterval
+		timer := time.NewTimer(delay)
+		defer timer.Stop()
+		for s.DatabaseError() != nil {
+			select {
+			case <-ctx.Done():
+				return
+			case <-timer.C:
+			}
+			if err := s.ReopenConfiguredDatabase(); err != nil {
+				s.MarkDatabaseUnavailable(err)
+				delay = min(delay*2, max(maxDatabaseReconnectInterval, s.reconnectInterval))
+				logger.Warn("database still unreachable", zap.Duration("retry_in", delay), zap.Error(err))
+				timer.Reset(delay)
+				continue
+			}
+			logger.Info("database connection restored")
+		}
+	}()
+	return done
+}
+
+// RegisterDBBinders adds services that must be rebound whenever the active database changes
+func (s *RuntimeService) RegisterDBBinders(binders ...DBBinder) {
+	s.dbBinders = append(s.dbBinders, binders...)
+}
+
 func (s *RuntimeService) CurrentDatabase() database.DBInterface {
 	if s.userService != nil && s.userService.GetDB() != nil {
 		return s.userService.GetDB()
@@ -52,6 +126,12 @@ func (s *RuntimeService) BindDatabase(db database.DBInterface) {
 	if s.networkService != nil {
 		s.networkService.SetDB(db)
 	}
+	for _, binder := range s.dbBinders {
+		binder.SetDB(db)
+	}
+	if db != nil {
+		s.MarkDatabaseUnavailable(nil)
+	}
 }
 
 func (s *RuntimeService) CloseCurrentDatabase() {
@@ -73,6 +153,9 @@ func (s *RuntimeService) CloseCurrentDatabase() {
 	if s.networkService != nil {
 		s.networkService.SetDB(nil)
 	}
+	for _, binder := range s.dbBinders {
+		binder.SetDB(nil)
+	}
 }
 
 func (s *RuntimeService) ReopenConfiguredDatabase() error {
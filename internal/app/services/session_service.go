@@ -168,6 +168,20 @@ func (s *SessionService) RevokeSession(userID, sessionID string) error {
 	return nil
 }
 
+// RevokeAllSessions signs out every user, e.g. after the JWT secret was replaced
+func (s *SessionService) RevokeAllSessions() (int64, error) {
+	db := s.getDB()
+	if db == nil {
+		return 0, ErrUserDBUnavailable
+	}
+
+	count, err := db.RevokeAllSessions(time.Now())
+	if err != nil {
+		return 0, fmt.Errorf("failed to revoke sessions: %w", err)
+	}
+	return count, nil
+}
+
 func (s *SessionService) RevokeOtherSessions(userID, currentSessionID string) (int, error) {
 	sessions, err := s.GetUserSessions(userID)
 	if err != nil {
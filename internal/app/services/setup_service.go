@@ -1,8 +1,12 @@
 package services
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/GT-610/tairitsu/internal/app/config"
 	"github.com/GT-610/tairitsu/internal/app/database"
@@ -17,8 +21,19 @@ type SetupService struct {
 	stateService   *StateService
 	userService    *UserService
 	networkService *NetworkService
+
+	statusMutex    sync.Mutex
+	cachedStatus   *SetupStatus
+	cachedStatusAt time.Time
 }
 
+// setupStatusCacheTTL is how long an initialized instance reuses its status, so clients
+// polling it do not each query the database
+const setupStatusCacheTTL = 2 * time.Second
+
+// connectionTestTimeout bounds both requests of a controller connection test
+const connectionTestTimeout = 10 * time.Second
+
 var (
 	ErrSetupUnsupportedDatabase        = errors.New("setup.unsupported_database")
 	ErrSetupInvalidConfig              = errors.New("setup.invalid_config")
@@ -35,8 +50,13 @@ var (
 	ErrSetupInitializationStateFailed  = errors.New("setup.initialization_state_failed")
 	ErrSetupAdminRequired              = errors.New("setup.admin_required")
 	ErrSetupZeroTierUnavailable        = errors.New("setup.zerotier_unavailable")
+	ErrSetupResetNotConfirmed          = errors.New("setup.reset_confirmation_required")
+	ErrSetupStepOutOfOrder             = errors.New("setup.step_out_of_order")
 )
 
+// ErrEncryptionKeyExternal is returned when the encryption key comes from TAIRITSU_MASTER_KEY_FILE
+var ErrEncryptionKeyExternal = config.ErrEncryptionKeyExternal
+
 func NewSetupService(runtimeService *RuntimeService, stateService *StateService, userService *UserService, networkService *NetworkService) *SetupService {
 	return &SetupService{
 		runtimeService: runtimeService,
@@ -47,20 +67,11 @@ func NewSetupService(runtimeService *RuntimeService, stateService *StateService,
 }
 
 func (s *SetupService) ConfigureDatabase(dbConfig models.DatabaseConfig) (database.Config, error) {
-	if dbConfig.Type != string(database.SQLite) {
+	dbCfg := database.ConfigFromRequest(dbConfig)
+	if dbCfg.Type != database.SQLite {
 		return database.Config{}, ErrSetupUnsupportedDatabase
 	}
 
-	dbCfg := database.Config{
-		Type: database.DatabaseType(dbConfig.Type),
-		Path: dbConfig.Path,
-		Host: dbConfig.Host,
-		Port: dbConfig.Port,
-		User: dbConfig.User,
-		Pass: dbConfig.Pass,
-		Name: dbConfig.Name,
-	}
-
 	db, err := database.NewDatabase(dbCfg)
 	if err != nil {
 		return database.Config{}, fmt.Errorf("%w: %v", ErrSetupDatabaseConnectionFailed, err)
@@ -74,10 +85,6 @@ func (s *SetupService) ConfigureDatabase(dbConfig models.DatabaseConfig) (databa
 		return database.Config{}, fmt.Errorf("%w: %v", ErrSetupDatabaseInitialization, err)
 	}
 
-	if dbCfg.Type == database.SQLite && dbCfg.Path == "" {
-		dbCfg.Path = "data/tairitsu.db"
-	}
-
 	if err := s.stateService.SaveDatabaseConfig(dbCfg); err != nil {
 		if closeErr := db.Close(); closeErr != nil {
 			logger.Warn("failed to close database after save configuration error", zap.Error(closeErr))
@@ -86,12 +93,27 @@ func (s *SetupService) ConfigureDatabase(dbConfig models.DatabaseConfig) (databa
 		return database.Config{}, fmt.Errorf("%w: %v", ErrSetupDatabaseConfigSaveFailed, err)
 	}
 
+	if err := s.recordSetupStep(SetupStepDatabase); err != nil {
+		if closeErr := db.Close(); closeErr != nil {
+			logger.Warn("failed to close database after save setup progress error", zap.Error(closeErr))
+		}
+		return database.Config{}, fmt.Errorf("%w: %v", ErrSetupDatabaseConfigSaveFailed, err)
+	}
+
 	s.runtimeService.BindDatabase(db)
 	return dbCfg, nil
 }
 
-func (s *SetupService) SaveZeroTierConfig(controllerURL, tokenPath string) (*zerotier.Status, error) {
-	if err := s.stateService.SaveZeroTierConfig(controllerURL, tokenPath); err != nil {
+// SaveZeroTierConfig connects the controller at controllerURL, authenticating with the token
+// read from tokenPath or with token itself; exactly one of the two must be given
+func (s *SetupService) SaveZeroTierConfig(controllerURL, tokenPath, token string) (*zerotier.Status, error) {
+	if err := s.requireSetupStep(SetupStepZeroTier); err != nil {
+		return nil, err
+	}
+	if (tokenPath == "") == (strings.TrimSpace(token) == "") {
+		return nil, fmt.Errorf("%w: give either a token path or a token", ErrSetupInvalidConfig)
+	}
+	if err := s.stateService.SaveZeroTierConfig(controllerURL, tokenPath, token); err != nil {
 		return nil, fmt.Errorf("%w: %v", ErrSetupZeroTierConfigSaveFailed, err)
 	}
 
@@ -104,13 +126,63 @@ func (s *SetupService) SaveZeroTierConfig(controllerURL, tokenPath string) (*zer
 	if err != nil {
 		return nil, fmt.Errorf("%w: %v", ErrSetupZeroTierValidationFailed, err)
 	}
+	// The status is readable with a token that cannot manage networks
+	if err := ztClient.CheckControllerAccess(context.Background()); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrSetupZeroTierValidationFailed, err)
+	}
+
+	if err := s.recordSetupStep(SetupStepZeroTier); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrSetupZeroTierConfigSaveFailed, err)
+	}
 
 	s.runtimeService.BindZTClient(ztClient)
 	return status, nil
 }
 
-func (s *SetupService) GetSetupStatus() SetupStatus {
-	return s.stateService.GetSetupStatus(s.userService, s.networkService)
+// TestZeroTierConnection checks the controller at controllerURL with the token read from
+// tokenPath or with token itself, without saving either. Failures of the controller are
+// reported in the result; an error means the check could not be run.
+func (s *SetupService) TestZeroTierConnection(controllerURL, tokenPath, token string) (zerotier.ConnectionCheck, error) {
+	token = strings.TrimSpace(token)
+	if strings.TrimSpace(controllerURL) == "" || (tokenPath == "") == (token == "") {
+		return zerotier.ConnectionCheck{}, fmt.Errorf("%w: give a controller URL and either a token path or a token", ErrSetupInvalidConfig)
+	}
+	if tokenPath != "" {
+		var err error
+		if token, err = config.ReadTokenFile(tokenPath); err != nil {
+			return zerotier.ConnectionCheck{}, fmt.Errorf("%w: %v", ErrSetupZeroTierClientCreateFailed, err)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), connectionTestTimeout)
+	defer cancel()
+	return zerotier.NewClientWithOptions(controllerURL, token, nil).TestConnection(ctx), nil
+}
+
+// GetSetupStatus reports setup progress; fresh refreshes the cached controller status first.
+// Once initialized, the status is reused for setupStatusCacheTTL unless fresh is set; during
+// setup every call reads the current state.
+func (s *SetupService) GetSetupStatus(fresh bool) SetupStatus {
+	s.statusMutex.Lock()
+	defer s.statusMutex.Unlock()
+
+	if !fresh && s.cachedStatus != nil && time.Since(s.cachedStatusAt) < setupStatusCacheTTL && s.stateService.IsInitialized() {
+		return *s.cachedStatus
+	}
+	status := s.stateService.setupStatus(s.userService, s.networkService, fresh)
+	if status.Initialized {
+		s.cachedStatus, s.cachedStatusAt = &status, time.Now()
+	} else {
+		s.cachedStatus = nil
+	}
+	return status
+}
+
+// forgetSetupStatus drops the cached status after a change it reports
+func (s *SetupService) forgetSetupStatus() {
+	s.statusMutex.Lock()
+	defer s.statusMutex.Unlock()
+	s.cachedStatus = nil
 }
 
 func (s *SetupService) GetRuntimeSettings() RuntimeSettings {
@@ -118,21 +190,57 @@ func (s *SetupService) GetRuntimeSettings() RuntimeSettings {
 }
 
 func (s *SetupService) UpdateRuntimeSettings(settings RuntimeSettings) error {
+	defer s.forgetSetupStatus()
 	return s.stateService.SaveRuntimeSettings(settings)
 }
 
-func (s *SetupService) InitializeAdminCreation() (string, error) {
+// GetPasswordPolicy returns the password policy enforced for local accounts
+func (s *SetupService) GetPasswordPolicy() PasswordPolicy {
+	return s.userService.PasswordPolicy()
+}
+
+// UpdatePasswordPolicy validates, persists and applies a new password policy
+func (s *SetupService) UpdatePasswordPolicy(policy PasswordPolicy) (PasswordPolicy, error) {
+	policy.MaxLength = MaxPasswordLength
+	if err := policy.Validate(); err != nil {
+		return PasswordPolicy{}, err
+	}
+
+	cfg := s.stateService.ensureConfig()
+	if err := config.SetPasswordPolicyOn(cfg, policy.ConfigValue()); err != nil {
+		logger.Error("service: failed to save password policy", zap.Error(err))
+		return PasswordPolicy{}, err
+	}
+	s.userService.SetPasswordPolicy(policy)
+	return policy, nil
+}
+
+// RotateEncryptionKey replaces the key that encrypts the stored controller token and
+// database password
+func (s *SetupService) RotateEncryptionKey() error {
+	cfg := s.stateService.ensureConfig()
+	if err := config.RotateEncryptionKey(cfg); err != nil {
+		logger.Error("service: failed to rotate encryption key", zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+// InitializeAdminCreation clears the configured database before the first administrator is
+// created. The reset only happens with confirmReset set; without it the call fails and the
+// data is kept.
+func (s *SetupService) InitializeAdminCreation(confirmReset bool) (string, error) {
 	dbConfig := s.stateService.DatabaseConfig()
 	if dbConfig.Type == "" {
 		return "", ErrSetupInvalidConfig
 	}
-	if dbConfig.Type != database.SQLite {
-		return "", fmt.Errorf("%w: %s", ErrSetupUnsupportedDatabase, dbConfig.Type)
-	}
 
 	if s.stateService.IsInitialized() {
 		return "", ErrSetupAlreadyInitialized
 	}
+	if err := s.requireSetupStep(SetupStepAdmin); err != nil {
+		return "", err
+	}
 
 	resetDoneKey := "admin_creation_reset_done"
 	if config.GetTempSetting(resetDoneKey) == "true" {
@@ -148,9 +256,13 @@ func (s *SetupService) InitializeAdminCreation() (string, error) {
 		return string(dbConfig.Type), nil
 	}
 
+	if !confirmReset {
+		return "", ErrSetupResetNotConfirmed
+	}
+
 	s.runtimeService.CloseCurrentDatabase()
 
-	if err := database.ResetDatabase(dbConfig); err != nil {
+	if err := database.ResetDatabase(dbConfig, true); err != nil {
 		return "", fmt.Errorf("%w: %v", ErrSetupAdminCreationInitFailed, err)
 	}
 
@@ -164,11 +276,16 @@ func (s *SetupService) InitializeAdminCreation() (string, error) {
 
 func (s *SetupService) SetInitialized(initialized bool) error {
 	if initialized {
+		// The admin step is checked by validateInitializationReady, which can reopen the database
+		if err := s.requireSetupStep(SetupStepAdmin); err != nil {
+			return err
+		}
 		if err := s.validateInitializationReady(); err != nil {
 			return err
 		}
 	}
 
+	defer s.forgetSetupStatus()
 	if err := s.stateService.SetInitialized(initialized); err != nil {
 		return fmt.Errorf("%w: %v", ErrSetupInitializationStateFailed, err)
 	}
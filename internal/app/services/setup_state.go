@@ -0,0 +1,96 @@
+package services
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/GT-610/tairitsu/internal/app/config"
+)
+
+// SetupStep is one stage of the first-run wizard
+type SetupStep string
+
+const (
+	SetupStepDatabase SetupStep = "database"
+	SetupStepZeroTier SetupStep = "zerotier"
+	SetupStepAdmin    SetupStep = "admin"
+	SetupStepFinalize SetupStep = "finalize"
+)
+
+// setupSteps lists the wizard steps in the order they must be completed
+var setupSteps = []SetupStep{SetupStepDatabase, SetupStepZeroTier, SetupStepAdmin, SetupStepFinalize}
+
+// SetupState describes how far the setup wizard got
+type SetupState struct {
+	Steps       []SetupStep `json:"steps"`
+	Completed   []SetupStep `json:"completed"`
+	Current     SetupStep   `json:"current,omitempty"` // Next step to complete; empty once initialized
+	Initialized bool        `json:"initialized"`
+}
+
+// GetSetupState returns the wizard progress
+func (s *SetupService) GetSetupState() SetupState {
+	completed := s.completedSetupSteps()
+	state := SetupState{
+		Steps:       slices.Clone(setupSteps),
+		Completed:   slices.Clone(setupSteps[:completed]),
+		Initialized: s.stateService.IsInitialized(),
+	}
+	if completed < len(setupSteps) {
+		state.Current = setupSteps[completed]
+	}
+	return state
+}
+
+// completedSetupSteps counts the completed wizard steps. The administrator lives in the
+// database and finalizing is the initialized flag, so only the steps before them are
+// recorded in the config file.
+func (s *SetupService) completedSetupSteps() int {
+	if s.stateService.IsInitialized() {
+		return len(setupSteps)
+	}
+
+	completed := s.recordedSetupSteps()
+	if completed == slices.Index(setupSteps, SetupStepAdmin) && s.userService != nil {
+		if hasAdmin, err := s.userService.HasAdminUser(); err == nil && hasAdmin {
+			completed++
+		}
+	}
+	return completed
+}
+
+// recordedSetupSteps counts the steps recorded in the config file. Configs saved before
+// progress was recorded are judged by the settings they hold.
+func (s *SetupService) recordedSetupSteps() int {
+	if step := s.stateService.SetupCompletedStep(); step != "" {
+		return slices.Index(setupSteps, SetupStep(step)) + 1
+	}
+
+	completed := 0
+	if s.stateService.DatabaseConfigured() {
+		completed++
+		if config.ZeroTierConfigured(s.stateService.Config()) {
+			completed++
+		}
+	}
+	return completed
+}
+
+// requireSetupStep refuses to run step before every step ahead of it is complete
+func (s *SetupService) requireSetupStep(step SetupStep) error {
+	completed := s.completedSetupSteps()
+	if completed < slices.Index(setupSteps, step) {
+		return fmt.Errorf("%w: complete the %s step first", ErrSetupStepOutOfOrder, setupSteps[completed])
+	}
+	return nil
+}
+
+// recordSetupStep persists step as completed, keeping any later step already reached
+func (s *SetupService) recordSetupStep(step SetupStep) error {
+	completed := max(s.recordedSetupSteps(), slices.Index(setupSteps, step)+1)
+	latest := string(setupSteps[completed-1])
+	if s.stateService.SetupCompletedStep() == latest {
+		return nil
+	}
+	return s.stateService.SaveSetupCompletedStep(latest)
+}
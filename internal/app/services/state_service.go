@@ -1,6 +1,8 @@
 package services
 
 import (
+	"time"
+
 	"github.com/GT-610/tairitsu/internal/app/config"
 	"github.com/GT-610/tairitsu/internal/app/database"
 	"github.com/GT-610/tairitsu/internal/app/logger"
@@ -20,6 +22,32 @@ type SetupStatus struct {
 	ZeroTierConfig          *SetupZeroTier   `json:"zeroTierConfig,omitempty"`
 	AllowPublicRegistration bool             `json:"allowPublicRegistration"`
 	ZTStatus                *zerotier.Status `json:"ztStatus,omitempty"`
+	// ZTStatusFetchedAt is when ZTStatus was read from the controller status cache
+	ZTStatusFetchedAt *time.Time `json:"ztStatusFetchedAt,omitempty"`
+	ZTStatusStale     bool       `json:"ztStatusStale"`
+	// ZTVersion is the controller version as Tairitsu understood it, empty when it could not
+	// be parsed; ZTFeatures lists the network settings that version accepts
+	ZTVersion  string             `json:"ztVersion,omitempty"`
+	ZTFeatures []zerotier.Feature `json:"ztFeatures,omitempty"`
+	DemoMode   bool               `json:"demoMode"`
+	// Checks reports the dependency checks this status needed, by StatusCheckDatabase and
+	// StatusCheckController; a controller status read from the cache needs no check
+	Checks map[string]StatusCheck `json:"checks,omitempty"`
+}
+
+// Names of the checks in SetupStatus.Checks
+const (
+	StatusCheckDatabase   = "database"
+	StatusCheckController = "controller"
+)
+
+// statusCheckTimeout bounds each dependency check of the setup status
+const statusCheckTimeout = 3 * time.Second
+
+// StatusCheck reports how long one dependency check took and why it failed
+type StatusCheck struct {
+	LatencyMs int64  `json:"latencyMs"`
+	Error     string `json:"error,omitempty"`
 }
 
 type SetupDatabase struct {
@@ -33,7 +61,7 @@ type SetupZeroTier struct {
 }
 
 type RuntimeSettings struct {
-	AllowPublicRegistration bool `json:"allow_public_registration"`
+	AllowPublicRegistration bool `json:"allowPublicRegistration"`
 }
 
 type StateService struct {
@@ -65,11 +93,33 @@ func (s *StateService) SetInitialized(initialized bool) error {
 	return config.SaveConfig(cfg)
 }
 
+// SetupCompletedStep returns the last setup step recorded in the config file
+func (s *StateService) SetupCompletedStep() string {
+	cfg := s.Config()
+	if cfg == nil {
+		return ""
+	}
+	return cfg.Setup.CompletedStep
+}
+
+// SaveSetupCompletedStep records the last completed setup step
+func (s *StateService) SaveSetupCompletedStep(step string) error {
+	cfg := s.ensureConfig()
+	cfg.Setup.CompletedStep = step
+	return config.SaveConfig(cfg)
+}
+
 func (s *StateService) IsInitialized() bool {
 	cfg := s.Config()
 	return cfg != nil && cfg.Initialized
 }
 
+// IsDemoMode reports whether the application runs against seeded throwaway data
+func (s *StateService) IsDemoMode() bool {
+	cfg := s.Config()
+	return cfg != nil && cfg.DemoMode
+}
+
 func (s *StateService) DatabaseConfigured() bool {
 	cfg := s.Config()
 	return cfg != nil && cfg.Database.Type != ""
@@ -87,8 +137,13 @@ func (s *StateService) SaveDatabaseConfig(dbCfg database.Config) error {
 	return nil
 }
 
-func (s *StateService) SaveZeroTierConfig(url, tokenPath string) error {
+// SaveZeroTierConfig stores the controller URL with either a token file path or, when token
+// is set, the token itself
+func (s *StateService) SaveZeroTierConfig(url, tokenPath, token string) error {
 	cfg := s.ensureConfig()
+	if token != "" {
+		return config.SetZTInlineConfigOn(cfg, url, token)
+	}
 	if err := config.SetZTConfigOn(cfg, url, tokenPath); err != nil {
 		return err
 	}
@@ -106,14 +161,41 @@ func (s *StateService) SaveRuntimeSettings(settings RuntimeSettings) error {
 	return config.SetAllowPublicRegistrationOn(cfg, settings.AllowPublicRegistration)
 }
 
+// RotateJWTSecret replaces the JWT secret and hands it to jwtService, which keeps accepting
+// tokens signed with the replaced secret for config.PreviousJWTSecretWindow
+func (s *StateService) RotateJWTSecret(jwtService *JWTService) (time.Time, error) {
+	cfg := s.ensureConfig()
+	if err := config.RotateJWTSecret(cfg); err != nil {
+		logger.Error("service: failed to rotate JWT secret", zap.Error(err))
+		return time.Time{}, err
+	}
+	if err := jwtService.SetSecrets(cfg.Security.JWTSecret, cfg.Security.PreviousJWTSecrets); err != nil {
+		return time.Time{}, err
+	}
+
+	var previousValidUntil time.Time
+	if retired := cfg.Security.PreviousJWTSecrets; len(retired) > 0 {
+		previousValidUntil = retired[len(retired)-1].ExpiresAt
+	}
+	return previousValidUntil, nil
+}
+
 func (s *StateService) CreateZTClient() (*zerotier.Client, error) {
 	return zerotier.NewClientWithConfig(s.Config())
 }
 
+// GetSetupStatus reports setup progress from the cached controller status
 func (s *StateService) GetSetupStatus(userService *UserService, networkService *NetworkService) SetupStatus {
+	return s.setupStatus(userService, networkService, false)
+}
+
+// setupStatus assembles the setup status. The administrator lookup and any controller round
+// trip run concurrently, each bounded by statusCheckTimeout, and report their latency in
+// Checks. fresh refreshes the controller status cache of a bound runtime client first.
+func (s *StateService) setupStatus(userService *UserService, networkService *NetworkService, fresh bool) SetupStatus {
 	cfg := s.Config()
 	databaseConfigured := s.DatabaseConfigured()
-	zeroTierConfigured := cfg != nil && cfg.ZeroTier.URL != "" && cfg.ZeroTier.TokenPath != ""
+	zeroTierConfigured := config.ZeroTierConfigured(cfg)
 
 	status := SetupStatus{
 		Initialized:             s.IsInitialized(),
@@ -122,6 +204,7 @@ func (s *StateService) GetSetupStatus(userService *UserService, networkService *
 		ZeroTierConfigured:      zeroTierConfigured,
 		AdminCreationPrepared:   config.GetTempSetting("admin_creation_reset_done") == "true",
 		AllowPublicRegistration: config.AllowPublicRegistration(s.Config()),
+		DemoMode:                s.IsDemoMode(),
 	}
 
 	if databaseConfigured && cfg != nil {
@@ -138,50 +221,146 @@ func (s *StateService) GetSetupStatus(userService *UserService, networkService *
 		}
 	}
 
+	runtimeClient := networkService != nil && networkService.getZTClient() != nil
+
+	var waitAdmin func() (setupAdmin, StatusCheck)
 	if databaseConfigured && userService != nil {
-		users, err := userService.GetAllUsers()
-		if err != nil {
-			logger.Warn("GetSetupStatus: GetAllUsers failed", zap.Error(err))
-		}
-		for _, user := range users {
-			if user.Role == "admin" {
-				status.HasAdmin = true
-				status.AdminUsername = user.Username
-				break
+		initialized := status.Initialized
+		waitAdmin = startStatusCheck(func() (setupAdmin, error) {
+			return findSetupAdmin(userService, initialized)
+		})
+	}
+
+	var waitController func() (*zerotier.Status, StatusCheck)
+	switch {
+	case fresh && runtimeClient:
+		waitController = startStatusCheck(func() (*zerotier.Status, error) {
+			return nil, networkService.RefreshControllerStatus()
+		})
+	case zeroTierConfigured && !runtimeClient:
+		// The setup wizard checks a controller before the runtime client is bound to it
+		waitController = startStatusCheck(func() (*zerotier.Status, error) {
+			ztClient, err := s.CreateZTClient()
+			if err != nil {
+				return nil, err
 			}
+			return ztClient.GetStatus()
+		})
+	}
+
+	var liveStatus *zerotier.Status
+	if waitAdmin != nil || waitController != nil {
+		status.Checks = make(map[string]StatusCheck)
+	}
+	if waitAdmin != nil {
+		var admin setupAdmin
+		admin, status.Checks[StatusCheckDatabase] = waitAdmin()
+		if message := status.Checks[StatusCheckDatabase].Error; message != "" {
+			logger.Warn("GetSetupStatus: administrator check failed", zap.String("error", message))
 		}
+		status.HasAdmin = admin.exists
+		status.AdminUsername = admin.username
+	}
+	if waitController != nil {
+		liveStatus, status.Checks[StatusCheckController] = waitController()
 	}
 
-	if zeroTierConfigured {
-		if networkService != nil {
-			if ztStatus, err := networkService.GetStatus(); err == nil {
-				status.ZTStatus = ztStatus
-			}
+	if runtimeClient && (zeroTierConfigured || status.Initialized) {
+		if snapshot := networkService.ControllerStatus(false); snapshot.Fetched() {
+			fetchedAt := snapshot.FetchedAt
+			status.ZTStatus = snapshot.Status
+			status.ZTStatusFetchedAt = &fetchedAt
+			status.ZTStatusStale = snapshot.Stale
 		}
-		if status.ZTStatus == nil {
-			if ztClient, err := s.CreateZTClient(); err == nil {
-				if ztStatus, err := ztClient.GetStatus(); err == nil {
-					status.ZTStatus = ztStatus
-				}
-			}
+	}
+	if status.ZTStatus == nil {
+		status.ZTStatus = liveStatus
+	}
+	if status.ZTStatus != nil {
+		features := zerotier.FeaturesForVersion(status.ZTStatus.Version)
+		if !features.Version.IsZero() {
+			status.ZTVersion = features.Version.String()
 		}
+		status.ZTFeatures = features.List()
 	}
 
 	if status.Initialized && status.ZTStatus == nil && networkService != nil {
-		if ztStatus, err := networkService.GetStatus(); err == nil {
-			status.ZTStatus = ztStatus
-		} else {
-			status.ZTStatus = &zerotier.Status{
-				Version: "unknown",
-				Address: "",
-				Online:  false,
-			}
+		status.ZTStatus = &zerotier.Status{
+			Version: "unknown",
+			Address: "",
+			Online:  false,
 		}
 	}
 
 	return status
 }
 
+// setupAdmin is what the setup status reports about the administrator account
+type setupAdmin struct {
+	exists   bool
+	username string
+}
+
+// findSetupAdmin checks whether an administrator exists. Only the setup wizard shows which
+// account it is, and the users table then holds little more than that account, so the
+// username is looked up only before initialization.
+func findSetupAdmin(userService *UserService, initialized bool) (setupAdmin, error) {
+	exists, err := userService.HasAdminUser()
+	if err != nil || !exists {
+		return setupAdmin{}, err
+	}
+	admin := setupAdmin{exists: true}
+	if initialized {
+		return admin, nil
+	}
+	users, err := userService.GetAllUsers()
+	if err != nil {
+		return admin, err
+	}
+	for _, user := range users {
+		if user.Role == "admin" {
+			admin.username = user.Username
+			break
+		}
+	}
+	return admin, nil
+}
+
+// startStatusCheck runs check in the background and returns a function that waits up to
+// statusCheckTimeout for its result. A check that times out keeps running; its result is
+// discarded.
+func startStatusCheck[T any](check func() (T, error)) func() (T, StatusCheck) {
+	type outcome struct {
+		value T
+		err   error
+	}
+	started := time.Now()
+	done := make(chan outcome, 1)
+	go func() {
+		value, err := check()
+		done <- outcome{value: value, err: err}
+	}()
+
+	return func() (T, StatusCheck) {
+		timer := time.NewTimer(max(statusCheckTimeout-time.Since(started), 0))
+		defer timer.Stop()
+		select {
+		case result := <-done:
+			report := StatusCheck{LatencyMs: time.Since(started).Milliseconds()}
+			if result.err != nil {
+				report.Error = result.err.Error()
+			}
+			return result.value, report
+		case <-timer.C:
+			var zero T
+			return zero, StatusCheck{
+				LatencyMs: time.Since(started).Milliseconds(),
+				Error:     "timed out after " + statusCheckTimeout.String(),
+			}
+		}
+	}
+}
+
 func (s *StateService) ensureConfig() *config.Config {
 	cfg := s.Config()
 	if cfg == nil {
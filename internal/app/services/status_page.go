@@ -0,0 +1,169 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/GT-610/tairitsu/internal/app/logger"
+	"go.uber.org/zap"
+)
+
+const (
+	defaultStatusPageTitle = "Tairitsu Status"
+	statusPageCacheTTL     = 30 * time.Second
+)
+
+var ErrStatusPageDisabled = errors.New("status page is disabled")
+
+// StatusPageNetwork is the aggregate shown for a published network; it never carries member data
+type StatusPageNetwork struct {
+	Name        string `json:"name"`
+	OnlineNodes int    `json:"onlineNodes"`
+	TotalNodes  int    `json:"totalNodes"`
+}
+
+// StatusPage is the public view of instance health
+type StatusPage struct {
+	Title       string              `json:"title"`
+	LogoText    string              `json:"logoText,omitempty"`
+	Status      string              `json:"status"`
+	Components  map[string]string   `json:"components"`
+	Networks    []StatusPageNetwork `json:"networks"`
+	GeneratedAt time.Time           `json:"generatedAt"`
+}
+
+// StatusPageService builds and caches the public status page
+type StatusPageService struct {
+	stateService   *StateService
+	healthService  *HealthService
+	networkService *NetworkService
+
+	mutex     sync.Mutex
+	cached    *StatusPage
+	expiresAt time.Time
+}
+
+// NewStatusPageService creates a new status page service instance
+func NewStatusPageService(stateService *StateService, healthService *HealthService, networkService *NetworkService) *StatusPageService {
+	return &StatusPageService{
+		stateService:   stateService,
+		healthService:  healthService,
+		networkService: networkService,
+	}
+}
+
+// Page returns the cached status page, rebuilding it once the cache expires
+func (s *StatusPageService) Page(ctx context.Context) (*StatusPage, error) {
+	cfg := s.stateService.Config()
+	if cfg == nil || !cfg.StatusPage.Enabled {
+		return nil, ErrStatusPageDisabled
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.cached != nil && time.Now().Before(s.expiresAt) {
+		return s.cached, nil
+	}
+
+	page := &StatusPage{
+		Title:      cfg.StatusPage.Title,
+		LogoText:   cfg.StatusPage.LogoText,
+		Components: map[string]string{},
+		Networks:   s.publishedNetworks(),
+	}
+	if page.Title == "" {
+		page.Title = defaultStatusPageTitle
+	}
+	report := s.healthService.Check(ctx)
+	page.Status = report.Status
+	page.GeneratedAt = report.CheckedAt
+	for name, component := range report.Components {
+		page.Components[name] = component.Status
+	}
+
+	s.cached = page
+	s.expiresAt = time.Now().Add(statusPageCacheTTL)
+	return page, nil
+}
+
+// Invalidate drops the cached page so the next request rebuilds it
+func (s *StatusPageService) Invalidate() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.cached = nil
+}
+
+// SetNetworkPublished lists or unlists a network on the status page
+func (s *StatusPageService) SetNetworkPublished(networkID string, published bool) error {
+	network, err := s.networkService.getNetwork(networkID)
+	if err != nil {
+		return err
+	}
+
+	network.StatusPagePublished = published
+	network.UpdatedAt = time.Now()
+	if err := s.networkService.getDB().UpdateNetwork(network); err != nil {
+		logger.Error("service: failed to update status page publication", zap.String("network_id", networkID), zap.Error(err))
+		return err
+	}
+	s.Invalidate()
+	return nil
+}
+
+// publishedNetworks counts online nodes per published network. A member is online when the
+// controller currently has it as a peer.
+func (s *StatusPageService) publishedNetworks() []StatusPageNetwork {
+	networks := make([]StatusPageNetwork, 0)
+	db := s.networkService.getDB()
+	if db == nil {
+		return networks
+	}
+	records, err := db.GetAllNetworks()
+	if err != nil {
+		logger.Warn("service: failed to list networks for the status page", zap.Error(err))
+		return networks
+	}
+
+	client := s.networkService.getZTClient()
+	online := map[string]bool{}
+	if client != nil {
+		peers, err := client.GetPeers()
+		if err != nil {
+			logger.Warn("service: failed to get peers for the status page", zap.Error(err))
+		}
+		for _, peer := range peers {
+			online[peer.Address] = true
+		}
+	}
+
+	for _, record := range records {
+		if !record.StatusPagePublished {
+			continue
+		}
+		entry := StatusPageNetwork{Name: record.Name}
+		if client != nil {
+			members, err := client.GetMembers(record.ID)
+			if err != nil {
+				logger.Warn("service: failed to get members for the status page", zap.String("network_id", record.ID), zap.Error(err))
+			}
+			for _, member := range members {
+				if !member.Config.Authorized {
+					continue
+				}
+				entry.TotalNodes++
+				if online[member.Address] {
+					entry.OnlineNodes++
+				}
+			}
+		}
+		networks = append(networks, entry)
+	}
+
+	sort.Slice(networks, func(i, j int) bool {
+		return networks[i].Name < networks[j].Name
+	})
+	return networks
+}
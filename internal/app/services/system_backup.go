@@ -0,0 +1,500 @@
+package services
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/GT-610/tairitsu/internal/app/crypto"
+	"github.com/GT-610/tairitsu/internal/app/logger"
+	"go.uber.org/zap"
+)
+
+const (
+	// SystemBackupFormat identifies system backup archives
+	SystemBackupFormat = "tairitsu-system-backup"
+	// SystemBackupVersion is the version of the archive layout
+	SystemBackupVersion = 1
+
+	// AuditActionSystemBackup is the audit action recorded for every backup written
+	AuditActionSystemBackup = "system.backup"
+	// AuditActionSystemRestore is the audit action recorded for every restore
+	AuditActionSystemRestore = "system.restore"
+
+	systemBackupPrefix     = "tairitsu-backup-"
+	systemBackupSuffix     = ".json.gz"
+	systemBackupTimeLayout = "20060102-150405"
+)
+
+// Triggers recorded on backup runs
+const (
+	BackupTriggerSchedule = "schedule"
+	BackupTriggerManual   = "manual"
+)
+
+// systemBackupNamePattern matches the file names written by the backup service, so names taken
+// from requests can never point outside the backup directory
+var systemBackupNamePattern = regexp.MustCompile(`^tairitsu-backup-\d{8}-\d{6}(-\d+)?\.json\.gz$`)
+
+var (
+	ErrBackupRunning       = errors.New("a backup or restore is already running")
+	ErrBackupNotFound      = errors.New("backup not found")
+	ErrBackupInvalid       = errors.New("backup archive is not valid")
+	ErrBackupKeyMismatch   = errors.New("backup was encrypted with a different security key")
+	ErrBackupKeyMissing    = errors.New("no security key is configured to encrypt backups")
+	ErrBackupNetworkFailed = errors.New("controller network could not be backed up")
+)
+
+// SystemBackupArchive is the content of a backup file. Network and member settings are kept in
+// the clear; the users, ownership and token tables are encrypted with a key derived from the
+// JWT secret, so a backup can only be restored by an instance that shares that secret.
+type SystemBackupArchive struct {
+	Format    string          `json:"format"`
+	Version   int             `json:"version"`
+	CreatedAt time.Time       `json:"createdAt"`
+	Trigger   string          `json:"trigger"`
+	Networks  []NetworkBackup `json:"networks"`
+	State     string          `json:"state"` // Encrypted AppStateSnapshot
+}
+
+// SystemBackupInfo describes a backup file
+type SystemBackupInfo struct {
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"createdAt"`
+	SizeBytes int64     `json:"sizeBytes"`
+}
+
+// SystemBackupRun describes one backup attempt
+type SystemBackupRun struct {
+	Trigger    string    `json:"trigger"`
+	StartedAt  time.Time `json:"startedAt"`
+	FinishedAt time.Time `json:"finishedAt"`
+	Name       string    `json:"name,omitempty"`
+	Networks   int       `json:"networks"`
+	Error      string    `json:"error,omitempty"`
+}
+
+// SystemBackupStatus is the backup job view
+type SystemBackupStatus struct {
+	Running       bool             `json:"running"`
+	Directory     string           `json:"directory"`
+	IntervalHours int              `json:"intervalHours"` // Zero when scheduled backups are disabled
+	Retention     int              `json:"retention"`
+	NextRunAt     *time.Time       `json:"nextRunAt,omitempty"`
+	LastRun       *SystemBackupRun `json:"lastRun,omitempty"`
+}
+
+// SystemRestoreNetworkFailure reports a network that could not be written back to the controller
+type SystemRestoreNetworkFailure struct {
+	NetworkID string `json:"networkId"`
+	Reason    string `json:"reason"`
+}
+
+// SystemRestoreMemberFailure reports a member that could not be re-added
+type SystemRestoreMemberFailure struct {
+	NetworkID string `json:"networkId"`
+	NetworkRestoreMemberFailure
+}
+
+// SystemRestoreReport summarizes a restore
+type SystemRestoreReport struct {
+	Name             string                        `json:"name"`
+	State            *AppStateImportReport         `json:"state"`
+	RestoredNetworks int                           `json:"restoredNetworks"`
+	RestoredMembers  int                           `json:"restoredMembers"`
+	FailedNetworks   []SystemRestoreNetworkFailure `json:"failedNetworks"`
+	FailedMembers    []SystemRestoreMemberFailure  `json:"failedMembers"`
+}
+
+// SystemBackupService writes controller-wide backups of every network, its members and the
+// Tairitsu-side state to timestamped files, keeping the most recent ones
+type SystemBackupService struct {
+	networkService *NetworkService
+	appState       *AppStateService
+	stateService   *StateService
+	audit          *AuditService
+	directory      string
+	interval       time.Duration
+	retention      int
+
+	mutex     sync.RWMutex
+	running   bool
+	nextRunAt time.Time
+	lastRun   *SystemBackupRun
+}
+
+// NewSystemBackupService creates the backup job. Scheduled backups run every interval; a zero
+// interval only allows backups on demand.
+func NewSystemBackupService(networkService *NetworkService, appState *AppStateService, stateService *StateService, audit *AuditService, directory string, interval time.Duration, retention int) *SystemBackupService {
+	return &SystemBackupService{
+		networkService: networkService,
+		appState:       appState,
+		stateService:   stateService,
+		audit:          audit,
+		directory:      directory,
+		interval:       interval,
+		retention:      retention,
+	}
+}
+
+// Status returns the backup job view
+func (s *SystemBackupService) Status() SystemBackupStatus {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	status := SystemBackupStatus{
+		Running:       s.running,
+		Directory:     s.directory,
+		IntervalHours: int(s.interval / time.Hour),
+		Retention:     s.retention,
+	}
+	if !s.nextRunAt.IsZero() {
+		next := s.nextRunAt
+		status.NextRunAt = &next
+	}
+	if s.lastRun != nil {
+		last := *s.lastRun
+		status.LastRun = &last
+	}
+	return status
+}
+
+// Backup writes a new backup file and prunes the oldest ones beyond the retention
+func (s *SystemBackupService) Backup(trigger string, actorID string) (*SystemBackupInfo, error) {
+	if err := s.reserve(); err != nil {
+		return nil, err
+	}
+
+	run := &SystemBackupRun{Trigger: trigger, StartedAt: time.Now()}
+	info, err := s.backup(run)
+	run.FinishedAt = time.Now()
+	if err != nil {
+		run.Error = err.Error()
+	}
+	s.mutex.Lock()
+	s.running = false
+	s.lastRun = run
+	s.mutex.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Info("system backup written", zap.String("trigger", trigger), zap.String("name", info.Name), zap.Int64("size", info.SizeBytes))
+	s.recordAudit(actorID, AuditActionSystemBackup, info.Name, fmt.Sprintf("trigger=%s networks=%d size=%d", trigger, run.Networks, info.SizeBytes))
+	if err := s.prune(); err != nil {
+		logger.Warn("failed to prune old system backups", zap.Error(err))
+	}
+	return info, nil
+}
+
+func (s *SystemBackupService) backup(run *SystemBackupRun) (*SystemBackupInfo, error) {
+	secret, err := s.secret()
+	if err != nil {
+		return nil, err
+	}
+	snapshot, err := s.appState.Snapshot()
+	if err != nil {
+		return nil, err
+	}
+	networks, err := s.networkService.ExportControllerNetworks()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrBackupNetworkFailed, err)
+	}
+	run.Networks = len(networks)
+
+	payload, err := json.Marshal(snapshot)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode app state: %w", err)
+	}
+	state, err := crypto.Encrypt(string(payload), secret)
+	if err != nil {
+		return nil, err
+	}
+	archive := &SystemBackupArchive{
+		Format:    SystemBackupFormat,
+		Version:   SystemBackupVersion,
+		CreatedAt: snapshot.ExportedAt,
+		Trigger:   run.Trigger,
+		Networks:  networks,
+		State:     state,
+	}
+
+	if err := os.MkdirAll(s.directory, 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create backup directory: %w", err)
+	}
+	name, err := s.writeArchive(archive)
+	if err != nil {
+		return nil, err
+	}
+	run.Name = name
+	return s.info(name)
+}
+
+// writeArchive writes to a temporary file first, so a failed backup never leaves a truncated
+// archive behind under a valid name
+func (s *SystemBackupService) writeArchive(archive *SystemBackupArchive) (string, error) {
+	file, err := os.CreateTemp(s.directory, ".tairitsu-backup-*.tmp")
+	if err != nil {
+		return "", fmt.Errorf("failed to create backup file: %w", err)
+	}
+	tempPath := file.Name()
+	defer os.Remove(tempPath)
+
+	gz := gzip.NewWriter(file)
+	encodeErr := json.NewEncoder(gz).Encode(archive)
+	closeErr := errors.Join(gz.Close(), file.Close())
+	if err := errors.Join(encodeErr, closeErr); err != nil {
+		return "", fmt.Errorf("failed to write backup file: %w", err)
+	}
+
+	base := systemBackupPrefix + archive.CreatedAt.UTC().Format(systemBackupTimeLayout)
+	name := base + systemBackupSuffix
+	for i := 2; ; i++ {
+		if _, err := os.Stat(filepath.Join(s.directory, name)); errors.Is(err, os.ErrNotExist) {
+			break
+		}
+		name = fmt.Sprintf("%s-%d%s", base, i, systemBackupSuffix)
+	}
+	if err := os.Rename(tempPath, filepath.Join(s.directory, name)); err != nil {
+		return "", fmt.Errorf("failed to save backup file: %w", err)
+	}
+	return name, nil
+}
+
+// List returns the available backups, newest first
+func (s *SystemBackupService) List() ([]SystemBackupInfo, error) {
+	entries, err := os.ReadDir(s.directory)
+	if errors.Is(err, os.ErrNotExist) {
+		return []SystemBackupInfo{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read backup directory: %w", err)
+	}
+
+	backups := []SystemBackupInfo{}
+	for _, entry := range entries {
+		if entry.IsDir() || !systemBackupNamePattern.MatchString(entry.Name()) {
+			continue
+		}
+		info, err := s.info(entry.Name())
+		if err != nil {
+			return nil, err
+		}
+		backups = append(backups, *info)
+	}
+	sort.Slice(backups, func(i, j int) bool {
+		if !backups[i].CreatedAt.Equal(backups[j].CreatedAt) {
+			return backups[i].CreatedAt.After(backups[j].CreatedAt)
+		}
+		return backupSequence(backups[i].Name) > backupSequence(backups[j].Name)
+	})
+	return backups, nil
+}
+
+// backupSequence orders backups written within the same second; the first one has no suffix
+func backupSequence(name string) int {
+	stamp := strings.TrimSuffix(strings.TrimPrefix(name, systemBackupPrefix), systemBackupSuffix)
+	if len(stamp) <= len(systemBackupTimeLayout) {
+		return 1
+	}
+	sequence, err := strconv.Atoi(stamp[len(systemBackupTimeLayout)+1:])
+	if err != nil {
+		return 1
+	}
+	return sequence
+}
+
+func (s *SystemBackupService) info(name string) (*SystemBackupInfo, error) {
+	stat, err := os.Stat(filepath.Join(s.directory, name))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read backup file: %w", err)
+	}
+	stamp := strings.TrimPrefix(name, systemBackupPrefix)[:len(systemBackupTimeLayout)]
+	createdAt, err := time.Parse(systemBackupTimeLayout, stamp)
+	if err != nil {
+		createdAt = stat.ModTime().UTC()
+	}
+	return &SystemBackupInfo{Name: name, CreatedAt: createdAt, SizeBytes: stat.Size()}, nil
+}
+
+// prune removes the oldest backups beyond the retention
+func (s *SystemBackupService) prune() error {
+	backups, err := s.List()
+	if err != nil {
+		return err
+	}
+	for _, backup := range backups[min(s.retention, len(backups)):] {
+		if err := os.Remove(filepath.Join(s.directory, backup.Name)); err != nil {
+			return err
+		}
+		logger.Info("pruned old system backup", zap.String("name", backup.Name))
+	}
+	return nil
+}
+
+// Restore writes a backup back: the Tairitsu-side state is merged into the database, keeping
+// existing rows on conflicts, and every network is written back to the controller under its
+// original ID. Networks and members the controller rejects are reported instead of failing
+// the restore.
+func (s *SystemBackupService) Restore(name string, actorID string) (*SystemRestoreReport, error) {
+	if !systemBackupNamePattern.MatchString(name) {
+		return nil, ErrBackupNotFound
+	}
+	if err := s.reserve(); err != nil {
+		return nil, err
+	}
+	defer func() {
+		s.mutex.Lock()
+		s.running = false
+		s.mutex.Unlock()
+	}()
+
+	archive, err := s.readArchive(name)
+	if err != nil {
+		return nil, err
+	}
+	secret, err := s.secret()
+	if err != nil {
+		return nil, err
+	}
+	payload, err := crypto.Decrypt(archive.State, secret)
+	if err != nil {
+		if errors.Is(err, crypto.ErrDecryptFailed) {
+			return nil, ErrBackupKeyMismatch
+		}
+		return nil, fmt.Errorf("%w: %v", ErrBackupInvalid, err)
+	}
+	var snapshot AppStateSnapshot
+	if err := json.Unmarshal([]byte(payload), &snapshot); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrBackupInvalid, err)
+	}
+
+	report := &SystemRestoreReport{
+		Name:           name,
+		FailedNetworks: []SystemRestoreNetworkFailure{},
+		FailedMembers:  []SystemRestoreMemberFailure{},
+	}
+	if report.State, err = s.appState.Restore(&snapshot, AppStateOnConflictSkip); err != nil {
+		return nil, err
+	}
+	for i := range archive.Networks {
+		network := &archive.Networks[i]
+		restored, failures, err := s.networkService.RestoreControllerNetwork(network)
+		if err != nil {
+			report.FailedNetworks = append(report.FailedNetworks, SystemRestoreNetworkFailure{NetworkID: network.SourceID, Reason: err.Error()})
+			continue
+		}
+		report.RestoredNetworks++
+		report.RestoredMembers += restored
+		for _, failure := range failures {
+			report.FailedMembers = append(report.FailedMembers, SystemRestoreMemberFailure{NetworkID: network.SourceID, NetworkRestoreMemberFailure: failure})
+		}
+	}
+
+	logger.Info("system backup restored",
+		zap.String("name", name),
+		zap.Int("restored_networks", report.RestoredNetworks),
+		zap.Int("failed_networks", len(report.FailedNetworks)),
+		zap.Int("failed_members", len(report.FailedMembers)))
+	s.recordAudit(actorID, AuditActionSystemRestore, name, fmt.Sprintf("networks=%d failed_networks=%d failed_members=%d",
+		report.RestoredNetworks, len(report.FailedNetworks), len(report.FailedMembers)))
+	return report, nil
+}
+
+func (s *SystemBackupService) readArchive(name string) (*SystemBackupArchive, error) {
+	file, err := os.Open(filepath.Join(s.directory, name))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrBackupNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open backup file: %w", err)
+	}
+	defer file.Close()
+
+	gz, err := gzip.NewReader(file)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrBackupInvalid, err)
+	}
+	defer gz.Close()
+	var archive SystemBackupArchive
+	if err := json.NewDecoder(gz).Decode(&archive); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrBackupInvalid, err)
+	}
+	if archive.Format != SystemBackupFormat || archive.Version < 1 || archive.Version > SystemBackupVersion {
+		return nil, ErrBackupInvalid
+	}
+	return &archive, nil
+}
+
+// reserve marks a backup or restore as running, so only one can run at a time
+func (s *SystemBackupService) reserve() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.running {
+		return ErrBackupRunning
+	}
+	s.running = true
+	return nil
+}
+
+func (s *SystemBackupService) secret() (string, error) {
+	cfg := s.stateService.Config()
+	if cfg == nil || cfg.Security.JWTSecret == "" {
+		return "", ErrBackupKeyMissing
+	}
+	return cfg.Security.JWTSecret, nil
+}
+
+func (s *SystemBackupService) recordAudit(actorID, action, target, details string) {
+	if s.audit == nil {
+		return
+	}
+	if actorID == "" {
+		actorID = auditSystemActor
+	}
+	if _, err := s.audit.Record(AuditEntryInput{ActorID: actorID, Action: action, Target: target, Details: details}); err != nil {
+		logger.Warn("failed to record system backup audit entry", zap.String("action", action), zap.Error(err))
+	}
+}
+
+// Start runs scheduled backups until ctx is cancelled; nothing is scheduled without an interval
+func (s *SystemBackupService) Start(ctx context.Context) <-chan struct{} {
+	done := make(chan struct{})
+	if s.interval <= 0 {
+		close(done)
+		return done
+	}
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(s.interval)
+		defer ticker.Stop()
+		s.setNextRun(time.Now().Add(s.interval))
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				s.setNextRun(time.Now().Add(s.interval))
+				if _, err := s.Backup(BackupTriggerSchedule, ""); err != nil {
+					logger.Warn("scheduled system backup failed", zap.Error(err))
+				}
+			}
+		}
+	}()
+	return done
+}
+
+func (s *SystemBackupService) setNextRun(at time.Time) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.nextRunAt = at
+}
@@ -0,0 +1,164 @@
+package services
+
+import (
+	stdnet "net"
+	"os"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/shirou/gopsutil/v3/disk"
+	psnet "github.com/shirou/gopsutil/v3/net"
+)
+
+// DiskUsage is the usage of the partition holding the data directory
+type DiskUsage struct {
+	Path        string  `json:"path"`
+	Total       uint64  `json:"total"` // Bytes
+	Used        uint64  `json:"used"`
+	Free        uint64  `json:"free"`
+	UsedPercent float64 `json:"usedPercent"`
+}
+
+// DiskIORates is the disk throughput across physical disks since the previous reading
+type DiskIORates struct {
+	ReadBytesPerSec  float64 `json:"readBytesPerSec"`
+	WriteBytesPerSec float64 `json:"writeBytesPerSec"`
+}
+
+// NetworkIORates is the host network throughput, loopback excluded, since the previous reading
+type NetworkIORates struct {
+	RecvBytesPerSec float64 `json:"recvBytesPerSec"`
+	SentBytesPerSec float64 `json:"sentBytesPerSec"`
+}
+
+// RuntimeStats describes the Tairitsu process itself
+type RuntimeStats struct {
+	Goroutines int    `json:"goroutines"`
+	HeapInUse  uint64 `json:"heapInUse"` // Bytes
+}
+
+// ioCounters is a cumulative byte counter reading that rates are computed from
+type ioCounters struct {
+	at            time.Time
+	diskRead      uint64
+	diskWrite     uint64
+	diskAvailable bool
+	netRecv       uint64
+	netSent       uint64
+	netAvailable  bool
+}
+
+// getDiskUsage reads the partition holding the data directory, or the working directory
+// before the data directory exists
+func (s *SystemService) getDiskUsage() (*DiskUsage, error) {
+	path := s.dataDir
+	if _, err := os.Stat(path); err != nil {
+		path = "."
+	}
+	usage, err := disk.Usage(path)
+	if err != nil {
+		return nil, err
+	}
+	return &DiskUsage{Path: path, Total: usage.Total, Used: usage.Used, Free: usage.Free, UsedPercent: usage.UsedPercent}, nil
+}
+
+// readIOCounters reads the cumulative disk and network byte counters; either may be unavailable
+func readIOCounters() (ioCounters, []error) {
+	counters := ioCounters{at: time.Now()}
+	var errs []error
+
+	if disks, err := disk.IOCounters(); err != nil {
+		errs = append(errs, err)
+	} else {
+		for name, stat := range disks {
+			if !isWholeDisk(name, disks) {
+				continue
+			}
+			counters.diskRead += stat.ReadBytes
+			counters.diskWrite += stat.WriteBytes
+		}
+		counters.diskAvailable = true
+	}
+
+	if nics, err := psnet.IOCounters(true); err != nil {
+		errs = append(errs, err)
+	} else {
+		loopback := loopbackInterfaces()
+		for _, nic := range nics {
+			if loopback[nic.Name] {
+				continue
+			}
+			counters.netRecv += nic.BytesRecv
+			counters.netSent += nic.BytesSent
+		}
+		counters.netAvailable = true
+	}
+	return counters, errs
+}
+
+// isWholeDisk leaves out partitions and virtual block devices, whose I/O is already counted
+// by the disk beneath them
+func isWholeDisk(name string, all map[string]disk.IOCountersStat) bool {
+	for _, prefix := range []string{"loop", "ram", "zram", "dm-", "md"} {
+		if strings.HasPrefix(name, prefix) {
+			return false
+		}
+	}
+	for other := range all {
+		if other != name && strings.HasPrefix(name, other) && isPartitionSuffix(name[len(other):]) {
+			return false
+		}
+	}
+	return true
+}
+
+// isPartitionSuffix matches what follows a disk name in its partitions: sda1, nvme0n1p1
+func isPartitionSuffix(suffix string) bool {
+	suffix = strings.TrimPrefix(suffix, "p")
+	return suffix != "" && strings.Trim(suffix, "0123456789") == ""
+}
+
+func loopbackInterfaces() map[string]bool {
+	loopback := make(map[string]bool)
+	interfaces, err := stdnet.Interfaces()
+	if err != nil {
+		return loopback
+	}
+	for _, iface := range interfaces {
+		if iface.Flags&stdnet.FlagLoopback != 0 {
+			loopback[iface.Name] = true
+		}
+	}
+	return loopback
+}
+
+// ioRates turns two counter readings into per-second rates; a rate is nil without two
+// readings of its counters, or when a counter went backwards
+func ioRates(previous, current ioCounters) (*DiskIORates, *NetworkIORates) {
+	elapsed := current.at.Sub(previous.at).Seconds()
+	if elapsed <= 0 {
+		return nil, nil
+	}
+	var diskRates *DiskIORates
+	if previous.diskAvailable && current.diskAvailable && current.diskRead >= previous.diskRead && current.diskWrite >= previous.diskWrite {
+		diskRates = &DiskIORates{
+			ReadBytesPerSec:  float64(current.diskRead-previous.diskRead) / elapsed,
+			WriteBytesPerSec: float64(current.diskWrite-previous.diskWrite) / elapsed,
+		}
+	}
+	var netRates *NetworkIORates
+	if previous.netAvailable && current.netAvailable && current.netRecv >= previous.netRecv && current.netSent >= previous.netSent {
+		netRates = &NetworkIORates{
+			RecvBytesPerSec: float64(current.netRecv-previous.netRecv) / elapsed,
+			SentBytesPerSec: float64(current.netSent-previous.netSent) / elapsed,
+		}
+	}
+	return diskRates, netRates
+}
+
+func getRuntimeStats() RuntimeStats {
+	var memStats runtime.MemStats
+	runtime.ReadMemStats(&memStats)
+	return RuntimeStats{Goroutines: runtime.NumGoroutine(), HeapInUse: memStats.HeapInuse}
+}
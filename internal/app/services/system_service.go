@@ -1,10 +1,13 @@
 package services
 
 import (
+	"context"
+	"errors"
 	"sync"
 	"time"
 
 	"github.com/GT-610/tairitsu/internal/app/logger"
+	"github.com/GT-610/tairitsu/internal/app/paths"
 	"github.com/shirou/gopsutil/v3/cpu"
 	"github.com/shirou/gopsutil/v3/host"
 	"github.com/shirou/gopsutil/v3/mem"
@@ -20,20 +23,127 @@ type SystemStats struct {
 	Platform        string  `json:"platform"`        // Platform (linux, windows, darwin)
 	PlatformVersion string  `json:"platformVersion"` // Platform version
 	KernelVersion   string  `json:"kernelVersion"`   // Kernel version
+
+	// The metrics below are left out when they cannot be read, and the rates until a
+	// second reading exists
+	DiskUsage *DiskUsage      `json:"diskUsage,omitempty"` // Partition holding the data directory
+	DiskIO    *DiskIORates    `json:"diskIO,omitempty"`
+	NetworkIO *NetworkIORates `json:"networkIO,omitempty"`
+	Runtime   RuntimeStats    `json:"runtime"`
+}
+
+// SystemStatsSample is one CPU and memory reading of the stats history
+type SystemStatsSample struct {
+	Timestamp   int64   `json:"timestamp"` // Unix timestamp in milliseconds
+	CPUUsage    float64 `json:"cpuUsage"`
+	MemoryUsage float64 `json:"memoryUsage"`
+}
+
+// SystemStatsHistory is the sampled history of a time window, averaged down to at most the requested points
+type SystemStatsHistory struct {
+	WindowSeconds   int64               `json:"windowSeconds"`
+	IntervalSeconds float64             `json:"intervalSeconds"` // Sampling interval of the underlying readings
+	Points          []SystemStatsSample `json:"points"`
 }
 
+const (
+	defaultSystemStatsSampleInterval = 30 * time.Second
+	// systemStatsHistorySize holds 24 hours at the default interval
+	systemStatsHistorySize = 2880
+
+	DefaultStatsHistoryWindow = time.Hour
+	DefaultStatsHistoryPoints = 120
+	MaxStatsHistoryPoints     = 1000
+)
+
+// ErrInvalidStatsHistoryQuery is returned for a history window or point count out of range
+var ErrInvalidStatsHistoryQuery = errors.New("invalid stats history query")
+
 // SystemService handles system-related operations and statistics
 type SystemService struct {
 	statsCache  *SystemStats
 	cacheMutex  sync.RWMutex
 	cacheExpiry time.Duration
+
+	// The sampler fills history, a ring buffer of the latest systemStatsHistorySize readings
+	sampleInterval time.Duration
+	sampling       bool
+	history        []SystemStatsSample
+	historyNext    int
+	hostInfo       *host.InfoStat
+
+	dataDir   string
+	lastIO    ioCounters
+	hasLastIO bool
 }
 
 // NewSystemService creates a new system service instance
 func NewSystemService() *SystemService {
 	return &SystemService{
-		cacheExpiry: 5 * time.Second, // Cache expiry time: 5 seconds
+		cacheExpiry:    5 * time.Second, // Cache expiry time: 5 seconds
+		sampleInterval: defaultSystemStatsSampleInterval,
+		history:        make([]SystemStatsSample, 0, systemStatsHistorySize),
+		dataDir:        paths.DataDir(), // Holds the SQLite database, backups and the configuration
+	}
+}
+
+// SetSampleInterval changes how often the background sampler reads CPU and memory usage
+func (s *SystemService) SetSampleInterval(interval time.Duration) {
+	if interval <= 0 {
+		interval = defaultSystemStatsSampleInterval
+	}
+	s.cacheMutex.Lock()
+	defer s.cacheMutex.Unlock()
+	s.sampleInterval = interval
+}
+
+// Start samples system stats into the history until ctx is done. While it runs,
+// GetSystemStats serves the latest sample instead of reading the system itself.
+func (s *SystemService) Start(ctx context.Context) <-chan struct{} {
+	done := make(chan struct{})
+	s.cacheMutex.Lock()
+	s.sampling = true
+	interval := s.sampleInterval
+	s.cacheMutex.Unlock()
+
+	go func() {
+		defer close(done)
+		defer func() {
+			s.cacheMutex.Lock()
+			s.sampling = false
+			s.cacheMutex.Unlock()
+		}()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			s.sample()
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+	return done
+}
+
+func (s *SystemService) sample() {
+	stats, err := s.collectSystemStats()
+	if err != nil {
+		logger.Warn("Failed to sample system stats", zap.Error(err))
+		return
+	}
+
+	s.cacheMutex.Lock()
+	defer s.cacheMutex.Unlock()
+	s.statsCache = stats
+	sample := SystemStatsSample{Timestamp: stats.Timestamp, CPUUsage: stats.CPUUsage, MemoryUsage: stats.MemoryUsage}
+	if len(s.history) < systemStatsHistorySize {
+		s.history = append(s.history, sample)
+	} else {
+		s.history[s.historyNext] = sample
 	}
+	s.historyNext = (s.historyNext + 1) % systemStatsHistorySize
 }
 
 // GetSystemStats retrieves the current system statistics
@@ -61,6 +171,72 @@ func (s *SystemService) GetSystemStats() (*SystemStats, error) {
 	return stats, nil
 }
 
+// GetSystemStatsHistory returns the samples of the last window, averaged into at most points
+// equal time buckets; buckets without samples are left out
+func (s *SystemService) GetSystemStatsHistory(window time.Duration, points int) (*SystemStatsHistory, error) {
+	if window <= 0 || points <= 0 || points > MaxStatsHistoryPoints {
+		return nil, ErrInvalidStatsHistoryQuery
+	}
+
+	s.cacheMutex.RLock()
+	interval := s.sampleInterval
+	if window > interval*systemStatsHistorySize {
+		s.cacheMutex.RUnlock()
+		return nil, ErrInvalidStatsHistoryQuery
+	}
+	samples := make([]SystemStatsSample, 0, len(s.history))
+	since := time.Now().Add(-window).UnixMilli()
+	for i := range s.history {
+		// The oldest sample sits at historyNext, which is past the end until the ring is full
+		sample := s.history[(s.historyNext+i)%len(s.history)]
+		if sample.Timestamp >= since {
+			samples = append(samples, sample)
+		}
+	}
+	s.cacheMutex.RUnlock()
+
+	return &SystemStatsHistory{
+		WindowSeconds:   int64(window / time.Second),
+		IntervalSeconds: interval.Seconds(),
+		Points:          downsampleStats(samples, since, window.Milliseconds(), points),
+	}, nil
+}
+
+// downsampleStats averages time-ordered samples into points buckets covering the window
+func downsampleStats(samples []SystemStatsSample, since, windowMillis int64, points int) []SystemStatsSample {
+	if len(samples) <= points {
+		return samples
+	}
+	result := make([]SystemStatsSample, 0, points)
+	bucketMillis := max(windowMillis/int64(points), 1)
+	var sum SystemStatsSample
+	count, bucket := 0, int64(-1)
+	flush := func() {
+		if count == 0 {
+			return
+		}
+		result = append(result, SystemStatsSample{
+			Timestamp:   sum.Timestamp / int64(count),
+			CPUUsage:    sum.CPUUsage / float64(count),
+			MemoryUsage: sum.MemoryUsage / float64(count),
+		})
+		sum, count = SystemStatsSample{}, 0
+	}
+	for _, sample := range samples {
+		index := min((sample.Timestamp-since)/bucketMillis, int64(points-1))
+		if index != bucket {
+			flush()
+			bucket = index
+		}
+		sum.Timestamp += sample.Timestamp
+		sum.CPUUsage += sample.CPUUsage
+		sum.MemoryUsage += sample.MemoryUsage
+		count++
+	}
+	flush()
+	return result
+}
+
 // isCacheValid checks if the cache is still valid
 func (s *SystemService) isCacheValid() bool {
 	s.cacheMutex.RLock()
@@ -69,6 +245,10 @@ func (s *SystemService) isCacheValid() bool {
 	if s.statsCache == nil {
 		return false
 	}
+	// The sampler keeps the cache current
+	if s.sampling {
+		return true
+	}
 
 	// Check if cache has expired
 	cacheTime := time.UnixMilli(s.statsCache.Timestamp)
@@ -91,14 +271,22 @@ func (s *SystemService) collectSystemStats() (*SystemStats, error) {
 		return nil, err
 	}
 
-	// Get OS information
-	hostInfo, err := host.Info()
-	if err != nil {
-		logger.Error("Failed to get OS info", zap.Error(err))
-		return nil, err
+	// Get OS information, which does not change while running
+	s.cacheMutex.RLock()
+	hostInfo := s.hostInfo
+	s.cacheMutex.RUnlock()
+	if hostInfo == nil {
+		hostInfo, err = host.Info()
+		if err != nil {
+			logger.Error("Failed to get OS info", zap.Error(err))
+			return nil, err
+		}
+		s.cacheMutex.Lock()
+		s.hostInfo = hostInfo
+		s.cacheMutex.Unlock()
 	}
 
-	return &SystemStats{
+	stats := &SystemStats{
 		CPUUsage:        cpuUsage,
 		MemoryUsage:     memoryUsage,
 		Timestamp:       time.Now().UnixMilli(),
@@ -106,14 +294,34 @@ func (s *SystemService) collectSystemStats() (*SystemStats, error) {
 		Platform:        hostInfo.Platform,
 		PlatformVersion: hostInfo.PlatformVersion,
 		KernelVersion:   hostInfo.KernelVersion,
-	}, nil
+		Runtime:         getRuntimeStats(),
+	}
+
+	// Disk and network metrics are optional; a failure only leaves its fields out
+	if diskUsage, err := s.getDiskUsage(); err != nil {
+		logger.Warn("Failed to get disk usage", zap.String("path", s.dataDir), zap.Error(err))
+	} else {
+		stats.DiskUsage = diskUsage
+	}
+	counters, errs := readIOCounters()
+	for _, err := range errs {
+		logger.Warn("Failed to get I/O counters", zap.Error(err))
+	}
+	s.cacheMutex.Lock()
+	if s.hasLastIO {
+		stats.DiskIO, stats.NetworkIO = ioRates(s.lastIO, counters)
+	}
+	s.lastIO, s.hasLastIO = counters, true
+	s.cacheMutex.Unlock()
+
+	return stats, nil
 }
 
 // getCPUUsage retrieves the current CPU usage percentage
 func (s *SystemService) getCPUUsage() (float64, error) {
-	// Get CPU usage over 1 second interval
-	// Using 1-second interval for more accurate reading
-	percentages, err := cpu.Percent(1*time.Second, false)
+	// Usage since the previous reading, so collecting never blocks; the sampler
+	// makes that the average over its interval
+	percentages, err := cpu.Percent(0, false)
 	if err != nil {
 		return 0, err
 	}
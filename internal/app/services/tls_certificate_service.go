@@ -0,0 +1,110 @@
+package services
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/GT-610/tairitsu/internal/app/logger"
+	"go.uber.org/zap"
+)
+
+// ErrTLSCertificateNotConfigured is returned when reloading without certificate files, including
+// when Let's Encrypt manages the certificate
+var ErrTLSCertificateNotConfigured = errors.New("no TLS certificate files are configured")
+
+// TLSCertificateInfo describes the certificate being served
+type TLSCertificateInfo struct {
+	Subject   string    `json:"subject"`
+	DNSNames  []string  `json:"dnsNames"`
+	NotBefore time.Time `json:"notBefore"`
+	NotAfter  time.Time `json:"notAfter"`
+}
+
+// TLSCertificateService serves the certificate loaded from the configured files and swaps in a
+// renewed one on reload, without restarting the listener
+type TLSCertificateService struct {
+	mutex       sync.RWMutex
+	certFile    string
+	keyFile     string
+	certificate *tls.Certificate
+}
+
+// NewTLSCertificateService creates a certificate service without a certificate
+func NewTLSCertificateService() *TLSCertificateService {
+	return &TLSCertificateService{}
+}
+
+// LoadFiles loads a certificate and key pair and serves it from now on; later reloads read the same files
+func (s *TLSCertificateService) LoadFiles(certFile, keyFile string) error {
+	certificate, err := loadTLSCertificate(certFile, keyFile)
+	if err != nil {
+		return err
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.certFile, s.keyFile = certFile, keyFile
+	s.certificate = certificate
+	return nil
+}
+
+// Reload reads the certificate files again. On failure the current certificate stays in use.
+func (s *TLSCertificateService) Reload() (*TLSCertificateInfo, error) {
+	s.mutex.RLock()
+	certFile, keyFile := s.certFile, s.keyFile
+	s.mutex.RUnlock()
+	if certFile == "" {
+		return nil, ErrTLSCertificateNotConfigured
+	}
+
+	certificate, err := loadTLSCertificate(certFile, keyFile)
+	if err != nil {
+		logger.Error("service: failed to reload TLS certificate; keeping the current one", zap.String("cert_file", certFile), zap.Error(err))
+		return nil, err
+	}
+
+	s.mutex.Lock()
+	s.certificate = certificate
+	s.mutex.Unlock()
+
+	info := tlsCertificateInfo(certificate)
+	logger.Info("service: TLS certificate reloaded", zap.String("subject", info.Subject), zap.Time("not_after", info.NotAfter))
+	return info, nil
+}
+
+// GetCertificate implements tls.Config.GetCertificate
+func (s *TLSCertificateService) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	if s.certificate == nil {
+		return nil, ErrTLSCertificateNotConfigured
+	}
+	return s.certificate, nil
+}
+
+func loadTLSCertificate(certFile, keyFile string) (*tls.Certificate, error) {
+	certificate, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load TLS certificate %s with key %s: %w", certFile, keyFile, err)
+	}
+	if certificate.Leaf == nil {
+		if certificate.Leaf, err = x509.ParseCertificate(certificate.Certificate[0]); err != nil {
+			return nil, fmt.Errorf("failed to parse TLS certificate %s: %w", certFile, err)
+		}
+	}
+	return &certificate, nil
+}
+
+func tlsCertificateInfo(certificate *tls.Certificate) *TLSCertificateInfo {
+	leaf := certificate.Leaf
+	return &TLSCertificateInfo{
+		Subject:   leaf.Subject.String(),
+		DNSNames:  leaf.DNSNames,
+		NotBefore: leaf.NotBefore,
+		NotAfter:  leaf.NotAfter,
+	}
+}
@@ -6,16 +6,18 @@ var (
 	ErrUserDBUnavailable          = errors.New("database is not configured; complete initial setup first")
 	ErrInvalidUsername            = errors.New("username is required")
 	ErrUsernameTooLong            = errors.New("username is too long")
-	ErrPasswordTooShort           = errors.New("password must be at least 6 characters")
-	ErrPasswordTooLong            = errors.New("password must be at most 32 characters")
+	ErrPasswordTooShort           = errors.New("password is too short")
+	ErrPasswordTooLong            = errors.New("password is too long")
 	ErrUsernameExists             = errors.New("username already exists")
+	ErrInvalidEmail               = errors.New("email address is invalid")
+	ErrEmailExists                = errors.New("email address is already in use")
 	ErrInvalidCredentials         = errors.New("username or password is incorrect")
 	ErrUserNotFound               = errors.New("user not found")
 	ErrOldPasswordIncorrect       = errors.New("current password is incorrect")
 	ErrAdminTransferSelf          = errors.New("cannot transfer administrator role to yourself")
 	ErrAdminResetSelf             = errors.New("cannot reset your own password; use the change password flow")
 	ErrAdminDeleteSelf            = errors.New("cannot delete yourself; transfer administrator role first or use another administrator account")
-	ErrAdminDeleteBlocked         = errors.New("cannot delete the current administrator account; transfer administrator role first")
+	ErrAdminDeleteBlocked         = errors.New("cannot delete the last administrator account; transfer administrator role first")
 	ErrTransferTargetAdmin        = errors.New("target user is already an administrator")
 	ErrAdminAccessDenied          = errors.New("current user is not an administrator")
 	ErrPublicRegistrationDisabled = errors.New("public registration is disabled; contact an administrator to create an account")
@@ -23,6 +25,17 @@ var (
 	ErrSessionAccessDenied        = errors.New("session access denied")
 	ErrSessionRevoked             = errors.New("session is no longer valid; sign in again")
 	ErrSessionExpired             = errors.New("session expired; sign in again")
+	ErrPasswordResetTokenInvalid  = errors.New("password reset token is invalid or has already been used")
+	ErrPasswordResetTokenExpired  = errors.New("password reset token has expired; request a new one")
+	ErrPasswordLoginDisabled      = errors.New("password sign-in is disabled; use single sign-on")
+	ErrOIDCDisabled               = errors.New("single sign-on is not enabled")
+	ErrOIDCInvalidState           = errors.New("single sign-on request expired or did not start here; sign in again")
+	ErrOIDCProviderError          = errors.New("single sign-on provider returned an error or could not be reached")
+	ErrOIDCInvalidToken           = errors.New("single sign-on provider returned an invalid identity token")
+	ErrOIDCEmailNotVerified       = errors.New("the provider has not verified this email address, so it cannot be linked to an existing account")
+	ErrCaptchaRequired            = errors.New("complete the captcha challenge to continue")
+	ErrCaptchaInvalid             = errors.New("captcha challenge was not solved or has expired; try again")
+	ErrCaptchaUnavailable         = errors.New("captcha provider could not be reached; try again later")
 )
 
 func IsUserDBUnavailable(err error) bool {
@@ -41,6 +54,14 @@ func IsUsernameExists(err error) bool {
 	return errors.Is(err, ErrUsernameExists)
 }
 
+func IsInvalidEmail(err error) bool {
+	return errors.Is(err, ErrInvalidEmail)
+}
+
+func IsEmailExists(err error) bool {
+	return errors.Is(err, ErrEmailExists)
+}
+
 func IsInvalidUsername(err error) bool {
 	return errors.Is(err, ErrInvalidUsername)
 }
@@ -104,3 +125,47 @@ func IsSessionRevoked(err error) bool {
 func IsSessionExpired(err error) bool {
 	return errors.Is(err, ErrSessionExpired)
 }
+
+func IsPasswordResetTokenInvalid(err error) bool {
+	return errors.Is(err, ErrPasswordResetTokenInvalid)
+}
+
+func IsPasswordResetTokenExpired(err error) bool {
+	return errors.Is(err, ErrPasswordResetTokenExpired)
+}
+
+func IsPasswordLoginDisabled(err error) bool {
+	return errors.Is(err, ErrPasswordLoginDisabled)
+}
+
+func IsOIDCDisabled(err error) bool {
+	return errors.Is(err, ErrOIDCDisabled)
+}
+
+func IsOIDCInvalidState(err error) bool {
+	return errors.Is(err, ErrOIDCInvalidState)
+}
+
+func IsOIDCProviderError(err error) bool {
+	return errors.Is(err, ErrOIDCProviderError)
+}
+
+func IsOIDCInvalidToken(err error) bool {
+	return errors.Is(err, ErrOIDCInvalidToken)
+}
+
+func IsOIDCEmailNotVerified(err error) bool {
+	return errors.Is(err, ErrOIDCEmailNotVerified)
+}
+
+func IsCaptchaRequired(err error) bool {
+	return errors.Is(err, ErrCaptchaRequired)
+}
+
+func IsCaptchaInvalid(err error) bool {
+	return errors.Is(err, ErrCaptchaInvalid)
+}
+
+func IsCaptchaUnavailable(err error) bool {
+	return errors.Is(err, ErrCaptchaUnavailable)
+}
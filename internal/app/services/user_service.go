@@ -3,10 +3,12 @@ package services
 import (
 	"crypto/rand"
 	"fmt"
+	"net/mail"
 	"strings"
 	"sync"
 	"time"
 
+	"github.com/GT-610/tairitsu/internal/app/config"
 	"github.com/GT-610/tairitsu/internal/app/database"
 	"github.com/GT-610/tairitsu/internal/app/logger"
 	"github.com/GT-610/tairitsu/internal/app/models"
@@ -18,8 +20,45 @@ import (
 const temporaryPasswordAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz23456789"
 
 type UserService struct {
-	db    database.DBInterface
-	mutex sync.RWMutex
+	db       database.DBInterface
+	policy   *PasswordPolicy
+	hashCost int
+	webhooks *WebhookDispatcher
+	mutex    sync.RWMutex
+}
+
+// SetPasswordHashCost sets the bcrypt cost of new password hashes. Stored hashes of another
+// cost are replaced when their user next logs in. A cost outside
+// config.MinPasswordHashCost to config.MaxPasswordHashCost uses bcrypt.DefaultCost.
+func (s *UserService) SetPasswordHashCost(cost int) {
+	if cost < config.MinPasswordHashCost || cost > config.MaxPasswordHashCost {
+		cost = bcrypt.DefaultCost
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.hashCost = cost
+}
+
+// passwordHashCost returns the bcrypt cost of new password hashes
+func (s *UserService) passwordHashCost() int {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	if s.hashCost == 0 {
+		return bcrypt.DefaultCost
+	}
+	return s.hashCost
+}
+
+// hashPassword hashes a password with the configured cost
+func (s *UserService) hashPassword(password string) ([]byte, error) {
+	return bcrypt.GenerateFromPassword([]byte(password), s.passwordHashCost())
+}
+
+// SetWebhookDispatcher sets where user events are sent
+func (s *UserService) SetWebhookDispatcher(dispatcher *WebhookDispatcher) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.webhooks = dispatcher
 }
 
 func (s *UserService) SetDB(db database.DBInterface) {
@@ -59,17 +98,48 @@ func normalizeUsername(username string) (string, error) {
 	return normalized, nil
 }
 
-func validatePassword(password string) error {
-	if len(password) < 6 {
-		return ErrPasswordTooShort
+// maxEmailLength is the longest address allowed by RFC 5321
+const maxEmailLength = 254
+
+// normalizeEmail trims and lowercases an address; an empty input yields an empty address
+func normalizeEmail(email string) (string, error) {
+	normalized := strings.ToLower(strings.TrimSpace(email))
+	if normalized == "" {
+		return "", nil
 	}
-	if len(password) > 32 {
-		return ErrPasswordTooLong
+	if len(normalized) > maxEmailLength {
+		return "", ErrInvalidEmail
+	}
+	address, err := mail.ParseAddress(normalized)
+	if err != nil || address.Address != normalized || address.Name != "" {
+		return "", ErrInvalidEmail
+	}
+	return normalized, nil
+}
+
+// ensureEmailAvailable fails with ErrEmailExists when another user already has the address
+func ensureEmailAvailable(db database.DBInterface, email string, userID string) error {
+	existing, err := db.GetUserByEmail(email)
+	if err != nil {
+		return fmt.Errorf("failed to check email: %w", err)
+	}
+	if existing != nil && existing.ID != userID {
+		return ErrEmailExists
 	}
 	return nil
 }
 
 func (s *UserService) Register(req *models.RegisterRequest, role ...string) (*models.User, error) {
+	userRole := "user"
+	if len(role) > 0 && role[0] != "" {
+		userRole = role[0]
+	}
+	return s.createUser(req, userRole, false)
+}
+
+// createUser validates and stores a new account. mustChangePassword marks passwords the user
+// did not choose, so they are asked to replace it before using the rest of the API.
+func (s *UserService) createUser(req *models.RegisterRequest, userRole string, mustChangePassword bool) (*models.User, error) {
 	db := s.getDB()
 	if db == nil {
 		logger.Error("service: registration failed; database is not initialized")
@@ -81,7 +151,7 @@ func (s *UserService) Register(req *models.RegisterRequest, role ...string) (*mo
 		return nil, err
 	}
 
-	if err := validatePassword(req.Password); err != nil {
+	if err := ValidatePassword(s.PasswordPolicy(), username, req.Password); err != nil {
 		return nil, err
 	}
 
@@ -97,24 +167,34 @@ func (s *UserService) Register(req *models.RegisterRequest, role ...string) (*mo
 		return nil, ErrUsernameExists
 	}
 
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+	email, err := normalizeEmail(req.Email)
 	if err != nil {
-		logger.Error("service: registration failed while hashing password", zap.String("username", req.Username), zap.Error(err))
 		return nil, err
 	}
+	if email != "" {
+		if err := ensureEmailAvailable(db, email, ""); err != nil {
+			logger.Error("service: registration failed; email unavailable", zap.String("username", username), zap.Error(err))
+			return nil, err
+		}
+	}
 
-	userRole := "user"
-	if len(role) > 0 && role[0] != "" {
-		userRole = role[0]
+	hashedPassword, err := s.hashPassword(req.Password)
+	if err != nil {
+		logger.Error("service: registration failed while hashing password", zap.String("username", req.Username), zap.Error(err))
+		return nil, err
 	}
 
 	user := &models.User{
-		ID:        uuid.New().String(),
-		Username:  username,
-		Password:  string(hashedPassword),
-		Role:      userRole,
-		CreatedAt: time.Now(),
-		UpdatedAt: time.Now(),
+		ID:                 uuid.New().String(),
+		Username:           username,
+		Password:           string(hashedPassword),
+		Role:               userRole,
+		MustChangePassword: mustChangePassword,
+		CreatedAt:          time.Now(),
+		UpdatedAt:          time.Now(),
+	}
+	if email != "" {
+		user.Email = &email
 	}
 
 	if err := db.CreateUser(user); err != nil {
@@ -123,11 +203,17 @@ func (s *UserService) Register(req *models.RegisterRequest, role ...string) (*mo
 	}
 
 	logger.Info("service: user registered successfully", zap.String("user_id", user.ID), zap.String("username", user.Username), zap.String("role", userRole))
+	s.mutex.RLock()
+	dispatcher := s.webhooks
+	s.mutex.RUnlock()
+	dispatcher.Dispatch(WebhookEventUserCreated, userCreatedWebhookData(user))
 
 	return user, nil
 }
 
-func (s *UserService) CreateUserByAdmin(currentAdminID, username string) (*models.User, string, error) {
+// CreateUserByAdmin creates a normal user with the supplied password, or a generated one when
+// it is empty, and returns that temporary password. The user must change it after signing in.
+func (s *UserService) CreateUserByAdmin(currentAdminID string, req *models.CreateUserRequest) (*models.User, string, error) {
 	currentAdmin, err := s.GetUserByID(currentAdminID)
 	if err != nil {
 		return nil, "", err
@@ -136,30 +222,45 @@ func (s *UserService) CreateUserByAdmin(currentAdminID, username string) (*model
 		return nil, "", ErrAdminAccessDenied
 	}
 
-	temporaryPassword, err := generateTemporaryPassword(16)
+	user, temporaryPassword, err := s.CreateUserWithTemporaryPassword(req, "user")
 	if err != nil {
-		logger.Error("service: failed to generate temporary password", zap.String("admin_user_id", currentAdminID), zap.Error(err))
 		return nil, "", err
 	}
 
-	normalizedUsername, err := normalizeUsername(username)
+	logger.Info("service: administrator created user successfully",
+		zap.String("admin_user_id", currentAdminID),
+		zap.String("user_id", user.ID),
+		zap.String("username", user.Username))
+
+	return user, temporaryPassword, nil
+}
+
+// CreateUserWithTemporaryPassword creates a user with role and the supplied password, or a
+// generated one when it is empty, which the user must change after signing in. It does not
+// check who is asking; operators reach it from the command line.
+func (s *UserService) CreateUserWithTemporaryPassword(req *models.CreateUserRequest, role string) (*models.User, string, error) {
+	normalizedUsername, err := normalizeUsername(req.Username)
 	if err != nil {
 		return nil, "", err
 	}
 
-	user, err := s.Register(&models.RegisterRequest{
+	temporaryPassword := req.Password
+	if temporaryPassword == "" {
+		temporaryPassword, err = s.newTemporaryPassword(normalizedUsername)
+		if err != nil {
+			logger.Error("service: failed to generate temporary password", zap.String("username", normalizedUsername), zap.Error(err))
+			return nil, "", err
+		}
+	}
+
+	user, err := s.createUser(&models.RegisterRequest{
 		Username: normalizedUsername,
 		Password: temporaryPassword,
-	}, "user")
+		Email:    req.Email,
+	}, role, true)
 	if err != nil {
 		return nil, "", err
 	}
-
-	logger.Info("service: administrator created user successfully",
-		zap.String("admin_user_id", currentAdminID),
-		zap.String("user_id", user.ID),
-		zap.String("username", user.Username))
-
 	return user, temporaryPassword, nil
 }
 
@@ -187,12 +288,39 @@ func (s *UserService) Login(req *models.LoginRequest) (*models.User, error) {
 		logger.Error("service: login failed; password mismatch", zap.String("user_id", user.ID))
 		return nil, ErrInvalidCredentials
 	}
+	s.rehashPassword(db, user, req.Password)
 
 	logger.Info("service: user logged in successfully", zap.String("user_id", user.ID), zap.String("username", user.Username))
 
 	return user, nil
 }
 
+// rehashPassword replaces the stored hash of a user who just logged in with password when
+// it was made with another cost than the configured one. Failing to do so does not fail
+// the login, which simply tries again next time.
+func (s *UserService) rehashPassword(db database.DBInterface, user *models.User, password string) {
+	cost, err := bcrypt.Cost([]byte(user.Password))
+	if err != nil || cost == s.passwordHashCost() {
+		return
+	}
+	hashedPassword, err := s.hashPassword(password)
+	if err != nil {
+		logger.Warn("service: failed to rehash password", zap.String("user_id", user.ID), zap.Error(err))
+		return
+	}
+	// A concurrent login or password change may have replaced the hash already
+	replaced, err := db.ReplaceUserPasswordHash(user.ID, user.Password, string(hashedPassword))
+	if err != nil {
+		logger.Warn("service: failed to store rehashed password", zap.String("user_id", user.ID), zap.Error(err))
+		return
+	}
+	if replaced {
+		user.Password = string(hashedPassword)
+		logger.Info("service: rehashed password with the configured cost", zap.String("user_id", user.ID),
+			zap.Int("previous_cost", cost), zap.Int("cost", s.passwordHashCost()))
+	}
+}
+
 func (s *UserService) GetUserByID(id string) (*models.User, error) {
 	db := s.getDB()
 	if db == nil {
@@ -218,6 +346,59 @@ func (s *UserService) GetUserByID(id string) (*models.User, error) {
 	return user, nil
 }
 
+// UpdateProfile changes the authenticated user's own profile fields. An empty email removes
+// the address; one already used by another account fails with ErrEmailExists.
+func (s *UserService) UpdateProfile(userID string, req *models.UpdateProfileRequest) (*models.User, error) {
+	db := s.getDB()
+	if db == nil {
+		logger.Error("service: profile update failed; database is not initialized")
+		return nil, ErrUserDBUnavailable
+	}
+
+	email, err := normalizeEmail(req.Email)
+	if err != nil {
+		return nil, err
+	}
+
+	var updated *models.User
+	err = db.WithTransaction(func(tx database.DBInterface) error {
+		user, err := tx.GetUserByID(userID)
+		if err != nil {
+			return fmt.Errorf("failed to read user: %w", err)
+		}
+		if user == nil {
+			return ErrUserNotFound
+		}
+		if email != "" {
+			if err := ensureEmailAvailable(tx, email, userID); err != nil {
+				return err
+			}
+		}
+
+		if email == user.EmailAddress() {
+			updated = user
+			return nil
+		}
+		user.Email = nil
+		if email != "" {
+			user.Email = &email
+		}
+		user.UpdatedAt = time.Now()
+		if err := tx.UpdateUser(user); err != nil {
+			return fmt.Errorf("failed to save user: %w", err)
+		}
+		updated = user
+		return nil
+	})
+	if err != nil {
+		logger.Error("service: profile update failed", zap.String("user_id", userID), zap.Error(err))
+		return nil, err
+	}
+
+	logger.Info("service: profile updated", zap.String("user_id", userID))
+	return updated, nil
+}
+
 func (s *UserService) GetAllUsers() ([]*models.User, error) {
 	db := s.getDB()
 	if db == nil {
@@ -273,10 +454,6 @@ func (s *UserService) changePassword(userID, oldPassword, newPassword, currentSe
 
 	logger.Info("service: changing password", zap.String("user_id", userID))
 
-	if err := validatePassword(newPassword); err != nil {
-		return 0, err
-	}
-
 	user, err := db.GetUserByID(userID)
 	if err != nil {
 		logger.Error("service: password change failed while getting user", zap.String("user_id", userID), zap.Error(err))
@@ -293,7 +470,11 @@ func (s *UserService) changePassword(userID, oldPassword, newPassword, currentSe
 		return 0, ErrOldPasswordIncorrect
 	}
 
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(newPassword), bcrypt.DefaultCost)
+	if err := ValidatePassword(s.PasswordPolicy(), user.Username, newPassword); err != nil {
+		return 0, err
+	}
+
+	hashedPassword, err := s.hashPassword(newPassword)
 	if err != nil {
 		logger.Error("service: password change failed while hashing password", zap.String("user_id", userID), zap.Error(err))
 		return 0, err
@@ -303,6 +484,7 @@ func (s *UserService) changePassword(userID, oldPassword, newPassword, currentSe
 	now := time.Now()
 	if err := db.WithTransaction(func(tx database.DBInterface) error {
 		user.Password = string(hashedPassword)
+		user.MustChangePassword = false
 		user.UpdatedAt = now
 
 		if err := tx.UpdateUser(user); err != nil {
@@ -394,16 +576,16 @@ func (s *UserService) TransferAdmin(currentAdminID, targetUserID string) (*model
 	return targetUser, nil
 }
 
-func generateTemporaryPassword(length int) (string, error) {
+func generateTemporaryPassword(length int, alphabet string) (string, error) {
 	password := make([]byte, length)
-	max := byte(len(temporaryPasswordAlphabet))
+	max := byte(len(alphabet))
 	random := make([]byte, length)
 	if _, err := rand.Read(random); err != nil {
 		return "", fmt.Errorf("failed to generate random password: %w", err)
 	}
 
 	for index, value := range random {
-		password[index] = temporaryPasswordAlphabet[int(value)%int(max)]
+		password[index] = alphabet[int(value)%int(max)]
 	}
 
 	return string(password), nil
@@ -428,18 +610,41 @@ func (s *UserService) ResetPasswordByAdmin(currentAdminID, targetUserID string)
 		return nil, "", 0, ErrAdminAccessDenied
 	}
 
+	targetUser, temporaryPassword, revokedSessions, err := s.ResetPasswordToTemporary(targetUserID)
+	if err != nil {
+		return nil, "", 0, err
+	}
+
+	logger.Info("service: administrator reset user password successfully",
+		zap.String("admin_user_id", currentAdminID),
+		zap.String("target_user_id", targetUserID),
+		zap.String("target_username", targetUser.Username),
+		zap.Int("revoked_sessions", revokedSessions))
+
+	return targetUser, temporaryPassword, revokedSessions, nil
+}
+
+// ResetPasswordToTemporary replaces a user's password with a generated one they must change,
+// revoking their sessions, and returns it with the number of revoked sessions. It does not
+// check who is asking; operators reach it from the command line.
+func (s *UserService) ResetPasswordToTemporary(targetUserID string) (*models.User, string, int, error) {
+	db := s.getDB()
+	if db == nil {
+		return nil, "", 0, ErrUserDBUnavailable
+	}
+
 	targetUser, err := s.GetUserByID(targetUserID)
 	if err != nil {
 		return nil, "", 0, err
 	}
 
-	temporaryPassword, err := generateTemporaryPassword(16)
+	temporaryPassword, err := s.newTemporaryPassword(targetUser.Username)
 	if err != nil {
 		logger.Error("service: failed to generate temporary password", zap.String("target_user_id", targetUserID), zap.Error(err))
 		return nil, "", 0, err
 	}
 
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(temporaryPassword), bcrypt.DefaultCost)
+	hashedPassword, err := s.hashPassword(temporaryPassword)
 	if err != nil {
 		logger.Error("service: password reset failed while hashing password", zap.String("target_user_id", targetUserID), zap.Error(err))
 		return nil, "", 0, err
@@ -449,6 +654,7 @@ func (s *UserService) ResetPasswordByAdmin(currentAdminID, targetUserID string)
 	now := time.Now()
 	if err := db.WithTransaction(func(tx database.DBInterface) error {
 		targetUser.Password = string(hashedPassword)
+		targetUser.MustChangePassword = true
 		targetUser.UpdatedAt = now
 		if err := tx.UpdateUser(targetUser); err != nil {
 			return fmt.Errorf("failed to update password: %w", err)
@@ -477,12 +683,6 @@ func (s *UserService) ResetPasswordByAdmin(currentAdminID, targetUserID string)
 		return nil, "", 0, err
 	}
 
-	logger.Info("service: administrator reset user password successfully",
-		zap.String("admin_user_id", currentAdminID),
-		zap.String("target_user_id", targetUserID),
-		zap.String("target_username", targetUser.Username),
-		zap.Int("revoked_sessions", revokedSessions))
-
 	return targetUser, temporaryPassword, revokedSessions, nil
 }
 
@@ -509,14 +709,22 @@ func (s *UserService) DeleteUserByAdmin(currentAdminID, targetUserID string) (*m
 	if err != nil {
 		return nil, 0, 0, err
 	}
-	if targetUser.Role == "admin" {
-		return nil, 0, 0, ErrAdminDeleteBlocked
-	}
 
 	now := time.Now()
 	transferredNetworks := 0
 	revokedSessions := 0
 	if err := db.WithTransaction(func(tx database.DBInterface) error {
+		// Counted inside the transaction so concurrent deletions or transfers cannot remove every administrator
+		if targetUser.Role == "admin" {
+			admins, err := tx.CountUsersByRole("admin")
+			if err != nil {
+				return fmt.Errorf("failed to count administrators: %w", err)
+			}
+			if admins <= 1 {
+				return ErrAdminDeleteBlocked
+			}
+		}
+
 		networks, err := tx.GetNetworksByOwnerID(targetUserID)
 		if err != nil {
 			return fmt.Errorf("failed to read user networks: %w", err)
@@ -562,6 +770,14 @@ func (s *UserService) DeleteUserByAdmin(currentAdminID, targetUserID string) (*m
 			}
 		}
 
+		if err := tx.DeleteDeviceClaimsByUser(targetUserID); err != nil {
+			return fmt.Errorf("failed to delete device claims for user: %w", err)
+		}
+
+		if err := tx.DeleteOrganizationMembershipsByUser(targetUserID); err != nil {
+			return fmt.Errorf("failed to delete organization memberships for user: %w", err)
+		}
+
 		if err := tx.DeleteUser(targetUserID); err != nil {
 			return fmt.Errorf("failed to delete user: %w", err)
 		}
@@ -0,0 +1,510 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/GT-610/tairitsu/internal/app/database"
+	"github.com/GT-610/tairitsu/internal/app/logger"
+	"github.com/GT-610/tairitsu/internal/app/models"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// Webhook event names
+const (
+	WebhookEventPing               = "ping"
+	WebhookEventNetworkCreated     = "network.created"
+	WebhookEventNetworkDeleted     = "network.deleted"
+	WebhookEventMemberAuthorized   = "member.authorized"
+	WebhookEventMemberDeauthorized = "member.deauthorized"
+	WebhookEventUserCreated        = "user.created"
+)
+
+const (
+	// WebhookSignatureHeader carries the HMAC-SHA256 signature of the delivery body
+	WebhookSignatureHeader = "X-Tairitsu-Signature"
+	WebhookEventHeader     = "X-Tairitsu-Event"
+	WebhookDeliveryHeader  = "X-Tairitsu-Delivery"
+
+	webhookQueueSize              = 256
+	webhookMaxAttempts            = 5
+	webhookDeliveriesKept         = 200
+	webhookErrorBodyLimit         = 512
+	maxWebhookNameLength          = 64
+	defaultWebhookRetryDelay      = 2 * time.Second
+	defaultWebhookDeliveryTimeout = 10 * time.Second
+)
+
+// WebhookEvents lists the events a webhook can subscribe to
+var WebhookEvents = []string{
+	WebhookEventNetworkCreated,
+	WebhookEventNetworkDeleted,
+	WebhookEventMemberAuthorized,
+	WebhookEventMemberDeauthorized,
+	WebhookEventUserCreated,
+}
+
+var (
+	ErrWebhookNotFound     = errors.New("webhook not found")
+	ErrWebhookInvalidName  = fmt.Errorf("webhook name must be at most %d characters", maxWebhookNameLength)
+	ErrWebhookInvalidURL   = errors.New("webhook URL must be an absolute http or https URL")
+	ErrWebhookInvalidEvent = fmt.Errorf("webhook events must be among %s", strings.Join(WebhookEvents, ", "))
+	ErrWebhookQueueFull    = errors.New("webhook queue is full; try again later")
+)
+
+// WebhookInput creates or changes a webhook. On update an empty secret keeps the current one
+// and a nil Enabled keeps the current state.
+type WebhookInput struct {
+	Name    string   `json:"name"`
+	URL     string   `json:"url"`
+	Secret  string   `json:"secret"`
+	Events  []string `json:"events"`
+	Enabled *bool    `json:"enabled"`
+}
+
+// WebhookPayload is the JSON body of every delivery. Data is the Webhook*Data type of the
+// event; Test marks deliveries sent with SendTestEvent.
+type WebhookPayload struct {
+	Event     string    `json:"event"`
+	CreatedAt time.Time `json:"createdAt"`
+	Test      bool      `json:"test,omitempty"`
+	Data      any       `json:"data"`
+}
+
+// webhookEvent is a queued event. An event with a delivery goes to that delivery's webhook
+// only; any other is fanned out to the subscribers.
+type webhookEvent struct {
+	payload  WebhookPayload
+	webhook  *models.Webhook
+	delivery *models.WebhookDelivery
+	body     []byte
+}
+
+// WebhookDispatcher manages the outbound webhooks and delivers events to them in the
+// background. Every delivery is signed with the webhook's secret and logged, and deliveries
+// that fail with a server error or no response are retried with exponential backoff.
+type WebhookDispatcher struct {
+	db    database.DBInterface
+	mutex sync.RWMutex
+
+	client     *http.Client
+	retryDelay time.Duration
+	queue      chan webhookEvent
+	deliveries sync.WaitGroup
+}
+
+// NewWebhookDispatcher creates a dispatcher; events are queued until Start is called
+func NewWebhookDispatcher(db database.DBInterface) *WebhookDispatcher {
+	return &WebhookDispatcher{
+		db:         db,
+		client:     &http.Client{Timeout: defaultWebhookDeliveryTimeout},
+		retryDelay: defaultWebhookRetryDelay,
+		queue:      make(chan webhookEvent, webhookQueueSize),
+	}
+}
+
+func (d *WebhookDispatcher) SetDB(db database.DBInterface) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	d.db = db
+}
+
+func (d *WebhookDispatcher) getDB() database.DBInterface {
+	d.mutex.RLock()
+	defer d.mutex.RUnlock()
+	return d.db
+}
+
+// SetRetryDelay changes the delay before the first retry; each further retry waits twice as long
+func (d *WebhookDispatcher) SetRetryDelay(delay time.Duration) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	d.retryDelay = delay
+}
+
+func (d *WebhookDispatcher) getRetryDelay() time.Duration {
+	d.mutex.RLock()
+	defer d.mutex.RUnlock()
+	return d.retryDelay
+}
+
+// Dispatch queues an event for every webhook subscribed to it without waiting for delivery.
+// A nil dispatcher ignores events, so services work without one.
+func (d *WebhookDispatcher) Dispatch(event string, data any) {
+	if d == nil {
+		return
+	}
+	select {
+	case d.queue <- webhookEvent{payload: WebhookPayload{Event: event, CreatedAt: time.Now().UTC(), Data: data}}:
+	default:
+		logger.Warn("webhook queue is full; dropping event", zap.String("event", event))
+	}
+}
+
+// Start delivers queued events until ctx is cancelled, then waits for running deliveries
+func (d *WebhookDispatcher) Start(ctx context.Context) <-chan struct{} {
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for {
+			select {
+			case <-ctx.Done():
+				d.deliveries.Wait()
+				return
+			case event := <-d.queue:
+				if event.delivery == nil {
+					d.fanOut(ctx, event.payload)
+				} else if db := d.getDB(); db != nil {
+					d.startDelivery(ctx, db, event.webhook, event.delivery, event.body)
+				}
+			}
+		}
+	}()
+	return done
+}
+
+// fanOut starts one delivery for every enabled webhook subscribed to the event
+func (d *WebhookDispatcher) fanOut(ctx context.Context, payload WebhookPayload) {
+	db := d.getDB()
+	if db == nil {
+		return
+	}
+	webhooks, err := db.ListWebhooks()
+	if err != nil {
+		logger.Warn("failed to list webhooks; dropping event", zap.String("event", payload.Event), zap.Error(err))
+		return
+	}
+	for _, webhook := range webhooks {
+		events := webhook.EventList()
+		if !webhook.Enabled || (len(events) > 0 && !slices.Contains(events, payload.Event)) {
+			continue
+		}
+		delivery, body, err := d.newDelivery(db, webhook, payload)
+		if err != nil {
+			logger.Warn("failed to log webhook delivery", zap.String("webhook_id", webhook.ID), zap.String("event", payload.Event), zap.Error(err))
+			continue
+		}
+		d.startDelivery(ctx, db, webhook, delivery, body)
+	}
+}
+
+func (d *WebhookDispatcher) startDelivery(ctx context.Context, db database.DBInterface, webhook *models.Webhook, delivery *models.WebhookDelivery, body []byte) {
+	d.deliveries.Add(1)
+	go func() {
+		defer d.deliveries.Done()
+		d.deliver(ctx, db, webhook, delivery, body, webhookMaxAttempts)
+	}()
+}
+
+func (d *WebhookDispatcher) newDelivery(db database.DBInterface, webhook *models.Webhook, payload WebhookPayload) (*models.WebhookDelivery, []byte, error) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return nil, nil, err
+	}
+	delivery := &models.WebhookDelivery{
+		WebhookID: webhook.ID,
+		Event:     payload.Event,
+		Payload:   string(body),
+		CreatedAt: time.Now(),
+	}
+	if err := db.CreateWebhookDelivery(delivery); err != nil {
+		return nil, nil, err
+	}
+	return delivery, body, nil
+}
+
+// deliver sends a delivery until it succeeds, fails permanently, runs out of attempts or
+// ctx is cancelled, and logs the outcome
+func (d *WebhookDispatcher) deliver(ctx context.Context, db database.DBInterface, webhook *models.Webhook, delivery *models.WebhookDelivery, body []byte, maxAttempts int) {
+	delay := d.getRetryDelay()
+	for {
+		delivery.Attempts++
+		retry := d.attempt(ctx, webhook, delivery, body)
+		if delivery.Success || !retry || delivery.Attempts >= maxAttempts {
+			break
+		}
+		if err := db.UpdateWebhookDelivery(delivery); err != nil {
+			logger.Warn("failed to log webhook attempt", zap.Uint64("delivery_id", delivery.ID), zap.Error(err))
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			delivery.Error = "delivery cancelled at shutdown: " + delivery.Error
+			d.complete(db, delivery)
+			return
+		case <-timer.C:
+		}
+		delay *= 2
+	}
+	d.complete(db, delivery)
+}
+
+func (d *WebhookDispatcher) complete(db database.DBInterface, delivery *models.WebhookDelivery) {
+	now := time.Now()
+	delivery.CompletedAt = &now
+	if err := db.UpdateWebhookDelivery(delivery); err != nil {
+		logger.Warn("failed to log webhook delivery", zap.Uint64("delivery_id", delivery.ID), zap.Error(err))
+	}
+	if _, err := db.DeleteWebhookDeliveriesBeyond(delivery.WebhookID, webhookDeliveriesKept); err != nil {
+		logger.Warn("failed to prune webhook deliveries", zap.String("webhook_id", delivery.WebhookID), zap.Error(err))
+	}
+}
+
+// attempt posts the delivery once and records the result. It reports whether a failure may
+// succeed when retried: server errors and requests without a response.
+func (d *WebhookDispatcher) attempt(ctx context.Context, webhook *models.Webhook, delivery *models.WebhookDelivery, body []byte) bool {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook.URL, bytes.NewReader(body))
+	if err != nil {
+		delivery.Error = err.Error()
+		return false
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "Tairitsu-Webhook")
+	req.Header.Set(WebhookEventHeader, delivery.Event)
+	req.Header.Set(WebhookDeliveryHeader, strconv.FormatUint(delivery.ID, 10))
+	req.Header.Set(WebhookSignatureHeader, SignWebhookPayload(webhook.Secret, body))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		delivery.StatusCode = 0
+		delivery.Error = err.Error()
+		return true
+	}
+	defer resp.Body.Close()
+
+	delivery.StatusCode = resp.StatusCode
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		delivery.Success = true
+		delivery.Error = ""
+		return false
+	}
+	snippet, _ := io.ReadAll(io.LimitReader(resp.Body, webhookErrorBodyLimit))
+	delivery.Error = strings.TrimSpace(fmt.Sprintf("receiver answered %d %s", resp.StatusCode, snippet))
+	return resp.StatusCode >= 500
+}
+
+// SignWebhookPayload returns the signature header value of a delivery body: the hex-encoded
+// HMAC-SHA256 of the body keyed with the webhook secret, prefixed with "sha256="
+func SignWebhookPayload(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func normalizeWebhookInput(input WebhookInput) (WebhookInput, error) {
+	input.Name = strings.TrimSpace(input.Name)
+	if len(input.Name) > maxWebhookNameLength {
+		return input, ErrWebhookInvalidName
+	}
+	input.URL = strings.TrimSpace(input.URL)
+	parsed, err := url.Parse(input.URL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return input, ErrWebhookInvalidURL
+	}
+
+	events := make([]string, 0, len(input.Events))
+	for _, event := range input.Events {
+		event = strings.TrimSpace(event)
+		if !slices.Contains(WebhookEvents, event) {
+			return input, ErrWebhookInvalidEvent
+		}
+		if !slices.Contains(events, event) {
+			events = append(events, event)
+		}
+	}
+	input.Events = events
+	return input, nil
+}
+
+func newWebhookSecret() (string, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", fmt.Errorf("failed to generate webhook secret: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(secret), nil
+}
+
+// CreateWebhook stores a webhook and returns its secret, which is generated when none is given
+// and never returned again
+func (d *WebhookDispatcher) CreateWebhook(input WebhookInput, createdBy string) (*models.Webhook, string, error) {
+	db := d.getDB()
+	if db == nil {
+		return nil, "", ErrUserDBUnavailable
+	}
+	input, err := normalizeWebhookInput(input)
+	if err != nil {
+		return nil, "", err
+	}
+	secret := input.Secret
+	if secret == "" {
+		if secret, err = newWebhookSecret(); err != nil {
+			return nil, "", err
+		}
+	}
+
+	now := time.Now()
+	webhook := &models.Webhook{
+		ID:        uuid.New().String(),
+		Name:      input.Name,
+		URL:       input.URL,
+		Secret:    secret,
+		Events:    strings.Join(input.Events, ","),
+		Enabled:   input.Enabled == nil || *input.Enabled,
+		CreatedBy: createdBy,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if err := db.CreateWebhook(webhook); err != nil {
+		return nil, "", fmt.Errorf("failed to create webhook: %w", err)
+	}
+	logger.Info("service: webhook created", zap.String("webhook_id", webhook.ID), zap.String("created_by", createdBy), zap.Strings("events", input.Events))
+	return webhook, secret, nil
+}
+
+// ListWebhooks returns every webhook
+func (d *WebhookDispatcher) ListWebhooks() ([]*models.Webhook, error) {
+	db := d.getDB()
+	if db == nil {
+		return nil, ErrUserDBUnavailable
+	}
+	return db.ListWebhooks()
+}
+
+// GetWebhook returns a webhook or ErrWebhookNotFound
+func (d *WebhookDispatcher) GetWebhook(id string) (*models.Webhook, error) {
+	db := d.getDB()
+	if db == nil {
+		return nil, ErrUserDBUnavailable
+	}
+	webhook, err := db.GetWebhookByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if webhook == nil {
+		return nil, ErrWebhookNotFound
+	}
+	return webhook, nil
+}
+
+// UpdateWebhook replaces the settings of a webhook
+func (d *WebhookDispatcher) UpdateWebhook(id string, input WebhookInput) (*models.Webhook, error) {
+	webhook, err := d.GetWebhook(id)
+	if err != nil {
+		return nil, err
+	}
+	if input, err = normalizeWebhookInput(input); err != nil {
+		return nil, err
+	}
+
+	webhook.Name = input.Name
+	webhook.URL = input.URL
+	webhook.Events = strings.Join(input.Events, ",")
+	if input.Secret != "" {
+		webhook.Secret = input.Secret
+	}
+	if input.Enabled != nil {
+		webhook.Enabled = *input.Enabled
+	}
+	webhook.UpdatedAt = time.Now()
+	if err := d.getDB().UpdateWebhook(webhook); err != nil {
+		return nil, fmt.Errorf("failed to update webhook: %w", err)
+	}
+	return webhook, nil
+}
+
+// DeleteWebhook deletes a webhook and its delivery log
+func (d *WebhookDispatcher) DeleteWebhook(id string) error {
+	if _, err := d.GetWebhook(id); err != nil {
+		return err
+	}
+	if err := d.getDB().DeleteWebhook(id); err != nil {
+		return fmt.Errorf("failed to delete webhook: %w", err)
+	}
+	logger.Info("service: webhook deleted", zap.String("webhook_id", id))
+	return nil
+}
+
+// ListDeliveries returns the latest deliveries of a webhook, newest first
+func (d *WebhookDispatcher) ListDeliveries(id string, limit int) ([]*models.WebhookDelivery, error) {
+	if _, err := d.GetWebhook(id); err != nil {
+		return nil, err
+	}
+	if limit <= 0 || limit > webhookDeliveriesKept {
+		limit = webhookDeliveriesKept
+	}
+	return d.getDB().ListWebhookDeliveries(id, limit)
+}
+
+// Ping sends a ping event to a webhook right away, even when it is disabled, and returns the
+// logged delivery. It is not retried, so the result shows how the receiver answered.
+func (d *WebhookDispatcher) Ping(ctx context.Context, id string) (*models.WebhookDelivery, error) {
+	webhook, err := d.GetWebhook(id)
+	if err != nil {
+		return nil, err
+	}
+	db := d.getDB()
+	delivery, body, err := d.newDelivery(db, webhook, WebhookPayload{
+		Event:     WebhookEventPing,
+		CreatedAt: time.Now().UTC(),
+		Data:      pingWebhookData(webhook),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to log webhook delivery: %w", err)
+	}
+	d.deliver(ctx, db, webhook, delivery, body, 1)
+	return delivery, nil
+}
+
+// SendTestEvent queues an event marked as a test for one webhook, even when it is disabled or
+// not subscribed to the event. The data is a realistic sample built like that of real events,
+// and the delivery is signed and retried like any other. It returns the delivery as logged
+// before the first attempt; ListDeliveries shows how it went.
+func (d *WebhookDispatcher) SendTestEvent(id, event string) (*models.WebhookDelivery, error) {
+	webhook, err := d.GetWebhook(id)
+	if err != nil {
+		return nil, err
+	}
+	if !slices.Contains(WebhookEvents, event) {
+		return nil, ErrWebhookInvalidEvent
+	}
+	db := d.getDB()
+	delivery, body, err := d.newDelivery(db, webhook, WebhookPayload{
+		Event:     event,
+		CreatedAt: time.Now().UTC(),
+		Test:      true,
+		Data:      webhookSamples[event](webhook),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to log webhook delivery: %w", err)
+	}
+
+	// The queued delivery is changed by its attempts, so the caller gets a copy
+	logged := *delivery
+	select {
+	case d.queue <- webhookEvent{webhook: webhook, delivery: delivery, body: body}:
+		return &logged, nil
+	default:
+		delivery.Error = ErrWebhookQueueFull.Error()
+		d.complete(db, delivery)
+		return nil, ErrWebhookQueueFull
+	}
+}
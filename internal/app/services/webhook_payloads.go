@@ -0,0 +1,158 @@
+package services
+
+import (
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/GT-610/tairitsu/internal/app/config"
+	"github.com/GT-610/tairitsu/internal/app/models"
+	"github.com/GT-610/tairitsu/internal/zerotier"
+)
+
+// WebhookPingData is the data of a ping event
+type WebhookPingData struct {
+	WebhookID string `json:"webhookId"`
+}
+
+// WebhookNetworkCreatedData is the data of a network.created event
+type WebhookNetworkCreatedData struct {
+	NetworkID  string `json:"networkId"`
+	Name       string `json:"name"`
+	OwnerID    string `json:"ownerId"`
+	Controller string `json:"controller"`
+}
+
+// WebhookNetworkDeletedData is the data of a network.deleted event
+type WebhookNetworkDeletedData struct {
+	NetworkID string `json:"networkId"`
+	Name      string `json:"name"`
+	DeletedBy string `json:"deletedBy"`
+}
+
+// WebhookMemberData is the data of member.authorized and member.deauthorized events
+type WebhookMemberData struct {
+	NetworkID string `json:"networkId"`
+	MemberID  string `json:"memberId"`
+	ChangedBy string `json:"changedBy"`
+}
+
+// WebhookUserCreatedData is the data of a user.created event
+type WebhookUserCreatedData struct {
+	UserID   string `json:"userId"`
+	Username string `json:"username"`
+	Role     string `json:"role"`
+}
+
+// The builders below are the only way event data is made, for real events and test events
+// alike, so test deliveries and the published schemas cannot drift from what receivers get.
+
+func pingWebhookData(webhook *models.Webhook) WebhookPingData {
+	return WebhookPingData{WebhookID: webhook.ID}
+}
+
+func networkCreatedWebhookData(network *zerotier.Network, ownerID, controller string) WebhookNetworkCreatedData {
+	return WebhookNetworkCreatedData{NetworkID: network.ID, Name: network.Name, OwnerID: ownerID, Controller: controller}
+}
+
+func networkDeletedWebhookData(network *models.Network, deletedBy string) WebhookNetworkDeletedData {
+	return WebhookNetworkDeletedData{NetworkID: network.ID, Name: network.Name, DeletedBy: deletedBy}
+}
+
+func memberWebhookData(networkID, memberID, changedBy string) WebhookMemberData {
+	return WebhookMemberData{NetworkID: networkID, MemberID: memberID, ChangedBy: changedBy}
+}
+
+func userCreatedWebhookData(user *models.User) WebhookUserCreatedData {
+	return WebhookUserCreatedData{UserID: user.ID, Username: user.Username, Role: user.Role}
+}
+
+const (
+	sampleWebhookNetworkID = "8056c2e21c000001"
+	sampleWebhookUserID    = "00000000-0000-4000-8000-000000000001"
+)
+
+// webhookSamples builds realistic data for test deliveries, one entry per event
+var webhookSamples = map[string]func(webhook *models.Webhook) any{
+	WebhookEventPing: func(webhook *models.Webhook) any {
+		return pingWebhookData(webhook)
+	},
+	WebhookEventNetworkCreated: func(*models.Webhook) any {
+		return networkCreatedWebhookData(&zerotier.Network{ID: sampleWebhookNetworkID, Name: "office"}, sampleWebhookUserID, config.DefaultControllerName)
+	},
+	WebhookEventNetworkDeleted: func(*models.Webhook) any {
+		return networkDeletedWebhookData(&models.Network{ID: sampleWebhookNetworkID, Name: "office"}, sampleWebhookUserID)
+	},
+	WebhookEventMemberAuthorized: func(*models.Webhook) any {
+		return memberWebhookData(sampleWebhookNetworkID, "a1a1a1a1a1", sampleWebhookUserID)
+	},
+	WebhookEventMemberDeauthorized: func(*models.Webhook) any {
+		return memberWebhookData(sampleWebhookNetworkID, "a1a1a1a1a1", sampleWebhookUserID)
+	},
+	WebhookEventUserCreated: func(*models.Webhook) any {
+		return userCreatedWebhookData(&models.User{ID: sampleWebhookUserID, Username: "alice", Role: "user"})
+	},
+}
+
+// WebhookSchemas returns the JSON Schema of the payload of every event, keyed by event. The
+// schemas are generated from the Go types the payloads are encoded from.
+func WebhookSchemas() map[string]map[string]any {
+	schemas := make(map[string]map[string]any, len(webhookSamples))
+	for event, sample := range webhookSamples {
+		schema := jsonSchemaOf(reflect.TypeOf(WebhookPayload{}))
+		schema["$schema"] = "https://json-schema.org/draft/2020-12/schema"
+		schema["title"] = event
+		properties := schema["properties"].(map[string]any)
+		properties["event"] = map[string]any{"type": "string", "const": event}
+		properties["data"] = jsonSchemaOf(reflect.TypeOf(sample(&models.Webhook{})))
+		schemas[event] = schema
+	}
+	return schemas
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// jsonSchemaOf describes how encoding/json encodes values of t. Fields without omitempty
+// are required; interface types accept any value.
+func jsonSchemaOf(t reflect.Type) map[string]any {
+	if t.Kind() == reflect.Pointer {
+		return jsonSchemaOf(t.Elem())
+	}
+	if t == timeType {
+		return map[string]any{"type": "string", "format": "date-time"}
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		return map[string]any{"type": "array", "items": jsonSchemaOf(t.Elem())}
+	case reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": jsonSchemaOf(t.Elem())}
+	case reflect.Struct:
+		properties := map[string]any{}
+		required := []string{}
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			name, options, _ := strings.Cut(field.Tag.Get("json"), ",")
+			if !field.IsExported() || name == "-" {
+				continue
+			}
+			if name == "" {
+				name = field.Name
+			}
+			properties[name] = jsonSchemaOf(field.Type)
+			if !strings.Contains(options, "omitempty") {
+				required = append(required, name)
+			}
+		}
+		return map[string]any{"type": "object", "properties": properties, "required": required, "additionalProperties": false}
+	}
+	return map[string]any{}
+}
@@ -0,0 +1,120 @@
+/*
+ * Tairitsu - A ZeroTier Network Controller Manager
+ * Copyright (C) 2025 Patmeow Lab
+ * SPDX-License-Identifier: GPL-3.0-only
+ */
+
+// Package ipcalc does the address range arithmetic behind ZeroTier assignment pools,
+// for IPv4 and IPv6 alike.
+package ipcalc
+
+import (
+	"fmt"
+	"math/big"
+	"net/netip"
+	"slices"
+)
+
+// Range is an inclusive range of addresses of one family
+type Range struct {
+	Start netip.Addr
+	End   netip.Addr
+}
+
+// ParseRange parses an inclusive range such as a ZeroTier ipRangeStart and ipRangeEnd
+func ParseRange(start, end string) (Range, error) {
+	startAddr, err := netip.ParseAddr(start)
+	if err != nil {
+		return Range{}, fmt.Errorf("invalid range start %q: %w", start, err)
+	}
+	endAddr, err := netip.ParseAddr(end)
+	if err != nil {
+		return Range{}, fmt.Errorf("invalid range end %q: %w", end, err)
+	}
+	startAddr, endAddr = startAddr.Unmap(), endAddr.Unmap()
+	if startAddr.Is4() != endAddr.Is4() {
+		return Range{}, fmt.Errorf("range %s-%s mixes IPv4 and IPv6", start, end)
+	}
+	if endAddr.Less(startAddr) {
+		return Range{}, fmt.Errorf("range end %s is before its start %s", end, start)
+	}
+	return Range{Start: startAddr.WithZone(""), End: endAddr.WithZone("")}, nil
+}
+
+// Contains reports whether addr lies in the range, both ends included
+func (r Range) Contains(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	return addr.Is4() == r.Start.Is4() && r.Start.Compare(addr) <= 0 && addr.Compare(r.End) <= 0
+}
+
+// Size returns how many addresses the range holds; an IPv6 range can exceed 64 bits
+func (r Range) Size() *big.Int {
+	size := new(big.Int).Sub(addrInt(r.End), addrInt(r.Start))
+	return size.Add(size, big.NewInt(1))
+}
+
+func (r Range) String() string {
+	return r.Start.String() + "-" + r.End.String()
+}
+
+func addrInt(addr netip.Addr) *big.Int {
+	return new(big.Int).SetBytes(addr.AsSlice())
+}
+
+// Usage is how much of a range is taken by a set of addresses
+type Usage struct {
+	Range    Range
+	Assigned []netip.Addr
+	Capacity *big.Int
+	Free     *big.Int
+	NextFree []netip.Addr
+}
+
+// Utilization matches assigned addresses against ranges. Each range reports the addresses
+// it contains and up to nextFree of its lowest unassigned addresses; addresses outside every
+// range are returned separately. Duplicate addresses count once.
+func Utilization(ranges []Range, assigned []netip.Addr, nextFree int) ([]Usage, []netip.Addr) {
+	unique := make(map[netip.Addr]struct{}, len(assigned))
+	for _, addr := range assigned {
+		unique[addr.Unmap().WithZone("")] = struct{}{}
+	}
+
+	usages := make([]Usage, len(ranges))
+	inRange := make(map[netip.Addr]struct{}, len(unique))
+	for i, r := range ranges {
+		usage := Usage{Range: r, Assigned: []netip.Addr{}, Capacity: r.Size()}
+		for addr := range unique {
+			if r.Contains(addr) {
+				usage.Assigned = append(usage.Assigned, addr)
+				inRange[addr] = struct{}{}
+			}
+		}
+		slices.SortFunc(usage.Assigned, netip.Addr.Compare)
+		usage.Free = new(big.Int).Sub(usage.Capacity, big.NewInt(int64(len(usage.Assigned))))
+		usage.NextFree = freeAddrs(r, unique, nextFree)
+		usages[i] = usage
+	}
+
+	outside := make([]netip.Addr, 0)
+	for addr := range unique {
+		if _, ok := inRange[addr]; !ok {
+			outside = append(outside, addr)
+		}
+	}
+	slices.SortFunc(outside, netip.Addr.Compare)
+	return usages, outside
+}
+
+// freeAddrs walks the range from its start, so it visits at most len(used)+limit addresses
+func freeAddrs(r Range, used map[netip.Addr]struct{}, limit int) []netip.Addr {
+	free := make([]netip.Addr, 0, max(limit, 0))
+	for addr := r.Start; addr.IsValid() && len(free) < limit; addr = addr.Next() {
+		if _, taken := used[addr]; !taken {
+			free = append(free, addr)
+		}
+		if addr == r.End {
+			break
+		}
+	}
+	return free
+}
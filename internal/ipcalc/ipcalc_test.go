@@ -0,0 +1,117 @@
+package ipcalc
+
+import (
+	"net/netip"
+	"slices"
+	"testing"
+)
+
+func mustRange(t *testing.T, start, end string) Range {
+	t.Helper()
+	r, err := ParseRange(start, end)
+	if err != nil {
+		t.Fatalf("ParseRange(%q, %q) error = %v", start, end, err)
+	}
+	return r
+}
+
+func addrs(values ...string) []netip.Addr {
+	parsed := make([]netip.Addr, len(values))
+	for i, value := range values {
+		parsed[i] = netip.MustParseAddr(value)
+	}
+	return parsed
+}
+
+func TestParseRange_RejectsInvalidRanges(t *testing.T) {
+	for _, tc := range []struct{ start, end string }{
+		{"10.0.0.9", "10.0.0.1"},
+		{"10.0.0.1", "fd00::1"},
+		{"10.0.0.1", "not-an-address"},
+		{"", "10.0.0.1"},
+	} {
+		if _, err := ParseRange(tc.start, tc.end); err == nil {
+			t.Errorf("ParseRange(%q, %q) succeeded, want error", tc.start, tc.end)
+		}
+	}
+}
+
+func TestRange_ContainsBothEnds(t *testing.T) {
+	r := mustRange(t, "10.0.0.10", "10.0.0.20")
+	for value, want := range map[string]bool{
+		"10.0.0.9":         false,
+		"10.0.0.10":        true,
+		"10.0.0.15":        true,
+		"10.0.0.20":        true,
+		"10.0.0.21":        false,
+		"::ffff:10.0.0.10": true,
+		"fd00::a":          false,
+	} {
+		if got := r.Contains(netip.MustParseAddr(value)); got != want {
+			t.Errorf("Contains(%s) = %v, want %v", value, got, want)
+		}
+	}
+}
+
+func TestRange_Size(t *testing.T) {
+	for _, tc := range []struct {
+		start, end string
+		want       string
+	}{
+		{"10.0.0.1", "10.0.0.1", "1"},
+		{"10.0.0.1", "10.0.0.254", "254"},
+		{"0.0.0.0", "255.255.255.255", "4294967296"},
+		{"fd00::", "fd00::ffff:ffff:ffff:ffff", "18446744073709551616"},
+	} {
+		if got := mustRange(t, tc.start, tc.end).Size().String(); got != tc.want {
+			t.Errorf("Size(%s-%s) = %s, want %s", tc.start, tc.end, got, tc.want)
+		}
+	}
+}
+
+func TestUtilization_CountsPoolBoundariesAndOutsideAddresses(t *testing.T) {
+	pool := mustRange(t, "10.0.0.1", "10.0.0.5")
+	assigned := addrs("10.0.0.1", "10.0.0.5", "10.0.0.3", "10.0.0.3", "10.0.0.6", "192.168.1.1")
+
+	usages, outside := Utilization([]Range{pool}, assigned, 10)
+	if len(usages) != 1 {
+		t.Fatalf("len(usages) = %d, want 1", len(usages))
+	}
+	usage := usages[0]
+	if !slices.Equal(usage.Assigned, addrs("10.0.0.1", "10.0.0.3", "10.0.0.5")) {
+		t.Errorf("Assigned = %v", usage.Assigned)
+	}
+	if usage.Capacity.String() != "5" || usage.Free.String() != "2" {
+		t.Errorf("Capacity = %s, Free = %s, want 5 and 2", usage.Capacity, usage.Free)
+	}
+	if !slices.Equal(usage.NextFree, addrs("10.0.0.2", "10.0.0.4")) {
+		t.Errorf("NextFree = %v", usage.NextFree)
+	}
+	if !slices.Equal(outside, addrs("10.0.0.6", "192.168.1.1")) {
+		t.Errorf("outside = %v", outside)
+	}
+}
+
+func TestUtilization_FullPoolAndLimit(t *testing.T) {
+	full := mustRange(t, "10.0.0.1", "10.0.0.2")
+	wide := mustRange(t, "fd00::1", "fd00::ffff")
+
+	usages, outside := Utilization([]Range{full, wide}, addrs("10.0.0.1", "10.0.0.2", "fd00::2"), 3)
+	if len(usages[0].NextFree) != 0 || usages[0].Free.Sign() != 0 {
+		t.Errorf("full pool NextFree = %v, Free = %s", usages[0].NextFree, usages[0].Free)
+	}
+	if !slices.Equal(usages[1].NextFree, addrs("fd00::1", "fd00::3", "fd00::4")) {
+		t.Errorf("IPv6 NextFree = %v", usages[1].NextFree)
+	}
+	if len(outside) != 0 {
+		t.Errorf("outside = %v, want none", outside)
+	}
+}
+
+func TestUtilization_RangeEndingAtLastAddress(t *testing.T) {
+	r := mustRange(t, "255.255.255.254", "255.255.255.255")
+	usages, _ := Utilization([]Range{r}, nil, 5)
+	if !slices.Equal(usages[0].NextFree, addrs("255.255.255.254", "255.255.255.255")) {
+		t.Errorf("NextFree = %v", usages[0].NextFree)
+	}
+}
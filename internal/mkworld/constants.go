@@ -20,6 +20,10 @@ var (
 	ErrNoRootNodes            = errors.New("at least one root node is required")
 	ErrReservedPlanetID       = errors.New("planet id is reserved")
 	ErrInvalidBirthTime       = errors.New("birth time is invalid")
+	ErrMalformedWorld         = errors.New("malformed world file")
+	ErrUnresolvableHostname   = errors.New("endpoint hostname did not resolve")
+	ErrInvalidResolveFamily   = errors.New("invalid address family")
+	ErrIdentityMismatch       = errors.New("identity public key does not match its address")
 )
 
 const (
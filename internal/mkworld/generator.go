@@ -13,23 +13,44 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 	"time"
 )
 
 type RootNodeConfig struct {
-	IdentityPublic string   `json:"identity_public"`
+	IdentityPublic string   `json:"identityPublic"`
 	Comments       string   `json:"comments"`
 	Endpoints      []string `json:"endpoints"`
 }
 
+// SigningKeySource tells where the keys that signed a world came from
+type SigningKeySource string
+
+const (
+	// SigningKeysEphemeral keys were generated for one world and discarded
+	SigningKeysEphemeral SigningKeySource = "ephemeral"
+	// SigningKeysReused keys were read from the signing key directory
+	SigningKeysReused SigningKeySource = "reused"
+	// SigningKeysCreated keys were generated and saved to the signing key directory
+	SigningKeysCreated SigningKeySource = "created"
+)
+
 type GenerateOptions struct {
-	RootNodes       []RootNodeConfig
+	RootNodes []RootNodeConfig
+	// SigningKeyPath is the directory holding previous.c25519 and current.c25519. Missing
+	// keys are created there, so later planets are signed by the same key; without a
+	// directory the planet is signed with throwaway keys.
 	SigningKeyPath  string
 	PlanetID        uint64
 	BirthTime       int64
 	RecommendValues bool
 	DownloadName    string
+	// Hostnames allows endpoints like "root1.example.com/9993"; nil accepts IP addresses only
+	Hostnames *HostnameResolution
+	// SkipValidation accepts root identities whose public key does not derive their address.
+	// Nodes reject such roots, so it only suits identities made outside ZeroTier.
+	SkipValidation bool
 }
 
 type GeneratedPlanet struct {
@@ -40,6 +61,27 @@ type GeneratedPlanet struct {
 	RootNodeCount         int
 	EndpointCount         int
 	UsedRecommendedValues bool
+	SigningKeys           SigningKeySource
+}
+
+// MoonOptions configures GenerateMoon. SigningKeyPath works as in GenerateOptions; a zero
+// Timestamp uses the current time. Hostnames and SkipValidation work as in GenerateOptions.
+type MoonOptions struct {
+	RootNodes      []RootNodeConfig
+	SigningKeyPath string
+	Timestamp      int64
+	Hostnames      *HostnameResolution
+	SkipValidation bool
+}
+
+type GeneratedMoon struct {
+	MoonID        uint64
+	Timestamp     int64
+	MoonData      []byte
+	FileName      string
+	RootNodeCount int
+	EndpointCount int
+	SigningKeys   SigningKeySource
 }
 
 var (
@@ -55,7 +97,7 @@ func GeneratePlanet(opts *GenerateOptions) (*GeneratedPlanet, error) {
 		return nil, ErrMaxRootNodesExceeded
 	}
 
-	prevPub, curPub, prevPriv, _, err := loadSigningKeys(opts.SigningKeyPath)
+	keys, err := loadSigningKeys(opts.SigningKeyPath)
 	if err != nil {
 		return nil, err
 	}
@@ -65,50 +107,106 @@ func GeneratePlanet(opts *GenerateOptions) (*GeneratedPlanet, error) {
 		return nil, err
 	}
 
-	nodes := make([]*ZtWorldPlanetNode, 0, len(opts.RootNodes))
-	seenIdentities := make(map[string]struct{}, len(opts.RootNodes))
-	totalEndpoints := 0
+	nodes, totalEndpoints, err := parseRootNodes(opts.RootNodes, opts.Hostnames, !opts.SkipValidation)
+	if err != nil {
+		return nil, err
+	}
 
-	for _, rootNodeConfig := range opts.RootNodes {
-		if strings.TrimSpace(rootNodeConfig.IdentityPublic) == "" {
-			return nil, ErrIdentityPublicRequired
-		}
+	ztW := &ZtWorld{
+		Type:      ZT_WORLD_TYPE_PLANET,
+		ID:        ZtWorldID(planetID),
+		Timestamp: uint64(birthTime),
+		Nodes:     nodes,
+	}
 
-		identity, err := ParseIdentityPublic(rootNodeConfig.IdentityPublic)
-		if err != nil {
-			return nil, err
-		}
+	ztW.PublicKeyMustBeSignedByNextTime = keys.curPub
 
-		identityKey := strings.TrimSpace(rootNodeConfig.IdentityPublic)
-		if _, exists := seenIdentities[identityKey]; exists {
-			return nil, fmt.Errorf("%w: %s", ErrDuplicateIdentity, identity.ZtNodeAddressString())
-		}
-		seenIdentities[identityKey] = struct{}{}
+	finalData, err := signWorld(ztW, keys.prevPub, keys.prevPriv)
+	if err != nil {
+		return nil, err
+	}
 
-		endpoints, err := parseRootNodeEndpoints(rootNodeConfig.Endpoints)
-		if err != nil {
-			return nil, err
-		}
+	return &GeneratedPlanet{
+		PlanetID:              planetID,
+		BirthTime:             birthTime,
+		PlanetData:            finalData,
+		DownloadName:          normalizeDownloadName(opts.DownloadName),
+		RootNodeCount:         len(nodes),
+		EndpointCount:         totalEndpoints,
+		UsedRecommendedValues: usedRecommendedValues,
+		SigningKeys:           keys.source,
+	}, nil
+}
 
-		nodes = append(nodes, &ZtWorldPlanetNode{
-			Identity:  identity,
-			Endpoints: endpoints,
-			Comments:  strings.TrimSpace(rootNodeConfig.Comments),
-		})
-		totalEndpoints += len(endpoints)
+// GenerateMoon builds a moon world from one or more roots. Its ID is the address of the first
+// root, which is also what nodes pass to `zerotier-cli orbit`.
+func GenerateMoon(opts *MoonOptions) (*GeneratedMoon, error) {
+	if len(opts.RootNodes) == 0 {
+		return nil, ErrNoRootNodes
+	}
+	if len(opts.RootNodes) > ZT_WORLD_MAX_ROOTS {
+		return nil, ErrMaxRootNodesExceeded
+	}
+
+	keys, err := loadSigningKeys(opts.SigningKeyPath)
+	if err != nil {
+		return nil, err
+	}
+
+	nodes, totalEndpoints, err := parseRootNodes(opts.RootNodes, opts.Hostnames, !opts.SkipValidation)
+	if err != nil {
+		return nil, err
+	}
 
+	timestamp := opts.Timestamp
+	if timestamp == 0 {
+		timestamp = time.Now().UnixMilli()
+	} else if timestamp < 0 {
+		return nil, ErrInvalidBirthTime
 	}
 
+	moonID := moonIDFromAddress(nodes[0].Identity.ZtNodeAddress)
 	ztW := &ZtWorld{
-		Type:      ZT_WORLD_TYPE_PLANET,
-		ID:        ZtWorldID(planetID),
-		Timestamp: uint64(birthTime),
+		Type:      ZT_WORLD_TYPE_MOON,
+		ID:        ZtWorldID(moonID),
+		Timestamp: uint64(timestamp),
 		Nodes:     nodes,
 	}
 
-	ztW.PublicKeyMustBeSignedByNextTime = curPub
+	ztW.PublicKeyMustBeSignedByNextTime = keys.curPub
 
-	toSignData, err := ztW.Serialize(true, [ZT_C25519_SIGNATURE_LEN]byte{})
+	finalData, err := signWorld(ztW, keys.prevPub, keys.prevPriv)
+	if err != nil {
+		return nil, err
+	}
+
+	return &GeneratedMoon{
+		MoonID:        moonID,
+		Timestamp:     timestamp,
+		MoonData:      finalData,
+		FileName:      MoonFileName(moonID),
+		RootNodeCount: len(nodes),
+		EndpointCount: totalEndpoints,
+		SigningKeys:   keys.source,
+	}, nil
+}
+
+// MoonFileName is the name zerotier-one expects in its moons.d directory
+func MoonFileName(moonID uint64) string {
+	return fmt.Sprintf("%016x.moon", moonID)
+}
+
+func moonIDFromAddress(address [5]byte) uint64 {
+	var id uint64
+	for _, b := range address {
+		id = id<<8 | uint64(b)
+	}
+	return id
+}
+
+// signWorld signs w with the previous key pair and returns the serialized world
+func signWorld(w *ZtWorld, prevPub [ZT_C25519_PUBLIC_KEY_LEN]byte, prevPriv [ZT_C25519_PRIVATE_KEY_LEN]byte) ([]byte, error) {
+	toSignData, err := w.Serialize(true, [ZT_C25519_SIGNATURE_LEN]byte{})
 	if err != nil {
 		return nil, fmt.Errorf("failed to serialize for signing: %w", err)
 	}
@@ -118,44 +216,120 @@ func GeneratePlanet(opts *GenerateOptions) (*GeneratedPlanet, error) {
 		return nil, fmt.Errorf("failed to sign: %w", err)
 	}
 
-	finalData, err := ztW.Serialize(false, sig)
+	finalData, err := w.Serialize(false, sig)
 	if err != nil {
 		return nil, fmt.Errorf("failed to serialize final: %w", err)
 	}
+	return finalData, nil
+}
 
-	return &GeneratedPlanet{
-		PlanetID:              planetID,
-		BirthTime:             birthTime,
-		PlanetData:            finalData,
-		DownloadName:          normalizeDownloadName(opts.DownloadName),
-		RootNodeCount:         len(nodes),
-		EndpointCount:         totalEndpoints,
-		UsedRecommendedValues: usedRecommendedValues,
-	}, nil
+// parseRootNodes checks and converts the root configs. Hostnames that do not resolve are
+// collected over all roots, so a single error names every one of them.
+func parseRootNodes(configs []RootNodeConfig, hostnames *HostnameResolution, validateIdentities bool) ([]*ZtWorldPlanetNode, int, error) {
+	nodes := make([]*ZtWorldPlanetNode, 0, len(configs))
+	seenIdentities := make(map[string]struct{}, len(configs))
+	totalEndpoints := 0
+	var unresolved []string
+
+	for _, rootNodeConfig := range configs {
+		if strings.TrimSpace(rootNodeConfig.IdentityPublic) == "" {
+			return nil, 0, ErrIdentityPublicRequired
+		}
+
+		identity, err := ParseIdentityPublic(rootNodeConfig.IdentityPublic)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		identityKey := strings.TrimSpace(rootNodeConfig.IdentityPublic)
+		if _, exists := seenIdentities[identityKey]; exists {
+			return nil, 0, fmt.Errorf("%w: %s", ErrDuplicateIdentity, identity.ZtNodeAddressString())
+		}
+		seenIdentities[identityKey] = struct{}{}
+
+		if validateIdentities {
+			if err := ValidateIdentity(identity); err != nil {
+				return nil, 0, err
+			}
+		}
+
+		endpoints, unresolvedHosts, err := parseRootNodeEndpoints(rootNodeConfig.Endpoints, hostnames)
+		if err != nil {
+			return nil, 0, err
+		}
+		for _, host := range unresolvedHosts {
+			if !slices.Contains(unresolved, host) {
+				unresolved = append(unresolved, host)
+			}
+		}
+
+		nodes = append(nodes, &ZtWorldPlanetNode{
+			Identity:  identity,
+			Endpoints: endpoints,
+			Comments:  strings.TrimSpace(rootNodeConfig.Comments),
+		})
+		totalEndpoints += len(endpoints)
+	}
+
+	if len(unresolved) > 0 {
+		return nil, 0, fmt.Errorf("%w: %s", ErrUnresolvableHostname, strings.Join(unresolved, ", "))
+	}
+	return nodes, totalEndpoints, nil
 }
 
-func parseRootNodeEndpoints(values []string) ([]*ZtNodeInetAddr, error) {
+// parseRootNodeEndpoints converts the endpoints of one root and returns the hostnames that did
+// not resolve. An address listed twice is an error, but one a hostname also resolves to is
+// kept once, since several names commonly point at the same root.
+func parseRootNodeEndpoints(values []string, hostnames *HostnameResolution) ([]*ZtNodeInetAddr, []string, error) {
 	endpointValues := normalizeEndpoints(values)
 	if len(endpointValues) == 0 {
-		return nil, ErrNoEndpoints
+		return nil, nil, ErrNoEndpoints
+	}
+	if len(endpointValues) > ZT_WORLD_MAX_STABLE_ENDPOINTS_PER_ROOT {
+		return nil, nil, fmt.Errorf("%w: %d per root, at most %d", ErrMaxEndpointsExceeded, len(endpointValues), ZT_WORLD_MAX_STABLE_ENDPOINTS_PER_ROOT)
 	}
 
 	endpoints := make([]*ZtNodeInetAddr, 0, len(endpointValues))
-	seenEndpoints := make(map[string]struct{}, len(endpointValues))
+	// seenEndpoints tells whether an address was listed literally
+	seenEndpoints := make(map[string]bool, len(endpointValues))
+	var unresolved []string
 	for _, epStr := range endpointValues {
 		ep := &ZtNodeInetAddr{}
-		if err := ep.FromString(epStr); err != nil {
-			return nil, fmt.Errorf("%w: %s", ErrInvalidEndpoint, epStr)
+		if err := ep.FromString(epStr); err == nil {
+			literal, exists := seenEndpoints[ep.String()]
+			if exists && literal {
+				return nil, nil, fmt.Errorf("%w: %s", ErrDuplicateEndpoint, epStr)
+			}
+			seenEndpoints[ep.String()] = true
+			if !exists {
+				endpoints = append(endpoints, ep)
+			}
+			continue
 		}
-		endpointKey := ep.IP.String() + "/" + fmt.Sprintf("%d", ep.Port)
-		if _, exists := seenEndpoints[endpointKey]; exists {
-			return nil, fmt.Errorf("%w: %s", ErrDuplicateEndpoint, epStr)
+
+		host, port, isHostname := splitHostnameEndpoint(epStr)
+		if hostnames == nil || !isHostname {
+			return nil, nil, fmt.Errorf("%w: %s", ErrInvalidEndpoint, epStr)
+		}
+		ips, err := hostnames.addresses(host)
+		if err != nil || len(ips) == 0 {
+			unresolved = append(unresolved, host)
+			continue
+		}
+		for _, ip := range ips {
+			resolved := &ZtNodeInetAddr{IP: ip, Port: port}
+			if _, exists := seenEndpoints[resolved.String()]; exists {
+				continue
+			}
+			seenEndpoints[resolved.String()] = false
+			endpoints = append(endpoints, resolved)
 		}
-		seenEndpoints[endpointKey] = struct{}{}
-		endpoints = append(endpoints, ep)
 	}
 
-	return endpoints, nil
+	if len(endpoints) > ZT_WORLD_MAX_STABLE_ENDPOINTS_PER_ROOT {
+		return nil, nil, fmt.Errorf("%w: %d per root after resolving hostnames, at most %d", ErrMaxEndpointsExceeded, len(endpoints), ZT_WORLD_MAX_STABLE_ENDPOINTS_PER_ROOT)
+	}
+	return endpoints, unresolved, nil
 }
 
 func resolvePlanetMetadata(opts *GenerateOptions) (planetID uint64, birthTime int64, usedRecommendedValues bool, err error) {
@@ -247,23 +421,59 @@ func normalizeEndpoints(values []string) []string {
 	return normalized
 }
 
-func loadSigningKeys(signingKeyPath string) (prevPub, curPub [ZT_C25519_PUBLIC_KEY_LEN]byte, prevPriv, curPriv [ZT_C25519_PRIVATE_KEY_LEN]byte, err error) {
+type signingKeySet struct {
+	prevPub, curPub   [ZT_C25519_PUBLIC_KEY_LEN]byte
+	prevPriv, curPriv [ZT_C25519_PRIVATE_KEY_LEN]byte
+	source            SigningKeySource
+}
+
+// loadSigningKeys reads the keys in signingKeyPath, creating both when neither exists. When
+// only one exists nothing is written, since replacing a key breaks planet updates.
+func loadSigningKeys(signingKeyPath string) (keys signingKeySet, err error) {
 	if strings.TrimSpace(signingKeyPath) == "" {
-		prevPub, prevPriv = GenerateDualPair()
-		curPub = prevPub
-		curPriv = prevPriv
+		keys.prevPub, keys.prevPriv = GenerateDualPair()
+		keys.curPub = keys.prevPub
+		keys.curPriv = keys.prevPriv
+		keys.source = SigningKeysEphemeral
 		return
 	}
 
 	prevPath := filepath.Join(signingKeyPath, "previous.c25519")
 	curPath := filepath.Join(signingKeyPath, "current.c25519")
-	prevPub, curPub, prevPriv, curPriv, err = ReadSigningKeys(prevPath, curPath)
+	keys.source = SigningKeysReused
+	prevExists, err := fileExists(prevPath)
+	if err != nil {
+		return keys, fmt.Errorf("%w: %v", ErrInvalidSigningKeys, err)
+	}
+	curExists, err := fileExists(curPath)
+	if err != nil {
+		return keys, fmt.Errorf("%w: %v", ErrInvalidSigningKeys, err)
+	}
+	if !prevExists && !curExists {
+		if err = CreateSigningKeys(prevPath, curPath); err != nil {
+			return keys, fmt.Errorf("failed to create signing keys: %w", err)
+		}
+		keys.source = SigningKeysCreated
+	}
+
+	keys.prevPub, keys.curPub, keys.prevPriv, keys.curPriv, err = ReadSigningKeys(prevPath, curPath)
 	if err != nil {
 		err = fmt.Errorf("%w: %v", ErrInvalidSigningKeys, err)
 	}
 	return
 }
 
+func fileExists(path string) (bool, error) {
+	_, err := os.Stat(path)
+	if err == nil {
+		return true, nil
+	}
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	return false, err
+}
+
 func generatePlanetID() (uint64, error) {
 	b := make([]byte, 4)
 	for {
@@ -346,3 +556,18 @@ func ReadSigningKeys(prevPath, curPath string) (prevPub, curPub [ZT_C25519_PUBLI
 func EnsureDirectory(path string) error {
 	return os.MkdirAll(filepath.Dir(path), 0755)
 }
+
+// CHeader renders a world as the C header zerotier-one compiles in as its default planet
+func CHeader(data []byte) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "#define ZT_DEFAULT_WORLD_LENGTH %d\n", len(data))
+	b.WriteString("static const unsigned char ZT_DEFAULT_WORLD[ZT_DEFAULT_WORLD_LENGTH] = {")
+	for i, value := range data {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		fmt.Fprintf(&b, "0x%02x", value)
+	}
+	b.WriteString("};\n")
+	return b.String()
+}
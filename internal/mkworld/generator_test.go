@@ -1,16 +1,20 @@
 package mkworld
 
 import (
+	"bytes"
+	"encoding/binary"
 	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 )
 
 const (
 	validIdentityPublic       = "f76fd3000b:0:542c89e34a369c2281ed940d05beeffdbaa66930f17b875e9172e43d0ba30b6a39708507f4d64e66cde4a1040d2a995d01209d685ca6c4adb4a5c880af1e9715"
-	secondValidIdentityPublic = "6a4d8f1c22:0:4c2b7d5e9f4a3c2281ed940d05beeffdbaa66930f17b875e9172e43d0ba30b6a39708507f4d64e66cde4a1040d2a995d01209d685ca6c4adb4a5c880af1e9726"
+	secondValidIdentityPublic = "8e4df28b72:0:ac3d46abe0c21f3cfe7a6c8d6a85cfcffcb82fbd55af6a4d6350657c68200843fa2e16f9418bbd9702cae365f2af5fb4c420908b803a681d4daef6114d78a2d7"
 )
 
 func testRootNode(identity string, endpoints ...string) RootNodeConfig {
@@ -190,6 +194,261 @@ func TestGeneratePlanet_UsesCustomMetadataAndSigningKeys(t *testing.T) {
 	}
 }
 
+func TestGenerateMoon_WritesMoonHeader(t *testing.T) {
+	timestamp := time.Now().UnixMilli()
+	result, err := GenerateMoon(&MoonOptions{
+		RootNodes: []RootNodeConfig{
+			testRootNode(validIdentityPublic, "203.0.113.1/9993"),
+			testRootNode(secondValidIdentityPublic, "203.0.113.2/9993", "2001:db8::2/9993"),
+		},
+		Timestamp: timestamp,
+	})
+	if err != nil {
+		t.Fatalf("GenerateMoon() error = %v", err)
+	}
+
+	if result.MoonID != 0xf76fd3000b {
+		t.Fatalf("MoonID = %x, want f76fd3000b", result.MoonID)
+	}
+	if result.FileName != "000000f76fd3000b.moon" {
+		t.Fatalf("FileName = %q, want 000000f76fd3000b.moon", result.FileName)
+	}
+	if result.RootNodeCount != 2 || result.EndpointCount != 3 {
+		t.Fatalf("RootNodeCount, EndpointCount = %d, %d, want 2, 3", result.RootNodeCount, result.EndpointCount)
+	}
+
+	data := result.MoonData
+	if len(data) < 17 {
+		t.Fatalf("MoonData is %d bytes, too short for a world header", len(data))
+	}
+	if worldType := ZtWorldType(data[0]); worldType != ZT_WORLD_TYPE_MOON {
+		t.Fatalf("world type = %d, want %d", worldType, ZT_WORLD_TYPE_MOON)
+	}
+	if id := binary.BigEndian.Uint64(data[1:9]); id != result.MoonID {
+		t.Fatalf("world id = %x, want %x", id, result.MoonID)
+	}
+	if ts := binary.BigEndian.Uint64(data[9:17]); ts != uint64(timestamp) {
+		t.Fatalf("world timestamp = %d, want %d", ts, timestamp)
+	}
+	rootCountOffset := 17 + ZT_C25519_PUBLIC_KEY_LEN + ZT_C25519_SIGNATURE_LEN
+	if roots := data[rootCountOffset]; roots != 2 {
+		t.Fatalf("root count = %d, want 2", roots)
+	}
+}
+
+func TestGenerateMoon_RejectsInvalidInput(t *testing.T) {
+	tooManyEndpoints := make([]string, 0, ZT_WORLD_MAX_STABLE_ENDPOINTS_PER_ROOT+1)
+	for i := 0; i <= ZT_WORLD_MAX_STABLE_ENDPOINTS_PER_ROOT; i++ {
+		tooManyEndpoints = append(tooManyEndpoints, fmt.Sprintf("203.0.113.%d/9993", i+1))
+	}
+
+	testCases := []struct {
+		name    string
+		options *MoonOptions
+		target  error
+	}{
+		{
+			name:    "missing root nodes",
+			options: &MoonOptions{},
+			target:  ErrNoRootNodes,
+		},
+		{
+			name:    "too many endpoints",
+			options: &MoonOptions{RootNodes: []RootNodeConfig{testRootNode(validIdentityPublic, tooManyEndpoints...)}},
+			target:  ErrMaxEndpointsExceeded,
+		},
+		{
+			name:    "invalid identity",
+			options: &MoonOptions{RootNodes: []RootNodeConfig{testRootNode("invalid", "203.0.113.1/9993")}},
+			target:  ErrInvalidIdentity,
+		},
+		{
+			name:    "negative timestamp",
+			options: &MoonOptions{RootNodes: []RootNodeConfig{testRootNode(validIdentityPublic, "203.0.113.1/9993")}, Timestamp: -1},
+			target:  ErrInvalidBirthTime,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := GenerateMoon(tc.options)
+			if !errors.Is(err, tc.target) {
+				t.Fatalf("GenerateMoon() error = %v, want %v", err, tc.target)
+			}
+		})
+	}
+}
+
+func TestWorldDeserialize_RoundTripsGeneratedWorlds(t *testing.T) {
+	roots := []RootNodeConfig{
+		testRootNode(validIdentityPublic, "203.0.113.1/9993", "2001:db8::1/9993"),
+		testRootNode(secondValidIdentityPublic, "203.0.113.2/9993"),
+	}
+	planet, err := GeneratePlanet(&GenerateOptions{RootNodes: roots, RecommendValues: true})
+	if err != nil {
+		t.Fatalf("GeneratePlanet() error = %v", err)
+	}
+	moon, err := GenerateMoon(&MoonOptions{RootNodes: roots})
+	if err != nil {
+		t.Fatalf("GenerateMoon() error = %v", err)
+	}
+
+	testCases := []struct {
+		name      string
+		data      []byte
+		worldType ZtWorldType
+		id        uint64
+		timestamp int64
+	}{
+		{name: "planet", data: planet.PlanetData, worldType: ZT_WORLD_TYPE_PLANET, id: planet.PlanetID, timestamp: planet.BirthTime},
+		{name: "moon", data: moon.MoonData, worldType: ZT_WORLD_TYPE_MOON, id: moon.MoonID, timestamp: moon.Timestamp},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			world := &ZtWorld{}
+			sig, err := world.Deserialize(tc.data)
+			if err != nil {
+				t.Fatalf("Deserialize() error = %v", err)
+			}
+			if world.Type != tc.worldType || uint64(world.ID) != tc.id || world.Timestamp != uint64(tc.timestamp) {
+				t.Fatalf("header = %d/%d/%d, want %d/%d/%d", world.Type, world.ID, world.Timestamp, tc.worldType, tc.id, tc.timestamp)
+			}
+
+			again, err := world.Serialize(false, sig)
+			if err != nil {
+				t.Fatalf("Serialize() error = %v", err)
+			}
+			if !bytes.Equal(again, tc.data) {
+				t.Fatal("serializing the parsed world does not reproduce the file")
+			}
+
+			inspected, err := InspectWorld(tc.data)
+			if err != nil {
+				t.Fatalf("InspectWorld() error = %v", err)
+			}
+			if len(inspected.RootNodes) != len(roots) || inspected.EndpointCount != 3 {
+				t.Fatalf("InspectWorld() found %d roots and %d endpoints, want %d and 3", len(inspected.RootNodes), inspected.EndpointCount, len(roots))
+			}
+			for i, root := range inspected.RootNodes {
+				if root.IdentityPublic != roots[i].IdentityPublic {
+					t.Fatalf("root %d identity = %q, want %q", i, root.IdentityPublic, roots[i].IdentityPublic)
+				}
+				if strings.Join(root.Endpoints, ",") != strings.Join(roots[i].Endpoints, ",") {
+					t.Fatalf("root %d endpoints = %v, want %v", i, root.Endpoints, roots[i].Endpoints)
+				}
+			}
+		})
+	}
+}
+
+func TestWorldDeserialize_ReportsOffsetOfMalformedData(t *testing.T) {
+	planet, err := GeneratePlanet(&GenerateOptions{
+		RootNodes:       []RootNodeConfig{testRootNode(validIdentityPublic, "203.0.113.1/9993")},
+		RecommendValues: true,
+	})
+	if err != nil {
+		t.Fatalf("GeneratePlanet() error = %v", err)
+	}
+	endpointFamilyOffset := 1 + 8 + 8 + ZT_C25519_PUBLIC_KEY_LEN + ZT_C25519_SIGNATURE_LEN + 1 + 5 + 1 + ZT_C25519_PUBLIC_KEY_LEN + 1 + 1
+
+	badFamily := bytes.Clone(planet.PlanetData)
+	badFamily[endpointFamilyOffset] = 9
+
+	testCases := []struct {
+		name   string
+		data   []byte
+		offset int
+	}{
+		{name: "unknown type", data: append([]byte{2}, planet.PlanetData[1:]...), offset: 0},
+		{name: "truncated", data: planet.PlanetData[:40], offset: 17},
+		{name: "bad endpoint family", data: badFamily, offset: endpointFamilyOffset},
+		{name: "trailing bytes", data: append(bytes.Clone(planet.PlanetData), 0), offset: len(planet.PlanetData)},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := (&ZtWorld{}).Deserialize(tc.data)
+			var formatErr *WorldFormatError
+			if !errors.As(err, &formatErr) || !errors.Is(err, ErrMalformedWorld) {
+				t.Fatalf("Deserialize() error = %v, want a WorldFormatError", err)
+			}
+			if formatErr.Offset != tc.offset {
+				t.Fatalf("Offset = %d, want %d (%v)", formatErr.Offset, tc.offset, err)
+			}
+		})
+	}
+}
+
+func TestGeneratePlanet_PersistsAndReusesSigningKeys(t *testing.T) {
+	keyDir := t.TempDir()
+	generate := func() (*GeneratedPlanet, *InspectedWorld) {
+		t.Helper()
+		result, err := GeneratePlanet(&GenerateOptions{
+			RootNodes:       []RootNodeConfig{testRootNode(validIdentityPublic, "203.0.113.1/9993")},
+			SigningKeyPath:  keyDir,
+			RecommendValues: true,
+		})
+		if err != nil {
+			t.Fatalf("GeneratePlanet() error = %v", err)
+		}
+		inspected, err := InspectWorld(result.PlanetData)
+		if err != nil {
+			t.Fatalf("InspectWorld() error = %v", err)
+		}
+		return result, inspected
+	}
+
+	first, firstWorld := generate()
+	if first.SigningKeys != SigningKeysCreated {
+		t.Fatalf("first SigningKeys = %q, want %q", first.SigningKeys, SigningKeysCreated)
+	}
+	if _, err := os.Stat(filepath.Join(keyDir, "current.c25519")); err != nil {
+		t.Fatalf("current.c25519 was not written: %v", err)
+	}
+
+	second, secondWorld := generate()
+	if second.SigningKeys != SigningKeysReused {
+		t.Fatalf("second SigningKeys = %q, want %q", second.SigningKeys, SigningKeysReused)
+	}
+	if firstWorld.UpdatesMustBeSignedBy != secondWorld.UpdatesMustBeSignedBy {
+		t.Fatal("consecutive planets are signed by different keys")
+	}
+
+	ephemeral, err := GeneratePlanet(&GenerateOptions{
+		RootNodes:       []RootNodeConfig{testRootNode(validIdentityPublic, "203.0.113.1/9993")},
+		RecommendValues: true,
+	})
+	if err != nil {
+		t.Fatalf("GeneratePlanet() error = %v", err)
+	}
+	ephemeralWorld, err := InspectWorld(ephemeral.PlanetData)
+	if err != nil {
+		t.Fatalf("InspectWorld() error = %v", err)
+	}
+	if ephemeral.SigningKeys != SigningKeysEphemeral || ephemeralWorld.UpdatesMustBeSignedBy == firstWorld.UpdatesMustBeSignedBy {
+		t.Fatal("a planet without a key directory must use throwaway keys")
+	}
+}
+
+func TestGeneratePlanet_RefusesToReplaceAHalfPresentKeyPair(t *testing.T) {
+	keyDir := t.TempDir()
+	pub, priv := GenerateDualPair()
+	if err := writeKeyFile(filepath.Join(keyDir, "previous.c25519"), pub, priv); err != nil {
+		t.Fatalf("write previous key: %v", err)
+	}
+
+	_, err := GeneratePlanet(&GenerateOptions{
+		RootNodes:       []RootNodeConfig{testRootNode(validIdentityPublic, "203.0.113.1/9993")},
+		SigningKeyPath:  keyDir,
+		RecommendValues: true,
+	})
+	if !errors.Is(err, ErrInvalidSigningKeys) {
+		t.Fatalf("GeneratePlanet() error = %v, want %v", err, ErrInvalidSigningKeys)
+	}
+	if _, statErr := os.Stat(filepath.Join(keyDir, "current.c25519")); !os.IsNotExist(statErr) {
+		t.Fatal("current.c25519 must not be created next to an existing previous key")
+	}
+}
+
 func TestReadSigningKeys_RejectsInvalidLength(t *testing.T) {
 	tempDir := t.TempDir()
 	prevPath := filepath.Join(tempDir, "previous.c25519")
@@ -0,0 +1,92 @@
+/*
+ * Tairitsu - A ZeroTier Network Controller Manager
+ * Copyright (C) 2025 Patmeow Lab
+ * SPDX-License-Identifier: GPL-3.0-only
+ */
+
+package mkworld
+
+import (
+	"crypto/sha512"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+
+	"golang.org/x/crypto/salsa20/salsa"
+)
+
+const (
+	// ZT_IDENTITY_GEN_MEMORY is the scratch memory of the identity hash
+	ZT_IDENTITY_GEN_MEMORY = 2097152
+	// ZT_IDENTITY_GEN_HASHCASH_FIRST_BYTE_LESS_THAN bounds the first hash byte of a valid identity
+	ZT_IDENTITY_GEN_HASHCASH_FIRST_BYTE_LESS_THAN = 17
+	// ZT_ADDRESS_RESERVED_PREFIX is the first byte of addresses no identity may use
+	ZT_ADDRESS_RESERVED_PREFIX = 0xff
+)
+
+// salsa20Stream continues one Salsa20/20 key stream over calls, like ZeroTier's Salsa20::crypt20
+type salsa20Stream struct {
+	key     [32]byte
+	counter [16]byte
+}
+
+func newSalsa20Stream(key []byte, iv []byte) *salsa20Stream {
+	s := &salsa20Stream{}
+	copy(s.key[:], key)
+	copy(s.counter[:8], iv)
+	return s
+}
+
+// crypt20 XORs data, whose length is a multiple of 64, with the next blocks of the key stream
+func (s *salsa20Stream) crypt20(data []byte) {
+	salsa.XORKeyStream(data, data, &s.counter, &s.key)
+	block := binary.LittleEndian.Uint64(s.counter[8:])
+	binary.LittleEndian.PutUint64(s.counter[8:], block+uint64(len(data)/64))
+}
+
+// ComputeZeroTierIdentityMemoryHardHash computes the hash a ZeroTier identity derives its
+// address from. The address is the last five bytes of the digest, and the identity is only
+// valid when the first byte is below ZT_IDENTITY_GEN_HASHCASH_FIRST_BYTE_LESS_THAN.
+func ComputeZeroTierIdentityMemoryHardHash(publicKey []byte) [64]byte {
+	digest := sha512.Sum512(publicKey)
+
+	// Fill the scratch memory sequentially, so it cannot be computed in parts
+	genmem := make([]byte, ZT_IDENTITY_GEN_MEMORY)
+	s20 := newSalsa20Stream(digest[:32], digest[32:40])
+	s20.crypt20(genmem[:64])
+	for i := 64; i < ZT_IDENTITY_GEN_MEMORY; i += 64 {
+		copy(genmem[i:i+64], genmem[i-64:i])
+		s20.crypt20(genmem[i : i+64])
+	}
+
+	// Render the final digest using the scratch memory as a lookup table
+	const words = ZT_IDENTITY_GEN_MEMORY / 8
+	for i := 0; i < words; i += 2 {
+		idx1 := binary.BigEndian.Uint64(genmem[i*8:]) % 8
+		idx2 := binary.BigEndian.Uint64(genmem[(i+1)*8:]) % words
+		var tmp [8]byte
+		copy(tmp[:], genmem[idx2*8:idx2*8+8])
+		copy(genmem[idx2*8:idx2*8+8], digest[idx1*8:idx1*8+8])
+		copy(digest[idx1*8:idx1*8+8], tmp[:])
+		s20.crypt20(digest[:])
+	}
+	return digest
+}
+
+// ValidateIdentity checks that the public key of identity derives its address, the way nodes
+// validate the roots of a world. A mistyped address or key fails with ErrIdentityMismatch.
+func ValidateIdentity(identity *ZtWorldPlanetNodeIdentity) error {
+	address := identity.ZtNodeAddressString()
+	if identity.ZtNodeAddress == [5]byte{} || identity.ZtNodeAddress[0] == ZT_ADDRESS_RESERVED_PREFIX {
+		return fmt.Errorf("%w: address %s is reserved", ErrIdentityMismatch, address)
+	}
+
+	digest := ComputeZeroTierIdentityMemoryHardHash(identity.PublicKey[:])
+	if derived := hex.EncodeToString(digest[59:64]); derived != address {
+		return fmt.Errorf("%w: public key belongs to address %s, not %s", ErrIdentityMismatch, derived, address)
+	}
+	if digest[0] >= ZT_IDENTITY_GEN_HASHCASH_FIRST_BYTE_LESS_THAN {
+		return fmt.Errorf("%w: public key of %s was not generated by ZeroTier", ErrIdentityMismatch, address)
+	}
+	return nil
+}
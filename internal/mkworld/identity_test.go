@@ -0,0 +1,74 @@
+package mkworld
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// knownGoodIdentityPublic is the public part of the known good identity in ZeroTier's selftest
+const knownGoodIdentityPublic = "8e4df28b72:0:ac3d46abe0c21f3cfe7a6c8d6a85cfcffcb82fbd55af6a4d6350657c68200843fa2e16f9418bbd9702cae365f2af5fb4c420908b803a681d4daef6114d78a2d7"
+
+func TestValidateIdentity_AcceptsRealIdentities(t *testing.T) {
+	for _, identityPublic := range []string{knownGoodIdentityPublic, validIdentityPublic} {
+		identity, err := ParseIdentityPublic(identityPublic)
+		if err != nil {
+			t.Fatalf("ParseIdentityPublic() error = %v", err)
+		}
+		if err := ValidateIdentity(identity); err != nil {
+			t.Fatalf("ValidateIdentity(%s) error = %v", identity.ZtNodeAddressString(), err)
+		}
+	}
+}
+
+func TestValidateIdentity_RejectsMistypedIdentities(t *testing.T) {
+	testCases := []struct {
+		name           string
+		identityPublic string
+		message        string
+	}{
+		{
+			name:           "mistyped address",
+			identityPublic: "8e4df28b73" + knownGoodIdentityPublic[10:],
+			message:        "belongs to address 8e4df28b72",
+		},
+		{
+			name:           "mistyped public key",
+			identityPublic: knownGoodIdentityPublic[:len(knownGoodIdentityPublic)-1] + "8",
+		},
+		{
+			name:           "reserved address",
+			identityPublic: "ff4df28b72" + knownGoodIdentityPublic[10:],
+			message:        "reserved",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			identity, err := ParseIdentityPublic(tc.identityPublic)
+			if err != nil {
+				t.Fatalf("ParseIdentityPublic() error = %v", err)
+			}
+			err = ValidateIdentity(identity)
+			if !errors.Is(err, ErrIdentityMismatch) {
+				t.Fatalf("ValidateIdentity() error = %v, want %v", err, ErrIdentityMismatch)
+			}
+			if !strings.Contains(err.Error(), tc.message) {
+				t.Fatalf("ValidateIdentity() error = %q, want it to mention %q", err, tc.message)
+			}
+		})
+	}
+}
+
+func TestGeneratePlanet_SkipValidationAcceptsMismatchedIdentities(t *testing.T) {
+	roots := []RootNodeConfig{testRootNode("8e4df28b73"+knownGoodIdentityPublic[10:], "203.0.113.1/9993")}
+
+	_, err := GeneratePlanet(&GenerateOptions{RootNodes: roots, RecommendValues: true})
+	if !errors.Is(err, ErrIdentityMismatch) {
+		t.Fatalf("GeneratePlanet() error = %v, want %v", err, ErrIdentityMismatch)
+	}
+
+	if _, err := GeneratePlanet(&GenerateOptions{RootNodes: roots, RecommendValues: true, SkipValidation: true}); err != nil {
+		t.Fatalf("GeneratePlanet(SkipValidation) error = %v", err)
+	}
+}
@@ -0,0 +1,61 @@
+/*
+ * Tairitsu - A ZeroTier Network Controller Manager
+ * Copyright (C) 2025 Patmeow Lab
+ * SPDX-License-Identifier: GPL-3.0-only
+ */
+
+package mkworld
+
+// InspectedWorld describes a parsed planet or moon. RootNodes uses the generator's input
+// format, so an existing world can be regenerated with changes.
+type InspectedWorld struct {
+	Type                  ZtWorldType
+	ID                    uint64
+	Timestamp             int64
+	UpdatesMustBeSignedBy [ZT_C25519_PUBLIC_KEY_LEN]byte
+	Signature             [ZT_C25519_SIGNATURE_LEN]byte
+	RootNodes             []RootNodeConfig
+	EndpointCount         int
+}
+
+// InspectWorld parses a planet or moon file. Malformed data yields a *WorldFormatError.
+func InspectWorld(data []byte) (*InspectedWorld, error) {
+	world := &ZtWorld{}
+	sig, err := world.Deserialize(data)
+	if err != nil {
+		return nil, err
+	}
+
+	inspected := &InspectedWorld{
+		Type:                  world.Type,
+		ID:                    uint64(world.ID),
+		Timestamp:             int64(world.Timestamp),
+		UpdatesMustBeSignedBy: world.PublicKeyMustBeSignedByNextTime,
+		Signature:             sig,
+		RootNodes:             make([]RootNodeConfig, 0, len(world.Nodes)),
+	}
+	for _, node := range world.Nodes {
+		endpoints := make([]string, 0, len(node.Endpoints))
+		for _, endpoint := range node.Endpoints {
+			endpoints = append(endpoints, endpoint.String())
+		}
+		inspected.RootNodes = append(inspected.RootNodes, RootNodeConfig{
+			IdentityPublic: node.Identity.String(),
+			Endpoints:      endpoints,
+		})
+		inspected.EndpointCount += len(endpoints)
+	}
+	return inspected, nil
+}
+
+// String names the world type the way zerotier-one does
+func (t ZtWorldType) String() string {
+	switch t {
+	case ZT_WORLD_TYPE_PLANET:
+		return "planet"
+	case ZT_WORLD_TYPE_MOON:
+		return "moon"
+	default:
+		return "unknown"
+	}
+}
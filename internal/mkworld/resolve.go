@@ -0,0 +1,87 @@
+/*
+ * Tairitsu - A ZeroTier Network Controller Manager
+ * Copyright (C) 2025 Patmeow Lab
+ * SPDX-License-Identifier: GPL-3.0-only
+ */
+
+package mkworld
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+)
+
+// ResolveFamily selects which addresses of a hostname endpoint become endpoints
+type ResolveFamily string
+
+const (
+	ResolveIPv4 ResolveFamily = "ipv4"
+	ResolveIPv6 ResolveFamily = "ipv6"
+	ResolveBoth ResolveFamily = "both"
+)
+
+// ParseResolveFamily reads a family name; an empty name means both families
+func ParseResolveFamily(value string) (ResolveFamily, error) {
+	switch family := ResolveFamily(strings.ToLower(strings.TrimSpace(value))); family {
+	case "":
+		return ResolveBoth, nil
+	case ResolveIPv4, ResolveIPv6, ResolveBoth:
+		return family, nil
+	default:
+		return "", fmt.Errorf("%w: %q, expected ipv4, ipv6 or both", ErrInvalidResolveFamily, value)
+	}
+}
+
+// HostnameResolution lets endpoints name a host, such as "root1.example.com/9993". The host is
+// resolved when the world is generated and adds one endpoint per address of Family.
+type HostnameResolution struct {
+	Family ResolveFamily
+	// Lookup resolves a hostname; nil uses net.LookupIP
+	Lookup func(host string) ([]net.IP, error)
+}
+
+func (r *HostnameResolution) lookup(host string) ([]net.IP, error) {
+	if r.Lookup != nil {
+		return r.Lookup(host)
+	}
+	return net.LookupIP(host)
+}
+
+// addresses returns the addresses of host in the configured family, IPv4 first
+func (r *HostnameResolution) addresses(host string) ([]net.IP, error) {
+	ips, err := r.lookup(host)
+	if err != nil {
+		return nil, err
+	}
+	var ipv4, ipv6 []net.IP
+	for _, ip := range ips {
+		if ip.To4() != nil {
+			ipv4 = append(ipv4, ip)
+		} else if ip.To16() != nil {
+			ipv6 = append(ipv6, ip)
+		}
+	}
+	switch r.Family {
+	case ResolveIPv4:
+		return ipv4, nil
+	case ResolveIPv6:
+		return ipv6, nil
+	default:
+		return append(ipv4, ipv6...), nil
+	}
+}
+
+// splitHostnameEndpoint splits "host/port" when host looks like a DNS name
+func splitHostnameEndpoint(value string) (string, uint16, bool) {
+	host, portValue, found := strings.Cut(value, "/")
+	if !found || host == "" || net.ParseIP(host) != nil || strings.ContainsAny(host, "/: ") {
+		return "", 0, false
+	}
+	port, err := strconv.ParseUint(portValue, 10, 16)
+	if err != nil {
+		return "", 0, false
+	}
+	return host, uint16(port), true
+}
@@ -0,0 +1,157 @@
+package mkworld
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"testing"
+)
+
+// staticLookup resolves the hostnames in records and fails for any other
+func staticLookup(records map[string][]string) func(string) ([]net.IP, error) {
+	return func(host string) ([]net.IP, error) {
+		values, ok := records[host]
+		if !ok {
+			return nil, fmt.Errorf("lookup %s: no such host", host)
+		}
+		ips := make([]net.IP, 0, len(values))
+		for _, value := range values {
+			ips = append(ips, net.ParseIP(value))
+		}
+		return ips, nil
+	}
+}
+
+func endpointStrings(endpoints []*ZtNodeInetAddr) string {
+	values := make([]string, 0, len(endpoints))
+	for _, endpoint := range endpoints {
+		values = append(values, endpoint.String())
+	}
+	return strings.Join(values, ",")
+}
+
+func TestParseRootNodes_ResolvesHostnamesByFamily(t *testing.T) {
+	lookup := staticLookup(map[string][]string{
+		"root1.example.com": {"2001:db8::10", "203.0.113.10", "203.0.113.11"},
+	})
+	roots := []RootNodeConfig{testRootNode(validIdentityPublic, "198.51.100.1/9993", "root1.example.com/9993")}
+
+	testCases := []struct {
+		family ResolveFamily
+		want   string
+	}{
+		{ResolveBoth, "198.51.100.1/9993,203.0.113.10/9993,203.0.113.11/9993,2001:db8::10/9993"},
+		{ResolveIPv4, "198.51.100.1/9993,203.0.113.10/9993,203.0.113.11/9993"},
+		{ResolveIPv6, "198.51.100.1/9993,2001:db8::10/9993"},
+	}
+	for _, tc := range testCases {
+		t.Run(string(tc.family), func(t *testing.T) {
+			nodes, total, err := parseRootNodes(roots, &HostnameResolution{Family: tc.family, Lookup: lookup}, true)
+			if err != nil {
+				t.Fatalf("parseRootNodes() error = %v", err)
+			}
+			if got := endpointStrings(nodes[0].Endpoints); got != tc.want {
+				t.Fatalf("endpoints = %s, want %s", got, tc.want)
+			}
+			if total != strings.Count(tc.want, ",")+1 {
+				t.Fatalf("total endpoints = %d, want %d", total, strings.Count(tc.want, ",")+1)
+			}
+		})
+	}
+}
+
+func TestParseRootNodes_KeepsAResolvedDuplicateOnce(t *testing.T) {
+	lookup := staticLookup(map[string][]string{
+		"root1.example.com": {"203.0.113.10"},
+		"ipv4.example.com":  {"203.0.113.10"},
+	})
+	roots := []RootNodeConfig{testRootNode(validIdentityPublic, "root1.example.com/9993", "ipv4.example.com/9993", "203.0.113.10/9993")}
+
+	nodes, _, err := parseRootNodes(roots, &HostnameResolution{Lookup: lookup}, true)
+	if err != nil {
+		t.Fatalf("parseRootNodes() error = %v", err)
+	}
+	if got := endpointStrings(nodes[0].Endpoints); got != "203.0.113.10/9993" {
+		t.Fatalf("endpoints = %s, want 203.0.113.10/9993", got)
+	}
+}
+
+func TestParseRootNodes_NamesEveryUnresolvedHostname(t *testing.T) {
+	lookup := staticLookup(map[string][]string{"root1.example.com": {"203.0.113.10"}})
+	roots := []RootNodeConfig{
+		testRootNode(validIdentityPublic, "root1.example.com/9993", "gone.example.com/9993"),
+		testRootNode(secondValidIdentityPublic, "missing.example.com/9993"),
+	}
+
+	_, _, err := parseRootNodes(roots, &HostnameResolution{Lookup: lookup}, true)
+	if !errors.Is(err, ErrUnresolvableHostname) {
+		t.Fatalf("parseRootNodes() error = %v, want %v", err, ErrUnresolvableHostname)
+	}
+	for _, host := range []string{"gone.example.com", "missing.example.com"} {
+		if !strings.Contains(err.Error(), host) {
+			t.Fatalf("error %q does not name %s", err, host)
+		}
+	}
+	if strings.Contains(err.Error(), "root1.example.com") {
+		t.Fatalf("error %q names a resolved hostname", err)
+	}
+
+	// A hostname without addresses of the wanted family did not resolve either
+	_, _, err = parseRootNodes(roots[:1], &HostnameResolution{Family: ResolveIPv6, Lookup: lookup}, true)
+	if !errors.Is(err, ErrUnresolvableHostname) || !strings.Contains(err.Error(), "root1.example.com") {
+		t.Fatalf("parseRootNodes() error = %v, want root1.example.com unresolved", err)
+	}
+}
+
+func TestParseRootNodes_LimitsEndpointsAfterResolving(t *testing.T) {
+	addresses := make([]string, 0, ZT_WORLD_MAX_STABLE_ENDPOINTS_PER_ROOT)
+	for i := 0; i < ZT_WORLD_MAX_STABLE_ENDPOINTS_PER_ROOT; i++ {
+		addresses = append(addresses, fmt.Sprintf("203.0.113.%d", i+1))
+	}
+	lookup := staticLookup(map[string][]string{"root1.example.com": addresses})
+
+	_, _, err := parseRootNodes([]RootNodeConfig{testRootNode(validIdentityPublic, "root1.example.com/9993")}, &HostnameResolution{Lookup: lookup}, true)
+	if err != nil {
+		t.Fatalf("parseRootNodes() error = %v, want %d endpoints to fit", err, ZT_WORLD_MAX_STABLE_ENDPOINTS_PER_ROOT)
+	}
+
+	_, _, err = parseRootNodes([]RootNodeConfig{testRootNode(validIdentityPublic, "198.51.100.1/9993", "root1.example.com/9993")}, &HostnameResolution{Lookup: lookup}, true)
+	if !errors.Is(err, ErrMaxEndpointsExceeded) {
+		t.Fatalf("parseRootNodes() error = %v, want %v", err, ErrMaxEndpointsExceeded)
+	}
+}
+
+func TestGeneratePlanet_RejectsHostnamesWithoutResolution(t *testing.T) {
+	_, err := GeneratePlanet(&GenerateOptions{
+		RootNodes:       []RootNodeConfig{testRootNode(validIdentityPublic, "root1.example.com/9993")},
+		RecommendValues: true,
+	})
+	if !errors.Is(err, ErrInvalidEndpoint) {
+		t.Fatalf("GeneratePlanet() error = %v, want %v", err, ErrInvalidEndpoint)
+	}
+
+	planet, err := GeneratePlanet(&GenerateOptions{
+		RootNodes:       []RootNodeConfig{testRootNode(validIdentityPublic, "root1.example.com/9993")},
+		RecommendValues: true,
+		Hostnames:       &HostnameResolution{Lookup: staticLookup(map[string][]string{"root1.example.com": {"203.0.113.10", "2001:db8::10"}})},
+	})
+	if err != nil {
+		t.Fatalf("GeneratePlanet() error = %v", err)
+	}
+	if planet.EndpointCount != 2 {
+		t.Fatalf("endpointCount = %d, want 2", planet.EndpointCount)
+	}
+}
+
+func TestParseResolveFamily(t *testing.T) {
+	for value, want := range map[string]ResolveFamily{"": ResolveBoth, "IPv4": ResolveIPv4, "ipv6": ResolveIPv6, "both": ResolveBoth} {
+		got, err := ParseResolveFamily(value)
+		if err != nil || got != want {
+			t.Fatalf("ParseResolveFamily(%q) = %q, %v; want %q", value, got, err, want)
+		}
+	}
+	if _, err := ParseResolveFamily("ipv5"); !errors.Is(err, ErrInvalidResolveFamily) {
+		t.Fatalf("ParseResolveFamily(ipv5) error = %v, want %v", err, ErrInvalidResolveFamily)
+	}
+}
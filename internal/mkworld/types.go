@@ -9,6 +9,7 @@ package mkworld
 import (
 	"encoding/binary"
 	"encoding/hex"
+	"fmt"
 	"net"
 	"strconv"
 	"strings"
@@ -18,6 +19,7 @@ type ZtWorldType uint8
 
 const (
 	ZT_WORLD_TYPE_PLANET ZtWorldType = 1
+	ZT_WORLD_TYPE_MOON   ZtWorldType = 127
 )
 
 type ZtWorldID uint64
@@ -53,6 +55,11 @@ func (a *ZtNodeInetAddr) FromString(ipport string) error {
 	return nil
 }
 
+// String formats the address the way FromString reads it
+func (a *ZtNodeInetAddr) String() string {
+	return a.IP.String() + "/" + strconv.FormatUint(uint64(a.Port), 10)
+}
+
 func (a *ZtNodeInetAddr) Serialize() ([]byte, error) {
 	var buf []byte
 
@@ -77,6 +84,11 @@ func (id *ZtWorldPlanetNodeIdentity) ZtNodeAddressString() string {
 	return hex.EncodeToString(id.ZtNodeAddress[:])
 }
 
+// String formats the identity like identity.public
+func (id *ZtWorldPlanetNodeIdentity) String() string {
+	return id.ZtNodeAddressString() + ":0:" + hex.EncodeToString(id.PublicKey[:])
+}
+
 func (id *ZtWorldPlanetNodeIdentity) Serialize() ([]byte, error) {
 	var buf []byte
 	buf = append(buf, id.ZtNodeAddress[:]...)
@@ -156,6 +168,11 @@ func (w *ZtWorld) Serialize(forSign bool, c25519sig [ZT_C25519_SIGNATURE_LEN]byt
 		buf = append(buf, nBytes...)
 	}
 
+	if w.Type == ZT_WORLD_TYPE_MOON {
+		// Length of the attached dictionary, which zerotier-one does not use yet
+		buf = binary.BigEndian.AppendUint16(buf, 0)
+	}
+
 	if forSign {
 		buf = binary.BigEndian.AppendUint64(buf, 0xf7f7f7f7f7f7f7f7)
 	}
@@ -166,3 +183,202 @@ func (w *ZtWorld) Serialize(forSign bool, c25519sig [ZT_C25519_SIGNATURE_LEN]byt
 
 	return buf, nil
 }
+
+// WorldFormatError reports where a serialized world stopped making sense
+type WorldFormatError struct {
+	Offset int
+	Reason string
+}
+
+func (e *WorldFormatError) Error() string {
+	return fmt.Sprintf("%s at byte %d: %s", ErrMalformedWorld, e.Offset, e.Reason)
+}
+
+func (e *WorldFormatError) Unwrap() error {
+	return ErrMalformedWorld
+}
+
+// worldReader walks serialized world data, remembering the offset for error messages
+type worldReader struct {
+	data   []byte
+	offset int
+}
+
+func (r *worldReader) fail(reason string, args ...any) error {
+	return &WorldFormatError{Offset: r.offset, Reason: fmt.Sprintf(reason, args...)}
+}
+
+func (r *worldReader) next(n int, field string) ([]byte, error) {
+	if len(r.data)-r.offset < n {
+		return nil, r.fail("truncated %s: need %d bytes, %d left", field, n, len(r.data)-r.offset)
+	}
+	value := r.data[r.offset : r.offset+n]
+	r.offset += n
+	return value, nil
+}
+
+func (r *worldReader) byte(field string) (byte, error) {
+	value, err := r.next(1, field)
+	if err != nil {
+		return 0, err
+	}
+	return value[0], nil
+}
+
+func (r *worldReader) uint64(field string) (uint64, error) {
+	value, err := r.next(8, field)
+	if err != nil {
+		return 0, err
+	}
+	return binary.BigEndian.Uint64(value), nil
+}
+
+// Deserialize reads a planet or moon written by Serialize or by zerotier-one and returns its
+// signature. The signature is not verified.
+func (w *ZtWorld) Deserialize(data []byte) ([ZT_C25519_SIGNATURE_LEN]byte, error) {
+	var sig [ZT_C25519_SIGNATURE_LEN]byte
+	if len(data) > ZT_WORLD_MAX_SERIALIZED_LENGTH {
+		return sig, ErrSerializedDataTooLarge
+	}
+	r := &worldReader{data: data}
+
+	worldType, err := r.byte("world type")
+	if err != nil {
+		return sig, err
+	}
+	if ZtWorldType(worldType) != ZT_WORLD_TYPE_PLANET && ZtWorldType(worldType) != ZT_WORLD_TYPE_MOON {
+		r.offset--
+		return sig, r.fail("unknown world type %d", worldType)
+	}
+	id, err := r.uint64("world id")
+	if err != nil {
+		return sig, err
+	}
+	timestamp, err := r.uint64("timestamp")
+	if err != nil {
+		return sig, err
+	}
+	signingKey, err := r.next(ZT_C25519_PUBLIC_KEY_LEN, "update signing key")
+	if err != nil {
+		return sig, err
+	}
+	signature, err := r.next(ZT_C25519_SIGNATURE_LEN, "signature")
+	if err != nil {
+		return sig, err
+	}
+
+	rootCount, err := r.byte("root count")
+	if err != nil {
+		return sig, err
+	}
+	if int(rootCount) > ZT_WORLD_MAX_ROOTS {
+		r.offset--
+		return sig, r.fail("%d roots, at most %d are allowed", rootCount, ZT_WORLD_MAX_ROOTS)
+	}
+	nodes := make([]*ZtWorldPlanetNode, 0, rootCount)
+	for i := 0; i < int(rootCount); i++ {
+		node, err := r.rootNode()
+		if err != nil {
+			return sig, err
+		}
+		nodes = append(nodes, node)
+	}
+
+	if ZtWorldType(worldType) == ZT_WORLD_TYPE_MOON {
+		value, err := r.next(2, "moon dictionary length")
+		if err != nil {
+			return sig, err
+		}
+		if _, err := r.next(int(binary.BigEndian.Uint16(value)), "moon dictionary"); err != nil {
+			return sig, err
+		}
+	}
+	if r.offset != len(data) {
+		return sig, r.fail("%d unexpected trailing bytes", len(data)-r.offset)
+	}
+
+	w.Type = ZtWorldType(worldType)
+	w.ID = ZtWorldID(id)
+	w.Timestamp = timestamp
+	copy(w.PublicKeyMustBeSignedByNextTime[:], signingKey)
+	w.Nodes = nodes
+	copy(sig[:], signature)
+	return sig, nil
+}
+
+func (r *worldReader) rootNode() (*ZtWorldPlanetNode, error) {
+	identity := &ZtWorldPlanetNodeIdentity{}
+	address, err := r.next(5, "root address")
+	if err != nil {
+		return nil, err
+	}
+	copy(identity.ZtNodeAddress[:], address)
+
+	identityType, err := r.byte("identity type")
+	if err != nil {
+		return nil, err
+	}
+	if identityType != 0 {
+		r.offset--
+		return nil, r.fail("unsupported identity type %d", identityType)
+	}
+	publicKey, err := r.next(ZT_C25519_PUBLIC_KEY_LEN, "root public key")
+	if err != nil {
+		return nil, err
+	}
+	copy(identity.PublicKey[:], publicKey)
+
+	// A root identity may carry its private key; it is skipped rather than kept
+	privateKeyLen, err := r.byte("private key length")
+	if err != nil {
+		return nil, err
+	}
+	if _, err := r.next(int(privateKeyLen), "root private key"); err != nil {
+		return nil, err
+	}
+
+	endpointCount, err := r.byte("endpoint count")
+	if err != nil {
+		return nil, err
+	}
+	if int(endpointCount) > ZT_WORLD_MAX_STABLE_ENDPOINTS_PER_ROOT {
+		r.offset--
+		return nil, r.fail("%d endpoints, at most %d are allowed per root", endpointCount, ZT_WORLD_MAX_STABLE_ENDPOINTS_PER_ROOT)
+	}
+	endpoints := make([]*ZtNodeInetAddr, 0, endpointCount)
+	for i := 0; i < int(endpointCount); i++ {
+		endpoint, err := r.endpoint()
+		if err != nil {
+			return nil, err
+		}
+		endpoints = append(endpoints, endpoint)
+	}
+
+	return &ZtWorldPlanetNode{Identity: identity, Endpoints: endpoints}, nil
+}
+
+func (r *worldReader) endpoint() (*ZtNodeInetAddr, error) {
+	family, err := r.byte("endpoint family")
+	if err != nil {
+		return nil, err
+	}
+	var ipLen int
+	switch family {
+	case 4:
+		ipLen = net.IPv4len
+	case 6:
+		ipLen = net.IPv6len
+	default:
+		r.offset--
+		return nil, r.fail("unsupported endpoint family %d", family)
+	}
+	ip, err := r.next(ipLen, "endpoint address")
+	if err != nil {
+		return nil, err
+	}
+	port, err := r.next(2, "endpoint port")
+	if err != nil {
+		return nil, err
+	}
+	return &ZtNodeInetAddr{IP: net.IP(append([]byte(nil), ip...)), Port: binary.BigEndian.Uint16(port)}, nil
+}
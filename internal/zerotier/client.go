@@ -2,16 +2,21 @@ package zerotier
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 	"sort"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/GT-610/tairitsu/internal/app/config"
 	"github.com/GT-610/tairitsu/internal/app/logger"
+	"go.uber.org/zap"
 )
 
 // Client is a ZeroTier controller API client.
@@ -19,6 +24,15 @@ type Client struct {
 	BaseURL    string
 	Token      string
 	HTTPClient *http.Client
+	// TokenSource, when set, is asked for the current token after the controller answers 401,
+	// e.g. to re-read a rotated authtoken.secret. A request is retried once with a new token.
+	TokenSource func() (string, error)
+
+	tokenMutex sync.RWMutex
+
+	// version is the controller version from the latest status read
+	versionMutex sync.RWMutex
+	version      *string
 }
 
 const responsePreviewLimit = 160
@@ -41,9 +55,9 @@ type NetworkResponse struct {
 	Description                string             `json:"description"`
 	Private                    bool               `json:"private"`
 	AllowPassivePortForwarding bool               `json:"allowPassivePortForwarding"`
-	EnableBroadcast            bool               `json:"enableBroadcast"`
+	EnableBroadcast            *bool              `json:"enableBroadcast"`
 	Mtu                        int                `json:"mtu"`
-	MulticastLimit             int                `json:"multicastLimit"`
+	MulticastLimit             *int               `json:"multicastLimit"`
 	IpAssignmentPools          []IpAssignmentPool `json:"ipAssignmentPools"`
 	Routes                     []Route            `json:"routes"`
 	Tags                       []Tag              `json:"tags"`
@@ -51,6 +65,7 @@ type NetworkResponse struct {
 	DNS                        DNSConfig          `json:"dns"`
 	V4AssignMode               AssignmentMode     `json:"v4AssignMode"`
 	V6AssignMode               V6AssignmentMode   `json:"v6AssignMode"`
+	SsoEnabled                 bool               `json:"ssoEnabled"`
 	CreationTime               int64              `json:"creationTime"`
 	LastModifiedTime           int64              `json:"lastModifiedTime"`
 	Status                     string             `json:"status"`
@@ -69,12 +84,21 @@ func (n *Network) UnmarshalJSON(data []byte) error {
 	n.Modified = resp.LastModifiedTime
 	n.Status = resp.Status
 
+	// Controllers that leave out the broadcast setting or multicast limit use their defaults
+	enableBroadcast, multicastLimit := true, DefaultMulticastLimit
+	if resp.EnableBroadcast != nil {
+		enableBroadcast = *resp.EnableBroadcast
+	}
+	if resp.MulticastLimit != nil {
+		multicastLimit = *resp.MulticastLimit
+	}
+
 	n.Config = NetworkConfig{
 		Private:                    resp.Private,
 		AllowPassivePortForwarding: resp.AllowPassivePortForwarding,
-		EnableBroadcast:            resp.EnableBroadcast,
+		EnableBroadcast:            enableBroadcast,
 		Mtu:                        resp.Mtu,
-		MulticastLimit:             resp.MulticastLimit,
+		MulticastLimit:             multicastLimit,
 		IpAssignmentPools:          resp.IpAssignmentPools,
 		Routes:                     resp.Routes,
 		Tags:                       resp.Tags,
@@ -82,18 +106,22 @@ func (n *Network) UnmarshalJSON(data []byte) error {
 		DNS:                        resp.DNS,
 		V4AssignMode:               resp.V4AssignMode,
 		V6AssignMode:               resp.V6AssignMode,
+		SsoEnabled:                 resp.SsoEnabled,
 	}
 
 	return nil
 }
 
+// DefaultMulticastLimit is the multicast limit controllers give new networks
+const DefaultMulticastLimit = 32
+
 // NetworkUpdateRequest is a partial network update request (no required fields).
 type NetworkUpdateRequest struct {
 	Name                 string             `json:"name,omitempty"`
 	Description          string             `json:"description,omitempty"`
 	Private              bool               `json:"private"`
 	AllowPassiveBridging *bool              `json:"allowPassiveBridging,omitempty"`
-	EnableBroadcast      bool               `json:"enableBroadcast"`
+	EnableBroadcast      *bool              `json:"enableBroadcast,omitempty"`
 	Mtu                  *int               `json:"mtu,omitempty"`
 	MulticastLimit       *int               `json:"multicastLimit,omitempty"`
 	IpAssignmentPools    []IpAssignmentPool `json:"ipAssignmentPools,omitempty"`
@@ -101,6 +129,7 @@ type NetworkUpdateRequest struct {
 	DNS                  *DNSConfig         `json:"dns,omitempty"`
 	V4AssignMode         *AssignmentMode    `json:"v4AssignMode,omitempty"`
 	V6AssignMode         *V6AssignmentMode  `json:"v6AssignMode,omitempty"`
+	SsoEnabled           *bool              `json:"ssoEnabled,omitempty"`
 }
 
 // NetworkConfig holds the network configuration fields.
@@ -117,6 +146,7 @@ type NetworkConfig struct {
 	DNS                        DNSConfig          `json:"dns"`
 	V4AssignMode               AssignmentMode     `json:"v4AssignMode"`
 	V6AssignMode               V6AssignmentMode   `json:"v6AssignMode"`
+	SsoEnabled                 bool               `json:"ssoEnabled"`
 }
 
 type DNSConfig struct {
@@ -146,6 +176,22 @@ func (d *DNSConfig) UnmarshalJSON(data []byte) error {
 	return nil
 }
 
+// MarshalJSON writes an empty server list as [] rather than null, so sending an empty
+// configuration clears the servers the controller pushes
+func (d DNSConfig) MarshalJSON() ([]byte, error) {
+	type dnsAlias DNSConfig
+	alias := dnsAlias(d)
+	if alias.Servers == nil {
+		alias.Servers = []string{}
+	}
+	return json.Marshal(alias)
+}
+
+// IsEmpty reports whether the configuration pushes neither a search domain nor servers
+func (d DNSConfig) IsEmpty() bool {
+	return d.Domain == "" && len(d.Servers) == 0
+}
+
 // V6AssignmentMode represents IPv6 assignment mode.
 type V6AssignmentMode struct {
 	ZT      bool `json:"zt"`
@@ -171,17 +217,112 @@ type Tag struct {
 	Value int `json:"value"`
 }
 
-// Rule represents a network rule.
+// UnmarshalJSON accepts the [id, value] pairs controllers use for member tags as well as objects.
+func (t *Tag) UnmarshalJSON(data []byte) error {
+	var pair []int
+	if err := json.Unmarshal(data, &pair); err == nil {
+		if len(pair) != 2 {
+			return fmt.Errorf("tag must be an [id, value] pair, got %d values", len(pair))
+		}
+		t.ID, t.Value = pair[0], pair[1]
+		return nil
+	}
+
+	type tagAlias Tag
+	var alias tagAlias
+	if err := json.Unmarshal(data, &alias); err != nil {
+		return fmt.Errorf("failed to unmarshal tag: %w", err)
+	}
+	*t = Tag(alias)
+	return nil
+}
+
+// TagPairs is a member's tag list in the [id, value] pair form the controller accepts.
+type TagPairs []Tag
+
+func (p TagPairs) MarshalJSON() ([]byte, error) {
+	pairs := make([][2]int, 0, len(p))
+	for _, tag := range p {
+		pairs = append(pairs, [2]int{tag.ID, tag.Value})
+	}
+	return json.Marshal(pairs)
+}
+
+// ResolvedTag is a member tag together with the names Tairitsu has defined for it.
+type ResolvedTag struct {
+	ID        int    `json:"id"`
+	Value     int    `json:"value"`
+	Name      string `json:"name,omitempty"`
+	ValueName string `json:"valueName,omitempty"`
+}
+
+// ResolvedCapability is a member capability together with the name Tairitsu has defined for it.
+type ResolvedCapability struct {
+	ID   int    `json:"id"`
+	Name string `json:"name,omitempty"`
+}
+
+// Rule is one entry of a network's rule list in the controller's JSON form. Matches come
+// first and the action that follows them applies when they all match; Not inverts a match and
+// Or joins it to the previous one with OR instead of AND. Only the fields of the rule's Type
+// are set.
 type Rule struct {
-	Not       bool   `json:"not"`
-	Or        bool   `json:"or,omitempty"`
-	Type      string `json:"type"`
-	Metric    int    `json:"metric"`
-	PortMin   int    `json:"portMin,omitempty"`
-	PortMax   int    `json:"portMax,omitempty"`
-	EthType   int    `json:"etherType,omitempty"`
-	IPVersion int    `json:"ipVersion,omitempty"`
-	Action    string `json:"action"`
+	Type string `json:"type"`
+	Not  bool   `json:"not"`
+	Or   bool   `json:"or"`
+
+	// ACTION_TEE, ACTION_WATCH and ACTION_REDIRECT
+	Address string `json:"address,omitempty"`
+	Flags   uint32 `json:"flags,omitempty"`
+	Length  int    `json:"length,omitempty"`
+
+	// MATCH_SOURCE_ZEROTIER_ADDRESS and MATCH_DEST_ZEROTIER_ADDRESS
+	ZT string `json:"zt,omitempty"`
+	// MATCH_MAC_SOURCE and MATCH_MAC_DEST
+	MAC string `json:"mac,omitempty"`
+	// MATCH_IPV4_SOURCE, MATCH_IPV4_DEST, MATCH_IPV6_SOURCE and MATCH_IPV6_DEST, in CIDR form
+	IP string `json:"ip,omitempty"`
+
+	VlanID     int  `json:"vlanId,omitempty"`
+	VlanPCP    int  `json:"vlanPcp,omitempty"`
+	VlanDEI    int  `json:"vlanDei,omitempty"`
+	EtherType  int  `json:"etherType,omitempty"`
+	IPProtocol int  `json:"ipProtocol,omitempty"`
+	ICMPType   int  `json:"icmpType,omitempty"`
+	ICMPCode   *int `json:"icmpCode,omitempty"` // Nil matches every code
+
+	// Port, frame size and IP TOS ranges
+	Start int `json:"start,omitempty"`
+	End   int `json:"end,omitempty"`
+	// Mask is a hex string for MATCH_CHARACTERISTICS and a number for MATCH_IP_TOS
+	Mask json.RawMessage `json:"mask,omitempty"`
+
+	// MATCH_RANDOM matches when a random 32-bit value is below Probability
+	Probability uint32 `json:"probability,omitempty"`
+
+	// Tag matchers (MATCH_TAGS_* and MATCH_TAG_SENDER/RECEIVER)
+	ID    uint32 `json:"id,omitempty"`
+	Value uint32 `json:"value,omitempty"`
+}
+
+// Capability is a named rule set that members holding it evaluate before the network rules.
+type Capability struct {
+	ID      uint32 `json:"id"`
+	Default bool   `json:"default"`
+	Rules   []Rule `json:"rules"`
+}
+
+// TagDefinition declares a tag of the network and the value members get when none is set.
+type TagDefinition struct {
+	ID      uint32  `json:"id"`
+	Default *uint32 `json:"default"`
+}
+
+// NetworkRules is the rule set of a network: its rules, capabilities and tag definitions.
+type NetworkRules struct {
+	Rules        []Rule          `json:"rules"`
+	Capabilities []Capability    `json:"capabilities"`
+	Tags         []TagDefinition `json:"tags"`
 }
 
 // AssignmentMode represents IPv4 assignment mode.
@@ -214,6 +355,24 @@ type Member struct {
 	PeerLatency     int          `json:"peerLatency,omitempty"`
 	PeerRole        string       `json:"peerRole,omitempty"`
 	PreferredPath   string       `json:"preferredPath,omitempty"`
+	// Metadata is kept in Tairitsu's database rather than by the controller
+	Metadata *MemberMetadata `json:"metadata,omitempty"`
+	// ResolvedTags and ResolvedCapabilities name the numeric tags and capabilities after the
+	// network's definitions in Tairitsu's database
+	ResolvedTags         []ResolvedTag        `json:"resolvedTags,omitempty"`
+	ResolvedCapabilities []ResolvedCapability `json:"resolvedCapabilities,omitempty"`
+}
+
+// MemberMetadata is what Tairitsu stores about a member besides the controller's fields
+type MemberMetadata struct {
+	DisplayName string            `json:"displayName,omitempty"`
+	Notes       string            `json:"notes,omitempty"`
+	Tags        map[string]string `json:"tags,omitempty"`
+	// AuthorizedUntil is when Tairitsu deauthorizes the member, with the seconds left until then
+	AuthorizedUntil               *time.Time `json:"authorizedUntil,omitempty"`
+	AuthorizationRemainingSeconds *int64     `json:"authorizationRemainingSeconds,omitempty"`
+	UpdatedBy                     string     `json:"updatedBy,omitempty"`
+	UpdatedAt                     time.Time  `json:"updatedAt"`
 }
 
 type memberAlias struct {
@@ -315,6 +474,9 @@ type MemberUpdateRequest struct {
 	ActiveBridge    *bool    `json:"activeBridge,omitempty"`
 	IPAssignments   []string `json:"ipAssignments,omitempty"`
 	NoAutoAssignIPs *bool    `json:"noAutoAssignIps,omitempty"`
+	// Tags and Capabilities replace the member's whole list when set; an empty list clears it
+	Tags         *TagPairs `json:"tags,omitempty"`
+	Capabilities *[]int    `json:"capabilities,omitempty"`
 }
 
 // MemberConfig holds member configuration fields.
@@ -327,6 +489,17 @@ type MemberConfig struct {
 	NoAutoAssignIPs bool     `json:"noAutoAssignIps"`
 }
 
+// Peer roles reported by the node API
+const (
+	PeerRolePlanet = "PLANET"
+	PeerRoleMoon   = "MOON"
+	PeerRoleLeaf   = "LEAF"
+)
+
+// DefaultPeerPath is the node API endpoint listing peers. It is served by the node hosting
+// the controller, not the controller API, so some controller setups do not expose it.
+const DefaultPeerPath = "/peer"
+
 type Peer struct {
 	Address       string     `json:"address"`
 	Latency       int        `json:"latency"`
@@ -339,10 +512,15 @@ type Peer struct {
 	VersionRev    int        `json:"versionRev"`
 }
 
+// PeerPath is one physical path to a peer. Address is the endpoint as "ip/port"; LastSend and
+// LastReceive are Unix times in milliseconds.
 type PeerPath struct {
-	Active    bool   `json:"active"`
-	Address   string `json:"address"`
-	Preferred bool   `json:"preferred"`
+	Active      bool   `json:"active"`
+	Address     string `json:"address"`
+	Preferred   bool   `json:"preferred"`
+	Expired     bool   `json:"expired"`
+	LastSend    int64  `json:"lastSend"`
+	LastReceive int64  `json:"lastReceive"`
 }
 
 // Status represents the ZeroTier controller status.
@@ -392,48 +570,142 @@ func NewClientWithConfig(cfg *config.Config) (*Client, error) {
 		return nil, fmt.Errorf("failed to get ZeroTier token: %w", err)
 	}
 
-	httpClient := &http.Client{
-		Timeout: 10 * time.Second,
-		Transport: &http.Transport{
-			MaxIdleConns:        20,
-			MaxIdleConnsPerHost: 10,
-			IdleConnTimeout:     90 * time.Second,
-		},
-	}
-
 	baseURL := cfg.ZeroTier.URL
 	if baseURL == "" {
 		baseURL = "http://localhost:9993"
 		logger.Warn("ZeroTier URL not configured, falling back to default; this will not work if ZeroTier runs in a separate container")
 	}
 
+	client := NewClientWithOptions(baseURL, token, nil)
+	if tokenPath := cfg.ZeroTier.TokenPath; tokenPath != "" {
+		client.TokenSource = tokenFileSource(tokenPath)
+	}
+	return client, nil
+}
+
+// NewClientWithOptions builds a client for the controller at baseURL; a nil httpClient uses
+// the default pooled client with a 10 second timeout
+func NewClientWithOptions(baseURL, token string, httpClient *http.Client) *Client {
+	if httpClient == nil {
+		httpClient = newHTTPClient()
+	}
 	return &Client{
 		BaseURL:    baseURL,
 		Token:      token,
 		HTTPClient: httpClient,
-	}, nil
+	}
+}
+
+// NewClientForController connects to one of the additional controllers in the configuration
+func NewClientForController(controller config.ZeroTierControllerConfig) (*Client, error) {
+	if controller.URL == "" {
+		return nil, fmt.Errorf("controller %q has no URL", controller.Name)
+	}
+	token, err := config.ReadTokenFile(controller.TokenPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load token of controller %q: %w", controller.Name, err)
+	}
+
+	client := NewClientWithOptions(controller.URL, token, nil)
+	client.TokenSource = tokenFileSource(controller.TokenPath)
+	return client, nil
+}
+
+// tokenFileSource re-reads a token file, so a controller whose authtoken.secret was rotated
+// is reached again without reconfiguring Tairitsu
+func tokenFileSource(path string) func() (string, error) {
+	return func() (string, error) {
+		return config.ReadTokenFile(path)
+	}
+}
+
+func newHTTPClient() *http.Client {
+	return &http.Client{
+		Timeout: 10 * time.Second,
+		Transport: &http.Transport{
+			MaxIdleConns:        20,
+			MaxIdleConnsPerHost: 10,
+			IdleConnTimeout:     90 * time.Second,
+		},
+	}
+}
+
+// StatusError is returned when the controller answers with an unexpected HTTP status
+type StatusError struct {
+	StatusCode int
+	// Body is the response body with the auth token removed
+	Body string
+}
+
+// Error quotes only the start of the body, so a large error page does not end up in logs
+// or API responses
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("request failed (status %d): %s", e.StatusCode, responsePreview([]byte(e.Body)))
+}
+
+// IsNotFound reports whether the controller answered 404, e.g. for an unknown member
+func IsNotFound(err error) bool {
+	var statusErr *StatusError
+	return errors.As(err, &statusErr) && statusErr.StatusCode == http.StatusNotFound
+}
+
+// networkPath is the controller endpoint of a network
+func networkPath(networkID string) string {
+	return "/controller/network/" + pathSegment(networkID)
+}
+
+// pathSegment escapes an ID for use as one path segment, so a malformed ID cannot reach another
+// controller endpoint. Callers validate IDs first; this is a second line of defense.
+func pathSegment(id string) string {
+	switch id {
+	case ".", "..":
+		return strings.Repeat("%2E", len(id))
+	}
+	return url.PathEscape(id)
 }
 
 // doRequest executes an HTTP request against the ZeroTier controller.
 func (c *Client) doRequest(method, endpoint string, body interface{}) ([]byte, error) {
-	url := fmt.Sprintf("%s%s", c.BaseURL, endpoint)
+	return c.doRequestContext(context.Background(), method, endpoint, body)
+}
 
-	var bodyReader io.Reader
+// doRequestContext executes an HTTP request against the ZeroTier controller, bounded by ctx.
+func (c *Client) doRequestContext(ctx context.Context, method, endpoint string, body interface{}) ([]byte, error) {
+	var jsonData []byte
 	if body != nil {
-		jsonData, err := json.Marshal(body)
+		var err error
+		jsonData, err = json.Marshal(body)
 		if err != nil {
 			return nil, fmt.Errorf("failed to serialize request body: %w", err)
 		}
-		bodyReader = bytes.NewBuffer(jsonData)
 	}
 
-	req, err := http.NewRequest(method, url, bodyReader)
+	token := c.currentToken()
+	respBody, err := c.send(ctx, method, endpoint, jsonData, token)
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) && statusErr.StatusCode == http.StatusUnauthorized {
+		if fresh, ok := c.refreshToken(token); ok {
+			return c.send(ctx, method, endpoint, jsonData, fresh)
+		}
+	}
+	return respBody, err
+}
+
+func (c *Client) send(ctx context.Context, method, endpoint string, jsonData []byte, token string) ([]byte, error) {
+	url := fmt.Sprintf("%s%s", c.BaseURL, endpoint)
+
+	var bodyReader io.Reader
+	if jsonData != nil {
+		bodyReader = bytes.NewReader(jsonData)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, url, bodyReader)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
 	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("X-ZT1-Auth", c.Token)
+	req.Header.Set("X-ZT1-Auth", token)
 
 	resp, err := c.HTTPClient.Do(req)
 	if err != nil {
@@ -452,15 +724,49 @@ func (c *Client) doRequest(method, endpoint string, body interface{}) ([]byte, e
 	}
 
 	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
-		return nil, fmt.Errorf("request failed (status %d): %s", resp.StatusCode, string(respBody))
+		return nil, &StatusError{StatusCode: resp.StatusCode, Body: redactToken(string(respBody), token)}
 	}
 
 	return respBody, nil
 }
 
+func (c *Client) currentToken() string {
+	c.tokenMutex.RLock()
+	defer c.tokenMutex.RUnlock()
+	return c.Token
+}
+
+// refreshToken asks TokenSource for the token after rejected was refused, and keeps it
+// when it differs
+func (c *Client) refreshToken(rejected string) (string, bool) {
+	if c.TokenSource == nil {
+		return "", false
+	}
+	token, err := c.TokenSource()
+	if err != nil {
+		logger.Warn("controller rejected the token and it could not be reloaded", zap.Error(err))
+		return "", false
+	}
+	token = strings.TrimSpace(token)
+	if token == "" || token == rejected {
+		return "", false
+	}
+
+	c.tokenMutex.Lock()
+	c.Token = token
+	c.tokenMutex.Unlock()
+	logger.Info("controller rejected the token; retrying with the reloaded token")
+	return token, true
+}
+
 // GetStatus retrieves the ZeroTier controller status.
 func (c *Client) GetStatus() (*Status, error) {
-	respBody, err := c.doRequest("GET", "/status", nil)
+	return c.GetStatusContext(context.Background())
+}
+
+// GetStatusContext retrieves the controller status, giving up when ctx is done.
+func (c *Client) GetStatusContext(ctx context.Context) (*Status, error) {
+	respBody, err := c.doRequestContext(ctx, "GET", "/status", nil)
 	if err != nil {
 		return nil, err
 	}
@@ -470,9 +776,29 @@ func (c *Client) GetStatus() (*Status, error) {
 		return nil, fmt.Errorf("failed to unmarshal status response: %w; preview: %s", err, responsePreview(respBody))
 	}
 
+	c.versionMutex.Lock()
+	c.version = &status.Version
+	c.versionMutex.Unlock()
 	return &status, nil
 }
 
+// ControllerFeatures returns what the controller's version supports. The version of the
+// latest status read is used; the status is read when there was none yet.
+func (c *Client) ControllerFeatures() (FeatureSet, error) {
+	c.versionMutex.RLock()
+	version := c.version
+	c.versionMutex.RUnlock()
+	if version != nil {
+		return FeaturesForVersion(*version), nil
+	}
+
+	status, err := c.GetStatus()
+	if err != nil {
+		return FeatureSet{}, err
+	}
+	return FeaturesForVersion(status.Version), nil
+}
+
 // GetNetworkIDs retrieves only the network ID list (lightweight).
 func (c *Client) GetNetworkIDs() ([]string, error) {
 	respBody, err := c.doRequest("GET", "/controller/network", nil)
@@ -488,6 +814,119 @@ func (c *Client) GetNetworkIDs() ([]string, error) {
 	return networkIDs, nil
 }
 
+// networkDetailConcurrency bounds the network requests GetNetworks sends at once
+const networkDetailConcurrency = 8
+
+// NetworkListError reports the networks GetNetworks listed but could not read
+type NetworkListError struct {
+	// Failures maps the ID of each network that could not be read to the reason
+	Failures map[string]error
+}
+
+func (e *NetworkListError) Error() string {
+	networkIDs := make([]string, 0, len(e.Failures))
+	for networkID := range e.Failures {
+		networkIDs = append(networkIDs, networkID)
+	}
+	sort.Strings(networkIDs)
+	reasons := make([]string, len(networkIDs))
+	for i, networkID := range networkIDs {
+		reasons[i] = fmt.Sprintf("%s: %v", networkID, e.Failures[networkID])
+	}
+	return fmt.Sprintf("failed to read %d network(s): %s", len(networkIDs), strings.Join(reasons, "; "))
+}
+
+func (e *NetworkListError) Unwrap() []error {
+	errs := make([]error, 0, len(e.Failures))
+	for _, err := range e.Failures {
+		errs = append(errs, err)
+	}
+	return errs
+}
+
+// GetNetworks retrieves every network of the controller, sorted by ID. A stock controller
+// lists only network IDs, so each network is then fetched on its own. Networks that cannot
+// be read are left out and reported by a *NetworkListError returned with the others.
+func (c *Client) GetNetworks() ([]Network, error) {
+	respBody, err := c.doRequest("GET", "/controller/network", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if networks, ok := parseNetworkObjects(respBody); ok {
+		// Proxies such as ztncui answer with the networks themselves
+		return networks, nil
+	}
+	networkIDs, err := parseNetworkIDs(respBody)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse network ID list: %w; preview: %s", err, responsePreview(respBody))
+	}
+	return c.getNetworkDetails(networkIDs)
+}
+
+// getNetworkDetails fetches networks concurrently, in the order of networkIDs
+func (c *Client) getNetworkDetails(networkIDs []string) ([]Network, error) {
+	details := make([]*Network, len(networkIDs))
+	errs := make([]error, len(networkIDs))
+	if len(networkIDs) > 0 {
+		var wg sync.WaitGroup
+		limiter := make(chan struct{}, min(networkDetailConcurrency, len(networkIDs)))
+		for i, networkID := range networkIDs {
+			wg.Add(1)
+			go func(i int, networkID string) {
+				defer wg.Done()
+				limiter <- struct{}{}
+				defer func() { <-limiter }()
+				details[i], errs[i] = c.GetNetwork(networkID)
+			}(i, networkID)
+		}
+		wg.Wait()
+	}
+
+	networks := make([]Network, 0, len(networkIDs))
+	failures := make(map[string]error)
+	for i, networkID := range networkIDs {
+		switch {
+		case errs[i] != nil:
+			failures[networkID] = errs[i]
+		case details[i] != nil:
+			networks = append(networks, *details[i])
+		}
+	}
+	if len(failures) > 0 {
+		return networks, &NetworkListError{Failures: failures}
+	}
+	return networks, nil
+}
+
+// parseNetworkObjects reads a network list holding complete network objects, as the
+// controller writes them, rather than IDs or summaries
+func parseNetworkObjects(data []byte) ([]Network, bool) {
+	var items []json.RawMessage
+	if err := json.Unmarshal(data, &items); err != nil || len(items) == 0 {
+		return nil, false
+	}
+	networks := make([]Network, 0, len(items))
+	for _, item := range items {
+		var marker struct {
+			ID      string `json:"id"`
+			ObjType string `json:"objtype"`
+		}
+		if err := json.Unmarshal(item, &marker); err != nil || marker.ID == "" || marker.ObjType != "network" {
+			return nil, false
+		}
+		var network Network
+		if err := json.Unmarshal(item, &network); err != nil {
+			return nil, false
+		}
+		networks = append(networks, network)
+	}
+	sort.Slice(networks, func(i, j int) bool {
+		return networks[i].ID < networks[j].ID
+	})
+	return networks, true
+}
+
 func parseNetworkIDs(data []byte) ([]string, error) {
 	trimmed := string(bytes.TrimSpace(data))
 	if trimmed == "" || trimmed == "null" {
@@ -589,7 +1028,7 @@ func looksLikeNetworkID(id string) bool {
 
 // GetNetwork retrieves a single network by ID.
 func (c *Client) GetNetwork(networkID string) (*Network, error) {
-	endpoint := fmt.Sprintf("/controller/network/%s", networkID)
+	endpoint := networkPath(networkID)
 	respBody, err := c.doRequest("GET", endpoint, nil)
 	if err != nil {
 		return nil, err
@@ -603,9 +1042,29 @@ func (c *Client) GetNetwork(networkID string) (*Network, error) {
 	return &network, nil
 }
 
-// CreateNetwork creates a new network.
+// CreateNetwork creates a new network with its name, description and privacy. Its MTU and
+// DNS configuration are sent along when set; other settings keep the controller's defaults.
 func (c *Client) CreateNetwork(network *Network) (*Network, error) {
-	respBody, err := c.doRequest("POST", "/controller/network", network)
+	return c.CreateNetworkWithSettings(network, nil)
+}
+
+// CreateNetworkWithSettings creates a network like CreateNetwork, starting it with the
+// fields set in settings. The controller API takes flat settings, as for a partial update.
+func (c *Client) CreateNetworkWithSettings(network *Network, settings *NetworkUpdateRequest) (*Network, error) {
+	body := NetworkUpdateRequest{}
+	if settings != nil {
+		body = *settings
+	}
+	body.Name, body.Description, body.Private = network.Name, network.Description, network.Config.Private
+	if body.Mtu == nil && network.Config.Mtu > 0 {
+		mtu := network.Config.Mtu
+		body.Mtu = &mtu
+	}
+	if body.DNS == nil && !network.Config.DNS.IsEmpty() {
+		dns := network.Config.DNS
+		body.DNS = &dns
+	}
+	respBody, err := c.doRequest("POST", "/controller/network", body)
 	if err != nil {
 		return nil, err
 	}
@@ -620,7 +1079,7 @@ func (c *Client) CreateNetwork(network *Network) (*Network, error) {
 
 // PartialUpdateNetwork partially updates a network configuration.
 func (c *Client) PartialUpdateNetwork(networkID string, updateReq *NetworkUpdateRequest) (*Network, error) {
-	endpoint := fmt.Sprintf("/controller/network/%s", networkID)
+	endpoint := networkPath(networkID)
 	respBody, err := c.doRequest("POST", endpoint, updateReq)
 	if err != nil {
 		return nil, err
@@ -634,52 +1093,121 @@ func (c *Client) PartialUpdateNetwork(networkID string, updateReq *NetworkUpdate
 	return &updatedNetwork, nil
 }
 
+// GetNetworkRules retrieves the rules, capabilities and tag definitions of a network.
+func (c *Client) GetNetworkRules(networkID string) (*NetworkRules, error) {
+	endpoint := networkPath(networkID)
+	respBody, err := c.doRequest("GET", endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var rules NetworkRules
+	if err := json.Unmarshal(respBody, &rules); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal network rules: %w; preview: %s", err, responsePreview(respBody))
+	}
+	normalizeNetworkRules(&rules)
+
+	return &rules, nil
+}
+
+// UpdateNetworkRules replaces the rules, capabilities and tag definitions of a network and
+// leaves its other settings alone.
+func (c *Client) UpdateNetworkRules(networkID string, rules *NetworkRules) (*NetworkRules, error) {
+	endpoint := networkPath(networkID)
+	respBody, err := c.doRequest("POST", endpoint, rules)
+	if err != nil {
+		return nil, err
+	}
+
+	var updated NetworkRules
+	if err := json.Unmarshal(respBody, &updated); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal update network rules response: %w; preview: %s", err, responsePreview(respBody))
+	}
+	normalizeNetworkRules(&updated)
+
+	return &updated, nil
+}
+
+func normalizeNetworkRules(rules *NetworkRules) {
+	if rules.Rules == nil {
+		rules.Rules = []Rule{}
+	}
+	if rules.Capabilities == nil {
+		rules.Capabilities = []Capability{}
+	}
+	if rules.Tags == nil {
+		rules.Tags = []TagDefinition{}
+	}
+}
+
 // DeleteNetwork deletes a network by ID.
 func (c *Client) DeleteNetwork(networkID string) error {
-	endpoint := fmt.Sprintf("/controller/network/%s", networkID)
+	endpoint := networkPath(networkID)
 	_, err := c.doRequest("DELETE", endpoint, nil)
 	return err
 }
 
-// GetMembers retrieves all members of a network.
+// GetMembers retrieves all members of a network. A stock controller lists only a
+// nodeID→revision index, so each member is then fetched on its own.
 func (c *Client) GetMembers(networkID string) ([]Member, error) {
-	endpoint := fmt.Sprintf("/controller/network/%s/member", networkID)
+	endpoint := networkPath(networkID) + "/member"
 	respBody, err := c.doRequest("GET", endpoint, nil)
 	if err != nil {
 		return nil, err
 	}
 
-	members, err := parseMemberList(respBody)
-	if err == nil {
-		return members, nil
+	if !isMemberIndex(respBody) {
+		// Proxies such as ztncui answer with the members themselves
+		return parseMemberList(respBody)
 	}
-
-	memberIDs, indexErr := parseMemberIndexList(respBody)
-	if indexErr != nil {
+	memberIDs, err := parseMemberIndexList(respBody)
+	if err != nil {
 		return nil, err
 	}
+	return c.getMemberDetails(networkID, memberIDs)
+}
 
-	members = make([]Member, 0, len(memberIDs))
-	for _, memberID := range memberIDs {
-		member, detailErr := c.GetMember(networkID, memberID)
-		if detailErr != nil {
-			return nil, fmt.Errorf("failed to get member %s detail: %w", memberID, detailErr)
-		}
-		if member != nil {
-			members = append(members, *member)
+// memberDetailConcurrency bounds the member requests sent at once for a member index
+const memberDetailConcurrency = 8
+
+// getMemberDetails fetches the members of a member index concurrently, in the order of
+// memberIDs. Members deleted since the index was read are left out.
+func (c *Client) getMemberDetails(networkID string, memberIDs []string) ([]Member, error) {
+	details := make([]*Member, len(memberIDs))
+	errs := make([]error, len(memberIDs))
+	if len(memberIDs) > 0 {
+		var wg sync.WaitGroup
+		limiter := make(chan struct{}, min(memberDetailConcurrency, len(memberIDs)))
+		for i, memberID := range memberIDs {
+			wg.Add(1)
+			go func(i int, memberID string) {
+				defer wg.Done()
+				limiter <- struct{}{}
+				defer func() { <-limiter }()
+				details[i], errs[i] = c.GetMember(networkID, memberID)
+			}(i, memberID)
 		}
+		wg.Wait()
 	}
 
-	sort.Slice(members, func(i, j int) bool {
-		return members[i].ID < members[j].ID
-	})
-
+	members := make([]Member, 0, len(memberIDs))
+	for i, memberID := range memberIDs {
+		if IsNotFound(errs[i]) {
+			continue
+		}
+		if errs[i] != nil {
+			return nil, fmt.Errorf("failed to get member %s detail: %w", memberID, errs[i])
+		}
+		if details[i] != nil {
+			members = append(members, *details[i])
+		}
+	}
 	return members, nil
 }
 
 // GetMember retrieves a single member by network ID and member ID.
 func (c *Client) GetMember(networkID, memberID string) (*Member, error) {
-	endpoint := fmt.Sprintf("/controller/network/%s/member/%s", networkID, memberID)
+	endpoint := networkPath(networkID) + "/member/" + pathSegment(memberID)
 	respBody, err := c.doRequest("GET", endpoint, nil)
 	if err != nil {
 		return nil, err
@@ -695,7 +1223,13 @@ func (c *Client) GetMember(networkID, memberID string) (*Member, error) {
 
 // GetPeers retrieves all peer nodes.
 func (c *Client) GetPeers() ([]Peer, error) {
-	respBody, err := c.doRequest("GET", "/peer", nil)
+	return c.GetPeersAt(DefaultPeerPath)
+}
+
+// GetPeersAt retrieves all peer nodes from the given endpoint, for nodes that serve the peer
+// list under another path. A node without the endpoint answers with a 404 StatusError.
+func (c *Client) GetPeersAt(path string) ([]Peer, error) {
+	respBody, err := c.doRequest("GET", path, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -714,7 +1248,7 @@ func (c *Client) GetPeers() ([]Peer, error) {
 
 // UpdateMember updates a member's configuration.
 func (c *Client) UpdateMember(networkID, memberID string, member *MemberUpdateRequest) (*Member, error) {
-	endpoint := fmt.Sprintf("/controller/network/%s/member/%s", networkID, memberID)
+	endpoint := networkPath(networkID) + "/member/" + pathSegment(memberID)
 	respBody, err := c.doRequest("POST", endpoint, member)
 	if err != nil {
 		return nil, err
@@ -730,7 +1264,7 @@ func (c *Client) UpdateMember(networkID, memberID string, member *MemberUpdateRe
 
 // DeleteMember removes a member from a network.
 func (c *Client) DeleteMember(networkID, memberID string) error {
-	endpoint := fmt.Sprintf("/controller/network/%s/member/%s", networkID, memberID)
+	endpoint := networkPath(networkID) + "/member/" + pathSegment(memberID)
 	_, err := c.doRequest("DELETE", endpoint, nil)
 	return err
 }
@@ -761,6 +1295,21 @@ func parseMemberList(respBody []byte) ([]Member, error) {
 	return members, nil
 }
 
+// isMemberIndex reports whether a member list is the nodeID→revision map a stock controller
+// answers with, rather than member objects
+func isMemberIndex(respBody []byte) bool {
+	var entries map[string]json.RawMessage
+	if err := json.Unmarshal(respBody, &entries); err != nil {
+		return false
+	}
+	for _, raw := range entries {
+		if trimmed := bytes.TrimSpace(raw); len(trimmed) == 0 || trimmed[0] == '{' {
+			return false
+		}
+	}
+	return true
+}
+
 func parseMemberIndexList(respBody []byte) ([]string, error) {
 	var memberIndex map[string]int
 	if err := json.Unmarshal(respBody, &memberIndex); err != nil {
@@ -798,6 +1347,15 @@ func parsePeerList(respBody []byte) ([]Peer, error) {
 	return peers, nil
 }
 
+// redactToken removes the auth token from text the controller sent back, such as a proxy
+// error page echoing the request headers
+func redactToken(text, token string) string {
+	if token == "" {
+		return text
+	}
+	return strings.ReplaceAll(text, token, "[redacted]")
+}
+
 func responsePreview(respBody []byte) string {
 	preview := bytes.TrimSpace(respBody)
 	if len(preview) == 0 {
@@ -0,0 +1,633 @@
+package zerotier
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/GT-610/tairitsu/internal/app/config"
+)
+
+const (
+	fixtureToken     = "fixture-token"
+	fixtureNetworkID = "8056c2e21c000001"
+	fixtureMemberID  = "a1b2c3d4e5"
+)
+
+func readFixture(t *testing.T, name string) []byte {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatalf("read fixture %s: %v", name, err)
+	}
+	return data
+}
+
+type recordedRequest struct {
+	method      string
+	path        string
+	escapedPath string
+	body        map[string]any
+}
+
+// fixtureController answers like a ZeroTier 1.14 controller from the files in testdata, keyed
+// by "METHOD /path". It records the requests it receives; routes maps to a status and body.
+type fixtureController struct {
+	t        *testing.T
+	mutex    sync.Mutex
+	routes   map[string]fixtureResponse
+	requests []recordedRequest
+}
+
+type fixtureResponse struct {
+	status int
+	body   []byte
+}
+
+func newFixtureController(t *testing.T) (*fixtureController, *Client) {
+	t.Helper()
+
+	controller := &fixtureController{t: t, routes: map[string]fixtureResponse{
+		"GET /status":             {http.StatusOK, readFixture(t, "status.json")},
+		"GET /peer":               {http.StatusOK, readFixture(t, "peers.json")},
+		"GET /controller/network": {http.StatusOK, readFixture(t, "networks.json")},
+		"GET /controller/network/" + fixtureNetworkID:                                   {http.StatusOK, readFixture(t, "network.json")},
+		"POST /controller/network/" + fixtureNetworkID:                                  {http.StatusOK, readFixture(t, "network.json")},
+		"POST /controller/network":                                                      {http.StatusOK, readFixture(t, "network.json")},
+		"DELETE /controller/network/" + fixtureNetworkID:                                {http.StatusOK, readFixture(t, "network.json")},
+		"GET /controller/network/" + fixtureNetworkID + "/member":                       {http.StatusOK, readFixture(t, "members.json")},
+		"GET /controller/network/" + fixtureNetworkID + "/member/" + fixtureMemberID:    {http.StatusOK, readFixture(t, "member.json")},
+		"GET /controller/network/" + fixtureNetworkID + "/member/0f1e2d3c4b":            {http.StatusOK, []byte(`{"id":"0f1e2d3c4b","address":"0f1e2d3c4b","authorized":false,"nwid":"8056c2e21c000001","objtype":"member"}`)},
+		"POST /controller/network/" + fixtureNetworkID + "/member/" + fixtureMemberID:   {http.StatusOK, readFixture(t, "member.json")},
+		"DELETE /controller/network/" + fixtureNetworkID + "/member/" + fixtureMemberID: {http.StatusOK, readFixture(t, "member.json")},
+	}}
+
+	server := httptest.NewServer(controller)
+	t.Cleanup(server.Close)
+	return controller, NewClientWithOptions(server.URL, fixtureToken, server.Client())
+}
+
+func (f *fixtureController) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("X-ZT1-Auth") != fixtureToken {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	request := recordedRequest{method: r.Method, path: r.URL.Path, escapedPath: r.URL.EscapedPath()}
+	if raw, _ := io.ReadAll(r.Body); len(raw) > 0 {
+		if err := json.Unmarshal(raw, &request.body); err != nil {
+			f.t.Errorf("%s %s sent invalid JSON: %v", r.Method, r.URL.Path, err)
+		}
+	}
+
+	f.mutex.Lock()
+	f.requests = append(f.requests, request)
+	response, ok := f.routes[r.Method+" "+r.URL.Path]
+	f.mutex.Unlock()
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`{}`))
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(response.status)
+	_, _ = w.Write(response.body)
+}
+
+func (f *fixtureController) respond(route string, status int, body string) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.routes[route] = fixtureResponse{status, []byte(body)}
+}
+
+func (f *fixtureController) requestCount() int {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return len(f.requests)
+}
+
+func (f *fixtureController) lastRequest(t *testing.T) recordedRequest {
+	t.Helper()
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	if len(f.requests) == 0 {
+		t.Fatal("controller received no request")
+	}
+	return f.requests[len(f.requests)-1]
+}
+
+func TestClientGetStatus(t *testing.T) {
+	_, client := newFixtureController(t)
+
+	status, err := client.GetStatus()
+	if err != nil {
+		t.Fatalf("GetStatus() error = %v", err)
+	}
+	if status.Version != "1.14.2" || status.Address != "8056c2e21c" || !status.Online {
+		t.Fatalf("GetStatus() = %+v", status)
+	}
+}
+
+func TestClientControllerFeaturesFollowTheLatestStatus(t *testing.T) {
+	controller, client := newFixtureController(t)
+
+	features, err := client.ControllerFeatures()
+	if err != nil {
+		t.Fatalf("ControllerFeatures() error = %v", err)
+	}
+	if features.Version != (Version{1, 14, 2}) || !features.Supports(FeatureSSO) {
+		t.Fatalf("ControllerFeatures() = %+v", features)
+	}
+	requests := controller.requestCount()
+	if _, err := client.ControllerFeatures(); err != nil || controller.requestCount() != requests {
+		t.Fatalf("ControllerFeatures() read the status again: %v", err)
+	}
+
+	// A controller downgraded to 1.10 is noticed at the next status read
+	controller.respond("GET /status", http.StatusOK, `{"version":"1.10.6","address":"8056c2e21c","online":true}`)
+	if _, err := client.GetStatus(); err != nil {
+		t.Fatalf("GetStatus() error = %v", err)
+	}
+	features, _ = client.ControllerFeatures()
+	if features.Supports(FeatureDNS) || !features.Supports(FeatureMulticastLimit) {
+		t.Fatalf("ControllerFeatures() after downgrade = %+v", features)
+	}
+}
+
+func TestClientGetNetworkIDs(t *testing.T) {
+	_, client := newFixtureController(t)
+
+	ids, err := client.GetNetworkIDs()
+	if err != nil {
+		t.Fatalf("GetNetworkIDs() error = %v", err)
+	}
+	if strings.Join(ids, ",") != "8056c2e21c000001,8056c2e21c5e4f2a" {
+		t.Fatalf("GetNetworkIDs() = %v", ids)
+	}
+}
+
+func TestClientGetNetworksReadsEachListedNetwork(t *testing.T) {
+	controller, client := newFixtureController(t)
+	controller.respond("GET /controller/network/8056c2e21c5e4f2a", http.StatusOK, `{"id":"8056c2e21c5e4f2a","name":"second","objtype":"network"}`)
+
+	networks, err := client.GetNetworks()
+	if err != nil {
+		t.Fatalf("GetNetworks() error = %v", err)
+	}
+	if len(networks) != 2 || networks[0].ID != fixtureNetworkID || networks[1].Name != "second" {
+		t.Fatalf("GetNetworks() = %+v", networks)
+	}
+}
+
+func TestClientGetNetworksReturnsTheNetworksThatLoaded(t *testing.T) {
+	_, client := newFixtureController(t)
+
+	// The fixture controller has no route for the second network, so it answers 404
+	networks, err := client.GetNetworks()
+	var listErr *NetworkListError
+	if !errors.As(err, &listErr) {
+		t.Fatalf("GetNetworks() error = %v, want a *NetworkListError", err)
+	}
+	if len(listErr.Failures) != 1 || !IsNotFound(listErr.Failures["8056c2e21c5e4f2a"]) || !IsNotFound(err) {
+		t.Fatalf("failures = %v", listErr.Failures)
+	}
+	if len(networks) != 1 || networks[0].ID != fixtureNetworkID {
+		t.Fatalf("GetNetworks() = %+v", networks)
+	}
+}
+
+func TestClientGetNetworksAcceptsNetworkObjects(t *testing.T) {
+	controller, client := newFixtureController(t)
+	controller.respond("GET /controller/network", http.StatusOK, "["+string(readFixture(t, "network.json"))+"]")
+
+	networks, err := client.GetNetworks()
+	if err != nil {
+		t.Fatalf("GetNetworks() error = %v", err)
+	}
+	if len(networks) != 1 || networks[0].ID != fixtureNetworkID {
+		t.Fatalf("GetNetworks() = %+v", networks)
+	}
+	if request := controller.lastRequest(t); request.path != "/controller/network" {
+		t.Fatalf("networks were fetched one by one: last request %s", request.path)
+	}
+}
+
+func TestClientGetNetwork(t *testing.T) {
+	_, client := newFixtureController(t)
+
+	network, err := client.GetNetwork(fixtureNetworkID)
+	if err != nil {
+		t.Fatalf("GetNetwork() error = %v", err)
+	}
+	if network.ID != fixtureNetworkID || network.Name != "lab" {
+		t.Fatalf("GetNetwork() = %+v", network)
+	}
+	config := network.Config
+	if !config.Private || !config.EnableBroadcast || config.Mtu != 2800 || config.MulticastLimit != 32 {
+		t.Fatalf("config = %+v", config)
+	}
+	if len(config.IpAssignmentPools) != 1 || config.IpAssignmentPools[0].IpRangeStart != "10.147.17.1" {
+		t.Fatalf("ipAssignmentPools = %+v", config.IpAssignmentPools)
+	}
+	if len(config.Routes) != 1 || config.Routes[0].Target != "10.147.17.0/24" || config.Routes[0].Via != "" {
+		t.Fatalf("routes = %+v", config.Routes)
+	}
+	if config.DNS.Domain != "lab.example" || len(config.Rules) != 5 || !config.V4AssignMode.ZT || !config.V6AssignMode.Rfc4193 {
+		t.Fatalf("config = %+v", config)
+	}
+}
+
+func TestClientCreateAndUpdateNetwork(t *testing.T) {
+	controller, client := newFixtureController(t)
+
+	created, err := client.CreateNetwork(&Network{Name: "lab", Config: NetworkConfig{Private: true}})
+	if err != nil {
+		t.Fatalf("CreateNetwork() error = %v", err)
+	}
+	if created.ID != fixtureNetworkID {
+		t.Fatalf("CreateNetwork() id = %q", created.ID)
+	}
+	if request := controller.lastRequest(t); request.method != http.MethodPost || request.path != "/controller/network" {
+		t.Fatalf("request = %s %s", request.method, request.path)
+	}
+
+	mtu := 1400
+	if _, err := client.PartialUpdateNetwork(fixtureNetworkID, &NetworkUpdateRequest{Mtu: &mtu}); err != nil {
+		t.Fatalf("PartialUpdateNetwork() error = %v", err)
+	}
+	request := controller.lastRequest(t)
+	if request.path != "/controller/network/"+fixtureNetworkID || request.body["mtu"] != float64(1400) {
+		t.Fatalf("request = %s %v", request.path, request.body)
+	}
+	if _, ok := request.body["routes"]; ok {
+		t.Fatalf("partial update sent unset fields: %v", request.body)
+	}
+}
+
+func TestClientDefaultsMissingBroadcastAndMulticastSettings(t *testing.T) {
+	controller, client := newFixtureController(t)
+	controller.respond("GET /controller/network/"+fixtureNetworkID, http.StatusOK, string(readFixture(t, "network_legacy.json")))
+
+	network, err := client.GetNetwork(fixtureNetworkID)
+	if err != nil {
+		t.Fatalf("GetNetwork() error = %v", err)
+	}
+	if !network.Config.EnableBroadcast || network.Config.MulticastLimit != DefaultMulticastLimit {
+		t.Fatalf("config = %+v", network.Config)
+	}
+
+	// Explicit values, including zero, are kept
+	controller.respond("GET /controller/network/"+fixtureNetworkID, http.StatusOK, `{"id":"8056c2e21c000001","enableBroadcast":false,"multicastLimit":0}`)
+	network, err = client.GetNetwork(fixtureNetworkID)
+	if err != nil {
+		t.Fatalf("GetNetwork() error = %v", err)
+	}
+	if network.Config.EnableBroadcast || network.Config.MulticastLimit != 0 {
+		t.Fatalf("config = %+v", network.Config)
+	}
+}
+
+func TestClientSendsBroadcastAndMulticastSettingsOnlyWhenSet(t *testing.T) {
+	controller, client := newFixtureController(t)
+
+	limit := 64
+	disabled := false
+	if _, err := client.CreateNetworkWithSettings(&Network{Name: "lab"}, &NetworkUpdateRequest{MulticastLimit: &limit, EnableBroadcast: &disabled}); err != nil {
+		t.Fatalf("CreateNetworkWithSettings() error = %v", err)
+	}
+	request := controller.lastRequest(t)
+	if request.body["name"] != "lab" || request.body["multicastLimit"] != float64(64) || request.body["enableBroadcast"] != false {
+		t.Fatalf("create body = %v", request.body)
+	}
+
+	mtu := 1400
+	if _, err := client.PartialUpdateNetwork(fixtureNetworkID, &NetworkUpdateRequest{Mtu: &mtu}); err != nil {
+		t.Fatalf("PartialUpdateNetwork() error = %v", err)
+	}
+	request = controller.lastRequest(t)
+	for _, field := range []string{"enableBroadcast", "multicastLimit"} {
+		if _, ok := request.body[field]; ok {
+			t.Fatalf("partial update sent %s: %v", field, request.body)
+		}
+	}
+}
+
+func TestClientNetworkRules(t *testing.T) {
+	controller, client := newFixtureController(t)
+
+	rules, err := client.GetNetworkRules(fixtureNetworkID)
+	if err != nil {
+		t.Fatalf("GetNetworkRules() error = %v", err)
+	}
+	if len(rules.Rules) != 5 || rules.Rules[0].Type != "MATCH_ETHERTYPE" || !rules.Rules[0].Not || rules.Capabilities == nil || rules.Tags == nil {
+		t.Fatalf("GetNetworkRules() = %+v", rules)
+	}
+
+	if _, err := client.UpdateNetworkRules(fixtureNetworkID, &NetworkRules{Rules: []Rule{{Type: "ACTION_ACCEPT"}}}); err != nil {
+		t.Fatalf("UpdateNetworkRules() error = %v", err)
+	}
+	request := controller.lastRequest(t)
+	if _, ok := request.body["name"]; ok {
+		t.Fatalf("rules update sent network settings: %v", request.body)
+	}
+	if _, ok := request.body["rules"]; !ok {
+		t.Fatalf("rules update sent no rules: %v", request.body)
+	}
+}
+
+func TestClientDeleteNetwork(t *testing.T) {
+	controller, client := newFixtureController(t)
+
+	if err := client.DeleteNetwork(fixtureNetworkID); err != nil {
+		t.Fatalf("DeleteNetwork() error = %v", err)
+	}
+	if request := controller.lastRequest(t); request.method != http.MethodDelete {
+		t.Fatalf("request method = %s", request.method)
+	}
+}
+
+func TestClientGetMembersFollowsTheMemberIndex(t *testing.T) {
+	_, client := newFixtureController(t)
+
+	members, err := client.GetMembers(fixtureNetworkID)
+	if err != nil {
+		t.Fatalf("GetMembers() error = %v", err)
+	}
+	if len(members) != 2 || members[0].ID != "0f1e2d3c4b" || members[1].ID != fixtureMemberID {
+		t.Fatalf("GetMembers() = %+v", members)
+	}
+	if len(members[1].IPAssignments) != 1 || members[1].IPAssignments[0] != "10.147.17.23" {
+		t.Fatalf("ipAssignments = %v", members[1].IPAssignments)
+	}
+}
+
+func TestClientGetMembersAcceptsMemberArrays(t *testing.T) {
+	controller, client := newFixtureController(t)
+	controller.respond("GET /controller/network/"+fixtureNetworkID+"/member", http.StatusOK, string(readFixture(t, "members_array.json")))
+
+	members, err := client.GetMembers(fixtureNetworkID)
+	if err != nil {
+		t.Fatalf("GetMembers() error = %v", err)
+	}
+	if len(members) != 2 || members[0].ID != fixtureMemberID || members[0].Name != "laptop" {
+		t.Fatalf("GetMembers() = %+v", members)
+	}
+	if len(members[0].IPAssignments) != 1 || members[0].IPAssignments[0] != "10.147.17.23" {
+		t.Fatalf("ipAssignments = %v", members[0].IPAssignments)
+	}
+	if request := controller.lastRequest(t); request.path != "/controller/network/"+fixtureNetworkID+"/member" {
+		t.Fatalf("members were fetched one by one: last request %s", request.path)
+	}
+}
+
+func TestClientGetMembersSkipsMembersDeletedSinceTheIndex(t *testing.T) {
+	controller, client := newFixtureController(t)
+	controller.respond("GET /controller/network/"+fixtureNetworkID+"/member", http.StatusOK, `{"a1b2c3d4e5": 3, "0f1e2d3c4b": 1, "ffffffffff": 2}`)
+
+	members, err := client.GetMembers(fixtureNetworkID)
+	if err != nil {
+		t.Fatalf("GetMembers() error = %v", err)
+	}
+	if len(members) != 2 {
+		t.Fatalf("GetMembers() = %+v", members)
+	}
+
+	controller.respond("GET /controller/network/"+fixtureNetworkID+"/member/0f1e2d3c4b", http.StatusInternalServerError, `{}`)
+	if _, err := client.GetMembers(fixtureNetworkID); err == nil || !strings.Contains(err.Error(), "0f1e2d3c4b") {
+		t.Fatalf("GetMembers() error = %v, want the failing member", err)
+	}
+}
+
+func TestClientGetMember(t *testing.T) {
+	_, client := newFixtureController(t)
+
+	member, err := client.GetMember(fixtureNetworkID, fixtureMemberID)
+	if err != nil {
+		t.Fatalf("GetMember() error = %v", err)
+	}
+	if !member.Authorized || !member.Config.Authorized || member.ClientVersion != "1.14.2" {
+		t.Fatalf("GetMember() = %+v", member)
+	}
+	if len(member.IPAssignments) != 1 || member.IPAssignments[0] != "10.147.17.23" {
+		t.Fatalf("ipAssignments = %v", member.IPAssignments)
+	}
+	if len(member.Tags) != 1 || member.Tags[0] != (Tag{ID: 100, Value: 2}) || len(member.Capabilities) != 1 {
+		t.Fatalf("tags = %v, capabilities = %v", member.Tags, member.Capabilities)
+	}
+}
+
+func TestClientUpdateAndDeleteMember(t *testing.T) {
+	controller, client := newFixtureController(t)
+
+	authorized := false
+	if _, err := client.UpdateMember(fixtureNetworkID, fixtureMemberID, &MemberUpdateRequest{Authorized: &authorized}); err != nil {
+		t.Fatalf("UpdateMember() error = %v", err)
+	}
+	request := controller.lastRequest(t)
+	if request.body["authorized"] != false || len(request.body) != 1 {
+		t.Fatalf("update body = %v", request.body)
+	}
+
+	if err := client.DeleteMember(fixtureNetworkID, fixtureMemberID); err != nil {
+		t.Fatalf("DeleteMember() error = %v", err)
+	}
+}
+
+func TestClientGetPeers(t *testing.T) {
+	_, client := newFixtureController(t)
+
+	peers, err := client.GetPeers()
+	if err != nil {
+		t.Fatalf("GetPeers() error = %v", err)
+	}
+	if len(peers) != 2 || peers[0].Address != "778cde7190" || peers[0].Role != "PLANET" {
+		t.Fatalf("GetPeers() = %+v", peers)
+	}
+	if peers[1].Latency != 12 || len(peers[1].Paths) != 1 || !peers[1].Paths[0].Preferred {
+		t.Fatalf("leaf peer = %+v", peers[1])
+	}
+	path := peers[0].Paths[0]
+	if path.Address != "103.195.103.66/9993" || path.LastSend != 1760486399010 || path.LastReceive != 1760486399120 || path.Expired {
+		t.Fatalf("planet path = %+v", path)
+	}
+}
+
+func TestClientGetPeersAtAnotherPath(t *testing.T) {
+	controller, client := newFixtureController(t)
+	controller.respond("GET /node/peer", http.StatusOK, string(readFixture(t, "peers.json")))
+
+	peers, err := client.GetPeersAt("/node/peer")
+	if err != nil {
+		t.Fatalf("GetPeersAt() error = %v", err)
+	}
+	if len(peers) != 2 || controller.lastRequest(t).path != "/node/peer" {
+		t.Fatalf("GetPeersAt() = %+v from %s", peers, controller.lastRequest(t).path)
+	}
+
+	if _, err := client.GetPeersAt("/missing/peer"); !IsNotFound(err) {
+		t.Fatalf("GetPeersAt() without the endpoint error = %v, want a 404 StatusError", err)
+	}
+}
+
+func TestClientReportsUnknownObjects(t *testing.T) {
+	_, client := newFixtureController(t)
+
+	_, err := client.GetMember(fixtureNetworkID, "ffffffffff")
+	if !IsNotFound(err) {
+		t.Fatalf("GetMember() error = %v, want a 404 StatusError", err)
+	}
+	if _, err := client.GetNetwork("ffffffffffffffff"); !IsNotFound(err) {
+		t.Fatalf("GetNetwork() error = %v, want a 404 StatusError", err)
+	}
+}
+
+func TestClientEscapesIDsInRequestPaths(t *testing.T) {
+	controller, client := newFixtureController(t)
+
+	tests := []struct {
+		name string
+		call func() error
+		want string
+	}{
+		{
+			name: "member traversal",
+			call: func() error { _, err := client.GetMember(fixtureNetworkID, "../../../status"); return err },
+			want: "/controller/network/" + fixtureNetworkID + "/member/..%2F..%2F..%2Fstatus",
+		},
+		{
+			name: "network parent segment",
+			call: func() error { _, err := client.GetNetwork(".."); return err },
+			want: "/controller/network/%2E%2E",
+		},
+		{
+			name: "network query characters",
+			call: func() error { return client.DeleteNetwork(fixtureNetworkID + "?x=1#y") },
+			want: "/controller/network/" + fixtureNetworkID + "%3Fx=1%23y",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.call(); !IsNotFound(err) {
+				t.Fatalf("error = %v, want a 404 StatusError", err)
+			}
+			if got := controller.lastRequest(t).escapedPath; got != tt.want {
+				t.Fatalf("path = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClientReportsAuthFailures(t *testing.T) {
+	_, client := newFixtureController(t)
+	client.Token = "wrong-token"
+
+	_, err := client.GetStatus()
+	var statusErr *StatusError
+	if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("GetStatus() error = %v, want a 401 StatusError", err)
+	}
+}
+
+func TestClientStatusErrorsHideTheTokenAndLongBodies(t *testing.T) {
+	controller, client := newFixtureController(t)
+	page := "<html>bad gateway; X-ZT1-Auth: " + fixtureToken + "</html>" + strings.Repeat("x", 4096) + "tail-of-page"
+	controller.respond("GET /status", http.StatusBadGateway, page)
+
+	_, err := client.GetStatus()
+	var statusErr *StatusError
+	if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusBadGateway {
+		t.Fatalf("GetStatus() error = %v, want a 502 StatusError", err)
+	}
+	if strings.Contains(statusErr.Body, fixtureToken) || !strings.Contains(statusErr.Body, "[redacted]") {
+		t.Fatalf("StatusError.Body = %q, want the token redacted", statusErr.Body[:80])
+	}
+	if message := err.Error(); strings.Contains(message, fixtureToken) || strings.Contains(message, "tail-of-page") {
+		t.Fatalf("error = %q, want neither the token nor the whole body", message)
+	}
+}
+
+func TestClientReportsInvalidJSON(t *testing.T) {
+	controller, client := newFixtureController(t)
+	controller.respond("GET /status", http.StatusOK, `<html>proxy error</html>`)
+	controller.respond("GET /controller/network/"+fixtureNetworkID+"/member/"+fixtureMemberID, http.StatusOK, `{"id":`)
+	controller.respond("GET /controller/network", http.StatusOK, `42`)
+
+	if _, err := client.GetStatus(); err == nil || !strings.Contains(err.Error(), "preview: <html>proxy error</html>") {
+		t.Fatalf("GetStatus() error = %v, want a decode error with a preview", err)
+	}
+	if _, err := client.GetMember(fixtureNetworkID, fixtureMemberID); err == nil || IsNotFound(err) {
+		t.Fatalf("GetMember() error = %v, want a decode error", err)
+	}
+	if _, err := client.GetNetworkIDs(); err == nil {
+		t.Fatal("GetNetworkIDs() error = nil, want an unsupported format error")
+	}
+}
+
+func TestNewClientWithOptionsDefaultsTheHTTPClient(t *testing.T) {
+	client := NewClientWithOptions("http://127.0.0.1:9993", "token", nil)
+	if client.HTTPClient == nil || client.HTTPClient.Timeout == 0 {
+		t.Fatalf("HTTPClient = %+v, want the default client with a timeout", client.HTTPClient)
+	}
+
+	custom := &http.Client{}
+	if NewClientWithOptions("http://127.0.0.1:9993", "token", custom).HTTPClient != custom {
+		t.Fatal("NewClientWithOptions() did not keep the given HTTP client")
+	}
+}
+
+func TestClientReloadsARotatedTokenFile(t *testing.T) {
+	controller, fixtureClient := newFixtureController(t)
+	tokenPath := filepath.Join(t.TempDir(), "authtoken.secret")
+	if err := os.WriteFile(tokenPath, []byte("token-before-rotation\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	client, err := NewClientForController(config.ZeroTierControllerConfig{Name: "lab", URL: fixtureClient.BaseURL, TokenPath: tokenPath})
+	if err != nil {
+		t.Fatalf("NewClientForController() error = %v", err)
+	}
+	client.HTTPClient = fixtureClient.HTTPClient
+
+	if _, err := client.GetStatus(); err == nil {
+		t.Fatal("GetStatus() error = nil, want 401 before the file holds the controller's token")
+	}
+
+	if err := os.WriteFile(tokenPath, []byte(fixtureToken+"\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	authorized := true
+	if _, err := client.UpdateMember(fixtureNetworkID, fixtureMemberID, &MemberUpdateRequest{Authorized: &authorized}); err != nil {
+		t.Fatalf("UpdateMember() error = %v, want a retry with the rotated token", err)
+	}
+	if request := controller.lastRequest(t); request.body["authorized"] != true {
+		t.Fatalf("retried request body = %v, want the original body", request.body)
+	}
+	if client.currentToken() != fixtureToken {
+		t.Fatalf("token = %q, want the rotated token kept", client.currentToken())
+	}
+}
+
+func TestClientDoesNotRetryWithAnUnchangedToken(t *testing.T) {
+	_, client := newFixtureController(t)
+	client.Token = "stale-token"
+	calls := 0
+	client.TokenSource = func() (string, error) {
+		calls++
+		return "stale-token", nil
+	}
+
+	_, err := client.GetStatus()
+	var statusErr *StatusError
+	if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("GetStatus() error = %v, want a 401 StatusError", err)
+	}
+	if calls != 1 {
+		t.Fatalf("TokenSource calls = %d, want 1", calls)
+	}
+}
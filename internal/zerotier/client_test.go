@@ -2,6 +2,7 @@ package zerotier
 
 import (
 	"encoding/json"
+	"strings"
 	"testing"
 )
 
@@ -68,6 +69,25 @@ func TestParseNetworkIDsSupportsCommonResponseShapes(t *testing.T) {
 	}
 }
 
+func TestDNSConfigMarshalJSONSendsAnEmptyObjectToClear(t *testing.T) {
+	update := NetworkUpdateRequest{DNS: &DNSConfig{}}
+	data, err := json.Marshal(update)
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+	if !strings.Contains(string(data), `"dns":{"domain":"","servers":[]}`) {
+		t.Fatalf("json.Marshal() = %s", data)
+	}
+
+	data, err = json.Marshal(NetworkUpdateRequest{})
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+	if strings.Contains(string(data), `"dns"`) {
+		t.Fatalf("json.Marshal() of an update without DNS = %s", data)
+	}
+}
+
 func TestDNSConfigUnmarshalJSONSupportsArrayNullAndObject(t *testing.T) {
 	testCases := []struct {
 		name string
@@ -124,3 +144,51 @@ func TestMemberUnmarshalJSONUsesVersionFallbackWhenAvailable(t *testing.T) {
 		t.Fatalf("clientVersion = %q, want %q", member.ClientVersion, "1.14.2")
 	}
 }
+
+func TestNetworkUnmarshalJSONKeepsIPv6AssignModes(t *testing.T) {
+	// Captured from a ZeroTier 1.14 controller GET /controller/network/{id} response.
+	data := `{
+		"id":"8056c2e21c000001",
+		"nwid":"8056c2e21c000001",
+		"name":"lab-net",
+		"private":true,
+		"mtu":2800,
+		"multicastLimit":32,
+		"enableBroadcast":true,
+		"v4AssignMode":{"zt":true},
+		"v6AssignMode":{"6plane":true,"rfc4193":true,"zt":false},
+		"routes":[{"target":"10.147.17.0/24","via":null}],
+		"dns":[],
+		"creationTime":1700000000000,
+		"objtype":"network"
+	}`
+
+	var network Network
+	if err := json.Unmarshal([]byte(data), &network); err != nil {
+		t.Fatalf("json.Unmarshal() error = %v", err)
+	}
+
+	mode := network.Config.V6AssignMode
+	if !mode.Plane6 || !mode.Rfc4193 || mode.ZT {
+		t.Fatalf("v6AssignMode = %+v, want 6plane and rfc4193 only", mode)
+	}
+}
+
+func TestNetworkUpdateRequestMarshalsIPv6AssignModes(t *testing.T) {
+	req := NetworkUpdateRequest{Private: true, V6AssignMode: &V6AssignmentMode{Plane6: true}}
+
+	data, err := json.Marshal(req)
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+
+	var payload struct {
+		V6AssignMode map[string]bool `json:"v6AssignMode"`
+	}
+	if err := json.Unmarshal(data, &payload); err != nil {
+		t.Fatalf("json.Unmarshal() error = %v", err)
+	}
+	if !payload.V6AssignMode["6plane"] || payload.V6AssignMode["rfc4193"] || payload.V6AssignMode["zt"] {
+		t.Fatalf("v6AssignMode payload = %v, want only 6plane enabled", payload.V6AssignMode)
+	}
+}
@@ -0,0 +1,89 @@
+package zerotier
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// ConnectionCheck is the result of TestConnection. Each step is only tried once the one
+// before it passed, so the first false field is the one that failed.
+type ConnectionCheck struct {
+	// Reachable reports whether the address answered like a ZeroTier node
+	Reachable bool `json:"reachable"`
+	// Authenticated reports whether the node accepted the token
+	Authenticated bool `json:"authenticated"`
+	// ControllerAccess reports whether the token may use the controller API
+	ControllerAccess bool   `json:"controllerAccess"`
+	Version          string `json:"version,omitempty"`
+	// LatencyMs is the round trip time of the status request
+	LatencyMs int64  `json:"latencyMs"`
+	Error     string `json:"error,omitempty"`
+}
+
+// OK reports whether every step passed
+func (c ConnectionCheck) OK() bool {
+	return c.Reachable && c.Authenticated && c.ControllerAccess
+}
+
+// TestConnection checks that the controller can be reached, accepts the token and lets it
+// use the controller API. The node status is readable with a token that cannot manage
+// networks, so the network list is read as well.
+func (c *Client) TestConnection(ctx context.Context) ConnectionCheck {
+	started := time.Now()
+	status, err := c.GetStatusContext(ctx)
+	check := ConnectionCheck{LatencyMs: time.Since(started).Milliseconds()}
+
+	var statusErr *StatusError
+	switch {
+	case errors.As(err, &statusErr) && isAuthStatus(statusErr.StatusCode):
+		check.Reachable = true
+		check.Error = fmt.Sprintf("the controller rejected the token (status %d); check that it is the node's authtoken.secret", statusErr.StatusCode)
+		return check
+	case errors.As(err, &statusErr):
+		check.Error = fmt.Sprintf("the address answered with status %d instead of the ZeroTier node status; check the controller URL", statusErr.StatusCode)
+		return check
+	case errors.As(err, new(*url.Error)):
+		check.Error = fmt.Sprintf("the controller could not be reached: %v", err)
+		return check
+	case err != nil:
+		check.Error = fmt.Sprintf("the address did not answer like a ZeroTier node: %v", err)
+		return check
+	}
+	check.Reachable = true
+	check.Authenticated = true
+	check.Version = status.Version
+
+	if err := c.CheckControllerAccess(ctx); err != nil {
+		check.Error = err.Error()
+		return check
+	}
+	check.ControllerAccess = true
+	return check
+}
+
+// CheckControllerAccess lists the controller's networks to confirm the token may use the
+// controller API, and explains why it may not
+func (c *Client) CheckControllerAccess(ctx context.Context) error {
+	respBody, err := c.doRequestContext(ctx, "GET", "/controller/network", nil)
+	var statusErr *StatusError
+	switch {
+	case errors.As(err, &statusErr) && isAuthStatus(statusErr.StatusCode):
+		return fmt.Errorf("the token was accepted but may not use the controller API (status %d); use the controller's authtoken.secret", statusErr.StatusCode)
+	case errors.As(err, &statusErr) && statusErr.StatusCode == http.StatusNotFound:
+		return errors.New("the node does not run a network controller")
+	case err != nil:
+		return fmt.Errorf("the controller API could not be read: %w", err)
+	}
+	if _, err := parseNetworkIDs(respBody); err != nil {
+		return fmt.Errorf("the controller API answered with an unexpected network list: %w", err)
+	}
+	return nil
+}
+
+func isAuthStatus(code int) bool {
+	return code == http.StatusUnauthorized || code == http.StatusForbidden
+}
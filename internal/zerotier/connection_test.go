@@ -0,0 +1,84 @@
+package zerotier
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestClientTestConnectionPassesWithControllerAccess(t *testing.T) {
+	_, client := newFixtureController(t)
+
+	check := client.TestConnection(context.Background())
+	if !check.OK() || check.Error != "" || check.Version == "" {
+		t.Fatalf("TestConnection() = %+v", check)
+	}
+}
+
+func TestClientTestConnectionReportsEachFailure(t *testing.T) {
+	testCases := []struct {
+		name    string
+		routes  map[string]int
+		want    ConnectionCheck
+		message string
+	}{
+		{
+			name:    "token rejected",
+			routes:  map[string]int{"GET /status": http.StatusUnauthorized},
+			want:    ConnectionCheck{Reachable: true},
+			message: "rejected the token (status 401)",
+		},
+		{
+			name:    "controller path unauthorized",
+			routes:  map[string]int{"GET /controller/network": http.StatusUnauthorized},
+			want:    ConnectionCheck{Reachable: true, Authenticated: true},
+			message: "may not use the controller API (status 401)",
+		},
+		{
+			name:    "controller path forbidden",
+			routes:  map[string]int{"GET /controller/network": http.StatusForbidden},
+			want:    ConnectionCheck{Reachable: true, Authenticated: true},
+			message: "may not use the controller API (status 403)",
+		},
+		{
+			name:    "no controller",
+			routes:  map[string]int{"GET /controller/network": http.StatusNotFound},
+			want:    ConnectionCheck{Reachable: true, Authenticated: true},
+			message: "does not run a network controller",
+		},
+		{
+			name:    "not a ZeroTier node",
+			routes:  map[string]int{"GET /status": http.StatusBadGateway},
+			want:    ConnectionCheck{},
+			message: "status 502 instead of the ZeroTier node status",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			controller, client := newFixtureController(t)
+			for route, status := range tc.routes {
+				controller.respond(route, status, `{}`)
+			}
+
+			check := client.TestConnection(context.Background())
+			if check.Reachable != tc.want.Reachable || check.Authenticated != tc.want.Authenticated || check.ControllerAccess {
+				t.Fatalf("TestConnection() = %+v", check)
+			}
+			if !strings.Contains(check.Error, tc.message) {
+				t.Fatalf("error = %q, want it to mention %q", check.Error, tc.message)
+			}
+		})
+	}
+}
+
+func TestClientTestConnectionReportsAnUnreachableController(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	server.Close()
+
+	check := NewClientWithOptions(server.URL, fixtureToken, nil).TestConnection(context.Background())
+	if check.Reachable || !strings.Contains(check.Error, "could not be reached") {
+		t.Fatalf("TestConnection() = %+v", check)
+	}
+}
@@ -0,0 +1,162 @@
+package zerotier
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Version is a controller version such as 1.14.2. The zero Version means the version is
+// unknown, e.g. because a proxy in front of the controller does not report one.
+type Version struct {
+	Major int
+	Minor int
+	Patch int
+}
+
+// ParseVersion reads a version of the form major.minor[.patch]; a pre-release or build
+// suffix such as -beta1 is ignored
+func ParseVersion(raw string) (Version, error) {
+	trimmed := strings.TrimPrefix(strings.TrimSpace(raw), "v")
+	if end := strings.IndexAny(trimmed, "-+ "); end >= 0 {
+		trimmed = trimmed[:end]
+	}
+	parts := strings.Split(trimmed, ".")
+	if len(parts) < 2 || len(parts) > 3 {
+		return Version{}, fmt.Errorf("invalid controller version %q", raw)
+	}
+	numbers := make([]int, 3)
+	for i, part := range parts {
+		number, err := strconv.Atoi(part)
+		if err != nil || number < 0 {
+			return Version{}, fmt.Errorf("invalid controller version %q", raw)
+		}
+		numbers[i] = number
+	}
+	return Version{Major: numbers[0], Minor: numbers[1], Patch: numbers[2]}, nil
+}
+
+// Compare returns -1, 0 or 1 when v is older than, the same as or newer than other
+func (v Version) Compare(other Version) int {
+	for _, diff := range []int{v.Major - other.Major, v.Minor - other.Minor, v.Patch - other.Patch} {
+		switch {
+		case diff < 0:
+			return -1
+		case diff > 0:
+			return 1
+		}
+	}
+	return 0
+}
+
+// AtLeast reports whether v is other or newer
+func (v Version) AtLeast(other Version) bool {
+	return v.Compare(other) >= 0
+}
+
+// IsZero reports whether the version is unknown
+func (v Version) IsZero() bool {
+	return v == Version{}
+}
+
+func (v Version) String() string {
+	return fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+}
+
+// Feature is a network setting that only some controller versions accept
+type Feature string
+
+const (
+	FeatureMulticastLimit Feature = "multicastLimit"
+	FeatureBroadcast      Feature = "broadcast"
+	FeatureDNS            Feature = "dns"
+	FeatureSSO            Feature = "sso"
+)
+
+// featureVersions is the oldest controller version accepting each feature, oldest first.
+// Older controllers reject a network update carrying the field.
+var featureVersions = []struct {
+	feature Feature
+	since   Version
+	label   string
+}{
+	{FeatureMulticastLimit, Version{1, 10, 0}, "the multicast limit"},
+	{FeatureBroadcast, Version{1, 10, 0}, "the broadcast setting"},
+	{FeatureDNS, Version{1, 12, 0}, "DNS configuration"},
+	{FeatureSSO, Version{1, 14, 0}, "single sign-on"},
+}
+
+// FeatureSet is what a controller version supports. A set for an unknown version
+// supports every feature, since Tairitsu cannot tell what to hold back.
+type FeatureSet struct {
+	Version Version
+}
+
+// FeaturesForVersion returns the features of a controller reporting raw as its version;
+// a version that cannot be parsed gives the set of an unknown version
+func FeaturesForVersion(raw string) FeatureSet {
+	version, err := ParseVersion(raw)
+	if err != nil {
+		return FeatureSet{}
+	}
+	return FeatureSet{Version: version}
+}
+
+// Supports reports whether the controller accepts feature
+func (f FeatureSet) Supports(feature Feature) bool {
+	if f.Version.IsZero() {
+		return true
+	}
+	for _, entry := range featureVersions {
+		if entry.feature == feature {
+			return f.Version.AtLeast(entry.since)
+		}
+	}
+	return true
+}
+
+// List returns the supported features, oldest first
+func (f FeatureSet) List() []Feature {
+	features := make([]Feature, 0, len(featureVersions))
+	for _, entry := range featureVersions {
+		if f.Supports(entry.feature) {
+			features = append(features, entry.feature)
+		}
+	}
+	return features
+}
+
+// UnsupportedFeatureError is returned for a network update using a feature the
+// controller is too old to accept
+type UnsupportedFeatureError struct {
+	Feature Feature
+	// Required is the oldest controller version accepting the feature
+	Required Version
+	// Controller is the version the controller reported
+	Controller Version
+	label      string
+}
+
+func (e *UnsupportedFeatureError) Error() string {
+	return fmt.Sprintf("%s requires ZeroTier %s or newer, but the controller runs %s", e.label, e.Required, e.Controller)
+}
+
+// CheckNetworkUpdate returns an *UnsupportedFeatureError for the first field of req the
+// controller does not accept
+func (f FeatureSet) CheckNetworkUpdate(req *NetworkUpdateRequest) error {
+	if req == nil {
+		return nil
+	}
+	used := map[Feature]bool{
+		FeatureMulticastLimit: req.MulticastLimit != nil,
+		FeatureBroadcast:      req.EnableBroadcast != nil,
+		FeatureDNS:            req.DNS != nil,
+		FeatureSSO:            req.SsoEnabled != nil && *req.SsoEnabled,
+	}
+	for _, entry := range featureVersions {
+		if used[entry.feature] && !f.Supports(entry.feature) {
+			return &UnsupportedFeatureError{Feature: entry.feature, Required: entry.since, Controller: f.Version, label: entry.label}
+		}
+	}
+	return nil
+}
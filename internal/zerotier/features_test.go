@@ -0,0 +1,96 @@
+package zerotier
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestParseVersion(t *testing.T) {
+	testCases := []struct {
+		raw  string
+		want Version
+	}{
+		{raw: "1.14.2", want: Version{1, 14, 2}},
+		{raw: "1.12", want: Version{1, 12, 0}},
+		{raw: " v1.10.6-beta1 ", want: Version{1, 10, 6}},
+		{raw: "1.8.4+build", want: Version{1, 8, 4}},
+	}
+	for _, tc := range testCases {
+		got, err := ParseVersion(tc.raw)
+		if err != nil || got != tc.want {
+			t.Fatalf("ParseVersion(%q) = %v, %v; want %v", tc.raw, got, err, tc.want)
+		}
+	}
+
+	for _, raw := range []string{"", "unknown", "1", "1.x.2", "1.2.3.4", "1.-2.0"} {
+		if _, err := ParseVersion(raw); err == nil {
+			t.Fatalf("ParseVersion(%q) succeeded", raw)
+		}
+	}
+}
+
+func TestVersionCompare(t *testing.T) {
+	if !(Version{1, 12, 0}).AtLeast(Version{1, 10, 6}) || (Version{1, 10, 6}).AtLeast(Version{1, 12, 0}) {
+		t.Fatal("1.12.0 must be newer than 1.10.6")
+	}
+	if (Version{1, 14, 2}).Compare(Version{1, 14, 2}) != 0 || (Version{2, 0, 0}).Compare(Version{1, 99, 9}) != 1 {
+		t.Fatal("Compare ordered versions wrongly")
+	}
+}
+
+func TestFeaturesDifferByControllerVersion(t *testing.T) {
+	testCases := []struct {
+		version string
+		want    []Feature
+	}{
+		{version: "1.8.4", want: []Feature{}},
+		{version: "1.10.6", want: []Feature{FeatureMulticastLimit, FeatureBroadcast}},
+		{version: "1.12.2", want: []Feature{FeatureMulticastLimit, FeatureBroadcast, FeatureDNS}},
+		{version: "1.14.0", want: []Feature{FeatureMulticastLimit, FeatureBroadcast, FeatureDNS, FeatureSSO}},
+		// Without a version nothing is held back
+		{version: "", want: []Feature{FeatureMulticastLimit, FeatureBroadcast, FeatureDNS, FeatureSSO}},
+	}
+	for _, tc := range testCases {
+		if got := FeaturesForVersion(tc.version).List(); !reflect.DeepEqual(got, tc.want) {
+			t.Fatalf("FeaturesForVersion(%q).List() = %v, want %v", tc.version, got, tc.want)
+		}
+	}
+}
+
+func TestCheckNetworkUpdateNamesTheVersionNeeded(t *testing.T) {
+	limit := 32
+	enabled := true
+	disabled := false
+	dns := &NetworkUpdateRequest{DNS: &DNSConfig{Domain: "lab.example"}}
+
+	if err := FeaturesForVersion("1.10.6").CheckNetworkUpdate(&NetworkUpdateRequest{MulticastLimit: &limit, EnableBroadcast: &disabled}); err != nil {
+		t.Fatalf("1.10 rejected the multicast limit: %v", err)
+	}
+	err := FeaturesForVersion("1.8.4").CheckNetworkUpdate(&NetworkUpdateRequest{EnableBroadcast: &disabled})
+	var unsupported *UnsupportedFeatureError
+	if !errors.As(err, &unsupported) || unsupported.Feature != FeatureBroadcast {
+		t.Fatalf("1.8 accepted the broadcast setting: %v", err)
+	}
+	err = FeaturesForVersion("1.10.6").CheckNetworkUpdate(dns)
+	if !errors.As(err, &unsupported) || unsupported.Feature != FeatureDNS || unsupported.Required != (Version{1, 12, 0}) {
+		t.Fatalf("1.10 accepted DNS configuration: %v", err)
+	}
+	if err.Error() != "DNS configuration requires ZeroTier 1.12.0 or newer, but the controller runs 1.10.6" {
+		t.Fatalf("error = %q", err.Error())
+	}
+
+	if err := FeaturesForVersion("1.12.2").CheckNetworkUpdate(dns); err != nil {
+		t.Fatalf("1.12 rejected DNS configuration: %v", err)
+	}
+	err = FeaturesForVersion("1.12.2").CheckNetworkUpdate(&NetworkUpdateRequest{SsoEnabled: &enabled})
+	if !errors.As(err, &unsupported) || unsupported.Feature != FeatureSSO {
+		t.Fatalf("1.12 accepted single sign-on: %v", err)
+	}
+	if err := FeaturesForVersion("1.12.2").CheckNetworkUpdate(&NetworkUpdateRequest{SsoEnabled: &disabled}); err != nil {
+		t.Fatalf("1.12 rejected disabling single sign-on: %v", err)
+	}
+	if err := FeaturesForVersion("1.14.2").CheckNetworkUpdate(&NetworkUpdateRequest{SsoEnabled: &enabled, DNS: dns.DNS}); err != nil {
+		t.Fatalf("1.14 rejected single sign-on: %v", err)
+	}
+}
@@ -0,0 +1,56 @@
+package zerotier
+
+import (
+	"encoding/binary"
+	"fmt"
+	"net"
+	"strconv"
+)
+
+const (
+	rfc4193PrefixBits  = 88
+	sixPlanePrefixBits = 40
+)
+
+// RFC4193Prefix returns the /88 RFC4193 prefix ZeroTier derives from a network ID.
+// Each member address is formed by appending its 40-bit node ID to this prefix.
+func RFC4193Prefix(networkID string) (string, error) {
+	nwid, err := parseNetworkID(networkID)
+	if err != nil {
+		return "", err
+	}
+
+	ip := make(net.IP, net.IPv6len)
+	ip[0] = 0xfd
+	binary.BigEndian.PutUint64(ip[1:9], nwid)
+	ip[9] = 0x99
+	ip[10] = 0x93
+
+	return fmt.Sprintf("%s/%d", ip.String(), rfc4193PrefixBits), nil
+}
+
+// SixPlanePrefix returns the /40 6PLANE prefix ZeroTier derives from a network ID.
+// Each member is assigned a /80 under this prefix keyed by its node ID.
+func SixPlanePrefix(networkID string) (string, error) {
+	nwid, err := parseNetworkID(networkID)
+	if err != nil {
+		return "", err
+	}
+
+	ip := make(net.IP, net.IPv6len)
+	ip[0] = 0xfc
+	binary.BigEndian.PutUint32(ip[1:5], uint32(nwid>>32)^uint32(nwid))
+
+	return fmt.Sprintf("%s/%d", ip.String(), sixPlanePrefixBits), nil
+}
+
+func parseNetworkID(networkID string) (uint64, error) {
+	if len(networkID) != 16 {
+		return 0, fmt.Errorf("invalid network ID %q: must be 16 hex characters", networkID)
+	}
+	nwid, err := strconv.ParseUint(networkID, 16, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid network ID %q: %w", networkID, err)
+	}
+	return nwid, nil
+}
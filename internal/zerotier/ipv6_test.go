@@ -0,0 +1,34 @@
+package zerotier
+
+import "testing"
+
+func TestRFC4193PrefixDerivesFromNetworkID(t *testing.T) {
+	got, err := RFC4193Prefix("8056c2e21c000001")
+	if err != nil {
+		t.Fatalf("RFC4193Prefix() error = %v", err)
+	}
+	if want := "fd80:56c2:e21c:0:199:9300::/88"; got != want {
+		t.Fatalf("RFC4193Prefix() = %q, want %q", got, want)
+	}
+}
+
+func TestSixPlanePrefixFoldsNetworkID(t *testing.T) {
+	got, err := SixPlanePrefix("8056c2e21c000001")
+	if err != nil {
+		t.Fatalf("SixPlanePrefix() error = %v", err)
+	}
+	if want := "fc9c:56c2:e300::/40"; got != want {
+		t.Fatalf("SixPlanePrefix() = %q, want %q", got, want)
+	}
+}
+
+func TestIPv6PrefixRejectsInvalidNetworkID(t *testing.T) {
+	for _, id := range []string{"", "8056c2e21c00000", "zzzzzzzzzzzzzzzz"} {
+		if _, err := RFC4193Prefix(id); err == nil {
+			t.Fatalf("RFC4193Prefix(%q) error = nil, want error", id)
+		}
+		if _, err := SixPlanePrefix(id); err == nil {
+			t.Fatalf("SixPlanePrefix(%q) error = nil, want error", id)
+		}
+	}
+}
@@ -0,0 +1,479 @@
+// Package ztmock provides an in-memory ZeroTier controller that speaks the subset
+// of the controller API used by Tairitsu. It backs demo mode and tests.
+package ztmock
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	networkPathPrefix = "/controller/network"
+	defaultVersion    = "1.14.2"
+)
+
+// Controller is an in-memory ZeroTier controller.
+type Controller struct {
+	Address string
+	Token   string
+	// Version is the version the status endpoint reports; empty means 1.14.2
+	Version string
+
+	mutex    sync.RWMutex
+	networks map[string]map[string]any
+	members  map[string]map[string]map[string]any
+
+	server   *http.Server
+	listener net.Listener
+}
+
+// NewController creates an empty controller with the given 10-digit node address.
+func NewController(address string) *Controller {
+	return &Controller{
+		Address:  address,
+		Token:    randomHex(12),
+		networks: make(map[string]map[string]any),
+		members:  make(map[string]map[string]map[string]any),
+	}
+}
+
+// Start serves the controller API on a loopback port and returns its base URL.
+func (c *Controller) Start() (string, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return "", fmt.Errorf("failed to listen for mock controller: %w", err)
+	}
+
+	c.listener = listener
+	c.server = &http.Server{Handler: c, ReadHeaderTimeout: 5 * time.Second}
+	go func() {
+		_ = c.server.Serve(listener)
+	}()
+
+	return "http://" + listener.Addr().String(), nil
+}
+
+// Close stops the controller HTTP server.
+func (c *Controller) Close() error {
+	if c.server == nil {
+		return nil
+	}
+	return c.server.Close()
+}
+
+// AddNetwork stores a network in flat controller form and returns its ID.
+func (c *Controller) AddNetwork(fields map[string]any) string {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.addNetworkLocked(fields)
+}
+
+// AddMember stores a member in flat controller form.
+func (c *Controller) AddMember(networkID string, memberID string, fields map[string]any) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.addMemberLocked(networkID, memberID, fields)
+}
+
+// NetworkIDs returns the IDs of every stored network in sorted order.
+func (c *Controller) NetworkIDs() []string {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	return c.networkIDsLocked()
+}
+
+// ServeHTTP implements the controller API.
+func (c *Controller) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("X-ZT1-Auth") != c.Token {
+		writeJSON(w, http.StatusUnauthorized, map[string]any{"error": "unauthorized"})
+		return
+	}
+
+	path := strings.TrimSuffix(r.URL.Path, "/")
+	switch {
+	case path == "/status":
+		c.handleStatus(w, r)
+	case path == "/peer":
+		c.handlePeers(w, r)
+	case path == networkPathPrefix || strings.HasPrefix(path, networkPathPrefix+"/"):
+		c.handleController(w, r, strings.Trim(strings.TrimPrefix(path, networkPathPrefix), "/"))
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (c *Controller) handleStatus(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	version := c.Version
+	if version == "" {
+		version = defaultVersion
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"address":              c.Address,
+		"version":              version,
+		"online":               true,
+		"tcpFallbackActive":    false,
+		"tcpFallbackAvailable": false,
+		"apiReady":             true,
+	})
+}
+
+func (c *Controller) handlePeers(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
+	peers := make([]map[string]any, 0)
+	seen := make(map[string]struct{})
+	for _, networkID := range c.networkIDsLocked() {
+		for _, memberID := range sortedKeys(c.members[networkID]) {
+			member := c.members[networkID][memberID]
+			if online, _ := member["online"].(bool); !online {
+				continue
+			}
+			if _, ok := seen[memberID]; ok {
+				continue
+			}
+			seen[memberID] = struct{}{}
+			peers = append(peers, map[string]any{
+				"address": memberID,
+				"latency": member["peerLatency"],
+				"role":    "LEAF",
+				"version": member["clientVersion"],
+				"paths": []map[string]any{{
+					"active":    true,
+					"preferred": true,
+					"address":   member["physicalAddress"],
+				}},
+			})
+		}
+	}
+
+	writeJSON(w, http.StatusOK, peers)
+}
+
+func (c *Controller) handleController(w http.ResponseWriter, r *http.Request, rest string) {
+	parts := []string{}
+	if rest != "" {
+		parts = strings.Split(rest, "/")
+	}
+
+	switch {
+	case len(parts) == 0:
+		c.handleNetworkCollection(w, r)
+	case len(parts) == 1 && strings.HasSuffix(parts[0], "______") && r.Method == http.MethodPost:
+		c.createNetwork(w, r)
+	case len(parts) == 1:
+		c.handleNetwork(w, r, parts[0])
+	case len(parts) == 2 && parts[1] == "member":
+		c.handleMemberCollection(w, r, parts[0])
+	case len(parts) == 3 && parts[1] == "member":
+		c.handleMember(w, r, parts[0], parts[2])
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (c *Controller) handleNetworkCollection(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, http.StatusOK, c.NetworkIDs())
+	case http.MethodPost:
+		c.createNetwork(w, r)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func (c *Controller) createNetwork(w http.ResponseWriter, r *http.Request) {
+	fields, ok := decodeBody(w, r)
+	if !ok {
+		return
+	}
+
+	c.mutex.Lock()
+	id := c.addNetworkLocked(fields)
+	network := cloneMap(c.networks[id])
+	c.mutex.Unlock()
+
+	writeJSON(w, http.StatusOK, network)
+}
+
+func (c *Controller) handleNetwork(w http.ResponseWriter, r *http.Request, networkID string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	network, ok := c.networks[networkID]
+	if !ok && r.Method == http.MethodPost && len(networkID) == 16 && strings.HasPrefix(networkID, c.Address) {
+		// Like the real controller, posting to an unused ID under this controller creates it
+		fields, decoded := decodeBody(w, r)
+		if !decoded {
+			return
+		}
+		fields["id"] = networkID
+		c.addNetworkLocked(fields)
+		writeJSON(w, http.StatusOK, cloneMap(c.networks[networkID]))
+		return
+	}
+	if !ok {
+		writeJSON(w, http.StatusNotFound, map[string]any{"error": "network not found"})
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, http.StatusOK, cloneMap(network))
+	case http.MethodPost:
+		fields, ok := decodeBody(w, r)
+		if !ok {
+			return
+		}
+		mergeFields(network, fields)
+		network["revision"] = intValue(network["revision"]) + 1
+		network["lastModifiedTime"] = time.Now().UnixMilli()
+		writeJSON(w, http.StatusOK, cloneMap(network))
+	case http.MethodDelete:
+		delete(c.networks, networkID)
+		delete(c.members, networkID)
+		writeJSON(w, http.StatusOK, cloneMap(network))
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func (c *Controller) handleMemberCollection(w http.ResponseWriter, r *http.Request, networkID string) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
+	if _, ok := c.networks[networkID]; !ok {
+		writeJSON(w, http.StatusNotFound, map[string]any{"error": "network not found"})
+		return
+	}
+
+	members := make([]map[string]any, 0, len(c.members[networkID]))
+	for _, memberID := range sortedKeys(c.members[networkID]) {
+		members = append(members, cloneMap(c.members[networkID][memberID]))
+	}
+	writeJSON(w, http.StatusOK, members)
+}
+
+func (c *Controller) handleMember(w http.ResponseWriter, r *http.Request, networkID, memberID string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if _, ok := c.networks[networkID]; !ok {
+		writeJSON(w, http.StatusNotFound, map[string]any{"error": "network not found"})
+		return
+	}
+	member, ok := c.members[networkID][memberID]
+
+	switch r.Method {
+	case http.MethodGet:
+		if !ok {
+			writeJSON(w, http.StatusNotFound, map[string]any{"error": "member not found"})
+			return
+		}
+		writeJSON(w, http.StatusOK, cloneMap(member))
+	case http.MethodPost:
+		fields, decoded := decodeBody(w, r)
+		if !decoded {
+			return
+		}
+		if !ok {
+			c.addMemberLocked(networkID, memberID, fields)
+			member = c.members[networkID][memberID]
+		} else {
+			mergeFields(member, fields)
+			member["revision"] = intValue(member["revision"]) + 1
+		}
+		writeJSON(w, http.StatusOK, cloneMap(member))
+	case http.MethodDelete:
+		if !ok {
+			writeJSON(w, http.StatusNotFound, map[string]any{"error": "member not found"})
+			return
+		}
+		delete(c.members[networkID], memberID)
+		writeJSON(w, http.StatusOK, cloneMap(member))
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func (c *Controller) addNetworkLocked(fields map[string]any) string {
+	id, _ := fields["id"].(string)
+	if len(id) != 16 {
+		for {
+			id = c.Address + randomHex(3)
+			if _, exists := c.networks[id]; !exists {
+				break
+			}
+		}
+	}
+
+	now := time.Now().UnixMilli()
+	network := map[string]any{
+		"id":                id,
+		"nwid":              id,
+		"objtype":           "network",
+		"name":              "",
+		"private":           true,
+		"enableBroadcast":   true,
+		"mtu":               2800,
+		"multicastLimit":    32,
+		"creationTime":      now,
+		"lastModifiedTime":  now,
+		"revision":          1,
+		"routes":            []any{},
+		"ipAssignmentPools": []any{},
+		"rules":             []any{map[string]any{"type": "ACTION_ACCEPT"}},
+		"tags":              []any{},
+		"capabilities":      []any{},
+		"dns":               []any{},
+		"v4AssignMode":      map[string]any{"zt": false},
+		"v6AssignMode":      map[string]any{"zt": false, "6plane": false, "rfc4193": false},
+	}
+	mergeFields(network, fields)
+	keepCreationTime(network, fields)
+	network["id"] = id
+	network["nwid"] = id
+
+	c.networks[id] = network
+	if _, ok := c.members[id]; !ok {
+		c.members[id] = make(map[string]map[string]any)
+	}
+	return id
+}
+
+func (c *Controller) addMemberLocked(networkID, memberID string, fields map[string]any) {
+	if _, ok := c.members[networkID]; !ok {
+		c.members[networkID] = make(map[string]map[string]any)
+	}
+
+	member := map[string]any{
+		"id":              memberID,
+		"address":         memberID,
+		"nwid":            networkID,
+		"objtype":         "member",
+		"authorized":      false,
+		"activeBridge":    false,
+		"ipAssignments":   []any{},
+		"noAutoAssignIps": false,
+		"tags":            []any{},
+		"capabilities":    []any{},
+		"creationTime":    time.Now().UnixMilli(),
+		"revision":        1,
+	}
+	mergeFields(member, fields)
+	keepCreationTime(member, fields)
+	member["id"] = memberID
+	member["address"] = memberID
+	member["nwid"] = networkID
+
+	c.members[networkID][memberID] = member
+}
+
+func (c *Controller) networkIDsLocked() []string {
+	return sortedKeys(c.networks)
+}
+
+// mergeFields applies a request body to a stored object. Nested "config" objects,
+// as sent by clients that marshal the structured network type, are flattened.
+func mergeFields(target map[string]any, fields map[string]any) {
+	for key, value := range fields {
+		switch key {
+		case "id", "nwid", "address", "objtype", "creationTime", "lastModifiedTime", "revision", "status":
+			continue
+		case "config":
+			if nested, ok := value.(map[string]any); ok {
+				mergeFields(target, nested)
+			}
+			continue
+		}
+		target[key] = value
+	}
+}
+
+// keepCreationTime lets seeded objects carry a historic creation time.
+func keepCreationTime(target map[string]any, fields map[string]any) {
+	if created := intValue(fields["creationTime"]); created > 0 {
+		target["creationTime"] = created
+	}
+}
+
+func decodeBody(w http.ResponseWriter, r *http.Request) (map[string]any, bool) {
+	fields := map[string]any{}
+	if r.Body == nil {
+		return fields, true
+	}
+	if err := json.NewDecoder(r.Body).Decode(&fields); err != nil && !errors.Is(err, io.EOF) {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"error": "invalid JSON body"})
+		return nil, false
+	}
+	return fields, true
+}
+
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+func cloneMap(source map[string]any) map[string]any {
+	clone := make(map[string]any, len(source))
+	for key, value := range source {
+		clone[key] = value
+	}
+	return clone
+}
+
+func sortedKeys[V any](items map[string]V) []string {
+	keys := make([]string, 0, len(items))
+	for key := range items {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func intValue(value any) int64 {
+	switch v := value.(type) {
+	case int:
+		return int64(v)
+	case int64:
+		return v
+	case float64:
+		return int64(v)
+	default:
+		return 0
+	}
+}
+
+func randomHex(n int) string {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		panic(fmt.Sprintf("ztmock: failed to read random bytes: %v", err))
+	}
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,126 @@
+package ztmock
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/GT-610/tairitsu/internal/zerotier"
+)
+
+func newTestClient(t *testing.T, controller *Controller) *zerotier.Client {
+	t.Helper()
+
+	baseURL, err := controller.Start()
+	if err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	t.Cleanup(func() {
+		_ = controller.Close()
+	})
+
+	return &zerotier.Client{BaseURL: baseURL, Token: controller.Token, HTTPClient: &http.Client{Timeout: 5 * time.Second}}
+}
+
+func TestControllerAppliesNetworkAndMemberMutations(t *testing.T) {
+	controller := NewController(DemoAddress)
+	client := newTestClient(t, controller)
+
+	created, err := client.CreateNetwork(&zerotier.Network{Name: "lab", Config: zerotier.NetworkConfig{Private: true, Mtu: 1400}})
+	if err != nil {
+		t.Fatalf("CreateNetwork() error = %v", err)
+	}
+	if len(created.ID) != 16 || created.Name != "lab" || created.Config.Mtu != 1400 {
+		t.Fatalf("created network = %+v, want 16-char ID, name lab and mtu 1400", created)
+	}
+
+	updated, err := client.PartialUpdateNetwork(created.ID, &zerotier.NetworkUpdateRequest{
+		Private:      true,
+		V6AssignMode: &zerotier.V6AssignmentMode{Plane6: true},
+	})
+	if err != nil {
+		t.Fatalf("PartialUpdateNetwork() error = %v", err)
+	}
+	if !updated.Config.V6AssignMode.Plane6 {
+		t.Fatalf("v6AssignMode = %+v, want 6plane enabled", updated.Config.V6AssignMode)
+	}
+
+	authorized := true
+	if _, err := client.UpdateMember(created.ID, "0123456789", &zerotier.MemberUpdateRequest{Name: "node", Authorized: &authorized}); err != nil {
+		t.Fatalf("UpdateMember() error = %v", err)
+	}
+	members, err := client.GetMembers(created.ID)
+	if err != nil {
+		t.Fatalf("GetMembers() error = %v", err)
+	}
+	if len(members) != 1 || !members[0].Authorized || members[0].Name != "node" {
+		t.Fatalf("members = %+v, want one authorized member named node", members)
+	}
+
+	if err := client.DeleteNetwork(created.ID); err != nil {
+		t.Fatalf("DeleteNetwork() error = %v", err)
+	}
+	if ids, err := client.GetNetworkIDs(); err != nil || len(ids) != 0 {
+		t.Fatalf("GetNetworkIDs() = %v, %v; want no networks", ids, err)
+	}
+}
+
+func TestControllerCreatesNetworkPostedToUnusedID(t *testing.T) {
+	controller := NewController(DemoAddress)
+	client := newTestClient(t, controller)
+
+	networkID := DemoAddress + "00abcd"
+	created, err := client.PartialUpdateNetwork(networkID, &zerotier.NetworkUpdateRequest{Name: "restored", Private: true})
+	if err != nil {
+		t.Fatalf("PartialUpdateNetwork() error = %v", err)
+	}
+	if created.ID != networkID || created.Name != "restored" {
+		t.Fatalf("created network = %+v, want ID %s named restored", created, networkID)
+	}
+
+	if _, err := client.PartialUpdateNetwork("ffffffffff000001", &zerotier.NetworkUpdateRequest{Private: true}); err == nil {
+		t.Fatalf("PartialUpdateNetwork() on another controller's ID error = nil, want not found")
+	}
+}
+
+func TestControllerRejectsWrongToken(t *testing.T) {
+	controller := NewController(DemoAddress)
+	client := newTestClient(t, controller)
+	client.Token = "wrong"
+
+	if _, err := client.GetStatus(); err == nil {
+		t.Fatalf("GetStatus() error = nil, want unauthorized error")
+	}
+}
+
+func TestSeedDemoDataCreatesMembersInVariedStates(t *testing.T) {
+	controller := NewController(DemoAddress)
+	networkIDs := SeedDemoData(controller)
+	client := newTestClient(t, controller)
+
+	if len(networkIDs) != len(demoNetworks) {
+		t.Fatalf("seeded %d networks, want %d", len(networkIDs), len(demoNetworks))
+	}
+
+	var authorized, pending, online int
+	for _, networkID := range networkIDs {
+		members, err := client.GetMembers(networkID)
+		if err != nil {
+			t.Fatalf("GetMembers(%s) error = %v", networkID, err)
+		}
+		for _, member := range members {
+			switch {
+			case !member.Authorized:
+				pending++
+			case member.Online:
+				online++
+				authorized++
+			default:
+				authorized++
+			}
+		}
+	}
+	if authorized == 0 || pending == 0 || online == 0 {
+		t.Fatalf("authorized=%d pending=%d online=%d, want every state represented", authorized, pending, online)
+	}
+}
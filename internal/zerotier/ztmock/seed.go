@@ -0,0 +1,85 @@
+package ztmock
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"time"
+)
+
+// DemoAddress is the node address of the seeded demo controller.
+const DemoAddress = "de30c0ffee"
+
+type demoNetwork struct {
+	name        string
+	description string
+	subnet      string
+	memberCount int
+}
+
+var demoNetworks = []demoNetwork{
+	{name: "office-lan", description: "Head office workstations and printers", subnet: "10.147.17", memberCount: 18},
+	{name: "homelab", description: "Servers, NAS and the odd Raspberry Pi", subnet: "10.147.20", memberCount: 12},
+	{name: "iot-sensors", description: "Field sensors reporting over cellular", subnet: "172.25.3", memberCount: 10},
+}
+
+var demoHostnames = []string{
+	"alice-laptop", "bob-desktop", "build-runner", "camera-lobby", "db-primary", "db-replica",
+	"edge-gateway", "grafana", "jumpbox", "kiosk", "media-server", "nas", "pi-hole", "printer",
+	"sensor", "thermostat", "vpn-exit", "workstation",
+}
+
+var demoVersions = []string{"1.14.2", "1.14.0", "1.12.2", "1.10.6"}
+
+// SeedDemoData fills the controller with networks and members in a variety of states.
+// The data is derived from a fixed seed so demo sessions look the same every time.
+func SeedDemoData(c *Controller) []string {
+	rng := rand.New(rand.NewPCG(1762, 1764))
+	now := time.Now()
+
+	networkIDs := make([]string, 0, len(demoNetworks))
+	for i, def := range demoNetworks {
+		networkID := fmt.Sprintf("%s%06x", c.Address, i+1)
+		c.AddNetwork(map[string]any{
+			"id":              networkID,
+			"name":            def.name,
+			"private":         true,
+			"enableBroadcast": true,
+			"v4AssignMode":    map[string]any{"zt": true},
+			"v6AssignMode":    map[string]any{"zt": false, "6plane": i == 1, "rfc4193": i == 0},
+			"routes":          []any{map[string]any{"target": def.subnet + ".0/24", "via": nil}},
+			"ipAssignmentPools": []any{map[string]any{
+				"ipRangeStart": def.subnet + ".1",
+				"ipRangeEnd":   def.subnet + ".254",
+			}},
+			"creationTime": now.Add(-time.Duration(90-i*20) * 24 * time.Hour).UnixMilli(),
+		})
+		networkIDs = append(networkIDs, networkID)
+
+		for m := 0; m < def.memberCount; m++ {
+			memberID := fmt.Sprintf("%010x", rng.Uint64()&0xffffffffff)
+			authorized := rng.IntN(5) != 0
+			online := authorized && rng.IntN(3) != 0
+			fields := map[string]any{
+				"name":          fmt.Sprintf("%s-%02d", demoHostnames[rng.IntN(len(demoHostnames))], m+1),
+				"authorized":    authorized,
+				"activeBridge":  rng.IntN(12) == 0,
+				"ipAssignments": []any{},
+				"clientVersion": demoVersions[rng.IntN(len(demoVersions))],
+				"creationTime":  now.Add(-time.Duration(rng.IntN(60*24)) * time.Hour).UnixMilli(),
+				"online":        online,
+				"lastOnline":    now.Add(-time.Duration(rng.IntN(72*60)) * time.Minute).UnixMilli(),
+			}
+			if authorized {
+				fields["ipAssignments"] = []any{fmt.Sprintf("%s.%d", def.subnet, m+10)}
+			}
+			if online {
+				fields["lastOnline"] = now.UnixMilli()
+				fields["peerLatency"] = 5 + rng.IntN(180)
+				fields["physicalAddress"] = fmt.Sprintf("203.0.113.%d/%d", 1+rng.IntN(250), 9993+rng.IntN(20))
+			}
+			c.AddMember(networkID, memberID, fields)
+		}
+	}
+
+	return networkIDs
+}
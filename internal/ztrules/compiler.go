@@ -0,0 +1,674 @@
+/*
+ * Tairitsu - A ZeroTier Network Controller Manager
+ * Copyright (C) 2025 Patmeow Lab
+ * SPDX-License-Identifier: GPL-3.0-only
+ */
+
+// Package ztrules compiles the ZeroTier rules language, the syntax ZeroTier Central's rules
+// editor uses, into the rule JSON a network controller accepts.
+package ztrules
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net"
+	"net/netip"
+	"strconv"
+	"strings"
+
+	"github.com/GT-610/tairitsu/internal/zerotier"
+)
+
+// Controller limits from ZeroTierOne.h
+const (
+	MaxNetworkRules    = 1024
+	MaxCapabilities    = 128
+	MaxCapabilityRules = 64
+	MaxTags            = 128
+)
+
+// tagDefinition is a tag declared in the source together with its named values
+type tagDefinition struct {
+	name   string
+	id     uint32
+	values map[string]uint32
+}
+
+type compiler struct {
+	tokens []token
+	end    token
+	pos    int
+
+	// known holds the tags of the whole source, collected by the first pass; nil while
+	// that pass runs, so tag matchers are not resolved yet
+	known map[string]*tagDefinition
+
+	tags         map[string]*tagDefinition
+	tagIDs       map[uint32]string
+	capabilities map[string]bool
+	capIDs       map[uint32]string
+	result       zerotier.NetworkRules
+}
+
+// Compile translates rules source into the rules, capabilities and tag definitions of a
+// network. A statement is an action followed by the matches it depends on, as in
+// "drop not ethertype ipv4 and not ethertype arp;", a capability
+// ("cap name id 1 accept ipprotocol tcp; ;") or a tag definition
+// ("tag department id 100 enum 1 sales default 1;"). Errors are *Error values carrying the
+// line and column of the offending word.
+func Compile(source string) (*zerotier.NetworkRules, error) {
+	tokens, end := tokenize(source)
+
+	// Tags may be used above their definition, so the first pass only collects them
+	collect := newCompiler(tokens, end, nil)
+	if err := collect.run(); err != nil {
+		return nil, err
+	}
+
+	compile := newCompiler(tokens, end, collect.tags)
+	if err := compile.run(); err != nil {
+		return nil, err
+	}
+	return &compile.result, nil
+}
+
+func newCompiler(tokens []token, end token, known map[string]*tagDefinition) *compiler {
+	return &compiler{
+		tokens:       tokens,
+		end:          end,
+		known:        known,
+		tags:         make(map[string]*tagDefinition),
+		tagIDs:       make(map[uint32]string),
+		capabilities: make(map[string]bool),
+		capIDs:       make(map[uint32]string),
+		result: zerotier.NetworkRules{
+			Rules:        []zerotier.Rule{},
+			Capabilities: []zerotier.Capability{},
+			Tags:         []zerotier.TagDefinition{},
+		},
+	}
+}
+
+func (c *compiler) next() (token, bool) {
+	if c.pos >= len(c.tokens) {
+		return c.end, false
+	}
+	tok := c.tokens[c.pos]
+	c.pos++
+	return tok, true
+}
+
+// expect returns the next word, failing when the source ends or a ';' comes first
+func (c *compiler) expect(what string) (token, *Error) {
+	tok, ok := c.next()
+	if !ok {
+		return tok, tok.errorf("expected %s but the rules ended", what)
+	}
+	if tok.text == ";" {
+		return tok, tok.errorf("expected %s before ';'", what)
+	}
+	return tok, nil
+}
+
+func (c *compiler) run() *Error {
+	for {
+		tok, ok := c.next()
+		if !ok {
+			return nil
+		}
+
+		switch tok.text {
+		case ";":
+			// An empty statement
+		case "tag":
+			if err := c.parseTag(tok); err != nil {
+				return err
+			}
+		case "cap":
+			if err := c.parseCapability(tok); err != nil {
+				return err
+			}
+		case "macro", "include":
+			return tok.errorf("%s is not supported", tok.text)
+		default:
+			rules, err := c.parseRule(tok)
+			if err != nil {
+				return err
+			}
+			c.result.Rules = append(c.result.Rules, rules...)
+			if len(c.result.Rules) > MaxNetworkRules {
+				return tok.errorf("a network can have at most %d rule entries", MaxNetworkRules)
+			}
+		}
+	}
+}
+
+// parseRule reads an action, its arguments and matches up to the terminating ';'. The matches
+// come first in the result, followed by the action.
+func (c *compiler) parseRule(actionTok token) ([]zerotier.Rule, *Error) {
+	actionType, ok := actions[actionTok.text]
+	if !ok {
+		return nil, actionTok.errorf("unknown action %q", actionTok.text)
+	}
+
+	action := zerotier.Rule{Type: actionType}
+	switch actionTok.text {
+	case "tee", "watch":
+		length, err := c.numberArg("a length", -1, math.MaxUint16)
+		if err != nil {
+			return nil, err
+		}
+		// -1 copies the whole frame, which is never longer than the largest length
+		if length < 0 {
+			length = math.MaxUint16
+		}
+		action.Length = int(length)
+		if action.Address, err = c.addressArg(); err != nil {
+			return nil, err
+		}
+	case "redirect":
+		address, err := c.addressArg()
+		if err != nil {
+			return nil, err
+		}
+		action.Address = address
+	}
+
+	var rules []zerotier.Rule
+	for {
+		tok, ok := c.next()
+		if !ok {
+			return nil, actionTok.errorf("%s rule is missing its terminating ';'", actionTok.text)
+		}
+		if tok.text == ";" {
+			break
+		}
+
+		or := false
+		switch tok.text {
+		case "and", "or":
+			or = tok.text == "or"
+			var err *Error
+			if tok, err = c.expect("a match"); err != nil {
+				return nil, err
+			}
+		}
+		not := false
+		if tok.text == "not" {
+			not = true
+			var err *Error
+			if tok, err = c.expect("a match"); err != nil {
+				return nil, err
+			}
+		}
+
+		rule, err := c.parseMatch(tok)
+		if err != nil {
+			return nil, err
+		}
+		rule.Not = not
+		rule.Or = or
+		rules = append(rules, rule)
+	}
+
+	return append(rules, action), nil
+}
+
+func (c *compiler) parseMatch(tok token) (zerotier.Rule, *Error) {
+	var rule zerotier.Rule
+	var err *Error
+
+	switch tok.text {
+	case "ztsrc", "ztdest":
+		rule.Type = MatchSourceZeroTierAddress
+		if tok.text == "ztdest" {
+			rule.Type = MatchDestZeroTierAddress
+		}
+		rule.ZT, err = c.addressArg()
+	case "vlan":
+		rule.Type = MatchVlanID
+		rule.VlanID, err = c.intArg("a VLAN ID", 0, 4095)
+	case "vlanpcp":
+		rule.Type = MatchVlanPCP
+		rule.VlanPCP, err = c.intArg("a VLAN PCP", 0, 7)
+	case "vlandei":
+		rule.Type = MatchVlanDEI
+		rule.VlanDEI, err = c.intArg("a VLAN DEI", 0, 1)
+	case "macsrc", "macdest":
+		rule.Type = MatchMACSource
+		if tok.text == "macdest" {
+			rule.Type = MatchMACDest
+		}
+		rule.MAC, err = c.macArg()
+	case "ipsrc", "ipdest":
+		var prefix netip.Prefix
+		if prefix, err = c.prefixArg(); err != nil {
+			break
+		}
+		source := tok.text == "ipsrc"
+		switch {
+		case prefix.Addr().Is4() && source:
+			rule.Type = MatchIPv4Source
+		case prefix.Addr().Is4():
+			rule.Type = MatchIPv4Dest
+		case source:
+			rule.Type = MatchIPv6Source
+		default:
+			rule.Type = MatchIPv6Dest
+		}
+		rule.IP = prefix.String()
+	case "iptos":
+		rule.Type = MatchIPTos
+		var mask int
+		if mask, err = c.intArg("a TOS mask", 0, math.MaxUint8); err != nil {
+			break
+		}
+		rule.Mask = json.RawMessage(strconv.Itoa(mask))
+		rule.Start, rule.End, err = c.rangeArg("a TOS range", math.MaxUint8)
+	case "ipprotocol":
+		rule.Type = MatchIPProtocol
+		rule.IPProtocol, err = c.namedArg("an IP protocol", ipProtocols, math.MaxUint8)
+	case "ethertype":
+		rule.Type = MatchEtherType
+		rule.EtherType, err = c.namedArg("an ethertype", etherTypes, math.MaxUint16)
+	case "icmp":
+		rule.Type = MatchICMP
+		if rule.ICMPType, err = c.intArg("an ICMP type", 0, math.MaxUint8); err != nil {
+			break
+		}
+		var code int
+		if code, err = c.intArg("an ICMP code", -1, math.MaxUint8); err != nil {
+			break
+		}
+		// -1 matches every code
+		if code >= 0 {
+			rule.ICMPCode = &code
+		}
+	case "sport", "dport":
+		rule.Type = MatchIPSourcePortRange
+		if tok.text == "dport" {
+			rule.Type = MatchIPDestPortRange
+		}
+		rule.Start, rule.End, err = c.rangeArg("a port or port range", math.MaxUint16)
+	case "framesize":
+		rule.Type = MatchFrameSizeRange
+		rule.Start, rule.End, err = c.rangeArg("a frame size or size range", math.MaxUint16)
+	case "random":
+		rule.Type = MatchRandom
+		rule.Probability, err = c.probabilityArg()
+	case "chr":
+		rule.Type = MatchCharacteristics
+		var mask uint64
+		if mask, err = c.characteristicsArg(); err != nil {
+			break
+		}
+		rule.Mask = json.RawMessage(fmt.Sprintf("%q", fmt.Sprintf("%016x", mask)))
+	default:
+		matchType, ok := tagMatches[tok.text]
+		if _, isAction := actions[tok.text]; !ok && isAction {
+			return rule, tok.errorf("expected a match but found the action %q; is a ';' missing before it?", tok.text)
+		}
+		if !ok {
+			return rule, tok.errorf("unknown match %q", tok.text)
+		}
+		rule.Type = matchType
+		rule.ID, rule.Value, err = c.tagMatchArgs()
+	}
+
+	return rule, err
+}
+
+// parseTag reads "tag <name> id <id> [default <value>] [enum <value> <name>]... [flag <bit> <name>]... ;"
+func (c *compiler) parseTag(tagTok token) *Error {
+	nameTok, err := c.expect("a tag name")
+	if err != nil {
+		return err
+	}
+	if _, numErr := parseNumber(nameTok.text); numErr == nil {
+		return nameTok.errorf("tag name %q must not be a number", nameTok.text)
+	}
+	if _, exists := c.tags[nameTok.text]; exists {
+		return nameTok.errorf("tag %q is already defined", nameTok.text)
+	}
+
+	definition := &tagDefinition{name: nameTok.text, values: make(map[string]uint32)}
+	hasID := false
+	var defaultTok *token
+	for {
+		tok, ok := c.next()
+		if !ok {
+			return tagTok.errorf("tag %q is missing its terminating ';'", nameTok.text)
+		}
+		if tok.text == ";" {
+			break
+		}
+
+		switch tok.text {
+		case "id":
+			id, err := c.numberArg("a tag ID", 0, math.MaxUint32)
+			if err != nil {
+				return err
+			}
+			definition.id = uint32(id)
+			hasID = true
+		case "default":
+			valueTok, err := c.expect("a default value")
+			if err != nil {
+				return err
+			}
+			defaultTok = &valueTok
+		case "enum", "flag":
+			var value uint32
+			if tok.text == "enum" {
+				number, err := c.numberArg("an enum value", 0, math.MaxUint32)
+				if err != nil {
+					return err
+				}
+				value = uint32(number)
+			} else {
+				bit, err := c.numberArg("a flag bit", 0, 31)
+				if err != nil {
+					return err
+				}
+				value = 1 << bit
+			}
+			valueName, err := c.expect("a value name")
+			if err != nil {
+				return err
+			}
+			if _, exists := definition.values[valueName.text]; exists {
+				return valueName.errorf("tag %q already has a value named %q", nameTok.text, valueName.text)
+			}
+			definition.values[valueName.text] = value
+		default:
+			return tok.errorf("unknown tag property %q", tok.text)
+		}
+	}
+
+	if !hasID {
+		return nameTok.errorf("tag %q needs an id", nameTok.text)
+	}
+	if other, used := c.tagIDs[definition.id]; used {
+		return nameTok.errorf("tag ID %d is already used by tag %q", definition.id, other)
+	}
+	if len(c.result.Tags) >= MaxTags {
+		return tagTok.errorf("a network can have at most %d tags", MaxTags)
+	}
+
+	tag := zerotier.TagDefinition{ID: definition.id}
+	if defaultTok != nil {
+		value, err := tagValue(definition, *defaultTok)
+		if err != nil {
+			return err
+		}
+		tag.Default = &value
+	}
+
+	c.tags[definition.name] = definition
+	c.tagIDs[definition.id] = definition.name
+	c.result.Tags = append(c.result.Tags, tag)
+	return nil
+}
+
+// parseCapability reads "cap <name> id <id> <rule>... ;"; each rule ends with its own ';'
+func (c *compiler) parseCapability(capTok token) *Error {
+	nameTok, err := c.expect("a capability name")
+	if err != nil {
+		return err
+	}
+	if c.capabilities[nameTok.text] {
+		return nameTok.errorf("capability %q is already defined", nameTok.text)
+	}
+	idTok, err := c.expect("'id'")
+	if err != nil {
+		return err
+	}
+	if idTok.text != "id" {
+		return idTok.errorf("expected 'id' after the capability name, found %q", idTok.text)
+	}
+	id, err := c.numberArg("a capability ID", 0, math.MaxUint32)
+	if err != nil {
+		return err
+	}
+	if other, used := c.capIDs[uint32(id)]; used {
+		return idTok.errorf("capability ID %d is already used by capability %q", id, other)
+	}
+
+	capability := zerotier.Capability{ID: uint32(id), Rules: []zerotier.Rule{}}
+	for {
+		tok, ok := c.next()
+		if !ok {
+			return capTok.errorf("capability %q is missing its terminating ';'", nameTok.text)
+		}
+		if tok.text == ";" {
+			break
+		}
+		if tok.text == "tag" || tok.text == "cap" {
+			return tok.errorf("%s cannot be defined inside a capability", tok.text)
+		}
+
+		rules, err := c.parseRule(tok)
+		if err != nil {
+			return err
+		}
+		capability.Rules = append(capability.Rules, rules...)
+		if len(capability.Rules) > MaxCapabilityRules {
+			return tok.errorf("a capability can have at most %d rule entries", MaxCapabilityRules)
+		}
+	}
+
+	if len(c.result.Capabilities) >= MaxCapabilities {
+		return capTok.errorf("a network can have at most %d capabilities", MaxCapabilities)
+	}
+	c.capabilities[nameTok.text] = true
+	c.capIDs[capability.ID] = nameTok.text
+	c.result.Capabilities = append(c.result.Capabilities, capability)
+	return nil
+}
+
+// tagMatchArgs reads the tag and value of a tag matcher. The tag is a name or a numeric ID and
+// the value a number or names of the tag's values joined by '|'.
+func (c *compiler) tagMatchArgs() (uint32, uint32, *Error) {
+	tagTok, err := c.expect("a tag")
+	if err != nil {
+		return 0, 0, err
+	}
+	valueTok, err := c.expect("a tag value")
+	if err != nil {
+		return 0, 0, err
+	}
+	if c.known == nil {
+		return 0, 0, nil
+	}
+
+	var definition *tagDefinition
+	if id, numErr := parseNumber(tagTok.text); numErr == nil {
+		if id < 0 || id > math.MaxUint32 {
+			return 0, 0, tagTok.errorf("tag ID must be between 0 and %d", uint32(math.MaxUint32))
+		}
+		definition = &tagDefinition{id: uint32(id)}
+		for _, known := range c.known {
+			if known.id == uint32(id) {
+				definition = known
+				break
+			}
+		}
+	} else if definition = c.known[tagTok.text]; definition == nil {
+		return 0, 0, tagTok.errorf("unknown tag %q", tagTok.text)
+	}
+
+	value, err := tagValue(definition, valueTok)
+	if err != nil {
+		return 0, 0, err
+	}
+	return definition.id, value, nil
+}
+
+// tagValue resolves a tag value written as a number or as value names joined by '|'
+func tagValue(definition *tagDefinition, tok token) (uint32, *Error) {
+	if number, err := parseNumber(tok.text); err == nil {
+		if number < 0 || number > math.MaxUint32 {
+			return 0, tok.errorf("tag value must be between 0 and %d", uint32(math.MaxUint32))
+		}
+		return uint32(number), nil
+	}
+
+	var value uint32
+	for _, name := range strings.Split(tok.text, "|") {
+		named, ok := definition.values[name]
+		if !ok {
+			if definition.name == "" {
+				return 0, tok.errorf("tag %d has no definition, so its value must be a number", definition.id)
+			}
+			return 0, tok.errorf("tag %q has no value named %q", definition.name, name)
+		}
+		value |= named
+	}
+	return value, nil
+}
+
+func (c *compiler) numberArg(what string, min, max int64) (int64, *Error) {
+	tok, err := c.expect(what)
+	if err != nil {
+		return 0, err
+	}
+	return numberIn(tok, what, min, max)
+}
+
+func (c *compiler) intArg(what string, min, max int64) (int, *Error) {
+	number, err := c.numberArg(what, min, max)
+	return int(number), err
+}
+
+// namedArg reads a number or one of the given names
+func (c *compiler) namedArg(what string, names map[string]uint64, max int64) (int, *Error) {
+	tok, err := c.expect(what)
+	if err != nil {
+		return 0, err
+	}
+	if value, ok := names[tok.text]; ok {
+		return int(value), nil
+	}
+	number, err := numberIn(tok, what, 0, max)
+	return int(number), err
+}
+
+// rangeArg reads a single value or an inclusive "start-end" range
+func (c *compiler) rangeArg(what string, max int64) (int, int, *Error) {
+	tok, err := c.expect(what)
+	if err != nil {
+		return 0, 0, err
+	}
+	startText, endText, isRange := strings.Cut(tok.text, "-")
+	if !isRange {
+		endText = startText
+	}
+	start, startErr := parseNumber(startText)
+	end, endErr := parseNumber(endText)
+	if startErr != nil || endErr != nil {
+		return 0, 0, tok.errorf("expected %s, found %q", what, tok.text)
+	}
+	if start < 0 || end > max || start > end {
+		return 0, 0, tok.errorf("%q must be a value or range between 0 and %d", tok.text, max)
+	}
+	return int(start), int(end), nil
+}
+
+// addressArg reads a 10 digit hex ZeroTier address
+func (c *compiler) addressArg() (string, *Error) {
+	tok, err := c.expect("a ZeroTier address")
+	if err != nil {
+		return "", err
+	}
+	address := strings.ToLower(tok.text)
+	if len(address) != 10 {
+		return "", tok.errorf("%q is not a 10 digit ZeroTier address", tok.text)
+	}
+	if _, parseErr := strconv.ParseUint(address, 16, 64); parseErr != nil {
+		return "", tok.errorf("%q is not a 10 digit ZeroTier address", tok.text)
+	}
+	return address, nil
+}
+
+func (c *compiler) macArg() (string, *Error) {
+	tok, err := c.expect("a MAC address")
+	if err != nil {
+		return "", err
+	}
+	mac, parseErr := net.ParseMAC(tok.text)
+	if parseErr != nil || len(mac) != 6 {
+		return "", tok.errorf("%q is not a MAC address", tok.text)
+	}
+	return mac.String(), nil
+}
+
+// prefixArg reads an IP address or CIDR; a bare address matches only itself
+func (c *compiler) prefixArg() (netip.Prefix, *Error) {
+	tok, err := c.expect("an IP address or CIDR")
+	if err != nil {
+		return netip.Prefix{}, err
+	}
+	if strings.Contains(tok.text, "/") {
+		prefix, parseErr := netip.ParsePrefix(tok.text)
+		if parseErr != nil {
+			return netip.Prefix{}, tok.errorf("%q is not an IP address or CIDR", tok.text)
+		}
+		return prefix, nil
+	}
+	addr, parseErr := netip.ParseAddr(tok.text)
+	if parseErr != nil || addr.Zone() != "" {
+		return netip.Prefix{}, tok.errorf("%q is not an IP address or CIDR", tok.text)
+	}
+	return netip.PrefixFrom(addr, addr.BitLen()), nil
+}
+
+// probabilityArg reads a probability between 0 and 1 and scales it to the 32-bit threshold
+// the controller compares a random value against
+func (c *compiler) probabilityArg() (uint32, *Error) {
+	tok, err := c.expect("a probability")
+	if err != nil {
+		return 0, err
+	}
+	probability, parseErr := strconv.ParseFloat(tok.text, 64)
+	if parseErr != nil || probability < 0 || probability > 1 {
+		return 0, tok.errorf("probability must be between 0 and 1, found %q", tok.text)
+	}
+	return uint32(math.Round(probability * math.MaxUint32)), nil
+}
+
+// characteristicsArg reads characteristic names joined by ','
+func (c *compiler) characteristicsArg() (uint64, *Error) {
+	tok, err := c.expect("packet characteristics")
+	if err != nil {
+		return 0, err
+	}
+	var mask uint64
+	for _, name := range strings.Split(tok.text, ",") {
+		bit, ok := characteristics[name]
+		if !ok {
+			return 0, tok.errorf("unknown packet characteristic %q", name)
+		}
+		mask |= bit
+	}
+	return mask, nil
+}
+
+func numberIn(tok token, what string, min, max int64) (int64, *Error) {
+	number, err := parseNumber(tok.text)
+	if err != nil {
+		return 0, tok.errorf("expected %s, found %q", what, tok.text)
+	}
+	if number < min || number > max {
+		return 0, tok.errorf("%s must be between %d and %d, found %d", what, min, max, number)
+	}
+	return number, nil
+}
+
+// parseNumber reads a decimal number or a hex number prefixed with 0x
+func parseNumber(text string) (int64, error) {
+	if hex, ok := strings.CutPrefix(strings.ToLower(text), "0x"); ok {
+		return strconv.ParseInt(hex, 16, 64)
+	}
+	return strconv.ParseInt(text, 10, 64)
+}
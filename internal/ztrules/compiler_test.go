@@ -0,0 +1,165 @@
+package ztrules
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func compileJSON(t *testing.T, source string) string {
+	t.Helper()
+	rules, err := Compile(source)
+	if err != nil {
+		t.Fatalf("Compile() error = %v", err)
+	}
+	data, err := json.Marshal(rules)
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+	return string(data)
+}
+
+func TestCompile_PutsMatchesBeforeTheirAction(t *testing.T) {
+	got := compileJSON(t, `
+# Allow only IPv4, IPv6 and ARP
+drop
+  not ethertype ipv4
+  and not ethertype arp
+  or ethertype 0x86dd
+;
+accept;
+`)
+	want := `{"rules":[` +
+		`{"type":"MATCH_ETHERTYPE","not":true,"or":false,"etherType":2048},` +
+		`{"type":"MATCH_ETHERTYPE","not":true,"or":false,"etherType":2054},` +
+		`{"type":"MATCH_ETHERTYPE","not":false,"or":true,"etherType":34525},` +
+		`{"type":"ACTION_DROP","not":false,"or":false},` +
+		`{"type":"ACTION_ACCEPT","not":false,"or":false}],` +
+		`"capabilities":[],"tags":[]}`
+	if got != want {
+		t.Fatalf("Compile() = %s\nwant %s", got, want)
+	}
+}
+
+func TestCompile_Matches(t *testing.T) {
+	testCases := []struct {
+		source string
+		want   string
+	}{
+		{"accept macsrc 02:AA:bb:cc:dd:ee;", `{"type":"MATCH_MAC_SOURCE","not":false,"or":false,"mac":"02:aa:bb:cc:dd:ee"}`},
+		{"accept macdest 02:aa:bb:cc:dd:ee;", `{"type":"MATCH_MAC_DEST","not":false,"or":false,"mac":"02:aa:bb:cc:dd:ee"}`},
+		{"accept ipsrc 10.0.0.0/8;", `{"type":"MATCH_IPV4_SOURCE","not":false,"or":false,"ip":"10.0.0.0/8"}`},
+		{"accept ipdest 10.1.2.3;", `{"type":"MATCH_IPV4_DEST","not":false,"or":false,"ip":"10.1.2.3/32"}`},
+		{"accept ipsrc fd00::/8;", `{"type":"MATCH_IPV6_SOURCE","not":false,"or":false,"ip":"fd00::/8"}`},
+		{"accept ipprotocol tcp;", `{"type":"MATCH_IP_PROTOCOL","not":false,"or":false,"ipProtocol":6}`},
+		{"accept ztsrc 89E92CEEE5;", `{"type":"MATCH_SOURCE_ZEROTIER_ADDRESS","not":false,"or":false,"zt":"89e92ceee5"}`},
+		{"accept dport 1024-65535;", `{"type":"MATCH_IP_DEST_PORT_RANGE","not":false,"or":false,"start":1024,"end":65535}`},
+		{"accept sport 22;", `{"type":"MATCH_IP_SOURCE_PORT_RANGE","not":false,"or":false,"start":22,"end":22}`},
+		{"accept chr tcp_syn,tcp_ack;", `{"type":"MATCH_CHARACTERISTICS","not":false,"or":false,"mask":"0000000000000012"}`},
+		{"accept chr inbound;", `{"type":"MATCH_CHARACTERISTICS","not":false,"or":false,"mask":"8000000000000000"}`},
+		{"accept icmp 8 -1;", `{"type":"MATCH_ICMP","not":false,"or":false,"icmpType":8}`},
+		{"accept icmp 3 0;", `{"type":"MATCH_ICMP","not":false,"or":false,"icmpType":3,"icmpCode":0}`},
+		{"accept iptos 0xfc 46-48;", `{"type":"MATCH_IP_TOS","not":false,"or":false,"start":46,"end":48,"mask":252}`},
+		{"accept random 1;", `{"type":"MATCH_RANDOM","not":false,"or":false,"probability":4294967295}`},
+		{"accept vlan 100;", `{"type":"MATCH_VLAN_ID","not":false,"or":false,"vlanId":100}`},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.source, func(t *testing.T) {
+			rules, err := Compile(tc.source)
+			if err != nil {
+				t.Fatalf("Compile() error = %v", err)
+			}
+			if len(rules.Rules) != 2 {
+				t.Fatalf("rules = %d, want a match and an action", len(rules.Rules))
+			}
+			data, _ := json.Marshal(rules.Rules[0])
+			if string(data) != tc.want {
+				t.Fatalf("match = %s\nwant %s", data, tc.want)
+			}
+		})
+	}
+}
+
+func TestCompile_Actions(t *testing.T) {
+	got := compileJSON(t, "tee -1 89e92ceee5; watch 128 89e92ceee5; redirect 89e92ceee5; break;")
+	want := `{"rules":[` +
+		`{"type":"ACTION_TEE","not":false,"or":false,"address":"89e92ceee5","length":65535},` +
+		`{"type":"ACTION_WATCH","not":false,"or":false,"address":"89e92ceee5","length":128},` +
+		`{"type":"ACTION_REDIRECT","not":false,"or":false,"address":"89e92ceee5"},` +
+		`{"type":"ACTION_BREAK","not":false,"or":false}],` +
+		`"capabilities":[],"tags":[]}`
+	if got != want {
+		t.Fatalf("Compile() = %s\nwant %s", got, want)
+	}
+}
+
+func TestCompile_CapabilitiesAndTags(t *testing.T) {
+	got := compileJSON(t, `
+drop not teq department engineering|sales and not tseq 2000 5;
+cap superuser
+  id 1000
+  accept;
+;
+tag department
+  id 1000
+  enum 100 sales
+  flag 1 engineering
+  default sales
+;
+accept;
+`)
+	want := `{"rules":[` +
+		`{"type":"MATCH_TAGS_EQUAL","not":true,"or":false,"id":1000,"value":102},` +
+		`{"type":"MATCH_TAG_SENDER","not":true,"or":false,"id":2000,"value":5},` +
+		`{"type":"ACTION_DROP","not":false,"or":false},` +
+		`{"type":"ACTION_ACCEPT","not":false,"or":false}],` +
+		`"capabilities":[{"id":1000,"default":false,"rules":[{"type":"ACTION_ACCEPT","not":false,"or":false}]}],` +
+		`"tags":[{"id":1000,"default":100}]}`
+	if got != want {
+		t.Fatalf("Compile() = %s\nwant %s", got, want)
+	}
+}
+
+func TestCompile_ReportsErrorPositions(t *testing.T) {
+	testCases := []struct {
+		name   string
+		source string
+		line   int
+		column int
+		want   string
+	}{
+		{"unknown action", "accept;\n  allow;", 2, 3, `unknown action "allow"`},
+		{"unknown match", "drop not ethertpe ipv4;", 1, 10, `unknown match "ethertpe"`},
+		{"bad address", "accept ipsrc 10.0.0.300/8;", 1, 14, "is not an IP address or CIDR"},
+		{"missing terminator", "accept ipprotocol udp;\n\n  drop ipprotocol tcp", 3, 3, "missing its terminating ';'"},
+		{"action as match", "accept\ndrop;", 2, 1, "is a ';' missing before it?"},
+		{"missing argument", "accept dport;", 1, 13, "expected a port or port range before ';'"},
+		{"port out of range", "accept dport 70000;", 1, 14, "between 0 and 65535"},
+		{"unknown tag", "drop teq team 1;", 1, 10, `unknown tag "team"`},
+		{"unknown tag value", "tag team id 1 enum 1 red;\ndrop teq team blue;", 2, 15, `has no value named "blue"`},
+		{"duplicate tag id", "tag a id 1;\ntag b id 1;", 2, 5, "already used"},
+		{"macro", "macro allow_ip(a) accept ipsrc $a; ;", 1, 1, "not supported"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := Compile(tc.source)
+			var compileErr *Error
+			if !errors.As(err, &compileErr) {
+				t.Fatalf("Compile() error = %v, want *Error", err)
+			}
+			if compileErr.Line != tc.line || compileErr.Column != tc.column {
+				t.Fatalf("position = %d:%d, want %d:%d (%v)", compileErr.Line, compileErr.Column, tc.line, tc.column, err)
+			}
+			if !strings.Contains(compileErr.Message, tc.want) {
+				t.Fatalf("message = %q, want it to contain %q", compileErr.Message, tc.want)
+			}
+		})
+	}
+}
+
+func TestCompile_EmptySource(t *testing.T) {
+	if got, want := compileJSON(t, "  # nothing yet\n"), `{"rules":[],"capabilities":[],"tags":[]}`; got != want {
+		t.Fatalf("Compile() = %s, want %s", got, want)
+	}
+}
@@ -0,0 +1,84 @@
+/*
+ * Tairitsu - A ZeroTier Network Controller Manager
+ * Copyright (C) 2025 Patmeow Lab
+ * SPDX-License-Identifier: GPL-3.0-only
+ */
+
+package ztrules
+
+import (
+	"fmt"
+	"unicode"
+)
+
+// Error is a compile error at a position of the rules source; lines and columns start at 1
+type Error struct {
+	Line    int
+	Column  int
+	Message string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("line %d, column %d: %s", e.Line, e.Column, e.Message)
+}
+
+// token is a word of the source or a ';' terminator
+type token struct {
+	text   string
+	line   int
+	column int
+}
+
+func (t token) errorf(format string, args ...any) *Error {
+	return &Error{Line: t.line, Column: t.column, Message: fmt.Sprintf(format, args...)}
+}
+
+// tokenize splits source into words and ';' terminators. A '#' starts a comment that runs to
+// the end of the line. The returned end token marks the position just past the source.
+func tokenize(source string) ([]token, token) {
+	var tokens []token
+	line, column := 1, 1
+	var word []rune
+	var start token
+
+	flush := func() {
+		if len(word) > 0 {
+			start.text = string(word)
+			tokens = append(tokens, start)
+			word = word[:0]
+		}
+	}
+
+	comment := false
+	for _, r := range source {
+		switch {
+		case r == '\n':
+			flush()
+			comment = false
+		case comment:
+		case r == '#':
+			flush()
+			comment = true
+		case r == ';':
+			flush()
+			tokens = append(tokens, token{text: ";", line: line, column: column})
+		case unicode.IsSpace(r):
+			flush()
+		default:
+			if len(word) == 0 {
+				start = token{line: line, column: column}
+			}
+			word = append(word, r)
+		}
+
+		if r == '\n' {
+			line++
+			column = 1
+		} else {
+			column++
+		}
+	}
+	flush()
+
+	return tokens, token{line: line, column: column}
+}
@@ -0,0 +1,125 @@
+/*
+ * Tairitsu - A ZeroTier Network Controller Manager
+ * Copyright (C) 2025 Patmeow Lab
+ * SPDX-License-Identifier: GPL-3.0-only
+ */
+
+package ztrules
+
+// Rule types of the controller's rule JSON
+const (
+	ActionDrop     = "ACTION_DROP"
+	ActionAccept   = "ACTION_ACCEPT"
+	ActionBreak    = "ACTION_BREAK"
+	ActionTee      = "ACTION_TEE"
+	ActionWatch    = "ACTION_WATCH"
+	ActionRedirect = "ACTION_REDIRECT"
+
+	MatchSourceZeroTierAddress = "MATCH_SOURCE_ZEROTIER_ADDRESS"
+	MatchDestZeroTierAddress   = "MATCH_DEST_ZEROTIER_ADDRESS"
+	MatchVlanID                = "MATCH_VLAN_ID"
+	MatchVlanPCP               = "MATCH_VLAN_PCP"
+	MatchVlanDEI               = "MATCH_VLAN_DEI"
+	MatchMACSource             = "MATCH_MAC_SOURCE"
+	MatchMACDest               = "MATCH_MAC_DEST"
+	MatchIPv4Source            = "MATCH_IPV4_SOURCE"
+	MatchIPv4Dest              = "MATCH_IPV4_DEST"
+	MatchIPv6Source            = "MATCH_IPV6_SOURCE"
+	MatchIPv6Dest              = "MATCH_IPV6_DEST"
+	MatchIPTos                 = "MATCH_IP_TOS"
+	MatchIPProtocol            = "MATCH_IP_PROTOCOL"
+	MatchEtherType             = "MATCH_ETHERTYPE"
+	MatchICMP                  = "MATCH_ICMP"
+	MatchIPSourcePortRange     = "MATCH_IP_SOURCE_PORT_RANGE"
+	MatchIPDestPortRange       = "MATCH_IP_DEST_PORT_RANGE"
+	MatchCharacteristics       = "MATCH_CHARACTERISTICS"
+	MatchFrameSizeRange        = "MATCH_FRAME_SIZE_RANGE"
+	MatchRandom                = "MATCH_RANDOM"
+	MatchTagsDifference        = "MATCH_TAGS_DIFFERENCE"
+	MatchTagsBitwiseAnd        = "MATCH_TAGS_BITWISE_AND"
+	MatchTagsBitwiseOr         = "MATCH_TAGS_BITWISE_OR"
+	MatchTagsBitwiseXor        = "MATCH_TAGS_BITWISE_XOR"
+	MatchTagsEqual             = "MATCH_TAGS_EQUAL"
+	MatchTagSender             = "MATCH_TAG_SENDER"
+	MatchTagReceiver           = "MATCH_TAG_RECEIVER"
+)
+
+// actions maps the action keywords to rule types
+var actions = map[string]string{
+	"drop":     ActionDrop,
+	"accept":   ActionAccept,
+	"break":    ActionBreak,
+	"tee":      ActionTee,
+	"watch":    ActionWatch,
+	"redirect": ActionRedirect,
+}
+
+// tagMatches maps the tag matcher keywords to rule types
+var tagMatches = map[string]string{
+	"tdiff": MatchTagsDifference,
+	"tand":  MatchTagsBitwiseAnd,
+	"tor":   MatchTagsBitwiseOr,
+	"txor":  MatchTagsBitwiseXor,
+	"teq":   MatchTagsEqual,
+	"tseq":  MatchTagSender,
+	"treq":  MatchTagReceiver,
+}
+
+// etherTypes are the ethertype names the DSL accepts besides numbers
+var etherTypes = map[string]uint64{
+	"ipv4":  0x0800,
+	"arp":   0x0806,
+	"wol":   0x0842,
+	"rarp":  0x8035,
+	"atalk": 0x809b,
+	"aarp":  0x80f3,
+	"ipx_a": 0x8137,
+	"ipx_b": 0x8138,
+	"ipv6":  0x86dd,
+}
+
+// ipProtocols are the IP protocol names the DSL accepts besides numbers
+var ipProtocols = map[string]uint64{
+	"icmp":    1,
+	"igmp":    2,
+	"ipip":    4,
+	"tcp":     6,
+	"egp":     8,
+	"igp":     9,
+	"udp":     17,
+	"rdp":     27,
+	"ipv6":    41,
+	"rsvp":    46,
+	"gre":     47,
+	"esp":     50,
+	"ah":      51,
+	"icmp6":   58,
+	"icmpv6":  58,
+	"ospf":    89,
+	"pim":     103,
+	"vrrp":    112,
+	"l2tp":    115,
+	"sctp":    132,
+	"udplite": 136,
+}
+
+// characteristics are the packet characteristic bits matched by chr
+var characteristics = map[string]uint64{
+	"inbound":   0x8000000000000000,
+	"multicast": 0x4000000000000000,
+	"broadcast": 0x2000000000000000,
+	"ipauth":    0x1000000000000000,
+	"macauth":   0x0800000000000000,
+	"tcp_rs_0":  0x0000000000000800,
+	"tcp_rs_1":  0x0000000000000400,
+	"tcp_rs_2":  0x0000000000000200,
+	"tcp_ns":    0x0000000000000100,
+	"tcp_cwr":   0x0000000000000080,
+	"tcp_ece":   0x0000000000000040,
+	"tcp_urg":   0x0000000000000020,
+	"tcp_ack":   0x0000000000000010,
+	"tcp_psh":   0x0000000000000008,
+	"tcp_rst":   0x0000000000000004,
+	"tcp_syn":   0x0000000000000002,
+	"tcp_fin":   0x0000000000000001,
+}
@@ -0,0 +1,80 @@
+package apierror
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/GT-610/tairitsu/internal/app/apierror"
+	"github.com/gofiber/fiber/v3"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBindNamesTheMistypedField(t *testing.T) {
+	var target struct {
+		Inner struct {
+			Flag bool `json:"flag"`
+		} `json:"inner"`
+	}
+	err := json.Unmarshal([]byte(`{"inner": {"flag": "yes"}}`), &target)
+
+	apiErr := apierror.Bind(apierror.CodeInvalidBody, err)
+
+	assert.Equal(t, fiber.StatusBadRequest, apiErr.Status)
+	assert.Equal(t, apierror.CodeInvalidBody, apiErr.Code)
+	assert.Equal(t, []apierror.FieldError{{Field: "inner.flag", Message: "must be a boolean"}}, apiErr.Fields)
+	assert.NotContains(t, apiErr.Message, "json:")
+}
+
+func TestBindHidesDecoderInternals(t *testing.T) {
+	var target map[string]any
+	err := json.Unmarshal([]byte(`{"name":`), &target)
+
+	apiErr := apierror.Bind(apierror.CodeInvalidBody, err)
+
+	assert.Equal(t, "Invalid request body", apiErr.Message)
+	assert.Equal(t, "request body is not valid JSON", apiErr.Detail)
+	assert.Empty(t, apiErr.Fields)
+}
+
+func TestValidationListsJoinedFieldErrors(t *testing.T) {
+	err := errors.Join(apierror.Invalid("name", "name is too long"), fmt.Errorf("wrapped: %w", apierror.Invalid("description", "description is too long")))
+
+	apiErr := apierror.Validation(err)
+
+	assert.Equal(t, apierror.CodeValidationFailed, apiErr.Code)
+	assert.Equal(t, "name is too long", apiErr.Message)
+	assert.Equal(t, []apierror.FieldError{
+		{Field: "name", Message: "name is too long"},
+		{Field: "description", Message: "description is too long"},
+	}, apiErr.Fields)
+}
+
+func TestValidationWithoutFieldsIsABadRequest(t *testing.T) {
+	apiErr := apierror.Validation(errors.New("something is off"))
+
+	assert.Equal(t, "http.bad_request", apiErr.Code)
+	assert.Equal(t, "something is off", apiErr.Message)
+	assert.Empty(t, apiErr.Fields)
+}
+
+func TestDefaultCodeAndDetailLength(t *testing.T) {
+	assert.Equal(t, "http.not_found", apierror.DefaultCode(fiber.StatusNotFound))
+	assert.Equal(t, apierror.CodeUnknown, apierror.DefaultCode(599))
+
+	apiErr := apierror.New(fiber.StatusBadRequest, apierror.CodeInvalidBody, "Invalid").WithDetail(strings.Repeat("x", 300))
+	assert.Len(t, apiErr.Detail, 256)
+}
+
+func TestUserMessageOnlyTrustsMarkedErrors(t *testing.T) {
+	_, ok := apierror.UserMessage(errors.New("pq: relation \"users\" does not exist"))
+	assert.False(t, ok)
+
+	marked := fmt.Errorf("import: %w", apierror.UserFacing(errors.New("Owner has left the organization")))
+	message, ok := apierror.UserMessage(marked)
+	assert.True(t, ok)
+	assert.Equal(t, "Owner has left the organization", message)
+	assert.Nil(t, apierror.UserFacing(nil))
+}
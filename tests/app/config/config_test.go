@@ -10,21 +10,23 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/GT-610/tairitsu/internal/app/config"
+	"github.com/GT-610/tairitsu/internal/app/paths"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
 func TestLoadConfigGeneratesAndPersistsJWTSecret(t *testing.T) {
-	useTemporaryWorkingDirectory(t)
+	useTemporaryDataDir(t)
 	t.Setenv("JWT_SECRET", "")
 
 	first, err := config.LoadConfig()
 	require.NoError(t, err)
 	require.NotEmpty(t, first.Security.JWTSecret)
 
-	configBytes, err := os.ReadFile(filepath.Join("data", "config.json"))
+	configBytes, err := os.ReadFile(filepath.Join(paths.DataDir(), "config.json"))
 	require.NoError(t, err)
 	var persisted config.Config
 	require.NoError(t, json.Unmarshal(configBytes, &persisted))
@@ -37,7 +39,7 @@ func TestLoadConfigGeneratesAndPersistsJWTSecret(t *testing.T) {
 }
 
 func TestLoadConfigMigratesLegacyCredentialsEncryptedWithEmptyJWTSecret(t *testing.T) {
-	useTemporaryWorkingDirectory(t)
+	useTemporaryDataDir(t)
 	t.Setenv("JWT_SECRET", "")
 
 	legacyConfig := &config.Config{
@@ -72,8 +74,21 @@ func TestLoadConfigMigratesLegacyCredentialsEncryptedWithEmptyJWTSecret(t *testi
 	assert.Equal(t, "legacy-database-password", databasePassword)
 }
 
+func TestLoadConfigMigratesLegacyDatabaseSettings(t *testing.T) {
+	useTemporaryDataDir(t)
+	t.Setenv("JWT_SECRET", "")
+
+	legacy := `{"initialized":false,"database":{"type":"SQLite3","path":""},"security":{"jwt_secret":""}}`
+	require.NoError(t, os.WriteFile(filepath.Join(paths.DataDir(), "config.json"), []byte(legacy), 0600))
+
+	cfg, err := config.LoadConfig()
+	require.NoError(t, err)
+	assert.Equal(t, config.DatabaseSQLite, cfg.Database.Type)
+	assert.Equal(t, config.DefaultSQLitePath, cfg.Database.Path)
+}
+
 func TestLoadConfigContinuesWhenTokenRetryFails(t *testing.T) {
-	useTemporaryWorkingDirectory(t)
+	useTemporaryDataDir(t)
 	t.Setenv("JWT_SECRET", "")
 	t.Setenv("ZT_TOKEN_PATH", filepath.Join(t.TempDir(), "missing-authtoken.secret"))
 
@@ -83,14 +98,10 @@ func TestLoadConfigContinuesWhenTokenRetryFails(t *testing.T) {
 	assert.Empty(t, cfg.ZeroTier.Token)
 }
 
-func useTemporaryWorkingDirectory(t *testing.T) {
+// useTemporaryDataDir keeps config.json and the key file of a test in its own data directory
+func useTemporaryDataDir(t *testing.T) {
 	t.Helper()
-	originalWorkingDirectory, err := os.Getwd()
-	require.NoError(t, err)
-	require.NoError(t, os.Chdir(t.TempDir()))
-	t.Cleanup(func() {
-		require.NoError(t, os.Chdir(originalWorkingDirectory))
-	})
+	t.Cleanup(paths.SetDataDir(t.TempDir()))
 }
 
 func encryptWithLegacyEmptyKey(t *testing.T, plaintext string) string {
@@ -131,3 +142,312 @@ func TestTempSettings(t *testing.T) {
 	// Assert - Should return empty string for non-existent key
 	assert.Empty(t, nonExistentValue)
 }
+
+// saveInitializedConfig persists an initialized configuration whose token was encrypted with secret.
+func saveInitializedConfig(t *testing.T, secret string, token string) *config.Config {
+	t.Helper()
+	cfg := &config.Config{Initialized: true, Server: config.ServerConfig{Port: 8080}, Security: config.SecurityConfig{JWTSecret: secret}}
+	require.NoError(t, config.SetZTTokenOn(cfg, token))
+	require.NoError(t, config.SaveConfig(cfg))
+	return cfg
+}
+
+// writeConfigFile writes cfg to config.json without the validation of SaveConfig, like an edit by hand
+func writeConfigFile(t *testing.T, cfg *config.Config) {
+	t.Helper()
+	data, err := json.Marshal(cfg)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(paths.DataDir(), "config.json"), data, 0600))
+}
+
+func TestLoadConfigRefusesInitializedConfigWithMissingSecret(t *testing.T) {
+	useTemporaryDataDir(t)
+	saved := saveInitializedConfig(t, "an-original-secret-long-enough-for-use", "controller-token")
+	saved.Security.JWTSecret = ""
+	require.ErrorIs(t, config.SaveConfig(saved), config.ErrInvalidConfig, "an initialized configuration is never saved without a secret")
+	// A secret removed by hand is still refused on load
+	writeConfigFile(t, saved)
+	before, err := os.ReadFile(filepath.Join(paths.DataDir(), "config.json"))
+	require.NoError(t, err)
+
+	cfg, err := config.LoadConfig()
+	require.ErrorIs(t, err, config.ErrWeakSecret)
+	assert.Nil(t, cfg)
+	assert.Contains(t, err.Error(), "--regenerate-secrets")
+	assert.Contains(t, err.Error(), "missing")
+
+	after, err := os.ReadFile(filepath.Join(paths.DataDir(), "config.json"))
+	require.NoError(t, err)
+	assert.Equal(t, before, after, "a refused configuration must not be rewritten")
+}
+
+func TestLoadConfigRefusesInitializedConfigWithShortSecret(t *testing.T) {
+	useTemporaryDataDir(t)
+	saveInitializedConfig(t, "short", "controller-token")
+
+	_, err := config.LoadConfig()
+	require.ErrorIs(t, err, config.ErrWeakSecret)
+	assert.Contains(t, err.Error(), "only 5 characters long")
+}
+
+func TestLoadConfigRegeneratesWeakSecretAndReencryptsCredentials(t *testing.T) {
+	useTemporaryDataDir(t)
+	saveInitializedConfig(t, "short", "controller-token")
+
+	cfg, err := config.LoadConfigWithOptions(config.LoadOptions{RegenerateSecrets: true})
+	require.NoError(t, err)
+	assert.True(t, cfg.SecretsRegenerated)
+	assert.GreaterOrEqual(t, len(cfg.Security.JWTSecret), config.MinSecretLength)
+
+	token, err := config.GetZTTokenFrom(cfg)
+	require.NoError(t, err)
+	assert.Equal(t, "controller-token", token)
+
+	reloaded, err := config.LoadConfig()
+	require.NoError(t, err)
+	assert.Equal(t, cfg.Security.JWTSecret, reloaded.Security.JWTSecret)
+	assert.False(t, reloaded.SecretsRegenerated)
+	token, err = config.GetZTTokenFrom(reloaded)
+	require.NoError(t, err)
+	assert.Equal(t, "controller-token", token)
+}
+
+func TestLoadConfigRegenerationReloadsUnrecoverableTokenFromPath(t *testing.T) {
+	useTemporaryDataDir(t)
+	saved := saveInitializedConfig(t, "an-original-secret-long-enough-for-use", "lost-token")
+	tokenPath := filepath.Join(t.TempDir(), "authtoken.secret")
+	require.NoError(t, os.WriteFile(tokenPath, []byte("file-token\n"), 0600))
+	saved.Security.JWTSecret = ""
+	saved.ZeroTier.TokenPath = tokenPath
+	writeConfigFile(t, saved)
+
+	cfg, err := config.LoadConfigWithOptions(config.LoadOptions{RegenerateSecrets: true})
+	require.NoError(t, err)
+
+	token, err := config.GetZTTokenFrom(cfg)
+	require.NoError(t, err)
+	assert.Equal(t, "file-token", token)
+}
+
+func TestLoggerOptionsFromAppliesLoggingSection(t *testing.T) {
+	t.Setenv("APP_ENV", "production")
+	console := true
+	cfg := &config.Config{Logging: config.LoggingConfig{
+		Level:      "debug",
+		FilePath:   "/var/log/tairitsu/app.log",
+		MaxBackups: 2,
+		Console:    &console,
+		Encoding:   "json",
+	}}
+
+	opts := config.LoggerOptionsFrom(cfg)
+	assert.Equal(t, "debug", opts.Level)
+	assert.Equal(t, "/var/log/tairitsu/app.log", opts.FilePath)
+	assert.Equal(t, 2, opts.MaxBackups)
+	assert.True(t, opts.Console)
+	assert.Equal(t, "json", opts.Encoding)
+
+	defaults := config.LoggerOptionsFrom(&config.Config{})
+	assert.Equal(t, "info", defaults.Level)
+	assert.Equal(t, filepath.Join("logs", "tairitsu.log"), defaults.FilePath)
+	assert.False(t, defaults.Console, "console output is off in production unless configured")
+
+	// A chosen data directory holds the logs, and relative paths are interpreted in it
+	useTemporaryDataDir(t)
+	defaults = config.LoggerOptionsFrom(&config.Config{})
+	assert.Equal(t, filepath.Join(paths.DataDir(), "logs", "tairitsu.log"), defaults.FilePath)
+	relative := config.LoggerOptionsFrom(&config.Config{Logging: config.LoggingConfig{FilePath: "audit/app.log"}})
+	assert.Equal(t, filepath.Join(paths.DataDir(), "audit", "app.log"), relative.FilePath)
+}
+
+func TestLoadConfigMigratesCredentialsEncryptedWithJWTSecret(t *testing.T) {
+	useTemporaryDataDir(t)
+	saved := saveInitializedConfig(t, "an-original-secret-long-enough-for-use", "controller-token")
+	require.NoError(t, config.SetDatabasePasswordOn(saved, "database-password"))
+	require.NoError(t, config.SaveConfig(saved))
+
+	cfg, err := config.LoadConfig()
+	require.NoError(t, err)
+	assert.NotEqual(t, saved.ZeroTier.Token, cfg.ZeroTier.Token, "the token must be re-encrypted on load")
+	assert.NotEqual(t, saved.Database.Pass, cfg.Database.Pass, "the password must be re-encrypted on load")
+
+	info, err := os.Stat(filepath.Join(paths.DataDir(), "master.key"))
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	// The JWT secret no longer protects the credentials, so replacing it keeps them readable
+	cfg.Security.JWTSecret = "a-replacement-secret-long-enough-for-use"
+	require.NoError(t, config.SaveConfig(cfg))
+	reloaded, err := config.LoadConfig()
+	require.NoError(t, err)
+	token, err := config.GetZTTokenFrom(reloaded)
+	require.NoError(t, err)
+	assert.Equal(t, "controller-token", token)
+	password, err := config.GetDatabasePasswordFrom(reloaded)
+	require.NoError(t, err)
+	assert.Equal(t, "database-password", password)
+}
+
+func TestRotateEncryptionKeyReencryptsCredentials(t *testing.T) {
+	useTemporaryDataDir(t)
+	t.Setenv("JWT_SECRET", "")
+	cfg, err := config.LoadConfig()
+	require.NoError(t, err)
+	require.NoError(t, config.SetZTTokenOn(cfg, "controller-token"))
+	require.NoError(t, config.SetDatabasePasswordOn(cfg, "database-password"))
+	require.NoError(t, config.SaveConfig(cfg))
+	keyBefore, err := os.ReadFile(filepath.Join(paths.DataDir(), "master.key"))
+	require.NoError(t, err)
+	tokenBefore := cfg.ZeroTier.Token
+
+	require.NoError(t, config.RotateEncryptionKey(cfg))
+
+	keyAfter, err := os.ReadFile(filepath.Join(paths.DataDir(), "master.key"))
+	require.NoError(t, err)
+	assert.NotEqual(t, keyBefore, keyAfter)
+	assert.NotEqual(t, tokenBefore, cfg.ZeroTier.Token)
+
+	reloaded, err := config.LoadConfig()
+	require.NoError(t, err)
+	assert.Equal(t, cfg.ZeroTier.Token, reloaded.ZeroTier.Token, "rotated credentials must not need migrating")
+	token, err := config.GetZTTokenFrom(reloaded)
+	require.NoError(t, err)
+	assert.Equal(t, "controller-token", token)
+	password, err := config.GetDatabasePasswordFrom(reloaded)
+	require.NoError(t, err)
+	assert.Equal(t, "database-password", password)
+}
+
+func TestEncryptionKeyFileFromEnvironment(t *testing.T) {
+	useTemporaryDataDir(t)
+	t.Setenv("JWT_SECRET", "")
+	keyPath := filepath.Join(t.TempDir(), "master.key")
+	t.Setenv(config.EncryptionKeyFileEnv, keyPath)
+
+	_, err := config.LoadConfig()
+	require.Error(t, err, "a key file named by the environment must exist")
+
+	require.NoError(t, os.WriteFile(keyPath, []byte("a-docker-secret-used-as-the-key\n"), 0400))
+	cfg, err := config.LoadConfig()
+	require.NoError(t, err)
+	require.NoError(t, config.SetZTTokenOn(cfg, "controller-token"))
+	require.NoError(t, config.SaveConfig(cfg))
+	assert.NoFileExists(t, filepath.Join(paths.DataDir(), "master.key"))
+
+	assert.ErrorIs(t, config.RotateEncryptionKey(cfg), config.ErrEncryptionKeyExternal)
+	reloaded, err := config.LoadConfig()
+	require.NoError(t, err)
+	token, err := config.GetZTTokenFrom(reloaded)
+	require.NoError(t, err)
+	assert.Equal(t, "controller-token", token)
+}
+
+func TestRotateJWTSecretKeepsThePreviousSecretForTheWindow(t *testing.T) {
+	useTemporaryDataDir(t)
+	cfg := saveInitializedConfig(t, "an-original-secret-long-enough-for-use", "controller-token")
+	cfg.Security.PreviousJWTSecretHours = 2
+	cfg.Security.PreviousJWTSecrets = []config.RetiredJWTSecret{
+		{Secret: "an-expired-secret", ExpiresAt: time.Now().Add(-time.Minute)},
+	}
+
+	require.NoError(t, config.RotateJWTSecret(cfg))
+
+	assert.NotEqual(t, "an-original-secret-long-enough-for-use", cfg.Security.JWTSecret)
+	assert.GreaterOrEqual(t, len(cfg.Security.JWTSecret), config.MinSecretLength)
+	require.Len(t, cfg.Security.PreviousJWTSecrets, 1, "expired previous secrets are dropped")
+	previous := cfg.Security.PreviousJWTSecrets[0]
+	assert.Equal(t, "an-original-secret-long-enough-for-use", previous.Secret)
+	assert.WithinDuration(t, time.Now().Add(2*time.Hour), previous.ExpiresAt, time.Minute)
+
+	// Credentials encrypted with the JWT secret follow the rotation
+	token, err := config.GetZTTokenFrom(cfg)
+	require.NoError(t, err)
+	assert.Equal(t, "controller-token", token)
+
+	reloaded, err := config.LoadConfig()
+	require.NoError(t, err)
+	assert.Equal(t, cfg.Security.JWTSecret, reloaded.Security.JWTSecret)
+	assert.Equal(t, "an-original-secret-long-enough-for-use", reloaded.Security.PreviousJWTSecrets[0].Secret)
+	token, err = config.GetZTTokenFrom(reloaded)
+	require.NoError(t, err)
+	assert.Equal(t, "controller-token", token)
+}
+
+func TestTLSFromValidatesSettings(t *testing.T) {
+	settings, err := config.TLSFrom(&config.Config{})
+	require.NoError(t, err)
+	assert.Nil(t, settings)
+
+	settings, err = config.TLSFrom(&config.Config{Server: config.ServerConfig{Port: 443, TLS: config.TLSConfig{AutocertHosts: []string{"zt.example.com"}, RedirectPort: 80}}})
+	require.NoError(t, err)
+	assert.Equal(t, "./data/autocert", settings.AutocertCacheDir)
+	assert.Equal(t, 80, settings.RedirectPort)
+
+	for name, tls := range map[string]config.TLSConfig{
+		"files and autocert":      {CertFile: "cert.pem", KeyFile: "key.pem", AutocertHosts: []string{"zt.example.com"}},
+		"certificate without key": {CertFile: "cert.pem"},
+		"redirect without tls":    {RedirectPort: 80},
+		"redirect to own port":    {CertFile: "cert.pem", KeyFile: "key.pem", RedirectPort: 443},
+	} {
+		_, err := config.TLSFrom(&config.Config{Server: config.ServerConfig{Port: 443, TLS: tls}})
+		assert.Error(t, err, name)
+	}
+}
+
+func TestLogShippingFromAppliesDefaults(t *testing.T) {
+	settings, err := config.LogShippingFrom(&config.Config{})
+	require.NoError(t, err)
+	assert.Nil(t, settings)
+
+	settings, err = config.LogShippingFrom(&config.Config{LogShipping: config.LogShippingConfig{Target: "udp://siem.example.com:514/"}})
+	require.NoError(t, err)
+	assert.Equal(t, &config.LogShippingConfig{Target: "udp://siem.example.com:514", Format: config.LogShippingFormatRFC5424, QueueSize: 1024}, settings)
+
+	settings, err = config.LogShippingFrom(&config.Config{LogShipping: config.LogShippingConfig{Target: "file:events.jsonl", QueueSize: 10}})
+	require.NoError(t, err)
+	assert.Equal(t, "file:"+paths.Resolve("events.jsonl"), settings.Target)
+	assert.Equal(t, config.LogShippingFormatJSON, settings.Format)
+	assert.Equal(t, 10, settings.QueueSize)
+
+	for name, shipping := range map[string]config.LogShippingConfig{
+		"unknown scheme":      {Target: "http://siem.example.com:514"},
+		"missing port":        {Target: "tcp://siem.example.com"},
+		"file without path":   {Target: "file:"},
+		"unknown format":      {Target: "tcp://siem.example.com:514", Format: "cef"},
+		"negative queue size": {Target: "tcp://siem.example.com:514", QueueSize: -1},
+	} {
+		_, err := config.LogShippingFrom(&config.Config{LogShipping: shipping})
+		assert.Error(t, err, name)
+	}
+}
+
+func TestCaptchaFromAppliesProviderDefaults(t *testing.T) {
+	settings, err := config.CaptchaFrom(&config.Config{})
+	require.NoError(t, err)
+	assert.Empty(t, settings.Provider)
+
+	settings, err = config.CaptchaFrom(&config.Config{Captcha: config.CaptchaConfig{Provider: "Turnstile", SiteKey: "site", Secret: "secret"}})
+	require.NoError(t, err)
+	assert.Equal(t, config.CaptchaConfig{
+		Provider:      config.CaptchaProviderTurnstile,
+		SiteKey:       "site",
+		Secret:        "secret",
+		VerifyURL:     "https://challenges.cloudflare.com/turnstile/v0/siteverify",
+		LoginFailures: 3,
+	}, settings)
+
+	_, err = config.CaptchaFrom(&config.Config{Captcha: config.CaptchaConfig{Provider: "hcaptcha", SiteKey: "site", Secret: "secret", LoginFailures: -1}})
+	assert.Error(t, err)
+}
+
+func TestProxySettingsRejectInvalidEntries(t *testing.T) {
+	proxies, header, err := config.ProxySettingsFrom(&config.Config{Server: config.ServerConfig{
+		ProxyHeader:    "Forwarded",
+		TrustedProxies: []string{"10.0.0.0/8", "proxy.internal", "fd00::1"},
+	}})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "proxy.internal")
+	assert.Contains(t, err.Error(), "Forwarded")
+	assert.Equal(t, []string{"10.0.0.0/8", "fd00::1"}, proxies)
+	assert.Equal(t, "X-Real-IP", header)
+}
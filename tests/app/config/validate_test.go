@@ -0,0 +1,123 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/GT-610/tairitsu/internal/app/config"
+	"github.com/GT-610/tairitsu/internal/app/paths"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// validConfig returns an initialized configuration that passes Validate
+func validConfig() *config.Config {
+	return &config.Config{
+		Initialized: true,
+		Server:      config.ServerConfig{Port: 8080},
+		Security:    config.SecurityConfig{JWTSecret: "a-valid-secret-long-enough-for-use"},
+		ZeroTier:    config.ZeroTierConfig{URL: "http://localhost:9993"},
+		Database:    config.DatabaseConfig{Type: config.DatabaseSQLite, Path: config.DefaultSQLitePath},
+	}
+}
+
+func TestValidateRules(t *testing.T) {
+	tests := []struct {
+		name    string
+		edit    func(cfg *config.Config)
+		problem string
+	}{
+		{"port zero", func(cfg *config.Config) { cfg.Server.Port = 0 }, "server.port must be between 1 and 65535, got 0"},
+		{"port above range", func(cfg *config.Config) { cfg.Server.Port = 70000 }, "server.port must be between 1 and 65535, got 70000"},
+		{"missing secret once initialized", func(cfg *config.Config) { cfg.Security.JWTSecret = "" }, "security.jwt_secret must be set once the system is initialized"},
+		{"password hash cost below bcrypt minimum", func(cfg *config.Config) { cfg.Security.PasswordHashCost = 3 }, "security.password_hash_cost must be between 4 and 16, got 3"},
+		{"password hash cost above range", func(cfg *config.Config) { cfg.Security.PasswordHashCost = 17 }, "security.password_hash_cost must be between 4 and 16, got 17"},
+		{"zerotier url without scheme", func(cfg *config.Config) { cfg.ZeroTier.URL = "localhost:9993" }, "zerotier.url must start with http:// or https://"},
+		{"zerotier url without host", func(cfg *config.Config) { cfg.ZeroTier.URL = "http://" }, "zerotier.url must name a host"},
+		{"controller url", func(cfg *config.Config) {
+			cfg.ZeroTier.Controllers = []config.ZeroTierControllerConfig{{Name: "lab", URL: "ftp://lab:9993"}}
+		}, "zerotier.controllers[0].url must start with http:// or https://"},
+		{"unknown database type", func(cfg *config.Config) { cfg.Database.Type = "oracle" }, `database.type must be sqlite, postgresql or mysql, got "oracle"`},
+		{"postgresql without host", func(cfg *config.Config) {
+			cfg.Database = config.DatabaseConfig{Type: config.DatabasePostgreSQL, Port: 5432, User: "tairitsu", Name: "tairitsu"}
+		}, "database.host is required for postgresql"},
+		{"mysql without port", func(cfg *config.Config) {
+			cfg.Database = config.DatabaseConfig{Type: config.DatabaseMySQL, Host: "db", User: "tairitsu", Name: "tairitsu"}
+		}, "database.port must be between 1 and 65535 for mysql, got 0"},
+		{"sqlite journal mode", func(cfg *config.Config) { cfg.Database.SQLiteJournalMode = "wal2" }, `database.sqlite_journal_mode must be WAL, DELETE, TRUNCATE, PERSIST, MEMORY or OFF, got "wal2"`},
+		{"negative sqlite busy timeout", func(cfg *config.Config) { cfg.Database.SQLiteBusyTimeoutMillis = -1 }, "database.sqlite_busy_timeout_ms must not be negative"},
+		{"logging level", func(cfg *config.Config) { cfg.Logging.Level = "verbose" }, `logging.level must be debug, info, warn or error, got "verbose"`},
+		{"negative rate limit", func(cfg *config.Config) {
+			cfg.RateLimits = map[string]config.RateLimitConfig{"auth": {RefillRate: -1}}
+		}, "rate_limits.auth: capacity and refill_rate must not be negative"},
+		{"tls", func(cfg *config.Config) { cfg.Server.TLS.CertFile = "cert.pem" }, "server.tls: cert_file and key_file must be set together"},
+		{"captcha provider", func(cfg *config.Config) {
+			cfg.Captcha = config.CaptchaConfig{Provider: "recaptcha", SiteKey: "site", Secret: "secret"}
+		}, `captcha.provider must be hcaptcha or turnstile, got "recaptcha"`},
+		{"captcha without secret", func(cfg *config.Config) { cfg.Captcha = config.CaptchaConfig{Provider: "hcaptcha", SiteKey: "site"} }, "captcha requires site_key and secret"},
+		{"log shipping target", func(cfg *config.Config) { cfg.LogShipping.Target = "syslog.example.com:514" }, "log_shipping.target must start with udp://, tcp:// or file:"},
+	}
+
+	require.NoError(t, validConfig().Validate())
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := validConfig()
+			tt.edit(cfg)
+
+			err := cfg.Validate()
+			require.ErrorIs(t, err, config.ErrInvalidConfig)
+			var validationErr *config.ValidationError
+			require.True(t, errors.As(err, &validationErr))
+			require.Len(t, validationErr.Problems, 1)
+			assert.Contains(t, validationErr.Problems[0], tt.problem)
+		})
+	}
+}
+
+func TestValidateListsEveryProblem(t *testing.T) {
+	cfg := validConfig()
+	cfg.Server.Port = 0
+	cfg.Security.JWTSecret = ""
+	cfg.ZeroTier.URL = "localhost:9993"
+
+	var validationErr *config.ValidationError
+	require.True(t, errors.As(cfg.Validate(), &validationErr))
+	assert.Len(t, validationErr.Problems, 3)
+	assert.Contains(t, validationErr.Error(), "  - server.port")
+	assert.Contains(t, validationErr.Error(), "  - zerotier.url")
+}
+
+func TestValidateOnlyRequiresSecretOnceInitialized(t *testing.T) {
+	cfg := validConfig()
+	cfg.Initialized = false
+	cfg.Security.JWTSecret = ""
+	cfg.Database = config.DatabaseConfig{}
+	assert.NoError(t, cfg.Validate())
+}
+
+func TestLoadConfigRefusesInvalidInitializedConfig(t *testing.T) {
+	useTemporaryDataDir(t)
+	cfg := validConfig()
+	cfg.ZeroTier.URL = "localhost:9993"
+	writeConfigFile(t, cfg)
+
+	loaded, err := config.LoadConfig()
+	require.ErrorIs(t, err, config.ErrInvalidConfig)
+	assert.Nil(t, loaded)
+	assert.Contains(t, err.Error(), filepath.Join(paths.DataDir(), "config.json"))
+
+	assert.ErrorIs(t, config.SaveConfig(cfg), config.ErrInvalidConfig)
+}
+
+func TestLoadConfigAcceptsInvalidUninitializedConfig(t *testing.T) {
+	useTemporaryDataDir(t)
+	t.Setenv("JWT_SECRET", "")
+	require.NoError(t, os.WriteFile(filepath.Join(paths.DataDir(), "config.json"), []byte(`{"server":{"port":0}}`), 0600))
+
+	cfg, err := config.LoadConfig()
+	require.NoError(t, err, "the setup wizard must still start")
+	assert.False(t, cfg.Initialized)
+	assert.NoError(t, config.SaveConfig(cfg))
+}
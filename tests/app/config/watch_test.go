@@ -0,0 +1,106 @@
+package config
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/GT-610/tairitsu/internal/app/config"
+	"github.com/GT-610/tairitsu/internal/app/paths"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const watchTestSecret = "a-reloaded-secret-long-enough-for-use"
+
+// loadWatchedConfig saves an initialized config.json and loads it like the server does
+func loadWatchedConfig(t *testing.T) *config.Config {
+	t.Helper()
+	useTemporaryDataDir(t)
+	t.Setenv("JWT_SECRET", "")
+	saveInitializedConfig(t, watchTestSecret, "controller-token")
+	cfg, err := config.LoadConfig()
+	require.NoError(t, err)
+	return cfg
+}
+
+// editConfigFile changes config.json the way an administrator would, bypassing SaveConfig
+func editConfigFile(t *testing.T, edit func(cfg *config.Config)) {
+	t.Helper()
+	cfg, err := config.LoadConfig()
+	require.NoError(t, err)
+	edit(cfg)
+	writeConfigFile(t, cfg)
+}
+
+func TestReloadKeepsPreviousConfigOnInvalidFile(t *testing.T) {
+	cfg := loadWatchedConfig(t)
+	notified := 0
+	t.Cleanup(config.Subscribe(func(config.Change) { notified++ }))
+
+	require.NoError(t, os.WriteFile(paths.ConfigFile(), []byte(`{"initialized": true, "security": {`), 0600))
+	assert.ErrorContains(t, config.Reload(cfg), "failed to parse configuration file")
+
+	editConfigFile(t, func(edited *config.Config) { edited.Logging.Level = "verbose" })
+	assert.ErrorContains(t, config.Reload(cfg), "logging.level")
+
+	editConfigFile(t, func(edited *config.Config) {
+		edited.Logging.Level = ""
+		edited.RateLimits = map[string]config.RateLimitConfig{"auth": {Capacity: -1}}
+	})
+	assert.ErrorContains(t, config.Reload(cfg), "rate_limits.auth")
+
+	assert.Equal(t, watchTestSecret, cfg.Security.JWTSecret)
+	assert.Empty(t, cfg.Logging.Level)
+	assert.Empty(t, cfg.RateLimits)
+	assert.Zero(t, notified)
+}
+
+func TestReloadNotifiesSubscribers(t *testing.T) {
+	cfg := loadWatchedConfig(t)
+	var changes []config.Change
+	unsubscribe := config.Subscribe(func(change config.Change) { changes = append(changes, change) })
+	t.Cleanup(unsubscribe)
+
+	editConfigFile(t, func(edited *config.Config) {
+		edited.Logging.Level = "debug"
+		edited.Server.Port = 9000
+	})
+	require.NoError(t, config.Reload(cfg))
+
+	require.Len(t, changes, 1)
+	assert.Same(t, cfg, changes[0].Current, "the live configuration is updated in place")
+	assert.Empty(t, changes[0].Previous.Logging.Level)
+	assert.Equal(t, "debug", cfg.Logging.Level)
+	assert.Equal(t, []string{"server.port"}, config.RestartRequired(changes[0].Previous, changes[0].Current))
+
+	require.ErrorIs(t, config.Reload(cfg), config.ErrConfigUnchanged, "an unchanged file is not reloaded")
+	require.NoError(t, config.SaveConfig(cfg))
+	require.ErrorIs(t, config.Reload(cfg), config.ErrConfigUnchanged, "the application's own writes are not reloaded")
+
+	unsubscribe()
+	editConfigFile(t, func(edited *config.Config) { edited.Logging.Level = "warn" })
+	require.NoError(t, config.Reload(cfg))
+	assert.Len(t, changes, 1)
+}
+
+func TestWatchReloadsEditedFile(t *testing.T) {
+	cfg := loadWatchedConfig(t)
+	reloaded := make(chan config.Change, 1)
+	t.Cleanup(config.Subscribe(func(change config.Change) { reloaded <- change }))
+
+	watcher, err := config.Watch(cfg)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = watcher.Close() })
+
+	editConfigFile(t, func(edited *config.Config) {
+		edited.RateLimits = map[string]config.RateLimitConfig{"auth": {Capacity: 20, RefillRate: 2}}
+	})
+	select {
+	case change := <-reloaded:
+		assert.Empty(t, change.Previous.RateLimits)
+		assert.Equal(t, config.RateLimitConfig{Capacity: 20, RefillRate: 2}, change.Current.RateLimits["auth"])
+	case <-time.After(5 * time.Second):
+		t.Fatal("the edited configuration was not reloaded")
+	}
+}
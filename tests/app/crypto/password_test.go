@@ -0,0 +1,56 @@
+package crypto
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/GT-610/tairitsu/internal/app/crypto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSealWithPassword_RoundTrip(t *testing.T) {
+	plaintext := []byte(`{"users":[]}`)
+
+	sealed, err := crypto.SealWithPassword(plaintext, "archive-password")
+	require.NoError(t, err)
+	assert.Equal(t, crypto.KDFArgon2id, sealed.KDF)
+	assert.NotEmpty(t, sealed.Salt)
+
+	opened, err := crypto.OpenWithPassword(sealed, "archive-password")
+	require.NoError(t, err)
+	assert.Equal(t, plaintext, opened)
+
+	// A fresh salt makes every seal distinct
+	again, err := crypto.SealWithPassword(plaintext, "archive-password")
+	require.NoError(t, err)
+	assert.NotEqual(t, sealed.Salt, again.Salt)
+	assert.NotEqual(t, sealed.Ciphertext, again.Ciphertext)
+}
+
+func TestOpenWithPassword_WrongPassword(t *testing.T) {
+	sealed, err := crypto.SealWithPassword([]byte("secret"), "archive-password")
+	require.NoError(t, err)
+
+	opened, err := crypto.OpenWithPassword(sealed, "other-password")
+	assert.True(t, errors.Is(err, crypto.ErrDecryptFailed))
+	assert.Nil(t, opened)
+
+	_, err = crypto.SealWithPassword([]byte("secret"), "")
+	assert.True(t, errors.Is(err, crypto.ErrEncryptFailed))
+}
+
+func TestOpenWithPassword_RejectsUnsafeParameters(t *testing.T) {
+	sealed, err := crypto.SealWithPassword([]byte("secret"), "archive-password")
+	require.NoError(t, err)
+
+	tampered := *sealed
+	tampered.MemoryKiB = 4 * 1024 * 1024
+	_, err = crypto.OpenWithPassword(&tampered, "archive-password")
+	assert.True(t, errors.Is(err, crypto.ErrInvalidKDFSettings))
+
+	tampered = *sealed
+	tampered.KDF = "scrypt"
+	_, err = crypto.OpenWithPassword(&tampered, "archive-password")
+	assert.True(t, errors.Is(err, crypto.ErrUnsupportedKDF))
+}
@@ -0,0 +1,42 @@
+package crypto
+
+import (
+	"encoding/base64"
+	"net/url"
+	"testing"
+
+	"github.com/GT-610/tairitsu/internal/app/crypto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerateSecret_LengthAndURLSafety(t *testing.T) {
+	for _, length := range []int{1, 16, crypto.SecretLength, 64} {
+		secret, err := crypto.GenerateSecret(length)
+		require.NoError(t, err)
+		assert.Len(t, secret, base64.URLEncoding.EncodedLen(length))
+		assert.Equal(t, url.PathEscape(secret), secret, "secrets can be used in URLs unescaped")
+
+		decoded, err := base64.URLEncoding.DecodeString(secret)
+		require.NoError(t, err)
+		assert.Len(t, decoded, length)
+	}
+}
+
+func TestGenerateSecret_Unique(t *testing.T) {
+	seen := make(map[string]bool)
+	for range 1000 {
+		secret, err := crypto.GenerateSecret(crypto.SecretLength)
+		require.NoError(t, err)
+		require.False(t, seen[secret], "secret repeated")
+		seen[secret] = true
+	}
+}
+
+func TestGenerateSecret_RejectsInvalidLength(t *testing.T) {
+	for _, length := range []int{0, -1} {
+		secret, err := crypto.GenerateSecret(length)
+		assert.ErrorIs(t, err, crypto.ErrInvalidSecretLength)
+		assert.Empty(t, secret)
+	}
+}
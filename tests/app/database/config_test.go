@@ -0,0 +1,44 @@
+package database
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/GT-610/tairitsu/internal/app/config"
+	appdb "github.com/GT-610/tairitsu/internal/app/database"
+	"github.com/GT-610/tairitsu/internal/app/models"
+	"github.com/GT-610/tairitsu/internal/app/paths"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// The setup wizard saves the path it was given, and the factory opens the path it loads;
+// both must fall back to the same file, or a fresh install opens an empty database.
+func TestDefaultSQLitePathMatchesAcrossSetupAndFactory(t *testing.T) {
+	fromWizard := appdb.ConfigFromRequest(models.DatabaseConfig{Type: "sqlite"})
+	fromConfigFile := appdb.LoadConfigFromApp(&config.Config{Database: config.DatabaseConfig{Type: config.DatabaseSQLite}})
+
+	assert.Equal(t, config.DefaultSQLitePath, fromWizard.Path)
+	assert.Equal(t, fromWizard.Path, fromConfigFile.Path)
+}
+
+func TestConfigFromRequestKeepsServerSettings(t *testing.T) {
+	cfg := appdb.ConfigFromRequest(models.DatabaseConfig{Type: "mysql", Host: "db", Port: 3306, User: "tairitsu", Pass: "secret", Name: "tairitsu"})
+
+	assert.Equal(t, appdb.Config{Type: appdb.MySQL, Host: "db", Port: 3306, User: "tairitsu", Pass: "secret", Name: "tairitsu"}, cfg)
+}
+
+// Configurations written before the data directory was configurable name the database
+// data/tairitsu.db, relative to the working directory that held ./data
+func TestDefaultSQLitePathOpensInsideTheDataDirectory(t *testing.T) {
+	dataDir := t.TempDir()
+	t.Cleanup(paths.SetDataDir(dataDir))
+
+	db, err := appdb.NewDatabase(appdb.LoadConfigFromApp(&config.Config{Database: config.DatabaseConfig{Type: config.DatabaseSQLite, Path: config.DefaultSQLitePath}}))
+	require.NoError(t, err)
+	require.NoError(t, db.Init())
+	require.NoError(t, db.Close())
+
+	assert.FileExists(t, filepath.Join(dataDir, "tairitsu.db"))
+	assert.NoDirExists(t, filepath.Join(dataDir, "data"))
+}
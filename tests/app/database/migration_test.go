@@ -0,0 +1,131 @@
+package database
+
+import (
+	"path/filepath"
+	"testing"
+
+	appdb "github.com/GT-610/tairitsu/internal/app/database"
+	"github.com/GT-610/tairitsu/internal/app/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestMigrationsApplyToAFreshDatabase(t *testing.T) {
+	db, err := appdb.NewDatabase(appdb.Config{Type: appdb.SQLite, Path: filepath.Join(t.TempDir(), "tairitsu.db")})
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = db.Close()
+	})
+	migrator, ok := appdb.AsMigrator(db)
+	require.True(t, ok)
+
+	pending, err := migrator.Migrations()
+	require.NoError(t, err)
+	require.NotEmpty(t, pending)
+	for i, info := range pending {
+		assert.Equal(t, i+1, info.Version, "versions run in order without gaps")
+		assert.Nil(t, info.AppliedAt)
+	}
+
+	require.NoError(t, db.Init())
+	applied, err := migrator.Migrations()
+	require.NoError(t, err)
+	require.Len(t, applied, len(pending))
+	for _, info := range applied {
+		assert.NotNil(t, info.AppliedAt, "migration %d", info.Version)
+	}
+
+	ran, err := migrator.MigrateUp()
+	require.NoError(t, err)
+	assert.Empty(t, ran)
+}
+
+// A database created before migrations were versioned has every table but no
+// schema_migrations; migrating it must end with the same schema as a fresh install
+func TestMigrationsConvergeWithDatabasesFromBeforeVersioning(t *testing.T) {
+	dir := t.TempDir()
+	freshPath := filepath.Join(dir, "fresh.db")
+	legacyPath := filepath.Join(dir, "legacy.db")
+
+	fresh := openSQLiteForTest(t, freshPath)
+	require.NoError(t, fresh.Close())
+
+	legacy := openSQLiteForTest(t, legacyPath)
+	require.NoError(t, legacy.CreateNetwork(&models.Network{ID: "8056c2e21c000001", Name: "legacy", OrganizationID: models.DefaultOrganizationID}))
+	require.NoError(t, legacy.Close())
+	raw := openRawSQLite(t, legacyPath)
+	require.NoError(t, raw.Exec("DROP TABLE schema_migrations").Error)
+	closeRawSQLite(t, raw)
+
+	legacy = openSQLiteForTest(t, legacyPath)
+	migrator, ok := appdb.AsMigrator(legacy)
+	require.True(t, ok)
+	infos, err := migrator.Migrations()
+	require.NoError(t, err)
+	for _, info := range infos {
+		assert.NotNil(t, info.AppliedAt, "migration %d", info.Version)
+	}
+	network, err := legacy.GetNetworkByID("8056c2e21c000001")
+	require.NoError(t, err)
+	require.NotNil(t, network)
+	require.NoError(t, legacy.Close())
+
+	assert.Equal(t, sqliteSchema(t, freshPath), sqliteSchema(t, legacyPath))
+}
+
+func TestMigrationsAddTheMemberMetadataUniqueIndex(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tairitsu.db")
+	db := openSQLiteForTest(t, path)
+	require.NoError(t, db.Close())
+	raw := openRawSQLite(t, path)
+	require.NoError(t, raw.Exec("DROP INDEX idx_member_metadata_member").Error)
+	require.NoError(t, raw.Exec("DELETE FROM schema_migrations WHERE version = 5").Error)
+	closeRawSQLite(t, raw)
+
+	db = openSQLiteForTest(t, path)
+	require.NoError(t, db.Close())
+	for _, object := range sqliteSchema(t, path) {
+		if object.Name == "idx_member_metadata_member" {
+			require.NotNil(t, object.SQL)
+			assert.Equal(t, "CREATE UNIQUE INDEX `idx_member_metadata_member` ON `member_metadata`(`network_id`,`member_id`)", *object.SQL)
+			return
+		}
+	}
+	t.Fatal("the member metadata unique index was not created")
+}
+
+type schemaObject struct {
+	Type string
+	Name string
+	SQL  *string
+}
+
+// sqliteSchema lists the tables and indexes of the database at path with their definitions
+func sqliteSchema(t *testing.T, path string) []schemaObject {
+	t.Helper()
+
+	raw := openRawSQLite(t, path)
+	defer closeRawSQLite(t, raw)
+	var objects []schemaObject
+	require.NoError(t, raw.Raw("SELECT type, name, sql FROM sqlite_master WHERE type IN ('table', 'index') ORDER BY type, name").Scan(&objects).Error)
+	require.NotEmpty(t, objects)
+	return objects
+}
+
+func openRawSQLite(t *testing.T, path string) *gorm.DB {
+	t.Helper()
+
+	raw, err := gorm.Open(sqlite.Open(path), &gorm.Config{})
+	require.NoError(t, err)
+	return raw
+}
+
+func closeRawSQLite(t *testing.T, raw *gorm.DB) {
+	t.Helper()
+
+	sqlDB, err := raw.DB()
+	require.NoError(t, err)
+	require.NoError(t, sqlDB.Close())
+}
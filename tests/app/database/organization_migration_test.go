@@ -0,0 +1,45 @@
+package database
+
+import (
+	"path/filepath"
+	"testing"
+
+	appdb "github.com/GT-610/tairitsu/internal/app/database"
+	"github.com/GT-610/tairitsu/internal/app/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestInitPlacesExistingNetworksInTheDefaultOrganization(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tairitsu.db")
+
+	// A networks table from before organizations existed
+	raw, err := gorm.Open(sqlite.Open(path), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, raw.Exec("CREATE TABLE networks (id text PRIMARY KEY, name text, description text, owner_id text, created_at datetime, updated_at datetime)").Error)
+	require.NoError(t, raw.Exec("INSERT INTO networks (id, name, owner_id) VALUES ('8056c2e21c000001', 'legacy', 'user-1')").Error)
+	sqlDB, err := raw.DB()
+	require.NoError(t, err)
+	require.NoError(t, sqlDB.Close())
+
+	db, err := appdb.NewDatabase(appdb.Config{Type: appdb.SQLite, Path: path})
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = db.Close()
+	})
+	require.NoError(t, db.Init())
+	// Migrating again leaves the default organization alone
+	require.NoError(t, db.Init())
+
+	network, err := db.GetNetworkByID("8056c2e21c000001")
+	require.NoError(t, err)
+	require.NotNil(t, network)
+	assert.Equal(t, models.DefaultOrganizationID, network.OrganizationID)
+
+	organizations, err := db.ListOrganizations()
+	require.NoError(t, err)
+	require.Len(t, organizations, 1)
+	assert.Equal(t, models.DefaultOrganizationID, organizations[0].ID)
+}
@@ -1,8 +1,10 @@
 package database
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
+	"sync"
 	"testing"
 
 	appdb "github.com/GT-610/tairitsu/internal/app/database"
@@ -29,3 +31,61 @@ func TestNewDatabase_SQLiteCreatesParentDirectory(t *testing.T) {
 	_, err = os.Stat(dbPath)
 	assert.NoError(t, err)
 }
+
+// Parallel writes from the server and a command-line subcommand, which opens the same file
+// through its own handle, must queue for the write lock rather than fail with "database is locked"
+func TestNewDatabase_SQLiteParallelWritesDoNotLock(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "tairitsu.db")
+	server := openSQLiteForTest(t, dbPath)
+	command := openSQLiteForTest(t, dbPath)
+
+	const writers, usersPerWriter = 32, 20
+	var wg sync.WaitGroup
+	errs := make(chan error, writers*usersPerWriter*2)
+	for w := range writers {
+		db := server
+		if w%4 == 0 {
+			db = command
+		}
+		wg.Add(1)
+		go func(w int, db appdb.DBInterface) {
+			defer wg.Done()
+			for i := range usersPerWriter {
+				id := fmt.Sprintf("user-%d-%d", w, i)
+				if i%2 == 0 {
+					errs <- db.CreateUser(testTxUser(id))
+				} else {
+					errs <- db.WithTransaction(func(tx appdb.DBInterface) error {
+						if _, err := tx.GetUserByUsername(id); err != nil {
+							return err
+						}
+						return tx.CreateUser(testTxUser(id))
+					})
+				}
+				_, err := db.GetAllUsers()
+				errs <- err
+			}
+		}(w, db)
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		require.NoError(t, err)
+	}
+	users, err := server.GetAllUsers()
+	require.NoError(t, err)
+	assert.Len(t, users, writers*usersPerWriter)
+}
+
+func openSQLiteForTest(t *testing.T, path string) appdb.DBInterface {
+	t.Helper()
+
+	db, err := appdb.NewDatabase(appdb.Config{Type: appdb.SQLite, Path: path})
+	require.NoError(t, err)
+	require.NoError(t, db.Init())
+	t.Cleanup(func() {
+		_ = db.Close()
+	})
+	return db
+}
@@ -0,0 +1,140 @@
+package database
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	appdb "github.com/GT-610/tairitsu/internal/app/database"
+	"github.com/GT-610/tairitsu/internal/app/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// transactionBackends lists every backend the transaction conformance cases run against.
+// MySQL and PostgreSQL share the GORM implementation and need a live server.
+func transactionBackends() map[string]func(t *testing.T) appdb.DBInterface {
+	return map[string]func(t *testing.T) appdb.DBInterface{
+		"sqlite": func(t *testing.T) appdb.DBInterface {
+			db, err := appdb.NewDatabase(appdb.Config{Type: appdb.SQLite, Path: filepath.Join(t.TempDir(), "tairitsu.db")})
+			require.NoError(t, err)
+			require.NoError(t, db.Init())
+			t.Cleanup(func() {
+				_ = db.Close()
+			})
+			return db
+		},
+	}
+}
+
+func testTxUser(id string) *models.User {
+	now := time.Now()
+	return &models.User{ID: id, Username: id, Password: "hashed", Role: "user", CreatedAt: now, UpdatedAt: now}
+}
+
+func requireUserExists(t *testing.T, db appdb.DBInterface, id string, exists bool) {
+	t.Helper()
+
+	user, err := db.GetUserByID(id)
+	require.NoError(t, err)
+	if exists {
+		assert.NotNil(t, user, "user %s should exist", id)
+	} else {
+		assert.Nil(t, user, "user %s should not exist", id)
+	}
+}
+
+func TestWithTransactionConformance(t *testing.T) {
+	for name, newDB := range transactionBackends() {
+		t.Run(name+"/commit", func(t *testing.T) {
+			db := newDB(t)
+			require.NoError(t, db.WithTransaction(func(tx appdb.DBInterface) error {
+				return tx.CreateUser(testTxUser("committed"))
+			}))
+			requireUserExists(t, db, "committed", true)
+		})
+
+		t.Run(name+"/rollback on error", func(t *testing.T) {
+			db := newDB(t)
+			errAbort := errors.New("abort")
+			err := db.WithTransaction(func(tx appdb.DBInterface) error {
+				if err := tx.CreateUser(testTxUser("rolled-back")); err != nil {
+					return err
+				}
+				return errAbort
+			})
+			require.ErrorIs(t, err, errAbort)
+			requireUserExists(t, db, "rolled-back", false)
+		})
+
+		t.Run(name+"/rollback spans tables", func(t *testing.T) {
+			db := newDB(t)
+			errAbort := errors.New("abort")
+			err := db.WithTransaction(func(tx appdb.DBInterface) error {
+				if err := tx.CreateUser(testTxUser("owner")); err != nil {
+					return err
+				}
+				if err := tx.CreateNetwork(&models.Network{ID: "8056c2e21c000001", Name: "net", OwnerID: "owner"}); err != nil {
+					return err
+				}
+				return errAbort
+			})
+			require.ErrorIs(t, err, errAbort)
+			requireUserExists(t, db, "owner", false)
+			network, err := db.GetNetworkByID("8056c2e21c000001")
+			require.NoError(t, err)
+			assert.Nil(t, network)
+		})
+
+		t.Run(name+"/rollback on panic", func(t *testing.T) {
+			db := newDB(t)
+			assert.Panics(t, func() {
+				_ = db.WithTransaction(func(tx appdb.DBInterface) error {
+					require.NoError(t, tx.CreateUser(testTxUser("panicked")))
+					panic("boom")
+				})
+			})
+			requireUserExists(t, db, "panicked", false)
+		})
+
+		t.Run(name+"/nested joins outer", func(t *testing.T) {
+			db := newDB(t)
+			errAbort := errors.New("abort")
+			err := db.WithTransaction(func(tx appdb.DBInterface) error {
+				if err := tx.CreateUser(testTxUser("outer")); err != nil {
+					return err
+				}
+				innerErr := tx.WithTransaction(func(inner appdb.DBInterface) error {
+					if err := inner.CreateUser(testTxUser("inner-failed")); err != nil {
+						return err
+					}
+					return errAbort
+				})
+				require.ErrorIs(t, innerErr, errAbort)
+				return tx.WithTransaction(func(inner appdb.DBInterface) error {
+					return inner.CreateUser(testTxUser("inner-committed"))
+				})
+			})
+			require.NoError(t, err)
+			requireUserExists(t, db, "outer", true)
+			requireUserExists(t, db, "inner-committed", true)
+			requireUserExists(t, db, "inner-failed", false)
+		})
+
+		t.Run(name+"/outer rollback undoes nested", func(t *testing.T) {
+			db := newDB(t)
+			errAbort := errors.New("abort")
+			err := db.WithTransaction(func(tx appdb.DBInterface) error {
+				if err := tx.WithTransaction(func(inner appdb.DBInterface) error {
+					return inner.CreateUser(testTxUser("nested"))
+				}); err != nil {
+					return err
+				}
+				return errAbort
+			})
+			require.ErrorIs(t, err, errAbort)
+			requireUserExists(t, db, "nested", false)
+		})
+	}
+}
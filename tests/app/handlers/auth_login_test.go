@@ -8,6 +8,7 @@ import (
 	"path/filepath"
 	"testing"
 
+	"github.com/GT-610/tairitsu/internal/app/apptest"
 	"github.com/GT-610/tairitsu/internal/app/database"
 	apphandlers "github.com/GT-610/tairitsu/internal/app/handlers"
 	"github.com/GT-610/tairitsu/internal/app/models"
@@ -39,7 +40,7 @@ func TestAuthHandler_LoginRecordsForwardedClientIP(t *testing.T) {
 	authHandler := apphandlers.NewAuthHandler(
 		userService,
 		sessionService,
-		services.NewJWTService("test-secret"),
+		newTestJWTService(t, "test-secret"),
 		nil,
 		nil,
 	)
@@ -54,7 +55,7 @@ func TestAuthHandler_LoginRecordsForwardedClientIP(t *testing.T) {
 	req := httptest.NewRequest(http.MethodPost, "/auth/login", bytes.NewBufferString(`{"username":"alice","password":"secret123"}`))
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("X-Real-IP", "203.0.113.10")
-	resp, err := app.Test(req)
+	resp, err := app.Test(req, apptest.SlowTestConfig())
 	require.NoError(t, err)
 	defer resp.Body.Close()
 	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
@@ -65,3 +66,10 @@ func TestAuthHandler_LoginRecordsForwardedClientIP(t *testing.T) {
 	require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
 	assert.Equal(t, "203.0.113.10", body.Session.IPAddress)
 }
+
+func newTestJWTService(t *testing.T, secretKey string) *services.JWTService {
+	t.Helper()
+	jwtService, err := services.NewJWTService(secretKey)
+	require.NoError(t, err)
+	return jwtService
+}
@@ -9,6 +9,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/GT-610/tairitsu/internal/app/apptest"
 	"github.com/GT-610/tairitsu/internal/app/database"
 	apphandlers "github.com/GT-610/tairitsu/internal/app/handlers"
 	"github.com/GT-610/tairitsu/internal/app/middleware"
@@ -29,7 +30,7 @@ func TestAuthHandler_ChangePasswordRevokesOtherSessionsWhenRequested(t *testing.
 
 	userService := services.NewUserService(db)
 	sessionService := services.NewSessionService(db)
-	jwtService := services.NewJWTService("test-secret")
+	jwtService := newTestJWTService(t, "test-secret")
 	authHandler := apphandlers.NewAuthHandler(userService, sessionService, jwtService, nil, nil)
 
 	user, err := userService.Register(&models.RegisterRequest{
@@ -63,28 +64,25 @@ func TestAuthHandler_ChangePasswordRevokesOtherSessionsWhenRequested(t *testing.
 	app.Put("/profile/password", authHandler.ChangePassword)
 
 	body, err := json.Marshal(map[string]any{
-		"current_password":      "secret123",
-		"new_password":          "updated456",
-		"confirm_password":      "updated456",
-		"logout_other_sessions": true,
+		"currentPassword":     "secret123",
+		"newPassword":         "updated456",
+		"confirmPassword":     "updated456",
+		"logoutOtherSessions": true,
 	})
 	require.NoError(t, err)
 
 	req := httptest.NewRequest(http.MethodPut, "/profile/password", bytes.NewReader(body))
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("Authorization", "Bearer "+token)
-	resp, err := app.Test(req, fiber.TestConfig{
-		Timeout:       10 * time.Second,
-		FailOnTimeout: true,
-	})
+	resp, err := app.Test(req, apptest.SlowTestConfig())
 	require.NoError(t, err)
 	defer resp.Body.Close()
 	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
 
 	var responseBody struct {
-		MessageCode          string `json:"message_code"`
+		MessageCode          string `json:"messageCode"`
 		Message              string `json:"message"`
-		RevokedOtherSessions int    `json:"revoked_other_sessions"`
+		RevokedOtherSessions int    `json:"revokedOtherSessions"`
 	}
 	require.NoError(t, json.NewDecoder(resp.Body).Decode(&responseBody))
 	assert.Equal(t, "Password updated successfully", responseBody.Message)
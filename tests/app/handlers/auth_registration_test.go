@@ -2,11 +2,13 @@ package handlers
 
 import (
 	"bytes"
+	"encoding/json"
 	"net/http"
 	"net/http/httptest"
 	"path/filepath"
 	"testing"
 
+	"github.com/GT-610/tairitsu/internal/app/apptest"
 	"github.com/GT-610/tairitsu/internal/app/config"
 	"github.com/GT-610/tairitsu/internal/app/database"
 	apphandlers "github.com/GT-610/tairitsu/internal/app/handlers"
@@ -29,7 +31,7 @@ func TestAuthHandler_RegisterRejectsWhenPublicRegistrationDisabled(t *testing.T)
 			AllowPublicRegistration: boolPtr(false),
 		},
 		Database: config.DatabaseConfig{
-			Type: string(database.SQLite),
+			Type: database.SQLite,
 			Path: dbPath,
 		},
 		Security: config.SecurityConfig{
@@ -52,14 +54,56 @@ func TestAuthHandler_RegisterRejectsWhenPublicRegistrationDisabled(t *testing.T)
 	networkService := services.NewNetworkService(nil, db)
 	stateService := services.NewStateServiceWithConfig(config.AppConfig)
 	runtimeService := services.NewRuntimeService(userService, sessionService, networkService, stateService)
-	authHandler := apphandlers.NewAuthHandler(userService, sessionService, services.NewJWTService("test-secret"), runtimeService, stateService)
+	authHandler := apphandlers.NewAuthHandler(userService, sessionService, newTestJWTService(t, "test-secret"), runtimeService, stateService)
 
 	app := fiber.New()
 	app.Post("/auth/register", authHandler.Register)
 
 	req := httptest.NewRequest(http.MethodPost, "/auth/register", bytes.NewBufferString(`{"username":"user-1","password":"secret123"}`))
 	req.Header.Set("Content-Type", "application/json")
-	resp, err := app.Test(req)
+	resp, err := app.Test(req, apptest.SlowTestConfig())
 	require.NoError(t, err)
 	assert.Equal(t, fiber.StatusForbidden, resp.StatusCode)
 }
+
+func TestAuthHandler_RegisterReportsFailedPasswordRules(t *testing.T) {
+	db, err := database.NewDatabase(database.Config{Type: database.SQLite, Path: filepath.Join(t.TempDir(), "tairitsu.db")})
+	require.NoError(t, err)
+	require.NoError(t, db.Init())
+	t.Cleanup(func() {
+		require.NoError(t, db.Close())
+	})
+
+	cfg := &config.Config{Initialized: true, Security: config.SecurityConfig{JWTSecret: "test-secret"}}
+	userService := services.NewUserService(db)
+	policy := services.DefaultPasswordPolicy()
+	policy.RequireUpper = true
+	policy.RequireDigit = true
+	userService.SetPasswordPolicy(policy)
+	sessionService := services.NewSessionService(db)
+	stateService := services.NewStateServiceWithConfig(cfg)
+	runtimeService := services.NewRuntimeService(userService, sessionService, services.NewNetworkService(nil, db), stateService)
+	authHandler := apphandlers.NewAuthHandler(userService, sessionService, newTestJWTService(t, "test-secret"), runtimeService, stateService)
+
+	app := fiber.New()
+	app.Post("/auth/register", authHandler.Register)
+
+	req := httptest.NewRequest(http.MethodPost, "/auth/register", bytes.NewBufferString(`{"username":"alice","password":"alice-password"}`))
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := app.Test(req, apptest.SlowTestConfig())
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+
+	var body struct {
+		ErrorCode   string   `json:"errorCode"`
+		FailedRules []string `json:"failedRules"`
+		Policy      struct {
+			MinLength int `json:"minLength"`
+		} `json:"policy"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+	assert.Equal(t, "user.password_policy", body.ErrorCode)
+	assert.Equal(t, []string{services.PasswordRuleRequireUpper, services.PasswordRuleRequireDigit, services.PasswordRuleRejectUsername}, body.FailedRules)
+	assert.Equal(t, services.DefaultPasswordMinLength, body.Policy.MinLength)
+}
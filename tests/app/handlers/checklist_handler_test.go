@@ -0,0 +1,58 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/GT-610/tairitsu/internal/app/config"
+	"github.com/GT-610/tairitsu/internal/app/database"
+	apphandlers "github.com/GT-610/tairitsu/internal/app/handlers"
+	"github.com/GT-610/tairitsu/internal/app/services"
+	"github.com/gofiber/fiber/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func secureTransportDone(t *testing.T, trustedProxies []string) bool {
+	t.Helper()
+
+	db, err := database.NewDatabase(database.Config{Type: database.SQLite, Path: filepath.Join(t.TempDir(), "tairitsu.db")})
+	require.NoError(t, err)
+	require.NoError(t, db.Init())
+	t.Cleanup(func() {
+		require.NoError(t, db.Close())
+	})
+
+	stateService := services.NewStateServiceWithConfig(&config.Config{Initialized: true})
+	checklistService := services.NewChecklistService(stateService, services.NewUserService(db), services.NewNetworkService(nil, db))
+	app := fiber.New(fiber.Config{
+		TrustProxy:       true,
+		TrustProxyConfig: fiber.TrustProxyConfig{Proxies: trustedProxies},
+	})
+	app.Get("/checklist", apphandlers.NewChecklistHandler(checklistService).GetChecklist)
+
+	req := httptest.NewRequest(http.MethodGet, "/checklist", nil)
+	req.Header.Set(fiber.HeaderXForwardedProto, "https")
+	req.Header.Set("X-Real-IP", "203.0.113.10")
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+	require.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+	var checklist services.Checklist
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&checklist))
+	for _, item := range checklist.Items {
+		if item.ID == services.ChecklistItemSecureTransport {
+			return item.Done
+		}
+	}
+	t.Fatal("the checklist has no secure transport item")
+	return false
+}
+
+func TestChecklistHandler_TrustsForwardedProtoOnlyFromTrustedProxies(t *testing.T) {
+	assert.True(t, secureTransportDone(t, []string{"0.0.0.0/0", "::/0"}))
+	assert.False(t, secureTransportDone(t, []string{"10.9.9.9"}), "any client can send X-Forwarded-Proto")
+}
@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/GT-610/tairitsu/internal/app/apierror"
+	"github.com/GT-610/tairitsu/internal/app/database"
+	apphandlers "github.com/GT-610/tairitsu/internal/app/handlers"
+	"github.com/GT-610/tairitsu/internal/app/middleware"
+	"github.com/GT-610/tairitsu/internal/app/models"
+	"github.com/GT-610/tairitsu/internal/app/services"
+	"github.com/gofiber/fiber/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// sharedNetworksFailingDB fails the shared network query the way a broken database does
+type sharedNetworksFailingDB struct {
+	database.DBInterface
+	err error
+}
+
+func (d *sharedNetworksFailingDB) GetSharedNetworksByUserID(userID string) ([]*models.Network, error) {
+	return nil, d.err
+}
+
+func getSharedNetworksWithFailingDB(t *testing.T, dbErr error) (int, apierror.Response, string) {
+	t.Helper()
+
+	db, err := database.NewDatabase(database.Config{Type: database.SQLite, Path: filepath.Join(t.TempDir(), "tairitsu.db")})
+	require.NoError(t, err)
+	require.NoError(t, db.Init())
+	t.Cleanup(func() {
+		require.NoError(t, db.Close())
+	})
+
+	networkHandler := apphandlers.NewNetworkHandler(services.NewNetworkService(nil, &sharedNetworksFailingDB{DBInterface: db, err: dbErr}))
+	app := fiber.New()
+	app.Use(middleware.RequestID())
+	app.Use(func(c fiber.Ctx) error {
+		c.Locals("user_id", "user-1")
+		return c.Next()
+	})
+	app.Get("/networks/shared", networkHandler.GetSharedNetworks)
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/networks/shared", nil))
+	require.NoError(t, err)
+	raw, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	var body apierror.Response
+	require.NoError(t, json.Unmarshal(raw, &body))
+	return resp.StatusCode, body, string(raw)
+}
+
+func TestNetworkHandler_DatabaseFailureHidesSQL(t *testing.T) {
+	dbErr := errors.New("SQL logic error: no such column: network_viewers.user_id (1): SELECT * FROM `networks` INNER JOIN network_viewers ON network_viewers.network_id = networks.id WHERE network_viewers.user_id = \"user-1\"")
+
+	status, body, raw := getSharedNetworksWithFailingDB(t, dbErr)
+
+	assert.Equal(t, fiber.StatusInternalServerError, status)
+	assert.Equal(t, apierror.CodeSystemInternalError, body.ErrorCode)
+	assert.Equal(t, "Internal Server Error", body.Message)
+	assert.NotEmpty(t, body.RequestID, "the request ID ties the response to the logged error")
+	for _, fragment := range []string{"SQL", "SELECT", "FROM", "WHERE", "network_viewers", "no such column"} {
+		assert.NotContains(t, raw, fragment)
+	}
+}
+
+func TestNetworkHandler_UserFacingFailureKeepsItsMessage(t *testing.T) {
+	dbErr := apierror.UserFacing(errors.New("Shared networks are being migrated; try again in a minute"))
+
+	status, body, _ := getSharedNetworksWithFailingDB(t, dbErr)
+
+	assert.Equal(t, fiber.StatusInternalServerError, status)
+	assert.Equal(t, apierror.CodeSystemInternalError, body.ErrorCode)
+	assert.Equal(t, "Shared networks are being migrated; try again in a minute", body.Message)
+}
@@ -51,7 +51,7 @@ func TestNetworkHandler_ImportConsoleAndImportResponseShape(t *testing.T) {
 
 	networkService := services.NewNetworkService(ztClient, db)
 	userService := services.NewUserService(db)
-	jwtService := services.NewJWTService("test-secret")
+	jwtService := newTestJWTService(t, "test-secret")
 	sessionService := services.NewSessionService(db)
 	networkHandler := apphandlers.NewNetworkHandler(networkService)
 
@@ -88,7 +88,7 @@ func TestNetworkHandler_ImportConsoleAndImportResponseShape(t *testing.T) {
 	assert.Equal(t, "alpha", importableBody.Candidates[0].Name)
 	assert.True(t, importableBody.Candidates[0].CanImport)
 
-	postReq := httptest.NewRequest(http.MethodPost, "/admin/networks/import", bytes.NewBufferString(`{"network_ids":["8056c2e21c000001"],"owner_id":"user-1"}`))
+	postReq := httptest.NewRequest(http.MethodPost, "/admin/networks/import", bytes.NewBufferString(`{"networkIds":["8056c2e21c000001"],"ownerId":"user-1"}`))
 	postReq.Header.Set("Authorization", "Bearer "+adminToken)
 	postReq.Header.Set("Content-Type", "application/json")
 	postResp, err := app.Test(postReq)
@@ -0,0 +1,187 @@
+package handlers
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/GT-610/tairitsu/internal/app/apierror"
+	apphandlers "github.com/GT-610/tairitsu/internal/app/handlers"
+	"github.com/GT-610/tairitsu/internal/app/middleware"
+	"github.com/GT-610/tairitsu/internal/app/services"
+	"github.com/GT-610/tairitsu/internal/zerotier"
+	"github.com/gofiber/fiber/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	fakeNetworkID = "8056c2e21c000001"
+	fakeMemberID  = "a1a1a1a1a1"
+)
+
+// fakeNetworkService answers the network and member lookups with err, or with records whose
+// IDs echo the request; calling any other method panics on the nil embedded interface
+type fakeNetworkService struct {
+	services.NetworkServiceInterface
+	err error
+
+	networkID string
+	memberID  string
+	userID    string
+	update    *zerotier.MemberUpdateRequest
+}
+
+func (f *fakeNetworkService) GetNetworkByID(id string, userID string) (*services.NetworkDetail, error) {
+	f.networkID, f.userID = id, userID
+	if f.err != nil {
+		return nil, f.err
+	}
+	return &services.NetworkDetail{Network: &zerotier.Network{ID: id, Name: "office"}}, nil
+}
+
+func (f *fakeNetworkService) GetNetworkMember(networkID, memberID string, userID string) (*zerotier.Member, error) {
+	f.networkID, f.memberID, f.userID = networkID, memberID, userID
+	if f.err != nil {
+		return nil, f.err
+	}
+	return &zerotier.Member{ID: memberID, Address: memberID, Authorized: true}, nil
+}
+
+func (f *fakeNetworkService) UpdateNetworkMember(networkID, memberID string, member *zerotier.MemberUpdateRequest, userID string) (*zerotier.Member, error) {
+	f.networkID, f.memberID, f.userID, f.update = networkID, memberID, userID, member
+	if f.err != nil {
+		return nil, f.err
+	}
+	return &zerotier.Member{ID: memberID, Address: memberID, Authorized: member.Authorized != nil && *member.Authorized}, nil
+}
+
+// newNetworkHandlerApp routes the network and member handlers as the API does, for userID; an
+// empty userID leaves the request unauthenticated
+func newNetworkHandlerApp(service services.NetworkServiceInterface, userID string) *fiber.App {
+	app := fiber.New()
+	app.Use(middleware.ErrorHandler())
+	app.Use(func(c fiber.Ctx) error {
+		if userID != "" {
+			c.Locals("user_id", userID)
+		}
+		return c.Next()
+	})
+	networkHandler := apphandlers.NewNetworkHandler(service)
+	memberHandler := apphandlers.NewMemberHandler(service)
+	app.Get("/networks/:id", networkHandler.GetNetwork)
+	app.Get("/networks/:id/members/:memberId", memberHandler.GetMember)
+	app.Put("/networks/:id/members/:memberId", memberHandler.UpdateMember)
+	return app
+}
+
+func callHandler(t *testing.T, app *fiber.App, method, path, body string) (int, string) {
+	t.Helper()
+
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	raw, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	return resp.StatusCode, string(raw)
+}
+
+func errorCodeOf(t *testing.T, body string) string {
+	t.Helper()
+
+	var response apierror.Response
+	require.NoError(t, json.Unmarshal([]byte(body), &response), body)
+	return response.ErrorCode
+}
+
+func TestNetworkHandler_GetNetwork(t *testing.T) {
+	service := &fakeNetworkService{}
+	status, body := callHandler(t, newNetworkHandlerApp(service, "user-1"), http.MethodGet, "/networks/"+fakeNetworkID, "")
+	require.Equal(t, fiber.StatusOK, status, body)
+	assert.Equal(t, fakeNetworkID, service.networkID)
+	assert.Equal(t, "user-1", service.userID)
+	var network struct {
+		Name string `json:"name"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(body), &network))
+	assert.Equal(t, "office", network.Name)
+
+	service = &fakeNetworkService{err: services.ErrNetworkNotFound}
+	status, body = callHandler(t, newNetworkHandlerApp(service, "user-1"), http.MethodGet, "/networks/"+fakeNetworkID, "")
+	assert.Equal(t, fiber.StatusNotFound, status)
+	assert.Equal(t, apierror.CodeNetworkNotFound, errorCodeOf(t, body))
+
+	service = &fakeNetworkService{err: services.ErrNetworkAccessDenied}
+	status, body = callHandler(t, newNetworkHandlerApp(service, "user-1"), http.MethodGet, "/networks/"+fakeNetworkID, "")
+	assert.Equal(t, fiber.StatusForbidden, status)
+	assert.Equal(t, apierror.CodeNetworkAccessDenied, errorCodeOf(t, body))
+
+	service = &fakeNetworkService{}
+	status, body = callHandler(t, newNetworkHandlerApp(service, ""), http.MethodGet, "/networks/"+fakeNetworkID, "")
+	assert.Equal(t, fiber.StatusUnauthorized, status)
+	assert.Equal(t, apierror.CodeAuthUnauthorized, errorCodeOf(t, body))
+	assert.Empty(t, service.networkID, "an unauthenticated request does not reach the service")
+}
+
+func TestMemberHandler_GetMember(t *testing.T) {
+	path := "/networks/" + fakeNetworkID + "/members/" + fakeMemberID
+
+	service := &fakeNetworkService{}
+	status, body := callHandler(t, newNetworkHandlerApp(service, "user-1"), http.MethodGet, path, "")
+	require.Equal(t, fiber.StatusOK, status, body)
+	assert.Equal(t, fakeNetworkID, service.networkID, "the network ID comes from the :id route parameter")
+	assert.Equal(t, fakeMemberID, service.memberID)
+	var member zerotier.Member
+	require.NoError(t, json.Unmarshal([]byte(body), &member))
+	assert.Equal(t, fakeMemberID, member.ID)
+
+	service = &fakeNetworkService{err: services.ErrMemberNotFound}
+	status, body = callHandler(t, newNetworkHandlerApp(service, "user-1"), http.MethodGet, path, "")
+	assert.Equal(t, fiber.StatusNotFound, status)
+	assert.Equal(t, apierror.CodeMemberNotFound, errorCodeOf(t, body))
+
+	service = &fakeNetworkService{err: services.ErrMemberAccessDenied}
+	status, body = callHandler(t, newNetworkHandlerApp(service, "user-1"), http.MethodGet, path, "")
+	assert.Equal(t, fiber.StatusForbidden, status)
+	assert.Equal(t, apierror.CodeNetworkAccessDenied, errorCodeOf(t, body))
+
+	status, body = callHandler(t, newNetworkHandlerApp(&fakeNetworkService{}, ""), http.MethodGet, path, "")
+	assert.Equal(t, fiber.StatusUnauthorized, status)
+	assert.Equal(t, apierror.CodeAuthUnauthorized, errorCodeOf(t, body))
+}
+
+func TestMemberHandler_UpdateMember(t *testing.T) {
+	path := "/networks/" + fakeNetworkID + "/members/" + fakeMemberID
+
+	service := &fakeNetworkService{}
+	status, body := callHandler(t, newNetworkHandlerApp(service, "user-1"), http.MethodPut, path, `{"authorized":true}`)
+	require.Equal(t, fiber.StatusOK, status, body)
+	assert.Equal(t, fakeNetworkID, service.networkID, "the network ID comes from the :id route parameter")
+	assert.Equal(t, fakeMemberID, service.memberID)
+	require.NotNil(t, service.update)
+	require.NotNil(t, service.update.Authorized)
+	assert.True(t, *service.update.Authorized)
+
+	service = &fakeNetworkService{err: services.ErrNetworkNotFound}
+	status, body = callHandler(t, newNetworkHandlerApp(service, "user-1"), http.MethodPut, path, `{"authorized":true}`)
+	assert.Equal(t, fiber.StatusNotFound, status)
+	assert.Equal(t, apierror.CodeNetworkNotFound, errorCodeOf(t, body))
+
+	service = &fakeNetworkService{err: services.ErrMemberAccessDenied}
+	status, body = callHandler(t, newNetworkHandlerApp(service, "user-1"), http.MethodPut, path, `{"authorized":true}`)
+	assert.Equal(t, fiber.StatusForbidden, status)
+	assert.Equal(t, apierror.CodeNetworkAccessDenied, errorCodeOf(t, body))
+
+	service = &fakeNetworkService{}
+	status, body = callHandler(t, newNetworkHandlerApp(service, ""), http.MethodPut, path, `{"authorized":true}`)
+	assert.Equal(t, fiber.StatusUnauthorized, status)
+	assert.Equal(t, apierror.CodeAuthUnauthorized, errorCodeOf(t, body))
+	assert.Nil(t, service.update, "an unauthenticated request does not reach the service")
+}
@@ -5,13 +5,15 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
-	"os"
 	"path/filepath"
 	"testing"
 
+	"github.com/GT-610/tairitsu/internal/app/apptest"
 	"github.com/GT-610/tairitsu/internal/app/config"
 	"github.com/GT-610/tairitsu/internal/app/database"
 	apphandlers "github.com/GT-610/tairitsu/internal/app/handlers"
+	"github.com/GT-610/tairitsu/internal/app/models"
+	"github.com/GT-610/tairitsu/internal/app/paths"
 	"github.com/GT-610/tairitsu/internal/app/services"
 	"github.com/gofiber/fiber/v3"
 	"github.com/stretchr/testify/assert"
@@ -29,8 +31,9 @@ func TestSetupFlow_ResetDatabaseThenRegisterAdmin(t *testing.T) {
 	dbPath := filepath.Join(t.TempDir(), "nested", "tairitsu.db")
 	config.AppConfig = &config.Config{
 		Initialized: false,
+		Setup:       config.SetupConfig{CompletedStep: string(services.SetupStepZeroTier)},
 		Database: config.DatabaseConfig{
-			Type: string(database.SQLite),
+			Type: database.SQLite,
 			Path: dbPath,
 		},
 		Security: config.SecurityConfig{
@@ -53,13 +56,13 @@ func TestSetupFlow_ResetDatabaseThenRegisterAdmin(t *testing.T) {
 	runtimeService := services.NewRuntimeService(userService, sessionService, networkService, stateService)
 	setupService := services.NewSetupService(runtimeService, stateService, userService, networkService)
 	systemHandler := apphandlers.NewSystemHandler(setupService, services.NewSystemService())
-	authHandler := apphandlers.NewAuthHandler(userService, sessionService, services.NewJWTService("test-secret"), runtimeService, stateService)
+	authHandler := apphandlers.NewAuthHandler(userService, sessionService, newTestJWTService(t, "test-secret"), runtimeService, stateService)
 
 	app := fiber.New()
 	app.Post("/system/admin/init", systemHandler.InitializeAdminCreation)
 	app.Post("/auth/register", authHandler.Register)
 
-	initReq := httptest.NewRequest(http.MethodPost, "/system/admin/init", bytes.NewReader([]byte("{}")))
+	initReq := httptest.NewRequest(http.MethodPost, "/system/admin/init", bytes.NewReader([]byte(`{"confirmReset":true}`)))
 	initReq.Header.Set("Content-Type", "application/json")
 	initResp, err := app.Test(initReq)
 	require.NoError(t, err)
@@ -73,7 +76,7 @@ func TestSetupFlow_ResetDatabaseThenRegisterAdmin(t *testing.T) {
 
 	registerReq := httptest.NewRequest(http.MethodPost, "/auth/register", bytes.NewReader(registerPayload))
 	registerReq.Header.Set("Content-Type", "application/json")
-	registerResp, err := app.Test(registerReq)
+	registerResp, err := app.Test(registerReq, apptest.SlowTestConfig())
 	require.NoError(t, err)
 	assert.Equal(t, fiber.StatusCreated, registerResp.StatusCode)
 
@@ -95,18 +98,62 @@ func TestSetupFlow_ResetDatabaseThenRegisterAdmin(t *testing.T) {
 	assert.Equal(t, "admin", usersAfterSecondInit[0].Username)
 }
 
-func TestSetupFlow_ZeroTierTokenNotFoundReturnsOriginalDetail(t *testing.T) {
+func TestSetupFlow_ResetRequiresConfirmation(t *testing.T) {
 	originalConfig := config.AppConfig
-	originalWorkingDirectory, err := os.Getwd()
+	originalResetFlag := config.GetTempSetting("admin_creation_reset_done")
+	t.Cleanup(func() {
+		config.AppConfig = originalConfig
+		config.SetTempSetting("admin_creation_reset_done", originalResetFlag)
+	})
+
+	dbPath := filepath.Join(t.TempDir(), "tairitsu.db")
+	config.AppConfig = &config.Config{
+		Setup:    config.SetupConfig{CompletedStep: string(services.SetupStepZeroTier)},
+		Database: config.DatabaseConfig{Type: database.SQLite, Path: dbPath},
+		Security: config.SecurityConfig{JWTSecret: "test-secret"},
+	}
+	config.SetTempSetting("admin_creation_reset_done", "")
+
+	db, err := database.NewDatabase(database.Config{Type: database.SQLite, Path: dbPath})
+	require.NoError(t, err)
+	require.NoError(t, db.Init())
+	t.Cleanup(func() {
+		_ = db.Close()
+	})
+	userService := services.NewUserService(db)
+	_, err = userService.Register(&models.RegisterRequest{Username: "leftover", Password: "secret123"}, "user")
+	require.NoError(t, err)
+
+	stateService := services.NewStateServiceWithConfig(config.AppConfig)
+	runtimeService := services.NewRuntimeService(userService, services.NewSessionService(db), services.NewNetworkService(nil, db), stateService)
+	setupService := services.NewSetupService(runtimeService, stateService, userService, services.NewNetworkService(nil, db))
+	systemHandler := apphandlers.NewSystemHandler(setupService, services.NewSystemService())
+
+	app := fiber.New()
+	app.Post("/system/admin/init", systemHandler.InitializeAdminCreation)
+
+	req := httptest.NewRequest(http.MethodPost, "/system/admin/init", bytes.NewReader([]byte("{}")))
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := app.Test(req)
 	require.NoError(t, err)
-	temporaryWorkingDirectory := t.TempDir()
-	require.NoError(t, os.Chdir(temporaryWorkingDirectory))
+	assert.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+	var responseBody map[string]any
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&responseBody))
+	assert.Equal(t, "setup.reset_confirmation_required", responseBody["errorCode"])
+
+	users, err := userService.GetAllUsers()
+	require.NoError(t, err)
+	assert.Len(t, users, 1, "an unconfirmed reset keeps the data")
+}
+
+func TestSetupFlow_ZeroTierTokenNotFoundReturnsOriginalDetail(t *testing.T) {
+	originalConfig := config.AppConfig
+	t.Cleanup(paths.SetDataDir(t.TempDir()))
 	t.Cleanup(func() {
 		config.AppConfig = originalConfig
-		require.NoError(t, os.Chdir(originalWorkingDirectory))
 	})
 
-	stateService := services.NewStateServiceWithConfig(&config.Config{})
+	stateService := services.NewStateServiceWithConfig(&config.Config{Setup: config.SetupConfig{CompletedStep: string(services.SetupStepDatabase)}})
 	setupService := services.NewSetupService(
 		services.NewRuntimeService(nil, nil, nil, stateService),
 		stateService,
@@ -126,6 +173,59 @@ func TestSetupFlow_ZeroTierTokenNotFoundReturnsOriginalDetail(t *testing.T) {
 
 	var responseBody map[string]any
 	require.NoError(t, json.NewDecoder(resp.Body).Decode(&responseBody))
-	assert.Equal(t, "setup.zerotier_config_save_failed", responseBody["error_code"])
+	assert.Equal(t, "setup.zerotier_config_save_failed", responseBody["errorCode"])
 	assert.Equal(t, "failed to read token file: open /missing/authtoken.secret: no such file or directory", responseBody["detail"])
 }
+
+func TestSetupFlow_ZeroTierConnectionTestReportsControllerAccess(t *testing.T) {
+	originalConfig := config.AppConfig
+	t.Cleanup(func() {
+		config.AppConfig = originalConfig
+	})
+	controllerStatus := http.StatusUnauthorized
+	node := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/status" {
+			_, _ = w.Write([]byte(`{"address":"8056c2e21c","version":"1.12.2","online":true}`))
+			return
+		}
+		w.WriteHeader(controllerStatus)
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	t.Cleanup(node.Close)
+
+	stateService := services.NewStateServiceWithConfig(&config.Config{})
+	setupService := services.NewSetupService(services.NewRuntimeService(nil, nil, nil, stateService), stateService, nil, nil)
+	app := fiber.New()
+	app.Post("/system/zerotier/test", apphandlers.NewSystemHandler(setupService, services.NewSystemService()).TestZeroTierConnection)
+
+	testConnection := func(body string) (int, map[string]any) {
+		req := httptest.NewRequest(http.MethodPost, "/system/zerotier/test", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		var responseBody map[string]any
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&responseBody))
+		return resp.StatusCode, responseBody
+	}
+
+	for _, status := range []int{http.StatusUnauthorized, http.StatusForbidden} {
+		controllerStatus = status
+		code, check := testConnection(`{"controllerUrl":"` + node.URL + `","token":"node-token"}`)
+		assert.Equal(t, fiber.StatusOK, code, "an expected failure is reported in the result")
+		assert.Equal(t, true, check["reachable"])
+		assert.Equal(t, true, check["authenticated"])
+		assert.Equal(t, false, check["controllerAccess"])
+		assert.Equal(t, "1.12.2", check["version"])
+		assert.Contains(t, check["error"], "may not use the controller API")
+	}
+
+	controllerStatus = http.StatusOK
+	code, check := testConnection(`{"controllerUrl":"` + node.URL + `","token":"node-token"}`)
+	assert.Equal(t, fiber.StatusOK, code)
+	assert.Equal(t, true, check["controllerAccess"], "an empty list still grants access: %v", check)
+	assert.NotContains(t, check, "error")
+
+	code, check = testConnection(`{"controllerUrl":"` + node.URL + `"}`)
+	assert.Equal(t, fiber.StatusBadRequest, code)
+	assert.Equal(t, "setup.invalid_config", check["errorCode"])
+}
@@ -12,6 +12,7 @@ import (
 	"github.com/GT-610/tairitsu/internal/app/database"
 	apphandlers "github.com/GT-610/tairitsu/internal/app/handlers"
 	"github.com/GT-610/tairitsu/internal/app/models"
+	"github.com/GT-610/tairitsu/internal/app/paths"
 	"github.com/GT-610/tairitsu/internal/app/services"
 	"github.com/gofiber/fiber/v3"
 	"github.com/stretchr/testify/assert"
@@ -46,6 +47,22 @@ func (s *handlerStateDBStub) GetUserByUsername(username string) (*models.User, e
 	}
 	return nil, nil
 }
+func (s *handlerStateDBStub) GetUserByExternalSubject(subject string) (*models.User, error) {
+	for _, user := range s.users {
+		if user.ExternalSubject != nil && *user.ExternalSubject == subject {
+			return user, nil
+		}
+	}
+	return nil, nil
+}
+func (s *handlerStateDBStub) GetUserByEmail(email string) (*models.User, error) {
+	for _, user := range s.users {
+		if user.Email != nil && *user.Email == email {
+			return user, nil
+		}
+	}
+	return nil, nil
+}
 func (s *handlerStateDBStub) GetAllUsers() ([]*models.User, error) { return s.users, nil }
 func (s *handlerStateDBStub) GetUsersByIDs(ids []string) ([]*models.User, error) {
 	var result []*models.User
@@ -59,8 +76,11 @@ func (s *handlerStateDBStub) GetUsersByIDs(ids []string) ([]*models.User, error)
 	}
 	return result, nil
 }
-func (s *handlerStateDBStub) UpdateUser(user *models.User) error   { return nil }
-func (s *handlerStateDBStub) DeleteUser(id string) error           { return nil }
+func (s *handlerStateDBStub) UpdateUser(user *models.User) error { return nil }
+func (s *handlerStateDBStub) DeleteUser(id string) error         { return nil }
+func (s *handlerStateDBStub) ReplaceUserPasswordHash(userID, previousHash, hash string) (bool, error) {
+	return false, nil
+}
 func (s *handlerStateDBStub) CreateSession(session *models.Session) error {
 	return nil
 }
@@ -98,8 +118,236 @@ func (s *handlerStateDBStub) GetSharedNetworksByUserID(userID string) ([]*models
 	return []*models.Network{}, nil
 }
 func (s *handlerStateDBStub) DeleteNetworkViewer(networkID, userID string) error { return nil }
-func (s *handlerStateDBStub) DeleteAllNetworkViewers(networkID string) error    { return nil }
-func (s *handlerStateDBStub) DeleteExpiredSessions(before time.Time) error      { return nil }
+func (s *handlerStateDBStub) DeleteAllNetworkViewers(networkID string) error     { return nil }
+func (s *handlerStateDBStub) GetMemberMetadata(networkID, memberID string) (*models.MemberMetadata, error) {
+	return nil, nil
+}
+func (s *handlerStateDBStub) ListMemberMetadata(networkID string) ([]*models.MemberMetadata, error) {
+	return nil, nil
+}
+func (s *handlerStateDBStub) ListMemberMetadataExpiringBy(deadline time.Time) ([]*models.MemberMetadata, error) {
+	return nil, nil
+}
+func (s *handlerStateDBStub) SaveMemberMetadata(metadata *models.MemberMetadata) error { return nil }
+func (s *handlerStateDBStub) DeleteMemberMetadata(networkID, memberID string) error    { return nil }
+func (s *handlerStateDBStub) DeleteAllMemberMetadata(networkID string) error           { return nil }
+func (s *handlerStateDBStub) CreatePendingApproval(approval *models.PendingApproval) (bool, error) {
+	return false, nil
+}
+func (s *handlerStateDBStub) GetPendingApproval(id uint64) (*models.PendingApproval, error) {
+	return nil, nil
+}
+func (s *handlerStateDBStub) ListPendingApprovals(networkIDs []string) ([]*models.PendingApproval, error) {
+	return nil, nil
+}
+func (s *handlerStateDBStub) SavePendingApproval(approval *models.PendingApproval) error { return nil }
+func (s *handlerStateDBStub) DeletePendingApproval(networkID, memberID string) error     { return nil }
+func (s *handlerStateDBStub) DeleteAllPendingApprovals(networkID string) error           { return nil }
+func (s *handlerStateDBStub) CreateDeviceClaim(claim *models.DeviceClaim) error          { return nil }
+func (s *handlerStateDBStub) GetDeviceClaim(id uint64) (*models.DeviceClaim, error) {
+	return nil, nil
+}
+func (s *handlerStateDBStub) ListDeviceClaimsByUser(userID string) ([]*models.DeviceClaim, error) {
+	return nil, nil
+}
+func (s *handlerStateDBStub) ListDeviceClaimsByNode(nodeAddress string) ([]*models.DeviceClaim, error) {
+	return nil, nil
+}
+func (s *handlerStateDBStub) ListUnverifiedDeviceClaims() ([]*models.DeviceClaim, error) {
+	return nil, nil
+}
+func (s *handlerStateDBStub) SaveDeviceClaim(claim *models.DeviceClaim) error { return nil }
+func (s *handlerStateDBStub) DeleteDeviceClaim(id uint64) error               { return nil }
+func (s *handlerStateDBStub) DeleteDeviceClaimsByUser(userID string) error    { return nil }
+func (s *handlerStateDBStub) GetNetworksByOrganizationIDs(organizationIDs []string) ([]*models.Network, error) {
+	return nil, nil
+}
+func (s *handlerStateDBStub) MoveNetworksToOrganization(fromID, toID string) error {
+	return nil
+}
+func (s *handlerStateDBStub) CreateOrganization(organization *models.Organization) error {
+	return nil
+}
+func (s *handlerStateDBStub) GetOrganization(id string) (*models.Organization, error) {
+	return nil, nil
+}
+func (s *handlerStateDBStub) GetOrganizationByName(name string) (*models.Organization, error) {
+	return nil, nil
+}
+func (s *handlerStateDBStub) ListOrganizations() ([]*models.Organization, error) {
+	return nil, nil
+}
+func (s *handlerStateDBStub) UpdateOrganization(organization *models.Organization) error {
+	return nil
+}
+func (s *handlerStateDBStub) DeleteOrganization(id string) error {
+	return nil
+}
+func (s *handlerStateDBStub) UpsertOrganizationMember(member *models.OrganizationMember) error {
+	return nil
+}
+func (s *handlerStateDBStub) GetOrganizationMember(organizationID, userID string) (*models.OrganizationMember, error) {
+	return nil, nil
+}
+func (s *handlerStateDBStub) ListOrganizationMembers(organizationID string) ([]*models.OrganizationMember, error) {
+	return nil, nil
+}
+func (s *handlerStateDBStub) ListOrganizationMembershipsByUser(userID string) ([]*models.OrganizationMember, error) {
+	return nil, nil
+}
+func (s *handlerStateDBStub) DeleteOrganizationMember(organizationID, userID string) error {
+	return nil
+}
+func (s *handlerStateDBStub) DeleteAllOrganizationMembers(organizationID string) error {
+	return nil
+}
+func (s *handlerStateDBStub) DeleteOrganizationMembershipsByUser(userID string) error {
+	return nil
+}
+func (s *handlerStateDBStub) GetActiveNetworkLockdown(networkID string) (*models.NetworkLockdown, error) {
+	return nil, nil
+}
+func (s *handlerStateDBStub) CreateNetworkLockdown(lockdown *models.NetworkLockdown) error {
+	return nil
+}
+func (s *handlerStateDBStub) SaveNetworkLockdown(lockdown *models.NetworkLockdown) error { return nil }
+func (s *handlerStateDBStub) DeleteAllNetworkLockdowns(networkID string) error           { return nil }
+func (s *handlerStateDBStub) CreateOperation(operation *models.Operation, items []*models.OperationItem) error {
+	return nil
+}
+func (s *handlerStateDBStub) GetOperation(id string) (*models.Operation, error) { return nil, nil }
+func (s *handlerStateDBStub) GetOperationByIdempotencyKey(userID, key string) (*models.Operation, error) {
+	return nil, nil
+}
+func (s *handlerStateDBStub) SaveOperation(operation *models.Operation) error { return nil }
+func (s *handlerStateDBStub) ListOperations(userID string, limit int) ([]*models.Operation, error) {
+	return nil, nil
+}
+func (s *handlerStateDBStub) ListUnfinishedOperations() ([]*models.Operation, error) { return nil, nil }
+func (s *handlerStateDBStub) ListOperationItems(operationID string) ([]*models.OperationItem, error) {
+	return []*models.OperationItem{}, nil
+}
+func (s *handlerStateDBStub) SaveOperationItem(item *models.OperationItem) error { return nil }
+func (s *handlerStateDBStub) GetNetworkRuleSource(networkID string) (*models.NetworkRuleSource, error) {
+	return nil, nil
+}
+func (s *handlerStateDBStub) SaveNetworkRuleSource(source *models.NetworkRuleSource) error {
+	return nil
+}
+func (s *handlerStateDBStub) DeleteNetworkRuleSource(networkID string) error { return nil }
+func (s *handlerStateDBStub) ListNetworkTags(networkID string) ([]*models.NetworkTag, error) {
+	return nil, nil
+}
+func (s *handlerStateDBStub) CreateNetworkTag(tag *models.NetworkTag) error         { return nil }
+func (s *handlerStateDBStub) UpdateNetworkTag(tag *models.NetworkTag) error         { return nil }
+func (s *handlerStateDBStub) DeleteNetworkTag(networkID string, tagID uint32) error { return nil }
+func (s *handlerStateDBStub) DeleteAllNetworkTags(networkID string) error           { return nil }
+func (s *handlerStateDBStub) ListNetworkCapabilities(networkID string) ([]*models.NetworkCapability, error) {
+	return nil, nil
+}
+func (s *handlerStateDBStub) CreateNetworkCapability(capability *models.NetworkCapability) error {
+	return nil
+}
+func (s *handlerStateDBStub) DeleteNetworkCapability(networkID string, capabilityID uint32) error {
+	return nil
+}
+func (s *handlerStateDBStub) DeleteAllNetworkCapabilities(networkID string) error { return nil }
+func (s *handlerStateDBStub) CreateWebhook(webhook *models.Webhook) error         { return nil }
+func (s *handlerStateDBStub) GetWebhookByID(id string) (*models.Webhook, error)   { return nil, nil }
+func (s *handlerStateDBStub) ListWebhooks() ([]*models.Webhook, error)            { return nil, nil }
+func (s *handlerStateDBStub) UpdateWebhook(webhook *models.Webhook) error         { return nil }
+func (s *handlerStateDBStub) DeleteWebhook(id string) error                       { return nil }
+func (s *handlerStateDBStub) CreateWebhookDelivery(delivery *models.WebhookDelivery) error {
+	return nil
+}
+func (s *handlerStateDBStub) UpdateWebhookDelivery(delivery *models.WebhookDelivery) error {
+	return nil
+}
+func (s *handlerStateDBStub) ListWebhookDeliveries(webhookID string, limit int) ([]*models.WebhookDelivery, error) {
+	return nil, nil
+}
+func (s *handlerStateDBStub) DeleteWebhookDeliveriesBeyond(webhookID string, keep int) (int64, error) {
+	return 0, nil
+}
+func (s *handlerStateDBStub) DeleteExpiredSessions(before time.Time) error  { return nil }
+func (s *handlerStateDBStub) RevokeAllSessions(at time.Time) (int64, error) { return 0, nil }
+func (s *handlerStateDBStub) CreateMemberStatusEvents(events []*models.MemberStatusEvent) error {
+	return nil
+}
+func (s *handlerStateDBStub) ListLatestMemberStatusEvents(networkID string) ([]*models.MemberStatusEvent, error) {
+	return nil, nil
+}
+func (s *handlerStateDBStub) ListLatestOnlineMemberStatusEvents(networkID string) ([]*models.MemberStatusEvent, error) {
+	return nil, nil
+}
+func (s *handlerStateDBStub) ListMemberStatusEvents(networkID, memberID string, from, to time.Time) ([]*models.MemberStatusEvent, error) {
+	return nil, nil
+}
+func (s *handlerStateDBStub) GetMemberStatusEventBefore(networkID, memberID string, before time.Time) (*models.MemberStatusEvent, error) {
+	return nil, nil
+}
+func (s *handlerStateDBStub) ListMemberStatusEventsAfter(afterID uint64, limit int) ([]*models.MemberStatusEvent, error) {
+	return nil, nil
+}
+func (s *handlerStateDBStub) CreateControllerTraceEvents(events []*models.ControllerTraceEvent) error {
+	return nil
+}
+func (s *handlerStateDBStub) QueryControllerTraceEvents(query models.ControllerTraceQuery) ([]*models.ControllerTraceEvent, error) {
+	return nil, nil
+}
+func (s *handlerStateDBStub) DeleteControllerTraceEventsBefore(before time.Time) (int64, error) {
+	return 0, nil
+}
+func (s *handlerStateDBStub) CreatePlanetGeneration(generation *models.PlanetGeneration) error {
+	return nil
+}
+func (s *handlerStateDBStub) ListPlanetGenerations(limit int) ([]*models.PlanetGeneration, error) {
+	return nil, nil
+}
+func (s *handlerStateDBStub) GetPlanetGenerationByID(id uint64) (*models.PlanetGeneration, error) {
+	return nil, nil
+}
+func (s *handlerStateDBStub) DeletePlanetGenerationsBeyond(keep int) (int64, error) {
+	return 0, nil
+}
+func (s *handlerStateDBStub) CreateApiToken(token *models.ApiToken) error         { return nil }
+func (s *handlerStateDBStub) GetApiTokenByID(id string) (*models.ApiToken, error) { return nil, nil }
+func (s *handlerStateDBStub) GetApiTokenByHash(hash string) (*models.ApiToken, error) {
+	return nil, nil
+}
+func (s *handlerStateDBStub) GetApiTokensByUserID(userID string) ([]*models.ApiToken, error) {
+	return nil, nil
+}
+func (s *handlerStateDBStub) UpdateApiToken(token *models.ApiToken) error { return nil }
+func (s *handlerStateDBStub) CreatePasswordResetToken(token *models.PasswordResetToken) error {
+	return nil
+}
+func (s *handlerStateDBStub) GetPasswordResetTokenByHash(hash string) (*models.PasswordResetToken, error) {
+	return nil, nil
+}
+func (s *handlerStateDBStub) ConsumePasswordResetToken(hash string, at time.Time) (int64, error) {
+	return 0, nil
+}
+func (s *handlerStateDBStub) InvalidatePasswordResetTokens(userID string, at time.Time) (int64, error) {
+	return 0, nil
+}
+func (s *handlerStateDBStub) CreateAuditLog(entry *models.AuditLog) error  { return nil }
+func (s *handlerStateDBStub) GetLatestAuditLog() (*models.AuditLog, error) { return nil, nil }
+func (s *handlerStateDBStub) GetLatestAuditLogBefore(before time.Time) (*models.AuditLog, error) {
+	return nil, nil
+}
+func (s *handlerStateDBStub) ListAuditLogs(afterID uint64, limit int) ([]*models.AuditLog, error) {
+	return []*models.AuditLog{}, nil
+}
+func (s *handlerStateDBStub) ListRecentAuditLogs(limit int) ([]*models.AuditLog, error) {
+	return []*models.AuditLog{}, nil
+}
+func (s *handlerStateDBStub) QueryAuditLogs(query models.AuditLogQuery) ([]*models.AuditLog, error) {
+	return nil, nil
+}
+func (s *handlerStateDBStub) DeleteAuditLogsUpTo(id uint64) (int64, error)     { return 0, nil }
+func (s *handlerStateDBStub) GetAuditAnchor() (*models.AuditAnchor, error)     { return nil, nil }
+func (s *handlerStateDBStub) SaveAuditAnchor(anchor *models.AuditAnchor) error { return nil }
 func (s *handlerStateDBStub) HasAdminUser() (bool, error) {
 	for _, user := range s.users {
 		if user.Role == "admin" {
@@ -108,6 +356,15 @@ func (s *handlerStateDBStub) HasAdminUser() (bool, error) {
 	}
 	return false, nil
 }
+func (s *handlerStateDBStub) CountUsersByRole(role string) (int64, error) {
+	var count int64
+	for _, user := range s.users {
+		if user.Role == role {
+			count++
+		}
+	}
+	return count, nil
+}
 func (s *handlerStateDBStub) Ping() error  { return nil }
 func (s *handlerStateDBStub) Close() error { return nil }
 
@@ -122,7 +379,7 @@ func TestSystemHandler_GetSystemStatus_Uninitialized(t *testing.T) {
 	config.AppConfig = &config.Config{
 		Initialized: false,
 		Database: config.DatabaseConfig{
-			Type: string(database.SQLite),
+			Type: database.SQLite,
 			Path: "data/setup.db",
 		},
 		ZeroTier: config.ZeroTierConfig{
@@ -169,7 +426,7 @@ func TestSystemHandler_GetSystemStatus_Initialized(t *testing.T) {
 			AllowPublicRegistration: boolPtr(false),
 		},
 		Database: config.DatabaseConfig{
-			Type: string(database.SQLite),
+			Type: database.SQLite,
 			Path: "data/test.db",
 		},
 	}
@@ -221,6 +478,7 @@ func boolPtr(value bool) *bool {
 }
 
 func TestSystemHandler_RuntimeSettingsReadWrite(t *testing.T) {
+	t.Cleanup(paths.SetDataDir(t.TempDir()))
 	originalConfig := config.AppConfig
 	t.Cleanup(func() {
 		config.AppConfig = originalConfig
@@ -228,6 +486,8 @@ func TestSystemHandler_RuntimeSettingsReadWrite(t *testing.T) {
 
 	config.AppConfig = &config.Config{
 		Initialized: true,
+		Server:      config.ServerConfig{Port: 8080},
+		Security:    config.SecurityConfig{JWTSecret: "runtime-settings-secret"},
 		Registration: config.RegistrationConfig{
 			AllowPublicRegistration: boolPtr(true),
 		},
@@ -250,12 +510,12 @@ func TestSystemHandler_RuntimeSettingsReadWrite(t *testing.T) {
 	assert.Equal(t, fiber.StatusOK, getResp.StatusCode)
 
 	var getBody struct {
-		AllowPublicRegistration bool `json:"allow_public_registration"`
+		AllowPublicRegistration bool `json:"allowPublicRegistration"`
 	}
 	require.NoError(t, json.NewDecoder(getResp.Body).Decode(&getBody))
 	assert.True(t, getBody.AllowPublicRegistration)
 
-	updateReq := httptest.NewRequest(http.MethodPut, "/system/settings", bytes.NewBufferString(`{"allow_public_registration":false}`))
+	updateReq := httptest.NewRequest(http.MethodPut, "/system/settings", bytes.NewBufferString(`{"allowPublicRegistration":false}`))
 	updateReq.Header.Set("Content-Type", "application/json")
 	updateResp, err := app.Test(updateReq)
 	require.NoError(t, err)
@@ -271,8 +531,9 @@ func TestSystemHandler_SetInitializedRejectsMissingAdmin(t *testing.T) {
 
 	config.AppConfig = &config.Config{
 		Initialized: false,
+		Setup:       config.SetupConfig{CompletedStep: string(services.SetupStepZeroTier)},
 		Database: config.DatabaseConfig{
-			Type: string(database.SQLite),
+			Type: database.SQLite,
 			Path: "data/test.db",
 		},
 	}
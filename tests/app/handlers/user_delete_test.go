@@ -27,9 +27,9 @@ func TestUserHandler_DeleteUser_AdminOnlyAndResponseShape(t *testing.T) {
 	})
 
 	userService := services.NewUserService(db)
-	jwtService := services.NewJWTService("test-secret")
+	jwtService := newTestJWTService(t, "test-secret")
 	sessionService := services.NewSessionService(db)
-	userHandler := apphandlers.NewUserHandler(userService)
+	userHandler := apphandlers.NewUserHandler(userService, sessionService)
 
 	admin, err := userService.Register(&models.RegisterRequest{Username: "admin", Password: "secret123"}, "admin")
 	require.NoError(t, err)
@@ -81,8 +81,8 @@ func TestUserHandler_DeleteUser_AdminOnlyAndResponseShape(t *testing.T) {
 	var responseBody struct {
 		Message             string              `json:"message"`
 		User                models.UserResponse `json:"user"`
-		TransferredNetworks int                 `json:"transferred_networks"`
-		RevokedSessions     int                 `json:"revoked_sessions"`
+		TransferredNetworks int                 `json:"transferredNetworks"`
+		RevokedSessions     int                 `json:"revokedSessions"`
 	}
 	require.NoError(t, json.NewDecoder(resp.Body).Decode(&responseBody))
 	assert.Equal(t, target.ID, responseBody.User.ID)
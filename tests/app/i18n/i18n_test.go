@@ -0,0 +1,80 @@
+package i18n
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+
+	"github.com/GT-610/tairitsu/internal/app/i18n"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBundlesTranslateTheSameKeys(t *testing.T) {
+	require.Equal(t, []string{"en-US", "zh-CN"}, i18n.Locales())
+	assert.Equal(t, i18n.Keys(i18n.DefaultLocale), i18n.Keys(i18n.FallbackLocale))
+}
+
+func TestEveryErrorCodeHasAMessage(t *testing.T) {
+	source, err := os.ReadFile("../../../internal/app/apierror/codes.go")
+	require.NoError(t, err)
+
+	keys := messageKeys()
+	for _, match := range regexp.MustCompile(`Code\w+\s*=\s*"([^"]+)"`).FindAllStringSubmatch(string(source), -1) {
+		assert.True(t, keys[match[1]], "error code %s has no message", match[1])
+	}
+}
+
+func TestMessageKeysUsedByTheAPIHaveMessages(t *testing.T) {
+	usage := regexp.MustCompile(`(?:writeLocalizedMessage\(c, [\w.]+, |writeLocalizedStatusError\(c, [\w.]+, |Translate\(|i18n\.Text\(i18n\.LocaleFrom\(c\), )"([^"]+)"`)
+	keys := messageKeys()
+	used := 0
+	for _, dir := range []string{"../../../internal/app/handlers", "../../../internal/app/middleware"} {
+		files, err := filepath.Glob(filepath.Join(dir, "*.go"))
+		require.NoError(t, err)
+		for _, file := range files {
+			source, err := os.ReadFile(file)
+			require.NoError(t, err)
+			for _, match := range usage.FindAllStringSubmatch(string(source), -1) {
+				used++
+				assert.True(t, keys[match[1]], "%s uses %s, which has no message", filepath.Base(file), match[1])
+			}
+		}
+	}
+	assert.NotZero(t, used, "the usage pattern no longer matches the handlers")
+}
+
+func messageKeys() map[string]bool {
+	keys := map[string]bool{}
+	for _, key := range i18n.Keys(i18n.DefaultLocale) {
+		keys[key] = true
+	}
+	return keys
+}
+
+func TestTextFallsBackToChineseThenTheKey(t *testing.T) {
+	assert.Equal(t, "Network deleted successfully", i18n.Text("en-US", "network.delete_success"))
+	assert.Equal(t, "网络删除成功", i18n.Text("zh-CN", "network.delete_success"))
+	assert.Equal(t, "网络删除成功", i18n.Text("fr-FR", "network.delete_success"))
+	assert.Equal(t, "network.not_a_key", i18n.Text("en-US", "network.not_a_key"))
+}
+
+func TestNegotiate(t *testing.T) {
+	cases := map[string]string{
+		"":                             "en-US",
+		"zh-CN":                        "zh-CN",
+		"zh":                           "zh-CN",
+		"ZH-tw,en;q=0.5":               "zh-CN",
+		"en-GB":                        "en-US",
+		"fr-FR, zh-CN;q=0.8, en;q=0.9": "en-US",
+		"fr-FR, zh-CN;q=0.8":           "zh-CN",
+		"zh-CN;q=0, en":                "en-US",
+		"fr-FR, *;q=0.1":               "en-US",
+		"fr-FR":                        "en-US",
+		"zh-CN;q=bogus":                "en-US",
+	}
+	for header, want := range cases {
+		assert.Equal(t, want, i18n.Negotiate(header), "Accept-Language %q", header)
+	}
+}
@@ -0,0 +1,113 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/GT-610/tairitsu/internal/app/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func initFileLogger(t *testing.T, level string) string {
+	t.Helper()
+	t.Setenv("APP_ENV", "production")
+	logFile := filepath.Join(t.TempDir(), "logs", "tairitsu.log")
+	opts := logger.DefaultOptions()
+	opts.Level = level
+	opts.FilePath = logFile
+	logger.Init(opts)
+	return logFile
+}
+
+func TestSetLevelChangesVerbosityAtRuntime(t *testing.T) {
+	logFile := initFileLogger(t, "warn")
+	assert.Equal(t, "warn", logger.Level())
+
+	logger.Info("hidden at warn")
+	require.NoError(t, logger.SetLevel("debug"))
+	logger.Debug("visible at debug")
+	logger.Sync()
+
+	raw, err := os.ReadFile(logFile)
+	require.NoError(t, err)
+	assert.NotContains(t, string(raw), "hidden at warn")
+	assert.Contains(t, string(raw), "visible at debug")
+
+	assert.ErrorIs(t, logger.SetLevel("verbose"), logger.ErrInvalidLevel)
+	assert.Equal(t, "debug", logger.Level(), "an invalid level leaves the current one")
+}
+
+func TestInitFallsBackToStdoutWhenFileIsNotWritable(t *testing.T) {
+	t.Setenv("APP_ENV", "production")
+	blocker := filepath.Join(t.TempDir(), "not-a-directory")
+	require.NoError(t, os.WriteFile(blocker, nil, 0644))
+
+	opts := logger.DefaultOptions()
+	opts.FilePath = filepath.Join(blocker, "tairitsu.log")
+	assert.NotPanics(t, func() { logger.Init(opts) })
+	assert.NotPanics(t, func() { logger.Info("still logging") })
+	assert.NoFileExists(t, opts.FilePath)
+}
+
+// uninitializedEnv makes the test binary run TestLoggingBeforeInit's child half
+const uninitializedEnv = "TAIRITSU_TEST_LOGGER_UNINITIALIZED"
+
+// The package functions work before Init, in a fresh process since other tests call Init.
+// Warnings reach stderr, and Fatal still writes its entry before exiting.
+func TestLoggingBeforeInit(t *testing.T) {
+	if os.Getenv(uninitializedEnv) == "1" {
+		logger.Debug("debug before init")
+		logger.Info("info before init")
+		logger.Warn("warn before init")
+		logger.Error("error before init")
+		logger.WithRequestID(context.Background()).Info("request before init")
+		logger.Sync()
+		logger.Fatal("fatal before init")
+		return
+	}
+
+	cmd := exec.Command(os.Args[0], "-test.run=^TestLoggingBeforeInit$")
+	cmd.Env = append(os.Environ(), uninitializedEnv+"=1")
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	err := cmd.Run()
+
+	var exitErr *exec.ExitError
+	require.ErrorAs(t, err, &exitErr, stderr.String())
+	assert.Equal(t, 1, exitErr.ExitCode(), "Fatal exits instead of panicking")
+	output := stderr.String()
+	assert.NotContains(t, output, "panic")
+	assert.NotContains(t, output, "info before init")
+	assert.Contains(t, output, "warn before init")
+	assert.Contains(t, output, "error before init")
+	assert.Contains(t, output, "fatal before init")
+}
+
+// Configuration reloads rebuild the logger while requests are logging
+func TestInitWhileLogging(t *testing.T) {
+	logFile := initFileLogger(t, "info")
+	opts := logger.DefaultOptions()
+	opts.FilePath = logFile
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for range 200 {
+			logger.Info("logging during reload")
+		}
+	}()
+	for range 5 {
+		logger.Init(opts)
+	}
+	<-done
+	logger.Sync()
+
+	raw, err := os.ReadFile(logFile)
+	require.NoError(t, err)
+	assert.Contains(t, string(raw), "logging during reload")
+}
@@ -0,0 +1,232 @@
+package middleware
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/GT-610/tairitsu/internal/app/database"
+	"github.com/GT-610/tairitsu/internal/app/middleware"
+	"github.com/GT-610/tairitsu/internal/app/models"
+	"github.com/GT-610/tairitsu/internal/app/services"
+	"github.com/gofiber/fiber/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newApiTokenRouter protects GET and PUT /networks/:id with token-aware auth for user-1.
+func newApiTokenRouter(t *testing.T) (*fiber.App, *services.ApiTokenService, database.DBInterface) {
+	t.Helper()
+
+	db, err := database.NewDatabase(database.Config{Type: database.SQLite, Path: filepath.Join(t.TempDir(), "tairitsu.db")})
+	require.NoError(t, err)
+	require.NoError(t, db.Init())
+	t.Cleanup(func() {
+		_ = db.Close()
+	})
+	now := time.Now()
+	require.NoError(t, db.CreateUser(&models.User{ID: "user-1", Username: "ci", Password: "hashed", Role: "user", CreatedAt: now, UpdatedAt: now}))
+
+	tokenService := services.NewApiTokenService(db)
+	auth := middleware.AuthMiddlewareWithTokens(newTestJWTService(t, "test-secret-key"), services.NewSessionService(db), tokenService, services.NewUserService(db))
+
+	router := fiber.New()
+	handler := func(c fiber.Ctx) error {
+		return c.JSON(fiber.Map{"userId": c.Locals("user_id")})
+	}
+	router.Get("/networks/:id", auth, handler)
+	router.Put("/networks/:id", auth, handler)
+	return router, tokenService, db
+}
+
+func callWithToken(t *testing.T, router *fiber.App, method string, token string) (int, string) {
+	t.Helper()
+
+	req := httptest.NewRequest(method, "/networks/abc", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	resp, err := router.Test(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	return resp.StatusCode, string(body)
+}
+
+func TestApiTokenScopesGateMutatingRequests(t *testing.T) {
+	router, tokenService, _ := newApiTokenRouter(t)
+
+	_, readOnly, err := tokenService.CreateToken(services.ApiTokenCreateInput{UserID: "user-1", Name: "read", Scopes: []string{services.ApiTokenScopeRead}})
+	require.NoError(t, err)
+	_, readWrite, err := tokenService.CreateToken(services.ApiTokenCreateInput{UserID: "user-1", Name: "ci", Scopes: []string{services.ApiTokenScopeWrite}})
+	require.NoError(t, err)
+
+	status, body := callWithToken(t, router, http.MethodGet, readOnly)
+	assert.Equal(t, fiber.StatusOK, status)
+	assert.Contains(t, body, "user-1")
+
+	status, body = callWithToken(t, router, http.MethodPut, readOnly)
+	assert.Equal(t, fiber.StatusForbidden, status)
+	assert.Contains(t, body, "auth.insufficient_scope")
+
+	status, _ = callWithToken(t, router, http.MethodPut, readWrite)
+	assert.Equal(t, fiber.StatusOK, status)
+}
+
+func TestApiTokenRejectsExpiredAndRevokedTokens(t *testing.T) {
+	router, tokenService, db := newApiTokenRouter(t)
+
+	expiresAt := time.Now().Add(time.Hour)
+	expiring, expired, err := tokenService.CreateToken(services.ApiTokenCreateInput{UserID: "user-1", Name: "expiring", ExpiresAt: &expiresAt})
+	require.NoError(t, err)
+	past := time.Now().Add(-time.Minute)
+	expiring.ExpiresAt = &past
+	require.NoError(t, db.UpdateApiToken(expiring))
+
+	status, body := callWithToken(t, router, http.MethodGet, expired)
+	assert.Equal(t, fiber.StatusUnauthorized, status)
+	assert.Contains(t, body, "auth.invalid_token")
+
+	revokedToken, revoked, err := tokenService.CreateToken(services.ApiTokenCreateInput{UserID: "user-1", Name: "revoked"})
+	require.NoError(t, err)
+	status, _ = callWithToken(t, router, http.MethodGet, revoked)
+	require.Equal(t, fiber.StatusOK, status)
+
+	assert.ErrorIs(t, tokenService.RevokeToken("someone-else", revokedToken.ID), services.ErrApiTokenNotFound)
+	require.NoError(t, tokenService.RevokeToken("user-1", revokedToken.ID))
+	status, _ = callWithToken(t, router, http.MethodGet, revoked)
+	assert.Equal(t, fiber.StatusUnauthorized, status)
+
+	status, _ = callWithToken(t, router, http.MethodGet, services.ApiTokenPrefix+"unknown")
+	assert.Equal(t, fiber.StatusUnauthorized, status)
+}
+
+func TestApiTokenStoresOnlyHash(t *testing.T) {
+	_, tokenService, db := newApiTokenRouter(t)
+
+	token, plaintext, err := tokenService.CreateToken(services.ApiTokenCreateInput{UserID: "user-1", Name: "ci"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{services.ApiTokenScopeRead}, token.ScopeList())
+
+	stored, err := db.GetApiTokenByID(token.ID)
+	require.NoError(t, err)
+	assert.NotEqual(t, plaintext, stored.TokenHash)
+	assert.NotContains(t, stored.TokenHash, services.ApiTokenPrefix)
+
+	_, _, err = tokenService.CreateToken(services.ApiTokenCreateInput{UserID: "user-1", Name: "bad", Scopes: []string{"admin"}})
+	assert.ErrorIs(t, err, services.ErrApiTokenInvalidScope)
+}
+
+func TestNetworkScopedMembersWriteToken(t *testing.T) {
+	router, tokenService, db := newApiTokenRouter(t)
+	handler := func(c fiber.Ctx) error {
+		return c.SendStatus(fiber.StatusNoContent)
+	}
+	auth := middleware.AuthMiddlewareWithTokens(newTestJWTService(t, "test-secret-key"), services.NewSessionService(db), tokenService, services.NewUserService(db))
+	router.Put("/networks/:id/members/:memberId", middleware.TokenPermission(services.ApiTokenScopeMembersWrite), auth, handler)
+	router.Delete("/networks/:id", auth, handler)
+	// Under members:write by declaration, although nothing in its path says so
+	router.Post("/networks/:id/authorize-all", middleware.TokenPermission(services.ApiTokenScopeMembersWrite), auth, handler)
+	// Undeclared, so it needs write
+	router.Put("/networks/:id/members/:memberId/owner", auth, handler)
+
+	const scopedNetwork, otherNetwork = "8056c2e21c000001", "8056c2e21c000002"
+	now := time.Now()
+	for _, networkID := range []string{scopedNetwork, otherNetwork} {
+		require.NoError(t, db.CreateNetwork(&models.Network{ID: networkID, Name: networkID, OwnerID: "user-1", CreatedAt: now, UpdatedAt: now}))
+	}
+	tokenService.SetNetworkAuthorizer(services.NewNetworkService(nil, db))
+
+	_, plaintext, err := tokenService.CreateToken(services.ApiTokenCreateInput{
+		UserID:     "user-1",
+		Name:       "authorizer",
+		Scopes:     []string{services.ApiTokenScopeMembersWrite},
+		NetworkIDs: []string{scopedNetwork},
+	})
+	require.NoError(t, err)
+
+	call := func(method, path string) (int, string) {
+		req := httptest.NewRequest(method, path, nil)
+		req.Header.Set("Authorization", "Bearer "+plaintext)
+		resp, err := router.Test(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		return resp.StatusCode, string(body)
+	}
+
+	status, _ := call(http.MethodPut, "/networks/"+scopedNetwork+"/members/abcdef0123")
+	assert.Equal(t, fiber.StatusNoContent, status)
+
+	status, body := call(http.MethodDelete, "/networks/"+scopedNetwork)
+	assert.Equal(t, fiber.StatusForbidden, status)
+	assert.Contains(t, body, "missing the write scope")
+
+	status, body = call(http.MethodPut, "/networks/"+otherNetwork+"/members/abcdef0123")
+	assert.Equal(t, fiber.StatusForbidden, status)
+	assert.Contains(t, body, "network:"+otherNetwork)
+
+	status, _ = call(http.MethodPost, "/networks/"+scopedNetwork+"/authorize-all")
+	assert.Equal(t, fiber.StatusNoContent, status)
+
+	status, body = call(http.MethodPut, "/networks/"+scopedNetwork+"/members/abcdef0123/owner")
+	assert.Equal(t, fiber.StatusForbidden, status, "a route below members is not members:write unless it says so")
+	assert.Contains(t, body, "missing the write scope")
+}
+
+func TestReadOnlyTokenRejectedOnDeclaredWriteRoutes(t *testing.T) {
+	router, tokenService, db := newApiTokenRouter(t)
+	auth := middleware.AuthMiddlewareWithTokens(newTestJWTService(t, "test-secret-key"), services.NewSessionService(db), tokenService, services.NewUserService(db))
+	handler := func(c fiber.Ctx) error {
+		return c.SendStatus(fiber.StatusNoContent)
+	}
+	// A write reached with GET, which only its declaration marks as one
+	router.Get("/approvals/:id/approve", middleware.TokenPermission(services.ApiTokenScopeMembersWrite), auth, handler)
+	router.Post("/webhooks/:id/test", auth, handler)
+
+	_, readOnly, err := tokenService.CreateToken(services.ApiTokenCreateInput{UserID: "user-1", Name: "read", Scopes: []string{services.ApiTokenScopeRead}})
+	require.NoError(t, err)
+	_, membersWrite, err := tokenService.CreateToken(services.ApiTokenCreateInput{UserID: "user-1", Name: "members", Scopes: []string{services.ApiTokenScopeMembersWrite}})
+	require.NoError(t, err)
+
+	call := func(method, path, token string) (int, string) {
+		req := httptest.NewRequest(method, path, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		resp, err := router.Test(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		return resp.StatusCode, string(body)
+	}
+
+	status, body := call(http.MethodGet, "/approvals/42/approve", readOnly)
+	assert.Equal(t, fiber.StatusForbidden, status)
+	assert.Contains(t, body, "auth.insufficient_scope")
+	status, _ = call(http.MethodGet, "/approvals/42/approve", membersWrite)
+	assert.Equal(t, fiber.StatusNoContent, status)
+
+	status, body = call(http.MethodPost, "/webhooks/abc/test", readOnly)
+	assert.Equal(t, fiber.StatusForbidden, status)
+	assert.Contains(t, body, "auth.insufficient_scope")
+}
+
+func TestNetworkScopedTokenCreationRequiresDelegatedPermission(t *testing.T) {
+	_, tokenService, db := newApiTokenRouter(t)
+
+	const viewedNetwork = "8056c2e21c000003"
+	now := time.Now()
+	require.NoError(t, db.CreateUser(&models.User{ID: "owner-2", Username: "owner", Password: "hashed", Role: "user", CreatedAt: now, UpdatedAt: now}))
+	require.NoError(t, db.CreateNetwork(&models.Network{ID: viewedNetwork, Name: "viewed", OwnerID: "owner-2", CreatedAt: now, UpdatedAt: now}))
+	require.NoError(t, db.UpsertNetworkViewer(&models.NetworkViewer{NetworkID: viewedNetwork, UserID: "user-1", GrantedBy: "owner-2", CreatedAt: now, UpdatedAt: now}))
+	tokenService.SetNetworkAuthorizer(services.NewNetworkService(nil, db))
+
+	_, _, err := tokenService.CreateToken(services.ApiTokenCreateInput{UserID: "user-1", Name: "reader", NetworkIDs: []string{viewedNetwork}})
+	require.NoError(t, err)
+
+	_, _, err = tokenService.CreateToken(services.ApiTokenCreateInput{UserID: "user-1", Name: "writer", Scopes: []string{services.ApiTokenScopeMembersWrite}, NetworkIDs: []string{viewedNetwork}})
+	assert.ErrorIs(t, err, services.ErrApiTokenDelegation)
+
+	_, _, err = tokenService.CreateToken(services.ApiTokenCreateInput{UserID: "user-1", Name: "bad", NetworkIDs: []string{"not-a-network"}})
+	assert.ErrorIs(t, err, services.ErrApiTokenInvalidNetwork)
+}
@@ -1,6 +1,7 @@
 package middleware
 
 import (
+	"errors"
 	"io"
 	"net/http"
 	"net/http/httptest"
@@ -9,6 +10,7 @@ import (
 	appmiddleware "github.com/GT-610/tairitsu/internal/app/middleware"
 	"github.com/gofiber/fiber/v3"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 type stateStub struct {
@@ -30,7 +32,7 @@ func TestSetupOnly_BlocksAfterInitialization(t *testing.T) {
 	resp, err := router.Test(req)
 
 	assert.NoError(t, err)
-	assert.Equal(t, fiber.StatusConflict, resp.StatusCode)
+	assert.Equal(t, fiber.StatusForbidden, resp.StatusCode)
 
 	body, _ := io.ReadAll(resp.Body)
 	resp.Body.Close()
@@ -54,3 +56,48 @@ func TestInitializedOnly_BlocksBeforeInitialization(t *testing.T) {
 	resp.Body.Close()
 	assert.Contains(t, string(body), "system.setup_required")
 }
+
+type databaseStub struct {
+	err error
+}
+
+func (s databaseStub) DatabaseError() error {
+	return s.err
+}
+
+func TestRuntimeReady_ReportsUnreachableDatabase(t *testing.T) {
+	database := &databaseStub{err: errors.New("dial tcp 127.0.0.1:3306: connection refused")}
+	router := fiber.New()
+	router.Use(appmiddleware.RuntimeReadyWithState(stateStub{initialized: true}, database))
+	router.Get("/runtime-only", func(c fiber.Ctx) error {
+		return c.SendStatus(fiber.StatusNoContent)
+	})
+
+	resp, err := router.Test(httptest.NewRequest(http.MethodGet, "/runtime-only", nil))
+	require.NoError(t, err)
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	assert.Equal(t, fiber.StatusServiceUnavailable, resp.StatusCode)
+	assert.Contains(t, string(body), "system.database_unavailable")
+	assert.NotContains(t, string(body), "connection refused", "the connection error stays in the log")
+
+	database.err = nil
+	resp, err = router.Test(httptest.NewRequest(http.MethodGet, "/runtime-only", nil))
+	require.NoError(t, err)
+	assert.Equal(t, fiber.StatusNoContent, resp.StatusCode)
+}
+
+func TestRuntimeReady_ReportsSetupBeforeDatabase(t *testing.T) {
+	router := fiber.New()
+	router.Use(appmiddleware.RuntimeReadyWithState(stateStub{initialized: false}, databaseStub{}))
+	router.Get("/runtime-only", func(c fiber.Ctx) error {
+		return c.SendStatus(fiber.StatusNoContent)
+	})
+
+	resp, err := router.Test(httptest.NewRequest(http.MethodGet, "/runtime-only", nil))
+	require.NoError(t, err)
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	assert.Equal(t, fiber.StatusServiceUnavailable, resp.StatusCode)
+	assert.Contains(t, string(body), "system.setup_required")
+}
@@ -19,7 +19,7 @@ import (
 
 func TestAuthMiddleware_MissingToken(t *testing.T) {
 	// Arrange
-	jwtService := services.NewJWTService("test-secret-key")
+	jwtService := newTestJWTService(t, "test-secret-key")
 	authMiddleware := middleware.AuthMiddleware(jwtService, nil)
 
 	// Create a test router with the middleware
@@ -45,7 +45,7 @@ func TestAuthMiddleware_MissingToken(t *testing.T) {
 
 func TestAuthMiddleware_InvalidTokenFormat(t *testing.T) {
 	// Arrange
-	jwtService := services.NewJWTService("test-secret-key")
+	jwtService := newTestJWTService(t, "test-secret-key")
 	authMiddleware := middleware.AuthMiddleware(jwtService, nil)
 
 	// Create a test router with the middleware
@@ -72,7 +72,7 @@ func TestAuthMiddleware_InvalidTokenFormat(t *testing.T) {
 
 func TestAuthMiddleware_InvalidToken(t *testing.T) {
 	// Arrange
-	jwtService := services.NewJWTService("test-secret-key")
+	jwtService := newTestJWTService(t, "test-secret-key")
 	authMiddleware := middleware.AuthMiddleware(jwtService, nil)
 
 	// Create a test router with the middleware
@@ -99,7 +99,7 @@ func TestAuthMiddleware_InvalidToken(t *testing.T) {
 
 func TestAuthMiddleware_ValidToken(t *testing.T) {
 	// Arrange
-	jwtService := services.NewJWTService("test-secret-key")
+	jwtService := newTestJWTService(t, "test-secret-key")
 	authMiddleware := middleware.AuthMiddleware(jwtService, nil)
 
 	// Create a test user and generate a valid token
@@ -118,7 +118,7 @@ func TestAuthMiddleware_ValidToken(t *testing.T) {
 		username := c.Locals("username")
 		role := c.Locals("role")
 		return c.JSON(fiber.Map{
-			"user_id":  userID,
+			"userId":   userID,
 			"username": username,
 			"role":     role,
 		})
@@ -159,7 +159,7 @@ func TestAdminRequiredWithUserService_DeniesStaleAdminTokenAfterTransfer(t *test
 	}
 	require.NoError(t, db.CreateUser(currentAdmin))
 
-	jwtService := services.NewJWTService("test-secret-key")
+	jwtService := newTestJWTService(t, "test-secret-key")
 	authMiddleware := middleware.AuthMiddleware(jwtService, nil)
 	adminMiddleware := middleware.AdminRequiredWithUserService(services.NewUserService(db))
 
@@ -215,7 +215,7 @@ func TestAuthMiddleware_RejectsRevokedSession(t *testing.T) {
 	require.NoError(t, db.CreateSession(session))
 
 	sessionService := services.NewSessionService(db)
-	jwtService := services.NewJWTService("test-secret-key")
+	jwtService := newTestJWTService(t, "test-secret-key")
 	token, err := jwtService.GenerateToken(user, session.ID)
 	require.NoError(t, err)
 	require.NoError(t, sessionService.RevokeSession(user.ID, session.ID))
@@ -232,3 +232,10 @@ func TestAuthMiddleware_RejectsRevokedSession(t *testing.T) {
 	require.NoError(t, err)
 	assert.Equal(t, fiber.StatusUnauthorized, resp.StatusCode)
 }
+
+func newTestJWTService(t *testing.T, secretKey string) *services.JWTService {
+	t.Helper()
+	jwtService, err := services.NewJWTService(secretKey)
+	require.NoError(t, err)
+	return jwtService
+}
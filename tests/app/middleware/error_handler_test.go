@@ -22,12 +22,12 @@ func TestErrorHandlerPreservesFiberErrorStatus(t *testing.T) {
 	assert.Equal(t, fiber.StatusNotFound, resp.StatusCode)
 
 	var body struct {
-		Error   string `json:"error"`
-		Message string `json:"message"`
-		Code    int    `json:"code"`
+		Message   string `json:"message"`
+		ErrorCode string `json:"errorCode"`
+		Code      int    `json:"code"`
 	}
 	require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
-	assert.Equal(t, "Not Found", body.Error)
+	assert.Equal(t, "http.not_found", body.ErrorCode)
 	assert.Equal(t, "Not Found", body.Message)
 	assert.Equal(t, fiber.StatusNotFound, body.Code)
 }
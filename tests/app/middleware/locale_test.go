@@ -0,0 +1,78 @@
+package middleware
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/GT-610/tairitsu/internal/app/apierror"
+	appmiddleware "github.com/GT-610/tairitsu/internal/app/middleware"
+	"github.com/gofiber/fiber/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLocaleTranslatesLocalizedErrors(t *testing.T) {
+	app := fiber.New()
+	app.Use(appmiddleware.Locale())
+	app.Use(appmiddleware.ErrorHandler())
+	app.Get("/fail", func(c fiber.Ctx) error {
+		return errors.New("boom")
+	})
+
+	for header, want := range map[string]string{
+		"":                     "Internal Server Error",
+		"zh-CN,zh;q=0.9":       "服务器内部错误",
+		"de-DE, en-US;q=0.5":   "Internal Server Error",
+		"fr-FR, zh-Hans;q=0.2": "服务器内部错误",
+	} {
+		req := httptest.NewRequest(http.MethodGet, "/fail", nil)
+		if header != "" {
+			req.Header.Set(fiber.HeaderAcceptLanguage, header)
+		}
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		assert.Equal(t, fiber.StatusInternalServerError, resp.StatusCode)
+		assert.Contains(t, resp.Header.Get(fiber.HeaderVary), fiber.HeaderAcceptLanguage)
+
+		var body apierror.Response
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+		assert.Equal(t, want, body.Message, "Accept-Language %q", header)
+		assert.Equal(t, apierror.CodeSystemInternalError, body.ErrorCode, "the code does not change with the language")
+	}
+}
+
+func TestLocaleKeepsTheSpecificMessageAsDetail(t *testing.T) {
+	app := fiber.New()
+	app.Use(appmiddleware.Locale())
+	app.Get("/short", func(c fiber.Ctx) error {
+		return apierror.Write(c, apierror.New(fiber.StatusBadRequest, apierror.CodeUserInvalidPassword, "password is too short").Translate(apierror.CodeUserInvalidPassword))
+	})
+	app.Get("/missing", func(c fiber.Ctx) error {
+		return apierror.Write(c, apierror.New(fiber.StatusNotFound, apierror.CodeUserNotFound, "user not found").Translate(apierror.CodeUserNotFound))
+	})
+
+	get := func(path, acceptLanguage string) apierror.Response {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set(fiber.HeaderAcceptLanguage, acceptLanguage)
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		var body apierror.Response
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+		return body
+	}
+
+	body := get("/short", "en-US")
+	assert.Equal(t, "password is too short", body.Message)
+	assert.Empty(t, body.Detail)
+
+	body = get("/short", "zh-CN")
+	assert.Equal(t, "密码长度不符合要求", body.Message)
+	assert.Equal(t, "password is too short", body.Detail, "the translation is generic, so the English specifics stay available")
+
+	body = get("/missing", "zh-CN")
+	assert.Equal(t, "用户不存在", body.Message)
+	assert.Empty(t, body.Detail, "a message that only repeats the translated text adds no detail")
+}
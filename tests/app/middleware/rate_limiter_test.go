@@ -2,11 +2,14 @@ package middleware
 
 import (
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/GT-610/tairitsu/internal/app/config"
 	"github.com/GT-610/tairitsu/internal/app/middleware"
 	"github.com/gofiber/fiber/v3"
 	"github.com/stretchr/testify/assert"
@@ -68,7 +71,7 @@ func TestRateLimitMiddleware(t *testing.T) {
 
 	var body map[string]any
 	require.NoError(t, json.NewDecoder(secondResp.Body).Decode(&body))
-	assert.Equal(t, "system.rate_limited", body["error_code"])
+	assert.Equal(t, "system.rate_limited", body["errorCode"])
 }
 
 func TestRateLimitMiddleware_WithWait(t *testing.T) {
@@ -92,3 +95,138 @@ func TestRateLimitMiddleware_WithWait(t *testing.T) {
 	require.NoError(t, err)
 	assert.Equal(t, fiber.StatusNoContent, thirdResp.StatusCode)
 }
+
+func TestRateLimiterEvictsIdleBuckets(t *testing.T) {
+	limiter := middleware.NewRateLimiterWithTTL(5, 1, 50*time.Millisecond)
+	defer limiter.Stop()
+
+	for i := 0; i < 2000; i++ {
+		ip := fmt.Sprintf("198.51.%d.%d", i/256, i%256)
+		assert.True(t, limiter.GetBucket(ip).GetToken())
+	}
+	assert.Equal(t, 2000, limiter.BucketCount())
+
+	require.Eventually(t, func() bool {
+		return limiter.BucketCount() == 0
+	}, 2*time.Second, 10*time.Millisecond)
+}
+
+func TestRateLimiterStopEndsEviction(t *testing.T) {
+	limiter := middleware.NewRateLimiterWithTTL(1, 0, 20*time.Millisecond)
+	limiter.Stop()
+	limiter.Stop()
+
+	assert.True(t, limiter.GetBucket("192.0.2.1").GetToken())
+	time.Sleep(100 * time.Millisecond)
+	assert.Equal(t, 1, limiter.BucketCount())
+	assert.False(t, limiter.GetBucket("192.0.2.1").GetToken(), "a stopped limiter still limits")
+}
+
+func TestRateLimiterResetRefillsEveryClient(t *testing.T) {
+	limiter := middleware.NewRateLimiter(1, 0)
+	defer limiter.Stop()
+
+	assert.True(t, limiter.GetBucket("192.0.2.1").GetToken())
+	assert.False(t, limiter.GetBucket("192.0.2.1").GetToken())
+	limiter.Reset()
+	assert.Equal(t, 0, limiter.BucketCount())
+	assert.True(t, limiter.GetBucket("192.0.2.1").GetToken())
+}
+
+func TestRateLimitWithPolicySharesNamedLimiter(t *testing.T) {
+	app := fiber.New()
+	app.Get("/first", middleware.RateLimitWithPolicy("test-shared", 1, 0), func(c fiber.Ctx) error {
+		return c.SendStatus(fiber.StatusNoContent)
+	})
+	app.Get("/second", middleware.RateLimitWithPolicy("test-shared", 50, 50), func(c fiber.Ctx) error {
+		return c.SendStatus(fiber.StatusNoContent)
+	})
+
+	firstResp, err := app.Test(httptest.NewRequest(http.MethodGet, "/first", nil))
+	require.NoError(t, err)
+	assert.Equal(t, fiber.StatusNoContent, firstResp.StatusCode)
+
+	secondResp, err := app.Test(httptest.NewRequest(http.MethodGet, "/second", nil))
+	require.NoError(t, err)
+	assert.Equal(t, fiber.StatusTooManyRequests, secondResp.StatusCode, "routes naming the same policy share its buckets")
+
+	var shared *middleware.RateLimitPolicy
+	for _, policy := range middleware.RateLimitPolicies() {
+		if policy.Name == "test-shared" {
+			shared = &policy
+		}
+	}
+	require.NotNil(t, shared)
+	assert.Equal(t, middleware.RateLimitPolicy{Name: "test-shared", Capacity: 1, RefillRate: 0, RefillPeriodSeconds: 1, Buckets: 1}, *shared)
+}
+
+func TestApplyRateLimitSettingsOverridesBuiltInLimits(t *testing.T) {
+	limiter := middleware.RateLimiterForPolicy("test-settings", 1, 0)
+	t.Cleanup(func() { middleware.ApplyRateLimitSettings(nil) })
+
+	assert.True(t, limiter.GetBucket("192.0.2.1").GetToken())
+	assert.False(t, limiter.GetBucket("192.0.2.1").GetToken())
+
+	middleware.ApplyRateLimitSettings(map[string]config.RateLimitConfig{"test-settings": {Capacity: 5, RefillRate: 1}})
+	capacity, refillRate := limiter.Limits()
+	assert.Equal(t, 5, capacity)
+	assert.Equal(t, 1, refillRate)
+	assert.True(t, limiter.GetBucket("192.0.2.2").GetToken(), "new clients get the configured capacity")
+	for i := 0; i < 4; i++ {
+		assert.True(t, limiter.GetBucket("192.0.2.2").GetToken())
+	}
+	assert.False(t, limiter.GetBucket("192.0.2.2").GetToken())
+
+	middleware.ApplyRateLimitSettings(nil)
+	capacity, refillRate = limiter.Limits()
+	assert.Equal(t, 1, capacity, "removed overrides restore the built-in limits")
+	assert.Equal(t, 0, refillRate)
+}
+
+func TestRateLimiterForPolicyPerRefillsEachPeriod(t *testing.T) {
+	limiter := middleware.RateLimiterForPolicyPer("test-period", 1, 1, 200*time.Millisecond)
+	assert.Equal(t, 200*time.Millisecond, limiter.RefillPeriod())
+
+	assert.True(t, limiter.GetBucket("192.0.2.1").GetToken())
+	assert.False(t, limiter.GetBucket("192.0.2.1").GetToken())
+	time.Sleep(250 * time.Millisecond)
+	assert.True(t, limiter.GetBucket("192.0.2.1").GetToken())
+}
+
+func TestCredentialRateLimitLimitsEachAccount(t *testing.T) {
+	resetCredentialLimits := func() {
+		middleware.ApplyRateLimitSettings(nil)
+		middleware.LoginRateLimiter.Reset()
+		middleware.LoginUsernameRateLimiter.Reset()
+	}
+	resetCredentialLimits()
+	t.Cleanup(resetCredentialLimits)
+
+	app := fiber.New()
+	app.Post("/login", middleware.CredentialRateLimit(), func(c fiber.Ctx) error {
+		return c.SendStatus(fiber.StatusNoContent)
+	})
+	login := func(body string) int {
+		req := httptest.NewRequest(http.MethodPost, "/login", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		return resp.StatusCode
+	}
+
+	// Five attempts per minute from one address, whatever the account
+	for i := range 5 {
+		assert.Equal(t, fiber.StatusNoContent, login(fmt.Sprintf(`{"username":"user%d"}`, i)))
+	}
+	assert.Equal(t, fiber.StatusTooManyRequests, login(`{"username":"someone-else"}`))
+
+	// With the address limit raised, the account limit still applies, case-insensitively
+	middleware.ApplyRateLimitSettings(map[string]config.RateLimitConfig{middleware.RateLimitPolicyLogin: {Capacity: 100}})
+	middleware.LoginRateLimiter.Reset()
+	for range 5 {
+		assert.Equal(t, fiber.StatusNoContent, login(`{"username":"alice"}`))
+	}
+	assert.Equal(t, fiber.StatusTooManyRequests, login(`{"username":"Alice"}`))
+	assert.Equal(t, fiber.StatusNoContent, login(`{"username":"bob"}`))
+	assert.Equal(t, fiber.StatusNoContent, login(`{"token":"reset-token"}`), "requests without an account only count per address")
+}
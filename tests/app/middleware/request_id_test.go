@@ -0,0 +1,109 @@
+package middleware
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/GT-610/tairitsu/internal/app/apierror"
+	"github.com/GT-610/tairitsu/internal/app/logger"
+	appmiddleware "github.com/GT-610/tairitsu/internal/app/middleware"
+	"github.com/gofiber/fiber/v3"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newRequestIDApp() *fiber.App {
+	app := fiber.New()
+	app.Use(appmiddleware.RequestID())
+	app.Use(appmiddleware.ErrorHandler())
+	app.Get("/ok", func(c fiber.Ctx) error {
+		logger.WithRequestID(c).Info("handled")
+		return c.SendString(appmiddleware.RequestIDFrom(c))
+	})
+	app.Get("/fail", func(c fiber.Ctx) error {
+		return errors.New("boom")
+	})
+	return app
+}
+
+func TestRequestIDGeneratedAndEchoed(t *testing.T) {
+	app := newRequestIDApp()
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/ok", nil))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	requestID := resp.Header.Get(appmiddleware.RequestIDHeader)
+	_, err = uuid.Parse(requestID)
+	require.NoError(t, err, "a UUID is generated when the client sends none")
+
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.Equal(t, requestID, string(body), "handlers see the same ID")
+}
+
+func TestRequestIDHonorsIncomingHeader(t *testing.T) {
+	app := newRequestIDApp()
+
+	req := httptest.NewRequest(http.MethodGet, "/ok", nil)
+	req.Header.Set(appmiddleware.RequestIDHeader, "proxy-7f3a:42")
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+	assert.Equal(t, "proxy-7f3a:42", resp.Header.Get(appmiddleware.RequestIDHeader))
+
+	for _, incoming := range []string{"has spaces", "quote\"d", strings.Repeat("a", 129)} {
+		req = httptest.NewRequest(http.MethodGet, "/ok", nil)
+		req.Header.Set(appmiddleware.RequestIDHeader, incoming)
+		resp, err = app.Test(req)
+		require.NoError(t, err)
+		assert.NotEqual(t, incoming, resp.Header.Get(appmiddleware.RequestIDHeader), "malformed IDs are replaced")
+		assert.NotEmpty(t, resp.Header.Get(appmiddleware.RequestIDHeader))
+	}
+}
+
+func TestRequestIDInErrorBodyAndLogs(t *testing.T) {
+	t.Setenv("APP_ENV", "production")
+	logFile := filepath.Join(t.TempDir(), "tairitsu.log")
+	logger.InitLoggerWithFile("info", logFile)
+	t.Cleanup(func() { logger.InitLoggerWithFile("info", filepath.Join(t.TempDir(), "discard.log")) })
+
+	app := newRequestIDApp()
+
+	req := httptest.NewRequest(http.MethodGet, "/fail", nil)
+	req.Header.Set(appmiddleware.RequestIDHeader, "trace-fail")
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, fiber.StatusInternalServerError, resp.StatusCode)
+
+	var body apierror.Response
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+	assert.Equal(t, "trace-fail", body.RequestID)
+
+	req = httptest.NewRequest(http.MethodGet, "/ok", nil)
+	req.Header.Set(appmiddleware.RequestIDHeader, "trace-ok")
+	_, err = app.Test(req)
+	require.NoError(t, err)
+	logger.Sync()
+
+	raw, err := os.ReadFile(logFile)
+	require.NoError(t, err)
+	entries := map[string]string{}
+	for _, line := range strings.Split(strings.TrimSpace(string(raw)), "\n") {
+		var entry map[string]any
+		require.NoError(t, json.Unmarshal([]byte(line), &entry))
+		msg, _ := entry["msg"].(string)
+		requestID, _ := entry[logger.RequestIDKey].(string)
+		entries[msg] = requestID
+	}
+	assert.Equal(t, "trace-fail", entries["API error"])
+	assert.Equal(t, "trace-ok", entries["handled"])
+}
@@ -0,0 +1,51 @@
+package paths
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/GT-610/tairitsu/internal/app/paths"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInitPrefersTheFlagOverTheEnvironment(t *testing.T) {
+	t.Cleanup(func() { paths.Init("") })
+	flagDir, envDir := t.TempDir(), t.TempDir()
+	t.Setenv(paths.DataDirEnv, envDir)
+
+	paths.Init(flagDir)
+	assert.Equal(t, flagDir, paths.DataDir())
+	assert.Equal(t, filepath.Join(flagDir, "config.json"), paths.ConfigFile())
+
+	paths.Init("")
+	assert.Equal(t, envDir, paths.DataDir())
+
+	t.Setenv(paths.DataDirEnv, "")
+	paths.Init("")
+	assert.Equal(t, paths.DefaultDataDir, paths.DataDir())
+}
+
+func TestDefaultDataDirKeepsPathsRelativeToTheWorkingDirectory(t *testing.T) {
+	t.Cleanup(func() { paths.Init("") })
+	t.Setenv(paths.DataDirEnv, "")
+	paths.Init("")
+
+	assert.Equal(t, filepath.Join("data", "config.json"), paths.ConfigFile())
+	assert.Equal(t, "./logs", paths.LogDir())
+	assert.Equal(t, "data/tairitsu.db", paths.Resolve("data/tairitsu.db"))
+	assert.Equal(t, "certs/server.pem", paths.Resolve("certs/server.pem"))
+}
+
+func TestResolveInterpretsRelativePathsInAChosenDataDir(t *testing.T) {
+	dir := t.TempDir()
+	t.Cleanup(paths.SetDataDir(dir))
+
+	assert.Equal(t, filepath.Join(dir, "logs"), paths.LogDir())
+	assert.Equal(t, filepath.Join(dir, "planets"), paths.PlanetDir())
+	assert.Equal(t, filepath.Join(dir, "tairitsu.db"), paths.Resolve("data/tairitsu.db"))
+	assert.Equal(t, filepath.Join(dir, "backups"), paths.Resolve("./data/backups"))
+	assert.Equal(t, dir, paths.Resolve("data"))
+	assert.Equal(t, filepath.Join(dir, "certs", "server.pem"), paths.Resolve("certs/server.pem"))
+	assert.Equal(t, "/etc/tairitsu/server.pem", paths.Resolve("/etc/tairitsu/server.pem"))
+	assert.Empty(t, paths.Resolve(""))
+}
@@ -6,6 +6,7 @@ import (
 	"net/http/httptest"
 	"testing"
 
+	"github.com/GT-610/tairitsu/internal/app/apptest"
 	"github.com/GT-610/tairitsu/internal/app/assembly"
 	"github.com/GT-610/tairitsu/internal/app/config"
 	"github.com/GT-610/tairitsu/internal/app/routes"
@@ -33,7 +34,7 @@ func TestLoginRouteDoesNotUseSetupOnlyAfterInitialization(t *testing.T) {
 	req := httptest.NewRequest(http.MethodPost, "/api/auth/login", bytes.NewBufferString(`{"username":"admin","password":"secret123"}`))
 	req.Header.Set("Content-Type", "application/json")
 
-	resp, err := app.Test(req)
+	resp, err := app.Test(req, apptest.SlowTestConfig())
 	require.NoError(t, err)
 	assert.NotEqual(t, fiber.StatusConflict, resp.StatusCode)
 }
@@ -57,7 +58,7 @@ func TestRegisterRouteDoesNotUseSetupOnlyAfterInitialization(t *testing.T) {
 	req := httptest.NewRequest(http.MethodPost, "/api/auth/register", bytes.NewBufferString(`{"username":"user-1","password":"secret123"}`))
 	req.Header.Set("Content-Type", "application/json")
 
-	resp, err := app.Test(req)
+	resp, err := app.Test(req, apptest.SlowTestConfig())
 	require.NoError(t, err)
 	assert.NotEqual(t, fiber.StatusConflict, resp.StatusCode)
 }
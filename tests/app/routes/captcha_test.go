@@ -0,0 +1,85 @@
+package routes
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/GT-610/tairitsu/internal/app/config"
+	"github.com/GT-610/tairitsu/internal/app/services"
+	"github.com/gofiber/fiber/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// solvedCaptcha accepts the token "solved"
+type solvedCaptcha struct{}
+
+func (solvedCaptcha) Verify(_ context.Context, token, _ string) error {
+	if token != "solved" {
+		return services.ErrCaptchaInvalid
+	}
+	return nil
+}
+
+func withCaptcha(contract *contractApp) {
+	guard := services.NewCaptchaGuard(config.CaptchaConfig{Provider: config.CaptchaProviderTurnstile, SiteKey: "site-key", LoginFailures: 2}, solvedCaptcha{})
+	contract.dependencies.Handlers.Auth.SetCaptchaGuard(guard)
+	contract.token = ""
+}
+
+func TestLoginRequiresCaptchaAfterRepeatedFailures(t *testing.T) {
+	contract := newContractApp(t, false)
+	withCaptcha(contract)
+
+	status, body := contract.call(t, http.MethodGet, "/api/auth/methods", "")
+	require.Equal(t, fiber.StatusOK, status, body)
+	assert.Contains(t, body, `"captcha":{"provider":"turnstile","siteKey":"site-key"}`)
+
+	wrong := `{"username":"admin","password":"wrong-password"}`
+	status, body = contract.call(t, http.MethodPost, "/api/auth/login", wrong)
+	assert.Equal(t, fiber.StatusUnauthorized, status)
+	assert.NotContains(t, body, "captchaRequired")
+
+	status, body = contract.call(t, http.MethodPost, "/api/auth/login", wrong)
+	assert.Equal(t, fiber.StatusUnauthorized, status)
+	assert.Contains(t, body, `"captchaRequired":true`, "the response announces the captcha")
+
+	// The captcha is checked before the password, so a right password alone is refused
+	status, body = contract.call(t, http.MethodPost, "/api/auth/login", `{"username":"admin","password":"`+contractPassword+`"}`)
+	assert.Equal(t, fiber.StatusBadRequest, status)
+	assert.Contains(t, body, `"errorCode":"auth.captcha_required"`)
+
+	status, body = contract.call(t, http.MethodPost, "/api/auth/login", `{"username":"admin","password":"`+contractPassword+`","captchaToken":"guess"}`)
+	assert.Equal(t, fiber.StatusBadRequest, status)
+	assert.Contains(t, body, `"errorCode":"auth.captcha_invalid"`)
+
+	status, body = contract.call(t, http.MethodPost, "/api/auth/login", `{"username":"admin","password":"`+contractPassword+`","captchaToken":"solved"}`)
+	require.Equal(t, fiber.StatusOK, status, body)
+	assert.Contains(t, body, `"token"`)
+}
+
+func TestRegistrationRequiresCaptcha(t *testing.T) {
+	contract := newContractAppWith(t, false, func(cfg *config.Config) {
+		require.NoError(t, config.SetAllowPublicRegistrationOn(cfg, true))
+	})
+	withCaptcha(contract)
+
+	register := `{"username":"newcomer","password":"` + contractPassword + `"`
+	status, body := contract.call(t, http.MethodPost, "/api/auth/register", register+`}`)
+	assert.Equal(t, fiber.StatusBadRequest, status)
+	assert.Contains(t, body, `"errorCode":"auth.captcha_required"`)
+	assert.Contains(t, body, `"captchaRequired":true`)
+
+	status, body = contract.call(t, http.MethodPost, "/api/auth/register", register+`,"captchaToken":"solved"}`)
+	assert.Equal(t, fiber.StatusCreated, status, body)
+}
+
+func TestAuthMethodsOmitCaptchaWhenDisabled(t *testing.T) {
+	contract := newContractApp(t, false)
+	contract.token = ""
+
+	status, body := contract.call(t, http.MethodGet, "/api/auth/methods", "")
+	require.Equal(t, fiber.StatusOK, status, body)
+	assert.NotContains(t, body, "captcha")
+}
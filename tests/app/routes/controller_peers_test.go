@@ -0,0 +1,44 @@
+package routes
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/GT-610/tairitsu/internal/app/models"
+	"github.com/GT-610/tairitsu/internal/app/services"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestControllerPeersRoute(t *testing.T) {
+	contract := newContractApp(t, false)
+
+	status, raw := contract.call(t, http.MethodGet, "/api/controller/peers", "")
+	require.Equal(t, http.StatusOK, status, raw)
+	var peers services.ControllerPeers
+	require.NoError(t, json.Unmarshal([]byte(raw), &peers))
+	assert.Equal(t, "default", peers.Controller)
+	require.Len(t, peers.Peers, 1)
+	assert.Equal(t, contractMemberID, peers.Peers[0].Address)
+	assert.Equal(t, "LEAF", peers.Peers[0].Role)
+
+	status, raw = contract.call(t, http.MethodGet, "/api/controller/peers?role=PLANET", "")
+	require.Equal(t, http.StatusOK, status, raw)
+	require.NoError(t, json.Unmarshal([]byte(raw), &peers))
+	assert.Empty(t, peers.Peers)
+
+	status, raw = contract.call(t, http.MethodGet, "/api/controller/peers?role=ROOT", "")
+	assert.Equal(t, http.StatusBadRequest, status)
+	assert.Contains(t, raw, `"errorCode":"controller.invalid_peer_role"`)
+
+	status, raw = contract.call(t, http.MethodGet, "/api/controller/peers?controller=lab", "")
+	assert.Equal(t, http.StatusNotFound, status)
+	assert.Contains(t, raw, `"errorCode":"controller.not_found"`)
+
+	member, err := contract.dependencies.Services.User.Register(&models.RegisterRequest{Username: "member", Password: contractPassword}, "user")
+	require.NoError(t, err)
+	contract.token = contract.issueToken(t, member)
+	status, _ = contract.call(t, http.MethodGet, "/api/controller/peers", "")
+	assert.Equal(t, http.StatusForbidden, status)
+}
@@ -0,0 +1,48 @@
+package routes
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/GT-610/tairitsu/internal/app/services"
+	"github.com/gofiber/fiber/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestControllerTraceIngestionAcceptsPlainTextAndSkipsAudit(t *testing.T) {
+	contract := newContractApp(t, false)
+
+	lines := "event=FILTER_RESULT networkId=" + contract.networkID + " remoteZtAddr=" + contractMemberID + " outcome=accept rule=allow-all\nnot a trace line\n"
+	req := httptest.NewRequest(http.MethodPost, "/api/admin/controller/trace", bytes.NewBufferString(lines))
+	req.Header.Set("Content-Type", "text/plain")
+	req.Header.Set("Authorization", "Bearer "+contract.token)
+	resp, err := contract.app.Test(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	raw, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	require.Equal(t, fiber.StatusOK, resp.StatusCode, string(raw))
+
+	var result services.ControllerTraceIngestResult
+	require.NoError(t, json.Unmarshal(raw, &result))
+	assert.Equal(t, services.ControllerTraceIngestResult{Accepted: 2, Parsed: 1, ParseFailures: 1}, result)
+
+	status, body := contract.call(t, http.MethodGet, "/api/networks/"+contract.networkID+"/members/"+contractMemberID+"/trace", "")
+	require.Equal(t, fiber.StatusOK, status, body)
+	assert.Contains(t, body, `"rule":"allow-all"`)
+	assert.Contains(t, body, `"outcome":"accept"`)
+
+	status, body = contract.call(t, http.MethodGet, "/api/admin/controller/trace?parsed=false", "")
+	require.Equal(t, fiber.StatusOK, status, body)
+	assert.Contains(t, body, `"raw":"not a trace line"`)
+	assert.Contains(t, body, `"parseFailures":1`)
+
+	status, body = contract.call(t, http.MethodGet, "/api/admin/audit", "")
+	require.Equal(t, fiber.StatusOK, status, body)
+	assert.NotContains(t, body, "/api/admin/controller/trace")
+}
@@ -0,0 +1,39 @@
+package routes
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/GT-610/tairitsu/internal/app/models"
+	"github.com/GT-610/tairitsu/internal/zerotier/ztmock"
+	"github.com/gofiber/fiber/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestControllersListHidesAddressesFromUsers(t *testing.T) {
+	contract := newContractApp(t, false)
+
+	status, body := contract.call(t, http.MethodGet, "/api/controllers", "")
+	require.Equal(t, fiber.StatusOK, status, body)
+	assert.Contains(t, body, `"name":"default"`)
+	assert.Contains(t, body, `"address":"`+ztmock.DemoAddress+`"`)
+	assert.Contains(t, body, `"url":"http://`)
+
+	user, err := contract.dependencies.Services.User.Register(&models.RegisterRequest{Username: "member", Password: contractPassword}, "user")
+	require.NoError(t, err)
+	contract.token = contract.issueToken(t, user)
+
+	status, body = contract.call(t, http.MethodGet, "/api/controllers", "")
+	require.Equal(t, fiber.StatusOK, status, body)
+	assert.Contains(t, body, `"name":"default"`)
+	assert.NotContains(t, body, `"url"`)
+}
+
+func TestCreateNetworkOnUnknownController(t *testing.T) {
+	contract := newContractApp(t, false)
+
+	status, body := contract.call(t, http.MethodPost, "/api/networks?controller=missing", `{"name":"nowhere"}`)
+	assert.Equal(t, fiber.StatusNotFound, status, body)
+	assert.Contains(t, body, `"errorCode":"controller.not_found"`)
+}
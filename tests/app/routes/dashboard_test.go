@@ -0,0 +1,71 @@
+package routes
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/GT-610/tairitsu/internal/app/models"
+	"github.com/GT-610/tairitsu/internal/app/services"
+	"github.com/gofiber/fiber/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func getDashboard(t *testing.T, contract *contractApp) services.DashboardSummary {
+	t.Helper()
+
+	status, body := contract.call(t, http.MethodGet, "/api/dashboard", "")
+	require.Equal(t, fiber.StatusOK, status, body)
+	var summary services.DashboardSummary
+	require.NoError(t, json.Unmarshal([]byte(body), &summary))
+	return summary
+}
+
+func TestDashboardShowsAdministratorsEverything(t *testing.T) {
+	contract := newContractApp(t, false)
+	contract.controller.AddMember(contract.networkID, "b2b2b2b2b2", map[string]any{"authorized": false})
+	status, body := contract.call(t, http.MethodPut, "/api/networks/"+contract.networkID+"/metadata", `{"description":"dashboard"}`)
+	require.Equal(t, fiber.StatusOK, status, body)
+
+	summary := getDashboard(t, contract)
+	require.NotNil(t, summary.Networks)
+	assert.Equal(t, services.DashboardNetworks{Count: 1, Members: 2, OnlineMembers: 1, UnauthorizedMembers: 1}, *summary.Networks)
+	require.NotNil(t, summary.Users)
+	assert.Equal(t, 1, summary.Users.Count)
+	require.NotNil(t, summary.Checklist)
+	assert.Equal(t, 3, summary.Checklist.Outstanding, "a network exists; transport, backups and a second admin are outstanding")
+	require.NotNil(t, summary.Controller)
+	assert.True(t, summary.Controller.Online)
+	assert.NotEmpty(t, summary.RecentAudit)
+	assert.Empty(t, summary.Unavailable)
+}
+
+func TestDashboardLimitsRegularUsersToTheirNetworks(t *testing.T) {
+	contract := newContractApp(t, false)
+	user, err := contract.dependencies.Services.User.Register(&models.RegisterRequest{Username: "dashboard-user", Password: contractPassword}, "user")
+	require.NoError(t, err)
+	contract.token = contract.issueToken(t, user)
+
+	summary := getDashboard(t, contract)
+	require.NotNil(t, summary.Networks)
+	assert.Equal(t, 0, summary.Networks.Count)
+	assert.Nil(t, summary.Users)
+	assert.Nil(t, summary.Checklist)
+	assert.Empty(t, summary.RecentAudit)
+}
+
+func TestDashboardDegradesWhenTheControllerIsDown(t *testing.T) {
+	contract := newContractApp(t, false)
+	require.NoError(t, contract.controller.Close())
+
+	summary := getDashboard(t, contract)
+	require.NotNil(t, summary.Networks)
+	assert.Equal(t, 1, summary.Networks.Count)
+	assert.Equal(t, 1, summary.Networks.UnreachableNetworks)
+	assert.Equal(t, 0, summary.Networks.Members)
+	require.NotNil(t, summary.Users)
+	if summary.Controller != nil {
+		assert.False(t, summary.Controller.Online)
+	}
+}
@@ -0,0 +1,154 @@
+package routes
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/GT-610/tairitsu/internal/app/models"
+	"github.com/GT-610/tairitsu/internal/app/services"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeviceClaimsLetUsersManageTheirOwnNodes(t *testing.T) {
+	contract := newContractApp(t, false)
+	adminToken := contract.token
+	const phoneID = "b2b2b2b2b2"
+
+	alice, err := contract.dependencies.Services.User.Register(&models.RegisterRequest{Username: "alice", Password: contractPassword}, "user")
+	require.NoError(t, err)
+	bob, err := contract.dependencies.Services.User.Register(&models.RegisterRequest{Username: "bob", Password: contractPassword}, "user")
+	require.NoError(t, err)
+	aliceToken := contract.issueToken(t, alice)
+	bobToken := contract.issueToken(t, bob)
+
+	contract.token = aliceToken
+	status, raw := contract.call(t, http.MethodPost, "/api/devices/claim", `{"nodeAddress": "A1A1A1A1A1"}`)
+	require.Equal(t, http.StatusBadRequest, status, raw)
+	assert.Contains(t, raw, `"field":"nodeAddress"`)
+
+	// A claim nobody verified yet shows no networks and grants nothing
+	status, raw = contract.call(t, http.MethodPost, "/api/devices/claim", `{"nodeAddress": "`+contractMemberID+`"}`)
+	require.Equal(t, http.StatusOK, status, raw)
+	var laptop services.Device
+	require.NoError(t, json.Unmarshal([]byte(raw), &laptop))
+	assert.False(t, laptop.Verified)
+	assert.Empty(t, laptop.Networks)
+	assert.True(t, strings.HasPrefix(laptop.ClaimCode, services.DeviceClaimCodePrefix))
+
+	status, raw = contract.call(t, http.MethodPut, "/api/devices/"+contractMemberID+"/name", `{"displayName": "Alice's laptop"}`)
+	assert.Equal(t, http.StatusForbidden, status)
+	assert.Contains(t, raw, `"errorCode":"device.claim_not_verified"`)
+
+	// Naming the member after the claim code verifies the claim
+	status, raw = contract.call(t, http.MethodPost, "/api/devices/claim", `{"nodeAddress": "`+phoneID+`"}`)
+	require.Equal(t, http.StatusOK, status, raw)
+	var phone services.Device
+	require.NoError(t, json.Unmarshal([]byte(raw), &phone))
+	contract.controller.AddMember(contract.networkID, phoneID, map[string]any{"name": phone.ClaimCode, "authorized": false})
+
+	status, raw = contract.call(t, http.MethodGet, "/api/devices", "")
+	require.Equal(t, http.StatusOK, status, raw)
+	var devices []services.Device
+	require.NoError(t, json.Unmarshal([]byte(raw), &devices))
+	require.Len(t, devices, 2)
+	assert.False(t, devices[0].Verified)
+	require.True(t, devices[1].Verified)
+	require.Len(t, devices[1].Networks, 1)
+	assert.Equal(t, contract.networkID, devices[1].Networks[0].NetworkID)
+	assert.False(t, devices[1].Networks[0].Authorized)
+
+	contract.token = bobToken
+	status, raw = contract.call(t, http.MethodPost, "/api/devices/claim", `{"nodeAddress": "`+phoneID+`"}`)
+	assert.Equal(t, http.StatusConflict, status)
+	assert.Contains(t, raw, `"errorCode":"device.claimed_by_other"`)
+	status, raw = contract.call(t, http.MethodPut, "/api/devices/"+phoneID+"/name", `{"displayName": "mine now"}`)
+	assert.Equal(t, http.StatusNotFound, status)
+	assert.Contains(t, raw, `"errorCode":"device.claim_not_found"`)
+	status, _ = contract.call(t, http.MethodGet, "/api/admin/devices/claims", "")
+	assert.Equal(t, http.StatusForbidden, status)
+
+	// Authorization requests land in the owner's approval queue once
+	contract.token = aliceToken
+	target := fmt.Sprintf("/api/devices/%s/networks/%s/authorization-request", phoneID, contract.networkID)
+	status, raw = contract.call(t, http.MethodPost, target, "")
+	require.Equal(t, http.StatusOK, status, raw)
+	var approval models.PendingApproval
+	require.NoError(t, json.Unmarshal([]byte(raw), &approval))
+	assert.Equal(t, phoneID, approval.MemberID)
+	status, raw = contract.call(t, http.MethodPost, target, "")
+	require.Equal(t, http.StatusOK, status, raw)
+	assert.Contains(t, raw, fmt.Sprintf(`"id":%d`, approval.ID))
+
+	contract.token = adminToken
+	status, raw = contract.call(t, http.MethodGet, "/api/approvals", "")
+	require.Equal(t, http.StatusOK, status, raw)
+	assert.Contains(t, raw, `"memberId":"`+phoneID+`"`)
+
+	// An administrator verifies the other claim
+	status, raw = contract.call(t, http.MethodGet, "/api/admin/devices/claims", "")
+	require.Equal(t, http.StatusOK, status, raw)
+	var claims []models.DeviceClaim
+	require.NoError(t, json.Unmarshal([]byte(raw), &claims))
+	require.Len(t, claims, 1)
+	assert.Equal(t, contractMemberID, claims[0].NodeAddress)
+	status, raw = contract.call(t, http.MethodPost, fmt.Sprintf("/api/admin/devices/claims/%d", claims[0].ID), `{"action": "approve"}`)
+	require.Equal(t, http.StatusOK, status, raw)
+	assert.Contains(t, raw, `"verified":true`)
+
+	contract.token = aliceToken
+	status, raw = contract.call(t, http.MethodPut, "/api/devices/"+contractMemberID+"/name", `{"displayName": "Alice's laptop"}`)
+	require.Equal(t, http.StatusOK, status, raw)
+	require.NoError(t, json.Unmarshal([]byte(raw), &laptop))
+	require.Len(t, laptop.Networks, 1)
+	assert.Equal(t, "Alice's laptop", laptop.Networks[0].DisplayName)
+	assert.True(t, laptop.Networks[0].Authorized)
+
+	status, raw = contract.call(t, http.MethodPost, fmt.Sprintf("/api/devices/%s/networks/%s/authorization-request", contractMemberID, contract.networkID), "")
+	assert.Equal(t, http.StatusConflict, status)
+	assert.Contains(t, raw, `"errorCode":"device.already_authorized"`)
+
+	status, raw = contract.call(t, http.MethodDelete, "/api/devices/"+contractMemberID, "")
+	require.Equal(t, http.StatusNoContent, status, raw)
+	status, raw = contract.call(t, http.MethodGet, "/api/devices", "")
+	require.Equal(t, http.StatusOK, status, raw)
+	require.NoError(t, json.Unmarshal([]byte(raw), &devices))
+	require.Len(t, devices, 1)
+	assert.Equal(t, phoneID, devices[0].NodeAddress)
+}
+
+func TestDeviceClaimCodeOnTheClaimantsOwnNetworkDoesNotVerify(t *testing.T) {
+	contract := newContractApp(t, false)
+	const strangerNodeID = "c3c3c3c3c3"
+
+	mallory, err := contract.dependencies.Services.User.Register(&models.RegisterRequest{Username: "mallory", Password: contractPassword}, "user")
+	require.NoError(t, err)
+	ownNetworkID := contract.controller.AddNetwork(map[string]any{"name": "mallory-lab"})
+	now := time.Now()
+	require.NoError(t, contract.dependencies.Database.CreateNetwork(&models.Network{ID: ownNetworkID, Name: "mallory-lab", OwnerID: mallory.ID, CreatedAt: now, UpdatedAt: now}))
+	contract.controller.AddMember(contract.networkID, strangerNodeID, map[string]any{"name": "someone else's node", "authorized": true})
+
+	contract.token = contract.issueToken(t, mallory)
+	status, raw := contract.call(t, http.MethodPost, "/api/devices/claim", `{"nodeAddress": "`+strangerNodeID+`"}`)
+	require.Equal(t, http.StatusOK, status, raw)
+	var claim services.Device
+	require.NoError(t, json.Unmarshal([]byte(raw), &claim))
+
+	// Anyone can add a node to their own network and name it, so that proves nothing
+	contract.controller.AddMember(ownNetworkID, strangerNodeID, map[string]any{"name": claim.ClaimCode})
+	status, raw = contract.call(t, http.MethodGet, "/api/devices", "")
+	require.Equal(t, http.StatusOK, status, raw)
+	var devices []services.Device
+	require.NoError(t, json.Unmarshal([]byte(raw), &devices))
+	require.Len(t, devices, 1)
+	assert.False(t, devices[0].Verified)
+	assert.Empty(t, devices[0].Networks)
+
+	status, raw = contract.call(t, http.MethodPut, "/api/devices/"+strangerNodeID+"/name", `{"displayName": "mine now"}`)
+	assert.Equal(t, http.StatusForbidden, status)
+	assert.Contains(t, raw, `"errorCode":"device.claim_not_verified"`)
+}
@@ -0,0 +1,125 @@
+package routes
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/GT-610/tairitsu/internal/app/apierror"
+	appmiddleware "github.com/GT-610/tairitsu/internal/app/middleware"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestErrorResponsesShareOneEnvelope(t *testing.T) {
+	contract := newContractApp(t, false)
+
+	tests := []struct {
+		name      string
+		method    string
+		target    string
+		body      string
+		anonymous bool
+		status    int
+		errorCode string
+		fields    []apierror.FieldError
+		detail    string
+	}{
+		{
+			name:      "missing token",
+			method:    http.MethodGet,
+			target:    "/api/networks",
+			anonymous: true,
+			status:    http.StatusUnauthorized,
+			errorCode: apierror.CodeAuthMissingToken,
+		},
+		{
+			name:      "unknown network",
+			method:    http.MethodGet,
+			target:    "/api/networks/ffffffffffffffff",
+			status:    http.StatusNotFound,
+			errorCode: apierror.CodeNetworkNotFound,
+		},
+		{
+			name:      "malformed network ID",
+			method:    http.MethodGet,
+			target:    "/api/networks/not-a-network-id",
+			status:    http.StatusBadRequest,
+			errorCode: apierror.CodeValidationFailed,
+			fields:    []apierror.FieldError{{Field: "networkId", Message: "network ID must be 16 lowercase hexadecimal characters"}},
+		},
+		{
+			name:      "wrong field type",
+			method:    http.MethodPost,
+			target:    "/api/networks",
+			body:      `{"name": 5}`,
+			status:    http.StatusBadRequest,
+			errorCode: apierror.CodeInvalidBody,
+			fields:    []apierror.FieldError{{Field: "name", Message: "must be a string"}},
+		},
+		{
+			name:      "malformed JSON",
+			method:    http.MethodPost,
+			target:    "/api/networks",
+			body:      `{"name":`,
+			status:    http.StatusBadRequest,
+			errorCode: apierror.CodeInvalidBody,
+			detail:    "request body is not valid JSON",
+		},
+		{
+			name:      "endpoint code kept for bad body",
+			method:    http.MethodPost,
+			target:    "/api/webhooks",
+			body:      `{"url": true}`,
+			status:    http.StatusBadRequest,
+			errorCode: apierror.CodeWebhookInvalidRequest,
+			fields:    []apierror.FieldError{{Field: "url", Message: "must be a string"}},
+		},
+		{
+			name:      "unknown API route",
+			method:    http.MethodGet,
+			target:    "/api/does-not-exist",
+			status:    http.StatusNotFound,
+			errorCode: "http.not_found",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var reader io.Reader
+			if tt.body != "" {
+				reader = bytes.NewBufferString(tt.body)
+			}
+			req := httptest.NewRequest(tt.method, tt.target, reader)
+			req.Header.Set(appmiddleware.RequestIDHeader, "envelope-"+tt.errorCode)
+			if tt.body != "" {
+				req.Header.Set("Content-Type", "application/json")
+			}
+			if !tt.anonymous {
+				req.Header.Set("Authorization", "Bearer "+contract.token)
+			}
+			resp, err := contract.app.Test(req)
+			require.NoError(t, err)
+			defer resp.Body.Close()
+
+			encoded, err := io.ReadAll(resp.Body)
+			require.NoError(t, err)
+			var body apierror.Response
+			require.NoError(t, json.Unmarshal(encoded, &body))
+			var raw map[string]any
+			require.NoError(t, json.Unmarshal(encoded, &raw))
+
+			assert.Equal(t, tt.status, resp.StatusCode)
+			assert.Equal(t, tt.status, body.Code)
+			assert.Equal(t, tt.errorCode, body.ErrorCode)
+			assert.NotEmpty(t, body.Message)
+			assert.Equal(t, "envelope-"+tt.errorCode, body.RequestID)
+			assert.Equal(t, tt.fields, body.Fields)
+			assert.Equal(t, tt.detail, body.Detail)
+			assert.NotContains(t, raw, "error", "the envelope has no legacy error field")
+		})
+	}
+}
@@ -0,0 +1,146 @@
+package routes
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/GT-610/tairitsu/internal/app/assembly"
+	"github.com/GT-610/tairitsu/internal/app/config"
+	"github.com/GT-610/tairitsu/internal/app/routes"
+	"github.com/gofiber/fiber/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newFrontendApp(t *testing.T, files map[string]string) *fiber.App {
+	t.Helper()
+
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	}
+	cfg := &config.Config{
+		Security: config.SecurityConfig{JWTSecret: "frontend-test-secret"},
+		Server:   config.ServerConfig{Frontend: config.FrontendConfig{Directory: dir}},
+	}
+	app := fiber.New()
+	routes.SetupRoutes(app, assembly.NewDependencies(cfg, nil, nil))
+	return app
+}
+
+func getFrontend(t *testing.T, app *fiber.App, target, acceptEncoding string) (*http.Response, string) {
+	t.Helper()
+
+	req := httptest.NewRequest(http.MethodGet, target, nil)
+	if acceptEncoding != "" {
+		req.Header.Set("Accept-Encoding", acceptEncoding)
+	}
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	return resp, string(body)
+}
+
+var frontendBuild = map[string]string{
+	"index.html":                  "<div id=root></div>",
+	"favicon.svg":                 "<svg/>",
+	"assets/index-4f2a9c1d.js":    "console.log('app')",
+	"assets/index-4f2a9c1d.js.br": "brotli",
+	"assets/index-4f2a9c1d.js.gz": "gzip",
+	"assets/index-7be1d0aa.css":   "body{}",
+}
+
+func TestFrontendServesIndexAndFallsBackForClientRoutes(t *testing.T) {
+	app := newFrontendApp(t, frontendBuild)
+
+	for _, target := range []string{"/", "/index.html", "/networks/8056c2e21c000001", "/settings/"} {
+		resp, body := getFrontend(t, app, target, "")
+		require.Equal(t, fiber.StatusOK, resp.StatusCode, target)
+		assert.Equal(t, "<div id=root></div>", body, target)
+		assert.Equal(t, "text/html; charset=utf-8", resp.Header.Get("Content-Type"), target)
+		assert.Equal(t, "no-cache", resp.Header.Get("Cache-Control"), target)
+	}
+
+	resp, body := getFrontend(t, app, "/favicon.svg", "")
+	require.Equal(t, fiber.StatusOK, resp.StatusCode)
+	assert.Equal(t, "<svg/>", body)
+	assert.Equal(t, "no-cache", resp.Header.Get("Cache-Control"))
+
+	resp, _ = getFrontend(t, app, "/assets/missing-00000000.js", "")
+	assert.Equal(t, fiber.StatusNotFound, resp.StatusCode)
+	// Paths are cleaned before the lookup, so traversal stays inside the build
+	resp, body = getFrontend(t, app, "/../../etc/passwd", "")
+	require.Equal(t, fiber.StatusOK, resp.StatusCode)
+	assert.Equal(t, "<div id=root></div>", body)
+}
+
+func TestFrontendServesHashedAssetsWithPrecompressedVariants(t *testing.T) {
+	app := newFrontendApp(t, frontendBuild)
+	const script = "/assets/index-4f2a9c1d.js"
+
+	for acceptEncoding, want := range map[string]struct{ body, encoding string }{
+		"gzip, deflate, br":  {"brotli", "br"},
+		"gzip":               {"gzip", "gzip"},
+		"br;q=0, gzip;q=0.5": {"gzip", "gzip"},
+		"identity":           {"console.log('app')", ""},
+		"":                   {"console.log('app')", ""},
+	} {
+		resp, body := getFrontend(t, app, script, acceptEncoding)
+		require.Equal(t, fiber.StatusOK, resp.StatusCode, acceptEncoding)
+		assert.Equal(t, want.body, body, acceptEncoding)
+		assert.Equal(t, want.encoding, resp.Header.Get("Content-Encoding"), acceptEncoding)
+		assert.Contains(t, resp.Header.Get("Content-Type"), "javascript", acceptEncoding)
+		assert.Equal(t, "public, max-age=31536000, immutable", resp.Header.Get("Cache-Control"), acceptEncoding)
+		assert.Contains(t, resp.Header.Get("Vary"), "Accept-Encoding", acceptEncoding)
+	}
+
+	resp, body := getFrontend(t, app, "/assets/index-7be1d0aa.css", "br, gzip")
+	require.Equal(t, fiber.StatusOK, resp.StatusCode)
+	assert.Equal(t, "body{}", body)
+	assert.Empty(t, resp.Header.Get("Content-Encoding"))
+}
+
+func TestFrontendNeverAnswersAPIPaths(t *testing.T) {
+	app := newFrontendApp(t, frontendBuild)
+
+	resp, body := getFrontend(t, app, "/api/health?verbose=false", "")
+	require.Equal(t, fiber.StatusOK, resp.StatusCode)
+	assert.JSONEq(t, `{"status":"ok"}`, body)
+
+	for _, target := range []string{"/api", "/api/", "/api/no-such-route", "/api/networks/x/no-such-route"} {
+		resp, body := getFrontend(t, app, target, "")
+		assert.Equal(t, fiber.StatusNotFound, resp.StatusCode, target)
+		assert.NotContains(t, body, "<div id=root>", target)
+		assert.Contains(t, resp.Header.Get("Content-Type"), "application/json", target)
+	}
+}
+
+func TestFrontendKeepsPlaceholderWithoutBuild(t *testing.T) {
+	for name, files := range map[string]map[string]string{
+		"not configured":        nil,
+		"directory lacks index": {"assets/app.js": "console.log('app')"},
+	} {
+		var app *fiber.App
+		if files == nil {
+			app = fiber.New()
+			routes.SetupRoutes(app, assembly.NewDependencies(&config.Config{Security: config.SecurityConfig{JWTSecret: "frontend-test-secret"}}, nil, nil))
+		} else {
+			app = newFrontendApp(t, files)
+		}
+
+		resp, body := getFrontend(t, app, "/", "")
+		require.Equal(t, fiber.StatusOK, resp.StatusCode, name)
+		assert.Contains(t, body, "This is Tairitsu backend service.", name)
+
+		resp, _ = getFrontend(t, app, "/networks", "")
+		assert.Equal(t, fiber.StatusNotFound, resp.StatusCode, name)
+	}
+}
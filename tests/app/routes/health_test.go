@@ -0,0 +1,77 @@
+package routes
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/GT-610/tairitsu/internal/app/assembly"
+	"github.com/GT-610/tairitsu/internal/app/config"
+	"github.com/GT-610/tairitsu/internal/app/database"
+	"github.com/GT-610/tairitsu/internal/app/routes"
+	"github.com/gofiber/fiber/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHealthRouteReturns503WhenDatabaseDown(t *testing.T) {
+	db, err := database.NewDatabase(database.Config{Type: database.SQLite, Path: filepath.Join(t.TempDir(), "tairitsu.db")})
+	require.NoError(t, err)
+	require.NoError(t, db.Init())
+	require.NoError(t, db.Close())
+
+	app := fiber.New()
+	routes.SetupRoutes(app, assembly.NewDependencies(&config.Config{Security: config.SecurityConfig{JWTSecret: "test-secret"}}, db, nil))
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/api/health", nil))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, fiber.StatusServiceUnavailable, resp.StatusCode)
+
+	var report struct {
+		Status     string                    `json:"status"`
+		Components map[string]map[string]any `json:"components"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&report))
+	assert.Equal(t, "down", report.Status)
+	assert.Equal(t, "down", report.Components["database"]["status"])
+	assert.Equal(t, "not_configured", report.Components["zerotier"]["status"])
+
+	liveness, err := app.Test(httptest.NewRequest(http.MethodGet, "/api/health?verbose=false", nil))
+	require.NoError(t, err)
+	defer liveness.Body.Close()
+	assert.Equal(t, fiber.StatusOK, liveness.StatusCode)
+
+	var body map[string]any
+	require.NoError(t, json.NewDecoder(liveness.Body).Decode(&body))
+	assert.Equal(t, map[string]any{"status": "ok"}, body)
+}
+
+func TestReadyRouteRequiresABoundReachableDatabase(t *testing.T) {
+	ready := func(dependencies *assembly.Dependencies) int {
+		app := fiber.New()
+		routes.SetupRoutes(app, dependencies)
+		resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/api/ready", nil))
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		return resp.StatusCode
+	}
+	cfg := &config.Config{Security: config.SecurityConfig{JWTSecret: "test-secret"}}
+
+	db, err := database.NewDatabase(database.Config{Type: database.SQLite, Path: filepath.Join(t.TempDir(), "tairitsu.db")})
+	require.NoError(t, err)
+	require.NoError(t, db.Init())
+	assert.Equal(t, fiber.StatusOK, ready(assembly.NewDependencies(cfg, db, nil)))
+
+	require.NoError(t, db.Close())
+	assert.Equal(t, fiber.StatusServiceUnavailable, ready(assembly.NewDependencies(cfg, db, nil)), "a database that fails its ping is not ready")
+
+	assert.Equal(t, fiber.StatusServiceUnavailable, ready(assembly.NewDependencies(cfg, nil, nil)), "no database is not ready")
+
+	unreachable := assembly.NewDependencies(cfg, nil, nil)
+	unreachable.Services.Runtime.MarkDatabaseUnavailable(errors.New("connection refused"))
+	assert.Equal(t, fiber.StatusServiceUnavailable, ready(unreachable))
+}
@@ -0,0 +1,51 @@
+package routes
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/GT-610/tairitsu/internal/app/apierror"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMalformedIDsAreRejectedBeforeReachingTheController(t *testing.T) {
+	contract := newContractApp(t, false)
+	upperNetworkID := strings.ToUpper(contract.networkID)
+
+	tests := []struct {
+		name   string
+		method string
+		target string
+		body   string
+		field  string
+	}{
+		{"network traversal", http.MethodGet, "/api/networks/..%2F..%2Fstatus", "", "networkId"},
+		{"network with query characters", http.MethodDelete, "/api/networks/" + contract.networkID[:15] + "%3F", "", "networkId"},
+		{"uppercase network", http.MethodGet, "/api/networks/" + upperNetworkID, "", "networkId"},
+		{"uppercase network members", http.MethodGet, "/api/networks/" + upperNetworkID + "/members", "", "networkId"},
+		{"member traversal", http.MethodGet, "/api/networks/" + contract.networkID + "/members/..%2F..%2F..", "", "memberId"},
+		{"uppercase member", http.MethodPut, "/api/networks/" + contract.networkID + "/members/" + strings.ToUpper(contractMemberID), `{"authorized": false}`, "memberId"},
+		{"member with spaces", http.MethodDelete, "/api/networks/" + contract.networkID + "/members/%20" + contractMemberID[:9], "", "memberId"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, raw := contract.call(t, tt.method, tt.target, tt.body)
+			require.Equal(t, http.StatusBadRequest, status, raw)
+
+			var body apierror.Response
+			require.NoError(t, json.Unmarshal([]byte(raw), &body))
+			assert.Equal(t, apierror.CodeValidationFailed, body.ErrorCode)
+			require.Len(t, body.Fields, 1)
+			assert.Equal(t, tt.field, body.Fields[0].Field)
+		})
+	}
+
+	// The member is untouched, so none of the requests reached the controller
+	status, raw := contract.call(t, http.MethodGet, "/api/networks/"+contract.networkID+"/members/"+contractMemberID, "")
+	require.Equal(t, http.StatusOK, status, raw)
+	assert.Contains(t, raw, `"authorized":true`)
+}
@@ -0,0 +1,292 @@
+package routes
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/GT-610/tairitsu/internal/app/apptest"
+	"github.com/GT-610/tairitsu/internal/app/assembly"
+	"github.com/GT-610/tairitsu/internal/app/config"
+	"github.com/GT-610/tairitsu/internal/app/database"
+	"github.com/GT-610/tairitsu/internal/app/middleware"
+	"github.com/GT-610/tairitsu/internal/app/models"
+	"github.com/GT-610/tairitsu/internal/app/paths"
+	"github.com/GT-610/tairitsu/internal/app/routes"
+	"github.com/GT-610/tairitsu/internal/app/services"
+	"github.com/GT-610/tairitsu/internal/zerotier"
+	"github.com/GT-610/tairitsu/internal/zerotier/ztmock"
+	"github.com/gofiber/fiber/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var updateContract = flag.Bool("update", false, "rewrite the JSON field name snapshot")
+
+const (
+	contractGoldenPath = "testdata/json_fields.golden.json"
+	contractMemberID   = "a1a1a1a1a1"
+	contractPassword   = "Contract-pass-123"
+)
+
+var camelCaseField = regexp.MustCompile(`^[a-z][a-zA-Z0-9]*$`)
+
+// controllerNativeFields are ZeroTier controller field names passed through unchanged
+var controllerNativeFields = map[string]bool{"6plane": true}
+
+type contractApp struct {
+	app          *fiber.App
+	dependencies *assembly.Dependencies
+	controller   *ztmock.Controller
+	networkID    string
+	token        string
+}
+
+// newContractApp serves one network with one member, owned by an administrator who is logged in.
+func newContractApp(t *testing.T, legacyFields bool) *contractApp {
+	t.Helper()
+	return newContractAppWith(t, legacyFields, nil)
+}
+
+// newContractAppWith is newContractApp with configure applied to the configuration first
+func newContractAppWith(t *testing.T, legacyFields bool, configure func(*config.Config)) *contractApp {
+	t.Helper()
+	// Handlers that change settings save config.json
+	t.Cleanup(paths.SetDataDir(t.TempDir()))
+
+	controller := ztmock.NewController(ztmock.DemoAddress)
+	networkID := controller.AddNetwork(map[string]any{"name": "contract"})
+	controller.AddMember(networkID, contractMemberID, map[string]any{"name": "laptop", "authorized": true, "online": true, "ipAssignments": []any{"10.0.0.2"}})
+	baseURL, err := controller.Start()
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = controller.Close()
+	})
+
+	db, err := database.NewDatabase(database.Config{Type: database.SQLite, Path: filepath.Join(t.TempDir(), "tairitsu.db")})
+	require.NoError(t, err)
+	require.NoError(t, db.Init())
+	t.Cleanup(func() {
+		_ = db.Close()
+	})
+
+	cfg := &config.Config{
+		Initialized: true,
+		Security:    config.SecurityConfig{JWTSecret: "contract-test-secret"},
+		Server:      config.ServerConfig{Port: 8080, LegacyJSONFields: legacyFields},
+	}
+	if configure != nil {
+		configure(cfg)
+	}
+	client := &zerotier.Client{BaseURL: baseURL, Token: controller.Token, HTTPClient: &http.Client{Timeout: 5 * time.Second}}
+	dependencies := assembly.NewDependencies(cfg, db, client)
+	admin, err := dependencies.Services.User.Register(&models.RegisterRequest{Username: "admin", Password: contractPassword}, "admin")
+	require.NoError(t, err)
+	now := time.Now()
+	require.NoError(t, db.CreateNetwork(&models.Network{ID: networkID, Name: "contract", OwnerID: admin.ID, CreatedAt: now, UpdatedAt: now}))
+
+	// Every test app shares the limiters and the same client address
+	middleware.DefaultRateLimiter.Reset()
+	middleware.AuthRateLimiter.Reset()
+	middleware.LoginRateLimiter.Reset()
+	middleware.LoginUsernameRateLimiter.Reset()
+	app := fiber.New()
+	routes.SetupRoutes(app, dependencies)
+	contract := &contractApp{app: app, dependencies: dependencies, controller: controller, networkID: networkID}
+
+	contract.token = contract.issueToken(t, admin)
+	return contract
+}
+
+// issueToken signs a session token the way login does, without spending the shared login rate limit
+func (a *contractApp) issueToken(t *testing.T, user *models.User) string {
+	t.Helper()
+
+	session, err := a.dependencies.Services.Session.CreateSession(services.SessionCreateInput{
+		UserID:    user.ID,
+		ExpiresAt: time.Now().Add(a.dependencies.Services.JWT.AccessExpiry()),
+	})
+	require.NoError(t, err)
+	token, err := a.dependencies.Services.JWT.GenerateToken(user, session.ID)
+	require.NoError(t, err)
+	return token
+}
+
+func (a *contractApp) call(t *testing.T, method, target, body string) (int, string) {
+	t.Helper()
+
+	var reader io.Reader
+	if body != "" {
+		reader = bytes.NewBufferString(body)
+	}
+	req := httptest.NewRequest(method, target, reader)
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if a.token != "" {
+		req.Header.Set("Authorization", "Bearer "+a.token)
+	}
+	// Logins hash with bcrypt, and controller calls add to it on a busy machine
+	resp, err := a.app.Test(req, apptest.SlowTestConfig())
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	raw, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	return resp.StatusCode, string(raw)
+}
+
+// fieldPaths lists every object key in a JSON document as a dotted path; array items add "[]"
+func fieldPaths(value any, prefix string, paths map[string]struct{}) {
+	switch typed := value.(type) {
+	case map[string]any:
+		for key, child := range typed {
+			path := key
+			if prefix != "" {
+				path = prefix + "." + key
+			}
+			paths[path] = struct{}{}
+			fieldPaths(child, path, paths)
+		}
+	case []any:
+		for _, child := range typed {
+			fieldPaths(child, prefix+"[]", paths)
+		}
+	}
+}
+
+func sortedFieldPaths(t *testing.T, body string) []string {
+	t.Helper()
+
+	var document any
+	require.NoError(t, json.Unmarshal([]byte(body), &document), body)
+	set := map[string]struct{}{}
+	fieldPaths(document, "", set)
+	paths := make([]string, 0, len(set))
+	for path := range set {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+func TestJSONFieldNamesMatchContractSnapshot(t *testing.T) {
+	contract := newContractApp(t, false)
+	memberPath := "/api/networks/" + contract.networkID + "/members/" + contractMemberID
+	contract.controller.AddMember(contract.networkID, "b000000009", map[string]any{"name": "pending", "authorized": false})
+	require.NoError(t, contract.dependencies.Services.MemberStatus.Poll())
+
+	endpoints := []struct {
+		name   string
+		method string
+		target string
+		body   string
+	}{
+		{"GET /api/system/status", http.MethodGet, "/api/system/status", ""},
+		{"GET /api/system/password-policy", http.MethodGet, "/api/system/password-policy", ""},
+		{"GET /api/system/settings", http.MethodGet, "/api/system/settings", ""},
+		{"GET /api/health", http.MethodGet, "/api/health", ""},
+		{"GET /api/profile", http.MethodGet, "/api/profile", ""},
+		{"GET /api/profile/sessions", http.MethodGet, "/api/profile/sessions", ""},
+		{"POST /api/tokens", http.MethodPost, "/api/tokens", `{"name":"ci","scopes":["read"]}`},
+		{"GET /api/tokens", http.MethodGet, "/api/tokens", ""},
+		{"GET /api/networks", http.MethodGet, "/api/networks", ""},
+		{"GET /api/networks/:id", http.MethodGet, "/api/networks/" + contract.networkID, ""},
+		{"GET /api/networks/:id/members", http.MethodGet, "/api/networks/" + contract.networkID + "/members", ""},
+		{"GET /api/networks/:id/privacy", http.MethodGet, "/api/networks/" + contract.networkID + "/privacy", ""},
+		{"GET /api/networks/:id/rules", http.MethodGet, "/api/networks/" + contract.networkID + "/rules", ""},
+		{"POST /api/networks/:id/tags", http.MethodPost, "/api/networks/" + contract.networkID + "/tags", `{"id":100,"name":"department","values":{"sales":1}}`},
+		{"GET /api/networks/:id/tags", http.MethodGet, "/api/networks/" + contract.networkID + "/tags", ""},
+		{"POST /api/networks/:id/capabilities", http.MethodPost, "/api/networks/" + contract.networkID + "/capabilities", `{"id":7,"name":"ssh"}`},
+		{"PUT /api/networks/:id/members/:memberId/tags", http.MethodPut, memberPath + "/tags", `{"tags":{"department":"sales"}}`},
+		{"GET /api/networks/:id/ipv6-prefixes", http.MethodGet, "/api/networks/" + contract.networkID + "/ipv6-prefixes", ""},
+		{"GET /api/networks/:id/viewers", http.MethodGet, "/api/networks/" + contract.networkID + "/viewers", ""},
+		{"GET /api/networks/:id/members/:memberId/history", http.MethodGet, memberPath + "/history", ""},
+		{"PATCH /api/networks/:id/members/:memberId/metadata", http.MethodPatch, memberPath + "/metadata", `{"displayName":"build","notes":"rack 4","tags":{"owner":"ops"}}`},
+		{"GET /api/approvals", http.MethodGet, "/api/approvals", ""},
+		{"POST /api/approvals/:id", http.MethodPost, "/api/approvals/1", `{"action":"approve"}`},
+		{"GET /api/users", http.MethodGet, "/api/users", ""},
+		{"GET /api/webhooks", http.MethodGet, "/api/webhooks", ""},
+		{"GET /api/admin/audit", http.MethodGet, "/api/admin/audit", ""},
+		{"GET /api/admin/audit/verify", http.MethodGet, "/api/admin/audit/verify", ""},
+		{"GET /api/admin/networks/importable", http.MethodGet, "/api/admin/networks/importable", ""},
+		{"GET /api/admin/checklist", http.MethodGet, "/api/admin/checklist", ""},
+		{"error envelope", http.MethodGet, "/api/networks/not-a-network-id", ""},
+	}
+
+	snapshot := map[string][]string{}
+	for _, endpoint := range endpoints {
+		status, body := contract.call(t, endpoint.method, endpoint.target, endpoint.body)
+		require.Less(t, status, fiber.StatusInternalServerError, "%s: %s", endpoint.name, body)
+		paths := sortedFieldPaths(t, body)
+		for _, path := range paths {
+			field := strings.TrimSuffix(path[strings.LastIndex(path, ".")+1:], "[]")
+			if controllerNativeFields[field] {
+				continue
+			}
+			assert.Regexp(t, camelCaseField, field, "%s: field %s is not camelCase", endpoint.name, path)
+		}
+		snapshot[endpoint.name] = paths
+	}
+
+	if *updateContract {
+		encoded, err := json.MarshalIndent(snapshot, "", "  ")
+		require.NoError(t, err)
+		require.NoError(t, os.WriteFile(contractGoldenPath, append(encoded, '\n'), 0o644))
+		return
+	}
+	raw, err := os.ReadFile(contractGoldenPath)
+	require.NoError(t, err, "run go test ./tests/app/routes -run TestJSONFieldNamesMatchContractSnapshot -update to create the snapshot")
+	var golden map[string][]string
+	require.NoError(t, json.Unmarshal(raw, &golden))
+	for name, paths := range snapshot {
+		assert.Equal(t, golden[name], paths, "field names of %s changed; update the snapshot only for intentional API changes", name)
+	}
+}
+
+func TestLegacyJSONFieldsEmitBothCasings(t *testing.T) {
+	contract := newContractApp(t, true)
+
+	_, body := contract.call(t, http.MethodGet, "/api/networks/not-a-network-id", "")
+	var envelope map[string]any
+	require.NoError(t, json.Unmarshal([]byte(body), &envelope))
+	assert.Equal(t, envelope["errorCode"], envelope["error_code"])
+	assert.NotEmpty(t, envelope["errorCode"])
+
+	_, body = contract.call(t, http.MethodGet, "/api/networks", "")
+	var networks []map[string]any
+	require.NoError(t, json.Unmarshal([]byte(body), &networks))
+	require.Len(t, networks, 1)
+	assert.Equal(t, networks[0]["ownerId"], networks[0]["owner_id"])
+	assert.Equal(t, networks[0]["memberCount"], networks[0]["member_count"])
+}
+
+func TestLegacyJSONFieldsAcceptSnakeCaseRequests(t *testing.T) {
+	contract := newContractApp(t, true)
+
+	status, body := contract.call(t, http.MethodPut, "/api/system/settings", `{"allow_public_registration":false}`)
+	require.Equal(t, fiber.StatusOK, status, body)
+	assert.Contains(t, body, `"allowPublicRegistration":false`)
+	assert.Contains(t, body, `"allow_public_registration":false`)
+
+	status, body = contract.call(t, http.MethodPut, "/api/system/settings", `{"allow_public_registration":false,"allowPublicRegistration":true}`)
+	require.Equal(t, fiber.StatusOK, status, body)
+	assert.Contains(t, body, `"allowPublicRegistration":true`)
+}
+
+func TestLegacyJSONFieldsDisabledKeepsCamelCaseOnly(t *testing.T) {
+	contract := newContractApp(t, false)
+
+	_, body := contract.call(t, http.MethodGet, "/api/networks/not-a-network-id", "")
+	assert.Contains(t, body, `"errorCode"`)
+	assert.NotContains(t, body, `"error_code"`)
+}
@@ -0,0 +1,36 @@
+package routes
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/GT-610/tairitsu/internal/app/models"
+	"github.com/gofiber/fiber/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRotateJWTSecretKeepsExistingSessionsSignedIn(t *testing.T) {
+	contract := newContractApp(t, false)
+	tokenBeforeRotation := contract.token
+
+	status, body := contract.call(t, http.MethodPost, "/api/system/rotate-jwt-secret", "")
+	require.Equal(t, fiber.StatusOK, status, body)
+	assert.Contains(t, body, `"messageCode":"auth.jwt_secret_rotated"`)
+	assert.Contains(t, body, `"previousSecretValidUntil"`)
+
+	cfg := contract.dependencies.Services.State.Config()
+	assert.NotEqual(t, "contract-test-secret", cfg.Security.JWTSecret)
+	require.Len(t, cfg.Security.PreviousJWTSecrets, 1)
+	assert.Equal(t, "contract-test-secret", cfg.Security.PreviousJWTSecrets[0].Secret)
+
+	status, body = contract.call(t, http.MethodGet, "/api/profile", "")
+	assert.Equal(t, fiber.StatusOK, status, body)
+
+	user, err := contract.dependencies.Services.User.Register(&models.RegisterRequest{Username: "after-rotation", Password: contractPassword}, "user")
+	require.NoError(t, err)
+	contract.token = contract.issueToken(t, user)
+	assert.NotEqual(t, tokenBeforeRotation, contract.token)
+	status, body = contract.call(t, http.MethodGet, "/api/profile", "")
+	assert.Equal(t, fiber.StatusOK, status, body)
+}
@@ -0,0 +1,31 @@
+package routes
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/GT-610/tairitsu/internal/app/logger"
+	"github.com/gofiber/fiber/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLogLevelEndpointChangesLevelAtRuntime(t *testing.T) {
+	contract := newContractApp(t, false)
+	previous := logger.Level()
+	t.Cleanup(func() { _ = logger.SetLevel(previous) })
+
+	status, body := contract.call(t, http.MethodPut, "/api/system/log-level", `{"level":"debug"}`)
+	require.Equal(t, fiber.StatusOK, status, body)
+	assert.JSONEq(t, `{"level":"debug"}`, body)
+
+	status, body = contract.call(t, http.MethodGet, "/api/system/log-level", "")
+	require.Equal(t, fiber.StatusOK, status, body)
+	assert.JSONEq(t, `{"level":"debug"}`, body)
+	assert.Equal(t, "debug", logger.Level())
+
+	status, body = contract.call(t, http.MethodPut, "/api/system/log-level", `{"level":"loud"}`)
+	assert.Equal(t, fiber.StatusBadRequest, status)
+	assert.Contains(t, body, "system.invalid_log_level")
+	assert.Equal(t, "debug", logger.Level())
+}
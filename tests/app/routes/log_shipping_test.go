@@ -0,0 +1,41 @@
+package routes
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/GT-610/tairitsu/internal/app/config"
+	"github.com/GT-610/tairitsu/internal/app/services"
+	"github.com/gofiber/fiber/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLogShippingStatusReportsDisabledShipping(t *testing.T) {
+	contract := newContractApp(t, false)
+
+	status, body := contract.call(t, http.MethodGet, "/api/system/log-shipping/status", "")
+	require.Equal(t, fiber.StatusOK, status, body)
+	assert.JSONEq(t, `{"enabled":false,"connected":false,"queueDepth":0,"queueCapacity":0,"shipped":0,"dropped":0}`, body)
+}
+
+// Audited requests are queued for shipping; the shipper is not started in tests, so they stay queued
+func TestLogShippingStatusCountsQueuedEvents(t *testing.T) {
+	contract := newContractAppWith(t, false, func(cfg *config.Config) {
+		cfg.LogShipping = config.LogShippingConfig{Target: "tcp://127.0.0.1:6514", QueueSize: 4}
+	})
+
+	status, body := contract.call(t, http.MethodPut, "/api/system/log-level", `{"level":"info"}`)
+	require.Equal(t, fiber.StatusOK, status, body)
+
+	status, body = contract.call(t, http.MethodGet, "/api/system/log-shipping/status", "")
+	require.Equal(t, fiber.StatusOK, status, body)
+	var shipping services.LogShippingStatus
+	require.NoError(t, json.Unmarshal([]byte(body), &shipping))
+	assert.True(t, shipping.Enabled)
+	assert.Equal(t, "tcp://127.0.0.1:6514", shipping.Target)
+	assert.Equal(t, config.LogShippingFormatRFC5424, shipping.Format)
+	assert.Equal(t, 4, shipping.QueueCapacity)
+	assert.Equal(t, 1, shipping.QueueDepth)
+}
@@ -0,0 +1,30 @@
+package routes
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDatabaseCompactionTriggeredFromJobsEndpoint(t *testing.T) {
+	contract := newContractApp(t, false)
+
+	status, body := contract.call(t, http.MethodPost, "/api/admin/maintenance/compact?force=true", "")
+	require.Equal(t, fiber.StatusAccepted, status, body)
+	assert.Contains(t, body, `"messageCode":"maintenance.compaction_started"`)
+
+	require.Eventually(t, func() bool {
+		status, body = contract.call(t, http.MethodGet, "/api/admin/jobs", "")
+		return status == fiber.StatusOK && strings.Contains(body, `"compacted":true`)
+	}, 5*time.Second, 20*time.Millisecond, body)
+	assert.Contains(t, body, `"databaseCompaction":{"supported":true,"running":false`)
+	assert.Contains(t, body, `"trigger":"manual"`)
+
+	status, body = contract.call(t, http.MethodPost, "/api/admin/maintenance/compact?force=maybe", "")
+	assert.Equal(t, fiber.StatusBadRequest, status, body)
+}
@@ -0,0 +1,65 @@
+package routes
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/GT-610/tairitsu/internal/app/models"
+	"github.com/gofiber/fiber/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemberRoutesReadTheNetworkAndMemberParams(t *testing.T) {
+	contract := newContractApp(t, false)
+	memberPath := "/api/networks/" + contract.networkID + "/members/" + contractMemberID
+
+	status, body := contract.call(t, http.MethodGet, memberPath, "")
+	require.Equal(t, fiber.StatusOK, status, body)
+	assert.Contains(t, body, `"id":"`+contractMemberID+`"`)
+
+	status, body = contract.call(t, http.MethodPut, memberPath, `{"name":"renamed"}`)
+	require.Equal(t, fiber.StatusOK, status, body)
+	assert.Contains(t, body, `"name":"renamed"`)
+
+	status, body = contract.call(t, http.MethodGet, "/api/networks/"+contract.networkID+"/members/b2b2b2b2b2", "")
+	assert.Equal(t, fiber.StatusNotFound, status)
+	assert.Contains(t, body, `"errorCode":"member.not_found"`)
+
+	status, _ = contract.call(t, http.MethodGet, "/api/networks/"+contract.networkID+"/members/not-a-member", "")
+	assert.Equal(t, fiber.StatusBadRequest, status)
+}
+
+func TestMemberRoutesRejectUsersWithoutAccess(t *testing.T) {
+	contract := newContractApp(t, false)
+	memberPath := "/api/networks/" + contract.networkID + "/members/" + contractMemberID
+	adminToken := contract.token
+
+	viewer, err := contract.dependencies.Services.User.Register(&models.RegisterRequest{Username: "viewer", Password: contractPassword}, "user")
+	require.NoError(t, err)
+	outsider, err := contract.dependencies.Services.User.Register(&models.RegisterRequest{Username: "outsider", Password: contractPassword}, "user")
+	require.NoError(t, err)
+	status, body := contract.call(t, http.MethodPost, "/api/networks/"+contract.networkID+"/viewers", `{"userId":"`+viewer.ID+`"}`)
+	require.Equal(t, fiber.StatusOK, status, body)
+
+	contract.token = contract.issueToken(t, outsider)
+	status, body = contract.call(t, http.MethodGet, memberPath, "")
+	assert.Equal(t, fiber.StatusForbidden, status)
+	assert.Contains(t, body, `"errorCode":"network.access_denied"`)
+
+	contract.token = contract.issueToken(t, viewer)
+	status, body = contract.call(t, http.MethodGet, memberPath, "")
+	assert.Equal(t, fiber.StatusOK, status, body)
+	status, _ = contract.call(t, http.MethodPut, memberPath, `{"authorized":false}`)
+	assert.Equal(t, fiber.StatusForbidden, status)
+
+	contract.token = ""
+	status, body = contract.call(t, http.MethodGet, memberPath, "")
+	assert.Equal(t, fiber.StatusUnauthorized, status)
+	assert.Contains(t, body, `"errorCode":"auth.missing_token"`)
+
+	contract.token = adminToken
+	status, body = contract.call(t, http.MethodGet, memberPath, "")
+	require.Equal(t, fiber.StatusOK, status)
+	assert.Contains(t, body, `"authorized":true`)
+}
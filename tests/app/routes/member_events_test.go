@@ -0,0 +1,109 @@
+package routes
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/GT-610/tairitsu/internal/app/models"
+	"github.com/gofiber/fiber/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemberEventStreamDeliversEventsWithQueryToken(t *testing.T) {
+	contract := newContractApp(t, false)
+	hub := contract.dependencies.Services.MemberEvents
+
+	type streamResult struct {
+		resp *http.Response
+		body string
+		err  error
+	}
+	results := make(chan streamResult, 1)
+	go func() {
+		req := httptest.NewRequest(http.MethodGet, "/api/networks/"+contract.networkID+"/events?access_token="+contract.token, nil)
+		resp, err := contract.app.Test(req, fiber.TestConfig{Timeout: 10 * time.Second})
+		if err != nil {
+			results <- streamResult{err: err}
+			return
+		}
+		defer resp.Body.Close()
+		raw, err := io.ReadAll(resp.Body)
+		results <- streamResult{resp: resp, body: string(raw), err: err}
+	}()
+
+	require.Eventually(t, func() bool {
+		return hub.Subscribers(contract.networkID) == 1
+	}, 5*time.Second, 10*time.Millisecond)
+	require.NoError(t, hub.Poll())
+	contract.controller.AddMember(contract.networkID, contractMemberID, map[string]any{"name": "laptop", "authorized": true, "online": false})
+	require.NoError(t, hub.Poll())
+	hub.Close()
+
+	result := <-results
+	require.NoError(t, result.err)
+	assert.Equal(t, fiber.StatusOK, result.resp.StatusCode)
+	assert.Equal(t, "text/event-stream", result.resp.Header.Get("Content-Type"))
+	assert.Contains(t, result.body, "event: member.offline\ndata: ")
+	assert.Contains(t, result.body, `"memberId":"`+contractMemberID+`"`)
+	assert.Contains(t, result.body, "event: closed\ndata: {\"errorCode\":\"events.stopped\"}\n\n")
+}
+
+func TestMemberEventStreamRejectsInaccessibleNetworks(t *testing.T) {
+	contract := newContractApp(t, false)
+
+	outsider, err := contract.dependencies.Services.User.Register(&models.RegisterRequest{Username: "outsider", Password: contractPassword}, "user")
+	require.NoError(t, err)
+	outsiderToken := contract.issueToken(t, outsider)
+	contract.token = ""
+
+	status, body := contract.call(t, http.MethodGet, "/api/networks/"+contract.networkID+"/events?access_token="+outsiderToken, "")
+	assert.Equal(t, fiber.StatusForbidden, status)
+	assert.Contains(t, body, `"errorCode":"network.access_denied"`)
+
+	status, body = contract.call(t, http.MethodGet, "/api/networks/"+contract.networkID+"/events", "")
+	assert.Equal(t, fiber.StatusUnauthorized, status)
+	assert.Contains(t, body, `"errorCode":"auth.missing_token"`)
+	assert.Zero(t, contract.dependencies.Services.MemberEvents.Subscribers(contract.networkID))
+}
+
+func TestMemberEventRefreshAndJobsView(t *testing.T) {
+	contract := newContractApp(t, false)
+	hub := contract.dependencies.Services.MemberEvents
+
+	status, body := contract.call(t, http.MethodGet, "/api/admin/jobs", "")
+	require.Equal(t, fiber.StatusOK, status, body)
+	assert.Contains(t, body, `"memberPolling":[]`)
+
+	users, err := contract.dependencies.Services.User.GetAllUsers()
+	require.NoError(t, err)
+	require.Len(t, users, 1)
+	subscription, err := hub.Subscribe(contract.networkID, users[0].ID)
+	require.NoError(t, err)
+	defer subscription.Close()
+	require.NoError(t, hub.Poll())
+
+	status, body = contract.call(t, http.MethodPost, "/api/networks/"+contract.networkID+"/events/refresh", "")
+	require.Equal(t, fiber.StatusAccepted, status, body)
+
+	status, body = contract.call(t, http.MethodGet, "/api/admin/jobs", "")
+	require.Equal(t, fiber.StatusOK, status, body)
+	var jobs struct {
+		MemberPolling []struct {
+			NetworkID       string  `json:"networkId"`
+			IntervalSeconds float64 `json:"intervalSeconds"`
+			Reason          string  `json:"reason"`
+			Subscribers     int     `json:"subscribers"`
+		} `json:"memberPolling"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(body), &jobs))
+	require.Len(t, jobs.MemberPolling, 1)
+	assert.Equal(t, contract.networkID, jobs.MemberPolling[0].NetworkID)
+	assert.Equal(t, "immediate", jobs.MemberPolling[0].Reason)
+	assert.Equal(t, 1, jobs.MemberPolling[0].Subscribers)
+	assert.Positive(t, jobs.MemberPolling[0].IntervalSeconds)
+}
@@ -0,0 +1,36 @@
+package routes
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemberUpdateSetsAnAuthorizationDeadline(t *testing.T) {
+	contract := newContractApp(t, false)
+	target := "/api/networks/" + contract.networkID + "/members/" + contractMemberID
+
+	status, raw := contract.call(t, http.MethodPut, target, `{"authorizedUntil": "next week"}`)
+	assert.Equal(t, http.StatusBadRequest, status)
+	assert.Contains(t, raw, `"field":"authorizedUntil"`)
+	status, raw = contract.call(t, http.MethodPut, target, `{"authorizedUntil": "`+time.Now().Add(-time.Hour).UTC().Format(time.RFC3339)+`"}`)
+	assert.Equal(t, http.StatusBadRequest, status)
+	assert.Contains(t, raw, `"field":"authorizedUntil"`)
+
+	deadline := time.Now().Add(7 * 24 * time.Hour).UTC().Format(time.RFC3339)
+	status, raw = contract.call(t, http.MethodPut, target, `{"authorized": true, "authorizedUntil": "`+deadline+`"}`)
+	require.Equal(t, http.StatusOK, status, raw)
+	assert.Contains(t, raw, `"authorizedUntil":"`+deadline+`"`)
+
+	status, raw = contract.call(t, http.MethodGet, "/api/networks/"+contract.networkID+"/members", "")
+	require.Equal(t, http.StatusOK, status, raw)
+	assert.Contains(t, raw, `"authorizationRemainingSeconds":`)
+
+	// An empty deadline clears it again
+	status, raw = contract.call(t, http.MethodPut, target, `{"authorizedUntil": ""}`)
+	require.Equal(t, http.StatusOK, status, raw)
+	assert.NotContains(t, raw, `"authorizedUntil"`)
+}
@@ -0,0 +1,54 @@
+package routes
+
+import (
+	"encoding/csv"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemberExportHonorsListFilters(t *testing.T) {
+	contract := newContractApp(t, true)
+	contract.controller.AddMember(contract.networkID, "b2b2b2b2b2", map[string]any{"name": "printer, floor 2", "authorized": false, "online": false})
+	contract.controller.AddMember(contract.networkID, "c3c3c3c3c3", map[string]any{"name": "phone", "authorized": true, "online": false})
+
+	req := httptest.NewRequest(http.MethodGet, "/api/networks/"+contract.networkID+"/members/export?bom=true", nil)
+	req.Header.Set("Authorization", "Bearer "+contract.token)
+	resp, err := contract.app.Test(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, fiber.StatusOK, resp.StatusCode)
+	assert.Equal(t, "text/csv; charset=utf-8", resp.Header.Get("Content-Type"))
+	assert.Regexp(t, `^attachment; filename="members-`+contract.networkID+`-\d{8}\.csv"$`, resp.Header.Get("Content-Disposition"))
+	raw, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(string(raw), "\ufeff"))
+	records, err := csv.NewReader(strings.NewReader(strings.TrimPrefix(string(raw), "\ufeff"))).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, records, 4)
+	assert.Equal(t, "printer, floor 2", records[2][1])
+
+	status, body := contract.call(t, http.MethodGet, "/api/networks/"+contract.networkID+"/members/export?format=json&authorized=true&online=false", "")
+	require.Equal(t, fiber.StatusOK, status, body)
+	assert.JSONEq(t, `[{"address":"c3c3c3c3c3","name":"phone","authorized":true,"online":false,"ipAssignments":[]}]`, body)
+
+	status, body = contract.call(t, http.MethodGet, "/api/networks/"+contract.networkID+"/members/export?format=json&q=LAPTOP", "")
+	require.Equal(t, fiber.StatusOK, status, body)
+	assert.Contains(t, body, `"address":"`+contractMemberID+`"`)
+	assert.NotContains(t, body, "phone")
+
+	status, _ = contract.call(t, http.MethodGet, "/api/networks/"+contract.networkID+"/members/export?format=xml", "")
+	assert.Equal(t, fiber.StatusBadRequest, status)
+	status, _ = contract.call(t, http.MethodGet, "/api/networks/"+contract.networkID+"/members/export?online=maybe", "")
+	assert.Equal(t, fiber.StatusBadRequest, status)
+
+	status, body = contract.call(t, http.MethodGet, "/api/networks/"+contract.networkID+"/members?online=false", "")
+	require.Equal(t, fiber.StatusOK, status, body)
+	assert.NotContains(t, body, `"id":"`+contractMemberID+`"`)
+}
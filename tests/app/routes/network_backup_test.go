@@ -0,0 +1,48 @@
+package routes
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/GT-610/tairitsu/internal/app/services"
+	"github.com/gofiber/fiber/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNetworkBackupAndRestore(t *testing.T) {
+	contract := newContractApp(t, false)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/networks/"+contract.networkID+"/backup", nil)
+	req.Header.Set("Authorization", "Bearer "+contract.token)
+	resp, err := contract.app.Test(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, fiber.StatusOK, resp.StatusCode)
+	assert.Regexp(t, `^attachment; filename="network-`+contract.networkID+`-\d{8}\.json"$`, resp.Header.Get("Content-Disposition"))
+	var backup services.NetworkBackup
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&backup))
+	require.Len(t, backup.Members, 1)
+	assert.Equal(t, contractMemberID, backup.Members[0].Address)
+
+	backup.Members = append(backup.Members, services.NetworkBackupMember{Address: "zz"})
+	document, err := json.Marshal(backup)
+	require.NoError(t, err)
+	status, body := contract.call(t, http.MethodPost, "/api/networks/restore", string(document))
+	require.Equal(t, fiber.StatusCreated, status, body)
+	var result services.NetworkRestoreResult
+	require.NoError(t, json.Unmarshal([]byte(body), &result))
+	assert.NotEqual(t, contract.networkID, result.Network.ID)
+	assert.Equal(t, 1, result.RestoredMembers)
+	require.Len(t, result.FailedMembers, 1)
+	assert.Equal(t, services.RestoreReasonInvalidAddress, result.FailedMembers[0].ReasonCode)
+
+	status, body = contract.call(t, http.MethodGet, "/api/networks/"+result.Network.ID+"/members/"+contractMemberID, "")
+	require.Equal(t, fiber.StatusOK, status, body)
+
+	status, body = contract.call(t, http.MethodPost, "/api/networks/restore", `{"version":2,"network":{"name":"x"}}`)
+	assert.Equal(t, fiber.StatusBadRequest, status)
+	assert.Contains(t, body, `"errorCode":"network.backup_invalid"`)
+}
@@ -0,0 +1,69 @@
+package routes
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/GT-610/tairitsu/internal/app/models"
+	"github.com/GT-610/tairitsu/internal/app/services"
+	"github.com/gofiber/fiber/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNetworkBatchProgressIsObservable(t *testing.T) {
+	contract := newContractApp(t, false)
+	request := `{"count":3,"namePattern":"lab-{index}","config":{"mtu":1400},"idempotencyKey":"semester"}`
+
+	var started services.OperationDetail
+	status, body := contract.call(t, http.MethodPost, "/api/networks/batch", request)
+	require.Equal(t, fiber.StatusAccepted, status, body)
+	require.NoError(t, json.Unmarshal([]byte(body), &started))
+	assert.Equal(t, models.OperationKindNetworkBatch, started.Kind)
+	assert.Equal(t, 3, started.Total)
+
+	var operation services.OperationDetail
+	require.Eventually(t, func() bool {
+		status, body := contract.call(t, http.MethodGet, "/api/operations/"+started.ID, "")
+		require.Equal(t, fiber.StatusOK, status, body)
+		require.NoError(t, json.Unmarshal([]byte(body), &operation))
+		return operation.Finished()
+	}, 5*time.Second, 20*time.Millisecond)
+	assert.Equal(t, models.OperationStatusSucceeded, operation.Status)
+	assert.Equal(t, 3, operation.Done)
+	require.Len(t, operation.Items, 3)
+	assert.Equal(t, "lab-3", operation.Items[2].Name)
+	assert.NotEmpty(t, operation.Items[2].NetworkID)
+
+	status, body = contract.call(t, http.MethodPost, "/api/networks/batch", request)
+	require.Equal(t, fiber.StatusAccepted, status, body)
+	assert.Contains(t, body, `"id":"`+started.ID+`"`)
+	status, body = contract.call(t, http.MethodGet, "/api/networks?summary=false", "")
+	require.Equal(t, fiber.StatusOK, status, body)
+	var networks []services.NetworkSummary
+	require.NoError(t, json.Unmarshal([]byte(body), &networks))
+	assert.Len(t, networks, 4, "the retry created nothing")
+
+	status, body = contract.call(t, http.MethodPost, "/api/networks/batch", `{"count":4,"namePattern":"lab-{index}","config":{"mtu":1400},"idempotencyKey":"semester"}`)
+	assert.Equal(t, fiber.StatusConflict, status, body)
+	assert.Contains(t, body, "operation.idempotency_key_reused")
+
+	status, body = contract.call(t, http.MethodPost, "/api/networks/batch", `{"count":3,"namePattern":"lab"}`)
+	assert.Equal(t, fiber.StatusBadRequest, status, body)
+	status, body = contract.call(t, http.MethodPost, "/api/networks/batch", `{"count":101,"namePattern":"lab-{index}"}`)
+	assert.Equal(t, fiber.StatusBadRequest, status, body)
+
+	status, body = contract.call(t, http.MethodGet, "/api/operations", "")
+	require.Equal(t, fiber.StatusOK, status, body)
+	assert.Contains(t, body, `"id":"`+started.ID+`"`)
+	assert.NotContains(t, body, `"items"`, "the list leaves out batch items")
+	status, body = contract.call(t, http.MethodDelete, "/api/operations/"+started.ID, "")
+	assert.Equal(t, fiber.StatusConflict, status, body)
+	assert.Contains(t, body, "operation.not_running")
+
+	status, body = contract.call(t, http.MethodGet, "/api/operations/missing", "")
+	assert.Equal(t, fiber.StatusNotFound, status, body)
+	assert.Contains(t, body, "operation.not_found")
+}
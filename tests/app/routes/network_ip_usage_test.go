@@ -0,0 +1,36 @@
+package routes
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/GT-610/tairitsu/internal/app/services"
+	"github.com/gofiber/fiber/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNetworkIPUsageReportsPoolsAndOutsideAddresses(t *testing.T) {
+	contract := newContractApp(t, false)
+	contract.controller.AddMember(contract.networkID, "b2b2b2b2b2", map[string]any{"authorized": true, "ipAssignments": []any{"10.0.0.4", "fd00::1"}})
+	contract.controller.AddMember(contract.networkID, "c3c3c3c3c3", map[string]any{"authorized": true, "ipAssignments": []any{"192.168.9.9"}})
+	networkPath := "/api/networks/" + contract.networkID
+
+	status, body := contract.call(t, http.MethodPut, networkPath, `{"ipAssignmentPools":[{"ipRangeStart":"10.0.0.1","ipRangeEnd":"10.0.0.4"},{"ipRangeStart":"fd00::","ipRangeEnd":"fd00::ffff:ffff:ffff:ffff"}]}`)
+	require.Equal(t, fiber.StatusOK, status, body)
+
+	status, body = contract.call(t, http.MethodGet, networkPath+"/ip-usage?next=2", "")
+	require.Equal(t, fiber.StatusOK, status, body)
+	var usage services.NetworkIPUsage
+	require.NoError(t, json.Unmarshal([]byte(body), &usage))
+	require.Len(t, usage.Pools, 2)
+	assert.Equal(t, services.IPPoolUsage{IPRangeStart: "10.0.0.1", IPRangeEnd: "10.0.0.4", Capacity: "4", Assigned: 2, Free: "2", NextFree: []string{"10.0.0.1", "10.0.0.3"}}, usage.Pools[0])
+	assert.Equal(t, "18446744073709551616", usage.Pools[1].Capacity)
+	assert.Equal(t, 1, usage.Pools[1].Assigned)
+	assert.Equal(t, []string{"fd00::", "fd00::2"}, usage.Pools[1].NextFree)
+	assert.Equal(t, []string{"192.168.9.9"}, usage.OutsidePools)
+
+	status, _ = contract.call(t, http.MethodGet, networkPath+"/ip-usage?next=1000", "")
+	assert.Equal(t, fiber.StatusBadRequest, status)
+}
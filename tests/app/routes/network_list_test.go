@@ -0,0 +1,31 @@
+package routes
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/GT-610/tairitsu/internal/app/services"
+	"github.com/gofiber/fiber/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNetworkListSummaryParameter(t *testing.T) {
+	contract := newContractApp(t, false)
+
+	var networks []services.NetworkSummary
+	status, body := contract.call(t, http.MethodGet, "/api/networks", "")
+	require.Equal(t, fiber.StatusOK, status, body)
+	require.NoError(t, json.Unmarshal([]byte(body), &networks))
+	require.Len(t, networks, 1)
+	require.NotNil(t, networks[0].MemberCounts)
+	assert.Equal(t, networks[0].MemberCount, networks[0].MemberCounts.Total)
+
+	status, body = contract.call(t, http.MethodGet, "/api/networks?summary=false", "")
+	require.Equal(t, fiber.StatusOK, status, body)
+	assert.Contains(t, body, `"memberCounts":null`)
+
+	status, body = contract.call(t, http.MethodGet, "/api/networks?summary=maybe", "")
+	assert.Equal(t, fiber.StatusBadRequest, status, body)
+}
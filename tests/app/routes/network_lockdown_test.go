@@ -0,0 +1,89 @@
+package routes
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"testing"
+
+	"github.com/GT-610/tairitsu/internal/app/models"
+	"github.com/GT-610/tairitsu/internal/app/services"
+	"github.com/gofiber/fiber/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNetworkLockdownDeauthorizesMembersAndRollsBack(t *testing.T) {
+	contract := newContractApp(t, false)
+	contract.controller.AddMember(contract.networkID, "b2b2b2b2b2", map[string]any{"name": "phone", "authorized": true})
+	contract.controller.AddMember(contract.networkID, "c3c3c3c3c3", map[string]any{"name": "router", "authorized": true})
+	contract.controller.AddMember(contract.networkID, "d4d4d4d4d4", map[string]any{"name": "pending", "authorized": false})
+	lockdownPath := "/api/networks/" + contract.networkID + "/lockdown"
+	memberPath := "/api/networks/" + contract.networkID + "/members/"
+
+	status, body := contract.call(t, http.MethodPost, lockdownPath+"/rollback", "")
+	assert.Equal(t, fiber.StatusNotFound, status)
+	assert.Contains(t, body, `"errorCode":"lockdown.not_found"`)
+
+	status, body = contract.call(t, http.MethodPost, lockdownPath, `{"except":["c3c3c3c3c3"]}`)
+	require.Equal(t, fiber.StatusCreated, status, body)
+	var result services.NetworkLockdownResult
+	require.NoError(t, json.Unmarshal([]byte(body), &result))
+	assert.Equal(t, []string{contractMemberID, "b2b2b2b2b2"}, result.Changed)
+	assert.Empty(t, result.Failures)
+	assert.True(t, result.Lockdown.Active)
+	assert.Equal(t, []string{"c3c3c3c3c3"}, result.Lockdown.Except)
+
+	for memberID, authorized := range map[string]bool{contractMemberID: false, "b2b2b2b2b2": false, "c3c3c3c3c3": true, "d4d4d4d4d4": false} {
+		status, body = contract.call(t, http.MethodGet, memberPath+memberID, "")
+		require.Equal(t, fiber.StatusOK, status, body)
+		assert.Contains(t, body, `"authorized":`+strconv.FormatBool(authorized), memberID)
+	}
+
+	status, body = contract.call(t, http.MethodPost, lockdownPath, "")
+	assert.Equal(t, fiber.StatusConflict, status)
+	assert.Contains(t, body, `"errorCode":"lockdown.active"`)
+
+	// A member deleted during the lockdown is not created again by the rollback
+	status, body = contract.call(t, http.MethodDelete, memberPath+"b2b2b2b2b2", "")
+	require.Equal(t, fiber.StatusOK, status, body)
+
+	status, body = contract.call(t, http.MethodPost, lockdownPath+"/rollback", "")
+	require.Equal(t, fiber.StatusOK, status, body)
+	result = services.NetworkLockdownResult{}
+	require.NoError(t, json.Unmarshal([]byte(body), &result))
+	assert.Equal(t, []string{contractMemberID}, result.Changed)
+	assert.False(t, result.Lockdown.Active)
+	assert.NotNil(t, result.Lockdown.RolledBackAt)
+
+	status, body = contract.call(t, http.MethodGet, memberPath+contractMemberID, "")
+	require.Equal(t, fiber.StatusOK, status, body)
+	assert.Contains(t, body, `"authorized":true`)
+	status, _ = contract.call(t, http.MethodGet, memberPath+"b2b2b2b2b2", "")
+	assert.Equal(t, fiber.StatusNotFound, status)
+	status, body = contract.call(t, http.MethodGet, memberPath+"d4d4d4d4d4", "")
+	require.Equal(t, fiber.StatusOK, status, body)
+	assert.Contains(t, body, `"authorized":false`)
+
+	status, body = contract.call(t, http.MethodGet, "/api/admin/audit", "")
+	require.Equal(t, fiber.StatusOK, status, body)
+	assert.Contains(t, body, lockdownPath+"/rollback")
+}
+
+func TestNetworkLockdownRequiresNetworkOwner(t *testing.T) {
+	contract := newContractApp(t, false)
+	lockdownPath := "/api/networks/" + contract.networkID + "/lockdown"
+
+	outsider, err := contract.dependencies.Services.User.Register(&models.RegisterRequest{Username: "outsider", Password: contractPassword}, "user")
+	require.NoError(t, err)
+	contract.token = contract.issueToken(t, outsider)
+
+	status, body := contract.call(t, http.MethodPost, lockdownPath, "")
+	assert.Equal(t, fiber.StatusForbidden, status)
+	assert.Contains(t, body, `"errorCode":"network.access_denied"`)
+	status, _ = contract.call(t, http.MethodPost, lockdownPath+"/rollback", "")
+	assert.Equal(t, fiber.StatusForbidden, status)
+
+	status, body = contract.call(t, http.MethodPost, lockdownPath, `{"except":["not-a-member"]}`)
+	assert.Equal(t, fiber.StatusBadRequest, status, body)
+}
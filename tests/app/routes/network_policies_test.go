@@ -0,0 +1,66 @@
+package routes
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/GT-610/tairitsu/internal/app/models"
+	"github.com/GT-610/tairitsu/internal/app/services"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNetworkPoliciesAndInactivityDryRun(t *testing.T) {
+	contract := newContractApp(t, false)
+	const staleID = "c3c3c3c3c3"
+	contract.controller.AddMember(contract.networkID, staleID, map[string]any{"authorized": true})
+	require.NoError(t, contract.dependencies.Database.CreateMemberStatusEvents([]*models.MemberStatusEvent{
+		{NetworkID: contract.networkID, MemberID: staleID, Online: false, ChangedAt: time.Now().Add(-10 * 24 * time.Hour)},
+	}))
+	target := "/api/networks/" + contract.networkID + "/policies"
+
+	status, raw := contract.call(t, http.MethodGet, target, "")
+	require.Equal(t, http.StatusOK, status, raw)
+	var policies services.NetworkPolicies
+	require.NoError(t, json.Unmarshal([]byte(raw), &policies))
+	assert.Equal(t, services.InactivityPolicy{Days: 0, Action: models.InactivityActionFlag}, policies.Inactivity)
+
+	status, raw = contract.call(t, http.MethodPut, target, `{"inactivity": {"days": 30, "action": "remove"}}`)
+	assert.Equal(t, http.StatusBadRequest, status)
+	assert.Contains(t, raw, `"errorCode":"network.invalid_policy"`)
+
+	status, raw = contract.call(t, http.MethodPut, target, `{"inactivity": {"days": 30, "action": "enforce"}}`)
+	require.Equal(t, http.StatusOK, status, raw)
+	assert.Contains(t, raw, `"action":"enforce"`)
+
+	// The saved 30 days catch nobody; previewing 7 days finds the member offline for 10
+	status, raw = contract.call(t, http.MethodGet, target+"/inactivity/dry-run", "")
+	require.Equal(t, http.StatusOK, status, raw)
+	var report services.InactivityReport
+	require.NoError(t, json.Unmarshal([]byte(raw), &report))
+	assert.True(t, report.DryRun)
+	assert.Empty(t, report.Inactive)
+
+	status, raw = contract.call(t, http.MethodGet, target+"/inactivity/dry-run?days=7", "")
+	require.Equal(t, http.StatusOK, status, raw)
+	require.NoError(t, json.Unmarshal([]byte(raw), &report))
+	require.Len(t, report.Inactive, 1)
+	assert.Equal(t, staleID, report.Inactive[0].MemberID)
+	assert.Empty(t, report.Deauthorized)
+
+	status, _ = contract.call(t, http.MethodGet, target+"/inactivity/dry-run?days=soon", "")
+	assert.Equal(t, http.StatusBadRequest, status)
+
+	// The dry run changed nothing
+	status, raw = contract.call(t, http.MethodGet, "/api/networks/"+contract.networkID+"/members/"+staleID, "")
+	require.Equal(t, http.StatusOK, status, raw)
+	assert.Contains(t, raw, `"authorized":true`)
+
+	member, err := contract.dependencies.Services.User.Register(&models.RegisterRequest{Username: "member", Password: contractPassword}, "user")
+	require.NoError(t, err)
+	contract.token = contract.issueToken(t, member)
+	status, _ = contract.call(t, http.MethodPut, target, `{"inactivity": {"days": 1}}`)
+	assert.Equal(t, http.StatusForbidden, status)
+}
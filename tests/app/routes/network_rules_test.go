@@ -0,0 +1,64 @@
+package routes
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/GT-610/tairitsu/internal/app/services"
+	"github.com/gofiber/fiber/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNetworkRulesCompileAndApply(t *testing.T) {
+	contract := newContractApp(t, false)
+	path := "/api/networks/" + contract.networkID + "/rules"
+
+	status, body := contract.call(t, http.MethodGet, path, "")
+	require.Equal(t, fiber.StatusOK, status, body)
+	var detail services.NetworkRulesDetail
+	require.NoError(t, json.Unmarshal([]byte(body), &detail))
+	assert.Empty(t, detail.Source)
+	require.Len(t, detail.Rules, 1)
+	assert.Equal(t, "ACTION_ACCEPT", detail.Rules[0].Type)
+
+	source := "drop not ethertype ipv4 and not ethertype arp;\ntag role id 1 enum 1 admin;\ncap ssh id 7 accept dport 22; ;\naccept;"
+	document, err := json.Marshal(map[string]string{"source": source})
+	require.NoError(t, err)
+	status, body = contract.call(t, http.MethodPut, path, string(document))
+	require.Equal(t, fiber.StatusOK, status, body)
+
+	status, body = contract.call(t, http.MethodGet, path, "")
+	require.Equal(t, fiber.StatusOK, status, body)
+	detail = services.NetworkRulesDetail{}
+	require.NoError(t, json.Unmarshal([]byte(body), &detail))
+	assert.Equal(t, source, detail.Source)
+	assert.NotEmpty(t, detail.UpdatedBy)
+	require.Len(t, detail.Rules, 4)
+	assert.Equal(t, "MATCH_ETHERTYPE", detail.Rules[0].Type)
+	assert.True(t, detail.Rules[0].Not)
+	assert.Equal(t, 0x0800, detail.Rules[0].EtherType)
+	assert.Equal(t, "ACTION_DROP", detail.Rules[2].Type)
+	require.Len(t, detail.Capabilities, 1)
+	assert.EqualValues(t, 7, detail.Capabilities[0].ID)
+	require.Len(t, detail.Tags, 1)
+	assert.EqualValues(t, 1, detail.Tags[0].ID)
+
+	status, body = contract.call(t, http.MethodPut, path, `{"source":"accept;\n  drop ipprotocol tcpp;"}`)
+	assert.Equal(t, fiber.StatusBadRequest, status)
+	var compileErr struct {
+		ErrorCode string `json:"errorCode"`
+		Line      int    `json:"line"`
+		Column    int    `json:"column"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(body), &compileErr))
+	assert.Equal(t, "network.rules_invalid", compileErr.ErrorCode)
+	assert.Equal(t, 2, compileErr.Line)
+	assert.Equal(t, 19, compileErr.Column)
+
+	// A rejected source leaves the saved rules alone
+	status, body = contract.call(t, http.MethodGet, path, "")
+	require.Equal(t, fiber.StatusOK, status, body)
+	assert.Contains(t, body, `"source":"drop not ethertype ipv4`)
+}
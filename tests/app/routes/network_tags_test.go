@@ -0,0 +1,69 @@
+package routes
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/GT-610/tairitsu/internal/zerotier"
+	"github.com/gofiber/fiber/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemberTagsAndCapabilitiesResolveNames(t *testing.T) {
+	contract := newContractApp(t, false)
+	networkPath := "/api/networks/" + contract.networkID
+	memberPath := networkPath + "/members/" + contractMemberID
+
+	status, body := contract.call(t, http.MethodPost, networkPath+"/tags", `{"id":100,"name":"department","values":{"sales":1,"engineering":2}}`)
+	require.Equal(t, fiber.StatusCreated, status, body)
+	status, body = contract.call(t, http.MethodPost, networkPath+"/tags", `{"id":100,"name":"floor"}`)
+	assert.Equal(t, fiber.StatusConflict, status, body)
+	status, body = contract.call(t, http.MethodPost, networkPath+"/capabilities", `{"id":7,"name":"ssh"}`)
+	require.Equal(t, fiber.StatusCreated, status, body)
+
+	status, body = contract.call(t, http.MethodPut, memberPath+"/tags", `{"tags":{"department":"engineering"}}`)
+	require.Equal(t, fiber.StatusOK, status, body)
+	status, body = contract.call(t, http.MethodPut, memberPath+"/capabilities", `{"capabilities":["ssh"]}`)
+	require.Equal(t, fiber.StatusOK, status, body)
+
+	status, body = contract.call(t, http.MethodGet, memberPath, "")
+	require.Equal(t, fiber.StatusOK, status, body)
+	var resolved struct {
+		Config               zerotier.MemberConfig         `json:"config"`
+		ResolvedTags         []zerotier.ResolvedTag        `json:"resolvedTags"`
+		ResolvedCapabilities []zerotier.ResolvedCapability `json:"resolvedCapabilities"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(body), &resolved))
+	assert.Equal(t, []zerotier.Tag{{ID: 100, Value: 2}}, resolved.Config.Tags)
+	assert.Equal(t, []zerotier.ResolvedTag{{ID: 100, Value: 2, Name: "department", ValueName: "engineering"}}, resolved.ResolvedTags)
+	assert.Equal(t, []int{7}, resolved.Config.Capabilities)
+	assert.Equal(t, []zerotier.ResolvedCapability{{ID: 7, Name: "ssh"}}, resolved.ResolvedCapabilities)
+
+	status, body = contract.call(t, http.MethodPut, memberPath+"/tags", `{"tags":{"team":1}}`)
+	assert.Equal(t, fiber.StatusBadRequest, status)
+	assert.Contains(t, body, `"errorCode":"member.tag_undefined"`)
+	status, body = contract.call(t, http.MethodPut, memberPath+"/tags", `{"tags":{"department":"marketing"}}`)
+	assert.Equal(t, fiber.StatusBadRequest, status)
+	assert.Contains(t, body, `"errorCode":"member.tag_value_invalid"`)
+
+	// Raw IDs sent through the generic member update are checked against the definitions too
+	status, body = contract.call(t, http.MethodPut, memberPath, `{"tags":[[5,1]]}`)
+	assert.Equal(t, fiber.StatusBadRequest, status)
+	assert.Contains(t, body, `"errorCode":"member.tag_undefined"`)
+	status, body = contract.call(t, http.MethodPut, memberPath, `{"capabilities":[8]}`)
+	assert.Equal(t, fiber.StatusBadRequest, status)
+	assert.Contains(t, body, `"errorCode":"member.tag_undefined"`)
+
+	status, body = contract.call(t, http.MethodPut, memberPath+"/tags", `{"tags":{}}`)
+	require.Equal(t, fiber.StatusOK, status, body)
+	var member zerotier.Member
+	require.NoError(t, json.Unmarshal([]byte(body), &member))
+	assert.Empty(t, member.Config.Tags)
+
+	status, body = contract.call(t, http.MethodDelete, networkPath+"/tags/100", "")
+	require.Equal(t, fiber.StatusOK, status, body)
+	status, _ = contract.call(t, http.MethodDelete, networkPath+"/tags/100", "")
+	assert.Equal(t, fiber.StatusNotFound, status)
+}
@@ -0,0 +1,316 @@
+package routes
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/GT-610/tairitsu/internal/app/apptest"
+	"github.com/GT-610/tairitsu/internal/app/config"
+	"github.com/GT-610/tairitsu/internal/app/models"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	oidcClientID     = "tairitsu"
+	oidcClientSecret = "provider-secret"
+)
+
+// oidcProvider is a minimal OpenID Connect provider that returns a prepared ID token per code
+type oidcProvider struct {
+	server *httptest.Server
+	key    *rsa.PrivateKey
+
+	mutex sync.Mutex
+	codes map[string]string
+}
+
+func newOIDCProvider(t *testing.T) *oidcProvider {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	provider := &oidcProvider{key: key, codes: map[string]string{}}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 provider.server.URL,
+			"authorization_endpoint": provider.server.URL + "/authorize",
+			"token_endpoint":         provider.server.URL + "/token",
+			"jwks_uri":               provider.server.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		encode := base64.RawURLEncoding.EncodeToString
+		_ = json.NewEncoder(w).Encode(map[string]any{"keys": []map[string]string{{
+			"kty": "RSA", "kid": "key-1", "use": "sig", "alg": "RS256",
+			"n": encode(key.N.Bytes()), "e": encode(big.NewInt(int64(key.E)).Bytes()),
+		}}})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		clientID, secret, ok := r.BasicAuth()
+		if !ok || clientID != oidcClientID || secret != oidcClientSecret || r.FormValue("grant_type") != "authorization_code" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		provider.mutex.Lock()
+		idToken, found := provider.codes[r.FormValue("code")]
+		delete(provider.codes, r.FormValue("code"))
+		provider.mutex.Unlock()
+		if !found {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"error":"invalid_grant"}`))
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]string{"access_token": "unused", "token_type": "Bearer", "id_token": idToken})
+	})
+	provider.server = httptest.NewServer(mux)
+	t.Cleanup(provider.server.Close)
+	return provider
+}
+
+func sign(t *testing.T, claims jwt.MapClaims, key *rsa.PrivateKey) string {
+	t.Helper()
+
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = "key-1"
+	signed, err := token.SignedString(key)
+	require.NoError(t, err)
+	return signed
+}
+
+// issue prepares code to return an ID token for subject with the standard claims filled in
+func (p *oidcProvider) issue(t *testing.T, code, nonce, subject string, extra jwt.MapClaims) {
+	p.issueWith(t, code, nonce, subject, extra, p.key)
+}
+
+// issueWith is issue with the ID token signed by key. A nil extra claim leaves that claim out.
+func (p *oidcProvider) issueWith(t *testing.T, code, nonce, subject string, extra jwt.MapClaims, key *rsa.PrivateKey) {
+	claims := jwt.MapClaims{
+		"iss":   p.server.URL,
+		"aud":   oidcClientID,
+		"sub":   subject,
+		"nonce": nonce,
+		"iat":   time.Now().Unix(),
+		"exp":   time.Now().Add(5 * time.Minute).Unix(),
+	}
+	for name, value := range extra {
+		if value == nil {
+			delete(claims, name)
+			continue
+		}
+		claims[name] = value
+	}
+	idToken := sign(t, claims, key)
+	p.mutex.Lock()
+	p.codes[code] = idToken
+	p.mutex.Unlock()
+}
+
+func newOIDCApp(t *testing.T, provider *oidcProvider, disablePasswordLogin bool) *contractApp {
+	return newContractAppWith(t, false, func(cfg *config.Config) {
+		cfg.OIDC = config.OIDCConfig{
+			Enabled:              true,
+			IssuerURL:            provider.server.URL,
+			ClientID:             oidcClientID,
+			ClientSecret:         oidcClientSecret,
+			RedirectURL:          "https://tairitsu.example/api/auth/oidc/callback",
+			RoleClaim:            "groups",
+			AdminRoles:           []string{"tairitsu-admins"},
+			DisablePasswordLogin: disablePasswordLogin,
+		}
+	})
+}
+
+// startOIDC follows /api/auth/oidc/login and returns the state cookie, state and nonce
+func startOIDC(t *testing.T, app *contractApp, provider *oidcProvider) (*http.Cookie, string, string) {
+	t.Helper()
+
+	resp, err := app.app.Test(httptest.NewRequest(http.MethodGet, "/api/auth/oidc/login", nil))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusFound, resp.StatusCode)
+
+	location, err := url.Parse(resp.Header.Get("Location"))
+	require.NoError(t, err)
+	assert.Equal(t, provider.server.URL+"/authorize", location.Scheme+"://"+location.Host+location.Path)
+	assert.Equal(t, oidcClientID, location.Query().Get("client_id"))
+	assert.Equal(t, "openid email profile", location.Query().Get("scope"))
+	require.Len(t, resp.Cookies(), 1)
+	return resp.Cookies()[0], location.Query().Get("state"), location.Query().Get("nonce")
+}
+
+// finishOIDC calls the callback and returns the values from the redirect's fragment
+func finishOIDC(t *testing.T, app *contractApp, cookie *http.Cookie, state, code string) url.Values {
+	t.Helper()
+
+	req := httptest.NewRequest(http.MethodGet, "/api/auth/oidc/callback?"+url.Values{"state": {state}, "code": {code}}.Encode(), nil)
+	if cookie != nil {
+		req.AddCookie(cookie)
+	}
+	resp, err := app.app.Test(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusFound, resp.StatusCode)
+
+	path, fragment, _ := strings.Cut(resp.Header.Get("Location"), "#")
+	assert.Equal(t, "/login", path)
+	values, err := url.ParseQuery(fragment)
+	require.NoError(t, err)
+	return values
+}
+
+func profileWith(t *testing.T, app *contractApp, token string) models.UserResponse {
+	t.Helper()
+
+	app.token = token
+	status, raw := app.call(t, http.MethodGet, "/api/profile", "")
+	require.Equal(t, http.StatusOK, status, raw)
+	var profile models.UserResponse
+	require.NoError(t, json.Unmarshal([]byte(raw), &profile))
+	return profile
+}
+
+func TestOIDCProvisionsUsersAndMapsRoles(t *testing.T) {
+	provider := newOIDCProvider(t)
+	app := newOIDCApp(t, provider, false)
+
+	cookie, state, nonce := startOIDC(t, app, provider)
+	provider.issue(t, "code-1", nonce, "subject-alice", jwt.MapClaims{
+		"email": "Alice@Example.com", "email_verified": true, "preferred_username": "alice", "groups": []string{"staff", "tairitsu-admins"},
+	})
+	result := finishOIDC(t, app, cookie, state, "code-1")
+	require.NotEmpty(t, result.Get("token"), result.Encode())
+
+	profile := profileWith(t, app, result.Get("token"))
+	assert.Equal(t, "alice", profile.Username)
+	assert.Equal(t, "alice@example.com", profile.Email)
+	assert.Equal(t, "admin", profile.Role)
+
+	// Signing in again finds the same account by subject; the role follows the provider
+	cookie, state, nonce = startOIDC(t, app, provider)
+	provider.issue(t, "code-2", nonce, "subject-alice", jwt.MapClaims{"preferred_username": "alice", "groups": "staff"})
+	result = finishOIDC(t, app, cookie, state, "code-2")
+	again := profileWith(t, app, result.Get("token"))
+	assert.Equal(t, profile.ID, again.ID)
+	assert.Equal(t, "user", again.Role)
+
+	// A second provider user asking for a taken username gets a suffix
+	cookie, state, nonce = startOIDC(t, app, provider)
+	provider.issue(t, "code-3", nonce, "subject-other-alice", jwt.MapClaims{"preferred_username": "alice"})
+	result = finishOIDC(t, app, cookie, state, "code-3")
+	assert.Equal(t, "alice-2", profileWith(t, app, result.Get("token")).Username)
+}
+
+func TestOIDCLinksExistingPasswordAccountByEmail(t *testing.T) {
+	provider := newOIDCProvider(t)
+	app := newOIDCApp(t, provider, false)
+	_, err := app.dependencies.Services.User.Register(&models.RegisterRequest{Username: "bob", Password: contractPassword, Email: "bob@example.com"}, "user")
+	require.NoError(t, err)
+
+	// An unverified address is not enough to take over the account
+	cookie, state, nonce := startOIDC(t, app, provider)
+	provider.issue(t, "code-1", nonce, "subject-bob", jwt.MapClaims{"email": "bob@example.com", "email_verified": false})
+	result := finishOIDC(t, app, cookie, state, "code-1")
+	assert.Equal(t, "auth.oidc_email_not_verified", result.Get("error"))
+	assert.Empty(t, result.Get("token"))
+
+	cookie, state, nonce = startOIDC(t, app, provider)
+	provider.issue(t, "code-2", nonce, "subject-bob", jwt.MapClaims{"email": "bob@example.com", "email_verified": true, "preferred_username": "robert"})
+	result = finishOIDC(t, app, cookie, state, "code-2")
+	profile := profileWith(t, app, result.Get("token"))
+	assert.Equal(t, "bob", profile.Username)
+
+	// The linked account keeps its password
+	req := httptest.NewRequest(http.MethodPost, "/api/auth/login", bytes.NewBufferString(`{"username":"bob","password":"`+contractPassword+`"}`))
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := app.app.Test(req, apptest.SlowTestConfig())
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+}
+
+func TestOIDCRejectsForgedCallbacks(t *testing.T) {
+	provider := newOIDCProvider(t)
+	app := newOIDCApp(t, provider, false)
+
+	// A callback without the state cookie did not start in this browser
+	_, state, nonce := startOIDC(t, app, provider)
+	provider.issue(t, "code-1", nonce, "subject-eve", nil)
+	assert.Equal(t, "auth.oidc_invalid_state", finishOIDC(t, app, nil, state, "code-1").Get("error"))
+
+	cookie, _, nonce := startOIDC(t, app, provider)
+	provider.issue(t, "code-2", nonce, "subject-eve", nil)
+	assert.Equal(t, "auth.oidc_invalid_state", finishOIDC(t, app, cookie, "other-state", "code-2").Get("error"))
+
+	// The ID token must carry the nonce of this sign-in
+	cookie, state, _ = startOIDC(t, app, provider)
+	provider.issue(t, "code-3", "replayed-nonce", "subject-eve", nil)
+	assert.Equal(t, "auth.oidc_invalid_token", finishOIDC(t, app, cookie, state, "code-3").Get("error"))
+
+	// ...and be signed by the provider
+	forger, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	cookie, state, nonce = startOIDC(t, app, provider)
+	provider.issueWith(t, "code-4", nonce, "subject-eve", nil, forger)
+	assert.Equal(t, "auth.oidc_invalid_token", finishOIDC(t, app, cookie, state, "code-4").Get("error"))
+
+	// Codes the provider does not know are refused at the token endpoint
+	cookie, state, _ = startOIDC(t, app, provider)
+	assert.Equal(t, "auth.oidc_provider_error", finishOIDC(t, app, cookie, state, "code-5").Get("error"))
+
+	users, err := app.dependencies.Services.User.GetAllUsers()
+	require.NoError(t, err)
+	assert.Len(t, users, 1, "no forged callback may provision an account")
+}
+
+func TestOIDCRejectsIDTokensWithoutExpiry(t *testing.T) {
+	provider := newOIDCProvider(t)
+	app := newOIDCApp(t, provider, false)
+
+	cookie, state, nonce := startOIDC(t, app, provider)
+	provider.issue(t, "code-1", nonce, "subject-eve", jwt.MapClaims{"exp": nil})
+	assert.Equal(t, "auth.oidc_invalid_token", finishOIDC(t, app, cookie, state, "code-1").Get("error"))
+
+	users, err := app.dependencies.Services.User.GetAllUsers()
+	require.NoError(t, err)
+	assert.Len(t, users, 1)
+}
+
+func TestOIDCCanReplacePasswordLogin(t *testing.T) {
+	provider := newOIDCProvider(t)
+	app := newOIDCApp(t, provider, true)
+
+	status, raw := app.call(t, http.MethodGet, "/api/auth/methods", "")
+	require.Equal(t, http.StatusOK, status)
+	assert.JSONEq(t, `{"password": false, "oidc": true}`, raw)
+
+	status, raw = app.call(t, http.MethodPost, "/api/auth/login", `{"username":"admin","password":"`+contractPassword+`"}`)
+	assert.Equal(t, http.StatusForbidden, status)
+	assert.Contains(t, raw, `"errorCode":"auth.password_login_disabled"`)
+}
+
+func TestOIDCIsOffByDefault(t *testing.T) {
+	app := newContractApp(t, false)
+
+	status, raw := app.call(t, http.MethodGet, "/api/auth/methods", "")
+	require.Equal(t, http.StatusOK, status)
+	assert.JSONEq(t, `{"password": true, "oidc": false}`, raw)
+
+	status, raw = app.call(t, http.MethodGet, "/api/auth/oidc/login", "")
+	assert.Equal(t, http.StatusNotFound, status)
+	assert.Contains(t, raw, `"errorCode":"auth.oidc_disabled"`)
+}
@@ -0,0 +1,117 @@
+package routes
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/GT-610/tairitsu/internal/app/models"
+	"github.com/GT-610/tairitsu/internal/app/services"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOrganizationsScopeNetworksToTheirMembers(t *testing.T) {
+	contract := newContractApp(t, false)
+	adminToken := contract.token
+	otherNetworkID := contract.controller.AddNetwork(map[string]any{"name": "internal"})
+	now := time.Now()
+	require.NoError(t, contract.dependencies.Database.CreateNetwork(&models.Network{ID: otherNetworkID, Name: "internal", OwnerID: "nobody", CreatedAt: now, UpdatedAt: now}))
+
+	operator, err := contract.dependencies.Services.User.Register(&models.RegisterRequest{Username: "operator", Password: contractPassword}, "user")
+	require.NoError(t, err)
+	staff, err := contract.dependencies.Services.User.Register(&models.RegisterRequest{Username: "staff", Password: contractPassword}, "user")
+	require.NoError(t, err)
+	outsider, err := contract.dependencies.Services.User.Register(&models.RegisterRequest{Username: "outsider", Password: contractPassword}, "user")
+	require.NoError(t, err)
+
+	// Administrators see every network, all in the default organization at first
+	status, raw := contract.call(t, http.MethodGet, "/api/networks", "")
+	require.Equal(t, http.StatusOK, status, raw)
+	var networks []services.NetworkSummary
+	require.NoError(t, json.Unmarshal([]byte(raw), &networks))
+	require.Len(t, networks, 2)
+	assert.Equal(t, models.DefaultOrganizationID, networks[0].OrganizationID)
+
+	status, raw = contract.call(t, http.MethodPost, "/api/organizations", `{"name": "  "}`)
+	assert.Equal(t, http.StatusBadRequest, status)
+	assert.Contains(t, raw, `"errorCode":"organization.invalid"`)
+	status, raw = contract.call(t, http.MethodPost, "/api/organizations", `{"name": "Acme", "description": "Customer"}`)
+	require.Equal(t, http.StatusCreated, status, raw)
+	var acme models.Organization
+	require.NoError(t, json.Unmarshal([]byte(raw), &acme))
+	status, raw = contract.call(t, http.MethodPost, "/api/organizations", `{"name": "Acme"}`)
+	assert.Equal(t, http.StatusConflict, status)
+	assert.Contains(t, raw, `"errorCode":"organization.name_taken"`)
+
+	orgTarget := "/api/organizations/" + acme.ID
+	status, raw = contract.call(t, http.MethodPut, orgTarget+"/networks/"+contract.networkID, "")
+	require.Equal(t, http.StatusOK, status, raw)
+	assert.Contains(t, raw, `"organizationId":"`+acme.ID+`"`)
+	status, raw = contract.call(t, http.MethodPut, orgTarget+"/members/"+operator.ID, `{"role": "owner"}`)
+	assert.Equal(t, http.StatusBadRequest, status)
+	assert.Contains(t, raw, `"errorCode":"organization.invalid_role"`)
+	status, raw = contract.call(t, http.MethodPut, orgTarget+"/members/"+operator.ID, `{"role": "org_admin"}`)
+	require.Equal(t, http.StatusOK, status, raw)
+
+	// Organization administrators manage members and the organization's networks
+	contract.token = contract.issueToken(t, operator)
+	status, raw = contract.call(t, http.MethodPut, orgTarget+"/members/"+staff.ID, `{"role": "org_member"}`)
+	require.Equal(t, http.StatusOK, status, raw)
+	status, raw = contract.call(t, http.MethodPut, "/api/networks/"+contract.networkID+"/members/"+contractMemberID, `{"name": "renamed"}`)
+	require.Equal(t, http.StatusOK, status, raw)
+	status, _ = contract.call(t, http.MethodPost, "/api/organizations", `{"name": "Mine"}`)
+	assert.Equal(t, http.StatusForbidden, status)
+	status, raw = contract.call(t, http.MethodGet, "/api/organizations", "")
+	require.Equal(t, http.StatusOK, status, raw)
+	var organizations []services.OrganizationSummary
+	require.NoError(t, json.Unmarshal([]byte(raw), &organizations))
+	require.Len(t, organizations, 1)
+	assert.Equal(t, models.OrganizationRoleAdmin, organizations[0].Role)
+
+	// Organization members only see the organization's networks and cannot change them
+	contract.token = contract.issueToken(t, staff)
+	status, raw = contract.call(t, http.MethodGet, "/api/networks?org="+acme.ID, "")
+	require.Equal(t, http.StatusOK, status, raw)
+	require.NoError(t, json.Unmarshal([]byte(raw), &networks))
+	require.Len(t, networks, 1)
+	assert.Equal(t, contract.networkID, networks[0].ID)
+	status, raw = contract.call(t, http.MethodGet, "/api/networks/"+contract.networkID+"/members", "")
+	require.Equal(t, http.StatusOK, status, raw)
+	status, _ = contract.call(t, http.MethodPut, "/api/networks/"+contract.networkID+"/members/"+contractMemberID, `{"name": "mine"}`)
+	assert.Equal(t, http.StatusForbidden, status)
+	status, _ = contract.call(t, http.MethodGet, "/api/networks/"+otherNetworkID+"/members", "")
+	assert.Equal(t, http.StatusForbidden, status)
+	status, raw = contract.call(t, http.MethodGet, orgTarget+"/members", "")
+	require.Equal(t, http.StatusOK, status, raw)
+	assert.Contains(t, raw, `"username":"staff"`)
+	status, raw = contract.call(t, http.MethodDelete, orgTarget+"/members/"+operator.ID, "")
+	assert.Equal(t, http.StatusForbidden, status)
+	assert.Contains(t, raw, `"errorCode":"organization.access_denied"`)
+
+	contract.token = contract.issueToken(t, outsider)
+	status, raw = contract.call(t, http.MethodGet, "/api/networks", "")
+	require.Equal(t, http.StatusOK, status, raw)
+	assert.Equal(t, "[]", raw)
+	status, _ = contract.call(t, http.MethodGet, orgTarget+"/members", "")
+	assert.Equal(t, http.StatusForbidden, status)
+	status, raw = contract.call(t, http.MethodGet, "/api/networks?org=missing", "")
+	assert.Equal(t, http.StatusNotFound, status)
+	assert.Contains(t, raw, `"errorCode":"organization.not_found"`)
+
+	// Deleting an organization returns its networks to the default organization
+	contract.token = adminToken
+	status, raw = contract.call(t, http.MethodDelete, "/api/organizations/"+models.DefaultOrganizationID, "")
+	assert.Equal(t, http.StatusConflict, status)
+	assert.Contains(t, raw, `"errorCode":"organization.default_protected"`)
+	status, raw = contract.call(t, http.MethodDelete, orgTarget, "")
+	require.Equal(t, http.StatusNoContent, status, raw)
+	network, err := contract.dependencies.Database.GetNetworkByID(contract.networkID)
+	require.NoError(t, err)
+	assert.Equal(t, models.DefaultOrganizationID, network.OrganizationID)
+
+	contract.token = contract.issueToken(t, staff)
+	status, _ = contract.call(t, http.MethodGet, "/api/networks/"+contract.networkID+"/members", "")
+	assert.Equal(t, http.StatusForbidden, status)
+}
@@ -0,0 +1,42 @@
+package routes
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/GT-610/tairitsu/internal/app/models"
+	"github.com/gofiber/fiber/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPasswordResetTokenFlow(t *testing.T) {
+	contract := newContractApp(t, false)
+	user, err := contract.dependencies.Services.User.Register(&models.RegisterRequest{Username: "alice", Password: contractPassword}, "user")
+	require.NoError(t, err)
+
+	status, body := contract.call(t, http.MethodPost, "/api/users/"+user.ID+"/reset-token", "")
+	require.Equal(t, fiber.StatusCreated, status, body)
+	var issued struct {
+		Token string `json:"token"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(body), &issued))
+	require.NotEmpty(t, issued.Token)
+
+	contract.token = ""
+	status, body = contract.call(t, http.MethodPost, "/api/auth/reset-request", `{"username":"nobody"}`)
+	assert.Equal(t, fiber.StatusAccepted, status, body)
+
+	confirm := `{"token":"` + issued.Token + `","newPassword":"Recovered123"}`
+	status, body = contract.call(t, http.MethodPost, "/api/auth/reset-confirm", confirm)
+	require.Equal(t, fiber.StatusOK, status, body)
+	assert.Contains(t, body, `"messageCode":"auth.password_reset_completed"`)
+
+	status, body = contract.call(t, http.MethodPost, "/api/auth/reset-confirm", confirm)
+	assert.Equal(t, fiber.StatusBadRequest, status)
+	assert.Contains(t, body, `"errorCode":"user.reset_token_invalid"`)
+
+	_, err = contract.dependencies.Services.User.Login(&models.LoginRequest{Username: "alice", Password: "Recovered123"})
+	require.NoError(t, err)
+}
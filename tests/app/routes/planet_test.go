@@ -0,0 +1,151 @@
+package routes
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/GT-610/tairitsu/internal/app/models"
+	"github.com/GT-610/tairitsu/internal/mkworld"
+	"github.com/gofiber/fiber/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const moonRootIdentity = "f76fd3000b:0:542c89e34a369c2281ed940d05beeffdbaa66930f17b875e9172e43d0ba30b6a39708507f4d64e66cde4a1040d2a995d01209d685ca6c4adb4a5c880af1e9715"
+
+func TestGenerateMoonReturnsEncodedMoon(t *testing.T) {
+	contract := newContractApp(t, false)
+
+	status, body := contract.call(t, http.MethodPost, "/api/admin/planet/moon", `{"rootNodes":[{"identityPublic":"`+moonRootIdentity+`","endpoints":["203.0.113.1/9993"]}]}`)
+	require.Equal(t, fiber.StatusOK, status, body)
+	var moon struct {
+		MoonData []byte `json:"moonData"`
+		MoonID   string `json:"moonId"`
+		FileName string `json:"fileName"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(body), &moon))
+	assert.Equal(t, "000000f76fd3000b", moon.MoonID)
+	assert.Equal(t, "000000f76fd3000b.moon", moon.FileName)
+	require.NotEmpty(t, moon.MoonData)
+	assert.Equal(t, byte(mkworld.ZT_WORLD_TYPE_MOON), moon.MoonData[0])
+
+	status, body = contract.call(t, http.MethodPost, "/api/admin/planet/moon", `{"rootNodes":[{"identityPublic":"`+moonRootIdentity+`"}]}`)
+	assert.Equal(t, fiber.StatusBadRequest, status, body)
+}
+
+func TestInspectWorldDecodesUploadsAndReportsOffsets(t *testing.T) {
+	contract := newContractApp(t, false)
+	moon, err := mkworld.GenerateMoon(&mkworld.MoonOptions{
+		RootNodes: []mkworld.RootNodeConfig{{IdentityPublic: moonRootIdentity, Endpoints: []string{"203.0.113.1/9993"}}},
+	})
+	require.NoError(t, err)
+
+	var form bytes.Buffer
+	writer := multipart.NewWriter(&form)
+	part, err := writer.CreateFormFile("file", moon.FileName)
+	require.NoError(t, err)
+	_, err = part.Write(moon.MoonData)
+	require.NoError(t, err)
+	require.NoError(t, writer.Close())
+
+	req := httptest.NewRequest(http.MethodPost, "/api/admin/planet/inspect", &form)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	req.Header.Set("Authorization", "Bearer "+contract.token)
+	resp, err := contract.app.Test(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	raw, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	require.Equal(t, fiber.StatusOK, resp.StatusCode, string(raw))
+
+	var inspected struct {
+		Type      string `json:"type"`
+		ID        uint64 `json:"id"`
+		RootNodes []struct {
+			IdentityPublic string   `json:"identityPublic"`
+			Endpoints      []string `json:"endpoints"`
+		} `json:"rootNodes"`
+	}
+	require.NoError(t, json.Unmarshal(raw, &inspected))
+	assert.Equal(t, "moon", inspected.Type)
+	assert.Equal(t, moon.MoonID, inspected.ID)
+	require.Len(t, inspected.RootNodes, 1)
+	assert.Equal(t, moonRootIdentity, inspected.RootNodes[0].IdentityPublic)
+	assert.Equal(t, []string{"203.0.113.1/9993"}, inspected.RootNodes[0].Endpoints)
+
+	truncated := base64.StdEncoding.EncodeToString(moon.MoonData[:40])
+	status, body := contract.call(t, http.MethodPost, "/api/admin/planet/inspect", `{"planetData":"`+truncated+`"}`)
+	assert.Equal(t, fiber.StatusBadRequest, status, body)
+	assert.Contains(t, body, `"offset":17`)
+}
+
+func TestPlanetHistoryDownloadsEarlierGenerations(t *testing.T) {
+	contract := newContractApp(t, false)
+
+	status, body := contract.call(t, http.MethodPost, "/api/admin/planet/generate", `{"rootNodes":[{"identityPublic":"`+moonRootIdentity+`","endpoints":["203.0.113.1/9993"]}],"recommendValues":true,"downloadName":"planet.custom"}`)
+	require.Equal(t, fiber.StatusOK, status, body)
+	var generated struct {
+		PlanetData []byte `json:"planetData"`
+		HistoryID  uint64 `json:"historyId"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(body), &generated))
+	require.NotZero(t, generated.HistoryID)
+
+	status, body = contract.call(t, http.MethodGet, "/api/admin/planet/history", "")
+	require.Equal(t, fiber.StatusOK, status, body)
+	assert.Contains(t, body, `"downloadName":"planet.custom"`)
+	assert.NotContains(t, body, `"data"`)
+
+	download := fmt.Sprintf("/api/admin/planet/%d/download", generated.HistoryID)
+	req := httptest.NewRequest(http.MethodGet, download, nil)
+	req.Header.Set("Authorization", "Bearer "+contract.token)
+	resp, err := contract.app.Test(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	raw, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	require.Equal(t, fiber.StatusOK, resp.StatusCode)
+	assert.Equal(t, fiber.MIMEOctetStream, resp.Header.Get(fiber.HeaderContentType))
+	assert.Equal(t, `attachment; filename="planet.custom"`, resp.Header.Get(fiber.HeaderContentDisposition))
+	assert.Equal(t, generated.PlanetData, raw)
+
+	status, body = contract.call(t, http.MethodGet, download+"?format=cheader", "")
+	require.Equal(t, fiber.StatusOK, status, body)
+	assert.Contains(t, body, fmt.Sprintf("#define ZT_DEFAULT_WORLD_LENGTH %d", len(generated.PlanetData)))
+
+	status, body = contract.call(t, http.MethodGet, "/api/admin/planet/999/download", "")
+	assert.Equal(t, fiber.StatusNotFound, status, body)
+
+	user, err := contract.dependencies.Services.User.Register(&models.RegisterRequest{Username: "member", Password: contractPassword}, "user")
+	require.NoError(t, err)
+	contract.token = contract.issueToken(t, user)
+	status, _ = contract.call(t, http.MethodGet, "/api/admin/planet/history", "")
+	assert.Equal(t, fiber.StatusForbidden, status)
+}
+
+func TestValidateIdentityChecksAddressDerivation(t *testing.T) {
+	contract := newContractApp(t, false)
+
+	status, body := contract.call(t, http.MethodPost, "/api/admin/planet/validate-identity", `{"identityPublic":"`+moonRootIdentity+`"}`)
+	require.Equal(t, fiber.StatusOK, status, body)
+	assert.JSONEq(t, `{"valid":true,"address":"f76fd3000b"}`, body)
+
+	mistyped := "f76fd3000c" + moonRootIdentity[10:]
+	status, body = contract.call(t, http.MethodPost, "/api/admin/planet/validate-identity", `{"identityPublic":"`+mistyped+`"}`)
+	require.Equal(t, fiber.StatusOK, status, body)
+	assert.Contains(t, body, `"valid":false`)
+	assert.Contains(t, body, "belongs to address f76fd3000b")
+
+	status, body = contract.call(t, http.MethodPost, "/api/admin/planet/generate", `{"rootNodes":[{"identityPublic":"`+mistyped+`","endpoints":["203.0.113.1/9993"]}],"recommendValues":true}`)
+	assert.Equal(t, fiber.StatusBadRequest, status, body)
+
+	status, body = contract.call(t, http.MethodPost, "/api/admin/planet/generate", `{"rootNodes":[{"identityPublic":"`+mistyped+`","endpoints":["203.0.113.1/9993"]}],"recommendValues":true,"skipValidation":true}`)
+	assert.Equal(t, fiber.StatusOK, status, body)
+}
@@ -0,0 +1,33 @@
+package routes
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/GT-610/tairitsu/internal/app/models"
+	"github.com/gofiber/fiber/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProfileEmailUpdateReportsConflicts(t *testing.T) {
+	contract := newContractApp(t, false)
+	_, err := contract.dependencies.Services.User.Register(&models.RegisterRequest{Username: "taken", Password: contractPassword, Email: "taken@example.com"}, "user")
+	require.NoError(t, err)
+
+	status, body := contract.call(t, http.MethodPut, "/api/profile", `{"email":"Admin@Example.com"}`)
+	require.Equal(t, fiber.StatusOK, status, body)
+	assert.Contains(t, body, `"email":"admin@example.com"`)
+
+	status, body = contract.call(t, http.MethodGet, "/api/profile", "")
+	require.Equal(t, fiber.StatusOK, status, body)
+	assert.Contains(t, body, `"email":"admin@example.com"`)
+
+	status, body = contract.call(t, http.MethodPut, "/api/profile", `{"email":"taken@example.com"}`)
+	assert.Equal(t, fiber.StatusConflict, status)
+	assert.Contains(t, body, `"errorCode":"user.email_exists"`)
+
+	status, body = contract.call(t, http.MethodPut, "/api/profile", `{"email":"nope"}`)
+	assert.Equal(t, fiber.StatusBadRequest, status)
+	assert.Contains(t, body, `"errorCode":"user.invalid_email"`)
+}
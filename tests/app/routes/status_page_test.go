@@ -0,0 +1,108 @@
+package routes
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/GT-610/tairitsu/internal/app/assembly"
+	"github.com/GT-610/tairitsu/internal/app/config"
+	"github.com/GT-610/tairitsu/internal/app/database"
+	"github.com/GT-610/tairitsu/internal/app/models"
+	"github.com/GT-610/tairitsu/internal/app/routes"
+	"github.com/GT-610/tairitsu/internal/app/services"
+	"github.com/GT-610/tairitsu/internal/zerotier"
+	"github.com/GT-610/tairitsu/internal/zerotier/ztmock"
+	"github.com/gofiber/fiber/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newStatusPageApp serves a published and an unpublished network, each with identifiable members.
+func newStatusPageApp(t *testing.T, enabled bool) *fiber.App {
+	t.Helper()
+
+	controller := ztmock.NewController(ztmock.DemoAddress)
+	publishedID := controller.AddNetwork(map[string]any{"name": "public-net"})
+	privateID := controller.AddNetwork(map[string]any{"name": "secret-net"})
+	controller.AddMember(publishedID, "a1a1a1a1a1", map[string]any{"name": "laptop-of-alice", "authorized": true, "online": true, "physicalAddress": "198.51.100.7/9993"})
+	controller.AddMember(publishedID, "b2b2b2b2b2", map[string]any{"name": "desktop-of-bob", "authorized": true, "online": false})
+	controller.AddMember(privateID, "c3c3c3c3c3", map[string]any{"name": "hidden-node", "authorized": true, "online": true, "physicalAddress": "198.51.100.8/9993"})
+	baseURL, err := controller.Start()
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = controller.Close()
+	})
+
+	db, err := database.NewDatabase(database.Config{Type: database.SQLite, Path: filepath.Join(t.TempDir(), "tairitsu.db")})
+	require.NoError(t, err)
+	require.NoError(t, db.Init())
+	t.Cleanup(func() {
+		_ = db.Close()
+	})
+	now := time.Now()
+	require.NoError(t, db.CreateNetwork(&models.Network{ID: publishedID, Name: "public-net", OwnerID: "owner-1", CreatedAt: now, UpdatedAt: now}))
+	require.NoError(t, db.CreateNetwork(&models.Network{ID: privateID, Name: "secret-net", OwnerID: "owner-1", CreatedAt: now, UpdatedAt: now}))
+
+	cfg := &config.Config{
+		Initialized: true,
+		Security:    config.SecurityConfig{JWTSecret: "test-secret"},
+		StatusPage:  config.StatusPageConfig{Enabled: enabled, Title: "Team Status", LogoText: "ACME"},
+	}
+	client := &zerotier.Client{BaseURL: baseURL, Token: controller.Token, HTTPClient: &http.Client{Timeout: 5 * time.Second}}
+	dependencies := assembly.NewDependencies(cfg, db, client)
+	require.NoError(t, dependencies.Services.StatusPage.SetNetworkPublished(publishedID, true))
+
+	app := fiber.New()
+	routes.SetupRoutes(app, dependencies)
+	return app
+}
+
+func getStatusPage(t *testing.T, app *fiber.App, target string) (int, string, string) {
+	t.Helper()
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodGet, target, nil))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	return resp.StatusCode, resp.Header.Get(fiber.HeaderCacheControl), string(body)
+}
+
+func TestStatusPageListsOnlyPublishedNetworks(t *testing.T) {
+	app := newStatusPageApp(t, true)
+
+	status, cacheControl, body := getStatusPage(t, app, "/status-page?format=json")
+	require.Equal(t, fiber.StatusOK, status)
+	assert.Equal(t, "public, max-age=30", cacheControl)
+
+	var page services.StatusPage
+	require.NoError(t, json.Unmarshal([]byte(body), &page))
+	assert.Equal(t, "Team Status", page.Title)
+	assert.Equal(t, "ACME", page.LogoText)
+	assert.Equal(t, services.HealthStatusOK, page.Status)
+	assert.Equal(t, []services.StatusPageNetwork{{Name: "public-net", OnlineNodes: 1, TotalNodes: 2}}, page.Networks)
+
+	status, _, html := getStatusPage(t, app, "/status-page")
+	require.Equal(t, fiber.StatusOK, status)
+	assert.Contains(t, html, "public-net")
+	assert.Contains(t, html, "1 / 2")
+
+	for _, variant := range []string{body, html} {
+		assert.NotContains(t, variant, "secret-net")
+		for _, leaked := range []string{"a1a1a1a1a1", "b2b2b2b2b2", "c3c3c3c3c3", "laptop-of-alice", "desktop-of-bob", "hidden-node", "198.51.100", "owner-1"} {
+			assert.NotContains(t, variant, leaked)
+		}
+	}
+}
+
+func TestStatusPageIsNotFoundWhenDisabled(t *testing.T) {
+	app := newStatusPageApp(t, false)
+
+	status, _, _ := getStatusPage(t, app, "/status-page")
+	assert.Equal(t, fiber.StatusNotFound, status)
+}
@@ -0,0 +1,53 @@
+package routes
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/GT-610/tairitsu/internal/app/services"
+	"github.com/gofiber/fiber/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSystemBackupCreateListAndRestore(t *testing.T) {
+	contract := newContractApp(t, false)
+
+	status, body := contract.call(t, http.MethodPost, "/api/system/backup", "")
+	require.Equal(t, fiber.StatusCreated, status, body)
+	var created struct {
+		Backup services.SystemBackupInfo `json:"backup"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(body), &created))
+	require.NotEmpty(t, created.Backup.Name)
+
+	status, body = contract.call(t, http.MethodGet, "/api/system/backups", "")
+	require.Equal(t, fiber.StatusOK, status, body)
+	var listed struct {
+		Backups []services.SystemBackupInfo `json:"backups"`
+		Job     services.SystemBackupStatus `json:"job"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(body), &listed))
+	require.Len(t, listed.Backups, 1)
+	assert.Equal(t, created.Backup.Name, listed.Backups[0].Name)
+	require.NotNil(t, listed.Job.LastRun)
+
+	restorePath := "/api/system/backups/" + created.Backup.Name + "/restore"
+	status, body = contract.call(t, http.MethodPost, restorePath, `{"confirm":"something-else"}`)
+	assert.Equal(t, fiber.StatusBadRequest, status)
+	assert.Contains(t, body, `"errorCode":"backup.confirmation_required"`)
+
+	status, body = contract.call(t, http.MethodPost, restorePath, `{"confirm":"`+created.Backup.Name+`"}`)
+	require.Equal(t, fiber.StatusOK, status, body)
+	var restored struct {
+		Report services.SystemRestoreReport `json:"report"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(body), &restored))
+	assert.Equal(t, 1, restored.Report.RestoredNetworks)
+	assert.Empty(t, restored.Report.FailedNetworks)
+
+	status, body = contract.call(t, http.MethodPost, "/api/system/backups/tairitsu-backup-20200101-000000.json.gz/restore", `{"confirm":"tairitsu-backup-20200101-000000.json.gz"}`)
+	assert.Equal(t, fiber.StatusNotFound, status)
+	assert.Contains(t, body, `"errorCode":"backup.not_found"`)
+}
@@ -0,0 +1,17 @@
+package routes
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTLSReloadWithoutCertificateFiles(t *testing.T) {
+	contract := newContractApp(t, false)
+
+	status, body := contract.call(t, http.MethodPost, "/api/system/tls/reload", "")
+	assert.Equal(t, fiber.StatusConflict, status)
+	assert.Contains(t, body, `"errorCode":"tls.not_configured"`)
+}
@@ -0,0 +1,103 @@
+package routes
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/GT-610/tairitsu/internal/app/models"
+	"github.com/gofiber/fiber/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAdminCreatedUserMustChangePasswordFirst(t *testing.T) {
+	contract := newContractApp(t, false)
+
+	status, body := contract.call(t, http.MethodPost, "/api/users", `{"username":"bob","password":"Welcome123","email":"bob@example.com"}`)
+	require.Equal(t, fiber.StatusCreated, status, body)
+	var created struct {
+		User              models.UserResponse `json:"user"`
+		TemporaryPassword string              `json:"temporaryPassword"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(body), &created))
+	assert.Equal(t, "Welcome123", created.TemporaryPassword)
+	assert.True(t, created.User.MustChangePassword)
+
+	user, err := contract.dependencies.Services.User.GetUserByID(created.User.ID)
+	require.NoError(t, err)
+	contract.token = contract.issueToken(t, user)
+
+	status, body = contract.call(t, http.MethodGet, "/api/networks", "")
+	assert.Equal(t, fiber.StatusForbidden, status)
+	assert.Contains(t, body, `"errorCode":"auth.password_change_required"`)
+
+	status, body = contract.call(t, http.MethodGet, "/api/profile", "")
+	require.Equal(t, fiber.StatusOK, status, body)
+	assert.Contains(t, body, `"mustChangePassword":true`)
+
+	status, body = contract.call(t, http.MethodPut, "/api/profile/password", `{"currentPassword":"Welcome123","newPassword":"Chosen1234","confirmPassword":"Chosen1234"}`)
+	require.Equal(t, fiber.StatusOK, status, body)
+
+	status, body = contract.call(t, http.MethodGet, "/api/networks", "")
+	assert.Equal(t, fiber.StatusOK, status, body)
+}
+
+func TestAdminDeleteUserKeepsLastAdmin(t *testing.T) {
+	contract := newContractApp(t, false)
+	users, err := contract.dependencies.Services.User.GetAllUsers()
+	require.NoError(t, err)
+	require.Len(t, users, 1)
+	admin := users[0]
+
+	otherAdmin, err := contract.dependencies.Services.User.Register(&models.RegisterRequest{Username: "second", Password: contractPassword}, "admin")
+	require.NoError(t, err)
+
+	status, body := contract.call(t, http.MethodDelete, "/api/users/"+admin.ID, "")
+	assert.Equal(t, fiber.StatusBadRequest, status)
+	assert.Contains(t, body, `"errorCode":"user.invalid_admin_operation"`)
+
+	status, body = contract.call(t, http.MethodDelete, "/api/users/"+otherAdmin.ID, "")
+	require.Equal(t, fiber.StatusOK, status, body)
+
+	remaining, err := contract.dependencies.Services.User.GetAllUsers()
+	require.NoError(t, err)
+	require.Len(t, remaining, 1)
+	assert.Equal(t, admin.ID, remaining[0].ID)
+}
+
+func TestAdminRevokesAnotherUsersSession(t *testing.T) {
+	contract := newContractApp(t, false)
+	adminToken := contract.token
+	bob, err := contract.dependencies.Services.User.Register(&models.RegisterRequest{Username: "bob", Password: contractPassword}, "user")
+	require.NoError(t, err)
+	bobToken := contract.issueToken(t, bob)
+
+	status, body := contract.call(t, http.MethodGet, "/api/users/"+bob.ID+"/sessions", "")
+	require.Equal(t, fiber.StatusOK, status, body)
+	var listed struct {
+		Sessions []models.SessionResponse `json:"sessions"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(body), &listed))
+	require.Len(t, listed.Sessions, 1)
+	sessionID := listed.Sessions[0].ID
+
+	// Regular users cannot reach another user's sessions
+	contract.token = bobToken
+	status, _ = contract.call(t, http.MethodGet, "/api/users/"+bob.ID+"/sessions", "")
+	assert.Equal(t, fiber.StatusForbidden, status)
+
+	contract.token = adminToken
+	status, body = contract.call(t, http.MethodDelete, "/api/users/"+bob.ID+"/sessions/missing", "")
+	assert.Equal(t, fiber.StatusNotFound, status, body)
+	status, body = contract.call(t, http.MethodDelete, "/api/users/missing/sessions/"+sessionID, "")
+	assert.Equal(t, fiber.StatusNotFound, status, body)
+	status, body = contract.call(t, http.MethodDelete, "/api/users/"+bob.ID+"/sessions/"+sessionID, "")
+	require.Equal(t, fiber.StatusOK, status, body)
+	assert.Contains(t, body, `"messageCode":"user.session_revoked"`)
+
+	// The revoked token is rejected even though the JWT itself is still valid
+	contract.token = bobToken
+	status, body = contract.call(t, http.MethodGet, "/api/profile", "")
+	assert.Equal(t, fiber.StatusUnauthorized, status, body)
+}
@@ -0,0 +1,116 @@
+package routes
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/GT-610/tairitsu/internal/app/models"
+	"github.com/gofiber/fiber/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWebhookManagementAndPing(t *testing.T) {
+	contract := newContractApp(t, false)
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer receiver.Close()
+
+	status, body := contract.call(t, http.MethodPost, "/api/webhooks", `{"name":"ops","url":"`+receiver.URL+`","events":["member.authorized"]}`)
+	require.Equal(t, fiber.StatusCreated, status, body)
+	var created struct {
+		Secret  string                 `json:"secret"`
+		Webhook models.WebhookResponse `json:"webhook"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(body), &created))
+	require.NotEmpty(t, created.Secret)
+	assert.Equal(t, []string{"member.authorized"}, created.Webhook.Events)
+
+	status, body = contract.call(t, http.MethodGet, "/api/webhooks", "")
+	require.Equal(t, fiber.StatusOK, status, body)
+	assert.NotContains(t, body, created.Secret)
+
+	status, body = contract.call(t, http.MethodPost, "/api/webhooks/"+created.Webhook.ID+"/ping", "")
+	require.Equal(t, fiber.StatusOK, status, body)
+	var delivery models.WebhookDelivery
+	require.NoError(t, json.Unmarshal([]byte(body), &delivery))
+	assert.True(t, delivery.Success)
+	assert.Equal(t, http.StatusNoContent, delivery.StatusCode)
+
+	status, body = contract.call(t, http.MethodGet, "/api/webhooks/"+created.Webhook.ID+"/deliveries", "")
+	require.Equal(t, fiber.StatusOK, status, body)
+	assert.Contains(t, body, `"event":"ping"`)
+
+	status, body = contract.call(t, http.MethodPost, "/api/webhooks", `{"url":"not a url"}`)
+	assert.Equal(t, fiber.StatusBadRequest, status)
+	assert.Contains(t, body, `"errorCode":"webhook.invalid_request"`)
+
+	status, _ = contract.call(t, http.MethodDelete, "/api/webhooks/"+created.Webhook.ID, "")
+	require.Equal(t, fiber.StatusOK, status)
+	status, body = contract.call(t, http.MethodGet, "/api/webhooks/"+created.Webhook.ID+"/deliveries", "")
+	assert.Equal(t, fiber.StatusNotFound, status)
+	assert.Contains(t, body, `"errorCode":"webhook.not_found"`)
+}
+
+func TestWebhookTestEventAndPayloadSchemas(t *testing.T) {
+	contract := newContractApp(t, false)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := contract.dependencies.Services.Webhooks.Start(ctx)
+	defer func() {
+		cancel()
+		<-done
+	}()
+	received := make(chan string, 1)
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received <- r.Header.Get("X-Tairitsu-Event")
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer receiver.Close()
+
+	status, body := contract.call(t, http.MethodPost, "/api/webhooks", `{"url":"`+receiver.URL+`","events":["user.created"]}`)
+	require.Equal(t, fiber.StatusCreated, status, body)
+	var created struct {
+		Webhook models.WebhookResponse `json:"webhook"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(body), &created))
+
+	status, body = contract.call(t, http.MethodPost, "/api/admin/webhooks/"+created.Webhook.ID+"/test", `{"event":"network.deleted"}`)
+	require.Equal(t, fiber.StatusAccepted, status, body)
+	var delivery models.WebhookDelivery
+	require.NoError(t, json.Unmarshal([]byte(body), &delivery))
+	assert.Equal(t, "network.deleted", delivery.Event)
+	assert.Contains(t, delivery.Payload, `"test":true`)
+	select {
+	case event := <-received:
+		assert.Equal(t, "network.deleted", event)
+	case <-time.After(5 * time.Second):
+		t.Fatal("the test event was not delivered")
+	}
+
+	status, body = contract.call(t, http.MethodPost, "/api/admin/webhooks/"+created.Webhook.ID+"/test", `{"event":"network.renamed"}`)
+	assert.Equal(t, fiber.StatusBadRequest, status)
+	assert.Contains(t, body, `"errorCode":"webhook.invalid_request"`)
+	status, body = contract.call(t, http.MethodPost, "/api/admin/webhooks/missing/test", `{"event":"network.deleted"}`)
+	assert.Equal(t, fiber.StatusNotFound, status)
+	assert.Contains(t, body, `"errorCode":"webhook.not_found"`)
+
+	status, body = contract.call(t, http.MethodGet, "/api/admin/webhooks/schema", "")
+	require.Equal(t, fiber.StatusOK, status, body)
+	var schemas struct {
+		Schemas map[string]struct {
+			Properties struct {
+				Data struct {
+					Required []string `json:"required"`
+				} `json:"data"`
+			} `json:"properties"`
+		} `json:"schemas"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(body), &schemas))
+	assert.ElementsMatch(t, []string{"networkId", "name", "deletedBy"}, schemas.Schemas["network.deleted"].Properties.Data.Required)
+	assert.Contains(t, schemas.Schemas, "user.created")
+}
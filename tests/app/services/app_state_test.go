@@ -0,0 +1,218 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/GT-610/tairitsu/internal/app/config"
+	"github.com/GT-610/tairitsu/internal/app/database"
+	"github.com/GT-610/tairitsu/internal/app/models"
+	"github.com/GT-610/tairitsu/internal/app/paths"
+	"github.com/GT-610/tairitsu/internal/app/services"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	appStateNetworkID = "8056c2e21c000001"
+	appStatePassword  = "correct horse battery staple"
+)
+
+type appStateHarness struct {
+	db       database.DBInterface
+	cfg      *config.Config
+	appState *services.AppStateService
+}
+
+func newAppStateHarness(t *testing.T) *appStateHarness {
+	t.Helper()
+
+	db := newTestSQLiteDB(t)
+	cfg := &config.Config{Initialized: true, Server: config.ServerConfig{Port: 8080}, Security: config.SecurityConfig{JWTSecret: "harness-secret"}}
+	return &appStateHarness{
+		db:       db,
+		cfg:      cfg,
+		appState: services.NewAppStateService(db, services.NewStateServiceWithConfig(cfg), services.NewUserService(db)),
+	}
+}
+
+// populateAppState seeds a source instance: an administrator, a user owning a shared network,
+// an API token, audit entries, member history and non-default preferences
+func populateAppState(t *testing.T, harness *appStateHarness) {
+	t.Helper()
+
+	db := harness.db
+	createTestUser(t, db, "source-admin", "admin")
+	admin, err := db.GetUserByID("source-admin")
+	require.NoError(t, err)
+	admin.Username = "admin"
+	require.NoError(t, db.UpdateUser(admin))
+	createTestUser(t, db, "alice", "user")
+
+	created := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	require.NoError(t, db.CreateNetwork(&models.Network{
+		ID: appStateNetworkID, Name: "lab", Description: "home lab", OwnerID: "alice",
+		PhysicalAddressPolicy: models.PhysicalAddressPolicyHidden, StatusPagePublished: true,
+		CreatedAt: created, UpdatedAt: created,
+	}))
+	require.NoError(t, db.UpsertNetworkViewer(&models.NetworkViewer{NetworkID: appStateNetworkID, UserID: "source-admin", GrantedBy: "alice", CreatedAt: created, UpdatedAt: created}))
+	require.NoError(t, db.CreateApiToken(&models.ApiToken{ID: "token-1", UserID: "alice", Name: "ci", TokenHash: "hash-1", Scopes: "read", CreatedAt: created, UpdatedAt: created}))
+
+	audit := services.NewAuditService(db)
+	_, err = audit.Record(services.AuditEntryInput{ActorID: "alice", Action: "POST", Target: "/api/networks"})
+	require.NoError(t, err)
+	_, err = audit.Record(services.AuditEntryInput{ActorID: "source-admin", Action: "PUT", Target: "/api/system/settings"})
+	require.NoError(t, err)
+
+	require.NoError(t, db.CreateMemberStatusEvents([]*models.MemberStatusEvent{
+		{NetworkID: appStateNetworkID, MemberID: "a1a1a1a1a1", Online: true, ChangedAt: created},
+		{NetworkID: appStateNetworkID, MemberID: "a1a1a1a1a1", Online: false, ChangedAt: created.Add(time.Hour)},
+	}))
+
+	harness.cfg.StatusPage = config.StatusPageConfig{Enabled: true, Title: "Lab status"}
+	harness.cfg.Security.PasswordPolicy = config.PasswordPolicyConfig{MinLength: 12, RequireDigit: true}
+	harness.cfg.Audit.RetentionDays = 90
+}
+
+func TestAppStateRoundTripsIntoFreshInstance(t *testing.T) {
+	t.Cleanup(paths.SetDataDir(t.TempDir()))
+	source := newAppStateHarness(t)
+	populateAppState(t, source)
+
+	archive, err := source.appState.Export(appStatePassword)
+	require.NoError(t, err)
+	assert.Equal(t, services.AppStateArchiveFormat, archive.Format)
+	assert.NotContains(t, archive.Ciphertext, "alice", "the archive is encrypted")
+
+	// The new host already ran setup, so its administrator exists under another ID
+	target := newAppStateHarness(t)
+	createTestUser(t, target.db, "target-admin", "admin")
+	targetAdmin, err := target.db.GetUserByID("target-admin")
+	require.NoError(t, err)
+	targetAdmin.Username = "admin"
+	require.NoError(t, target.db.UpdateUser(targetAdmin))
+
+	_, err = target.appState.Import(archive, "wrong password", services.AppStateOnConflictAbort)
+	assert.ErrorIs(t, err, services.ErrAppStateWrongPassword)
+
+	report, err := target.appState.Import(archive, appStatePassword, services.AppStateOnConflictAbort)
+	require.NoError(t, err)
+	assert.True(t, report.Applied)
+	assert.True(t, report.SettingsApplied)
+	assert.Empty(t, report.Conflicts)
+	assert.Equal(t, []services.AppStateMergedUser{{Username: "admin", SourceID: "source-admin", TargetID: "target-admin"}}, report.MergedUsers)
+	assert.Equal(t, map[string]int{
+		services.AppStateTableUsers:              1,
+		services.AppStateTableNetworks:           1,
+		services.AppStateTableNetworkViewers:     1,
+		services.AppStateTableApiTokens:          1,
+		services.AppStateTableAuditLogs:          2,
+		services.AppStateTableMemberStatusEvents: 2,
+	}, report.Imported)
+
+	sourceState, err := source.appState.Snapshot()
+	require.NoError(t, err)
+	targetState, err := target.appState.Snapshot()
+	require.NoError(t, err)
+
+	assert.Equal(t, sourceState.Settings, targetState.Settings)
+	assert.Equal(t, 12, target.cfg.Security.PasswordPolicy.MinLength)
+	assert.Equal(t, "harness-secret", target.cfg.Security.JWTSecret, "the signing secret stays with the host")
+
+	usersByName := func(state *services.AppStateSnapshot) map[string]services.AppStateUser {
+		users := map[string]services.AppStateUser{}
+		for _, user := range state.Users {
+			users[user.Username] = user
+		}
+		return users
+	}
+	sourceUsers, targetUsers := usersByName(sourceState), usersByName(targetState)
+	require.Len(t, targetUsers, 2)
+	assert.Equal(t, "target-admin", targetUsers["admin"].ID)
+	assert.Equal(t, sourceUsers["alice"].ID, targetUsers["alice"].ID)
+	assert.Equal(t, sourceUsers["alice"].PasswordHash, targetUsers["alice"].PasswordHash)
+	assert.Equal(t, sourceUsers["alice"].Role, targetUsers["alice"].Role)
+
+	require.Len(t, targetState.Networks, 1)
+	sourceNetwork, targetNetwork := sourceState.Networks[0], targetState.Networks[0]
+	assert.Equal(t, sourceNetwork.ID, targetNetwork.ID)
+	assert.Equal(t, sourceNetwork.OwnerID, targetNetwork.OwnerID)
+	assert.Equal(t, sourceNetwork.Name, targetNetwork.Name)
+	assert.Equal(t, sourceNetwork.Description, targetNetwork.Description)
+	assert.Equal(t, sourceNetwork.PhysicalAddressPolicy, targetNetwork.PhysicalAddressPolicy)
+	assert.Equal(t, sourceNetwork.StatusPagePublished, targetNetwork.StatusPagePublished)
+	assert.True(t, sourceNetwork.CreatedAt.Equal(targetNetwork.CreatedAt))
+
+	require.Len(t, targetState.NetworkViewers, 1)
+	assert.Equal(t, "target-admin", targetState.NetworkViewers[0].UserID, "references follow the merged user")
+	assert.Equal(t, "alice", targetState.NetworkViewers[0].GrantedBy)
+
+	require.Len(t, targetState.ApiTokens, 1)
+	assert.Equal(t, sourceState.ApiTokens[0].TokenHash, targetState.ApiTokens[0].TokenHash)
+	assert.Equal(t, sourceState.ApiTokens[0].Scopes, targetState.ApiTokens[0].Scopes)
+
+	require.Len(t, targetState.AuditLogs, 2)
+	for i := range sourceState.AuditLogs {
+		assert.Equal(t, sourceState.AuditLogs[i].Hash, targetState.AuditLogs[i].Hash)
+		assert.Equal(t, sourceState.AuditLogs[i].PrevHash, targetState.AuditLogs[i].PrevHash)
+	}
+	verification, err := services.NewAuditService(target.db).Verify()
+	require.NoError(t, err)
+	assert.True(t, verification.Valid, "the imported audit chain still verifies")
+
+	require.Len(t, targetState.MemberStatusEvents, 2)
+	for i := range sourceState.MemberStatusEvents {
+		assert.Equal(t, sourceState.MemberStatusEvents[i].Online, targetState.MemberStatusEvents[i].Online)
+		assert.True(t, sourceState.MemberStatusEvents[i].ChangedAt.Equal(targetState.MemberStatusEvents[i].ChangedAt))
+	}
+
+	// Importing again only finds the audit log occupied
+	report, err = target.appState.Import(archive, appStatePassword, services.AppStateOnConflictAbort)
+	assert.ErrorIs(t, err, services.ErrAppStateConflicts)
+	require.Len(t, report.Conflicts, 1)
+	assert.Equal(t, services.AppStateTableAuditLogs, report.Conflicts[0].Table)
+
+	report, err = target.appState.Import(archive, appStatePassword, services.AppStateOnConflictSkip)
+	require.NoError(t, err)
+	assert.True(t, report.Applied)
+	for table, count := range report.Imported {
+		assert.Zero(t, count, table)
+	}
+	assert.Equal(t, 2, report.Unchanged[services.AppStateTableMemberStatusEvents])
+}
+
+func TestAppStateImportAbortsOnConflictingNetworks(t *testing.T) {
+	t.Cleanup(paths.SetDataDir(t.TempDir()))
+	source := newAppStateHarness(t)
+	populateAppState(t, source)
+	archive, err := source.appState.Export(appStatePassword)
+	require.NoError(t, err)
+
+	target := newAppStateHarness(t)
+	createTestUser(t, target.db, "bob", "admin")
+	now := time.Now()
+	require.NoError(t, target.db.CreateNetwork(&models.Network{ID: appStateNetworkID, Name: "lab", OwnerID: "bob", CreatedAt: now, UpdatedAt: now}))
+
+	report, err := target.appState.Import(archive, appStatePassword, services.AppStateOnConflictAbort)
+	assert.ErrorIs(t, err, services.ErrAppStateConflicts)
+	require.NotNil(t, report)
+	assert.False(t, report.Applied)
+	assert.Contains(t, report.Conflicts, services.AppStateConflict{
+		Table: services.AppStateTableNetworks, ID: appStateNetworkID, Reason: "network is already registered with different ownership or metadata",
+	})
+	alice, err := target.db.GetUserByID("alice")
+	require.NoError(t, err)
+	assert.Nil(t, alice, "an aborted import writes nothing")
+	assert.Zero(t, target.cfg.StatusPage.Title)
+
+	report, err = target.appState.Import(archive, appStatePassword, services.AppStateOnConflictSkip)
+	require.NoError(t, err)
+	assert.Equal(t, 0, report.Imported[services.AppStateTableNetworks])
+	assert.Equal(t, 0, report.Imported[services.AppStateTableNetworkViewers], "viewers of a skipped network are skipped too")
+	network, err := target.db.GetNetworkByID(appStateNetworkID)
+	require.NoError(t, err)
+	assert.Equal(t, "bob", network.OwnerID)
+	alice, err = target.db.GetUserByID("alice")
+	require.NoError(t, err)
+	assert.NotNil(t, alice)
+}
@@ -0,0 +1,159 @@
+package services
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/GT-610/tairitsu/internal/app/database"
+	"github.com/GT-610/tairitsu/internal/app/models"
+	"github.com/GT-610/tairitsu/internal/app/services"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// newAuditTestDB returns the application database and a raw handle to the same file for tampering.
+func newAuditTestDB(t *testing.T) (database.DBInterface, *gorm.DB) {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "audit.db")
+	db, err := database.NewDatabase(database.Config{Type: database.SQLite, Path: path})
+	require.NoError(t, err)
+	require.NoError(t, db.Init())
+	t.Cleanup(func() {
+		require.NoError(t, db.Close())
+	})
+
+	raw, err := gorm.Open(sqlite.Open(path), &gorm.Config{})
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		sqlDB, err := raw.DB()
+		require.NoError(t, err)
+		require.NoError(t, sqlDB.Close())
+	})
+
+	return db, raw
+}
+
+func recordAuditEntries(t *testing.T, service *services.AuditService, count int) []*models.AuditLog {
+	t.Helper()
+
+	entries := make([]*models.AuditLog, 0, count)
+	for i := 0; i < count; i++ {
+		entry, err := service.Record(services.AuditEntryInput{
+			ActorID: "admin-1",
+			Action:  "POST",
+			Target:  fmt.Sprintf("/api/networks/%d", i),
+			Details: "status=200",
+		})
+		require.NoError(t, err)
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+func TestAuditServiceChainsEntries(t *testing.T) {
+	db, _ := newAuditTestDB(t)
+	service := services.NewAuditService(db)
+
+	entries := recordAuditEntries(t, service, 3)
+
+	assert.Empty(t, entries[0].PrevHash)
+	assert.Equal(t, entries[0].Hash, entries[1].PrevHash)
+	assert.Equal(t, entries[1].Hash, entries[2].PrevHash)
+
+	result, err := service.Verify()
+	require.NoError(t, err)
+	assert.True(t, result.Valid)
+	assert.Equal(t, 3, result.CheckedCount)
+}
+
+func TestAuditServiceVerifyPinpointsModifiedRow(t *testing.T) {
+	db, raw := newAuditTestDB(t)
+	service := services.NewAuditService(db)
+	entries := recordAuditEntries(t, service, 4)
+
+	require.NoError(t, raw.Exec("UPDATE audit_logs SET details = ? WHERE id = ?", "status=500", entries[2].ID).Error)
+
+	result, err := service.Verify()
+	require.NoError(t, err)
+	assert.False(t, result.Valid)
+	assert.Equal(t, entries[2].ID, result.BrokenEntryID)
+	assert.Equal(t, 3, result.CheckedCount)
+}
+
+func TestAuditServiceVerifyDetectsRehashedRowAndDeletion(t *testing.T) {
+	db, raw := newAuditTestDB(t)
+	service := services.NewAuditService(db)
+	entries := recordAuditEntries(t, service, 4)
+
+	forged := *entries[1]
+	forged.Details = "status=500"
+	forged.Hash = services.HashAuditEntry(&forged)
+	require.NoError(t, raw.Exec("UPDATE audit_logs SET details = ?, hash = ? WHERE id = ?", forged.Details, forged.Hash, forged.ID).Error)
+
+	result, err := service.Verify()
+	require.NoError(t, err)
+	assert.False(t, result.Valid)
+	assert.Equal(t, entries[2].ID, result.BrokenEntryID)
+
+	require.NoError(t, raw.Exec("UPDATE audit_logs SET details = ?, hash = ? WHERE id = ?", entries[1].Details, entries[1].Hash, entries[1].ID).Error)
+	require.NoError(t, raw.Exec("DELETE FROM audit_logs WHERE id = ?", entries[2].ID).Error)
+
+	result, err = service.Verify()
+	require.NoError(t, err)
+	assert.False(t, result.Valid)
+	assert.Equal(t, entries[3].ID, result.BrokenEntryID)
+}
+
+func TestAuditServicePruneAnchorsChain(t *testing.T) {
+	db, raw := newAuditTestDB(t)
+	service := services.NewAuditService(db)
+	entries := recordAuditEntries(t, service, 3)
+
+	deleted, err := service.Prune(time.Now().Add(time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), deleted)
+
+	anchor, err := db.GetAuditAnchor()
+	require.NoError(t, err)
+	require.NotNil(t, anchor)
+	assert.Equal(t, entries[2].Hash, anchor.LastPrunedHash)
+
+	next, err := service.Record(services.AuditEntryInput{ActorID: "admin-1", Action: "DELETE", Target: "/api/users/u1"})
+	require.NoError(t, err)
+	assert.Equal(t, entries[2].Hash, next.PrevHash)
+
+	result, err := service.Verify()
+	require.NoError(t, err)
+	assert.True(t, result.Valid)
+	assert.Equal(t, 1, result.CheckedCount)
+
+	require.NoError(t, raw.Exec("UPDATE audit_anchors SET last_pruned_hash = ?", "forged").Error)
+	result, err = service.Verify()
+	require.NoError(t, err)
+	assert.False(t, result.Valid)
+	assert.Equal(t, next.ID, result.BrokenEntryID)
+}
+
+func TestAuditServiceVerifyRecentChecksNewestSegment(t *testing.T) {
+	db, raw := newAuditTestDB(t)
+	service := services.NewAuditService(db)
+	entries := recordAuditEntries(t, service, 5)
+
+	require.NoError(t, raw.Exec("UPDATE audit_logs SET target = ? WHERE id = ?", "/api/forged", entries[0].ID).Error)
+
+	result, err := service.VerifyRecent(3)
+	require.NoError(t, err)
+	assert.True(t, result.Valid)
+	assert.Equal(t, 3, result.CheckedCount)
+
+	require.NoError(t, raw.Exec("UPDATE audit_logs SET target = ? WHERE id = ?", "/api/forged", entries[4].ID).Error)
+	result, err = service.VerifyRecent(3)
+	require.NoError(t, err)
+	assert.False(t, result.Valid)
+	assert.Equal(t, entries[4].ID, result.BrokenEntryID)
+}
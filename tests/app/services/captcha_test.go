@@ -0,0 +1,89 @@
+package services
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/GT-610/tairitsu/internal/app/config"
+	"github.com/GT-610/tairitsu/internal/app/services"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeCaptchaVerifier accepts the token "solved"
+type fakeCaptchaVerifier struct {
+	calls int
+}
+
+func (v *fakeCaptchaVerifier) Verify(_ context.Context, token, _ string) error {
+	v.calls++
+	if token != "solved" {
+		return services.ErrCaptchaInvalid
+	}
+	return nil
+}
+
+func TestCaptchaGuardRequiresCaptchaAfterRepeatedFailures(t *testing.T) {
+	verifier := &fakeCaptchaVerifier{}
+	guard := services.NewCaptchaGuard(config.CaptchaConfig{Provider: config.CaptchaProviderTurnstile, SiteKey: "site", LoginFailures: 2}, verifier)
+	require.True(t, guard.Enabled())
+	assert.Equal(t, &services.CaptchaChallenge{Provider: "turnstile", SiteKey: "site"}, guard.Challenge())
+
+	assert.False(t, guard.LoginNeedsCaptcha("alice", "192.0.2.1"))
+	assert.False(t, guard.RecordLoginFailure("alice", "192.0.2.1"))
+	assert.True(t, guard.RecordLoginFailure("Alice", "192.0.2.2"), "the second failure for the username reaches the threshold")
+	assert.True(t, guard.LoginNeedsCaptcha("alice", "198.51.100.1"), "the username needs a captcha from any address")
+	assert.False(t, guard.LoginNeedsCaptcha("bob", "198.51.100.1"))
+
+	guard.ClearLoginFailures("alice")
+	assert.False(t, guard.LoginNeedsCaptcha("alice", "198.51.100.1"))
+
+	assert.False(t, guard.RecordLoginFailure("carol", "203.0.113.9"))
+	assert.True(t, guard.RecordLoginFailure("dave", "203.0.113.9"))
+	assert.True(t, guard.LoginNeedsCaptcha("erin", "203.0.113.9"), "the address needs a captcha for any username")
+
+	assert.ErrorIs(t, guard.Check(context.Background(), "", "203.0.113.9"), services.ErrCaptchaRequired)
+	assert.ErrorIs(t, guard.Check(context.Background(), "guess", "203.0.113.9"), services.ErrCaptchaInvalid)
+	assert.NoError(t, guard.Check(context.Background(), "solved", "203.0.113.9"))
+	assert.Equal(t, 2, verifier.calls, "an empty token is not sent to the provider")
+}
+
+func TestCaptchaGuardWithoutVerifierNeverAsks(t *testing.T) {
+	guard := services.NewCaptchaGuard(config.CaptchaConfig{}, nil)
+
+	assert.False(t, guard.Enabled())
+	assert.Nil(t, guard.Challenge())
+	for range 10 {
+		assert.False(t, guard.RecordLoginFailure("alice", "192.0.2.1"))
+	}
+	assert.False(t, guard.LoginNeedsCaptcha("alice", "192.0.2.1"))
+	assert.NoError(t, guard.Check(context.Background(), "", "192.0.2.1"))
+}
+
+func TestCaptchaVerifierPostsToSiteVerify(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, r.ParseForm())
+		assert.Equal(t, "secret", r.PostForm.Get("secret"))
+		assert.Equal(t, "192.0.2.1", r.PostForm.Get("remoteip"))
+		w.Header().Set("Content-Type", "application/json")
+		if r.PostForm.Get("response") == "solved" {
+			_, _ = w.Write([]byte(`{"success":true}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"success":false,"error-codes":["invalid-input-response"]}`))
+	}))
+	t.Cleanup(server.Close)
+
+	verifier := services.NewCaptchaVerifier(config.CaptchaConfig{Provider: config.CaptchaProviderHCaptcha, SiteKey: "site", Secret: "secret", VerifyURL: server.URL})
+	require.NotNil(t, verifier)
+	assert.NoError(t, verifier.Verify(context.Background(), "solved", "192.0.2.1"))
+	err := verifier.Verify(context.Background(), "guess", "192.0.2.1")
+	assert.ErrorIs(t, err, services.ErrCaptchaInvalid)
+	assert.Contains(t, err.Error(), "invalid-input-response")
+
+	server.Close()
+	assert.ErrorIs(t, verifier.Verify(context.Background(), "solved", "192.0.2.1"), services.ErrCaptchaUnavailable)
+	assert.Nil(t, services.NewCaptchaVerifier(config.CaptchaConfig{}))
+}
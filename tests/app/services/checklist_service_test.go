@@ -0,0 +1,127 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/GT-610/tairitsu/internal/app/config"
+	"github.com/GT-610/tairitsu/internal/app/database"
+	"github.com/GT-610/tairitsu/internal/app/models"
+	"github.com/GT-610/tairitsu/internal/app/paths"
+	"github.com/GT-610/tairitsu/internal/app/services"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestChecklistService(t *testing.T) (*services.ChecklistService, *config.Config, database.DBInterface) {
+	t.Helper()
+
+	db := newTestSQLiteDB(t)
+	createTestUser(t, db, "admin-1", "admin")
+	cfg := &config.Config{Initialized: true, Server: config.ServerConfig{Port: 8080}, Security: config.SecurityConfig{JWTSecret: "checklist-secret-long-enough-for-startup"}}
+	stateService := services.NewStateServiceWithConfig(cfg)
+	userService := services.NewUserService(db)
+	networkService := services.NewNetworkService(nil, db)
+
+	return services.NewChecklistService(stateService, userService, networkService), cfg, db
+}
+
+func checklistItem(t *testing.T, checklist *services.Checklist, id string) services.ChecklistItem {
+	t.Helper()
+
+	for _, item := range checklist.Items {
+		if item.ID == id {
+			return item
+		}
+	}
+	t.Fatalf("checklist item %q not found", id)
+	return services.ChecklistItem{}
+}
+
+func TestChecklistServiceStartsWithEveryItemOutstanding(t *testing.T) {
+	service, _, _ := newTestChecklistService(t)
+
+	checklist, err := service.GetChecklist(services.ChecklistContext{})
+	require.NoError(t, err)
+
+	assert.Len(t, checklist.Items, 4)
+	assert.Equal(t, 4, checklist.Outstanding)
+	for _, item := range checklist.Items {
+		assert.False(t, item.Done, item.ID)
+		assert.False(t, item.Dismissed, item.ID)
+		assert.NotEmpty(t, item.Pointer, item.ID)
+	}
+}
+
+func TestChecklistServiceDetectsNetworksAdminsAndTransport(t *testing.T) {
+	service, _, db := newTestChecklistService(t)
+
+	now := time.Now()
+	require.NoError(t, db.CreateNetwork(&models.Network{ID: "8056c2e21c000001", Name: "alpha", OwnerID: "admin-1", CreatedAt: now, UpdatedAt: now}))
+	createTestUser(t, db, "admin-2", "admin")
+
+	checklist, err := service.GetChecklist(services.ChecklistContext{SecureTransport: true})
+	require.NoError(t, err)
+
+	assert.True(t, checklistItem(t, checklist, services.ChecklistItemNetworkCreated).Done)
+	assert.True(t, checklistItem(t, checklist, services.ChecklistItemMultipleAdmins).Done)
+	assert.True(t, checklistItem(t, checklist, services.ChecklistItemSecureTransport).Done)
+	assert.False(t, checklistItem(t, checklist, services.ChecklistItemBackupsConfigured).Done)
+	assert.Equal(t, 1, checklist.Outstanding)
+}
+
+func TestChecklistServiceCountsConfiguredTLSAsSecureTransport(t *testing.T) {
+	service, cfg, _ := newTestChecklistService(t)
+	cfg.Server.TLS = config.TLSConfig{CertFile: "tls/cert.pem", KeyFile: "tls/key.pem"}
+
+	checklist, err := service.GetChecklist(services.ChecklistContext{})
+	require.NoError(t, err)
+	assert.True(t, checklistItem(t, checklist, services.ChecklistItemSecureTransport).Done)
+}
+
+func TestChecklistServiceDetectsScheduledBackups(t *testing.T) {
+	service, cfg, _ := newTestChecklistService(t)
+
+	checklist, err := service.GetChecklist(services.ChecklistContext{})
+	require.NoError(t, err)
+	item := checklistItem(t, checklist, services.ChecklistItemBackupsConfigured)
+	assert.False(t, item.Done, "scheduled backups are off by default")
+
+	cfg.Backup.IntervalHours = 24
+	checklist, err = service.GetChecklist(services.ChecklistContext{})
+	require.NoError(t, err)
+	assert.True(t, checklistItem(t, checklist, services.ChecklistItemBackupsConfigured).Done)
+	assert.Equal(t, 3, checklist.Outstanding)
+}
+
+func TestChecklistServiceDismissalIsPersisted(t *testing.T) {
+	t.Cleanup(paths.SetDataDir(t.TempDir()))
+	originalConfig := config.AppConfig
+	t.Cleanup(func() {
+		config.AppConfig = originalConfig
+	})
+
+	service, cfg, _ := newTestChecklistService(t)
+
+	require.NoError(t, service.SetItemDismissed(services.ChecklistItemMultipleAdmins, true))
+	assert.Equal(t, []string{services.ChecklistItemMultipleAdmins}, cfg.Checklist.Dismissed)
+
+	reloaded, err := config.LoadConfig()
+	require.NoError(t, err)
+	assert.True(t, config.ChecklistItemDismissed(reloaded, services.ChecklistItemMultipleAdmins))
+
+	checklist, err := service.GetChecklist(services.ChecklistContext{})
+	require.NoError(t, err)
+	assert.True(t, checklistItem(t, checklist, services.ChecklistItemMultipleAdmins).Dismissed)
+	assert.Equal(t, 3, checklist.Outstanding)
+
+	require.NoError(t, service.SetItemDismissed(services.ChecklistItemMultipleAdmins, false))
+	assert.Empty(t, cfg.Checklist.Dismissed)
+}
+
+func TestChecklistServiceRejectsUnknownItem(t *testing.T) {
+	service, _, _ := newTestChecklistService(t)
+
+	err := service.SetItemDismissed("unknown", true)
+	require.ErrorIs(t, err, services.ErrChecklistItemNotFound)
+}
@@ -0,0 +1,119 @@
+package services
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/GT-610/tairitsu/internal/app/models"
+	"github.com/GT-610/tairitsu/internal/app/services"
+	"github.com/GT-610/tairitsu/internal/zerotier"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// controllerFixtureDir holds the ZeroTier 1.14 responses the zerotier client tests also use
+var controllerFixtureDir = filepath.Join("..", "..", "..", "internal", "zerotier", "testdata")
+
+const (
+	fixtureNetworkID = "8056c2e21c000001"
+	fixtureMemberID  = "a1b2c3d4e5"
+)
+
+// newFixtureClient serves the recorded controller responses for the given paths
+func newFixtureClient(t *testing.T, files map[string]string) *zerotier.Client {
+	t.Helper()
+
+	bodies := make(map[string][]byte, len(files))
+	for path, name := range files {
+		data, err := os.ReadFile(filepath.Join(controllerFixtureDir, name))
+		require.NoError(t, err)
+		bodies[path] = data
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, ok := bodies[r.URL.Path]
+		if !ok || r.Method != http.MethodGet {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(body)
+	}))
+	t.Cleanup(server.Close)
+
+	return zerotier.NewClientWithOptions(server.URL, "fixture-token", server.Client())
+}
+
+func TestGetNetworkMemberFromRecordedControllerResponses(t *testing.T) {
+	client := newFixtureClient(t, map[string]string{
+		"/controller/network/" + fixtureNetworkID + "/member/" + fixtureMemberID: "member.json",
+		"/peer": "peers.json",
+	})
+	db := newTestSQLiteDB(t)
+	now := time.Now()
+	require.NoError(t, db.CreateNetwork(&models.Network{ID: fixtureNetworkID, Name: "lab", OwnerID: "owner", CreatedAt: now, UpdatedAt: now}))
+	service := services.NewNetworkService(client, db)
+
+	member, err := service.GetNetworkMember(fixtureNetworkID, fixtureMemberID, "owner")
+	require.NoError(t, err)
+	assert.True(t, member.Authorized)
+	assert.Equal(t, []string{"10.147.17.23"}, member.IPAssignments)
+	assert.Equal(t, "1.14.2", member.ClientVersion)
+	assert.Equal(t, "LEAF", member.PeerRole)
+	assert.Equal(t, 12, member.PeerLatency)
+	assert.Equal(t, []zerotier.Tag{{ID: 100, Value: 2}}, member.Tags)
+
+	_, err = service.GetNetworkMember(fixtureNetworkID, "ffffffffff", "owner")
+	assert.ErrorIs(t, err, services.ErrMemberNotFound)
+}
+
+func TestRuntimeStatusFromRecordedControllerResponse(t *testing.T) {
+	client := newFixtureClient(t, map[string]string{"/status": "status.json"})
+	service := services.NewNetworkService(client, newTestSQLiteDB(t))
+
+	status := service.GetRuntimeStatus(true)
+	assert.Equal(t, "1.14.2", status.Version)
+	assert.Equal(t, "8056c2e21c", status.Address)
+	assert.Equal(t, "online", status.ZeroTierStatus)
+}
+
+func TestControllerPeersFromRecordedControllerResponse(t *testing.T) {
+	client := newFixtureClient(t, map[string]string{"/peer": "peers.json"})
+	service := services.NewNetworkService(client, newTestSQLiteDB(t))
+
+	all, err := service.GetControllerPeers("", "")
+	require.NoError(t, err)
+	assert.Equal(t, "default", all.Controller)
+	require.Len(t, all.Peers, 2)
+
+	roots, err := service.GetControllerPeers("", "planet")
+	require.NoError(t, err)
+	require.Len(t, roots.Peers, 1)
+	root := roots.Peers[0]
+	assert.Equal(t, "778cde7190", root.Address)
+	assert.Equal(t, 131, root.Latency)
+	require.Len(t, root.Paths, 1)
+	assert.Equal(t, "103.195.103.66/9993", root.Paths[0].Address)
+	assert.Equal(t, int64(1760486399010), root.Paths[0].LastSend)
+	assert.Equal(t, int64(1760486399120), root.Paths[0].LastReceive)
+
+	moons, err := service.GetControllerPeers("", zerotier.PeerRoleMoon)
+	require.NoError(t, err)
+	assert.Empty(t, moons.Peers)
+
+	_, err = service.GetControllerPeers("", "root")
+	assert.ErrorIs(t, err, services.ErrInvalidPeerRole)
+	_, err = service.GetControllerPeers("lab", "")
+	assert.ErrorIs(t, err, services.ErrControllerNotFound)
+}
+
+func TestControllerPeersUnsupportedWithoutPeerEndpoint(t *testing.T) {
+	client := newFixtureClient(t, map[string]string{"/status": "status.json"})
+	service := services.NewNetworkService(client, newTestSQLiteDB(t))
+
+	_, err := service.GetControllerPeers("", "")
+	assert.ErrorIs(t, err, services.ErrPeersUnsupported)
+}
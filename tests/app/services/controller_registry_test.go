@@ -0,0 +1,111 @@
+package services
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/GT-610/tairitsu/internal/app/config"
+	"github.com/GT-610/tairitsu/internal/app/services"
+	"github.com/GT-610/tairitsu/internal/zerotier"
+	"github.com/GT-610/tairitsu/internal/zerotier/ztmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func startMockController(t *testing.T, address string) (*ztmock.Controller, string) {
+	t.Helper()
+
+	controller := ztmock.NewController(address)
+	baseURL, err := controller.Start()
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = controller.Close()
+	})
+	return controller, baseURL
+}
+
+// newTwoControllerService binds the default controller and registers a second one named "lab"
+func newTwoControllerService(t *testing.T) (*services.NetworkService, *ztmock.Controller, *ztmock.Controller) {
+	t.Helper()
+
+	primary, primaryURL := startMockController(t, ztmock.DemoAddress)
+	lab, labURL := startMockController(t, "c0ffee0001")
+
+	tokenPath := filepath.Join(t.TempDir(), "lab.secret")
+	require.NoError(t, os.WriteFile(tokenPath, []byte(lab.Token+"\n"), 0600))
+
+	db := newTestSQLiteDB(t)
+	createTestUser(t, db, "owner-1", "user")
+	client := &zerotier.Client{BaseURL: primaryURL, Token: primary.Token, HTTPClient: &http.Client{Timeout: 5 * time.Second}}
+	service := services.NewNetworkService(client, db)
+
+	cfg := &config.Config{}
+	cfg.ZeroTier.Controllers = []config.ZeroTierControllerConfig{
+		{Name: "lab", URL: labURL, TokenPath: tokenPath},
+		{Name: "offline", URL: "http://127.0.0.1:1", TokenPath: filepath.Join(t.TempDir(), "missing.secret")},
+	}
+	service.Controllers().LoadControllers(cfg)
+	return service, primary, lab
+}
+
+func TestNetworksAreManagedOnTheirOwnController(t *testing.T) {
+	service, primary, lab := newTwoControllerService(t)
+
+	created, err := service.CreateNetwork("lab", &zerotier.Network{Name: "lab-net"}, "owner-1")
+	require.NoError(t, err)
+	assert.Contains(t, lab.NetworkIDs(), created.ID)
+	assert.NotContains(t, primary.NetworkIDs(), created.ID)
+
+	detail, err := service.GetNetworkByID(created.ID, "owner-1")
+	require.NoError(t, err)
+	assert.Equal(t, "lab-net", detail.Name)
+
+	onDefault, err := service.CreateNetwork("", &zerotier.Network{Name: "main-net"}, "owner-1")
+	require.NoError(t, err)
+	assert.Contains(t, primary.NetworkIDs(), onDefault.ID)
+
+	summaries, err := service.GetAllNetworks("owner-1")
+	require.NoError(t, err)
+	controllers := map[string]string{}
+	for _, summary := range summaries {
+		controllers[summary.ID] = summary.Controller
+	}
+	assert.Equal(t, map[string]string{created.ID: "lab", onDefault.ID: config.DefaultControllerName}, controllers)
+}
+
+func TestUnknownAndUnavailableControllersAreRejected(t *testing.T) {
+	service, _, _ := newTwoControllerService(t)
+
+	_, err := service.CreateNetwork("missing", &zerotier.Network{Name: "nowhere"}, "owner-1")
+	assert.ErrorIs(t, err, services.ErrControllerNotFound)
+
+	_, err = service.CreateNetwork("offline", &zerotier.Network{Name: "nowhere"}, "owner-1")
+	assert.ErrorIs(t, err, services.ErrControllerUnavailable)
+
+	_, err = service.GetImportableNetworks("missing")
+	assert.ErrorIs(t, err, services.ErrControllerNotFound)
+}
+
+func TestControllerRegistryListsEveryController(t *testing.T) {
+	service, _, _ := newTwoControllerService(t)
+
+	infos := service.Controllers().List()
+	require.Len(t, infos, 3)
+
+	assert.Equal(t, config.DefaultControllerName, infos[0].Name)
+	assert.True(t, infos[0].Default)
+	require.NotNil(t, infos[0].Status)
+	assert.Equal(t, ztmock.DemoAddress, infos[0].Status.Address)
+
+	assert.Equal(t, "lab", infos[1].Name)
+	assert.False(t, infos[1].Default)
+	require.NotNil(t, infos[1].Status)
+	assert.Equal(t, "c0ffee0001", infos[1].Status.Address)
+
+	assert.Equal(t, "offline", infos[2].Name)
+	assert.Nil(t, infos[2].Status)
+	assert.NotEmpty(t, infos[2].Error)
+}
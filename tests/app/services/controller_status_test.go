@@ -0,0 +1,165 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/GT-610/tairitsu/internal/app/services"
+	"github.com/GT-610/tairitsu/internal/zerotier"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// countingStatusClient serves /status with the given version and counts the requests;
+// the controller answers 500 while failing is set
+func countingStatusClient(t *testing.T, version string, requests *atomic.Int32, failing *atomic.Bool) *zerotier.Client {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/status" {
+			http.NotFound(w, r)
+			return
+		}
+		requests.Add(1)
+		if failing.Load() {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		require.NoError(t, json.NewEncoder(w).Encode(map[string]any{"version": version, "address": "f76fd3000b", "online": true}))
+	}))
+	t.Cleanup(server.Close)
+
+	return &zerotier.Client{BaseURL: server.URL, Token: "test-token", HTTPClient: server.Client()}
+}
+
+func TestControllerStatusServesTheCachedCopy(t *testing.T) {
+	var requests atomic.Int32
+	var failing atomic.Bool
+	service := services.NewNetworkService(countingStatusClient(t, "1.14.2", &requests, &failing), newTestSQLiteDB(t))
+
+	first := service.ControllerStatus(false)
+	require.True(t, first.Fetched())
+	assert.Equal(t, "1.14.2", first.Status.Version)
+	assert.False(t, first.Stale)
+	assert.False(t, first.FetchedAt.IsZero())
+
+	cached := service.GetRuntimeStatus(false)
+	assert.Equal(t, int32(1), requests.Load(), "the first request fills the cache")
+	assert.Equal(t, "online", cached.ZeroTierStatus)
+	require.NotNil(t, cached.StatusFetchedAt)
+	assert.Equal(t, first.FetchedAt, *cached.StatusFetchedAt)
+
+	service.GetRuntimeStatus(true)
+	assert.Equal(t, int32(2), requests.Load(), "fresh bypasses the cache")
+}
+
+func TestControllerStatusKeepsTheLastStatusWhenARefreshFails(t *testing.T) {
+	var requests atomic.Int32
+	var failing atomic.Bool
+	service := services.NewNetworkService(countingStatusClient(t, "1.14.2", &requests, &failing), newTestSQLiteDB(t))
+	require.NoError(t, service.RefreshControllerStatus())
+
+	failing.Store(true)
+	require.Error(t, service.RefreshControllerStatus())
+
+	snapshot := service.ControllerStatus(false)
+	require.True(t, snapshot.Fetched())
+	assert.True(t, snapshot.Stale)
+	assert.Error(t, snapshot.Err)
+
+	status := service.GetRuntimeStatus(false)
+	assert.Equal(t, "1.14.2", status.Version)
+	assert.True(t, status.StatusStale)
+	assert.NotEmpty(t, status.ZeroTierError)
+}
+
+func TestControllerStatusNeverFetched(t *testing.T) {
+	var requests atomic.Int32
+	var failing atomic.Bool
+	failing.Store(true)
+	service := services.NewNetworkService(countingStatusClient(t, "1.14.2", &requests, &failing), newTestSQLiteDB(t))
+
+	snapshot := service.ControllerStatus(false)
+	assert.False(t, snapshot.Fetched())
+	assert.True(t, snapshot.FetchedAt.IsZero())
+	assert.Error(t, snapshot.Err)
+
+	status := service.GetRuntimeStatus(false)
+	assert.Equal(t, "error", status.ZeroTierStatus)
+	assert.Nil(t, status.StatusFetchedAt)
+	assert.Equal(t, int32(1), requests.Load(), "a failed first fetch is cached too")
+}
+
+func TestControllerStatusFollowsTheBoundClient(t *testing.T) {
+	var requests atomic.Int32
+	var failing atomic.Bool
+	service := services.NewNetworkService(countingStatusClient(t, "1.14.2", &requests, &failing), newTestSQLiteDB(t))
+	require.Equal(t, "1.14.2", service.ControllerStatus(false).Status.Version)
+
+	service.SetZTClient(countingStatusClient(t, "1.16.0", &requests, &failing))
+	assert.Equal(t, "1.16.0", service.ControllerStatus(false).Status.Version)
+}
+
+func TestControllerStatusRefreshesInTheBackground(t *testing.T) {
+	var requests atomic.Int32
+	var failing atomic.Bool
+	service := services.NewNetworkService(countingStatusClient(t, "1.14.2", &requests, &failing), newTestSQLiteDB(t))
+	service.SetStatusRefreshInterval(10 * time.Millisecond)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := service.StartStatusRefresh(ctx)
+	require.Eventually(t, func() bool { return requests.Load() >= 3 }, 2*time.Second, 5*time.Millisecond)
+	cancel()
+	<-done
+
+	snapshot := service.ControllerStatus(false)
+	assert.True(t, snapshot.Fetched())
+}
+
+// Run with -race: requests read the client while a runtime reload swaps it
+func TestSetZTClientIsSafeDuringRequests(t *testing.T) {
+	var requests atomic.Int32
+	var failing atomic.Bool
+	clients := []*zerotier.Client{
+		countingStatusClient(t, "1.14.2", &requests, &failing),
+		countingStatusClient(t, "1.16.0", &requests, &failing),
+	}
+	service := services.NewNetworkService(clients[0], newTestSQLiteDB(t))
+
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+	for range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+				status, err := service.GetStatus()
+				if assert.NoError(t, err) {
+					assert.Contains(t, []string{"1.14.2", "1.16.0"}, status.Version)
+				}
+				service.GetRuntimeStatus(false)
+			}
+		}()
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for i := 0; requests.Load() < 100 && time.Now().Before(deadline); i++ {
+		service.SetZTClient(clients[i%2])
+		time.Sleep(time.Millisecond)
+	}
+	close(stop)
+	wg.Wait()
+	assert.GreaterOrEqual(t, requests.Load(), int32(100))
+}
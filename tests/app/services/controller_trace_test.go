@@ -0,0 +1,104 @@
+package services
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/GT-610/tairitsu/internal/app/models"
+	"github.com/GT-610/tairitsu/internal/app/services"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const traceNetworkID = "8056c2e21c000001"
+
+func readTraceSamples(t *testing.T) []string {
+	t.Helper()
+
+	raw, err := os.ReadFile("testdata/controller_trace.log")
+	require.NoError(t, err)
+	return strings.Split(string(raw), "\n")
+}
+
+func TestParseControllerTraceLineRecognizesKnownFormats(t *testing.T) {
+	lines := readTraceSamples(t)
+
+	accepted := services.ParseControllerTraceLine(lines[0])
+	assert.True(t, accepted.Parsed)
+	assert.Equal(t, services.TraceFormatJSON, accepted.Format)
+	assert.Equal(t, traceNetworkID, accepted.NetworkID)
+	assert.Equal(t, "a1a1a1a1a1", accepted.MemberID)
+	assert.Equal(t, services.TraceOutcomeAccept, accepted.Outcome)
+	assert.Equal(t, "allow-ssh", accepted.Rule)
+	assert.Equal(t, "RULE_EVALUATED", accepted.EventType)
+	require.NotNil(t, accepted.OccurredAt)
+	assert.True(t, accepted.OccurredAt.Equal(time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)))
+
+	dropped := services.ParseControllerTraceLine(lines[1])
+	assert.True(t, dropped.Parsed)
+	assert.Equal(t, traceNetworkID, dropped.NetworkID)
+	assert.Equal(t, services.TraceOutcomeDrop, dropped.Outcome)
+	require.NotNil(t, dropped.OccurredAt)
+	assert.True(t, dropped.OccurredAt.Equal(time.UnixMilli(1767323045000)))
+
+	syslog := services.ParseControllerTraceLine(lines[2])
+	assert.True(t, syslog.Parsed)
+	assert.Equal(t, services.TraceFormatKeyValue, syslog.Format)
+	assert.Equal(t, "a1a1a1a1a1", syslog.MemberID)
+	assert.Equal(t, services.TraceOutcomeDrop, syslog.Outcome)
+	assert.Equal(t, "deny all", syslog.Rule)
+	assert.Nil(t, syslog.OccurredAt)
+
+	tee := services.ParseControllerTraceLine(lines[3])
+	assert.True(t, tee.Parsed)
+	assert.Equal(t, "c3c3c3c3c3", tee.MemberID)
+	assert.Equal(t, "tee", tee.Outcome)
+
+	for _, line := range lines[5:8] {
+		event := services.ParseControllerTraceLine(line)
+		assert.False(t, event.Parsed, line)
+		assert.Equal(t, line, event.Raw)
+		assert.Empty(t, event.NetworkID)
+	}
+}
+
+func TestControllerTraceIngestionCorrelatesMembersAndPrunes(t *testing.T) {
+	db := newTestSQLiteDB(t)
+	createTestUser(t, db, "owner-1", "user")
+	createTestUser(t, db, "other-1", "user")
+	now := time.Now()
+	require.NoError(t, db.CreateNetwork(&models.Network{ID: traceNetworkID, Name: "trace", OwnerID: "owner-1", CreatedAt: now, UpdatedAt: now}))
+
+	traceService := services.NewControllerTraceService(db)
+	result, err := traceService.Ingest(readTraceSamples(t))
+	require.NoError(t, err)
+	assert.Equal(t, services.ControllerTraceIngestResult{Accepted: 7, Parsed: 4, ParseFailures: 3}, *result)
+	assert.Equal(t, services.ControllerTraceStats{Received: 7, Parsed: 4, ParseFailures: 3}, traceService.Stats())
+
+	parsed := false
+	raw, err := traceService.List(services.ControllerTraceListQuery{Parsed: &parsed})
+	require.NoError(t, err)
+	assert.Len(t, raw, 3)
+
+	networkService := services.NewNetworkService(nil, db)
+	decisions, err := networkService.GetMemberTraceEvents(traceNetworkID, "a1a1a1a1a1", "owner-1", 0)
+	require.NoError(t, err)
+	require.Len(t, decisions, 2)
+	assert.Equal(t, "deny all", decisions[0].Rule, "newest first")
+	assert.Equal(t, "allow-ssh", decisions[1].Rule)
+
+	_, err = networkService.GetMemberTraceEvents(traceNetworkID, "a1a1a1a1a1", "other-1", 0)
+	assert.True(t, services.IsNetworkAccessDenied(err))
+
+	pruned, err := traceService.Prune(time.Now().Add(time.Minute))
+	require.NoError(t, err)
+	assert.EqualValues(t, 7, pruned)
+	remaining, err := traceService.List(services.ControllerTraceListQuery{})
+	require.NoError(t, err)
+	assert.Empty(t, remaining)
+
+	_, err = traceService.Ingest(make([]string, services.ControllerTraceMaxLines+1))
+	assert.ErrorIs(t, err, services.ErrTraceBatchTooLarge)
+}
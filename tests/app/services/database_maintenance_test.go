@@ -0,0 +1,164 @@
+package services
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/GT-610/tairitsu/internal/app/database"
+	"github.com/GT-610/tairitsu/internal/app/models"
+	"github.com/GT-610/tairitsu/internal/app/services"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// bloatDatabase fills the trace table with large rows and prunes them, leaving free pages behind.
+// The last trace line is kept so compaction has live rows to preserve.
+func bloatDatabase(t *testing.T, db database.DBInterface) {
+	t.Helper()
+
+	old := time.Now().Add(-48 * time.Hour)
+	raw := strings.Repeat("x", 4000)
+	for batch := 0; batch < 10; batch++ {
+		events := make([]*models.ControllerTraceEvent, 0, 200)
+		for i := 0; i < 200; i++ {
+			events = append(events, &models.ControllerTraceEvent{ReceivedAt: old, Raw: raw})
+		}
+		require.NoError(t, db.CreateControllerTraceEvents(events))
+	}
+	require.NoError(t, db.CreateControllerTraceEvents([]*models.ControllerTraceEvent{{ReceivedAt: time.Now(), Raw: "kept"}}))
+
+	deleted, err := db.DeleteControllerTraceEventsBefore(time.Now().Add(-time.Hour))
+	require.NoError(t, err)
+	require.EqualValues(t, 2000, deleted)
+}
+
+func newDatabaseMaintenanceFixture(t *testing.T) (database.DBInterface, *services.DatabaseMaintenanceService, *services.AuditService) {
+	t.Helper()
+
+	db := newTestSQLiteDB(t)
+	audit := services.NewAuditService(db)
+	maintenance := services.NewDatabaseMaintenanceService(db, services.NewMaintenanceMode(), audit, time.Hour, 0.2)
+	return db, maintenance, audit
+}
+
+func databaseFileSize(t *testing.T, db database.DBInterface) (string, int64) {
+	t.Helper()
+
+	compactor, ok := database.AsCompactor(db)
+	require.True(t, ok)
+	stats, err := compactor.SQLiteFileStats()
+	require.NoError(t, err)
+	info, err := os.Stat(stats.Path)
+	require.NoError(t, err)
+	return stats.Path, info.Size()
+}
+
+func TestDatabaseCompactionShrinksBloatedFile(t *testing.T) {
+	db, maintenance, audit := newDatabaseMaintenanceFixture(t)
+	createTestUser(t, db, "admin-1", "admin")
+	bloatDatabase(t, db)
+
+	run, err := maintenance.Compact(services.CompactionTriggerManual, "admin-1", false)
+	require.NoError(t, err)
+	require.True(t, run.Compacted, run.SkippedReason)
+	assert.Greater(t, run.FreeRatioBefore, 0.2)
+	assert.Less(t, run.SizeAfter, run.SizeBefore/2)
+
+	_, size := databaseFileSize(t, db)
+	assert.Equal(t, run.SizeAfter, size, "the write-ahead log is truncated after compaction")
+
+	user, err := db.GetUserByID("admin-1")
+	require.NoError(t, err)
+	require.NotNil(t, user)
+	kept, err := db.QueryControllerTraceEvents(models.ControllerTraceQuery{Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, kept, 1)
+	assert.Equal(t, "kept", kept[0].Raw)
+
+	entries, err := db.ListAuditLogs(0, 10)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, services.AuditActionDatabaseCompact, entries[0].Action)
+	assert.Equal(t, "admin-1", entries[0].ActorID)
+	assert.Contains(t, entries[0].Details, "trigger=manual")
+	verification, err := audit.Verify()
+	require.NoError(t, err)
+	assert.True(t, verification.Valid)
+
+	status := maintenance.Status()
+	assert.True(t, status.Supported)
+	assert.False(t, status.Running)
+	assert.Equal(t, 20, status.FreePercentThreshold)
+	require.NotNil(t, status.LastRun)
+	assert.Equal(t, run.SizeAfter, status.LastRun.SizeAfter)
+}
+
+func TestDatabaseCompactionSkipsBelowThresholdUnlessForced(t *testing.T) {
+	db, maintenance, _ := newDatabaseMaintenanceFixture(t)
+	createTestUser(t, db, "admin-1", "admin")
+
+	run, err := maintenance.Compact(services.CompactionTriggerSchedule, "", false)
+	require.NoError(t, err)
+	assert.False(t, run.Compacted)
+	assert.NotEmpty(t, run.SkippedReason)
+
+	entries, err := db.ListAuditLogs(0, 10)
+	require.NoError(t, err)
+	assert.Empty(t, entries, "skipped runs are not audited")
+
+	run, err = maintenance.Compact(services.CompactionTriggerManual, "admin-1", true)
+	require.NoError(t, err)
+	assert.True(t, run.Compacted)
+}
+
+func TestDatabaseCompactionRefusesWithoutFreeDiskSpace(t *testing.T) {
+	db, maintenance, _ := newDatabaseMaintenanceFixture(t)
+	bloatDatabase(t, db)
+	_, sizeBefore := databaseFileSize(t, db)
+	maintenance.SetFreeDiskSpaceFunc(func(string) (int64, error) { return 1024, nil })
+
+	run, err := maintenance.Compact(services.CompactionTriggerManual, "admin-1", false)
+	assert.ErrorIs(t, err, services.ErrInsufficientDiskSpace)
+	require.NotNil(t, run)
+	assert.False(t, run.Compacted)
+	assert.NotEmpty(t, run.Error)
+
+	_, sizeAfter := databaseFileSize(t, db)
+	assert.Equal(t, sizeBefore, sizeAfter)
+	assert.Equal(t, run.Error, maintenance.Status().LastRun.Error)
+}
+
+func TestMaintenanceModePausesWrites(t *testing.T) {
+	mode := services.NewMaintenanceMode()
+
+	// Entering waits for the write in flight
+	release, ok := mode.AcquireWrite(0)
+	require.True(t, ok)
+	entered := make(chan func())
+	go func() { entered <- mode.Enter("test") }()
+	select {
+	case <-entered:
+		t.Fatal("maintenance started while a write was in flight")
+	case <-time.After(50 * time.Millisecond):
+	}
+	release()
+	resume := <-entered
+
+	reason, active := mode.Active()
+	assert.True(t, active)
+	assert.Equal(t, "test", reason)
+	_, ok = mode.AcquireWrite(50 * time.Millisecond)
+	assert.False(t, ok, "writes are turned away once the wait runs out")
+
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		resume()
+	}()
+	release, ok = mode.AcquireWrite(5 * time.Second)
+	require.True(t, ok, "a paused write proceeds when maintenance ends")
+	release()
+	_, active = mode.Active()
+	assert.False(t, active)
+}
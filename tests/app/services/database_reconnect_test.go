@@ -0,0 +1,89 @@
+package services
+
+import (
+	"context"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/GT-610/tairitsu/internal/app/config"
+	"github.com/GT-610/tairitsu/internal/app/services"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// closedPort returns a local port nothing listens on
+func closedPort(t *testing.T) int {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	port := ln.Addr().(*net.TCPAddr).Port
+	require.NoError(t, ln.Close())
+	return port
+}
+
+func TestRuntimeServiceReconnectsOnceTheDatabaseIsBack(t *testing.T) {
+	// A regular file where the database directory belongs keeps SQLite from opening until it is removed
+	blocker := filepath.Join(t.TempDir(), "data")
+	require.NoError(t, os.WriteFile(blocker, nil, 0600))
+	cfg := &config.Config{Initialized: true, Database: config.DatabaseConfig{
+		Type: config.DatabaseSQLite, Path: filepath.Join(blocker, "tairitsu.db"),
+	}}
+	userService := services.NewUserService(nil)
+	networkService := services.NewNetworkService(nil, nil)
+	runtimeService := services.NewRuntimeService(userService, services.NewSessionService(nil), networkService, services.NewStateServiceWithConfig(cfg))
+	runtimeService.SetDatabaseReconnectInterval(10 * time.Millisecond)
+	t.Cleanup(runtimeService.CloseCurrentDatabase)
+
+	err := runtimeService.ReopenConfiguredDatabase()
+	require.Error(t, err)
+	runtimeService.MarkDatabaseUnavailable(err)
+
+	health := services.NewHealthService(networkService)
+	health.SetDatabaseState(runtimeService)
+	report := health.Check(context.Background())
+	assert.Equal(t, services.HealthStatusDown, report.Components[services.HealthComponentDatabase].Status)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	done := runtimeService.StartDatabaseReconnect(ctx)
+
+	time.Sleep(50 * time.Millisecond)
+	assert.Error(t, runtimeService.DatabaseError(), "the database is still down")
+	assert.Nil(t, runtimeService.CurrentDatabase())
+
+	require.NoError(t, os.Remove(blocker))
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("reconnect loop did not finish after the database came back")
+	}
+	assert.NoError(t, runtimeService.DatabaseError())
+	require.NotNil(t, userService.GetDB())
+	assert.NoError(t, userService.GetDB().Ping())
+	assert.Equal(t, services.HealthStatusOK, health.Check(context.Background()).Components[services.HealthComponentDatabase].Status)
+}
+
+func TestRuntimeServiceReconnectStopsWithTheContext(t *testing.T) {
+	cfg := &config.Config{Initialized: true, Database: config.DatabaseConfig{
+		Type: config.DatabasePostgreSQL, Host: "127.0.0.1", Port: closedPort(t), User: "tairitsu", Name: "tairitsu",
+	}}
+	runtimeService := services.NewRuntimeService(services.NewUserService(nil), services.NewSessionService(nil), services.NewNetworkService(nil, nil), services.NewStateServiceWithConfig(cfg))
+	runtimeService.SetDatabaseReconnectInterval(10 * time.Millisecond)
+	runtimeService.MarkDatabaseUnavailable(assert.AnError)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := runtimeService.StartDatabaseReconnect(ctx)
+	time.Sleep(30 * time.Millisecond)
+	cancel()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("reconnect loop ignored cancellation")
+	}
+	assert.Error(t, runtimeService.DatabaseError())
+}
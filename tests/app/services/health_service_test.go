@@ -0,0 +1,86 @@
+package services
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/GT-610/tairitsu/internal/app/services"
+	"github.com/GT-610/tairitsu/internal/zerotier"
+	"github.com/GT-610/tairitsu/internal/zerotier/ztmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newHealthTestZTClient(t *testing.T) *zerotier.Client {
+	t.Helper()
+
+	controller := ztmock.NewController(ztmock.DemoAddress)
+	baseURL, err := controller.Start()
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = controller.Close()
+	})
+	return &zerotier.Client{BaseURL: baseURL, Token: controller.Token, HTTPClient: &http.Client{Timeout: 5 * time.Second}}
+}
+
+func TestHealthServiceReportsOKWhenDependenciesRespond(t *testing.T) {
+	service := services.NewHealthService(services.NewNetworkService(newHealthTestZTClient(t), newTestSQLiteDB(t)))
+
+	report := service.Check(context.Background())
+	assert.Equal(t, services.HealthStatusOK, report.Status)
+	assert.Equal(t, services.HealthStatusOK, report.Components[services.HealthComponentDatabase].Status)
+	assert.Equal(t, services.HealthStatusOK, report.Components[services.HealthComponentZeroTier].Status)
+}
+
+func TestHealthServiceReportsDegradedWhenControllerUnreachable(t *testing.T) {
+	client := newHealthTestZTClient(t)
+	client.Token = "wrong-token"
+	service := services.NewHealthService(services.NewNetworkService(client, newTestSQLiteDB(t)))
+
+	report := service.Check(context.Background())
+	assert.Equal(t, services.HealthStatusDegraded, report.Status)
+	zt := report.Components[services.HealthComponentZeroTier]
+	assert.Equal(t, services.HealthStatusDown, zt.Status)
+	assert.Equal(t, "controller unreachable", zt.Error)
+}
+
+func TestHealthServiceReportsDownWhenDatabaseGone(t *testing.T) {
+	db := newTestSQLiteDB(t)
+	require.NoError(t, db.Close())
+	service := services.NewHealthService(services.NewNetworkService(newHealthTestZTClient(t), db))
+
+	report := service.Check(context.Background())
+	assert.Equal(t, services.HealthStatusDown, report.Status)
+	assert.Equal(t, services.HealthStatusDown, report.Components[services.HealthComponentDatabase].Status)
+}
+
+func TestHealthServiceTimesOutHungController(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	t.Cleanup(server.Close)
+	t.Cleanup(func() { close(release) })
+
+	client := &zerotier.Client{BaseURL: server.URL, Token: "token", HTTPClient: server.Client()}
+	service := services.NewHealthService(services.NewNetworkService(client, newTestSQLiteDB(t)))
+	service.SetTimeout(50 * time.Millisecond)
+
+	start := time.Now()
+	report := service.Check(context.Background())
+	assert.Less(t, time.Since(start), time.Second)
+	assert.Equal(t, services.HealthStatusDegraded, report.Status)
+	assert.Equal(t, "health check timed out", report.Components[services.HealthComponentZeroTier].Error)
+}
+
+func TestHealthServiceIgnoresUnconfiguredDependencies(t *testing.T) {
+	service := services.NewHealthService(services.NewNetworkService(nil, nil))
+
+	report := service.Check(context.Background())
+	assert.Equal(t, services.HealthStatusOK, report.Status)
+	assert.Equal(t, services.HealthStatusNotConfigured, report.Components[services.HealthComponentDatabase].Status)
+	assert.Equal(t, services.HealthStatusNotConfigured, report.Components[services.HealthComponentZeroTier].Status)
+}
@@ -2,7 +2,9 @@ package services
 
 import (
 	"testing"
+	"time"
 
+	"github.com/GT-610/tairitsu/internal/app/config"
 	"github.com/GT-610/tairitsu/internal/app/models"
 	"github.com/GT-610/tairitsu/internal/app/services"
 	"github.com/golang-jwt/jwt/v4"
@@ -15,16 +17,24 @@ func TestNewJWTService(t *testing.T) {
 	secretKey := "test-secret-key"
 
 	// Act
-	jwtService := services.NewJWTService(secretKey)
+	jwtService, err := services.NewJWTService(secretKey)
 
 	// Assert
+	require.NoError(t, err)
 	assert.NotNil(t, jwtService)
 }
 
+func TestNewJWTServiceRejectsEmptySecret(t *testing.T) {
+	jwtService, err := services.NewJWTService("")
+
+	assert.ErrorIs(t, err, services.ErrEmptyJWTSecret)
+	assert.Nil(t, jwtService)
+}
+
 func TestJWTService_GenerateToken(t *testing.T) {
 	// Arrange
 	secretKey := "test-secret-key"
-	jwtService := services.NewJWTService(secretKey)
+	jwtService := newTestJWTService(t, secretKey)
 	user := &models.User{
 		ID:       "test-user-id",
 		Username: "test-user",
@@ -42,7 +52,7 @@ func TestJWTService_GenerateToken(t *testing.T) {
 func TestJWTService_ValidateToken(t *testing.T) {
 	// Arrange
 	secretKey := "test-secret-key"
-	jwtService := services.NewJWTService(secretKey)
+	jwtService := newTestJWTService(t, secretKey)
 	user := &models.User{
 		ID:       "test-user-id",
 		Username: "test-user",
@@ -68,7 +78,7 @@ func TestJWTService_ValidateToken(t *testing.T) {
 func TestJWTService_ValidateToken_InvalidToken(t *testing.T) {
 	// Arrange
 	secretKey := "test-secret-key"
-	jwtService := services.NewJWTService(secretKey)
+	jwtService := newTestJWTService(t, secretKey)
 	invalidToken := "invalid-token"
 
 	// Act
@@ -82,7 +92,7 @@ func TestJWTService_ValidateToken_InvalidToken(t *testing.T) {
 func TestJWTService_ValidateToken_WrongSecret(t *testing.T) {
 	// Arrange
 	// Generate token with one secret
-	jwtService1 := services.NewJWTService("secret1")
+	jwtService1 := newTestJWTService(t, "secret1")
 	user := &models.User{
 		ID:       "test-user-id",
 		Username: "test-user",
@@ -93,7 +103,7 @@ func TestJWTService_ValidateToken_WrongSecret(t *testing.T) {
 	assert.NoError(t, err)
 
 	// Try to validate with different secret
-	jwtService2 := services.NewJWTService("secret2")
+	jwtService2 := newTestJWTService(t, "secret2")
 
 	// Act
 	claims, err := jwtService2.ValidateToken(token)
@@ -114,8 +124,71 @@ func TestJWTService_ValidateToken_RejectsOtherHMACAlgorithms(t *testing.T) {
 	tokenString, err := token.SignedString([]byte(secret))
 	require.NoError(t, err)
 
-	claims, err := services.NewJWTService(secret).ValidateToken(tokenString)
+	claims, err := newTestJWTService(t, secret).ValidateToken(tokenString)
 
 	assert.ErrorContains(t, err, "invalid signing method")
 	assert.Nil(t, claims)
 }
+
+func newTestJWTService(t *testing.T, secretKey string) *services.JWTService {
+	t.Helper()
+	jwtService, err := services.NewJWTService(secretKey)
+	require.NoError(t, err)
+	return jwtService
+}
+
+func TestJWTServiceAcceptsTokensSignedBeforeRotation(t *testing.T) {
+	jwtService := newTestJWTService(t, "secret-before-rotation")
+	user := &models.User{ID: "user-1", Username: "alice", Role: "user"}
+	oldToken, err := jwtService.GenerateToken(user, "session-1")
+	require.NoError(t, err)
+
+	require.NoError(t, jwtService.SetSecrets("secret-after-rotation", []config.RetiredJWTSecret{
+		{Secret: "secret-before-rotation", ExpiresAt: time.Now().Add(time.Hour)},
+	}))
+
+	claims, err := jwtService.ValidateToken(oldToken)
+	require.NoError(t, err)
+	assert.Equal(t, "session-1", claims.SessionID)
+
+	newToken, err := jwtService.GenerateToken(user, "session-2")
+	require.NoError(t, err)
+	_, err = newTestJWTService(t, "secret-before-rotation").ValidateToken(newToken)
+	assert.Error(t, err, "new tokens must be signed with the current secret")
+	_, err = jwtService.ValidateToken(newToken)
+	assert.NoError(t, err)
+}
+
+func TestJWTServiceRejectsTokensOfExpiredPreviousSecrets(t *testing.T) {
+	jwtService := newTestJWTService(t, "secret-before-rotation")
+	oldToken, err := jwtService.GenerateToken(&models.User{ID: "user-1", Username: "alice", Role: "user"}, "session-1")
+	require.NoError(t, err)
+
+	require.NoError(t, jwtService.SetSecrets("secret-after-rotation", []config.RetiredJWTSecret{
+		{Secret: "secret-before-rotation", ExpiresAt: time.Now().Add(-time.Minute)},
+	}))
+
+	_, err = jwtService.ValidateToken(oldToken)
+	assert.ErrorIs(t, err, jwt.ErrSignatureInvalid)
+	assert.ErrorIs(t, jwtService.SetSecrets("", nil), services.ErrEmptyJWTSecret)
+}
+
+func TestJWTServiceReportsExpiryOfTokensSignedWithPreviousSecret(t *testing.T) {
+	expired := jwt.NewWithClaims(jwt.SigningMethodHS256, services.JWTClaims{
+		UserID: "user-1",
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(-time.Minute)),
+		},
+	})
+	tokenString, err := expired.SignedString([]byte("secret-before-rotation"))
+	require.NoError(t, err)
+
+	jwtService := newTestJWTService(t, "secret-after-rotation")
+	require.NoError(t, jwtService.SetSecrets("secret-after-rotation", []config.RetiredJWTSecret{
+		{Secret: "secret-before-rotation", ExpiresAt: time.Now().Add(time.Hour)},
+	}))
+
+	_, err = jwtService.ValidateToken(tokenString)
+	assert.ErrorIs(t, err, jwt.ErrTokenExpired)
+	assert.NotErrorIs(t, err, jwt.ErrSignatureInvalid)
+}
@@ -0,0 +1,171 @@
+package services
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/GT-610/tairitsu/internal/app/config"
+	"github.com/GT-610/tairitsu/internal/app/services"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// startLogShipper starts shipper and stops it when the test ends
+func startLogShipper(t *testing.T, shipper *services.LogShipper) {
+	t.Helper()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := shipper.Start(ctx)
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+}
+
+func TestLogShipperSendsRFC5424OverUDP(t *testing.T) {
+	listener, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = listener.Close() })
+
+	shipper := services.NewLogShipper(&config.LogShippingConfig{Target: "udp://" + listener.LocalAddr().String(), Format: config.LogShippingFormatRFC5424, QueueSize: 8})
+	startLogShipper(t, shipper)
+	shipper.Ship(services.ShippedEvent{Kind: services.ShippedEventAuth, Action: services.AuthEventLoginFailed, Target: `ali"ce`, Details: "method=password", IP: "192.0.2.7"})
+
+	buffer := make([]byte, 2048)
+	require.NoError(t, listener.SetReadDeadline(time.Now().Add(5*time.Second)))
+	n, _, err := listener.ReadFrom(buffer)
+	require.NoError(t, err)
+	message := string(buffer[:n])
+
+	// authpriv (10) * 8 + warning (4)
+	assert.True(t, strings.HasPrefix(message, "<84>1 "), message)
+	assert.Contains(t, message, " tairitsu ")
+	assert.Contains(t, message, " auth.login_failed ")
+	assert.Contains(t, message, `[tairitsu@32473 kind="auth" target="ali\"ce" ip="192.0.2.7"] method=password`)
+}
+
+func TestLogShipperSendsJSONLinesOverTCP(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = listener.Close() })
+
+	shipper := services.NewLogShipper(&config.LogShippingConfig{Target: "tcp://" + listener.Addr().String(), Format: config.LogShippingFormatJSON, QueueSize: 8})
+	startLogShipper(t, shipper)
+	shipper.Ship(services.ShippedEvent{Kind: services.ShippedEventAudit, Action: "POST", ActorID: "admin-1", Target: "/api/networks"})
+	shipper.Ship(services.ShippedEvent{Kind: services.ShippedEventAudit, Action: "DELETE", ActorID: "admin-1", Target: "/api/networks/1"})
+
+	conn, err := listener.Accept()
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(5*time.Second)))
+	reader := bufio.NewReader(conn)
+	for _, action := range []string{"POST", "DELETE"} {
+		line, err := reader.ReadString('\n')
+		require.NoError(t, err)
+		var event services.ShippedEvent
+		require.NoError(t, json.Unmarshal([]byte(line), &event))
+		assert.Equal(t, action, event.Action)
+		assert.Equal(t, "admin-1", event.ActorID)
+		assert.False(t, event.Time.IsZero())
+	}
+
+	require.Eventually(t, func() bool { return shipper.Status().Shipped == 2 }, 5*time.Second, 10*time.Millisecond)
+	status := shipper.Status()
+	assert.True(t, status.Connected)
+	assert.Zero(t, status.QueueDepth)
+}
+
+// An event that could not be sent is kept and sent once the TCP target is back
+func TestLogShipperReconnectsToTCPTarget(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	address := listener.Addr().String()
+	require.NoError(t, listener.Close())
+
+	shipper := services.NewLogShipper(&config.LogShippingConfig{Target: "tcp://" + address, Format: config.LogShippingFormatRFC5424, QueueSize: 8})
+	startLogShipper(t, shipper)
+	shipper.Ship(services.ShippedEvent{Kind: services.ShippedEventAudit, Action: "PUT", Target: "/api/users/1"})
+
+	require.Eventually(t, func() bool { return shipper.Status().LastError != "" }, 5*time.Second, 10*time.Millisecond)
+	assert.False(t, shipper.Status().Connected)
+
+	listener, err = net.Listen("tcp", address)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = listener.Close() })
+	conn, err := listener.Accept()
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(5*time.Second)))
+
+	// Octet counting frames every message with its length
+	reader := bufio.NewReader(conn)
+	prefix, err := reader.ReadString(' ')
+	require.NoError(t, err)
+	length, err := strconv.Atoi(strings.TrimSpace(prefix))
+	require.NoError(t, err)
+	message := make([]byte, length)
+	_, err = io.ReadFull(reader, message)
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(string(message), "<109>1 "), string(message))
+	assert.Contains(t, string(message), `target="/api/users/1"`)
+	require.Eventually(t, func() bool { return shipper.Status().Connected }, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestLogShipperDropsAndCountsEventsWhenTheQueueIsFull(t *testing.T) {
+	shipper := services.NewLogShipper(&config.LogShippingConfig{Target: "udp://127.0.0.1:9", Format: config.LogShippingFormatJSON, QueueSize: 2})
+
+	// Not started, so nothing leaves the queue
+	for range 5 {
+		shipper.Ship(services.ShippedEvent{Kind: services.ShippedEventAudit, Action: "POST"})
+	}
+
+	status := shipper.Status()
+	assert.True(t, status.Enabled)
+	assert.Equal(t, 2, status.QueueDepth)
+	assert.Equal(t, 2, status.QueueCapacity)
+	assert.Equal(t, int64(3), status.Dropped)
+}
+
+func TestLogShipperWithoutTargetIgnoresEvents(t *testing.T) {
+	shipper := services.NewLogShipper(nil)
+	shipper.Ship(services.ShippedEvent{Kind: services.ShippedEventAudit, Action: "POST"})
+
+	assert.Equal(t, services.LogShippingStatus{}, shipper.Status())
+	<-shipper.Start(context.Background())
+}
+
+// Recorded audit entries reach a JSON Lines file, and events queued at shutdown are written
+func TestAuditServiceShipsRecordedEntriesToFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.jsonl")
+	shipper := services.NewLogShipper(&config.LogShippingConfig{Target: "file:" + path, Format: config.LogShippingFormatJSON, QueueSize: 8})
+	db, _ := newAuditTestDB(t)
+	audit := services.NewAuditService(db)
+	audit.SetLogShipper(shipper)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := shipper.Start(ctx)
+	entries := recordAuditEntries(t, audit, 2)
+	require.Eventually(t, func() bool { return shipper.Status().Shipped == 2 }, 5*time.Second, 10*time.Millisecond)
+	cancel()
+	<-done
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	require.Len(t, lines, 2)
+	var event services.ShippedEvent
+	require.NoError(t, json.Unmarshal([]byte(lines[1]), &event))
+	assert.Equal(t, services.ShippedEventAudit, event.Kind)
+	assert.Equal(t, entries[1].Target, event.Target)
+	assert.Equal(t, entries[1].ActorID, event.ActorID)
+	assert.True(t, entries[1].CreatedAt.Equal(event.Time))
+}
@@ -0,0 +1,133 @@
+package services
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/GT-610/tairitsu/internal/app/models"
+	"github.com/GT-610/tairitsu/internal/app/services"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const pendingMemberID = "b000000002"
+
+type recordingApprovalNotifier struct {
+	notifications []services.PendingApprovalNotification
+}
+
+func (n *recordingApprovalNotifier) NotifyPendingApproval(notification services.PendingApprovalNotification) error {
+	n.notifications = append(n.notifications, notification)
+	return nil
+}
+
+func TestMemberStatusCollectorQueuesUnauthorizedMembersOnce(t *testing.T) {
+	controller, _, service, networkID := newMemberHistoryFixture(t)
+	controller.AddMember(networkID, pendingMemberID, map[string]any{"authorized": false, "name": "new laptop"})
+	notifier := &recordingApprovalNotifier{}
+	collector := services.NewMemberStatusCollector(service, time.Minute)
+	collector.SetApprovalNotifier(notifier)
+
+	require.NoError(t, collector.Poll())
+	require.NoError(t, collector.Poll())
+
+	approvals, err := service.ListPendingApprovals("owner-1")
+	require.NoError(t, err)
+	require.Len(t, approvals, 1)
+	assert.Equal(t, pendingMemberID, approvals[0].MemberID)
+	assert.Equal(t, "new laptop", approvals[0].Name)
+	require.Len(t, notifier.notifications, 1)
+	assert.Equal(t, services.PendingApprovalEvent, notifier.notifications[0].Event)
+	assert.Equal(t, "history", notifier.notifications[0].NetworkName)
+
+	others, err := service.ListPendingApprovals("other-1")
+	require.NoError(t, err)
+	assert.Empty(t, others)
+
+	// Authorizing the member elsewhere resolves the entry
+	controller.AddMember(networkID, pendingMemberID, map[string]any{"authorized": true})
+	require.NoError(t, collector.Poll())
+	approvals, err = service.ListPendingApprovals("owner-1")
+	require.NoError(t, err)
+	assert.Empty(t, approvals)
+}
+
+func TestDecidePendingApprovalUpdatesTheMember(t *testing.T) {
+	controller, _, service, networkID := newMemberHistoryFixture(t)
+	controller.AddMember(networkID, pendingMemberID, map[string]any{"authorized": false})
+	collector := services.NewMemberStatusCollector(service, time.Minute)
+	require.NoError(t, collector.Poll())
+
+	approvals, err := service.ListPendingApprovals("owner-1")
+	require.NoError(t, err)
+	require.Len(t, approvals, 1)
+	id := approvals[0].ID
+
+	_, err = service.DecidePendingApproval(id, true, "other-1")
+	assert.True(t, services.IsNetworkAccessDenied(err), "only the owner may decide: %v", err)
+
+	approval, err := service.DecidePendingApproval(id, true, "owner-1")
+	require.NoError(t, err)
+	assert.Equal(t, models.ApprovalApproved, approval.Status)
+	assert.Equal(t, "owner-1", approval.DecidedBy)
+	require.NotNil(t, approval.DecidedAt)
+
+	member, err := service.GetNetworkMember(networkID, pendingMemberID, "owner-1")
+	require.NoError(t, err)
+	assert.True(t, member.Config.Authorized)
+
+	_, err = service.DecidePendingApproval(id, false, "owner-1")
+	assert.ErrorIs(t, err, services.ErrApprovalDecided)
+	_, err = service.DecidePendingApproval(id+100, true, "owner-1")
+	assert.ErrorIs(t, err, services.ErrApprovalNotFound)
+}
+
+func TestDeniedMembersAreNotQueuedAgain(t *testing.T) {
+	controller, _, service, networkID := newMemberHistoryFixture(t)
+	controller.AddMember(networkID, pendingMemberID, map[string]any{"authorized": false})
+	notifier := &recordingApprovalNotifier{}
+	collector := services.NewMemberStatusCollector(service, time.Minute)
+	collector.SetApprovalNotifier(notifier)
+	require.NoError(t, collector.Poll())
+
+	approvals, err := service.ListPendingApprovals("owner-1")
+	require.NoError(t, err)
+	require.Len(t, approvals, 1)
+	_, err = service.DecidePendingApproval(approvals[0].ID, false, "owner-1")
+	require.NoError(t, err)
+
+	require.NoError(t, collector.Poll())
+	approvals, err = service.ListPendingApprovals("owner-1")
+	require.NoError(t, err)
+	assert.Empty(t, approvals)
+	assert.Len(t, notifier.notifications, 1)
+}
+
+func TestWebhookApprovalNotifierPostsJSON(t *testing.T) {
+	received := make(chan services.PendingApprovalNotification, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		var notification services.PendingApprovalNotification
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&notification))
+		received <- notification
+	}))
+	defer server.Close()
+
+	notifier := services.NewWebhookApprovalNotifier(server.URL)
+	approval := &models.PendingApproval{ID: 7, NetworkID: "8056c2e21c000001", MemberID: pendingMemberID, Status: models.ApprovalPending}
+	require.NoError(t, notifier.NotifyPendingApproval(services.PendingApprovalNotification{Event: services.PendingApprovalEvent, NetworkName: "office", Approval: approval}))
+
+	notification := <-received
+	assert.Equal(t, "office", notification.NetworkName)
+	require.NotNil(t, notification.Approval)
+	assert.Equal(t, pendingMemberID, notification.Approval.MemberID)
+
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer failing.Close()
+	assert.Error(t, services.NewWebhookApprovalNotifier(failing.URL).NotifyPendingApproval(services.PendingApprovalNotification{Approval: approval}))
+}
@@ -0,0 +1,193 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/GT-610/tairitsu/internal/app/models"
+	"github.com/GT-610/tairitsu/internal/app/services"
+	"github.com/GT-610/tairitsu/internal/zerotier"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func receiveMemberEvents(subscription *services.MemberEventSubscription) []services.MemberEvent {
+	var events []services.MemberEvent
+	for {
+		select {
+		case event, ok := <-subscription.Events():
+			if !ok {
+				return events
+			}
+			events = append(events, event)
+		default:
+			return events
+		}
+	}
+}
+
+func TestMemberEventHubPublishesChangesAfterBaseline(t *testing.T) {
+	controller, _, service, networkID := newMemberHistoryFixture(t)
+	hub := services.NewMemberEventHub(service, time.Minute)
+
+	subscription, err := hub.Subscribe(networkID, "owner-1")
+	require.NoError(t, err)
+	defer subscription.Close()
+
+	require.NoError(t, hub.Poll())
+	assert.Empty(t, receiveMemberEvents(subscription), "the first poll only records a baseline")
+
+	controller.AddMember(networkID, historyMemberID, map[string]any{"authorized": false, "online": false})
+	controller.AddMember(networkID, "b000000002", map[string]any{"authorized": false})
+	require.NoError(t, hub.Poll())
+
+	events := receiveMemberEvents(subscription)
+	require.Len(t, events, 3)
+	assert.Equal(t, services.MemberEventDeauthorized, events[0].Type)
+	assert.Equal(t, services.MemberEventOffline, events[1].Type)
+	assert.Equal(t, historyMemberID, events[1].MemberID)
+	assert.Equal(t, services.MemberEventJoined, events[2].Type)
+	require.NotNil(t, events[2].Member)
+	assert.Equal(t, "b000000002", events[2].Member.ID)
+	assert.Equal(t, networkID, events[2].NetworkID)
+
+	require.NoError(t, hub.Poll())
+	assert.Empty(t, receiveMemberEvents(subscription))
+}
+
+func TestMemberEventHubRejectsAndClosesUnauthorizedSubscribers(t *testing.T) {
+	_, db, service, networkID := newMemberHistoryFixture(t)
+	hub := services.NewMemberEventHub(service, time.Minute)
+
+	_, err := hub.Subscribe(networkID, "other-1")
+	assert.True(t, services.IsNetworkAccessDenied(err))
+	assert.Zero(t, hub.Subscribers(networkID))
+
+	require.NoError(t, db.UpsertNetworkViewer(&models.NetworkViewer{NetworkID: networkID, UserID: "other-1", GrantedBy: "owner-1", CreatedAt: time.Now(), UpdatedAt: time.Now()}))
+	subscription, err := hub.Subscribe(networkID, "other-1")
+	require.NoError(t, err)
+	assert.Equal(t, 1, hub.Subscribers(networkID))
+
+	require.NoError(t, db.DeleteNetworkViewer(networkID, "other-1"))
+	require.NoError(t, hub.Poll())
+
+	_, open := <-subscription.Events()
+	assert.False(t, open)
+	assert.True(t, services.IsNetworkAccessDenied(subscription.Err()))
+	assert.Zero(t, hub.Subscribers(networkID))
+}
+
+func TestMemberEventHubStopClosesSubscriptions(t *testing.T) {
+	_, _, service, networkID := newMemberHistoryFixture(t)
+	hub := services.NewMemberEventHub(service, time.Minute)
+	subscription, err := hub.Subscribe(networkID, "owner-1")
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := hub.Start(ctx)
+	cancel()
+	<-done
+
+	_, open := <-subscription.Events()
+	assert.False(t, open)
+	assert.ErrorIs(t, subscription.Err(), services.ErrMemberEventsStopped)
+	_, err = hub.Subscribe(networkID, "owner-1")
+	assert.ErrorIs(t, err, services.ErrMemberEventsStopped)
+}
+
+func onlyPollSchedule(t *testing.T, hub *services.MemberEventHub) services.MemberPollSchedule {
+	t.Helper()
+
+	schedules := hub.PollSchedules()
+	require.Len(t, schedules, 1)
+	return schedules[0]
+}
+
+func TestMemberEventHubBacksOffIdleNetworksAndPollsOnAttach(t *testing.T) {
+	controller, _, service, networkID := newMemberHistoryFixture(t)
+	hub := services.NewMemberEventHub(service, 10*time.Second)
+	clock := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	hub.SetClock(func() time.Time { return clock })
+
+	subscription, err := hub.Subscribe(networkID, "owner-1")
+	require.NoError(t, err)
+	defer subscription.Close()
+	schedule := onlyPollSchedule(t, hub)
+	assert.Equal(t, services.MemberPollReasonImmediate, schedule.Reason)
+	assert.Equal(t, clock, schedule.NextPollAt)
+
+	require.NoError(t, hub.PollDue())
+	schedule = onlyPollSchedule(t, hub)
+	assert.Equal(t, services.MemberPollReasonBase, schedule.Reason)
+	assert.Equal(t, clock.Add(10*time.Second), schedule.NextPollAt)
+
+	clock = clock.Add(5 * time.Second)
+	require.NoError(t, hub.PollDue())
+	assert.Equal(t, 1, onlyPollSchedule(t, hub).IdlePolls, "a network is not polled before it is due")
+
+	var intervals []float64
+	for range 6 {
+		clock = onlyPollSchedule(t, hub).NextPollAt
+		require.NoError(t, hub.PollDue())
+		intervals = append(intervals, onlyPollSchedule(t, hub).IntervalSeconds)
+	}
+	assert.Equal(t, []float64{10, 10, 20, 40, 80, 80}, intervals)
+	assert.Equal(t, services.MemberPollReasonIdle, onlyPollSchedule(t, hub).Reason)
+
+	second, err := hub.Subscribe(networkID, "owner-1")
+	require.NoError(t, err)
+	defer second.Close()
+	schedule = onlyPollSchedule(t, hub)
+	assert.Equal(t, services.MemberPollReasonImmediate, schedule.Reason)
+	assert.Equal(t, clock, schedule.NextPollAt)
+	assert.Equal(t, 2, schedule.Subscribers)
+
+	controller.AddMember(networkID, historyMemberID, map[string]any{"authorized": true, "online": false})
+	require.NoError(t, hub.PollDue())
+	schedule = onlyPollSchedule(t, hub)
+	assert.Equal(t, services.MemberPollReasonBase, schedule.Reason, "a change resets the backoff")
+	assert.Zero(t, schedule.IdlePolls)
+	assert.Len(t, receiveMemberEvents(subscription), 1)
+}
+
+func TestMemberEventHubAcceleratesAfterMutationsAndForPendingMembers(t *testing.T) {
+	controller, _, service, networkID := newMemberHistoryFixture(t)
+	hub := services.NewMemberEventHub(service, 10*time.Second)
+	clock := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	hub.SetClock(func() time.Time { return clock })
+
+	subscription, err := hub.Subscribe(networkID, "owner-1")
+	require.NoError(t, err)
+	defer subscription.Close()
+	require.NoError(t, hub.PollDue())
+
+	clock = clock.Add(time.Second)
+	_, err = service.UpdateNetworkMember(networkID, historyMemberID, &zerotier.MemberUpdateRequest{Name: "renamed"}, "owner-1")
+	require.NoError(t, err)
+	schedule := onlyPollSchedule(t, hub)
+	assert.Equal(t, services.MemberPollReasonMutation, schedule.Reason)
+	assert.Equal(t, 2.0, schedule.IntervalSeconds)
+	assert.Equal(t, clock.Add(2*time.Second), schedule.NextPollAt)
+
+	clock = schedule.NextPollAt
+	require.NoError(t, hub.PollDue())
+	assert.Equal(t, services.MemberPollReasonMutation, onlyPollSchedule(t, hub).Reason, "the boost outlasts one poll")
+
+	clock = clock.Add(time.Minute)
+	require.NoError(t, hub.PollDue())
+	assert.Equal(t, services.MemberPollReasonBase, onlyPollSchedule(t, hub).Reason)
+
+	controller.AddMember(networkID, "b000000002", map[string]any{"authorized": false})
+	clock = onlyPollSchedule(t, hub).NextPollAt
+	require.NoError(t, hub.PollDue())
+	schedule = onlyPollSchedule(t, hub)
+	assert.Equal(t, services.MemberPollReasonPending, schedule.Reason)
+	assert.Equal(t, 2.0, schedule.IntervalSeconds)
+
+	assert.True(t, services.IsNetworkAccessDenied(hub.Refresh(networkID, "other-1")))
+	require.NoError(t, hub.Refresh(networkID, "owner-1"))
+	schedule = onlyPollSchedule(t, hub)
+	assert.Equal(t, services.MemberPollReasonImmediate, schedule.Reason)
+	assert.Equal(t, clock, schedule.NextPollAt)
+}
@@ -0,0 +1,64 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/GT-610/tairitsu/internal/app/services"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemberExpiryDeauthorizesOnceTheDeadlinePassed(t *testing.T) {
+	_, db, service, networkID := newMemberHistoryFixture(t)
+	job := services.NewMemberExpiryService(service, services.NewAuditService(db), time.Minute)
+
+	_, err := service.SetMemberAuthorizedUntil(networkID, historyMemberID, deadlineIn(-time.Minute), "owner-1")
+	assert.ErrorIs(t, err, services.ErrAuthorizedUntilPast)
+	_, err = service.SetMemberAuthorizedUntil(networkID, historyMemberID, deadlineIn(time.Hour), "other-1")
+	require.Error(t, err, "only members with write access may set a deadline")
+
+	metadata, err := service.SetMemberAuthorizedUntil(networkID, historyMemberID, deadlineIn(time.Hour), "owner-1")
+	require.NoError(t, err)
+	require.NotNil(t, metadata.AuthorizationRemainingSeconds)
+	assert.InDelta(t, 3600, *metadata.AuthorizationRemainingSeconds, 5)
+
+	// Extending the deadline before it passes keeps the member authorized
+	_, err = service.SetMemberAuthorizedUntil(networkID, historyMemberID, deadlineIn(7*24*time.Hour), "owner-1")
+	require.NoError(t, err)
+	run, err := job.Run(time.Now().Add(2 * time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, 0, run.Expired)
+	member, err := service.GetNetworkMember(networkID, historyMemberID, "owner-1")
+	require.NoError(t, err)
+	assert.True(t, member.Config.Authorized)
+
+	// A run long after the deadline, as after downtime, still deauthorizes, and only once
+	run, err = job.Run(time.Now().Add(30 * 24 * time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, 1, run.Expired)
+	member, err = service.GetNetworkMember(networkID, historyMemberID, "owner-1")
+	require.NoError(t, err)
+	assert.False(t, member.Config.Authorized)
+	require.NotNil(t, member.Metadata)
+	assert.Nil(t, member.Metadata.AuthorizedUntil)
+
+	run, err = job.Run(time.Now().Add(30 * 24 * time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, 0, run.Expired)
+
+	entries, err := db.ListRecentAuditLogs(10)
+	require.NoError(t, err)
+	expired := 0
+	for _, entry := range entries {
+		if entry.Action == services.AuditActionMemberAuthorizationExpired {
+			expired++
+		}
+	}
+	assert.Equal(t, 1, expired)
+}
+
+func deadlineIn(d time.Duration) *time.Time {
+	at := time.Now().Add(d)
+	return &at
+}
@@ -0,0 +1,96 @@
+package services
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/GT-610/tairitsu/internal/app/models"
+	"github.com/GT-610/tairitsu/internal/app/services"
+	"github.com/GT-610/tairitsu/internal/zerotier"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func exportMembersFixture() []zerotier.Member {
+	lastSeen := time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+	return []zerotier.Member{
+		{ID: "a1a1a1a1a1", Address: "a1a1a1a1a1", Name: `Lab, "east" rack`, Authorized: true, Online: true, IPAssignments: []string{"10.0.0.2", "fd00::2"}, LastSeen: lastSeen.UnixMilli()},
+		{ID: "b2b2b2b2b2", Name: "=HYPERLINK(\"x\")"},
+	}
+}
+
+func TestMemberExportCSVQuotesAndGuardsCells(t *testing.T) {
+	var out bytes.Buffer
+	require.NoError(t, services.WriteMemberExport(&out, exportMembersFixture(), services.MemberExportOptions{Format: services.MemberExportCSV, BOM: true}))
+
+	raw := out.Bytes()
+	require.True(t, bytes.HasPrefix(raw, []byte{0xEF, 0xBB, 0xBF}))
+	records, err := csv.NewReader(bytes.NewReader(raw[3:])).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, records, 3)
+	assert.Equal(t, []string{"address", "name", "authorized", "online", "ipAssignments", "lastSeen"}, records[0])
+	assert.Equal(t, []string{"a1a1a1a1a1", `Lab, "east" rack`, "true", "true", "10.0.0.2;fd00::2", "2026-05-01T12:00:00Z"}, records[1])
+	assert.Equal(t, []string{"b2b2b2b2b2", `'=HYPERLINK("x")`, "false", "false", "", ""}, records[2])
+	assert.Contains(t, string(raw), `"Lab, ""east"" rack"`)
+
+	out.Reset()
+	require.NoError(t, services.WriteMemberExport(&out, nil, services.MemberExportOptions{Format: services.MemberExportCSV}))
+	assert.Equal(t, "address,name,authorized,online,ipAssignments,lastSeen\n", out.String())
+}
+
+func TestMemberExportJSON(t *testing.T) {
+	var out bytes.Buffer
+	require.NoError(t, services.WriteMemberExport(&out, exportMembersFixture(), services.MemberExportOptions{Format: services.MemberExportJSON}))
+
+	var rows []services.MemberExportRow
+	require.NoError(t, json.Unmarshal(out.Bytes(), &rows))
+	require.Len(t, rows, 2)
+	assert.Equal(t, `Lab, "east" rack`, rows[0].Name)
+	assert.Equal(t, []string{"10.0.0.2", "fd00::2"}, rows[0].IPAssignments)
+	assert.Equal(t, "=HYPERLINK(\"x\")", rows[1].Name, "JSON keeps names verbatim")
+	assert.Empty(t, rows[1].LastSeen)
+	assert.Equal(t, []string{}, rows[1].IPAssignments)
+
+	out.Reset()
+	require.NoError(t, services.WriteMemberExport(&out, nil, services.MemberExportOptions{Format: services.MemberExportJSON}))
+	assert.JSONEq(t, "[]", out.String())
+
+	assert.ErrorIs(t, services.WriteMemberExport(&out, nil, services.MemberExportOptions{Format: "xml"}), services.ErrInvalidExportFormat)
+}
+
+func TestExportNetworkMembersTakesOnlineFromPeersAndLastSeenFromHistory(t *testing.T) {
+	const networkID = "8056c2e21c000001"
+	// A lastOnline sent by the controller is not trusted
+	service, db := newPeerControllerService(t, networkID, `[
+		{"id":"1111111111","address":"1111111111"},
+		{"id":"2222222222","address":"2222222222"},
+		{"id":"3333333333","address":"3333333333","lastOnline":1700000000000}
+	]`, "1111111111")
+	wentOffline := time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+	require.NoError(t, db.CreateMemberStatusEvents([]*models.MemberStatusEvent{
+		{NetworkID: networkID, MemberID: "2222222222", Online: true, ChangedAt: wentOffline.Add(-time.Hour)},
+		{NetworkID: networkID, MemberID: "2222222222", Online: false, ChangedAt: wentOffline},
+		// First seen offline, so it was never seen at all
+		{NetworkID: networkID, MemberID: "3333333333", Online: false, ChangedAt: wentOffline},
+	}))
+
+	members, err := service.ExportNetworkMembers(networkID, "owner-1", services.MemberListQuery{})
+	require.NoError(t, err)
+	require.Len(t, members, 3)
+	rows := make([]services.MemberExportRow, 0, len(members))
+	for _, member := range members {
+		rows = append(rows, services.NewMemberExportRow(member))
+	}
+
+	assert.True(t, rows[0].Online)
+	lastSeen, err := time.Parse(time.RFC3339, rows[0].LastSeen)
+	require.NoError(t, err)
+	assert.WithinDuration(t, time.Now(), lastSeen, time.Minute, "an online member is seen now")
+	assert.False(t, rows[1].Online)
+	assert.Equal(t, "2026-05-01T12:00:00Z", rows[1].LastSeen)
+	assert.False(t, rows[2].Online)
+	assert.Empty(t, rows[2].LastSeen)
+}
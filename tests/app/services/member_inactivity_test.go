@@ -0,0 +1,113 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/GT-610/tairitsu/internal/app/database"
+	"github.com/GT-610/tairitsu/internal/app/models"
+	"github.com/GT-610/tairitsu/internal/app/services"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	staleMemberID    = "c000000001"
+	pinnedMemberID   = "c000000002"
+	bannedMemberID   = "c000000003"
+	returnedMemberID = "c000000004"
+	unknownMemberID  = "c000000005"
+)
+
+// Members are added in the shape ZeroTier 1.14 controllers return, which has no lastOnline;
+// inactivity comes from peers and the status history alone.
+func TestInactivityPolicyFlagsThenDeauthorizesStaleMembers(t *testing.T) {
+	controller, db, service, networkID := newMemberHistoryFixture(t)
+	longAgo := time.Now().Add(-40 * 24 * time.Hour)
+	controller.AddMember(networkID, staleMemberID, map[string]any{"authorized": true, "name": "old laptop"})
+	controller.AddMember(networkID, pinnedMemberID, map[string]any{"authorized": true})
+	controller.AddMember(networkID, bannedMemberID, map[string]any{"authorized": false})
+	// Recorded offline long ago but a peer now, before the collector noticed
+	controller.AddMember(networkID, returnedMemberID, map[string]any{"authorized": true, "online": true})
+	// Never recorded, so nothing is known about when it was last online
+	controller.AddMember(networkID, unknownMemberID, map[string]any{"authorized": true})
+	events := []*models.MemberStatusEvent{}
+	for _, memberID := range []string{staleMemberID, pinnedMemberID, bannedMemberID, returnedMemberID} {
+		events = append(events, &models.MemberStatusEvent{NetworkID: networkID, MemberID: memberID, Online: false, ChangedAt: longAgo})
+	}
+	require.NoError(t, db.CreateMemberStatusEvents(events))
+	_, err := service.UpdateMemberMetadata(networkID, pinnedMemberID, services.MemberMetadataUpdate{Tags: map[string]string{services.PinnedMemberTag: "true"}}, "owner-1")
+	require.NoError(t, err)
+	job := services.NewMemberInactivityService(service, services.NewAuditService(db), time.Hour)
+
+	_, err = service.UpdateNetworkPolicies(networkID, services.NetworkPolicies{Inactivity: services.InactivityPolicy{Days: 30, Action: "delete"}}, "owner-1")
+	assert.ErrorIs(t, err, services.ErrInvalidInactivityPolicy)
+	_, err = service.UpdateNetworkPolicies(networkID, services.NetworkPolicies{Inactivity: services.InactivityPolicy{Days: 30}}, "other-1")
+	assert.True(t, services.IsNetworkAccessDenied(err), "only the owner may change policies: %v", err)
+	policies, err := service.UpdateNetworkPolicies(networkID, services.NetworkPolicies{Inactivity: services.InactivityPolicy{Days: 30}}, "owner-1")
+	require.NoError(t, err)
+	assert.Equal(t, models.InactivityActionFlag, policies.Inactivity.Action)
+
+	// Flagging only reports the stale member, and audits it once
+	for range 2 {
+		reports, err := job.Run(time.Now())
+		require.NoError(t, err)
+		require.Len(t, reports, 1)
+		require.Len(t, reports[0].Inactive, 1)
+		assert.Equal(t, staleMemberID, reports[0].Inactive[0].MemberID)
+		assert.GreaterOrEqual(t, reports[0].Inactive[0].InactiveDays, 39)
+		assert.Empty(t, reports[0].Deauthorized)
+	}
+	assert.Equal(t, 1, countAuditActions(t, db, services.AuditActionMemberInactiveFlagged))
+	member, err := service.GetNetworkMember(networkID, staleMemberID, "owner-1")
+	require.NoError(t, err)
+	assert.True(t, member.Config.Authorized)
+
+	preview, err := service.PreviewInactivity(networkID, 0, "owner-1")
+	require.NoError(t, err)
+	assert.True(t, preview.DryRun)
+	require.Len(t, preview.Inactive, 1)
+	assert.Equal(t, staleMemberID, preview.Inactive[0].MemberID)
+
+	// Enforcing deauthorizes it and queues it for approval again
+	_, err = service.UpdateNetworkPolicies(networkID, services.NetworkPolicies{Inactivity: services.InactivityPolicy{Days: 30, Action: models.InactivityActionEnforce}}, "owner-1")
+	require.NoError(t, err)
+	reports, err := job.Run(time.Now())
+	require.NoError(t, err)
+	require.Len(t, reports, 1)
+	assert.Equal(t, []string{staleMemberID}, reports[0].Deauthorized)
+
+	member, err = service.GetNetworkMember(networkID, staleMemberID, "owner-1")
+	require.NoError(t, err)
+	assert.False(t, member.Config.Authorized)
+	for _, memberID := range []string{pinnedMemberID, returnedMemberID, unknownMemberID, historyMemberID} {
+		member, err = service.GetNetworkMember(networkID, memberID, "owner-1")
+		require.NoError(t, err)
+		assert.True(t, member.Config.Authorized, "member %s is left alone", memberID)
+	}
+
+	approvals, err := service.ListPendingApprovals("owner-1")
+	require.NoError(t, err)
+	require.Len(t, approvals, 1)
+	assert.Equal(t, staleMemberID, approvals[0].MemberID)
+	assert.Equal(t, "old laptop", approvals[0].Name)
+	assert.Equal(t, 1, countAuditActions(t, db, services.AuditActionMemberInactiveDeauthorized))
+
+	status := job.Status()
+	require.NotNil(t, status.LastRun)
+	assert.Equal(t, 1, status.LastRun.Deauthorized)
+}
+
+func countAuditActions(t *testing.T, db database.DBInterface, action string) int {
+	t.Helper()
+
+	entries, err := db.ListRecentAuditLogs(50)
+	require.NoError(t, err)
+	count := 0
+	for _, entry := range entries {
+		if entry.Action == action {
+			count++
+		}
+	}
+	return count
+}
@@ -0,0 +1,90 @@
+package services
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/GT-610/tairitsu/internal/app/services"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func stringPtr(value string) *string {
+	return &value
+}
+
+func TestMemberMetadataIsMergedIntoMemberResponses(t *testing.T) {
+	service, networkID := newPrivacyNetworkService(t)
+
+	metadata, err := service.UpdateMemberMetadata(networkID, privacyIPv4MemberID, services.MemberMetadataUpdate{
+		DisplayName: stringPtr("  Build server "),
+		Notes:       stringPtr("Rack 4, shelf 2"),
+		Tags:        map[string]string{"owner": "ops", "asset": "A-1042"},
+	}, "owner-1")
+	require.NoError(t, err)
+	assert.Equal(t, "Build server", metadata.DisplayName)
+	assert.Equal(t, "owner-1", metadata.UpdatedBy)
+
+	// A partial update keeps the fields it leaves out
+	_, err = service.UpdateMemberMetadata(networkID, privacyIPv4MemberID, services.MemberMetadataUpdate{Notes: stringPtr("Moved to rack 5")}, "owner-1")
+	require.NoError(t, err)
+
+	member, err := service.GetNetworkMember(networkID, privacyIPv4MemberID, "viewer-1")
+	require.NoError(t, err)
+	require.NotNil(t, member.Metadata)
+	assert.Equal(t, "Build server", member.Metadata.DisplayName)
+	assert.Equal(t, "Moved to rack 5", member.Metadata.Notes)
+	assert.Equal(t, map[string]string{"owner": "ops", "asset": "A-1042"}, member.Metadata.Tags)
+
+	members, err := service.GetNetworkMembers(networkID, "owner-1")
+	require.NoError(t, err)
+	for _, listed := range members {
+		if listed.ID == privacyIPv4MemberID {
+			require.NotNil(t, listed.Metadata)
+			assert.Equal(t, "Build server", listed.Metadata.DisplayName)
+		} else {
+			assert.Nil(t, listed.Metadata)
+		}
+	}
+}
+
+func TestMemberSearchMatchesMetadata(t *testing.T) {
+	service, networkID := newPrivacyNetworkService(t)
+	_, err := service.UpdateMemberMetadata(networkID, privacyIPv6MemberID, services.MemberMetadataUpdate{
+		DisplayName: stringPtr("Lab printer"),
+		Notes:       stringPtr("Second floor"),
+	}, "owner-1")
+	require.NoError(t, err)
+
+	for _, search := range []string{"PRINTER", "second floor"} {
+		page, err := service.GetNetworkMembersPage(networkID, "owner-1", services.MemberListQuery{Search: search}, services.PageRequest{Limit: 10})
+		require.NoError(t, err)
+		require.Len(t, page.Items, 1, search)
+		assert.Equal(t, privacyIPv6MemberID, page.Items[0].ID)
+	}
+}
+
+func TestMemberMetadataRequiresWriteAccessAndAKnownMember(t *testing.T) {
+	service, networkID := newPrivacyNetworkService(t)
+
+	_, err := service.UpdateMemberMetadata(networkID, privacyIPv4MemberID, services.MemberMetadataUpdate{DisplayName: stringPtr("viewer edit")}, "viewer-1")
+	assert.True(t, services.IsNetworkAccessDenied(err), "viewers cannot edit metadata: %v", err)
+
+	_, err = service.UpdateMemberMetadata(networkID, "a0000000ff", services.MemberMetadataUpdate{DisplayName: stringPtr("ghost")}, "owner-1")
+	assert.ErrorIs(t, err, services.ErrMemberNotFound)
+
+	_, err = service.UpdateMemberMetadata(networkID, privacyIPv4MemberID, services.MemberMetadataUpdate{Notes: stringPtr(strings.Repeat("x", 4097))}, "owner-1")
+	assert.ErrorIs(t, err, services.ErrInvalidMemberMetadata)
+}
+
+func TestRemovingAMemberDeletesItsMetadata(t *testing.T) {
+	service, networkID := newPrivacyNetworkService(t)
+	_, err := service.UpdateMemberMetadata(networkID, privacyIPv4MemberID, services.MemberMetadataUpdate{DisplayName: stringPtr("Build server")}, "owner-1")
+	require.NoError(t, err)
+
+	require.NoError(t, service.RemoveNetworkMember(networkID, privacyIPv4MemberID, "owner-1"))
+
+	metadata, err := service.GetDB().GetMemberMetadata(networkID, privacyIPv4MemberID)
+	require.NoError(t, err)
+	assert.Nil(t, metadata)
+}
@@ -0,0 +1,104 @@
+package services
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/GT-610/tairitsu/internal/app/database"
+	"github.com/GT-610/tairitsu/internal/app/models"
+	"github.com/GT-610/tairitsu/internal/app/services"
+	"github.com/GT-610/tairitsu/internal/zerotier"
+	"github.com/GT-610/tairitsu/internal/zerotier/ztmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const historyMemberID = "b000000001"
+
+func newMemberHistoryFixture(t *testing.T) (*ztmock.Controller, database.DBInterface, *services.NetworkService, string) {
+	t.Helper()
+
+	controller := ztmock.NewController(ztmock.DemoAddress)
+	networkID := controller.AddNetwork(map[string]any{"name": "history"})
+	controller.AddMember(networkID, historyMemberID, map[string]any{"authorized": true, "online": true})
+	baseURL, err := controller.Start()
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = controller.Close()
+	})
+
+	db := newTestSQLiteDB(t)
+	now := time.Now()
+	createTestUser(t, db, "owner-1", "user")
+	createTestUser(t, db, "other-1", "user")
+	require.NoError(t, db.CreateNetwork(&models.Network{ID: networkID, Name: "history", OwnerID: "owner-1", CreatedAt: now, UpdatedAt: now}))
+
+	client := &zerotier.Client{BaseURL: baseURL, Token: controller.Token, HTTPClient: &http.Client{Timeout: 5 * time.Second}}
+	return controller, db, services.NewNetworkService(client, db), networkID
+}
+
+func TestMemberStatusCollectorRecordsOnlyTransitions(t *testing.T) {
+	controller, _, service, networkID := newMemberHistoryFixture(t)
+	collector := services.NewMemberStatusCollector(service, time.Minute)
+
+	require.NoError(t, collector.Poll())
+	require.NoError(t, collector.Poll())
+	controller.AddMember(networkID, historyMemberID, map[string]any{"authorized": true, "online": false})
+	require.NoError(t, collector.Poll())
+
+	history, err := service.GetMemberStatusHistory(networkID, historyMemberID, "owner-1", services.MemberStatusHistoryQuery{})
+	require.NoError(t, err)
+	require.Len(t, history.Points, 2)
+	assert.True(t, history.Points[0].Online)
+	assert.False(t, history.Points[1].Online)
+	assert.Nil(t, history.InitialOnline)
+	assert.False(t, history.Downsampled)
+}
+
+func TestMemberStatusCollectorResumesFromStoredState(t *testing.T) {
+	_, _, service, networkID := newMemberHistoryFixture(t)
+
+	require.NoError(t, services.NewMemberStatusCollector(service, time.Minute).Poll())
+	require.NoError(t, services.NewMemberStatusCollector(service, time.Minute).Poll())
+
+	history, err := service.GetMemberStatusHistory(networkID, historyMemberID, "owner-1", services.MemberStatusHistoryQuery{})
+	require.NoError(t, err)
+	assert.Len(t, history.Points, 1)
+}
+
+func TestMemberStatusHistoryFiltersAndDownsamples(t *testing.T) {
+	_, db, service, networkID := newMemberHistoryFixture(t)
+
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	var events []*models.MemberStatusEvent
+	for i := 0; i < 100; i++ {
+		events = append(events, &models.MemberStatusEvent{
+			NetworkID: networkID,
+			MemberID:  historyMemberID,
+			Online:    i%2 == 0,
+			ChangedAt: start.Add(time.Duration(i) * time.Minute),
+		})
+	}
+	require.NoError(t, db.CreateMemberStatusEvents(events))
+
+	history, err := service.GetMemberStatusHistory(networkID, historyMemberID, "owner-1", services.MemberStatusHistoryQuery{
+		From:      start.Add(10 * time.Minute),
+		To:        start.Add(99 * time.Minute),
+		MaxPoints: 9,
+	})
+	require.NoError(t, err)
+	require.NotNil(t, history.InitialOnline)
+	assert.False(t, *history.InitialOnline)
+	assert.True(t, history.Downsampled)
+	assert.LessOrEqual(t, len(history.Points), 9)
+	last := history.Points[len(history.Points)-1]
+	assert.True(t, last.Time.Equal(start.Add(99*time.Minute)))
+	assert.False(t, last.Online)
+
+	_, err = service.GetMemberStatusHistory(networkID, historyMemberID, "owner-1", services.MemberStatusHistoryQuery{From: start, To: start.Add(-time.Minute)})
+	assert.ErrorIs(t, err, services.ErrInvalidHistoryRange)
+
+	_, err = service.GetMemberStatusHistory(networkID, historyMemberID, "other-1", services.MemberStatusHistoryQuery{})
+	assert.True(t, services.IsNetworkAccessDenied(err))
+}
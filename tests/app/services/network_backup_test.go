@@ -0,0 +1,121 @@
+package services
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/GT-610/tairitsu/internal/app/models"
+	"github.com/GT-610/tairitsu/internal/app/services"
+	"github.com/GT-610/tairitsu/internal/zerotier"
+	"github.com/GT-610/tairitsu/internal/zerotier/ztmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newBackupNetworkService(t *testing.T) (*services.NetworkService, *ztmock.Controller, string) {
+	t.Helper()
+
+	controller := ztmock.NewController(ztmock.DemoAddress)
+	networkID := controller.AddNetwork(map[string]any{
+		"name":              "lab",
+		"mtu":               1400,
+		"enableBroadcast":   false,
+		"routes":            []any{map[string]any{"target": "10.20.0.0/24"}},
+		"ipAssignmentPools": []any{map[string]any{"ipRangeStart": "10.20.0.10", "ipRangeEnd": "10.20.0.200"}},
+		"v4AssignMode":      map[string]any{"zt": true},
+		"dns":               map[string]any{"domain": "lab.internal", "servers": []any{"10.20.0.1"}},
+	})
+	controller.AddMember(networkID, "a1a1a1a1a1", map[string]any{"name": "gateway", "authorized": true, "activeBridge": true, "ipAssignments": []any{"10.20.0.1"}})
+	controller.AddMember(networkID, "b2b2b2b2b2", map[string]any{"name": "pending"})
+	baseURL, err := controller.Start()
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = controller.Close()
+	})
+
+	db := newTestSQLiteDB(t)
+	now := time.Now()
+	require.NoError(t, db.CreateUser(&models.User{ID: "owner-1", Username: "owner", Password: "hashed", Role: "user", CreatedAt: now, UpdatedAt: now}))
+	require.NoError(t, db.CreateUser(&models.User{ID: "other-1", Username: "other", Password: "hashed", Role: "user", CreatedAt: now, UpdatedAt: now}))
+	require.NoError(t, db.CreateNetwork(&models.Network{ID: networkID, Name: "lab", Description: "Lab network", OwnerID: "owner-1", CreatedAt: now, UpdatedAt: now}))
+
+	client := &zerotier.Client{BaseURL: baseURL, Token: controller.Token, HTTPClient: &http.Client{Timeout: 5 * time.Second}}
+	return services.NewNetworkService(client, db), controller, networkID
+}
+
+func TestNetworkBackupRoundTrip(t *testing.T) {
+	service, _, networkID := newBackupNetworkService(t)
+
+	backup, err := service.ExportNetwork(networkID, "owner-1")
+	require.NoError(t, err)
+	assert.Equal(t, services.NetworkBackupVersion, backup.Version)
+	assert.Equal(t, "Lab network", backup.Network.Description)
+	require.Len(t, backup.Members, 2)
+
+	// The document survives being saved to a file and read back
+	raw, err := json.Marshal(backup)
+	require.NoError(t, err)
+	var decoded services.NetworkBackup
+	require.NoError(t, json.Unmarshal(raw, &decoded))
+
+	result, err := service.RestoreNetwork("", &decoded, "owner-1")
+	require.NoError(t, err)
+	assert.NotEqual(t, networkID, result.Network.ID)
+	assert.Equal(t, 2, result.RestoredMembers)
+	assert.Empty(t, result.FailedMembers)
+
+	restored, err := service.GetNetworkByID(result.Network.ID, "owner-1")
+	require.NoError(t, err)
+	assert.Equal(t, "lab", restored.Network.Name)
+	assert.Equal(t, "Lab network", restored.DBDescription)
+	assert.Equal(t, 1400, restored.Network.Config.Mtu)
+	assert.False(t, restored.Network.Config.EnableBroadcast)
+	assert.True(t, restored.Network.Config.V4AssignMode.ZT)
+	assert.Equal(t, []zerotier.Route{{Target: "10.20.0.0/24"}}, restored.Network.Config.Routes)
+	assert.Equal(t, "lab.internal", restored.Network.Config.DNS.Domain)
+
+	again, err := service.ExportNetwork(result.Network.ID, "owner-1")
+	require.NoError(t, err)
+	assert.Equal(t, backup.Network.Config, again.Network.Config)
+	assert.Equal(t, backup.Members, again.Members)
+}
+
+func TestNetworkRestoreReportsFailedMembers(t *testing.T) {
+	service, _, networkID := newBackupNetworkService(t)
+
+	backup, err := service.ExportNetwork(networkID, "owner-1")
+	require.NoError(t, err)
+	backup.Members = append(backup.Members,
+		services.NetworkBackupMember{Address: "not-a-node"},
+		services.NetworkBackupMember{Address: "A1A1A1A1A1", Authorized: true},
+	)
+
+	result, err := service.RestoreNetwork("", backup, "other-1")
+	require.NoError(t, err)
+	assert.Equal(t, 2, result.RestoredMembers)
+	require.Len(t, result.FailedMembers, 2)
+	assert.Equal(t, services.RestoreReasonInvalidAddress, result.FailedMembers[0].ReasonCode)
+	assert.Equal(t, "not-a-node", result.FailedMembers[0].Address)
+	assert.Equal(t, services.RestoreReasonDuplicate, result.FailedMembers[1].ReasonCode)
+
+	member, err := service.GetNetworkMember(result.Network.ID, "a1a1a1a1a1", "other-1")
+	require.NoError(t, err)
+	assert.True(t, member.Authorized)
+	pending, err := service.GetNetworkMember(result.Network.ID, "b2b2b2b2b2", "other-1")
+	require.NoError(t, err)
+	assert.False(t, pending.Authorized)
+}
+
+func TestNetworkBackupChecksOwnershipAndVersion(t *testing.T) {
+	service, _, networkID := newBackupNetworkService(t)
+
+	_, err := service.ExportNetwork(networkID, "other-1")
+	assert.ErrorIs(t, err, services.ErrNetworkAccessDenied)
+
+	_, err = service.RestoreNetwork("", &services.NetworkBackup{Version: 99}, "owner-1")
+	assert.ErrorIs(t, err, services.ErrUnsupportedBackupVersion)
+	_, err = service.RestoreNetwork("", nil, "owner-1")
+	assert.ErrorIs(t, err, services.ErrInvalidNetworkBackup)
+}
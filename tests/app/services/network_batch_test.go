@@ -0,0 +1,172 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/GT-610/tairitsu/internal/app/models"
+	"github.com/GT-610/tairitsu/internal/app/services"
+	"github.com/GT-610/tairitsu/internal/zerotier"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// batchController is a fake controller that creates networks with sequential IDs and
+// refuses to create the network named failName while failing is set
+type batchController struct {
+	failName string
+	failing  atomic.Bool
+
+	mutex     sync.Mutex
+	created   map[string]string // network ID to name
+	updates   []zerotier.NetworkUpdateRequest
+	inFlight  int
+	maxFlight int
+}
+
+func (c *batchController) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if r.URL.Path == "/status" {
+		_, _ = w.Write([]byte(`{"version":"1.14.2","online":true}`))
+		return
+	}
+	if r.URL.Path != "/controller/network" {
+		var update zerotier.NetworkUpdateRequest
+		_ = json.NewDecoder(r.Body).Decode(&update)
+		c.mutex.Lock()
+		c.updates = append(c.updates, update)
+		c.mutex.Unlock()
+		_ = json.NewEncoder(w).Encode(zerotier.Network{ID: r.URL.Path[len("/controller/network/"):]})
+		return
+	}
+
+	var network zerotier.Network
+	_ = json.NewDecoder(r.Body).Decode(&network)
+	c.mutex.Lock()
+	c.inFlight++
+	c.maxFlight = max(c.maxFlight, c.inFlight)
+	c.mutex.Unlock()
+	time.Sleep(20 * time.Millisecond)
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.inFlight--
+	if network.Name == c.failName && c.failing.Load() {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`{"error":"refused"}`))
+		return
+	}
+	network.ID = fmt.Sprintf("8056c2e21c%06x", len(c.created)+1)
+	c.created[network.ID] = network.Name
+	_ = json.NewEncoder(w).Encode(network)
+}
+
+func (c *batchController) createdCount() int {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return len(c.created)
+}
+
+// waitForOperation waits until an operation has finished and returns it
+func waitForOperation(t *testing.T, operations *services.OperationManager, id, userID string) *services.OperationDetail {
+	t.Helper()
+
+	var detail *services.OperationDetail
+	require.Eventually(t, func() bool {
+		var err error
+		detail, err = operations.Get(id, userID, false)
+		require.NoError(t, err)
+		return detail.Finished()
+	}, 5*time.Second, 10*time.Millisecond)
+	return detail
+}
+
+func TestNetworkServiceBatchCreatesNetworksAndResumesFailedItems(t *testing.T) {
+	db := newTestSQLiteDB(t)
+	createTestUser(t, db, "teacher", "user")
+	createTestUser(t, db, "student", "user")
+	createTestUser(t, db, "admin", "admin")
+
+	controller := &batchController{failName: "lab-03", created: make(map[string]string)}
+	controller.failing.Store(true)
+	server := httptest.NewServer(controller)
+	t.Cleanup(server.Close)
+	service := services.NewNetworkService(&zerotier.Client{BaseURL: server.URL, Token: "test-token", HTTPClient: server.Client()}, db)
+	operations := services.NewOperationManager(db, 2)
+	service.SetOperationManager(operations)
+
+	mtu := 1400
+	req := services.NetworkBatchRequest{
+		Count:          12,
+		NamePattern:    "lab-{index}",
+		Description:    "semester lab",
+		Config:         &zerotier.NetworkUpdateRequest{Name: "ignored", Mtu: &mtu},
+		IdempotencyKey: "semester-start",
+	}
+	started, err := service.StartNetworkBatch(req, "teacher")
+	require.NoError(t, err)
+	assert.Equal(t, 12, started.Total)
+	require.Len(t, started.Items, 12)
+	assert.Equal(t, "lab-01", started.Items[0].Name)
+	assert.Equal(t, "lab-12", started.Items[11].Name)
+
+	detail := waitForOperation(t, operations, started.ID, "teacher")
+	assert.Equal(t, models.OperationStatusFailed, detail.Status)
+	assert.Equal(t, "1 of 12 networks were not created", detail.Error)
+	assert.Equal(t, 11, detail.Done)
+	assert.Equal(t, 1, detail.Failed)
+	assert.Equal(t, models.OperationItemFailed, detail.Items[2].Status)
+	assert.Contains(t, detail.Items[2].Error, "refused")
+	assert.NotEmpty(t, detail.Items[0].NetworkID)
+	assert.Equal(t, 11, controller.createdCount())
+	assert.LessOrEqual(t, controller.maxFlight, 4, "creations run in a bounded pool")
+	assert.Greater(t, controller.maxFlight, 1, "creations run concurrently")
+	require.Len(t, controller.updates, 11)
+	assert.Equal(t, 1400, *controller.updates[0].Mtu)
+	assert.Empty(t, controller.updates[0].Name, "the batch config does not rename networks")
+
+	stored, err := db.GetNetworkByID(detail.Items[0].NetworkID)
+	require.NoError(t, err)
+	require.NotNil(t, stored)
+	assert.Equal(t, "lab-01", stored.Name)
+	assert.Equal(t, "teacher", stored.OwnerID)
+
+	// Retrying with the key creates only the network that failed
+	controller.failing.Store(false)
+	_, err = service.StartNetworkBatch(req, "teacher")
+	require.NoError(t, err)
+	detail = waitForOperation(t, operations, started.ID, "teacher")
+	assert.Equal(t, models.OperationStatusSucceeded, detail.Status)
+	assert.Equal(t, 12, detail.Done)
+	assert.Zero(t, detail.Failed)
+	assert.Empty(t, detail.Items[2].Error)
+	assert.Equal(t, 12, controller.createdCount())
+	var result services.NetworkBatchResult
+	require.NoError(t, json.Unmarshal(detail.Result, &result))
+	require.Len(t, result.NetworkIDs, 12)
+	assert.Equal(t, detail.Items[2].NetworkID, result.NetworkIDs[2])
+
+	again, err := service.StartNetworkBatch(req, "teacher")
+	require.NoError(t, err)
+	assert.Equal(t, started.ID, again.ID)
+	assert.Equal(t, models.OperationStatusSucceeded, again.Status)
+	assert.Equal(t, 12, controller.createdCount(), "a finished batch is not run again")
+
+	changed := req
+	changed.Count = 13
+	_, err = service.StartNetworkBatch(changed, "teacher")
+	assert.ErrorIs(t, err, services.ErrIdempotencyKeyReused)
+}
+
+func TestNetworkBatchNamesPadIndexes(t *testing.T) {
+	assert.Equal(t, []string{"a-1-x", "a-2-x"}, services.NetworkBatchNames("a-{index}-x", 2))
+	names := services.NetworkBatchNames("lab{index}", 100)
+	assert.Equal(t, "lab001", names[0])
+	assert.Equal(t, "lab100", names[99])
+}
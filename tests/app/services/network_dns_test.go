@@ -0,0 +1,78 @@
+package services
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/GT-610/tairitsu/internal/app/services"
+	"github.com/GT-610/tairitsu/internal/zerotier"
+	"github.com/GT-610/tairitsu/internal/zerotier/ztmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateDNSConfig(t *testing.T) {
+	valid := []zerotier.DNSConfig{
+		{},
+		{Domain: "home.arpa"},
+		{Domain: "lab-1.example.com.", Servers: []string{"10.0.0.1", "fd00::53"}},
+		{Servers: []string{"1.1.1.1"}},
+	}
+	for _, dns := range valid {
+		assert.NoError(t, services.ValidateDNSConfig(&dns), "%+v", dns)
+	}
+
+	invalid := []zerotier.DNSConfig{
+		{Domain: "lab example"},
+		{Domain: "-lab.example"},
+		{Domain: "lab..example"},
+		{Domain: strings.Repeat("a", 64) + ".example"},
+		{Domain: "lab.example", Servers: []string{"dns.example"}},
+		{Domain: "lab.example", Servers: []string{"10.0.0.1/24"}},
+		{Domain: "lab.example", Servers: []string{"fe80::1%eth0"}},
+		{Domain: "lab.example", Servers: []string{"10.0.0.1", "10.0.0.1"}},
+		{Domain: "lab.example", Servers: []string{"10.0.0.1", "10.0.0.2", "10.0.0.3", "10.0.0.4", "10.0.0.5"}},
+	}
+	for _, dns := range invalid {
+		assert.ErrorIs(t, services.ValidateDNSConfig(&dns), services.ErrInvalidDNSConfig, "%+v", dns)
+	}
+}
+
+func TestNetworkServiceSetsAndClearsDNS(t *testing.T) {
+	controller := ztmock.NewController(ztmock.DemoAddress)
+	baseURL, err := controller.Start()
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = controller.Close()
+	})
+	db := newTestSQLiteDB(t)
+	createTestUser(t, db, "user-1", "user")
+	client := &zerotier.Client{BaseURL: baseURL, Token: controller.Token, HTTPClient: &http.Client{Timeout: 5 * time.Second}}
+	service := services.NewNetworkService(client, db)
+
+	created, err := service.CreateNetwork("", &zerotier.Network{Name: "lab", Config: zerotier.NetworkConfig{
+		DNS: zerotier.DNSConfig{Domain: " lab.example ", Servers: []string{"10.20.0.1"}},
+	}}, "user-1")
+	require.NoError(t, err)
+	assert.Equal(t, zerotier.DNSConfig{Domain: "lab.example", Servers: []string{"10.20.0.1"}}, created.Config.DNS)
+
+	_, err = service.CreateNetwork("", &zerotier.Network{Name: "bad", Config: zerotier.NetworkConfig{
+		DNS: zerotier.DNSConfig{Domain: "lab.example", Servers: []string{"resolver"}},
+	}}, "user-1")
+	assert.ErrorIs(t, err, services.ErrInvalidDNSConfig)
+	assert.Len(t, controller.NetworkIDs(), 1, "an invalid configuration creates no network")
+
+	_, err = service.UpdateNetwork(created.ID, &zerotier.NetworkUpdateRequest{DNS: &zerotier.DNSConfig{Domain: "lab_example"}}, "user-1")
+	assert.ErrorIs(t, err, services.ErrInvalidDNSConfig)
+
+	// Leaving DNS out keeps it, and an empty configuration clears it on the controller
+	updated, err := service.UpdateNetwork(created.ID, &zerotier.NetworkUpdateRequest{Name: "lab-2"}, "user-1")
+	require.NoError(t, err)
+	assert.Equal(t, "lab.example", updated.Config.DNS.Domain)
+	updated, err = service.UpdateNetwork(created.ID, &zerotier.NetworkUpdateRequest{DNS: &zerotier.DNSConfig{}}, "user-1")
+	require.NoError(t, err)
+	assert.Empty(t, updated.Config.DNS.Domain)
+	assert.Empty(t, updated.Config.DNS.Servers)
+}
@@ -0,0 +1,76 @@
+package services
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/GT-610/tairitsu/internal/app/models"
+	"github.com/GT-610/tairitsu/internal/app/services"
+	"github.com/GT-610/tairitsu/internal/zerotier"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNetworkServiceGetNetworkIPv6PrefixesReportsEnabledModes(t *testing.T) {
+	db := newTestSQLiteDB(t)
+	now := time.Now()
+	require.NoError(t, db.CreateNetwork(&models.Network{
+		ID:        "8056c2e21c000001",
+		Name:      "alpha",
+		OwnerID:   "owner-1",
+		CreatedAt: now,
+		UpdatedAt: now,
+	}))
+
+	service := services.NewNetworkService(newRawNetworkZTClient(t, "8056c2e21c000001",
+		`{"id":"8056c2e21c000001","name":"alpha","private":true,"v6AssignMode":{"6plane":true,"rfc4193":false,"zt":false}}`), db)
+
+	prefixes, err := service.GetNetworkIPv6Prefixes("8056c2e21c000001", "owner-1")
+	require.NoError(t, err)
+	assert.Equal(t, "8056c2e21c000001", prefixes.NetworkID)
+	assert.True(t, prefixes.SixPlaneEnabled)
+	assert.Equal(t, "fc9c:56c2:e300::/40", prefixes.SixPlanePrefix)
+	assert.False(t, prefixes.RFC4193Enabled)
+	assert.Equal(t, "fd80:56c2:e21c:0:199:9300::/88", prefixes.RFC4193Prefix)
+}
+
+func TestNetworkServiceGetNetworkIPv6PrefixesRejectsUnrelatedUser(t *testing.T) {
+	db := newTestSQLiteDB(t)
+	now := time.Now()
+	require.NoError(t, db.CreateNetwork(&models.Network{
+		ID:        "8056c2e21c000001",
+		Name:      "alpha",
+		OwnerID:   "owner-1",
+		CreatedAt: now,
+		UpdatedAt: now,
+	}))
+
+	service := services.NewNetworkService(newRawNetworkZTClient(t, "8056c2e21c000001", `{"id":"8056c2e21c000001"}`), db)
+
+	prefixes, err := service.GetNetworkIPv6Prefixes("8056c2e21c000001", "owner-2")
+	require.ErrorIs(t, err, services.ErrMemberAccessDenied)
+	assert.Nil(t, prefixes)
+}
+
+// newRawNetworkZTClient serves a captured controller network body verbatim, keeping the flat controller layout.
+func newRawNetworkZTClient(t *testing.T, networkID string, body string) *zerotier.Client {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/controller/network/"+networkID {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(body))
+	}))
+	t.Cleanup(server.Close)
+
+	return &zerotier.Client{
+		BaseURL:    server.URL,
+		Token:      "test-token",
+		HTTPClient: server.Client(),
+	}
+}
@@ -0,0 +1,134 @@
+package services
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/GT-610/tairitsu/internal/app/models"
+	"github.com/GT-610/tairitsu/internal/app/services"
+	"github.com/GT-610/tairitsu/internal/zerotier"
+	"github.com/GT-610/tairitsu/internal/zerotier/ztmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	privacyIPv4MemberID = "a000000001"
+	privacyIPv6MemberID = "a000000002"
+)
+
+// newPrivacyNetworkService serves two online members on a network owned by owner-1 and
+// shared read-only with viewer-1 and admin-1.
+func newPrivacyNetworkService(t *testing.T) (*services.NetworkService, string) {
+	t.Helper()
+
+	controller := ztmock.NewController(ztmock.DemoAddress)
+	networkID := controller.AddNetwork(map[string]any{"name": "privacy"})
+	controller.AddMember(networkID, privacyIPv4MemberID, map[string]any{"authorized": true, "online": true, "physicalAddress": "203.0.113.45/9993"})
+	controller.AddMember(networkID, privacyIPv6MemberID, map[string]any{"authorized": true, "online": true, "physicalAddress": "2001:db8:abcd:12::1/9993"})
+	baseURL, err := controller.Start()
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = controller.Close()
+	})
+
+	db := newTestSQLiteDB(t)
+	now := time.Now()
+	for _, user := range []models.User{
+		{ID: "owner-1", Username: "owner", Password: "hashed", Role: "user"},
+		{ID: "viewer-1", Username: "viewer", Password: "hashed", Role: "user"},
+		{ID: "admin-1", Username: "admin", Password: "hashed", Role: "admin"},
+	} {
+		user.CreatedAt, user.UpdatedAt = now, now
+		require.NoError(t, db.CreateUser(&user))
+	}
+	require.NoError(t, db.CreateNetwork(&models.Network{ID: networkID, Name: "privacy", OwnerID: "owner-1", CreatedAt: now, UpdatedAt: now}))
+	for _, userID := range []string{"viewer-1", "admin-1"} {
+		require.NoError(t, db.UpsertNetworkViewer(&models.NetworkViewer{NetworkID: networkID, UserID: userID, GrantedBy: "owner-1", CreatedAt: now, UpdatedAt: now}))
+	}
+
+	client := &zerotier.Client{BaseURL: baseURL, Token: controller.Token, HTTPClient: &http.Client{Timeout: 5 * time.Second}}
+	return services.NewNetworkService(client, db), networkID
+}
+
+// visiblePaths collects the physical address returned for each member by every read endpoint.
+func visiblePaths(t *testing.T, service *services.NetworkService, networkID string, userID string) map[string][]string {
+	t.Helper()
+
+	paths := map[string][]string{}
+	members, err := service.GetNetworkMembers(networkID, userID)
+	require.NoError(t, err)
+	for _, member := range members {
+		paths[member.ID] = append(paths[member.ID], member.PreferredPath)
+	}
+
+	page, err := service.GetNetworkMembersPage(networkID, userID, services.MemberListQuery{}, services.PageRequest{Limit: 10})
+	require.NoError(t, err)
+	for _, member := range page.Items {
+		paths[member.ID] = append(paths[member.ID], member.PreferredPath)
+	}
+
+	for _, memberID := range []string{privacyIPv4MemberID, privacyIPv6MemberID} {
+		member, err := service.GetNetworkMember(networkID, memberID, userID)
+		require.NoError(t, err)
+		paths[memberID] = append(paths[memberID], member.PreferredPath)
+	}
+	return paths
+}
+
+func TestPhysicalAddressPolicyForViewers(t *testing.T) {
+	tests := []struct {
+		policy string
+		ipv4   string
+		ipv6   string
+	}{
+		{policy: models.PhysicalAddressPolicyFull, ipv4: "203.0.113.45/9993", ipv6: "2001:db8:abcd:12::1/9993"},
+		{policy: models.PhysicalAddressPolicyTruncated, ipv4: "203.0.113.0/24", ipv6: "2001:db8:abcd::/48"},
+		{policy: models.PhysicalAddressPolicyHidden, ipv4: "", ipv6: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.policy, func(t *testing.T) {
+			service, networkID := newPrivacyNetworkService(t)
+			privacy, err := service.UpdateNetworkPrivacy(networkID, tt.policy, "owner-1")
+			require.NoError(t, err)
+			assert.Equal(t, tt.policy, privacy.PhysicalAddressPolicy)
+
+			paths := visiblePaths(t, service, networkID, "viewer-1")
+			assert.Equal(t, []string{tt.ipv4, tt.ipv4, tt.ipv4}, paths[privacyIPv4MemberID])
+			assert.Equal(t, []string{tt.ipv6, tt.ipv6, tt.ipv6}, paths[privacyIPv6MemberID])
+
+			for _, userID := range []string{"owner-1", "admin-1"} {
+				paths := visiblePaths(t, service, networkID, userID)
+				assert.Equal(t, []string{"203.0.113.45/9993", "203.0.113.45/9993", "203.0.113.45/9993"}, paths[privacyIPv4MemberID], userID)
+			}
+		})
+	}
+}
+
+func TestPhysicalAddressPolicyDefaultsToTruncated(t *testing.T) {
+	service, networkID := newPrivacyNetworkService(t)
+
+	privacy, err := service.GetNetworkPrivacy(networkID, "viewer-1")
+	require.NoError(t, err)
+	assert.Equal(t, models.PhysicalAddressPolicyTruncated, privacy.PhysicalAddressPolicy)
+
+	member, err := service.GetNetworkMember(networkID, privacyIPv4MemberID, "viewer-1")
+	require.NoError(t, err)
+	assert.Equal(t, "203.0.113.0/24", member.PreferredPath)
+}
+
+func TestUpdateNetworkPrivacyRequiresOwnerAndValidPolicy(t *testing.T) {
+	service, networkID := newPrivacyNetworkService(t)
+
+	_, err := service.UpdateNetworkPrivacy(networkID, "partial", "owner-1")
+	assert.ErrorIs(t, err, services.ErrInvalidPhysicalAddressPolicy)
+
+	_, err = service.UpdateNetworkPrivacy(networkID, models.PhysicalAddressPolicyFull, "viewer-1")
+	require.Error(t, err)
+	assert.True(t, services.IsNetworkAccessDenied(err))
+
+	_, err = service.GetNetworkPrivacy(networkID, "stranger")
+	assert.Error(t, err)
+}
@@ -6,6 +6,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"path/filepath"
+	"strings"
 	"sync/atomic"
 	"testing"
 	"time"
@@ -14,6 +15,7 @@ import (
 	"github.com/GT-610/tairitsu/internal/app/models"
 	"github.com/GT-610/tairitsu/internal/app/services"
 	"github.com/GT-610/tairitsu/internal/zerotier"
+	"github.com/GT-610/tairitsu/internal/zerotier/ztmock"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -56,7 +58,7 @@ func TestNetworkServiceImportNetworks_AllSuccess(t *testing.T) {
 
 	service := services.NewNetworkService(client, db)
 
-	result, err := service.ImportNetworks([]string{"8056c2e21c000001", "8056c2e21c000002"}, "user-1", "admin")
+	result, err := service.ImportNetworks("", []string{"8056c2e21c000001", "8056c2e21c000002"}, "user-1", "admin")
 	require.NoError(t, err)
 	require.NotNil(t, result)
 	assert.Equal(t, services.ImportTargetOwner{ID: "user-1", Username: "user-1"}, result.TargetOwner)
@@ -96,7 +98,7 @@ func TestNetworkServiceImportNetworks_PartialFailures(t *testing.T) {
 
 	service := services.NewNetworkService(client, db)
 
-	result, err := service.ImportNetworks([]string{"8056c2e21c000001", "8056c2e21c000010", "8056c2e21c000099"}, "user-1", "admin")
+	result, err := service.ImportNetworks("", []string{"8056c2e21c000001", "8056c2e21c000010", "8056c2e21c000099"}, "user-1", "admin")
 	require.NoError(t, err)
 	require.NotNil(t, result)
 	require.Len(t, result.Imported, 1)
@@ -125,7 +127,7 @@ func TestNetworkServiceGetImportableNetworks_EmptySliceWhenControllerHasNoNetwor
 
 	service := services.NewNetworkService(client, db)
 
-	result, err := service.GetImportableNetworks()
+	result, err := service.GetImportableNetworks("")
 	require.NoError(t, err)
 	assert.NotNil(t, result)
 	assert.Empty(t, result.Candidates)
@@ -145,7 +147,7 @@ func TestNetworkServiceGetRuntimeStatus_UsesZeroTierAndDatabaseTruth(t *testing.
 
 	service := services.NewNetworkService(client, db)
 
-	status := service.GetRuntimeStatus()
+	status := service.GetRuntimeStatus(false)
 	require.NotNil(t, status)
 	assert.Equal(t, "1.14.2", status.Version)
 	assert.Equal(t, "f76fd3000b", status.Address)
@@ -164,7 +166,7 @@ func TestNetworkServiceGetRuntimeStatus_ReturnsErrorStates(t *testing.T) {
 
 	service := services.NewNetworkService(client, db)
 
-	status := service.GetRuntimeStatus()
+	status := service.GetRuntimeStatus(false)
 	require.NotNil(t, status)
 	assert.Equal(t, "error", status.ZeroTierStatus)
 	assert.NotEmpty(t, status.ZeroTierError)
@@ -180,7 +182,7 @@ func TestNetworkServiceImportNetworks_RejectsNonAdmin(t *testing.T) {
 
 	service := services.NewNetworkService(client, db)
 
-	result, err := service.ImportNetworks([]string{"8056c2e21c000001"}, "user-1", "user")
+	result, err := service.ImportNetworks("", []string{"8056c2e21c000001"}, "user-1", "user")
 	require.ErrorIs(t, err, services.ErrImportAccessDenied)
 	assert.Nil(t, result)
 }
@@ -193,7 +195,7 @@ func TestNetworkServiceImportNetworks_RequiresExistingOwner(t *testing.T) {
 
 	service := services.NewNetworkService(client, db)
 
-	result, err := service.ImportNetworks([]string{"8056c2e21c000001"}, "missing-owner", "admin")
+	result, err := service.ImportNetworks("", []string{"8056c2e21c000001"}, "missing-owner", "admin")
 	require.ErrorIs(t, err, services.ErrImportOwnerNotFound)
 	assert.Nil(t, result)
 }
@@ -216,7 +218,7 @@ func TestNetworkServiceGetImportableNetworks_MarksOwnedNetworksAsNotImportable(t
 
 	service := services.NewNetworkService(client, db)
 
-	result, err := service.GetImportableNetworks()
+	result, err := service.GetImportableNetworks("")
 	require.NoError(t, err)
 	require.Len(t, result.Candidates, 2)
 	assert.Contains(t, result.Candidates, services.ImportableNetworkCandidate{
@@ -268,7 +270,7 @@ func TestNetworkServiceGetImportableNetworks_IncludesUnassignedAndBlockedCandida
 
 	service := services.NewNetworkService(client, db)
 
-	result, err := service.GetImportableNetworks()
+	result, err := service.GetImportableNetworks("")
 	require.NoError(t, err)
 	require.Len(t, result.Candidates, 2)
 	assert.Contains(t, result.Candidates, services.ImportableNetworkCandidate{
@@ -290,6 +292,8 @@ func TestNetworkServiceGetImportableNetworks_IncludesUnassignedAndBlockedCandida
 		ReasonMessage: "Controller network details could not be read, so this network cannot be imported yet",
 	})
 	assert.Equal(t, services.ImportableNetworksSummary{Total: 2, Available: 1, Managed: 0, Blocked: 1}, result.Summary)
+	require.Len(t, result.Warnings, 1)
+	assert.Contains(t, result.Warnings[0], "8056c2e21c000099")
 }
 
 func TestNetworkServiceGetNetworkByIDRejectsDifferentOwner(t *testing.T) {
@@ -562,6 +566,193 @@ func TestNetworkServiceGetAllNetworksReusesCachedMemberStats(t *testing.T) {
 	assert.Equal(t, int32(1), memberRequests.Load())
 }
 
+func TestNetworkServiceListNetworksSummarizesMembers(t *testing.T) {
+	db := newTestSQLiteDB(t)
+	createTestUser(t, db, "admin-1", "admin")
+	now := time.Now()
+	for i, id := range []string{"8056c2e21c000001", "8056c2e21c000002"} {
+		require.NoError(t, db.CreateNetwork(&models.Network{ID: id, Name: id, OwnerID: "admin-1", CreatedAt: now.Add(time.Duration(i) * time.Second), UpdatedAt: now}))
+	}
+
+	var memberRequests, peerRequests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/peer":
+			peerRequests.Add(1)
+			require.NoError(t, json.NewEncoder(w).Encode([]zerotier.Peer{{Address: "1111111111"}}))
+		case "/controller/network/8056c2e21c000001/member":
+			memberRequests.Add(1)
+			require.NoError(t, json.NewEncoder(w).Encode([]zerotier.Member{
+				{ID: "1111111111", Address: "1111111111", Config: zerotier.MemberConfig{Authorized: true}},
+				{ID: "2222222222", Address: "2222222222", Online: true, Config: zerotier.MemberConfig{Authorized: true}},
+				{ID: "3333333333", Address: "3333333333"},
+			}))
+		default:
+			memberRequests.Add(1)
+			http.Error(w, "controller failure", http.StatusInternalServerError)
+		}
+	}))
+	t.Cleanup(server.Close)
+	service := services.NewNetworkService(&zerotier.Client{BaseURL: server.URL, Token: "test-token", HTTPClient: server.Client()}, db)
+
+	summaries, err := service.ListNetworks("admin-1", "", false)
+	require.NoError(t, err)
+	require.Len(t, summaries, 2)
+	assert.Nil(t, summaries[0].MemberCounts)
+	assert.Zero(t, memberRequests.Load(), "summary=false does not ask the controller")
+
+	summaries, err = service.ListNetworks("admin-1", "", true)
+	require.NoError(t, err)
+	require.Len(t, summaries, 2)
+	assert.Equal(t, &services.MemberCounts{Total: 3, Authorized: 2, Online: 2}, summaries[0].MemberCounts)
+	assert.Equal(t, 1, summaries[0].PendingMemberCount)
+	assert.Nil(t, summaries[1].MemberCounts, "a network whose members cannot be read has no counts")
+	assert.Equal(t, int32(1), peerRequests.Load(), "peers are fetched once per controller")
+}
+
+// A controller that keeps no names or descriptions answers with the ones Tairitsu stored,
+// while names the controller does keep take precedence
+func TestNetworkServiceKeepsMetadataTheControllerDrops(t *testing.T) {
+	db := newTestSQLiteDB(t)
+	createTestUser(t, db, "user-1", "user")
+	require.NoError(t, db.CreateNetwork(&models.Network{ID: "8056c2e21c000043", Name: "stored-name", Description: "stored-desc", OwnerID: "user-1"}))
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/controller/network":
+			require.NoError(t, json.NewEncoder(w).Encode(zerotier.Network{ID: "8056c2e21c000042"}))
+		case r.URL.Path == "/controller/network/8056c2e21c000043":
+			require.NoError(t, json.NewEncoder(w).Encode(zerotier.Network{ID: "8056c2e21c000043", Name: "controller-name"}))
+		case strings.HasSuffix(r.URL.Path, "/member"):
+			require.NoError(t, json.NewEncoder(w).Encode([]zerotier.Member{}))
+		default:
+			require.NoError(t, json.NewEncoder(w).Encode(zerotier.Network{ID: strings.TrimPrefix(r.URL.Path, "/controller/network/")}))
+		}
+	}))
+	t.Cleanup(server.Close)
+	service := services.NewNetworkService(&zerotier.Client{BaseURL: server.URL, Token: "test-token", HTTPClient: server.Client()}, db)
+
+	created, err := service.CreateNetwork("", &zerotier.Network{Name: "office", Description: "office-desc"}, "user-1")
+	require.NoError(t, err)
+	assert.Equal(t, "office", created.Name)
+	stored, err := db.GetNetworkByID("8056c2e21c000042")
+	require.NoError(t, err)
+	require.NotNil(t, stored)
+	assert.Equal(t, "office", stored.Name)
+	assert.Equal(t, "office-desc", stored.Description)
+
+	detail, err := service.GetNetworkByID("8056c2e21c000042", "user-1")
+	require.NoError(t, err)
+	assert.Equal(t, "office", detail.Name)
+	assert.Equal(t, "office-desc", detail.Description)
+
+	updated, err := service.UpdateNetwork("8056c2e21c000042", &zerotier.NetworkUpdateRequest{Name: "branch"}, "user-1")
+	require.NoError(t, err)
+	assert.Equal(t, "branch", updated.Name)
+	assert.Equal(t, "office-desc", updated.Description)
+	summaries, err := service.GetAllNetworks("user-1")
+	require.NoError(t, err)
+	require.Len(t, summaries, 2)
+	assert.Equal(t, "branch", summaries[1].Name)
+
+	detail, err = service.GetNetworkByID("8056c2e21c000043", "user-1")
+	require.NoError(t, err)
+	assert.Equal(t, "controller-name", detail.Name, "a name the controller keeps wins")
+	assert.Equal(t, "stored-desc", detail.Description)
+}
+
+func TestNetworkServiceUpdateNetworkRejectsFeaturesTheControllerLacks(t *testing.T) {
+	testCases := []struct {
+		version     string
+		dnsAccepted bool
+		ssoAccepted bool
+	}{
+		{version: "1.10.6"},
+		{version: "1.12.2", dnsAccepted: true},
+		{version: "1.14.2", dnsAccepted: true, ssoAccepted: true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.version, func(t *testing.T) {
+			controller := ztmock.NewController(ztmock.DemoAddress)
+			controller.Version = tc.version
+			networkID := controller.AddNetwork(map[string]any{"name": "lab"})
+			baseURL, err := controller.Start()
+			require.NoError(t, err)
+			t.Cleanup(func() {
+				_ = controller.Close()
+			})
+			db := newTestSQLiteDB(t)
+			createTestUser(t, db, "user-1", "user")
+			require.NoError(t, db.CreateNetwork(&models.Network{ID: networkID, Name: "lab", OwnerID: "user-1"}))
+			service := services.NewNetworkService(&zerotier.Client{BaseURL: baseURL, Token: controller.Token, HTTPClient: &http.Client{Timeout: 5 * time.Second}}, db)
+
+			limit := 16
+			updated, err := service.UpdateNetwork(networkID, &zerotier.NetworkUpdateRequest{MulticastLimit: &limit}, "user-1")
+			require.NoError(t, err, "every supported version accepts a multicast limit")
+			assert.Equal(t, 16, updated.Config.MulticastLimit)
+
+			var unsupported *zerotier.UnsupportedFeatureError
+			_, err = service.UpdateNetwork(networkID, &zerotier.NetworkUpdateRequest{DNS: &zerotier.DNSConfig{Domain: "lab.example"}}, "user-1")
+			if tc.dnsAccepted {
+				require.NoError(t, err)
+			} else {
+				require.ErrorAs(t, err, &unsupported)
+				assert.Equal(t, zerotier.FeatureDNS, unsupported.Feature)
+				assert.Contains(t, err.Error(), "requires ZeroTier 1.12.0 or newer")
+			}
+
+			enabled, disabled := true, false
+			_, err = service.UpdateNetwork(networkID, &zerotier.NetworkUpdateRequest{SsoEnabled: &enabled}, "user-1")
+			if tc.ssoAccepted {
+				require.NoError(t, err)
+			} else {
+				require.ErrorAs(t, err, &unsupported)
+				assert.Equal(t, zerotier.FeatureSSO, unsupported.Feature)
+			}
+			updated, err = service.UpdateNetwork(networkID, &zerotier.NetworkUpdateRequest{SsoEnabled: &disabled}, "user-1")
+			require.NoError(t, err, "disabling single sign-on works everywhere")
+			assert.False(t, updated.Config.SsoEnabled)
+		})
+	}
+}
+
+func TestNetworkServiceCreatesAndUpdatesBroadcastAndMulticastSettings(t *testing.T) {
+	controller := ztmock.NewController(ztmock.DemoAddress)
+	baseURL, err := controller.Start()
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = controller.Close()
+	})
+	db := newTestSQLiteDB(t)
+	createTestUser(t, db, "user-1", "user")
+	service := services.NewNetworkService(&zerotier.Client{BaseURL: baseURL, Token: controller.Token, HTTPClient: &http.Client{Timeout: 5 * time.Second}}, db)
+
+	limit := 128
+	disabled := false
+	created, err := service.CreateNetworkWithSettings("", &zerotier.Network{Name: "lab"}, &zerotier.NetworkUpdateRequest{MulticastLimit: &limit, EnableBroadcast: &disabled}, "user-1")
+	require.NoError(t, err)
+	assert.Equal(t, 128, created.Config.MulticastLimit)
+	assert.False(t, created.Config.EnableBroadcast)
+	assert.True(t, created.Config.Private)
+
+	for _, invalid := range []int{-1, services.MaxMulticastLimit + 1} {
+		_, err = service.CreateNetworkWithSettings("", &zerotier.Network{Name: "bad"}, &zerotier.NetworkUpdateRequest{MulticastLimit: &invalid}, "user-1")
+		assert.ErrorIs(t, err, services.ErrInvalidMulticastLimit)
+		_, err = service.UpdateNetwork(created.ID, &zerotier.NetworkUpdateRequest{MulticastLimit: &invalid}, "user-1")
+		assert.ErrorIs(t, err, services.ErrInvalidMulticastLimit)
+	}
+	assert.Len(t, controller.NetworkIDs(), 1, "an invalid limit creates no network")
+
+	// An update that leaves broadcast out keeps it disabled
+	zero := 0
+	updated, err := service.UpdateNetwork(created.ID, &zerotier.NetworkUpdateRequest{MulticastLimit: &zero}, "user-1")
+	require.NoError(t, err)
+	assert.Equal(t, 0, updated.Config.MulticastLimit)
+	assert.False(t, updated.Config.EnableBroadcast)
+}
+
 func TestNetworkServiceGetNetworkByIDIncludesMembers(t *testing.T) {
 	db := newTestSQLiteDB(t)
 	now := time.Now()
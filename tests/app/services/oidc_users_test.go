@@ -0,0 +1,60 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/GT-610/tairitsu/internal/app/models"
+	"github.com/GT-610/tairitsu/internal/app/services"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSignInWithOIDCKeepsTheLastAdministrator(t *testing.T) {
+	userService := services.NewUserService(newTestSQLiteDB(t))
+	admin, err := userService.SignInWithOIDC(&services.OIDCIdentity{Subject: "subject-admin", Username: "root"}, "admin")
+	require.NoError(t, err)
+	assert.Equal(t, "admin", admin.Role)
+
+	// Demoting the only administrator would lock everybody out of administration
+	again, err := userService.SignInWithOIDC(&services.OIDCIdentity{Subject: "subject-admin"}, "user")
+	require.NoError(t, err)
+	assert.Equal(t, admin.ID, again.ID)
+	assert.Equal(t, "admin", again.Role)
+
+	_, err = userService.Register(&models.RegisterRequest{Username: "second", Password: "Password123!"}, "admin")
+	require.NoError(t, err)
+	again, err = userService.SignInWithOIDC(&services.OIDCIdentity{Subject: "subject-admin"}, "user")
+	require.NoError(t, err)
+	assert.Equal(t, "user", again.Role)
+
+	// No role claim leaves the role to Tairitsu
+	again, err = userService.SignInWithOIDC(&services.OIDCIdentity{Subject: "subject-admin"}, "")
+	require.NoError(t, err)
+	assert.Equal(t, "user", again.Role)
+}
+
+func TestSignInWithOIDCLinksEachAccountOnce(t *testing.T) {
+	userService := services.NewUserService(newTestSQLiteDB(t))
+	local, err := userService.Register(&models.RegisterRequest{Username: "carol", Password: "Password123!", Email: "carol@example.com"})
+	require.NoError(t, err)
+
+	linked, err := userService.SignInWithOIDC(&services.OIDCIdentity{Subject: "subject-1", Email: "CAROL@example.com", EmailVerified: true}, "")
+	require.NoError(t, err)
+	assert.Equal(t, local.ID, linked.ID)
+	require.NotNil(t, linked.ExternalSubject)
+	assert.Equal(t, "subject-1", *linked.ExternalSubject)
+
+	// Another subject claiming the same address cannot take the account over
+	_, err = userService.SignInWithOIDC(&services.OIDCIdentity{Subject: "subject-2", Email: "carol@example.com", EmailVerified: true}, "")
+	assert.ErrorIs(t, err, services.ErrEmailExists)
+
+	// Usernames are cut to the length limit without splitting characters
+	user, err := userService.SignInWithOIDC(&services.OIDCIdentity{Subject: "subject-3", Username: "ユーザーネームです"}, "")
+	require.NoError(t, err)
+	assert.Equal(t, "ユーザーネ", user.Username)
+	assert.Nil(t, user.Email)
+
+	// Provisioned accounts have no password to sign in with
+	_, err = userService.Login(&models.LoginRequest{Username: user.Username, Password: ""})
+	assert.ErrorIs(t, err, services.ErrInvalidCredentials)
+}
@@ -0,0 +1,174 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/GT-610/tairitsu/internal/app/models"
+	"github.com/GT-610/tairitsu/internal/app/services"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// startOperationManager starts operations and stops it before the database is closed
+func startOperationManager(t *testing.T, operations *services.OperationManager) {
+	t.Helper()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := operations.Start(ctx)
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+}
+
+// blockingOperation runs until its context is cancelled, reporting that it started
+func blockingOperation(started chan<- struct{}) services.OperationFunc {
+	return func(ctx context.Context, progress *services.OperationProgress) (any, error) {
+		progress.SetTotal(2)
+		progress.Add(1, 0)
+		close(started)
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+}
+
+func TestOperationManagerRecordsProgressAndResult(t *testing.T) {
+	db := newTestSQLiteDB(t)
+	operations := services.NewOperationManager(db, 2)
+	startOperationManager(t, operations)
+
+	operation := &models.Operation{Kind: "test", UserID: "alice"}
+	require.NoError(t, operations.Submit(operation, nil, func(ctx context.Context, progress *services.OperationProgress) (any, error) {
+		progress.SetTotal(3)
+		progress.Add(2, 1)
+		return map[string]int{"answer": 42}, nil
+	}))
+	assert.Equal(t, operation.ID, operation.IdempotencyKey, "the key defaults to the ID")
+
+	detail := waitForOperation(t, operations, operation.ID, "alice")
+	assert.Equal(t, models.OperationStatusSucceeded, detail.Status)
+	assert.Equal(t, 3, detail.Total)
+	assert.Equal(t, 2, detail.Done)
+	assert.Equal(t, 1, detail.Failed)
+	assert.JSONEq(t, `{"answer":42}`, string(detail.Result))
+	assert.NotNil(t, detail.StartedAt)
+	assert.NotNil(t, detail.FinishedAt)
+
+	failing := &models.Operation{Kind: "test", UserID: "alice"}
+	require.NoError(t, operations.Submit(failing, nil, func(ctx context.Context, progress *services.OperationProgress) (any, error) {
+		return nil, errors.New("controller unreachable")
+	}))
+	detail = waitForOperation(t, operations, failing.ID, "alice")
+	assert.Equal(t, models.OperationStatusFailed, detail.Status)
+	assert.Equal(t, "controller unreachable", detail.Error)
+
+	_, err := operations.Get(operation.ID, "bob", false)
+	assert.ErrorIs(t, err, services.ErrOperationNotFound)
+	_, err = operations.Get(operation.ID, "bob", true)
+	assert.NoError(t, err, "administrators see every operation")
+
+	list, err := operations.List("alice", false, 10)
+	require.NoError(t, err)
+	assert.Len(t, list, 2)
+	list, err = operations.List("bob", false, 10)
+	require.NoError(t, err)
+	assert.Empty(t, list)
+	list, err = operations.List("bob", true, 1)
+	require.NoError(t, err)
+	assert.Len(t, list, 1)
+}
+
+func TestOperationManagerCancelsRunningAndPendingOperations(t *testing.T) {
+	db := newTestSQLiteDB(t)
+	operations := services.NewOperationManager(db, 1)
+	startOperationManager(t, operations)
+
+	started := make(chan struct{})
+	running := &models.Operation{Kind: "test", UserID: "alice"}
+	require.NoError(t, operations.Submit(running, nil, blockingOperation(started)))
+	<-started
+
+	// The only worker is busy, so this one waits
+	var ran atomic.Bool
+	pending := &models.Operation{Kind: "test", UserID: "alice"}
+	require.NoError(t, operations.Submit(pending, nil, func(ctx context.Context, progress *services.OperationProgress) (any, error) {
+		ran.Store(true)
+		return nil, nil
+	}))
+	detail, err := operations.Get(pending.ID, "alice", false)
+	require.NoError(t, err)
+	assert.Equal(t, models.OperationStatusPending, detail.Status)
+
+	_, err = operations.Cancel(pending.ID, "bob", false)
+	assert.ErrorIs(t, err, services.ErrOperationNotFound)
+	_, err = operations.Cancel(pending.ID, "alice", false)
+	require.NoError(t, err)
+	detail = waitForOperation(t, operations, pending.ID, "alice")
+	assert.Equal(t, models.OperationStatusCancelled, detail.Status)
+
+	detail, err = operations.Get(running.ID, "alice", false)
+	require.NoError(t, err)
+	assert.Equal(t, models.OperationStatusRunning, detail.Status)
+	assert.Equal(t, 1, detail.Done)
+	_, err = operations.Cancel(running.ID, "admin", true)
+	require.NoError(t, err)
+	detail = waitForOperation(t, operations, running.ID, "alice")
+	assert.Equal(t, models.OperationStatusCancelled, detail.Status)
+	assert.Equal(t, 1, detail.Done, "progress made before cancelling is kept")
+	assert.False(t, operations.Active(running.ID))
+	assert.False(t, ran.Load(), "a cancelled operation never starts")
+
+	_, err = operations.Cancel(running.ID, "alice", false)
+	assert.ErrorIs(t, err, services.ErrOperationNotRunning)
+}
+
+// Operations a stopped process left unfinished are failed when the next one starts, and
+// operations running at shutdown are stopped and recorded as failed
+func TestOperationManagerPersistsStatusAcrossRestarts(t *testing.T) {
+	db := newTestSQLiteDB(t)
+	first := services.NewOperationManager(db, 2)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := first.Start(ctx)
+
+	started := make(chan struct{})
+	operation := &models.Operation{Kind: "test", UserID: "alice"}
+	require.NoError(t, first.Submit(operation, nil, blockingOperation(started)))
+	<-started
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the manager did not stop its operations")
+	}
+	err := first.Submit(&models.Operation{Kind: "test", UserID: "alice"}, nil, blockingOperation(make(chan struct{})))
+	assert.ErrorIs(t, err, services.ErrOperationsStopped)
+
+	second := services.NewOperationManager(db, 2)
+	detail, err := second.Get(operation.ID, "alice", false)
+	require.NoError(t, err)
+	assert.Equal(t, models.OperationStatusFailed, detail.Status)
+	assert.Equal(t, "operation was interrupted by shutdown", detail.Error)
+	assert.Equal(t, 2, detail.Total)
+	assert.Equal(t, 1, detail.Done)
+
+	// A crash leaves the operation running in the database
+	detail.Status = models.OperationStatusRunning
+	detail.FinishedAt = nil
+	require.NoError(t, db.SaveOperation(detail.Operation))
+	startOperationManager(t, second)
+	detail, err = second.Get(operation.ID, "alice", false)
+	require.NoError(t, err)
+	assert.Equal(t, models.OperationStatusFailed, detail.Status)
+	assert.Equal(t, "operation was interrupted by a restart", detail.Error)
+	assert.NotNil(t, detail.FinishedAt)
+
+	encoded, err := json.Marshal(detail)
+	require.NoError(t, err)
+	assert.NotContains(t, string(encoded), `"request"`)
+}
@@ -0,0 +1,234 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/GT-610/tairitsu/internal/app/database"
+	"github.com/GT-610/tairitsu/internal/app/models"
+	"github.com/GT-610/tairitsu/internal/app/services"
+	"github.com/GT-610/tairitsu/internal/zerotier"
+	"github.com/GT-610/tairitsu/internal/zerotier/ztmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newPagingNetworkService serves memberCount members from a mock controller on a network owned by owner-1.
+func newPagingNetworkService(t *testing.T, memberCount int) (*services.NetworkService, *ztmock.Controller, string) {
+	t.Helper()
+
+	controller := ztmock.NewController(ztmock.DemoAddress)
+	networkID := controller.AddNetwork(map[string]any{"name": "paging"})
+	for i := 0; i < memberCount; i++ {
+		controller.AddMember(networkID, memberIDAt(i*2), map[string]any{"name": fmt.Sprintf("node-%02d", i)})
+	}
+	baseURL, err := controller.Start()
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = controller.Close()
+	})
+
+	db := newTestSQLiteDB(t)
+	now := time.Now()
+	require.NoError(t, db.CreateNetwork(&models.Network{ID: networkID, Name: "paging", OwnerID: "owner-1", CreatedAt: now, UpdatedAt: now}))
+
+	client := &zerotier.Client{BaseURL: baseURL, Token: controller.Token, HTTPClient: &http.Client{Timeout: 5 * time.Second}}
+	return services.NewNetworkService(client, db), controller, networkID
+}
+
+func memberIDAt(i int) string {
+	return fmt.Sprintf("%010x", 0xa000000000+i)
+}
+
+func memberIDs(members []zerotier.Member) []string {
+	ids := make([]string, 0, len(members))
+	for _, member := range members {
+		ids = append(ids, member.ID)
+	}
+	return ids
+}
+
+func TestMemberCursorPagingHasNoDuplicatesOrGapsUnderChurn(t *testing.T) {
+	service, controller, networkID := newPagingNetworkService(t, 10)
+
+	first, err := service.GetNetworkMembersPage(networkID, "owner-1", services.MemberListQuery{}, services.PageRequest{Limit: 3})
+	require.NoError(t, err)
+	require.Equal(t, []string{memberIDAt(0), memberIDAt(2), memberIDAt(4)}, memberIDs(first.Items))
+	require.NotEmpty(t, first.NextCursor)
+	assert.Empty(t, first.PrevCursor)
+
+	// Remove a member already seen and insert one before the cursor; neither may disturb the next page.
+	require.NoError(t, service.RemoveNetworkMember(networkID, memberIDAt(2), "owner-1"))
+	controller.AddMember(networkID, memberIDAt(1), map[string]any{"name": "late"})
+
+	seen := memberIDs(first.Items)
+	cursor := first.NextCursor
+	for cursor != "" {
+		page, err := service.GetNetworkMembersPage(networkID, "owner-1", services.MemberListQuery{}, services.PageRequest{Cursor: cursor, Limit: 3})
+		require.NoError(t, err)
+		seen = append(seen, memberIDs(page.Items)...)
+		cursor = page.NextCursor
+	}
+
+	want := []string{memberIDAt(0), memberIDAt(2), memberIDAt(4)}
+	for i := 3; i < 10; i++ {
+		want = append(want, memberIDAt(i*2))
+	}
+	assert.Equal(t, want, seen)
+}
+
+func TestMemberOffsetPagingShiftsUnderChurn(t *testing.T) {
+	service, _, networkID := newPagingNetworkService(t, 10)
+
+	first, err := service.GetNetworkMembersPage(networkID, "owner-1", services.MemberListQuery{}, services.PageRequest{UseOffset: true, Limit: 3})
+	require.NoError(t, err)
+	require.NotNil(t, first.Total)
+	assert.Equal(t, 10, *first.Total)
+
+	require.NoError(t, service.RemoveNetworkMember(networkID, memberIDAt(2), "owner-1"))
+
+	// Documented offset behavior: removing an earlier member shifts the next page and skips one member.
+	second, err := service.GetNetworkMembersPage(networkID, "owner-1", services.MemberListQuery{}, services.PageRequest{UseOffset: true, Offset: 3, Limit: 3})
+	require.NoError(t, err)
+	assert.Equal(t, []string{memberIDAt(8), memberIDAt(10), memberIDAt(12)}, memberIDs(second.Items))
+	assert.NotContains(t, memberIDs(second.Items), memberIDAt(6))
+	assert.Empty(t, second.NextCursor)
+}
+
+func TestMemberCursorPagingWalksBackwards(t *testing.T) {
+	service, _, networkID := newPagingNetworkService(t, 7)
+	query := services.MemberListQuery{Sort: services.MemberSortName}
+
+	first, err := service.GetNetworkMembersPage(networkID, "owner-1", query, services.PageRequest{Limit: 3})
+	require.NoError(t, err)
+	second, err := service.GetNetworkMembersPage(networkID, "owner-1", query, services.PageRequest{Cursor: first.NextCursor, Limit: 3})
+	require.NoError(t, err)
+	require.NotEmpty(t, second.PrevCursor)
+
+	back, err := service.GetNetworkMembersPage(networkID, "owner-1", query, services.PageRequest{Cursor: second.PrevCursor, Limit: 3})
+	require.NoError(t, err)
+	assert.Equal(t, memberIDs(first.Items), memberIDs(back.Items))
+	assert.Empty(t, back.PrevCursor)
+	assert.NotEmpty(t, back.NextCursor)
+}
+
+func TestMemberCursorRejectsChangedQueryAndTampering(t *testing.T) {
+	service, _, networkID := newPagingNetworkService(t, 5)
+
+	first, err := service.GetNetworkMembersPage(networkID, "owner-1", services.MemberListQuery{}, services.PageRequest{Limit: 2})
+	require.NoError(t, err)
+
+	_, err = service.GetNetworkMembersPage(networkID, "owner-1", services.MemberListQuery{Sort: services.MemberSortName}, services.PageRequest{Cursor: first.NextCursor, Limit: 2})
+	assert.ErrorIs(t, err, services.ErrCursorQueryChanged)
+
+	authorized := true
+	_, err = service.GetNetworkMembersPage(networkID, "owner-1", services.MemberListQuery{Authorized: &authorized}, services.PageRequest{Cursor: first.NextCursor, Limit: 2})
+	assert.ErrorIs(t, err, services.ErrCursorQueryChanged)
+
+	payload, signature, _ := strings.Cut(first.NextCursor, ".")
+	tampered := payload[:len(payload)-1] + "A." + signature
+	_, err = service.GetNetworkMembersPage(networkID, "owner-1", services.MemberListQuery{}, services.PageRequest{Cursor: tampered, Limit: 2})
+	assert.ErrorIs(t, err, services.ErrInvalidCursor)
+
+	_, err = service.GetNetworkMembersPage(networkID, "owner-1", services.MemberListQuery{}, services.PageRequest{Cursor: first.NextCursor, UseOffset: true, Limit: 2})
+	assert.ErrorIs(t, err, services.ErrInvalidPageRequest)
+}
+
+func TestAuditCursorPagingIgnoresNewEntries(t *testing.T) {
+	db, _ := newAuditTestDB(t)
+	service := services.NewAuditService(db)
+	entries := recordAuditEntries(t, service, 5)
+
+	first, err := service.ListEntries(services.AuditListQuery{}, services.PageRequest{Limit: 2})
+	require.NoError(t, err)
+	require.Len(t, first.Items, 2)
+	assert.Equal(t, entries[4].ID, first.Items[0].ID)
+
+	recordAuditEntries(t, service, 3)
+
+	var seen []uint64
+	for _, entry := range first.Items {
+		seen = append(seen, entry.ID)
+	}
+	cursor := first.NextCursor
+	for cursor != "" {
+		page, err := service.ListEntries(services.AuditListQuery{}, services.PageRequest{Cursor: cursor, Limit: 2})
+		require.NoError(t, err)
+		for _, entry := range page.Items {
+			seen = append(seen, entry.ID)
+		}
+		cursor = page.NextCursor
+	}
+
+	assert.Equal(t, []uint64{entries[4].ID, entries[3].ID, entries[2].ID, entries[1].ID, entries[0].ID}, seen)
+
+	_, err = service.ListEntries(services.AuditListQuery{Action: "DELETE"}, services.PageRequest{Cursor: first.NextCursor})
+	assert.ErrorIs(t, err, services.ErrCursorQueryChanged)
+}
+
+func TestAuditCursorPrevReturnsNewerEntries(t *testing.T) {
+	db, _ := newAuditTestDB(t)
+	service := services.NewAuditService(db)
+	entries := recordAuditEntries(t, service, 5)
+
+	first, err := service.ListEntries(services.AuditListQuery{}, services.PageRequest{Limit: 2})
+	require.NoError(t, err)
+	second, err := service.ListEntries(services.AuditListQuery{}, services.PageRequest{Cursor: first.NextCursor, Limit: 2})
+	require.NoError(t, err)
+
+	back, err := service.ListEntries(services.AuditListQuery{}, services.PageRequest{Cursor: second.PrevCursor, Limit: 2})
+	require.NoError(t, err)
+	require.Len(t, back.Items, 2)
+	assert.Equal(t, entries[4].ID, back.Items[0].ID)
+	assert.Equal(t, entries[3].ID, back.Items[1].ID)
+	assert.Empty(t, back.PrevCursor)
+}
+
+// newPeerControllerService serves members, given as controller JSON, and a peer list holding
+// peerAddresses, for a network owned by owner-1
+func newPeerControllerService(t *testing.T, networkID string, members string, peerAddresses ...string) (*services.NetworkService, database.DBInterface) {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/peer":
+			peers := []zerotier.Peer{}
+			for _, address := range peerAddresses {
+				peers = append(peers, zerotier.Peer{Address: address})
+			}
+			require.NoError(t, json.NewEncoder(w).Encode(peers))
+		case "/controller/network/" + networkID + "/member":
+			_, err := w.Write([]byte(members))
+			require.NoError(t, err)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+	db := newTestSQLiteDB(t)
+	now := time.Now()
+	require.NoError(t, db.CreateNetwork(&models.Network{ID: networkID, Name: "peers", OwnerID: "owner-1", CreatedAt: now, UpdatedAt: now}))
+	return services.NewNetworkService(&zerotier.Client{BaseURL: server.URL, Token: "test-token", HTTPClient: server.Client()}, db), db
+}
+
+// Controllers leave online out of members, so the online filter has to come from the peer list
+func TestMemberOnlineFilterFollowsPeers(t *testing.T) {
+	const networkID = "8056c2e21c000001"
+	service, _ := newPeerControllerService(t, networkID,
+		`[{"id":"1111111111","address":"1111111111","authorized":true},{"id":"2222222222","address":"2222222222","authorized":true}]`,
+		"1111111111")
+
+	online, offline := true, false
+	page, err := service.GetNetworkMembersPage(networkID, "owner-1", services.MemberListQuery{Online: &online}, services.PageRequest{Limit: 10})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"1111111111"}, memberIDs(page.Items))
+	page, err = service.GetNetworkMembersPage(networkID, "owner-1", services.MemberListQuery{Online: &offline}, services.PageRequest{Limit: 10})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"2222222222"}, memberIDs(page.Items))
+}
@@ -0,0 +1,77 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/GT-610/tairitsu/internal/app/models"
+	"github.com/GT-610/tairitsu/internal/app/services"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func storedHashCost(t *testing.T, user *models.User) int {
+	t.Helper()
+	cost, err := bcrypt.Cost([]byte(user.Password))
+	require.NoError(t, err)
+	return cost
+}
+
+func TestUserServiceHashesWithTheConfiguredCost(t *testing.T) {
+	db := newTestSQLiteDB(t)
+	service := services.NewUserService(db)
+	service.SetPasswordHashCost(bcrypt.MinCost)
+
+	user, err := service.Register(&models.RegisterRequest{Username: "admin", Password: "secret123"}, "admin")
+	require.NoError(t, err)
+	stored, err := db.GetUserByID(user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, bcrypt.MinCost, storedHashCost(t, stored))
+
+	service.SetPasswordHashCost(bcrypt.MinCost + 1)
+	err = service.ChangePassword(user.ID, "secret123", "secret456")
+	require.NoError(t, err)
+	stored, err = db.GetUserByID(user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, bcrypt.MinCost+1, storedHashCost(t, stored))
+
+	// Absurd costs fall back to bcrypt's default
+	service.SetPasswordHashCost(40)
+	err = service.ChangePassword(user.ID, "secret456", "secret789")
+	require.NoError(t, err)
+	stored, err = db.GetUserByID(user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, bcrypt.DefaultCost, storedHashCost(t, stored))
+}
+
+func TestUserServiceRehashesPasswordOnceOnLogin(t *testing.T) {
+	db := newTestSQLiteDB(t)
+	service := services.NewUserService(db)
+	service.SetPasswordHashCost(bcrypt.MinCost)
+	user, err := service.Register(&models.RegisterRequest{Username: "admin", Password: "secret123"}, "admin")
+	require.NoError(t, err)
+
+	service.SetPasswordHashCost(bcrypt.MinCost + 1)
+	_, err = service.Login(&models.LoginRequest{Username: "admin", Password: "wrong-password"})
+	assert.ErrorIs(t, err, services.ErrInvalidCredentials)
+	stored, err := db.GetUserByID(user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, bcrypt.MinCost, storedHashCost(t, stored), "a failed login keeps the hash")
+
+	_, err = service.Login(&models.LoginRequest{Username: "admin", Password: "secret123"})
+	require.NoError(t, err, "the old hash still logs in")
+	rehashed, err := db.GetUserByID(user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, bcrypt.MinCost+1, storedHashCost(t, rehashed))
+
+	_, err = service.Login(&models.LoginRequest{Username: "admin", Password: "secret123"})
+	require.NoError(t, err, "the new hash logs in")
+	current, err := db.GetUserByID(user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, rehashed.Password, current.Password, "a hash of the configured cost is not replaced again")
+
+	// A login holding a hash that was replaced meanwhile leaves the newer one alone
+	replaced, err := db.ReplaceUserPasswordHash(user.ID, stored.Password, "stale")
+	require.NoError(t, err)
+	assert.False(t, replaced)
+}
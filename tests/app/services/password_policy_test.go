@@ -0,0 +1,79 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/GT-610/tairitsu/internal/app/config"
+	"github.com/GT-610/tairitsu/internal/app/models"
+	"github.com/GT-610/tairitsu/internal/app/services"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidatePasswordListsEveryFailedRule(t *testing.T) {
+	policy := services.PasswordPolicy{MinLength: 10, MaxLength: services.MaxPasswordLength, RequireUpper: true, RequireLower: true, RequireDigit: true, RequireSymbol: true, RejectUsername: true}
+
+	err := services.ValidatePassword(policy, "bob", "xbobx")
+	var policyErr *services.PasswordPolicyError
+	require.ErrorAs(t, err, &policyErr)
+	assert.Equal(t, []string{
+		services.PasswordRuleMinLength,
+		services.PasswordRuleRequireUpper,
+		services.PasswordRuleRequireDigit,
+		services.PasswordRuleRequireSymbol,
+		services.PasswordRuleRejectUsername,
+	}, policyErr.Failed)
+	assert.ErrorIs(t, err, services.ErrPasswordTooShort)
+	assert.NotErrorIs(t, err, services.ErrPasswordTooLong)
+
+	assert.NoError(t, services.ValidatePassword(policy, "bob", "Str0ng!Passw0rd"))
+}
+
+func TestValidatePasswordRejectsUsernameCaseInsensitively(t *testing.T) {
+	err := services.ValidatePassword(services.DefaultPasswordPolicy(), "Alice", "myALICEpass")
+	var policyErr *services.PasswordPolicyError
+	require.ErrorAs(t, err, &policyErr)
+	assert.Equal(t, []string{services.PasswordRuleRejectUsername}, policyErr.Failed)
+}
+
+func TestPasswordPolicyFromConfigRoundTrips(t *testing.T) {
+	assert.Equal(t, services.DefaultPasswordPolicy(), services.PasswordPolicyFromConfig(&config.Config{}))
+
+	policy := services.PasswordPolicy{MinLength: 12, MaxLength: services.MaxPasswordLength, RequireSymbol: true}
+	cfg := &config.Config{Security: config.SecurityConfig{PasswordPolicy: policy.ConfigValue()}}
+	assert.Equal(t, policy, services.PasswordPolicyFromConfig(cfg))
+
+	assert.ErrorIs(t, services.PasswordPolicy{MinLength: 4}.Validate(), services.ErrInvalidPasswordPolicy)
+}
+
+func TestUserServiceChangePasswordEnforcesPolicy(t *testing.T) {
+	db := newTestSQLiteDB(t)
+	service := services.NewUserService(db)
+	user, err := service.Register(&models.RegisterRequest{Username: "carol", Password: "secret123"}, "user")
+	require.NoError(t, err)
+
+	policy := services.DefaultPasswordPolicy()
+	policy.RequireUpper = true
+	service.SetPasswordPolicy(policy)
+
+	err = service.ChangePassword(user.ID, "secret123", "another123")
+	var policyErr *services.PasswordPolicyError
+	require.ErrorAs(t, err, &policyErr)
+	assert.Equal(t, []string{services.PasswordRuleRequireUpper}, policyErr.Failed)
+
+	require.NoError(t, service.ChangePassword(user.ID, "secret123", "Another123"))
+}
+
+func TestTemporaryPasswordsSatisfyPolicy(t *testing.T) {
+	db := newTestSQLiteDB(t)
+	service := services.NewUserService(db)
+	admin, err := service.Register(&models.RegisterRequest{Username: "admin", Password: "secret123"}, "admin")
+	require.NoError(t, err)
+
+	service.SetPasswordPolicy(services.PasswordPolicy{MinLength: 20, MaxLength: services.MaxPasswordLength, RequireUpper: true, RequireLower: true, RequireDigit: true, RequireSymbol: true, RejectUsername: true})
+
+	_, temporaryPassword, err := service.CreateUserByAdmin(admin.ID, &models.CreateUserRequest{Username: "dave"})
+	require.NoError(t, err)
+	assert.Len(t, temporaryPassword, 20)
+	assert.NoError(t, services.ValidatePassword(service.PasswordPolicy(), "dave", temporaryPassword))
+}
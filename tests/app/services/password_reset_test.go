@@ -0,0 +1,123 @@
+package services
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+	"time"
+
+	"github.com/GT-610/tairitsu/internal/app/database"
+	"github.com/GT-610/tairitsu/internal/app/models"
+	"github.com/GT-610/tairitsu/internal/app/services"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPasswordResetTokenIsSingleUse(t *testing.T) {
+	db := newTestSQLiteDB(t)
+	service := services.NewUserService(db)
+	admin, err := service.Register(&models.RegisterRequest{Username: "admin", Password: "secret123"}, "admin")
+	require.NoError(t, err)
+	now := time.Now()
+	require.NoError(t, db.CreateSession(&models.Session{ID: "session-1", UserID: admin.ID, LastSeenAt: now, ExpiresAt: now.Add(time.Hour), CreatedAt: now, UpdatedAt: now}))
+
+	first, err := service.IssueAdminPasswordResetToken()
+	require.NoError(t, err)
+	assert.Equal(t, admin.ID, first.User.ID)
+	assert.WithinDuration(t, now.Add(services.PasswordResetTokenTTL), first.ExpiresAt, time.Minute)
+	second, err := service.IssuePasswordResetToken(admin.ID)
+	require.NoError(t, err)
+
+	user, err := service.ConfirmPasswordReset(&models.PasswordResetConfirmRequest{Token: first.Token, NewPassword: "Recovered123"})
+	require.NoError(t, err)
+	assert.Equal(t, admin.ID, user.ID)
+
+	_, err = service.Login(&models.LoginRequest{Username: "admin", Password: "Recovered123"})
+	require.NoError(t, err)
+	session, err := db.GetSessionByID("session-1")
+	require.NoError(t, err)
+	assert.NotNil(t, session.RevokedAt, "existing sessions are signed out")
+
+	_, err = service.ConfirmPasswordReset(&models.PasswordResetConfirmRequest{Token: first.Token, NewPassword: "Another1234"})
+	assert.ErrorIs(t, err, services.ErrPasswordResetTokenInvalid, "a used token is rejected")
+	_, err = service.ConfirmPasswordReset(&models.PasswordResetConfirmRequest{Token: second.Token, NewPassword: "Another1234"})
+	assert.ErrorIs(t, err, services.ErrPasswordResetTokenInvalid, "outstanding tokens are invalidated once one is used")
+	_, err = service.ConfirmPasswordReset(&models.PasswordResetConfirmRequest{Token: "unknown", NewPassword: "Another1234"})
+	assert.ErrorIs(t, err, services.ErrPasswordResetTokenInvalid)
+}
+
+// staleResetTokenDB reads every reset token as unused, as a confirmation racing another one
+// that used the token in the meantime would
+type staleResetTokenDB struct {
+	database.DBInterface
+}
+
+func (d *staleResetTokenDB) WithTransaction(fn func(database.DBInterface) error) error {
+	return d.DBInterface.WithTransaction(func(tx database.DBInterface) error {
+		return fn(&staleResetTokenDB{DBInterface: tx})
+	})
+}
+
+func (d *staleResetTokenDB) GetPasswordResetTokenByHash(hash string) (*models.PasswordResetToken, error) {
+	token, err := d.DBInterface.GetPasswordResetTokenByHash(hash)
+	if token != nil {
+		token.UsedAt = nil
+	}
+	return token, err
+}
+
+func TestPasswordResetTokenConfirmedTwiceChangesThePasswordOnce(t *testing.T) {
+	db := newTestSQLiteDB(t)
+	service := services.NewUserService(db)
+	user, err := service.Register(&models.RegisterRequest{Username: "alice", Password: "secret123"}, "user")
+	require.NoError(t, err)
+	ticket, err := service.IssuePasswordResetToken(user.ID)
+	require.NoError(t, err)
+
+	_, err = service.ConfirmPasswordReset(&models.PasswordResetConfirmRequest{Token: ticket.Token, NewPassword: "Recovered123"})
+	require.NoError(t, err)
+	_, err = services.NewUserService(&staleResetTokenDB{DBInterface: db}).ConfirmPasswordReset(&models.PasswordResetConfirmRequest{Token: ticket.Token, NewPassword: "Another1234"})
+	assert.ErrorIs(t, err, services.ErrPasswordResetTokenInvalid, "a token is consumed only while it is unused")
+
+	_, err = service.Login(&models.LoginRequest{Username: "alice", Password: "Recovered123"})
+	assert.NoError(t, err, "the second confirmation did not change the password")
+}
+
+func TestPasswordResetRejectsExpiredTokensAndWeakPasswords(t *testing.T) {
+	db := newTestSQLiteDB(t)
+	service := services.NewUserService(db)
+	user, err := service.Register(&models.RegisterRequest{Username: "alice", Password: "secret123"}, "user")
+	require.NoError(t, err)
+
+	sum := sha256.Sum256([]byte("expired-token"))
+	require.NoError(t, db.CreatePasswordResetToken(&models.PasswordResetToken{
+		ID:        "reset-1",
+		UserID:    user.ID,
+		TokenHash: hex.EncodeToString(sum[:]),
+		ExpiresAt: time.Now().Add(-time.Minute),
+		CreatedAt: time.Now().Add(-time.Hour),
+	}))
+	_, err = service.ConfirmPasswordReset(&models.PasswordResetConfirmRequest{Token: "expired-token", NewPassword: "Recovered123"})
+	assert.ErrorIs(t, err, services.ErrPasswordResetTokenExpired)
+
+	ticket, err := service.IssuePasswordResetToken(user.ID)
+	require.NoError(t, err)
+	_, err = service.ConfirmPasswordReset(&models.PasswordResetConfirmRequest{Token: ticket.Token, NewPassword: "x"})
+	assert.ErrorIs(t, err, services.ErrPasswordTooShort)
+
+	// A rejected password leaves the token usable
+	_, err = service.ConfirmPasswordReset(&models.PasswordResetConfirmRequest{Token: ticket.Token, NewPassword: "Recovered123"})
+	require.NoError(t, err)
+}
+
+func TestPasswordResetRequestDoesNotRevealAccounts(t *testing.T) {
+	db := newTestSQLiteDB(t)
+	service := services.NewUserService(db)
+	_, err := service.Register(&models.RegisterRequest{Username: "alice", Password: "secret123", Email: "alice@example.com"}, "user")
+	require.NoError(t, err)
+
+	assert.NoError(t, service.RequestPasswordReset(&models.PasswordResetRequest{Username: "alice"}))
+	assert.NoError(t, service.RequestPasswordReset(&models.PasswordResetRequest{Email: "Alice@Example.com"}))
+	assert.NoError(t, service.RequestPasswordReset(&models.PasswordResetRequest{Username: "nobody"}))
+	assert.NoError(t, service.RequestPasswordReset(&models.PasswordResetRequest{}))
+}
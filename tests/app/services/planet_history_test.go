@@ -0,0 +1,59 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/GT-610/tairitsu/internal/app/services"
+	"github.com/GT-610/tairitsu/internal/mkworld"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const historyRootIdentity = "f76fd3000b:0:542c89e34a369c2281ed940d05beeffdbaa66930f17b875e9172e43d0ba30b6a39708507f4d64e66cde4a1040d2a995d01209d685ca6c4adb4a5c880af1e9715"
+
+func generateHistoryPlanet(t *testing.T) (*mkworld.GeneratedPlanet, []mkworld.RootNodeConfig) {
+	t.Helper()
+
+	roots := []mkworld.RootNodeConfig{{IdentityPublic: historyRootIdentity, Comments: "office", Endpoints: []string{"203.0.113.1/9993"}}}
+	planet, err := mkworld.GeneratePlanet(&mkworld.GenerateOptions{RootNodes: roots, RecommendValues: true})
+	require.NoError(t, err)
+	return planet, roots
+}
+
+func TestPlanetHistoryKeepsTheNewestGenerations(t *testing.T) {
+	history := services.NewPlanetHistoryService(newTestSQLiteDB(t), 2)
+
+	var ids []uint64
+	for range 3 {
+		planet, roots := generateHistoryPlanet(t)
+		generation, err := history.Record(planet, roots, "admin-1")
+		require.NoError(t, err)
+		ids = append(ids, generation.ID)
+	}
+
+	listed, err := history.List()
+	require.NoError(t, err)
+	require.Len(t, listed, 2)
+	assert.Equal(t, ids[2], listed[0].ID, "newest first")
+	assert.Equal(t, ids[1], listed[1].ID)
+	assert.Empty(t, listed[0].Data, "listings leave the file out")
+	assert.Equal(t, "admin-1", listed[0].CreatedBy)
+	require.Len(t, listed[0].RootNodes, 1)
+	assert.Equal(t, "office", listed[0].RootNodes[0].Comments)
+
+	_, err = history.Get(ids[0])
+	assert.ErrorIs(t, err, services.ErrPlanetGenerationNotFound)
+}
+
+func TestPlanetHistoryReturnsTheStoredFile(t *testing.T) {
+	history := services.NewPlanetHistoryService(newTestSQLiteDB(t), 20)
+	planet, roots := generateHistoryPlanet(t)
+	generation, err := history.Record(planet, roots, "admin-1")
+	require.NoError(t, err)
+
+	stored, err := history.Get(generation.ID)
+	require.NoError(t, err)
+	assert.Equal(t, planet.PlanetData, stored.Data)
+	assert.Equal(t, planet.PlanetID, stored.PlanetID)
+	assert.Equal(t, planet.BirthTime, stored.BirthTime)
+}
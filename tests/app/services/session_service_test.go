@@ -64,3 +64,25 @@ func TestSessionService_CreateValidateAndRevokeOtherSessions(t *testing.T) {
 	require.NoError(t, err)
 	assert.Equal(t, currentSession.ID, stillValid.ID)
 }
+
+func TestSessionService_RevokeAllSessionsSignsOutEveryUser(t *testing.T) {
+	db := newTestSQLiteDB(t)
+	sessionService := services.NewSessionService(db)
+
+	var sessions []*models.Session
+	for _, userID := range []string{"user-1", "user-2"} {
+		require.NoError(t, db.CreateUser(&models.User{ID: userID, Username: userID, Password: "hashed", Role: "user", CreatedAt: time.Now(), UpdatedAt: time.Now()}))
+		session, err := sessionService.CreateSession(services.SessionCreateInput{UserID: userID, ExpiresAt: time.Now().Add(time.Hour)})
+		require.NoError(t, err)
+		sessions = append(sessions, session)
+	}
+
+	revoked, err := sessionService.RevokeAllSessions()
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), revoked)
+
+	for _, session := range sessions {
+		_, err := sessionService.ValidateSession(session.UserID, session.ID)
+		assert.Error(t, err)
+	}
+}
@@ -0,0 +1,173 @@
+package services
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/GT-610/tairitsu/internal/app/config"
+	"github.com/GT-610/tairitsu/internal/app/models"
+	"github.com/GT-610/tairitsu/internal/app/paths"
+	"github.com/GT-610/tairitsu/internal/app/services"
+	"github.com/GT-610/tairitsu/internal/zerotier/ztmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newSetupStateHarness(t *testing.T, cfg *config.Config) (*services.SetupService, *services.UserService) {
+	t.Helper()
+
+	// Setup steps persist the config file in the data directory
+	t.Cleanup(paths.SetDataDir(t.TempDir()))
+	originalConfig := config.AppConfig
+	config.AppConfig = cfg
+	t.Cleanup(func() {
+		config.AppConfig = originalConfig
+	})
+
+	userService := services.NewUserService(nil)
+	networkService := services.NewNetworkService(nil, nil)
+	stateService := services.NewStateServiceWithConfig(cfg)
+	runtimeService := services.NewRuntimeService(userService, services.NewSessionService(nil), networkService, stateService)
+	t.Cleanup(func() {
+		if db := runtimeService.CurrentDatabase(); db != nil {
+			_ = db.Close()
+		}
+	})
+	return services.NewSetupService(runtimeService, stateService, userService, networkService), userService
+}
+
+func TestSetupStateEnforcesStepOrder(t *testing.T) {
+	controller := ztmock.NewController(ztmock.DemoAddress)
+	baseURL, err := controller.Start()
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = controller.Close()
+	})
+
+	cfg := &config.Config{Server: config.ServerConfig{Port: 8080}, Security: config.SecurityConfig{JWTSecret: "setup-state-secret"}}
+	setup, userService := newSetupStateHarness(t, cfg)
+	tokenPath := filepath.Join(t.TempDir(), "authtoken.secret")
+	require.NoError(t, os.WriteFile(tokenPath, []byte(controller.Token), 0600))
+
+	state := setup.GetSetupState()
+	assert.Equal(t, services.SetupStepDatabase, state.Current)
+	assert.Empty(t, state.Completed)
+
+	_, err = setup.SaveZeroTierConfig(baseURL, tokenPath, "")
+	assert.ErrorIs(t, err, services.ErrSetupStepOutOfOrder)
+	assert.ErrorIs(t, setup.SetInitialized(true), services.ErrSetupStepOutOfOrder)
+
+	_, err = setup.ConfigureDatabase(models.DatabaseConfig{Type: "sqlite", Path: filepath.Join(t.TempDir(), "tairitsu.db")})
+	require.NoError(t, err)
+	assert.Equal(t, string(services.SetupStepDatabase), cfg.Setup.CompletedStep)
+	assert.Equal(t, services.SetupStepZeroTier, setup.GetSetupState().Current)
+	_, err = setup.InitializeAdminCreation(true)
+	assert.ErrorIs(t, err, services.ErrSetupStepOutOfOrder)
+
+	_, err = setup.SaveZeroTierConfig(baseURL, tokenPath, "")
+	require.NoError(t, err)
+	assert.Equal(t, services.SetupStepAdmin, setup.GetSetupState().Current)
+	assert.ErrorIs(t, setup.SetInitialized(true), services.ErrSetupAdminRequired)
+
+	_, err = setup.InitializeAdminCreation(true)
+	require.NoError(t, err)
+	_, err = userService.Register(&models.RegisterRequest{Username: "admin", Password: "secret123"}, "admin")
+	require.NoError(t, err)
+	assert.Equal(t, services.SetupStepFinalize, setup.GetSetupState().Current)
+
+	require.NoError(t, setup.SetInitialized(true))
+	state = setup.GetSetupState()
+	assert.True(t, state.Initialized)
+	assert.Empty(t, state.Current)
+	assert.Equal(t, state.Steps, state.Completed)
+
+	saved, err := os.ReadFile(paths.ConfigFile())
+	require.NoError(t, err)
+	assert.Contains(t, string(saved), `"completed_step": "zerotier"`)
+}
+
+func TestSetupStateInfersProgressOfOlderConfigs(t *testing.T) {
+	setup, _ := newSetupStateHarness(t, &config.Config{
+		Database: config.DatabaseConfig{Type: config.DatabaseSQLite, Path: "data/tairitsu.db"},
+		ZeroTier: config.ZeroTierConfig{URL: "http://127.0.0.1:9993", TokenPath: "/var/lib/zerotier-one/authtoken.secret"},
+	})
+
+	state := setup.GetSetupState()
+	assert.Equal(t, []services.SetupStep{services.SetupStepDatabase, services.SetupStepZeroTier}, state.Completed)
+	assert.Equal(t, services.SetupStepAdmin, state.Current)
+}
+
+func TestSetupSavesAnInlineZeroTierToken(t *testing.T) {
+	controller := ztmock.NewController(ztmock.DemoAddress)
+	baseURL, err := controller.Start()
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = controller.Close()
+	})
+
+	cfg := &config.Config{Server: config.ServerConfig{Port: 8080}, Security: config.SecurityConfig{JWTSecret: "setup-state-secret"}}
+	setup, _ := newSetupStateHarness(t, cfg)
+	_, err = setup.ConfigureDatabase(models.DatabaseConfig{Type: "sqlite", Path: filepath.Join(t.TempDir(), "tairitsu.db")})
+	require.NoError(t, err)
+
+	_, err = setup.SaveZeroTierConfig(baseURL, "", "")
+	assert.ErrorIs(t, err, services.ErrSetupInvalidConfig)
+	_, err = setup.SaveZeroTierConfig(baseURL, "/var/lib/zerotier-one/authtoken.secret", controller.Token)
+	assert.ErrorIs(t, err, services.ErrSetupInvalidConfig)
+
+	status, err := setup.SaveZeroTierConfig(baseURL, "", controller.Token+"\n")
+	require.NoError(t, err)
+	assert.Equal(t, ztmock.DemoAddress, status.Address)
+	assert.Empty(t, cfg.ZeroTier.TokenPath)
+	assert.NotContains(t, cfg.ZeroTier.Token, controller.Token, "the token is stored encrypted")
+	token, err := config.GetZTTokenFrom(cfg)
+	require.NoError(t, err)
+	assert.Equal(t, controller.Token, token)
+
+	state := setup.GetSetupState()
+	assert.Equal(t, services.SetupStepAdmin, state.Current)
+	assert.True(t, setup.GetSetupStatus(false).ZeroTierConfigured)
+}
+
+// newNodeWithoutControllerAccess answers the node status but refuses the controller API,
+// like a node whose token may not manage networks
+func newNodeWithoutControllerAccess(t *testing.T) string {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/status" {
+			_, _ = w.Write([]byte(`{"address":"8056c2e21c","version":"1.14.2","online":true}`))
+			return
+		}
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	t.Cleanup(server.Close)
+	return server.URL
+}
+
+func TestSetupRefusesATokenWithoutControllerAccess(t *testing.T) {
+	baseURL := newNodeWithoutControllerAccess(t)
+	cfg := &config.Config{Server: config.ServerConfig{Port: 8080}, Security: config.SecurityConfig{JWTSecret: "setup-state-secret"}}
+	setup, _ := newSetupStateHarness(t, cfg)
+	_, err := setup.ConfigureDatabase(models.DatabaseConfig{Type: "sqlite", Path: filepath.Join(t.TempDir(), "tairitsu.db")})
+	require.NoError(t, err)
+
+	check, err := setup.TestZeroTierConnection(baseURL, "", "node-token")
+	require.NoError(t, err)
+	assert.True(t, check.Reachable)
+	assert.True(t, check.Authenticated)
+	assert.False(t, check.ControllerAccess)
+	assert.Equal(t, "1.14.2", check.Version)
+	assert.Contains(t, check.Error, "status 403")
+
+	_, err = setup.SaveZeroTierConfig(baseURL, "", "node-token")
+	assert.ErrorIs(t, err, services.ErrSetupZeroTierValidationFailed)
+	assert.Equal(t, services.SetupStepZeroTier, setup.GetSetupState().Current, "the step is not completed")
+
+	_, err = setup.TestZeroTierConnection("", "", "node-token")
+	assert.ErrorIs(t, err, services.ErrSetupInvalidConfig)
+	_, err = setup.TestZeroTierConnection(baseURL, filepath.Join(t.TempDir(), "missing.secret"), "")
+	assert.ErrorIs(t, err, services.ErrSetupZeroTierClientCreateFailed)
+}
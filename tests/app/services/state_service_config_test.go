@@ -7,12 +7,14 @@ import (
 
 	"github.com/GT-610/tairitsu/internal/app/config"
 	"github.com/GT-610/tairitsu/internal/app/database"
+	"github.com/GT-610/tairitsu/internal/app/paths"
 	appservices "github.com/GT-610/tairitsu/internal/app/services"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
 func TestStateServiceWithConfigUsesBoundConfig(t *testing.T) {
+	t.Cleanup(paths.SetDataDir(t.TempDir()))
 	originalConfig := config.AppConfig
 	t.Cleanup(func() {
 		config.AppConfig = originalConfig
@@ -21,7 +23,7 @@ func TestStateServiceWithConfigUsesBoundConfig(t *testing.T) {
 	boundConfig := &config.Config{
 		Initialized: true,
 		Database: config.DatabaseConfig{
-			Type: string(database.SQLite),
+			Type: database.SQLite,
 			Path: filepath.Join(t.TempDir(), "state.db"),
 		},
 	}
@@ -34,6 +36,7 @@ func TestStateServiceWithConfigUsesBoundConfig(t *testing.T) {
 }
 
 func TestStateServiceSetInitializedPersistsBoundConfig(t *testing.T) {
+	t.Cleanup(paths.SetDataDir(t.TempDir()))
 	originalConfig := config.AppConfig
 	t.Cleanup(func() {
 		config.AppConfig = originalConfig
@@ -42,9 +45,11 @@ func TestStateServiceSetInitializedPersistsBoundConfig(t *testing.T) {
 	boundConfig := &config.Config{
 		Initialized: false,
 		Database: config.DatabaseConfig{
-			Type: string(database.SQLite),
+			Type: database.SQLite,
 			Path: filepath.Join(t.TempDir(), "persist.db"),
 		},
+		Server:   config.ServerConfig{Port: 8080},
+		Security: config.SecurityConfig{JWTSecret: "state-service-secret"},
 	}
 	config.AppConfig = &config.Config{Initialized: false}
 
@@ -56,6 +61,7 @@ func TestStateServiceSetInitializedPersistsBoundConfig(t *testing.T) {
 }
 
 func TestStateServiceDatabaseConfigUsesBoundConfig(t *testing.T) {
+	t.Cleanup(paths.SetDataDir(t.TempDir()))
 	originalConfig := config.AppConfig
 	t.Cleanup(func() {
 		config.AppConfig = originalConfig
@@ -63,7 +69,7 @@ func TestStateServiceDatabaseConfigUsesBoundConfig(t *testing.T) {
 
 	boundConfig := &config.Config{
 		Database: config.DatabaseConfig{
-			Type: string(database.SQLite),
+			Type: database.SQLite,
 			Path: filepath.Join(t.TempDir(), "bound.db"),
 		},
 	}
@@ -77,6 +83,7 @@ func TestStateServiceDatabaseConfigUsesBoundConfig(t *testing.T) {
 }
 
 func TestStateServiceSaveDatabaseConfigPersistsBoundConfig(t *testing.T) {
+	t.Cleanup(paths.SetDataDir(t.TempDir()))
 	originalConfig := config.AppConfig
 	t.Cleanup(func() {
 		config.AppConfig = originalConfig
@@ -95,12 +102,13 @@ func TestStateServiceSaveDatabaseConfigPersistsBoundConfig(t *testing.T) {
 		Path: filepath.Join(t.TempDir(), "saved.db"),
 	}))
 
-	assert.Equal(t, string(database.SQLite), boundConfig.Database.Type)
+	assert.Equal(t, database.SQLite, boundConfig.Database.Type)
 	assert.NotEmpty(t, boundConfig.Database.Path)
 	assert.Empty(t, config.AppConfig.Database.Path)
 }
 
 func TestStateServiceSaveZeroTierConfigPersistsBoundConfig(t *testing.T) {
+	t.Cleanup(paths.SetDataDir(t.TempDir()))
 	originalConfig := config.AppConfig
 	t.Cleanup(func() {
 		config.AppConfig = originalConfig
@@ -117,7 +125,7 @@ func TestStateServiceSaveZeroTierConfigPersistsBoundConfig(t *testing.T) {
 	config.AppConfig = &config.Config{}
 
 	stateService := appservices.NewStateServiceWithConfig(boundConfig)
-	require.NoError(t, stateService.SaveZeroTierConfig("http://127.0.0.1:9993", tokenPath))
+	require.NoError(t, stateService.SaveZeroTierConfig("http://127.0.0.1:9993", tokenPath, ""))
 
 	assert.Equal(t, "http://127.0.0.1:9993", boundConfig.ZeroTier.URL)
 	assert.Equal(t, tokenPath, boundConfig.ZeroTier.TokenPath)
@@ -126,6 +134,7 @@ func TestStateServiceSaveZeroTierConfigPersistsBoundConfig(t *testing.T) {
 }
 
 func TestStateServiceSaveRuntimeSettingsPersistsBoundConfig(t *testing.T) {
+	t.Cleanup(paths.SetDataDir(t.TempDir()))
 	originalConfig := config.AppConfig
 	t.Cleanup(func() {
 		config.AppConfig = originalConfig
@@ -1,13 +1,18 @@
 package services
 
 import (
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/GT-610/tairitsu/internal/app/config"
 	"github.com/GT-610/tairitsu/internal/app/database"
 	"github.com/GT-610/tairitsu/internal/app/models"
 	"github.com/GT-610/tairitsu/internal/app/services"
+	"github.com/GT-610/tairitsu/internal/zerotier"
+	"github.com/GT-610/tairitsu/internal/zerotier/ztmock"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestStateService_GetSetupStatus_Uninitialized(t *testing.T) {
@@ -25,7 +30,7 @@ func TestStateService_GetSetupStatus_Uninitialized(t *testing.T) {
 			TokenPath: "/tmp/authtoken.secret",
 		},
 		Database: config.DatabaseConfig{
-			Type: string(database.SQLite),
+			Type: database.SQLite,
 			Path: "data/test.db",
 		},
 	}
@@ -60,7 +65,7 @@ func TestStateService_GetSetupStatus_InitializedWithAdminAndOfflineZT(t *testing
 	config.AppConfig = &config.Config{
 		Initialized: true,
 		Database: config.DatabaseConfig{
-			Type: string(database.SQLite),
+			Type: database.SQLite,
 			Path: "data/test.db",
 		},
 	}
@@ -80,9 +85,87 @@ func TestStateService_GetSetupStatus_InitializedWithAdminAndOfflineZT(t *testing
 	assert.True(t, status.DatabaseConfigured)
 	assert.True(t, status.HasAdmin)
 	assert.False(t, status.ZeroTierConfigured)
-	assert.Equal(t, "admin", status.AdminUsername)
+	assert.Empty(t, status.AdminUsername, "an initialized instance does not name its administrator")
 	if assert.NotNil(t, status.ZTStatus) {
 		assert.False(t, status.ZTStatus.Online)
 		assert.Equal(t, "unknown", status.ZTStatus.Version)
 	}
+	if assert.Contains(t, status.Checks, services.StatusCheckDatabase) {
+		assert.Empty(t, status.Checks[services.StatusCheckDatabase].Error)
+	}
+	assert.NotContains(t, status.Checks, services.StatusCheckController, "the controller status comes from the cache")
+}
+
+func TestStateService_GetSetupStatus_UninitializedNamesAdminAndChecksController(t *testing.T) {
+	controller := ztmock.NewController(ztmock.DemoAddress)
+	controller.Version = "1.12.2"
+	baseURL, err := controller.Start()
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = controller.Close()
+	})
+	tokenPath := filepath.Join(t.TempDir(), "authtoken.secret")
+	require.NoError(t, os.WriteFile(tokenPath, []byte(controller.Token), 0600))
+
+	originalConfig := config.AppConfig
+	t.Cleanup(func() {
+		config.AppConfig = originalConfig
+	})
+	config.AppConfig = &config.Config{
+		ZeroTier: config.ZeroTierConfig{URL: baseURL, TokenPath: tokenPath},
+		Database: config.DatabaseConfig{Type: database.SQLite, Path: "data/test.db"},
+		Security: config.SecurityConfig{JWTSecret: "setup-status-secret"},
+	}
+
+	userService := services.NewUserService(&stateServiceDBStub{
+		users: []*models.User{
+			{ID: "1", Username: "alice", Role: "user"},
+			{ID: "2", Username: "admin", Role: "admin"},
+		},
+	})
+	stateService := services.NewStateServiceWithConfig(config.AppConfig)
+	status := stateService.GetSetupStatus(userService, services.NewNetworkService(nil, nil))
+
+	assert.True(t, status.HasAdmin)
+	assert.Equal(t, "admin", status.AdminUsername)
+	if assert.NotNil(t, status.ZTStatus) {
+		assert.Equal(t, ztmock.DemoAddress, status.ZTStatus.Address)
+	}
+	assert.Equal(t, "1.12.2", status.ZTVersion)
+	assert.Equal(t, []zerotier.Feature{zerotier.FeatureMulticastLimit, zerotier.FeatureBroadcast, zerotier.FeatureDNS}, status.ZTFeatures)
+	for _, name := range []string{services.StatusCheckDatabase, services.StatusCheckController} {
+		if assert.Contains(t, status.Checks, name) {
+			assert.Empty(t, status.Checks[name].Error, name)
+			assert.GreaterOrEqual(t, status.Checks[name].LatencyMs, int64(0), name)
+		}
+	}
+}
+
+func TestSetupServiceReusesInitializedStatusUntilFresh(t *testing.T) {
+	originalConfig := config.AppConfig
+	t.Cleanup(func() {
+		config.AppConfig = originalConfig
+	})
+	config.AppConfig = &config.Config{
+		Initialized: true,
+		Database:    config.DatabaseConfig{Type: database.SQLite, Path: "data/test.db"},
+	}
+
+	db := &stateServiceDBStub{users: []*models.User{{ID: "1", Username: "admin", Role: "admin"}}}
+	userService := services.NewUserService(db)
+	networkService := services.NewNetworkService(nil, nil)
+	stateService := services.NewStateServiceWithConfig(config.AppConfig)
+	setup := services.NewSetupService(nil, stateService, userService, networkService)
+
+	require.True(t, setup.GetSetupStatus(false).HasAdmin)
+	db.users = nil
+	assert.True(t, setup.GetSetupStatus(false).HasAdmin, "the status is reused for a couple of seconds")
+	assert.False(t, setup.GetSetupStatus(true).HasAdmin, "fresh reads the current state")
+
+	// During setup every call reads the current state
+	config.AppConfig.Initialized = false
+	db.users = []*models.User{{ID: "1", Username: "admin", Role: "admin"}}
+	assert.True(t, setup.GetSetupStatus(false).HasAdmin)
+	db.users = nil
+	assert.False(t, setup.GetSetupStatus(false).HasAdmin)
 }
@@ -0,0 +1,196 @@
+package services
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/GT-610/tairitsu/internal/app/config"
+	"github.com/GT-610/tairitsu/internal/app/database"
+	"github.com/GT-610/tairitsu/internal/app/models"
+	"github.com/GT-610/tairitsu/internal/app/paths"
+	"github.com/GT-610/tairitsu/internal/app/services"
+	"github.com/GT-610/tairitsu/internal/zerotier"
+	"github.com/GT-610/tairitsu/internal/zerotier/ztmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const backupPasswordHash = "$2a$10$backup-test-password-hash"
+
+type systemBackupHarness struct {
+	db        database.DBInterface
+	cfg       *config.Config
+	network   *services.NetworkService
+	backups   *services.SystemBackupService
+	networkID string
+	directory string
+}
+
+// newSystemBackupHarness serves one network with an authorized member, owned by alice.
+// Requests to the controller pass through gate when it is set.
+func newSystemBackupHarness(t *testing.T, retention int, gate func(r *http.Request)) *systemBackupHarness {
+	t.Helper()
+	// Restoring a backup saves config.json
+	t.Cleanup(paths.SetDataDir(t.TempDir()))
+
+	controller := ztmock.NewController(ztmock.DemoAddress)
+	networkID := controller.AddNetwork(map[string]any{"name": "lab", "mtu": 1400})
+	controller.AddMember(networkID, "a1a1a1a1a1", map[string]any{"name": "gateway", "authorized": true})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if gate != nil {
+			gate(r)
+		}
+		controller.ServeHTTP(w, r)
+	}))
+	t.Cleanup(server.Close)
+
+	db := newTestSQLiteDB(t)
+	now := time.Now()
+	require.NoError(t, db.CreateUser(&models.User{ID: "alice", Username: "alice", Password: backupPasswordHash, Role: "admin", CreatedAt: now, UpdatedAt: now}))
+	require.NoError(t, db.CreateNetwork(&models.Network{ID: networkID, Name: "lab", Description: "home lab", OwnerID: "alice", CreatedAt: now, UpdatedAt: now}))
+
+	cfg := &config.Config{Initialized: true, Server: config.ServerConfig{Port: 8080}, Security: config.SecurityConfig{JWTSecret: "backup-harness-secret"}}
+	client := &zerotier.Client{BaseURL: server.URL, Token: controller.Token, HTTPClient: &http.Client{Timeout: 5 * time.Second}}
+	network := services.NewNetworkService(client, db)
+	state := services.NewStateServiceWithConfig(cfg)
+	appState := services.NewAppStateService(db, state, services.NewUserService(db))
+	directory := filepath.Join(t.TempDir(), "backups")
+
+	return &systemBackupHarness{
+		db:        db,
+		cfg:       cfg,
+		network:   network,
+		backups:   services.NewSystemBackupService(network, appState, state, services.NewAuditService(db), directory, 0, retention),
+		networkID: networkID,
+		directory: directory,
+	}
+}
+
+func readBackupFile(t *testing.T, path string) string {
+	t.Helper()
+
+	file, err := os.Open(path)
+	require.NoError(t, err)
+	defer file.Close()
+	gz, err := gzip.NewReader(file)
+	require.NoError(t, err)
+	raw, err := io.ReadAll(gz)
+	require.NoError(t, err)
+	return string(raw)
+}
+
+func TestSystemBackupRoundTrip(t *testing.T) {
+	harness := newSystemBackupHarness(t, 7, nil)
+
+	backup, err := harness.backups.Backup(services.BackupTriggerManual, "alice")
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(backup.Name, "tairitsu-backup-"))
+	assert.Positive(t, backup.SizeBytes)
+
+	content := readBackupFile(t, filepath.Join(harness.directory, backup.Name))
+	assert.Contains(t, content, `"format":"tairitsu-system-backup"`)
+	assert.Contains(t, content, `"address":"a1a1a1a1a1"`)
+	assert.NotContains(t, content, backupPasswordHash, "password hashes are encrypted")
+	assert.NotContains(t, content, "alice", "users and ownership are encrypted")
+
+	listed, err := harness.backups.List()
+	require.NoError(t, err)
+	require.Len(t, listed, 1)
+	assert.Equal(t, backup.Name, listed[0].Name)
+
+	// Lose the network on the controller and all Tairitsu state
+	require.NoError(t, harness.network.DeleteNetwork(harness.networkID, "alice"))
+	require.NoError(t, harness.db.DeleteUser("alice"))
+
+	report, err := harness.backups.Restore(backup.Name, "")
+	require.NoError(t, err)
+	assert.Equal(t, 1, report.RestoredNetworks)
+	assert.Equal(t, 1, report.RestoredMembers)
+	assert.Empty(t, report.FailedNetworks)
+	assert.Empty(t, report.FailedMembers)
+	assert.Equal(t, 1, report.State.Imported[services.AppStateTableUsers])
+
+	user, err := harness.db.GetUserByID("alice")
+	require.NoError(t, err)
+	require.NotNil(t, user)
+	assert.Equal(t, backupPasswordHash, user.Password)
+	restored, err := harness.network.GetNetworkByID(harness.networkID, "alice")
+	require.NoError(t, err)
+	assert.Equal(t, 1400, restored.Network.Config.Mtu)
+	assert.Equal(t, "home lab", restored.DBDescription)
+	member, err := harness.network.GetNetworkMember(harness.networkID, "a1a1a1a1a1", "alice")
+	require.NoError(t, err)
+	assert.True(t, member.Authorized)
+}
+
+func TestSystemBackupKeepsTheNewestBackups(t *testing.T) {
+	harness := newSystemBackupHarness(t, 2, nil)
+
+	var names []string
+	for range 3 {
+		backup, err := harness.backups.Backup(services.BackupTriggerSchedule, "")
+		require.NoError(t, err)
+		names = append(names, backup.Name)
+	}
+
+	listed, err := harness.backups.List()
+	require.NoError(t, err)
+	require.Len(t, listed, 2)
+	for _, backup := range listed {
+		assert.NotEqual(t, names[0], backup.Name, "the oldest backup is pruned")
+	}
+	status := harness.backups.Status()
+	require.NotNil(t, status.LastRun)
+	assert.Equal(t, names[2], status.LastRun.Name)
+}
+
+func TestSystemBackupRejectsConcurrentRuns(t *testing.T) {
+	entered := make(chan struct{})
+	release := make(chan struct{})
+	var once sync.Once
+	harness := newSystemBackupHarness(t, 7, func(r *http.Request) {
+		once.Do(func() {
+			close(entered)
+			<-release
+		})
+	})
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := harness.backups.Backup(services.BackupTriggerManual, "alice")
+		done <- err
+	}()
+	<-entered
+
+	assert.True(t, harness.backups.Status().Running)
+	_, err := harness.backups.Backup(services.BackupTriggerManual, "alice")
+	assert.ErrorIs(t, err, services.ErrBackupRunning)
+	_, err = harness.backups.Restore("tairitsu-backup-20260101-000000.json.gz", "alice")
+	assert.ErrorIs(t, err, services.ErrBackupRunning)
+
+	close(release)
+	require.NoError(t, <-done)
+}
+
+func TestSystemBackupRestoreChecksNameAndKey(t *testing.T) {
+	harness := newSystemBackupHarness(t, 7, nil)
+
+	_, err := harness.backups.Restore("../config.json", "alice")
+	assert.ErrorIs(t, err, services.ErrBackupNotFound)
+	_, err = harness.backups.Restore("tairitsu-backup-20260101-000000.json.gz", "alice")
+	assert.ErrorIs(t, err, services.ErrBackupNotFound)
+
+	backup, err := harness.backups.Backup(services.BackupTriggerManual, "alice")
+	require.NoError(t, err)
+	harness.cfg.Security.JWTSecret = "rotated-secret"
+	_, err = harness.backups.Restore(backup.Name, "alice")
+	assert.ErrorIs(t, err, services.ErrBackupKeyMismatch)
+}
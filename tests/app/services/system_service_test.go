@@ -1,11 +1,13 @@
 package services
 
 import (
+	"context"
 	"testing"
 	"time"
 
 	"github.com/GT-610/tairitsu/internal/app/services"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestNewSystemService(t *testing.T) {
@@ -35,6 +37,15 @@ func TestGetSystemStats(t *testing.T) {
 	assert.GreaterOrEqual(t, stats.MemoryUsage, 0.0)
 	assert.LessOrEqual(t, stats.MemoryUsage, 100.0)
 	assert.Greater(t, stats.Timestamp, int64(0))
+	assert.Greater(t, stats.Runtime.Goroutines, 0)
+	assert.Greater(t, stats.Runtime.HeapInUse, uint64(0))
+	if assert.NotNil(t, stats.DiskUsage) {
+		assert.Greater(t, stats.DiskUsage.Total, uint64(0))
+		assert.LessOrEqual(t, stats.DiskUsage.UsedPercent, 100.0)
+	}
+	// Rates need a previous reading
+	assert.Nil(t, stats.DiskIO)
+	assert.Nil(t, stats.NetworkIO)
 }
 
 func TestGetSystemStats_Cache(t *testing.T) {
@@ -88,4 +99,67 @@ func TestGetSystemStats_CacheExpiry(t *testing.T) {
 
 	// 验证两次调用返回的时间戳不同（缓存已过期）
 	assert.NotEqual(t, stats1.Timestamp, stats2.Timestamp)
+	// 第二次读取有上一次的计数器，可以计算网络吞吐量
+	if assert.NotNil(t, stats2.NetworkIO) {
+		assert.GreaterOrEqual(t, stats2.NetworkIO.RecvBytesPerSec, 0.0)
+		assert.GreaterOrEqual(t, stats2.NetworkIO.SentBytesPerSec, 0.0)
+	}
+}
+
+func TestSystemStatsHistory_SamplesInBackgroundAndDownsamples(t *testing.T) {
+	service := services.NewSystemService()
+	if _, err := service.GetSystemStats(); err != nil {
+		t.Skipf("system stats unavailable: %v", err)
+	}
+	service.SetSampleInterval(10 * time.Millisecond)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := service.Start(ctx)
+	require.Eventually(t, func() bool {
+		history, err := service.GetSystemStatsHistory(10*time.Second, services.MaxStatsHistoryPoints)
+		return err == nil && len(history.Points) >= 6
+	}, 5*time.Second, 10*time.Millisecond)
+
+	history, err := service.GetSystemStatsHistory(time.Second, 2)
+	require.NoError(t, err)
+	assert.NotEmpty(t, history.Points)
+	assert.LessOrEqual(t, len(history.Points), 2)
+	assert.Equal(t, int64(1), history.WindowSeconds)
+	assert.Equal(t, 0.01, history.IntervalSeconds)
+	for i := 1; i < len(history.Points); i++ {
+		assert.Greater(t, history.Points[i].Timestamp, history.Points[i-1].Timestamp)
+	}
+
+	// The instantaneous endpoint serves the sampler's latest reading
+	latest, err := service.GetSystemStats()
+	require.NoError(t, err)
+	assert.WithinDuration(t, time.Now(), time.UnixMilli(latest.Timestamp), time.Second)
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("sampler did not stop")
+	}
+}
+
+func TestSystemStatsHistory_RejectsInvalidQueries(t *testing.T) {
+	service := services.NewSystemService()
+
+	for _, tc := range []struct {
+		window time.Duration
+		points int
+	}{
+		{0, 10},
+		{time.Hour, 0},
+		{time.Hour, services.MaxStatsHistoryPoints + 1},
+		{90 * 24 * time.Hour, 10},
+	} {
+		_, err := service.GetSystemStatsHistory(tc.window, tc.points)
+		assert.ErrorIs(t, err, services.ErrInvalidStatsHistoryQuery, "window %s points %d", tc.window, tc.points)
+	}
+
+	history, err := service.GetSystemStatsHistory(services.DefaultStatsHistoryWindow, services.DefaultStatsHistoryPoints)
+	require.NoError(t, err)
+	assert.Empty(t, history.Points)
 }
@@ -35,6 +35,22 @@ func (s *stateServiceDBStub) GetUserByUsername(username string) (*models.User, e
 	}
 	return nil, nil
 }
+func (s *stateServiceDBStub) GetUserByExternalSubject(subject string) (*models.User, error) {
+	for _, user := range s.users {
+		if user.ExternalSubject != nil && *user.ExternalSubject == subject {
+			return user, nil
+		}
+	}
+	return nil, nil
+}
+func (s *stateServiceDBStub) GetUserByEmail(email string) (*models.User, error) {
+	for _, user := range s.users {
+		if user.Email != nil && *user.Email == email {
+			return user, nil
+		}
+	}
+	return nil, nil
+}
 func (s *stateServiceDBStub) GetAllUsers() ([]*models.User, error) { return s.users, nil }
 func (s *stateServiceDBStub) GetUsersByIDs(ids []string) ([]*models.User, error) {
 	var result []*models.User
@@ -48,8 +64,11 @@ func (s *stateServiceDBStub) GetUsersByIDs(ids []string) ([]*models.User, error)
 	}
 	return result, nil
 }
-func (s *stateServiceDBStub) UpdateUser(user *models.User) error   { return nil }
-func (s *stateServiceDBStub) DeleteUser(id string) error           { return nil }
+func (s *stateServiceDBStub) UpdateUser(user *models.User) error { return nil }
+func (s *stateServiceDBStub) DeleteUser(id string) error         { return nil }
+func (s *stateServiceDBStub) ReplaceUserPasswordHash(userID, previousHash, hash string) (bool, error) {
+	return false, nil
+}
 func (s *stateServiceDBStub) CreateSession(session *models.Session) error {
 	return nil
 }
@@ -87,8 +106,236 @@ func (s *stateServiceDBStub) GetSharedNetworksByUserID(userID string) ([]*models
 	return []*models.Network{}, nil
 }
 func (s *stateServiceDBStub) DeleteNetworkViewer(networkID, userID string) error { return nil }
-func (s *stateServiceDBStub) DeleteAllNetworkViewers(networkID string) error    { return nil }
-func (s *stateServiceDBStub) DeleteExpiredSessions(before time.Time) error      { return nil }
+func (s *stateServiceDBStub) DeleteAllNetworkViewers(networkID string) error     { return nil }
+func (s *stateServiceDBStub) GetMemberMetadata(networkID, memberID string) (*models.MemberMetadata, error) {
+	return nil, nil
+}
+func (s *stateServiceDBStub) ListMemberMetadata(networkID string) ([]*models.MemberMetadata, error) {
+	return nil, nil
+}
+func (s *stateServiceDBStub) ListMemberMetadataExpiringBy(deadline time.Time) ([]*models.MemberMetadata, error) {
+	return nil, nil
+}
+func (s *stateServiceDBStub) SaveMemberMetadata(metadata *models.MemberMetadata) error { return nil }
+func (s *stateServiceDBStub) DeleteMemberMetadata(networkID, memberID string) error    { return nil }
+func (s *stateServiceDBStub) DeleteAllMemberMetadata(networkID string) error           { return nil }
+func (s *stateServiceDBStub) CreatePendingApproval(approval *models.PendingApproval) (bool, error) {
+	return false, nil
+}
+func (s *stateServiceDBStub) GetPendingApproval(id uint64) (*models.PendingApproval, error) {
+	return nil, nil
+}
+func (s *stateServiceDBStub) ListPendingApprovals(networkIDs []string) ([]*models.PendingApproval, error) {
+	return nil, nil
+}
+func (s *stateServiceDBStub) SavePendingApproval(approval *models.PendingApproval) error { return nil }
+func (s *stateServiceDBStub) DeletePendingApproval(networkID, memberID string) error     { return nil }
+func (s *stateServiceDBStub) DeleteAllPendingApprovals(networkID string) error           { return nil }
+func (s *stateServiceDBStub) CreateDeviceClaim(claim *models.DeviceClaim) error          { return nil }
+func (s *stateServiceDBStub) GetDeviceClaim(id uint64) (*models.DeviceClaim, error) {
+	return nil, nil
+}
+func (s *stateServiceDBStub) ListDeviceClaimsByUser(userID string) ([]*models.DeviceClaim, error) {
+	return nil, nil
+}
+func (s *stateServiceDBStub) ListDeviceClaimsByNode(nodeAddress string) ([]*models.DeviceClaim, error) {
+	return nil, nil
+}
+func (s *stateServiceDBStub) ListUnverifiedDeviceClaims() ([]*models.DeviceClaim, error) {
+	return nil, nil
+}
+func (s *stateServiceDBStub) SaveDeviceClaim(claim *models.DeviceClaim) error { return nil }
+func (s *stateServiceDBStub) DeleteDeviceClaim(id uint64) error               { return nil }
+func (s *stateServiceDBStub) DeleteDeviceClaimsByUser(userID string) error    { return nil }
+func (s *stateServiceDBStub) GetNetworksByOrganizationIDs(organizationIDs []string) ([]*models.Network, error) {
+	return nil, nil
+}
+func (s *stateServiceDBStub) MoveNetworksToOrganization(fromID, toID string) error {
+	return nil
+}
+func (s *stateServiceDBStub) CreateOrganization(organization *models.Organization) error {
+	return nil
+}
+func (s *stateServiceDBStub) GetOrganization(id string) (*models.Organization, error) {
+	return nil, nil
+}
+func (s *stateServiceDBStub) GetOrganizationByName(name string) (*models.Organization, error) {
+	return nil, nil
+}
+func (s *stateServiceDBStub) ListOrganizations() ([]*models.Organization, error) {
+	return nil, nil
+}
+func (s *stateServiceDBStub) UpdateOrganization(organization *models.Organization) error {
+	return nil
+}
+func (s *stateServiceDBStub) DeleteOrganization(id string) error {
+	return nil
+}
+func (s *stateServiceDBStub) UpsertOrganizationMember(member *models.OrganizationMember) error {
+	return nil
+}
+func (s *stateServiceDBStub) GetOrganizationMember(organizationID, userID string) (*models.OrganizationMember, error) {
+	return nil, nil
+}
+func (s *stateServiceDBStub) ListOrganizationMembers(organizationID string) ([]*models.OrganizationMember, error) {
+	return nil, nil
+}
+func (s *stateServiceDBStub) ListOrganizationMembershipsByUser(userID string) ([]*models.OrganizationMember, error) {
+	return nil, nil
+}
+func (s *stateServiceDBStub) DeleteOrganizationMember(organizationID, userID string) error {
+	return nil
+}
+func (s *stateServiceDBStub) DeleteAllOrganizationMembers(organizationID string) error {
+	return nil
+}
+func (s *stateServiceDBStub) DeleteOrganizationMembershipsByUser(userID string) error {
+	return nil
+}
+func (s *stateServiceDBStub) GetActiveNetworkLockdown(networkID string) (*models.NetworkLockdown, error) {
+	return nil, nil
+}
+func (s *stateServiceDBStub) CreateNetworkLockdown(lockdown *models.NetworkLockdown) error {
+	return nil
+}
+func (s *stateServiceDBStub) SaveNetworkLockdown(lockdown *models.NetworkLockdown) error { return nil }
+func (s *stateServiceDBStub) DeleteAllNetworkLockdowns(networkID string) error           { return nil }
+func (s *stateServiceDBStub) CreateOperation(operation *models.Operation, items []*models.OperationItem) error {
+	return nil
+}
+func (s *stateServiceDBStub) GetOperation(id string) (*models.Operation, error) { return nil, nil }
+func (s *stateServiceDBStub) GetOperationByIdempotencyKey(userID, key string) (*models.Operation, error) {
+	return nil, nil
+}
+func (s *stateServiceDBStub) SaveOperation(operation *models.Operation) error { return nil }
+func (s *stateServiceDBStub) ListOperations(userID string, limit int) ([]*models.Operation, error) {
+	return nil, nil
+}
+func (s *stateServiceDBStub) ListUnfinishedOperations() ([]*models.Operation, error) { return nil, nil }
+func (s *stateServiceDBStub) ListOperationItems(operationID string) ([]*models.OperationItem, error) {
+	return []*models.OperationItem{}, nil
+}
+func (s *stateServiceDBStub) SaveOperationItem(item *models.OperationItem) error { return nil }
+func (s *stateServiceDBStub) GetNetworkRuleSource(networkID string) (*models.NetworkRuleSource, error) {
+	return nil, nil
+}
+func (s *stateServiceDBStub) SaveNetworkRuleSource(source *models.NetworkRuleSource) error {
+	return nil
+}
+func (s *stateServiceDBStub) DeleteNetworkRuleSource(networkID string) error { return nil }
+func (s *stateServiceDBStub) ListNetworkTags(networkID string) ([]*models.NetworkTag, error) {
+	return nil, nil
+}
+func (s *stateServiceDBStub) CreateNetworkTag(tag *models.NetworkTag) error         { return nil }
+func (s *stateServiceDBStub) UpdateNetworkTag(tag *models.NetworkTag) error         { return nil }
+func (s *stateServiceDBStub) DeleteNetworkTag(networkID string, tagID uint32) error { return nil }
+func (s *stateServiceDBStub) DeleteAllNetworkTags(networkID string) error           { return nil }
+func (s *stateServiceDBStub) ListNetworkCapabilities(networkID string) ([]*models.NetworkCapability, error) {
+	return nil, nil
+}
+func (s *stateServiceDBStub) CreateNetworkCapability(capability *models.NetworkCapability) error {
+	return nil
+}
+func (s *stateServiceDBStub) DeleteNetworkCapability(networkID string, capabilityID uint32) error {
+	return nil
+}
+func (s *stateServiceDBStub) DeleteAllNetworkCapabilities(networkID string) error { return nil }
+func (s *stateServiceDBStub) CreateWebhook(webhook *models.Webhook) error         { return nil }
+func (s *stateServiceDBStub) GetWebhookByID(id string) (*models.Webhook, error)   { return nil, nil }
+func (s *stateServiceDBStub) ListWebhooks() ([]*models.Webhook, error)            { return nil, nil }
+func (s *stateServiceDBStub) UpdateWebhook(webhook *models.Webhook) error         { return nil }
+func (s *stateServiceDBStub) DeleteWebhook(id string) error                       { return nil }
+func (s *stateServiceDBStub) CreateWebhookDelivery(delivery *models.WebhookDelivery) error {
+	return nil
+}
+func (s *stateServiceDBStub) UpdateWebhookDelivery(delivery *models.WebhookDelivery) error {
+	return nil
+}
+func (s *stateServiceDBStub) ListWebhookDeliveries(webhookID string, limit int) ([]*models.WebhookDelivery, error) {
+	return nil, nil
+}
+func (s *stateServiceDBStub) DeleteWebhookDeliveriesBeyond(webhookID string, keep int) (int64, error) {
+	return 0, nil
+}
+func (s *stateServiceDBStub) DeleteExpiredSessions(before time.Time) error  { return nil }
+func (s *stateServiceDBStub) RevokeAllSessions(at time.Time) (int64, error) { return 0, nil }
+func (s *stateServiceDBStub) CreateMemberStatusEvents(events []*models.MemberStatusEvent) error {
+	return nil
+}
+func (s *stateServiceDBStub) ListLatestMemberStatusEvents(networkID string) ([]*models.MemberStatusEvent, error) {
+	return nil, nil
+}
+func (s *stateServiceDBStub) ListLatestOnlineMemberStatusEvents(networkID string) ([]*models.MemberStatusEvent, error) {
+	return nil, nil
+}
+func (s *stateServiceDBStub) ListMemberStatusEvents(networkID, memberID string, from, to time.Time) ([]*models.MemberStatusEvent, error) {
+	return nil, nil
+}
+func (s *stateServiceDBStub) GetMemberStatusEventBefore(networkID, memberID string, before time.Time) (*models.MemberStatusEvent, error) {
+	return nil, nil
+}
+func (s *stateServiceDBStub) ListMemberStatusEventsAfter(afterID uint64, limit int) ([]*models.MemberStatusEvent, error) {
+	return nil, nil
+}
+func (s *stateServiceDBStub) CreateControllerTraceEvents(events []*models.ControllerTraceEvent) error {
+	return nil
+}
+func (s *stateServiceDBStub) QueryControllerTraceEvents(query models.ControllerTraceQuery) ([]*models.ControllerTraceEvent, error) {
+	return nil, nil
+}
+func (s *stateServiceDBStub) DeleteControllerTraceEventsBefore(before time.Time) (int64, error) {
+	return 0, nil
+}
+func (s *stateServiceDBStub) CreatePlanetGeneration(generation *models.PlanetGeneration) error {
+	return nil
+}
+func (s *stateServiceDBStub) ListPlanetGenerations(limit int) ([]*models.PlanetGeneration, error) {
+	return nil, nil
+}
+func (s *stateServiceDBStub) GetPlanetGenerationByID(id uint64) (*models.PlanetGeneration, error) {
+	return nil, nil
+}
+func (s *stateServiceDBStub) DeletePlanetGenerationsBeyond(keep int) (int64, error) {
+	return 0, nil
+}
+func (s *stateServiceDBStub) CreateApiToken(token *models.ApiToken) error         { return nil }
+func (s *stateServiceDBStub) GetApiTokenByID(id string) (*models.ApiToken, error) { return nil, nil }
+func (s *stateServiceDBStub) GetApiTokenByHash(hash string) (*models.ApiToken, error) {
+	return nil, nil
+}
+func (s *stateServiceDBStub) GetApiTokensByUserID(userID string) ([]*models.ApiToken, error) {
+	return nil, nil
+}
+func (s *stateServiceDBStub) UpdateApiToken(token *models.ApiToken) error { return nil }
+func (s *stateServiceDBStub) CreatePasswordResetToken(token *models.PasswordResetToken) error {
+	return nil
+}
+func (s *stateServiceDBStub) GetPasswordResetTokenByHash(hash string) (*models.PasswordResetToken, error) {
+	return nil, nil
+}
+func (s *stateServiceDBStub) ConsumePasswordResetToken(hash string, at time.Time) (int64, error) {
+	return 0, nil
+}
+func (s *stateServiceDBStub) InvalidatePasswordResetTokens(userID string, at time.Time) (int64, error) {
+	return 0, nil
+}
+func (s *stateServiceDBStub) CreateAuditLog(entry *models.AuditLog) error  { return nil }
+func (s *stateServiceDBStub) GetLatestAuditLog() (*models.AuditLog, error) { return nil, nil }
+func (s *stateServiceDBStub) GetLatestAuditLogBefore(before time.Time) (*models.AuditLog, error) {
+	return nil, nil
+}
+func (s *stateServiceDBStub) ListAuditLogs(afterID uint64, limit int) ([]*models.AuditLog, error) {
+	return []*models.AuditLog{}, nil
+}
+func (s *stateServiceDBStub) ListRecentAuditLogs(limit int) ([]*models.AuditLog, error) {
+	return []*models.AuditLog{}, nil
+}
+func (s *stateServiceDBStub) QueryAuditLogs(query models.AuditLogQuery) ([]*models.AuditLog, error) {
+	return nil, nil
+}
+func (s *stateServiceDBStub) DeleteAuditLogsUpTo(id uint64) (int64, error)     { return 0, nil }
+func (s *stateServiceDBStub) GetAuditAnchor() (*models.AuditAnchor, error)     { return nil, nil }
+func (s *stateServiceDBStub) SaveAuditAnchor(anchor *models.AuditAnchor) error { return nil }
 func (s *stateServiceDBStub) HasAdminUser() (bool, error) {
 	for _, user := range s.users {
 		if user.Role == "admin" {
@@ -97,5 +344,14 @@ func (s *stateServiceDBStub) HasAdminUser() (bool, error) {
 	}
 	return false, nil
 }
+func (s *stateServiceDBStub) CountUsersByRole(role string) (int64, error) {
+	var count int64
+	for _, user := range s.users {
+		if user.Role == role {
+			count++
+		}
+	}
+	return count, nil
+}
 func (s *stateServiceDBStub) Ping() error  { return nil }
 func (s *stateServiceDBStub) Close() error { return nil }
@@ -0,0 +1,86 @@
+package services
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/GT-610/tairitsu/internal/app/services"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeSelfSignedCertificate writes a certificate and key for host into dir
+func writeSelfSignedCertificate(t *testing.T, dir, host string) (string, string) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: host},
+		DNSNames:     []string{host},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(24 * time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	certFile, keyFile := filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+	require.NoError(t, os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600))
+	require.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600))
+	return certFile, keyFile
+}
+
+func TestTLSCertificateServiceReloadsRenewedFiles(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := writeSelfSignedCertificate(t, dir, "old.example.com")
+	service := services.NewTLSCertificateService()
+	require.NoError(t, service.LoadFiles(certFile, keyFile))
+
+	served, err := service.GetCertificate(nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"old.example.com"}, served.Leaf.DNSNames)
+
+	writeSelfSignedCertificate(t, dir, "new.example.com")
+	info, err := service.Reload()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"new.example.com"}, info.DNSNames)
+	served, err = service.GetCertificate(nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"new.example.com"}, served.Leaf.DNSNames)
+}
+
+func TestTLSCertificateServiceKeepsCertificateWhenReloadFails(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := writeSelfSignedCertificate(t, dir, "current.example.com")
+	service := services.NewTLSCertificateService()
+	require.NoError(t, service.LoadFiles(certFile, keyFile))
+
+	require.NoError(t, os.WriteFile(keyFile, []byte("not a key"), 0o600))
+	_, err := service.Reload()
+	require.Error(t, err)
+	served, err := service.GetCertificate(nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"current.example.com"}, served.Leaf.DNSNames)
+}
+
+func TestTLSCertificateServiceWithoutFiles(t *testing.T) {
+	service := services.NewTLSCertificateService()
+	_, err := service.Reload()
+	assert.ErrorIs(t, err, services.ErrTLSCertificateNotConfigured)
+
+	err = service.LoadFiles(filepath.Join(t.TempDir(), "missing.pem"), filepath.Join(t.TempDir(), "missing-key.pem"))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "missing.pem")
+}
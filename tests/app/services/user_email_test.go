@@ -0,0 +1,78 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/GT-610/tairitsu/internal/app/database"
+	"github.com/GT-610/tairitsu/internal/app/models"
+	"github.com/GT-610/tairitsu/internal/app/services"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// userEmailBackends runs email checks against SQLite and the in-memory stub alike
+var userEmailBackends = map[string]func(t *testing.T) database.DBInterface{
+	"sqlite": func(t *testing.T) database.DBInterface { return newTestSQLiteDB(t) },
+	"memory": func(t *testing.T) database.DBInterface { return &stateServiceDBStub{} },
+}
+
+func TestRegisterRejectsDuplicateEmail(t *testing.T) {
+	for name, newDB := range userEmailBackends {
+		t.Run(name, func(t *testing.T) {
+			userService := services.NewUserService(newDB(t))
+
+			alice, err := userService.Register(&models.RegisterRequest{Username: "alice", Password: "Password123!", Email: " Alice@Example.com "})
+			require.NoError(t, err)
+			assert.Equal(t, "alice@example.com", alice.EmailAddress())
+
+			_, err = userService.Register(&models.RegisterRequest{Username: "bob", Password: "Password123!", Email: "ALICE@example.com"})
+			assert.ErrorIs(t, err, services.ErrEmailExists)
+
+			_, err = userService.Register(&models.RegisterRequest{Username: "bob", Password: "Password123!", Email: "not-an-address"})
+			assert.ErrorIs(t, err, services.ErrInvalidEmail)
+
+			// Addresses are optional, and several accounts may go without one
+			for _, username := range []string{"carol", "dave"} {
+				user, err := userService.Register(&models.RegisterRequest{Username: username, Password: "Password123!"})
+				require.NoError(t, err)
+				assert.Nil(t, user.Email)
+			}
+		})
+	}
+}
+
+func TestUpdateProfileChangesEmail(t *testing.T) {
+	for name, newDB := range userEmailBackends {
+		t.Run(name, func(t *testing.T) {
+			db := newDB(t)
+			userService := services.NewUserService(db)
+			alice, err := userService.Register(&models.RegisterRequest{Username: "alice", Password: "Password123!", Email: "alice@example.com"})
+			require.NoError(t, err)
+			bob, err := userService.Register(&models.RegisterRequest{Username: "bob", Password: "Password123!"})
+			require.NoError(t, err)
+
+			_, err = userService.UpdateProfile(bob.ID, &models.UpdateProfileRequest{Email: "Alice@example.com"})
+			assert.ErrorIs(t, err, services.ErrEmailExists)
+
+			updated, err := userService.UpdateProfile(bob.ID, &models.UpdateProfileRequest{Email: "Bob@Example.com"})
+			require.NoError(t, err)
+			assert.Equal(t, "bob@example.com", updated.EmailAddress())
+			stored, err := db.GetUserByEmail("bob@example.com")
+			require.NoError(t, err)
+			require.NotNil(t, stored)
+			assert.Equal(t, bob.ID, stored.ID)
+
+			// Keeping one's own address is not a conflict; clearing it frees the address
+			_, err = userService.UpdateProfile(alice.ID, &models.UpdateProfileRequest{Email: "alice@example.com"})
+			require.NoError(t, err)
+			updated, err = userService.UpdateProfile(alice.ID, &models.UpdateProfileRequest{Email: ""})
+			require.NoError(t, err)
+			assert.Nil(t, updated.Email)
+			_, err = userService.UpdateProfile(bob.ID, &models.UpdateProfileRequest{Email: "alice@example.com"})
+			require.NoError(t, err)
+
+			_, err = userService.UpdateProfile("missing", &models.UpdateProfileRequest{Email: "x@example.com"})
+			assert.ErrorIs(t, err, services.ErrUserNotFound)
+		})
+	}
+}
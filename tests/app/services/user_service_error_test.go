@@ -36,11 +36,20 @@ func (d *txFailingDB) GetUserByID(id string) (*models.User, error) {
 func (d *txFailingDB) GetUserByUsername(username string) (*models.User, error) {
 	return d.inner.GetUserByUsername(username)
 }
+func (d *txFailingDB) GetUserByEmail(email string) (*models.User, error) {
+	return d.inner.GetUserByEmail(email)
+}
+func (d *txFailingDB) GetUserByExternalSubject(subject string) (*models.User, error) {
+	return d.inner.GetUserByExternalSubject(subject)
+}
 func (d *txFailingDB) GetAllUsers() ([]*models.User, error) { return d.inner.GetAllUsers() }
 func (d *txFailingDB) GetUsersByIDs(ids []string) ([]*models.User, error) {
 	return d.inner.GetUsersByIDs(ids)
 }
-func (d *txFailingDB) UpdateUser(user *models.User) error   { return d.inner.UpdateUser(user) }
+func (d *txFailingDB) UpdateUser(user *models.User) error { return d.inner.UpdateUser(user) }
+func (d *txFailingDB) ReplaceUserPasswordHash(userID, previousHash, hash string) (bool, error) {
+	return d.inner.ReplaceUserPasswordHash(userID, previousHash, hash)
+}
 func (d *txFailingDB) DeleteUser(id string) error {
 	if d.failDeleteUser {
 		return fmt.Errorf("forced delete failure")
@@ -95,12 +104,317 @@ func (d *txFailingDB) DeleteNetworkViewer(networkID, userID string) error {
 func (d *txFailingDB) DeleteAllNetworkViewers(networkID string) error {
 	return d.inner.DeleteAllNetworkViewers(networkID)
 }
+func (d *txFailingDB) GetMemberMetadata(networkID, memberID string) (*models.MemberMetadata, error) {
+	return d.inner.GetMemberMetadata(networkID, memberID)
+}
+func (d *txFailingDB) ListMemberMetadata(networkID string) ([]*models.MemberMetadata, error) {
+	return d.inner.ListMemberMetadata(networkID)
+}
+func (d *txFailingDB) ListMemberMetadataExpiringBy(deadline time.Time) ([]*models.MemberMetadata, error) {
+	return d.inner.ListMemberMetadataExpiringBy(deadline)
+}
+func (d *txFailingDB) SaveMemberMetadata(metadata *models.MemberMetadata) error {
+	return d.inner.SaveMemberMetadata(metadata)
+}
+func (d *txFailingDB) DeleteMemberMetadata(networkID, memberID string) error {
+	return d.inner.DeleteMemberMetadata(networkID, memberID)
+}
+func (d *txFailingDB) DeleteAllMemberMetadata(networkID string) error {
+	return d.inner.DeleteAllMemberMetadata(networkID)
+}
+func (d *txFailingDB) CreatePendingApproval(approval *models.PendingApproval) (bool, error) {
+	return d.inner.CreatePendingApproval(approval)
+}
+func (d *txFailingDB) GetPendingApproval(id uint64) (*models.PendingApproval, error) {
+	return d.inner.GetPendingApproval(id)
+}
+func (d *txFailingDB) ListPendingApprovals(networkIDs []string) ([]*models.PendingApproval, error) {
+	return d.inner.ListPendingApprovals(networkIDs)
+}
+func (d *txFailingDB) SavePendingApproval(approval *models.PendingApproval) error {
+	return d.inner.SavePendingApproval(approval)
+}
+func (d *txFailingDB) DeletePendingApproval(networkID, memberID string) error {
+	return d.inner.DeletePendingApproval(networkID, memberID)
+}
+func (d *txFailingDB) DeleteAllPendingApprovals(networkID string) error {
+	return d.inner.DeleteAllPendingApprovals(networkID)
+}
+func (d *txFailingDB) CreateDeviceClaim(claim *models.DeviceClaim) error {
+	return d.inner.CreateDeviceClaim(claim)
+}
+func (d *txFailingDB) GetDeviceClaim(id uint64) (*models.DeviceClaim, error) {
+	return d.inner.GetDeviceClaim(id)
+}
+func (d *txFailingDB) ListDeviceClaimsByUser(userID string) ([]*models.DeviceClaim, error) {
+	return d.inner.ListDeviceClaimsByUser(userID)
+}
+func (d *txFailingDB) ListDeviceClaimsByNode(nodeAddress string) ([]*models.DeviceClaim, error) {
+	return d.inner.ListDeviceClaimsByNode(nodeAddress)
+}
+func (d *txFailingDB) ListUnverifiedDeviceClaims() ([]*models.DeviceClaim, error) {
+	return d.inner.ListUnverifiedDeviceClaims()
+}
+func (d *txFailingDB) SaveDeviceClaim(claim *models.DeviceClaim) error {
+	return d.inner.SaveDeviceClaim(claim)
+}
+func (d *txFailingDB) DeleteDeviceClaim(id uint64) error {
+	return d.inner.DeleteDeviceClaim(id)
+}
+func (d *txFailingDB) DeleteDeviceClaimsByUser(userID string) error {
+	return d.inner.DeleteDeviceClaimsByUser(userID)
+}
+func (d *txFailingDB) GetNetworksByOrganizationIDs(organizationIDs []string) ([]*models.Network, error) {
+	return d.inner.GetNetworksByOrganizationIDs(organizationIDs)
+}
+func (d *txFailingDB) MoveNetworksToOrganization(fromID, toID string) error {
+	return d.inner.MoveNetworksToOrganization(fromID, toID)
+}
+func (d *txFailingDB) CreateOrganization(organization *models.Organization) error {
+	return d.inner.CreateOrganization(organization)
+}
+func (d *txFailingDB) GetOrganization(id string) (*models.Organization, error) {
+	return d.inner.GetOrganization(id)
+}
+func (d *txFailingDB) GetOrganizationByName(name string) (*models.Organization, error) {
+	return d.inner.GetOrganizationByName(name)
+}
+func (d *txFailingDB) ListOrganizations() ([]*models.Organization, error) {
+	return d.inner.ListOrganizations()
+}
+func (d *txFailingDB) UpdateOrganization(organization *models.Organization) error {
+	return d.inner.UpdateOrganization(organization)
+}
+func (d *txFailingDB) DeleteOrganization(id string) error {
+	return d.inner.DeleteOrganization(id)
+}
+func (d *txFailingDB) UpsertOrganizationMember(member *models.OrganizationMember) error {
+	return d.inner.UpsertOrganizationMember(member)
+}
+func (d *txFailingDB) GetOrganizationMember(organizationID, userID string) (*models.OrganizationMember, error) {
+	return d.inner.GetOrganizationMember(organizationID, userID)
+}
+func (d *txFailingDB) ListOrganizationMembers(organizationID string) ([]*models.OrganizationMember, error) {
+	return d.inner.ListOrganizationMembers(organizationID)
+}
+func (d *txFailingDB) ListOrganizationMembershipsByUser(userID string) ([]*models.OrganizationMember, error) {
+	return d.inner.ListOrganizationMembershipsByUser(userID)
+}
+func (d *txFailingDB) DeleteOrganizationMember(organizationID, userID string) error {
+	return d.inner.DeleteOrganizationMember(organizationID, userID)
+}
+func (d *txFailingDB) DeleteAllOrganizationMembers(organizationID string) error {
+	return d.inner.DeleteAllOrganizationMembers(organizationID)
+}
+func (d *txFailingDB) DeleteOrganizationMembershipsByUser(userID string) error {
+	return d.inner.DeleteOrganizationMembershipsByUser(userID)
+}
+func (d *txFailingDB) GetActiveNetworkLockdown(networkID string) (*models.NetworkLockdown, error) {
+	return d.inner.GetActiveNetworkLockdown(networkID)
+}
+func (d *txFailingDB) CreateNetworkLockdown(lockdown *models.NetworkLockdown) error {
+	return d.inner.CreateNetworkLockdown(lockdown)
+}
+func (d *txFailingDB) SaveNetworkLockdown(lockdown *models.NetworkLockdown) error {
+	return d.inner.SaveNetworkLockdown(lockdown)
+}
+func (d *txFailingDB) DeleteAllNetworkLockdowns(networkID string) error {
+	return d.inner.DeleteAllNetworkLockdowns(networkID)
+}
+func (d *txFailingDB) CreateOperation(operation *models.Operation, items []*models.OperationItem) error {
+	return d.inner.CreateOperation(operation, items)
+}
+func (d *txFailingDB) GetOperation(id string) (*models.Operation, error) {
+	return d.inner.GetOperation(id)
+}
+func (d *txFailingDB) GetOperationByIdempotencyKey(userID, key string) (*models.Operation, error) {
+	return d.inner.GetOperationByIdempotencyKey(userID, key)
+}
+func (d *txFailingDB) SaveOperation(operation *models.Operation) error {
+	return d.inner.SaveOperation(operation)
+}
+
+func (d *txFailingDB) ListOperations(userID string, limit int) ([]*models.Operation, error) {
+	return d.inner.ListOperations(userID, limit)
+}
+
+func (d *txFailingDB) ListUnfinishedOperations() ([]*models.Operation, error) {
+	return d.inner.ListUnfinishedOperations()
+}
+func (d *txFailingDB) ListOperationItems(operationID string) ([]*models.OperationItem, error) {
+	return d.inner.ListOperationItems(operationID)
+}
+func (d *txFailingDB) SaveOperationItem(item *models.OperationItem) error {
+	return d.inner.SaveOperationItem(item)
+}
+func (d *txFailingDB) GetNetworkRuleSource(networkID string) (*models.NetworkRuleSource, error) {
+	return d.inner.GetNetworkRuleSource(networkID)
+}
+func (d *txFailingDB) SaveNetworkRuleSource(source *models.NetworkRuleSource) error {
+	return d.inner.SaveNetworkRuleSource(source)
+}
+func (d *txFailingDB) DeleteNetworkRuleSource(networkID string) error {
+	return d.inner.DeleteNetworkRuleSource(networkID)
+}
+func (d *txFailingDB) ListNetworkTags(networkID string) ([]*models.NetworkTag, error) {
+	return d.inner.ListNetworkTags(networkID)
+}
+func (d *txFailingDB) CreateNetworkTag(tag *models.NetworkTag) error {
+	return d.inner.CreateNetworkTag(tag)
+}
+func (d *txFailingDB) UpdateNetworkTag(tag *models.NetworkTag) error {
+	return d.inner.UpdateNetworkTag(tag)
+}
+func (d *txFailingDB) DeleteNetworkTag(networkID string, tagID uint32) error {
+	return d.inner.DeleteNetworkTag(networkID, tagID)
+}
+func (d *txFailingDB) DeleteAllNetworkTags(networkID string) error {
+	return d.inner.DeleteAllNetworkTags(networkID)
+}
+func (d *txFailingDB) ListNetworkCapabilities(networkID string) ([]*models.NetworkCapability, error) {
+	return d.inner.ListNetworkCapabilities(networkID)
+}
+func (d *txFailingDB) CreateNetworkCapability(capability *models.NetworkCapability) error {
+	return d.inner.CreateNetworkCapability(capability)
+}
+func (d *txFailingDB) DeleteNetworkCapability(networkID string, capabilityID uint32) error {
+	return d.inner.DeleteNetworkCapability(networkID, capabilityID)
+}
+func (d *txFailingDB) DeleteAllNetworkCapabilities(networkID string) error {
+	return d.inner.DeleteAllNetworkCapabilities(networkID)
+}
+func (d *txFailingDB) CreateWebhook(webhook *models.Webhook) error {
+	return d.inner.CreateWebhook(webhook)
+}
+func (d *txFailingDB) GetWebhookByID(id string) (*models.Webhook, error) {
+	return d.inner.GetWebhookByID(id)
+}
+func (d *txFailingDB) ListWebhooks() ([]*models.Webhook, error) {
+	return d.inner.ListWebhooks()
+}
+func (d *txFailingDB) UpdateWebhook(webhook *models.Webhook) error {
+	return d.inner.UpdateWebhook(webhook)
+}
+func (d *txFailingDB) DeleteWebhook(id string) error {
+	return d.inner.DeleteWebhook(id)
+}
+func (d *txFailingDB) CreateWebhookDelivery(delivery *models.WebhookDelivery) error {
+	return d.inner.CreateWebhookDelivery(delivery)
+}
+func (d *txFailingDB) UpdateWebhookDelivery(delivery *models.WebhookDelivery) error {
+	return d.inner.UpdateWebhookDelivery(delivery)
+}
+func (d *txFailingDB) ListWebhookDeliveries(webhookID string, limit int) ([]*models.WebhookDelivery, error) {
+	return d.inner.ListWebhookDeliveries(webhookID, limit)
+}
+func (d *txFailingDB) DeleteWebhookDeliveriesBeyond(webhookID string, keep int) (int64, error) {
+	return d.inner.DeleteWebhookDeliveriesBeyond(webhookID, keep)
+}
 func (d *txFailingDB) DeleteExpiredSessions(before time.Time) error {
 	return d.inner.DeleteExpiredSessions(before)
 }
-func (d *txFailingDB) HasAdminUser() (bool, error)   { return d.inner.HasAdminUser() }
-func (d *txFailingDB) Ping() error                  { return d.inner.Ping() }
-func (d *txFailingDB) Close() error                  { return d.inner.Close() }
+func (d *txFailingDB) RevokeAllSessions(at time.Time) (int64, error) {
+	return d.inner.RevokeAllSessions(at)
+}
+func (d *txFailingDB) CreateMemberStatusEvents(events []*models.MemberStatusEvent) error {
+	return d.inner.CreateMemberStatusEvents(events)
+}
+func (d *txFailingDB) ListLatestMemberStatusEvents(networkID string) ([]*models.MemberStatusEvent, error) {
+	return d.inner.ListLatestMemberStatusEvents(networkID)
+}
+func (d *txFailingDB) ListLatestOnlineMemberStatusEvents(networkID string) ([]*models.MemberStatusEvent, error) {
+	return d.inner.ListLatestOnlineMemberStatusEvents(networkID)
+}
+func (d *txFailingDB) ListMemberStatusEvents(networkID, memberID string, from, to time.Time) ([]*models.MemberStatusEvent, error) {
+	return d.inner.ListMemberStatusEvents(networkID, memberID, from, to)
+}
+func (d *txFailingDB) GetMemberStatusEventBefore(networkID, memberID string, before time.Time) (*models.MemberStatusEvent, error) {
+	return d.inner.GetMemberStatusEventBefore(networkID, memberID, before)
+}
+func (d *txFailingDB) ListMemberStatusEventsAfter(afterID uint64, limit int) ([]*models.MemberStatusEvent, error) {
+	return d.inner.ListMemberStatusEventsAfter(afterID, limit)
+}
+func (d *txFailingDB) CreateControllerTraceEvents(events []*models.ControllerTraceEvent) error {
+	return d.inner.CreateControllerTraceEvents(events)
+}
+func (d *txFailingDB) QueryControllerTraceEvents(query models.ControllerTraceQuery) ([]*models.ControllerTraceEvent, error) {
+	return d.inner.QueryControllerTraceEvents(query)
+}
+func (d *txFailingDB) DeleteControllerTraceEventsBefore(before time.Time) (int64, error) {
+	return d.inner.DeleteControllerTraceEventsBefore(before)
+}
+func (d *txFailingDB) CreatePlanetGeneration(generation *models.PlanetGeneration) error {
+	return d.inner.CreatePlanetGeneration(generation)
+}
+func (d *txFailingDB) ListPlanetGenerations(limit int) ([]*models.PlanetGeneration, error) {
+	return d.inner.ListPlanetGenerations(limit)
+}
+func (d *txFailingDB) GetPlanetGenerationByID(id uint64) (*models.PlanetGeneration, error) {
+	return d.inner.GetPlanetGenerationByID(id)
+}
+func (d *txFailingDB) DeletePlanetGenerationsBeyond(keep int) (int64, error) {
+	return d.inner.DeletePlanetGenerationsBeyond(keep)
+}
+func (d *txFailingDB) CreateApiToken(token *models.ApiToken) error {
+	return d.inner.CreateApiToken(token)
+}
+func (d *txFailingDB) GetApiTokenByID(id string) (*models.ApiToken, error) {
+	return d.inner.GetApiTokenByID(id)
+}
+func (d *txFailingDB) GetApiTokenByHash(hash string) (*models.ApiToken, error) {
+	return d.inner.GetApiTokenByHash(hash)
+}
+func (d *txFailingDB) GetApiTokensByUserID(userID string) ([]*models.ApiToken, error) {
+	return d.inner.GetApiTokensByUserID(userID)
+}
+func (d *txFailingDB) UpdateApiToken(token *models.ApiToken) error {
+	return d.inner.UpdateApiToken(token)
+}
+func (d *txFailingDB) CreatePasswordResetToken(token *models.PasswordResetToken) error {
+	return d.inner.CreatePasswordResetToken(token)
+}
+func (d *txFailingDB) GetPasswordResetTokenByHash(hash string) (*models.PasswordResetToken, error) {
+	return d.inner.GetPasswordResetTokenByHash(hash)
+}
+func (d *txFailingDB) ConsumePasswordResetToken(hash string, at time.Time) (int64, error) {
+	return d.inner.ConsumePasswordResetToken(hash, at)
+}
+func (d *txFailingDB) InvalidatePasswordResetTokens(userID string, at time.Time) (int64, error) {
+	return d.inner.InvalidatePasswordResetTokens(userID, at)
+}
+func (d *txFailingDB) CreateAuditLog(entry *models.AuditLog) error {
+	return d.inner.CreateAuditLog(entry)
+}
+func (d *txFailingDB) GetLatestAuditLog() (*models.AuditLog, error) {
+	return d.inner.GetLatestAuditLog()
+}
+func (d *txFailingDB) GetLatestAuditLogBefore(before time.Time) (*models.AuditLog, error) {
+	return d.inner.GetLatestAuditLogBefore(before)
+}
+func (d *txFailingDB) ListAuditLogs(afterID uint64, limit int) ([]*models.AuditLog, error) {
+	return d.inner.ListAuditLogs(afterID, limit)
+}
+func (d *txFailingDB) ListRecentAuditLogs(limit int) ([]*models.AuditLog, error) {
+	return d.inner.ListRecentAuditLogs(limit)
+}
+func (d *txFailingDB) QueryAuditLogs(query models.AuditLogQuery) ([]*models.AuditLog, error) {
+	return d.inner.QueryAuditLogs(query)
+}
+func (d *txFailingDB) DeleteAuditLogsUpTo(id uint64) (int64, error) {
+	return d.inner.DeleteAuditLogsUpTo(id)
+}
+func (d *txFailingDB) GetAuditAnchor() (*models.AuditAnchor, error) {
+	return d.inner.GetAuditAnchor()
+}
+func (d *txFailingDB) SaveAuditAnchor(anchor *models.AuditAnchor) error {
+	return d.inner.SaveAuditAnchor(anchor)
+}
+func (d *txFailingDB) HasAdminUser() (bool, error) { return d.inner.HasAdminUser() }
+func (d *txFailingDB) CountUsersByRole(role string) (int64, error) {
+	return d.inner.CountUsersByRole(role)
+}
+func (d *txFailingDB) Ping() error  { return d.inner.Ping() }
+func (d *txFailingDB) Close() error { return d.inner.Close() }
 
 func TestUserServiceRegisterReturnsSentinelForDuplicateUsername(t *testing.T) {
 	db := newTestSQLiteDB(t)
@@ -380,15 +694,41 @@ func TestUserServiceCreateUserByAdminCreatesUserWithTemporaryPassword(t *testing
 	admin, err := service.Register(&models.RegisterRequest{Username: "admin", Password: "secret123"}, "admin")
 	require.NoError(t, err)
 
-	user, temporaryPassword, err := service.CreateUserByAdmin(admin.ID, "bob")
+	user, temporaryPassword, err := service.CreateUserByAdmin(admin.ID, &models.CreateUserRequest{Username: "bob"})
 	require.NoError(t, err)
 	assert.Equal(t, "bob", user.Username)
 	assert.Equal(t, "user", user.Role)
 	assert.Len(t, temporaryPassword, 16)
+	assert.True(t, user.MustChangePassword)
 
 	loggedIn, err := service.Login(&models.LoginRequest{Username: "bob", Password: temporaryPassword})
 	require.NoError(t, err)
 	assert.Equal(t, user.ID, loggedIn.ID)
+
+	require.NoError(t, service.ChangePassword(user.ID, temporaryPassword, "Chosen1234"))
+	reloaded, err := service.GetUserByID(user.ID)
+	require.NoError(t, err)
+	assert.False(t, reloaded.MustChangePassword)
+}
+
+func TestUserServiceCreateUserByAdminUsesSuppliedPassword(t *testing.T) {
+	db := newTestSQLiteDB(t)
+	service := appservices.NewUserService(db)
+
+	admin, err := service.Register(&models.RegisterRequest{Username: "admin", Password: "secret123"}, "admin")
+	require.NoError(t, err)
+
+	user, temporaryPassword, err := service.CreateUserByAdmin(admin.ID, &models.CreateUserRequest{Username: "carol", Password: "Welcome123", Email: "Carol@Example.com"})
+	require.NoError(t, err)
+	assert.Equal(t, "Welcome123", temporaryPassword)
+	assert.Equal(t, "carol@example.com", user.EmailAddress())
+	assert.True(t, user.MustChangePassword)
+
+	_, err = service.Login(&models.LoginRequest{Username: "carol", Password: "Welcome123"})
+	require.NoError(t, err)
+
+	_, _, err = service.CreateUserByAdmin(admin.ID, &models.CreateUserRequest{Username: "dan", Password: "x"})
+	require.ErrorIs(t, err, appservices.ErrPasswordTooShort)
 }
 
 func TestUserServiceCreateUserByAdminRejectsWhitespaceUsername(t *testing.T) {
@@ -398,7 +738,7 @@ func TestUserServiceCreateUserByAdminRejectsWhitespaceUsername(t *testing.T) {
 	admin, err := service.Register(&models.RegisterRequest{Username: "admin", Password: "secret123"}, "admin")
 	require.NoError(t, err)
 
-	_, _, err = service.CreateUserByAdmin(admin.ID, "   ")
+	_, _, err = service.CreateUserByAdmin(admin.ID, &models.CreateUserRequest{Username: "   "})
 	require.ErrorIs(t, err, appservices.ErrInvalidUsername)
 }
 
@@ -537,7 +877,7 @@ func TestUserServiceDeleteUserByAdminRejectsDeletingSelf(t *testing.T) {
 	require.ErrorIs(t, err, appservices.ErrAdminDeleteSelf)
 }
 
-func TestUserServiceDeleteUserByAdminRejectsDeletingAdmin(t *testing.T) {
+func TestUserServiceDeleteUserByAdminDeletesAdminWhileAnotherRemains(t *testing.T) {
 	db := newTestSQLiteDB(t)
 	service := appservices.NewUserService(db)
 
@@ -546,6 +886,49 @@ func TestUserServiceDeleteUserByAdminRejectsDeletingAdmin(t *testing.T) {
 	otherAdmin, err := service.Register(&models.RegisterRequest{Username: "other-admin", Password: "secret123"}, "admin")
 	require.NoError(t, err)
 
+	deleted, _, _, err := service.DeleteUserByAdmin(admin.ID, otherAdmin.ID)
+	require.NoError(t, err)
+	assert.Equal(t, otherAdmin.ID, deleted.ID)
+
+	admins, err := db.CountUsersByRole("admin")
+	require.NoError(t, err)
+	assert.EqualValues(t, 1, admins)
+}
+
+// demotingDB demotes a user when a transaction starts, standing in for an administrator
+// transfer that commits between the permission check and the deletion
+type demotingDB struct {
+	database.DBInterface
+	demoteUserID string
+}
+
+func (d *demotingDB) WithTransaction(fn func(database.DBInterface) error) error {
+	user, err := d.DBInterface.GetUserByID(d.demoteUserID)
+	if err != nil {
+		return err
+	}
+	user.Role = "user"
+	if err := d.DBInterface.UpdateUser(user); err != nil {
+		return err
+	}
+	return d.DBInterface.WithTransaction(fn)
+}
+
+func TestUserServiceDeleteUserByAdminRejectsDeletingLastAdmin(t *testing.T) {
+	db := newTestSQLiteDB(t)
+	setup := appservices.NewUserService(db)
+
+	admin, err := setup.Register(&models.RegisterRequest{Username: "admin", Password: "secret123"}, "admin")
+	require.NoError(t, err)
+	otherAdmin, err := setup.Register(&models.RegisterRequest{Username: "other-admin", Password: "secret123"}, "admin")
+	require.NoError(t, err)
+
+	service := appservices.NewUserService(&demotingDB{DBInterface: db, demoteUserID: admin.ID})
 	_, _, _, err = service.DeleteUserByAdmin(admin.ID, otherAdmin.ID)
 	require.ErrorIs(t, err, appservices.ErrAdminDeleteBlocked)
+
+	remaining, err := db.GetUserByID(otherAdmin.ID)
+	require.NoError(t, err)
+	require.NotNil(t, remaining)
+	assert.Equal(t, "admin", remaining.Role)
 }
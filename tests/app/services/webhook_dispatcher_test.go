@@ -0,0 +1,393 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/GT-610/tairitsu/internal/app/models"
+	"github.com/GT-610/tairitsu/internal/app/services"
+	"github.com/GT-610/tairitsu/internal/zerotier"
+	"github.com/GT-610/tairitsu/internal/zerotier/ztmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type webhookRequest struct {
+	event     string
+	signature string
+	body      []byte
+}
+
+// webhookReceiver records requests and answers them with the queued status codes, then 200
+type webhookReceiver struct {
+	mutex    sync.Mutex
+	requests []webhookRequest
+	statuses []int
+}
+
+func (r *webhookReceiver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	body, _ := io.ReadAll(req.Body)
+	r.mutex.Lock()
+	r.requests = append(r.requests, webhookRequest{event: req.Header.Get(services.WebhookEventHeader), signature: req.Header.Get(services.WebhookSignatureHeader), body: body})
+	status := http.StatusOK
+	if len(r.statuses) > 0 {
+		status, r.statuses = r.statuses[0], r.statuses[1:]
+	}
+	r.mutex.Unlock()
+	w.WriteHeader(status)
+}
+
+func (r *webhookReceiver) received() []webhookRequest {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return append([]webhookRequest(nil), r.requests...)
+}
+
+func newStartedWebhookDispatcher(t *testing.T) (*services.WebhookDispatcher, *webhookReceiver, string) {
+	t.Helper()
+
+	receiver := &webhookReceiver{}
+	server := httptest.NewServer(receiver)
+	t.Cleanup(server.Close)
+
+	dispatcher := services.NewWebhookDispatcher(newTestSQLiteDB(t))
+	dispatcher.SetRetryDelay(10 * time.Millisecond)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := dispatcher.Start(ctx)
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+	return dispatcher, receiver, server.URL
+}
+
+func waitForDeliveries(t *testing.T, dispatcher *services.WebhookDispatcher, webhookID string, count int) []*models.WebhookDelivery {
+	t.Helper()
+
+	var deliveries []*models.WebhookDelivery
+	require.Eventually(t, func() bool {
+		var err error
+		deliveries, err = dispatcher.ListDeliveries(webhookID, 0)
+		if err != nil || len(deliveries) < count {
+			return false
+		}
+		for _, delivery := range deliveries {
+			if delivery.CompletedAt == nil {
+				return false
+			}
+		}
+		return true
+	}, 5*time.Second, 10*time.Millisecond)
+	return deliveries
+}
+
+func TestWebhookDispatcherDeliversSignedEventsToSubscribers(t *testing.T) {
+	dispatcher, receiver, url := newStartedWebhookDispatcher(t)
+
+	all, secret, err := dispatcher.CreateWebhook(services.WebhookInput{Name: "all", URL: url, Secret: "shared-secret"}, "admin-1")
+	require.NoError(t, err)
+	assert.Equal(t, "shared-secret", secret)
+	usersOnly, generated, err := dispatcher.CreateWebhook(services.WebhookInput{URL: url, Events: []string{services.WebhookEventUserCreated}}, "admin-1")
+	require.NoError(t, err)
+	assert.NotEmpty(t, generated)
+	disabled := false
+	_, _, err = dispatcher.CreateWebhook(services.WebhookInput{URL: url, Enabled: &disabled}, "admin-1")
+	require.NoError(t, err)
+
+	dispatcher.Dispatch(services.WebhookEventNetworkCreated, services.WebhookNetworkCreatedData{NetworkID: "8056c2e21c000001"})
+
+	deliveries := waitForDeliveries(t, dispatcher, all.ID, 1)
+	assert.True(t, deliveries[0].Success)
+	assert.Equal(t, 1, deliveries[0].Attempts)
+	assert.Equal(t, http.StatusOK, deliveries[0].StatusCode)
+	skipped, err := dispatcher.ListDeliveries(usersOnly.ID, 0)
+	require.NoError(t, err)
+	assert.Empty(t, skipped)
+
+	requests := receiver.received()
+	require.Len(t, requests, 1)
+	assert.Equal(t, services.WebhookEventNetworkCreated, requests[0].event)
+	assert.Equal(t, services.SignWebhookPayload("shared-secret", requests[0].body), requests[0].signature)
+	var payload struct {
+		Data services.WebhookNetworkCreatedData `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(requests[0].body, &payload))
+	assert.Equal(t, "8056c2e21c000001", payload.Data.NetworkID)
+}
+
+func TestWebhookDispatcherRetriesServerErrorsOnly(t *testing.T) {
+	dispatcher, receiver, url := newStartedWebhookDispatcher(t)
+	webhook, _, err := dispatcher.CreateWebhook(services.WebhookInput{URL: url}, "admin-1")
+	require.NoError(t, err)
+
+	receiver.statuses = []int{http.StatusBadGateway, http.StatusServiceUnavailable}
+	dispatcher.Dispatch(services.WebhookEventUserCreated, services.WebhookUserCreatedData{UserID: "user-1"})
+	deliveries := waitForDeliveries(t, dispatcher, webhook.ID, 1)
+	assert.True(t, deliveries[0].Success)
+	assert.Equal(t, 3, deliveries[0].Attempts)
+
+	receiver.statuses = []int{http.StatusGone}
+	dispatcher.Dispatch(services.WebhookEventUserCreated, services.WebhookUserCreatedData{UserID: "user-2"})
+	deliveries = waitForDeliveries(t, dispatcher, webhook.ID, 2)
+	assert.False(t, deliveries[0].Success)
+	assert.Equal(t, 1, deliveries[0].Attempts)
+	assert.Equal(t, http.StatusGone, deliveries[0].StatusCode)
+	assert.Contains(t, deliveries[0].Error, "410")
+}
+
+func TestWebhookPingAnswersRightAway(t *testing.T) {
+	dispatcher, receiver, url := newStartedWebhookDispatcher(t)
+	webhook, _, err := dispatcher.CreateWebhook(services.WebhookInput{URL: url, Events: []string{services.WebhookEventNetworkDeleted}}, "admin-1")
+	require.NoError(t, err)
+
+	receiver.statuses = []int{http.StatusInternalServerError}
+	delivery, err := dispatcher.Ping(context.Background(), webhook.ID)
+	require.NoError(t, err)
+	assert.False(t, delivery.Success)
+	assert.Equal(t, 1, delivery.Attempts)
+	assert.Equal(t, http.StatusInternalServerError, delivery.StatusCode)
+
+	delivery, err = dispatcher.Ping(context.Background(), webhook.ID)
+	require.NoError(t, err)
+	assert.True(t, delivery.Success)
+	assert.Equal(t, services.WebhookEventPing, receiver.received()[1].event)
+
+	_, err = dispatcher.Ping(context.Background(), "missing")
+	assert.ErrorIs(t, err, services.ErrWebhookNotFound)
+}
+
+func TestWebhookInputValidation(t *testing.T) {
+	dispatcher := services.NewWebhookDispatcher(newTestSQLiteDB(t))
+
+	_, _, err := dispatcher.CreateWebhook(services.WebhookInput{URL: "ftp://example.com/hook"}, "admin-1")
+	assert.ErrorIs(t, err, services.ErrWebhookInvalidURL)
+	_, _, err = dispatcher.CreateWebhook(services.WebhookInput{URL: "/relative"}, "admin-1")
+	assert.ErrorIs(t, err, services.ErrWebhookInvalidURL)
+	_, _, err = dispatcher.CreateWebhook(services.WebhookInput{URL: "https://example.com/hook", Events: []string{"network.renamed"}}, "admin-1")
+	assert.ErrorIs(t, err, services.ErrWebhookInvalidEvent)
+
+	webhook, _, err := dispatcher.CreateWebhook(services.WebhookInput{URL: "https://example.com/hook", Secret: "first"}, "admin-1")
+	require.NoError(t, err)
+	updated, err := dispatcher.UpdateWebhook(webhook.ID, services.WebhookInput{URL: "https://example.com/other", Events: []string{services.WebhookEventUserCreated, services.WebhookEventUserCreated}})
+	require.NoError(t, err)
+	assert.Equal(t, "first", updated.Secret)
+	assert.Equal(t, []string{services.WebhookEventUserCreated}, updated.EventList())
+	assert.True(t, updated.Enabled)
+
+	require.NoError(t, dispatcher.DeleteWebhook(webhook.ID))
+	assert.ErrorIs(t, dispatcher.DeleteWebhook(webhook.ID), services.ErrWebhookNotFound)
+}
+
+func TestUserRegistrationDispatchesUserCreated(t *testing.T) {
+	dispatcher, receiver, url := newStartedWebhookDispatcher(t)
+	webhook, _, err := dispatcher.CreateWebhook(services.WebhookInput{URL: url}, "admin-1")
+	require.NoError(t, err)
+
+	userService := services.NewUserService(newTestSQLiteDB(t))
+	userService.SetWebhookDispatcher(dispatcher)
+	user, err := userService.Register(&models.RegisterRequest{Username: "alice", Password: "Password123!"})
+	require.NoError(t, err)
+
+	waitForDeliveries(t, dispatcher, webhook.ID, 1)
+	var payload struct {
+		Event string                          `json:"event"`
+		Data  services.WebhookUserCreatedData `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(receiver.received()[0].body, &payload))
+	assert.Equal(t, services.WebhookEventUserCreated, payload.Event)
+	assert.Equal(t, user.ID, payload.Data.UserID)
+	assert.Equal(t, "alice", payload.Data.Username)
+}
+
+func TestWebhookTestEventsGoThroughTheDeliveryPipeline(t *testing.T) {
+	dispatcher, receiver, url := newStartedWebhookDispatcher(t)
+	disabled := false
+	webhook, _, err := dispatcher.CreateWebhook(services.WebhookInput{URL: url, Secret: "shared-secret", Events: []string{services.WebhookEventUserCreated}, Enabled: &disabled}, "admin-1")
+	require.NoError(t, err)
+
+	receiver.statuses = []int{http.StatusBadGateway}
+	logged, err := dispatcher.SendTestEvent(webhook.ID, services.WebhookEventMemberAuthorized)
+	require.NoError(t, err)
+	assert.Equal(t, services.WebhookEventMemberAuthorized, logged.Event)
+	assert.Zero(t, logged.Attempts, "the delivery is returned before its first attempt")
+
+	deliveries := waitForDeliveries(t, dispatcher, webhook.ID, 1)
+	assert.Equal(t, logged.ID, deliveries[0].ID)
+	assert.True(t, deliveries[0].Success)
+	assert.Equal(t, 2, deliveries[0].Attempts, "server errors are retried")
+
+	requests := receiver.received()
+	require.Len(t, requests, 2)
+	assert.Equal(t, services.WebhookEventMemberAuthorized, requests[1].event)
+	assert.Equal(t, services.SignWebhookPayload("shared-secret", requests[1].body), requests[1].signature)
+	var payload struct {
+		Test bool                       `json:"test"`
+		Data services.WebhookMemberData `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(requests[1].body, &payload))
+	assert.True(t, payload.Test)
+	assert.NotEmpty(t, payload.Data.NetworkID)
+	assert.NotEmpty(t, payload.Data.MemberID)
+
+	_, err = dispatcher.SendTestEvent(webhook.ID, "network.renamed")
+	assert.ErrorIs(t, err, services.ErrWebhookInvalidEvent)
+	_, err = dispatcher.SendTestEvent(webhook.ID, services.WebhookEventPing)
+	assert.ErrorIs(t, err, services.ErrWebhookInvalidEvent, "pings have their own endpoint")
+	_, err = dispatcher.SendTestEvent("missing", services.WebhookEventUserCreated)
+	assert.ErrorIs(t, err, services.ErrWebhookNotFound)
+}
+
+// schemaViolations checks value against the subset of JSON Schema WebhookSchemas uses
+func schemaViolations(schema map[string]any, value any, path string) []string {
+	if constant, ok := schema["const"]; ok && constant != value {
+		return []string{fmt.Sprintf("%s must be %v, got %#v", path, constant, value)}
+	}
+	var ok bool
+	switch schema["type"] {
+	case "string":
+		_, ok = value.(string)
+	case "boolean":
+		_, ok = value.(bool)
+	case "integer", "number":
+		_, ok = value.(float64)
+	case "object":
+		var object map[string]any
+		if object, ok = value.(map[string]any); ok {
+			return objectViolations(schema, object, path)
+		}
+	default:
+		ok = true
+	}
+	if !ok {
+		return []string{fmt.Sprintf("%s must be of type %v, got %#v", path, schema["type"], value)}
+	}
+	return nil
+}
+
+func objectViolations(schema map[string]any, object map[string]any, path string) []string {
+	var violations []string
+	properties, _ := schema["properties"].(map[string]any)
+	for _, name := range schema["required"].([]string) {
+		if _, ok := object[name]; !ok {
+			violations = append(violations, fmt.Sprintf("%s lacks the required %s", path, name))
+		}
+	}
+	for name, field := range object {
+		property, ok := properties[name]
+		if !ok {
+			if schema["additionalProperties"] == false {
+				violations = append(violations, fmt.Sprintf("%s has the undeclared %s", path, name))
+			}
+			continue
+		}
+		violations = append(violations, schemaViolations(property.(map[string]any), field, path+"."+name)...)
+	}
+	return violations
+}
+
+func decodeDeliveryBody(t *testing.T, body []byte) map[string]any {
+	t.Helper()
+
+	var payload map[string]any
+	require.NoError(t, json.Unmarshal(body, &payload))
+	return payload
+}
+
+func TestWebhookTestPayloadsMatchThePublishedSchemas(t *testing.T) {
+	dispatcher, receiver, url := newStartedWebhookDispatcher(t)
+	webhook, _, err := dispatcher.CreateWebhook(services.WebhookInput{URL: url}, "admin-1")
+	require.NoError(t, err)
+
+	schemas := services.WebhookSchemas()
+	assert.Contains(t, schemas, services.WebhookEventPing)
+	for _, event := range services.WebhookEvents {
+		require.Contains(t, schemas, event)
+		_, err := dispatcher.SendTestEvent(webhook.ID, event)
+		require.NoError(t, err)
+	}
+	waitForDeliveries(t, dispatcher, webhook.ID, len(services.WebhookEvents))
+
+	requests := receiver.received()
+	require.Len(t, requests, len(services.WebhookEvents))
+	for _, request := range requests {
+		payload := decodeDeliveryBody(t, request.body)
+		assert.Equal(t, true, payload["test"])
+		assert.Empty(t, schemaViolations(schemas[request.event], payload, request.event))
+	}
+
+	// A payload of another event's shape is caught, so the check above is not vacuous
+	index := slices.IndexFunc(requests, func(request webhookRequest) bool {
+		return request.event == services.WebhookEventMemberAuthorized
+	})
+	require.NotEqual(t, -1, index)
+	memberPayload := decodeDeliveryBody(t, requests[index].body)
+	assert.NotEmpty(t, schemaViolations(schemas[services.WebhookEventUserCreated], memberPayload, "payload"))
+}
+
+func TestWebhookTestAndRealPayloadsShareBuilders(t *testing.T) {
+	dispatcher, receiver, url := newStartedWebhookDispatcher(t)
+	webhook, _, err := dispatcher.CreateWebhook(services.WebhookInput{URL: url}, "admin-1")
+	require.NoError(t, err)
+
+	controller := ztmock.NewController(ztmock.DemoAddress)
+	baseURL, err := controller.Start()
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = controller.Close()
+	})
+	db := newTestSQLiteDB(t)
+	userService := services.NewUserService(db)
+	userService.SetWebhookDispatcher(dispatcher)
+	user, err := userService.Register(&models.RegisterRequest{Username: "alice", Password: "Password123!"})
+	require.NoError(t, err)
+	networkService := services.NewNetworkService(&zerotier.Client{BaseURL: baseURL, Token: controller.Token, HTTPClient: &http.Client{Timeout: 5 * time.Second}}, db)
+	networkService.SetWebhookDispatcher(dispatcher)
+	network, err := networkService.CreateNetwork("", &zerotier.Network{Name: "lab"}, user.ID)
+	require.NoError(t, err)
+	require.NoError(t, networkService.DeleteNetwork(network.ID, user.ID))
+	waitForDeliveries(t, dispatcher, webhook.ID, 3)
+
+	for _, event := range []string{services.WebhookEventUserCreated, services.WebhookEventNetworkCreated, services.WebhookEventNetworkDeleted} {
+		_, err := dispatcher.SendTestEvent(webhook.ID, event)
+		require.NoError(t, err)
+	}
+	waitForDeliveries(t, dispatcher, webhook.ID, 6)
+
+	schemas := services.WebhookSchemas()
+	realData := map[string]map[string]any{}
+	testData := map[string]map[string]any{}
+	for _, request := range receiver.received() {
+		payload := decodeDeliveryBody(t, request.body)
+		assert.Empty(t, schemaViolations(schemas[request.event], payload, request.event))
+		if payload["test"] == true {
+			testData[request.event] = payload["data"].(map[string]any)
+		} else {
+			realData[request.event] = payload["data"].(map[string]any)
+		}
+	}
+	require.Len(t, realData, 3)
+	for event, data := range realData {
+		require.Contains(t, testData, event)
+		assert.Equal(t, keysOf(data), keysOf(testData[event]), event)
+	}
+	assert.Equal(t, user.ID, realData[services.WebhookEventUserCreated]["userId"])
+	assert.Equal(t, network.ID, realData[services.WebhookEventNetworkDeleted]["networkId"])
+}
+
+func keysOf(object map[string]any) []string {
+	keys := make([]string, 0, len(object))
+	for key := range object {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,76 @@
+package build
+
+import (
+	"bufio"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const repoRoot = "../.."
+
+func modulePath(t *testing.T) string {
+	t.Helper()
+
+	file, err := os.Open(filepath.Join(repoRoot, "go.mod"))
+	require.NoError(t, err)
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if rest, ok := strings.CutPrefix(strings.TrimSpace(scanner.Text()), "module "); ok {
+			return strings.TrimSpace(rest)
+		}
+	}
+	require.NoError(t, scanner.Err())
+	t.Fatal("go.mod has no module directive")
+	return ""
+}
+
+// Imports of this repository's packages must use the go.mod module path. The Docker build only
+// compiles ./cmd/tairitsu, so a stale path in a package it does not reach would go unnoticed.
+func TestRepositoryImportsUseTheModulePath(t *testing.T) {
+	module := modulePath(t)
+	repoName := module[strings.LastIndex(module, "/")+1:]
+
+	fset := token.NewFileSet()
+	err := filepath.WalkDir(repoRoot, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() {
+			switch entry.Name() {
+			case "node_modules", "web", "build", ".git":
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !strings.HasSuffix(path, ".go") {
+			return nil
+		}
+
+		file, err := parser.ParseFile(fset, path, nil, parser.ImportsOnly)
+		if err != nil {
+			return err
+		}
+		for _, spec := range file.Imports {
+			importPath, err := strconv.Unquote(spec.Path.Value)
+			if err != nil {
+				return err
+			}
+			if strings.Contains(importPath, "/"+repoName+"/") && !strings.HasPrefix(importPath, module+"/") {
+				assert.Failf(t, "foreign module path", "%s imports %q; use %s", fset.Position(spec.Pos()), importPath, module)
+			}
+		}
+		return nil
+	})
+	require.NoError(t, err)
+}
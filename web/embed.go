@@ -0,0 +1,19 @@
+//go:build embedfrontend
+
+// Package web bundles the frontend build into the binary. Build the frontend first
+// (bun run build in web/) and then the backend with -tags embedfrontend.
+package web
+
+import (
+	"embed"
+	"io/fs"
+)
+
+//go:embed all:dist
+var dist embed.FS
+
+// Dist returns the bundled frontend build
+func Dist() fs.FS {
+	files, _ := fs.Sub(dist, "dist")
+	return files
+}
@@ -0,0 +1,12 @@
+//go:build !embedfrontend
+
+// Package web bundles the frontend build into the binary. Build the frontend first
+// (bun run build in web/) and then the backend with -tags embedfrontend.
+package web
+
+import "io/fs"
+
+// Dist returns nil: this binary was built without the embedfrontend tag
+func Dist() fs.FS {
+	return nil
+}
@@ -35,6 +35,7 @@ function MulticastSettingsSection({
             size="small"
             value={draftValue.multicastLimit}
             onChange={(e) => onChange({ ...draftValue, multicastLimit: parseInt(e.target.value, 10) || 0 })}
+            slotProps={{ htmlInput: { min: 0, max: 4096 } }}
             sx={{ width: 120 }}
           />
         </Box>
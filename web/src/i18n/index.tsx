@@ -106,9 +106,21 @@ const messageCodes: Record<string, { en: string; 'zh-CN': string }> = {
   'auth.logout_success': { en: 'Current session signed out', 'zh-CN': '已退出当前会话' },
   'auth.session_removed': { en: 'Session removed', 'zh-CN': '会话已移除' },
   'auth.other_sessions_removed': { en: 'Other sessions removed', 'zh-CN': '其他会话已移除' },
+  'auth.jwt_secret_rotated': { en: 'JWT secret rotated', 'zh-CN': 'JWT 密钥已轮换' },
+  'auth.jwt_secret_rotation_failed': { en: 'Failed to rotate the JWT secret', 'zh-CN': '轮换 JWT 密钥失败' },
+  'auth.password_change_required': { en: 'Change your temporary password before continuing', 'zh-CN': '请先修改临时密码' },
   'auth.password_updated': { en: 'Password updated successfully', 'zh-CN': '密码修改成功' },
   'auth.password_confirmation_mismatch': { en: 'The new password and confirmation do not match', 'zh-CN': '新密码与确认密码不匹配' },
   'auth.token_generation_failed': { en: 'Failed to generate token', 'zh-CN': '生成令牌失败' },
+  'auth.password_login_disabled': { en: 'Password sign-in is disabled; use single sign-on', 'zh-CN': '密码登录已禁用，请使用单点登录' },
+  'auth.captcha_required': { en: 'Complete the captcha challenge to continue', 'zh-CN': '请完成人机验证后继续' },
+  'auth.captcha_invalid': { en: 'The captcha challenge was not solved or has expired; try again', 'zh-CN': '人机验证未通过或已过期，请重试' },
+  'auth.captcha_unavailable': { en: 'The captcha provider could not be reached; try again later', 'zh-CN': '无法连接人机验证服务，请稍后重试' },
+  'auth.oidc_disabled': { en: 'Single sign-on is not enabled', 'zh-CN': '未启用单点登录' },
+  'auth.oidc_invalid_state': { en: 'The single sign-on request expired; sign in again', 'zh-CN': '单点登录请求已过期，请重新登录' },
+  'auth.oidc_provider_error': { en: 'The single sign-on provider refused the sign-in or could not be reached', 'zh-CN': '单点登录提供方拒绝了登录或无法访问' },
+  'auth.oidc_invalid_token': { en: 'The single sign-on provider returned an invalid identity token', 'zh-CN': '单点登录提供方返回的身份令牌无效' },
+  'auth.oidc_email_not_verified': { en: 'The provider has not verified this email address, so it cannot be linked to an existing account', 'zh-CN': '提供方未验证该邮箱地址，无法关联到已有账户' },
   'user.db_unavailable': { en: 'Database is not configured. Complete initial setup first.', 'zh-CN': '系统尚未配置数据库，请先完成初始设置' },
   'user.invalid_username': { en: 'Username is required', 'zh-CN': '用户名不能为空' },
   'user.username_exists': { en: 'Username already exists', 'zh-CN': '用户名已存在' },
@@ -116,6 +128,9 @@ const messageCodes: Record<string, { en: string; 'zh-CN': string }> = {
   'user.not_found': { en: 'User not found', 'zh-CN': '用户不存在' },
   'user.old_password_incorrect': { en: 'Current password is incorrect', 'zh-CN': '原密码错误' },
   'user.admin_access_denied': { en: 'The current user is not an administrator.', 'zh-CN': '当前用户不是管理员，无法执行该操作' },
+  'user.reset_token_invalid': { en: 'The password reset token is invalid or has already been used', 'zh-CN': '密码重置令牌无效或已被使用' },
+  'user.reset_token_expired': { en: 'The password reset token has expired. Request a new one.', 'zh-CN': '密码重置令牌已过期，请重新申请' },
+  'user.session_revoked': { en: 'Session signed out', 'zh-CN': '会话已登出' },
   'user.invalid_admin_operation': { en: 'This administrator operation is not allowed', 'zh-CN': '该管理员操作不允许' },
   'user.public_registration_disabled': { en: 'Public registration is disabled. Contact an administrator to create an account.', 'zh-CN': '公开注册已关闭，请联系管理员创建账户' },
   'user.required': { en: 'User is required', 'zh-CN': '必须指定用户' },
@@ -123,7 +138,12 @@ const messageCodes: Record<string, { en: string; 'zh-CN': string }> = {
   'session.access_denied': { en: 'You do not have access to this session', 'zh-CN': '无权访问该会话' },
   'session.revoked': { en: 'Session is no longer valid. Please sign in again.', 'zh-CN': '会话已失效，请重新登录' },
   'session.expired': { en: 'Session expired. Please sign in again.', 'zh-CN': '会话已过期，请重新登录' },
+  'controller.not_found': { en: 'ZeroTier controller not found', 'zh-CN': 'ZeroTier 控制器不存在' },
+  'controller.invalid_peer_role': { en: 'Peer role must be PLANET, MOON or LEAF', 'zh-CN': '节点角色必须是 PLANET、MOON 或 LEAF' },
+  'controller.peers_unsupported': { en: 'This controller does not expose its peer list', 'zh-CN': '该控制器未提供节点列表' },
+  'controller.unavailable': { en: 'The ZeroTier controller is not connected', 'zh-CN': 'ZeroTier 控制器未连接' },
   'network.not_found': { en: 'Network not found', 'zh-CN': '网络不存在' },
+  'planet.not_found': { en: 'Generated planet not found', 'zh-CN': '生成的 Planet 不存在' },
   'network.access_denied': { en: 'Network access denied', 'zh-CN': '无权限访问网络' },
   'network.member_access_denied': { en: 'Network member access denied', 'zh-CN': '无权限访问网络成员' },
   'network.viewer_access_denied': { en: 'Network viewer access denied', 'zh-CN': '无权限管理网络查看授权' },
@@ -137,10 +157,46 @@ const messageCodes: Record<string, { en: string; 'zh-CN': string }> = {
   'network.viewer_removed': { en: 'Read-only viewer access removed', 'zh-CN': '已移除只读查看权限' },
   'ipv6.mustBeInSubnet': { en: 'Must be within {{subnet}}', 'zh-CN': '必须落在 {{subnet}} 内' },
   'member.not_found': { en: 'Member not found', 'zh-CN': '成员不存在' },
+  'member.metadata_invalid': { en: 'Invalid member details', 'zh-CN': '成员备注信息无效' },
+  'network.invalid_policy': { en: 'Invalid network policy', 'zh-CN': '网络策略无效' },
+  'network.rules_invalid': { en: 'The flow rules could not be compiled', 'zh-CN': '流规则无法编译' },
+  'network.feature_unsupported': { en: 'The ZeroTier controller is too old for this setting', 'zh-CN': 'ZeroTier 控制器版本过低，不支持此设置' },
+  'tag.not_found': { en: 'Tag not found', 'zh-CN': '标签不存在' },
+  'tag.conflict': { en: 'A tag or capability with this ID or name already exists', 'zh-CN': '已存在相同 ID 或名称的标签或能力' },
+  'tag.invalid': { en: 'Invalid tag or capability definition', 'zh-CN': '标签或能力定义无效' },
+  'tag.deleted': { en: 'Tag deleted', 'zh-CN': '标签已删除' },
+  'capability.not_found': { en: 'Capability not found', 'zh-CN': '能力不存在' },
+  'capability.deleted': { en: 'Capability deleted', 'zh-CN': '能力已删除' },
+  'member.tag_undefined': { en: 'The tag or capability is not defined on this network', 'zh-CN': '该网络未定义此标签或能力' },
+  'member.tag_value_invalid': { en: 'Invalid tag value', 'zh-CN': '标签值无效' },
+  'lockdown.active': { en: 'This network is already locked down', 'zh-CN': '该网络已处于锁定状态' },
+  'lockdown.not_found': { en: 'This network is not locked down', 'zh-CN': '该网络未处于锁定状态' },
+  'approval.not_found': { en: 'Pending approval not found', 'zh-CN': '待审批记录不存在' },
+  'approval.already_decided': { en: 'This member was already approved or denied', 'zh-CN': '该成员已被批准或拒绝' },
+  'device.claim_not_found': { en: 'You have not claimed this device', 'zh-CN': '你尚未认领该设备' },
+  'device.claim_not_verified': { en: 'This device claim is not verified yet', 'zh-CN': '该设备认领尚未验证' },
+  'device.claimed_by_other': { en: 'This device is already claimed by another user', 'zh-CN': '该设备已被其他用户认领' },
+  'device.already_authorized': { en: 'This device is already authorized on the network', 'zh-CN': '该设备已在此网络中获得授权' },
+  'operation.not_found': { en: 'Operation not found', 'zh-CN': '操作不存在' },
+  'operation.idempotency_key_reused': { en: 'This idempotency key was already used for a different request', 'zh-CN': '该幂等键已用于其他请求' },
+  'operation.not_running': { en: 'The operation has already finished', 'zh-CN': '该操作已结束' },
+  'organization.not_found': { en: 'Organization not found', 'zh-CN': '组织不存在' },
+  'organization.access_denied': { en: 'You do not have access to this organization', 'zh-CN': '你无权访问该组织' },
+  'organization.member_not_found': { en: 'This user is not a member of the organization', 'zh-CN': '该用户不是此组织的成员' },
+  'organization.name_taken': { en: 'An organization with this name already exists', 'zh-CN': '同名组织已存在' },
+  'organization.invalid': { en: 'Invalid organization name or description', 'zh-CN': '组织名称或描述无效' },
+  'organization.invalid_role': { en: 'The role must be organization admin or organization member', 'zh-CN': '角色必须是组织管理员或组织成员' },
+  'organization.default_protected': { en: 'The default organization cannot be deleted', 'zh-CN': '默认组织无法删除' },
+  'webhook.invalid_request': { en: 'Invalid webhook settings', 'zh-CN': 'Webhook 设置无效' },
+  'webhook.not_found': { en: 'Webhook not found', 'zh-CN': 'Webhook 不存在' },
+  'webhook.deleted': { en: 'Webhook deleted', 'zh-CN': 'Webhook 已删除' },
   'member.delete_success': { en: 'Member deleted successfully', 'zh-CN': '成员删除成功' },
   'system.already_initialized': { en: 'The system is already initialized. This endpoint is only available during first-time setup.', 'zh-CN': '系统已初始化，当前接口仅在首次设置期间可用' },
   'system.setup_required': { en: 'System setup is required. Complete the setup wizard first.', 'zh-CN': '系统尚未初始化，请先完成设置向导' },
+  'system.database_unavailable': { en: 'The database is temporarily unavailable. Try again shortly.', 'zh-CN': '数据库暂时不可用，请稍后再试' },
   'system.user_service_unavailable': { en: 'User service is unavailable', 'zh-CN': '用户服务不可用' },
+  'request.invalid_body': { en: 'The request body is invalid', 'zh-CN': '请求体无效' },
+  'request.validation_failed': { en: 'Some fields are invalid', 'zh-CN': '部分字段无效' },
   'system.rate_limited': { en: 'Too many requests. Please try again later.', 'zh-CN': '请求频率过高，请稍后再试' },
   'system.internal_error': { en: 'Internal server error', 'zh-CN': '服务器内部错误' },
   'system.settings_updated': { en: 'Instance settings updated successfully', 'zh-CN': '实例设置更新成功' },
@@ -149,7 +205,13 @@ const messageCodes: Record<string, { en: string; 'zh-CN': string }> = {
   'system.admin_creation_initialized': { en: 'Administrator account creation step initialized successfully', 'zh-CN': '管理员账户创建步骤初始化成功' },
   'system.initialized_updated': { en: 'Initialization state updated successfully', 'zh-CN': '初始化状态更新成功' },
   'system.stats_unavailable': { en: 'Unable to retrieve system resource statistics', 'zh-CN': '无法获取系统资源统计信息' },
+  'system.stats_history_invalid': { en: 'Invalid statistics window or point count', 'zh-CN': '统计时间窗口或数据点数量无效' },
+  'system.encryption_key_rotated': { en: 'Encryption key rotated', 'zh-CN': '加密密钥已轮换' },
+  'system.encryption_key_external': { en: 'The encryption key is provided externally and cannot be rotated here', 'zh-CN': '加密密钥由外部提供，无法在此轮换' },
+  'system.encryption_key_rotation_failed': { en: 'Failed to rotate the encryption key', 'zh-CN': '轮换加密密钥失败' },
   'setup.unsupported_database': { en: 'Only SQLite is currently supported', 'zh-CN': '当前仅支持 SQLite' },
+  'setup.step_out_of_order': { en: 'Complete the previous setup steps first', 'zh-CN': '请先完成前面的设置步骤' },
+  'setup.reset_confirmation_required': { en: 'Confirm the database reset to continue', 'zh-CN': '请确认重置数据库后继续' },
   'setup.invalid_config': { en: 'Setup configuration is incomplete', 'zh-CN': '设置配置不完整' },
   'setup.database_connection_failed': { en: 'Database connection failed', 'zh-CN': '数据库连接失败' },
   'setup.database_initialization_failed': { en: 'Database initialization failed', 'zh-CN': '数据库初始化失败' },
@@ -555,7 +617,7 @@ const rawEn: Record<string, string> = {
   '已保存': 'Saved',
   '未知步骤': 'Unknown step',
   '当前步骤状态：': 'Current step status: ',
-  '本向导会依次完成 ZeroTier 控制器连接、SQLite 配置、首个管理员创建，以及运行态切换。': 'This wizard will walk you through connecting a ZeroTier controller, configuring SQLite, creating the first administrator, and switching to runtime mode.',
+  '本向导会依次完成 SQLite 配置、ZeroTier 控制器连接、首个管理员创建，以及运行态切换。': 'This wizard will walk you through configuring SQLite, connecting a ZeroTier controller, creating the first administrator, and switching to runtime mode.',
   '这一步会测试连接并保存配置。刷新页面后，已保存的控制器地址和 token 路径会自动回显。': 'This step will test the connection and save the configuration. After refreshing the page, the saved controller URL and token path will be restored automatically.',
   'ZeroTier 控制器 URL': 'ZeroTier Controller URL',
   '例如 /var/lib/zerotier-one/authtoken.secret': 'e.g. /var/lib/zerotier-one/authtoken.secret',
@@ -576,12 +638,17 @@ const rawEn: Record<string, string> = {
   '初始化状态尚未生效，请稍后重试': 'Initialization state has not taken effect yet. Please try again later.',
   '系统初始化完成，正在进入登录页面...': 'Setup complete. Redirecting to the login page...',
   'ZeroTier 控制器连接成功并已保存：': 'ZeroTier controller connected and saved: ',
+  '无法连接到 ZeroTier 控制器，请检查控制器地址': 'Could not reach the ZeroTier controller. Check the controller URL.',
+  'ZeroTier 控制器拒绝了认证令牌': 'The ZeroTier controller rejected the auth token.',
+  '认证令牌无权管理网络，请使用控制器的 authtoken.secret': 'The auth token may not manage networks. Use the authtoken.secret of the controller.',
   'SQLite 配置已保存：': 'SQLite configuration saved: ',
   '首个管理员': 'First administrator',
   '创建成功': 'created successfully',
   '数据库类型': 'Database type',
   'SQLite 文件路径': 'SQLite file path',
   '认证令牌文件路径': 'Auth token file path',
+  '或直接填写认证令牌': 'Or enter the auth token directly',
+  '控制器运行在无法共享文件的容器中时使用；令牌会加密保存，填写后将忽略文件路径': 'Use this when the controller runs in a container whose files cannot be shared. The token is stored encrypted and the file path is ignored.',
   '身份加载': 'Identity Loading',
   'Planet 配置': 'Planet Configuration',
   '高级模式': 'Advanced Mode',
@@ -624,6 +691,7 @@ const rawEn: Record<string, string> = {
   '当前没有已接管网络。': 'No claimed networks yet.',
   '这些网络不会出现在当前导入批次中，通常是因为控制器详情读取失败或状态异常。请先排查控制器连通性，再刷新列表重试。': 'These networks are excluded from the current import batch, usually because controller details could not be read or the state is abnormal. Check controller connectivity, then refresh and try again.',
   '当前没有需要人工处理的网络。': 'No networks need manual attention.',
+  '部分控制器网络无法读取，已列为需处理：': 'Some controller networks could not be read and are listed as needing attention:',
   '已被 Tairitsu 接管': 'Managed by Tairitsu',
   '同一批次中重复提交了该网络，已跳过重复项': 'This network was submitted more than once in the same batch and the duplicate was skipped',
   '网络不存在于 ZeroTier 控制器中': 'Network does not exist in the ZeroTier controller',
@@ -725,6 +793,11 @@ function resolveThemeMode(preference: ThemePreference): PaletteMode {
   return preference === 'system' ? detectSystemThemeMode() : preference
 }
 
+// The Accept-Language sent with API requests, so server messages match the display language
+export function apiAcceptLanguage(): string {
+  return resolveLanguage(getStoredLanguagePreference()) === 'zh-CN' ? 'zh-CN' : 'en-US'
+}
+
 function getStoredLanguagePreference(): LanguagePreference {
   try {
     return normalizeLanguagePreference(localStorage.getItem(LANGUAGE_STORAGE_KEY))
@@ -779,6 +852,7 @@ export function translateRawText(value: string, language: Language): string {
     [/网络 ID/g, 'Network ID', /Network ID/g, '网络 ID'],
     [/已授权 /g, 'Authorized ', /Authorized /g, '已授权 '],
     [/待授权 /g, 'Pending ', /Pending /g, '待授权 '],
+    [/在线 /g, 'Online ', /Online /g, '在线 '],
     [/ 台/g, ' devices', / devices/g, ' 台'],
     [/最近活跃：/g, 'Last active: ', /Last active: /g, '最近活跃：'],
     [/登录时间：/g, 'Signed in: ', /Signed in: /g, '登录时间：'],
@@ -79,9 +79,9 @@ function Dashboard() {
   const buildOverviewStats = (networks: NetworkSummary[]): OverviewStats => {
     return networks.reduce<OverviewStats>((summary, network) => ({
       networkCount: summary.networkCount + 1,
-      memberCount: summary.memberCount + (network.member_count || 0),
-      authorizedMemberCount: summary.authorizedMemberCount + (network.authorized_member_count || 0),
-      pendingMemberCount: summary.pendingMemberCount + (network.pending_member_count || 0),
+      memberCount: summary.memberCount + (network.memberCount || 0),
+      authorizedMemberCount: summary.authorizedMemberCount + (network.authorizedMemberCount || 0),
+      pendingMemberCount: summary.pendingMemberCount + (network.pendingMemberCount || 0),
     }), {
       networkCount: 0,
       memberCount: 0,
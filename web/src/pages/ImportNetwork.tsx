@@ -63,10 +63,10 @@ function statusLabel(status: ImportableNetworkCandidate['status']) {
 
 function candidateSecondary(candidate: ImportableNetworkCandidate, translateText: (value: string) => string) {
   const parts = [
-    candidate.reason_message ? translateText(candidate.reason_message) : '',
-    candidate.owner_username ? `${translateText('所有者')}: ${candidate.owner_username}` : '',
-    typeof candidate.member_count === 'number' ? `${translateText('成员')} ${candidate.member_count}` : '',
-    candidate.controller_status ? `${translateText('状态')} ${candidate.controller_status}` : '',
+    candidate.reasonMessage ? translateText(candidate.reasonMessage) : '',
+    candidate.ownerUsername ? `${translateText('所有者')}: ${candidate.ownerUsername}` : '',
+    typeof candidate.memberCount === 'number' ? `${translateText('成员')} ${candidate.memberCount}` : '',
+    candidate.controllerStatus ? `${translateText('状态')} ${candidate.controllerStatus}` : '',
   ].filter(Boolean)
 
   return parts.join(' · ')
@@ -109,8 +109,8 @@ function ImportNetwork() {
 
       const availableIds = new Set(
         (nextResponse.candidates || [])
-          .filter((candidate) => candidate.can_import)
-          .map((candidate) => candidate.network_id),
+          .filter((candidate) => candidate.canImport)
+          .map((candidate) => candidate.networkId),
       )
       setSelectedNetworks((previous) => new Set([...previous].filter((id) => availableIds.has(id))))
     } catch (err: unknown) {
@@ -148,7 +148,7 @@ function ImportNetwork() {
       return
     }
 
-    setSelectedNetworks(new Set(availableCandidates.map((candidate) => candidate.network_id)))
+    setSelectedNetworks(new Set(availableCandidates.map((candidate) => candidate.networkId)))
   }
 
   const handleImport = async () => {
@@ -182,7 +182,7 @@ function ImportNetwork() {
     }
   }
 
-  const firstImportedNetworkId = importResult?.imported[0]?.network_id || ''
+  const firstImportedNetworkId = importResult?.imported[0]?.networkId || ''
   const importFeedback = importResult ? buildImportResultAlertPresentation(importResult) : null
 
   return (
@@ -228,6 +228,15 @@ function ImportNetwork() {
         </Alert>
       )}
 
+      {response?.warnings && response.warnings.length > 0 && (
+        <Alert severity="warning" sx={{ mb: 3 }}>
+          {translateText('部分控制器网络无法读取，已列为需处理：')}
+          {response.warnings.map((warning) => (
+            <Typography key={warning} variant="body2">{warning}</Typography>
+          ))}
+        </Alert>
+      )}
+
       <Alert severity="info" sx={{ mb: 3 }}>
         {translateText('该页面用于接管控制器中已存在但尚未纳入 Tairitsu 管理的网络，并将其分配给指定 owner。已接管网络会保留在控制器中，仅补齐 Tairitsu 侧登记和归属关系。')}
       </Alert>
@@ -297,10 +306,10 @@ function ImportNetwork() {
               <Typography variant="subtitle2" sx={{ mb: 1 }}>{translateText('已成功接管')}</Typography>
               <List dense>
                 {importResult.imported.map((item) => (
-                  <ListItem key={`imported-${item.network_id}`} disablePadding sx={{ py: 0.5 }}>
+                  <ListItem key={`imported-${item.networkId}`} disablePadding sx={{ py: 0.5 }}>
                     <ListItemText
-                      primary={item.name || item.network_id}
-                      secondary={`${item.network_id} · ${translateText('已分配给 ')}${item.owner_username || translateText('目标 owner')}`}
+                      primary={item.name || item.networkId}
+                      secondary={`${item.networkId} · ${translateText('已分配给 ')}${item.ownerUsername || translateText('目标 owner')}`}
                     />
                   </ListItem>
                 ))}
@@ -313,10 +322,10 @@ function ImportNetwork() {
               <Typography variant="subtitle2" sx={{ mb: 1 }}>{translateText('已跳过')}</Typography>
               <List dense>
                 {importResult.skipped.map((item) => (
-                  <ListItem key={`skipped-${item.network_id}-${item.reason_code}`} disablePadding sx={{ py: 0.5 }}>
+                  <ListItem key={`skipped-${item.networkId}-${item.reasonCode}`} disablePadding sx={{ py: 0.5 }}>
                     <ListItemText
-                      primary={item.name || item.network_id}
-                      secondary={`${item.network_id} · ${translateText(item.reason_message || '已跳过')}`}
+                      primary={item.name || item.networkId}
+                      secondary={`${item.networkId} · ${translateText(item.reasonMessage || '已跳过')}`}
                     />
                   </ListItem>
                 ))}
@@ -329,10 +338,10 @@ function ImportNetwork() {
               <Typography variant="subtitle2" sx={{ mb: 1 }}>{translateText('失败项')}</Typography>
               <List dense>
                 {importResult.failed.map((item) => (
-                  <ListItem key={`failed-${item.network_id}-${item.reason_code}`} disablePadding sx={{ py: 0.5 }}>
+                  <ListItem key={`failed-${item.networkId}-${item.reasonCode}`} disablePadding sx={{ py: 0.5 }}>
                     <ListItemText
-                      primary={item.name || item.network_id}
-                      secondary={`${item.network_id} · ${translateText(item.reason_message || '导入失败')}`}
+                      primary={item.name || item.networkId}
+                      secondary={`${item.networkId} · ${translateText(item.reasonMessage || '导入失败')}`}
                     />
                   </ListItem>
                 ))}
@@ -390,28 +399,28 @@ function ImportNetwork() {
               </TableHead>
               <TableBody>
                 {availableCandidates.map((candidate) => (
-                  <TableRow key={candidate.network_id} hover>
+                  <TableRow key={candidate.networkId} hover>
                     <TableCell padding="checkbox">
                       <Checkbox
-                        checked={selectedNetworks.has(candidate.network_id)}
-                        onChange={() => handleToggle(candidate.network_id)}
+                        checked={selectedNetworks.has(candidate.networkId)}
+                        onChange={() => handleToggle(candidate.networkId)}
                       />
                     </TableCell>
                     <TableCell>
                       <Typography variant="body2" sx={{ fontWeight: 600 }}>
-                        {candidate.name || candidate.network_id}
+                        {candidate.name || candidate.networkId}
                       </Typography>
                       <Typography variant="caption" color="text.secondary">
-                        {candidate.network_id}
+                        {candidate.networkId}
                       </Typography>
                     </TableCell>
                     <TableCell>
                       <Chip label={translateText(statusLabel(candidate.status))} size="small" color={statusChipColor(candidate.status)} />
                     </TableCell>
-                    <TableCell>{typeof candidate.member_count === 'number' ? candidate.member_count : '-'}</TableCell>
-                    <TableCell>{candidate.controller_status || '-'}</TableCell>
+                    <TableCell>{typeof candidate.memberCount === 'number' ? candidate.memberCount : '-'}</TableCell>
+                    <TableCell>{candidate.controllerStatus || '-'}</TableCell>
                     <TableCell>
-                      <Typography variant="body2">{translateText(candidate.reason_message)}</Typography>
+                      <Typography variant="body2">{translateText(candidate.reasonMessage)}</Typography>
                     </TableCell>
                   </TableRow>
                 ))}
@@ -430,9 +439,9 @@ function ImportNetwork() {
         ) : (
           <List dense>
             {managedCandidates.map((candidate) => (
-              <ListItem key={candidate.network_id} disablePadding sx={{ py: 0.75 }}>
+              <ListItem key={candidate.networkId} disablePadding sx={{ py: 0.75 }}>
                 <ListItemText
-                  primary={candidate.name || candidate.network_id}
+                  primary={candidate.name || candidate.networkId}
                   secondary={candidateSecondary(candidate, translateText)}
                 />
               </ListItem>
@@ -453,9 +462,9 @@ function ImportNetwork() {
         ) : (
           <List dense>
             {blockedCandidates.map((candidate) => (
-              <ListItem key={candidate.network_id} disablePadding sx={{ py: 0.75 }}>
+              <ListItem key={candidate.networkId} disablePadding sx={{ py: 0.75 }}>
                 <ListItemText
-                  primary={candidate.name || candidate.network_id}
+                  primary={candidate.name || candidate.networkId}
                   secondary={candidateSecondary(candidate, translateText)}
                 />
               </ListItem>
@@ -113,7 +113,7 @@ function Login() {
       const response = await authAPI.login({
         username: formData.username,
         password: formData.password,
-        remember_me: rememberMe,
+        rememberMe,
       });
       
       // Extract user data and token from response
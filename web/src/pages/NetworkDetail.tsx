@@ -23,6 +23,7 @@ import { ArrowBack, ContentCopy } from '@mui/icons-material'
 import { Link, useParams, useNavigate } from 'react-router-dom'
 import {
   type IpAssignmentPool,
+  type MemberEvent,
   type Network,
   type NetworkConfig,
   type NetworkMetadataUpdateRequest,
@@ -78,6 +79,7 @@ import {
   isValidDnsServer,
 } from '../utils/networkValidation'
 import { formatNetworkMember } from '../utils/memberUtils'
+import { applyMemberEvent, memberEventTypes } from '../utils/memberEvents'
 import { useTranslation } from '../i18n'
 
 const defaultNetworkConfig: Partial<NetworkConfig> = {
@@ -176,6 +178,19 @@ function NetworkDetail() {
     void fetchNetworkDetail()
   }, [id])
 
+  useEffect(() => {
+    if (!id) return
+    const events = memberAPI.openMemberEvents(id)
+    const handleMemberEvent = (message: MessageEvent<string>) => {
+      const event = JSON.parse(message.data) as MemberEvent
+      setNetwork((current) => (current ? { ...current, members: applyMemberEvent(current.members || [], event) } : current))
+    }
+    memberEventTypes.forEach((type) => events.addEventListener(type, handleMemberEvent))
+    // The server ends the stream when access is revoked or it shuts down; stop reconnecting
+    events.addEventListener('closed', () => events.close())
+    return () => events.close()
+  }, [id])
+
   const showSnackbar = (message: string, severity: 'success' | 'error' | 'info' = 'success') => {
     setSnackbar({ open: true, message, severity })
   }
@@ -800,7 +815,7 @@ function NetworkDetail() {
                         <Box>
                           <Typography variant="body1">{viewer.username}</Typography>
                           <Typography variant="body2" color="text.secondary">
-                            {translateText('授权时间：')}{viewer.created_at ? formatDateTime(viewer.created_at) : '-'}
+                            {translateText('授权时间：')}{viewer.createdAt ? formatDateTime(viewer.createdAt) : '-'}
                           </Typography>
                         </Box>
                         <Button
@@ -2,7 +2,7 @@ import { useState, useEffect } from 'react';
 import { Box, Typography, Button, Table, TableBody, TableCell, TableContainer, TableHead, TableRow, Paper, CircularProgress, Alert, Modal, TextField, IconButton, Grid, Card, CardContent, Dialog, DialogTitle, DialogContent, DialogContentText, DialogActions, Stack, Chip } from '@mui/material';
 import { Link, useLocation } from 'react-router-dom';
 import { Add, Delete, Close, Refresh } from '@mui/icons-material';
-import { networkAPI, type NetworkSummary, type SharedNetworkSummary } from '../services/api';
+import { networkAPI, type MemberCounts, type NetworkSummary, type SharedNetworkSummary } from '../services/api';
 import { getErrorMessage } from '../services/errors';
 import { useTranslation } from '../i18n';
 import { getNavigationMessage, summaryCardSx } from '../utils/sharedStyles';
@@ -11,13 +11,14 @@ type DisplayNetwork = {
   id: string;
   name?: string;
   description?: string;
-  owner_id: string;
-  owner_username?: string;
-  member_count: number;
-  authorized_member_count: number;
-  pending_member_count: number;
-  created_at: string;
-  updated_at: string;
+  ownerId: string;
+  ownerUsername?: string;
+  memberCount: number;
+  authorizedMemberCount: number;
+  pendingMemberCount: number;
+  memberCounts?: MemberCounts | null;
+  createdAt: string;
+  updatedAt: string;
   readOnly: boolean;
   detailPath: string;
 }
@@ -337,17 +338,18 @@ function Networks() {
                             {network.description}
                           </Typography>
                         ) : null}
-                        {network.readOnly && network.owner_username ? (
+                        {network.readOnly && network.ownerUsername ? (
                           <Typography variant="body2" color="text.secondary">
-                            {translateText('共享来源：')}{network.owner_username}
+                            {translateText('共享来源：')}{network.ownerUsername}
                           </Typography>
                         ) : null}
                       </TableCell>
                       <TableCell>{network.id}</TableCell>
                       <TableCell>
-                        {network.member_count}{translateText(' 台')}
+                        {network.memberCount}{translateText(' 台')}
                         <Typography variant="body2" color="text.secondary">
-                          {translateText('已授权 ')}{network.authorized_member_count} / {translateText('待授权 ')}{network.pending_member_count}
+                          {translateText('已授权 ')}{network.authorizedMemberCount} / {translateText('待授权 ')}{network.pendingMemberCount}
+                          {network.memberCounts ? <> / {translateText('在线 ')}{network.memberCounts.online}</> : null}
                         </Typography>
                       </TableCell>
                       <TableCell>
@@ -1,4 +1,4 @@
-import { useState } from 'react'
+import { useEffect, useState } from 'react'
 import {
   Accordion,
   AccordionDetails,
@@ -25,7 +25,7 @@ import KeyIcon from '@mui/icons-material/Key'
 import SyncIcon from '@mui/icons-material/Sync'
 import FolderOpenIcon from '@mui/icons-material/FolderOpen'
 import EditNoteIcon from '@mui/icons-material/EditNote'
-import { planetAPI, type GeneratePlanetResponse, type SigningKeysInfoResponse } from '../services/api'
+import { planetAPI, type GeneratePlanetResponse, type SigningKeysInfoResponse, type ValidateIdentityResponse } from '../services/api'
 import { getErrorMessage } from '../services/errors'
 import {
   getPlanetDownloadName,
@@ -103,8 +103,32 @@ function PlanetGenerator() {
   const [rootNodes, setRootNodes] = useState<RootNodeDraft[]>([createRootNodeDraft()])
   const [generatedPlanet, setGeneratedPlanet] = useState<PlanetResultState | null>(null)
 
+  const [identityChecks, setIdentityChecks] = useState<Record<string, ValidateIdentityResponse>>({})
+
   const identitySummary = parsePlanetIdentityPublic(identityPublic)
 
+  // Well-formed identities the server has not checked yet, as one string so the effect below
+  // only runs again when that set changes
+  const uncheckedIdentities = rootNodes
+    .map((rootNode) => rootNode.identityPublic.trim())
+    .filter((value) => parsePlanetIdentityPublic(value) && !identityChecks[value])
+    .join('\n')
+
+  useEffect(() => {
+    if (!uncheckedIdentities) {
+      return
+    }
+    // Wait until the user stops typing before the server hashes the key
+    const timer = window.setTimeout(() => {
+      for (const value of uncheckedIdentities.split('\n')) {
+        planetAPI.validateIdentity(value)
+          .then((response) => setIdentityChecks((previous) => ({ ...previous, [value]: response.data })))
+          .catch(() => undefined)
+      }
+    }, 400)
+    return () => window.clearTimeout(timer)
+  }, [uncheckedIdentities])
+
   const syncMainFlowIntoFirstRootNode = () => {
     setRootNodes((previous) => {
       const [first, ...rest] = previous.length > 0 ? previous : [createRootNodeDraft()]
@@ -162,8 +186,8 @@ function PlanetGenerator() {
       setLoadingIdentity(true)
       setIdentityMessage(null)
       const response = await planetAPI.getIdentity(nextPath)
-      setIdentityPublic(response.data.identity_public)
-      setResolvedIdentityPath(response.data.identity_path)
+      setIdentityPublic(response.data.identityPublic)
+      setResolvedIdentityPath(response.data.identityPath)
       setIdentityMessage({ severity: 'success', text: 'identity.public 读取成功' })
     } catch (error: unknown) {
       setIdentityPublic('')
@@ -191,8 +215,8 @@ function PlanetGenerator() {
           ? {
               ...item,
               loadingIdentity: false,
-              identityPublic: response.data.identity_public,
-              resolvedIdentityPath: response.data.identity_path,
+              identityPublic: response.data.identityPublic,
+              resolvedIdentityPath: response.data.identityPath,
               message: { severity: 'success', text: 'identity.public 读取成功' },
             }
           : item
@@ -258,13 +282,13 @@ function PlanetGenerator() {
   }
 
   const buildMainFlowRootNode = () => ({
-    identity_public: identityPublic.trim(),
+    identityPublic: identityPublic.trim(),
     comments: comments.trim(),
     endpoints: normalizePlanetEndpoints(endpoints.map((endpoint) => endpoint.value)),
   })
 
   const buildAdvancedRootNodes = () => rootNodes.map((rootNode) => ({
-    identity_public: rootNode.identityPublic.trim(),
+    identityPublic: rootNode.identityPublic.trim(),
     comments: rootNode.comments.trim(),
     endpoints: normalizePlanetEndpoints(rootNode.endpoints.map((endpoint) => endpoint.value)),
   }))
@@ -324,12 +348,12 @@ function PlanetGenerator() {
       setMessage(null)
 
       const response = await planetAPI.generatePlanet({
-        root_nodes: advancedModeEnabled ? buildAdvancedRootNodes() : [buildMainFlowRootNode()],
-        signing_key_path: advancedModeEnabled && useCustomSigningKeys ? signingKeyPath.trim() : undefined,
-        planet_id: advancedModeEnabled && !recommendValues ? Number(planetId) : undefined,
-        birth_time: advancedModeEnabled && !recommendValues ? Number(birthTime) : undefined,
-        recommend_values: advancedModeEnabled ? recommendValues : true,
-        download_name: advancedModeEnabled ? downloadName.trim() : 'planet',
+        rootNodes: advancedModeEnabled ? buildAdvancedRootNodes() : [buildMainFlowRootNode()],
+        signingKeyDir: advancedModeEnabled && useCustomSigningKeys ? signingKeyPath.trim() : undefined,
+        planetId: advancedModeEnabled && !recommendValues ? Number(planetId) : undefined,
+        birthTime: advancedModeEnabled && !recommendValues ? Number(birthTime) : undefined,
+        recommendValues: advancedModeEnabled ? recommendValues : true,
+        downloadName: advancedModeEnabled ? downloadName.trim() : 'planet',
       })
 
       setGeneratedPlanet(response.data)
@@ -347,11 +371,11 @@ function PlanetGenerator() {
       return
     }
 
-    const blob = new Blob([new Uint8Array(generatedPlanet.planet_data)], { type: 'application/octet-stream' })
+    const blob = new Blob([new Uint8Array(generatedPlanet.planetData)], { type: 'application/octet-stream' })
     const url = URL.createObjectURL(blob)
     const anchor = document.createElement('a')
     anchor.href = url
-    anchor.download = getPlanetDownloadName(generatedPlanet.download_name)
+    anchor.download = getPlanetDownloadName(generatedPlanet.downloadName)
     document.body.appendChild(anchor)
     anchor.click()
     document.body.removeChild(anchor)
@@ -576,7 +600,7 @@ function PlanetGenerator() {
                 />
 
                 <Typography variant="body2" color="text.secondary" sx={{ mb: 2 }}>
-                  推荐值会由系统自动生成更安全的 `planet_id` 与 `birth_time`。关闭后可手工指定，但不建议随意修改。
+                  推荐值会由系统自动生成更安全的 `planetId` 与 `birthTime`。关闭后可手工指定，但不建议随意修改。
                 </Typography>
 
                 <Stack spacing={2}>
@@ -670,11 +694,11 @@ function PlanetGenerator() {
                         </Box>
                         <Box>
                           <Typography variant="body2" color="text.secondary">previous.c25519</Typography>
-                          <Typography variant="body1">{signingKeysInfo.previous_exists ? '已找到' : '未找到'}</Typography>
+                          <Typography variant="body1">{signingKeysInfo.previousExists ? '已找到' : '未找到'}</Typography>
                         </Box>
                         <Box>
                           <Typography variant="body2" color="text.secondary">current.c25519</Typography>
-                          <Typography variant="body1">{signingKeysInfo.current_exists ? '已找到' : '未找到'}</Typography>
+                          <Typography variant="body1">{signingKeysInfo.currentExists ? '已找到' : '未找到'}</Typography>
                         </Box>
                       </Box>
                     )}
@@ -702,6 +726,8 @@ function PlanetGenerator() {
                 <Stack spacing={2}>
                   {rootNodes.map((rootNode, index) => {
                     const rootIdentitySummary = parsePlanetIdentityPublic(rootNode.identityPublic)
+                    const rootIdentityCheck = rootIdentitySummary ? identityChecks[rootNode.identityPublic.trim()] : undefined
+                    const rootIdentityMismatch = rootIdentityCheck?.valid === false
                     return (
                       <Card key={rootNode.id} variant="outlined">
                         <CardContent>
@@ -774,10 +800,12 @@ function PlanetGenerator() {
                               placeholder="格式：10hexdigits:0:publicKey"
                               helperText={rootNode.identityPublic.trim()
                                 ? rootIdentitySummary
-                                  ? '该 identity.public 将作为此根节点的身份'
+                                  ? rootIdentityMismatch
+                                    ? `公钥与地址不匹配：${rootIdentityCheck?.error}`
+                                    : '该 identity.public 将作为此根节点的身份'
                                   : 'identity.public 格式无效'
                                 : '可读取后自动填入，也可直接手工粘贴'}
-                              error={Boolean(rootNode.identityPublic.trim()) && !rootIdentitySummary}
+                              error={Boolean(rootNode.identityPublic.trim()) && (!rootIdentitySummary || rootIdentityMismatch)}
                               disabled={rootNode.loadingIdentity || generating}
                             />
 
@@ -837,27 +865,27 @@ function PlanetGenerator() {
             <Box sx={{ display: 'grid', gridTemplateColumns: { xs: '1fr', md: 'repeat(3, 1fr)' }, gap: 2, mb: 3 }}>
               <Box>
                 <Typography variant="body2" color="text.secondary">Planet ID</Typography>
-                <Typography variant="body1">{generatedPlanet.planet_id}</Typography>
+                <Typography variant="body1">{generatedPlanet.planetId}</Typography>
               </Box>
               <Box>
                 <Typography variant="body2" color="text.secondary">生成时间</Typography>
-                <Typography variant="body1">{new Date(generatedPlanet.birth_time).toLocaleString()}</Typography>
+                <Typography variant="body1">{new Date(generatedPlanet.birthTime).toLocaleString()}</Typography>
               </Box>
               <Box>
                 <Typography variant="body2" color="text.secondary">Root Node 数</Typography>
-                <Typography variant="body1">{generatedPlanet.root_node_count}</Typography>
+                <Typography variant="body1">{generatedPlanet.rootNodeCount}</Typography>
               </Box>
               <Box>
                 <Typography variant="body2" color="text.secondary">总端点数</Typography>
-                <Typography variant="body1">{generatedPlanet.endpoint_count}</Typography>
+                <Typography variant="body1">{generatedPlanet.endpointCount}</Typography>
               </Box>
               <Box>
                 <Typography variant="body2" color="text.secondary">下载文件名</Typography>
-                <Typography variant="body1">{generatedPlanet.download_name}</Typography>
+                <Typography variant="body1">{generatedPlanet.downloadName}</Typography>
               </Box>
               <Box>
                 <Typography variant="body2" color="text.secondary">推荐值</Typography>
-                <Typography variant="body1">{generatedPlanet.used_recommended_values ? '已使用' : '未使用'}</Typography>
+                <Typography variant="body1">{generatedPlanet.usedRecommendedValues ? '已使用' : '未使用'}</Typography>
               </Box>
             </Box>
 
@@ -135,17 +135,17 @@ function Settings() {
     try {
       setChangingPassword(true)
       const response = await authAPI.updatePassword({
-        current_password: passwordForm.oldPassword,
-        new_password: passwordForm.newPassword,
-        confirm_password: passwordForm.confirmPassword,
-        logout_other_sessions: logoutOtherSessionsOnPasswordChange,
+        currentPassword: passwordForm.oldPassword,
+        newPassword: passwordForm.newPassword,
+        confirmPassword: passwordForm.confirmPassword,
+        logoutOtherSessions: logoutOtherSessionsOnPasswordChange,
       })
 
       await reloadSessions()
       setMessage({
         severity: 'success',
-        text: response.data.revoked_other_sessions > 0
-          ? t('password.changedWithRevoked', { count: response.data.revoked_other_sessions })
+        text: response.data.revokedOtherSessions > 0
+          ? t('password.changedWithRevoked', { count: response.data.revokedOtherSessions })
           : translateText('密码修改成功'),
       })
       resetPasswordDialog()
@@ -18,7 +18,7 @@ import {
 } from '@mui/material';
 import ArrowForwardIcon from '@mui/icons-material/ArrowForward';
 import { useTranslation, type LanguagePreference } from '../i18n';
-import { authAPI, systemAPI, type DatabaseSetupConfig, type SetupStatus, type ZeroTierSetupConfig } from '../services/api';
+import { authAPI, systemAPI, type DatabaseSetupConfig, type SetupStatus, type ZeroTierConnectionCheck, type ZeroTierSetupConfig } from '../services/api';
 import { getErrorMessage } from '../services/errors';
 import { getInitialSetupWizardStep } from '../utils/setupWizard';
 
@@ -29,6 +29,17 @@ interface AdminData {
 
 const setupCompletedEvent = 'tairitsu-setup-complete';
 
+// connectionCheckHint names the step of a failed controller connection test
+const connectionCheckHint = (check: ZeroTierConnectionCheck) => {
+  if (!check.reachable) {
+    return '无法连接到 ZeroTier 控制器，请检查控制器地址';
+  }
+  if (!check.authenticated) {
+    return 'ZeroTier 控制器拒绝了认证令牌';
+  }
+  return '认证令牌无权管理网络，请使用控制器的 authtoken.secret';
+};
+
 const defaultDbConfig: DatabaseSetupConfig = {
   type: 'sqlite',
   path: '',
@@ -44,8 +55,8 @@ function SetupWizard() {
 
   const steps = useMemo(() => [
     translateText('欢迎使用 Tairitsu'),
-    translateText('配置 ZeroTier 控制器'),
     translateText('配置数据库'),
+    translateText('配置 ZeroTier 控制器'),
     translateText('创建管理员账户'),
     translateText('完成设置'),
   ], [translateText]);
@@ -114,18 +125,27 @@ function SetupWizard() {
       }
 
       if (activeStep === 1) {
-        const response = await systemAPI.saveZtConfig(ztConfig);
+        const response = await systemAPI.configureDatabase(dbConfig);
         const nextStatus = await fetchSetupStatus();
-        setSuccess(`${translateText('ZeroTier 控制器连接成功并已保存：')}${response.data.status.address || response.data.config.controllerUrl}`);
+        const savedPath = response.data.config.path || nextStatus.databaseConfig?.path || 'data/tairitsu.db';
+        setSuccess(`${translateText('SQLite 配置已保存：')}${savedPath}`);
         setActiveStep(Math.max(2, getInitialSetupWizardStep(nextStatus)));
         return;
       }
 
       if (activeStep === 2) {
-        const response = await systemAPI.configureDatabase(dbConfig);
+        const token = ztConfig.token?.trim();
+        const request = token
+          ? { controllerUrl: ztConfig.controllerUrl, tokenPath: '', token }
+          : { controllerUrl: ztConfig.controllerUrl, tokenPath: ztConfig.tokenPath };
+        const { data: check } = await systemAPI.testZtConnection(request);
+        if (!check.controllerAccess) {
+          setError(`${translateText(connectionCheckHint(check))}${check.error ? ` (${check.error})` : ''}`);
+          return;
+        }
+        const response = await systemAPI.saveZtConfig(request);
         const nextStatus = await fetchSetupStatus();
-        const savedPath = response.data.config.path || nextStatus.databaseConfig?.path || 'data/tairitsu.db';
-        setSuccess(`${translateText('SQLite 配置已保存：')}${savedPath}`);
+        setSuccess(`${translateText('ZeroTier 控制器连接成功并已保存：')}${response.data.status.address || response.data.config.controllerUrl}`);
         setActiveStep(Math.max(3, getInitialSetupWizardStep(nextStatus)));
         return;
       }
@@ -217,7 +237,7 @@ function SetupWizard() {
               {translateText('欢迎使用 Tairitsu')}
             </Typography>
             <Typography variant="body1" align="center" sx={{ maxWidth: 520, mb: 4 }}>
-              {translateText('本向导会依次完成 ZeroTier 控制器连接、SQLite 配置、首个管理员创建，以及运行态切换。')}
+              {translateText('本向导会依次完成 SQLite 配置、ZeroTier 控制器连接、首个管理员创建，以及运行态切换。')}
             </Typography>
             <Button
               variant="contained"
@@ -231,6 +251,38 @@ function SetupWizard() {
           </Paper>
         );
       case 1:
+        return (
+          <Paper sx={{ p: 3 }}>
+            <Typography variant="h5" gutterBottom>
+              {translateText('配置数据库')}
+            </Typography>
+            <Typography variant="body1" sx={{ mb: 2 }}>
+              {translateText('当前仅支持 SQLite。PostgreSQL 等其他数据库将在后续版本推出。')}
+            </Typography>
+            <TextField
+              margin="normal"
+              fullWidth
+              id="type"
+              label={translateText('数据库类型')}
+              value={dbConfig.type}
+              disabled
+            />
+            <TextField
+              margin="normal"
+              fullWidth
+              id="path"
+              label={translateText('SQLite 文件路径')}
+              name="path"
+              autoComplete="file-path"
+              value={dbConfig.path}
+              onChange={(event) => setDbConfig((previous) => ({ ...previous, path: event.target.value }))}
+              disabled={loading}
+              helperText={translateText('留空则使用默认值 data/tairitsu.db')}
+            />
+            {renderMessages()}
+          </Paper>
+        );
+      case 2:
         return (
           <Paper sx={{ p: 3 }}>
             <Typography variant="h5" gutterBottom>
@@ -253,7 +305,7 @@ function SetupWizard() {
             />
             <TextField
               margin="normal"
-              required
+              required={!ztConfig.token}
               fullWidth
               id="tokenPath"
               label={translateText('认证令牌文件路径')}
@@ -264,43 +316,24 @@ function SetupWizard() {
               disabled={loading}
               helperText={translateText('例如 /var/lib/zerotier-one/authtoken.secret')}
             />
-            {status?.ztStatus && (
-              <Alert severity={status.ztStatus.online ? 'success' : 'warning'} sx={{ mt: 2 }}>
-                {translateText('当前控制器状态：')}{status.ztStatus.online ? translateText('在线') : translateText('离线')}{status.ztStatus.address ? ` · ${status.ztStatus.address}` : ''}
-              </Alert>
-            )}
-            {renderMessages()}
-          </Paper>
-        );
-      case 2:
-        return (
-          <Paper sx={{ p: 3 }}>
-            <Typography variant="h5" gutterBottom>
-              {translateText('配置数据库')}
-            </Typography>
-            <Typography variant="body1" sx={{ mb: 2 }}>
-              {translateText('当前仅支持 SQLite。PostgreSQL 等其他数据库将在后续版本推出。')}
-            </Typography>
             <TextField
               margin="normal"
               fullWidth
-              id="type"
-              label={translateText('数据库类型')}
-              value={dbConfig.type}
-              disabled
-            />
-            <TextField
-              margin="normal"
-              fullWidth
-              id="path"
-              label={translateText('SQLite 文件路径')}
-              name="path"
-              autoComplete="file-path"
-              value={dbConfig.path}
-              onChange={(event) => setDbConfig((previous) => ({ ...previous, path: event.target.value }))}
+              id="token"
+              type="password"
+              label={translateText('或直接填写认证令牌')}
+              name="token"
+              autoComplete="off"
+              value={ztConfig.token || ''}
+              onChange={(event) => setZtConfig((previous) => ({ ...previous, token: event.target.value }))}
               disabled={loading}
-              helperText={translateText('留空则使用默认值 data/tairitsu.db')}
+              helperText={translateText('控制器运行在无法共享文件的容器中时使用；令牌会加密保存，填写后将忽略文件路径')}
             />
+            {status?.ztStatus && (
+              <Alert severity={status.ztStatus.online ? 'success' : 'warning'} sx={{ mt: 2 }}>
+                {translateText('当前控制器状态：')}{status.ztStatus.online ? translateText('在线') : translateText('离线')}{status.ztStatus.address ? ` · ${status.ztStatus.address}` : ''}
+              </Alert>
+            )}
             {renderMessages()}
           </Paper>
         );
@@ -417,9 +450,9 @@ function SetupWizard() {
               {loading ? (
                 <CircularProgress size={24} />
               ) : activeStep === 1 ? (
-                translateText('测试并保存')
-              ) : activeStep === 2 ? (
                 translateText('保存数据库配置')
+              ) : activeStep === 2 ? (
+                translateText('测试并保存')
               ) : activeStep === 3 ? (
                 translateText('创建首个管理员')
               ) : activeStep === 4 ? (
@@ -110,7 +110,7 @@ function SharedNetworkMembers() {
               {network?.name}
             </Typography>
             <Typography variant="body2" color="text.secondary">
-              由 {network?.owner_username || network?.owner_id} 授予只读查看权限
+              由 {network?.ownerUsername || network?.ownerId} 授予只读查看权限
             </Typography>
           </Box>
         </Box>
@@ -47,8 +47,8 @@ function UserManagement() {
   const [loading, setLoading] = useState<boolean>(true);
   const [updating, setUpdating] = useState<boolean>(false);
   const [message, setMessage] = useState<{ text: string; severity: 'success' | 'error' | 'info' } | null>(null);
-  const [runtimeSettings, setRuntimeSettings] = useState<RuntimeSettings>({ allow_public_registration: true });
-  const [initialRuntimeSettings, setInitialRuntimeSettings] = useState<RuntimeSettings>({ allow_public_registration: true });
+  const [runtimeSettings, setRuntimeSettings] = useState<RuntimeSettings>({ allowPublicRegistration: true });
+  const [initialRuntimeSettings, setInitialRuntimeSettings] = useState<RuntimeSettings>({ allowPublicRegistration: true });
   const [savingRuntimeSettings, setSavingRuntimeSettings] = useState(false);
   const [targetAdminId, setTargetAdminId] = useState('');
   const [transferringAdmin, setTransferringAdmin] = useState(false);
@@ -94,7 +94,7 @@ function UserManagement() {
   }, []);
 
   const transferCandidates = users.filter((candidate) => candidate.id !== currentUser?.id && candidate.role !== 'admin');
-  const runtimeSettingsUnsaved = runtimeSettings.allow_public_registration !== initialRuntimeSettings.allow_public_registration;
+  const runtimeSettingsUnsaved = runtimeSettings.allowPublicRegistration !== initialRuntimeSettings.allowPublicRegistration;
 
   const handleCreateUser = async () => {
     if (!createUsername.trim()) {
@@ -132,7 +132,7 @@ function UserManagement() {
       setResetTarget(null);
       setResetResult(response.data);
       setMessage({
-        text: t('users.resetPasswordSuccess', { name: response.data.user.username, count: response.data.revoked_sessions }),
+        text: t('users.resetPasswordSuccess', { name: response.data.user.username, count: response.data.revokedSessions }),
         severity: 'success',
       });
     } catch (error: unknown) {
@@ -157,7 +157,7 @@ function UserManagement() {
       setDeleteTarget(null);
       setDeleteResult(response.data);
       setMessage({
-        text: t('users.deleteSuccess', { name: response.data.user.username, count: response.data.transferred_networks }),
+        text: t('users.deleteSuccess', { name: response.data.user.username, count: response.data.transferredNetworks }),
         severity: 'success',
       });
     } catch (error: unknown) {
@@ -277,10 +277,10 @@ function UserManagement() {
             <FormControlLabel
               control={(
                 <Switch
-                  checked={runtimeSettings.allow_public_registration}
+                  checked={runtimeSettings.allowPublicRegistration}
                   onChange={(event) => setRuntimeSettings((previous) => ({
                     ...previous,
-                    allow_public_registration: event.target.checked,
+                    allowPublicRegistration: event.target.checked,
                   }))}
                 />
               )}
@@ -456,7 +456,7 @@ function UserManagement() {
       <OneTimePasswordDialog
         open={Boolean(createResult)}
         username={createResult?.user.username || ''}
-        password={createResult?.temporary_password || ''}
+        password={createResult?.temporaryPassword || ''}
         subjectLabel={translateText('新用户')}
         footerText={translateText('用户首次收到密码后，应尽快登录并到“设置”中修改为自己的新密码。')}
         onClose={() => setCreateResult(null)}
@@ -498,10 +498,10 @@ function UserManagement() {
               {deleteResult?.user.username || translateText('该用户')} {translateText('已被删除。')}
             </Alert>
             <Typography variant="body2" color="text.secondary">
-              {t('users.transferredNetworks', { count: deleteResult?.transferred_networks ?? 0 })}
+              {t('users.transferredNetworks', { count: deleteResult?.transferredNetworks ?? 0 })}
             </Typography>
             <Typography variant="body2" color="text.secondary">
-              {t('users.revokedSessions', { count: deleteResult?.revoked_sessions ?? 0 })}
+              {t('users.revokedSessions', { count: deleteResult?.revokedSessions ?? 0 })}
             </Typography>
             <Typography variant="body2" color="text.secondary">
               {translateText('这些网络现在已归当前管理员所有，可在网络列表中继续管理。')}
@@ -543,9 +543,9 @@ function UserManagement() {
       <OneTimePasswordDialog
         open={Boolean(resetResult)}
         username={resetResult?.user.username || ''}
-        password={resetResult?.temporary_password || ''}
+        password={resetResult?.temporaryPassword || ''}
         subjectLabel={translateText('目标用户')}
-        footerText={`${translateText('已吊销会话：')}${resetResult?.revoked_sessions ?? 0}${translateText(' 个。用户收到密码后应尽快登录并修改为自己的新密码。')}`}
+        footerText={`${translateText('已吊销会话：')}${resetResult?.revokedSessions ?? 0}${translateText(' 个。用户收到密码后应尽快登录并修改为自己的新密码。')}`}
         onClose={() => setResetResult(null)}
       />
     </Box>
@@ -1,12 +1,15 @@
 import axios from 'axios'
 import { toError } from './errors'
+import { apiAcceptLanguage } from '../i18n'
 
 // Type definitions for API responses and requests
 
 export interface User {
   id: string;
   username: string;
+  email?: string;
   role: 'admin' | 'user';
+  mustChangePassword?: boolean;
   createdAt: string;
   updatedAt: string;
 }
@@ -24,21 +27,21 @@ export interface TransferAdminResponse {
 export interface ResetUserPasswordResponse {
   message: string;
   user: User;
-  temporary_password: string;
-  revoked_sessions: number;
+  temporaryPassword: string;
+  revokedSessions: number;
 }
 
 export interface CreateUserResponse {
   message: string;
   user: User;
-  temporary_password: string;
+  temporaryPassword: string;
 }
 
 export interface DeleteUserResponse {
   message: string;
   user: User;
-  transferred_networks: number;
-  revoked_sessions: number;
+  transferredNetworks: number;
+  revokedSessions: number;
 }
 
 export interface UserSession {
@@ -56,14 +59,14 @@ export interface UserSession {
 
 export interface UpdatePasswordResponse {
   message: string;
-  revoked_other_sessions: number;
+  revokedOtherSessions: number;
 }
 
 export interface Network {
   id: string;
   name: string;
   description?: string;
-  db_description?: string;
+  dbDescription?: string;
   config: NetworkConfig;
   members: Member[];
   status: string;
@@ -71,38 +74,87 @@ export interface Network {
   updatedAt: string;
 }
 
+export interface ControllerInfo {
+  name: string;
+  url?: string;
+  default: boolean;
+  status?: {
+    version: string;
+    address: string;
+    online: boolean;
+    tcpFallbackAvailable?: boolean;
+    apiReady?: boolean;
+  };
+  error?: string;
+}
+
+export type PeerRole = 'PLANET' | 'MOON' | 'LEAF';
+
+export interface PeerPath {
+  active: boolean;
+  address: string;
+  preferred: boolean;
+  expired: boolean;
+  lastSend: number;
+  lastReceive: number;
+}
+
+export interface ControllerPeer {
+  address: string;
+  latency: number;
+  role: PeerRole;
+  version: string;
+  paths: PeerPath[];
+}
+
+export interface ControllerPeers {
+  controller: string;
+  peers: ControllerPeer[];
+}
+
+export interface MemberCounts {
+  total: number;
+  authorized: number;
+  online: number;
+}
+
 export interface NetworkSummary {
   id: string;
   name: string;
   description?: string;
-  owner_id: string;
-  member_count: number;
-  authorized_member_count: number;
-  pending_member_count: number;
-  created_at: string;
-  updated_at: string;
+  ownerId: string;
+  controller: string;
+  organizationId: string;
+  memberCount: number;
+  authorizedMemberCount: number;
+  pendingMemberCount: number;
+  memberCounts: MemberCounts | null;
+  createdAt: string;
+  updatedAt: string;
 }
 
 export interface SharedNetworkSummary {
   id: string;
   name: string;
   description?: string;
-  owner_id: string;
-  owner_username: string;
-  member_count: number;
-  authorized_member_count: number;
-  pending_member_count: number;
-  created_at: string;
-  updated_at: string;
+  ownerId: string;
+  ownerUsername: string;
+  controller: string;
+  memberCount: number;
+  authorizedMemberCount: number;
+  pendingMemberCount: number;
+  memberCounts: MemberCounts | null;
+  createdAt: string;
+  updatedAt: string;
 }
 
 export interface NetworkViewer {
   id: string;
   username: string;
   role: 'admin' | 'user';
-  granted_by: string;
-  created_at: string;
-  updated_at: string;
+  grantedBy: string;
+  createdAt: string;
+  updatedAt: string;
 }
 
 export interface NetworkConfig {
@@ -112,6 +164,7 @@ export interface NetworkConfig {
   mtu?: number;
   multicastLimit?: number;
   dns?: DNSConfig;
+  ssoEnabled?: boolean;
   v4AssignMode?: {
     zt: boolean;
   };
@@ -124,6 +177,154 @@ export interface NetworkConfig {
   ipAssignmentPools?: IpAssignmentPool[];
 }
 
+// One entry of the controller's rule list; only the fields of its type are present
+export interface NetworkRule {
+  type: string;
+  not: boolean;
+  or: boolean;
+  address?: string;
+  flags?: number;
+  length?: number;
+  zt?: string;
+  mac?: string;
+  ip?: string;
+  vlanId?: number;
+  vlanPcp?: number;
+  vlanDei?: number;
+  etherType?: number;
+  ipProtocol?: number;
+  icmpType?: number;
+  icmpCode?: number;
+  start?: number;
+  end?: number;
+  mask?: string | number;
+  probability?: number;
+  id?: number;
+  value?: number;
+}
+
+export interface NetworkRules {
+  source: string;
+  updatedBy?: string;
+  updatedAt?: string;
+  rules: NetworkRule[];
+  capabilities: { id: number; default: boolean; rules: NetworkRule[] }[];
+  tags: { id: number; default: number | null }[];
+}
+
+// Returned with errorCode network.rules_invalid when the rules source does not compile
+export interface NetworkRulesError {
+  message: string;
+  errorCode: string;
+  line?: number;
+  column?: number;
+}
+
+// capacity and free are decimal strings; IPv6 pools exceed what a number holds exactly
+export interface IPPoolUsage {
+  ipRangeStart: string;
+  ipRangeEnd: string;
+  capacity: string;
+  assigned: number;
+  free: string;
+  nextFree: string[];
+}
+
+export interface InactivityPolicy {
+  days: number;
+  action: 'flag' | 'enforce';
+}
+
+export interface NetworkPolicies {
+  networkId: string;
+  inactivity: InactivityPolicy;
+}
+
+export interface InactivityReport {
+  networkId: string;
+  policy: InactivityPolicy;
+  dryRun: boolean;
+  checkedAt: string;
+  inactive: { memberId: string; name: string; lastOnline: string; inactiveDays: number }[];
+  deauthorized: string[];
+  failures: { memberId: string; error: string }[];
+}
+
+export interface NetworkIPUsage {
+  networkId: string;
+  pools: IPPoolUsage[];
+  outsidePools: string[];
+}
+
+export interface NetworkBackupMember {
+  address: string;
+  name: string;
+  authorized: boolean;
+  activeBridge: boolean;
+  ipAssignments: string[];
+  noAutoAssignIps: boolean;
+}
+
+export interface NetworkBackup {
+  version: number;
+  exportedAt: string;
+  sourceId?: string;
+  network: {
+    name: string;
+    description: string;
+    config: NetworkConfig;
+  };
+  members: NetworkBackupMember[];
+}
+
+export interface NetworkRestoreResult {
+  network: Network;
+  restoredMembers: number;
+  failedMembers: {
+    address: string;
+    reasonCode: 'invalid_address' | 'duplicate_member' | 'controller_error';
+    reasonMessage: string;
+  }[];
+}
+
+export interface NetworkBatchRequest {
+  count: number;
+  // Must contain {index}, replaced by the zero-padded 1-based index of each network
+  namePattern: string;
+  description?: string;
+  controller?: string;
+  config?: NetworkUpdateRequest;
+  idempotencyKey?: string;
+}
+
+export interface OperationItem {
+  index: number;
+  name: string;
+  status: 'pending' | 'created' | 'failed';
+  networkId?: string;
+  error?: string;
+  updatedAt: string;
+}
+
+export interface Operation {
+  id: string;
+  kind: 'network_batch';
+  userId: string;
+  idempotencyKey: string;
+  status: 'pending' | 'running' | 'succeeded' | 'failed' | 'cancelled';
+  error?: string;
+  createdAt: string;
+  updatedAt: string;
+  startedAt?: string;
+  finishedAt?: string;
+  total: number;
+  done: number;
+  failed: number;
+  result?: unknown;
+  // Only network batches fetched one at a time list their items
+  items?: OperationItem[];
+}
+
 export interface NetworkUpdateRequest {
   name?: string;
   description?: string;
@@ -133,6 +334,7 @@ export interface NetworkUpdateRequest {
   mtu?: number;
   multicastLimit?: number;
   dns?: DNSConfig;
+  ssoEnabled?: boolean;
   v4AssignMode?: {
     zt: boolean;
   };
@@ -170,6 +372,37 @@ export interface MemberTag {
   value: number;
 }
 
+export interface ResolvedMemberTag {
+  id: number;
+  value: number;
+  name?: string;
+  valueName?: string;
+}
+
+export interface ResolvedMemberCapability {
+  id: number;
+  name?: string;
+}
+
+// Tag and capability names kept by Tairitsu for a network
+export interface NetworkTag {
+  networkId: string;
+  id: number;
+  name: string;
+  values?: Record<string, number>;
+  createdBy?: string;
+  createdAt: string;
+  updatedAt: string;
+}
+
+export interface NetworkCapability {
+  networkId: string;
+  id: number;
+  name: string;
+  createdBy?: string;
+  createdAt: string;
+}
+
 export interface Member {
   id: string;
   name?: string;
@@ -184,6 +417,9 @@ export interface Member {
   creationTime?: number;
   tags?: MemberTag[];
   capabilities?: number[];
+  // tags and capabilities with the names defined on the network
+  resolvedTags?: ResolvedMemberTag[];
+  resolvedCapabilities?: ResolvedMemberCapability[];
   peerVersion?: string;
   peerLatency?: number;
   peerRole?: string;
@@ -195,20 +431,222 @@ export interface Member {
     noAutoAssignIps?: boolean;
   };
   noAutoAssignIps?: boolean;
+  // Kept by Tairitsu rather than the controller
+  metadata?: MemberMetadata;
+}
+
+export interface MemberMetadata {
+  displayName?: string;
+  notes?: string;
+  tags?: Record<string, string>;
+  // RFC 3339 deadline after which the member is deauthorized
+  authorizedUntil?: string;
+  authorizationRemainingSeconds?: number;
+  updatedBy?: string;
+  updatedAt: string;
+}
+
+// Omitted fields are kept; an empty tags object removes all tags
+export interface MemberMetadataUpdate {
+  displayName?: string;
+  notes?: string;
+  tags?: Record<string, string>;
+}
+
+export interface NetworkLockdown {
+  id: number;
+  networkId: string;
+  active: boolean;
+  except: string[];
+  // Members deauthorized by the lockdown that have not been authorized again yet
+  deauthorized: string[];
+  createdBy: string;
+  createdAt: string;
+  rolledBackBy?: string;
+  rolledBackAt?: string;
+}
+
+export interface NetworkLockdownResult {
+  lockdown: NetworkLockdown;
+  changed: string[];
+  failures: { memberId: string; error: string }[];
+}
+
+export interface PendingApproval {
+  id: number;
+  networkId: string;
+  memberId: string;
+  name: string;
+  status: 'pending' | 'approved' | 'denied';
+  detectedAt: string;
+  decidedBy?: string;
+  decidedAt?: string;
+}
+
+export interface DeviceClaim {
+  id: number;
+  userId: string;
+  nodeAddress: string;
+  claimCode: string;
+  verified: boolean;
+  verifiedBy?: string;
+  verifiedAt?: string;
+  createdAt: string;
+}
+
+export interface DeviceMembership {
+  networkId: string;
+  networkName: string;
+  authorized: boolean;
+  name: string;
+  displayName?: string;
+  ipAssignments: string[];
+}
+
+export interface Device extends DeviceClaim {
+  networks: DeviceMembership[];
+}
+
+export type OrganizationRole = 'org_admin' | 'org_member';
+
+export interface Organization {
+  id: string;
+  name: string;
+  description: string;
+  // The caller's role; omitted for administrators outside the organization
+  role?: OrganizationRole;
+  createdAt: string;
+  updatedAt: string;
+}
+
+export interface OrganizationMember {
+  userId: string;
+  username: string;
+  role: OrganizationRole;
+  addedBy: string;
+  createdAt: string;
+  updatedAt: string;
+}
+
+export type MemberEventType =
+  | 'member.joined'
+  | 'member.left'
+  | 'member.authorized'
+  | 'member.deauthorized'
+  | 'member.online'
+  | 'member.offline';
+
+export interface MemberEvent {
+  type: MemberEventType;
+  networkId: string;
+  memberId: string;
+  time: string;
+  member?: Member;
+}
+
+export interface MemberPollSchedule {
+  networkId: string;
+  intervalSeconds: number;
+  reason: 'base' | 'immediate' | 'mutation' | 'pending' | 'idle';
+  nextPollAt: string;
+  lastPollAt?: string;
+  idlePolls: number;
+  subscribers: number;
+}
+
+export interface CompactionRun {
+  trigger: 'schedule' | 'manual';
+  startedAt: string;
+  finishedAt: string;
+  compacted: boolean;
+  skippedReason?: string;
+  error?: string;
+  sizeBefore: number;
+  sizeAfter: number;
+  freeRatioBefore: number;
+}
+
+export interface CompactionStatus {
+  supported: boolean;
+  running: boolean;
+  intervalHours: number;
+  freePercentThreshold: number;
+  nextRunAt?: string;
+  lastRun?: CompactionRun;
+}
+
+export interface SystemBackupInfo {
+  name: string;
+  createdAt: string;
+  sizeBytes: number;
+}
+
+export interface SystemBackupRun {
+  trigger: 'schedule' | 'manual';
+  startedAt: string;
+  finishedAt: string;
+  name?: string;
+  networks: number;
+  error?: string;
+}
+
+export interface SystemBackupStatus {
+  running: boolean;
+  directory: string;
+  intervalHours: number;
+  retention: number;
+  nextRunAt?: string;
+  lastRun?: SystemBackupRun;
+}
+
+export interface SystemRestoreReport {
+  name: string;
+  state: AppStateImportReport;
+  restoredNetworks: number;
+  restoredMembers: number;
+  failedNetworks: { networkId: string; reason: string }[];
+  failedMembers: (NetworkRestoreResult['failedMembers'][number] & { networkId: string })[];
+}
+
+export interface JobsResponse {
+  memberPolling: MemberPollSchedule[];
+  databaseCompaction: CompactionStatus;
+  systemBackup: SystemBackupStatus;
+}
+
+export interface AppStateArchive {
+  format: string;
+  version: number;
+  createdAt: string;
+  kdf: string;
+  salt: string;
+  time: number;
+  memoryKiB: number;
+  threads: number;
+  ciphertext: string;
+}
+
+export interface AppStateImportReport {
+  applied: boolean;
+  settingsApplied: boolean;
+  imported: Record<string, number>;
+  unchanged: Record<string, number>;
+  mergedUsers: { username: string; sourceId: string; targetId: string }[];
+  conflicts: { table: string; id: string; reason: string }[];
 }
 
 export interface ImportableNetworkCandidate {
-  network_id: string;
+  networkId: string;
   name?: string;
   description?: string;
-  controller_status?: string;
-  member_count?: number;
+  controllerStatus?: string;
+  memberCount?: number;
   status: 'available' | 'managed' | 'blocked';
-  can_import: boolean;
-  reason_code: string;
-  reason_message: string;
-  owner_id?: string;
-  owner_username?: string;
+  canImport: boolean;
+  reasonCode: string;
+  reasonMessage: string;
+  ownerId?: string;
+  ownerUsername?: string;
 }
 
 export interface ImportableNetworksResponse {
@@ -219,19 +657,20 @@ export interface ImportableNetworksResponse {
     managed: number;
     blocked: number;
   };
+  warnings?: string[];
 }
 
 export interface ImportNetworkResultItem {
-  network_id: string;
+  networkId: string;
   name?: string;
-  owner_id?: string;
-  owner_username?: string;
-  reason_code?: string;
-  reason_message?: string;
+  ownerId?: string;
+  ownerUsername?: string;
+  reasonCode?: string;
+  reasonMessage?: string;
 }
 
 export interface ImportNetworksResponse {
-  target_owner: {
+  targetOwner: {
     id: string;
     username: string;
   };
@@ -256,6 +695,8 @@ export interface RuntimeStatus {
   databaseStatus: 'connected' | 'disconnected' | 'error';
   zeroTierError?: string;
   databaseError?: string;
+  statusFetchedAt?: string;
+  statusStale: boolean;
 }
 
 // System statistics interface
@@ -267,6 +708,32 @@ export interface SystemStats {
   platform: string;
   platformVersion: string;
   kernelVersion: string;
+  // Omitted when unreadable; the rates also until the server has a previous reading
+  diskUsage?: { path: string; total: number; used: number; free: number; usedPercent: number };
+  diskIO?: { readBytesPerSec: number; writeBytesPerSec: number };
+  networkIO?: { recvBytesPerSec: number; sentBytesPerSec: number };
+  runtime: { goroutines: number; heapInUse: number };
+}
+
+export interface SystemStatsSample {
+  timestamp: number;
+  cpuUsage: number;
+  memoryUsage: number;
+}
+
+export interface SystemStatsHistory {
+  windowSeconds: number;
+  intervalSeconds: number;
+  points: SystemStatsSample[];
+}
+
+export type SetupStep = 'database' | 'zerotier' | 'admin' | 'finalize';
+
+export interface SetupState {
+  steps: SetupStep[];
+  completed: SetupStep[];
+  current?: SetupStep;
+  initialized: boolean;
 }
 
 export interface SetupStatus {
@@ -293,6 +760,19 @@ export interface SetupStatus {
     tcpFallbackAvailable?: boolean;
     apiReady?: boolean;
   };
+  ztStatusFetchedAt?: string;
+  ztStatusStale: boolean;
+  // The parsed controller version and the network settings it accepts
+  ztVersion?: string;
+  ztFeatures?: ControllerFeature[];
+  checks?: Record<'database' | 'controller', StatusCheck | undefined>;
+}
+
+export type ControllerFeature = 'multicastLimit' | 'broadcast' | 'dns' | 'sso';
+
+export interface StatusCheck {
+  latencyMs: number;
+  error?: string;
 }
 
 export interface DatabaseSetupConfig {
@@ -303,6 +783,8 @@ export interface DatabaseSetupConfig {
 export interface ZeroTierSetupConfig {
   controllerUrl: string;
   tokenPath: string;
+  // Sent instead of tokenPath when Tairitsu cannot read the controller's files
+  token?: string;
 }
 
 export interface DatabaseSetupResponse {
@@ -316,6 +798,16 @@ export interface ZeroTierSetupResponse {
   status: NonNullable<SetupStatus['ztStatus']>;
 }
 
+// Result of a controller connection test; the first false field is the step that failed
+export interface ZeroTierConnectionCheck {
+  reachable: boolean;
+  authenticated: boolean;
+  controllerAccess: boolean;
+  version?: string;
+  latencyMs: number;
+  error?: string;
+}
+
 export interface InitializeAdminCreationResponse {
   message: string;
   resetDone: boolean;
@@ -327,56 +819,121 @@ export interface SetInitializedResponse {
 }
 
 export interface RuntimeSettings {
-  allow_public_registration: boolean;
+  allowPublicRegistration: boolean;
 }
 
 export interface IdentityInfo {
   message: string;
-  identity_public: string;
-  identity_path: string;
+  identityPublic: string;
+  identityPath: string;
 }
 
 export interface PlanetRootNodeRequest {
-  identity_public: string;
+  identityPublic: string;
   comments?: string;
   endpoints: string[];
 }
 
 export interface GeneratePlanetResponse {
   message: string;
-  planet_data: number[];
-  planet_id: number;
-  birth_time: number;
-  download_name: string;
-  root_node_count: number;
-  endpoint_count: number;
-  used_recommended_values: boolean;
+  planetData: number[];
+  planetId: number;
+  birthTime: number;
+  downloadName: string;
+  rootNodeCount: number;
+  endpointCount: number;
+  usedRecommendedValues: boolean;
+  signingKeys: SigningKeySource;
+  signingKeyDir?: string;
+  historyId?: number;
 }
 
+export interface PlanetGeneration {
+  id: number;
+  planetId: number;
+  birthTime: number;
+  downloadName: string;
+  rootNodes: PlanetRootNodeRequest[];
+  rootNodeCount: number;
+  endpointCount: number;
+  signingKeys: SigningKeySource;
+  createdBy: string;
+  createdAt: string;
+}
+
+export type SigningKeySource = 'reused' | 'created' | 'ephemeral';
+
 export interface GeneratePlanetRequest {
-  root_nodes: PlanetRootNodeRequest[];
-  signing_key_path?: string;
-  planet_id?: number;
-  birth_time?: number;
-  recommend_values?: boolean;
-  download_name?: string;
+  rootNodes: PlanetRootNodeRequest[];
+  signingKeyDir?: string;
+  planetId?: number;
+  birthTime?: number;
+  recommendValues?: boolean;
+  downloadName?: string;
+  // Resolve endpoints like "root1.example.com/9993"; defaults to true
+  resolveHostnames?: boolean;
+  resolveFamily?: ResolveFamily;
+  // Accept roots whose public key does not derive their address
+  skipValidation?: boolean;
+}
+
+export interface ValidateIdentityResponse {
+  valid: boolean;
+  address?: string;
+  error?: string;
+}
+
+export type ResolveFamily = 'ipv4' | 'ipv6' | 'both';
+
+export interface GenerateMoonRequest {
+  rootNodes: PlanetRootNodeRequest[];
+  signingKeyDir?: string;
+  timestamp?: number;
+  resolveHostnames?: boolean;
+  resolveFamily?: ResolveFamily;
+  skipValidation?: boolean;
+}
+
+export interface GenerateMoonResponse {
+  message: string;
+  // Base64-encoded moon file
+  moonData: string;
+  moonId: string;
+  timestamp: number;
+  fileName: string;
+  rootNodeCount: number;
+  endpointCount: number;
+  signingKeys: SigningKeySource;
+  signingKeyDir?: string;
+}
+
+export interface InspectWorldResponse {
+  message: string;
+  type: 'planet' | 'moon';
+  id: number;
+  timestamp: number;
+  updatesMustBeSignedBy: string;
+  signature: string;
+  rootNodes: PlanetRootNodeRequest[];
+  rootNodeCount: number;
+  endpointCount: number;
 }
 
 export interface SigningKeysInfoResponse {
   message: string;
-  signing_key_path: string;
-  previous_key_path: string;
-  current_key_path: string;
-  previous_exists: boolean;
-  current_exists: boolean;
+  signingKeyPath: string;
+  previousKeyPath: string;
+  currentKeyPath: string;
+  previousExists: boolean;
+  currentExists: boolean;
   ready: boolean;
 }
 
 export interface GenerateSigningKeysResponse {
   message: string;
-  signing_key_path: string;
-  previous_key_path: string;
-  current_key_path: string;
+  signingKeyPath: string;
+  previousKeyPath: string;
+  currentKeyPath: string;
 }
 
 // Create axios instance
@@ -396,6 +953,7 @@ api.interceptors.request.use(
     if (token) {
       config.headers['Authorization'] = `Bearer ${token}`
     }
+    config.headers['Accept-Language'] = apiAcceptLanguage()
     return config
   },
   error => {
@@ -409,6 +967,10 @@ api.interceptors.response.use(
     return response
   },
   error => {
+    // Administrator-issued passwords must be replaced before anything else; the form lives in settings
+    if (error?.response?.data?.errorCode === 'auth.password_change_required' && window.location.pathname !== '/settings') {
+      window.location.assign('/settings')
+    }
     return Promise.reject(toError(error))
   }
 )
@@ -416,13 +978,19 @@ api.interceptors.response.use(
 // Authentication related APIs
 export const authAPI = {
   // User registration
-  register: (data: { username: string; password: string }) => api.post<RegisterResponse>('/auth/register', data),
+  register: (data: { username: string; password: string; captchaToken?: string }) => api.post<RegisterResponse>('/auth/register', data),
   // User login
-  login: (data: { username: string; password: string; remember_me?: boolean }) => api.post<{ user: User; token: string; session: UserSession }>('/auth/login', data),
+  login: (data: { username: string; password: string; rememberMe?: boolean; captchaToken?: string }) => api.post<{ user: User; token: string; session: UserSession }>('/auth/login', data),
+  // Sign-in methods offered by the login page
+  getMethods: () => api.get<{ password: boolean; oidc: boolean; captcha?: { provider: 'hcaptcha' | 'turnstile'; siteKey: string } }>('/auth/methods'),
+  // Browser URL that starts single sign-on; the callback returns to /login#token=... or #error=...
+  oidcLoginUrl: () => `${api.defaults.baseURL ?? ''}/auth/oidc/login`,
   // Logout current session
   logout: () => api.post<{ message: string }>('/auth/logout'),
   // Get user profile
   getProfile: () => api.get<User>('/profile'),
+  // Update own profile; an empty email removes it
+  updateProfile: (data: { email: string }) => api.put<User>('/profile', data),
   // Get current user's sessions
   getSessions: () => api.get<{ sessions: UserSession[] }>('/profile/sessions'),
   // Revoke one session
@@ -430,7 +998,11 @@ export const authAPI = {
   // Revoke all other sessions
   revokeOtherSessions: () => api.delete<{ message: string; count: number }>('/profile/sessions/others'),
   // Update user password
-  updatePassword: (data: { current_password: string; new_password: string; confirm_password: string; logout_other_sessions?: boolean }) => api.put<UpdatePasswordResponse>('/profile/password', data)
+  updatePassword: (data: { currentPassword: string; newPassword: string; confirmPassword: string; logoutOtherSessions?: boolean }) => api.put<UpdatePasswordResponse>('/profile/password', data),
+  // Request a password reset token by username or email
+  requestPasswordReset: (data: { username?: string; email?: string }) => api.post<{ message: string }>('/auth/reset-request', data),
+  // Set a new password with a reset token
+  confirmPasswordReset: (data: { token: string; newPassword: string }) => api.post<{ message: string }>('/auth/reset-confirm', data)
 }
 
 // User management APIs
@@ -438,78 +1010,311 @@ export const userAPI = {
   // Get all users
   getAllUsers: () => api.get<User[]>('/users'),
   // Create one user as admin
-  createUser: (data: { username: string }) => api.post<CreateUserResponse>('/users', data),
+  createUser: (data: { username: string; password?: string; email?: string }) => api.post<CreateUserResponse>('/users', data),
   // Delete one user as admin
   deleteUser: (userId: string) => api.delete<DeleteUserResponse>(`/users/${userId}`),
   // Transfer admin role to another user
-  transferAdmin: (userId: string) => api.post<TransferAdminResponse>('/users/transfer-admin', { user_id: userId }),
+  transferAdmin: (userId: string) => api.post<TransferAdminResponse>('/users/transfer-admin', { userId }),
   // Reset one user's password as admin
-  resetPassword: (userId: string) => api.post<ResetUserPasswordResponse>(`/users/${userId}/reset-password`)
+  resetPassword: (userId: string) => api.post<ResetUserPasswordResponse>(`/users/${userId}/reset-password`),
+  // Issue a one-time password reset token as admin
+  issueResetToken: (userId: string) => api.post<{ message: string; user: User; token: string; expiresAt: string }>(`/users/${userId}/reset-token`),
+  // List one user's sessions as admin
+  getUserSessions: (userId: string) => api.get<{ sessions: UserSession[] }>(`/users/${userId}/sessions`),
+  // Sign out one of a user's sessions as admin
+  revokeUserSession: (userId: string, sessionId: string) => api.delete<{ message: string }>(`/users/${userId}/sessions/${sessionId}`)
 }
 
 // ZeroTier network related APIs
 export const networkAPI = {
   // Get all networks (from database, lightweight)
-  getAllNetworks: () => api.get<NetworkSummary[]>('/networks'),
+  getAllNetworks: (org?: string) => api.get<NetworkSummary[]>('/networks', { params: { org } }),
   // Get read-only shared networks for current user
   getSharedNetworks: () => api.get<SharedNetworkSummary[]>('/networks/shared'),
   // Get a single network (with full details from ZeroTier API)
   getNetwork: (networkId: string) => api.get<Network>(`/networks/${networkId}`),
   // Create a network
-  createNetwork: (data: { name: string; description?: string }) => api.post<Network>('/networks', data),
+  createNetwork: (data: { name: string; description?: string; dns?: DNSConfig; multicastLimit?: number; enableBroadcast?: boolean }, controller?: string) => api.post<Network>('/networks', data, { params: { controller } }),
   // Update a network (config only, goes to ZeroTier controller)
   updateNetwork: (networkId: string, data: NetworkUpdateRequest) => api.put<Network>(`/networks/${networkId}`, data),
   // Update network metadata (name and description, goes to database only for description, both for name)
   updateNetworkMetadata: (networkId: string, data: NetworkMetadataUpdateRequest) => api.put<Network>(`/networks/${networkId}/metadata`, data),
+  // Get the saved rules source and the controller's compiled rules
+  getNetworkRules: (networkId: string) => api.get<NetworkRules>(`/networks/${networkId}/rules`),
+  // Compile rules source and apply it to the network
+  updateNetworkRules: (networkId: string, source: string) => api.put<NetworkRules>(`/networks/${networkId}/rules`, { source }),
+  // Get the named tags of a network
+  getNetworkTags: (networkId: string) => api.get<NetworkTag[]>(`/networks/${networkId}/tags`),
+  // Name a tag ID, optionally with named values
+  createNetworkTag: (networkId: string, data: { id: number; name: string; values?: Record<string, number> }) => api.post<NetworkTag>(`/networks/${networkId}/tags`, data),
+  // Rename a tag and replace its named values
+  updateNetworkTag: (networkId: string, tagId: number, data: { name: string; values?: Record<string, number> }) => api.put<NetworkTag>(`/networks/${networkId}/tags/${tagId}`, data),
+  // Remove a tag definition
+  deleteNetworkTag: (networkId: string, tagId: number) => api.delete<{ message: string }>(`/networks/${networkId}/tags/${tagId}`),
+  // Get the named capabilities of a network
+  getNetworkCapabilities: (networkId: string) => api.get<NetworkCapability[]>(`/networks/${networkId}/capabilities`),
+  // Name a capability ID
+  createNetworkCapability: (networkId: string, data: { id: number; name: string }) => api.post<NetworkCapability>(`/networks/${networkId}/capabilities`, data),
+  // Remove a capability definition
+  deleteNetworkCapability: (networkId: string, capabilityId: number) => api.delete<{ message: string }>(`/networks/${networkId}/capabilities/${capabilityId}`),
+  // Get how much of each IP assignment pool is in use
+  getNetworkIPUsage: (networkId: string, next?: number) => api.get<NetworkIPUsage>(`/networks/${networkId}/ip-usage`, { params: { next } }),
+  // Get the automated policies of a network
+  getNetworkPolicies: (networkId: string) => api.get<NetworkPolicies>(`/networks/${networkId}/policies`),
+  // Replace the automated policies of an owned network
+  updateNetworkPolicies: (networkId: string, data: { inactivity: InactivityPolicy }) => api.put<NetworkPolicies>(`/networks/${networkId}/policies`, data),
+  // Report the members the inactivity policy would deauthorize now, optionally for another threshold
+  previewInactivity: (networkId: string, days?: number) =>
+    api.get<InactivityReport>(`/networks/${networkId}/policies/inactivity/dry-run`, { params: { days } }),
   // Delete a network
   deleteNetwork: (networkId: string) => api.delete<void>(`/networks/${networkId}`),
+  // Download a network backup document
+  backupNetwork: (networkId: string) => api.get<NetworkBackup>(`/networks/${networkId}/backup`),
+  // Create a new network from a backup document
+  restoreNetwork: (backup: NetworkBackup, controller?: string) => api.post<NetworkRestoreResult>('/networks/restore', backup, { params: { controller } }),
+  // Start creating several networks of the same shape
+  createNetworkBatch: (request: NetworkBatchRequest) => api.post<Operation>('/networks/batch', request),
+  // Get the progress of a long-running operation
+  getOperation: (operationId: string) => api.get<Operation>(`/operations/${operationId}`),
+  // List the newest long-running operations, of every user for administrators
+  listOperations: (limit?: number) => api.get<{ operations: Operation[] }>('/operations', { params: { limit } }),
+  // Cancel a pending or running operation
+  cancelOperation: (operationId: string) => api.delete<Operation>(`/operations/${operationId}`),
   // Get read-only viewers for an owned network
   getNetworkViewers: (networkId: string) => api.get<NetworkViewer[]>(`/networks/${networkId}/viewers`),
   // Get eligible users for read-only sharing
   getNetworkViewerCandidates: (networkId: string) => api.get<NetworkViewer[]>(`/networks/${networkId}/viewers/available`),
   // Grant read-only viewer access
-  addNetworkViewer: (networkId: string, userId: string) => api.post<{ message: string }>(`/networks/${networkId}/viewers`, { user_id: userId }),
+  addNetworkViewer: (networkId: string, userId: string) => api.post<{ message: string }>(`/networks/${networkId}/viewers`, { userId }),
   // Revoke read-only viewer access
   deleteNetworkViewer: (networkId: string, userId: string) => api.delete<{ message: string }>(`/networks/${networkId}/viewers/${userId}`),
   // Get importable networks (admin only)
-  getImportableNetworks: () => api.get<ImportableNetworksResponse>('/admin/networks/importable'),
+  getImportableNetworks: (controller?: string) => api.get<ImportableNetworksResponse>('/admin/networks/importable', { params: { controller } }),
   // Import specified networks (admin only)
-  importNetworks: (networkIds: string[], ownerId: string) => api.post<ImportNetworksResponse>('/admin/networks/import', {
-    network_ids: networkIds,
-    owner_id: ownerId
-  })
+  importNetworks: (networkIds: string[], ownerId: string, controller?: string) => api.post<ImportNetworksResponse>('/admin/networks/import', {
+    networkIds,
+    ownerId
+  }, { params: { controller } }),
+  // List the configured ZeroTier controllers
+  getControllers: () => api.get<{ controllers: ControllerInfo[] }>('/controllers'),
+  // List the peers a controller sees (admin only)
+  getControllerPeers: (controller?: string, role?: PeerRole) => api.get<ControllerPeers>('/controller/peers', { params: { controller, role } })
 }
 
 // Member related APIs
 export const memberAPI = {
   // Get network members
   getMembers: (networkId: string) => api.get<Member[]>(`/networks/${networkId}/members`),
+  // Download the member list as CSV or JSON
+  exportMembers: (networkId: string, params: { format?: 'csv' | 'json'; bom?: boolean; authorized?: boolean; online?: boolean; q?: string } = {}) =>
+    api.get<Blob>(`/networks/${networkId}/members/export`, { params, responseType: 'blob' }),
   // Update a member
-  updateMember: (networkId: string, memberId: string, data: { authorized?: boolean; name?: string; activeBridge?: boolean; noAutoAssignIps?: boolean; ipAssignments?: string[] }) => api.put<Member>(`/networks/${networkId}/members/${memberId}`, data),
+  updateMember: (networkId: string, memberId: string, data: { authorized?: boolean; name?: string; activeBridge?: boolean; noAutoAssignIps?: boolean; ipAssignments?: string[]; authorizedUntil?: string }) => api.put<Member>(`/networks/${networkId}/members/${memberId}`, data),
+  // Update the display name, notes and tags Tairitsu keeps for a member
+  updateMemberMetadata: (networkId: string, memberId: string, data: MemberMetadataUpdate) => api.patch<MemberMetadata>(`/networks/${networkId}/members/${memberId}/metadata`, data),
+  // Replace a member's tags, keyed by tag name; values are numbers or value names
+  setMemberTags: (networkId: string, memberId: string, tags: Record<string, number | string>) => api.put<Member>(`/networks/${networkId}/members/${memberId}/tags`, { tags }),
+  // Replace a member's capabilities, given by capability name
+  setMemberCapabilities: (networkId: string, memberId: string, capabilities: string[]) => api.put<Member>(`/networks/${networkId}/members/${memberId}/capabilities`, { capabilities }),
   // Delete a member
-  deleteMember: (networkId: string, memberId: string) => api.delete<void>(`/networks/${networkId}/members/${memberId}`)
+  deleteMember: (networkId: string, memberId: string) => api.delete<void>(`/networks/${networkId}/members/${memberId}`),
+  // Deauthorize every authorized member except the listed ones
+  lockdownNetwork: (networkId: string, except: string[] = []) => api.post<NetworkLockdownResult>(`/networks/${networkId}/lockdown`, { except }),
+  // Authorize again the members the active lockdown deauthorized
+  rollbackNetworkLockdown: (networkId: string) => api.post<NetworkLockdownResult>(`/networks/${networkId}/lockdown/rollback`),
+  // Open the live member event stream; EventSource cannot send headers, so the token is passed as a query parameter
+  openMemberEvents: (networkId: string) => {
+    const token = localStorage.getItem('token') || sessionStorage.getItem('token') || ''
+    return new EventSource(`/api/networks/${networkId}/events?access_token=${encodeURIComponent(token)}`)
+  },
+  // Ask the server to poll the member event stream of a network now
+  refreshMemberEvents: (networkId: string) => api.post<void>(`/networks/${networkId}/events/refresh`),
+  // List members waiting for approval in the networks the user owns
+  getPendingApprovals: () => api.get<PendingApproval[]>('/approvals'),
+  // Approve or deny a member waiting for approval
+  decideApproval: (id: number, action: 'approve' | 'deny') => api.post<PendingApproval>(`/approvals/${id}`, { action })
 }
 
 // System related APIs
 export const systemAPI = {
   // Get system status
-  getStatus: () => api.get<RuntimeStatus>('/status'),
+  getStatus: (fresh = false) => api.get<RuntimeStatus>('/status', { params: { fresh } }),
   // Get system setup status (used to check if it's first run)
   getSetupStatus: () => api.get<SetupStatus>('/system/status'),
+  // Get setup wizard progress
+  getSetupState: () => api.get<SetupState>('/system/setup/state'),
   // Configure database
   configureDatabase: (config: DatabaseSetupConfig) => api.post<DatabaseSetupResponse>('/system/database', config),
+  // Test a controller URL and token without saving them
+  testZtConnection: (config: ZeroTierSetupConfig) => api.post<ZeroTierConnectionCheck>('/system/zerotier/test', config),
   // Save ZeroTier configuration
   saveZtConfig: (config: ZeroTierSetupConfig) => api.post<ZeroTierSetupResponse>('/system/zerotier/config', config),
   // Set system initialization status
   setInitialized: (initialized: boolean) => api.post<SetInitializedResponse>('/system/initialized', { initialized }),
-  // Initialize admin account creation step
-  initializeAdminCreation: () => api.post<InitializeAdminCreationResponse>('/system/admin/init'),
+  // Initialize admin account creation step; this clears the configured database
+  initializeAdminCreation: () => api.post<InitializeAdminCreationResponse>('/system/admin/init', { confirmReset: true }),
   // Get runtime settings (admin only)
   getRuntimeSettings: () => api.get<RuntimeSettings>('/system/settings'),
   // Update runtime settings (admin only)
   updateRuntimeSettings: (settings: RuntimeSettings) => api.put<{ message: string; settings: RuntimeSettings }>('/system/settings', settings),
   // Get system statistics (CPU, memory usage)
-  getSystemStats: () => api.get<SystemStats>('/system/stats')
+  getSystemStats: () => api.get<SystemStats>('/system/stats'),
+  // Get sampled CPU and memory usage, e.g. window '1h' averaged into 120 points
+  getSystemStatsHistory: (window = '1h', points = 120) => api.get<SystemStatsHistory>('/system/stats/history', { params: { window, points } }),
+  // Get background job schedules (admin only)
+  getJobs: () => api.get<JobsResponse>('/admin/jobs'),
+  // Start a database compaction in the background (admin only)
+  compactDatabase: (force = false) => api.post<{ message: string }>('/admin/maintenance/compact', null, { params: { force } }),
+  // Get the current log level (admin only)
+  getLogLevel: () => api.get<{ level: string }>('/system/log-level'),
+  // Change the log level until the next restart (admin only)
+  updateLogLevel: (level: 'debug' | 'info' | 'warn' | 'error') => api.put<{ level: string }>('/system/log-level', { level }),
+  // Replace the key that encrypts stored credentials (admin only)
+  rotateEncryptionKey: () => api.post<{ message: string }>('/admin/security/encryption-key/rotate'),
+  // Replace the JWT signing secret; existing sessions stay signed in (admin only)
+  rotateJwtSecret: () => api.post<{ message: string; previousSecretValidUntil: string }>('/system/rotate-jwt-secret'),
+  // Export the encrypted app state archive (admin only)
+  exportAppState: (password: string) => api.get<AppStateArchive>('/admin/export/app-state', {
+    headers: { 'X-Archive-Password': password }
+  }),
+  // Import an app state archive; onConflict is 'abort' or 'skip' (admin only)
+  importAppState: (archive: AppStateArchive, password: string, onConflict: 'abort' | 'skip' = 'abort') =>
+    api.post<{ message: string; report: AppStateImportReport }>('/admin/import/app-state', archive, {
+      headers: { 'X-Archive-Password': password },
+      params: { onConflict }
+    }),
+  // Write a controller-wide backup now (admin only)
+  createBackup: () => api.post<{ message: string; backup: SystemBackupInfo }>('/system/backup'),
+  // List system backups, newest first (admin only)
+  listBackups: () => api.get<{ backups: SystemBackupInfo[]; job: SystemBackupStatus }>('/system/backups'),
+  // Restore a system backup; the name is repeated as confirmation (admin only)
+  restoreBackup: (name: string) =>
+    api.post<{ message: string; report: SystemRestoreReport }>(`/system/backups/${encodeURIComponent(name)}/restore`, { confirm: name })
+}
+
+export type WebhookEvent = 'network.created' | 'network.deleted' | 'member.authorized' | 'member.deauthorized' | 'user.created'
+
+export interface AuditEntry {
+  id: number;
+  actorId: string;
+  action: string;
+  target: string;
+  details: string;
+  createdAt: string;
+  prevHash: string;
+  hash: string;
+}
+
+export interface DashboardSummary {
+  networks: {
+    count: number;
+    members: number;
+    onlineMembers: number;
+    unauthorizedMembers: number;
+    unreachableNetworks: number;
+  } | null;
+  // Only included for admins
+  users: { count: number } | null;
+  controller: {
+    online: boolean;
+    version?: string;
+    address?: string;
+    stale: boolean;
+    fetchedAt?: string;
+    error?: string;
+  } | null;
+  recentAudit: AuditEntry[] | null;
+  // Sections that failed or timed out and are null above
+  unavailable: string[];
+}
+
+export interface Webhook {
+  id: string;
+  name: string;
+  url: string;
+  events: WebhookEvent[];
+  enabled: boolean;
+  createdBy: string;
+  createdAt: string;
+  updatedAt: string;
+}
+
+export interface WebhookInput {
+  name?: string;
+  url: string;
+  secret?: string;
+  events?: WebhookEvent[];
+  enabled?: boolean;
+}
+
+export interface WebhookDelivery {
+  id: number;
+  webhookId: string;
+  event: WebhookEvent | 'ping';
+  payload: string;
+  attempts: number;
+  statusCode?: number;
+  success: boolean;
+  error?: string;
+  createdAt: string;
+  completedAt?: string;
+}
+
+// Dashboard related APIs
+export const dashboardAPI = {
+  // Get the overview of the networks the user can access; admins see everything
+  getDashboard: () => api.get<DashboardSummary>('/dashboard')
+}
+
+// Device related APIs; devices are nodes the user claimed as their own
+export const deviceAPI = {
+  // List the claimed devices and the networks verified ones are members of
+  getDevices: () => api.get<Device[]>('/devices'),
+  // Claim a node; naming its member after the returned claim code verifies the claim
+  claimDevice: (nodeAddress: string) => api.post<Device>('/devices/claim', { nodeAddress }),
+  // Drop a claim
+  deleteDevice: (address: string) => api.delete(`/devices/${address}`),
+  // Set the display name of a verified device on all of its networks
+  renameDevice: (address: string, displayName: string) => api.put<Device>(`/devices/${address}/name`, { displayName }),
+  // Ask the network owner to authorize a verified device
+  requestAuthorization: (address: string, networkId: string) =>
+    api.post<PendingApproval>(`/devices/${address}/networks/${networkId}/authorization-request`),
+  // List the claims waiting for an administrator (admin only)
+  getPendingClaims: () => api.get<DeviceClaim[]>('/admin/devices/claims'),
+  // Verify or reject a claim (admin only)
+  decideClaim: (id: number, action: 'approve' | 'deny') => api.post<DeviceClaim>(`/admin/devices/claims/${id}`, { action })
+}
+
+// Organization related APIs; organizations group users and networks
+export const organizationAPI = {
+  // List every organization for admins, the user's own organizations otherwise
+  getOrganizations: () => api.get<Organization[]>('/organizations'),
+  // Create, change or delete an organization (admin only)
+  createOrganization: (data: { name: string; description?: string }) => api.post<Organization>('/organizations', data),
+  updateOrganization: (id: string, data: { name: string; description?: string }) => api.put<Organization>(`/organizations/${id}`, data),
+  deleteOrganization: (id: string) => api.delete(`/organizations/${id}`),
+  // Move a network to an organization (admin only)
+  assignNetwork: (id: string, networkId: string) => api.put<NetworkSummary>(`/organizations/${id}/networks/${networkId}`),
+  // Members; changing them requires org_admin or an admin
+  getMembers: (id: string) => api.get<OrganizationMember[]>(`/organizations/${id}/members`),
+  setMember: (id: string, userId: string, role: OrganizationRole) => api.put<OrganizationMember>(`/organizations/${id}/members/${userId}`, { role }),
+  removeMember: (id: string, userId: string) => api.delete(`/organizations/${id}/members/${userId}`)
+}
+
+// Webhook related APIs (admin only)
+export const webhookAPI = {
+  // List webhooks and the events they can subscribe to
+  getWebhooks: () => api.get<{ webhooks: Webhook[]; events: WebhookEvent[] }>('/webhooks'),
+  // Create a webhook; the secret is only returned here
+  createWebhook: (data: WebhookInput) => api.post<{ secret: string; webhook: Webhook }>('/webhooks', data),
+  // Replace the settings of a webhook; an empty secret keeps the current one
+  updateWebhook: (id: string, data: WebhookInput) => api.put<Webhook>(`/webhooks/${id}`, data),
+  // Delete a webhook and its delivery log
+  deleteWebhook: (id: string) => api.delete<{ message: string }>(`/webhooks/${id}`),
+  // Get the latest deliveries of a webhook
+  getDeliveries: (id: string, limit?: number) => api.get<{ deliveries: WebhookDelivery[] }>(`/webhooks/${id}/deliveries`, { params: { limit } }),
+  // Send a ping event right away
+  pingWebhook: (id: string) => api.post<WebhookDelivery>(`/webhooks/${id}/ping`)
 }
 
 // Planet related APIs (admin only)
@@ -518,6 +1323,8 @@ export const planetAPI = {
   getIdentity: (ztPath?: string) => api.get<IdentityInfo>('/admin/planet/identity', {
     params: { path: ztPath || '/var/lib/zerotier-one' }
   }),
+  // Check that an identity's public key derives its address
+  validateIdentity: (identityPublic: string) => api.post<ValidateIdentityResponse>('/admin/planet/validate-identity', { identityPublic }),
   // Inspect signing key files from a directory
   getSigningKeysInfo: (ztPath?: string) => api.get<SigningKeysInfoResponse>('/admin/planet/signing-keys', {
     params: { path: ztPath || '/var/lib/zerotier-one' }
@@ -527,7 +1334,26 @@ export const planetAPI = {
     params: { path: ztPath || '/var/lib/zerotier-one' }
   }),
   // Generate custom planet file
-  generatePlanet: (data: GeneratePlanetRequest) => api.post<GeneratePlanetResponse>('/admin/planet/generate', data)
+  generatePlanet: (data: GeneratePlanetRequest) => api.post<GeneratePlanetResponse>('/admin/planet/generate', data),
+  // List earlier generated planets
+  getPlanetHistory: () => api.get<{ planets: PlanetGeneration[] }>('/admin/planet/history'),
+  // Download an earlier generated planet, as the binary file or as a C header
+  downloadPlanet: (id: number, format: 'binary' | 'cheader' = 'binary') => api.get<Blob>(`/admin/planet/${id}/download`, {
+    params: { format },
+    responseType: 'blob'
+  }),
+  // Generate a moon file named after its first root
+  generateMoon: (data: GenerateMoonRequest) => api.post<GenerateMoonResponse>('/admin/planet/moon', data),
+  // Decode the planet file in the ZeroTier directory
+  inspectPlanet: (ztPath?: string) => api.get<InspectWorldResponse>('/admin/planet/inspect', {
+    params: ztPath ? { path: ztPath } : undefined
+  }),
+  // Decode an uploaded planet or moon file
+  inspectWorldFile: (file: File) => {
+    const form = new FormData()
+    form.append('file', file)
+    return api.post<InspectWorldResponse>('/admin/planet/inspect', form)
+  }
 }
 
 export default api
@@ -7,7 +7,7 @@ test('displays the original setup detail returned by the server', () => {
     isAxiosError: true,
     response: {
       data: {
-        error_code: 'setup.zerotier_config_save_failed',
+        errorCode: 'setup.zerotier_config_save_failed',
         detail,
       },
     },
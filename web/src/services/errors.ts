@@ -1,10 +1,30 @@
 import axios, { type AxiosError } from 'axios';
 import { getDetailSeparator, translateMessageCode } from '../i18n';
 
+interface ErrorFieldData {
+  field?: string;
+  message?: string;
+}
+
 interface ErrorResponseData {
   message?: string;
-  error_code?: string;
+  errorCode?: string;
   detail?: string;
+  fields?: ErrorFieldData[];
+}
+
+// fieldDetail lists field-level problems when the response has no detail of its own
+function fieldDetail(data: ErrorResponseData | undefined): string | undefined {
+  if (typeof data?.detail === 'string' && data.detail.trim() !== '') {
+    return data.detail;
+  }
+  if (!Array.isArray(data?.fields) || data.fields.length === 0) {
+    return undefined;
+  }
+  return data.fields
+    .filter((field) => typeof field.message === 'string' && field.message !== '')
+    .map((field) => (field.field ? `${field.field}: ${field.message}` : field.message))
+    .join('; ');
 }
 
 export function toError(error: unknown): Error {
@@ -23,8 +43,8 @@ export function getErrorMessage(error: unknown, fallback: string): string {
   const sep = getDetailSeparator();
 
   if (isAxiosError(error)) {
-    const responseCode = error.response?.data?.error_code;
-    const responseDetail = error.response?.data?.detail;
+    const responseCode = error.response?.data?.errorCode;
+    const responseDetail = fieldDetail(error.response?.data);
 
     if (typeof responseCode === 'string' && responseCode.trim() !== '') {
       const translatedMessage = translateMessageCode(responseCode);
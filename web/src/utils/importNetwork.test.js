@@ -4,9 +4,9 @@ import { buildImportResultAlertPresentation, buildImportResultFeedback, groupImp
 describe('importNetwork utils', () => {
   test('groups candidates by status', () => {
     const grouped = groupImportCandidates([
-      { network_id: '1', status: 'available', can_import: true, reason_code: 'unregistered', reason_message: 'ok' },
-      { network_id: '2', status: 'managed', can_import: false, reason_code: 'already_managed', reason_message: 'managed' },
-      { network_id: '3', status: 'blocked', can_import: false, reason_code: 'controller_read_failed', reason_message: 'blocked' },
+      { networkId: '1', status: 'available', canImport: true, reasonCode: 'unregistered', reasonMessage: 'ok' },
+      { networkId: '2', status: 'managed', canImport: false, reasonCode: 'already_managed', reasonMessage: 'managed' },
+      { networkId: '3', status: 'blocked', canImport: false, reasonCode: 'controller_read_failed', reasonMessage: 'blocked' },
     ])
 
     expect(grouped.available).toHaveLength(1)
@@ -16,9 +16,9 @@ describe('importNetwork utils', () => {
 
   test('builds success feedback for full success', () => {
     const feedback = buildImportResultFeedback({
-      target_owner: { id: 'owner-1', username: 'alice' },
+      targetOwner: { id: 'owner-1', username: 'alice' },
       summary: { requested: 2, imported: 2, failed: 0, skipped: 0 },
-      imported: [{ network_id: '1' }, { network_id: '2' }],
+      imported: [{ networkId: '1' }, { networkId: '2' }],
       failed: [],
       skipped: [],
     })
@@ -30,10 +30,10 @@ describe('importNetwork utils', () => {
 
   test('builds error feedback for all-failed result', () => {
     const feedback = buildImportResultFeedback({
-      target_owner: { id: 'owner-1', username: 'alice' },
+      targetOwner: { id: 'owner-1', username: 'alice' },
       summary: { requested: 2, imported: 0, failed: 2, skipped: 0 },
       imported: [],
-      failed: [{ network_id: '1' }, { network_id: '2' }],
+      failed: [{ networkId: '1' }, { networkId: '2' }],
       skipped: [],
     })
 
@@ -43,10 +43,10 @@ describe('importNetwork utils', () => {
 
   test('builds alert presentation for all-failed result without success icon', () => {
     const presentation = buildImportResultAlertPresentation({
-      target_owner: { id: 'owner-1', username: 'alice' },
+      targetOwner: { id: 'owner-1', username: 'alice' },
       summary: { requested: 2, imported: 0, failed: 2, skipped: 0 },
       imported: [],
-      failed: [{ network_id: '1' }, { network_id: '2' }],
+      failed: [{ networkId: '1' }, { networkId: '2' }],
       skipped: [],
     })
 
@@ -21,7 +21,7 @@ export function buildImportResultFeedback(result: ImportNetworksResponse): Impor
   if (result.summary.imported > 0 && result.summary.failed === 0 && result.summary.skipped === 0) {
     return {
       severity: 'success' as const,
-      text: `已将 ${result.summary.imported} 个网络分配给 ${result.target_owner.username}，控制器接管列表已刷新。`,
+      text: `已将 ${result.summary.imported} 个网络分配给 ${result.targetOwner.username}，控制器接管列表已刷新。`,
     }
   }
 
@@ -0,0 +1,42 @@
+import { describe, expect, test } from 'bun:test'
+import { applyMemberEvent } from './memberEvents'
+
+describe('memberEvents utils', () => {
+  const members = [
+    { id: 'a1a1a1a1a1', authorized: true, online: true },
+    { id: 'b2b2b2b2b2', authorized: false, online: false },
+  ]
+
+  test('replaces a changed member in place', () => {
+    const next = applyMemberEvent(members, {
+      type: 'member.offline',
+      networkId: '8056c2e21c000001',
+      memberId: 'a1a1a1a1a1',
+      time: '2026-01-01T00:00:00Z',
+      member: { id: 'a1a1a1a1a1', authorized: true, online: false },
+    })
+
+    expect(next).toHaveLength(2)
+    expect(next[0].online).toBe(false)
+    expect(next[1]).toBe(members[1])
+  })
+
+  test('appends joined members and removes members that left', () => {
+    const joined = applyMemberEvent(members, {
+      type: 'member.joined',
+      networkId: '8056c2e21c000001',
+      memberId: 'c3c3c3c3c3',
+      time: '2026-01-01T00:00:00Z',
+      member: { id: 'c3c3c3c3c3' },
+    })
+    expect(joined.map((member) => member.id)).toEqual(['a1a1a1a1a1', 'b2b2b2b2b2', 'c3c3c3c3c3'])
+
+    const left = applyMemberEvent(joined, {
+      type: 'member.left',
+      networkId: '8056c2e21c000001',
+      memberId: 'b2b2b2b2b2',
+      time: '2026-01-01T00:00:00Z',
+    })
+    expect(left.map((member) => member.id)).toEqual(['a1a1a1a1a1', 'c3c3c3c3c3'])
+  })
+})
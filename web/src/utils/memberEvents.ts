@@ -0,0 +1,22 @@
+import type { Member, MemberEvent, MemberEventType } from '../services/api'
+
+export const memberEventTypes: MemberEventType[] = [
+  'member.joined',
+  'member.left',
+  'member.authorized',
+  'member.deauthorized',
+  'member.online',
+  'member.offline',
+]
+
+// applyMemberEvent returns the member list after a live member event
+export function applyMemberEvent(members: Member[], event: MemberEvent): Member[] {
+  const updated = event.member
+  if (event.type === 'member.left' || !updated) {
+    return members.filter((member) => member.id !== event.memberId)
+  }
+  if (!members.some((member) => member.id === event.memberId)) {
+    return [...members, updated]
+  }
+  return members.map((member) => (member.id === event.memberId ? updated : member))
+}
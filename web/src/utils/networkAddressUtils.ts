@@ -168,5 +168,5 @@ export function getIPv6Pools(pools: IpAssignmentPool[]): IpAssignmentPool[] {
 }
 
 export function getNetworkDescription(network: Network): string {
-  return network.db_description || network.description || ''
+  return network.dbDescription || network.description || ''
 }
@@ -22,10 +22,12 @@ describe('planet utils', () => {
     expect(validatePlanetEndpoints(['1.1.1.1'])).toBe('1.1.1.1：格式应为 IP/Port')
     expect(validatePlanetEndpoints(['1.1.1.1/70000'])).toBe('1.1.1.1/70000：端口号必须在 1-65535 之间')
     expect(validatePlanetEndpoints(['300.1.1.1/9993'])).toBe('300.1.1.1/9993：IP 地址格式无效')
+    expect(validatePlanetEndpoints(['root1.example.com/9993', 'root1.example.com.:9993/9993'])).toBe('root1.example.com.:9993/9993：IP 地址格式无效')
   })
 
   test('accepts valid IPv4 and IPv6 endpoints and uses stable download name fallback', () => {
     expect(validatePlanetEndpoints(['203.0.113.1/9993', '2001:db8::1/9993'])).toBeNull()
+    expect(validatePlanetEndpointValue('root1.example.com/9993')).toBeNull()
     expect(getPlanetDownloadName()).toBe('planet')
     expect(getPlanetDownloadName('planet')).toBe('planet')
   })
@@ -32,6 +32,16 @@ function isValidIPv6(value: string): boolean {
   return value.includes(':') && /^[0-9a-fA-F:]+$/.test(value)
 }
 
+// Hostname endpoints are resolved by the server when the planet is generated; a name ending
+// in a numeric label is a mistyped IPv4 address instead
+function isValidHostname(value: string): boolean {
+  const labels = value.replace(/\.$/, '').split('.')
+  if (labels.length < 2 || /^\d+$/.test(labels[labels.length - 1])) {
+    return false
+  }
+  return labels.every((label) => /^[a-zA-Z0-9]([a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?$/.test(label))
+}
+
 export function parsePlanetIdentityPublic(value: string): PlanetIdentitySummary | null {
   const trimmed = value.trim()
   const parts = trimmed.split(':')
@@ -69,7 +79,7 @@ export function validatePlanetEndpointValue(value: string): string | null {
     return '端口号必须在 1-65535 之间'
   }
 
-  if (!isValidIPv4(host) && !isValidIPv6(host)) {
+  if (!isValidIPv4(host) && !isValidIPv6(host) && !isValidHostname(host)) {
     return 'IP 地址格式无效'
   }
 
@@ -31,7 +31,7 @@ describe('setupWizard', () => {
       zerotierConfigured: false,
       adminCreationPrepared: true,
       allowPublicRegistration: true,
-    })).toBe(1)
+    })).toBe(2)
 
     expect(getInitialSetupWizardStep({
       initialized: false,
@@ -41,7 +41,7 @@ describe('setupWizard', () => {
       zerotierConfigured: true,
       adminCreationPrepared: true,
       allowPublicRegistration: true,
-    })).toBe(2)
+    })).toBe(1)
 
     expect(getInitialSetupWizardStep({
       initialized: false,
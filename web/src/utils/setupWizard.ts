@@ -4,10 +4,10 @@ export function getInitialSetupWizardStep(status: SetupStatus): number {
   if (!status.zerotierConfigured && !status.databaseConfigured && !status.hasAdmin) {
     return 0
   }
-  if (!status.zerotierConfigured) {
+  if (!status.databaseConfigured) {
     return 1
   }
-  if (!status.databaseConfigured) {
+  if (!status.zerotierConfigured) {
     return 2
   }
   if (!status.hasAdmin) {